@@ -0,0 +1,51 @@
+// matter-all-clusters-device is a Matter device example exposing every
+// implemented server cluster, for certification-style and interop testing.
+//
+// The clusters are spread over endpoints by device type; see package
+// examples/allclusters. A test harness simulates events, such as a smoke
+// alarm or a jammed lock, with General Diagnostics TestEventTrigger and the
+// enable key.
+//
+// Usage:
+//
+//	matter-all-clusters-device [options]
+//
+// Options:
+//
+//	-port          UDP/TCP port (default: 5540)
+//	-discriminator 12-bit discriminator (default: 3840)
+//	-passcode      Setup passcode (default: 20202021)
+//	-storage       Path for persistent storage (default: in-memory)
+//	-name          Device name (default: "Matter All Clusters")
+//	-vendor        Vendor ID (default: 0xFFF1)
+//	-product       Product ID (default: 0x8001)
+//	-enable-key    TestEventTrigger enable key, 32 hex digits
+//	               (default: 00112233445566778899aabbccddeeff)
+//
+// Example:
+//
+//	matter-all-clusters-device -discriminator 1234 -enable-key 00112233445566778899aabbccddeeff
+package main
+
+import (
+	"log"
+
+	"github.com/backkem/matter/examples/allclusters"
+	"github.com/backkem/matter/examples/common"
+)
+
+func main() {
+	// Parse command-line flags
+	opts := common.ParseFlags()
+
+	// Create the all-clusters device
+	device, err := allclusters.NewDevice(opts)
+	if err != nil {
+		log.Fatalf("Failed to create all-clusters device: %v", err)
+	}
+
+	// Run the device (blocks until interrupted)
+	if err := common.RunDevice(device.Node); err != nil {
+		log.Fatalf("Device error: %v", err)
+	}
+}
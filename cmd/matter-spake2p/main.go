@@ -0,0 +1,111 @@
+// matter-spake2p generates SPAKE2+ verifiers for provisioning devices.
+//
+// A device provisioned with a verifier, its salt and its iteration count
+// acts as PASE responder without ever holding its passcode. For each
+// passcode, matter-spake2p prints a CSV row in the columns of the SDK's
+// spake2p tool, followed by the verifier record: the three values in one
+// blob, as read by pase.ParseVerifierRecord.
+//
+// Usage:
+//
+//	matter-spake2p [options] <passcode>...
+//
+// Options:
+//
+//	-count       Generate verifiers for this many random passcodes
+//	-iterations  PBKDF2 iteration count, 1000-100000 (default: 1000)
+//	-salt        Base64 PBKDF2 salt, 16-32 bytes (default: 32 random bytes per passcode)
+//
+// Example:
+//
+//	matter-spake2p 20202021
+//	matter-spake2p -count 100 -iterations 15000 > verifiers.csv
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/backkem/matter/pkg/commissioning/payload"
+	"github.com/backkem/matter/pkg/securechannel/pase"
+)
+
+func main() {
+	count := flag.Int("count", 0, "Generate verifiers for this many random passcodes")
+	iterations := flag.Uint("iterations", pase.PBKDFMinIterations, "PBKDF2 iteration count")
+	saltFlag := flag.String("salt", "", "Base64 PBKDF2 salt (empty = 32 random bytes per passcode)")
+	flag.Usage = usage
+	flag.Parse()
+
+	var salt []byte
+	if *saltFlag != "" {
+		var err error
+		if salt, err = base64.StdEncoding.DecodeString(*saltFlag); err != nil {
+			fatal(fmt.Errorf("invalid salt: %v", err))
+		}
+	}
+
+	var passcodes []uint32
+	for _, arg := range flag.Args() {
+		v, err := strconv.ParseUint(arg, 10, 32)
+		if err != nil {
+			fatal(fmt.Errorf("invalid passcode %q", arg))
+		}
+		passcodes = append(passcodes, uint32(v))
+	}
+	for i := 0; i < *count; i++ {
+		passcode, err := payload.GeneratePasscode()
+		if err != nil {
+			fatal(err)
+		}
+		passcodes = append(passcodes, passcode)
+	}
+	if len(passcodes) == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	w := csv.NewWriter(os.Stdout)
+	w.Write([]string{"Index", "PIN Code", "Iteration Count", "Salt", "Verifier", "Record"})
+	for i, passcode := range passcodes {
+		s := salt
+		if s == nil {
+			s = make([]byte, pase.PBKDFMaxSaltLength)
+			if _, err := rand.Read(s); err != nil {
+				fatal(err)
+			}
+		}
+		record, err := pase.NewVerifierRecord(passcode, s, uint32(*iterations))
+		if err != nil {
+			fatal(fmt.Errorf("passcode %d: %w", passcode, err))
+		}
+		w.Write([]string{
+			strconv.Itoa(i),
+			fmt.Sprintf("%08d", passcode),
+			strconv.FormatUint(uint64(record.Iterations), 10),
+			base64.StdEncoding.EncodeToString(record.Salt),
+			record.Verifier.String(),
+			record.String(),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		fatal(err)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [options] <passcode>...\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "\nOptions:\n")
+	flag.PrintDefaults()
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	os.Exit(1)
+}
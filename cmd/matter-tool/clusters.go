@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/backkem/matter/pkg/clusters/onoff"
+	"github.com/backkem/matter/pkg/im"
+	imsg "github.com/backkem/matter/pkg/im/message"
+)
+
+var (
+	errWriteUnsupported     = errors.New("attribute writes are not supported by the IM client yet")
+	errSubscribeUnsupported = errors.New("subscriptions are not supported by the IM client yet")
+)
+
+// Wildcard IDs, as chip-tool takes them.
+const (
+	wildcardEndpoint = 0xFFFF
+	wildcardID       = 0xFFFFFFFF
+)
+
+func (t *tool) onOffOn(ctx context.Context, args []string) error {
+	return t.invokeEach(ctx, args[0], args[1], uint32(onoff.ClusterID), uint32(onoff.CmdOn), nil)
+}
+
+func (t *tool) onOffOff(ctx context.Context, args []string) error {
+	return t.invokeEach(ctx, args[0], args[1], uint32(onoff.ClusterID), uint32(onoff.CmdOff), nil)
+}
+
+func (t *tool) onOffToggle(ctx context.Context, args []string) error {
+	return t.invokeEach(ctx, args[0], args[1], uint32(onoff.ClusterID), uint32(onoff.CmdToggle), nil)
+}
+
+func (t *tool) onOffRead(ctx context.Context, args []string) error {
+	if args[0] != "on-off" {
+		return fmt.Errorf("unknown attribute %q", args[0])
+	}
+	return t.read(ctx, args[1], args[2], fmt.Sprint(uint32(onoff.ClusterID)), fmt.Sprint(uint32(onoff.AttrOnOff)))
+}
+
+// readByID reads attributes by ID. Each list argument may name several
+// IDs; every combination is read in one request.
+func (t *tool) readByID(ctx context.Context, args []string) error {
+	return t.read(ctx, args[2], args[3], args[0], args[1])
+}
+
+// commandByID invokes a command by ID with a JSON payload.
+func (t *tool) commandByID(ctx context.Context, args []string) error {
+	clusterID, err := parseUint(args[0], 32)
+	if err != nil {
+		return err
+	}
+	commandID, err := parseUint(args[1], 32)
+	if err != nil {
+		return err
+	}
+	fields, err := encodeJSON(args[2])
+	if err != nil {
+		return fmt.Errorf("payload: %w", err)
+	}
+	return t.invokeEach(ctx, args[3], args[4], uint32(clusterID), uint32(commandID), fields)
+}
+
+func (t *tool) writeByID(ctx context.Context, args []string) error {
+	return errWriteUnsupported
+}
+
+func (t *tool) subscribeByID(ctx context.Context, args []string) error {
+	return errSubscribeUnsupported
+}
+
+// invokeEach invokes a command on each of a node's endpoints and prints
+// the results.
+func (t *tool) invokeEach(ctx context.Context, node, endpoints string, clusterID, commandID uint32, fields []byte) error {
+	nodeID, err := parseUint(node, 64)
+	if err != nil {
+		return err
+	}
+	endpointIDs, err := parseList(endpoints, 16)
+	if err != nil {
+		return err
+	}
+	p, err := t.connect(ctx, nodeID)
+	if err != nil {
+		return err
+	}
+
+	for _, endpointID := range endpointIDs {
+		result, err := t.ctrl.SendCommand(ctx, p.sess, p.addr, uint16(endpointID), clusterID, commandID, fields)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Endpoint: %d Cluster: 0x%04X Command: 0x%04X\n", endpointID, clusterID, commandID)
+		if result.HasStatus {
+			fmt.Printf("  Status: %s", result.Status)
+			if result.ClusterStatus != nil {
+				fmt.Printf(" (cluster status 0x%02X)", *result.ClusterStatus)
+			}
+			fmt.Println()
+			continue
+		}
+		if len(result.ResponseData) == 0 {
+			fmt.Println("  Response: no fields")
+			continue
+		}
+		fmt.Println("  Response:")
+		if err := printTLV(result.ResponseData, "    "); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// read reads the attributes named by the ID lists from a node and prints
+// each report.
+func (t *tool) read(ctx context.Context, node, endpoints, clusters, attributes string) error {
+	nodeID, err := parseUint(node, 64)
+	if err != nil {
+		return err
+	}
+	endpointIDs, err := parseList(endpoints, 16)
+	if err != nil {
+		return err
+	}
+	clusterIDs, err := parseList(clusters, 32)
+	if err != nil {
+		return err
+	}
+	attributeIDs, err := parseList(attributes, 32)
+	if err != nil {
+		return err
+	}
+
+	req := &imsg.ReadRequestMessage{FabricFiltered: true}
+	for _, e := range endpointIDs {
+		for _, c := range clusterIDs {
+			for _, a := range attributeIDs {
+				var path imsg.AttributePathIB
+				if e != wildcardEndpoint {
+					id := imsg.EndpointID(e)
+					path.Endpoint = &id
+				}
+				if c != wildcardID {
+					id := imsg.ClusterID(c)
+					path.Cluster = &id
+				}
+				if a != wildcardID {
+					id := imsg.AttributeID(a)
+					path.Attribute = &id
+				}
+				req.AttributeRequests = append(req.AttributeRequests, path)
+			}
+		}
+	}
+
+	p, err := t.connect(ctx, nodeID)
+	if err != nil {
+		return err
+	}
+	client := im.NewClient(im.ClientConfig{
+		ExchangeManager: t.ctrl.Node().ExchangeManager(),
+		LoggerFactory:   t.ctrl.Node().LoggerFactory(),
+	})
+	report, err := client.Read(ctx, p.sess, p.addr, req)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range report.AttributeReports {
+		switch {
+		case r.AttributeData != nil:
+			printPath(r.AttributeData.Path)
+			fmt.Printf("  DataVersion: 0x%08X\n", uint32(r.AttributeData.DataVersion))
+			if err := printTLV(r.AttributeData.Data, "  "); err != nil {
+				return err
+			}
+		case r.AttributeStatus != nil:
+			printPath(r.AttributeStatus.Path)
+			fmt.Printf("  Status: %s\n", r.AttributeStatus.Status.Status)
+		}
+	}
+	return nil
+}
+
+// printPath prints a concrete attribute path of a report.
+func printPath(path imsg.AttributePathIB) {
+	var endpoint, cluster, attribute uint32
+	if path.Endpoint != nil {
+		endpoint = uint32(*path.Endpoint)
+	}
+	if path.Cluster != nil {
+		cluster = uint32(*path.Cluster)
+	}
+	if path.Attribute != nil {
+		attribute = uint32(*path.Attribute)
+	}
+	fmt.Printf("Endpoint: %d Cluster: 0x%04X Attribute: 0x%04X", endpoint, cluster, attribute)
+	if path.ListIndex != nil {
+		fmt.Print(" (list item)")
+	}
+	fmt.Println()
+}
@@ -0,0 +1,132 @@
+// matter-tool is a command-line Matter controller.
+//
+// Its commands follow chip-tool's: a cluster, a command and positional
+// arguments, with the target node ID and endpoint last. It serves both as a
+// debugging tool and as a worked example of the examples/controller API.
+//
+// Usage:
+//
+//	matter-tool [options] <cluster> <command> [arguments]
+//
+// Commands:
+//
+//	pairing code <node-id> <payload>                                Pair using a QR or manual code
+//	pairing onnetwork <node-id> <passcode>                          Pair with the first commissionable device
+//	pairing onnetwork-long <node-id> <passcode> <discriminator>     Pair with a device by discriminator
+//	pairing ble-wifi <node-id> <ssid> <password> <passcode> <discriminator>
+//	pairing ble-thread <node-id> <dataset> <passcode> <discriminator>
+//	onoff on|off|toggle <node-id> <endpoint-ids>                    Invoke an OnOff command
+//	onoff read on-off <node-id> <endpoint-ids>                      Read the OnOff attribute
+//	any read-by-id <cluster-ids> <attribute-ids> <node-id> <endpoint-ids>
+//	any command-by-id <cluster-id> <command-id> <payload> <node-id> <endpoint-id>
+//	any write-by-id <cluster-ids> <attribute-ids> <value> <node-id> <endpoint-ids>
+//	any subscribe-by-id <cluster-ids> <attribute-ids> <min-interval> <max-interval> <node-id> <endpoint-ids>
+//	interactive start                                               Read commands from stdin
+//
+// IDs may be decimal or 0x-prefixed hex; lists are comma separated and
+// 0xFFFF / 0xFFFFFFFF are wildcards. Command payloads and written values are
+// JSON, as in chip-tool: an object's keys are context tags, so
+// '{"0": 1, "1": "hex:0102"}' is a structure with an unsigned integer and
+// an octet string.
+//
+// Options:
+//
+//	-address   Device address, host:port (default: discover via DNS-SD)
+//	-passcode  Setup passcode to pair with before a command (default: none)
+//	-port      Local UDP port (default: 5541)
+//	-timeout   Timeout of each command (default: 30s)
+//	-log       Log levels, e.g. "warn,exchange=debug" (default: error)
+//
+// Pairing establishes a PASE session, which lives as long as the process:
+// operational credentials are not installed and no state is stored. Pair
+// and control a device in one process with interactive mode, or pass
+// -address and -passcode to pair implicitly before a single command. A
+// device accepts one PASE session per commissioning window, so the latter
+// works once per window.
+//
+// Example:
+//
+//	matter-tool pairing code 1 MT:-24J042C00KA0648G00
+//	matter-tool -address [::1]:5540 -passcode 20202021 onoff toggle 1 1
+//	echo "pairing onnetwork 1 20202021
+//	any read-by-id 0x0028 0x0003 1 0" | matter-tool interactive start
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/backkem/matter/examples/controller"
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/matter"
+)
+
+func main() {
+	var opts toolOptions
+	flag.StringVar(&opts.address, "address", "", "Device address, host:port (empty = discover via DNS-SD)")
+	flag.Func("passcode", "Setup passcode to pair with before a command", func(s string) error {
+		v, err := parseUint(s, 32)
+		opts.passcode = uint32(v)
+		return err
+	})
+	port := flag.Int("port", controller.DefaultPort, "Local UDP port")
+	flag.DurationVar(&opts.timeout, "timeout", 30*time.Second, "Timeout of each command")
+	logLevels := flag.String("log", "error", `Log levels, e.g. "warn,exchange=debug"`)
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	ctrl, err := controller.NewWithConfig(matter.NodeConfig{
+		VendorID:      fabric.VendorID(controller.DefaultVendorID),
+		ProductID:     controller.DefaultProductID,
+		DeviceName:    "matter-tool",
+		Discriminator: controller.DefaultDiscriminator,
+		Passcode:      controller.DefaultPasscode,
+		Port:          *port,
+		Storage:       matter.NewMemoryStorage(),
+		Logger:        slog.New(slog.NewTextHandler(os.Stderr, nil)),
+		LogLevels:     *logLevels,
+	})
+	if err != nil {
+		fatal(err)
+	}
+
+	t := newTool(ctrl, opts)
+	if err := t.start(); err != nil {
+		fatal(err)
+	}
+	err = t.run(flag.Args())
+	t.stop()
+	if err != nil {
+		fatal(err)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [options] <cluster> <command> [arguments]\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %s %s %s\n", c.cluster, c.name, c.args)
+	}
+	fmt.Fprintf(os.Stderr, "  interactive start\n")
+	fmt.Fprintf(os.Stderr, "\nOptions:\n")
+	flag.PrintDefaults()
+}
+
+func fatal(err error) {
+	var uerr usageError
+	if errors.As(err, &uerr) {
+		fmt.Fprintf(os.Stderr, "Usage: %s\n", uerr)
+		os.Exit(2)
+	}
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	os.Exit(1)
+}
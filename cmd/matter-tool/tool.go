@@ -0,0 +1,311 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/backkem/matter/examples/controller"
+	"github.com/backkem/matter/pkg/commissioning/payload"
+	"github.com/backkem/matter/pkg/discovery"
+	"github.com/backkem/matter/pkg/session"
+	"github.com/backkem/matter/pkg/transport"
+)
+
+var (
+	errBLEUnsupported = errors.New("BLE commissioning is not supported; pair on the network instead")
+	errNotPaired      = errors.New("node not paired; pair it in interactive mode or pass -address and -passcode")
+	errDeviceNotFound = errors.New("no commissionable device found")
+)
+
+// usageError reports a command invoked with the wrong arguments.
+type usageError string
+
+func (e usageError) Error() string { return string(e) }
+
+// toolOptions are the command-line options shared by all commands.
+type toolOptions struct {
+	address  string
+	passcode uint32
+	timeout  time.Duration
+}
+
+// peer is a device paired in this process.
+type peer struct {
+	sess *session.SecureContext
+	addr transport.PeerAddress
+}
+
+// tool runs commands against the devices it pairs with.
+type tool struct {
+	ctrl  *controller.Controller
+	opts  toolOptions
+	peers map[uint64]peer
+}
+
+// command is a chip-tool style command: a cluster, a command name and
+// positional arguments.
+type command struct {
+	cluster string
+	name    string
+	args    string
+	run     func(t *tool, ctx context.Context, args []string) error
+}
+
+// commands lists the commands in usage order.
+var commands = []command{
+	{"pairing", "code", "<node-id> <payload>", (*tool).pairCode},
+	{"pairing", "onnetwork", "<node-id> <passcode>", (*tool).pairOnNetwork},
+	{"pairing", "onnetwork-long", "<node-id> <passcode> <discriminator>", (*tool).pairOnNetworkLong},
+	{"pairing", "ble-wifi", "<node-id> <ssid> <password> <passcode> <discriminator>", (*tool).pairBLE},
+	{"pairing", "ble-thread", "<node-id> <dataset> <passcode> <discriminator>", (*tool).pairBLE},
+	{"onoff", "on", "<node-id> <endpoint-ids>", (*tool).onOffOn},
+	{"onoff", "off", "<node-id> <endpoint-ids>", (*tool).onOffOff},
+	{"onoff", "toggle", "<node-id> <endpoint-ids>", (*tool).onOffToggle},
+	{"onoff", "read", "on-off <node-id> <endpoint-ids>", (*tool).onOffRead},
+	{"any", "read-by-id", "<cluster-ids> <attribute-ids> <node-id> <endpoint-ids>", (*tool).readByID},
+	{"any", "command-by-id", "<cluster-id> <command-id> <payload> <node-id> <endpoint-id>", (*tool).commandByID},
+	{"any", "write-by-id", "<cluster-ids> <attribute-ids> <value> <node-id> <endpoint-ids>", (*tool).writeByID},
+	{"any", "subscribe-by-id", "<cluster-ids> <attribute-ids> <min-interval> <max-interval> <node-id> <endpoint-ids>", (*tool).subscribeByID},
+}
+
+func newTool(ctrl *controller.Controller, opts toolOptions) *tool {
+	return &tool{
+		ctrl:  ctrl,
+		opts:  opts,
+		peers: make(map[uint64]peer),
+	}
+}
+
+func (t *tool) start() error {
+	ctx, cancel := context.WithTimeout(context.Background(), t.opts.timeout)
+	defer cancel()
+	return t.ctrl.Start(ctx)
+}
+
+func (t *tool) stop() {
+	t.ctrl.Stop()
+}
+
+// run runs one command line, or reads command lines from stdin for
+// "interactive start".
+func (t *tool) run(args []string) error {
+	if len(args) == 2 && args[0] == "interactive" && args[1] == "start" {
+		return t.interactive()
+	}
+	if len(args) < 2 {
+		return usageError("<cluster> <command> [arguments]")
+	}
+
+	for _, c := range commands {
+		if c.cluster != args[0] || c.name != args[1] {
+			continue
+		}
+		want := len(strings.Fields(c.args))
+		if len(args)-2 != want {
+			return usageError(fmt.Sprintf("%s %s %s", c.cluster, c.name, c.args))
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), t.opts.timeout)
+		defer cancel()
+		return c.run(t, ctx, args[2:])
+	}
+	return fmt.Errorf("unknown command %q", strings.Join(args[:2], " "))
+}
+
+// interactive runs the command lines read from stdin until EOF or "quit".
+// Devices paired by one line stay paired for the next.
+func (t *tool) interactive() error {
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Fprint(os.Stderr, ">>> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(os.Stderr)
+			return scanner.Err()
+		}
+		args, err := splitLine(scanner.Text())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+		if args[0] == "quit" || args[0] == "exit" {
+			return nil
+		}
+		if err := t.run(args); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+	}
+}
+
+// splitLine splits a command line into arguments at spaces, keeping
+// single- or double-quoted text, such as a JSON payload, together.
+func splitLine(line string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	var quote rune
+	inArg := false
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated quote")
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
+// pairCode pairs with the device a QR code ("MT:...") or manual pairing
+// code describes.
+func (t *tool) pairCode(ctx context.Context, args []string) error {
+	nodeID, err := parseUint(args[0], 64)
+	if err != nil {
+		return err
+	}
+	var p *payload.SetupPayload
+	if strings.HasPrefix(args[1], "MT:") {
+		p, err = payload.ParseQRCode(args[1])
+	} else {
+		p, err = payload.ParseManualCode(args[1])
+	}
+	if err != nil {
+		return err
+	}
+	return t.pair(ctx, nodeID, p.Passcode, &p.Discriminator)
+}
+
+// pairOnNetwork pairs with the first commissionable device found.
+func (t *tool) pairOnNetwork(ctx context.Context, args []string) error {
+	nodeID, err := parseUint(args[0], 64)
+	if err != nil {
+		return err
+	}
+	passcode, err := parseUint(args[1], 32)
+	if err != nil {
+		return err
+	}
+	return t.pair(ctx, nodeID, uint32(passcode), nil)
+}
+
+// pairOnNetworkLong pairs with the commissionable device with a long
+// discriminator.
+func (t *tool) pairOnNetworkLong(ctx context.Context, args []string) error {
+	nodeID, err := parseUint(args[0], 64)
+	if err != nil {
+		return err
+	}
+	passcode, err := parseUint(args[1], 32)
+	if err != nil {
+		return err
+	}
+	discriminator, err := parseUint(args[2], 12)
+	if err != nil {
+		return err
+	}
+	d := payload.NewLongDiscriminator(uint16(discriminator))
+	return t.pair(ctx, nodeID, uint32(passcode), &d)
+}
+
+func (t *tool) pairBLE(ctx context.Context, args []string) error {
+	return errBLEUnsupported
+}
+
+// pair establishes a PASE session with a device and records it under
+// nodeID. The device is the one at -address, or else is discovered by
+// its discriminator, if not nil.
+func (t *tool) pair(ctx context.Context, nodeID uint64, passcode uint32, discriminator *payload.Discriminator) error {
+	addr, err := t.deviceAddress(ctx, discriminator)
+	if err != nil {
+		return err
+	}
+	sess, err := t.ctrl.CommissionDevice(ctx, addr, passcode)
+	if err != nil {
+		return err
+	}
+	t.peers[nodeID] = peer{sess: sess, addr: addr}
+	fmt.Printf("Paired node 0x%X at %s\n", nodeID, addr)
+	return nil
+}
+
+// deviceAddress returns the -address option, or else the address of a
+// commissionable device with the discriminator (any device if nil).
+func (t *tool) deviceAddress(ctx context.Context, discriminator *payload.Discriminator) (transport.PeerAddress, error) {
+	if t.opts.address != "" {
+		return transport.UDPAddrFromString(t.opts.address)
+	}
+
+	resolver, err := discovery.NewResolver(discovery.ResolverConfig{})
+	if err != nil {
+		return transport.PeerAddress{}, err
+	}
+	var services <-chan discovery.ResolvedService
+	if discriminator != nil && !discriminator.IsShort() {
+		services, err = resolver.BrowseCommissionableWithFilter(ctx, discovery.LongDiscriminatorSubtype(discriminator.Long()))
+	} else {
+		services, err = resolver.BrowseCommissionable(ctx)
+	}
+	if err != nil {
+		return transport.PeerAddress{}, err
+	}
+
+	local, _ := discovery.InterfaceAddresses(nil)
+	for svc := range services {
+		if discriminator != nil && discriminator.IsShort() {
+			long, err := parseUint(svc.Text["D"], 12)
+			if err != nil || !discriminator.Matches(uint16(long)) {
+				continue
+			}
+		}
+		if addrs := svc.UDPAddrs(local); len(addrs) > 0 {
+			return transport.NewUDPPeerAddress(addrs[0]), nil
+		}
+		ip := svc.PreferredIP()
+		if ip == nil {
+			continue
+		}
+		return transport.UDPAddrFromString(net.JoinHostPort(ip.String(), strconv.Itoa(svc.Port)))
+	}
+	return transport.PeerAddress{}, errDeviceNotFound
+}
+
+// connect returns the session to a node: the one it was paired with, or a
+// new one paired with the -passcode option.
+func (t *tool) connect(ctx context.Context, nodeID uint64) (peer, error) {
+	if p, ok := t.peers[nodeID]; ok {
+		return p, nil
+	}
+	if t.opts.passcode == 0 {
+		return peer{}, fmt.Errorf("node 0x%X: %w", nodeID, errNotPaired)
+	}
+	if err := t.pair(ctx, nodeID, t.opts.passcode, nil); err != nil {
+		return peer{}, err
+	}
+	return t.peers[nodeID], nil
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// parseUint parses a decimal or 0x-prefixed hex ID of at most bits bits.
+func parseUint(s string, bits int) (uint64, error) {
+	v, err := strconv.ParseUint(s, 0, bits)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return v, nil
+}
+
+// parseList parses a comma-separated list of IDs.
+func parseList(s string, bits int) ([]uint64, error) {
+	var ids []uint64
+	for _, field := range strings.Split(s, ",") {
+		id, err := parseUint(strings.TrimSpace(field), bits)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// encodeJSON encodes a JSON value as anonymous TLV. Objects become
+// structures keyed by context tag, arrays become arrays, and strings
+// prefixed "hex:" become octet strings. Integers are signed if negative.
+func encodeJSON(s string) ([]byte, error) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := encodeValue(tlv.NewWriter(&buf), tlv.Anonymous(), v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeValue(w *tlv.Writer, tag tlv.Tag, v any) error {
+	switch v := v.(type) {
+	case nil:
+		return w.PutNull(tag)
+	case bool:
+		return w.PutBool(tag, v)
+	case string:
+		if data, ok := strings.CutPrefix(v, "hex:"); ok {
+			b, err := hex.DecodeString(data)
+			if err != nil {
+				return fmt.Errorf("invalid octet string %q", v)
+			}
+			return w.PutBytes(tag, b)
+		}
+		return w.PutString(tag, v)
+	case json.Number:
+		if u, err := strconv.ParseUint(v.String(), 10, 64); err == nil {
+			return w.PutUint(tag, u)
+		}
+		if i, err := v.Int64(); err == nil {
+			return w.PutInt(tag, i)
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return err
+		}
+		return w.PutFloat64(tag, f)
+	case []any:
+		if err := w.StartArray(tag); err != nil {
+			return err
+		}
+		for _, elem := range v {
+			if err := encodeValue(w, tlv.Anonymous(), elem); err != nil {
+				return err
+			}
+		}
+		return w.EndContainer()
+	case map[string]any:
+		// Fields in ascending tag order
+		tags := make([]int, 0, len(v))
+		fields := make(map[int]any, len(v))
+		for key, field := range v {
+			n, err := parseUint(key, 8)
+			if err != nil {
+				return fmt.Errorf("invalid field tag %q", key)
+			}
+			tags = append(tags, int(n))
+			fields[int(n)] = field
+		}
+		sort.Ints(tags)
+
+		if err := w.StartStructure(tag); err != nil {
+			return err
+		}
+		for _, n := range tags {
+			if err := encodeValue(w, tlv.ContextTag(uint8(n)), fields[n]); err != nil {
+				return err
+			}
+		}
+		return w.EndContainer()
+	default:
+		return fmt.Errorf("unsupported value %v", v)
+	}
+}
+
+// printTLV prints a TLV element, one line per value, each indented by
+// indent. The element's own tag is left out.
+func printTLV(data []byte, indent string) error {
+	if len(data) == 0 {
+		return nil
+	}
+	r := tlv.NewReader(bytes.NewReader(data))
+	if err := r.Next(); err != nil {
+		return err
+	}
+	return printElement(r, indent, true)
+}
+
+func printElement(r *tlv.Reader, indent string, top bool) error {
+	label := indent
+	switch tag := r.Tag(); {
+	case top:
+	case tag.IsContext():
+		label += fmt.Sprintf("%d: ", tag.TagNumber())
+	case tag.IsProfileSpecific():
+		label += fmt.Sprintf("0x%04X:0x%04X:%d: ", tag.VendorID(), tag.ProfileNumber(), tag.TagNumber())
+	}
+
+	typ := r.Type()
+	switch {
+	case typ.IsContainer():
+		open, end := "{", "}"
+		if typ != tlv.ElementTypeStruct {
+			open, end = "[", "]"
+		}
+		fmt.Println(label + open)
+		if err := r.EnterContainer(); err != nil {
+			return err
+		}
+		for {
+			if err := r.Next(); err != nil {
+				return err
+			}
+			if r.IsEndOfContainer() {
+				break
+			}
+			if err := printElement(r, indent+"  ", false); err != nil {
+				return err
+			}
+		}
+		if err := r.ExitContainer(); err != nil {
+			return err
+		}
+		fmt.Println(indent + end)
+		return nil
+	case typ.IsSignedInt():
+		v, err := r.Int()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s%d\n", label, v)
+	case typ.IsUnsignedInt():
+		v, err := r.Uint()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s%d (0x%X)\n", label, v, v)
+	case typ.IsBool():
+		v, err := r.Bool()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s%t\n", label, v)
+	case typ == tlv.ElementTypeFloat32:
+		v, err := r.Float32()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s%g\n", label, v)
+	case typ == tlv.ElementTypeFloat64:
+		v, err := r.Float64()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s%g\n", label, v)
+	case typ.IsUTF8String():
+		v, err := r.String()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s%q\n", label, v)
+	case typ.IsBytes():
+		v, err := r.Bytes()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%shex:%x\n", label, v)
+	case typ == tlv.ElementTypeNull:
+		fmt.Println(label + "null")
+	default:
+		return errors.New("unexpected TLV element")
+	}
+	return nil
+}
@@ -0,0 +1,916 @@
+// Package allclusters implements a Matter device exposing every server
+// cluster of this module, for certification-style and interop testing, in
+// the manner of the C++ SDK's all-clusters-app.
+//
+// The clusters are spread over endpoints by device type:
+//
+//   - Root (0): the node's clusters, with Localization Configuration,
+//     Time Format Localization, Unit Localization and General Diagnostics
+//   - On/Off Light (1): On/Off, Mode Select
+//   - Sensors (2): Temperature, Relative Humidity, Illuminance and
+//     Occupancy; Air Quality with CO2 and PM2.5 Concentration
+//   - Thermostat (3), Door Lock (4), Generic Switch (5), Smoke CO Alarm
+//     (6), Water Valve (7)
+//   - Laundry Washer (8): Laundry Washer Mode and Controls, Temperature
+//     Control, Operational State
+//   - Robotic Vacuum Cleaner (9): RVC Run and Clean Mode, RVC Operational
+//     State, Service Area
+//   - EVSE (10): Energy EVSE, Electrical Power and Energy Measurement,
+//     Device Energy Management
+//   - Basic Video Player (11): Media Playback, Keypad Input, Content
+//     Launcher, Media Input, Wake On LAN
+//   - Chime (12), Network Infrastructure Manager (13): Thread Network
+//     Directory
+//
+// A test harness simulates events through the TestEventTrigger command of
+// General Diagnostics, with the device's enable key (DefaultEnableKey
+// unless set); see the Trigger constants. Clusters needing platform
+// backends, such as Thread Border Router Management, Camera AV Stream
+// Management and WebRTC Transport, are not included.
+//
+// Example usage:
+//
+//	opts := common.DefaultOptions()
+//	device, _ := allclusters.NewDevice(opts)
+//	device.Node.Start(ctx)
+//	...
+//	device.HandleTestEventTrigger(allclusters.TriggerSmokeCritical)
+package allclusters
+
+import (
+	"encoding/hex"
+	"log"
+
+	"github.com/backkem/matter/examples/common"
+	"github.com/backkem/matter/pkg/clusters"
+	"github.com/backkem/matter/pkg/clusters/airquality"
+	"github.com/backkem/matter/pkg/clusters/chime"
+	"github.com/backkem/matter/pkg/clusters/concentrationmeasurement"
+	"github.com/backkem/matter/pkg/clusters/contentlauncher"
+	"github.com/backkem/matter/pkg/clusters/deviceenergymanagement"
+	"github.com/backkem/matter/pkg/clusters/doorlock"
+	"github.com/backkem/matter/pkg/clusters/electricalenergymeasurement"
+	"github.com/backkem/matter/pkg/clusters/electricalpowermeasurement"
+	"github.com/backkem/matter/pkg/clusters/energyevse"
+	"github.com/backkem/matter/pkg/clusters/generaldiagnostics"
+	"github.com/backkem/matter/pkg/clusters/illuminancemeasurement"
+	"github.com/backkem/matter/pkg/clusters/keypadinput"
+	"github.com/backkem/matter/pkg/clusters/laundrywashercontrols"
+	"github.com/backkem/matter/pkg/clusters/localizationconfiguration"
+	"github.com/backkem/matter/pkg/clusters/mediainput"
+	"github.com/backkem/matter/pkg/clusters/mediaplayback"
+	"github.com/backkem/matter/pkg/clusters/modebase"
+	"github.com/backkem/matter/pkg/clusters/modeselect"
+	"github.com/backkem/matter/pkg/clusters/occupancysensing"
+	"github.com/backkem/matter/pkg/clusters/onoff"
+	"github.com/backkem/matter/pkg/clusters/operationalstate"
+	"github.com/backkem/matter/pkg/clusters/relativehumiditymeasurement"
+	"github.com/backkem/matter/pkg/clusters/servicearea"
+	"github.com/backkem/matter/pkg/clusters/smokecoalarm"
+	"github.com/backkem/matter/pkg/clusters/switchcluster"
+	"github.com/backkem/matter/pkg/clusters/temperaturecontrol"
+	"github.com/backkem/matter/pkg/clusters/temperaturemeasurement"
+	"github.com/backkem/matter/pkg/clusters/thermostat"
+	"github.com/backkem/matter/pkg/clusters/threadnetworkdirectory"
+	"github.com/backkem/matter/pkg/clusters/timeformatlocalization"
+	"github.com/backkem/matter/pkg/clusters/unitlocalization"
+	"github.com/backkem/matter/pkg/clusters/valveconfigurationandcontrol"
+	"github.com/backkem/matter/pkg/clusters/wakeonlan"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/matter"
+)
+
+// Endpoint IDs.
+const (
+	LightEndpointID          datamodel.EndpointID = 1
+	SensorsEndpointID        datamodel.EndpointID = 2
+	ThermostatEndpointID     datamodel.EndpointID = 3
+	DoorLockEndpointID       datamodel.EndpointID = 4
+	SwitchEndpointID         datamodel.EndpointID = 5
+	SmokeCOAlarmEndpointID   datamodel.EndpointID = 6
+	ValveEndpointID          datamodel.EndpointID = 7
+	WasherEndpointID         datamodel.EndpointID = 8
+	RVCEndpointID            datamodel.EndpointID = 9
+	EVSEEndpointID           datamodel.EndpointID = 10
+	VideoPlayerEndpointID    datamodel.EndpointID = 11
+	ChimeEndpointID          datamodel.EndpointID = 12
+	NetworkManagerEndpointID datamodel.EndpointID = 13
+)
+
+// Test event triggers, each handled by the delegate of its cluster (see
+// generaldiagnostics.TriggerCluster). The values in the lower bits are
+// this device's own.
+const (
+	TriggerUnoccupied uint64 = 0x0406_0000_0000_0000
+	TriggerOccupied   uint64 = 0x0406_0000_0000_0001
+
+	TriggerAirQualityGood uint64 = 0x005B_0000_0000_0001
+	TriggerAirQualityPoor uint64 = 0x005B_0000_0000_0004
+
+	TriggerSmokeCOClear         uint64 = 0x005C_0000_0000_0000
+	TriggerSmokeCritical        uint64 = 0x005C_0000_0000_0001
+	TriggerCOCritical           uint64 = 0x005C_0000_0000_0002
+	TriggerSmokeCOHardwareFault uint64 = 0x005C_0000_0000_0003
+	TriggerSmokeCOEndOfService  uint64 = 0x005C_0000_0000_0004
+
+	TriggerDoorLockJammed uint64 = 0x0101_0000_0000_0001
+
+	TriggerSwitchRelease uint64 = 0x003B_0000_0000_0000
+	TriggerSwitchPress   uint64 = 0x003B_0000_0000_0001
+
+	TriggerValveFaultClear uint64 = 0x0081_0000_0000_0000
+	TriggerValveFault      uint64 = 0x0081_0000_0000_0001
+
+	TriggerWasherError uint64 = 0x0060_0000_0000_0001
+
+	TriggerEVSEUnplug uint64 = 0x0099_0000_0000_0000
+	TriggerEVSEPlugIn uint64 = 0x0099_0000_0000_0001
+)
+
+// DefaultEnableKey is the TestEventTrigger enable key used unless one is
+// set, the same as the C++ all-clusters-app's.
+var DefaultEnableKey, _ = hex.DecodeString("00112233445566778899aabbccddeeff")
+
+// Device represents the all-clusters device. Each cluster instance is
+// exposed for tests to drive.
+type Device struct {
+	// Node is the underlying Matter node.
+	Node *matter.Node
+
+	OnOff      *onoff.Cluster
+	ModeSelect *modeselect.Cluster
+
+	Temperature *temperaturemeasurement.Cluster
+	Humidity    *relativehumiditymeasurement.Cluster
+	Illuminance *illuminancemeasurement.Cluster
+	Occupancy   *occupancysensing.Cluster
+	AirQuality  *airquality.Cluster
+	CO2         *concentrationmeasurement.Cluster
+	PM25        *concentrationmeasurement.Cluster
+
+	Thermostat   *thermostat.Cluster
+	DoorLock     *doorlock.Cluster
+	Switch       *switchcluster.Cluster
+	SmokeCOAlarm *smokecoalarm.Cluster
+	Valve        *valveconfigurationandcontrol.Cluster
+
+	WasherMode        *modebase.Cluster
+	WasherControls    *laundrywashercontrols.Cluster
+	WasherTemperature *temperaturecontrol.Cluster
+	WasherState       *operationalstate.Cluster
+
+	RVCRunMode   *modebase.Cluster
+	RVCCleanMode *modebase.Cluster
+	RVCState     *operationalstate.Cluster
+	ServiceArea  *servicearea.Cluster
+
+	EVSE             *energyevse.Cluster
+	Power            *electricalpowermeasurement.Cluster
+	Energy           *electricalenergymeasurement.Cluster
+	EnergyManagement *deviceenergymanagement.Cluster
+
+	MediaPlayback   *mediaplayback.Cluster
+	KeypadInput     *keypadinput.Cluster
+	ContentLauncher *contentlauncher.Cluster
+	MediaInput      *mediainput.Cluster
+	WakeOnLAN       *wakeonlan.Cluster
+
+	Chime                  *chime.Cluster
+	ThreadNetworkDirectory *threadnetworkdirectory.Cluster
+}
+
+// NewDevice creates a new all-clusters device with the given options.
+// Test event triggers use opts.EnableKey, or DefaultEnableKey.
+func NewDevice(opts common.Options) (*Device, error) {
+	// Apply all-clusters defaults
+	if opts.DeviceName == "" || opts.DeviceName == "Matter Device" {
+		opts.DeviceName = "Matter All Clusters"
+	}
+	if opts.EnableKey == nil {
+		opts.EnableKey = DefaultEnableKey
+	}
+
+	d := &Device{}
+	node, err := common.CreateNode(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return d, d.init(node)
+}
+
+// NewDeviceWithConfig creates a new all-clusters device with a custom
+// Matter config. This is useful for testing. Test event triggers use
+// config.TestEventTriggerEnableKey, or DefaultEnableKey.
+func NewDeviceWithConfig(config matter.NodeConfig) (*Device, error) {
+	if config.TestEventTriggerEnableKey == nil {
+		config.TestEventTriggerEnableKey = DefaultEnableKey
+	}
+
+	d := &Device{}
+	node, err := matter.NewNode(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return d, d.init(node)
+}
+
+// init creates the clusters and adds their endpoints to node.
+func (d *Device) init(node *matter.Node) error {
+	d.Node = node
+	events := node.EventPublisher()
+
+	for _, build := range []func(datamodel.EventPublisher) (*matter.Endpoint, error){
+		d.newLight,
+		d.newSensors,
+		d.newThermostat,
+		d.newDoorLock,
+		d.newSwitch,
+		d.newSmokeCOAlarm,
+		d.newValve,
+		d.newWasher,
+		d.newRVC,
+		d.newEVSE,
+		d.newVideoPlayer,
+		d.newChime,
+		d.newNetworkManager,
+	} {
+		ep, err := build(events)
+		if err != nil {
+			return err
+		}
+		if err := node.AddEndpoint(ep); err != nil {
+			return err
+		}
+	}
+
+	if err := d.registerTriggers(); err != nil {
+		return err
+	}
+	return d.addRootClusters()
+}
+
+// addRootClusters adds the node-wide localization clusters to the root
+// endpoint.
+func (d *Device) addRootClusters() error {
+	locales, err := localizationconfiguration.New(localizationconfiguration.Config{
+		EndpointID:       matter.RootEndpointID,
+		SupportedLocales: []string{"en-US", "de-DE", "fr-FR"},
+	})
+	if err != nil {
+		return err
+	}
+
+	gregorian := timeformatlocalization.CalendarTypeGregorian
+	timeFormat, err := timeformatlocalization.New(timeformatlocalization.Config{
+		EndpointID:             matter.RootEndpointID,
+		FeatureMap:             timeformatlocalization.FeatureCalendarFormat,
+		HourFormat:             timeformatlocalization.HourFormat24hr,
+		SupportedCalendarTypes: []timeformatlocalization.CalendarType{timeformatlocalization.CalendarTypeGregorian},
+		ActiveCalendarType:     &gregorian,
+	})
+	if err != nil {
+		return err
+	}
+
+	units, err := unitlocalization.New(unitlocalization.Config{
+		EndpointID: matter.RootEndpointID,
+		FeatureMap: unitlocalization.FeatureTemperatureUnit,
+		SupportedTemperatureUnits: []unitlocalization.TempUnit{
+			unitlocalization.TempUnitCelsius, unitlocalization.TempUnitFahrenheit, unitlocalization.TempUnitKelvin,
+		},
+		TemperatureUnit: unitlocalization.TempUnitCelsius,
+	})
+	if err != nil {
+		return err
+	}
+
+	root := d.Node.GetEndpoint(matter.RootEndpointID)
+	root.AddCluster(locales).AddCluster(timeFormat).AddCluster(units)
+	return nil
+}
+
+// newLight creates the On/Off Light endpoint.
+func (d *Device) newLight(datamodel.EventPublisher) (*matter.Endpoint, error) {
+	d.OnOff = onoff.New(onoff.Config{
+		EndpointID: LightEndpointID,
+		OnStateChange: func(_ datamodel.EndpointID, on bool) {
+			log.Printf("Light is now %v", on)
+		},
+	})
+
+	var err error
+	d.ModeSelect, err = modeselect.New(modeselect.Config{
+		EndpointID:  LightEndpointID,
+		Description: "Color temperature",
+		Modes: []modebase.ModeOption{
+			{Label: "Warm", Mode: 0},
+			{Label: "Neutral", Mode: 1},
+			{Label: "Cool", Mode: 2},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return matter.NewEndpoint(LightEndpointID).
+		WithDeviceType(0x0100, 3). // On/Off Light
+		WithDeviceType(0x0027, 1). // Mode Select
+		AddCluster(d.OnOff).
+		AddCluster(d.ModeSelect), nil
+}
+
+// newSensors creates the environmental sensors endpoint.
+func (d *Device) newSensors(events datamodel.EventPublisher) (*matter.Endpoint, error) {
+	d.Temperature = temperaturemeasurement.New(temperaturemeasurement.Config{EndpointID: SensorsEndpointID})
+	d.Humidity = relativehumiditymeasurement.New(relativehumiditymeasurement.Config{EndpointID: SensorsEndpointID})
+	d.Illuminance = illuminancemeasurement.New(illuminancemeasurement.Config{EndpointID: SensorsEndpointID})
+	d.Occupancy = occupancysensing.New(occupancysensing.Config{
+		EndpointID:     SensorsEndpointID,
+		FeatureMap:     occupancysensing.FeaturePassiveInfrared,
+		EventPublisher: events,
+	})
+	d.AirQuality = airquality.New(airquality.Config{
+		EndpointID: SensorsEndpointID,
+		FeatureMap: airquality.FeatureFair | airquality.FeatureModerate | airquality.FeatureVeryPoor | airquality.FeatureExtremelyPoor,
+	})
+
+	concentration := func(kind concentrationmeasurement.Kind) *concentrationmeasurement.Cluster {
+		return concentrationmeasurement.New(concentrationmeasurement.Config{
+			EndpointID:      SensorsEndpointID,
+			Kind:            kind,
+			FeatureMap:      concentrationmeasurement.FeatureNumericMeasurement | concentrationmeasurement.FeatureLevelIndication,
+			MeasurementUnit: concentrationmeasurement.MeasurementUnitPPM,
+		})
+	}
+	d.CO2 = concentration(concentrationmeasurement.CarbonDioxide)
+	d.PM25 = concentration(concentrationmeasurement.PM25)
+
+	// Start from a comfortable room
+	temperature, humidity := int16(2150), uint16(4500)
+	d.Temperature.SetMeasuredValue(&temperature)
+	d.Humidity.SetMeasuredValue(&humidity)
+	d.Illuminance.SetLux(300)
+	d.AirQuality.SetAirQuality(airquality.AirQualityGood)
+
+	return matter.NewEndpoint(SensorsEndpointID).
+		WithDeviceType(0x0302, 2). // Temperature Sensor
+		WithDeviceType(0x0307, 2). // Humidity Sensor
+		WithDeviceType(0x0106, 3). // Light Sensor
+		WithDeviceType(0x0107, 4). // Occupancy Sensor
+		WithDeviceType(0x002C, 1). // Air Quality Sensor
+		AddCluster(d.Temperature).
+		AddCluster(d.Humidity).
+		AddCluster(d.Illuminance).
+		AddCluster(d.Occupancy).
+		AddCluster(d.AirQuality).
+		AddCluster(d.CO2).
+		AddCluster(d.PM25), nil
+}
+
+// newThermostat creates the Thermostat endpoint.
+func (d *Device) newThermostat(datamodel.EventPublisher) (*matter.Endpoint, error) {
+	d.Thermostat = thermostat.New(thermostat.Config{
+		EndpointID:        ThermostatEndpointID,
+		FeatureMap:        thermostat.FeatureHeating | thermostat.FeatureCooling | thermostat.FeatureAutoMode,
+		InitialSystemMode: thermostat.SystemModeAuto,
+	})
+	temperature := int16(2150)
+	d.Thermostat.SetLocalTemperature(&temperature)
+
+	return matter.NewEndpoint(ThermostatEndpointID).
+		WithDeviceType(0x0301, 4). // Thermostat
+		AddCluster(d.Thermostat), nil
+}
+
+// newDoorLock creates the Door Lock endpoint.
+func (d *Device) newDoorLock(events datamodel.EventPublisher) (*matter.Endpoint, error) {
+	d.DoorLock = doorlock.New(doorlock.Config{
+		EndpointID:       DoorLockEndpointID,
+		FeatureMap:       doorlock.FeaturePINCredential | doorlock.FeatureUser,
+		LockType:         doorlock.LockTypeDeadBolt,
+		InitialLockState: doorlock.LockStateLocked,
+		Store:            doorlock.NewMemoryCredentialStore(),
+		EventPublisher:   events,
+		OnLockStateChange: func(_ datamodel.EndpointID, state doorlock.LockState) {
+			log.Printf("Door lock state is now %d", state)
+		},
+	})
+
+	return matter.NewEndpoint(DoorLockEndpointID).
+		WithDeviceType(0x000A, 3). // Door Lock
+		AddCluster(d.DoorLock), nil
+}
+
+// newSwitch creates the Generic Switch endpoint.
+func (d *Device) newSwitch(events datamodel.EventPublisher) (*matter.Endpoint, error) {
+	d.Switch = switchcluster.New(switchcluster.Config{
+		EndpointID:        SwitchEndpointID,
+		FeatureMap:        switchcluster.FeatureMomentarySwitch | switchcluster.FeatureMomentarySwitchRelease,
+		NumberOfPositions: 2,
+		EventPublisher:    events,
+	})
+
+	return matter.NewEndpoint(SwitchEndpointID).
+		WithDeviceType(0x000F, 3). // Generic Switch
+		AddCluster(d.Switch), nil
+}
+
+// newSmokeCOAlarm creates the Smoke CO Alarm endpoint.
+func (d *Device) newSmokeCOAlarm(events datamodel.EventPublisher) (*matter.Endpoint, error) {
+	var err error
+	d.SmokeCOAlarm, err = smokecoalarm.New(smokecoalarm.Config{
+		EndpointID:        SmokeCOAlarmEndpointID,
+		FeatureMap:        smokecoalarm.FeatureSmokeAlarm | smokecoalarm.FeatureCOAlarm,
+		EnableDeviceMuted: true,
+		EnableSelfTest:    true,
+		EventPublisher:    events,
+		OnExpressedStateChange: func(_ datamodel.EndpointID, state smokecoalarm.ExpressedState) {
+			log.Printf("Smoke CO alarm expressed state is now %d", state)
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return matter.NewEndpoint(SmokeCOAlarmEndpointID).
+		WithDeviceType(0x0076, 1). // Smoke CO Alarm
+		AddCluster(d.SmokeCOAlarm), nil
+}
+
+// newValve creates the Water Valve endpoint.
+func (d *Device) newValve(events datamodel.EventPublisher) (*matter.Endpoint, error) {
+	var err error
+	d.Valve, err = valveconfigurationandcontrol.New(valveconfigurationandcontrol.Config{
+		EndpointID:       ValveEndpointID,
+		FeatureMap:       valveconfigurationandcontrol.FeatureLevel,
+		EnableValveFault: true,
+		EventPublisher:   events,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return matter.NewEndpoint(ValveEndpointID).
+		WithDeviceType(0x0042, 1). // Water Valve
+		AddCluster(d.Valve), nil
+}
+
+// newWasher creates the Laundry Washer endpoint.
+func (d *Device) newWasher(events datamodel.EventPublisher) (*matter.Endpoint, error) {
+	var err error
+	d.WasherMode, err = modebase.New(modebase.Config{
+		EndpointID: WasherEndpointID,
+		Definition: modebase.LaundryWasherMode,
+		Modes: []modebase.ModeOption{
+			{Label: "Normal", Mode: 0, ModeTags: []modebase.ModeTag{{Value: modebase.LaundryWasherModeTagNormal}}},
+			{Label: "Delicate", Mode: 1, ModeTags: []modebase.ModeTag{{Value: modebase.LaundryWasherModeTagDelicate}}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	spin := uint8(1)
+	d.WasherControls, err = laundrywashercontrols.New(laundrywashercontrols.Config{
+		EndpointID:       WasherEndpointID,
+		FeatureMap:       laundrywashercontrols.FeatureSpin | laundrywashercontrols.FeatureRinse,
+		SpinSpeeds:       []string{"Off", "800", "1400"},
+		InitialSpinSpeed: &spin,
+		SupportedRinses: []laundrywashercontrols.NumberOfRinses{
+			laundrywashercontrols.RinsesNormal, laundrywashercontrols.RinsesExtra,
+		},
+		InitialRinses: laundrywashercontrols.RinsesNormal,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	d.WasherTemperature, err = temperaturecontrol.New(temperaturecontrol.Config{
+		EndpointID:      WasherEndpointID,
+		FeatureMap:      temperaturecontrol.FeatureTemperatureLevel,
+		SupportedLevels: []string{"Cold", "40°C", "60°C"},
+		InitialLevel:    1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	d.WasherState, err = operationalstate.New(operationalstate.Config{
+		EndpointID:     WasherEndpointID,
+		PhaseList:      []string{"Wash", "Rinse", "Spin"},
+		EventPublisher: events,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return matter.NewEndpoint(WasherEndpointID).
+		WithDeviceType(0x0073, 1). // Laundry Washer
+		AddCluster(d.WasherMode).
+		AddCluster(d.WasherControls).
+		AddCluster(d.WasherTemperature).
+		AddCluster(d.WasherState), nil
+}
+
+// newRVC creates the Robotic Vacuum Cleaner endpoint.
+func (d *Device) newRVC(events datamodel.EventPublisher) (*matter.Endpoint, error) {
+	var err error
+	d.RVCRunMode, err = modebase.New(modebase.Config{
+		EndpointID: RVCEndpointID,
+		Definition: modebase.RVCRunMode,
+		Modes: []modebase.ModeOption{
+			{Label: "Idle", Mode: 0, ModeTags: []modebase.ModeTag{{Value: modebase.RVCRunModeTagIdle}}},
+			{Label: "Cleaning", Mode: 1, ModeTags: []modebase.ModeTag{{Value: modebase.RVCRunModeTagCleaning}}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	d.RVCCleanMode, err = modebase.New(modebase.Config{
+		EndpointID: RVCEndpointID,
+		Definition: modebase.RVCCleanMode,
+		Modes: []modebase.ModeOption{
+			{Label: "Vacuum", Mode: 0, ModeTags: []modebase.ModeTag{{Value: modebase.RVCCleanModeTagVacuum}}},
+			{Label: "Mop", Mode: 1, ModeTags: []modebase.ModeTag{{Value: modebase.RVCCleanModeTagMop}}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	d.RVCState, err = operationalstate.New(operationalstate.Config{
+		EndpointID:     RVCEndpointID,
+		Definition:     operationalstate.RVCOperationalState,
+		InitialState:   operationalstate.RVCStateDocked,
+		EventPublisher: events,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	room := func(id uint32, name string) servicearea.Area {
+		return servicearea.Area{AreaID: id, AreaInfo: servicearea.AreaInfo{
+			LocationInfo: &servicearea.LocationDescriptor{LocationName: name},
+		}}
+	}
+	d.ServiceArea, err = servicearea.New(servicearea.Config{
+		EndpointID:        RVCEndpointID,
+		SupportedAreas:    []servicearea.Area{room(1, "Kitchen"), room(2, "Living Room")},
+		EnableCurrentArea: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return matter.NewEndpoint(RVCEndpointID).
+		WithDeviceType(0x0074, 3). // Robotic Vacuum Cleaner
+		AddCluster(d.RVCRunMode).
+		AddCluster(d.RVCCleanMode).
+		AddCluster(d.RVCState).
+		AddCluster(d.ServiceArea), nil
+}
+
+// accuracy returns a 1% accuracy entry for the range [min, max].
+func accuracy(t clusters.MeasurementType, min, max int64) clusters.MeasurementAccuracy {
+	onePercent := uint16(100)
+	return clusters.MeasurementAccuracy{
+		MeasurementType:  t,
+		Measured:         true,
+		MinMeasuredValue: min,
+		MaxMeasuredValue: max,
+		AccuracyRanges: []clusters.MeasurementAccuracyRange{
+			{RangeMin: min, RangeMax: max, PercentMax: &onePercent},
+		},
+	}
+}
+
+// newEVSE creates the EVSE endpoint.
+func (d *Device) newEVSE(events datamodel.EventPublisher) (*matter.Endpoint, error) {
+	const maxPower = 7_360_000 // 32 A at 230 V, in mW
+
+	var err error
+	d.EVSE, err = energyevse.New(energyevse.Config{
+		EndpointID:      EVSEEndpointID,
+		CircuitCapacity: 32000,
+		EventPublisher:  events,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	d.Power, err = electricalpowermeasurement.New(electricalpowermeasurement.Config{
+		EndpointID: EVSEEndpointID,
+		FeatureMap: electricalpowermeasurement.FeatureAlternatingCurrent,
+		Accuracy: []clusters.MeasurementAccuracy{
+			accuracy(clusters.MeasurementTypeActivePower, 0, maxPower),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	d.Energy, err = electricalenergymeasurement.New(electricalenergymeasurement.Config{
+		EndpointID:     EVSEEndpointID,
+		FeatureMap:     electricalenergymeasurement.FeatureImportedEnergy | electricalenergymeasurement.FeatureCumulativeEnergy,
+		Accuracy:       accuracy(clusters.MeasurementTypeElectricalEnergy, 0, 1<<50),
+		EventPublisher: events,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	d.EnergyManagement, err = deviceenergymanagement.New(deviceenergymanagement.Config{
+		EndpointID:   EVSEEndpointID,
+		FeatureMap:   deviceenergymanagement.FeaturePowerAdjustment,
+		ESAType:      deviceenergymanagement.ESATypeEVSE,
+		InitialState: deviceenergymanagement.ESAStateOnline,
+		AbsMaxPower:  maxPower,
+		PowerAdjustments: []deviceenergymanagement.PowerAdjust{
+			{MinPower: 1_380_000, MaxPower: maxPower, MinDuration: 60, MaxDuration: 3600},
+		},
+		EventPublisher: events,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return matter.NewEndpoint(EVSEEndpointID).
+		WithDeviceType(0x050C, 2). // EVSE
+		WithDeviceType(0x050D, 2). // Device Energy Management
+		WithDeviceType(0x0510, 1). // Electrical Sensor
+		AddCluster(d.EVSE).
+		AddCluster(d.Power).
+		AddCluster(d.Energy).
+		AddCluster(d.EnergyManagement), nil
+}
+
+// videoPlayer is the media delegate of the Basic Video Player endpoint.
+// It accepts every command.
+type videoPlayer struct{}
+
+func (videoPlayer) HandlePlay(speed float32) mediaplayback.Status { return mediaplayback.StatusSuccess }
+func (videoPlayer) HandlePause() mediaplayback.Status             { return mediaplayback.StatusSuccess }
+func (videoPlayer) HandleStop() mediaplayback.Status              { return mediaplayback.StatusSuccess }
+func (videoPlayer) HandleSeek(position uint64) mediaplayback.Status {
+	return mediaplayback.StatusSuccess
+}
+func (videoPlayer) HandlePrevious() mediaplayback.Status { return mediaplayback.StatusSuccess }
+func (videoPlayer) HandleNext() mediaplayback.Status     { return mediaplayback.StatusSuccess }
+
+// HandleSendKey implements keypadinput.Delegate.
+func (videoPlayer) HandleSendKey(key keypadinput.KeyCode) keypadinput.Status {
+	log.Printf("Video player key 0x%02X", uint8(key))
+	return keypadinput.StatusSuccess
+}
+
+// HandleLaunchContent implements contentlauncher.Delegate.
+func (videoPlayer) HandleLaunchContent(search []contentlauncher.Parameter, autoPlay bool, data string) (contentlauncher.Status, string) {
+	return contentlauncher.StatusSuccess, data
+}
+
+// HandleLaunchURL implements contentlauncher.Delegate.
+func (videoPlayer) HandleLaunchURL(url, displayString string) (contentlauncher.Status, string) {
+	log.Printf("Video player launching %s", url)
+	return contentlauncher.StatusSuccess, ""
+}
+
+func (videoPlayer) HandleSelectInput(index uint8) error              { return nil }
+func (videoPlayer) HandleShowInputStatus() error                     { return nil }
+func (videoPlayer) HandleHideInputStatus() error                     { return nil }
+func (videoPlayer) HandleRenameInput(index uint8, name string) error { return nil }
+
+// newVideoPlayer creates the Basic Video Player endpoint.
+func (d *Device) newVideoPlayer(datamodel.EventPublisher) (*matter.Endpoint, error) {
+	var err error
+	d.MediaPlayback, err = mediaplayback.New(mediaplayback.Config{
+		EndpointID: VideoPlayerEndpointID,
+		FeatureMap: mediaplayback.FeatureAdvancedSeek | mediaplayback.FeatureVariableSpeed,
+		Delegate:   videoPlayer{},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	d.KeypadInput, err = keypadinput.New(keypadinput.Config{
+		EndpointID: VideoPlayerEndpointID,
+		Delegate:   videoPlayer{},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	d.ContentLauncher, err = contentlauncher.New(contentlauncher.Config{
+		EndpointID:                  VideoPlayerEndpointID,
+		FeatureMap:                  contentlauncher.FeatureContentSearch | contentlauncher.FeatureURLPlayback,
+		AcceptHeader:                []string{"application/dash+xml", "application/x-mpegURL"},
+		SupportedStreamingProtocols: contentlauncher.StreamingProtocolDASH | contentlauncher.StreamingProtocolHLS,
+		Delegate:                    videoPlayer{},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	d.MediaInput, err = mediainput.New(mediainput.Config{
+		EndpointID: VideoPlayerEndpointID,
+		FeatureMap: mediainput.FeatureNameUpdates,
+		Inputs: []mediainput.InputInfo{
+			{Index: 1, InputType: mediainput.InputTypeInternal, Name: "Apps"},
+			{Index: 2, InputType: mediainput.InputTypeAux, Name: "HDMI 1"},
+		},
+		CurrentInput: 1,
+		Delegate:     videoPlayer{},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	d.WakeOnLAN, err = wakeonlan.New(wakeonlan.Config{EndpointID: VideoPlayerEndpointID})
+	if err != nil {
+		return nil, err
+	}
+
+	return matter.NewEndpoint(VideoPlayerEndpointID).
+		WithDeviceType(0x0028, 2). // Basic Video Player
+		AddCluster(d.MediaPlayback).
+		AddCluster(d.KeypadInput).
+		AddCluster(d.ContentLauncher).
+		AddCluster(d.MediaInput).
+		AddCluster(d.WakeOnLAN), nil
+}
+
+// newChime creates the Chime endpoint.
+func (d *Device) newChime(datamodel.EventPublisher) (*matter.Endpoint, error) {
+	var err error
+	d.Chime, err = chime.New(chime.Config{
+		EndpointID:  ChimeEndpointID,
+		ChimeSounds: []chime.ChimeSound{{ChimeID: 0, Name: "Ding Dong"}, {ChimeID: 1, Name: "Westminster"}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return matter.NewEndpoint(ChimeEndpointID).
+		WithDeviceType(0x0146, 1). // Chime
+		AddCluster(d.Chime), nil
+}
+
+// newNetworkManager creates the Network Infrastructure Manager endpoint.
+func (d *Device) newNetworkManager(datamodel.EventPublisher) (*matter.Endpoint, error) {
+	var err error
+	d.ThreadNetworkDirectory, err = threadnetworkdirectory.New(threadnetworkdirectory.Config{
+		EndpointID: NetworkManagerEndpointID,
+		TableSize:  threadnetworkdirectory.MinThreadNetworkTableSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return matter.NewEndpoint(NetworkManagerEndpointID).
+		WithDeviceType(0x0090, 1). // Network Infrastructure Manager
+		AddCluster(d.ThreadNetworkDirectory), nil
+}
+
+// registerTriggers registers the test event trigger delegates of the
+// clusters.
+func (d *Device) registerTriggers() error {
+	delegates := map[datamodel.ClusterID]generaldiagnostics.TestEventTriggerFunc{
+		occupancysensing.ClusterID:                  d.occupancyTrigger,
+		airquality.ClusterID:                        d.airQualityTrigger,
+		smokecoalarm.ClusterID:                      d.smokeCOAlarmTrigger,
+		doorlock.ClusterID:                          d.doorLockTrigger,
+		switchcluster.ClusterID:                     d.switchTrigger,
+		valveconfigurationandcontrol.ClusterID:      d.valveTrigger,
+		operationalstate.OperationalState.ClusterID: d.washerTrigger,
+		energyevse.ClusterID:                        d.evseTrigger,
+	}
+	for cluster, delegate := range delegates {
+		if err := d.Node.TestEventTriggers().Register(cluster, delegate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HandleTestEventTrigger simulates the event of one of the Trigger
+// constants, as the TestEventTrigger command does.
+func (d *Device) HandleTestEventTrigger(trigger uint64) error {
+	return d.Node.TestEventTriggers().HandleTestEventTrigger(trigger)
+}
+
+func (d *Device) occupancyTrigger(trigger uint64) error {
+	switch trigger {
+	case TriggerUnoccupied, TriggerOccupied:
+		return d.Occupancy.SetOccupied(trigger == TriggerOccupied)
+	}
+	return generaldiagnostics.ErrUnknownTrigger
+}
+
+func (d *Device) airQualityTrigger(trigger uint64) error {
+	switch trigger {
+	case TriggerAirQualityGood:
+		return d.AirQuality.SetAirQuality(airquality.AirQualityGood)
+	case TriggerAirQualityPoor:
+		return d.AirQuality.SetAirQuality(airquality.AirQualityPoor)
+	}
+	return generaldiagnostics.ErrUnknownTrigger
+}
+
+func (d *Device) smokeCOAlarmTrigger(trigger uint64) error {
+	switch trigger {
+	case TriggerSmokeCOClear:
+		for _, err := range []error{
+			d.SmokeCOAlarm.SetSmokeState(smokecoalarm.AlarmStateNormal),
+			d.SmokeCOAlarm.SetCOState(smokecoalarm.AlarmStateNormal),
+			d.SmokeCOAlarm.SetHardwareFault(false),
+			d.SmokeCOAlarm.SetEndOfService(smokecoalarm.EndOfServiceNormal),
+		} {
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case TriggerSmokeCritical:
+		return d.SmokeCOAlarm.SetSmokeState(smokecoalarm.AlarmStateCritical)
+	case TriggerCOCritical:
+		return d.SmokeCOAlarm.SetCOState(smokecoalarm.AlarmStateCritical)
+	case TriggerSmokeCOHardwareFault:
+		return d.SmokeCOAlarm.SetHardwareFault(true)
+	case TriggerSmokeCOEndOfService:
+		return d.SmokeCOAlarm.SetEndOfService(smokecoalarm.EndOfServiceExpired)
+	}
+	return generaldiagnostics.ErrUnknownTrigger
+}
+
+func (d *Device) doorLockTrigger(trigger uint64) error {
+	if trigger == TriggerDoorLockJammed {
+		return d.DoorLock.Alarm(doorlock.AlarmCodeLockJammed)
+	}
+	return generaldiagnostics.ErrUnknownTrigger
+}
+
+func (d *Device) switchTrigger(trigger uint64) error {
+	switch trigger {
+	case TriggerSwitchPress:
+		return d.Switch.Press(1)
+	case TriggerSwitchRelease:
+		return d.Switch.Release()
+	}
+	return generaldiagnostics.ErrUnknownTrigger
+}
+
+func (d *Device) valveTrigger(trigger uint64) error {
+	switch trigger {
+	case TriggerValveFault:
+		return d.Valve.SetValveFault(valveconfigurationandcontrol.ValveFaultGeneralFault)
+	case TriggerValveFaultClear:
+		return d.Valve.SetValveFault(0)
+	}
+	return generaldiagnostics.ErrUnknownTrigger
+}
+
+func (d *Device) washerTrigger(trigger uint64) error {
+	if trigger == TriggerWasherError {
+		return d.WasherState.SetError(operationalstate.ErrorState{ID: operationalstate.ErrorUnableToCompleteOperation})
+	}
+	return generaldiagnostics.ErrUnknownTrigger
+}
+
+func (d *Device) evseTrigger(trigger uint64) error {
+	switch trigger {
+	case TriggerEVSEPlugIn:
+		if err := d.EVSE.PlugIn(); err != nil {
+			return err
+		}
+		return d.EVSE.SetEVDemand(true)
+	case TriggerEVSEUnplug:
+		return d.EVSE.Unplug()
+	}
+	return generaldiagnostics.ErrUnknownTrigger
+}
+
+// OnboardingPayload returns the QR code payload for commissioning.
+func (d *Device) OnboardingPayload() string {
+	return d.Node.OnboardingPayload()
+}
+
+// ManualPairingCode returns the manual pairing code for commissioning.
+func (d *Device) ManualPairingCode() string {
+	return d.Node.ManualPairingCode()
+}
+
+// GetNode returns the underlying Matter node.
+// Implements the TestDevice interface for integration testing.
+func (d *Device) GetNode() *matter.Node {
+	return d.Node
+}
+
+// Factory creates an all-clusters device from a Matter node config.
+// Use this with the test infrastructure:
+//
+//	pair := integration.NewTestPair(t, allclusters.Factory)
+func Factory(config matter.NodeConfig) (*Device, error) {
+	return NewDeviceWithConfig(config)
+}
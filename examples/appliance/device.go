@@ -0,0 +1,501 @@
+// Package appliance implements a Matter kitchen and laundry appliance
+// device.
+//
+// The node hosts three appliances, one per endpoint, each built from the
+// same pieces: a Mode Base derived mode cluster selecting the program, an
+// Operational State cluster running the cycle and Temperature Control
+// setting its temperature.
+//
+//   - Laundry Washer (1): Laundry Washer Mode, Laundry Washer Controls,
+//     Temperature Control (levels), Operational State
+//   - Dishwasher (2): Dishwasher Mode, Dishwasher Alarm, Temperature
+//     Control (numeric), Operational State
+//   - Oven cavity (3): Oven Mode, Temperature Control (numeric, stepped),
+//     Oven Cavity Operational State
+//
+// Programs and settings are locked while a cycle runs. The simulated
+// hardware side is driven through Advance and the dishwasher door.
+//
+// Example usage:
+//
+//	opts := common.DefaultOptions()
+//	device, _ := appliance.NewDevice(opts)
+//	device.Node.Start(ctx)
+//	...
+//	device.Advance(&device.Washer) // move the wash cycle to its next phase
+package appliance
+
+import (
+	"log"
+
+	"github.com/backkem/matter/examples/common"
+	"github.com/backkem/matter/pkg/clusters/alarmbase"
+	"github.com/backkem/matter/pkg/clusters/laundrywashercontrols"
+	"github.com/backkem/matter/pkg/clusters/modebase"
+	"github.com/backkem/matter/pkg/clusters/operationalstate"
+	"github.com/backkem/matter/pkg/clusters/temperaturecontrol"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/matter"
+)
+
+// DeviceType constants for the appliances.
+const (
+	// LaundryWasherDeviceType is the device type for Laundry Washer (0x0073).
+	LaundryWasherDeviceType uint32 = 0x0073
+
+	// DishwasherDeviceType is the device type for Dishwasher (0x0075).
+	DishwasherDeviceType uint32 = 0x0075
+
+	// CabinetDeviceType is the device type for Temperature Controlled
+	// Cabinet (0x0071), used for the oven cavity.
+	CabinetDeviceType uint32 = 0x0071
+
+	// WasherEndpointID is the endpoint ID for the laundry washer.
+	WasherEndpointID datamodel.EndpointID = 1
+
+	// DishwasherEndpointID is the endpoint ID for the dishwasher.
+	DishwasherEndpointID datamodel.EndpointID = 2
+
+	// OvenEndpointID is the endpoint ID for the oven cavity.
+	OvenEndpointID datamodel.EndpointID = 3
+)
+
+// Laundry washer modes.
+const (
+	WasherModeNormal   uint8 = 0
+	WasherModeDelicate uint8 = 1
+	WasherModeHeavy    uint8 = 2
+	WasherModeWhites   uint8 = 3
+)
+
+// Dishwasher modes.
+const (
+	DishwasherModeNormal uint8 = 0
+	DishwasherModeHeavy  uint8 = 1
+	DishwasherModeLight  uint8 = 2
+)
+
+// Oven modes.
+const (
+	OvenModeBake       uint8 = 0
+	OvenModeConvection uint8 = 1
+	OvenModeGrill      uint8 = 2
+)
+
+// Appliance groups the clusters shared by every appliance endpoint.
+type Appliance struct {
+	// Mode selects the program.
+	Mode *modebase.Cluster
+
+	// OperationalState runs the cycle.
+	OperationalState *operationalstate.Cluster
+
+	// Temperature sets the cycle temperature.
+	Temperature *temperaturecontrol.Cluster
+
+	// phases is the number of phases in a cycle.
+	phases int
+}
+
+// running returns true while a cycle is in progress.
+func (a *Appliance) running() bool {
+	switch a.OperationalState.OperationalState() {
+	case operationalstate.StateRunning, operationalstate.StatePaused:
+		return true
+	}
+	return false
+}
+
+// Device represents the appliance device.
+type Device struct {
+	// Node is the underlying Matter node.
+	Node *matter.Node
+
+	// Washer is the laundry washer.
+	Washer Appliance
+
+	// WasherControls is the Laundry Washer Controls cluster instance.
+	WasherControls *laundrywashercontrols.Cluster
+
+	// Dishwasher is the dishwasher.
+	Dishwasher Appliance
+
+	// DishwasherAlarm is the Dishwasher Alarm cluster instance.
+	DishwasherAlarm *alarmbase.Cluster
+
+	// Oven is the oven cavity.
+	Oven Appliance
+}
+
+// cycleDelegate locks an appliance's settings while its cycle runs and
+// starts each cycle at its first phase.
+type cycleDelegate struct{ a *Appliance }
+
+// HandleChangeToMode implements modebase.Delegate.
+func (c cycleDelegate) HandleChangeToMode(newMode uint8) (modebase.ChangeStatus, string) {
+	if c.a.running() {
+		return modebase.ChangeStatusInvalidInMode, "cycle in progress"
+	}
+	return modebase.ChangeStatusSuccess, ""
+}
+
+// HandleSetTemperature implements temperaturecontrol.Delegate.
+func (c cycleDelegate) HandleSetTemperature(temperature *int16, level *uint8) error {
+	return c.locked()
+}
+
+// HandleSpinSpeed implements laundrywashercontrols.Delegate.
+func (c cycleDelegate) HandleSpinSpeed(index *uint8) error {
+	return c.locked()
+}
+
+// HandleNumberOfRinses implements laundrywashercontrols.Delegate.
+func (c cycleDelegate) HandleNumberOfRinses(rinses laundrywashercontrols.NumberOfRinses) error {
+	return c.locked()
+}
+
+// locked returns ErrInvalidInState while a cycle runs.
+func (c cycleDelegate) locked() error {
+	if c.a.running() {
+		return datamodel.ErrInvalidInState
+	}
+	return nil
+}
+
+func (c cycleDelegate) HandlePause() operationalstate.ErrorState  { return operationalstate.NoError }
+func (c cycleDelegate) HandleResume() operationalstate.ErrorState { return operationalstate.NoError }
+func (c cycleDelegate) HandleStop() operationalstate.ErrorState   { return operationalstate.NoError }
+
+// HandleStart implements operationalstate.Delegate.
+func (c cycleDelegate) HandleStart() operationalstate.ErrorState {
+	c.a.OperationalState.SetCurrentPhase(0)
+	return operationalstate.NoError
+}
+
+// dishwasherDelegate keeps the door shut while the dishwasher runs.
+type dishwasherDelegate struct {
+	cycleDelegate
+	alarm func() *alarmbase.Cluster
+}
+
+// HandleStart implements operationalstate.Delegate.
+func (d dishwasherDelegate) HandleStart() operationalstate.ErrorState {
+	if d.alarm().State()&alarmbase.DishwasherAlarmDoorError != 0 {
+		return operationalstate.ErrorState{ID: operationalstate.ErrorUnableToStartOrResume, Details: "door open"}
+	}
+	return d.cycleDelegate.HandleStart()
+}
+
+// HandleResume implements operationalstate.Delegate.
+func (d dishwasherDelegate) HandleResume() operationalstate.ErrorState {
+	if d.alarm().State()&alarmbase.DishwasherAlarmDoorError != 0 {
+		return operationalstate.ErrorState{ID: operationalstate.ErrorUnableToStartOrResume, Details: "door open"}
+	}
+	return operationalstate.NoError
+}
+
+// NewDevice creates a new appliance device with the given options.
+//
+// The device has:
+//   - Root Endpoint (0): Automatically created with required clusters
+//   - Laundry Washer Endpoint (1)
+//   - Dishwasher Endpoint (2)
+//   - Oven Cavity Endpoint (3)
+func NewDevice(opts common.Options) (*Device, error) {
+	// Apply appliance-specific defaults
+	if opts.DeviceName == "" || opts.DeviceName == "Matter Device" {
+		opts.DeviceName = "Matter Appliance"
+	}
+
+	node, err := common.CreateNode(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return newDevice(node)
+}
+
+// NewDeviceWithConfig creates a new appliance device with a custom Matter
+// config. This is useful for testing.
+func NewDeviceWithConfig(config matter.NodeConfig) (*Device, error) {
+	node, err := matter.NewNode(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return newDevice(node)
+}
+
+// newDevice creates the appliance clusters and adds their endpoints to node.
+func newDevice(node *matter.Node) (*Device, error) {
+	d := &Device{Node: node}
+
+	if err := d.newWasher(); err != nil {
+		return nil, err
+	}
+	if err := d.newDishwasher(); err != nil {
+		return nil, err
+	}
+	if err := d.newOven(); err != nil {
+		return nil, err
+	}
+
+	washerEP := matter.NewEndpoint(WasherEndpointID).
+		WithDeviceType(LaundryWasherDeviceType, 1).
+		AddCluster(d.Washer.Mode).
+		AddCluster(d.WasherControls).
+		AddCluster(d.Washer.Temperature).
+		AddCluster(d.Washer.OperationalState)
+
+	dishwasherEP := matter.NewEndpoint(DishwasherEndpointID).
+		WithDeviceType(DishwasherDeviceType, 1).
+		AddCluster(d.Dishwasher.Mode).
+		AddCluster(d.DishwasherAlarm).
+		AddCluster(d.Dishwasher.Temperature).
+		AddCluster(d.Dishwasher.OperationalState)
+
+	ovenEP := matter.NewEndpoint(OvenEndpointID).
+		WithDeviceType(CabinetDeviceType, 3).
+		AddCluster(d.Oven.Mode).
+		AddCluster(d.Oven.Temperature).
+		AddCluster(d.Oven.OperationalState)
+
+	for _, ep := range []*matter.Endpoint{washerEP, dishwasherEP, ovenEP} {
+		if err := node.AddEndpoint(ep); err != nil {
+			return nil, err
+		}
+	}
+
+	return d, nil
+}
+
+// logState returns an OnStateChange callback logging the named appliance.
+func logState(name string) func(datamodel.EndpointID, operationalstate.State) {
+	return func(_ datamodel.EndpointID, state operationalstate.State) {
+		log.Printf("%s operational state is now 0x%02X", name, uint8(state))
+	}
+}
+
+// newWasher creates the laundry washer clusters.
+func (d *Device) newWasher() error {
+	a := &d.Washer
+	delegate := cycleDelegate{a}
+	phases := []string{"Pre-wash", "Wash", "Rinse", "Spin"}
+	a.phases = len(phases)
+
+	var err error
+	a.Mode, err = modebase.New(modebase.Config{
+		EndpointID: WasherEndpointID,
+		Definition: modebase.LaundryWasherMode,
+		Modes: []modebase.ModeOption{
+			{Label: "Normal", Mode: WasherModeNormal, ModeTags: []modebase.ModeTag{{Value: modebase.LaundryWasherModeTagNormal}}},
+			{Label: "Delicate", Mode: WasherModeDelicate, ModeTags: []modebase.ModeTag{{Value: modebase.LaundryWasherModeTagDelicate}}},
+			{Label: "Heavy", Mode: WasherModeHeavy, ModeTags: []modebase.ModeTag{{Value: modebase.LaundryWasherModeTagHeavy}}},
+			{Label: "Whites", Mode: WasherModeWhites, ModeTags: []modebase.ModeTag{{Value: modebase.LaundryWasherModeTagWhites}}},
+		},
+		InitialMode: WasherModeNormal,
+		Delegate:    delegate,
+	})
+	if err != nil {
+		return err
+	}
+
+	spin := uint8(2)
+	d.WasherControls, err = laundrywashercontrols.New(laundrywashercontrols.Config{
+		EndpointID:       WasherEndpointID,
+		FeatureMap:       laundrywashercontrols.FeatureSpin | laundrywashercontrols.FeatureRinse,
+		SpinSpeeds:       []string{"Off", "800", "1200", "1400"},
+		InitialSpinSpeed: &spin,
+		SupportedRinses: []laundrywashercontrols.NumberOfRinses{
+			laundrywashercontrols.RinsesNormal, laundrywashercontrols.RinsesExtra, laundrywashercontrols.RinsesMax,
+		},
+		InitialRinses: laundrywashercontrols.RinsesNormal,
+		Delegate:      delegate,
+	})
+	if err != nil {
+		return err
+	}
+
+	a.Temperature, err = temperaturecontrol.New(temperaturecontrol.Config{
+		EndpointID:      WasherEndpointID,
+		FeatureMap:      temperaturecontrol.FeatureTemperatureLevel,
+		SupportedLevels: []string{"Cold", "30°C", "40°C", "60°C", "90°C"},
+		InitialLevel:    2,
+		Delegate:        delegate,
+	})
+	if err != nil {
+		return err
+	}
+
+	a.OperationalState, err = operationalstate.New(operationalstate.Config{
+		EndpointID:    WasherEndpointID,
+		PhaseList:     phases,
+		Delegate:      delegate,
+		OnStateChange: logState("Washer"),
+	})
+	return err
+}
+
+// newDishwasher creates the dishwasher clusters.
+func (d *Device) newDishwasher() error {
+	a := &d.Dishwasher
+	delegate := dishwasherDelegate{
+		cycleDelegate: cycleDelegate{a},
+		alarm:         func() *alarmbase.Cluster { return d.DishwasherAlarm },
+	}
+	phases := []string{"Pre-wash", "Main wash", "Rinse", "Drying"}
+	a.phases = len(phases)
+
+	var err error
+	a.Mode, err = modebase.New(modebase.Config{
+		EndpointID: DishwasherEndpointID,
+		Definition: modebase.DishwasherMode,
+		Modes: []modebase.ModeOption{
+			{Label: "Normal", Mode: DishwasherModeNormal, ModeTags: []modebase.ModeTag{{Value: modebase.DishwasherModeTagNormal}}},
+			{Label: "Heavy", Mode: DishwasherModeHeavy, ModeTags: []modebase.ModeTag{{Value: modebase.DishwasherModeTagHeavy}}},
+			{Label: "Light", Mode: DishwasherModeLight, ModeTags: []modebase.ModeTag{{Value: modebase.DishwasherModeTagLight}}},
+		},
+		InitialMode: DishwasherModeNormal,
+		Delegate:    delegate,
+	})
+	if err != nil {
+		return err
+	}
+
+	d.DishwasherAlarm, err = alarmbase.New(alarmbase.Config{
+		EndpointID:                DishwasherEndpointID,
+		Definition:                alarmbase.DishwasherAlarm,
+		FeatureMap:                alarmbase.FeatureReset,
+		Supported:                 alarmbase.DishwasherAlarm.Alarms,
+		Latch:                     alarmbase.DishwasherAlarmInflowError | alarmbase.DishwasherAlarmDrainError,
+		EnableModifyEnabledAlarms: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	a.Temperature, err = temperaturecontrol.New(temperaturecontrol.Config{
+		EndpointID:      DishwasherEndpointID,
+		FeatureMap:      temperaturecontrol.FeatureTemperatureNumber,
+		MinTemperature:  4500,
+		MaxTemperature:  7000,
+		InitialSetpoint: 5500,
+		Delegate:        delegate,
+	})
+	if err != nil {
+		return err
+	}
+
+	a.OperationalState, err = operationalstate.New(operationalstate.Config{
+		EndpointID:    DishwasherEndpointID,
+		PhaseList:     phases,
+		Delegate:      delegate,
+		OnStateChange: logState("Dishwasher"),
+	})
+	return err
+}
+
+// newOven creates the oven cavity clusters.
+func (d *Device) newOven() error {
+	a := &d.Oven
+	delegate := cycleDelegate{a}
+	phases := []string{"Pre-heating", "Cooking"}
+	a.phases = len(phases)
+
+	var err error
+	a.Mode, err = modebase.New(modebase.Config{
+		EndpointID: OvenEndpointID,
+		Definition: modebase.OvenMode,
+		Modes: []modebase.ModeOption{
+			{Label: "Bake", Mode: OvenModeBake, ModeTags: []modebase.ModeTag{{Value: modebase.OvenModeTagBake}}},
+			{Label: "Convection", Mode: OvenModeConvection, ModeTags: []modebase.ModeTag{{Value: modebase.OvenModeTagConvection}}},
+			{Label: "Grill", Mode: OvenModeGrill, ModeTags: []modebase.ModeTag{{Value: modebase.OvenModeTagGrill}}},
+		},
+		InitialMode: OvenModeBake,
+		Delegate:    delegate,
+	})
+	if err != nil {
+		return err
+	}
+
+	a.Temperature, err = temperaturecontrol.New(temperaturecontrol.Config{
+		EndpointID:      OvenEndpointID,
+		FeatureMap:      temperaturecontrol.FeatureTemperatureNumber | temperaturecontrol.FeatureTemperatureStep,
+		MinTemperature:  5000,
+		MaxTemperature:  25000,
+		Step:            500,
+		InitialSetpoint: 18000,
+		Delegate:        delegate,
+	})
+	if err != nil {
+		return err
+	}
+
+	a.OperationalState, err = operationalstate.New(operationalstate.Config{
+		EndpointID:    OvenEndpointID,
+		Definition:    operationalstate.OvenCavityOperationalState,
+		PhaseList:     phases,
+		Delegate:      delegate,
+		OnStateChange: logState("Oven"),
+	})
+	return err
+}
+
+// Advance simulates an appliance finishing its current phase. After the
+// last phase the cycle completes and the appliance stops. It does nothing
+// unless the appliance is running.
+func (d *Device) Advance(a *Appliance) error {
+	if a.OperationalState.OperationalState() != operationalstate.StateRunning {
+		return nil
+	}
+	next := 0
+	if p := a.OperationalState.CurrentPhase(); p != nil {
+		next = int(*p) + 1
+	}
+	if next >= a.phases {
+		return a.OperationalState.CompleteOperation(operationalstate.ErrorNoError, operationalstate.StateStopped)
+	}
+	return a.OperationalState.SetCurrentPhase(uint8(next))
+}
+
+// OpenDishwasherDoor simulates the dishwasher door opening: it raises the
+// door alarm and pauses a running cycle.
+func (d *Device) OpenDishwasherDoor() error {
+	if err := d.DishwasherAlarm.SetAlarm(alarmbase.DishwasherAlarmDoorError, true); err != nil {
+		return err
+	}
+	if d.Dishwasher.OperationalState.OperationalState() == operationalstate.StateRunning {
+		return d.Dishwasher.OperationalState.SetOperationalState(operationalstate.StatePaused)
+	}
+	return nil
+}
+
+// CloseDishwasherDoor simulates the dishwasher door closing. A paused
+// cycle stays paused until a controller resumes it.
+func (d *Device) CloseDishwasherDoor() error {
+	return d.DishwasherAlarm.SetAlarm(alarmbase.DishwasherAlarmDoorError, false)
+}
+
+// OnboardingPayload returns the QR code payload for commissioning.
+func (d *Device) OnboardingPayload() string {
+	return d.Node.OnboardingPayload()
+}
+
+// ManualPairingCode returns the manual pairing code for commissioning.
+func (d *Device) ManualPairingCode() string {
+	return d.Node.ManualPairingCode()
+}
+
+// GetNode returns the underlying Matter node.
+// Implements the TestDevice interface for integration testing.
+func (d *Device) GetNode() *matter.Node {
+	return d.Node
+}
+
+// Factory creates an appliance device from a Matter node config.
+// Use this with the test infrastructure:
+//
+//	pair := integration.NewTestPair(t, appliance.Factory)
+func Factory(config matter.NodeConfig) (*Device, error) {
+	return NewDeviceWithConfig(config)
+}
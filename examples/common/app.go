@@ -4,13 +4,16 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/backkem/matter/pkg/fabric"
 	"github.com/backkem/matter/pkg/matter"
-	"github.com/pion/logging"
+	"github.com/backkem/matter/pkg/message"
+	"github.com/backkem/matter/pkg/pcapng"
 )
 
 // CreateNode creates a Matter node from Options.
@@ -26,9 +29,6 @@ func CreateNode(opts Options) (*matter.Node, error) {
 		storage = matter.NewMemoryStorage()
 	}
 
-	// Create logger factory for diagnostics
-	loggerFactory := logging.NewDefaultLoggerFactory()
-
 	// Create node configuration
 	config := matter.NodeConfig{
 		VendorID:      fabric.VendorID(opts.VendorID),
@@ -38,7 +38,10 @@ func CreateNode(opts Options) (*matter.Node, error) {
 		Passcode:      opts.Passcode,
 		Port:          opts.Port,
 		Storage:       storage,
-		LoggerFactory: loggerFactory,
+		Logger:        slog.New(slog.NewTextHandler(os.Stderr, nil)),
+		LogLevels:     opts.LogLevels,
+
+		TestEventTriggerEnableKey: opts.EnableKey,
 
 		// Add callbacks for visibility
 		OnStateChanged: func(state matter.NodeState) {
@@ -46,6 +49,24 @@ func CreateNode(opts Options) (*matter.Node, error) {
 		},
 	}
 
+	// Capture frames, decrypted with the node's session keys
+	var node *matter.Node
+	if opts.CapturePath != "" {
+		f, err := os.Create(opts.CapturePath)
+		if err != nil {
+			return nil, fmt.Errorf("create capture: %w", err)
+		}
+		config.Tap, err = pcapng.NewWriter(f, pcapng.Config{
+			Decrypt: func(data []byte, outbound bool) (*message.Frame, error) {
+				return node.SessionManager().Open(data, outbound)
+			},
+		})
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("create capture: %w", err)
+		}
+	}
+
 	// Create node
 	node, err := matter.NewNode(config)
 	if err != nil {
@@ -82,9 +103,11 @@ func RunDevice(node *matter.Node) error {
 	// Wait for context cancellation (signal)
 	<-ctx.Done()
 
-	// Stop the node
+	// Stop the node, giving its peers a few seconds to hear CloseSession
 	log.Println("Shutting down...")
-	if err := node.Stop(); err != nil {
+	stopCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := node.Stop(stopCtx); err != nil {
 		return fmt.Errorf("stop node: %w", err)
 	}
 
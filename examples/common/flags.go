@@ -2,9 +2,12 @@
 package common
 
 import (
+	"encoding/hex"
 	"flag"
 	"fmt"
 	"os"
+
+	"github.com/backkem/matter/pkg/clusters/generaldiagnostics"
 )
 
 // Options holds standard CLI flags for Matter device examples.
@@ -30,6 +33,17 @@ type Options struct {
 
 	// ProductID is the product ID.
 	ProductID uint16
+
+	// LogLevels sets per-subsystem log levels, e.g. "warn,exchange=debug".
+	LogLevels string
+
+	// CapturePath is a pcapng file recording all frames.
+	// If empty, frames are not captured.
+	CapturePath string
+
+	// EnableKey is the 16-byte key a test harness presents with
+	// TestEventTrigger. If nil, test event triggers are disabled.
+	EnableKey []byte
 }
 
 // DefaultOptions returns Options with sensible defaults for testing.
@@ -54,6 +68,9 @@ func DefaultOptions() Options {
 //	-name          Device name (default: "Matter Device")
 //	-vendor        Vendor ID (default: 0xFFF1)
 //	-product       Product ID (default: 0x8001)
+//	-log           Log levels, e.g. "warn,exchange=debug" (default: info)
+//	-pcap          Write frames to a pcapng file (default: off)
+//	-enable-key    Test event trigger enable key, 32 hex digits (default: off)
 func ParseFlags() Options {
 	defaults := DefaultOptions()
 	o := Options{}
@@ -82,6 +99,19 @@ func ParseFlags() Options {
 	})
 	flag.StringVar(&o.StoragePath, "storage", "", "Path for persistent storage (empty = in-memory)")
 	flag.StringVar(&o.DeviceName, "name", defaults.DeviceName, "Device name")
+	flag.StringVar(&o.LogLevels, "log", "", `Log levels, e.g. "warn,exchange=debug,im=info"`)
+	flag.StringVar(&o.CapturePath, "pcap", "", "Write frames to a pcapng file")
+	flag.Func("enable-key", "Test event trigger enable key, 32 hex digits", func(s string) error {
+		key, err := hex.DecodeString(s)
+		if err != nil {
+			return err
+		}
+		if len(key) != generaldiagnostics.EnableKeySize {
+			return fmt.Errorf("enable key must be %d bytes, got %d", generaldiagnostics.EnableKeySize, len(key))
+		}
+		o.EnableKey = key
+		return nil
+	})
 	flag.Func("vendor", fmt.Sprintf("Vendor ID (default: 0x%04X)", defaults.VendorID), func(s string) error {
 		var v uint16
 		_, err := fmt.Sscanf(s, "%d", &v)
@@ -0,0 +1,222 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/backkem/matter/pkg/clusters/admincommissioning"
+	"github.com/backkem/matter/pkg/clusters/basic"
+	"github.com/backkem/matter/pkg/commissioning/payload"
+	"github.com/backkem/matter/pkg/im"
+	imsg "github.com/backkem/matter/pkg/im/message"
+	"github.com/backkem/matter/pkg/securechannel/pase"
+	"github.com/backkem/matter/pkg/session"
+	"github.com/backkem/matter/pkg/tlv"
+	"github.com/backkem/matter/pkg/transport"
+)
+
+// DefaultCommissioningWindowTimeout is the default duration of a
+// commissioning window opened for another commissioner.
+const DefaultCommissioningWindowTimeout = admincommissioning.MinCommissioningTimeout
+
+// ErrCommandFailed is returned when the device rejects a command.
+var ErrCommandFailed = errors.New("controller: command failed")
+
+// CommissioningWindow is a commissioning window opened on a device for
+// another commissioner. Hand its QRCode or ManualCode to that commissioner.
+type CommissioningWindow struct {
+	// Passcode is the one-time passcode accepted in the window.
+	Passcode uint32
+
+	// Discriminator is the discriminator the device advertises.
+	Discriminator uint16
+
+	// Timeout is how long the window stays open.
+	Timeout time.Duration
+
+	// QRCode is the onboarding payload as a QR code string.
+	QRCode string
+
+	// ManualCode is the onboarding payload as a manual pairing code.
+	ManualCode string
+}
+
+// OpenCommissioningWindow opens a commissioning window on a device this
+// controller administers, so a second commissioner can add it to its own
+// fabric (multi-admin).
+//
+// A fresh passcode and discriminator are generated, and the device only
+// receives the derived PAKE verifier through the Administrator
+// Commissioning cluster (Enhanced Commissioning Method). The returned
+// window carries the onboarding payload to hand to the other commissioner.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout
+//   - sess: Secure session to the device
+//   - peerAddr: Device network address
+//   - timeout: Window duration (180-900s); 0 selects the default
+func (c *Controller) OpenCommissioningWindow(
+	ctx context.Context,
+	sess *session.SecureContext,
+	peerAddr transport.PeerAddress,
+	timeout time.Duration,
+) (*CommissioningWindow, error) {
+	if timeout == 0 {
+		timeout = DefaultCommissioningWindowTimeout
+	}
+
+	client, err := c.imClient()
+	if err != nil {
+		return nil, err
+	}
+
+	// The device's identity goes into the QR code
+	vendorID, err := c.readUint(ctx, sess, peerAddr, uint32(basic.ClusterID), uint32(basic.AttrVendorID))
+	if err != nil {
+		return nil, err
+	}
+	productID, err := c.readUint(ctx, sess, peerAddr, uint32(basic.ClusterID), uint32(basic.AttrProductID))
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate the window's PAKE parameters
+	passcode, err := payload.GeneratePasscode()
+	if err != nil {
+		return nil, err
+	}
+	pbkdf, err := payload.DefaultPBKDFParams()
+	if err != nil {
+		return nil, err
+	}
+	var buf [2]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return nil, err
+	}
+	discriminator := binary.LittleEndian.Uint16(buf[:]) & admincommissioning.MaxDiscriminator
+
+	verifier, err := pase.GenerateVerifier(passcode, pbkdf.Salt, pbkdf.Iterations)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := admincommissioning.EncodeOpenCommissioningWindowRequest(&admincommissioning.OpenCommissioningWindowRequest{
+		CommissioningTimeout: uint16(timeout / time.Second),
+		PAKEPasscodeVerifier: verifier.Serialize(),
+		Discriminator:        discriminator,
+		Iterations:           pbkdf.Iterations,
+		Salt:                 pbkdf.Salt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := client.TimedInvokeWithStatus(ctx, sess, peerAddr, 0,
+		uint32(admincommissioning.ClusterID), uint32(admincommissioning.CmdOpenCommissioningWindow), req, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkStatus(result); err != nil {
+		return nil, err
+	}
+
+	p := payload.SetupPayload{
+		VendorID:                 uint16(vendorID),
+		ProductID:                uint16(productID),
+		CommissioningFlow:        payload.CommissioningFlowStandard,
+		DiscoveryCapabilities:    payload.DiscoveryCapabilityOnNetwork,
+		HasDiscoveryCapabilities: true,
+		Discriminator:            payload.NewLongDiscriminator(discriminator),
+		Passcode:                 passcode,
+	}
+	qr, err := payload.EncodeQRCode(&p)
+	if err != nil {
+		return nil, err
+	}
+	manual, err := payload.EncodeManualCode(&p)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CommissioningWindow{
+		Passcode:      passcode,
+		Discriminator: discriminator,
+		Timeout:       timeout,
+		QRCode:        qr,
+		ManualCode:    manual,
+	}, nil
+}
+
+// RevokeCommissioning closes a commissioning window opened on the device.
+func (c *Controller) RevokeCommissioning(
+	ctx context.Context,
+	sess *session.SecureContext,
+	peerAddr transport.PeerAddress,
+) error {
+	client, err := c.imClient()
+	if err != nil {
+		return err
+	}
+
+	result, err := client.TimedInvokeWithStatus(ctx, sess, peerAddr, 0,
+		uint32(admincommissioning.ClusterID), uint32(admincommissioning.CmdRevokeCommissioning), nil, 0)
+	if err != nil {
+		return err
+	}
+	return checkStatus(result)
+}
+
+// imClient returns an IM client over the controller's exchange manager.
+func (c *Controller) imClient() (*im.Client, error) {
+	c.mu.RLock()
+	started := c.started
+	c.mu.RUnlock()
+	if !started {
+		return nil, ErrNotStarted
+	}
+
+	exchMgr := c.node.ExchangeManager()
+	if exchMgr == nil {
+		return nil, errors.New("controller: exchange manager not available")
+	}
+
+	return im.NewClient(im.ClientConfig{
+		ExchangeManager: exchMgr,
+		LoggerFactory:   c.node.LoggerFactory(),
+	}), nil
+}
+
+// checkStatus returns ErrCommandFailed if an invoke returned a failure status.
+func checkStatus(result *im.InvokeResult) error {
+	if !result.HasStatus || result.Status == imsg.StatusSuccess {
+		return nil
+	}
+	if result.ClusterStatus != nil {
+		return fmt.Errorf("%w: %s (cluster status 0x%02x)", ErrCommandFailed, result.Status, *result.ClusterStatus)
+	}
+	return fmt.Errorf("%w: %s", ErrCommandFailed, result.Status)
+}
+
+// readUint reads an unsigned integer attribute from the root endpoint.
+func (c *Controller) readUint(
+	ctx context.Context,
+	sess *session.SecureContext,
+	peerAddr transport.PeerAddress,
+	clusterID uint32,
+	attributeID uint32,
+) (uint64, error) {
+	data, err := c.ReadAttribute(ctx, sess, peerAddr, 0, clusterID, attributeID)
+	if err != nil {
+		return 0, err
+	}
+	r := tlv.NewReader(bytes.NewReader(data))
+	if err := r.Next(); err != nil {
+		return 0, err
+	}
+	return r.Uint()
+}
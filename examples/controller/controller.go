@@ -1,7 +1,7 @@
 // Package controller implements a Matter controller/commissioner.
 //
 // This package can be imported directly for testing or compiled
-// as part of a binary (see cmd/matter-controller).
+// as part of a binary (see cmd/matter-tool).
 //
 // Example usage:
 //
@@ -181,7 +181,7 @@ func (c *Controller) Stop() error {
 	}
 
 	if c.node != nil {
-		if err := c.node.Stop(); err != nil {
+		if err := c.node.Stop(context.Background()); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,184 @@
+package controller
+
+import (
+	"context"
+	"errors"
+
+	"github.com/backkem/matter/pkg/crypto"
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/im"
+	"github.com/backkem/matter/pkg/session"
+	"github.com/backkem/matter/pkg/transport"
+)
+
+// ErrNoFabric is returned when the controller has no fabric, or not the
+// one a group is on.
+var ErrNoFabric = errors.New("controller: fabric not found")
+
+// Group is a group of devices on one of the controller's fabrics.
+//
+// The members must already know the group: its ID mapped to a key set in
+// their Group Key Management cluster and their endpoints added to it
+// through the Groups cluster.
+type Group struct {
+	// GroupID identifies the group on its fabric.
+	GroupID uint16
+
+	// EpochKey is the current epoch key of the group's key set (16 bytes).
+	EpochKey []byte
+
+	// FabricIndex is the controller's fabric the group is on. If 0, the
+	// controller's only fabric.
+	FabricIndex fabric.FabricIndex
+
+	// PeerAddr overrides the group's IPv6 multicast address, e.g. on
+	// networks without multicast routing.
+	PeerAddr *transport.PeerAddress
+}
+
+// GroupMember is a member of a group reached by unicast, to confirm a
+// group command or read group state.
+type GroupMember struct {
+	Session  *session.SecureContext
+	PeerAddr transport.PeerAddress
+
+	// Endpoint is the member's endpoint in the group.
+	Endpoint uint16
+}
+
+// GroupMemberResult is the outcome of a command or read on one member.
+type GroupMemberResult struct {
+	Member GroupMember
+
+	// Result is the command result. Nil for reads.
+	Result *im.InvokeResult
+
+	// Data is the TLV-encoded attribute value. Nil for commands.
+	Data []byte
+
+	// Err is the error of the command or read, if any.
+	Err error
+}
+
+// GroupInvoke sends a command to a group.
+//
+// Without members, the command is sent once to the group's multicast
+// address. Group commands are never confirmed, so GroupInvoke returns as
+// soon as the message is sent, with no results.
+//
+// With members, the command is sent by unicast to each member instead,
+// as a fallback where multicast is unavailable or confirmation is needed.
+// A result is returned per member; the error is only set if the command
+// could not be sent at all.
+//
+// Spec: Section 8.9.2 (group invoke)
+func (c *Controller) GroupInvoke(
+	ctx context.Context,
+	group Group,
+	clusterID uint32,
+	commandID uint32,
+	requestData []byte,
+	members ...GroupMember,
+) ([]GroupMemberResult, error) {
+	client, err := c.imClient()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(members) > 0 {
+		results := make([]GroupMemberResult, len(members))
+		for i, m := range members {
+			results[i].Member = m
+			results[i].Result, results[i].Err = client.InvokeWithStatus(
+				ctx, m.Session, m.PeerAddr, m.Endpoint, clusterID, commandID, requestData)
+			if results[i].Err == nil {
+				results[i].Err = checkStatus(results[i].Result)
+			}
+		}
+		return results, nil
+	}
+
+	groupCtx, peerAddr, err := c.groupContext(group)
+	if err != nil {
+		return nil, err
+	}
+	return nil, client.GroupInvoke(ctx, groupCtx, peerAddr, clusterID, commandID, requestData)
+}
+
+// ReadGroupAttribute reads an attribute from each member of a group.
+// Reads cannot be sent to a group, only to its members one by one.
+//
+// Spec: Section 8.4.3 (reads are unicast)
+func (c *Controller) ReadGroupAttribute(
+	ctx context.Context,
+	members []GroupMember,
+	clusterID uint32,
+	attributeID uint32,
+) ([]GroupMemberResult, error) {
+	client, err := c.imClient()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]GroupMemberResult, len(members))
+	for i, m := range members {
+		results[i].Member = m
+		results[i].Data, results[i].Err = client.ReadAttribute(
+			ctx, m.Session, m.PeerAddr, m.Endpoint, clusterID, attributeID)
+	}
+	return results, nil
+}
+
+// groupContext creates the context to send messages to a group from the
+// controller, with the group's peer address.
+func (c *Controller) groupContext(group Group) (*session.GroupContext, transport.PeerAddress, error) {
+	info, err := c.groupFabric(group.FabricIndex)
+	if err != nil {
+		return nil, transport.PeerAddress{}, err
+	}
+
+	// Spec 4.17.2: operational group key and session ID
+	key, err := crypto.DeriveGroupOperationalKeyV1(group.EpochKey, info.CompressedFabricID[:])
+	if err != nil {
+		return nil, transport.PeerAddress{}, err
+	}
+	sessionID, err := crypto.DeriveGroupSessionIDV1(key)
+	if err != nil {
+		return nil, transport.PeerAddress{}, err
+	}
+
+	groupCtx, err := session.NewGroupContext(session.GroupContextConfig{
+		SourceNodeID:   info.NodeID,
+		FabricIndex:    info.FabricIndex,
+		GroupID:        group.GroupID,
+		GroupSessionID: sessionID,
+		OperationalKey: key,
+		Counter:        c.node.SessionManager().GroupDataCounter(),
+	})
+	if err != nil {
+		return nil, transport.PeerAddress{}, err
+	}
+
+	peerAddr := transport.NewGroupPeerAddress(uint64(info.FabricID), group.GroupID)
+	if group.PeerAddr != nil {
+		peerAddr = *group.PeerAddr
+	}
+	return groupCtx, peerAddr, nil
+}
+
+// groupFabric returns the controller's fabric of a group.
+func (c *Controller) groupFabric(index fabric.FabricIndex) (*fabric.FabricInfo, error) {
+	fabrics := c.node.Fabrics()
+	if index == 0 {
+		if len(fabrics) != 1 {
+			return nil, ErrNoFabric
+		}
+		return fabrics[0], nil
+	}
+	for _, info := range fabrics {
+		if info.FabricIndex == index {
+			return info, nil
+		}
+	}
+	return nil, ErrNoFabric
+}
@@ -0,0 +1,243 @@
+// Package evse implements a Matter EV charger (Electric Vehicle Supply
+// Equipment) device.
+//
+// The device exposes Energy EVSE together with Electrical Power and Energy
+// Measurement on one endpoint. A controller enables or disables charging;
+// the simulated vehicle side is driven through PlugIn, Unplug and Meter.
+//
+// This package can be imported directly for testing or compiled as part
+// of a binary (see cmd/matter-evse-device).
+//
+// Example usage:
+//
+//	opts := common.DefaultOptions()
+//	device, _ := evse.NewDevice(opts)
+//	device.Node.Start(ctx)
+//	...
+//	device.PlugIn() // simulate a vehicle asking for energy
+package evse
+
+import (
+	"log"
+	"time"
+
+	"github.com/backkem/matter/examples/common"
+	"github.com/backkem/matter/pkg/clusters"
+	"github.com/backkem/matter/pkg/clusters/electricalenergymeasurement"
+	"github.com/backkem/matter/pkg/clusters/electricalpowermeasurement"
+	"github.com/backkem/matter/pkg/clusters/energyevse"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/matter"
+)
+
+// DeviceType constants for EVSE.
+const (
+	// EVSEDeviceType is the device type for EVSE (0x050C).
+	EVSEDeviceType uint32 = 0x050C
+
+	// ElectricalSensorDeviceType is the device type for Electrical Sensor (0x0510).
+	ElectricalSensorDeviceType uint32 = 0x0510
+
+	// EVSEEndpointID is the endpoint ID for the charger.
+	EVSEEndpointID datamodel.EndpointID = 1
+
+	// CircuitCapacity is the simulated supply capacity (32 A), in mA.
+	CircuitCapacity int64 = 32000
+
+	// maxPower is the charger's maximum power (32 A at 230 V), in mW.
+	maxPower int64 = 7_360_000
+)
+
+// Device represents an EV charger device.
+type Device struct {
+	// Node is the underlying Matter node.
+	Node *matter.Node
+
+	// EVSE is the Energy EVSE cluster instance.
+	EVSE *energyevse.Cluster
+
+	// Power is the Electrical Power Measurement cluster instance.
+	Power *electricalpowermeasurement.Cluster
+
+	// Energy is the Electrical Energy Measurement cluster instance.
+	Energy *electricalenergymeasurement.Cluster
+
+	// imported is the cumulative imported energy in mWh.
+	imported int64
+}
+
+// chargerDelegate logs supply changes.
+type chargerDelegate struct{}
+
+// HandleEnableCharging implements energyevse.Delegate.
+func (chargerDelegate) HandleEnableCharging(minimumCurrent, maximumCurrent int64) error {
+	log.Printf("EVSE charging enabled: %d-%d mA", minimumCurrent, maximumCurrent)
+	return nil
+}
+
+// HandleDisable implements energyevse.Delegate.
+func (chargerDelegate) HandleDisable() error {
+	log.Printf("EVSE charging disabled")
+	return nil
+}
+
+// NewDevice creates a new EV charger device with the given options.
+//
+// The device has:
+//   - Root Endpoint (0): Automatically created with required clusters
+//   - EVSE Endpoint (1): Energy EVSE, Electrical Power Measurement,
+//     Electrical Energy Measurement
+func NewDevice(opts common.Options) (*Device, error) {
+	// Apply EVSE-specific defaults
+	if opts.DeviceName == "" || opts.DeviceName == "Matter Device" {
+		opts.DeviceName = "Matter EV Charger"
+	}
+
+	node, err := common.CreateNode(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return newDevice(node)
+}
+
+// NewDeviceWithConfig creates a new EV charger device with a custom Matter
+// config. This is useful for testing.
+func NewDeviceWithConfig(config matter.NodeConfig) (*Device, error) {
+	node, err := matter.NewNode(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return newDevice(node)
+}
+
+// accuracy returns a 1% accuracy entry for the range [min, max].
+func accuracy(t clusters.MeasurementType, min, max int64) clusters.MeasurementAccuracy {
+	onePercent := uint16(100)
+	return clusters.MeasurementAccuracy{
+		MeasurementType:  t,
+		Measured:         true,
+		MinMeasuredValue: min,
+		MaxMeasuredValue: max,
+		AccuracyRanges: []clusters.MeasurementAccuracyRange{
+			{RangeMin: min, RangeMax: max, PercentMax: &onePercent},
+		},
+	}
+}
+
+// newDevice creates the charger clusters and adds the EVSE endpoint to node.
+func newDevice(node *matter.Node) (*Device, error) {
+	evse, err := energyevse.New(energyevse.Config{
+		EndpointID:      EVSEEndpointID,
+		CircuitCapacity: CircuitCapacity,
+		Delegate:        chargerDelegate{},
+		OnStateChange: func(_ datamodel.EndpointID, state energyevse.State) {
+			log.Printf("EVSE state is now %d", state)
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	power, err := electricalpowermeasurement.New(electricalpowermeasurement.Config{
+		EndpointID: EVSEEndpointID,
+		FeatureMap: electricalpowermeasurement.FeatureAlternatingCurrent,
+		Accuracy: []clusters.MeasurementAccuracy{
+			accuracy(clusters.MeasurementTypeActivePower, 0, maxPower),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	energy, err := electricalenergymeasurement.New(electricalenergymeasurement.Config{
+		EndpointID: EVSEEndpointID,
+		FeatureMap: electricalenergymeasurement.FeatureImportedEnergy | electricalenergymeasurement.FeatureCumulativeEnergy,
+		Accuracy:   accuracy(clusters.MeasurementTypeElectricalEnergy, 0, 1<<50),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	evseEP := matter.NewEndpoint(EVSEEndpointID).
+		WithDeviceType(EVSEDeviceType, 2).
+		WithDeviceType(ElectricalSensorDeviceType, 1).
+		AddCluster(evse).
+		AddCluster(power).
+		AddCluster(energy)
+
+	if err := node.AddEndpoint(evseEP); err != nil {
+		return nil, err
+	}
+
+	return &Device{
+		Node:   node,
+		EVSE:   evse,
+		Power:  power,
+		Energy: energy,
+	}, nil
+}
+
+// PlugIn simulates a vehicle being plugged in and asking for energy.
+func (d *Device) PlugIn() error {
+	if err := d.EVSE.PlugIn(); err != nil {
+		return err
+	}
+	return d.EVSE.SetEVDemand(true)
+}
+
+// Unplug simulates the vehicle being unplugged.
+func (d *Device) Unplug() error {
+	zero := int64(0)
+	if err := d.Power.SetActivePower(&zero); err != nil {
+		return err
+	}
+	return d.EVSE.Unplug()
+}
+
+// Meter simulates one metering period: while charging, the vehicle draws
+// the offered current at 230 V for the given period, otherwise nothing.
+func (d *Device) Meter(period time.Duration) error {
+	var power int64
+	if d.EVSE.State() == energyevse.StatePluggedInCharging {
+		power = d.EVSE.MaximumChargeCurrent() * 230 // mA * V = mW
+	}
+	if err := d.Power.SetActivePower(&power); err != nil {
+		return err
+	}
+
+	energy := power * int64(period) / int64(time.Hour) // mWh
+	if energy == 0 {
+		return nil
+	}
+	if err := d.EVSE.AddSessionEnergy(energy); err != nil {
+		return err
+	}
+	d.imported += energy
+	return d.Energy.ReportCumulativeEnergy(&electricalenergymeasurement.EnergyMeasurement{Energy: d.imported}, nil)
+}
+
+// OnboardingPayload returns the QR code payload for commissioning.
+func (d *Device) OnboardingPayload() string {
+	return d.Node.OnboardingPayload()
+}
+
+// ManualPairingCode returns the manual pairing code for commissioning.
+func (d *Device) ManualPairingCode() string {
+	return d.Node.ManualPairingCode()
+}
+
+// GetNode returns the underlying Matter node.
+// Implements the TestDevice interface for integration testing.
+func (d *Device) GetNode() *matter.Node {
+	return d.Node
+}
+
+// Factory creates an EVSE device from a Matter node config.
+// Use this with the test infrastructure:
+//
+//	pair := integration.NewTestPair(t, evse.Factory)
+func Factory(config matter.NodeConfig) (*Device, error) {
+	return NewDeviceWithConfig(config)
+}
@@ -0,0 +1,314 @@
+// Package rvc implements a Matter Robotic Vacuum Cleaner device.
+//
+// The device composes RVC Run Mode, RVC Clean Mode, RVC Operational State
+// and Service Area on one endpoint and ties them together the way a robot
+// does: switching the run mode to Cleaning starts the robot, GoHome sends
+// it back to the dock, and the clean mode and room selection can only
+// change while idle.
+//
+// Example usage:
+//
+//	opts := common.DefaultOptions()
+//	device, _ := rvc.NewDevice(opts)
+//	device.Node.Start(ctx)
+//	...
+//	device.Dock() // simulate reaching the dock
+package rvc
+
+import (
+	"log"
+
+	"github.com/backkem/matter/examples/common"
+	"github.com/backkem/matter/pkg/clusters/modebase"
+	"github.com/backkem/matter/pkg/clusters/operationalstate"
+	"github.com/backkem/matter/pkg/clusters/servicearea"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/matter"
+)
+
+// DeviceType constants for Robotic Vacuum Cleaner.
+const (
+	// RVCDeviceType is the device type for Robotic Vacuum Cleaner (0x0074).
+	RVCDeviceType uint32 = 0x0074
+
+	// RVCEndpointID is the endpoint ID for the robot.
+	RVCEndpointID datamodel.EndpointID = 1
+)
+
+// Run modes.
+const (
+	RunModeIdle     uint8 = 0
+	RunModeCleaning uint8 = 1
+	RunModeMapping  uint8 = 2
+)
+
+// Clean modes.
+const (
+	CleanModeVacuum    uint8 = 0
+	CleanModeMop       uint8 = 1
+	CleanModeDeepClean uint8 = 2
+)
+
+// Rooms (Service Area IDs).
+const (
+	AreaKitchen    uint32 = 1
+	AreaLivingRoom uint32 = 2
+	AreaBedroom    uint32 = 3
+)
+
+// Device represents a Robotic Vacuum Cleaner device.
+type Device struct {
+	// Node is the underlying Matter node.
+	Node *matter.Node
+
+	// RunMode is the RVC Run Mode cluster instance.
+	RunMode *modebase.Cluster
+
+	// CleanMode is the RVC Clean Mode cluster instance.
+	CleanMode *modebase.Cluster
+
+	// OperationalState is the RVC Operational State cluster instance.
+	OperationalState *operationalstate.Cluster
+
+	// ServiceArea is the Service Area cluster instance.
+	ServiceArea *servicearea.Cluster
+}
+
+// runModeDelegate starts and stops the robot on run mode changes.
+type runModeDelegate struct{ d *Device }
+
+// HandleChangeToMode implements modebase.Delegate.
+func (r runModeDelegate) HandleChangeToMode(newMode uint8) (modebase.ChangeStatus, string) {
+	state := r.d.OperationalState.OperationalState()
+
+	if newMode == RunModeIdle {
+		// Stop working and head back to the dock.
+		if state == operationalstate.StateRunning || state == operationalstate.StatePaused {
+			r.d.OperationalState.SetOperationalState(operationalstate.RVCStateSeekingCharger)
+		}
+		return modebase.ChangeStatusSuccess, ""
+	}
+
+	// Cleaning and mapping only start from idle.
+	if r.d.RunMode.CurrentMode() != RunModeIdle {
+		return modebase.ChangeStatusInvalidInMode, "switch to idle first"
+	}
+	switch state {
+	case operationalstate.StateStopped, operationalstate.RVCStateDocked, operationalstate.RVCStateCharging:
+	default:
+		return modebase.ChangeStatusInvalidInMode, "robot is busy"
+	}
+	r.d.OperationalState.SetOperationalState(operationalstate.StateRunning)
+	return modebase.ChangeStatusSuccess, ""
+}
+
+// cleanModeDelegate rejects clean mode changes while the robot is working.
+type cleanModeDelegate struct{ d *Device }
+
+// HandleChangeToMode implements modebase.Delegate.
+func (c cleanModeDelegate) HandleChangeToMode(newMode uint8) (modebase.ChangeStatus, string) {
+	if c.d.RunMode.CurrentMode() != RunModeIdle {
+		return modebase.RVCCleanStatusCleaningInProgress, ""
+	}
+	return modebase.ChangeStatusSuccess, ""
+}
+
+// opStateDelegate accepts Pause, Resume and GoHome.
+type opStateDelegate struct{ d *Device }
+
+func (o opStateDelegate) HandlePause() operationalstate.ErrorState  { return operationalstate.NoError }
+func (o opStateDelegate) HandleResume() operationalstate.ErrorState { return operationalstate.NoError }
+func (o opStateDelegate) HandleStart() operationalstate.ErrorState  { return operationalstate.NoError }
+func (o opStateDelegate) HandleStop() operationalstate.ErrorState   { return operationalstate.NoError }
+
+// HandleGoHome implements operationalstate.GoHomeDelegate.
+func (o opStateDelegate) HandleGoHome() operationalstate.ErrorState {
+	o.d.RunMode.UpdateCurrentMode(RunModeIdle)
+	return operationalstate.NoError
+}
+
+// serviceAreaDelegate only changes the room selection while idle and
+// only skips rooms while cleaning.
+type serviceAreaDelegate struct{ d *Device }
+
+// HandleSelectAreas implements servicearea.Delegate.
+func (s serviceAreaDelegate) HandleSelectAreas(areas []uint32) (servicearea.SelectAreasStatus, string) {
+	if s.d.RunMode.CurrentMode() != RunModeIdle {
+		return servicearea.SelectAreasStatusInvalidInMode, "robot is busy"
+	}
+	return servicearea.SelectAreasStatusSuccess, ""
+}
+
+// HandleSkipArea implements servicearea.Delegate.
+func (s serviceAreaDelegate) HandleSkipArea(areaID uint32) (servicearea.SkipAreaStatus, string) {
+	if s.d.OperationalState.OperationalState() != operationalstate.StateRunning {
+		return servicearea.SkipAreaStatusInvalidInMode, "robot is not cleaning"
+	}
+	log.Printf("RVC skipping area %d", areaID)
+	return servicearea.SkipAreaStatusSuccess, ""
+}
+
+// NewDevice creates a new Robotic Vacuum Cleaner device with the given options.
+//
+// The device has:
+//   - Root Endpoint (0): Automatically created with required clusters
+//   - RVC Endpoint (1): RVC Run Mode, RVC Clean Mode, RVC Operational State,
+//     Service Area
+func NewDevice(opts common.Options) (*Device, error) {
+	// Apply RVC-specific defaults
+	if opts.DeviceName == "" || opts.DeviceName == "Matter Device" {
+		opts.DeviceName = "Matter Robot Vacuum"
+	}
+
+	node, err := common.CreateNode(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return newDevice(node)
+}
+
+// NewDeviceWithConfig creates a new Robotic Vacuum Cleaner device with a
+// custom Matter config. This is useful for testing.
+func NewDeviceWithConfig(config matter.NodeConfig) (*Device, error) {
+	node, err := matter.NewNode(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return newDevice(node)
+}
+
+// newDevice creates the RVC clusters and adds the RVC endpoint to node.
+func newDevice(node *matter.Node) (*Device, error) {
+	d := &Device{Node: node}
+
+	runMode, err := modebase.New(modebase.Config{
+		EndpointID: RVCEndpointID,
+		Definition: modebase.RVCRunMode,
+		Modes: []modebase.ModeOption{
+			{Label: "Idle", Mode: RunModeIdle, ModeTags: []modebase.ModeTag{{Value: modebase.RVCRunModeTagIdle}}},
+			{Label: "Cleaning", Mode: RunModeCleaning, ModeTags: []modebase.ModeTag{{Value: modebase.RVCRunModeTagCleaning}}},
+			{Label: "Mapping", Mode: RunModeMapping, ModeTags: []modebase.ModeTag{{Value: modebase.RVCRunModeTagMapping}}},
+		},
+		InitialMode: RunModeIdle,
+		Delegate:    runModeDelegate{d},
+		OnModeChange: func(_ datamodel.EndpointID, mode uint8) {
+			log.Printf("RVC run mode is now %d", mode)
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cleanMode, err := modebase.New(modebase.Config{
+		EndpointID: RVCEndpointID,
+		Definition: modebase.RVCCleanMode,
+		Modes: []modebase.ModeOption{
+			{Label: "Vacuum", Mode: CleanModeVacuum, ModeTags: []modebase.ModeTag{{Value: modebase.RVCCleanModeTagVacuum}}},
+			{Label: "Mop", Mode: CleanModeMop, ModeTags: []modebase.ModeTag{{Value: modebase.RVCCleanModeTagMop}}},
+			{Label: "Deep Clean", Mode: CleanModeDeepClean, ModeTags: []modebase.ModeTag{
+				{Value: modebase.RVCCleanModeTagDeepClean},
+				{Value: modebase.RVCCleanModeTagVacuum},
+				{Value: modebase.RVCCleanModeTagMop},
+			}},
+		},
+		InitialMode: CleanModeVacuum,
+		Delegate:    cleanModeDelegate{d},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	opState, err := operationalstate.New(operationalstate.Config{
+		EndpointID:   RVCEndpointID,
+		Definition:   operationalstate.RVCOperationalState,
+		InitialState: operationalstate.RVCStateDocked,
+		Delegate:     opStateDelegate{d},
+		OnStateChange: func(_ datamodel.EndpointID, state operationalstate.State) {
+			log.Printf("RVC operational state is now 0x%02X", uint8(state))
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	room := func(id uint32, name string) servicearea.Area {
+		return servicearea.Area{AreaID: id, AreaInfo: servicearea.AreaInfo{
+			LocationInfo: &servicearea.LocationDescriptor{LocationName: name},
+		}}
+	}
+	serviceArea, err := servicearea.New(servicearea.Config{
+		EndpointID: RVCEndpointID,
+		SupportedAreas: []servicearea.Area{
+			room(AreaKitchen, "Kitchen"),
+			room(AreaLivingRoom, "Living Room"),
+			room(AreaBedroom, "Bedroom"),
+		},
+		EnableCurrentArea: true,
+		EnableSkipArea:    true,
+		Delegate:          serviceAreaDelegate{d},
+		OnSelectedAreasChange: func(_ datamodel.EndpointID, areas []uint32) {
+			log.Printf("RVC selected areas are now %v", areas)
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	d.RunMode = runMode
+	d.CleanMode = cleanMode
+	d.OperationalState = opState
+	d.ServiceArea = serviceArea
+
+	rvcEP := matter.NewEndpoint(RVCEndpointID).
+		WithDeviceType(RVCDeviceType, 3).
+		AddCluster(runMode).
+		AddCluster(cleanMode).
+		AddCluster(opState).
+		AddCluster(serviceArea)
+
+	if err := node.AddEndpoint(rvcEP); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// Dock simulates the robot reaching its dock: the operation completes and
+// the robot goes idle.
+func (d *Device) Dock() error {
+	if err := d.RunMode.UpdateCurrentMode(RunModeIdle); err != nil {
+		return err
+	}
+	return d.OperationalState.CompleteOperation(operationalstate.ErrorNoError, operationalstate.RVCStateDocked)
+}
+
+// ReportError simulates a robot fault such as a full dust bin.
+func (d *Device) ReportError(id operationalstate.ErrorStateID, details string) error {
+	return d.OperationalState.SetError(operationalstate.ErrorState{ID: id, Details: details})
+}
+
+// OnboardingPayload returns the QR code payload for commissioning.
+func (d *Device) OnboardingPayload() string {
+	return d.Node.OnboardingPayload()
+}
+
+// ManualPairingCode returns the manual pairing code for commissioning.
+func (d *Device) ManualPairingCode() string {
+	return d.Node.ManualPairingCode()
+}
+
+// GetNode returns the underlying Matter node.
+// Implements the TestDevice interface for integration testing.
+func (d *Device) GetNode() *matter.Node {
+	return d.Node
+}
+
+// Factory creates an RVC device from a Matter node config.
+// Use this with the test infrastructure:
+//
+//	pair := integration.NewTestPair(t, rvc.Factory)
+func Factory(config matter.NodeConfig) (*Device, error) {
+	return NewDeviceWithConfig(config)
+}
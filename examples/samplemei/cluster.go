@@ -0,0 +1,211 @@
+package samplemei
+
+import (
+	"context"
+	"sync"
+
+	"github.com/backkem/matter/pkg/clusters"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// VendorID is the vendor prefix of the cluster: Test Vendor 1.
+const VendorID uint16 = 0xFFF1
+
+// Cluster constants. A manufacturer-specific cluster ID carries the vendor
+// ID in its upper 16 bits and a suffix in 0xFC00-0xFFFE; see
+// datamodel.ManufacturerClusterID.
+const (
+	ClusterID       datamodel.ClusterID = 0xFFF1FC20
+	ClusterRevision uint16              = 1
+)
+
+// Attribute IDs. Attributes of a manufacturer-specific cluster may have
+// standard IDs; vendor-prefixed IDs, as on a standard cluster, cannot
+// clash with attributes the specification adds later.
+const (
+	AttrFlipFlop  datamodel.AttributeID = 0x0000
+	AttrPingCount datamodel.AttributeID = 0xFFF10000
+)
+
+// Command IDs.
+const (
+	CmdAddArguments         datamodel.CommandID = 0x00
+	CmdAddArgumentsResponse datamodel.CommandID = 0x01
+	CmdPing                 datamodel.CommandID = 0xFFF10002
+)
+
+// Cluster implements the Sample MEI cluster, a manufacturer-specific
+// cluster with:
+//   - FlipFlop (0x0000): a writable boolean
+//   - PingCount (0xFFF10000): the number of Ping commands received
+//   - AddArguments (0x00): returns the sum of two uint8 arguments in
+//     AddArgumentsResponse (0x01)
+//   - Ping (0xFFF10002): counts pings
+type Cluster struct {
+	*datamodel.ClusterBase
+
+	mu        sync.RWMutex
+	flipFlop  bool
+	pingCount uint32
+}
+
+// NewCluster creates a Sample MEI cluster on an endpoint.
+func NewCluster(endpointID datamodel.EndpointID) *Cluster {
+	return &Cluster{
+		ClusterBase: datamodel.NewClusterBase(ClusterID, endpointID, ClusterRevision),
+	}
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+	operatePriv := datamodel.PrivilegeOperate
+
+	return datamodel.MergeAttributeLists([]datamodel.AttributeEntry{
+		datamodel.NewReadWriteAttribute(AttrFlipFlop, 0, viewPriv, operatePriv),
+		datamodel.NewReadOnlyAttribute(AttrPingCount, 0, viewPriv),
+	})
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	return []datamodel.CommandEntry{
+		datamodel.NewCommandEntry(CmdAddArguments, 0, datamodel.PrivilegeOperate),
+		datamodel.NewCommandEntry(CmdPing, 0, datamodel.PrivilegeOperate),
+	}
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return []datamodel.CommandID{CmdAddArgumentsResponse}
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.AttributeList(), c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	switch req.Path.Attribute {
+	case AttrFlipFlop:
+		return w.PutBool(tlv.Anonymous(), c.flipFlop)
+	case AttrPingCount:
+		return w.PutUint(tlv.Anonymous(), uint64(c.pingCount))
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	if req.Path.Attribute != AttrFlipFlop {
+		return datamodel.ErrUnsupportedWrite
+	}
+	if err := r.Next(); err != nil {
+		return err
+	}
+	v, err := r.Bool()
+	if err != nil {
+		return err
+	}
+	c.SetFlipFlop(v)
+	return nil
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	switch req.Path.Command {
+	case CmdAddArguments:
+		return c.handleAddArguments(r)
+	case CmdPing:
+		c.mu.Lock()
+		c.pingCount++
+		c.mu.Unlock()
+		c.MarkDirty(AttrPingCount)
+		return clusters.EmptyResponse(), nil
+	default:
+		return nil, datamodel.ErrUnsupportedCommand
+	}
+}
+
+// handleAddArguments decodes the two arguments and returns their sum.
+func (c *Cluster) handleAddArguments(r *tlv.Reader) ([]byte, error) {
+	if err := r.Next(); err != nil {
+		return nil, err
+	}
+	if r.Type() != tlv.ElementTypeStruct {
+		return nil, datamodel.ErrInvalidCommand
+	}
+	if err := r.EnterContainer(); err != nil {
+		return nil, err
+	}
+
+	var args [2]uint64
+	for {
+		if err := r.Next(); err != nil || r.Type() == tlv.ElementTypeEnd {
+			break
+		}
+		tag := r.Tag()
+		if !tag.IsContext() || tag.TagNumber() > 1 {
+			continue
+		}
+		v, err := r.Uint()
+		if err != nil {
+			return nil, err
+		}
+		args[tag.TagNumber()] = v
+	}
+
+	sum := args[0] + args[1]
+	if args[0] > 0xFF || args[1] > 0xFF || sum > 0xFF {
+		return nil, datamodel.ErrConstraintError
+	}
+	return clusters.EncodeResponse(addArgumentsResponse{ReturnValue: uint8(sum)})
+}
+
+// addArgumentsResponse is the AddArgumentsResponse command.
+type addArgumentsResponse struct {
+	ReturnValue uint8
+}
+
+// MarshalTLV implements clusters.TLVMarshaler.
+func (r addArgumentsResponse) MarshalTLV(w *tlv.Writer) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(r.ReturnValue)); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// FlipFlop returns the FlipFlop attribute.
+func (c *Cluster) FlipFlop() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.flipFlop
+}
+
+// SetFlipFlop sets the FlipFlop attribute.
+func (c *Cluster) SetFlipFlop(v bool) {
+	c.mu.Lock()
+	changed := c.flipFlop != v
+	c.flipFlop = v
+	c.mu.Unlock()
+	if changed {
+		c.MarkDirty(AttrFlipFlop)
+	}
+}
+
+// PingCount returns the number of Ping commands received.
+func (c *Cluster) PingCount() uint32 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.pingCount
+}
@@ -0,0 +1,109 @@
+// Package samplemei implements a Matter On/Off Light with a
+// manufacturer-specific (vendor) cluster.
+//
+// The Sample MEI cluster shows how to define a cluster under a vendor ID:
+// its cluster ID, one attribute and one command carry the vendor prefix
+// 0xFFF1 in their upper 16 bits. The node lists the cluster in the
+// Descriptor ServerList and reports its IDs in the global AttributeList
+// and AcceptedCommandList like those of any standard cluster.
+//
+// Example usage:
+//
+//	opts := common.DefaultOptions()
+//	device, _ := samplemei.NewDevice(opts)
+//	device.Start(ctx)
+package samplemei
+
+import (
+	"github.com/backkem/matter/examples/common"
+	"github.com/backkem/matter/pkg/clusters/onoff"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/matter"
+)
+
+// Device type constants.
+const (
+	// OnOffLightDeviceType is the device type of the endpoint (0x0100).
+	// A vendor cluster extends the clusters of a standard device type.
+	OnOffLightDeviceType uint32 = 0x0100
+
+	// LightEndpointID is the endpoint ID of the light.
+	LightEndpointID datamodel.EndpointID = 1
+)
+
+// Device represents a device with the Sample MEI cluster.
+type Device struct {
+	// Node is the underlying Matter node.
+	Node *matter.Node
+
+	// OnOffCluster is the On/Off cluster instance.
+	OnOffCluster *onoff.Cluster
+
+	// SampleCluster is the Sample MEI cluster instance.
+	SampleCluster *Cluster
+}
+
+// NewDevice creates a new device with the given options.
+func NewDevice(opts common.Options) (*Device, error) {
+	if opts.DeviceName == "" || opts.DeviceName == "Matter Device" {
+		opts.DeviceName = "Matter Sample MEI"
+	}
+
+	node, err := common.CreateNode(opts)
+	if err != nil {
+		return nil, err
+	}
+	return newDevice(node)
+}
+
+// NewDeviceWithConfig creates a new device with a custom Matter config.
+func NewDeviceWithConfig(config matter.NodeConfig) (*Device, error) {
+	node, err := matter.NewNode(config)
+	if err != nil {
+		return nil, err
+	}
+	return newDevice(node)
+}
+
+// newDevice adds the light endpoint to a node. Node.AddEndpoint fails if
+// a cluster has an ID outside the standard and manufacturer-specific
+// ranges.
+func newDevice(node *matter.Node) (*Device, error) {
+	onOff := onoff.New(onoff.Config{EndpointID: LightEndpointID})
+	sample := NewCluster(LightEndpointID)
+
+	ep := matter.NewEndpoint(LightEndpointID).
+		WithDeviceType(OnOffLightDeviceType, 1).
+		AddCluster(onOff).
+		AddCluster(sample)
+	if err := node.AddEndpoint(ep); err != nil {
+		return nil, err
+	}
+
+	return &Device{
+		Node:          node,
+		OnOffCluster:  onOff,
+		SampleCluster: sample,
+	}, nil
+}
+
+// OnboardingPayload returns the QR code payload for commissioning.
+func (d *Device) OnboardingPayload() string {
+	return d.Node.OnboardingPayload()
+}
+
+// ManualPairingCode returns the manual pairing code for commissioning.
+func (d *Device) ManualPairingCode() string {
+	return d.Node.ManualPairingCode()
+}
+
+// GetNode returns the underlying Matter node.
+// Implements the TestDevice interface for integration testing.
+func (d *Device) GetNode() *matter.Node {
+	return d.Node
+}
+
+// Factory creates a Sample MEI device from a Matter node config.
+func Factory(config matter.NodeConfig) (*Device, error) {
+	return NewDeviceWithConfig(config)
+}
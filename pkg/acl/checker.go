@@ -23,6 +23,8 @@ func (NullDeviceTypeResolver) IsDeviceTypeOnEndpoint(uint32, uint16) bool {
 // It implements the algorithm from Spec 6.6.6.2.
 type Checker struct {
 	entries            []Entry
+	restrictions       []RestrictionEntry
+	commissioningARL   []RestrictionEntry
 	deviceTypeResolver DeviceTypeResolver
 	mu                 sync.RWMutex
 }
@@ -71,6 +73,26 @@ func (c *Checker) AddEntry(entry Entry) error {
 	return nil
 }
 
+// SetRestrictions replaces the Access Restriction List entries of all
+// fabrics. Entries are copied to prevent external modification.
+func (c *Checker) SetRestrictions(entries []RestrictionEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.restrictions = make([]RestrictionEntry, len(entries))
+	copy(c.restrictions, entries)
+}
+
+// SetCommissioningRestrictions replaces the Commissioning ARL, applied to
+// CASE subjects that are still commissioning.
+func (c *Checker) SetCommissioningRestrictions(entries []RestrictionEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.commissioningARL = make([]RestrictionEntry, len(entries))
+	copy(c.commissioningARL, entries)
+}
+
 // Check evaluates whether the subject has the required privilege on the target.
 // Implements Spec 6.6.6.2 "Overall Algorithm".
 //
@@ -83,17 +105,48 @@ func (c *Checker) AddEntry(entry Entry) error {
 //     d. Subject must match (empty = wildcard, or exact/CAT match)
 //     e. Target must match (empty = wildcard, or cluster/endpoint/devicetype match)
 //  3. First matching entry grants access; no match = denied
+//  4. Granted CASE access is Restricted if the Access Restriction List
+//     forbids the path
 func (c *Checker) Check(subject SubjectDescriptor, target RequestPath, required Privilege) Result {
+	result, _, _ := c.check(&subject, &target, required)
+	return result
+}
+
+// Decide evaluates an access control check like Check, and returns the
+// decision along with the ACL entry that granted it, for tracing.
+func (c *Checker) Decide(subject SubjectDescriptor, target RequestPath, required Privilege) Decision {
+	d := Decision{
+		Subject:    subject,
+		Target:     target,
+		Privilege:  required,
+		EntryIndex: -1,
+	}
+	result, entry, index := c.check(&subject, &target, required)
+	d.Result = result
+	if entry != nil {
+		matched := *entry
+		d.Entry = &matched
+		d.EntryIndex = index
+	}
+	d.Implicit = result == ResultAllowed && entry == nil
+	return d
+}
+
+// check implements Check. It also returns the matching entry and its index
+// among the entries of the subject's fabric, or nil and -1 if no entry
+// matched.
+func (c *Checker) check(subject *SubjectDescriptor, target *RequestPath, required Privilege) (Result, *Entry, int) {
 	// Step 1: PASE commissioning gets implicit Administer
 	// Spec 6.6.2.9: "Bootstrapping of the Access Control List"
 	if subject.AuthMode == AuthModePASE && subject.IsCommissioning {
-		return ResultAllowed
+		return ResultAllowed, nil, -1
 	}
 
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
 	// Step 2: Check each ACL entry
+	index := -1
 	for i := range c.entries {
 		entry := &c.entries[i]
 
@@ -104,6 +157,7 @@ func (c *Checker) Check(subject SubjectDescriptor, target RequestPath, required
 		if entry.FabricIndex != subject.FabricIndex {
 			continue
 		}
+		index++
 
 		// 2b: AuthMode must match
 		if entry.AuthMode != subject.AuthMode {
@@ -116,21 +170,48 @@ func (c *Checker) Check(subject SubjectDescriptor, target RequestPath, required
 		}
 
 		// 2d: Check subject match
-		if !c.subjectMatches(entry, &subject) {
+		if !c.subjectMatches(entry, subject) {
 			continue
 		}
 
 		// 2e: Check target match
-		if !c.targetMatches(entry, &target) {
+		if !c.targetMatches(entry, target) {
 			continue
 		}
 
-		// Match found!
-		return ResultAllowed
+		// Match found! Step 4: apply access restrictions
+		if c.isRestricted(subject, target) {
+			return ResultRestricted, entry, index
+		}
+		return ResultAllowed, entry, index
 	}
 
 	// Step 3: No matching entry
-	return ResultDenied
+	return ResultDenied, nil, -1
+}
+
+// isRestricted checks the path against the Access Restriction List.
+// Restrictions only apply to CASE subjects: the Commissioning ARL while
+// commissioning, otherwise the entries of the subject's fabric.
+func (c *Checker) isRestricted(subject *SubjectDescriptor, path *RequestPath) bool {
+	if subject.AuthMode != AuthModeCASE {
+		return false
+	}
+
+	entries := c.restrictions
+	if subject.IsCommissioning {
+		entries = c.commissioningARL
+	}
+	for i := range entries {
+		entry := &entries[i]
+		if !subject.IsCommissioning && entry.FabricIndex != subject.FabricIndex {
+			continue
+		}
+		if entry.Forbids(path) {
+			return true
+		}
+	}
+	return false
 }
 
 // subjectMatches checks if the subject descriptor matches the entry's subjects.
@@ -0,0 +1,147 @@
+package acl
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Decision records the outcome of an access control check, for auditing
+// and for debugging "access denied" issues.
+type Decision struct {
+	// Subject is the requester.
+	Subject SubjectDescriptor
+
+	// Target is the path that was accessed.
+	Target RequestPath
+
+	// Privilege is the privilege the operation requires.
+	Privilege Privilege
+
+	// Entry is a copy of the ACL entry that matched, or nil if none did.
+	// A Restricted decision has a matching entry: access was granted by
+	// the ACL but forbidden by the Access Restriction List.
+	Entry *Entry
+
+	// EntryIndex is the index of Entry in its fabric's ACL, as read from
+	// the Access Control cluster, or -1 if no entry matched.
+	EntryIndex int
+
+	// Implicit is true if access was granted without an entry, to a PASE
+	// subject commissioning the node.
+	Implicit bool
+
+	// Result is the outcome of the check.
+	Result Result
+}
+
+// String returns a one-line description of the decision.
+func (d Decision) String() string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s: fabric %d %s subject 0x%016X %s endpoint %d cluster 0x%04X",
+		d.Result, d.Subject.FabricIndex, d.Subject.AuthMode, d.Subject.Subject,
+		d.Target.RequestType, d.Target.Endpoint, d.Target.Cluster)
+	if d.Target.EntityID != nil {
+		fmt.Fprintf(&b, " entity 0x%04X", *d.Target.EntityID)
+	}
+	fmt.Fprintf(&b, " requires %s", d.Privilege)
+	switch {
+	case d.Implicit:
+		b.WriteString(", implicit (PASE commissioning)")
+	case d.Entry != nil:
+		fmt.Fprintf(&b, ", entry %d (%s)", d.EntryIndex, d.Entry.Privilege)
+		if d.Result == ResultRestricted {
+			b.WriteString(", forbidden by access restrictions")
+		}
+	default:
+		b.WriteString(", no matching entry")
+	}
+	return b.String()
+}
+
+// DecisionTracer is called with the decision of each access control check.
+// It is called synchronously on the request path and must not block.
+type DecisionTracer func(Decision)
+
+// DefaultDecisionLogSize is the number of decisions kept by a DecisionLog
+// if no size is given.
+const DefaultDecisionLogSize = 64
+
+// DecisionLog keeps the most recent access control decisions in memory.
+// Its Record and RecordDenied methods are DecisionTracers.
+type DecisionLog struct {
+	mu      sync.Mutex
+	entries []loggedDecision
+	next    int
+	full    bool
+}
+
+type loggedDecision struct {
+	at       time.Time
+	decision Decision
+}
+
+// NewDecisionLog creates a log of the last size decisions.
+// Size defaults to DefaultDecisionLogSize if zero or negative.
+func NewDecisionLog(size int) *DecisionLog {
+	if size <= 0 {
+		size = DefaultDecisionLogSize
+	}
+	return &DecisionLog{entries: make([]loggedDecision, size)}
+}
+
+// Record adds a decision to the log, dropping the oldest if it is full.
+func (l *DecisionLog) Record(d Decision) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries[l.next] = loggedDecision{at: time.Now(), decision: d}
+	l.next++
+	if l.next == len(l.entries) {
+		l.next = 0
+		l.full = true
+	}
+}
+
+// RecordDenied adds a decision to the log unless access was allowed, so
+// that routine reads do not push out the failures.
+func (l *DecisionLog) RecordDenied(d Decision) {
+	if d.Result != ResultAllowed {
+		l.Record(d)
+	}
+}
+
+// Decisions returns the logged decisions, oldest first.
+func (l *DecisionLog) Decisions() []Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	logged := l.ordered()
+	result := make([]Decision, len(logged))
+	for i := range logged {
+		result[i] = logged[i].decision
+	}
+	return result
+}
+
+// Bytes returns the logged decisions as text, one per line, oldest first.
+func (l *DecisionLog) Bytes() []byte {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var b bytes.Buffer
+	for _, e := range l.ordered() {
+		fmt.Fprintf(&b, "%s %s\n", e.at.UTC().Format(time.RFC3339), e.decision)
+	}
+	return b.Bytes()
+}
+
+// ordered returns the logged decisions, oldest first. Must be called with
+// l.mu held.
+func (l *DecisionLog) ordered() []loggedDecision {
+	if !l.full {
+		return append([]loggedDecision(nil), l.entries[:l.next]...)
+	}
+	return append(append([]loggedDecision(nil), l.entries[l.next:]...), l.entries[:l.next]...)
+}
@@ -0,0 +1,90 @@
+package acl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestManager_DecisionTracer(t *testing.T) {
+	var decisions []Decision
+	m := NewManager(nil, nil, WithDecisionTracer(func(d Decision) {
+		decisions = append(decisions, d)
+	}))
+
+	// Fabric 2's entries follow fabric 1's in the checker, but are
+	// reported by their index in fabric 2's ACL
+	m.CreateEntry(1, Entry{Privilege: PrivilegeAdminister, AuthMode: AuthModeCASE, Subjects: []uint64{0x1}})
+	m.CreateEntry(2, Entry{Privilege: PrivilegeAdminister, AuthMode: AuthModeCASE, Subjects: []uint64{0x1}})
+	m.CreateEntry(2, Entry{Privilege: PrivilegeOperate, AuthMode: AuthModeCASE, Subjects: []uint64{0x2}})
+	m.SetRestrictions(2, []RestrictionEntry{{
+		Endpoint:     1,
+		Cluster:      0x0006,
+		Restrictions: []Restriction{{Type: RestrictionCommandForbidden}},
+	}})
+
+	operator := SubjectDescriptor{FabricIndex: 2, AuthMode: AuthModeCASE, Subject: 0x2}
+	onOff := NewRequestPath(0x0006, 1, RequestTypeAttributeRead)
+	m.Check(operator, onOff, PrivilegeView)
+	m.Check(operator, onOff, PrivilegeManage)
+	m.Check(operator, NewRequestPathWithEntity(0x0006, 1, RequestTypeCommandInvoke, 1), PrivilegeOperate)
+	m.Check(SubjectDescriptor{AuthMode: AuthModePASE, IsCommissioning: true}, onOff, PrivilegeAdminister)
+
+	if len(decisions) != 4 {
+		t.Fatalf("traced %d decisions, want 4", len(decisions))
+	}
+	tests := []struct {
+		result   Result
+		index    int
+		implicit bool
+	}{
+		{ResultAllowed, 1, false},
+		{ResultDenied, -1, false},
+		{ResultRestricted, 1, false},
+		{ResultAllowed, -1, true},
+	}
+	for i, tt := range tests {
+		d := decisions[i]
+		if d.Result != tt.result || d.EntryIndex != tt.index || d.Implicit != tt.implicit {
+			t.Errorf("decision %d = %v, index %d, implicit %v; want %v, %d, %v",
+				i, d.Result, d.EntryIndex, d.Implicit, tt.result, tt.index, tt.implicit)
+		}
+		if (d.Entry != nil) != (tt.index >= 0) {
+			t.Errorf("decision %d entry = %+v", i, d.Entry)
+		}
+	}
+	if d := decisions[0]; d.Entry.Privilege != PrivilegeOperate || d.Privilege != PrivilegeView || d.Subject.Subject != 0x2 {
+		t.Errorf("decision 0 = %+v", d)
+	}
+	if s := decisions[1].String(); !strings.Contains(s, "Denied") || !strings.Contains(s, "requires Manage") ||
+		!strings.Contains(s, "no matching entry") {
+		t.Errorf("String() = %q", s)
+	}
+}
+
+func TestDecisionLog(t *testing.T) {
+	log := NewDecisionLog(2)
+	if got := log.Decisions(); len(got) != 0 {
+		t.Errorf("Decisions() = %v, want none", got)
+	}
+
+	path := NewRequestPath(0x0006, 1, RequestTypeAttributeRead)
+	for subject := uint64(1); subject <= 3; subject++ {
+		log.RecordDenied(Decision{Subject: SubjectDescriptor{Subject: subject}, Target: path, EntryIndex: -1})
+	}
+	log.RecordDenied(Decision{Result: ResultAllowed})
+
+	// The oldest decision is dropped, and allowed ones are not recorded
+	got := log.Decisions()
+	if len(got) != 2 || got[0].Subject.Subject != 2 || got[1].Subject.Subject != 3 {
+		t.Errorf("Decisions() = %+v, want subjects 2 and 3", got)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(log.Bytes())), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], "subject 0x0000000000000003") {
+		t.Errorf("Bytes() = %q", lines)
+	}
+
+	if l := NewDecisionLog(0); len(l.entries) != DefaultDecisionLogSize {
+		t.Errorf("NewDecisionLog(0) size = %d, want %d", len(l.entries), DefaultDecisionLogSize)
+	}
+}
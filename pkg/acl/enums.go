@@ -190,3 +190,59 @@ func (r Result) String() string {
 		return "Unknown"
 	}
 }
+
+// RestrictionType identifies what an access restriction forbids.
+// Spec: Section 9.10.5 (AccessRestrictionTypeEnum)
+type RestrictionType uint8
+
+const (
+	// RestrictionAttributeAccessForbidden forbids reading and writing
+	// an attribute.
+	RestrictionAttributeAccessForbidden RestrictionType = 0
+
+	// RestrictionAttributeWriteForbidden forbids writing an attribute.
+	RestrictionAttributeWriteForbidden RestrictionType = 1
+
+	// RestrictionCommandForbidden forbids invoking a command.
+	RestrictionCommandForbidden RestrictionType = 2
+
+	// RestrictionEventForbidden forbids reading an event.
+	RestrictionEventForbidden RestrictionType = 3
+)
+
+// String returns a human-readable name for the restriction type.
+func (t RestrictionType) String() string {
+	switch t {
+	case RestrictionAttributeAccessForbidden:
+		return "AttributeAccessForbidden"
+	case RestrictionAttributeWriteForbidden:
+		return "AttributeWriteForbidden"
+	case RestrictionCommandForbidden:
+		return "CommandForbidden"
+	case RestrictionEventForbidden:
+		return "EventForbidden"
+	default:
+		return "Unknown"
+	}
+}
+
+// IsValid returns true if the restriction type is a defined value.
+func (t RestrictionType) IsValid() bool {
+	return t <= RestrictionEventForbidden
+}
+
+// Forbids returns true if the restriction type applies to the request type.
+func (t RestrictionType) Forbids(requestType RequestType) bool {
+	switch t {
+	case RestrictionAttributeAccessForbidden:
+		return requestType == RequestTypeAttributeRead || requestType == RequestTypeAttributeWrite
+	case RestrictionAttributeWriteForbidden:
+		return requestType == RequestTypeAttributeWrite
+	case RestrictionCommandForbidden:
+		return requestType == RequestTypeCommandInvoke
+	case RestrictionEventForbidden:
+		return requestType == RequestTypeEventRead
+	default:
+		return false
+	}
+}
@@ -137,10 +137,18 @@ type Manager struct {
 	store   Store
 	mu      sync.RWMutex
 
+	// Access Restriction List, set by the device rather than by
+	// administrators and not persisted.
+	restrictions     map[fabric.FabricIndex][]RestrictionEntry
+	commissioningARL []RestrictionEntry
+
 	// Limits
 	maxEntriesPerFabric int
 	maxSubjectsPerEntry int
 	maxTargetsPerEntry  int
+
+	// tracer, if set, is called with the decision of each check
+	tracer DecisionTracer
 }
 
 // ManagerOption configures a Manager.
@@ -167,6 +175,14 @@ func WithMaxTargetsPerEntry(max int) ManagerOption {
 	}
 }
 
+// WithDecisionTracer sets a tracer that is called with the decision of
+// each access control check, e.g. the Record method of a DecisionLog.
+func WithDecisionTracer(tracer DecisionTracer) ManagerOption {
+	return func(m *Manager) {
+		m.tracer = tracer
+	}
+}
+
 // NewManager creates a new ACL manager.
 // If store is nil, a MemoryStore is used.
 // If resolver is nil, NullDeviceTypeResolver is used.
@@ -178,6 +194,7 @@ func NewManager(store Store, resolver DeviceTypeResolver, opts ...ManagerOption)
 	m := &Manager{
 		checker:             NewChecker(resolver),
 		store:               store,
+		restrictions:        make(map[fabric.FabricIndex][]RestrictionEntry),
 		maxEntriesPerFabric: DefaultMaxEntriesPerFabric,
 		maxSubjectsPerEntry: DefaultMaxSubjectsPerEntry,
 		maxTargetsPerEntry:  DefaultMaxTargetsPerEntry,
@@ -190,9 +207,30 @@ func NewManager(store Store, resolver DeviceTypeResolver, opts ...ManagerOption)
 	return m
 }
 
-// Check performs an access control check.
+// MaxEntriesPerFabric returns the number of entries each fabric may hold.
+func (m *Manager) MaxEntriesPerFabric() int {
+	return m.maxEntriesPerFabric
+}
+
+// MaxSubjectsPerEntry returns the number of subjects an entry may hold.
+func (m *Manager) MaxSubjectsPerEntry() int {
+	return m.maxSubjectsPerEntry
+}
+
+// MaxTargetsPerEntry returns the number of targets an entry may hold.
+func (m *Manager) MaxTargetsPerEntry() int {
+	return m.maxTargetsPerEntry
+}
+
+// Check performs an access control check, and reports the decision to the
+// tracer if one is set.
 func (m *Manager) Check(subject SubjectDescriptor, target RequestPath, privilege Privilege) Result {
-	return m.checker.Check(subject, target, privilege)
+	if m.tracer == nil {
+		return m.checker.Check(subject, target, privilege)
+	}
+	d := m.checker.Decide(subject, target, privilege)
+	m.tracer(d)
+	return d.Result
 }
 
 // CreateEntry validates and stores a new ACL entry.
@@ -315,7 +353,7 @@ func (m *Manager) GetEntryCount(fabricIndex fabric.FabricIndex) (int, error) {
 	return m.store.Count(fabricIndex)
 }
 
-// DeleteAllForFabric removes all entries for a fabric.
+// DeleteAllForFabric removes all entries and restrictions for a fabric.
 // Called when a fabric is removed.
 func (m *Manager) DeleteAllForFabric(fabricIndex fabric.FabricIndex) error {
 	m.mu.Lock()
@@ -325,9 +363,93 @@ func (m *Manager) DeleteAllForFabric(fabricIndex fabric.FabricIndex) error {
 		return err
 	}
 
+	if _, ok := m.restrictions[fabricIndex]; ok {
+		delete(m.restrictions, fabricIndex)
+		m.reloadRestrictions()
+	}
+
 	return m.reloadChecker()
 }
 
+// SetRestrictions replaces the Access Restriction List of a fabric.
+// An empty list lifts all restrictions.
+func (m *Manager) SetRestrictions(fabricIndex fabric.FabricIndex, entries []RestrictionEntry) error {
+	if !fabricIndex.IsValid() {
+		return ErrInvalidFabricIndex
+	}
+
+	list := make([]RestrictionEntry, len(entries))
+	for i, entry := range entries {
+		entry.FabricIndex = fabricIndex
+		if err := ValidateRestrictionEntry(&entry); err != nil {
+			return err
+		}
+		list[i] = entry
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(list) == 0 {
+		delete(m.restrictions, fabricIndex)
+	} else {
+		m.restrictions[fabricIndex] = list
+	}
+	m.reloadRestrictions()
+	return nil
+}
+
+// Restrictions returns the Access Restriction List of a fabric.
+func (m *Manager) Restrictions(fabricIndex fabric.FabricIndex) []RestrictionEntry {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]RestrictionEntry, len(m.restrictions[fabricIndex]))
+	copy(result, m.restrictions[fabricIndex])
+	return result
+}
+
+// SetCommissioningRestrictions replaces the Commissioning ARL: the
+// restrictions applied while a fabric is commissioned, and that the
+// device will apply to a new fabric unless reviewed.
+func (m *Manager) SetCommissioningRestrictions(entries []RestrictionEntry) error {
+	list := make([]RestrictionEntry, len(entries))
+	for i, entry := range entries {
+		entry.FabricIndex = 0
+		if err := ValidateRestrictionEntry(&entry); err != nil {
+			return err
+		}
+		list[i] = entry
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.commissioningARL = list
+	m.checker.SetCommissioningRestrictions(list)
+	return nil
+}
+
+// CommissioningRestrictions returns the Commissioning ARL.
+func (m *Manager) CommissioningRestrictions() []RestrictionEntry {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]RestrictionEntry, len(m.commissioningARL))
+	copy(result, m.commissioningARL)
+	return result
+}
+
+// reloadRestrictions pushes the restrictions of all fabrics to the checker.
+// Must be called with m.mu held.
+func (m *Manager) reloadRestrictions() {
+	var all []RestrictionEntry
+	for fi := fabric.FabricIndexMin; fi <= fabric.FabricIndexMax; fi++ {
+		all = append(all, m.restrictions[fi]...)
+	}
+	m.checker.SetRestrictions(all)
+}
+
 // reloadChecker rebuilds the checker's entry list from the store.
 // Must be called with m.mu held.
 func (m *Manager) reloadChecker() error {
@@ -0,0 +1,74 @@
+package acl
+
+import (
+	"errors"
+
+	"github.com/backkem/matter/pkg/fabric"
+)
+
+// Restriction validation errors.
+var (
+	ErrInvalidRestrictionType = errors.New("acl: invalid restriction type")
+	ErrNoRestrictions         = errors.New("acl: restriction entry must have at least one restriction")
+)
+
+// Restriction forbids one attribute, command or event of a cluster.
+// Spec: Section 9.10.5 (AccessRestrictionStruct)
+type Restriction struct {
+	// Type is the kind of entity the restriction forbids.
+	Type RestrictionType
+
+	// ID is the attribute, command or event ID, or nil for all entities
+	// of the type.
+	ID *uint32
+}
+
+// RestrictionEntry lists the restrictions on one cluster instance.
+// Spec: Section 9.10.5 (AccessRestrictionEntryStruct and
+// CommissioningAccessRestrictionEntryStruct)
+//
+// On a managed device, the device vendor decides which operations a
+// fabric may perform, independent of the privileges granted by the ACL.
+// Entries of the Commissioning ARL have FabricIndex 0.
+type RestrictionEntry struct {
+	FabricIndex  fabric.FabricIndex // Owning fabric (0 for commissioning)
+	Endpoint     uint16             // Endpoint of the cluster instance
+	Cluster      uint32             // Restricted cluster
+	Restrictions []Restriction      // What is forbidden (non-empty)
+}
+
+// Forbids returns true if the entry forbids the request path.
+func (e *RestrictionEntry) Forbids(path *RequestPath) bool {
+	if e.Endpoint != path.Endpoint || e.Cluster != path.Cluster {
+		return false
+	}
+	for _, r := range e.Restrictions {
+		if !r.Type.Forbids(path.RequestType) {
+			continue
+		}
+		if r.ID == nil || (path.EntityID != nil && *r.ID == *path.EntityID) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateRestrictionEntry checks if a restriction entry is valid.
+// The fabric index is not checked; the Manager assigns it.
+func ValidateRestrictionEntry(entry *RestrictionEntry) error {
+	if !IsValidEndpointID(entry.Endpoint) {
+		return ErrInvalidEndpointID
+	}
+	if !IsValidClusterID(entry.Cluster) {
+		return ErrInvalidClusterID
+	}
+	if len(entry.Restrictions) == 0 {
+		return ErrNoRestrictions
+	}
+	for _, r := range entry.Restrictions {
+		if !r.Type.IsValid() {
+			return ErrInvalidRestrictionType
+		}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package acl
+
+import (
+	"errors"
+	"testing"
+)
+
+func ptrU32(v uint32) *uint32 { return &v }
+
+func TestRestrictionType_Forbids(t *testing.T) {
+	tests := []struct {
+		typ  RestrictionType
+		req  RequestType
+		want bool
+	}{
+		{RestrictionAttributeAccessForbidden, RequestTypeAttributeRead, true},
+		{RestrictionAttributeAccessForbidden, RequestTypeAttributeWrite, true},
+		{RestrictionAttributeAccessForbidden, RequestTypeCommandInvoke, false},
+		{RestrictionAttributeWriteForbidden, RequestTypeAttributeRead, false},
+		{RestrictionAttributeWriteForbidden, RequestTypeAttributeWrite, true},
+		{RestrictionCommandForbidden, RequestTypeCommandInvoke, true},
+		{RestrictionCommandForbidden, RequestTypeEventRead, false},
+		{RestrictionEventForbidden, RequestTypeEventRead, true},
+		{RestrictionType(4), RequestTypeAttributeRead, false},
+	}
+	for _, tt := range tests {
+		if got := tt.typ.Forbids(tt.req); got != tt.want {
+			t.Errorf("%v.Forbids(%v) = %v, want %v", tt.typ, tt.req, got, tt.want)
+		}
+	}
+}
+
+func TestValidateRestrictionEntry(t *testing.T) {
+	valid := RestrictionEntry{
+		Endpoint:     1,
+		Cluster:      0x0006,
+		Restrictions: []Restriction{{Type: RestrictionCommandForbidden}},
+	}
+	if err := ValidateRestrictionEntry(&valid); err != nil {
+		t.Errorf("valid entry: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		entry RestrictionEntry
+		want  error
+	}{
+		{"no restrictions", RestrictionEntry{Endpoint: 1, Cluster: 0x0006}, ErrNoRestrictions},
+		{"wildcard endpoint", RestrictionEntry{Endpoint: 0xFFFF, Cluster: 0x0006, Restrictions: valid.Restrictions}, ErrInvalidEndpointID},
+		{"invalid cluster", RestrictionEntry{Endpoint: 1, Cluster: 0xFFFF_FFFF, Restrictions: valid.Restrictions}, ErrInvalidClusterID},
+		{"invalid type", RestrictionEntry{Endpoint: 1, Cluster: 0x0006, Restrictions: []Restriction{{Type: 9}}}, ErrInvalidRestrictionType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateRestrictionEntry(&tt.entry); !errors.Is(err, tt.want) {
+				t.Errorf("ValidateRestrictionEntry() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestChecker_Restrictions(t *testing.T) {
+	c := NewChecker(nil)
+	c.SetEntries([]Entry{
+		{FabricIndex: 1, Privilege: PrivilegeAdminister, AuthMode: AuthModeCASE},
+		{FabricIndex: 2, Privilege: PrivilegeAdminister, AuthMode: AuthModeCASE},
+	})
+	c.SetRestrictions([]RestrictionEntry{{
+		FabricIndex: 1,
+		Endpoint:    1,
+		Cluster:     0x0006,
+		Restrictions: []Restriction{
+			{Type: RestrictionAttributeWriteForbidden, ID: ptrU32(0x4001)},
+			{Type: RestrictionCommandForbidden},
+		},
+	}})
+	c.SetCommissioningRestrictions([]RestrictionEntry{{
+		Endpoint:     1,
+		Cluster:      0x0006,
+		Restrictions: []Restriction{{Type: RestrictionAttributeAccessForbidden}},
+	}})
+
+	fabric1 := SubjectDescriptor{FabricIndex: 1, AuthMode: AuthModeCASE, Subject: 0x1000}
+	fabric2 := SubjectDescriptor{FabricIndex: 2, AuthMode: AuthModeCASE, Subject: 0x1000}
+	commissioning := SubjectDescriptor{FabricIndex: 1, AuthMode: AuthModeCASE, Subject: 0x1000, IsCommissioning: true}
+	pase := SubjectDescriptor{AuthMode: AuthModePASE, Subject: NodeIDFromPAKEKeyID(0), IsCommissioning: true}
+
+	write := NewRequestPathWithEntity(0x0006, 1, RequestTypeAttributeWrite, 0x4001)
+	otherWrite := NewRequestPathWithEntity(0x0006, 1, RequestTypeAttributeWrite, 0x4002)
+	read := NewRequestPathWithEntity(0x0006, 1, RequestTypeAttributeRead, 0x4001)
+	invoke := NewRequestPathWithEntity(0x0006, 1, RequestTypeCommandInvoke, 0x02)
+	otherEndpoint := NewRequestPathWithEntity(0x0006, 2, RequestTypeCommandInvoke, 0x02)
+
+	tests := []struct {
+		name    string
+		subject SubjectDescriptor
+		path    RequestPath
+		want    Result
+	}{
+		{"restricted attribute write", fabric1, write, ResultRestricted},
+		{"other attribute write", fabric1, otherWrite, ResultAllowed},
+		{"attribute read", fabric1, read, ResultAllowed},
+		{"any command", fabric1, invoke, ResultRestricted},
+		{"other endpoint", fabric1, otherEndpoint, ResultAllowed},
+		{"other fabric", fabric2, invoke, ResultAllowed},
+		{"commissioning ARL", commissioning, read, ResultRestricted},
+		{"commissioning ARL allows invoke", commissioning, invoke, ResultAllowed},
+		{"PASE is not restricted", pase, read, ResultAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.Check(tt.subject, tt.path, PrivilegeView); got != tt.want {
+				t.Errorf("Check() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Restrictions never grant access the ACL denies.
+	fabric3 := SubjectDescriptor{FabricIndex: 3, AuthMode: AuthModeCASE, Subject: 0x1000}
+	if got := c.Check(fabric3, invoke, PrivilegeView); got != ResultDenied {
+		t.Errorf("Check() without ACL entry = %v, want Denied", got)
+	}
+}
+
+func TestManager_Restrictions(t *testing.T) {
+	m := NewManager(nil, nil)
+	if _, err := m.CreateEntry(1, Entry{Privilege: PrivilegeAdminister, AuthMode: AuthModeCASE}); err != nil {
+		t.Fatalf("CreateEntry() error: %v", err)
+	}
+
+	arl := []RestrictionEntry{{
+		FabricIndex:  7, // overwritten with the owning fabric
+		Endpoint:     1,
+		Cluster:      0x0006,
+		Restrictions: []Restriction{{Type: RestrictionCommandForbidden}},
+	}}
+	if err := m.SetRestrictions(0, arl); !errors.Is(err, ErrInvalidFabricIndex) {
+		t.Errorf("SetRestrictions(0) = %v, want ErrInvalidFabricIndex", err)
+	}
+	if err := m.SetRestrictions(1, []RestrictionEntry{{Endpoint: 1, Cluster: 0x0006}}); !errors.Is(err, ErrNoRestrictions) {
+		t.Errorf("SetRestrictions(invalid) = %v, want ErrNoRestrictions", err)
+	}
+	if err := m.SetRestrictions(1, arl); err != nil {
+		t.Fatalf("SetRestrictions() error: %v", err)
+	}
+	if got := m.Restrictions(1); len(got) != 1 || got[0].FabricIndex != 1 {
+		t.Errorf("Restrictions(1) = %+v", got)
+	}
+
+	subject := SubjectDescriptor{FabricIndex: 1, AuthMode: AuthModeCASE, Subject: 0x1000}
+	invoke := NewRequestPathWithEntity(0x0006, 1, RequestTypeCommandInvoke, 0x02)
+	if got := m.Check(subject, invoke, PrivilegeOperate); got != ResultRestricted {
+		t.Errorf("Check() = %v, want Restricted", got)
+	}
+
+	// Removing the fabric drops its restrictions.
+	if err := m.DeleteAllForFabric(1); err != nil {
+		t.Fatalf("DeleteAllForFabric() error: %v", err)
+	}
+	if got := m.Restrictions(1); len(got) != 0 {
+		t.Errorf("Restrictions(1) after delete = %+v", got)
+	}
+
+	if err := m.SetCommissioningRestrictions(arl); err != nil {
+		t.Fatalf("SetCommissioningRestrictions() error: %v", err)
+	}
+	if got := m.CommissioningRestrictions(); len(got) != 1 || got[0].FabricIndex != 0 {
+		t.Errorf("CommissioningRestrictions() = %+v", got)
+	}
+}
@@ -179,8 +179,9 @@ type SubjectDescriptor struct {
 	// CATs contains CASE Authenticated Tags from the certificate (CASE only).
 	CATs CATValues
 
-	// IsCommissioning is true if this is a PASE session during commissioning.
-	// When true, implicit Administer privilege is granted.
+	// IsCommissioning is true if the subject is commissioning the node.
+	// PASE subjects are granted implicit Administer privilege; CASE
+	// subjects are restricted by the Commissioning ARL.
 	IsCommissioning bool
 }
 
@@ -0,0 +1,84 @@
+// Package clock abstracts time for the stack's timers.
+//
+// Layers with timers take a Clock in their config: exchange for MRP
+// retransmissions and standalone acknowledgements, securechannel for
+// handshake timeouts, commissioning for the commissioning window and the
+// fail-safe, and the Interaction Model for subscription intervals and
+// timed requests. A nil Clock in any layer config means the real clock.
+//
+// Tests pass a FakeClock instead and move time forward with Advance, so
+// timing-dependent behavior is exercised without real sleeps.
+//
+// Example:
+//
+//	clk := clock.NewFakeClock(time.Time{})
+//	node, _ := matter.NewNode(matter.NodeConfig{..., Clock: clk})
+//	...
+//	clk.Advance(3 * time.Minute) // the commissioning window expires
+package clock
+
+import "time"
+
+// Clock tells the time and creates timers. Implementations must be safe
+// for concurrent use.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// AfterFunc calls f once d has elapsed.
+	AfterFunc(d time.Duration, f func()) Timer
+
+	// NewTimer creates a timer that sends the time on its channel once d
+	// has elapsed.
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a single-shot timer created by a Clock.
+type Timer interface {
+	// C returns the channel the time is sent on, or nil for timers created
+	// by AfterFunc.
+	C() <-chan time.Time
+
+	// Stop prevents the timer from firing. Returns false if the timer had
+	// already fired or been stopped.
+	Stop() bool
+
+	// Reset changes the timer to fire once d has elapsed. Returns true if
+	// the timer had been active.
+	Reset(d time.Duration) bool
+}
+
+// Real is the Clock of the time package.
+type Real struct{}
+
+// Now implements Clock.
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+// AfterFunc implements Clock. f is called in its own goroutine.
+func (Real) AfterFunc(d time.Duration, f func()) Timer {
+	return realTimer{time.AfterFunc(d, f)}
+}
+
+// NewTimer implements Clock.
+func (Real) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+// OrReal returns c, or Real if c is nil.
+func OrReal(c Clock) Clock {
+	if c == nil {
+		return Real{}
+	}
+	return c
+}
+
+// realTimer is a Timer backed by a time.Timer.
+type realTimer struct {
+	t *time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time        { return t.t.C }
+func (t realTimer) Stop() bool                 { return t.t.Stop() }
+func (t realTimer) Reset(d time.Duration) bool { return t.t.Reset(d) }
@@ -0,0 +1,150 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClockAdvance(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewFakeClock(start)
+
+	var fired []int
+	c.AfterFunc(3*time.Second, func() { fired = append(fired, 3) })
+	c.AfterFunc(time.Second, func() { fired = append(fired, 1) })
+	c.AfterFunc(2*time.Second, func() {
+		fired = append(fired, 2)
+		if got := c.Now(); !got.Equal(start.Add(2 * time.Second)) {
+			t.Errorf("Now() in callback = %v, want start+2s", got)
+		}
+		// Comes due within the same Advance
+		c.AfterFunc(500*time.Millisecond, func() { fired = append(fired, 25) })
+	})
+	if got := c.PendingTimers(); got != 3 {
+		t.Errorf("PendingTimers() = %d, want 3", got)
+	}
+
+	c.Advance(2500 * time.Millisecond)
+	if want := []int{1, 2, 25}; !equal(fired, want) {
+		t.Errorf("fired = %v, want %v", fired, want)
+	}
+	if got := c.Now(); !got.Equal(start.Add(2500 * time.Millisecond)) {
+		t.Errorf("Now() = %v, want start+2.5s", got)
+	}
+
+	c.Advance(time.Second)
+	if want := []int{1, 2, 25, 3}; !equal(fired, want) {
+		t.Errorf("fired = %v, want %v", fired, want)
+	}
+	if got := c.PendingTimers(); got != 0 {
+		t.Errorf("PendingTimers() = %d, want 0", got)
+	}
+}
+
+func TestFakeClockStopReset(t *testing.T) {
+	c := NewFakeClock(time.Time{})
+
+	fired := 0
+	timer := c.AfterFunc(time.Second, func() { fired++ })
+	if !timer.Stop() {
+		t.Error("Stop() = false for an active timer")
+	}
+	if timer.Stop() {
+		t.Error("Stop() = true for a stopped timer")
+	}
+	c.Advance(2 * time.Second)
+	if fired != 0 {
+		t.Errorf("stopped timer fired %d times", fired)
+	}
+
+	if timer.Reset(time.Second) {
+		t.Error("Reset() = true for a stopped timer")
+	}
+	if !timer.Reset(2 * time.Second) {
+		t.Error("Reset() = false for an active timer")
+	}
+	c.Advance(time.Second)
+	if fired != 0 {
+		t.Error("reset timer fired at its earlier deadline")
+	}
+	c.Advance(time.Second)
+	if fired != 1 {
+		t.Errorf("reset timer fired %d times, want 1", fired)
+	}
+}
+
+func TestFakeClockNewTimer(t *testing.T) {
+	c := NewFakeClock(time.Time{})
+
+	timer := c.NewTimer(time.Minute)
+	c.Advance(59 * time.Second)
+	select {
+	case <-timer.C():
+		t.Fatal("timer fired early")
+	default:
+	}
+
+	c.Advance(time.Second)
+	select {
+	case now := <-timer.C():
+		if !now.Equal(c.Now()) {
+			t.Errorf("timer sent %v, want %v", now, c.Now())
+		}
+	default:
+		t.Fatal("timer did not fire")
+	}
+}
+
+func TestFakeClockBlockUntil(t *testing.T) {
+	c := NewFakeClock(time.Time{})
+
+	done := make(chan struct{})
+	go func() {
+		c.BlockUntil(1)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("BlockUntil returned without timers")
+	case <-time.After(10 * time.Millisecond):
+	}
+	c.NewTimer(time.Second)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("BlockUntil did not return once a timer was armed")
+	}
+}
+
+func TestRealClock(t *testing.T) {
+	c := OrReal(nil)
+	if _, ok := c.(Real); !ok {
+		t.Fatalf("OrReal(nil) = %T, want Real", c)
+	}
+
+	fired := make(chan struct{})
+	c.AfterFunc(time.Millisecond, func() { close(fired) })
+	select {
+	case <-fired:
+	case <-time.After(time.Second):
+		t.Fatal("AfterFunc did not fire")
+	}
+
+	timer := c.NewTimer(time.Hour)
+	if !timer.Stop() {
+		t.Error("Stop() = false for an active timer")
+	}
+}
+
+func equal(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
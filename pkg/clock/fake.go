@@ -0,0 +1,178 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// FakeClock is a Clock for tests. Its time only moves when Advance or Set
+// is called, firing the timers that come due in order.
+type FakeClock struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	timers  []*fakeTimer
+	nextSeq uint64
+}
+
+// NewFakeClock creates a fake clock set to start. If start is zero, the
+// clock starts at 2000-01-01 00:00:00 UTC, the Matter epoch.
+func NewFakeClock(start time.Time) *FakeClock {
+	if start.IsZero() {
+		start = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	c := &FakeClock{now: start}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now implements Clock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// AfterFunc implements Clock. f is called by Advance, in Advance's
+// goroutine, so that its effects are complete when Advance returns.
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	t := &fakeTimer{clock: c, f: f}
+	t.Reset(d)
+	return t
+}
+
+// NewTimer implements Clock.
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{clock: c, c: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+// Advance moves the clock forward by d, firing every timer that comes due,
+// in order of expiry. Timers created by the fired callbacks fire too if
+// they come due within d.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	target := c.now.Add(d)
+	c.mu.Unlock()
+	c.Set(target)
+}
+
+// Set moves the clock forward to t, firing every timer that comes due.
+// Setting an earlier time has no effect.
+func (c *FakeClock) Set(t time.Time) {
+	for {
+		c.mu.Lock()
+		next := c.nextDueLocked(t)
+		if next == nil {
+			if t.After(c.now) {
+				c.now = t
+			}
+			c.mu.Unlock()
+			return
+		}
+		if next.when.After(c.now) {
+			c.now = next.when
+		}
+		c.removeLocked(next)
+		now := c.now
+		c.mu.Unlock()
+
+		next.fire(now)
+	}
+}
+
+// PendingTimers returns the number of timers that have not fired or been
+// stopped.
+func (c *FakeClock) PendingTimers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// BlockUntil blocks until at least n timers are pending, e.g. until a
+// goroutine under test has armed its timer.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.timers) < n {
+		c.cond.Wait()
+	}
+}
+
+// nextDueLocked returns the pending timer that expires first, if it
+// expires no later than t. Timers expiring together fire in the order they
+// were armed.
+// Caller must hold c.mu.
+func (c *FakeClock) nextDueLocked(t time.Time) *fakeTimer {
+	var next *fakeTimer
+	for _, timer := range c.timers {
+		if timer.when.After(t) {
+			continue
+		}
+		if next == nil || timer.when.Before(next.when) ||
+			(timer.when.Equal(next.when) && timer.seq < next.seq) {
+			next = timer
+		}
+	}
+	return next
+}
+
+// removeLocked removes a timer from the pending timers. Returns false if
+// it was not pending.
+// Caller must hold c.mu.
+func (c *FakeClock) removeLocked(t *fakeTimer) bool {
+	for i, timer := range c.timers {
+		if timer == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// fakeTimer is a Timer of a FakeClock.
+type fakeTimer struct {
+	clock *FakeClock
+	when  time.Time
+	seq   uint64
+	f     func()
+	c     chan time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.removeLocked(t)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	active := c.removeLocked(t)
+	t.when = c.now.Add(d)
+	t.seq = c.nextSeq
+	c.nextSeq++
+	c.timers = append(c.timers, t)
+	c.cond.Broadcast()
+	return active
+}
+
+// fire runs the timer's function or sends the time on its channel, like a
+// time.Timer, dropping the value if the channel is full.
+func (t *fakeTimer) fire(now time.Time) {
+	if t.f != nil {
+		t.f()
+		return
+	}
+	select {
+	case t.c <- now:
+	default:
+	}
+}
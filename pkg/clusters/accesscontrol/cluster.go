@@ -0,0 +1,350 @@
+// Package accesscontrol implements the Access Control Cluster (0x001F).
+//
+// The Access Control cluster exposes the node's Access Control List and,
+// on managed devices, the Access Restriction List (ARL) with which the
+// device vendor limits what each fabric may do. Administrators ask for
+// restrictions to be lifted with ReviewFabricRestrictions; the outcome is
+// reported with the FabricRestrictionReviewUpdate event.
+//
+// The ACL attribute is served read-only; entries are managed through
+// acl.Manager.
+//
+// This cluster is mandatory on the root endpoint (endpoint 0).
+//
+// Spec Reference: Section 9.10
+//
+// C++ Reference: src/app/clusters/access-control-server/access-control-server.cpp
+package accesscontrol
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"sync"
+
+	"github.com/backkem/matter/pkg/acl"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x001F
+	ClusterRevision uint16              = 2
+)
+
+// Attribute IDs (Spec 9.10.6).
+const (
+	AttrACL                           datamodel.AttributeID = 0x0000
+	AttrExtension                     datamodel.AttributeID = 0x0001
+	AttrSubjectsPerAccessControlEntry datamodel.AttributeID = 0x0002
+	AttrTargetsPerAccessControlEntry  datamodel.AttributeID = 0x0003
+	AttrAccessControlEntriesPerFabric datamodel.AttributeID = 0x0004
+	AttrCommissioningARL              datamodel.AttributeID = 0x0005
+	AttrARL                           datamodel.AttributeID = 0x0006
+)
+
+// Command IDs (Spec 9.10.8).
+const (
+	CmdReviewFabricRestrictions         datamodel.CommandID = 0x00
+	CmdReviewFabricRestrictionsResponse datamodel.CommandID = 0x01
+)
+
+// Event IDs (Spec 9.10.9).
+const (
+	EventAccessControlEntryChanged     datamodel.EventID = 0x00
+	EventAccessControlExtensionChanged datamodel.EventID = 0x01
+	EventFabricRestrictionReviewUpdate datamodel.EventID = 0x02
+)
+
+// Feature bits (Spec 9.10.4).
+type Feature uint32
+
+const (
+	// FeatureExtension indicates support for the Extension attribute.
+	FeatureExtension Feature = 1 << 0 // EXTS
+
+	// FeatureManagedDevice indicates support for access restrictions.
+	FeatureManagedDevice Feature = 1 << 1 // MNGD
+)
+
+// Reviewer reviews the restrictions an administrator asks to lift.
+//
+// The review is vendor-specific, e.g. the user approves it in the vendor's
+// app. ReviewFabricRestrictions must not block: it starts the review and
+// returns. Report progress with Cluster.EmitFabricRestrictionReviewUpdate
+// and apply the outcome with Cluster.SetRestrictions.
+type Reviewer interface {
+	// ReviewFabricRestrictions starts a review of the restrictions in arl
+	// for the fabric. The token identifies the review in later events.
+	// An error fails the command.
+	ReviewFabricRestrictions(fabricIndex fabric.FabricIndex, token uint64, arl []acl.RestrictionEntry) error
+}
+
+// Config provides dependencies for the Access Control cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to (should be 0).
+	EndpointID datamodel.EndpointID
+
+	// ACL is the node's access control manager. Required.
+	ACL *acl.Manager
+
+	// Reviewer handles ReviewFabricRestrictions requests.
+	// Optional - if set, the ManagedDevice feature is enabled.
+	Reviewer Reviewer
+
+	// EventPublisher for FabricRestrictionReviewUpdate events.
+	// Optional - if nil, events are not emitted.
+	EventPublisher datamodel.EventPublisher
+}
+
+// Cluster implements the Access Control cluster (0x001F).
+type Cluster struct {
+	*datamodel.ClusterBase
+	*datamodel.EventSource
+	config Config
+
+	// Review tokens (protected by mutex)
+	mu        sync.Mutex
+	nextToken uint64
+
+	// Cached attribute list (built on construction)
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Access Control cluster.
+func New(cfg Config) *Cluster {
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		EventSource: datamodel.NewEventSource(),
+		config:      cfg,
+	}
+
+	// Start tokens at a random value so they stay unique across reboots
+	var seed [8]byte
+	if _, err := rand.Read(seed[:]); err == nil {
+		c.nextToken = binary.BigEndian.Uint64(seed[:])
+	}
+
+	if c.isManagedDevice() {
+		c.SetFeatureMap(uint32(FeatureManagedDevice))
+	}
+
+	// Bind event source
+	if cfg.EventPublisher != nil {
+		c.EventSource.Bind(cfg.EndpointID, ClusterID, cfg.EventPublisher)
+		c.registerEvents()
+	}
+
+	// Build attribute list
+	c.attrList = c.buildAttributeList()
+
+	return c
+}
+
+// isManagedDevice returns true if the ManagedDevice feature is enabled.
+func (c *Cluster) isManagedDevice() bool {
+	return c.config.Reviewer != nil
+}
+
+// registerEvents registers the cluster's events for validation.
+func (c *Cluster) registerEvents() {
+	if c.isManagedDevice() {
+		c.EventSource.RegisterEvent(datamodel.NewEventEntry(
+			EventFabricRestrictionReviewUpdate,
+			datamodel.EventPriorityInfo,
+			datamodel.PrivilegeAdminister,
+			true,
+		))
+	}
+}
+
+// buildAttributeList constructs the list of supported attributes.
+func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+	adminPriv := datamodel.PrivilegeAdminister
+
+	attrs := []datamodel.AttributeEntry{
+		// Mandatory attributes
+		datamodel.NewReadOnlyAttribute(AttrACL,
+			datamodel.AttrQualityList|datamodel.AttrQualityFabricScoped|datamodel.AttrQualityFabricSensitive, adminPriv),
+		datamodel.NewReadOnlyAttribute(AttrSubjectsPerAccessControlEntry, datamodel.AttrQualityFixed, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrTargetsPerAccessControlEntry, datamodel.AttrQualityFixed, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrAccessControlEntriesPerFabric, datamodel.AttrQualityFixed, viewPriv),
+	}
+
+	// ManagedDevice feature attributes
+	if c.isManagedDevice() {
+		attrs = append(attrs,
+			datamodel.NewReadOnlyAttribute(AttrCommissioningARL, datamodel.AttrQualityList, viewPriv),
+			datamodel.NewReadOnlyAttribute(AttrARL, datamodel.AttrQualityList|datamodel.AttrQualityFabricScoped, viewPriv),
+		)
+	}
+
+	// Add global attributes
+	return datamodel.MergeAttributeLists(attrs)
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	if !c.isManagedDevice() {
+		return nil
+	}
+	return []datamodel.CommandEntry{
+		datamodel.NewCommandEntry(CmdReviewFabricRestrictions, datamodel.CmdQualityFabricScoped, datamodel.PrivilegeAdminister),
+	}
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	if !c.isManagedDevice() {
+		return nil
+	}
+	return []datamodel.CommandID{CmdReviewFabricRestrictionsResponse}
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	// Handle global attributes first
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	switch req.Path.Attribute {
+	case AttrACL:
+		return c.readACL(&req, w)
+
+	case AttrSubjectsPerAccessControlEntry:
+		return w.PutUint(tlv.Anonymous(), uint64(c.config.ACL.MaxSubjectsPerEntry()))
+
+	case AttrTargetsPerAccessControlEntry:
+		return w.PutUint(tlv.Anonymous(), uint64(c.config.ACL.MaxTargetsPerEntry()))
+
+	case AttrAccessControlEntriesPerFabric:
+		return w.PutUint(tlv.Anonymous(), uint64(c.config.ACL.MaxEntriesPerFabric()))
+
+	case AttrCommissioningARL:
+		if !c.isManagedDevice() {
+			return datamodel.ErrUnsupportedAttribute
+		}
+		return writeRestrictionEntries(w, tlv.Anonymous(), c.config.ACL.CommissioningRestrictions(), false)
+
+	case AttrARL:
+		if !c.isManagedDevice() {
+			return datamodel.ErrUnsupportedAttribute
+		}
+		return c.readARL(&req, w)
+
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// readACL writes the ACL attribute. Entries of other fabrics carry only
+// their fabric index, as all other fields are fabric-sensitive.
+func (c *Cluster) readACL(req *datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	if err := w.StartArray(tlv.Anonymous()); err != nil {
+		return err
+	}
+
+	accessing := req.FabricIndex()
+	for _, fi := range c.readFabrics(req) {
+		entries, err := c.config.ACL.GetEntries(fi)
+		if err != nil {
+			return err
+		}
+		for i := range entries {
+			if err := writeEntry(w, &entries[i], fi == accessing); err != nil {
+				return err
+			}
+		}
+	}
+
+	return w.EndContainer()
+}
+
+// readARL writes the ARL attribute.
+func (c *Cluster) readARL(req *datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	if err := w.StartArray(tlv.Anonymous()); err != nil {
+		return err
+	}
+
+	for _, fi := range c.readFabrics(req) {
+		for _, entry := range c.config.ACL.Restrictions(fi) {
+			if err := writeRestrictionEntry(w, tlv.Anonymous(), &entry, true); err != nil {
+				return err
+			}
+		}
+	}
+
+	return w.EndContainer()
+}
+
+// readFabrics returns the fabrics whose entries a read of a fabric-scoped
+// list covers: the accessing fabric if fabric-filtered, otherwise all.
+func (c *Cluster) readFabrics(req *datamodel.ReadAttributeRequest) []fabric.FabricIndex {
+	if req.IsFabricFiltered() {
+		if fi := req.FabricIndex(); fi.IsValid() {
+			return []fabric.FabricIndex{fi}
+		}
+		return nil
+	}
+
+	fabrics := make([]fabric.FabricIndex, 0, fabric.FabricIndexMax)
+	for fi := fabric.FabricIndexMin; fi <= fabric.FabricIndexMax; fi++ {
+		fabrics = append(fabrics, fi)
+	}
+	return fabrics
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	return datamodel.ErrUnsupportedWrite
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	switch req.Path.Command {
+	case CmdReviewFabricRestrictions:
+		if !c.isManagedDevice() {
+			return nil, datamodel.ErrUnsupportedCommand
+		}
+		return c.handleReviewFabricRestrictions(ctx, req, r)
+	default:
+		return nil, datamodel.ErrUnsupportedCommand
+	}
+}
+
+// SetRestrictions replaces the ARL of a fabric, e.g. after a review.
+func (c *Cluster) SetRestrictions(fabricIndex fabric.FabricIndex, entries []acl.RestrictionEntry) error {
+	if err := c.config.ACL.SetRestrictions(fabricIndex, entries); err != nil {
+		return err
+	}
+	c.IncrementDataVersion()
+	return nil
+}
+
+// SetCommissioningRestrictions replaces the Commissioning ARL.
+func (c *Cluster) SetCommissioningRestrictions(entries []acl.RestrictionEntry) error {
+	if err := c.config.ACL.SetCommissioningRestrictions(entries); err != nil {
+		return err
+	}
+	c.IncrementDataVersion()
+	return nil
+}
+
+// newToken returns a token for a new review.
+func (c *Cluster) newToken() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nextToken++
+	return c.nextToken
+}
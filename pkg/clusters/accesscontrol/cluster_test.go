@@ -0,0 +1,280 @@
+package accesscontrol
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/backkem/matter/pkg/acl"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// mockReviewer records review requests.
+type mockReviewer struct {
+	fabricIndex fabric.FabricIndex
+	token       uint64
+	arl         []acl.RestrictionEntry
+	err         error
+}
+
+func (m *mockReviewer) ReviewFabricRestrictions(fabricIndex fabric.FabricIndex, token uint64, arl []acl.RestrictionEntry) error {
+	m.fabricIndex = fabricIndex
+	m.token = token
+	m.arl = arl
+	return m.err
+}
+
+// mockEventPublisher records published events.
+type mockEventPublisher struct {
+	eventID     datamodel.EventID
+	data        interface{}
+	fabricIndex uint8
+}
+
+func (m *mockEventPublisher) PublishEvent(endpoint datamodel.EndpointID, cluster datamodel.ClusterID,
+	eventID datamodel.EventID, priority datamodel.EventPriority, data interface{}, fabricIndex uint8,
+) (datamodel.EventNumber, error) {
+	m.eventID = eventID
+	m.data = data
+	m.fabricIndex = fabricIndex
+	return 1, nil
+}
+
+// onOffRestriction forbids all On/Off commands on endpoint 1.
+var onOffRestriction = acl.RestrictionEntry{
+	Endpoint:     1,
+	Cluster:      0x0006,
+	Restrictions: []acl.Restriction{{Type: acl.RestrictionCommandForbidden}},
+}
+
+func readAttribute(t *testing.T, c *Cluster, attr datamodel.AttributeID, subject fabric.FabricIndex, filtered bool) *tlv.Reader {
+	t.Helper()
+	req := datamodel.ReadAttributeRequest{
+		Path:    datamodel.ConcreteAttributePath{Cluster: ClusterID, Attribute: attr},
+		Subject: &datamodel.SubjectDescriptor{FabricIndex: subject},
+	}
+	if filtered {
+		req.ReadFlags = datamodel.ReadFlagFabricFiltered
+	}
+	var buf bytes.Buffer
+	if err := c.ReadAttribute(context.Background(), req, tlv.NewWriter(&buf)); err != nil {
+		t.Fatalf("ReadAttribute(0x%04X): %v", attr, err)
+	}
+	r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+	if err := r.Next(); err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	return r
+}
+
+func TestManagedDeviceFeature(t *testing.T) {
+	plain := New(Config{ACL: acl.NewManager(nil, nil)})
+	if plain.FeatureMap() != 0 {
+		t.Errorf("FeatureMap = %#x, want 0", plain.FeatureMap())
+	}
+	err := plain.ReadAttribute(context.Background(), datamodel.ReadAttributeRequest{
+		Path: datamodel.ConcreteAttributePath{Cluster: ClusterID, Attribute: AttrARL},
+	}, tlv.NewWriter(&bytes.Buffer{}))
+	if !errors.Is(err, datamodel.ErrUnsupportedAttribute) {
+		t.Errorf("read ARL without feature = %v, want ErrUnsupportedAttribute", err)
+	}
+	if len(plain.AcceptedCommandList()) != 0 {
+		t.Error("ReviewFabricRestrictions accepted without feature")
+	}
+
+	managed := New(Config{ACL: acl.NewManager(nil, nil), Reviewer: &mockReviewer{}})
+	if managed.FeatureMap() != uint32(FeatureManagedDevice) {
+		t.Errorf("FeatureMap = %#x, want MNGD", managed.FeatureMap())
+	}
+	if datamodel.FindAttribute(managed.AttributeList(), AttrCommissioningARL) == nil {
+		t.Error("CommissioningARL missing from AttributeList")
+	}
+}
+
+func TestReadACL(t *testing.T) {
+	mgr := acl.NewManager(nil, nil)
+	for _, fi := range []fabric.FabricIndex{1, 2} {
+		if _, err := mgr.CreateEntry(fi, acl.Entry{
+			Privilege: acl.PrivilegeAdminister,
+			AuthMode:  acl.AuthModeCASE,
+			Subjects:  []uint64{0x1000},
+		}); err != nil {
+			t.Fatalf("CreateEntry: %v", err)
+		}
+	}
+	c := New(Config{ACL: mgr})
+
+	// fields returns the context tags of each entry in the list.
+	fields := func(r *tlv.Reader) [][]uint32 {
+		t.Helper()
+		var out [][]uint32
+		if err := r.EnterContainer(); err != nil {
+			t.Fatalf("EnterContainer: %v", err)
+		}
+		for r.Next() == nil && !r.IsEndOfContainer() {
+			var tags []uint32
+			if err := r.EnterContainer(); err != nil {
+				t.Fatalf("EnterContainer: %v", err)
+			}
+			for r.Next() == nil && !r.IsEndOfContainer() {
+				tags = append(tags, r.Tag().TagNumber())
+				r.Skip()
+			}
+			r.ExitContainer()
+			out = append(out, tags)
+		}
+		return out
+	}
+
+	filtered := fields(readAttribute(t, c, AttrACL, 1, true))
+	if len(filtered) != 1 || len(filtered[0]) != 5 {
+		t.Errorf("fabric-filtered ACL fields = %v, want one full entry", filtered)
+	}
+
+	all := fields(readAttribute(t, c, AttrACL, 1, false))
+	if len(all) != 2 {
+		t.Fatalf("unfiltered ACL entries = %d, want 2", len(all))
+	}
+	if len(all[1]) != 1 || all[1][0] != tagFabricIndex {
+		t.Errorf("other fabric's entry fields = %v, want only FabricIndex", all[1])
+	}
+
+	r := readAttribute(t, c, AttrAccessControlEntriesPerFabric, 1, true)
+	if v, _ := r.Uint(); v != acl.DefaultMaxEntriesPerFabric {
+		t.Errorf("AccessControlEntriesPerFabric = %d, want %d", v, acl.DefaultMaxEntriesPerFabric)
+	}
+}
+
+func TestReadARL(t *testing.T) {
+	c := New(Config{ACL: acl.NewManager(nil, nil), Reviewer: &mockReviewer{}})
+	if err := c.SetCommissioningRestrictions([]acl.RestrictionEntry{onOffRestriction}); err != nil {
+		t.Fatalf("SetCommissioningRestrictions: %v", err)
+	}
+	if err := c.SetRestrictions(1, []acl.RestrictionEntry{onOffRestriction}); err != nil {
+		t.Fatalf("SetRestrictions: %v", err)
+	}
+	version := c.DataVersion()
+	if err := c.SetRestrictions(2, []acl.RestrictionEntry{onOffRestriction}); err != nil {
+		t.Fatalf("SetRestrictions: %v", err)
+	}
+	if c.DataVersion() == version {
+		t.Error("SetRestrictions did not bump the data version")
+	}
+
+	commissioning, err := decodeRestrictionEntries(readAttribute(t, c, AttrCommissioningARL, 0, false))
+	if err != nil {
+		t.Fatalf("decode CommissioningARL: %v", err)
+	}
+	if len(commissioning) != 1 || commissioning[0].Cluster != 0x0006 ||
+		commissioning[0].Restrictions[0].Type != acl.RestrictionCommandForbidden {
+		t.Errorf("CommissioningARL = %+v", commissioning)
+	}
+
+	filtered, err := decodeRestrictionEntries(readAttribute(t, c, AttrARL, 2, true))
+	if err != nil {
+		t.Fatalf("decode ARL: %v", err)
+	}
+	if len(filtered) != 1 {
+		t.Errorf("fabric-filtered ARL entries = %d, want 1", len(filtered))
+	}
+
+	all, err := decodeRestrictionEntries(readAttribute(t, c, AttrARL, 2, false))
+	if err != nil {
+		t.Fatalf("decode ARL: %v", err)
+	}
+	if len(all) != 2 {
+		t.Errorf("unfiltered ARL entries = %d, want 2", len(all))
+	}
+}
+
+// encodeReviewRequest encodes a ReviewFabricRestrictions request.
+func encodeReviewRequest(t *testing.T, arl []acl.RestrictionEntry) *tlv.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeRestrictionEntries(w, tlv.ContextTag(0), arl, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.EndContainer(); err != nil {
+		t.Fatal(err)
+	}
+	return tlv.NewReader(bytes.NewReader(buf.Bytes()))
+}
+
+func TestReviewFabricRestrictions(t *testing.T) {
+	reviewer := &mockReviewer{}
+	publisher := &mockEventPublisher{}
+	c := New(Config{ACL: acl.NewManager(nil, nil), Reviewer: reviewer, EventPublisher: publisher})
+
+	invoke := func(fi fabric.FabricIndex, arl []acl.RestrictionEntry) ([]byte, error) {
+		return c.InvokeCommand(context.Background(), datamodel.InvokeRequest{
+			Path:    datamodel.ConcreteCommandPath{Cluster: ClusterID, Command: CmdReviewFabricRestrictions},
+			Subject: &datamodel.SubjectDescriptor{FabricIndex: fi},
+		}, encodeReviewRequest(t, arl))
+	}
+
+	resp, err := invoke(1, []acl.RestrictionEntry{onOffRestriction})
+	if err != nil {
+		t.Fatalf("InvokeCommand: %v", err)
+	}
+	if reviewer.fabricIndex != 1 || len(reviewer.arl) != 1 || reviewer.arl[0].FabricIndex != 1 {
+		t.Errorf("reviewer got fabric %d, arl %+v", reviewer.fabricIndex, reviewer.arl)
+	}
+
+	r := tlv.NewReader(bytes.NewReader(resp))
+	if err := r.Next(); err != nil {
+		t.Fatal(err)
+	}
+	r.EnterContainer()
+	if err := r.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if token, _ := r.Uint(); token != reviewer.token {
+		t.Errorf("response token = %d, want %d", token, reviewer.token)
+	}
+
+	// Each review gets a new token.
+	first := reviewer.token
+	if _, err := invoke(1, []acl.RestrictionEntry{onOffRestriction}); err != nil {
+		t.Fatalf("InvokeCommand: %v", err)
+	}
+	if reviewer.token == first {
+		t.Error("token reused")
+	}
+
+	// Invalid entries are a constraint error.
+	bad := onOffRestriction
+	bad.Endpoint = 0xFFFF
+	if _, err := invoke(1, []acl.RestrictionEntry{bad}); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("invalid entry error = %v, want ErrConstraintError", err)
+	}
+
+	// The command is fabric-scoped.
+	if _, err := invoke(0, []acl.RestrictionEntry{onOffRestriction}); !errors.Is(err, datamodel.ErrUnsupportedAccess) {
+		t.Errorf("no fabric error = %v, want ErrUnsupportedAccess", err)
+	}
+
+	// The device reports progress with the event.
+	if _, err := c.EmitFabricRestrictionReviewUpdate(FabricRestrictionReviewUpdateEvent{
+		Token:             reviewer.token,
+		ARLRequestFlowURL: "https://example.com/review",
+		FabricIndex:       1,
+	}); err != nil {
+		t.Fatalf("EmitFabricRestrictionReviewUpdate: %v", err)
+	}
+	if publisher.eventID != EventFabricRestrictionReviewUpdate || publisher.fabricIndex != 1 {
+		t.Errorf("published event %d on fabric %d", publisher.eventID, publisher.fabricIndex)
+	}
+	if _, err := c.EmitFabricRestrictionReviewUpdate(FabricRestrictionReviewUpdateEvent{
+		Instruction: string(make([]byte, MaxInstructionLength+1)),
+		FabricIndex: 1,
+	}); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("long instruction error = %v, want ErrConstraintError", err)
+	}
+}
@@ -0,0 +1,110 @@
+package accesscontrol
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/backkem/matter/pkg/acl"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// ReviewFabricRestrictionsRequest represents the ReviewFabricRestrictions
+// command request (Spec 9.10.8.1).
+type ReviewFabricRestrictionsRequest struct {
+	ARL []acl.RestrictionEntry
+}
+
+// ReviewFabricRestrictionsResponse represents the
+// ReviewFabricRestrictionsResponse command (Spec 9.10.8.2).
+type ReviewFabricRestrictionsResponse struct {
+	Token uint64
+}
+
+// handleReviewFabricRestrictions handles the ReviewFabricRestrictions command.
+//
+// Spec: Section 9.10.8.1
+func (c *Cluster) handleReviewFabricRestrictions(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	fabricIndex := req.FabricIndex()
+	if !fabricIndex.IsValid() {
+		return nil, datamodel.ErrUnsupportedAccess
+	}
+
+	var reviewReq ReviewFabricRestrictionsRequest
+	if err := decodeReviewFabricRestrictionsRequest(r, &reviewReq); err != nil {
+		return nil, err
+	}
+	for i := range reviewReq.ARL {
+		if err := acl.ValidateRestrictionEntry(&reviewReq.ARL[i]); err != nil {
+			return nil, datamodel.ErrConstraintError
+		}
+		reviewReq.ARL[i].FabricIndex = fabricIndex
+	}
+
+	token := c.newToken()
+	if err := c.config.Reviewer.ReviewFabricRestrictions(fabricIndex, token, reviewReq.ARL); err != nil {
+		return nil, err
+	}
+
+	return encodeReviewFabricRestrictionsResponse(ReviewFabricRestrictionsResponse{Token: token})
+}
+
+// decodeReviewFabricRestrictionsRequest decodes a ReviewFabricRestrictions
+// request from TLV.
+func decodeReviewFabricRestrictionsRequest(r *tlv.Reader, req *ReviewFabricRestrictionsRequest) error {
+	if r == nil {
+		return datamodel.ErrInvalidCommand
+	}
+	if err := r.Next(); err != nil {
+		return datamodel.ErrInvalidCommand
+	}
+	if r.Type() != tlv.ElementTypeStruct {
+		return datamodel.ErrInvalidCommand
+	}
+	if err := r.EnterContainer(); err != nil {
+		return datamodel.ErrInvalidCommand
+	}
+
+	found := false
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		tag := r.Tag()
+		if !tag.IsContext() || tag.TagNumber() != 0 {
+			continue
+		}
+		arl, err := decodeRestrictionEntries(r)
+		if err != nil {
+			return err
+		}
+		req.ARL = arl
+		found = true
+	}
+
+	if err := r.ExitContainer(); err != nil {
+		return datamodel.ErrInvalidCommand
+	}
+	if !found {
+		return datamodel.ErrInvalidCommand
+	}
+	return nil
+}
+
+// encodeReviewFabricRestrictionsResponse encodes the response to TLV.
+func encodeReviewFabricRestrictionsResponse(resp ReviewFabricRestrictionsResponse) ([]byte, error) {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return nil, err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), resp.Token); err != nil {
+		return nil, err
+	}
+	if err := w.EndContainer(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
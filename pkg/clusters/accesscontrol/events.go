@@ -0,0 +1,76 @@
+package accesscontrol
+
+import (
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Field length limits of FabricRestrictionReviewUpdate (Spec 9.10.9.3).
+const (
+	MaxInstructionLength       = 512
+	MaxARLRequestFlowURLLength = 256
+)
+
+// FabricRestrictionReviewUpdateEvent reports progress of a review started
+// with ReviewFabricRestrictions (Spec 9.10.9.3).
+// Priority: INFO, Conformance: ManagedDevice
+type FabricRestrictionReviewUpdateEvent struct {
+	// Token is the token returned by ReviewFabricRestrictions.
+	Token uint64
+
+	// Instruction tells the user how to complete the review.
+	// Optional - omitted if empty.
+	Instruction string
+
+	// ARLRequestFlowURL is a URL where the user can complete the review.
+	// Optional - omitted if empty.
+	ARLRequestFlowURL string
+
+	// FabricIndex is the fabric that requested the review.
+	FabricIndex fabric.FabricIndex
+}
+
+// MarshalTLV implements the TLVMarshaler interface.
+func (e FabricRestrictionReviewUpdateEvent) MarshalTLV(w *tlv.Writer) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), e.Token); err != nil {
+		return err
+	}
+	if e.Instruction != "" {
+		if err := w.PutString(tlv.ContextTag(1), e.Instruction); err != nil {
+			return err
+		}
+	}
+	if e.ARLRequestFlowURL != "" {
+		if err := w.PutString(tlv.ContextTag(2), e.ARLRequestFlowURL); err != nil {
+			return err
+		}
+	}
+	if err := w.PutUint(tlv.ContextTag(tagFabricIndex), uint64(e.FabricIndex)); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// EmitFabricRestrictionReviewUpdate emits the FabricRestrictionReviewUpdate
+// event for a review, e.g. to send the user to the vendor's request flow
+// or to report that the review finished.
+//
+// Spec: Section 9.10.9.3
+func (c *Cluster) EmitFabricRestrictionReviewUpdate(event FabricRestrictionReviewUpdateEvent) (datamodel.EventNumber, error) {
+	if !c.isManagedDevice() {
+		return 0, datamodel.ErrEventNotFound
+	}
+	if len(event.Instruction) > MaxInstructionLength || len(event.ARLRequestFlowURL) > MaxARLRequestFlowURLLength {
+		return 0, datamodel.ErrConstraintError
+	}
+	if !c.EventSource.IsBound() {
+		return 0, nil // No publisher, silently skip
+	}
+
+	return c.EventSource.EmitFabricScoped(EventFabricRestrictionReviewUpdate, datamodel.EventPriorityInfo,
+		event, uint8(event.FabricIndex))
+}
@@ -0,0 +1,327 @@
+package accesscontrol
+
+import (
+	"github.com/backkem/matter/pkg/acl"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Struct field tags.
+const (
+	tagFabricIndex = 0xFE
+
+	// AccessControlEntryStruct (Spec 9.10.5)
+	tagEntryPrivilege = 1
+	tagEntryAuthMode  = 2
+	tagEntrySubjects  = 3
+	tagEntryTargets   = 4
+
+	// AccessControlTargetStruct (Spec 9.10.5)
+	tagTargetCluster    = 0
+	tagTargetEndpoint   = 1
+	tagTargetDeviceType = 2
+
+	// AccessRestrictionStruct (Spec 9.10.5)
+	tagRestrictionType = 0
+	tagRestrictionID   = 1
+
+	// AccessRestrictionEntryStruct and
+	// CommissioningAccessRestrictionEntryStruct (Spec 9.10.5)
+	tagARLEndpoint     = 0
+	tagARLCluster      = 1
+	tagARLRestrictions = 2
+)
+
+// writeEntry writes an AccessControlEntryStruct. Fabric-sensitive fields
+// are omitted unless sensitive is true.
+func writeEntry(w *tlv.Writer, entry *acl.Entry, sensitive bool) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+
+	if sensitive {
+		if err := w.PutUint(tlv.ContextTag(tagEntryPrivilege), uint64(entry.Privilege)); err != nil {
+			return err
+		}
+		if err := w.PutUint(tlv.ContextTag(tagEntryAuthMode), uint64(entry.AuthMode)); err != nil {
+			return err
+		}
+
+		// Subjects: null for wildcard
+		if len(entry.Subjects) == 0 {
+			if err := w.PutNull(tlv.ContextTag(tagEntrySubjects)); err != nil {
+				return err
+			}
+		} else {
+			if err := w.StartArray(tlv.ContextTag(tagEntrySubjects)); err != nil {
+				return err
+			}
+			for _, subject := range entry.Subjects {
+				if err := w.PutUint(tlv.Anonymous(), subject); err != nil {
+					return err
+				}
+			}
+			if err := w.EndContainer(); err != nil {
+				return err
+			}
+		}
+
+		// Targets: null for wildcard
+		if len(entry.Targets) == 0 {
+			if err := w.PutNull(tlv.ContextTag(tagEntryTargets)); err != nil {
+				return err
+			}
+		} else {
+			if err := w.StartArray(tlv.ContextTag(tagEntryTargets)); err != nil {
+				return err
+			}
+			for i := range entry.Targets {
+				if err := writeTarget(w, &entry.Targets[i]); err != nil {
+					return err
+				}
+			}
+			if err := w.EndContainer(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := w.PutUint(tlv.ContextTag(tagFabricIndex), uint64(entry.FabricIndex)); err != nil {
+		return err
+	}
+
+	return w.EndContainer()
+}
+
+// writeTarget writes an AccessControlTargetStruct.
+func writeTarget(w *tlv.Writer, target *acl.Target) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	if err := putNullableUint(w, tagTargetCluster, target.Cluster); err != nil {
+		return err
+	}
+	var endpoint *uint32
+	if target.Endpoint != nil {
+		v := uint32(*target.Endpoint)
+		endpoint = &v
+	}
+	if err := putNullableUint(w, tagTargetEndpoint, endpoint); err != nil {
+		return err
+	}
+	if err := putNullableUint(w, tagTargetDeviceType, target.DeviceType); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// writeRestrictionEntries writes a list of restriction entries.
+func writeRestrictionEntries(w *tlv.Writer, tag tlv.Tag, entries []acl.RestrictionEntry, withFabric bool) error {
+	if err := w.StartArray(tag); err != nil {
+		return err
+	}
+	for i := range entries {
+		if err := writeRestrictionEntry(w, tlv.Anonymous(), &entries[i], withFabric); err != nil {
+			return err
+		}
+	}
+	return w.EndContainer()
+}
+
+// writeRestrictionEntry writes an AccessRestrictionEntryStruct, or a
+// CommissioningAccessRestrictionEntryStruct if withFabric is false.
+func writeRestrictionEntry(w *tlv.Writer, tag tlv.Tag, entry *acl.RestrictionEntry, withFabric bool) error {
+	if err := w.StartStructure(tag); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(tagARLEndpoint), uint64(entry.Endpoint)); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(tagARLCluster), uint64(entry.Cluster)); err != nil {
+		return err
+	}
+
+	if err := w.StartArray(tlv.ContextTag(tagARLRestrictions)); err != nil {
+		return err
+	}
+	for _, r := range entry.Restrictions {
+		if err := w.StartStructure(tlv.Anonymous()); err != nil {
+			return err
+		}
+		if err := w.PutUint(tlv.ContextTag(tagRestrictionType), uint64(r.Type)); err != nil {
+			return err
+		}
+		if err := putNullableUint(w, tagRestrictionID, r.ID); err != nil {
+			return err
+		}
+		if err := w.EndContainer(); err != nil {
+			return err
+		}
+	}
+	if err := w.EndContainer(); err != nil {
+		return err
+	}
+
+	if withFabric {
+		if err := w.PutUint(tlv.ContextTag(tagFabricIndex), uint64(entry.FabricIndex)); err != nil {
+			return err
+		}
+	}
+	return w.EndContainer()
+}
+
+// putNullableUint writes v, or null if v is nil.
+func putNullableUint(w *tlv.Writer, tag uint8, v *uint32) error {
+	if v == nil {
+		return w.PutNull(tlv.ContextTag(tag))
+	}
+	return w.PutUint(tlv.ContextTag(tag), uint64(*v))
+}
+
+// decodeRestrictionEntries decodes a list of
+// CommissioningAccessRestrictionEntryStruct. The reader must be positioned
+// on the list.
+func decodeRestrictionEntries(r *tlv.Reader) ([]acl.RestrictionEntry, error) {
+	if r.Type() != tlv.ElementTypeArray && r.Type() != tlv.ElementTypeList {
+		return nil, datamodel.ErrInvalidCommand
+	}
+	if err := r.EnterContainer(); err != nil {
+		return nil, datamodel.ErrInvalidCommand
+	}
+
+	var entries []acl.RestrictionEntry
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		entry, err := decodeRestrictionEntry(r)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := r.ExitContainer(); err != nil {
+		return nil, datamodel.ErrInvalidCommand
+	}
+	return entries, nil
+}
+
+// decodeRestrictionEntry decodes a CommissioningAccessRestrictionEntryStruct.
+func decodeRestrictionEntry(r *tlv.Reader) (acl.RestrictionEntry, error) {
+	var entry acl.RestrictionEntry
+	if r.Type() != tlv.ElementTypeStruct {
+		return entry, datamodel.ErrInvalidCommand
+	}
+	if err := r.EnterContainer(); err != nil {
+		return entry, datamodel.ErrInvalidCommand
+	}
+
+	var haveEndpoint, haveCluster, haveRestrictions bool
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		tag := r.Tag()
+		if !tag.IsContext() {
+			continue
+		}
+
+		switch tag.TagNumber() {
+		case tagARLEndpoint:
+			v, err := r.Uint()
+			if err != nil || v > 0xFFFF {
+				return entry, datamodel.ErrInvalidCommand
+			}
+			entry.Endpoint = uint16(v)
+			haveEndpoint = true
+		case tagARLCluster:
+			v, err := r.Uint()
+			if err != nil || v > 0xFFFFFFFF {
+				return entry, datamodel.ErrInvalidCommand
+			}
+			entry.Cluster = uint32(v)
+			haveCluster = true
+		case tagARLRestrictions:
+			restrictions, err := decodeRestrictions(r)
+			if err != nil {
+				return entry, err
+			}
+			entry.Restrictions = restrictions
+			haveRestrictions = true
+		}
+	}
+
+	if err := r.ExitContainer(); err != nil {
+		return entry, datamodel.ErrInvalidCommand
+	}
+	if !haveEndpoint || !haveCluster || !haveRestrictions {
+		return entry, datamodel.ErrInvalidCommand
+	}
+	return entry, nil
+}
+
+// decodeRestrictions decodes a list of AccessRestrictionStruct.
+func decodeRestrictions(r *tlv.Reader) ([]acl.Restriction, error) {
+	if err := r.EnterContainer(); err != nil {
+		return nil, datamodel.ErrInvalidCommand
+	}
+
+	var restrictions []acl.Restriction
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		if r.Type() != tlv.ElementTypeStruct {
+			return nil, datamodel.ErrInvalidCommand
+		}
+		if err := r.EnterContainer(); err != nil {
+			return nil, datamodel.ErrInvalidCommand
+		}
+
+		var restriction acl.Restriction
+		haveType := false
+		for {
+			if err := r.Next(); err != nil || r.IsEndOfContainer() {
+				break
+			}
+			tag := r.Tag()
+			if !tag.IsContext() {
+				continue
+			}
+
+			switch tag.TagNumber() {
+			case tagRestrictionType:
+				v, err := r.Uint()
+				if err != nil || v > 0xFF {
+					return nil, datamodel.ErrInvalidCommand
+				}
+				restriction.Type = acl.RestrictionType(v)
+				haveType = true
+			case tagRestrictionID:
+				if r.Type() == tlv.ElementTypeNull {
+					continue
+				}
+				v, err := r.Uint()
+				if err != nil || v > 0xFFFFFFFF {
+					return nil, datamodel.ErrInvalidCommand
+				}
+				id := uint32(v)
+				restriction.ID = &id
+			}
+		}
+
+		if err := r.ExitContainer(); err != nil {
+			return nil, datamodel.ErrInvalidCommand
+		}
+		if !haveType {
+			return nil, datamodel.ErrInvalidCommand
+		}
+		restrictions = append(restrictions, restriction)
+	}
+
+	if err := r.ExitContainer(); err != nil {
+		return nil, datamodel.ErrInvalidCommand
+	}
+	return restrictions, nil
+}
@@ -0,0 +1,162 @@
+package clusters
+
+import (
+	"errors"
+
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// MeasurementType identifies a kind of electrical measurement
+// (MeasurementTypeEnum, Spec 2.1.4.1). It is shared by the electrical
+// power and energy measurement clusters.
+type MeasurementType uint16
+
+const (
+	MeasurementTypeUnspecified      MeasurementType = 0x00
+	MeasurementTypeVoltage          MeasurementType = 0x01 // mV
+	MeasurementTypeActiveCurrent    MeasurementType = 0x02 // mA
+	MeasurementTypeReactiveCurrent  MeasurementType = 0x03 // mA
+	MeasurementTypeApparentCurrent  MeasurementType = 0x04 // mA
+	MeasurementTypeActivePower      MeasurementType = 0x05 // mW
+	MeasurementTypeReactivePower    MeasurementType = 0x06 // mVAR
+	MeasurementTypeApparentPower    MeasurementType = 0x07 // mVA
+	MeasurementTypeRMSVoltage       MeasurementType = 0x08 // mV
+	MeasurementTypeRMSCurrent       MeasurementType = 0x09 // mA
+	MeasurementTypeRMSPower         MeasurementType = 0x0A // mW
+	MeasurementTypeFrequency        MeasurementType = 0x0B // mHz
+	MeasurementTypePowerFactor      MeasurementType = 0x0C // 0.01%
+	MeasurementTypeNeutralCurrent   MeasurementType = 0x0D // mA
+	MeasurementTypeElectricalEnergy MeasurementType = 0x0E // mWh
+)
+
+// ErrInvalidAccuracy is returned when a MeasurementAccuracy is malformed.
+var ErrInvalidAccuracy = errors.New("invalid measurement accuracy")
+
+// MeasurementAccuracyRange gives the accuracy of readings within
+// [RangeMin, RangeMax] (MeasurementAccuracyRangeStruct, Spec 2.1.4.3).
+// At least one of PercentMax and FixedMax must be set. Percentages are in
+// 0.01% units; fixed values are in the unit of the measurement type.
+type MeasurementAccuracyRange struct {
+	RangeMin int64
+	RangeMax int64
+
+	PercentMax     *uint16
+	PercentMin     *uint16
+	PercentTypical *uint16
+
+	FixedMax     *uint64
+	FixedMin     *uint64
+	FixedTypical *uint64
+}
+
+// MeasurementAccuracy describes what a server can measure for one
+// measurement type and how accurately (MeasurementAccuracyStruct,
+// Spec 2.1.4.2).
+type MeasurementAccuracy struct {
+	MeasurementType MeasurementType
+
+	// Measured is true if the value is measured, false if estimated.
+	Measured bool
+
+	MinMeasuredValue int64
+	MaxMeasuredValue int64
+
+	// AccuracyRanges cover [MinMeasuredValue, MaxMeasuredValue] in
+	// ascending, non-overlapping order. At least one is required.
+	AccuracyRanges []MeasurementAccuracyRange
+}
+
+// Validate checks the constraints of Spec 2.1.4.2 and 2.1.4.3.
+func (a MeasurementAccuracy) Validate() error {
+	if a.MinMeasuredValue > a.MaxMeasuredValue || len(a.AccuracyRanges) == 0 {
+		return ErrInvalidAccuracy
+	}
+	for i, r := range a.AccuracyRanges {
+		if r.RangeMin > r.RangeMax {
+			return ErrInvalidAccuracy
+		}
+		if r.RangeMin < a.MinMeasuredValue || r.RangeMax > a.MaxMeasuredValue {
+			return ErrInvalidAccuracy
+		}
+		if i > 0 && r.RangeMin <= a.AccuracyRanges[i-1].RangeMax {
+			return ErrInvalidAccuracy
+		}
+		if r.PercentMax == nil && r.FixedMax == nil {
+			return ErrInvalidAccuracy
+		}
+	}
+	return nil
+}
+
+// InRange returns true if value lies within [MinMeasuredValue, MaxMeasuredValue].
+func (a MeasurementAccuracy) InRange(value int64) bool {
+	return value >= a.MinMeasuredValue && value <= a.MaxMeasuredValue
+}
+
+// MarshalTLV writes a MeasurementAccuracyStruct with an anonymous tag.
+func (a MeasurementAccuracy) MarshalTLV(w *tlv.Writer) error {
+	return a.Marshal(w, tlv.Anonymous())
+}
+
+// Marshal writes a MeasurementAccuracyStruct with the given tag.
+func (a MeasurementAccuracy) Marshal(w *tlv.Writer, tag tlv.Tag) error {
+	if err := w.StartStructure(tag); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(a.MeasurementType)); err != nil {
+		return err
+	}
+	if err := w.PutBool(tlv.ContextTag(1), a.Measured); err != nil {
+		return err
+	}
+	if err := w.PutInt(tlv.ContextTag(2), a.MinMeasuredValue); err != nil {
+		return err
+	}
+	if err := w.PutInt(tlv.ContextTag(3), a.MaxMeasuredValue); err != nil {
+		return err
+	}
+	if err := w.StartArray(tlv.ContextTag(4)); err != nil {
+		return err
+	}
+	for _, r := range a.AccuracyRanges {
+		if err := r.marshal(w); err != nil {
+			return err
+		}
+	}
+	if err := w.EndContainer(); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// marshal writes an anonymous MeasurementAccuracyRangeStruct.
+func (r MeasurementAccuracyRange) marshal(w *tlv.Writer) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	if err := w.PutInt(tlv.ContextTag(0), r.RangeMin); err != nil {
+		return err
+	}
+	if err := w.PutInt(tlv.ContextTag(1), r.RangeMax); err != nil {
+		return err
+	}
+	percents := []*uint16{r.PercentMax, r.PercentMin, r.PercentTypical}
+	for i, p := range percents {
+		if p == nil {
+			continue
+		}
+		if err := w.PutUint(tlv.ContextTag(uint8(2+i)), uint64(*p)); err != nil {
+			return err
+		}
+	}
+	fixed := []*uint64{r.FixedMax, r.FixedMin, r.FixedTypical}
+	for i, f := range fixed {
+		if f == nil {
+			continue
+		}
+		if err := w.PutUint(tlv.ContextTag(uint8(5+i)), *f); err != nil {
+			return err
+		}
+	}
+	return w.EndContainer()
+}
@@ -0,0 +1,104 @@
+package clusters
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+func TestMeasurementAccuracy_Validate(t *testing.T) {
+	pct := uint16(100)
+	fixed := uint64(50)
+
+	tests := []struct {
+		name string
+		a    MeasurementAccuracy
+		ok   bool
+	}{
+		{"valid", MeasurementAccuracy{
+			MaxMeasuredValue: 1000,
+			AccuracyRanges:   []MeasurementAccuracyRange{{RangeMax: 500, PercentMax: &pct}, {RangeMin: 501, RangeMax: 1000, FixedMax: &fixed}},
+		}, true},
+		{"min above max", MeasurementAccuracy{
+			MinMeasuredValue: 10,
+			AccuracyRanges:   []MeasurementAccuracyRange{{PercentMax: &pct}},
+		}, false},
+		{"no ranges", MeasurementAccuracy{MaxMeasuredValue: 1000}, false},
+		{"range outside bounds", MeasurementAccuracy{
+			MaxMeasuredValue: 1000,
+			AccuracyRanges:   []MeasurementAccuracyRange{{RangeMax: 2000, PercentMax: &pct}},
+		}, false},
+		{"overlapping ranges", MeasurementAccuracy{
+			MaxMeasuredValue: 1000,
+			AccuracyRanges:   []MeasurementAccuracyRange{{RangeMax: 500, PercentMax: &pct}, {RangeMin: 500, RangeMax: 1000, PercentMax: &pct}},
+		}, false},
+		{"no max accuracy", MeasurementAccuracy{
+			MaxMeasuredValue: 1000,
+			AccuracyRanges:   []MeasurementAccuracyRange{{RangeMax: 1000}},
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.a.Validate()
+			if tt.ok && err != nil {
+				t.Errorf("Validate() = %v, want nil", err)
+			}
+			if !tt.ok && !errors.Is(err, ErrInvalidAccuracy) {
+				t.Errorf("Validate() = %v, want ErrInvalidAccuracy", err)
+			}
+		})
+	}
+}
+
+func TestMeasurementAccuracy_Marshal(t *testing.T) {
+	pct := uint16(150)
+	a := MeasurementAccuracy{
+		MeasurementType:  MeasurementTypeActivePower,
+		Measured:         true,
+		MinMeasuredValue: -1000,
+		MaxMeasuredValue: 1000,
+		AccuracyRanges:   []MeasurementAccuracyRange{{RangeMin: -1000, RangeMax: 1000, PercentMax: &pct}},
+	}
+
+	var buf bytes.Buffer
+	if err := a.MarshalTLV(tlv.NewWriter(&buf)); err != nil {
+		t.Fatalf("MarshalTLV() error = %v", err)
+	}
+
+	r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+	r.Next()
+	r.EnterContainer()
+	r.Next()
+	if v, _ := r.Uint(); MeasurementType(v) != MeasurementTypeActivePower {
+		t.Errorf("MeasurementType = %d, want %d", v, MeasurementTypeActivePower)
+	}
+	r.Next()
+	if v, _ := r.Bool(); !v {
+		t.Error("Measured = false, want true")
+	}
+	r.Next()
+	if v, _ := r.Int(); v != -1000 {
+		t.Errorf("MinMeasuredValue = %d, want -1000", v)
+	}
+	r.Next() // MaxMeasuredValue
+	r.Next()
+	r.EnterContainer() // AccuracyRanges
+	r.Next()
+	r.EnterContainer()
+	r.Next()
+	r.Next()
+	r.Next()
+	if r.Tag().TagNumber() != 2 {
+		t.Fatalf("PercentMax tag = %d, want 2", r.Tag().TagNumber())
+	}
+	if v, _ := r.Uint(); v != 150 {
+		t.Errorf("PercentMax = %d, want 150", v)
+	}
+	r.Next()
+	if !r.IsEndOfContainer() {
+		t.Error("unexpected fields after PercentMax")
+	}
+}
@@ -0,0 +1,62 @@
+package admincommissioning
+
+import (
+	"bytes"
+
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Client-side encoding functions for Administrator Commissioning commands.
+// These are used by an administrator (controller) to open a commissioning
+// window for another commissioner. All commands must be sent as timed
+// invokes.
+
+// EncodeOpenCommissioningWindowRequest encodes an OpenCommissioningWindow
+// request to TLV.
+func EncodeOpenCommissioningWindowRequest(req *OpenCommissioningWindowRequest) ([]byte, error) {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return nil, err
+	}
+	if err := w.PutUint(tlv.ContextTag(tagCommissioningTimeout), uint64(req.CommissioningTimeout)); err != nil {
+		return nil, err
+	}
+	if err := w.PutBytes(tlv.ContextTag(tagPAKEVerifier), req.PAKEPasscodeVerifier); err != nil {
+		return nil, err
+	}
+	if err := w.PutUint(tlv.ContextTag(tagDiscriminator), uint64(req.Discriminator)); err != nil {
+		return nil, err
+	}
+	if err := w.PutUint(tlv.ContextTag(tagIterations), uint64(req.Iterations)); err != nil {
+		return nil, err
+	}
+	if err := w.PutBytes(tlv.ContextTag(tagSalt), req.Salt); err != nil {
+		return nil, err
+	}
+	if err := w.EndContainer(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// EncodeOpenBasicCommissioningWindowRequest encodes an
+// OpenBasicCommissioningWindow request to TLV.
+func EncodeOpenBasicCommissioningWindowRequest(req *OpenBasicCommissioningWindowRequest) ([]byte, error) {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return nil, err
+	}
+	if err := w.PutUint(tlv.ContextTag(tagCommissioningTimeout), uint64(req.CommissioningTimeout)); err != nil {
+		return nil, err
+	}
+	if err := w.EndContainer(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,335 @@
+// Package admincommissioning implements the Administrator Commissioning
+// Cluster (0x003C).
+//
+// The cluster lets an administrator open a commissioning window on an
+// already-commissioned node, so another commissioner can add it to a new
+// fabric (multi-admin). Windows are opened with a verifier supplied by the
+// administrator (Enhanced Commissioning Method) or, with the Basic feature,
+// with the node's onboarding passcode.
+//
+// The cluster does not manage windows itself: a WindowManager, typically
+// the node, opens and closes them and reports closure with WindowClosed.
+//
+// This cluster is mandatory on the root endpoint (endpoint 0).
+//
+// Spec Reference: Section 11.19
+//
+// C++ Reference: src/app/clusters/administrator-commissioning-server/
+package admincommissioning
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/securechannel/pase"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x003C
+	ClusterRevision uint16              = 1
+)
+
+// Attribute IDs (Spec 11.19.7).
+const (
+	AttrWindowStatus     datamodel.AttributeID = 0x0000
+	AttrAdminFabricIndex datamodel.AttributeID = 0x0001
+	AttrAdminVendorID    datamodel.AttributeID = 0x0002
+)
+
+// Command IDs (Spec 11.19.8).
+const (
+	CmdOpenCommissioningWindow      datamodel.CommandID = 0x00
+	CmdOpenBasicCommissioningWindow datamodel.CommandID = 0x01
+	CmdRevokeCommissioning          datamodel.CommandID = 0x02
+)
+
+// Feature bits (Spec 11.19.4).
+type Feature uint32
+
+const (
+	// FeatureBasic indicates support for OpenBasicCommissioningWindow.
+	FeatureBasic Feature = 1 << 0 // BC
+)
+
+// WindowStatus is the CommissioningWindowStatusEnum (Spec 11.19.5.1).
+type WindowStatus uint8
+
+const (
+	WindowNotOpen            WindowStatus = 0
+	WindowEnhancedWindowOpen WindowStatus = 1
+	WindowBasicWindowOpen    WindowStatus = 2
+)
+
+// String returns the name of the window status.
+func (s WindowStatus) String() string {
+	switch s {
+	case WindowNotOpen:
+		return "WindowNotOpen"
+	case WindowEnhancedWindowOpen:
+		return "EnhancedWindowOpen"
+	case WindowBasicWindowOpen:
+		return "BasicWindowOpen"
+	default:
+		return "Unknown"
+	}
+}
+
+// Cluster-specific status codes (Spec 11.19.6).
+const (
+	StatusCodeBusy               uint8 = 0x02
+	StatusCodePAKEParameterError uint8 = 0x03
+	StatusCodeWindowNotOpen      uint8 = 0x04
+)
+
+// Commissioning timeout limits (Spec 5.4.2.3.1).
+const (
+	MinCommissioningTimeout = 3 * time.Minute
+	MaxCommissioningTimeout = 15 * time.Minute
+)
+
+// MaxDiscriminator is the largest 12-bit discriminator.
+const MaxDiscriminator uint16 = 0x0FFF
+
+// Errors returned by commands. ErrBusy, ErrPAKEParameterError and
+// ErrWindowNotOpen correspond to the cluster-specific status codes.
+var (
+	// ErrBusy is returned when a commissioning window is already open or
+	// the WindowManager cannot open one.
+	ErrBusy = errors.New("admincommissioning: busy")
+
+	ErrPAKEParameterError = errors.New("admincommissioning: PAKE parameter error")
+	ErrWindowNotOpen      = errors.New("admincommissioning: window not open")
+)
+
+// PAKEParameters are the SPAKE2+ parameters of an enhanced commissioning
+// window, supplied by the administrator that opens it.
+type PAKEParameters struct {
+	// Verifier is the SPAKE2+ verifier derived from the new passcode.
+	Verifier *pase.Verifier
+
+	// Discriminator is the 12-bit discriminator to advertise.
+	Discriminator uint16
+
+	// Iterations is the PBKDF2 iteration count.
+	Iterations uint32
+
+	// Salt is the PBKDF2 salt (16-32 bytes).
+	Salt []byte
+}
+
+// WindowManager opens and closes the node's commissioning window.
+type WindowManager interface {
+	// IsCommissioningWindowOpen returns true if a commissioning window is open.
+	IsCommissioningWindowOpen() bool
+
+	// OpenEnhancedCommissioningWindow opens a window that accepts PASE
+	// with the given parameters. Any error is reported as Busy.
+	OpenEnhancedCommissioningWindow(timeout time.Duration, params PAKEParameters) error
+
+	// OpenCommissioningWindow opens a window that accepts PASE with the
+	// node's onboarding passcode. Only used with FeatureBasic. Any error is
+	// reported as Busy.
+	OpenCommissioningWindow(timeout time.Duration) error
+
+	// CloseCommissioningWindow closes the open commissioning window.
+	CloseCommissioningWindow() error
+}
+
+// Config provides dependencies for the Administrator Commissioning cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to (should be 0).
+	EndpointID datamodel.EndpointID
+
+	// WindowManager opens and closes commissioning windows. Required.
+	WindowManager WindowManager
+
+	// Fabrics resolves the vendor ID reported in AdminVendorId.
+	// Optional - if nil, AdminVendorId is null.
+	Fabrics *fabric.Table
+
+	// Basic enables the Basic feature and OpenBasicCommissioningWindow.
+	Basic bool
+}
+
+// Cluster implements the Administrator Commissioning cluster (0x003C).
+type Cluster struct {
+	*datamodel.ClusterBase
+	config Config
+
+	// Window opened by an administrator (protected by mu)
+	mu          sync.RWMutex
+	status      WindowStatus
+	adminFabric fabric.FabricIndex // 0 if null
+	adminVendor *fabric.VendorID
+
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Administrator Commissioning cluster.
+func New(cfg Config) *Cluster {
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		config:      cfg,
+	}
+	if cfg.Basic {
+		c.SetFeatureMap(uint32(FeatureBasic))
+	}
+	c.attrList = c.buildAttributeList()
+	return c
+}
+
+// buildAttributeList constructs the list of supported attributes.
+func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+
+	attrs := []datamodel.AttributeEntry{
+		datamodel.NewReadOnlyAttribute(AttrWindowStatus, 0, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrAdminFabricIndex, datamodel.AttrQualityNullable, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrAdminVendorID, datamodel.AttrQualityNullable, viewPriv),
+	}
+	return datamodel.MergeAttributeLists(attrs)
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	adminPriv := datamodel.PrivilegeAdminister
+
+	cmds := []datamodel.CommandEntry{
+		datamodel.NewCommandEntry(CmdOpenCommissioningWindow, datamodel.CmdQualityTimed, adminPriv),
+		datamodel.NewCommandEntry(CmdRevokeCommissioning, datamodel.CmdQualityTimed, adminPriv),
+	}
+	if c.config.Basic {
+		cmds = append(cmds, datamodel.NewCommandEntry(CmdOpenBasicCommissioningWindow, datamodel.CmdQualityTimed, adminPriv))
+	}
+	return cmds
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return nil
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	switch req.Path.Attribute {
+	case AttrWindowStatus:
+		return w.PutUint(tlv.Anonymous(), uint64(c.status))
+
+	case AttrAdminFabricIndex:
+		if c.adminFabric == fabric.FabricIndexInvalid {
+			return w.PutNull(tlv.Anonymous())
+		}
+		return w.PutUint(tlv.Anonymous(), uint64(c.adminFabric))
+
+	case AttrAdminVendorID:
+		if c.adminVendor == nil {
+			return w.PutNull(tlv.Anonymous())
+		}
+		return w.PutUint(tlv.Anonymous(), uint64(*c.adminVendor))
+
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	return datamodel.ErrUnsupportedWrite
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	switch req.Path.Command {
+	case CmdOpenCommissioningWindow:
+		return nil, c.handleOpenCommissioningWindow(req, r)
+	case CmdOpenBasicCommissioningWindow:
+		if !c.config.Basic {
+			return nil, datamodel.ErrUnsupportedCommand
+		}
+		return nil, c.handleOpenBasicCommissioningWindow(req, r)
+	case CmdRevokeCommissioning:
+		return nil, c.handleRevokeCommissioning(req)
+	default:
+		return nil, datamodel.ErrUnsupportedCommand
+	}
+}
+
+// WindowStatus returns the status of the administrator-opened window.
+func (c *Cluster) WindowStatus() WindowStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.status
+}
+
+// AdminFabricIndex returns the fabric of the administrator that opened the
+// window, or 0 if none.
+func (c *Cluster) AdminFabricIndex() fabric.FabricIndex {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.adminFabric
+}
+
+// windowOpened records a window opened by the administrator on fabricIndex.
+func (c *Cluster) windowOpened(status WindowStatus, fabricIndex fabric.FabricIndex) {
+	var vendor *fabric.VendorID
+	if c.config.Fabrics != nil {
+		if info, ok := c.config.Fabrics.Get(fabricIndex); ok {
+			vendor = &info.VendorID
+		}
+	}
+
+	c.mu.Lock()
+	c.status = status
+	c.adminFabric = fabricIndex
+	c.adminVendor = vendor
+	c.mu.Unlock()
+	c.IncrementDataVersion()
+}
+
+// WindowClosed reports that the commissioning window closed, through
+// expiry, revocation or commissioning. The WindowManager calls it for every
+// window it closes.
+func (c *Cluster) WindowClosed() {
+	c.mu.Lock()
+	changed := c.status != WindowNotOpen || c.adminFabric != fabric.FabricIndexInvalid
+	c.status = WindowNotOpen
+	c.adminFabric = fabric.FabricIndexInvalid
+	c.adminVendor = nil
+	c.mu.Unlock()
+	if changed {
+		c.IncrementDataVersion()
+	}
+}
+
+// FabricRemoved clears AdminFabricIndex if it refers to a removed fabric.
+func (c *Cluster) FabricRemoved(fabricIndex fabric.FabricIndex) {
+	c.mu.Lock()
+	changed := fabricIndex != fabric.FabricIndexInvalid && c.adminFabric == fabricIndex
+	if changed {
+		c.adminFabric = fabric.FabricIndexInvalid
+	}
+	c.mu.Unlock()
+	if changed {
+		c.IncrementDataVersion()
+	}
+}
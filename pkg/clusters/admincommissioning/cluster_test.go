@@ -0,0 +1,364 @@
+package admincommissioning
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/backkem/matter/pkg/clusters"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/securechannel/pase"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// mockWindowManager implements WindowManager for testing.
+type mockWindowManager struct {
+	open    bool
+	timeout time.Duration
+	params  *PAKEParameters
+	openErr error
+}
+
+func (m *mockWindowManager) IsCommissioningWindowOpen() bool { return m.open }
+
+func (m *mockWindowManager) OpenEnhancedCommissioningWindow(timeout time.Duration, params PAKEParameters) error {
+	if m.openErr != nil {
+		return m.openErr
+	}
+	m.open = true
+	m.timeout = timeout
+	m.params = &params
+	return nil
+}
+
+func (m *mockWindowManager) OpenCommissioningWindow(timeout time.Duration) error {
+	if m.openErr != nil {
+		return m.openErr
+	}
+	m.open = true
+	m.timeout = timeout
+	return nil
+}
+
+func (m *mockWindowManager) CloseCommissioningWindow() error {
+	m.open = false
+	return nil
+}
+
+const testFabricIndex fabric.FabricIndex = 1
+
+// createTestCluster creates a cluster with one fabric (vendor 0xFFF1).
+func createTestCluster(t *testing.T, basic bool) (*Cluster, *mockWindowManager) {
+	t.Helper()
+
+	table := fabric.NewTable(fabric.TableConfig{})
+	if err := table.Add(&fabric.FabricInfo{FabricIndex: testFabricIndex, FabricID: 1, VendorID: 0xFFF1}); err != nil {
+		t.Fatal(err)
+	}
+
+	wm := &mockWindowManager{}
+	return New(Config{EndpointID: 0, WindowManager: wm, Fabrics: table, Basic: basic}), wm
+}
+
+func validOpenRequest(t *testing.T) *OpenCommissioningWindowRequest {
+	t.Helper()
+
+	salt := bytes.Repeat([]byte{0x5A}, 32)
+	verifier, err := pase.GenerateVerifier(34567890, salt, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &OpenCommissioningWindowRequest{
+		CommissioningTimeout: 300,
+		PAKEPasscodeVerifier: verifier.Serialize(),
+		Discriminator:        0x0ABC,
+		Iterations:           1000,
+		Salt:                 salt,
+	}
+}
+
+func invoke(c *Cluster, cmd datamodel.CommandID, data []byte, timed bool) error {
+	req := datamodel.InvokeRequest{
+		Path: datamodel.ConcreteCommandPath{
+			Endpoint: 0,
+			Cluster:  ClusterID,
+			Command:  cmd,
+		},
+		Subject: &datamodel.SubjectDescriptor{FabricIndex: testFabricIndex},
+	}
+	if timed {
+		req.InvokeFlags = datamodel.InvokeFlagTimed
+	}
+	_, err := c.InvokeCommand(context.Background(), req, tlv.NewReader(bytes.NewReader(data)))
+	return err
+}
+
+func openEnhanced(t *testing.T, c *Cluster, req *OpenCommissioningWindowRequest) error {
+	t.Helper()
+
+	data, err := EncodeOpenCommissioningWindowRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return invoke(c, CmdOpenCommissioningWindow, data, true)
+}
+
+// readAttr reads an attribute and returns its value, or nil if null.
+func readAttr(t *testing.T, c *Cluster, attr datamodel.AttributeID) *uint64 {
+	t.Helper()
+
+	var buf bytes.Buffer
+	req := datamodel.ReadAttributeRequest{
+		Path: datamodel.ConcreteAttributePath{Endpoint: 0, Cluster: ClusterID, Attribute: attr},
+	}
+	if err := c.ReadAttribute(context.Background(), req, tlv.NewWriter(&buf)); err != nil {
+		t.Fatalf("read attribute 0x%04X: %v", attr, err)
+	}
+
+	r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+	if err := r.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if r.Type() == tlv.ElementTypeNull {
+		return nil
+	}
+	v, err := r.Uint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &v
+}
+
+func TestClusterID(t *testing.T) {
+	c, _ := createTestCluster(t, false)
+	if c.ID() != 0x003C {
+		t.Errorf("ID() = 0x%04X, want 0x003C", c.ID())
+	}
+}
+
+func TestReadAttributes_WindowNotOpen(t *testing.T) {
+	c, _ := createTestCluster(t, false)
+
+	if v := readAttr(t, c, AttrWindowStatus); v == nil || *v != uint64(WindowNotOpen) {
+		t.Errorf("WindowStatus = %v, want WindowNotOpen", v)
+	}
+	if v := readAttr(t, c, AttrAdminFabricIndex); v != nil {
+		t.Errorf("AdminFabricIndex = %d, want null", *v)
+	}
+	if v := readAttr(t, c, AttrAdminVendorID); v != nil {
+		t.Errorf("AdminVendorId = %d, want null", *v)
+	}
+}
+
+func TestAcceptedCommandList(t *testing.T) {
+	c, _ := createTestCluster(t, false)
+	if got := len(c.AcceptedCommandList()); got != 2 {
+		t.Errorf("accepted commands = %d, want 2", got)
+	}
+	for _, cmd := range c.AcceptedCommandList() {
+		if !cmd.HasQuality(datamodel.CmdQualityTimed) {
+			t.Errorf("command 0x%02X should require timed invoke", cmd.ID)
+		}
+	}
+
+	c, _ = createTestCluster(t, true)
+	if got := len(c.AcceptedCommandList()); got != 3 {
+		t.Errorf("accepted commands with Basic = %d, want 3", got)
+	}
+	if c.FeatureMap() != uint32(FeatureBasic) {
+		t.Errorf("FeatureMap = 0x%X, want 0x%X", c.FeatureMap(), FeatureBasic)
+	}
+}
+
+func TestOpenCommissioningWindow(t *testing.T) {
+	c, wm := createTestCluster(t, false)
+	req := validOpenRequest(t)
+
+	if err := openEnhanced(t, c, req); err != nil {
+		t.Fatalf("OpenCommissioningWindow: %v", err)
+	}
+
+	if !wm.open {
+		t.Fatal("window should be open")
+	}
+	if wm.timeout != 300*time.Second {
+		t.Errorf("timeout = %v, want 5m", wm.timeout)
+	}
+	if wm.params.Discriminator != req.Discriminator || wm.params.Iterations != req.Iterations {
+		t.Errorf("params = %+v", wm.params)
+	}
+	if !bytes.Equal(wm.params.Verifier.Serialize(), req.PAKEPasscodeVerifier) {
+		t.Error("verifier mismatch")
+	}
+
+	if v := readAttr(t, c, AttrWindowStatus); v == nil || *v != uint64(WindowEnhancedWindowOpen) {
+		t.Errorf("WindowStatus = %v, want EnhancedWindowOpen", v)
+	}
+	if v := readAttr(t, c, AttrAdminFabricIndex); v == nil || *v != uint64(testFabricIndex) {
+		t.Errorf("AdminFabricIndex = %v, want %d", v, testFabricIndex)
+	}
+	if v := readAttr(t, c, AttrAdminVendorID); v == nil || *v != 0xFFF1 {
+		t.Errorf("AdminVendorId = %v, want 0xFFF1", v)
+	}
+}
+
+func TestOpenCommissioningWindow_NotTimed(t *testing.T) {
+	c, wm := createTestCluster(t, false)
+
+	data, err := EncodeOpenCommissioningWindowRequest(validOpenRequest(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := invoke(c, CmdOpenCommissioningWindow, data, false); !errors.Is(err, clusters.ErrTimedRequired) {
+		t.Errorf("err = %v, want ErrTimedRequired", err)
+	}
+	if wm.open {
+		t.Error("window should not be open")
+	}
+}
+
+func TestOpenCommissioningWindow_Busy(t *testing.T) {
+	c, wm := createTestCluster(t, false)
+	wm.open = true
+
+	if err := openEnhanced(t, c, validOpenRequest(t)); !errors.Is(err, ErrBusy) {
+		t.Errorf("err = %v, want ErrBusy", err)
+	}
+
+	wm.open = false
+	wm.openErr = errors.New("advertising failed")
+	if err := openEnhanced(t, c, validOpenRequest(t)); !errors.Is(err, ErrBusy) {
+		t.Errorf("err = %v, want ErrBusy", err)
+	}
+	if c.WindowStatus() != WindowNotOpen {
+		t.Errorf("WindowStatus = %v, want WindowNotOpen", c.WindowStatus())
+	}
+}
+
+func TestOpenCommissioningWindow_InvalidParameters(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*OpenCommissioningWindowRequest)
+		want   error
+	}{
+		{"timeout too short", func(r *OpenCommissioningWindowRequest) { r.CommissioningTimeout = 179 }, datamodel.ErrInvalidCommand},
+		{"timeout too long", func(r *OpenCommissioningWindowRequest) { r.CommissioningTimeout = 901 }, datamodel.ErrInvalidCommand},
+		{"discriminator too large", func(r *OpenCommissioningWindowRequest) { r.Discriminator = 0x1000 }, datamodel.ErrInvalidCommand},
+		{"iterations too low", func(r *OpenCommissioningWindowRequest) { r.Iterations = 999 }, ErrPAKEParameterError},
+		{"salt too short", func(r *OpenCommissioningWindowRequest) { r.Salt = r.Salt[:15] }, ErrPAKEParameterError},
+		{"verifier truncated", func(r *OpenCommissioningWindowRequest) { r.PAKEPasscodeVerifier = r.PAKEPasscodeVerifier[:96] }, ErrPAKEParameterError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, wm := createTestCluster(t, false)
+			req := validOpenRequest(t)
+			tt.modify(req)
+
+			if err := openEnhanced(t, c, req); !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+			if wm.open {
+				t.Error("window should not be open")
+			}
+		})
+	}
+}
+
+func TestOpenBasicCommissioningWindow(t *testing.T) {
+	data, err := EncodeOpenBasicCommissioningWindowRequest(&OpenBasicCommissioningWindowRequest{CommissioningTimeout: 180})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, _ := createTestCluster(t, false)
+	if err := invoke(c, CmdOpenBasicCommissioningWindow, data, true); !errors.Is(err, datamodel.ErrUnsupportedCommand) {
+		t.Errorf("without Basic: err = %v, want ErrUnsupportedCommand", err)
+	}
+
+	c, wm := createTestCluster(t, true)
+	if err := invoke(c, CmdOpenBasicCommissioningWindow, data, true); err != nil {
+		t.Fatalf("OpenBasicCommissioningWindow: %v", err)
+	}
+	if !wm.open || wm.timeout != 3*time.Minute {
+		t.Errorf("window open = %v, timeout = %v", wm.open, wm.timeout)
+	}
+	if c.WindowStatus() != WindowBasicWindowOpen {
+		t.Errorf("WindowStatus = %v, want BasicWindowOpen", c.WindowStatus())
+	}
+}
+
+func TestRevokeCommissioning(t *testing.T) {
+	c, wm := createTestCluster(t, false)
+
+	if err := invoke(c, CmdRevokeCommissioning, nil, true); !errors.Is(err, ErrWindowNotOpen) {
+		t.Errorf("err = %v, want ErrWindowNotOpen", err)
+	}
+
+	if err := openEnhanced(t, c, validOpenRequest(t)); err != nil {
+		t.Fatal(err)
+	}
+	if err := invoke(c, CmdRevokeCommissioning, nil, false); !errors.Is(err, clusters.ErrTimedRequired) {
+		t.Errorf("err = %v, want ErrTimedRequired", err)
+	}
+
+	version := c.DataVersion()
+	if err := invoke(c, CmdRevokeCommissioning, nil, true); err != nil {
+		t.Fatalf("RevokeCommissioning: %v", err)
+	}
+	if wm.open {
+		t.Error("window should be closed")
+	}
+	if c.WindowStatus() != WindowNotOpen || c.AdminFabricIndex() != 0 {
+		t.Errorf("status = %v, admin fabric = %d", c.WindowStatus(), c.AdminFabricIndex())
+	}
+	if v := readAttr(t, c, AttrAdminVendorID); v != nil {
+		t.Errorf("AdminVendorId = %d, want null", *v)
+	}
+	if c.DataVersion() == version {
+		t.Error("DataVersion should change")
+	}
+}
+
+func TestFabricRemoved(t *testing.T) {
+	c, _ := createTestCluster(t, false)
+	if err := openEnhanced(t, c, validOpenRequest(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	c.FabricRemoved(2)
+	if c.AdminFabricIndex() != testFabricIndex {
+		t.Errorf("AdminFabricIndex = %d, want %d", c.AdminFabricIndex(), testFabricIndex)
+	}
+
+	c.FabricRemoved(testFabricIndex)
+	if c.AdminFabricIndex() != 0 {
+		t.Errorf("AdminFabricIndex = %d, want 0", c.AdminFabricIndex())
+	}
+	if c.WindowStatus() != WindowEnhancedWindowOpen {
+		t.Errorf("WindowStatus = %v, the window should stay open", c.WindowStatus())
+	}
+}
+
+func TestEncodeOpenCommissioningWindowRequest_RoundTrip(t *testing.T) {
+	want := validOpenRequest(t)
+	data, err := EncodeOpenCommissioningWindowRequest(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got OpenCommissioningWindowRequest
+	if err := decodeOpenCommissioningWindowRequest(tlv.NewReader(bytes.NewReader(data)), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.CommissioningTimeout != want.CommissioningTimeout ||
+		got.Discriminator != want.Discriminator ||
+		got.Iterations != want.Iterations ||
+		!bytes.Equal(got.Salt, want.Salt) ||
+		!bytes.Equal(got.PAKEPasscodeVerifier, want.PAKEPasscodeVerifier) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
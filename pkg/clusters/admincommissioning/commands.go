@@ -0,0 +1,262 @@
+package admincommissioning
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/backkem/matter/pkg/clusters"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/securechannel/pase"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Command field tags.
+const (
+	// OpenCommissioningWindow (Spec 11.19.8.1)
+	tagCommissioningTimeout = 0
+	tagPAKEVerifier         = 1
+	tagDiscriminator        = 2
+	tagIterations           = 3
+	tagSalt                 = 4
+)
+
+// OpenCommissioningWindowRequest represents the OpenCommissioningWindow
+// command request (Spec 11.19.8.1).
+type OpenCommissioningWindowRequest struct {
+	// CommissioningTimeout is the window duration in seconds (180-900).
+	CommissioningTimeout uint16
+
+	// PAKEPasscodeVerifier is the serialized SPAKE2+ verifier (97 bytes).
+	PAKEPasscodeVerifier []byte
+
+	Discriminator uint16
+	Iterations    uint32
+	Salt          []byte
+}
+
+// OpenBasicCommissioningWindowRequest represents the
+// OpenBasicCommissioningWindow command request (Spec 11.19.8.2).
+type OpenBasicCommissioningWindowRequest struct {
+	// CommissioningTimeout is the window duration in seconds (180-900).
+	CommissioningTimeout uint16
+}
+
+// handleOpenCommissioningWindow handles the OpenCommissioningWindow command.
+//
+// Spec: Section 11.19.8.1
+func (c *Cluster) handleOpenCommissioningWindow(req datamodel.InvokeRequest, r *tlv.Reader) error {
+	if err := clusters.RequireTimed(req); err != nil {
+		return err
+	}
+
+	var cmd OpenCommissioningWindowRequest
+	if err := decodeOpenCommissioningWindowRequest(r, &cmd); err != nil {
+		return err
+	}
+
+	if c.config.WindowManager == nil || c.config.WindowManager.IsCommissioningWindowOpen() {
+		return ErrBusy
+	}
+
+	if cmd.Iterations < pase.PBKDFMinIterations || cmd.Iterations > pase.PBKDFMaxIterations {
+		return ErrPAKEParameterError
+	}
+	if len(cmd.Salt) < pase.PBKDFMinSaltLength || len(cmd.Salt) > pase.PBKDFMaxSaltLength {
+		return ErrPAKEParameterError
+	}
+	timeout, err := commissioningTimeout(cmd.CommissioningTimeout)
+	if err != nil {
+		return err
+	}
+	if cmd.Discriminator > MaxDiscriminator {
+		return datamodel.ErrInvalidCommand
+	}
+	verifier, err := pase.DeserializeVerifier(cmd.PAKEPasscodeVerifier)
+	if err != nil {
+		return ErrPAKEParameterError
+	}
+
+	err = c.config.WindowManager.OpenEnhancedCommissioningWindow(timeout, PAKEParameters{
+		Verifier:      verifier,
+		Discriminator: cmd.Discriminator,
+		Iterations:    cmd.Iterations,
+		Salt:          cmd.Salt,
+	})
+	if err != nil {
+		return windowError(err)
+	}
+
+	c.windowOpened(WindowEnhancedWindowOpen, req.FabricIndex())
+	return nil
+}
+
+// handleOpenBasicCommissioningWindow handles the
+// OpenBasicCommissioningWindow command.
+//
+// Spec: Section 11.19.8.2
+func (c *Cluster) handleOpenBasicCommissioningWindow(req datamodel.InvokeRequest, r *tlv.Reader) error {
+	if err := clusters.RequireTimed(req); err != nil {
+		return err
+	}
+
+	var cmd OpenBasicCommissioningWindowRequest
+	if err := decodeOpenBasicCommissioningWindowRequest(r, &cmd); err != nil {
+		return err
+	}
+
+	if c.config.WindowManager == nil || c.config.WindowManager.IsCommissioningWindowOpen() {
+		return ErrBusy
+	}
+
+	timeout, err := commissioningTimeout(cmd.CommissioningTimeout)
+	if err != nil {
+		return err
+	}
+
+	if err := c.config.WindowManager.OpenCommissioningWindow(timeout); err != nil {
+		return windowError(err)
+	}
+
+	c.windowOpened(WindowBasicWindowOpen, req.FabricIndex())
+	return nil
+}
+
+// handleRevokeCommissioning handles the RevokeCommissioning command.
+//
+// Spec: Section 11.19.8.3
+func (c *Cluster) handleRevokeCommissioning(req datamodel.InvokeRequest) error {
+	if err := clusters.RequireTimed(req); err != nil {
+		return err
+	}
+
+	if c.config.WindowManager == nil || !c.config.WindowManager.IsCommissioningWindowOpen() {
+		return ErrWindowNotOpen
+	}
+	if err := c.config.WindowManager.CloseCommissioningWindow(); err != nil {
+		return ErrWindowNotOpen
+	}
+
+	c.WindowClosed()
+	return nil
+}
+
+// commissioningTimeout validates a CommissioningTimeout field.
+func commissioningTimeout(seconds uint16) (time.Duration, error) {
+	timeout := time.Duration(seconds) * time.Second
+	if timeout < MinCommissioningTimeout || timeout > MaxCommissioningTimeout {
+		return 0, datamodel.ErrInvalidCommand
+	}
+	return timeout, nil
+}
+
+// windowError maps a WindowManager failure to open a window to Busy.
+func windowError(err error) error {
+	if errors.Is(err, ErrBusy) {
+		return err
+	}
+	return fmt.Errorf("%w: %v", ErrBusy, err)
+}
+
+// decodeOpenCommissioningWindowRequest decodes an OpenCommissioningWindow
+// request. All fields are mandatory.
+func decodeOpenCommissioningWindowRequest(r *tlv.Reader, req *OpenCommissioningWindowRequest) error {
+	if err := r.Next(); err != nil {
+		return datamodel.ErrInvalidCommand
+	}
+	if r.Type() != tlv.ElementTypeStruct {
+		return datamodel.ErrInvalidCommand
+	}
+	if err := r.EnterContainer(); err != nil {
+		return datamodel.ErrInvalidCommand
+	}
+
+	var seen uint8
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		tag := r.Tag()
+		if !tag.IsContext() {
+			continue
+		}
+
+		var err error
+		switch tag.TagNumber() {
+		case tagCommissioningTimeout:
+			var v uint64
+			if v, err = r.Uint(); err == nil && v > 0xFFFF {
+				err = datamodel.ErrInvalidCommand
+			}
+			req.CommissioningTimeout = uint16(v)
+		case tagPAKEVerifier:
+			req.PAKEPasscodeVerifier, err = r.Bytes()
+		case tagDiscriminator:
+			var v uint64
+			if v, err = r.Uint(); err == nil && v > 0xFFFF {
+				err = datamodel.ErrInvalidCommand
+			}
+			req.Discriminator = uint16(v)
+		case tagIterations:
+			var v uint64
+			if v, err = r.Uint(); err == nil && v > 0xFFFFFFFF {
+				err = datamodel.ErrInvalidCommand
+			}
+			req.Iterations = uint32(v)
+		case tagSalt:
+			req.Salt, err = r.Bytes()
+		default:
+			continue
+		}
+		if err != nil {
+			return datamodel.ErrInvalidCommand
+		}
+		seen |= 1 << tag.TagNumber()
+	}
+
+	if err := r.ExitContainer(); err != nil {
+		return datamodel.ErrInvalidCommand
+	}
+	if seen != 0x1F {
+		return datamodel.ErrInvalidCommand
+	}
+	return nil
+}
+
+// decodeOpenBasicCommissioningWindowRequest decodes an
+// OpenBasicCommissioningWindow request.
+func decodeOpenBasicCommissioningWindowRequest(r *tlv.Reader, req *OpenBasicCommissioningWindowRequest) error {
+	if err := r.Next(); err != nil {
+		return datamodel.ErrInvalidCommand
+	}
+	if r.Type() != tlv.ElementTypeStruct {
+		return datamodel.ErrInvalidCommand
+	}
+	if err := r.EnterContainer(); err != nil {
+		return datamodel.ErrInvalidCommand
+	}
+
+	found := false
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		tag := r.Tag()
+		if tag.IsContext() && tag.TagNumber() == tagCommissioningTimeout {
+			v, err := r.Uint()
+			if err != nil || v > 0xFFFF {
+				return datamodel.ErrInvalidCommand
+			}
+			req.CommissioningTimeout = uint16(v)
+			found = true
+		}
+	}
+
+	if err := r.ExitContainer(); err != nil {
+		return datamodel.ErrInvalidCommand
+	}
+	if !found {
+		return datamodel.ErrInvalidCommand
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+// Package airquality implements the Air Quality Cluster (0x005B).
+//
+// The cluster exposes a single AirQuality classification. Which
+// classifications are valid depends on the feature map; the device sets the
+// current value through SetAirQuality. Detailed readings are exposed by the
+// concentration measurement clusters on the same endpoint.
+//
+// Spec Reference: Section 2.9
+//
+// C++ Reference: src/app/clusters/air-quality-server
+package airquality
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x005B
+	ClusterRevision uint16              = 1
+)
+
+// Attribute IDs (Spec 2.9.6).
+const (
+	AttrAirQuality datamodel.AttributeID = 0x0000
+)
+
+// Feature bits (Spec 2.9.4).
+type Feature uint32
+
+const (
+	// FeatureFair adds the Fair classification (FAIR).
+	FeatureFair Feature = 1 << 0
+
+	// FeatureModerate adds the Moderate classification (MOD).
+	FeatureModerate Feature = 1 << 1
+
+	// FeatureVeryPoor adds the VeryPoor classification (VPOOR).
+	FeatureVeryPoor Feature = 1 << 2
+
+	// FeatureExtremelyPoor adds the ExtremelyPoor classification (XPOOR).
+	FeatureExtremelyPoor Feature = 1 << 3
+)
+
+// AirQuality is the air quality classification (Spec 2.9.5.1).
+type AirQuality uint8
+
+const (
+	AirQualityUnknown       AirQuality = 0
+	AirQualityGood          AirQuality = 1
+	AirQualityFair          AirQuality = 2
+	AirQualityModerate      AirQuality = 3
+	AirQualityPoor          AirQuality = 4
+	AirQualityVeryPoor      AirQuality = 5
+	AirQualityExtremelyPoor AirQuality = 6
+)
+
+// String returns the name of the classification.
+func (q AirQuality) String() string {
+	switch q {
+	case AirQualityUnknown:
+		return "Unknown"
+	case AirQualityGood:
+		return "Good"
+	case AirQualityFair:
+		return "Fair"
+	case AirQualityModerate:
+		return "Moderate"
+	case AirQualityPoor:
+		return "Poor"
+	case AirQualityVeryPoor:
+		return "VeryPoor"
+	case AirQualityExtremelyPoor:
+		return "ExtremelyPoor"
+	default:
+		return "Invalid"
+	}
+}
+
+// ErrUnsupportedAirQuality is returned when a classification is not enabled
+// by the feature map.
+var ErrUnsupportedAirQuality = errors.New("airquality: classification not supported")
+
+// Config provides dependencies for the Air Quality cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to.
+	EndpointID datamodel.EndpointID
+
+	// FeatureMap indicates the supported classifications beyond
+	// Unknown, Good and Poor.
+	FeatureMap Feature
+}
+
+// Cluster implements the Air Quality cluster (0x005B).
+type Cluster struct {
+	*datamodel.ClusterBase
+	config Config
+
+	// Mutable state (protected by mutex)
+	mu         sync.RWMutex
+	airQuality AirQuality
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Air Quality cluster.
+func New(cfg Config) *Cluster {
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		config:      cfg,
+	}
+
+	c.ClusterBase.SetFeatureMap(uint32(cfg.FeatureMap))
+
+	c.attrList = datamodel.MergeAttributeLists([]datamodel.AttributeEntry{
+		datamodel.NewReadOnlyAttribute(AttrAirQuality, 0, datamodel.PrivilegeView),
+	})
+
+	return c
+}
+
+// hasFeature returns true if the feature bit is set.
+func (c *Cluster) hasFeature(f Feature) bool {
+	return c.config.FeatureMap&f != 0
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	return nil
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return nil
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	switch req.Path.Attribute {
+	case AttrAirQuality:
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		return w.PutUint(tlv.Anonymous(), uint64(c.airQuality))
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	return datamodel.ErrUnsupportedWrite
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	return nil, datamodel.ErrUnsupportedCommand
+}
+
+// Supports returns true if the classification is valid for the feature map.
+//
+// Spec: Section 2.9.5.1
+func (c *Cluster) Supports(q AirQuality) bool {
+	switch q {
+	case AirQualityUnknown, AirQualityGood, AirQualityPoor:
+		return true
+	case AirQualityFair:
+		return c.hasFeature(FeatureFair)
+	case AirQualityModerate:
+		return c.hasFeature(FeatureModerate)
+	case AirQualityVeryPoor:
+		return c.hasFeature(FeatureVeryPoor)
+	case AirQualityExtremelyPoor:
+		return c.hasFeature(FeatureExtremelyPoor)
+	default:
+		return false
+	}
+}
+
+// SetAirQuality updates the AirQuality attribute.
+func (c *Cluster) SetAirQuality(q AirQuality) error {
+	if !c.Supports(q) {
+		return ErrUnsupportedAirQuality
+	}
+
+	c.mu.Lock()
+	changed := c.airQuality != q
+	c.airQuality = q
+	c.mu.Unlock()
+
+	if changed {
+		c.IncrementDataVersion()
+	}
+	return nil
+}
+
+// AirQuality returns the current classification.
+func (c *Cluster) AirQuality() AirQuality {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.airQuality
+}
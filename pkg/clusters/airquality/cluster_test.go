@@ -0,0 +1,48 @@
+package airquality
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+func TestSetAirQuality(t *testing.T) {
+	c := New(Config{EndpointID: 1, FeatureMap: FeatureFair | FeatureModerate})
+
+	if c.ID() != ClusterID {
+		t.Errorf("ID() = 0x%04X, want 0x%04X", c.ID(), ClusterID)
+	}
+	if c.AirQuality() != AirQualityUnknown {
+		t.Errorf("initial AirQuality = %v, want Unknown", c.AirQuality())
+	}
+
+	for _, q := range []AirQuality{AirQualityGood, AirQualityFair, AirQualityModerate, AirQualityPoor} {
+		if err := c.SetAirQuality(q); err != nil {
+			t.Errorf("SetAirQuality(%v) failed: %v", q, err)
+		}
+	}
+	for _, q := range []AirQuality{AirQualityVeryPoor, AirQualityExtremelyPoor, AirQuality(7)} {
+		if err := c.SetAirQuality(q); !errors.Is(err, ErrUnsupportedAirQuality) {
+			t.Errorf("SetAirQuality(%v): err = %v, want ErrUnsupportedAirQuality", q, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	req := datamodel.ReadAttributeRequest{
+		Path: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: AttrAirQuality},
+	}
+	if err := c.ReadAttribute(context.Background(), req, tlv.NewWriter(&buf)); err != nil {
+		t.Fatal(err)
+	}
+	r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+	if err := r.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := r.Uint(); v != uint64(AirQualityPoor) {
+		t.Errorf("AirQuality = %d, want Poor", v)
+	}
+}
@@ -0,0 +1,398 @@
+// Package alarmbase implements the Alarm Base cluster pattern.
+//
+// Alarm Base is not a cluster on its own: Dishwasher Alarm and
+// Refrigerator Alarm are derived from it and differ only in cluster ID,
+// the alarm bits they define and whether alarms can be latched and reset.
+// A single Cluster implementation is instantiated from a Definition
+// describing the derived cluster.
+//
+// The device raises and clears alarm conditions with SetAlarm. Alarms not
+// enabled in Mask are suppressed; with the RESET feature, alarms in Latch
+// stay active after their condition clears until a client sends Reset.
+// Every change to State emits a Notify event.
+//
+// Spec Reference: Section 1.15
+//
+// C++ Reference: src/app/clusters/dishwasher-alarm-server/dishwasher-alarm-server.cpp
+package alarmbase
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/backkem/matter/pkg/clusters"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Attribute IDs (Spec 1.15.6).
+const (
+	AttrMask      datamodel.AttributeID = 0x0000
+	AttrLatch     datamodel.AttributeID = 0x0001
+	AttrState     datamodel.AttributeID = 0x0002
+	AttrSupported datamodel.AttributeID = 0x0003
+)
+
+// Command IDs (Spec 1.15.7).
+const (
+	CmdReset               datamodel.CommandID = 0x00
+	CmdModifyEnabledAlarms datamodel.CommandID = 0x01
+)
+
+// Event IDs (Spec 1.15.8).
+const (
+	EventNotify datamodel.EventID = 0x00
+)
+
+// Feature bits (Spec 1.15.4).
+type Feature uint32
+
+const (
+	// FeatureReset supports latching alarms and the Reset command (RESET).
+	FeatureReset Feature = 1 << 0
+)
+
+// Alarm is a bitmap of alarms. Derived clusters define the bits.
+type Alarm uint32
+
+// Errors returned by New.
+var (
+	ErrInvalidFeatures = errors.New("alarmbase: feature not allowed by definition")
+	ErrInvalidAlarms   = errors.New("alarmbase: invalid alarm bitmap")
+)
+
+// Delegate lets the application carry out or veto client commands.
+type Delegate interface {
+	// HandleResetAlarms is called for Reset with supported alarms. Return
+	// an error if the alarms cannot be reset.
+	HandleResetAlarms(alarms Alarm) error
+
+	// HandleModifyEnabledAlarms is called for ModifyEnabledAlarms with a
+	// supported mask. Return an error to reject it.
+	HandleModifyEnabledAlarms(mask Alarm) error
+}
+
+// Config provides dependencies for an Alarm Base derived cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to.
+	EndpointID datamodel.EndpointID
+
+	// Definition selects the derived cluster.
+	Definition Definition
+
+	// FeatureMap indicates supported features.
+	FeatureMap Feature
+
+	// Supported are the alarms the device can raise. Must be a non-empty
+	// subset of the definition's alarms.
+	Supported Alarm
+
+	// Mask are the initially enabled alarms. Defaults to Supported if zero.
+	Mask Alarm
+
+	// Latch are the alarms that stay active until Reset (RESET).
+	Latch Alarm
+
+	// EnableModifyEnabledAlarms enables the ModifyEnabledAlarms command,
+	// if the definition allows it.
+	EnableModifyEnabledAlarms bool
+
+	// Delegate carries out or vetoes commands (optional).
+	Delegate Delegate
+
+	// EventPublisher for Notify events.
+	// Optional - if nil, events are not emitted.
+	EventPublisher datamodel.EventPublisher
+}
+
+// Cluster implements an Alarm Base derived cluster.
+type Cluster struct {
+	*datamodel.ClusterBase
+	*datamodel.EventSource
+	config Config
+
+	// Mutable state (protected by mutex)
+	mu    sync.Mutex
+	mask  Alarm
+	state Alarm
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates an Alarm Base derived cluster. It returns an error if a
+// feature or alarm bitmap is not allowed by the definition.
+func New(cfg Config) (*Cluster, error) {
+	def := cfg.Definition
+	if cfg.FeatureMap&FeatureReset != 0 && !def.Reset {
+		return nil, ErrInvalidFeatures
+	}
+	if cfg.Supported == 0 || cfg.Supported&^def.Alarms != 0 {
+		return nil, ErrInvalidAlarms
+	}
+	if cfg.Mask == 0 {
+		cfg.Mask = cfg.Supported
+	}
+	if cfg.Mask&^cfg.Supported != 0 || cfg.Latch&^cfg.Supported != 0 {
+		return nil, ErrInvalidAlarms
+	}
+	if cfg.FeatureMap&FeatureReset == 0 {
+		cfg.Latch = 0
+	}
+	if !def.ModifyEnabledAlarms {
+		cfg.EnableModifyEnabledAlarms = false
+	}
+
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(def.ClusterID, cfg.EndpointID, def.Revision),
+		EventSource: datamodel.NewEventSource(),
+		config:      cfg,
+		mask:        cfg.Mask,
+	}
+
+	c.ClusterBase.SetFeatureMap(uint32(cfg.FeatureMap))
+
+	// Bind event source
+	if cfg.EventPublisher != nil {
+		c.EventSource.Bind(cfg.EndpointID, def.ClusterID, cfg.EventPublisher)
+		c.EventSource.RegisterEvents([]datamodel.EventEntry{
+			datamodel.NewEventEntry(EventNotify, datamodel.EventPriorityInfo, datamodel.PrivilegeView, false),
+		})
+	}
+
+	c.attrList = c.buildAttributeList()
+
+	return c, nil
+}
+
+// hasFeature returns true if the feature bit is set.
+func (c *Cluster) hasFeature(f Feature) bool {
+	return c.config.FeatureMap&f != 0
+}
+
+// buildAttributeList constructs the list of supported attributes.
+func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+
+	attrs := []datamodel.AttributeEntry{
+		datamodel.NewReadOnlyAttribute(AttrMask, 0, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrState, 0, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrSupported, datamodel.AttrQualityFixed, viewPriv),
+	}
+	if c.hasFeature(FeatureReset) {
+		attrs = append(attrs, datamodel.NewReadOnlyAttribute(AttrLatch, datamodel.AttrQualityFixed, viewPriv))
+	}
+
+	return datamodel.MergeAttributeLists(attrs)
+}
+
+// Definition returns the cluster definition.
+func (c *Cluster) Definition() Definition {
+	return c.config.Definition
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	var cmds []datamodel.CommandEntry
+	if c.hasFeature(FeatureReset) {
+		cmds = append(cmds, datamodel.NewCommandEntry(CmdReset, 0, datamodel.PrivilegeOperate))
+	}
+	if c.config.EnableModifyEnabledAlarms {
+		cmds = append(cmds, datamodel.NewCommandEntry(CmdModifyEnabledAlarms, 0, datamodel.PrivilegeOperate))
+	}
+	return cmds
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return nil
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch req.Path.Attribute {
+	case AttrMask:
+		return w.PutUint(tlv.Anonymous(), uint64(c.mask))
+	case AttrLatch:
+		if !c.hasFeature(FeatureReset) {
+			return datamodel.ErrUnsupportedAttribute
+		}
+		return w.PutUint(tlv.Anonymous(), uint64(c.config.Latch))
+	case AttrState:
+		return w.PutUint(tlv.Anonymous(), uint64(c.state))
+	case AttrSupported:
+		return w.PutUint(tlv.Anonymous(), uint64(c.config.Supported))
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	return datamodel.ErrUnsupportedWrite
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	var err error
+	switch {
+	case req.Path.Command == CmdReset && c.hasFeature(FeatureReset):
+		var alarms Alarm
+		if alarms, err = decodeAlarms(r); err == nil {
+			err = c.Reset(alarms)
+		}
+	case req.Path.Command == CmdModifyEnabledAlarms && c.config.EnableModifyEnabledAlarms:
+		var mask Alarm
+		if mask, err = decodeAlarms(r); err == nil {
+			err = c.ModifyEnabledAlarms(mask)
+		}
+	default:
+		return nil, datamodel.ErrUnsupportedCommand
+	}
+	if err != nil {
+		return nil, err
+	}
+	return clusters.EmptyResponse(), nil
+}
+
+// decodeAlarms decodes the single bitmap field of Reset and
+// ModifyEnabledAlarms.
+func decodeAlarms(r *tlv.Reader) (Alarm, error) {
+	if err := r.Next(); err != nil {
+		return 0, datamodel.ErrInvalidCommand
+	}
+	if err := r.EnterContainer(); err != nil {
+		return 0, datamodel.ErrInvalidCommand
+	}
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			return 0, datamodel.ErrInvalidCommand
+		}
+		tag := r.Tag()
+		if !tag.IsContext() || tag.TagNumber() != 0 {
+			continue
+		}
+		v, err := r.Uint()
+		if err != nil || v > 0xFFFFFFFF {
+			return 0, datamodel.ErrInvalidCommand
+		}
+		return Alarm(v), nil
+	}
+}
+
+// State returns the active alarms.
+func (c *Cluster) State() Alarm {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+// Mask returns the enabled alarms.
+func (c *Cluster) Mask() Alarm {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.mask
+}
+
+// SetAlarm raises or clears alarm conditions detected by the device.
+// Raised alarms not enabled in Mask are suppressed; cleared alarms in
+// Latch stay active until Reset.
+func (c *Cluster) SetAlarm(alarms Alarm, active bool) error {
+	if alarms&^c.config.Supported != 0 {
+		return ErrInvalidAlarms
+	}
+	return c.update(func() {
+		if active {
+			c.state |= alarms & c.mask
+		} else {
+			c.state &^= alarms &^ c.config.Latch
+		}
+	})
+}
+
+// Reset handles the Reset command: it clears the given alarms, including
+// latched ones. Returns ErrInvalidCommand for unsupported alarms.
+//
+// Spec: Section 1.15.7.1
+func (c *Cluster) Reset(alarms Alarm) error {
+	if alarms&^c.config.Supported != 0 {
+		return datamodel.ErrInvalidCommand
+	}
+	if d := c.config.Delegate; d != nil {
+		if err := d.HandleResetAlarms(alarms); err != nil {
+			return err
+		}
+	}
+	return c.update(func() {
+		c.state &^= alarms
+	})
+}
+
+// ModifyEnabledAlarms handles the ModifyEnabledAlarms command: it replaces
+// Mask and clears active alarms that are no longer enabled. Returns
+// ErrInvalidCommand for unsupported alarms.
+//
+// Spec: Section 1.15.7.2
+func (c *Cluster) ModifyEnabledAlarms(mask Alarm) error {
+	if mask&^c.config.Supported != 0 {
+		return datamodel.ErrInvalidCommand
+	}
+	if d := c.config.Delegate; d != nil {
+		if err := d.HandleModifyEnabledAlarms(mask); err != nil {
+			return err
+		}
+	}
+	return c.update(func() {
+		if c.mask != mask {
+			c.mask = mask
+			c.IncrementDataVersion()
+		}
+		c.state &= mask
+	})
+}
+
+// update applies change under the lock and emits Notify if State changed.
+func (c *Cluster) update(change func()) error {
+	c.mu.Lock()
+	old := c.state
+	change()
+	event := NotifyEvent{
+		Active:   c.state &^ old,
+		Inactive: old &^ c.state,
+		State:    c.state,
+		Mask:     c.mask,
+	}
+	if old != c.state {
+		c.IncrementDataVersion()
+	}
+	c.mu.Unlock()
+
+	if event.Active == 0 && event.Inactive == 0 {
+		return nil
+	}
+	return c.emit(EventNotify, datamodel.EventPriorityInfo, event)
+}
+
+// emit emits an event if a publisher is bound.
+func (c *Cluster) emit(eventID datamodel.EventID, priority datamodel.EventPriority, payload interface{}) error {
+	if !c.EventSource.IsBound() {
+		return nil // No publisher, silently skip
+	}
+	_, err := c.EventSource.Emit(eventID, priority, payload)
+	return err
+}
@@ -0,0 +1,197 @@
+package alarmbase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// mockEventPublisher implements EventPublisher for testing.
+type mockEventPublisher struct {
+	mu     sync.Mutex
+	events []NotifyEvent
+}
+
+func (m *mockEventPublisher) PublishEvent(
+	endpoint datamodel.EndpointID,
+	cluster datamodel.ClusterID,
+	eventID datamodel.EventID,
+	priority datamodel.EventPriority,
+	data interface{},
+	fabricIndex uint8,
+) (datamodel.EventNumber, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, data.(NotifyEvent))
+	return datamodel.EventNumber(len(m.events)), nil
+}
+
+// take returns and clears the published events.
+func (m *mockEventPublisher) take() []NotifyEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	events := m.events
+	m.events = nil
+	return events
+}
+
+// testDelegate optionally rejects commands.
+type testDelegate struct {
+	reject error
+}
+
+func (d *testDelegate) HandleResetAlarms(alarms Alarm) error       { return d.reject }
+func (d *testDelegate) HandleModifyEnabledAlarms(mask Alarm) error { return d.reject }
+
+func newDishwasherAlarm(t *testing.T, d Delegate, pub datamodel.EventPublisher) *Cluster {
+	t.Helper()
+	c, err := New(Config{
+		EndpointID:                1,
+		Definition:                DishwasherAlarm,
+		FeatureMap:                FeatureReset,
+		Supported:                 DishwasherAlarm.Alarms,
+		Latch:                     DishwasherAlarmInflowError | DishwasherAlarmDrainError,
+		EnableModifyEnabledAlarms: true,
+		Delegate:                  d,
+		EventPublisher:            pub,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+func invoke(c *Cluster, cmd datamodel.CommandID, alarms Alarm) error {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	w.StartStructure(tlv.Anonymous())
+	w.PutUint(tlv.ContextTag(0), uint64(alarms))
+	w.EndContainer()
+	req := datamodel.InvokeRequest{
+		Path: datamodel.ConcreteCommandPath{Endpoint: 1, Cluster: c.Definition().ClusterID, Command: cmd},
+	}
+	_, err := c.InvokeCommand(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes())))
+	return err
+}
+
+func TestNew_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want error
+	}{
+		{"no alarms", Config{Definition: DishwasherAlarm}, ErrInvalidAlarms},
+		{"undefined alarm", Config{Definition: RefrigeratorAlarm, Supported: 1 << 1}, ErrInvalidAlarms},
+		{"mask outside supported", Config{Definition: DishwasherAlarm, Supported: DishwasherAlarmDoorError, Mask: DishwasherAlarmDrainError}, ErrInvalidAlarms},
+		{"reset not allowed", Config{Definition: RefrigeratorAlarm, Supported: RefrigeratorAlarmDoorOpen, FeatureMap: FeatureReset}, ErrInvalidFeatures},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg); !errors.Is(err, tt.want) {
+				t.Errorf("New() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	c, err := New(Config{Definition: RefrigeratorAlarm, Supported: RefrigeratorAlarmDoorOpen, EnableModifyEnabledAlarms: true})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if cmds := c.AcceptedCommandList(); len(cmds) != 0 {
+		t.Errorf("AcceptedCommandList = %v, want none for Refrigerator Alarm", cmds)
+	}
+}
+
+func TestSetAlarm_Latch(t *testing.T) {
+	pub := &mockEventPublisher{}
+	c := newDishwasherAlarm(t, nil, pub)
+
+	c.SetAlarm(DishwasherAlarmDoorError|DishwasherAlarmDrainError, true)
+	if got := c.State(); got != DishwasherAlarmDoorError|DishwasherAlarmDrainError {
+		t.Errorf("State = 0x%02X", got)
+	}
+
+	// The drain error is latched; only the door error clears
+	c.SetAlarm(DishwasherAlarmDoorError|DishwasherAlarmDrainError, false)
+	if got := c.State(); got != DishwasherAlarmDrainError {
+		t.Errorf("State = 0x%02X, want latched drain error", got)
+	}
+
+	events := pub.take()
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if events[0].Active != DishwasherAlarmDoorError|DishwasherAlarmDrainError || events[0].Inactive != 0 {
+		t.Errorf("first event = %+v", events[0])
+	}
+	if events[1].Active != 0 || events[1].Inactive != DishwasherAlarmDoorError || events[1].State != DishwasherAlarmDrainError {
+		t.Errorf("second event = %+v", events[1])
+	}
+
+	// Reset clears latched alarms
+	if err := invoke(c, CmdReset, DishwasherAlarmDrainError); err != nil {
+		t.Fatalf("Reset error = %v", err)
+	}
+	if got := c.State(); got != 0 {
+		t.Errorf("State = 0x%02X after Reset, want 0", got)
+	}
+	if events := pub.take(); len(events) != 1 || events[0].Inactive != DishwasherAlarmDrainError {
+		t.Errorf("Reset events = %+v", events)
+	}
+
+	if err := invoke(c, CmdReset, 1<<6); !errors.Is(err, datamodel.ErrInvalidCommand) {
+		t.Errorf("Reset unsupported error = %v, want ErrInvalidCommand", err)
+	}
+}
+
+func TestModifyEnabledAlarms(t *testing.T) {
+	d := &testDelegate{}
+	c := newDishwasherAlarm(t, d, nil)
+
+	c.SetAlarm(DishwasherAlarmTempTooLow|DishwasherAlarmTempTooHigh, true)
+	if err := invoke(c, CmdModifyEnabledAlarms, DishwasherAlarmTempTooHigh); err != nil {
+		t.Fatalf("ModifyEnabledAlarms error = %v", err)
+	}
+	if c.Mask() != DishwasherAlarmTempTooHigh || c.State() != DishwasherAlarmTempTooHigh {
+		t.Errorf("Mask = 0x%02X, State = 0x%02X", c.Mask(), c.State())
+	}
+
+	// Disabled alarms are suppressed
+	c.SetAlarm(DishwasherAlarmDoorError, true)
+	if c.State() != DishwasherAlarmTempTooHigh {
+		t.Errorf("State = 0x%02X, masked alarm was raised", c.State())
+	}
+
+	d.reject = errors.New("busy")
+	if err := invoke(c, CmdModifyEnabledAlarms, DishwasherAlarm.Alarms); err == nil {
+		t.Error("vetoed ModifyEnabledAlarms succeeded")
+	}
+	if c.Mask() != DishwasherAlarmTempTooHigh {
+		t.Errorf("Mask = 0x%02X after veto", c.Mask())
+	}
+}
+
+func TestNotifyEvent_MarshalTLV(t *testing.T) {
+	var buf bytes.Buffer
+	e := NotifyEvent{Active: 1, Inactive: 2, State: 5, Mask: 7}
+	if err := e.MarshalTLV(tlv.NewWriter(&buf)); err != nil {
+		t.Fatalf("MarshalTLV error = %v", err)
+	}
+
+	r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+	r.Next()
+	r.EnterContainer()
+	var got []uint64
+	for r.Next() == nil && !r.IsEndOfContainer() {
+		v, _ := r.Uint()
+		got = append(got, v)
+	}
+	if len(got) != 4 || got[0] != 1 || got[1] != 2 || got[2] != 5 || got[3] != 7 {
+		t.Errorf("fields = %v", got)
+	}
+}
@@ -0,0 +1,60 @@
+package alarmbase
+
+import "github.com/backkem/matter/pkg/datamodel"
+
+// Dishwasher Alarm bits (Spec 8.4.5.1).
+const (
+	DishwasherAlarmInflowError     Alarm = 1 << 0
+	DishwasherAlarmDrainError      Alarm = 1 << 1
+	DishwasherAlarmDoorError       Alarm = 1 << 2
+	DishwasherAlarmTempTooLow      Alarm = 1 << 3
+	DishwasherAlarmTempTooHigh     Alarm = 1 << 4
+	DishwasherAlarmWaterLevelError Alarm = 1 << 5
+)
+
+// Refrigerator Alarm bits (Spec 8.8.5.1).
+const (
+	RefrigeratorAlarmDoorOpen Alarm = 1 << 0
+)
+
+// Definition describes a cluster derived from Alarm Base.
+type Definition struct {
+	// ClusterID of the derived cluster.
+	ClusterID datamodel.ClusterID
+
+	// Revision of the derived cluster.
+	Revision uint16
+
+	// Name of the derived cluster.
+	Name string
+
+	// Alarms are the alarm bits the derived cluster defines.
+	Alarms Alarm
+
+	// Reset is true if the derived cluster allows the RESET feature.
+	Reset bool
+
+	// ModifyEnabledAlarms is true if the derived cluster allows the
+	// ModifyEnabledAlarms command.
+	ModifyEnabledAlarms bool
+}
+
+// Definitions of the clusters derived from Alarm Base.
+var (
+	DishwasherAlarm = Definition{
+		ClusterID: 0x005D,
+		Revision:  1,
+		Name:      "Dishwasher Alarm",
+		Alarms: DishwasherAlarmInflowError | DishwasherAlarmDrainError | DishwasherAlarmDoorError |
+			DishwasherAlarmTempTooLow | DishwasherAlarmTempTooHigh | DishwasherAlarmWaterLevelError,
+		Reset:               true,
+		ModifyEnabledAlarms: true,
+	}
+
+	RefrigeratorAlarm = Definition{
+		ClusterID: 0x0057,
+		Revision:  1,
+		Name:      "Refrigerator Alarm",
+		Alarms:    RefrigeratorAlarmDoorOpen,
+	}
+)
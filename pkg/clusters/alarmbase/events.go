@@ -0,0 +1,33 @@
+package alarmbase
+
+import (
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// NotifyEvent is the payload of the Notify event (Spec 1.15.8.1).
+type NotifyEvent struct {
+	// Active are the alarms that became active.
+	Active Alarm
+
+	// Inactive are the alarms that became inactive.
+	Inactive Alarm
+
+	// State is the State attribute after the change.
+	State Alarm
+
+	// Mask is the Mask attribute after the change.
+	Mask Alarm
+}
+
+// MarshalTLV implements the TLVMarshaler interface.
+func (e NotifyEvent) MarshalTLV(w *tlv.Writer) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	for i, v := range []Alarm{e.Active, e.Inactive, e.State, e.Mask} {
+		if err := w.PutUint(tlv.ContextTag(uint8(i)), uint64(v)); err != nil {
+			return err
+		}
+	}
+	return w.EndContainer()
+}
@@ -81,16 +81,9 @@ func (c *Cluster) writeNodeLabel(r *tlv.Reader) error {
 		return datamodel.ErrConstraintError
 	}
 
-	// Update state
-	c.mu.Lock()
-	c.nodeLabel = label
-	c.mu.Unlock()
-
-	// Persist
-	if c.config.Storage != nil {
-		if err := c.config.Storage.StoreNodeLabel(label); err != nil {
-			return err
-		}
+	// Update and persist state
+	if err := c.nodeLabel.Set(label); err != nil {
+		return err
 	}
 
 	c.IncrementDataVersion()
@@ -117,16 +110,9 @@ func (c *Cluster) writeLocation(r *tlv.Reader) error {
 		return datamodel.ErrConstraintError
 	}
 
-	// Update state
-	c.mu.Lock()
-	c.location = location
-	c.mu.Unlock()
-
-	// Persist
-	if c.config.Storage != nil {
-		if err := c.config.Storage.StoreLocation(location); err != nil {
-			return err
-		}
+	// Update and persist state
+	if err := c.location.Set(location); err != nil {
+		return err
 	}
 
 	c.IncrementDataVersion()
@@ -147,16 +133,9 @@ func (c *Cluster) writeLocalConfigDisabled(r *tlv.Reader) error {
 		return err
 	}
 
-	// Update state
-	c.mu.Lock()
-	c.localConfigDisabled = disabled
-	c.mu.Unlock()
-
-	// Persist
-	if c.config.Storage != nil {
-		if err := c.config.Storage.StoreLocalConfigDisabled(disabled); err != nil {
-			return err
-		}
+	// Update and persist state
+	if err := c.localConfigDisabled.Set(disabled); err != nil {
+		return err
 	}
 
 	c.IncrementDataVersion()
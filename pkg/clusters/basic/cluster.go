@@ -163,36 +163,9 @@ type DeviceInfo struct {
 	Reachable *bool
 }
 
-// Storage provides persistence for mutable attributes.
-type Storage interface {
-	// LoadNodeLabel loads the persisted node label.
-	// Returns empty string if not found.
-	LoadNodeLabel() string
-
-	// StoreNodeLabel persists the node label.
-	StoreNodeLabel(label string) error
-
-	// LoadLocation loads the persisted location code.
-	// Returns "XX" if not found.
-	LoadLocation() string
-
-	// StoreLocation persists the location code.
-	StoreLocation(location string) error
-
-	// LoadLocalConfigDisabled loads the persisted value.
-	// Returns false if not found.
-	LoadLocalConfigDisabled() bool
-
-	// StoreLocalConfigDisabled persists the value.
-	StoreLocalConfigDisabled(disabled bool) error
-
-	// LoadConfigurationVersion loads the configuration version.
-	// Returns 1 if not found.
-	LoadConfigurationVersion() uint32
-
-	// StoreConfigurationVersion persists the configuration version.
-	StoreConfigurationVersion(version uint32) error
-}
+// Storage provides persistence for mutable attributes. Each attribute is
+// stored under its datamodel.AttributeKey.
+type Storage = datamodel.AttributeStorage
 
 // Config provides dependencies for the Basic Information cluster.
 type Config struct {
@@ -217,12 +190,13 @@ type Cluster struct {
 	*datamodel.EventSource
 	config Config
 
-	// Mutable state (protected by mutex)
-	mu                   sync.RWMutex
-	nodeLabel            string
-	location             string
-	localConfigDisabled  bool
-	configurationVersion uint32
+	// Mutable state, persisted if storage is available. mu serializes
+	// read-modify-write updates.
+	mu                   sync.Mutex
+	nodeLabel            *datamodel.PersistedAttribute[string]
+	location             *datamodel.PersistedAttribute[string]
+	localConfigDisabled  *datamodel.PersistedAttribute[bool]
+	configurationVersion *datamodel.PersistedAttribute[uint32]
 
 	// Cached attribute list (built on construction)
 	attrList []datamodel.AttributeEntry
@@ -234,20 +208,30 @@ func New(cfg Config) *Cluster {
 		ClusterBase: datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
 		EventSource: datamodel.NewEventSource(),
 		config:      cfg,
-		// Defaults
-		nodeLabel:            "",
-		location:             "XX",
-		localConfigDisabled:  false,
-		configurationVersion: 1,
 	}
 
 	// Load persisted values
-	if cfg.Storage != nil {
-		c.nodeLabel = cfg.Storage.LoadNodeLabel()
-		c.location = cfg.Storage.LoadLocation()
-		c.localConfigDisabled = cfg.Storage.LoadLocalConfigDisabled()
-		c.configurationVersion = cfg.Storage.LoadConfigurationVersion()
+	path := func(attr datamodel.AttributeID) datamodel.ConcreteAttributePath {
+		return datamodel.ConcreteAttributePath{Endpoint: cfg.EndpointID, Cluster: ClusterID, Attribute: attr}
 	}
+	c.nodeLabel = datamodel.NewPersistedAttribute(datamodel.PersistedAttributeConfig[string]{
+		Storage: cfg.Storage,
+		Path:    path(AttrNodeLabel),
+	})
+	c.location = datamodel.NewPersistedAttribute(datamodel.PersistedAttributeConfig[string]{
+		Storage: cfg.Storage,
+		Path:    path(AttrLocation),
+		Default: "XX",
+	})
+	c.localConfigDisabled = datamodel.NewPersistedAttribute(datamodel.PersistedAttributeConfig[bool]{
+		Storage: cfg.Storage,
+		Path:    path(AttrLocalConfigDisabled),
+	})
+	c.configurationVersion = datamodel.NewPersistedAttribute(datamodel.PersistedAttributeConfig[uint32]{
+		Storage: cfg.Storage,
+		Path:    path(AttrConfigurationVersion),
+		Default: 1,
+	})
 
 	// Bind event source
 	if cfg.EventPublisher != nil {
@@ -410,21 +394,13 @@ func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttribute
 
 	// Mutable attributes
 	case AttrNodeLabel:
-		c.mu.RLock()
-		defer c.mu.RUnlock()
-		return w.PutString(tlv.Anonymous(), c.nodeLabel)
+		return w.PutString(tlv.Anonymous(), c.nodeLabel.Get())
 	case AttrLocation:
-		c.mu.RLock()
-		defer c.mu.RUnlock()
-		return w.PutString(tlv.Anonymous(), c.location)
+		return w.PutString(tlv.Anonymous(), c.location.Get())
 	case AttrLocalConfigDisabled:
-		c.mu.RLock()
-		defer c.mu.RUnlock()
-		return w.PutBool(tlv.Anonymous(), c.localConfigDisabled)
+		return w.PutBool(tlv.Anonymous(), c.localConfigDisabled.Get())
 	case AttrConfigurationVersion:
-		c.mu.RLock()
-		defer c.mu.RUnlock()
-		return w.PutUint(tlv.Anonymous(), uint64(c.configurationVersion))
+		return w.PutUint(tlv.Anonymous(), uint64(c.configurationVersion.Get()))
 
 	// Optional fixed attributes
 	case AttrManufacturingDate:
@@ -487,30 +463,22 @@ func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest
 
 // GetNodeLabel returns the current node label.
 func (c *Cluster) GetNodeLabel() string {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	return c.nodeLabel
+	return c.nodeLabel.Get()
 }
 
 // GetLocation returns the current location code.
 func (c *Cluster) GetLocation() string {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	return c.location
+	return c.location.Get()
 }
 
 // GetLocalConfigDisabled returns the current local config disabled state.
 func (c *Cluster) GetLocalConfigDisabled() bool {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	return c.localConfigDisabled
+	return c.localConfigDisabled.Get()
 }
 
 // GetConfigurationVersion returns the current configuration version.
 func (c *Cluster) GetConfigurationVersion() uint32 {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	return c.configurationVersion
+	return c.configurationVersion.Get()
 }
 
 // IncrementConfigurationVersion increments the configuration version.
@@ -518,9 +486,6 @@ func (c *Cluster) GetConfigurationVersion() uint32 {
 func (c *Cluster) IncrementConfigurationVersion() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.configurationVersion++
-	if c.config.Storage != nil {
-		_ = c.config.Storage.StoreConfigurationVersion(c.configurationVersion)
-	}
+	_ = c.configurationVersion.Set(c.configurationVersion.Get() + 1)
 	c.IncrementDataVersion()
 }
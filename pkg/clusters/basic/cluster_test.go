@@ -33,29 +33,40 @@ const (
 
 // mockStorage implements Storage for testing.
 type mockStorage struct {
-	nodeLabel            string
-	location             string
-	localConfigDisabled  bool
-	configurationVersion uint32
+	data map[string][]byte
 }
 
 func newMockStorage() *mockStorage {
-	return &mockStorage{
-		nodeLabel:            "",
-		location:             "XX",
-		localConfigDisabled:  false,
-		configurationVersion: 1,
+	return &mockStorage{data: make(map[string][]byte)}
+}
+
+func (m *mockStorage) Load(key string) ([]byte, error) {
+	if v, ok := m.data[key]; ok {
+		return v, nil
 	}
+	return nil, fmt.Errorf("key %q not found", key)
+}
+
+func (m *mockStorage) Store(key string, value []byte) error {
+	m.data[key] = value
+	return nil
 }
 
-func (m *mockStorage) LoadNodeLabel() string                              { return m.nodeLabel }
-func (m *mockStorage) StoreNodeLabel(label string) error                  { m.nodeLabel = label; return nil }
-func (m *mockStorage) LoadLocation() string                               { return m.location }
-func (m *mockStorage) StoreLocation(location string) error                { m.location = location; return nil }
-func (m *mockStorage) LoadLocalConfigDisabled() bool                      { return m.localConfigDisabled }
-func (m *mockStorage) StoreLocalConfigDisabled(disabled bool) error       { m.localConfigDisabled = disabled; return nil }
-func (m *mockStorage) LoadConfigurationVersion() uint32                   { return m.configurationVersion }
-func (m *mockStorage) StoreConfigurationVersion(version uint32) error     { m.configurationVersion = version; return nil }
+// persisted returns the value of an attribute in the storage.
+func persisted[T any](m *mockStorage, attr datamodel.AttributeID) T {
+	return datamodel.NewPersistedAttribute(datamodel.PersistedAttributeConfig[T]{
+		Storage: m,
+		Path:    datamodel.ConcreteAttributePath{Cluster: ClusterID, Attribute: attr},
+	}).Get()
+}
+
+// persist stores the value of an attribute.
+func persist[T any](m *mockStorage, attr datamodel.AttributeID, v T) {
+	_ = datamodel.NewPersistedAttribute(datamodel.PersistedAttributeConfig[T]{
+		Storage: m,
+		Path:    datamodel.ConcreteAttributePath{Cluster: ClusterID, Attribute: attr},
+	}).Set(v)
+}
 
 // mockEventPublisher implements EventPublisher for testing.
 type mockEventPublisher struct {
@@ -642,8 +653,8 @@ func TestWriteNodeLabel(t *testing.T) {
 				}
 
 				// Verify persistence
-				if storage.nodeLabel != tt.label {
-					t.Errorf("expected persisted label %q, got %q", tt.label, storage.nodeLabel)
+				if persisted[string](storage, AttrNodeLabel) != tt.label {
+					t.Errorf("expected persisted label %q, got %q", tt.label, persisted[string](storage, AttrNodeLabel))
 				}
 			}
 		})
@@ -729,8 +740,8 @@ func TestWriteLocation(t *testing.T) {
 				}
 
 				// Verify persistence
-				if storage.location != tt.location {
-					t.Errorf("expected persisted location %q, got %q", tt.location, storage.location)
+				if persisted[string](storage, AttrLocation) != tt.location {
+					t.Errorf("expected persisted location %q, got %q", tt.location, persisted[string](storage, AttrLocation))
 				}
 			}
 		})
@@ -769,7 +780,7 @@ func TestWriteLocalConfigDisabled(t *testing.T) {
 		t.Error("expected LocalConfigDisabled to be true")
 	}
 
-	if !storage.localConfigDisabled {
+	if !persisted[bool](storage, AttrLocalConfigDisabled) {
 		t.Error("expected persisted LocalConfigDisabled to be true")
 	}
 
@@ -1010,17 +1021,17 @@ func TestIncrementConfigurationVersion(t *testing.T) {
 	}
 
 	// Verify persistence
-	if storage.configurationVersion != initial+1 {
-		t.Errorf("expected persisted ConfigurationVersion %d, got %d", initial+1, storage.configurationVersion)
+	if persisted[uint32](storage, AttrConfigurationVersion) != initial+1 {
+		t.Errorf("expected persisted ConfigurationVersion %d, got %d", initial+1, persisted[uint32](storage, AttrConfigurationVersion))
 	}
 }
 
 func TestPersistenceLoadOnCreate(t *testing.T) {
 	storage := newMockStorage()
-	storage.nodeLabel = "Persisted Label"
-	storage.location = "GB"
-	storage.localConfigDisabled = true
-	storage.configurationVersion = 42
+	persist(storage, AttrNodeLabel, "Persisted Label")
+	persist(storage, AttrLocation, "GB")
+	persist(storage, AttrLocalConfigDisabled, true)
+	persist(storage, AttrConfigurationVersion, uint32(42))
 
 	c := createTestCluster(storage, nil)
 
@@ -0,0 +1,550 @@
+// Package cameraavstreammanagement implements the Camera AV Stream
+// Management Cluster (0x0551).
+//
+// The cluster describes the audio, video and snapshot capabilities of a
+// camera and manages the streams allocated from them. Clients allocate
+// streams with a usage (e.g. LiveView or Recording) and transports such
+// as WebRTC Transport Provider reference those streams by ID. The actual
+// encoder setup and image capture are delegated to the camera.
+//
+// Spec Reference: Section 11.2
+//
+// C++ Reference: src/app/clusters/camera-av-stream-management-server
+package cameraavstreammanagement
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x0551
+	ClusterRevision uint16              = 1
+)
+
+// Attribute IDs (Spec 11.2.7).
+const (
+	AttrMaxConcurrentEncoders            datamodel.AttributeID = 0x0000
+	AttrMaxEncodedPixelRate              datamodel.AttributeID = 0x0001
+	AttrVideoSensorParams                datamodel.AttributeID = 0x0002
+	AttrMinViewportResolution            datamodel.AttributeID = 0x0004
+	AttrRateDistortionTradeOffPoints     datamodel.AttributeID = 0x0005
+	AttrMaxContentBufferSize             datamodel.AttributeID = 0x0006
+	AttrMicrophoneCapabilities           datamodel.AttributeID = 0x0007
+	AttrSnapshotCapabilities             datamodel.AttributeID = 0x000A
+	AttrMaxNetworkBandwidth              datamodel.AttributeID = 0x000B
+	AttrCurrentFrameRate                 datamodel.AttributeID = 0x000C
+	AttrSupportedStreamUsages            datamodel.AttributeID = 0x000E
+	AttrAllocatedVideoStreams            datamodel.AttributeID = 0x000F
+	AttrAllocatedAudioStreams            datamodel.AttributeID = 0x0010
+	AttrAllocatedSnapshotStreams         datamodel.AttributeID = 0x0011
+	AttrStreamUsagePriorities            datamodel.AttributeID = 0x0012
+	AttrSoftRecordingPrivacyModeEnabled  datamodel.AttributeID = 0x0013
+	AttrSoftLivestreamPrivacyModeEnabled datamodel.AttributeID = 0x0014
+)
+
+// Command IDs (Spec 11.2.8).
+const (
+	CmdAudioStreamAllocate            datamodel.CommandID = 0x00
+	CmdAudioStreamAllocateResponse    datamodel.CommandID = 0x01
+	CmdAudioStreamDeallocate          datamodel.CommandID = 0x02
+	CmdVideoStreamAllocate            datamodel.CommandID = 0x03
+	CmdVideoStreamAllocateResponse    datamodel.CommandID = 0x04
+	CmdVideoStreamModify              datamodel.CommandID = 0x05
+	CmdVideoStreamDeallocate          datamodel.CommandID = 0x06
+	CmdSnapshotStreamAllocate         datamodel.CommandID = 0x07
+	CmdSnapshotStreamAllocateResponse datamodel.CommandID = 0x08
+	CmdSnapshotStreamModify           datamodel.CommandID = 0x09
+	CmdSnapshotStreamDeallocate       datamodel.CommandID = 0x0A
+	CmdSetStreamPriorities            datamodel.CommandID = 0x0B
+	CmdCaptureSnapshot                datamodel.CommandID = 0x0C
+	CmdCaptureSnapshotResponse        datamodel.CommandID = 0x0D
+)
+
+// Feature bits (Spec 11.2.4).
+type Feature uint32
+
+const (
+	// FeatureAudio supports audio streams (ADO).
+	FeatureAudio Feature = 1 << 0
+
+	// FeatureVideo supports video streams (VDO).
+	FeatureVideo Feature = 1 << 1
+
+	// FeatureSnapshot supports snapshot streams and CaptureSnapshot (SNP).
+	FeatureSnapshot Feature = 1 << 2
+
+	// FeaturePrivacy supports the soft privacy mode attributes (PRIV).
+	FeaturePrivacy Feature = 1 << 3
+
+	// FeatureSpeaker supports a speaker (SPKR). Not implemented.
+	FeatureSpeaker Feature = 1 << 4
+
+	// FeatureImageControl supports image flip and rotation (ICTL). Not
+	// implemented.
+	FeatureImageControl Feature = 1 << 5
+
+	// FeatureWatermark supports watermarks on streams (WMARK).
+	FeatureWatermark Feature = 1 << 6
+
+	// FeatureOnScreenDisplay supports on-screen display on streams (OSD).
+	FeatureOnScreenDisplay Feature = 1 << 7
+
+	// FeatureLocalStorage supports local storage (LOCAL). Not implemented.
+	FeatureLocalStorage Feature = 1 << 8
+
+	// FeatureHighDynamicRange supports HDR (HDR). Not implemented.
+	FeatureHighDynamicRange Feature = 1 << 9
+
+	// FeatureNightVision supports night vision (NV). Not implemented.
+	FeatureNightVision Feature = 1 << 10
+)
+
+// supportedFeatures are the features implemented by this package.
+const supportedFeatures = FeatureAudio | FeatureVideo | FeatureSnapshot |
+	FeaturePrivacy | FeatureWatermark | FeatureOnScreenDisplay
+
+// Errors returned by New.
+var (
+	ErrInvalidFeatures    = errors.New("cameraavstreammanagement: unsupported feature combination")
+	ErrNoDelegate         = errors.New("cameraavstreammanagement: delegate is required")
+	ErrNoStreamUsages     = errors.New("cameraavstreammanagement: at least one stream usage is required")
+	ErrInvalidPriorities  = errors.New("cameraavstreammanagement: stream usage priorities must be unique supported usages")
+	ErrNoSnapshotCapacity = errors.New("cameraavstreammanagement: snapshot capabilities are required")
+)
+
+// Delegate configures the camera's encoders. Stream parameters have
+// already been validated against the cluster's capabilities; returning an
+// error rejects the request without changing cluster state.
+type Delegate interface {
+	// HandleVideoStreamAllocate starts a video encoder for s (VDO).
+	HandleVideoStreamAllocate(s VideoStream) error
+
+	// HandleVideoStreamModify applies changed watermark or OSD settings (VDO).
+	HandleVideoStreamModify(s VideoStream) error
+
+	// HandleVideoStreamDeallocate stops the video encoder (VDO).
+	HandleVideoStreamDeallocate(id uint16) error
+
+	// HandleAudioStreamAllocate starts an audio encoder for s (ADO).
+	HandleAudioStreamAllocate(s AudioStream) error
+
+	// HandleAudioStreamDeallocate stops the audio encoder (ADO).
+	HandleAudioStreamDeallocate(id uint16) error
+
+	// HandleSnapshotStreamAllocate prepares a snapshot stream (SNP).
+	HandleSnapshotStreamAllocate(s SnapshotStream) error
+
+	// HandleSnapshotStreamModify applies changed watermark or OSD
+	// settings (SNP).
+	HandleSnapshotStreamModify(s SnapshotStream) error
+
+	// HandleSnapshotStreamDeallocate releases the snapshot stream (SNP).
+	HandleSnapshotStreamDeallocate(id uint16) error
+
+	// HandleCaptureSnapshot captures an image from the snapshot stream at
+	// approximately the requested resolution (SNP).
+	HandleCaptureSnapshot(streamID uint16, resolution VideoResolution) (*Snapshot, error)
+}
+
+// Config provides dependencies for the Camera AV Stream Management cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to.
+	EndpointID datamodel.EndpointID
+
+	// FeatureMap indicates supported features. At least one of Audio,
+	// Video or Snapshot is required.
+	FeatureMap Feature
+
+	// MaxConcurrentEncoders is the number of video encoders (VDO).
+	MaxConcurrentEncoders uint8
+
+	// MaxEncodedPixelRate is the total pixels per second the encoders can
+	// produce across all video and snapshot streams (VDO or SNP).
+	MaxEncodedPixelRate uint32
+
+	// VideoSensorParams describes the image sensor (VDO or SNP).
+	VideoSensorParams VideoSensorParams
+
+	// MinViewportResolution is the smallest supported resolution (VDO).
+	MinViewportResolution VideoResolution
+
+	// RateDistortionTradeOffPoints lists the minimum bit rate per codec
+	// and resolution (VDO). Allocations are limited to listed codecs.
+	RateDistortionTradeOffPoints []RateDistortionTradeOffPoint
+
+	// MaxContentBufferSize is the size of the content buffer in bytes.
+	MaxContentBufferSize uint32
+
+	// MicrophoneCapabilities describes the microphone (ADO).
+	MicrophoneCapabilities AudioCapabilities
+
+	// SnapshotCapabilities lists the supported snapshot modes (SNP).
+	SnapshotCapabilities []SnapshotCapabilities
+
+	// MaxNetworkBandwidth is the total bit rate in bps available to all
+	// allocated streams.
+	MaxNetworkBandwidth uint32
+
+	// SupportedStreamUsages lists the stream usages the camera supports.
+	SupportedStreamUsages []StreamUsage
+
+	// StreamUsagePriorities is the initial priority order of usages.
+	// Defaults to SupportedStreamUsages.
+	StreamUsagePriorities []StreamUsage
+
+	// Delegate configures the encoders (required).
+	Delegate Delegate
+
+	// OnPrivacyModeChange is called when a soft privacy mode attribute is
+	// written (optional). Transports should end livestreams or recordings
+	// accordingly.
+	OnPrivacyModeChange func(endpoint datamodel.EndpointID, recording, livestream bool)
+}
+
+// Cluster implements the Camera AV Stream Management cluster (0x0551).
+type Cluster struct {
+	*datamodel.ClusterBase
+	config Config
+
+	// cmdMu serializes commands.
+	cmdMu sync.Mutex
+
+	// Mutable state (protected by mutex)
+	mu                sync.RWMutex
+	videoStreams      []VideoStream
+	audioStreams      []AudioStream
+	snapshotStreams   []SnapshotStream
+	priorities        []StreamUsage
+	currentFrameRate  uint16
+	recordingPrivacy  bool
+	livestreamPrivacy bool
+	nextStreamID      uint16
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Camera AV Stream Management cluster.
+func New(cfg Config) (*Cluster, error) {
+	if cfg.FeatureMap&^supportedFeatures != 0 {
+		return nil, ErrInvalidFeatures
+	}
+	if cfg.FeatureMap&(FeatureAudio|FeatureVideo|FeatureSnapshot) == 0 {
+		return nil, ErrInvalidFeatures
+	}
+	// Watermark and OSD apply to video or snapshot streams
+	if cfg.FeatureMap&(FeatureWatermark|FeatureOnScreenDisplay) != 0 &&
+		cfg.FeatureMap&(FeatureVideo|FeatureSnapshot) == 0 {
+		return nil, ErrInvalidFeatures
+	}
+	if cfg.Delegate == nil {
+		return nil, ErrNoDelegate
+	}
+	if len(cfg.SupportedStreamUsages) == 0 {
+		return nil, ErrNoStreamUsages
+	}
+	if cfg.FeatureMap&FeatureSnapshot != 0 && len(cfg.SnapshotCapabilities) == 0 {
+		return nil, ErrNoSnapshotCapacity
+	}
+	if cfg.StreamUsagePriorities == nil {
+		cfg.StreamUsagePriorities = cfg.SupportedStreamUsages
+	}
+
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		config:      cfg,
+	}
+	if err := c.validatePriorities(cfg.StreamUsagePriorities); err != nil {
+		return nil, ErrInvalidPriorities
+	}
+	c.priorities = append([]StreamUsage(nil), cfg.StreamUsagePriorities...)
+
+	c.ClusterBase.SetFeatureMap(uint32(cfg.FeatureMap))
+	c.attrList = c.buildAttributeList()
+
+	return c, nil
+}
+
+// hasFeature returns true if the feature bit is set.
+func (c *Cluster) hasFeature(f Feature) bool {
+	return c.config.FeatureMap&f != 0
+}
+
+// buildAttributeList constructs the list of supported attributes.
+func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+	managePriv := datamodel.PrivilegeManage
+	list := datamodel.AttrQualityList
+	fixed := datamodel.AttrQualityFixed
+
+	attrs := []datamodel.AttributeEntry{
+		datamodel.NewReadOnlyAttribute(AttrMaxContentBufferSize, fixed, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrMaxNetworkBandwidth, fixed, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrSupportedStreamUsages, list|fixed, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrStreamUsagePriorities, list, viewPriv),
+	}
+
+	if c.hasFeature(FeatureVideo) || c.hasFeature(FeatureSnapshot) {
+		attrs = append(attrs,
+			datamodel.NewReadOnlyAttribute(AttrMaxEncodedPixelRate, fixed, viewPriv),
+			datamodel.NewReadOnlyAttribute(AttrVideoSensorParams, fixed, viewPriv),
+		)
+	}
+	if c.hasFeature(FeatureVideo) {
+		attrs = append(attrs,
+			datamodel.NewReadOnlyAttribute(AttrMaxConcurrentEncoders, fixed, viewPriv),
+			datamodel.NewReadOnlyAttribute(AttrMinViewportResolution, fixed, viewPriv),
+			datamodel.NewReadOnlyAttribute(AttrRateDistortionTradeOffPoints, list|fixed, viewPriv),
+			datamodel.NewReadOnlyAttribute(AttrCurrentFrameRate, 0, viewPriv),
+			datamodel.NewReadOnlyAttribute(AttrAllocatedVideoStreams, list, viewPriv),
+		)
+	}
+	if c.hasFeature(FeatureAudio) {
+		attrs = append(attrs,
+			datamodel.NewReadOnlyAttribute(AttrMicrophoneCapabilities, fixed, viewPriv),
+			datamodel.NewReadOnlyAttribute(AttrAllocatedAudioStreams, list, viewPriv),
+		)
+	}
+	if c.hasFeature(FeatureSnapshot) {
+		attrs = append(attrs,
+			datamodel.NewReadOnlyAttribute(AttrSnapshotCapabilities, list|fixed, viewPriv),
+			datamodel.NewReadOnlyAttribute(AttrAllocatedSnapshotStreams, list, viewPriv),
+		)
+	}
+	if c.hasFeature(FeaturePrivacy) {
+		attrs = append(attrs,
+			datamodel.NewReadWriteAttribute(AttrSoftRecordingPrivacyModeEnabled, 0, viewPriv, managePriv),
+			datamodel.NewReadWriteAttribute(AttrSoftLivestreamPrivacyModeEnabled, 0, viewPriv, managePriv),
+		)
+	}
+
+	return datamodel.MergeAttributeLists(attrs)
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	operatePriv := datamodel.PrivilegeOperate
+	managePriv := datamodel.PrivilegeManage
+	watermarkOrOSD := c.hasFeature(FeatureWatermark) || c.hasFeature(FeatureOnScreenDisplay)
+
+	cmds := []datamodel.CommandEntry{
+		datamodel.NewCommandEntry(CmdSetStreamPriorities, 0, managePriv),
+	}
+	if c.hasFeature(FeatureAudio) {
+		cmds = append(cmds,
+			datamodel.NewCommandEntry(CmdAudioStreamAllocate, 0, managePriv),
+			datamodel.NewCommandEntry(CmdAudioStreamDeallocate, 0, managePriv),
+		)
+	}
+	if c.hasFeature(FeatureVideo) {
+		cmds = append(cmds,
+			datamodel.NewCommandEntry(CmdVideoStreamAllocate, 0, managePriv),
+			datamodel.NewCommandEntry(CmdVideoStreamDeallocate, 0, managePriv),
+		)
+		if watermarkOrOSD {
+			cmds = append(cmds, datamodel.NewCommandEntry(CmdVideoStreamModify, 0, managePriv))
+		}
+	}
+	if c.hasFeature(FeatureSnapshot) {
+		cmds = append(cmds,
+			datamodel.NewCommandEntry(CmdSnapshotStreamAllocate, 0, managePriv),
+			datamodel.NewCommandEntry(CmdSnapshotStreamDeallocate, 0, managePriv),
+			datamodel.NewCommandEntry(CmdCaptureSnapshot, 0, operatePriv),
+		)
+		if watermarkOrOSD {
+			cmds = append(cmds, datamodel.NewCommandEntry(CmdSnapshotStreamModify, 0, managePriv))
+		}
+	}
+	return cmds
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	var cmds []datamodel.CommandID
+	if c.hasFeature(FeatureAudio) {
+		cmds = append(cmds, CmdAudioStreamAllocateResponse)
+	}
+	if c.hasFeature(FeatureVideo) {
+		cmds = append(cmds, CmdVideoStreamAllocateResponse)
+	}
+	if c.hasFeature(FeatureSnapshot) {
+		cmds = append(cmds, CmdSnapshotStreamAllocateResponse, CmdCaptureSnapshotResponse)
+	}
+	return cmds
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+	if !c.hasAttribute(req.Path.Attribute) {
+		return datamodel.ErrUnsupportedAttribute
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	switch req.Path.Attribute {
+	case AttrMaxConcurrentEncoders:
+		return w.PutUint(tlv.Anonymous(), uint64(c.config.MaxConcurrentEncoders))
+	case AttrMaxEncodedPixelRate:
+		return w.PutUint(tlv.Anonymous(), uint64(c.config.MaxEncodedPixelRate))
+	case AttrVideoSensorParams:
+		return c.config.VideoSensorParams.MarshalTLV(w, tlv.Anonymous())
+	case AttrMinViewportResolution:
+		return c.config.MinViewportResolution.MarshalTLV(w, tlv.Anonymous())
+	case AttrRateDistortionTradeOffPoints:
+		return writeList(w, c.config.RateDistortionTradeOffPoints)
+	case AttrMaxContentBufferSize:
+		return w.PutUint(tlv.Anonymous(), uint64(c.config.MaxContentBufferSize))
+	case AttrMicrophoneCapabilities:
+		return c.config.MicrophoneCapabilities.MarshalTLV(w, tlv.Anonymous())
+	case AttrSnapshotCapabilities:
+		return writeList(w, c.config.SnapshotCapabilities)
+	case AttrMaxNetworkBandwidth:
+		return w.PutUint(tlv.Anonymous(), uint64(c.config.MaxNetworkBandwidth))
+	case AttrCurrentFrameRate:
+		return w.PutUint(tlv.Anonymous(), uint64(c.currentFrameRate))
+	case AttrSupportedStreamUsages:
+		return putUintList(w, tlv.Anonymous(), len(c.config.SupportedStreamUsages),
+			func(i int) uint64 { return uint64(c.config.SupportedStreamUsages[i]) })
+	case AttrAllocatedVideoStreams:
+		return writeList(w, c.videoStreams)
+	case AttrAllocatedAudioStreams:
+		return writeList(w, c.audioStreams)
+	case AttrAllocatedSnapshotStreams:
+		return writeList(w, c.snapshotStreams)
+	case AttrStreamUsagePriorities:
+		return putUintList(w, tlv.Anonymous(), len(c.priorities),
+			func(i int) uint64 { return uint64(c.priorities[i]) })
+	case AttrSoftRecordingPrivacyModeEnabled:
+		return w.PutBool(tlv.Anonymous(), c.recordingPrivacy)
+	case AttrSoftLivestreamPrivacyModeEnabled:
+		return w.PutBool(tlv.Anonymous(), c.livestreamPrivacy)
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// hasAttribute returns true if id is in the attribute list.
+func (c *Cluster) hasAttribute(id datamodel.AttributeID) bool {
+	for _, a := range c.attrList {
+		if a.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	switch req.Path.Attribute {
+	case AttrSoftRecordingPrivacyModeEnabled, AttrSoftLivestreamPrivacyModeEnabled:
+		if !c.hasFeature(FeaturePrivacy) {
+			return datamodel.ErrUnsupportedAttribute
+		}
+		if err := r.Next(); err != nil {
+			return err
+		}
+		v, err := r.Bool()
+		if err != nil {
+			return err
+		}
+		if req.Path.Attribute == AttrSoftRecordingPrivacyModeEnabled {
+			c.SetPrivacyMode(v, c.LivestreamPrivacyModeEnabled())
+		} else {
+			c.SetPrivacyMode(c.RecordingPrivacyModeEnabled(), v)
+		}
+		return nil
+	default:
+		if c.hasAttribute(req.Path.Attribute) {
+			return datamodel.ErrUnsupportedWrite
+		}
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// SetPrivacyMode sets the soft recording and livestream privacy modes
+// (PRIV). While livestream privacy is enabled no LiveView stream can be
+// acquired and no snapshot can be captured; while recording privacy is
+// enabled no Recording stream can be acquired.
+func (c *Cluster) SetPrivacyMode(recording, livestream bool) {
+	c.mu.Lock()
+	if c.recordingPrivacy == recording && c.livestreamPrivacy == livestream {
+		c.mu.Unlock()
+		return
+	}
+	c.recordingPrivacy, c.livestreamPrivacy = recording, livestream
+	c.mu.Unlock()
+
+	c.IncrementDataVersion()
+	if c.config.OnPrivacyModeChange != nil {
+		c.config.OnPrivacyModeChange(c.config.EndpointID, recording, livestream)
+	}
+}
+
+// RecordingPrivacyModeEnabled returns SoftRecordingPrivacyModeEnabled.
+func (c *Cluster) RecordingPrivacyModeEnabled() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.recordingPrivacy
+}
+
+// LivestreamPrivacyModeEnabled returns SoftLivestreamPrivacyModeEnabled.
+func (c *Cluster) LivestreamPrivacyModeEnabled() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.livestreamPrivacy
+}
+
+// SetCurrentFrameRate updates CurrentFrameRate, the frame rate the sensor
+// is currently producing (VDO).
+func (c *Cluster) SetCurrentFrameRate(fps uint16) {
+	c.mu.Lock()
+	changed := c.currentFrameRate != fps
+	c.currentFrameRate = fps
+	c.mu.Unlock()
+
+	if changed {
+		c.IncrementDataVersion()
+	}
+}
+
+// StreamUsagePriorities returns the current priority order of usages.
+func (c *Cluster) StreamUsagePriorities() []StreamUsage {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]StreamUsage(nil), c.priorities...)
+}
+
+// marshaler is implemented by the attribute struct types.
+type marshaler interface {
+	MarshalTLV(w *tlv.Writer, tag tlv.Tag) error
+}
+
+// writeList writes a list attribute of structs.
+func writeList[T marshaler](w *tlv.Writer, items []T) error {
+	if err := w.StartArray(tlv.Anonymous()); err != nil {
+		return err
+	}
+	for _, item := range items {
+		if err := item.MarshalTLV(w, tlv.Anonymous()); err != nil {
+			return err
+		}
+	}
+	return w.EndContainer()
+}
@@ -0,0 +1,472 @@
+package cameraavstreammanagement
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// mockCamera records delegate calls.
+type mockCamera struct {
+	video    []VideoStream
+	audio    []AudioStream
+	snapshot []SnapshotStream
+	released []uint16
+	fail     error
+}
+
+func (m *mockCamera) HandleVideoStreamAllocate(s VideoStream) error {
+	if m.fail != nil {
+		return m.fail
+	}
+	m.video = append(m.video, s)
+	return nil
+}
+
+func (m *mockCamera) HandleVideoStreamModify(s VideoStream) error { return m.fail }
+
+func (m *mockCamera) HandleVideoStreamDeallocate(id uint16) error {
+	m.released = append(m.released, id)
+	return nil
+}
+
+func (m *mockCamera) HandleAudioStreamAllocate(s AudioStream) error {
+	m.audio = append(m.audio, s)
+	return nil
+}
+
+func (m *mockCamera) HandleAudioStreamDeallocate(id uint16) error {
+	m.released = append(m.released, id)
+	return nil
+}
+
+func (m *mockCamera) HandleSnapshotStreamAllocate(s SnapshotStream) error {
+	m.snapshot = append(m.snapshot, s)
+	return nil
+}
+
+func (m *mockCamera) HandleSnapshotStreamModify(s SnapshotStream) error { return nil }
+
+func (m *mockCamera) HandleSnapshotStreamDeallocate(id uint16) error {
+	m.released = append(m.released, id)
+	return nil
+}
+
+func (m *mockCamera) HandleCaptureSnapshot(streamID uint16, resolution VideoResolution) (*Snapshot, error) {
+	return &Snapshot{Data: []byte{0xFF, 0xD8}, ImageCodec: ImageCodecJPEG, Resolution: resolution}, nil
+}
+
+var (
+	res720  = VideoResolution{Width: 1280, Height: 720}
+	res1080 = VideoResolution{Width: 1920, Height: 1080}
+	res360  = VideoResolution{Width: 640, Height: 360}
+)
+
+func testConfig(m *mockCamera) Config {
+	return Config{
+		EndpointID:            1,
+		FeatureMap:            FeatureAudio | FeatureVideo | FeatureSnapshot | FeaturePrivacy | FeatureWatermark,
+		MaxConcurrentEncoders: 2,
+		MaxEncodedPixelRate:   1920 * 1080 * 60,
+		VideoSensorParams:     VideoSensorParams{SensorWidth: 1920, SensorHeight: 1080, MaxFPS: 30},
+		MinViewportResolution: res360,
+		RateDistortionTradeOffPoints: []RateDistortionTradeOffPoint{
+			{Codec: VideoCodecH264, Resolution: res720, MinBitRate: 1_000_000},
+		},
+		MicrophoneCapabilities: AudioCapabilities{
+			MaxNumberOfChannels:  2,
+			SupportedCodecs:      []AudioCodec{AudioCodecOpus},
+			SupportedSampleRates: []uint32{48000},
+			SupportedBitDepths:   []uint8{16},
+		},
+		SnapshotCapabilities: []SnapshotCapabilities{
+			{Resolution: res1080, MaxFrameRate: 1, ImageCodec: ImageCodecJPEG},
+		},
+		MaxNetworkBandwidth:   10_000_000,
+		SupportedStreamUsages: []StreamUsage{StreamUsageLiveView, StreamUsageRecording},
+		Delegate:              m,
+	}
+}
+
+func newCluster(t *testing.T, m *mockCamera) *Cluster {
+	t.Helper()
+	c, err := New(testConfig(m))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+func liveView() VideoStream {
+	off := false
+	return VideoStream{
+		StreamUsage:      StreamUsageLiveView,
+		VideoCodec:       VideoCodecH264,
+		MinFrameRate:     15,
+		MaxFrameRate:     30,
+		MinResolution:    res360,
+		MaxResolution:    res720,
+		MinBitRate:       1_000_000,
+		MaxBitRate:       4_000_000,
+		KeyFrameInterval: 4000,
+		WatermarkEnabled: &off,
+	}
+}
+
+func invoke(c *Cluster, cmd datamodel.CommandID, encode func(w *tlv.Writer)) ([]byte, error) {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	w.StartStructure(tlv.Anonymous())
+	encode(w)
+	w.EndContainer()
+
+	req := datamodel.InvokeRequest{
+		Path: datamodel.ConcreteCommandPath{Endpoint: 1, Cluster: ClusterID, Command: cmd},
+	}
+	return c.InvokeCommand(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes())))
+}
+
+// responseID decodes the stream ID (tag 0) of an allocate response.
+func responseID(t *testing.T, resp []byte) uint16 {
+	t.Helper()
+	r := tlv.NewReader(bytes.NewReader(resp))
+	r.Next()
+	r.EnterContainer()
+	r.Next()
+	v, err := r.Uint()
+	if err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return uint16(v)
+}
+
+func TestNew_Validation(t *testing.T) {
+	m := &mockCamera{}
+	tests := []struct {
+		name   string
+		modify func(cfg *Config)
+		want   error
+	}{
+		{"valid", func(cfg *Config) {}, nil},
+		{"no delegate", func(cfg *Config) { cfg.Delegate = nil }, ErrNoDelegate},
+		{"no stream feature", func(cfg *Config) { cfg.FeatureMap = FeaturePrivacy }, ErrInvalidFeatures},
+		{"unimplemented feature", func(cfg *Config) { cfg.FeatureMap |= FeatureSpeaker }, ErrInvalidFeatures},
+		{"watermark without video", func(cfg *Config) { cfg.FeatureMap = FeatureAudio | FeatureWatermark }, ErrInvalidFeatures},
+		{"no usages", func(cfg *Config) { cfg.SupportedStreamUsages = nil }, ErrNoStreamUsages},
+		{"no snapshot capabilities", func(cfg *Config) { cfg.SnapshotCapabilities = nil }, ErrNoSnapshotCapacity},
+		{"duplicate priority", func(cfg *Config) {
+			cfg.StreamUsagePriorities = []StreamUsage{StreamUsageLiveView, StreamUsageLiveView}
+		}, ErrInvalidPriorities},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(m)
+			tt.modify(&cfg)
+			if _, err := New(cfg); !errors.Is(err, tt.want) {
+				t.Errorf("New() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVideoStreamAllocate(t *testing.T) {
+	m := &mockCamera{}
+	c := newCluster(t, m)
+
+	s := liveView()
+	resp, err := invoke(c, CmdVideoStreamAllocate, func(w *tlv.Writer) {
+		w.PutUint(tlv.ContextTag(0), uint64(s.StreamUsage))
+		w.PutUint(tlv.ContextTag(1), uint64(s.VideoCodec))
+		w.PutUint(tlv.ContextTag(2), uint64(s.MinFrameRate))
+		w.PutUint(tlv.ContextTag(3), uint64(s.MaxFrameRate))
+		s.MinResolution.MarshalTLV(w, tlv.ContextTag(4))
+		s.MaxResolution.MarshalTLV(w, tlv.ContextTag(5))
+		w.PutUint(tlv.ContextTag(6), uint64(s.MinBitRate))
+		w.PutUint(tlv.ContextTag(7), uint64(s.MaxBitRate))
+		w.PutUint(tlv.ContextTag(8), uint64(s.KeyFrameInterval))
+		w.PutBool(tlv.ContextTag(9), true)
+	})
+	if err != nil {
+		t.Fatalf("VideoStreamAllocate error = %v", err)
+	}
+	id := responseID(t, resp)
+	streams := c.VideoStreams()
+	if len(streams) != 1 || streams[0].VideoStreamID != id || len(m.video) != 1 {
+		t.Fatalf("streams = %+v", streams)
+	}
+	if streams[0].WatermarkEnabled == nil || !*streams[0].WatermarkEnabled {
+		t.Error("WatermarkEnabled not stored")
+	}
+
+	// Watermark is required with WMARK
+	_, err = invoke(c, CmdVideoStreamAllocate, func(w *tlv.Writer) {
+		w.PutUint(tlv.ContextTag(0), uint64(s.StreamUsage))
+		w.PutUint(tlv.ContextTag(1), uint64(s.VideoCodec))
+		w.PutUint(tlv.ContextTag(2), uint64(s.MinFrameRate))
+		w.PutUint(tlv.ContextTag(3), uint64(s.MaxFrameRate))
+		s.MinResolution.MarshalTLV(w, tlv.ContextTag(4))
+		s.MaxResolution.MarshalTLV(w, tlv.ContextTag(5))
+		w.PutUint(tlv.ContextTag(6), uint64(s.MinBitRate))
+		w.PutUint(tlv.ContextTag(7), uint64(s.MaxBitRate))
+		w.PutUint(tlv.ContextTag(8), uint64(s.KeyFrameInterval))
+	})
+	if !errors.Is(err, datamodel.ErrInvalidCommand) {
+		t.Errorf("missing watermark error = %v, want ErrInvalidCommand", err)
+	}
+}
+
+func TestAllocateVideoStream_Constraints(t *testing.T) {
+	c := newCluster(t, &mockCamera{})
+
+	tests := []struct {
+		name   string
+		modify func(s *VideoStream)
+		want   error
+	}{
+		{"unsupported codec", func(s *VideoStream) { s.VideoCodec = VideoCodecAV1 }, datamodel.ErrConstraintError},
+		{"frame rate range", func(s *VideoStream) { s.MinFrameRate = 31 }, datamodel.ErrConstraintError},
+		{"above sensor fps", func(s *VideoStream) { s.MaxFrameRate = 60 }, datamodel.ErrConstraintError},
+		{"above sensor size", func(s *VideoStream) { s.MaxResolution = VideoResolution{3840, 2160} }, datamodel.ErrConstraintError},
+		{"below viewport", func(s *VideoStream) { s.MinResolution = VideoResolution{320, 180} }, datamodel.ErrConstraintError},
+		{"bit rate range", func(s *VideoStream) { s.MinBitRate = 5_000_000 }, datamodel.ErrConstraintError},
+		{"unsupported usage", func(s *VideoStream) { s.StreamUsage = StreamUsageAnalysis }, datamodel.ErrConstraintError},
+		{"bandwidth", func(s *VideoStream) { s.MaxBitRate = 20_000_000 }, datamodel.ErrResourceExhausted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := liveView()
+			tt.modify(&s)
+			if _, err := c.AllocateVideoStream(s); !errors.Is(err, tt.want) {
+				t.Errorf("AllocateVideoStream() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllocateVideoStream_ReuseAndLimits(t *testing.T) {
+	m := &mockCamera{}
+	c := newCluster(t, m)
+
+	id1, err := c.AllocateVideoStream(liveView())
+	if err != nil {
+		t.Fatalf("AllocateVideoStream error = %v", err)
+	}
+	id2, _ := c.AllocateVideoStream(liveView())
+	if id1 != id2 || len(m.video) != 1 {
+		t.Errorf("identical stream not reused: %d, %d", id1, id2)
+	}
+
+	rec := liveView()
+	rec.StreamUsage = StreamUsageRecording
+	if _, err := c.AllocateVideoStream(rec); err != nil {
+		t.Fatalf("second encoder error = %v", err)
+	}
+	rec.MaxBitRate = 2_000_000
+	if _, err := c.AllocateVideoStream(rec); !errors.Is(err, datamodel.ErrResourceExhausted) {
+		t.Errorf("third encoder error = %v, want ErrResourceExhausted", err)
+	}
+
+	m.fail = errors.New("encoder busy")
+	c2 := newCluster(t, m)
+	if _, err := c2.AllocateVideoStream(liveView()); err == nil || len(c2.VideoStreams()) != 0 {
+		t.Errorf("delegate failure: err = %v, streams = %d", err, len(c2.VideoStreams()))
+	}
+}
+
+func TestDeallocate(t *testing.T) {
+	m := &mockCamera{}
+	c := newCluster(t, m)
+	id, _ := c.AllocateVideoStream(liveView())
+
+	if _, err := c.AcquireVideoStream(&id, StreamUsageLiveView); err != nil {
+		t.Fatalf("AcquireVideoStream error = %v", err)
+	}
+	_, err := invoke(c, CmdVideoStreamDeallocate, func(w *tlv.Writer) {
+		w.PutUint(tlv.ContextTag(0), uint64(id))
+	})
+	if !errors.Is(err, datamodel.ErrInvalidInState) {
+		t.Errorf("deallocate in use error = %v, want ErrInvalidInState", err)
+	}
+
+	c.ReleaseVideoStream(id)
+	if _, err := invoke(c, CmdVideoStreamDeallocate, func(w *tlv.Writer) {
+		w.PutUint(tlv.ContextTag(0), uint64(id))
+	}); err != nil {
+		t.Fatalf("VideoStreamDeallocate error = %v", err)
+	}
+	if len(c.VideoStreams()) != 0 || len(m.released) != 1 {
+		t.Errorf("stream not released")
+	}
+	if err := c.DeallocateVideoStream(id); !errors.Is(err, datamodel.ErrNotFound) {
+		t.Errorf("unknown stream error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestAudioStreamAllocate(t *testing.T) {
+	c := newCluster(t, &mockCamera{})
+
+	encode := func(channels uint64) func(w *tlv.Writer) {
+		return func(w *tlv.Writer) {
+			w.PutUint(tlv.ContextTag(0), uint64(StreamUsageLiveView))
+			w.PutUint(tlv.ContextTag(1), uint64(AudioCodecOpus))
+			w.PutUint(tlv.ContextTag(2), channels)
+			w.PutUint(tlv.ContextTag(3), 48000)
+			w.PutUint(tlv.ContextTag(4), 64000)
+			w.PutUint(tlv.ContextTag(5), 16)
+		}
+	}
+	if _, err := invoke(c, CmdAudioStreamAllocate, encode(3)); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("too many channels error = %v, want ErrConstraintError", err)
+	}
+	resp, err := invoke(c, CmdAudioStreamAllocate, encode(1))
+	if err != nil {
+		t.Fatalf("AudioStreamAllocate error = %v", err)
+	}
+	id := responseID(t, resp)
+	if got, err := c.AcquireAudioStream(nil, StreamUsageLiveView); err != nil || got != id {
+		t.Errorf("AcquireAudioStream = %d, %v, want %d", got, err, id)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	c := newCluster(t, &mockCamera{})
+	off := false
+
+	if _, err := c.CaptureSnapshot(nil, res720); !errors.Is(err, datamodel.ErrNotFound) {
+		t.Errorf("capture without stream error = %v, want ErrNotFound", err)
+	}
+
+	s := SnapshotStream{
+		ImageCodec:       ImageCodecJPEG,
+		FrameRate:        1,
+		MinResolution:    res720,
+		MaxResolution:    res1080,
+		Quality:          90,
+		WatermarkEnabled: &off,
+	}
+	if _, err := c.AllocateSnapshotStream(SnapshotStream{ImageCodec: ImageCodecJPEG, FrameRate: 1,
+		MinResolution: res360, MaxResolution: res720, Quality: 90, WatermarkEnabled: &off}); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("no matching capability error = %v, want ErrConstraintError", err)
+	}
+	if _, err := c.AllocateSnapshotStream(s); err != nil {
+		t.Fatalf("AllocateSnapshotStream error = %v", err)
+	}
+
+	resp, err := invoke(c, CmdCaptureSnapshot, func(w *tlv.Writer) {
+		w.PutNull(tlv.ContextTag(0))
+		res720.MarshalTLV(w, tlv.ContextTag(1))
+	})
+	if err != nil {
+		t.Fatalf("CaptureSnapshot error = %v", err)
+	}
+	r := tlv.NewReader(bytes.NewReader(resp))
+	r.Next()
+	r.EnterContainer()
+	r.Next()
+	if data, _ := r.Bytes(); len(data) != 2 {
+		t.Errorf("snapshot data = %x", data)
+	}
+
+	c.SetPrivacyMode(false, true)
+	if _, err := c.CaptureSnapshot(nil, res720); !errors.Is(err, datamodel.ErrInvalidInState) {
+		t.Errorf("capture with livestream privacy error = %v, want ErrInvalidInState", err)
+	}
+}
+
+func TestSetStreamPriorities(t *testing.T) {
+	c := newCluster(t, &mockCamera{})
+
+	encode := func(usages ...StreamUsage) func(w *tlv.Writer) {
+		return func(w *tlv.Writer) {
+			w.StartArray(tlv.ContextTag(0))
+			for _, u := range usages {
+				w.PutUint(tlv.Anonymous(), uint64(u))
+			}
+			w.EndContainer()
+		}
+	}
+	if _, err := invoke(c, CmdSetStreamPriorities, encode(StreamUsageLiveView, StreamUsageLiveView)); !errors.Is(err, datamodel.ErrAlreadyExists) {
+		t.Errorf("duplicate error = %v, want ErrAlreadyExists", err)
+	}
+	if _, err := invoke(c, CmdSetStreamPriorities, encode(StreamUsageAnalysis)); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("unsupported usage error = %v, want ErrConstraintError", err)
+	}
+	if _, err := invoke(c, CmdSetStreamPriorities, encode(StreamUsageRecording)); err != nil {
+		t.Fatalf("SetStreamPriorities error = %v", err)
+	}
+
+	// LiveView is no longer prioritized
+	if _, err := c.AllocateVideoStream(liveView()); !errors.Is(err, datamodel.ErrInvalidInState) {
+		t.Errorf("unprioritized usage error = %v, want ErrInvalidInState", err)
+	}
+
+	rec := liveView()
+	rec.StreamUsage = StreamUsageRecording
+	c.AllocateVideoStream(rec)
+	if err := c.SetStreamPriorities([]StreamUsage{StreamUsageLiveView}); !errors.Is(err, datamodel.ErrInvalidInState) {
+		t.Errorf("with allocated streams error = %v, want ErrInvalidInState", err)
+	}
+}
+
+func TestPrivacyMode(t *testing.T) {
+	var changes int
+	cfg := testConfig(&mockCamera{})
+	cfg.OnPrivacyModeChange = func(endpoint datamodel.EndpointID, recording, livestream bool) { changes++ }
+	c, _ := New(cfg)
+	id, _ := c.AllocateVideoStream(liveView())
+
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	w.PutBool(tlv.Anonymous(), true)
+	req := datamodel.WriteAttributeRequest{
+		Path: datamodel.ConcreteDataAttributePath{
+			ConcreteAttributePath: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: AttrSoftLivestreamPrivacyModeEnabled},
+		},
+	}
+	if err := c.WriteAttribute(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes()))); err != nil {
+		t.Fatalf("WriteAttribute error = %v", err)
+	}
+	if !c.LivestreamPrivacyModeEnabled() || c.RecordingPrivacyModeEnabled() || changes != 1 {
+		t.Errorf("privacy = %v/%v, changes = %d", c.RecordingPrivacyModeEnabled(), c.LivestreamPrivacyModeEnabled(), changes)
+	}
+	if _, err := c.AcquireVideoStream(&id, StreamUsageLiveView); !errors.Is(err, datamodel.ErrInvalidInState) {
+		t.Errorf("acquire with livestream privacy error = %v, want ErrInvalidInState", err)
+	}
+
+	req.Path.Attribute = AttrMaxNetworkBandwidth
+	if err := c.WriteAttribute(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes()))); !errors.Is(err, datamodel.ErrUnsupportedWrite) {
+		t.Errorf("write read-only error = %v, want ErrUnsupportedWrite", err)
+	}
+}
+
+func TestFeatureGating(t *testing.T) {
+	cfg := testConfig(&mockCamera{})
+	cfg.FeatureMap = FeatureVideo
+	c, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if _, err := invoke(c, CmdCaptureSnapshot, func(w *tlv.Writer) {}); !errors.Is(err, datamodel.ErrUnsupportedCommand) {
+		t.Errorf("CaptureSnapshot without SNP error = %v", err)
+	}
+	if _, err := invoke(c, CmdVideoStreamModify, func(w *tlv.Writer) {}); !errors.Is(err, datamodel.ErrUnsupportedCommand) {
+		t.Errorf("VideoStreamModify without WMARK/OSD error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	req := datamodel.ReadAttributeRequest{
+		Path: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: AttrMicrophoneCapabilities},
+	}
+	if err := c.ReadAttribute(context.Background(), req, tlv.NewWriter(&buf)); !errors.Is(err, datamodel.ErrUnsupportedAttribute) {
+		t.Errorf("MicrophoneCapabilities without ADO error = %v", err)
+	}
+	req.Path.Attribute = AttrAllocatedVideoStreams
+	if err := c.ReadAttribute(context.Background(), req, tlv.NewWriter(&buf)); err != nil {
+		t.Errorf("AllocatedVideoStreams error = %v", err)
+	}
+}
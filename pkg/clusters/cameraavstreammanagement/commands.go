@@ -0,0 +1,499 @@
+package cameraavstreammanagement
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/backkem/matter/pkg/clusters"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	if !c.acceptsCommand(req.Path.Command) {
+		return nil, datamodel.ErrUnsupportedCommand
+	}
+
+	switch req.Path.Command {
+	case CmdAudioStreamAllocate:
+		s, err := c.decodeAudioStreamAllocate(r)
+		if err != nil {
+			return nil, err
+		}
+		id, err := c.AllocateAudioStream(s)
+		if err != nil {
+			return nil, err
+		}
+		return encodeStreamIDResponse(id)
+
+	case CmdVideoStreamAllocate:
+		s, err := c.decodeVideoStreamAllocate(r)
+		if err != nil {
+			return nil, err
+		}
+		id, err := c.AllocateVideoStream(s)
+		if err != nil {
+			return nil, err
+		}
+		return encodeStreamIDResponse(id)
+
+	case CmdSnapshotStreamAllocate:
+		s, err := c.decodeSnapshotStreamAllocate(r)
+		if err != nil {
+			return nil, err
+		}
+		id, err := c.AllocateSnapshotStream(s)
+		if err != nil {
+			return nil, err
+		}
+		return encodeStreamIDResponse(id)
+
+	case CmdVideoStreamModify, CmdSnapshotStreamModify:
+		id, watermark, osd, err := decodeStreamModify(r)
+		if err != nil {
+			return nil, err
+		}
+		if req.Path.Command == CmdVideoStreamModify {
+			err = c.ModifyVideoStream(id, watermark, osd)
+		} else {
+			err = c.ModifySnapshotStream(id, watermark, osd)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return clusters.EmptyResponse(), nil
+
+	case CmdAudioStreamDeallocate, CmdVideoStreamDeallocate, CmdSnapshotStreamDeallocate:
+		id, err := decodeStreamID(r)
+		if err != nil {
+			return nil, err
+		}
+		switch req.Path.Command {
+		case CmdAudioStreamDeallocate:
+			err = c.DeallocateAudioStream(id)
+		case CmdVideoStreamDeallocate:
+			err = c.DeallocateVideoStream(id)
+		default:
+			err = c.DeallocateSnapshotStream(id)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return clusters.EmptyResponse(), nil
+
+	case CmdSetStreamPriorities:
+		priorities, err := decodeSetStreamPriorities(r)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.SetStreamPriorities(priorities); err != nil {
+			return nil, err
+		}
+		return clusters.EmptyResponse(), nil
+
+	case CmdCaptureSnapshot:
+		id, resolution, err := decodeCaptureSnapshot(r)
+		if err != nil {
+			return nil, err
+		}
+		snap, err := c.CaptureSnapshot(id, resolution)
+		if err != nil {
+			return nil, err
+		}
+		return encodeCaptureSnapshotResponse(snap)
+
+	default:
+		return nil, datamodel.ErrUnsupportedCommand
+	}
+}
+
+// acceptsCommand returns true if id is in the accepted command list.
+func (c *Cluster) acceptsCommand(id datamodel.CommandID) bool {
+	for _, cmd := range c.AcceptedCommandList() {
+		if cmd.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeFields enters the command structure and calls field for each
+// context-tagged element. Elements for which field returns false are
+// skipped.
+func decodeFields(r *tlv.Reader, field func(tag uint8) (bool, error)) error {
+	if err := r.Next(); err != nil {
+		return datamodel.ErrInvalidCommand
+	}
+	if err := r.EnterContainer(); err != nil {
+		return datamodel.ErrInvalidCommand
+	}
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		tag := r.Tag()
+		handled := false
+		if tag.IsContext() {
+			var err error
+			if handled, err = field(uint8(tag.TagNumber())); err != nil {
+				return err
+			}
+		}
+		if !handled {
+			if err := r.Skip(); err != nil {
+				return datamodel.ErrInvalidCommand
+			}
+		}
+	}
+	return nil
+}
+
+// readUint reads an unsigned integer no larger than max.
+func readUint(r *tlv.Reader, max uint64) (uint64, error) {
+	v, err := r.Uint()
+	if err != nil || v > max {
+		return 0, datamodel.ErrInvalidCommand
+	}
+	return v, nil
+}
+
+// readBool reads a boolean into a new pointer.
+func readBool(r *tlv.Reader) (*bool, error) {
+	v, err := r.Bool()
+	if err != nil {
+		return nil, datamodel.ErrInvalidCommand
+	}
+	return &v, nil
+}
+
+// decodeVideoStreamAllocate decodes VideoStreamAllocate (Spec 11.2.8.3).
+// WatermarkEnabled and OSDEnabled are required with their features.
+func (c *Cluster) decodeVideoStreamAllocate(r *tlv.Reader) (VideoStream, error) {
+	var s VideoStream
+	var seen uint16
+	err := decodeFields(r, func(tag uint8) (bool, error) {
+		var v uint64
+		var err error
+		switch tag {
+		case 0, 1:
+			v, err = readUint(r, 0xFF)
+		case 2, 3, 8:
+			v, err = readUint(r, 0xFFFF)
+		case 6, 7:
+			v, err = readUint(r, 0xFFFFFFFF)
+		case 4:
+			s.MinResolution, err = decodeResolution(r)
+		case 5:
+			s.MaxResolution, err = decodeResolution(r)
+		case 9:
+			s.WatermarkEnabled, err = readBool(r)
+		case 10:
+			s.OSDEnabled, err = readBool(r)
+		default:
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		switch tag {
+		case 0:
+			s.StreamUsage = StreamUsage(v)
+		case 1:
+			s.VideoCodec = VideoCodec(v)
+		case 2:
+			s.MinFrameRate = uint16(v)
+		case 3:
+			s.MaxFrameRate = uint16(v)
+		case 6:
+			s.MinBitRate = uint32(v)
+		case 7:
+			s.MaxBitRate = uint32(v)
+		case 8:
+			s.KeyFrameInterval = uint16(v)
+		}
+		seen |= 1 << tag
+		return true, nil
+	})
+	if err != nil {
+		return s, err
+	}
+	if seen&0x1FF != 0x1FF {
+		return s, datamodel.ErrInvalidCommand
+	}
+	if c.hasFeature(FeatureWatermark) && s.WatermarkEnabled == nil ||
+		c.hasFeature(FeatureOnScreenDisplay) && s.OSDEnabled == nil {
+		return s, datamodel.ErrInvalidCommand
+	}
+	return s, nil
+}
+
+// decodeAudioStreamAllocate decodes AudioStreamAllocate (Spec 11.2.8.1).
+func (c *Cluster) decodeAudioStreamAllocate(r *tlv.Reader) (AudioStream, error) {
+	var s AudioStream
+	var seen uint8
+	err := decodeFields(r, func(tag uint8) (bool, error) {
+		var v uint64
+		var err error
+		switch tag {
+		case 0, 1, 2, 5:
+			v, err = readUint(r, 0xFF)
+		case 3, 4:
+			v, err = readUint(r, 0xFFFFFFFF)
+		default:
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		switch tag {
+		case 0:
+			s.StreamUsage = StreamUsage(v)
+		case 1:
+			s.AudioCodec = AudioCodec(v)
+		case 2:
+			s.ChannelCount = uint8(v)
+		case 3:
+			s.SampleRate = uint32(v)
+		case 4:
+			s.BitRate = uint32(v)
+		case 5:
+			s.BitDepth = uint8(v)
+		}
+		seen |= 1 << tag
+		return true, nil
+	})
+	if err != nil {
+		return s, err
+	}
+	if seen != 0x3F {
+		return s, datamodel.ErrInvalidCommand
+	}
+	return s, nil
+}
+
+// decodeSnapshotStreamAllocate decodes SnapshotStreamAllocate
+// (Spec 11.2.8.7). WatermarkEnabled and OSDEnabled are required with
+// their features.
+func (c *Cluster) decodeSnapshotStreamAllocate(r *tlv.Reader) (SnapshotStream, error) {
+	var s SnapshotStream
+	var seen uint8
+	err := decodeFields(r, func(tag uint8) (bool, error) {
+		var v uint64
+		var err error
+		switch tag {
+		case 0, 4:
+			v, err = readUint(r, 0xFF)
+		case 1:
+			v, err = readUint(r, 0xFFFF)
+		case 2:
+			s.MinResolution, err = decodeResolution(r)
+		case 3:
+			s.MaxResolution, err = decodeResolution(r)
+		case 5:
+			s.WatermarkEnabled, err = readBool(r)
+		case 6:
+			s.OSDEnabled, err = readBool(r)
+		default:
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		switch tag {
+		case 0:
+			s.ImageCodec = ImageCodec(v)
+		case 1:
+			s.FrameRate = uint16(v)
+		case 4:
+			s.Quality = uint8(v)
+		}
+		seen |= 1 << tag
+		return true, nil
+	})
+	if err != nil {
+		return s, err
+	}
+	if seen&0x1F != 0x1F {
+		return s, datamodel.ErrInvalidCommand
+	}
+	if c.hasFeature(FeatureWatermark) && s.WatermarkEnabled == nil ||
+		c.hasFeature(FeatureOnScreenDisplay) && s.OSDEnabled == nil {
+		return s, datamodel.ErrInvalidCommand
+	}
+	return s, nil
+}
+
+// decodeStreamModify decodes VideoStreamModify and SnapshotStreamModify
+// (Spec 11.2.8.5 and 11.2.8.9): the stream ID (tag 0) and the optional
+// WatermarkEnabled (tag 1) and OSDEnabled (tag 2).
+func decodeStreamModify(r *tlv.Reader) (id uint16, watermark, osd *bool, err error) {
+	hasID := false
+	err = decodeFields(r, func(tag uint8) (bool, error) {
+		var err error
+		switch tag {
+		case 0:
+			var v uint64
+			v, err = readUint(r, 0xFFFF)
+			id, hasID = uint16(v), true
+		case 1:
+			watermark, err = readBool(r)
+		case 2:
+			osd, err = readBool(r)
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	if !hasID {
+		return 0, nil, nil, datamodel.ErrInvalidCommand
+	}
+	return id, watermark, osd, nil
+}
+
+// decodeStreamID decodes the stream ID (tag 0) of a Deallocate command.
+func decodeStreamID(r *tlv.Reader) (uint16, error) {
+	var id uint16
+	hasID := false
+	err := decodeFields(r, func(tag uint8) (bool, error) {
+		if tag != 0 {
+			return false, nil
+		}
+		v, err := readUint(r, 0xFFFF)
+		id, hasID = uint16(v), true
+		return true, err
+	})
+	if err != nil {
+		return 0, err
+	}
+	if !hasID {
+		return 0, datamodel.ErrInvalidCommand
+	}
+	return id, nil
+}
+
+// decodeSetStreamPriorities decodes SetStreamPriorities (Spec 11.2.8.11):
+// the list of stream usages (tag 0).
+func decodeSetStreamPriorities(r *tlv.Reader) ([]StreamUsage, error) {
+	var priorities []StreamUsage
+	hasList := false
+	err := decodeFields(r, func(tag uint8) (bool, error) {
+		if tag != 0 {
+			return false, nil
+		}
+		if err := r.EnterContainer(); err != nil {
+			return false, datamodel.ErrInvalidCommand
+		}
+		for {
+			if err := r.Next(); err != nil {
+				return false, datamodel.ErrInvalidCommand
+			}
+			if r.IsEndOfContainer() {
+				break
+			}
+			v, err := readUint(r, 0xFF)
+			if err != nil {
+				return false, err
+			}
+			priorities = append(priorities, StreamUsage(v))
+		}
+		if err := r.ExitContainer(); err != nil {
+			return false, datamodel.ErrInvalidCommand
+		}
+		hasList = true
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !hasList {
+		return nil, datamodel.ErrInvalidCommand
+	}
+	return priorities, nil
+}
+
+// decodeCaptureSnapshot decodes CaptureSnapshot (Spec 11.2.8.12): the
+// nullable SnapshotStreamID (tag 0) and RequestedResolution (tag 1).
+func decodeCaptureSnapshot(r *tlv.Reader) (*uint16, VideoResolution, error) {
+	var id *uint16
+	var resolution VideoResolution
+	var hasID, hasResolution bool
+	err := decodeFields(r, func(tag uint8) (bool, error) {
+		switch tag {
+		case 0:
+			hasID = true
+			if r.Type() == tlv.ElementTypeNull {
+				return true, nil
+			}
+			v, err := readUint(r, 0xFFFF)
+			if err != nil {
+				return false, err
+			}
+			sid := uint16(v)
+			id = &sid
+		case 1:
+			var err error
+			if resolution, err = decodeResolution(r); err != nil {
+				return false, err
+			}
+			hasResolution = true
+		default:
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, resolution, err
+	}
+	if !hasID || !hasResolution {
+		return nil, resolution, datamodel.ErrInvalidCommand
+	}
+	return id, resolution, nil
+}
+
+// encodeStreamIDResponse encodes an Audio, Video or Snapshot
+// StreamAllocateResponse: the allocated stream ID (tag 0).
+func encodeStreamIDResponse(id uint16) ([]byte, error) {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return nil, err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(id)); err != nil {
+		return nil, err
+	}
+	if err := w.EndContainer(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// encodeCaptureSnapshotResponse encodes CaptureSnapshotResponse
+// (Spec 11.2.8.13).
+func encodeCaptureSnapshotResponse(snap *Snapshot) ([]byte, error) {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return nil, err
+	}
+	if err := w.PutBytes(tlv.ContextTag(0), snap.Data); err != nil {
+		return nil, err
+	}
+	if err := w.PutUint(tlv.ContextTag(1), uint64(snap.ImageCodec)); err != nil {
+		return nil, err
+	}
+	if err := snap.Resolution.MarshalTLV(w, tlv.ContextTag(2)); err != nil {
+		return nil, err
+	}
+	if err := w.EndContainer(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
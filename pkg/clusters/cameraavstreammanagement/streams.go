@@ -0,0 +1,795 @@
+package cameraavstreammanagement
+
+import (
+	"github.com/backkem/matter/pkg/datamodel"
+)
+
+// Limits (Spec 11.2.8).
+const (
+	MaxKeyFrameInterval = 65500 // ms
+	MaxSnapshotQuality  = 100
+	maxReferenceCount   = 0xFF
+)
+
+// AllocateVideoStream allocates a video stream (VDO). The ID and reference
+// count of s are ignored. If an allocated stream already has identical
+// parameters its ID is returned instead.
+func (c *Cluster) AllocateVideoStream(s VideoStream) (uint16, error) {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	if !c.hasFeature(FeatureVideo) {
+		return 0, datamodel.ErrUnsupportedCommand
+	}
+	if err := c.validateVideoStream(s); err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	if !c.hasPriority(s.StreamUsage) {
+		c.mu.Unlock()
+		return 0, datamodel.ErrInvalidInState
+	}
+	for _, v := range c.videoStreams {
+		if sameVideoParams(v, s) {
+			c.mu.Unlock()
+			return v.VideoStreamID, nil
+		}
+	}
+	if c.config.MaxConcurrentEncoders > 0 && len(c.videoStreams) >= int(c.config.MaxConcurrentEncoders) {
+		c.mu.Unlock()
+		return 0, datamodel.ErrResourceExhausted
+	}
+	pixelRate := c.pixelRate() + s.MaxResolution.Pixels()*uint64(s.MaxFrameRate)
+	bandwidth := c.bandwidth() + uint64(s.MaxBitRate)
+	s.VideoStreamID = c.allocateStreamID()
+	c.mu.Unlock()
+
+	if err := c.checkResources(pixelRate, bandwidth); err != nil {
+		return 0, err
+	}
+
+	s.ReferenceCount = 0
+	if err := c.config.Delegate.HandleVideoStreamAllocate(s); err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	c.videoStreams = append(c.videoStreams, s)
+	c.mu.Unlock()
+
+	c.IncrementDataVersion()
+	return s.VideoStreamID, nil
+}
+
+// validateVideoStream checks the requested parameters against the
+// camera's capabilities.
+func (c *Cluster) validateVideoStream(s VideoStream) error {
+	if !s.StreamUsage.IsValid() || !c.supportsUsage(s.StreamUsage) {
+		return datamodel.ErrConstraintError
+	}
+	if s.VideoCodec > VideoCodecAV1 || !c.supportsVideoCodec(s.VideoCodec) {
+		return datamodel.ErrConstraintError
+	}
+	if s.MinFrameRate == 0 || s.MinFrameRate > s.MaxFrameRate {
+		return datamodel.ErrConstraintError
+	}
+	if max := c.config.VideoSensorParams.MaxFPS; max > 0 && s.MaxFrameRate > max {
+		return datamodel.ErrConstraintError
+	}
+	if s.MinBitRate == 0 || s.MinBitRate > s.MaxBitRate {
+		return datamodel.ErrConstraintError
+	}
+	if s.KeyFrameInterval > MaxKeyFrameInterval {
+		return datamodel.ErrConstraintError
+	}
+	if err := c.validateResolutionRange(s.MinResolution, s.MaxResolution); err != nil {
+		return err
+	}
+	if s.MinResolution.Width < c.config.MinViewportResolution.Width ||
+		s.MinResolution.Height < c.config.MinViewportResolution.Height {
+		return datamodel.ErrConstraintError
+	}
+	return c.validateWatermarkOSD(s.WatermarkEnabled, s.OSDEnabled)
+}
+
+// validateResolutionRange checks min <= max and that max fits the sensor.
+func (c *Cluster) validateResolutionRange(min, max VideoResolution) error {
+	if min.Width == 0 || min.Height == 0 || min.Width > max.Width || min.Height > max.Height {
+		return datamodel.ErrConstraintError
+	}
+	sensor := c.config.VideoSensorParams
+	if sensor.SensorWidth > 0 && sensor.SensorHeight > 0 &&
+		(max.Width > sensor.SensorWidth || max.Height > sensor.SensorHeight) {
+		return datamodel.ErrConstraintError
+	}
+	return nil
+}
+
+// validateWatermarkOSD checks that watermark and OSD settings are only
+// given with their features.
+func (c *Cluster) validateWatermarkOSD(watermark, osd *bool) error {
+	if watermark != nil && !c.hasFeature(FeatureWatermark) {
+		return datamodel.ErrConstraintError
+	}
+	if osd != nil && !c.hasFeature(FeatureOnScreenDisplay) {
+		return datamodel.ErrConstraintError
+	}
+	return nil
+}
+
+// supportsUsage returns true if u is in SupportedStreamUsages.
+func (c *Cluster) supportsUsage(u StreamUsage) bool {
+	for _, s := range c.config.SupportedStreamUsages {
+		if s == u {
+			return true
+		}
+	}
+	return false
+}
+
+// supportsVideoCodec returns true if a rate distortion trade-off point is
+// listed for the codec. Any codec is accepted if none are listed.
+func (c *Cluster) supportsVideoCodec(codec VideoCodec) bool {
+	if len(c.config.RateDistortionTradeOffPoints) == 0 {
+		return true
+	}
+	for _, p := range c.config.RateDistortionTradeOffPoints {
+		if p.Codec == codec {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPriority returns true if u is in StreamUsagePriorities. Caller must
+// hold mu.
+func (c *Cluster) hasPriority(u StreamUsage) bool {
+	for _, p := range c.priorities {
+		if p == u {
+			return true
+		}
+	}
+	return false
+}
+
+// sameVideoParams returns true if a and b were allocated with the same
+// parameters.
+func sameVideoParams(a, b VideoStream) bool {
+	return a.StreamUsage == b.StreamUsage && a.VideoCodec == b.VideoCodec &&
+		a.MinFrameRate == b.MinFrameRate && a.MaxFrameRate == b.MaxFrameRate &&
+		a.MinResolution == b.MinResolution && a.MaxResolution == b.MaxResolution &&
+		a.MinBitRate == b.MinBitRate && a.MaxBitRate == b.MaxBitRate &&
+		a.KeyFrameInterval == b.KeyFrameInterval &&
+		equalBool(a.WatermarkEnabled, b.WatermarkEnabled) && equalBool(a.OSDEnabled, b.OSDEnabled)
+}
+
+// equalBool compares two optional booleans.
+func equalBool(a, b *bool) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// pixelRate returns the encoded pixel rate of all video and snapshot
+// streams. Caller must hold mu.
+func (c *Cluster) pixelRate() uint64 {
+	var total uint64
+	for _, v := range c.videoStreams {
+		total += v.MaxResolution.Pixels() * uint64(v.MaxFrameRate)
+	}
+	for _, s := range c.snapshotStreams {
+		total += s.MaxResolution.Pixels() * uint64(s.FrameRate)
+	}
+	return total
+}
+
+// bandwidth returns the bit rate of all video and audio streams. Caller
+// must hold mu.
+func (c *Cluster) bandwidth() uint64 {
+	var total uint64
+	for _, v := range c.videoStreams {
+		total += uint64(v.MaxBitRate)
+	}
+	for _, a := range c.audioStreams {
+		total += uint64(a.BitRate)
+	}
+	return total
+}
+
+// checkResources returns ErrResourceExhausted if the totals exceed
+// MaxEncodedPixelRate or MaxNetworkBandwidth. A zero limit is unbounded.
+func (c *Cluster) checkResources(pixelRate, bandwidth uint64) error {
+	if max := c.config.MaxEncodedPixelRate; max > 0 && pixelRate > uint64(max) {
+		return datamodel.ErrResourceExhausted
+	}
+	if max := c.config.MaxNetworkBandwidth; max > 0 && bandwidth > uint64(max) {
+		return datamodel.ErrResourceExhausted
+	}
+	return nil
+}
+
+// allocateStreamID returns the next unused stream ID. Caller must hold mu.
+func (c *Cluster) allocateStreamID() uint16 {
+	for {
+		id := c.nextStreamID
+		c.nextStreamID++
+		if !c.streamIDInUse(id) {
+			return id
+		}
+	}
+}
+
+// streamIDInUse returns true if any stream has the ID. Caller must hold mu.
+func (c *Cluster) streamIDInUse(id uint16) bool {
+	for _, v := range c.videoStreams {
+		if v.VideoStreamID == id {
+			return true
+		}
+	}
+	for _, a := range c.audioStreams {
+		if a.AudioStreamID == id {
+			return true
+		}
+	}
+	for _, s := range c.snapshotStreams {
+		if s.SnapshotStreamID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// ModifyVideoStream changes the watermark and/or OSD setting of a video
+// stream (VDO and WMARK or OSD). Nil values are left unchanged.
+func (c *Cluster) ModifyVideoStream(id uint16, watermark, osd *bool) error {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	if watermark == nil && osd == nil {
+		return datamodel.ErrInvalidCommand
+	}
+	if err := c.validateWatermarkOSD(watermark, osd); err != nil {
+		return err
+	}
+
+	c.mu.RLock()
+	i := c.videoStreamIndex(id)
+	if i < 0 {
+		c.mu.RUnlock()
+		return datamodel.ErrNotFound
+	}
+	s := c.videoStreams[i]
+	c.mu.RUnlock()
+
+	if watermark != nil {
+		s.WatermarkEnabled = watermark
+	}
+	if osd != nil {
+		s.OSDEnabled = osd
+	}
+	if err := c.config.Delegate.HandleVideoStreamModify(s); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if i := c.videoStreamIndex(id); i >= 0 {
+		c.videoStreams[i].WatermarkEnabled = s.WatermarkEnabled
+		c.videoStreams[i].OSDEnabled = s.OSDEnabled
+	}
+	c.mu.Unlock()
+
+	c.IncrementDataVersion()
+	return nil
+}
+
+// DeallocateVideoStream releases a video stream (VDO). Streams in use by
+// a transport cannot be deallocated.
+func (c *Cluster) DeallocateVideoStream(id uint16) error {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	c.mu.RLock()
+	i := c.videoStreamIndex(id)
+	if i < 0 {
+		c.mu.RUnlock()
+		return datamodel.ErrNotFound
+	}
+	inUse := c.videoStreams[i].ReferenceCount > 0
+	c.mu.RUnlock()
+
+	if inUse {
+		return datamodel.ErrInvalidInState
+	}
+	if err := c.config.Delegate.HandleVideoStreamDeallocate(id); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if i := c.videoStreamIndex(id); i >= 0 {
+		c.videoStreams = append(c.videoStreams[:i], c.videoStreams[i+1:]...)
+	}
+	c.mu.Unlock()
+
+	c.IncrementDataVersion()
+	return nil
+}
+
+// videoStreamIndex returns the index of the video stream or -1. Caller
+// must hold mu.
+func (c *Cluster) videoStreamIndex(id uint16) int {
+	for i, v := range c.videoStreams {
+		if v.VideoStreamID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// VideoStreams returns the allocated video streams.
+func (c *Cluster) VideoStreams() []VideoStream {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]VideoStream(nil), c.videoStreams...)
+}
+
+// AllocateAudioStream allocates an audio stream (ADO). The ID and
+// reference count of s are ignored. If an allocated stream already has
+// identical parameters its ID is returned instead.
+func (c *Cluster) AllocateAudioStream(s AudioStream) (uint16, error) {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	if !c.hasFeature(FeatureAudio) {
+		return 0, datamodel.ErrUnsupportedCommand
+	}
+	if err := c.validateAudioStream(s); err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	if !c.hasPriority(s.StreamUsage) {
+		c.mu.Unlock()
+		return 0, datamodel.ErrInvalidInState
+	}
+	for _, a := range c.audioStreams {
+		if a.StreamUsage == s.StreamUsage && a.AudioCodec == s.AudioCodec &&
+			a.ChannelCount == s.ChannelCount && a.SampleRate == s.SampleRate &&
+			a.BitRate == s.BitRate && a.BitDepth == s.BitDepth {
+			c.mu.Unlock()
+			return a.AudioStreamID, nil
+		}
+	}
+	bandwidth := c.bandwidth() + uint64(s.BitRate)
+	s.AudioStreamID = c.allocateStreamID()
+	c.mu.Unlock()
+
+	if err := c.checkResources(0, bandwidth); err != nil {
+		return 0, err
+	}
+
+	s.ReferenceCount = 0
+	if err := c.config.Delegate.HandleAudioStreamAllocate(s); err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	c.audioStreams = append(c.audioStreams, s)
+	c.mu.Unlock()
+
+	c.IncrementDataVersion()
+	return s.AudioStreamID, nil
+}
+
+// validateAudioStream checks the requested parameters against
+// MicrophoneCapabilities.
+func (c *Cluster) validateAudioStream(s AudioStream) error {
+	caps := c.config.MicrophoneCapabilities
+	if !s.StreamUsage.IsValid() || !c.supportsUsage(s.StreamUsage) {
+		return datamodel.ErrConstraintError
+	}
+	if s.ChannelCount == 0 || s.ChannelCount > caps.MaxNumberOfChannels || s.BitRate == 0 {
+		return datamodel.ErrConstraintError
+	}
+	if !contains(caps.SupportedCodecs, s.AudioCodec) ||
+		!contains(caps.SupportedSampleRates, s.SampleRate) ||
+		!contains(caps.SupportedBitDepths, s.BitDepth) {
+		return datamodel.ErrConstraintError
+	}
+	return nil
+}
+
+// contains returns true if v is in list.
+func contains[T comparable](list []T, v T) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// DeallocateAudioStream releases an audio stream (ADO). Streams in use by
+// a transport cannot be deallocated.
+func (c *Cluster) DeallocateAudioStream(id uint16) error {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	c.mu.RLock()
+	i := c.audioStreamIndex(id)
+	if i < 0 {
+		c.mu.RUnlock()
+		return datamodel.ErrNotFound
+	}
+	inUse := c.audioStreams[i].ReferenceCount > 0
+	c.mu.RUnlock()
+
+	if inUse {
+		return datamodel.ErrInvalidInState
+	}
+	if err := c.config.Delegate.HandleAudioStreamDeallocate(id); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if i := c.audioStreamIndex(id); i >= 0 {
+		c.audioStreams = append(c.audioStreams[:i], c.audioStreams[i+1:]...)
+	}
+	c.mu.Unlock()
+
+	c.IncrementDataVersion()
+	return nil
+}
+
+// audioStreamIndex returns the index of the audio stream or -1. Caller
+// must hold mu.
+func (c *Cluster) audioStreamIndex(id uint16) int {
+	for i, a := range c.audioStreams {
+		if a.AudioStreamID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// AudioStreams returns the allocated audio streams.
+func (c *Cluster) AudioStreams() []AudioStream {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]AudioStream(nil), c.audioStreams...)
+}
+
+// AllocateSnapshotStream allocates a snapshot stream (SNP). The codec,
+// frame rate and resolution range must match one of the
+// SnapshotCapabilities, which also determines EncodedPixels and
+// HardwareEncoder. If an allocated stream already has identical
+// parameters its ID is returned instead.
+func (c *Cluster) AllocateSnapshotStream(s SnapshotStream) (uint16, error) {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	if !c.hasFeature(FeatureSnapshot) {
+		return 0, datamodel.ErrUnsupportedCommand
+	}
+	if s.Quality == 0 || s.Quality > MaxSnapshotQuality || s.FrameRate == 0 {
+		return 0, datamodel.ErrConstraintError
+	}
+	if err := c.validateResolutionRange(s.MinResolution, s.MaxResolution); err != nil {
+		return 0, err
+	}
+	if err := c.validateWatermarkOSD(s.WatermarkEnabled, s.OSDEnabled); err != nil {
+		return 0, err
+	}
+	capability, ok := c.matchSnapshotCapability(s)
+	if !ok {
+		return 0, datamodel.ErrConstraintError
+	}
+	s.EncodedPixels = capability.RequiresEncodedPixels
+	s.HardwareEncoder = capability.RequiresHardwareEncoder
+
+	c.mu.Lock()
+	for _, v := range c.snapshotStreams {
+		if v.ImageCodec == s.ImageCodec && v.FrameRate == s.FrameRate &&
+			v.MinResolution == s.MinResolution && v.MaxResolution == s.MaxResolution &&
+			v.Quality == s.Quality && equalBool(v.WatermarkEnabled, s.WatermarkEnabled) &&
+			equalBool(v.OSDEnabled, s.OSDEnabled) {
+			c.mu.Unlock()
+			return v.SnapshotStreamID, nil
+		}
+	}
+	pixelRate := c.pixelRate() + s.MaxResolution.Pixels()*uint64(s.FrameRate)
+	s.SnapshotStreamID = c.allocateStreamID()
+	c.mu.Unlock()
+
+	if err := c.checkResources(pixelRate, 0); err != nil {
+		return 0, err
+	}
+
+	s.ReferenceCount = 0
+	if err := c.config.Delegate.HandleSnapshotStreamAllocate(s); err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	c.snapshotStreams = append(c.snapshotStreams, s)
+	c.mu.Unlock()
+
+	c.IncrementDataVersion()
+	return s.SnapshotStreamID, nil
+}
+
+// matchSnapshotCapability returns the first capability with the stream's
+// codec whose resolution is within the requested range and whose frame
+// rate is at least the requested one.
+func (c *Cluster) matchSnapshotCapability(s SnapshotStream) (SnapshotCapabilities, bool) {
+	for _, capability := range c.config.SnapshotCapabilities {
+		if capability.ImageCodec == s.ImageCodec &&
+			capability.MaxFrameRate >= s.FrameRate &&
+			capability.Resolution.fits(s.MinResolution, s.MaxResolution) {
+			return capability, true
+		}
+	}
+	return SnapshotCapabilities{}, false
+}
+
+// ModifySnapshotStream changes the watermark and/or OSD setting of a
+// snapshot stream (SNP and WMARK or OSD). Nil values are left unchanged.
+func (c *Cluster) ModifySnapshotStream(id uint16, watermark, osd *bool) error {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	if watermark == nil && osd == nil {
+		return datamodel.ErrInvalidCommand
+	}
+	if err := c.validateWatermarkOSD(watermark, osd); err != nil {
+		return err
+	}
+
+	c.mu.RLock()
+	i := c.snapshotStreamIndex(id)
+	if i < 0 {
+		c.mu.RUnlock()
+		return datamodel.ErrNotFound
+	}
+	s := c.snapshotStreams[i]
+	c.mu.RUnlock()
+
+	if watermark != nil {
+		s.WatermarkEnabled = watermark
+	}
+	if osd != nil {
+		s.OSDEnabled = osd
+	}
+	if err := c.config.Delegate.HandleSnapshotStreamModify(s); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if i := c.snapshotStreamIndex(id); i >= 0 {
+		c.snapshotStreams[i].WatermarkEnabled = s.WatermarkEnabled
+		c.snapshotStreams[i].OSDEnabled = s.OSDEnabled
+	}
+	c.mu.Unlock()
+
+	c.IncrementDataVersion()
+	return nil
+}
+
+// DeallocateSnapshotStream releases a snapshot stream (SNP).
+func (c *Cluster) DeallocateSnapshotStream(id uint16) error {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	c.mu.RLock()
+	i := c.snapshotStreamIndex(id)
+	if i < 0 {
+		c.mu.RUnlock()
+		return datamodel.ErrNotFound
+	}
+	inUse := c.snapshotStreams[i].ReferenceCount > 0
+	c.mu.RUnlock()
+
+	if inUse {
+		return datamodel.ErrInvalidInState
+	}
+	if err := c.config.Delegate.HandleSnapshotStreamDeallocate(id); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if i := c.snapshotStreamIndex(id); i >= 0 {
+		c.snapshotStreams = append(c.snapshotStreams[:i], c.snapshotStreams[i+1:]...)
+	}
+	c.mu.Unlock()
+
+	c.IncrementDataVersion()
+	return nil
+}
+
+// snapshotStreamIndex returns the index of the snapshot stream or -1.
+// Caller must hold mu.
+func (c *Cluster) snapshotStreamIndex(id uint16) int {
+	for i, s := range c.snapshotStreams {
+		if s.SnapshotStreamID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// SnapshotStreams returns the allocated snapshot streams.
+func (c *Cluster) SnapshotStreams() []SnapshotStream {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]SnapshotStream(nil), c.snapshotStreams...)
+}
+
+// SetStreamPriorities replaces StreamUsagePriorities. The order can only
+// be changed while no streams are allocated.
+func (c *Cluster) SetStreamPriorities(priorities []StreamUsage) error {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	if err := c.validatePriorities(priorities); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if len(c.videoStreams) > 0 || len(c.audioStreams) > 0 || len(c.snapshotStreams) > 0 {
+		c.mu.Unlock()
+		return datamodel.ErrInvalidInState
+	}
+	c.priorities = append([]StreamUsage(nil), priorities...)
+	c.mu.Unlock()
+
+	c.IncrementDataVersion()
+	return nil
+}
+
+// validatePriorities checks that every usage is supported and listed once.
+func (c *Cluster) validatePriorities(priorities []StreamUsage) error {
+	for i, u := range priorities {
+		if !c.supportsUsage(u) {
+			return datamodel.ErrConstraintError
+		}
+		if contains(priorities[:i], u) {
+			return datamodel.ErrAlreadyExists
+		}
+	}
+	return nil
+}
+
+// CaptureSnapshot captures an image from a snapshot stream (SNP). If
+// streamID is nil the first allocated snapshot stream is used.
+func (c *Cluster) CaptureSnapshot(streamID *uint16, resolution VideoResolution) (*Snapshot, error) {
+	c.mu.RLock()
+	if c.livestreamPrivacy {
+		c.mu.RUnlock()
+		return nil, datamodel.ErrInvalidInState
+	}
+	var id uint16
+	switch {
+	case streamID != nil:
+		if c.snapshotStreamIndex(*streamID) < 0 {
+			c.mu.RUnlock()
+			return nil, datamodel.ErrNotFound
+		}
+		id = *streamID
+	case len(c.snapshotStreams) > 0:
+		id = c.snapshotStreams[0].SnapshotStreamID
+	default:
+		c.mu.RUnlock()
+		return nil, datamodel.ErrNotFound
+	}
+	c.mu.RUnlock()
+
+	snap, err := c.config.Delegate.HandleCaptureSnapshot(id, resolution)
+	if err != nil {
+		return nil, err
+	}
+	if snap == nil {
+		return nil, datamodel.ErrNotFound
+	}
+	return snap, nil
+}
+
+// AcquireVideoStream takes a reference on a video stream for a transport
+// such as WebRTC Transport Provider. If id is nil the first allocated
+// stream with the given usage is chosen. Acquiring fails with
+// ErrInvalidInState while the matching soft privacy mode is enabled.
+func (c *Cluster) AcquireVideoStream(id *uint16, usage StreamUsage) (uint16, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.checkPrivacy(usage); err != nil {
+		return 0, err
+	}
+	i := -1
+	if id != nil {
+		i = c.videoStreamIndex(*id)
+	} else {
+		for j, v := range c.videoStreams {
+			if v.StreamUsage == usage {
+				i = j
+				break
+			}
+		}
+	}
+	if i < 0 {
+		return 0, datamodel.ErrNotFound
+	}
+	if c.videoStreams[i].ReferenceCount == maxReferenceCount {
+		return 0, datamodel.ErrResourceExhausted
+	}
+	c.videoStreams[i].ReferenceCount++
+	c.IncrementDataVersion()
+	return c.videoStreams[i].VideoStreamID, nil
+}
+
+// ReleaseVideoStream drops a reference taken with AcquireVideoStream.
+func (c *Cluster) ReleaseVideoStream(id uint16) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if i := c.videoStreamIndex(id); i >= 0 && c.videoStreams[i].ReferenceCount > 0 {
+		c.videoStreams[i].ReferenceCount--
+		c.IncrementDataVersion()
+	}
+}
+
+// AcquireAudioStream takes a reference on an audio stream for a transport
+// such as WebRTC Transport Provider. If id is nil the first allocated
+// stream with the given usage is chosen. Acquiring fails with
+// ErrInvalidInState while the matching soft privacy mode is enabled.
+func (c *Cluster) AcquireAudioStream(id *uint16, usage StreamUsage) (uint16, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.checkPrivacy(usage); err != nil {
+		return 0, err
+	}
+	i := -1
+	if id != nil {
+		i = c.audioStreamIndex(*id)
+	} else {
+		for j, a := range c.audioStreams {
+			if a.StreamUsage == usage {
+				i = j
+				break
+			}
+		}
+	}
+	if i < 0 {
+		return 0, datamodel.ErrNotFound
+	}
+	if c.audioStreams[i].ReferenceCount == maxReferenceCount {
+		return 0, datamodel.ErrResourceExhausted
+	}
+	c.audioStreams[i].ReferenceCount++
+	c.IncrementDataVersion()
+	return c.audioStreams[i].AudioStreamID, nil
+}
+
+// ReleaseAudioStream drops a reference taken with AcquireAudioStream.
+func (c *Cluster) ReleaseAudioStream(id uint16) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if i := c.audioStreamIndex(id); i >= 0 && c.audioStreams[i].ReferenceCount > 0 {
+		c.audioStreams[i].ReferenceCount--
+		c.IncrementDataVersion()
+	}
+}
+
+// checkPrivacy returns ErrInvalidInState if the soft privacy mode for the
+// usage is enabled. Caller must hold mu.
+func (c *Cluster) checkPrivacy(usage StreamUsage) error {
+	switch {
+	case usage == StreamUsageLiveView && c.livestreamPrivacy:
+		return datamodel.ErrInvalidInState
+	case usage == StreamUsageRecording && c.recordingPrivacy:
+		return datamodel.ErrInvalidInState
+	}
+	return nil
+}
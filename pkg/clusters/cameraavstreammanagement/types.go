@@ -0,0 +1,395 @@
+package cameraavstreammanagement
+
+import (
+	webrtctransport "github.com/backkem/matter/pkg/clusters/webrtc-transport"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// StreamUsage is the global StreamUsageEnum shared with the WebRTC
+// transport clusters.
+type StreamUsage = webrtctransport.StreamUsageEnum
+
+// Stream usages (Spec 11.1.3.1).
+const (
+	StreamUsageInternal  = webrtctransport.StreamUsageInternal
+	StreamUsageRecording = webrtctransport.StreamUsageRecording
+	StreamUsageAnalysis  = webrtctransport.StreamUsageAnalysis
+	StreamUsageLiveView  = webrtctransport.StreamUsageLiveView
+)
+
+// VideoCodec is a video codec (VideoCodecEnum, Spec 11.2.6.1).
+type VideoCodec uint8
+
+const (
+	VideoCodecH264 VideoCodec = 0
+	VideoCodecHEVC VideoCodec = 1
+	VideoCodecVVC  VideoCodec = 2
+	VideoCodecAV1  VideoCodec = 3
+)
+
+// AudioCodec is an audio codec (AudioCodecEnum, Spec 11.2.6.2).
+type AudioCodec uint8
+
+const (
+	AudioCodecOpus  AudioCodec = 0
+	AudioCodecAACLC AudioCodec = 1
+)
+
+// ImageCodec is a snapshot image codec (ImageCodecEnum, Spec 11.2.6.3).
+type ImageCodec uint8
+
+const (
+	ImageCodecJPEG ImageCodec = 0
+)
+
+// VideoResolution is a resolution in pixels (VideoResolutionStruct,
+// Spec 11.2.6.5).
+type VideoResolution struct {
+	Width  uint16
+	Height uint16
+}
+
+// Pixels returns the number of pixels.
+func (r VideoResolution) Pixels() uint64 {
+	return uint64(r.Width) * uint64(r.Height)
+}
+
+// fits returns true if r is within [min, max] in both dimensions.
+func (r VideoResolution) fits(min, max VideoResolution) bool {
+	return r.Width >= min.Width && r.Height >= min.Height &&
+		r.Width <= max.Width && r.Height <= max.Height
+}
+
+// MarshalTLV encodes the struct with the given tag.
+func (r VideoResolution) MarshalTLV(w *tlv.Writer, tag tlv.Tag) error {
+	if err := w.StartStructure(tag); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(r.Width)); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(1), uint64(r.Height)); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// VideoSensorParams describes the image sensor (VideoSensorParamsStruct,
+// Spec 11.2.6.10).
+type VideoSensorParams struct {
+	SensorWidth  uint16
+	SensorHeight uint16
+	MaxFPS       uint16
+}
+
+// MarshalTLV encodes the struct with the given tag.
+func (p VideoSensorParams) MarshalTLV(w *tlv.Writer, tag tlv.Tag) error {
+	if err := w.StartStructure(tag); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(p.SensorWidth)); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(1), uint64(p.SensorHeight)); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(2), uint64(p.MaxFPS)); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// RateDistortionTradeOffPoint is the minimum bit rate for a codec at a
+// resolution (RateDistortionTradeOffPointsStruct, Spec 11.2.6.7).
+type RateDistortionTradeOffPoint struct {
+	Codec      VideoCodec
+	Resolution VideoResolution
+	MinBitRate uint32 // bps
+}
+
+// MarshalTLV encodes the struct with the given tag.
+func (p RateDistortionTradeOffPoint) MarshalTLV(w *tlv.Writer, tag tlv.Tag) error {
+	if err := w.StartStructure(tag); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(p.Codec)); err != nil {
+		return err
+	}
+	if err := p.Resolution.MarshalTLV(w, tlv.ContextTag(1)); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(2), uint64(p.MinBitRate)); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// AudioCapabilities describes the microphone (AudioCapabilitiesStruct,
+// Spec 11.2.6.8).
+type AudioCapabilities struct {
+	MaxNumberOfChannels  uint8
+	SupportedCodecs      []AudioCodec
+	SupportedSampleRates []uint32
+	SupportedBitDepths   []uint8
+}
+
+// MarshalTLV encodes the struct with the given tag.
+func (a AudioCapabilities) MarshalTLV(w *tlv.Writer, tag tlv.Tag) error {
+	if err := w.StartStructure(tag); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(a.MaxNumberOfChannels)); err != nil {
+		return err
+	}
+	if err := putUintList(w, tlv.ContextTag(1), len(a.SupportedCodecs), func(i int) uint64 { return uint64(a.SupportedCodecs[i]) }); err != nil {
+		return err
+	}
+	if err := putUintList(w, tlv.ContextTag(2), len(a.SupportedSampleRates), func(i int) uint64 { return uint64(a.SupportedSampleRates[i]) }); err != nil {
+		return err
+	}
+	if err := putUintList(w, tlv.ContextTag(3), len(a.SupportedBitDepths), func(i int) uint64 { return uint64(a.SupportedBitDepths[i]) }); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// SnapshotCapabilities describes a snapshot mode the camera supports
+// (SnapshotCapabilitiesStruct, Spec 11.2.6.9).
+type SnapshotCapabilities struct {
+	Resolution              VideoResolution
+	MaxFrameRate            uint16
+	ImageCodec              ImageCodec
+	RequiresEncodedPixels   bool
+	RequiresHardwareEncoder bool
+}
+
+// MarshalTLV encodes the struct with the given tag.
+func (s SnapshotCapabilities) MarshalTLV(w *tlv.Writer, tag tlv.Tag) error {
+	if err := w.StartStructure(tag); err != nil {
+		return err
+	}
+	if err := s.Resolution.MarshalTLV(w, tlv.ContextTag(0)); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(1), uint64(s.MaxFrameRate)); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(2), uint64(s.ImageCodec)); err != nil {
+		return err
+	}
+	if err := w.PutBool(tlv.ContextTag(3), s.RequiresEncodedPixels); err != nil {
+		return err
+	}
+	if err := w.PutBool(tlv.ContextTag(4), s.RequiresHardwareEncoder); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// VideoStream is an allocated video stream (VideoStreamStruct,
+// Spec 11.2.6.11).
+type VideoStream struct {
+	VideoStreamID    uint16
+	StreamUsage      StreamUsage
+	VideoCodec       VideoCodec
+	MinFrameRate     uint16
+	MaxFrameRate     uint16
+	MinResolution    VideoResolution
+	MaxResolution    VideoResolution
+	MinBitRate       uint32 // bps
+	MaxBitRate       uint32 // bps
+	KeyFrameInterval uint16 // ms
+	WatermarkEnabled *bool  // WMARK
+	OSDEnabled       *bool  // OSD
+	ReferenceCount   uint8
+}
+
+// MarshalTLV encodes the struct with the given tag.
+func (s VideoStream) MarshalTLV(w *tlv.Writer, tag tlv.Tag) error {
+	if err := w.StartStructure(tag); err != nil {
+		return err
+	}
+	fields := []uint64{uint64(s.VideoStreamID), uint64(s.StreamUsage), uint64(s.VideoCodec),
+		uint64(s.MinFrameRate), uint64(s.MaxFrameRate)}
+	for i, v := range fields {
+		if err := w.PutUint(tlv.ContextTag(uint8(i)), v); err != nil {
+			return err
+		}
+	}
+	if err := s.MinResolution.MarshalTLV(w, tlv.ContextTag(5)); err != nil {
+		return err
+	}
+	if err := s.MaxResolution.MarshalTLV(w, tlv.ContextTag(6)); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(7), uint64(s.MinBitRate)); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(8), uint64(s.MaxBitRate)); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(9), uint64(s.KeyFrameInterval)); err != nil {
+		return err
+	}
+	if err := putOptionalBool(w, tlv.ContextTag(10), s.WatermarkEnabled); err != nil {
+		return err
+	}
+	if err := putOptionalBool(w, tlv.ContextTag(11), s.OSDEnabled); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(12), uint64(s.ReferenceCount)); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// AudioStream is an allocated audio stream (AudioStreamStruct,
+// Spec 11.2.6.12).
+type AudioStream struct {
+	AudioStreamID  uint16
+	StreamUsage    StreamUsage
+	AudioCodec     AudioCodec
+	ChannelCount   uint8
+	SampleRate     uint32 // Hz
+	BitRate        uint32 // bps
+	BitDepth       uint8
+	ReferenceCount uint8
+}
+
+// MarshalTLV encodes the struct with the given tag.
+func (s AudioStream) MarshalTLV(w *tlv.Writer, tag tlv.Tag) error {
+	if err := w.StartStructure(tag); err != nil {
+		return err
+	}
+	fields := []uint64{uint64(s.AudioStreamID), uint64(s.StreamUsage), uint64(s.AudioCodec),
+		uint64(s.ChannelCount), uint64(s.SampleRate), uint64(s.BitRate), uint64(s.BitDepth),
+		uint64(s.ReferenceCount)}
+	for i, v := range fields {
+		if err := w.PutUint(tlv.ContextTag(uint8(i)), v); err != nil {
+			return err
+		}
+	}
+	return w.EndContainer()
+}
+
+// SnapshotStream is an allocated snapshot stream (SnapshotStreamStruct,
+// Spec 11.2.6.13).
+type SnapshotStream struct {
+	SnapshotStreamID uint16
+	ImageCodec       ImageCodec
+	FrameRate        uint16
+	MinResolution    VideoResolution
+	MaxResolution    VideoResolution
+	Quality          uint8
+	ReferenceCount   uint8
+	EncodedPixels    bool
+	HardwareEncoder  bool
+	WatermarkEnabled *bool // WMARK
+	OSDEnabled       *bool // OSD
+}
+
+// MarshalTLV encodes the struct with the given tag.
+func (s SnapshotStream) MarshalTLV(w *tlv.Writer, tag tlv.Tag) error {
+	if err := w.StartStructure(tag); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(s.SnapshotStreamID)); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(1), uint64(s.ImageCodec)); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(2), uint64(s.FrameRate)); err != nil {
+		return err
+	}
+	if err := s.MinResolution.MarshalTLV(w, tlv.ContextTag(3)); err != nil {
+		return err
+	}
+	if err := s.MaxResolution.MarshalTLV(w, tlv.ContextTag(4)); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(5), uint64(s.Quality)); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(6), uint64(s.ReferenceCount)); err != nil {
+		return err
+	}
+	if err := w.PutBool(tlv.ContextTag(7), s.EncodedPixels); err != nil {
+		return err
+	}
+	if err := w.PutBool(tlv.ContextTag(8), s.HardwareEncoder); err != nil {
+		return err
+	}
+	if err := putOptionalBool(w, tlv.ContextTag(9), s.WatermarkEnabled); err != nil {
+		return err
+	}
+	if err := putOptionalBool(w, tlv.ContextTag(10), s.OSDEnabled); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// Snapshot is a captured image returned by the delegate.
+type Snapshot struct {
+	Data       []byte
+	ImageCodec ImageCodec
+	Resolution VideoResolution
+}
+
+// putUintList writes a list of n unsigned values.
+func putUintList(w *tlv.Writer, tag tlv.Tag, n int, at func(i int) uint64) error {
+	if err := w.StartArray(tag); err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		if err := w.PutUint(tlv.Anonymous(), at(i)); err != nil {
+			return err
+		}
+	}
+	return w.EndContainer()
+}
+
+// putOptionalBool writes v if it is set.
+func putOptionalBool(w *tlv.Writer, tag tlv.Tag, v *bool) error {
+	if v == nil {
+		return nil
+	}
+	return w.PutBool(tag, *v)
+}
+
+// decodeResolution decodes a VideoResolutionStruct at the current element.
+func decodeResolution(r *tlv.Reader) (VideoResolution, error) {
+	var res VideoResolution
+	if err := r.EnterContainer(); err != nil {
+		return res, datamodel.ErrInvalidCommand
+	}
+	for {
+		if err := r.Next(); err != nil {
+			return res, datamodel.ErrInvalidCommand
+		}
+		if r.IsEndOfContainer() {
+			break
+		}
+		tag := r.Tag()
+		if !tag.IsContext() || tag.TagNumber() > 1 {
+			if err := r.Skip(); err != nil {
+				return res, datamodel.ErrInvalidCommand
+			}
+			continue
+		}
+		v, err := r.Uint()
+		if err != nil || v > 0xFFFF {
+			return res, datamodel.ErrInvalidCommand
+		}
+		if tag.TagNumber() == 0 {
+			res.Width = uint16(v)
+		} else {
+			res.Height = uint16(v)
+		}
+	}
+	if err := r.ExitContainer(); err != nil {
+		return res, datamodel.ErrInvalidCommand
+	}
+	return res, nil
+}
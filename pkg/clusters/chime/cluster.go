@@ -0,0 +1,381 @@
+// Package chime implements the Chime Cluster (0x0556).
+//
+// A chime device, typically paired with a doorbell, exposes the sounds it
+// can play. Clients select one of them and trigger it with PlayChimeSound;
+// the Delegate drives the speaker. While Enabled is false, PlayChimeSound
+// succeeds without playing anything.
+//
+// Spec Reference: Section 1.18
+//
+// C++ Reference: src/app/clusters/chime-server
+package chime
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/backkem/matter/pkg/clusters"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x0556
+	ClusterRevision uint16              = 1
+)
+
+// Attribute IDs (Spec 1.18.6).
+const (
+	AttrInstalledChimeSounds datamodel.AttributeID = 0x0000
+	AttrSelectedChime        datamodel.AttributeID = 0x0001
+	AttrEnabled              datamodel.AttributeID = 0x0002
+)
+
+// Command IDs (Spec 1.18.7).
+const (
+	CmdPlayChimeSound datamodel.CommandID = 0x00
+)
+
+// MaxNameLength is the maximum length of a chime sound name.
+const MaxNameLength = 48
+
+// Errors returned by configuration and the device-side API.
+var (
+	ErrNoChimeSounds    = errors.New("chime: at least one chime sound required")
+	ErrDuplicateChimeID = errors.New("chime: duplicate chime ID")
+	ErrInvalidChimeName = errors.New("chime: chime name must be 1-48 bytes")
+)
+
+// ChimeSound is the ChimeSoundStruct (Spec 1.18.5.1).
+type ChimeSound struct {
+	ChimeID uint8
+	Name    string
+}
+
+// MarshalTLV implements the TLVMarshaler interface.
+func (s ChimeSound) MarshalTLV(w *tlv.Writer) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(s.ChimeID)); err != nil {
+		return err
+	}
+	if err := w.PutString(tlv.ContextTag(1), s.Name); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// Delegate plays chime sounds on the device.
+type Delegate interface {
+	// HandlePlayChimeSound plays the given chime sound. An error fails
+	// the PlayChimeSound command.
+	HandlePlayChimeSound(chimeID uint8) error
+}
+
+// Storage provides persistence for the Chime cluster state.
+type Storage interface {
+	// Load retrieves a value by key.
+	Load(key string) ([]byte, error)
+	// Store persists a value.
+	Store(key string, value []byte) error
+}
+
+// Config provides dependencies for the Chime cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to.
+	EndpointID datamodel.EndpointID
+
+	// ChimeSounds lists the installed chime sounds. At least one is
+	// required; IDs must be unique.
+	ChimeSounds []ChimeSound
+
+	// SelectedChime is the initially selected chime if no persisted value
+	// exists. Defaults to the first installed sound if not installed.
+	SelectedChime uint8
+
+	// Disabled starts the cluster with Enabled false if no persisted
+	// value exists.
+	Disabled bool
+
+	// Delegate plays the sounds (optional).
+	Delegate Delegate
+
+	// Storage for persisting SelectedChime and Enabled (optional).
+	Storage Storage
+}
+
+// Cluster implements the Chime cluster (0x0556).
+type Cluster struct {
+	*datamodel.ClusterBase
+	config Config
+
+	// cmdMu serializes commands.
+	cmdMu sync.Mutex
+
+	// Mutable state (protected by mutex)
+	mu       sync.RWMutex
+	sounds   []ChimeSound
+	selected uint8
+	enabled  bool
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Chime cluster. It returns an error if the installed
+// sounds are invalid.
+func New(cfg Config) (*Cluster, error) {
+	if err := validateSounds(cfg.ChimeSounds); err != nil {
+		return nil, err
+	}
+
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		config:      cfg,
+		sounds:      append([]ChimeSound(nil), cfg.ChimeSounds...),
+		selected:    cfg.SelectedChime,
+		enabled:     !cfg.Disabled,
+	}
+	if cfg.Storage != nil {
+		c.loadPersistedState()
+	}
+	if !c.installed(c.selected) {
+		c.selected = c.sounds[0].ChimeID
+	}
+
+	c.attrList = c.buildAttributeList()
+
+	return c, nil
+}
+
+// validateSounds checks the installed sound list.
+func validateSounds(sounds []ChimeSound) error {
+	if len(sounds) == 0 {
+		return ErrNoChimeSounds
+	}
+	seen := make(map[uint8]bool, len(sounds))
+	for _, s := range sounds {
+		if seen[s.ChimeID] {
+			return ErrDuplicateChimeID
+		}
+		seen[s.ChimeID] = true
+		if len(s.Name) == 0 || len(s.Name) > MaxNameLength {
+			return ErrInvalidChimeName
+		}
+	}
+	return nil
+}
+
+// loadPersistedState loads SelectedChime and Enabled from storage.
+func (c *Cluster) loadPersistedState() {
+	if data, err := c.config.Storage.Load("selectedChime"); err == nil && len(data) == 1 {
+		c.selected = data[0]
+	}
+	if data, err := c.config.Storage.Load("enabled"); err == nil && len(data) == 1 {
+		c.enabled = data[0] != 0
+	}
+}
+
+// persist stores a single-byte value if storage is configured.
+func (c *Cluster) persist(key string, value byte) {
+	if c.config.Storage != nil {
+		_ = c.config.Storage.Store(key, []byte{value})
+	}
+}
+
+// installed returns true if a sound with the given ID is installed.
+// Caller must hold c.mu.
+func (c *Cluster) installed(id uint8) bool {
+	for _, s := range c.sounds {
+		if s.ChimeID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// buildAttributeList constructs the list of supported attributes.
+func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+	operatePriv := datamodel.PrivilegeOperate
+
+	return datamodel.MergeAttributeLists([]datamodel.AttributeEntry{
+		datamodel.NewReadOnlyAttribute(AttrInstalledChimeSounds, datamodel.AttrQualityList, viewPriv),
+		datamodel.NewReadWriteAttribute(AttrSelectedChime, 0, viewPriv, operatePriv),
+		datamodel.NewReadWriteAttribute(AttrEnabled, 0, viewPriv, operatePriv),
+	})
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	return []datamodel.CommandEntry{
+		datamodel.NewCommandEntry(CmdPlayChimeSound, 0, datamodel.PrivilegeOperate),
+	}
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return nil
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	switch req.Path.Attribute {
+	case AttrInstalledChimeSounds:
+		if err := w.StartArray(tlv.Anonymous()); err != nil {
+			return err
+		}
+		for _, s := range c.sounds {
+			if err := s.MarshalTLV(w); err != nil {
+				return err
+			}
+		}
+		return w.EndContainer()
+	case AttrSelectedChime:
+		return w.PutUint(tlv.Anonymous(), uint64(c.selected))
+	case AttrEnabled:
+		return w.PutBool(tlv.Anonymous(), c.enabled)
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	if err := r.Next(); err != nil {
+		return err
+	}
+
+	switch req.Path.Attribute {
+	case AttrSelectedChime:
+		v, err := r.Uint()
+		if err != nil {
+			return err
+		}
+		if v > 0xFF {
+			return datamodel.ErrConstraintError
+		}
+		return c.SetSelectedChime(uint8(v))
+	case AttrEnabled:
+		v, err := r.Bool()
+		if err != nil {
+			return err
+		}
+		c.SetEnabled(v)
+		return nil
+	default:
+		return datamodel.ErrUnsupportedWrite
+	}
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	if req.Path.Command != CmdPlayChimeSound {
+		return nil, datamodel.ErrUnsupportedCommand
+	}
+	if err := c.PlayChimeSound(); err != nil {
+		return nil, err
+	}
+	return clusters.EmptyResponse(), nil
+}
+
+// PlayChimeSound plays the selected chime sound if chimes are enabled.
+//
+// Spec: Section 1.18.7.1
+func (c *Cluster) PlayChimeSound() error {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	c.mu.RLock()
+	enabled, selected := c.enabled, c.selected
+	c.mu.RUnlock()
+
+	if !enabled || c.config.Delegate == nil {
+		return nil
+	}
+	return c.config.Delegate.HandlePlayChimeSound(selected)
+}
+
+// SetSelectedChime selects the sound played by PlayChimeSound.
+// Returns datamodel.ErrNotFound if the sound is not installed.
+func (c *Cluster) SetSelectedChime(id uint8) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.installed(id) {
+		return datamodel.ErrNotFound
+	}
+	if c.selected != id {
+		c.selected = id
+		c.persist("selectedChime", id)
+		c.IncrementDataVersion()
+	}
+	return nil
+}
+
+// SelectedChime returns the selected chime sound ID.
+func (c *Cluster) SelectedChime() uint8 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.selected
+}
+
+// SetEnabled enables or disables playing chime sounds.
+func (c *Cluster) SetEnabled(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.enabled != enabled {
+		c.enabled = enabled
+		v := byte(0)
+		if enabled {
+			v = 1
+		}
+		c.persist("enabled", v)
+		c.IncrementDataVersion()
+	}
+}
+
+// Enabled returns whether chime sounds are played.
+func (c *Cluster) Enabled() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.enabled
+}
+
+// SetInstalledChimeSounds replaces the installed sounds, e.g. after a
+// firmware update. If the selected sound is removed, the first sound is
+// selected.
+func (c *Cluster) SetInstalledChimeSounds(sounds []ChimeSound) error {
+	if err := validateSounds(sounds); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sounds = append([]ChimeSound(nil), sounds...)
+	if !c.installed(c.selected) {
+		c.selected = c.sounds[0].ChimeID
+		c.persist("selectedChime", c.selected)
+	}
+	c.IncrementDataVersion()
+	return nil
+}
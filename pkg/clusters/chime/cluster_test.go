@@ -0,0 +1,195 @@
+package chime
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+type mockDelegate struct {
+	played []uint8
+}
+
+func (m *mockDelegate) HandlePlayChimeSound(chimeID uint8) error {
+	m.played = append(m.played, chimeID)
+	return nil
+}
+
+type mapStorage map[string][]byte
+
+func (s mapStorage) Load(key string) ([]byte, error) {
+	v, ok := s[key]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return v, nil
+}
+
+func (s mapStorage) Store(key string, value []byte) error {
+	s[key] = value
+	return nil
+}
+
+var testSounds = []ChimeSound{
+	{ChimeID: 1, Name: "Ding Dong"},
+	{ChimeID: 5, Name: "Westminster"},
+}
+
+func writeAttr(c *Cluster, attr datamodel.AttributeID, put func(w *tlv.Writer) error) error {
+	var buf bytes.Buffer
+	if err := put(tlv.NewWriter(&buf)); err != nil {
+		return err
+	}
+	req := datamodel.WriteAttributeRequest{
+		Path: datamodel.ConcreteDataAttributePath{
+			ConcreteAttributePath: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: attr},
+		},
+	}
+	return c.WriteAttribute(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes())))
+}
+
+func play(c *Cluster) error {
+	req := datamodel.InvokeRequest{
+		Path: datamodel.ConcreteCommandPath{Endpoint: 1, Cluster: ClusterID, Command: CmdPlayChimeSound},
+	}
+	_, err := c.InvokeCommand(context.Background(), req, nil)
+	return err
+}
+
+func TestNew_Validation(t *testing.T) {
+	tests := []struct {
+		name   string
+		sounds []ChimeSound
+		want   error
+	}{
+		{"valid", testSounds, nil},
+		{"empty", nil, ErrNoChimeSounds},
+		{"duplicate", []ChimeSound{{1, "a"}, {1, "b"}}, ErrDuplicateChimeID},
+		{"empty name", []ChimeSound{{1, ""}}, ErrInvalidChimeName},
+		{"long name", []ChimeSound{{1, string(make([]byte, MaxNameLength+1))}}, ErrInvalidChimeName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(Config{ChimeSounds: tt.sounds}); !errors.Is(err, tt.want) {
+				t.Errorf("New() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadInstalledChimeSounds(t *testing.T) {
+	c, err := New(Config{EndpointID: 1, ChimeSounds: testSounds})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if got := c.SelectedChime(); got != 1 {
+		t.Errorf("SelectedChime = %d, want first installed sound 1", got)
+	}
+
+	var buf bytes.Buffer
+	req := datamodel.ReadAttributeRequest{
+		Path: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: AttrInstalledChimeSounds},
+	}
+	if err := c.ReadAttribute(context.Background(), req, tlv.NewWriter(&buf)); err != nil {
+		t.Fatalf("ReadAttribute error = %v", err)
+	}
+
+	r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+	r.Next()
+	r.EnterContainer()
+	var got []ChimeSound
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		var s ChimeSound
+		r.EnterContainer()
+		for {
+			if err := r.Next(); err != nil || r.IsEndOfContainer() {
+				break
+			}
+			switch r.Tag().TagNumber() {
+			case 0:
+				v, _ := r.Uint()
+				s.ChimeID = uint8(v)
+			case 1:
+				s.Name, _ = r.String()
+			}
+		}
+		r.ExitContainer()
+		got = append(got, s)
+	}
+	if len(got) != 2 || got[0] != testSounds[0] || got[1] != testSounds[1] {
+		t.Errorf("InstalledChimeSounds = %+v, want %+v", got, testSounds)
+	}
+}
+
+func TestPlayChimeSound(t *testing.T) {
+	d := &mockDelegate{}
+	c, _ := New(Config{EndpointID: 1, ChimeSounds: testSounds, Delegate: d})
+
+	if err := writeAttr(c, AttrSelectedChime, func(w *tlv.Writer) error {
+		return w.PutUint(tlv.Anonymous(), 5)
+	}); err != nil {
+		t.Fatalf("write SelectedChime error = %v", err)
+	}
+	if err := play(c); err != nil {
+		t.Fatalf("PlayChimeSound error = %v", err)
+	}
+
+	// Disabled chimes succeed silently.
+	if err := writeAttr(c, AttrEnabled, func(w *tlv.Writer) error {
+		return w.PutBool(tlv.Anonymous(), false)
+	}); err != nil {
+		t.Fatalf("write Enabled error = %v", err)
+	}
+	if err := play(c); err != nil {
+		t.Fatalf("PlayChimeSound (disabled) error = %v", err)
+	}
+
+	if len(d.played) != 1 || d.played[0] != 5 {
+		t.Errorf("played = %v, want [5]", d.played)
+	}
+}
+
+func TestSelectedChime_NotInstalled(t *testing.T) {
+	c, _ := New(Config{EndpointID: 1, ChimeSounds: testSounds})
+	version := c.DataVersion()
+
+	err := writeAttr(c, AttrSelectedChime, func(w *tlv.Writer) error {
+		return w.PutUint(tlv.Anonymous(), 2)
+	})
+	if !errors.Is(err, datamodel.ErrNotFound) {
+		t.Errorf("write SelectedChime error = %v, want ErrNotFound", err)
+	}
+	if c.SelectedChime() != 1 || c.DataVersion() != version {
+		t.Error("rejected write should not change state")
+	}
+}
+
+func TestSetInstalledChimeSounds(t *testing.T) {
+	c, _ := New(Config{EndpointID: 1, ChimeSounds: testSounds, SelectedChime: 5})
+
+	if err := c.SetInstalledChimeSounds([]ChimeSound{{ChimeID: 7, Name: "Bell"}}); err != nil {
+		t.Fatalf("SetInstalledChimeSounds error = %v", err)
+	}
+	if got := c.SelectedChime(); got != 7 {
+		t.Errorf("SelectedChime = %d, want 7 after removal", got)
+	}
+}
+
+func TestPersistence(t *testing.T) {
+	storage := mapStorage{}
+	c, _ := New(Config{EndpointID: 1, ChimeSounds: testSounds, Storage: storage})
+	c.SetSelectedChime(5)
+	c.SetEnabled(false)
+
+	c, _ = New(Config{EndpointID: 1, ChimeSounds: testSounds, Storage: storage})
+	if c.SelectedChime() != 5 || c.Enabled() {
+		t.Errorf("restored SelectedChime = %d, Enabled = %v", c.SelectedChime(), c.Enabled())
+	}
+}
@@ -0,0 +1,496 @@
+// Package concentrationmeasurement implements the Concentration Measurement
+// cluster family (Carbon Monoxide, Carbon Dioxide, PM2.5, TVOC, ...).
+//
+// All concentration measurement clusters share the same attributes,
+// features and behaviour and differ only in cluster ID. A single Cluster
+// implementation is instantiated per substance from a Kind; the Kinds table
+// lists every cluster in the family.
+//
+// Feature-dependent attribute sets:
+//   - NumericMeasurement (MEA): MeasuredValue, Min/MaxMeasuredValue,
+//     Uncertainty, MeasurementUnit
+//   - LevelIndication (LEV): LevelValue, optionally with Medium (MED) and
+//     Critical (CRI) levels
+//   - PeakMeasurement (PEA): PeakMeasuredValue over PeakMeasuredValueWindow
+//   - AverageMeasurement (AVG): AverageMeasuredValue over AverageMeasuredValueWindow
+//
+// Spec Reference: Section 2.10
+//
+// C++ Reference: src/app/clusters/concentration-measurement-server
+package concentrationmeasurement
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// ClusterRevision is shared by all concentration measurement clusters.
+const ClusterRevision uint16 = 3
+
+// Kind identifies one cluster of the concentration measurement family.
+type Kind struct {
+	ClusterID datamodel.ClusterID
+	Name      string
+}
+
+// Concentration measurement clusters (Spec 2.10.1).
+var (
+	CarbonMonoxide                = Kind{0x040C, "Carbon Monoxide Concentration Measurement"}
+	CarbonDioxide                 = Kind{0x040D, "Carbon Dioxide Concentration Measurement"}
+	NitrogenDioxide               = Kind{0x0413, "Nitrogen Dioxide Concentration Measurement"}
+	Ozone                         = Kind{0x0415, "Ozone Concentration Measurement"}
+	PM25                          = Kind{0x042A, "PM2.5 Concentration Measurement"}
+	Formaldehyde                  = Kind{0x042B, "Formaldehyde Concentration Measurement"}
+	PM1                           = Kind{0x042C, "PM1 Concentration Measurement"}
+	PM10                          = Kind{0x042D, "PM10 Concentration Measurement"}
+	TotalVolatileOrganicCompounds = Kind{0x042E, "Total Volatile Organic Compounds Concentration Measurement"}
+	Radon                         = Kind{0x042F, "Radon Concentration Measurement"}
+)
+
+// Kinds lists every cluster in the concentration measurement family.
+var Kinds = []Kind{
+	CarbonMonoxide,
+	CarbonDioxide,
+	NitrogenDioxide,
+	Ozone,
+	PM25,
+	Formaldehyde,
+	PM1,
+	PM10,
+	TotalVolatileOrganicCompounds,
+	Radon,
+}
+
+// KindByClusterID returns the Kind for a cluster ID.
+func KindByClusterID(id datamodel.ClusterID) (Kind, bool) {
+	for _, k := range Kinds {
+		if k.ClusterID == id {
+			return k, true
+		}
+	}
+	return Kind{}, false
+}
+
+// Attribute IDs (Spec 2.10.6).
+const (
+	AttrMeasuredValue              datamodel.AttributeID = 0x0000
+	AttrMinMeasuredValue           datamodel.AttributeID = 0x0001
+	AttrMaxMeasuredValue           datamodel.AttributeID = 0x0002
+	AttrPeakMeasuredValue          datamodel.AttributeID = 0x0003
+	AttrPeakMeasuredValueWindow    datamodel.AttributeID = 0x0004
+	AttrAverageMeasuredValue       datamodel.AttributeID = 0x0005
+	AttrAverageMeasuredValueWindow datamodel.AttributeID = 0x0006
+	AttrUncertainty                datamodel.AttributeID = 0x0007
+	AttrMeasurementUnit            datamodel.AttributeID = 0x0008
+	AttrMeasurementMedium          datamodel.AttributeID = 0x0009
+	AttrLevelValue                 datamodel.AttributeID = 0x000A
+)
+
+// Feature bits (Spec 2.10.4).
+type Feature uint32
+
+const (
+	// FeatureNumericMeasurement exposes numeric measurements (MEA).
+	FeatureNumericMeasurement Feature = 1 << 0
+
+	// FeatureLevelIndication exposes a coarse level (LEV).
+	FeatureLevelIndication Feature = 1 << 1
+
+	// FeatureMediumLevel adds the Medium level (MED). Requires LEV.
+	FeatureMediumLevel Feature = 1 << 2
+
+	// FeatureCriticalLevel adds the Critical level (CRI). Requires LEV.
+	FeatureCriticalLevel Feature = 1 << 3
+
+	// FeaturePeakMeasurement exposes the peak over a window (PEA). Requires MEA.
+	FeaturePeakMeasurement Feature = 1 << 4
+
+	// FeatureAverageMeasurement exposes the average over a window (AVG). Requires MEA.
+	FeatureAverageMeasurement Feature = 1 << 5
+)
+
+// MeasurementUnit is the unit of numeric measurements (Spec 2.10.5.1).
+type MeasurementUnit uint8
+
+const (
+	MeasurementUnitPPM  MeasurementUnit = 0 // parts per million
+	MeasurementUnitPPB  MeasurementUnit = 1 // parts per billion
+	MeasurementUnitPPT  MeasurementUnit = 2 // parts per trillion
+	MeasurementUnitMGM3 MeasurementUnit = 3 // milligram per m³
+	MeasurementUnitUGM3 MeasurementUnit = 4 // microgram per m³
+	MeasurementUnitNGM3 MeasurementUnit = 5 // nanogram per m³
+	MeasurementUnitPM3  MeasurementUnit = 6 // particles per m³
+	MeasurementUnitBQM3 MeasurementUnit = 7 // becquerel per m³
+)
+
+// MeasurementMedium is the medium being measured (Spec 2.10.5.2).
+type MeasurementMedium uint8
+
+const (
+	MeasurementMediumAir   MeasurementMedium = 0
+	MeasurementMediumWater MeasurementMedium = 1
+	MeasurementMediumSoil  MeasurementMedium = 2
+)
+
+// LevelValue is the coarse concentration level (Spec 2.10.5.3).
+type LevelValue uint8
+
+const (
+	LevelValueUnknown  LevelValue = 0
+	LevelValueLow      LevelValue = 1
+	LevelValueMedium   LevelValue = 2
+	LevelValueHigh     LevelValue = 3
+	LevelValueCritical LevelValue = 4
+)
+
+// Errors returned by the concentration measurement API.
+var (
+	ErrFeatureNotSupported = errors.New("concentration: feature not supported")
+	ErrOutOfRange          = errors.New("concentration: measured value out of range")
+	ErrInvalidLevel        = errors.New("concentration: level not supported")
+)
+
+// Config provides dependencies for a concentration measurement cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to.
+	EndpointID datamodel.EndpointID
+
+	// Kind selects the cluster (substance) being measured.
+	Kind Kind
+
+	// FeatureMap indicates supported features. At least one of
+	// FeatureNumericMeasurement or FeatureLevelIndication must be set;
+	// defaults to FeatureNumericMeasurement if neither is.
+	FeatureMap Feature
+
+	// MinMeasuredValue and MaxMeasuredValue bound the sensor range (MEA).
+	// nil reports the bound as unknown (null).
+	MinMeasuredValue *float32
+	MaxMeasuredValue *float32
+
+	// Uncertainty is the measurement uncertainty (MEA, optional).
+	Uncertainty *float32
+
+	// MeasurementUnit is the unit of numeric values (MEA).
+	MeasurementUnit MeasurementUnit
+
+	// MeasurementMedium is the medium being measured.
+	MeasurementMedium MeasurementMedium
+
+	// PeakMeasuredValueWindow is the window over which the peak is
+	// tracked (PEA). Defaults to one hour if zero.
+	PeakMeasuredValueWindow time.Duration
+
+	// AverageMeasuredValueWindow is the window of the device-supplied
+	// average (AVG). Defaults to one hour if zero.
+	AverageMeasuredValueWindow time.Duration
+}
+
+// DefaultMeasuredValueWindow is the default peak and average window.
+const DefaultMeasuredValueWindow = time.Hour
+
+// Cluster implements one concentration measurement cluster.
+type Cluster struct {
+	*datamodel.ClusterBase
+	config Config
+
+	// Mutable state (protected by mutex)
+	mu            sync.RWMutex
+	measuredValue *float32
+	peakValue     *float32
+	peakTime      time.Time
+	averageValue  *float32
+	level         LevelValue
+
+	// now is the time source, replaceable in tests.
+	now func() time.Time
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a concentration measurement cluster for cfg.Kind.
+func New(cfg Config) *Cluster {
+	if cfg.FeatureMap&(FeatureNumericMeasurement|FeatureLevelIndication) == 0 {
+		cfg.FeatureMap |= FeatureNumericMeasurement
+	}
+	if cfg.FeatureMap&FeatureLevelIndication == 0 {
+		cfg.FeatureMap &^= FeatureMediumLevel | FeatureCriticalLevel
+	}
+	if cfg.FeatureMap&FeatureNumericMeasurement == 0 {
+		cfg.FeatureMap &^= FeaturePeakMeasurement | FeatureAverageMeasurement
+	}
+	if cfg.PeakMeasuredValueWindow == 0 {
+		cfg.PeakMeasuredValueWindow = DefaultMeasuredValueWindow
+	}
+	if cfg.AverageMeasuredValueWindow == 0 {
+		cfg.AverageMeasuredValueWindow = DefaultMeasuredValueWindow
+	}
+
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(cfg.Kind.ClusterID, cfg.EndpointID, ClusterRevision),
+		config:      cfg,
+		now:         time.Now,
+	}
+
+	c.ClusterBase.SetFeatureMap(uint32(cfg.FeatureMap))
+
+	c.attrList = c.buildAttributeList()
+
+	return c
+}
+
+// Kind returns the cluster kind.
+func (c *Cluster) Kind() Kind {
+	return c.config.Kind
+}
+
+// hasFeature returns true if the feature bit is set.
+func (c *Cluster) hasFeature(f Feature) bool {
+	return c.config.FeatureMap&f != 0
+}
+
+// buildAttributeList constructs the list of supported attributes.
+func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+	nullable := datamodel.AttrQualityNullable
+
+	attrs := []datamodel.AttributeEntry{
+		datamodel.NewReadOnlyAttribute(AttrMeasurementMedium, datamodel.AttrQualityFixed, viewPriv),
+	}
+
+	if c.hasFeature(FeatureNumericMeasurement) {
+		attrs = append(attrs,
+			datamodel.NewReadOnlyAttribute(AttrMeasuredValue, nullable, viewPriv),
+			datamodel.NewReadOnlyAttribute(AttrMinMeasuredValue, nullable, viewPriv),
+			datamodel.NewReadOnlyAttribute(AttrMaxMeasuredValue, nullable, viewPriv),
+			datamodel.NewReadOnlyAttribute(AttrMeasurementUnit, datamodel.AttrQualityFixed, viewPriv),
+		)
+		if c.config.Uncertainty != nil {
+			attrs = append(attrs, datamodel.NewReadOnlyAttribute(AttrUncertainty, 0, viewPriv))
+		}
+	}
+
+	if c.hasFeature(FeaturePeakMeasurement) {
+		attrs = append(attrs,
+			datamodel.NewReadOnlyAttribute(AttrPeakMeasuredValue, nullable, viewPriv),
+			datamodel.NewReadOnlyAttribute(AttrPeakMeasuredValueWindow, 0, viewPriv),
+		)
+	}
+
+	if c.hasFeature(FeatureAverageMeasurement) {
+		attrs = append(attrs,
+			datamodel.NewReadOnlyAttribute(AttrAverageMeasuredValue, nullable, viewPriv),
+			datamodel.NewReadOnlyAttribute(AttrAverageMeasuredValueWindow, 0, viewPriv),
+		)
+	}
+
+	if c.hasFeature(FeatureLevelIndication) {
+		attrs = append(attrs,
+			datamodel.NewReadOnlyAttribute(AttrLevelValue, 0, viewPriv),
+		)
+	}
+
+	return datamodel.MergeAttributeLists(attrs)
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	return nil
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return nil
+}
+
+// hasAttribute returns true if the attribute is in the attribute list.
+func (c *Cluster) hasAttribute(id datamodel.AttributeID) bool {
+	for _, a := range c.attrList {
+		if a.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	if !c.hasAttribute(req.Path.Attribute) {
+		return datamodel.ErrUnsupportedAttribute
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	switch req.Path.Attribute {
+	case AttrMeasuredValue:
+		return putNullableFloat(w, c.measuredValue)
+	case AttrMinMeasuredValue:
+		return putNullableFloat(w, c.config.MinMeasuredValue)
+	case AttrMaxMeasuredValue:
+		return putNullableFloat(w, c.config.MaxMeasuredValue)
+	case AttrPeakMeasuredValue:
+		return putNullableFloat(w, c.peakValue)
+	case AttrPeakMeasuredValueWindow:
+		return w.PutUint(tlv.Anonymous(), uint64(c.config.PeakMeasuredValueWindow/time.Second))
+	case AttrAverageMeasuredValue:
+		return putNullableFloat(w, c.averageValue)
+	case AttrAverageMeasuredValueWindow:
+		return w.PutUint(tlv.Anonymous(), uint64(c.config.AverageMeasuredValueWindow/time.Second))
+	case AttrUncertainty:
+		return w.PutFloat32(tlv.Anonymous(), *c.config.Uncertainty)
+	case AttrMeasurementUnit:
+		return w.PutUint(tlv.Anonymous(), uint64(c.config.MeasurementUnit))
+	case AttrMeasurementMedium:
+		return w.PutUint(tlv.Anonymous(), uint64(c.config.MeasurementMedium))
+	case AttrLevelValue:
+		return w.PutUint(tlv.Anonymous(), uint64(c.level))
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	// All concentration measurement attributes are read-only
+	return datamodel.ErrUnsupportedWrite
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	return nil, datamodel.ErrUnsupportedCommand
+}
+
+// SetMeasuredValue records a new numeric reading (MEA); nil marks it unknown.
+// With the PeakMeasurement feature the peak is updated, restarting once
+// PeakMeasuredValueWindow has passed since the current peak was recorded.
+func (c *Cluster) SetMeasuredValue(value *float32) error {
+	if !c.hasFeature(FeatureNumericMeasurement) {
+		return ErrFeatureNotSupported
+	}
+	if value != nil {
+		v := *value
+		if math.IsNaN(float64(v)) ||
+			(c.config.MinMeasuredValue != nil && v < *c.config.MinMeasuredValue) ||
+			(c.config.MaxMeasuredValue != nil && v > *c.config.MaxMeasuredValue) {
+			return ErrOutOfRange
+		}
+		value = &v
+	}
+
+	c.mu.Lock()
+	c.measuredValue = value
+	if c.hasFeature(FeaturePeakMeasurement) && value != nil {
+		now := c.now()
+		expired := now.Sub(c.peakTime) >= c.config.PeakMeasuredValueWindow
+		if c.peakValue == nil || expired || *value >= *c.peakValue {
+			c.peakValue = value
+			c.peakTime = now
+		}
+	}
+	c.mu.Unlock()
+
+	c.IncrementDataVersion()
+	return nil
+}
+
+// MeasuredValue returns the current numeric reading, or nil if unknown.
+func (c *Cluster) MeasuredValue() *float32 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return copyFloat(c.measuredValue)
+}
+
+// PeakMeasuredValue returns the peak reading in the current window (PEA).
+func (c *Cluster) PeakMeasuredValue() *float32 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return copyFloat(c.peakValue)
+}
+
+// SetAverageMeasuredValue records the average over AverageMeasuredValueWindow
+// as computed by the device (AVG).
+func (c *Cluster) SetAverageMeasuredValue(value *float32) error {
+	if !c.hasFeature(FeatureAverageMeasurement) {
+		return ErrFeatureNotSupported
+	}
+	c.mu.Lock()
+	c.averageValue = copyFloat(value)
+	c.mu.Unlock()
+
+	c.IncrementDataVersion()
+	return nil
+}
+
+// SetLevel records the coarse concentration level (LEV).
+// Medium and Critical require the corresponding features.
+func (c *Cluster) SetLevel(level LevelValue) error {
+	if !c.hasFeature(FeatureLevelIndication) {
+		return ErrFeatureNotSupported
+	}
+	switch level {
+	case LevelValueUnknown, LevelValueLow, LevelValueHigh:
+	case LevelValueMedium:
+		if !c.hasFeature(FeatureMediumLevel) {
+			return ErrInvalidLevel
+		}
+	case LevelValueCritical:
+		if !c.hasFeature(FeatureCriticalLevel) {
+			return ErrInvalidLevel
+		}
+	default:
+		return ErrInvalidLevel
+	}
+
+	c.mu.Lock()
+	changed := c.level != level
+	c.level = level
+	c.mu.Unlock()
+
+	if changed {
+		c.IncrementDataVersion()
+	}
+	return nil
+}
+
+// Level returns the current LevelValue.
+func (c *Cluster) Level() LevelValue {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.level
+}
+
+// putNullableFloat writes v, or null if v is nil.
+func putNullableFloat(w *tlv.Writer, v *float32) error {
+	if v == nil {
+		return w.PutNull(tlv.Anonymous())
+	}
+	return w.PutFloat32(tlv.Anonymous(), *v)
+}
+
+// copyFloat returns a copy of a nullable float.
+func copyFloat(v *float32) *float32 {
+	if v == nil {
+		return nil
+	}
+	n := *v
+	return &n
+}
@@ -0,0 +1,172 @@
+package concentrationmeasurement
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+func float32p(v float32) *float32 { return &v }
+
+func read(t *testing.T, c *Cluster, attr datamodel.AttributeID) (*tlv.Reader, error) {
+	t.Helper()
+	var buf bytes.Buffer
+	req := datamodel.ReadAttributeRequest{
+		Path: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: c.ID(), Attribute: attr},
+	}
+	if err := c.ReadAttribute(context.Background(), req, tlv.NewWriter(&buf)); err != nil {
+		return nil, err
+	}
+	r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+	if err := r.Next(); err != nil {
+		t.Fatalf("failed to read value: %v", err)
+	}
+	return r, nil
+}
+
+func hasAttr(c *Cluster, id datamodel.AttributeID) bool {
+	for _, a := range c.AttributeList() {
+		if a.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func TestKinds(t *testing.T) {
+	seen := make(map[datamodel.ClusterID]bool)
+	for _, k := range Kinds {
+		if seen[k.ClusterID] {
+			t.Errorf("duplicate cluster ID 0x%04X", k.ClusterID)
+		}
+		seen[k.ClusterID] = true
+
+		c := New(Config{EndpointID: 1, Kind: k})
+		if c.ID() != k.ClusterID {
+			t.Errorf("%s: ID() = 0x%04X, want 0x%04X", k.Name, c.ID(), k.ClusterID)
+		}
+	}
+
+	if k, ok := KindByClusterID(0x042A); !ok || k != PM25 {
+		t.Errorf("KindByClusterID(0x042A) = %v, %v; want PM25", k, ok)
+	}
+	if _, ok := KindByClusterID(0x0006); ok {
+		t.Error("KindByClusterID(0x0006) should not match")
+	}
+}
+
+func TestAttributeList_FeatureGating(t *testing.T) {
+	tests := []struct {
+		name     string
+		features Feature
+		present  []datamodel.AttributeID
+		absent   []datamodel.AttributeID
+	}{
+		{
+			name:     "default numeric",
+			features: 0,
+			present:  []datamodel.AttributeID{AttrMeasuredValue, AttrMeasurementUnit, AttrMeasurementMedium},
+			absent:   []datamodel.AttributeID{AttrLevelValue, AttrPeakMeasuredValue, AttrAverageMeasuredValue, AttrUncertainty},
+		},
+		{
+			name:     "level only",
+			features: FeatureLevelIndication | FeaturePeakMeasurement,
+			present:  []datamodel.AttributeID{AttrLevelValue, AttrMeasurementMedium},
+			absent:   []datamodel.AttributeID{AttrMeasuredValue, AttrPeakMeasuredValue},
+		},
+		{
+			name:     "numeric with peak and average",
+			features: FeatureNumericMeasurement | FeaturePeakMeasurement | FeatureAverageMeasurement,
+			present:  []datamodel.AttributeID{AttrPeakMeasuredValue, AttrPeakMeasuredValueWindow, AttrAverageMeasuredValue, AttrAverageMeasuredValueWindow},
+			absent:   []datamodel.AttributeID{AttrLevelValue},
+		},
+	}
+
+	for _, tt := range tests {
+		c := New(Config{EndpointID: 1, Kind: CarbonDioxide, FeatureMap: tt.features})
+		for _, id := range tt.present {
+			if !hasAttr(c, id) {
+				t.Errorf("%s: attribute 0x%04X missing", tt.name, id)
+			}
+		}
+		for _, id := range tt.absent {
+			if hasAttr(c, id) {
+				t.Errorf("%s: attribute 0x%04X present", tt.name, id)
+			}
+			if _, err := read(t, c, id); !errors.Is(err, datamodel.ErrUnsupportedAttribute) {
+				t.Errorf("%s: read 0x%04X: err = %v, want ErrUnsupportedAttribute", tt.name, id, err)
+			}
+		}
+	}
+}
+
+func TestSetMeasuredValue_Peak(t *testing.T) {
+	now := time.Unix(1000, 0)
+	c := New(Config{
+		EndpointID:              1,
+		Kind:                    CarbonDioxide,
+		FeatureMap:              FeatureNumericMeasurement | FeaturePeakMeasurement,
+		MaxMeasuredValue:        float32p(5000),
+		MeasurementUnit:         MeasurementUnitPPM,
+		PeakMeasuredValueWindow: time.Minute,
+	})
+	c.now = func() time.Time { return now }
+
+	if err := c.SetMeasuredValue(float32p(800)); err != nil {
+		t.Fatalf("SetMeasuredValue failed: %v", err)
+	}
+	c.SetMeasuredValue(float32p(1200))
+	c.SetMeasuredValue(float32p(900))
+	if p := c.PeakMeasuredValue(); p == nil || *p != 1200 {
+		t.Errorf("peak = %v, want 1200", p)
+	}
+
+	// After the window, the next reading starts a new peak.
+	now = now.Add(2 * time.Minute)
+	c.SetMeasuredValue(float32p(700))
+	if p := c.PeakMeasuredValue(); p == nil || *p != 700 {
+		t.Errorf("peak = %v, want 700 after window", p)
+	}
+
+	if err := c.SetMeasuredValue(float32p(6000)); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("above max: err = %v, want ErrOutOfRange", err)
+	}
+
+	r, err := read(t, c, AttrMeasuredValue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := r.Float32(); err != nil || v != 700 {
+		t.Errorf("MeasuredValue = %v, %v; want 700", v, err)
+	}
+	r, _ = read(t, c, AttrPeakMeasuredValueWindow)
+	if v, _ := r.Uint(); v != 60 {
+		t.Errorf("PeakMeasuredValueWindow = %d, want 60", v)
+	}
+	r, _ = read(t, c, AttrMinMeasuredValue)
+	if r.Type() != tlv.ElementTypeNull {
+		t.Error("MinMeasuredValue should be null")
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	c := New(Config{EndpointID: 1, Kind: PM25, FeatureMap: FeatureLevelIndication | FeatureCriticalLevel})
+
+	if err := c.SetLevel(LevelValueCritical); err != nil {
+		t.Errorf("SetLevel(Critical) failed: %v", err)
+	}
+	if err := c.SetLevel(LevelValueMedium); !errors.Is(err, ErrInvalidLevel) {
+		t.Errorf("SetLevel(Medium): err = %v, want ErrInvalidLevel", err)
+	}
+	if c.Level() != LevelValueCritical {
+		t.Errorf("Level() = %d, want Critical", c.Level())
+	}
+	if err := c.SetMeasuredValue(float32p(10)); !errors.Is(err, ErrFeatureNotSupported) {
+		t.Errorf("SetMeasuredValue without MEA: err = %v, want ErrFeatureNotSupported", err)
+	}
+}
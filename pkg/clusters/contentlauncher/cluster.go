@@ -0,0 +1,321 @@
+// Package contentlauncher implements the Content Launcher Cluster (0x050A).
+//
+// The cluster lets a client start playback on a media device, either by
+// URL (URL Playback) or by searching the device's catalog with a list of
+// typed parameters (Content Search), e.g. "Genre: Comedy, Actor: ...".
+// The actual launch is delegated to the player.
+//
+// Spec Reference: Section 6.7
+//
+// C++ Reference: src/app/clusters/content-launch-server
+package contentlauncher
+
+import (
+	"context"
+	"errors"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x050A
+	ClusterRevision uint16              = 2
+)
+
+// Attribute IDs (Spec 6.7.6).
+const (
+	AttrAcceptHeader                datamodel.AttributeID = 0x0000
+	AttrSupportedStreamingProtocols datamodel.AttributeID = 0x0001
+)
+
+// Command IDs (Spec 6.7.7).
+const (
+	CmdLaunchContent    datamodel.CommandID = 0x00
+	CmdLaunchURL        datamodel.CommandID = 0x01
+	CmdLauncherResponse datamodel.CommandID = 0x02
+)
+
+// Feature bits (Spec 6.7.4).
+type Feature uint32
+
+const (
+	// FeatureContentSearch supports LaunchContent (CS).
+	FeatureContentSearch Feature = 1 << 0
+
+	// FeatureURLPlayback supports LaunchURL (UP).
+	FeatureURLPlayback Feature = 1 << 1
+)
+
+// StreamingProtocols is the SupportedProtocolsBitmap (Spec 6.7.5.1).
+type StreamingProtocols uint32
+
+const (
+	StreamingProtocolDASH StreamingProtocols = 1 << 0
+	StreamingProtocolHLS  StreamingProtocols = 1 << 1
+)
+
+// Status is the result of a launch (StatusEnum, Spec 6.7.5.2).
+type Status uint8
+
+const (
+	StatusSuccess                Status = 0
+	StatusURLNotAvailable        Status = 1
+	StatusAuthFailed             Status = 2
+	StatusTextTrackNotAvailable  Status = 3
+	StatusAudioTrackNotAvailable Status = 4
+)
+
+// String returns the name of the status.
+func (s Status) String() string {
+	switch s {
+	case StatusSuccess:
+		return "Success"
+	case StatusURLNotAvailable:
+		return "URLNotAvailable"
+	case StatusAuthFailed:
+		return "AuthFailed"
+	case StatusTextTrackNotAvailable:
+		return "TextTrackNotAvailable"
+	case StatusAudioTrackNotAvailable:
+		return "AudioTrackNotAvailable"
+	default:
+		return "Unknown"
+	}
+}
+
+// ParameterType is the type of a search parameter (ParameterEnum,
+// Spec 6.7.5.3).
+type ParameterType uint8
+
+const (
+	ParameterTypeActor      ParameterType = 0
+	ParameterTypeChannel    ParameterType = 1
+	ParameterTypeCharacter  ParameterType = 2
+	ParameterTypeDirector   ParameterType = 3
+	ParameterTypeEvent      ParameterType = 4
+	ParameterTypeFranchise  ParameterType = 5
+	ParameterTypeGenre      ParameterType = 6
+	ParameterTypeLeague     ParameterType = 7
+	ParameterTypePopularity ParameterType = 8
+	ParameterTypeProvider   ParameterType = 9
+	ParameterTypeSport      ParameterType = 10
+	ParameterTypeSportsTeam ParameterType = 11
+	ParameterTypeType       ParameterType = 12
+	ParameterTypeVideo      ParameterType = 13
+	ParameterTypeSeason     ParameterType = 14
+	ParameterTypeEpisode    ParameterType = 15
+	ParameterTypeAny        ParameterType = 16
+)
+
+// AdditionalInfo is a name/value pair (AdditionalInfoStruct, Spec 6.7.5.4),
+// used for external IDs of a search parameter.
+type AdditionalInfo struct {
+	Name  string
+	Value string
+}
+
+// Parameter is a search parameter (ParameterStruct, Spec 6.7.5.5).
+type Parameter struct {
+	Type           ParameterType
+	Value          string
+	ExternalIDList []AdditionalInfo
+}
+
+// Errors returned by New.
+var (
+	ErrInvalidFeatures = errors.New("contentlauncher: unsupported feature")
+	ErrNoDelegate      = errors.New("contentlauncher: delegate is required")
+)
+
+// Delegate launches content on the player. The returned data, if not
+// empty, is sent back in the LauncherResponse.
+type Delegate interface {
+	// HandleLaunchContent searches for content matching all parameters
+	// and launches the best match (CS). If autoPlay is false the content
+	// is shown without starting playback. data is app-specific and may
+	// be empty.
+	HandleLaunchContent(search []Parameter, autoPlay bool, data string) (Status, string)
+
+	// HandleLaunchURL launches the content at url (UP). displayString is
+	// an optional message to show while loading.
+	HandleLaunchURL(url, displayString string) (Status, string)
+}
+
+// Config provides dependencies for the Content Launcher cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to.
+	EndpointID datamodel.EndpointID
+
+	// FeatureMap indicates supported features.
+	FeatureMap Feature
+
+	// AcceptHeader lists the supported content types for URL playback,
+	// e.g. "application/dash+xml" (UP).
+	AcceptHeader []string
+
+	// SupportedStreamingProtocols indicates the supported adaptive
+	// streaming protocols (UP).
+	SupportedStreamingProtocols StreamingProtocols
+
+	// Delegate launches content (required).
+	Delegate Delegate
+}
+
+// Cluster implements the Content Launcher cluster (0x050A).
+type Cluster struct {
+	*datamodel.ClusterBase
+	config Config
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Content Launcher cluster.
+func New(cfg Config) (*Cluster, error) {
+	if cfg.FeatureMap&^(FeatureContentSearch|FeatureURLPlayback) != 0 {
+		return nil, ErrInvalidFeatures
+	}
+	if cfg.Delegate == nil {
+		return nil, ErrNoDelegate
+	}
+
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		config:      cfg,
+	}
+
+	c.ClusterBase.SetFeatureMap(uint32(cfg.FeatureMap))
+	c.attrList = c.buildAttributeList()
+
+	return c, nil
+}
+
+// hasFeature returns true if the feature bit is set.
+func (c *Cluster) hasFeature(f Feature) bool {
+	return c.config.FeatureMap&f != 0
+}
+
+// buildAttributeList constructs the list of supported attributes.
+func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+
+	var attrs []datamodel.AttributeEntry
+	if c.hasFeature(FeatureURLPlayback) {
+		attrs = append(attrs,
+			datamodel.NewReadOnlyAttribute(AttrAcceptHeader, datamodel.AttrQualityList, viewPriv),
+			datamodel.NewReadOnlyAttribute(AttrSupportedStreamingProtocols, 0, viewPriv),
+		)
+	}
+
+	return datamodel.MergeAttributeLists(attrs)
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	operatePriv := datamodel.PrivilegeOperate
+
+	var cmds []datamodel.CommandEntry
+	if c.hasFeature(FeatureContentSearch) {
+		cmds = append(cmds, datamodel.NewCommandEntry(CmdLaunchContent, 0, operatePriv))
+	}
+	if c.hasFeature(FeatureURLPlayback) {
+		cmds = append(cmds, datamodel.NewCommandEntry(CmdLaunchURL, 0, operatePriv))
+	}
+	return cmds
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return []datamodel.CommandID{CmdLauncherResponse}
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	if !c.hasFeature(FeatureURLPlayback) {
+		return datamodel.ErrUnsupportedAttribute
+	}
+
+	switch req.Path.Attribute {
+	case AttrAcceptHeader:
+		if err := w.StartArray(tlv.Anonymous()); err != nil {
+			return err
+		}
+		for _, h := range c.config.AcceptHeader {
+			if err := w.PutString(tlv.Anonymous(), h); err != nil {
+				return err
+			}
+		}
+		return w.EndContainer()
+	case AttrSupportedStreamingProtocols:
+		return w.PutUint(tlv.Anonymous(), uint64(c.config.SupportedStreamingProtocols))
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	return datamodel.ErrUnsupportedWrite
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	return datamodel.ResponseFields(c.InvokeCommandResponse(ctx, req, r))
+}
+
+// InvokeCommandResponse implements datamodel.ClusterWithCommandResponses.
+// Both launch commands are answered with a LauncherResponse.
+func (c *Cluster) InvokeCommandResponse(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) (*datamodel.CommandResponse, error) {
+	switch req.Path.Command {
+	case CmdLaunchContent:
+		if !c.hasFeature(FeatureContentSearch) {
+			return nil, datamodel.ErrUnsupportedCommand
+		}
+		cmd, err := decodeLaunchContent(r)
+		if err != nil {
+			return nil, err
+		}
+		status, data := c.config.Delegate.HandleLaunchContent(cmd.search, cmd.autoPlay, cmd.data)
+		return newLauncherResponse(status, data)
+
+	case CmdLaunchURL:
+		if !c.hasFeature(FeatureURLPlayback) {
+			return nil, datamodel.ErrUnsupportedCommand
+		}
+		url, display, err := decodeLaunchURL(r)
+		if err != nil {
+			return nil, err
+		}
+		status, data := c.config.Delegate.HandleLaunchURL(url, display)
+		return newLauncherResponse(status, data)
+
+	default:
+		return nil, datamodel.ErrUnsupportedCommand
+	}
+}
+
+// newLauncherResponse builds a LauncherResponse (Spec 6.7.7.3).
+func newLauncherResponse(status Status, data string) (*datamodel.CommandResponse, error) {
+	return datamodel.NewCommandResponse(CmdLauncherResponse, func(w *tlv.Writer) error {
+		if err := w.PutUint(tlv.ContextTag(0), uint64(status)); err != nil {
+			return err
+		}
+		if data == "" {
+			return nil
+		}
+		return w.PutString(tlv.ContextTag(1), data)
+	})
+}
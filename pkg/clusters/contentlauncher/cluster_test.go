@@ -0,0 +1,203 @@
+package contentlauncher
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// mockPlayer records launches.
+type mockPlayer struct {
+	search   []Parameter
+	autoPlay bool
+	data     string
+	url      string
+	display  string
+	status   Status
+}
+
+func (p *mockPlayer) HandleLaunchContent(search []Parameter, autoPlay bool, data string) (Status, string) {
+	p.search, p.autoPlay, p.data = search, autoPlay, data
+	return p.status, "launched"
+}
+
+func (p *mockPlayer) HandleLaunchURL(url, displayString string) (Status, string) {
+	p.url, p.display = url, displayString
+	return p.status, ""
+}
+
+func newCluster(t *testing.T, p *mockPlayer) *Cluster {
+	t.Helper()
+	c, err := New(Config{
+		EndpointID:                  1,
+		FeatureMap:                  FeatureContentSearch | FeatureURLPlayback,
+		AcceptHeader:                []string{"application/dash+xml"},
+		SupportedStreamingProtocols: StreamingProtocolDASH | StreamingProtocolHLS,
+		Delegate:                    p,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+func invoke(c *Cluster, cmd datamodel.CommandID, encode func(w *tlv.Writer)) (Status, string, error) {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	w.StartStructure(tlv.Anonymous())
+	encode(w)
+	w.EndContainer()
+
+	req := datamodel.InvokeRequest{
+		Path: datamodel.ConcreteCommandPath{Endpoint: 1, Cluster: ClusterID, Command: cmd},
+	}
+	resp, err := c.InvokeCommand(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes())))
+	if err != nil {
+		return 0, "", err
+	}
+
+	r := tlv.NewReader(bytes.NewReader(resp))
+	r.Next()
+	r.EnterContainer()
+	r.Next()
+	status, _ := r.Uint()
+	var data string
+	if r.Next() == nil && !r.IsEndOfContainer() {
+		data, _ = r.String()
+	}
+	return Status(status), data, nil
+}
+
+// putParameter encodes a ParameterStruct.
+func putParameter(w *tlv.Writer, typ ParameterType, value string, externalIDs ...AdditionalInfo) {
+	w.StartStructure(tlv.Anonymous())
+	w.PutUint(tlv.ContextTag(0), uint64(typ))
+	w.PutString(tlv.ContextTag(1), value)
+	if len(externalIDs) > 0 {
+		w.StartList(tlv.ContextTag(2))
+		for _, id := range externalIDs {
+			w.StartStructure(tlv.Anonymous())
+			w.PutString(tlv.ContextTag(0), id.Name)
+			w.PutString(tlv.ContextTag(1), id.Value)
+			w.EndContainer()
+		}
+		w.EndContainer()
+	}
+	w.EndContainer()
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(Config{Delegate: &mockPlayer{}, FeatureMap: 1 << 2}); !errors.Is(err, ErrInvalidFeatures) {
+		t.Errorf("error = %v, want ErrInvalidFeatures", err)
+	}
+	if _, err := New(Config{}); !errors.Is(err, ErrNoDelegate) {
+		t.Errorf("error = %v, want ErrNoDelegate", err)
+	}
+}
+
+func TestLaunchContent(t *testing.T) {
+	p := &mockPlayer{}
+	c := newCluster(t, p)
+
+	status, data, err := invoke(c, CmdLaunchContent, func(w *tlv.Writer) {
+		w.StartStructure(tlv.ContextTag(0))
+		w.StartList(tlv.ContextTag(0))
+		putParameter(w, ParameterTypeGenre, "Comedy")
+		putParameter(w, ParameterTypeVideo, "Big Buck Bunny",
+			AdditionalInfo{Name: "imdb", Value: "tt1254207"})
+		w.EndContainer()
+		w.EndContainer()
+		w.PutBool(tlv.ContextTag(1), true)
+		w.PutString(tlv.ContextTag(2), "profile=kids")
+		// Playback preferences are skipped
+		w.StartStructure(tlv.ContextTag(3))
+		w.PutUint(tlv.ContextTag(0), 1)
+		w.EndContainer()
+	})
+	if err != nil {
+		t.Fatalf("LaunchContent error = %v", err)
+	}
+	if status != StatusSuccess || data != "launched" {
+		t.Errorf("response = %v %q", status, data)
+	}
+
+	if len(p.search) != 2 || !p.autoPlay || p.data != "profile=kids" {
+		t.Fatalf("delegate got search = %+v autoPlay = %v data = %q", p.search, p.autoPlay, p.data)
+	}
+	if p.search[0].Type != ParameterTypeGenre || p.search[0].Value != "Comedy" {
+		t.Errorf("search[0] = %+v", p.search[0])
+	}
+	ids := p.search[1].ExternalIDList
+	if len(ids) != 1 || ids[0].Name != "imdb" || ids[0].Value != "tt1254207" {
+		t.Errorf("search[1].ExternalIDList = %+v", ids)
+	}
+}
+
+func TestLaunchContent_Invalid(t *testing.T) {
+	c := newCluster(t, &mockPlayer{})
+
+	_, _, err := invoke(c, CmdLaunchContent, func(w *tlv.Writer) {
+		w.PutBool(tlv.ContextTag(1), true)
+	})
+	if !errors.Is(err, datamodel.ErrInvalidCommand) {
+		t.Errorf("missing search error = %v, want ErrInvalidCommand", err)
+	}
+
+	_, _, err = invoke(c, CmdLaunchContent, func(w *tlv.Writer) {
+		w.StartStructure(tlv.ContextTag(0))
+		w.StartList(tlv.ContextTag(0))
+		putParameter(w, ParameterType(99), "x")
+		w.EndContainer()
+		w.EndContainer()
+		w.PutBool(tlv.ContextTag(1), true)
+	})
+	if !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("unknown parameter type error = %v, want ErrConstraintError", err)
+	}
+}
+
+func TestLaunchURL(t *testing.T) {
+	p := &mockPlayer{status: StatusURLNotAvailable}
+	c := newCluster(t, p)
+
+	status, _, err := invoke(c, CmdLaunchURL, func(w *tlv.Writer) {
+		w.PutString(tlv.ContextTag(0), "https://example.com/manifest.mpd")
+		w.PutString(tlv.ContextTag(1), "Loading...")
+	})
+	if err != nil {
+		t.Fatalf("LaunchURL error = %v", err)
+	}
+	if status != StatusURLNotAvailable {
+		t.Errorf("status = %v, want URLNotAvailable", status)
+	}
+	if p.url != "https://example.com/manifest.mpd" || p.display != "Loading..." {
+		t.Errorf("delegate got url = %q display = %q", p.url, p.display)
+	}
+
+	if _, _, err := invoke(c, CmdLaunchURL, func(w *tlv.Writer) {}); !errors.Is(err, datamodel.ErrInvalidCommand) {
+		t.Errorf("missing URL error = %v, want ErrInvalidCommand", err)
+	}
+}
+
+func TestFeatureGating(t *testing.T) {
+	c, _ := New(Config{EndpointID: 1, FeatureMap: FeatureContentSearch, Delegate: &mockPlayer{}})
+
+	_, _, err := invoke(c, CmdLaunchURL, func(w *tlv.Writer) {
+		w.PutString(tlv.ContextTag(0), "https://example.com")
+	})
+	if !errors.Is(err, datamodel.ErrUnsupportedCommand) {
+		t.Errorf("LaunchURL without UP error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	req := datamodel.ReadAttributeRequest{
+		Path: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: AttrAcceptHeader},
+	}
+	if err := c.ReadAttribute(context.Background(), req, tlv.NewWriter(&buf)); !errors.Is(err, datamodel.ErrUnsupportedAttribute) {
+		t.Errorf("AcceptHeader without UP error = %v", err)
+	}
+}
@@ -0,0 +1,272 @@
+package contentlauncher
+
+import (
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// launchContent is a decoded LaunchContent command.
+type launchContent struct {
+	search   []Parameter
+	autoPlay bool
+	data     string
+}
+
+// decodeLaunchContent decodes LaunchContent (Spec 6.7.7.1): Search
+// (tag 0), AutoPlay (tag 1) and the optional Data (tag 2). Playback
+// preferences (tags 3 and 4) are skipped.
+func decodeLaunchContent(r *tlv.Reader) (*launchContent, error) {
+	if err := r.Next(); err != nil {
+		return nil, datamodel.ErrInvalidCommand
+	}
+	if err := r.EnterContainer(); err != nil {
+		return nil, datamodel.ErrInvalidCommand
+	}
+
+	cmd := &launchContent{}
+	var hasSearch, hasAutoPlay bool
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		tag := r.Tag()
+		if !tag.IsContext() {
+			if err := r.Skip(); err != nil {
+				return nil, datamodel.ErrInvalidCommand
+			}
+			continue
+		}
+		switch tag.TagNumber() {
+		case 0:
+			search, err := decodeContentSearch(r)
+			if err != nil {
+				return nil, err
+			}
+			cmd.search, hasSearch = search, true
+		case 1:
+			v, err := r.Bool()
+			if err != nil {
+				return nil, datamodel.ErrInvalidCommand
+			}
+			cmd.autoPlay, hasAutoPlay = v, true
+		case 2:
+			v, err := r.String()
+			if err != nil {
+				return nil, datamodel.ErrInvalidCommand
+			}
+			cmd.data = v
+		default:
+			if err := r.Skip(); err != nil {
+				return nil, datamodel.ErrInvalidCommand
+			}
+		}
+	}
+	if !hasSearch || !hasAutoPlay {
+		return nil, datamodel.ErrInvalidCommand
+	}
+	return cmd, nil
+}
+
+// decodeContentSearch decodes a ContentSearchStruct (Spec 6.7.5.6): the
+// ParameterList (tag 0).
+func decodeContentSearch(r *tlv.Reader) ([]Parameter, error) {
+	if err := r.EnterContainer(); err != nil {
+		return nil, datamodel.ErrInvalidCommand
+	}
+
+	var params []Parameter
+	hasList := false
+	for {
+		if err := r.Next(); err != nil {
+			return nil, datamodel.ErrInvalidCommand
+		}
+		if r.IsEndOfContainer() {
+			break
+		}
+		tag := r.Tag()
+		if !tag.IsContext() || tag.TagNumber() != 0 {
+			if err := r.Skip(); err != nil {
+				return nil, datamodel.ErrInvalidCommand
+			}
+			continue
+		}
+		if err := r.EnterContainer(); err != nil {
+			return nil, datamodel.ErrInvalidCommand
+		}
+		for {
+			if err := r.Next(); err != nil {
+				return nil, datamodel.ErrInvalidCommand
+			}
+			if r.IsEndOfContainer() {
+				break
+			}
+			p, err := decodeParameter(r)
+			if err != nil {
+				return nil, err
+			}
+			params = append(params, p)
+		}
+		if err := r.ExitContainer(); err != nil {
+			return nil, datamodel.ErrInvalidCommand
+		}
+		hasList = true
+	}
+	if err := r.ExitContainer(); err != nil {
+		return nil, datamodel.ErrInvalidCommand
+	}
+	if !hasList {
+		return nil, datamodel.ErrInvalidCommand
+	}
+	return params, nil
+}
+
+// decodeParameter decodes a ParameterStruct (Spec 6.7.5.5). Unknown
+// parameter types are a constraint error.
+func decodeParameter(r *tlv.Reader) (Parameter, error) {
+	var p Parameter
+	if err := r.EnterContainer(); err != nil {
+		return p, datamodel.ErrInvalidCommand
+	}
+
+	var hasType, hasValue bool
+	for {
+		if err := r.Next(); err != nil {
+			return p, datamodel.ErrInvalidCommand
+		}
+		if r.IsEndOfContainer() {
+			break
+		}
+		tag := r.Tag()
+		if !tag.IsContext() {
+			if err := r.Skip(); err != nil {
+				return p, datamodel.ErrInvalidCommand
+			}
+			continue
+		}
+		switch tag.TagNumber() {
+		case 0:
+			v, err := r.Uint()
+			if err != nil {
+				return p, datamodel.ErrInvalidCommand
+			}
+			if v > uint64(ParameterTypeAny) {
+				return p, datamodel.ErrConstraintError
+			}
+			p.Type, hasType = ParameterType(v), true
+		case 1:
+			v, err := r.String()
+			if err != nil {
+				return p, datamodel.ErrInvalidCommand
+			}
+			p.Value, hasValue = v, true
+		case 2:
+			ids, err := decodeAdditionalInfoList(r)
+			if err != nil {
+				return p, err
+			}
+			p.ExternalIDList = ids
+		default:
+			if err := r.Skip(); err != nil {
+				return p, datamodel.ErrInvalidCommand
+			}
+		}
+	}
+	if err := r.ExitContainer(); err != nil {
+		return p, datamodel.ErrInvalidCommand
+	}
+	if !hasType || !hasValue {
+		return p, datamodel.ErrInvalidCommand
+	}
+	return p, nil
+}
+
+// decodeAdditionalInfoList decodes a list of AdditionalInfoStruct
+// (Spec 6.7.5.4).
+func decodeAdditionalInfoList(r *tlv.Reader) ([]AdditionalInfo, error) {
+	if err := r.EnterContainer(); err != nil {
+		return nil, datamodel.ErrInvalidCommand
+	}
+
+	var list []AdditionalInfo
+	for {
+		if err := r.Next(); err != nil {
+			return nil, datamodel.ErrInvalidCommand
+		}
+		if r.IsEndOfContainer() {
+			break
+		}
+		if err := r.EnterContainer(); err != nil {
+			return nil, datamodel.ErrInvalidCommand
+		}
+		var info AdditionalInfo
+		for {
+			if err := r.Next(); err != nil {
+				return nil, datamodel.ErrInvalidCommand
+			}
+			if r.IsEndOfContainer() {
+				break
+			}
+			tag := r.Tag()
+			if tag.IsContext() && tag.TagNumber() <= 1 {
+				v, err := r.String()
+				if err != nil {
+					return nil, datamodel.ErrInvalidCommand
+				}
+				if tag.TagNumber() == 0 {
+					info.Name = v
+				} else {
+					info.Value = v
+				}
+			} else if err := r.Skip(); err != nil {
+				return nil, datamodel.ErrInvalidCommand
+			}
+		}
+		if err := r.ExitContainer(); err != nil {
+			return nil, datamodel.ErrInvalidCommand
+		}
+		list = append(list, info)
+	}
+	if err := r.ExitContainer(); err != nil {
+		return nil, datamodel.ErrInvalidCommand
+	}
+	return list, nil
+}
+
+// decodeLaunchURL decodes LaunchURL (Spec 6.7.7.2): ContentURL (tag 0)
+// and the optional DisplayString (tag 1). Branding information and
+// playback preferences are skipped.
+func decodeLaunchURL(r *tlv.Reader) (url, display string, err error) {
+	if err := r.Next(); err != nil {
+		return "", "", datamodel.ErrInvalidCommand
+	}
+	if err := r.EnterContainer(); err != nil {
+		return "", "", datamodel.ErrInvalidCommand
+	}
+
+	hasURL := false
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		tag := r.Tag()
+		switch {
+		case tag.IsContext() && tag.TagNumber() == 0:
+			if url, err = r.String(); err != nil {
+				return "", "", datamodel.ErrInvalidCommand
+			}
+			hasURL = true
+		case tag.IsContext() && tag.TagNumber() == 1:
+			if display, err = r.String(); err != nil {
+				return "", "", datamodel.ErrInvalidCommand
+			}
+		default:
+			if err := r.Skip(); err != nil {
+				return "", "", datamodel.ErrInvalidCommand
+			}
+		}
+	}
+	if !hasURL || url == "" {
+		return "", "", datamodel.ErrInvalidCommand
+	}
+	return url, display, nil
+}
@@ -83,7 +83,22 @@ func (c *Cluster) readClientList(w *tlv.Writer) error {
 }
 
 // readPartsList writes the PartsList attribute (0x0003).
-// This attribute indicates composition of the device type instance.
+//
+// Spec: Section 9.5.6.4
+func (c *Cluster) readPartsList(w *tlv.Writer) error {
+	if err := w.StartArray(tlv.Anonymous()); err != nil {
+		return err
+	}
+	for _, id := range c.PartsList() {
+		if err := w.PutUint(tlv.Anonymous(), uint64(id)); err != nil {
+			return err
+		}
+	}
+	return w.EndContainer()
+}
+
+// PartsList returns the endpoints that are parts of this endpoint's device
+// type instance, as the PartsList attribute reports them.
 //
 // For root endpoint (0): Returns ALL non-root endpoints.
 // For other endpoints: Returns children based on composition pattern:
@@ -91,55 +106,44 @@ func (c *Cluster) readClientList(w *tlv.Writer) error {
 //   - kTree: Only direct children
 //
 // Spec: Section 9.5.6.4
-func (c *Cluster) readPartsList(w *tlv.Writer) error {
+func (c *Cluster) PartsList() []datamodel.EndpointID {
 	endpoints := c.config.Node.GetEndpoints()
-
-	if err := w.StartArray(tlv.Anonymous()); err != nil {
-		return err
-	}
+	var parts []datamodel.EndpointID
 
 	if c.config.EndpointID == 0 {
 		// Root endpoint: return all non-root endpoints
 		for _, ep := range endpoints {
 			if ep.ID() != 0 {
-				if err := w.PutUint(tlv.Anonymous(), uint64(ep.ID())); err != nil {
-					return err
-				}
+				parts = append(parts, ep.ID())
 			}
 		}
-	} else {
-		// Non-root endpoint: return children based on composition pattern
-		myEndpoint := c.config.Node.GetEndpoint(c.config.EndpointID)
-		if myEndpoint == nil {
-			return w.EndContainer()
-		}
+		return parts
+	}
 
-		entry := myEndpoint.Entry()
+	// Non-root endpoint: return children based on composition pattern
+	myEndpoint := c.config.Node.GetEndpoint(c.config.EndpointID)
+	if myEndpoint == nil {
+		return nil
+	}
 
-		switch entry.CompositionPattern {
-		case datamodel.CompositionFullFamily:
-			// All descendants - find all endpoints where we are an ancestor
-			for _, ep := range endpoints {
-				if c.isDescendantOf(ep.ID(), c.config.EndpointID, endpoints) {
-					if err := w.PutUint(tlv.Anonymous(), uint64(ep.ID())); err != nil {
-						return err
-					}
-				}
+	switch myEndpoint.Entry().CompositionPattern {
+	case datamodel.CompositionFullFamily:
+		// All descendants - find all endpoints where we are an ancestor
+		for _, ep := range endpoints {
+			if c.isDescendantOf(ep.ID(), c.config.EndpointID, endpoints) {
+				parts = append(parts, ep.ID())
 			}
-		case datamodel.CompositionTree:
-			// Direct children only
-			for _, ep := range endpoints {
-				epEntry := ep.Entry()
-				if epEntry.ParentID != nil && *epEntry.ParentID == c.config.EndpointID {
-					if err := w.PutUint(tlv.Anonymous(), uint64(ep.ID())); err != nil {
-						return err
-					}
-				}
+		}
+	case datamodel.CompositionTree:
+		// Direct children only
+		for _, ep := range endpoints {
+			epEntry := ep.Entry()
+			if epEntry.ParentID != nil && *epEntry.ParentID == c.config.EndpointID {
+				parts = append(parts, ep.ID())
 			}
 		}
 	}
-
-	return w.EndContainer()
+	return parts
 }
 
 // isDescendantOf checks if childID is a descendant of parentID.
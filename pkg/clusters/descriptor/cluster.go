@@ -39,6 +39,10 @@ const (
 	FeatureTagList Feature = 1 << 0 // TAGLIST
 )
 
+// MaxEndpointUniqueIDLength is the maximum length of EndpointUniqueID
+// (Spec 9.5.6.6).
+const MaxEndpointUniqueIDLength = 32
+
 // SemanticTag represents a semantic tag for endpoint disambiguation (Spec 9.5.6.5).
 type SemanticTag struct {
 	// MfgCode is the manufacturer code (null for standard tags).
@@ -67,7 +71,10 @@ type Config struct {
 	// If non-empty, the TAGLIST feature is enabled.
 	SemanticTags []SemanticTag
 
-	// EndpointUniqueID is an optional unique identifier for the endpoint.
+	// EndpointUniqueID is an optional identifier of the endpoint, unique
+	// within the node and stable across restarts, e.g. so that a controller
+	// recognizes a bridged device after its endpoint number changed.
+	// At most MaxEndpointUniqueIDLength characters.
 	EndpointUniqueID *string
 }
 
@@ -5,6 +5,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/backkem/matter/pkg/acl"
 	"github.com/backkem/matter/pkg/datamodel"
 	"github.com/backkem/matter/pkg/tlv"
 )
@@ -603,3 +604,45 @@ func TestCluster_GeneratedCommandList_Empty(t *testing.T) {
 		t.Errorf("GeneratedCommandList() len = %d, want 0", len(cmds))
 	}
 }
+
+func TestDeviceTypeResolver(t *testing.T) {
+	node := &mockNode{
+		endpoints: []datamodel.Endpoint{
+			&mockEndpoint{id: 0, deviceTypes: []datamodel.DeviceTypeEntry{{DeviceTypeID: 0x0016, Revision: 1}}},
+			&mockEndpoint{id: 1, deviceTypes: []datamodel.DeviceTypeEntry{{DeviceTypeID: 0x0100, Revision: 3}}},
+		},
+	}
+	var resolver acl.DeviceTypeResolver = NewDeviceTypeResolver(node)
+
+	tests := []struct {
+		deviceType uint32
+		endpoint   uint16
+		want       bool
+	}{
+		{0x0016, 0, true},
+		{0x0100, 1, true},
+		{0x0100, 0, false},
+		{0x0100, 2, false}, // no such endpoint
+	}
+	for _, tt := range tests {
+		if got := resolver.IsDeviceTypeOnEndpoint(tt.deviceType, tt.endpoint); got != tt.want {
+			t.Errorf("IsDeviceTypeOnEndpoint(0x%04X, %d) = %v, want %v", tt.deviceType, tt.endpoint, got, tt.want)
+		}
+	}
+
+	// An ACL entry targeting the device type grants access to its endpoints only.
+	checker := acl.NewChecker(resolver)
+	checker.SetEntries([]acl.Entry{{
+		FabricIndex: 1,
+		Privilege:   acl.PrivilegeOperate,
+		AuthMode:    acl.AuthModeCASE,
+		Targets:     []acl.Target{acl.NewTargetDeviceType(0x0100)},
+	}})
+	subject := acl.SubjectDescriptor{FabricIndex: 1, AuthMode: acl.AuthModeCASE, Subject: 0x1000}
+	if got := checker.Check(subject, acl.NewRequestPath(0x0006, 1, acl.RequestTypeCommandInvoke), acl.PrivilegeOperate); got != acl.ResultAllowed {
+		t.Errorf("Check(endpoint 1) = %v, want Allowed", got)
+	}
+	if got := checker.Check(subject, acl.NewRequestPath(0x0006, 0, acl.RequestTypeCommandInvoke), acl.PrivilegeOperate); got != acl.ResultDenied {
+		t.Errorf("Check(endpoint 0) = %v, want Denied", got)
+	}
+}
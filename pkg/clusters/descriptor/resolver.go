@@ -0,0 +1,40 @@
+package descriptor
+
+import (
+	"github.com/backkem/matter/pkg/datamodel"
+)
+
+// DeviceTypeResolver resolves ACL device type targets against the
+// DeviceTypeList each endpoint's Descriptor cluster reports.
+//
+// It implements acl.DeviceTypeResolver, so an ACL entry targeting a device
+// type grants access to every endpoint of that type. The node is queried on
+// each check, so endpoints added or removed later are picked up.
+//
+// Spec: Section 9.10.5 (AccessControlTargetStruct)
+type DeviceTypeResolver struct {
+	node datamodel.Node
+}
+
+// NewDeviceTypeResolver creates a resolver backed by the node's endpoints.
+func NewDeviceTypeResolver(node datamodel.Node) *DeviceTypeResolver {
+	return &DeviceTypeResolver{node: node}
+}
+
+// IsDeviceTypeOnEndpoint returns true if the endpoint lists the device type
+// in its DeviceTypeList.
+func (r *DeviceTypeResolver) IsDeviceTypeOnEndpoint(deviceType uint32, endpoint uint16) bool {
+	if r.node == nil {
+		return false
+	}
+	ep := r.node.GetEndpoint(datamodel.EndpointID(endpoint))
+	if ep == nil {
+		return false
+	}
+	for _, dt := range ep.GetDeviceTypes() {
+		if uint32(dt.DeviceTypeID) == deviceType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,612 @@
+// Package deviceenergymanagement implements the Device Energy Management
+// Cluster (0x0098).
+//
+// The cluster lets an energy management system steer an Energy Smart
+// Appliance (ESA): with the Power Adjustment feature (PA) a client asks
+// the appliance to run at a different power for a while, and with the
+// Pausable feature (PAU) it asks the appliance to pause. The user can opt
+// out of local or grid optimization at any time, which ends the adjustment.
+//
+// Adjustments end on their own when the requested duration expires, or
+// early when cancelled, opted out of, or when the appliance goes Offline
+// or reports a Fault. Each start and end emits an event; the end event
+// carries the energy the Delegate reports as used during the adjustment.
+//
+// The forecast features (PFR, SFR, STA, FA, CON) are not supported.
+//
+// Spec Reference: Section 9.2
+//
+// C++ Reference: src/app/clusters/device-energy-management-server/device-energy-management-server.cpp
+package deviceenergymanagement
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x0098
+	ClusterRevision uint16              = 4
+)
+
+// Attribute IDs (Spec 9.2.8).
+const (
+	AttrESAType                   datamodel.AttributeID = 0x0000
+	AttrESACanGenerate            datamodel.AttributeID = 0x0001
+	AttrESAState                  datamodel.AttributeID = 0x0002
+	AttrAbsMinPower               datamodel.AttributeID = 0x0003
+	AttrAbsMaxPower               datamodel.AttributeID = 0x0004
+	AttrPowerAdjustmentCapability datamodel.AttributeID = 0x0005
+	AttrForecast                  datamodel.AttributeID = 0x0006
+	AttrOptOutState               datamodel.AttributeID = 0x0007
+)
+
+// Command IDs (Spec 9.2.9).
+const (
+	CmdPowerAdjustRequest       datamodel.CommandID = 0x00
+	CmdCancelPowerAdjustRequest datamodel.CommandID = 0x01
+	CmdPauseRequest             datamodel.CommandID = 0x03
+	CmdResumeRequest            datamodel.CommandID = 0x04
+)
+
+// Event IDs (Spec 9.2.10).
+const (
+	EventPowerAdjustStart datamodel.EventID = 0x00
+	EventPowerAdjustEnd   datamodel.EventID = 0x01
+	EventPaused           datamodel.EventID = 0x02
+	EventResumed          datamodel.EventID = 0x03
+)
+
+// Feature bits (Spec 9.2.4).
+type Feature uint32
+
+const (
+	// FeaturePowerAdjustment allows temporary power adjustment (PA).
+	FeaturePowerAdjustment Feature = 1 << 0
+
+	// FeaturePowerForecastReporting reports a power forecast (PFR).
+	FeaturePowerForecastReporting Feature = 1 << 1
+
+	// FeatureStateForecastReporting reports a state forecast (SFR).
+	FeatureStateForecastReporting Feature = 1 << 2
+
+	// FeatureStartTimeAdjustment allows shifting the start time (STA).
+	FeatureStartTimeAdjustment Feature = 1 << 3
+
+	// FeaturePausable allows pausing the appliance (PAU).
+	FeaturePausable Feature = 1 << 4
+
+	// FeatureForecastAdjustment allows modifying the forecast (FA).
+	FeatureForecastAdjustment Feature = 1 << 5
+
+	// FeatureConstraintBasedAdjustment allows constraint based forecasts (CON).
+	FeatureConstraintBasedAdjustment Feature = 1 << 6
+)
+
+// supportedFeatures are the features this implementation handles.
+const supportedFeatures = FeaturePowerAdjustment | FeaturePausable
+
+// ESAType is the ESATypeEnum (Spec 9.2.7.7).
+type ESAType uint8
+
+const (
+	ESATypeEVSE                ESAType = 0x00
+	ESATypeSpaceHeating        ESAType = 0x01
+	ESATypeWaterHeating        ESAType = 0x02
+	ESATypeSpaceCooling        ESAType = 0x03
+	ESATypeSpaceHeatingCooling ESAType = 0x04
+	ESATypeBatteryStorage      ESAType = 0x05
+	ESATypeSolarPV             ESAType = 0x06
+	ESATypeFridgeFreezer       ESAType = 0x07
+	ESATypeWashingMachine      ESAType = 0x08
+	ESATypeDishwasher          ESAType = 0x09
+	ESATypeCooking             ESAType = 0x0A
+	ESATypeHomeWaterPump       ESAType = 0x0B
+	ESATypeIrrigationWaterPump ESAType = 0x0C
+	ESATypePoolPump            ESAType = 0x0D
+	ESATypeOther               ESAType = 0xFF
+)
+
+// ESAState is the ESAStateEnum (Spec 9.2.7.8).
+type ESAState uint8
+
+const (
+	ESAStateOffline           ESAState = 0x00
+	ESAStateOnline            ESAState = 0x01
+	ESAStateFault             ESAState = 0x02
+	ESAStatePowerAdjustActive ESAState = 0x03
+	ESAStatePaused            ESAState = 0x04
+)
+
+// OptOutState is the OptOutStateEnum (Spec 9.2.7.6).
+type OptOutState uint8
+
+const (
+	OptOutStateNoOptOut    OptOutState = 0x00
+	OptOutStateLocalOptOut OptOutState = 0x01
+	OptOutStateGridOptOut  OptOutState = 0x02
+	OptOutStateOptOut      OptOutState = 0x03
+)
+
+// AdjustmentCause is the AdjustmentCauseEnum (Spec 9.2.7.4).
+type AdjustmentCause uint8
+
+const (
+	AdjustmentCauseLocalOptimization AdjustmentCause = 0x00
+	AdjustmentCauseGridOptimization  AdjustmentCause = 0x01
+)
+
+// Cause is the CauseEnum reported when an adjustment ends (Spec 9.2.7.5).
+type Cause uint8
+
+const (
+	CauseNormalCompletion Cause = 0x00
+	CauseOffline          Cause = 0x01
+	CauseFault            Cause = 0x02
+	CauseUserOptOut       Cause = 0x03
+	CauseCancelled        Cause = 0x04
+)
+
+// PowerAdjustReason is the PowerAdjustReasonEnum (Spec 9.2.7.10).
+type PowerAdjustReason uint8
+
+const (
+	PowerAdjustReasonNoAdjustment      PowerAdjustReason = 0x00
+	PowerAdjustReasonLocalOptimization PowerAdjustReason = 0x01
+	PowerAdjustReasonGridOptimization  PowerAdjustReason = 0x02
+)
+
+// PowerAdjust is a PowerAdjustStruct (Spec 9.2.7.12): a range of power
+// (mW) the appliance can run at for a range of durations (s).
+type PowerAdjust struct {
+	MinPower    int64
+	MaxPower    int64
+	MinDuration uint32
+	MaxDuration uint32
+}
+
+// Errors returned by commands and configuration.
+var (
+	// ErrRequestRejected is returned when the appliance cannot honour a
+	// request in its current state or because the user opted out.
+	ErrRequestRejected = errors.New("deviceenergymanagement: request rejected")
+
+	ErrInvalidFeatures = errors.New("deviceenergymanagement: unsupported feature combination")
+	ErrInvalidPower    = errors.New("deviceenergymanagement: AbsMinPower exceeds AbsMaxPower")
+)
+
+// Delegate carries out adjustments on the appliance. Errors returned by
+// the Handle methods reject the request and are passed to the client.
+type Delegate interface {
+	// HandlePowerAdjust starts running at power (mW) for duration (s).
+	HandlePowerAdjust(power int64, duration uint32, cause AdjustmentCause) error
+
+	// HandlePowerAdjustEnd returns to normal operation and reports the
+	// energy (mWh) used during the adjustment.
+	HandlePowerAdjustEnd(cause Cause) (energyUse int64)
+
+	// HandlePause pauses the appliance for duration (s).
+	HandlePause(duration uint32, cause AdjustmentCause) error
+
+	// HandleResume resumes the appliance.
+	HandleResume(cause Cause)
+}
+
+// Config provides dependencies for the Device Energy Management cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to.
+	EndpointID datamodel.EndpointID
+
+	// FeatureMap indicates supported features. Only FeaturePowerAdjustment
+	// and FeaturePausable are supported.
+	FeatureMap Feature
+
+	// ESAType is the kind of appliance.
+	ESAType ESAType
+
+	// ESACanGenerate is true if the appliance can deliver power to the grid.
+	ESACanGenerate bool
+
+	// InitialState is the ESAState at startup. Defaults to Offline.
+	InitialState ESAState
+
+	// AbsMinPower and AbsMaxPower bound the appliance's power (mW).
+	AbsMinPower int64
+	AbsMaxPower int64
+
+	// PowerAdjustments are the adjustments offered in
+	// PowerAdjustmentCapability (PA). Nil reports the capability as null.
+	PowerAdjustments []PowerAdjust
+
+	// MinPauseDuration and MaxPauseDuration bound PauseRequest (PAU), in
+	// seconds. MaxPauseDuration defaults to 24 hours.
+	MinPauseDuration uint32
+	MaxPauseDuration uint32
+
+	// Delegate carries out requests (optional).
+	Delegate Delegate
+
+	// EventPublisher for PowerAdjust and Pause events.
+	// Optional - if nil, events are not emitted.
+	EventPublisher datamodel.EventPublisher
+
+	// OnStateChange is called when ESAState changes (optional).
+	OnStateChange func(endpoint datamodel.EndpointID, state ESAState)
+}
+
+// Cluster implements the Device Energy Management cluster (0x0098).
+type Cluster struct {
+	*datamodel.ClusterBase
+	*datamodel.EventSource
+	config Config
+
+	// cmdMu serializes requests so the delegate sees a stable state.
+	cmdMu sync.Mutex
+
+	// Mutable state (protected by mutex)
+	mu          sync.Mutex
+	state       ESAState
+	optOut      OptOutState
+	adjustments []PowerAdjust
+	reason      PowerAdjustReason
+
+	// Active power adjustment
+	adjusting   bool
+	adjustCause AdjustmentCause
+	adjustStart time.Time
+	adjustTimer *time.Timer
+	adjustGen   uint64 // invalidates stale timers
+
+	// Active pause
+	pauseCause AdjustmentCause
+	pauseTimer *time.Timer
+	pauseGen   uint64
+
+	// now returns the current time (for testing).
+	now func() time.Time
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Device Energy Management cluster. It returns an error
+// if the configuration requests unsupported features.
+func New(cfg Config) (*Cluster, error) {
+	if cfg.FeatureMap&^supportedFeatures != 0 {
+		return nil, ErrInvalidFeatures
+	}
+	if cfg.AbsMinPower > cfg.AbsMaxPower {
+		return nil, ErrInvalidPower
+	}
+	if cfg.MaxPauseDuration == 0 {
+		cfg.MaxPauseDuration = 24 * 60 * 60
+	}
+	switch cfg.InitialState {
+	case ESAStateOffline, ESAStateOnline, ESAStateFault:
+	default:
+		cfg.InitialState = ESAStateOffline
+	}
+
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		EventSource: datamodel.NewEventSource(),
+		config:      cfg,
+		state:       cfg.InitialState,
+		adjustments: cfg.PowerAdjustments,
+		now:         time.Now,
+	}
+
+	c.ClusterBase.SetFeatureMap(uint32(cfg.FeatureMap))
+
+	// Bind event source
+	if cfg.EventPublisher != nil {
+		c.EventSource.Bind(cfg.EndpointID, ClusterID, cfg.EventPublisher)
+		var events []datamodel.EventEntry
+		if c.hasFeature(FeaturePowerAdjustment) {
+			events = append(events,
+				datamodel.NewEventEntry(EventPowerAdjustStart, datamodel.EventPriorityInfo, datamodel.PrivilegeView, false),
+				datamodel.NewEventEntry(EventPowerAdjustEnd, datamodel.EventPriorityInfo, datamodel.PrivilegeView, false),
+			)
+		}
+		if c.hasFeature(FeaturePausable) {
+			events = append(events,
+				datamodel.NewEventEntry(EventPaused, datamodel.EventPriorityInfo, datamodel.PrivilegeView, false),
+				datamodel.NewEventEntry(EventResumed, datamodel.EventPriorityInfo, datamodel.PrivilegeView, false),
+			)
+		}
+		c.EventSource.RegisterEvents(events)
+	}
+
+	c.attrList = c.buildAttributeList()
+
+	return c, nil
+}
+
+// hasFeature returns true if the feature bit is set.
+func (c *Cluster) hasFeature(f Feature) bool {
+	return c.config.FeatureMap&f != 0
+}
+
+// buildAttributeList constructs the list of supported attributes.
+func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+
+	attrs := []datamodel.AttributeEntry{
+		datamodel.NewReadOnlyAttribute(AttrESAType, datamodel.AttrQualityFixed, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrESACanGenerate, datamodel.AttrQualityFixed, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrESAState, 0, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrAbsMinPower, 0, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrAbsMaxPower, 0, viewPriv),
+	}
+	if c.hasFeature(FeaturePowerAdjustment) {
+		attrs = append(attrs, datamodel.NewReadOnlyAttribute(AttrPowerAdjustmentCapability, datamodel.AttrQualityNullable, viewPriv))
+	}
+	if c.config.FeatureMap != 0 {
+		attrs = append(attrs, datamodel.NewReadOnlyAttribute(AttrOptOutState, 0, viewPriv))
+	}
+
+	return datamodel.MergeAttributeLists(attrs)
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	operatePriv := datamodel.PrivilegeOperate
+	var cmds []datamodel.CommandEntry
+	if c.hasFeature(FeaturePowerAdjustment) {
+		cmds = append(cmds,
+			datamodel.NewCommandEntry(CmdPowerAdjustRequest, 0, operatePriv),
+			datamodel.NewCommandEntry(CmdCancelPowerAdjustRequest, 0, operatePriv),
+		)
+	}
+	if c.hasFeature(FeaturePausable) {
+		cmds = append(cmds,
+			datamodel.NewCommandEntry(CmdPauseRequest, 0, operatePriv),
+			datamodel.NewCommandEntry(CmdResumeRequest, 0, operatePriv),
+		)
+	}
+	return cmds
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return nil
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch req.Path.Attribute {
+	case AttrESAType:
+		return w.PutUint(tlv.Anonymous(), uint64(c.config.ESAType))
+	case AttrESACanGenerate:
+		return w.PutBool(tlv.Anonymous(), c.config.ESACanGenerate)
+	case AttrESAState:
+		return w.PutUint(tlv.Anonymous(), uint64(c.state))
+	case AttrAbsMinPower:
+		return w.PutInt(tlv.Anonymous(), c.config.AbsMinPower)
+	case AttrAbsMaxPower:
+		return w.PutInt(tlv.Anonymous(), c.config.AbsMaxPower)
+	case AttrPowerAdjustmentCapability:
+		if !c.hasFeature(FeaturePowerAdjustment) {
+			return datamodel.ErrUnsupportedAttribute
+		}
+		return c.writeCapability(w)
+	case AttrOptOutState:
+		if c.config.FeatureMap == 0 {
+			return datamodel.ErrUnsupportedAttribute
+		}
+		return w.PutUint(tlv.Anonymous(), uint64(c.optOut))
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// writeCapability writes the nullable PowerAdjustCapabilityStruct.
+func (c *Cluster) writeCapability(w *tlv.Writer) error {
+	if c.adjustments == nil {
+		return w.PutNull(tlv.Anonymous())
+	}
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	if err := w.StartArray(tlv.ContextTag(0)); err != nil {
+		return err
+	}
+	for _, a := range c.adjustments {
+		if err := w.StartStructure(tlv.Anonymous()); err != nil {
+			return err
+		}
+		if err := w.PutInt(tlv.ContextTag(0), a.MinPower); err != nil {
+			return err
+		}
+		if err := w.PutInt(tlv.ContextTag(1), a.MaxPower); err != nil {
+			return err
+		}
+		if err := w.PutUint(tlv.ContextTag(2), uint64(a.MinDuration)); err != nil {
+			return err
+		}
+		if err := w.PutUint(tlv.ContextTag(3), uint64(a.MaxDuration)); err != nil {
+			return err
+		}
+		if err := w.EndContainer(); err != nil {
+			return err
+		}
+	}
+	if err := w.EndContainer(); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(1), uint64(c.reason)); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	// All Device Energy Management attributes are read-only
+	return datamodel.ErrUnsupportedWrite
+}
+
+// ESAState returns the current ESAState.
+func (c *Cluster) ESAState() ESAState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+// OptOutState returns the current OptOutState.
+func (c *Cluster) OptOutState() OptOutState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.optOut
+}
+
+// SetESAState reports the appliance going Online, Offline or into Fault.
+// Going Offline or Fault ends an active adjustment or pause. The
+// PowerAdjustActive and Paused states are managed by the cluster and
+// cannot be set directly.
+func (c *Cluster) SetESAState(state ESAState) error {
+	var cause Cause
+	switch state {
+	case ESAStateOnline:
+	case ESAStateOffline:
+		cause = CauseOffline
+	case ESAStateFault:
+		cause = CauseFault
+	default:
+		return datamodel.ErrConstraintError
+	}
+
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	if state != ESAStateOnline {
+		c.endAdjustments(cause)
+	}
+
+	c.mu.Lock()
+	if c.state == ESAStatePowerAdjustActive || c.state == ESAStatePaused {
+		// Already online with an adjustment in progress
+		c.mu.Unlock()
+		return nil
+	}
+	changed := c.setStateLocked(state)
+	c.mu.Unlock()
+
+	if changed {
+		c.notifyStateChange(state)
+	}
+	return nil
+}
+
+// SetOptOutState records the user's opt-out choice. Opting out of the
+// cause of an active adjustment or pause ends it with UserOptOut.
+func (c *Cluster) SetOptOutState(state OptOutState) error {
+	if state > OptOutStateOptOut {
+		return datamodel.ErrConstraintError
+	}
+
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	c.mu.Lock()
+	if c.optOut != state {
+		c.optOut = state
+		c.IncrementDataVersion()
+	}
+	endAdjust := c.adjusting && optedOut(state, c.adjustCause)
+	endPause := c.state == ESAStatePaused && optedOut(state, c.pauseCause)
+	c.mu.Unlock()
+
+	if endPause {
+		c.resume(CauseUserOptOut)
+	}
+	if endAdjust {
+		c.endPowerAdjust(CauseUserOptOut)
+	}
+	return nil
+}
+
+// SetPowerAdjustments replaces the adjustments offered in
+// PowerAdjustmentCapability. Nil reports the capability as null.
+func (c *Cluster) SetPowerAdjustments(adjustments []PowerAdjust) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.adjustments = adjustments
+	c.IncrementDataVersion()
+}
+
+// optedOut returns true if state forbids adjustments for cause.
+func optedOut(state OptOutState, cause AdjustmentCause) bool {
+	switch state {
+	case OptOutStateOptOut:
+		return true
+	case OptOutStateLocalOptOut:
+		return cause == AdjustmentCauseLocalOptimization
+	case OptOutStateGridOptOut:
+		return cause == AdjustmentCauseGridOptimization
+	default:
+		return false
+	}
+}
+
+// setStateLocked updates ESAState. Caller must hold c.mu.
+func (c *Cluster) setStateLocked(state ESAState) bool {
+	if c.state == state {
+		return false
+	}
+	c.state = state
+	c.IncrementDataVersion()
+	return true
+}
+
+// notifyStateChange calls the OnStateChange callback.
+func (c *Cluster) notifyStateChange(state ESAState) {
+	if c.config.OnStateChange != nil {
+		c.config.OnStateChange(c.config.EndpointID, state)
+	}
+}
+
+// endAdjustments ends any active pause and power adjustment with cause.
+// Caller must hold c.cmdMu.
+func (c *Cluster) endAdjustments(cause Cause) {
+	c.mu.Lock()
+	paused := c.state == ESAStatePaused
+	adjusting := c.adjusting
+	c.mu.Unlock()
+
+	if paused {
+		c.resume(cause)
+	}
+	if adjusting {
+		c.endPowerAdjust(cause)
+	}
+}
+
+// emit emits an event if a publisher is bound.
+func (c *Cluster) emit(eventID datamodel.EventID, payload interface{}) error {
+	if !c.EventSource.IsBound() {
+		return nil // No publisher, silently skip
+	}
+	_, err := c.EventSource.Emit(eventID, datamodel.EventPriorityInfo, payload)
+	return err
+}
@@ -0,0 +1,298 @@
+package deviceenergymanagement
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// mockEventPublisher implements EventPublisher for testing.
+type mockEventPublisher struct {
+	mu     sync.Mutex
+	events []publishedEvent
+}
+
+type publishedEvent struct {
+	id   datamodel.EventID
+	data interface{}
+}
+
+func (m *mockEventPublisher) PublishEvent(
+	endpoint datamodel.EndpointID,
+	cluster datamodel.ClusterID,
+	eventID datamodel.EventID,
+	priority datamodel.EventPriority,
+	data interface{},
+	fabricIndex uint8,
+) (datamodel.EventNumber, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, publishedEvent{id: eventID, data: data})
+	return datamodel.EventNumber(len(m.events)), nil
+}
+
+func (m *mockEventPublisher) ids() []datamodel.EventID {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := make([]datamodel.EventID, len(m.events))
+	for i, e := range m.events {
+		ids[i] = e.id
+	}
+	return ids
+}
+
+// testDelegate records calls and optionally rejects requests.
+type testDelegate struct {
+	reject error
+	energy int64
+	calls  []string
+}
+
+func (d *testDelegate) HandlePowerAdjust(power int64, duration uint32, cause AdjustmentCause) error {
+	d.calls = append(d.calls, "adjust")
+	return d.reject
+}
+
+func (d *testDelegate) HandlePowerAdjustEnd(cause Cause) int64 {
+	d.calls = append(d.calls, "adjust-end")
+	return d.energy
+}
+
+func (d *testDelegate) HandlePause(duration uint32, cause AdjustmentCause) error {
+	d.calls = append(d.calls, "pause")
+	return d.reject
+}
+
+func (d *testDelegate) HandleResume(cause Cause) {
+	d.calls = append(d.calls, "resume")
+}
+
+func newESA(t *testing.T, d Delegate, pub datamodel.EventPublisher) *Cluster {
+	t.Helper()
+	c, err := New(Config{
+		EndpointID:   1,
+		FeatureMap:   FeaturePowerAdjustment | FeaturePausable,
+		ESAType:      ESATypeWaterHeating,
+		InitialState: ESAStateOnline,
+		AbsMinPower:  0,
+		AbsMaxPower:  3_000_000,
+		PowerAdjustments: []PowerAdjust{
+			{MinPower: 0, MaxPower: 1_500_000, MinDuration: 60, MaxDuration: 3600},
+		},
+		MinPauseDuration: 60,
+		MaxPauseDuration: 7200,
+		Delegate:         d,
+		EventPublisher:   pub,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+func encodeFields(t *testing.T, fields ...int64) *tlv.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	w.StartStructure(tlv.Anonymous())
+	for i, v := range fields {
+		w.PutInt(tlv.ContextTag(uint8(i)), v)
+	}
+	w.EndContainer()
+	return tlv.NewReader(bytes.NewReader(buf.Bytes()))
+}
+
+func invoke(c *Cluster, cmd datamodel.CommandID, r *tlv.Reader) error {
+	req := datamodel.InvokeRequest{
+		Path: datamodel.ConcreteCommandPath{Endpoint: 1, Cluster: ClusterID, Command: cmd},
+	}
+	_, err := c.InvokeCommand(context.Background(), req, r)
+	return err
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(Config{FeatureMap: FeatureStartTimeAdjustment}); !errors.Is(err, ErrInvalidFeatures) {
+		t.Errorf("STA error = %v, want ErrInvalidFeatures", err)
+	}
+	if _, err := New(Config{AbsMinPower: 10, AbsMaxPower: 5}); !errors.Is(err, ErrInvalidPower) {
+		t.Errorf("power error = %v, want ErrInvalidPower", err)
+	}
+	c, err := New(Config{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if c.ESAState() != ESAStateOffline {
+		t.Errorf("ESAState = %d, want Offline", c.ESAState())
+	}
+	if len(c.AcceptedCommandList()) != 0 {
+		t.Error("no commands expected without features")
+	}
+}
+
+func TestPowerAdjust(t *testing.T) {
+	d := &testDelegate{energy: 250}
+	pub := &mockEventPublisher{}
+	c := newESA(t, d, pub)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	c.now = func() time.Time { return now }
+
+	// Out of the offered range
+	if err := invoke(c, CmdPowerAdjustRequest, encodeFields(t, 2_000_000, 600, 0)); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("power out of range error = %v, want ErrConstraintError", err)
+	}
+	if err := invoke(c, CmdPowerAdjustRequest, encodeFields(t, 1_000_000, 10, 0)); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("duration out of range error = %v, want ErrConstraintError", err)
+	}
+	if err := invoke(c, CmdCancelPowerAdjustRequest, nil); !errors.Is(err, datamodel.ErrInvalidInState) {
+		t.Errorf("cancel while idle error = %v, want ErrInvalidInState", err)
+	}
+
+	if err := invoke(c, CmdPowerAdjustRequest, encodeFields(t, 1_000_000, 600, int64(AdjustmentCauseGridOptimization))); err != nil {
+		t.Fatalf("PowerAdjustRequest error = %v", err)
+	}
+	if c.ESAState() != ESAStatePowerAdjustActive {
+		t.Errorf("ESAState = %d, want PowerAdjustActive", c.ESAState())
+	}
+
+	now = start.Add(90 * time.Second)
+	if err := invoke(c, CmdCancelPowerAdjustRequest, nil); err != nil {
+		t.Fatalf("CancelPowerAdjustRequest error = %v", err)
+	}
+	if c.ESAState() != ESAStateOnline {
+		t.Errorf("ESAState = %d, want Online", c.ESAState())
+	}
+
+	ids := pub.ids()
+	if len(ids) != 2 || ids[0] != EventPowerAdjustStart || ids[1] != EventPowerAdjustEnd {
+		t.Fatalf("events = %v, want [start end]", ids)
+	}
+	end := pub.events[1].data.(PowerAdjustEndEvent)
+	if end.Cause != CauseCancelled || end.Duration != 90 || end.EnergyUse != 250 {
+		t.Errorf("PowerAdjustEnd = %+v", end)
+	}
+}
+
+func TestPowerAdjust_Expiry(t *testing.T) {
+	pub := &mockEventPublisher{}
+	c := newESA(t, nil, pub)
+
+	if err := c.PowerAdjustRequest(500_000, 60, AdjustmentCauseLocalOptimization); err != nil {
+		t.Fatalf("PowerAdjustRequest error = %v", err)
+	}
+
+	// A stale timer is ignored
+	c.expirePowerAdjust(c.adjustGen - 1)
+	if c.ESAState() != ESAStatePowerAdjustActive {
+		t.Fatal("stale timer ended the adjustment")
+	}
+
+	c.expirePowerAdjust(c.adjustGen)
+	if c.ESAState() != ESAStateOnline {
+		t.Errorf("ESAState = %d, want Online", c.ESAState())
+	}
+	end := pub.events[len(pub.events)-1].data.(PowerAdjustEndEvent)
+	if end.Cause != CauseNormalCompletion {
+		t.Errorf("Cause = %d, want NormalCompletion", end.Cause)
+	}
+}
+
+func TestOptOut(t *testing.T) {
+	pub := &mockEventPublisher{}
+	c := newESA(t, nil, pub)
+
+	c.SetOptOutState(OptOutStateGridOptOut)
+	if err := c.PowerAdjustRequest(500_000, 60, AdjustmentCauseGridOptimization); !errors.Is(err, ErrRequestRejected) {
+		t.Errorf("grid request error = %v, want ErrRequestRejected", err)
+	}
+	if err := c.PowerAdjustRequest(500_000, 60, AdjustmentCauseLocalOptimization); err != nil {
+		t.Fatalf("local request error = %v", err)
+	}
+
+	// Opting out of local optimization ends the running adjustment
+	c.SetOptOutState(OptOutStateOptOut)
+	if c.ESAState() != ESAStateOnline {
+		t.Errorf("ESAState = %d, want Online", c.ESAState())
+	}
+	end := pub.events[len(pub.events)-1].data.(PowerAdjustEndEvent)
+	if end.Cause != CauseUserOptOut {
+		t.Errorf("Cause = %d, want UserOptOut", end.Cause)
+	}
+}
+
+func TestPauseResume(t *testing.T) {
+	d := &testDelegate{}
+	pub := &mockEventPublisher{}
+	c := newESA(t, d, pub)
+
+	if err := invoke(c, CmdResumeRequest, nil); !errors.Is(err, datamodel.ErrInvalidInState) {
+		t.Errorf("resume while running error = %v, want ErrInvalidInState", err)
+	}
+	if err := invoke(c, CmdPauseRequest, encodeFields(t, 10, 0)); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("short pause error = %v, want ErrConstraintError", err)
+	}
+
+	c.PowerAdjustRequest(500_000, 600, AdjustmentCauseLocalOptimization)
+	if err := invoke(c, CmdPauseRequest, encodeFields(t, 300, 0)); err != nil {
+		t.Fatalf("PauseRequest error = %v", err)
+	}
+	if c.ESAState() != ESAStatePaused {
+		t.Errorf("ESAState = %d, want Paused", c.ESAState())
+	}
+
+	// Resuming returns to the running adjustment
+	if err := invoke(c, CmdResumeRequest, nil); err != nil {
+		t.Fatalf("ResumeRequest error = %v", err)
+	}
+	if c.ESAState() != ESAStatePowerAdjustActive {
+		t.Errorf("ESAState = %d, want PowerAdjustActive", c.ESAState())
+	}
+	ev := pub.events[len(pub.events)-1]
+	if ev.id != EventResumed || ev.data.(ResumedEvent).Cause != CauseCancelled {
+		t.Errorf("last event = %+v, want Resumed(Cancelled)", ev)
+	}
+
+	// A vetoed pause leaves the state alone
+	d.reject = ErrRequestRejected
+	if err := c.PauseRequest(300, AdjustmentCauseLocalOptimization); !errors.Is(err, ErrRequestRejected) {
+		t.Errorf("vetoed pause error = %v, want ErrRequestRejected", err)
+	}
+	if c.ESAState() != ESAStatePowerAdjustActive {
+		t.Errorf("ESAState = %d, want PowerAdjustActive", c.ESAState())
+	}
+}
+
+func TestSetESAState(t *testing.T) {
+	pub := &mockEventPublisher{}
+	c := newESA(t, nil, pub)
+
+	c.PowerAdjustRequest(500_000, 600, AdjustmentCauseLocalOptimization)
+	c.PauseRequest(300, AdjustmentCauseLocalOptimization)
+
+	if err := c.SetESAState(ESAStateFault); err != nil {
+		t.Fatalf("SetESAState() error = %v", err)
+	}
+	if c.ESAState() != ESAStateFault {
+		t.Errorf("ESAState = %d, want Fault", c.ESAState())
+	}
+	ids := pub.ids()
+	if ids[len(ids)-2] != EventResumed || ids[len(ids)-1] != EventPowerAdjustEnd {
+		t.Errorf("events = %v, want Resumed then PowerAdjustEnd", ids)
+	}
+	if end := pub.events[len(pub.events)-1].data.(PowerAdjustEndEvent); end.Cause != CauseFault {
+		t.Errorf("Cause = %d, want Fault", end.Cause)
+	}
+
+	if err := c.PowerAdjustRequest(500_000, 600, AdjustmentCauseLocalOptimization); !errors.Is(err, ErrRequestRejected) {
+		t.Errorf("request in Fault error = %v, want ErrRequestRejected", err)
+	}
+	if err := c.SetESAState(ESAStatePaused); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("SetESAState(Paused) error = %v, want ErrConstraintError", err)
+	}
+}
@@ -0,0 +1,343 @@
+package deviceenergymanagement
+
+import (
+	"context"
+	"time"
+
+	"github.com/backkem/matter/pkg/clusters"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	var err error
+	switch req.Path.Command {
+	case CmdPowerAdjustRequest, CmdCancelPowerAdjustRequest:
+		if !c.hasFeature(FeaturePowerAdjustment) {
+			return nil, datamodel.ErrUnsupportedCommand
+		}
+		if req.Path.Command == CmdCancelPowerAdjustRequest {
+			err = c.CancelPowerAdjustRequest()
+			break
+		}
+		var fields map[uint8]int64
+		if fields, err = decodeFields(r); err != nil {
+			return nil, err
+		}
+		power, ok1 := fields[0]
+		duration, ok2 := fields[1]
+		cause, ok3 := fields[2]
+		if !ok1 || !ok2 || !ok3 || duration < 0 || duration > 0xFFFFFFFF {
+			return nil, datamodel.ErrInvalidCommand
+		}
+		err = c.PowerAdjustRequest(power, uint32(duration), AdjustmentCause(cause))
+
+	case CmdPauseRequest, CmdResumeRequest:
+		if !c.hasFeature(FeaturePausable) {
+			return nil, datamodel.ErrUnsupportedCommand
+		}
+		if req.Path.Command == CmdResumeRequest {
+			err = c.ResumeRequest()
+			break
+		}
+		var fields map[uint8]int64
+		if fields, err = decodeFields(r); err != nil {
+			return nil, err
+		}
+		duration, ok1 := fields[0]
+		cause, ok2 := fields[1]
+		if !ok1 || !ok2 || duration < 0 || duration > 0xFFFFFFFF {
+			return nil, datamodel.ErrInvalidCommand
+		}
+		err = c.PauseRequest(uint32(duration), AdjustmentCause(cause))
+
+	default:
+		return nil, datamodel.ErrUnsupportedCommand
+	}
+	if err != nil {
+		return nil, err
+	}
+	return clusters.EmptyResponse(), nil
+}
+
+// decodeFields reads the integer context-tagged fields of a request.
+func decodeFields(r *tlv.Reader) (map[uint8]int64, error) {
+	if err := r.Next(); err != nil {
+		return nil, datamodel.ErrInvalidCommand
+	}
+	if err := r.EnterContainer(); err != nil {
+		return nil, datamodel.ErrInvalidCommand
+	}
+
+	fields := make(map[uint8]int64)
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		tag := r.Tag()
+		if !tag.IsContext() {
+			continue
+		}
+		var v int64
+		if u, err := r.Uint(); err == nil {
+			if u > 1<<63-1 {
+				return nil, datamodel.ErrInvalidCommand
+			}
+			v = int64(u)
+		} else if i, err := r.Int(); err == nil {
+			v = i
+		} else {
+			return nil, datamodel.ErrInvalidCommand
+		}
+		fields[uint8(tag.TagNumber())] = v
+	}
+	return fields, nil
+}
+
+// acceptingLocked returns ErrRequestRejected if the appliance is Offline
+// or in Fault, or the user opted out of cause. Caller must hold c.mu.
+func (c *Cluster) acceptingLocked(cause AdjustmentCause) error {
+	if cause > AdjustmentCauseGridOptimization {
+		return datamodel.ErrConstraintError
+	}
+	if c.state == ESAStateOffline || c.state == ESAStateFault {
+		return ErrRequestRejected
+	}
+	if optedOut(c.optOut, cause) {
+		return ErrRequestRejected
+	}
+	return nil
+}
+
+// PowerAdjustRequest handles the PowerAdjustRequest command. The power
+// and duration must fit one of the offered adjustments. A request while
+// an adjustment is active replaces it.
+//
+// Spec: Section 9.2.9.1
+func (c *Cluster) PowerAdjustRequest(power int64, duration uint32, cause AdjustmentCause) error {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	c.mu.Lock()
+	if err := c.acceptingLocked(cause); err != nil {
+		c.mu.Unlock()
+		return err
+	}
+	fits := false
+	for _, a := range c.adjustments {
+		if power >= a.MinPower && power <= a.MaxPower &&
+			duration >= a.MinDuration && duration <= a.MaxDuration {
+			fits = true
+			break
+		}
+	}
+	c.mu.Unlock()
+	if !fits {
+		return datamodel.ErrConstraintError
+	}
+
+	if d := c.config.Delegate; d != nil {
+		if err := d.HandlePowerAdjust(power, duration, cause); err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	restarted := c.adjusting
+	if c.adjustTimer != nil {
+		c.adjustTimer.Stop()
+	}
+	c.adjustGen++
+	gen := c.adjustGen
+	c.adjustTimer = time.AfterFunc(time.Duration(duration)*time.Second, func() {
+		c.expirePowerAdjust(gen)
+	})
+	c.adjusting = true
+	c.adjustCause = cause
+	if !restarted {
+		c.adjustStart = c.now()
+	}
+	c.reason = PowerAdjustReasonLocalOptimization
+	if cause == AdjustmentCauseGridOptimization {
+		c.reason = PowerAdjustReasonGridOptimization
+	}
+	changed := false
+	if c.state != ESAStatePaused {
+		changed = c.setStateLocked(ESAStatePowerAdjustActive)
+	}
+	c.IncrementDataVersion()
+	c.mu.Unlock()
+
+	if changed {
+		c.notifyStateChange(ESAStatePowerAdjustActive)
+	}
+	if restarted {
+		return nil
+	}
+	return c.emit(EventPowerAdjustStart, PowerAdjustStartEvent{})
+}
+
+// CancelPowerAdjustRequest handles the CancelPowerAdjustRequest command.
+// Returns ErrInvalidInState if no adjustment is active.
+//
+// Spec: Section 9.2.9.2
+func (c *Cluster) CancelPowerAdjustRequest() error {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	c.mu.Lock()
+	adjusting := c.adjusting
+	c.mu.Unlock()
+	if !adjusting {
+		return datamodel.ErrInvalidInState
+	}
+	return c.endPowerAdjust(CauseCancelled)
+}
+
+// expirePowerAdjust ends the adjustment started as generation gen when its
+// duration elapses.
+func (c *Cluster) expirePowerAdjust(gen uint64) {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	c.mu.Lock()
+	current := c.adjusting && c.adjustGen == gen
+	c.mu.Unlock()
+	if current {
+		c.endPowerAdjust(CauseNormalCompletion)
+	}
+}
+
+// endPowerAdjust ends the active adjustment and emits PowerAdjustEnd.
+// Caller must hold c.cmdMu.
+func (c *Cluster) endPowerAdjust(cause Cause) error {
+	var energy int64
+	if d := c.config.Delegate; d != nil {
+		energy = d.HandlePowerAdjustEnd(cause)
+	}
+
+	c.mu.Lock()
+	if c.adjustTimer != nil {
+		c.adjustTimer.Stop()
+		c.adjustTimer = nil
+	}
+	c.adjustGen++
+	c.adjusting = false
+	elapsed := c.now().Sub(c.adjustStart)
+	c.reason = PowerAdjustReasonNoAdjustment
+	c.IncrementDataVersion()
+	changed := false
+	if c.state == ESAStatePowerAdjustActive {
+		changed = c.setStateLocked(ESAStateOnline)
+	}
+	c.mu.Unlock()
+
+	if changed {
+		c.notifyStateChange(ESAStateOnline)
+	}
+	return c.emit(EventPowerAdjustEnd, PowerAdjustEndEvent{
+		Cause:     cause,
+		Duration:  uint32(elapsed / time.Second),
+		EnergyUse: energy,
+	})
+}
+
+// PauseRequest handles the PauseRequest command. The duration must lie
+// within the configured pause limits.
+//
+// Spec: Section 9.2.9.4
+func (c *Cluster) PauseRequest(duration uint32, cause AdjustmentCause) error {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	c.mu.Lock()
+	err := c.acceptingLocked(cause)
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if duration < c.config.MinPauseDuration || duration > c.config.MaxPauseDuration {
+		return datamodel.ErrConstraintError
+	}
+
+	if d := c.config.Delegate; d != nil {
+		if err := d.HandlePause(duration, cause); err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	if c.pauseTimer != nil {
+		c.pauseTimer.Stop()
+	}
+	c.pauseGen++
+	gen := c.pauseGen
+	c.pauseTimer = time.AfterFunc(time.Duration(duration)*time.Second, func() {
+		c.expirePause(gen)
+	})
+	c.pauseCause = cause
+	changed := c.setStateLocked(ESAStatePaused)
+	c.mu.Unlock()
+
+	if !changed {
+		return nil // Pause extended
+	}
+	c.notifyStateChange(ESAStatePaused)
+	return c.emit(EventPaused, PausedEvent{})
+}
+
+// ResumeRequest handles the ResumeRequest command. Returns
+// ErrInvalidInState if the appliance is not paused.
+//
+// Spec: Section 9.2.9.5
+func (c *Cluster) ResumeRequest() error {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	if c.ESAState() != ESAStatePaused {
+		return datamodel.ErrInvalidInState
+	}
+	return c.resume(CauseCancelled)
+}
+
+// expirePause resumes the pause started as generation gen when its
+// duration elapses.
+func (c *Cluster) expirePause(gen uint64) {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	c.mu.Lock()
+	current := c.state == ESAStatePaused && c.pauseGen == gen
+	c.mu.Unlock()
+	if current {
+		c.resume(CauseNormalCompletion)
+	}
+}
+
+// resume ends the active pause and emits Resumed. The appliance returns
+// to PowerAdjustActive if an adjustment is still running, else Online.
+// Caller must hold c.cmdMu.
+func (c *Cluster) resume(cause Cause) error {
+	if d := c.config.Delegate; d != nil {
+		d.HandleResume(cause)
+	}
+
+	c.mu.Lock()
+	if c.pauseTimer != nil {
+		c.pauseTimer.Stop()
+		c.pauseTimer = nil
+	}
+	c.pauseGen++
+	next := ESAStateOnline
+	if c.adjusting {
+		next = ESAStatePowerAdjustActive
+	}
+	changed := c.setStateLocked(next)
+	c.mu.Unlock()
+
+	if changed {
+		c.notifyStateChange(next)
+	}
+	return c.emit(EventResumed, ResumedEvent{Cause: cause})
+}
@@ -0,0 +1,71 @@
+package deviceenergymanagement
+
+import (
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// PowerAdjustStartEvent is emitted when a power adjustment starts (Spec 9.2.10.1).
+// Priority: INFO, Conformance: PA
+type PowerAdjustStartEvent struct{}
+
+// MarshalTLV implements the TLVMarshaler interface.
+func (e PowerAdjustStartEvent) MarshalTLV(w *tlv.Writer) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// PowerAdjustEndEvent is emitted when a power adjustment ends (Spec 9.2.10.2).
+// Priority: INFO, Conformance: PA
+type PowerAdjustEndEvent struct {
+	Cause     Cause
+	Duration  uint32 // seconds the adjustment was active
+	EnergyUse int64  // mWh used during the adjustment
+}
+
+// MarshalTLV implements the TLVMarshaler interface.
+func (e PowerAdjustEndEvent) MarshalTLV(w *tlv.Writer) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(e.Cause)); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(1), uint64(e.Duration)); err != nil {
+		return err
+	}
+	if err := w.PutInt(tlv.ContextTag(2), e.EnergyUse); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// PausedEvent is emitted when the appliance pauses (Spec 9.2.10.3).
+// Priority: INFO, Conformance: PAU
+type PausedEvent struct{}
+
+// MarshalTLV implements the TLVMarshaler interface.
+func (e PausedEvent) MarshalTLV(w *tlv.Writer) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// ResumedEvent is emitted when the appliance resumes (Spec 9.2.10.4).
+// Priority: INFO, Conformance: PAU
+type ResumedEvent struct {
+	Cause Cause
+}
+
+// MarshalTLV implements the TLVMarshaler interface.
+func (e ResumedEvent) MarshalTLV(w *tlv.Writer) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(e.Cause)); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
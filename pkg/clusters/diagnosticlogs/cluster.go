@@ -0,0 +1,328 @@
+// Package diagnosticlogs implements the Diagnostic Logs Cluster (0x0032).
+//
+// The cluster lets an administrator retrieve logs from the node, e.g. to
+// debug a device in the field. Logs come from a Provider, per intent.
+// Transfer over BDX is not supported: the first 1024 bytes of the logs are
+// always returned in the response payload.
+//
+// Spec Reference: Section 11.11
+//
+// C++ Reference: src/app/clusters/diagnostic-logs-server
+package diagnosticlogs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x0032
+	ClusterRevision uint16              = 1
+)
+
+// Command IDs.
+const (
+	CmdRetrieveLogsRequest  datamodel.CommandID = 0x00
+	CmdRetrieveLogsResponse datamodel.CommandID = 0x01
+)
+
+// MaxLogContentSize is the largest log content returned in a
+// RetrieveLogsResponse.
+const MaxLogContentSize = 1024
+
+// Intent is the kind of logs requested (IntentEnum).
+type Intent uint8
+
+const (
+	// IntentEndUserSupport requests logs for end user support.
+	IntentEndUserSupport Intent = 0
+
+	// IntentNetworkDiag requests network diagnostics logs.
+	IntentNetworkDiag Intent = 1
+
+	// IntentCrashLogs requests crash logs.
+	IntentCrashLogs Intent = 2
+)
+
+// String returns the name of the intent.
+func (i Intent) String() string {
+	switch i {
+	case IntentEndUserSupport:
+		return "EndUserSupport"
+	case IntentNetworkDiag:
+		return "NetworkDiag"
+	case IntentCrashLogs:
+		return "CrashLogs"
+	default:
+		return "Unknown"
+	}
+}
+
+// TransferProtocol is how the logs are requested to be returned
+// (TransferProtocolEnum).
+type TransferProtocol uint8
+
+const (
+	// TransferProtocolResponsePayload returns the logs in the response.
+	TransferProtocolResponsePayload TransferProtocol = 0
+
+	// TransferProtocolBDX transfers the logs over BDX.
+	TransferProtocolBDX TransferProtocol = 1
+)
+
+// Status is the result of a RetrieveLogsRequest (StatusEnum).
+type Status uint8
+
+const (
+	// StatusSuccess indicates the logs were transferred.
+	StatusSuccess Status = 0
+
+	// StatusExhausted indicates all available logs were returned.
+	StatusExhausted Status = 1
+
+	// StatusNoLogs indicates no logs of the intent are available.
+	StatusNoLogs Status = 2
+
+	// StatusBusy indicates the request cannot be handled now.
+	StatusBusy Status = 3
+
+	// StatusDenied indicates the request was denied.
+	StatusDenied Status = 4
+)
+
+// String returns the name of the status.
+func (s Status) String() string {
+	switch s {
+	case StatusSuccess:
+		return "Success"
+	case StatusExhausted:
+		return "Exhausted"
+	case StatusNoLogs:
+		return "NoLogs"
+	case StatusBusy:
+		return "Busy"
+	case StatusDenied:
+		return "Denied"
+	default:
+		return "Unknown"
+	}
+}
+
+// Errors returned by New.
+var (
+	ErrNoProvider = errors.New("diagnosticlogs: provider is required")
+)
+
+// Provider supplies the node's logs.
+type Provider interface {
+	// Logs returns the logs of an intent, or nil if there are none.
+	// Logs longer than MaxLogContentSize are truncated.
+	Logs(intent Intent) []byte
+}
+
+// ProviderFunc adapts a function to a Provider.
+type ProviderFunc func(intent Intent) []byte
+
+// Logs implements Provider.
+func (f ProviderFunc) Logs(intent Intent) []byte {
+	return f(intent)
+}
+
+// Config provides dependencies for the Diagnostic Logs cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to (the root).
+	EndpointID datamodel.EndpointID
+
+	// Provider supplies the logs (required).
+	Provider Provider
+}
+
+// RetrieveLogsRequest is the RetrieveLogsRequest command.
+type RetrieveLogsRequest struct {
+	Intent                 Intent
+	RequestedProtocol      TransferProtocol
+	TransferFileDesignator string
+}
+
+// RetrieveLogsResponse is the RetrieveLogsResponse command.
+type RetrieveLogsResponse struct {
+	Status     Status
+	LogContent []byte
+}
+
+// Cluster implements the Diagnostic Logs cluster (0x0032).
+type Cluster struct {
+	*datamodel.ClusterBase
+	config Config
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Diagnostic Logs cluster.
+func New(cfg Config) (*Cluster, error) {
+	if cfg.Provider == nil {
+		return nil, ErrNoProvider
+	}
+
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		config:      cfg,
+	}
+	c.attrList = datamodel.MergeAttributeLists(nil)
+
+	return c, nil
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	return []datamodel.CommandEntry{
+		datamodel.NewCommandEntry(CmdRetrieveLogsRequest, 0, datamodel.PrivilegeOperate),
+	}
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return []datamodel.CommandID{CmdRetrieveLogsResponse}
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+	return datamodel.ErrUnsupportedAttribute
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	return datamodel.ErrUnsupportedWrite
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	switch req.Path.Command {
+	case CmdRetrieveLogsRequest:
+		request, err := decodeRetrieveLogsRequest(r)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := c.RetrieveLogs(request)
+		if err != nil {
+			return nil, err
+		}
+		return encodeRetrieveLogsResponse(resp)
+	default:
+		return nil, datamodel.ErrUnsupportedCommand
+	}
+}
+
+// RetrieveLogs handles a RetrieveLogsRequest. BDX requests must name a
+// file, and are answered in the response payload since BDX is not
+// supported.
+func (c *Cluster) RetrieveLogs(req *RetrieveLogsRequest) (*RetrieveLogsResponse, error) {
+	if req.Intent > IntentCrashLogs || req.RequestedProtocol > TransferProtocolBDX {
+		return nil, datamodel.ErrConstraintError
+	}
+	if req.RequestedProtocol == TransferProtocolBDX && req.TransferFileDesignator == "" {
+		return nil, datamodel.ErrInvalidCommand
+	}
+
+	logs := c.config.Provider.Logs(req.Intent)
+	if len(logs) == 0 {
+		return &RetrieveLogsResponse{Status: StatusNoLogs}, nil
+	}
+	if len(logs) > MaxLogContentSize {
+		logs = logs[:MaxLogContentSize]
+	}
+	return &RetrieveLogsResponse{Status: StatusExhausted, LogContent: logs}, nil
+}
+
+// decodeRetrieveLogsRequest decodes the fields of RetrieveLogsRequest:
+// Intent (0), RequestedProtocol (1) and TransferFileDesignator (2).
+func decodeRetrieveLogsRequest(r *tlv.Reader) (*RetrieveLogsRequest, error) {
+	if err := r.Next(); err != nil {
+		return nil, datamodel.ErrInvalidCommand
+	}
+	if err := r.EnterContainer(); err != nil {
+		return nil, datamodel.ErrInvalidCommand
+	}
+
+	req := &RetrieveLogsRequest{}
+	var hasIntent, hasProtocol bool
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		tag := r.Tag()
+		if !tag.IsContext() {
+			continue
+		}
+		switch tag.TagNumber() {
+		case 0:
+			v, err := r.Uint()
+			if err != nil || v > 0xFF {
+				return nil, datamodel.ErrInvalidCommand
+			}
+			req.Intent = Intent(v)
+			hasIntent = true
+		case 1:
+			v, err := r.Uint()
+			if err != nil || v > 0xFF {
+				return nil, datamodel.ErrInvalidCommand
+			}
+			req.RequestedProtocol = TransferProtocol(v)
+			hasProtocol = true
+		case 2:
+			v, err := r.String()
+			if err != nil {
+				return nil, datamodel.ErrInvalidCommand
+			}
+			if len(v) > 32 {
+				return nil, datamodel.ErrConstraintError
+			}
+			req.TransferFileDesignator = v
+		}
+	}
+	if !hasIntent || !hasProtocol {
+		return nil, datamodel.ErrInvalidCommand
+	}
+	return req, nil
+}
+
+// encodeRetrieveLogsResponse encodes a RetrieveLogsResponse.
+func encodeRetrieveLogsResponse(resp *RetrieveLogsResponse) ([]byte, error) {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return nil, err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(resp.Status)); err != nil {
+		return nil, err
+	}
+	content := resp.LogContent
+	if content == nil {
+		content = []byte{}
+	}
+	if err := w.PutBytes(tlv.ContextTag(1), content); err != nil {
+		return nil, err
+	}
+	if err := w.EndContainer(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,101 @@
+package diagnosticlogs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+func retrieveLogs(t *testing.T, c *Cluster, intent, protocol uint64, designator string) (Status, []byte, error) {
+	t.Helper()
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	w.StartStructure(tlv.Anonymous())
+	w.PutUint(tlv.ContextTag(0), intent)
+	w.PutUint(tlv.ContextTag(1), protocol)
+	if designator != "" {
+		w.PutString(tlv.ContextTag(2), designator)
+	}
+	w.EndContainer()
+
+	req := datamodel.InvokeRequest{
+		Path: datamodel.ConcreteCommandPath{Endpoint: 0, Cluster: ClusterID, Command: CmdRetrieveLogsRequest},
+	}
+	resp, err := c.InvokeCommand(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes())))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	r := tlv.NewReader(bytes.NewReader(resp))
+	r.Next()
+	r.EnterContainer()
+	r.Next()
+	status, err := r.Uint()
+	if err != nil {
+		t.Fatalf("decode Status error = %v", err)
+	}
+	r.Next()
+	content, err := r.Bytes()
+	if err != nil {
+		t.Fatalf("decode LogContent error = %v", err)
+	}
+	return Status(status), content, nil
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(Config{}); !errors.Is(err, ErrNoProvider) {
+		t.Errorf("error = %v, want ErrNoProvider", err)
+	}
+}
+
+func TestRetrieveLogs(t *testing.T) {
+	long := bytes.Repeat([]byte{'x'}, MaxLogContentSize+10)
+	c, err := New(Config{Provider: ProviderFunc(func(intent Intent) []byte {
+		switch intent {
+		case IntentEndUserSupport:
+			return []byte("access denied")
+		case IntentNetworkDiag:
+			return long
+		default:
+			return nil
+		}
+	})})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		intent     Intent
+		protocol   TransferProtocol
+		designator string
+		status     Status
+		size       int
+	}{
+		{"end user support", IntentEndUserSupport, TransferProtocolResponsePayload, "", StatusExhausted, 13},
+		{"truncated", IntentNetworkDiag, TransferProtocolResponsePayload, "", StatusExhausted, MaxLogContentSize},
+		{"no logs", IntentCrashLogs, TransferProtocolResponsePayload, "", StatusNoLogs, 0},
+		{"BDX falls back to payload", IntentEndUserSupport, TransferProtocolBDX, "logs.txt", StatusExhausted, 13},
+	}
+	for _, tt := range tests {
+		status, content, err := retrieveLogs(t, c, uint64(tt.intent), uint64(tt.protocol), tt.designator)
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.name, err)
+			continue
+		}
+		if status != tt.status || len(content) != tt.size {
+			t.Errorf("%s: = %v with %d bytes, want %v with %d", tt.name, status, len(content), tt.status, tt.size)
+		}
+	}
+
+	if _, _, err := retrieveLogs(t, c, 3, 0, ""); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("unknown intent error = %v, want ErrConstraintError", err)
+	}
+	if _, _, err := retrieveLogs(t, c, 0, 1, ""); !errors.Is(err, datamodel.ErrInvalidCommand) {
+		t.Errorf("BDX without designator error = %v, want ErrInvalidCommand", err)
+	}
+}
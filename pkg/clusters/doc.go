@@ -17,12 +17,53 @@
 //   - clusters/descriptor: Descriptor Cluster (0x001D)
 //   - clusters/basic: Basic Information Cluster (0x0028)
 //   - clusters/generalcommissioning: General Commissioning Cluster (0x0030)
+//   - clusters/diagnosticlogs: Diagnostic Logs Cluster (0x0032)
+//   - clusters/generaldiagnostics: General Diagnostics Cluster (0x0033)
+//   - clusters/icdmanagement: ICD Management Cluster (0x0046), client commands
+//   - clusters/localizationconfiguration: Localization Configuration Cluster (0x002B)
+//   - clusters/timeformatlocalization: Time Format Localization Cluster (0x002C)
+//   - clusters/unitlocalization: Unit Localization Cluster (0x002D)
 //   - clusters/onoff: On/Off Cluster (0x0006)
+//   - clusters/switchcluster: Switch Cluster (0x003B)
+//   - clusters/doorlock: Door Lock Cluster (0x0101)
+//   - clusters/airquality: Air Quality Cluster (0x005B)
+//   - clusters/thermostat: Thermostat Cluster (0x0201)
+//   - clusters/illuminancemeasurement: Illuminance Measurement Cluster (0x0400)
+//   - clusters/temperaturemeasurement: Temperature Measurement Cluster (0x0402)
+//   - clusters/relativehumiditymeasurement: Relative Humidity Measurement Cluster (0x0405)
+//   - clusters/occupancysensing: Occupancy Sensing Cluster (0x0406)
+//   - clusters/concentrationmeasurement: Concentration Measurement Clusters (0x040C-0x042F)
+//   - clusters/modeselect: Mode Select Cluster (0x0050)
+//   - clusters/modebase: Mode Base derived clusters (RVC Run Mode, Dishwasher Mode, ...)
+//   - clusters/operationalstate: Operational State Cluster (0x0060) and derived clusters (Oven Cavity, RVC)
+//   - clusters/electricalpowermeasurement: Electrical Power Measurement Cluster (0x0090)
+//   - clusters/electricalenergymeasurement: Electrical Energy Measurement Cluster (0x0091)
+//   - clusters/deviceenergymanagement: Device Energy Management Cluster (0x0098)
+//   - clusters/energyevse: Energy EVSE Cluster (0x0099)
+//   - clusters/smokecoalarm: Smoke CO Alarm Cluster (0x005C)
+//   - clusters/laundrywashercontrols: Laundry Washer Controls Cluster (0x0053)
+//   - clusters/temperaturecontrol: Temperature Control Cluster (0x0056)
+//   - clusters/alarmbase: Alarm Base derived clusters (Dishwasher Alarm, Refrigerator Alarm)
+//   - clusters/threadborderroutermanagement: Thread Border Router Management Cluster (0x0452)
+//   - clusters/threadnetworkdirectory: Thread Network Directory Cluster (0x0453)
+//   - clusters/wakeonlan: Wake On LAN Cluster (0x0503)
+//   - clusters/mediaplayback: Media Playback Cluster (0x0506)
+//   - clusters/mediainput: Media Input Cluster (0x0507)
+//   - clusters/keypadinput: Keypad Input Cluster (0x0509)
+//   - clusters/contentlauncher: Content Launcher Cluster (0x050A)
+//   - clusters/cameraavstreammanagement: Camera AV Stream Management Cluster (0x0551)
+//   - clusters/chime: Chime Cluster (0x0556)
+//   - clusters/valveconfigurationandcontrol: Valve Configuration and Control Cluster (0x0081)
+//   - clusters/servicearea: Service Area Cluster (0x0150)
 //
 // # Helpers
 //
 // This package provides common helpers for cluster implementations:
 //   - Timed command enforcement (timed.go)
 //   - Command TLV encoding/decoding (encoding.go)
+//   - Measured value attributes with report thresholds (measured.go)
+//   - Electrical measurement accuracy structs (accuracy.go)
+//   - Thread operational dataset parsing (threaddataset.go)
+//   - Scene extension field sets and the SceneParticipant interface (scenes.go)
 //   - Status response builders
 package clusters
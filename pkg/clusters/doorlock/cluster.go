@@ -0,0 +1,1010 @@
+// Package doorlock implements the Door Lock Cluster (0x0101).
+//
+// The Door Lock cluster exposes a lock actuator together with a user and
+// credential database (PIN codes, RFID tags). Remote LockDoor/UnlockDoor
+// and all user/credential management commands require a timed interaction.
+// Repeated invalid codes trigger the WrongCodeEntryLimit lockout, during
+// which code entry is rejected for UserCodeTemporaryDisableTime seconds.
+//
+// User and credential data is kept in a pluggable CredentialStore so that
+// devices can back it with secure storage.
+//
+// Spec Reference: Section 5.2
+//
+// C++ Reference: src/app/clusters/door-lock-server/door-lock-server.cpp
+package doorlock
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/backkem/matter/pkg/clusters"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x0101
+	ClusterRevision uint16              = 7
+)
+
+// Attribute IDs (Spec 5.2.9).
+const (
+	AttrLockState                           datamodel.AttributeID = 0x0000
+	AttrLockType                            datamodel.AttributeID = 0x0001
+	AttrActuatorEnabled                     datamodel.AttributeID = 0x0002
+	AttrNumberOfTotalUsersSupported         datamodel.AttributeID = 0x0011
+	AttrNumberOfPINUsersSupported           datamodel.AttributeID = 0x0012
+	AttrNumberOfRFIDUsersSupported          datamodel.AttributeID = 0x0013
+	AttrMaxPINCodeLength                    datamodel.AttributeID = 0x0017
+	AttrMinPINCodeLength                    datamodel.AttributeID = 0x0018
+	AttrMaxRFIDCodeLength                   datamodel.AttributeID = 0x0019
+	AttrMinRFIDCodeLength                   datamodel.AttributeID = 0x001A
+	AttrNumberOfCredentialsSupportedPerUser datamodel.AttributeID = 0x001C
+	AttrOperatingMode                       datamodel.AttributeID = 0x0025
+	AttrSupportedOperatingModes             datamodel.AttributeID = 0x0026
+	AttrWrongCodeEntryLimit                 datamodel.AttributeID = 0x0030
+	AttrUserCodeTemporaryDisableTime        datamodel.AttributeID = 0x0031
+	AttrRequirePINforRemoteOperation        datamodel.AttributeID = 0x0033
+)
+
+// Command IDs (Spec 5.2.10).
+const (
+	CmdLockDoor                    datamodel.CommandID = 0x00
+	CmdUnlockDoor                  datamodel.CommandID = 0x01
+	CmdSetUser                     datamodel.CommandID = 0x1A
+	CmdGetUser                     datamodel.CommandID = 0x1B
+	CmdGetUserResponse             datamodel.CommandID = 0x1C
+	CmdClearUser                   datamodel.CommandID = 0x1D
+	CmdSetCredential               datamodel.CommandID = 0x22
+	CmdSetCredentialResponse       datamodel.CommandID = 0x23
+	CmdGetCredentialStatus         datamodel.CommandID = 0x24
+	CmdGetCredentialStatusResponse datamodel.CommandID = 0x25
+	CmdClearCredential             datamodel.CommandID = 0x26
+)
+
+// Event IDs (Spec 5.2.11).
+const (
+	EventDoorLockAlarm      datamodel.EventID = 0x00
+	EventLockOperation      datamodel.EventID = 0x02
+	EventLockOperationError datamodel.EventID = 0x03
+	EventLockUserChange     datamodel.EventID = 0x04
+)
+
+// Feature bits (Spec 5.2.4).
+type Feature uint32
+
+const (
+	// FeaturePINCredential enables PIN code credentials (PIN).
+	FeaturePINCredential Feature = 1 << 0
+
+	// FeatureRFIDCredential enables RFID credentials (RID).
+	FeatureRFIDCredential Feature = 1 << 1
+
+	// FeatureFingerCredentials enables fingerprint/finger vein credentials (FGP).
+	FeatureFingerCredentials Feature = 1 << 2
+
+	// FeatureWeekDayAccessSchedules enables week day schedules (WDSCH).
+	FeatureWeekDayAccessSchedules Feature = 1 << 4
+
+	// FeatureDoorPositionSensor enables the door position sensor (DPS).
+	FeatureDoorPositionSensor Feature = 1 << 5
+
+	// FeatureFaceCredentials enables face credentials (FACE).
+	FeatureFaceCredentials Feature = 1 << 6
+
+	// FeatureCredentialsOverTheAirAccess allows a PIN to be required for
+	// remote lock operations (COTA).
+	FeatureCredentialsOverTheAirAccess Feature = 1 << 7
+
+	// FeatureUser enables the user database and its commands (USR).
+	FeatureUser Feature = 1 << 8
+
+	// FeatureYearDayAccessSchedules enables year day schedules (YDSCH).
+	FeatureYearDayAccessSchedules Feature = 1 << 10
+
+	// FeatureHolidaySchedules enables holiday schedules (HDSCH).
+	FeatureHolidaySchedules Feature = 1 << 11
+
+	// FeatureUnbolting enables the Unbolt command and Unlatched state (UBOLT).
+	FeatureUnbolting Feature = 1 << 12
+)
+
+// LockState is the state of the lock actuator (Spec 5.2.6.13).
+type LockState uint8
+
+const (
+	LockStateNotFullyLocked LockState = 0
+	LockStateLocked         LockState = 1
+	LockStateUnlocked       LockState = 2
+	LockStateUnlatched      LockState = 3
+)
+
+// String returns the name of the lock state.
+func (s LockState) String() string {
+	switch s {
+	case LockStateNotFullyLocked:
+		return "NotFullyLocked"
+	case LockStateLocked:
+		return "Locked"
+	case LockStateUnlocked:
+		return "Unlocked"
+	case LockStateUnlatched:
+		return "Unlatched"
+	default:
+		return "Unknown"
+	}
+}
+
+// LockType is the physical type of the lock (Spec 5.2.6.14).
+type LockType uint8
+
+const (
+	LockTypeDeadBolt           LockType = 0
+	LockTypeMagnetic           LockType = 1
+	LockTypeOther              LockType = 2
+	LockTypeMortise            LockType = 3
+	LockTypeRim                LockType = 4
+	LockTypeLatchBolt          LockType = 5
+	LockTypeCylindricalLock    LockType = 6
+	LockTypeTubularLock        LockType = 7
+	LockTypeInterconnectedLock LockType = 8
+	LockTypeDeadLatch          LockType = 9
+	LockTypeDoorFurniture      LockType = 10
+	LockTypeEurocylinder       LockType = 11
+)
+
+// OperatingMode is the lock operating mode (Spec 5.2.6.10).
+type OperatingMode uint8
+
+const (
+	OperatingModeNormal             OperatingMode = 0
+	OperatingModeVacation           OperatingMode = 1
+	OperatingModePrivacy            OperatingMode = 2
+	OperatingModeNoRemoteLockUnlock OperatingMode = 3
+	OperatingModePassage            OperatingMode = 4
+)
+
+// CredentialType identifies a kind of credential (Spec 5.2.6.5).
+type CredentialType uint8
+
+const (
+	CredentialTypeProgrammingPIN CredentialType = 0
+	CredentialTypePIN            CredentialType = 1
+	CredentialTypeRFID           CredentialType = 2
+	CredentialTypeFingerprint    CredentialType = 3
+	CredentialTypeFingerVein     CredentialType = 4
+	CredentialTypeFace           CredentialType = 5
+)
+
+// UserStatus is the status of a user slot (Spec 5.2.6.20).
+type UserStatus uint8
+
+const (
+	UserStatusAvailable        UserStatus = 0
+	UserStatusOccupiedEnabled  UserStatus = 1
+	UserStatusOccupiedDisabled UserStatus = 3
+)
+
+// UserType is the type of a user (Spec 5.2.6.21).
+type UserType uint8
+
+const (
+	UserTypeUnrestricted       UserType = 0
+	UserTypeYearDaySchedule    UserType = 1
+	UserTypeWeekDaySchedule    UserType = 2
+	UserTypeProgramming        UserType = 3
+	UserTypeNonAccess          UserType = 4
+	UserTypeForced             UserType = 5
+	UserTypeDisposable         UserType = 6
+	UserTypeExpiring           UserType = 7
+	UserTypeScheduleRestricted UserType = 8
+	UserTypeRemoteOnly         UserType = 9
+)
+
+// CredentialRule is the number of credentials a user must present (Spec 5.2.6.4).
+type CredentialRule uint8
+
+const (
+	CredentialRuleSingle CredentialRule = 0
+	CredentialRuleDual   CredentialRule = 1
+	CredentialRuleTri    CredentialRule = 2
+)
+
+// DataOperationType is the operation of a user/credential change (Spec 5.2.6.6).
+type DataOperationType uint8
+
+const (
+	DataOperationAdd    DataOperationType = 0
+	DataOperationClear  DataOperationType = 1
+	DataOperationModify DataOperationType = 2
+)
+
+// DlStatus is the status returned in SetCredentialResponse (Spec 5.2.6.8).
+type DlStatus uint8
+
+const (
+	DlStatusSuccess           DlStatus = 0x00
+	DlStatusFailure           DlStatus = 0x01
+	DlStatusDuplicate         DlStatus = 0x02
+	DlStatusOccupied          DlStatus = 0x03
+	DlStatusInvalidField      DlStatus = 0x85
+	DlStatusResourceExhausted DlStatus = 0x89
+	DlStatusNotFound          DlStatus = 0x8B
+)
+
+// Default values.
+const (
+	DefaultNumberOfTotalUsersSupported         uint16 = 10
+	DefaultNumberOfCredentialsSupportedPerUser uint8  = 5
+	DefaultMinPINCodeLength                    uint8  = 4
+	DefaultMaxPINCodeLength                    uint8  = 8
+	DefaultMinRFIDCodeLength                   uint8  = 8
+	DefaultMaxRFIDCodeLength                   uint8  = 20
+	DefaultWrongCodeEntryLimit                 uint8  = 5
+	DefaultUserCodeTemporaryDisableTime        uint8  = 60
+
+	// clearAllIndex is the index value meaning "all" in ClearUser (Spec 5.2.10.37).
+	clearAllIndex uint16 = 0xFFFE
+)
+
+// Config provides dependencies for the Door Lock cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to.
+	EndpointID datamodel.EndpointID
+
+	// FeatureMap indicates supported features.
+	FeatureMap Feature
+
+	// LockType is the physical lock type.
+	LockType LockType
+
+	// InitialLockState is the lock state at startup.
+	InitialLockState LockState
+
+	// NumberOfTotalUsersSupported is the size of the user table (USR).
+	// Defaults to DefaultNumberOfTotalUsersSupported if zero.
+	NumberOfTotalUsersSupported uint16
+
+	// NumberOfPINUsersSupported is the number of PIN credential slots (PIN).
+	// Defaults to NumberOfTotalUsersSupported if zero.
+	NumberOfPINUsersSupported uint16
+
+	// NumberOfRFIDUsersSupported is the number of RFID credential slots (RID).
+	// Defaults to NumberOfTotalUsersSupported if zero.
+	NumberOfRFIDUsersSupported uint16
+
+	// NumberOfCredentialsSupportedPerUser limits credentials per user (USR).
+	// Defaults to DefaultNumberOfCredentialsSupportedPerUser if zero.
+	NumberOfCredentialsSupportedPerUser uint8
+
+	// MinPINCodeLength and MaxPINCodeLength bound PIN length (PIN).
+	// Default to DefaultMinPINCodeLength/DefaultMaxPINCodeLength if zero.
+	MinPINCodeLength uint8
+	MaxPINCodeLength uint8
+
+	// MinRFIDCodeLength and MaxRFIDCodeLength bound RFID length (RID).
+	// Default to DefaultMinRFIDCodeLength/DefaultMaxRFIDCodeLength if zero.
+	MinRFIDCodeLength uint8
+	MaxRFIDCodeLength uint8
+
+	// WrongCodeEntryLimit is the number of invalid codes allowed before
+	// code entry is temporarily disabled (PIN | RID).
+	// Defaults to DefaultWrongCodeEntryLimit if zero.
+	WrongCodeEntryLimit uint8
+
+	// UserCodeTemporaryDisableTime is the lockout duration in seconds (PIN | RID).
+	// Defaults to DefaultUserCodeTemporaryDisableTime if zero.
+	UserCodeTemporaryDisableTime uint8
+
+	// RequirePINforRemoteOperation requires a PIN on LockDoor/UnlockDoor (COTA & PIN).
+	RequirePINforRemoteOperation bool
+
+	// Store persists users and credentials.
+	// Defaults to a MemoryCredentialStore if nil.
+	Store CredentialStore
+
+	// EventPublisher for door lock events.
+	// Optional - if nil, events are not emitted.
+	EventPublisher datamodel.EventPublisher
+
+	// OnLockStateChange is called when LockState changes (optional).
+	// Device firmware uses it to drive the actuator.
+	OnLockStateChange func(endpoint datamodel.EndpointID, state LockState)
+}
+
+// Cluster implements the Door Lock cluster (0x0101).
+type Cluster struct {
+	*datamodel.ClusterBase
+	*datamodel.EventSource
+	config Config
+
+	// Mutable state (protected by mutex)
+	mu                           sync.RWMutex
+	lockState                    LockState
+	actuatorEnabled              bool
+	operatingMode                OperatingMode
+	wrongCodeEntryLimit          uint8
+	userCodeTemporaryDisableTime uint8
+	requirePINforRemoteOperation bool
+
+	// WrongCodeEntryLimit tracking
+	wrongCodeCount uint8
+	disabledUntil  time.Time
+
+	// now is the time source, replaceable in tests.
+	now func() time.Time
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Door Lock cluster.
+func New(cfg Config) *Cluster {
+	if cfg.NumberOfTotalUsersSupported == 0 {
+		cfg.NumberOfTotalUsersSupported = DefaultNumberOfTotalUsersSupported
+	}
+	if cfg.NumberOfPINUsersSupported == 0 {
+		cfg.NumberOfPINUsersSupported = cfg.NumberOfTotalUsersSupported
+	}
+	if cfg.NumberOfRFIDUsersSupported == 0 {
+		cfg.NumberOfRFIDUsersSupported = cfg.NumberOfTotalUsersSupported
+	}
+	if cfg.NumberOfCredentialsSupportedPerUser == 0 {
+		cfg.NumberOfCredentialsSupportedPerUser = DefaultNumberOfCredentialsSupportedPerUser
+	}
+	if cfg.MinPINCodeLength == 0 {
+		cfg.MinPINCodeLength = DefaultMinPINCodeLength
+	}
+	if cfg.MaxPINCodeLength == 0 {
+		cfg.MaxPINCodeLength = DefaultMaxPINCodeLength
+	}
+	if cfg.MinRFIDCodeLength == 0 {
+		cfg.MinRFIDCodeLength = DefaultMinRFIDCodeLength
+	}
+	if cfg.MaxRFIDCodeLength == 0 {
+		cfg.MaxRFIDCodeLength = DefaultMaxRFIDCodeLength
+	}
+	if cfg.WrongCodeEntryLimit == 0 {
+		cfg.WrongCodeEntryLimit = DefaultWrongCodeEntryLimit
+	}
+	if cfg.UserCodeTemporaryDisableTime == 0 {
+		cfg.UserCodeTemporaryDisableTime = DefaultUserCodeTemporaryDisableTime
+	}
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryCredentialStore()
+	}
+
+	c := &Cluster{
+		ClusterBase:                  datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		EventSource:                  datamodel.NewEventSource(),
+		config:                       cfg,
+		lockState:                    cfg.InitialLockState,
+		actuatorEnabled:              true,
+		operatingMode:                OperatingModeNormal,
+		wrongCodeEntryLimit:          cfg.WrongCodeEntryLimit,
+		userCodeTemporaryDisableTime: cfg.UserCodeTemporaryDisableTime,
+		requirePINforRemoteOperation: cfg.RequirePINforRemoteOperation,
+		now:                          time.Now,
+	}
+
+	c.ClusterBase.SetFeatureMap(uint32(cfg.FeatureMap))
+
+	// Bind event source
+	if cfg.EventPublisher != nil {
+		c.EventSource.Bind(cfg.EndpointID, ClusterID, cfg.EventPublisher)
+		c.registerEvents()
+	}
+
+	c.attrList = c.buildAttributeList()
+
+	return c
+}
+
+// hasFeature returns true if the feature bit is set.
+func (c *Cluster) hasFeature(f Feature) bool {
+	return c.config.FeatureMap&f != 0
+}
+
+// hasCodeEntry returns true if the lock accepts PIN or RFID codes.
+func (c *Cluster) hasCodeEntry() bool {
+	return c.hasFeature(FeaturePINCredential) || c.hasFeature(FeatureRFIDCredential)
+}
+
+// registerEvents registers the events enabled by the feature map.
+func (c *Cluster) registerEvents() {
+	viewPriv := datamodel.PrivilegeView
+
+	c.EventSource.RegisterEvents([]datamodel.EventEntry{
+		datamodel.NewEventEntry(EventDoorLockAlarm, datamodel.EventPriorityCritical, viewPriv, false),
+		datamodel.NewEventEntry(EventLockOperation, datamodel.EventPriorityCritical, viewPriv, false),
+		datamodel.NewEventEntry(EventLockOperationError, datamodel.EventPriorityCritical, viewPriv, false),
+	})
+
+	if c.hasFeature(FeatureUser) {
+		c.EventSource.RegisterEvent(datamodel.NewEventEntry(
+			EventLockUserChange, datamodel.EventPriorityInfo, viewPriv, false,
+		))
+	}
+}
+
+// buildAttributeList constructs the list of supported attributes.
+func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+	managePriv := datamodel.PrivilegeManage
+	adminPriv := datamodel.PrivilegeAdminister
+
+	attrs := []datamodel.AttributeEntry{
+		datamodel.NewReadOnlyAttribute(AttrLockState, datamodel.AttrQualityNullable, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrLockType, datamodel.AttrQualityFixed, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrActuatorEnabled, 0, viewPriv),
+		datamodel.NewReadWriteAttribute(AttrOperatingMode, 0, viewPriv, managePriv),
+		datamodel.NewReadOnlyAttribute(AttrSupportedOperatingModes, datamodel.AttrQualityFixed, viewPriv),
+	}
+
+	if c.hasFeature(FeatureUser) {
+		attrs = append(attrs,
+			datamodel.NewReadOnlyAttribute(AttrNumberOfTotalUsersSupported, datamodel.AttrQualityFixed, viewPriv),
+			datamodel.NewReadOnlyAttribute(AttrNumberOfCredentialsSupportedPerUser, datamodel.AttrQualityFixed, viewPriv),
+		)
+	}
+
+	if c.hasFeature(FeaturePINCredential) {
+		attrs = append(attrs,
+			datamodel.NewReadOnlyAttribute(AttrNumberOfPINUsersSupported, datamodel.AttrQualityFixed, viewPriv),
+			datamodel.NewReadOnlyAttribute(AttrMaxPINCodeLength, datamodel.AttrQualityFixed, viewPriv),
+			datamodel.NewReadOnlyAttribute(AttrMinPINCodeLength, datamodel.AttrQualityFixed, viewPriv),
+		)
+	}
+
+	if c.hasFeature(FeatureRFIDCredential) {
+		attrs = append(attrs,
+			datamodel.NewReadOnlyAttribute(AttrNumberOfRFIDUsersSupported, datamodel.AttrQualityFixed, viewPriv),
+			datamodel.NewReadOnlyAttribute(AttrMaxRFIDCodeLength, datamodel.AttrQualityFixed, viewPriv),
+			datamodel.NewReadOnlyAttribute(AttrMinRFIDCodeLength, datamodel.AttrQualityFixed, viewPriv),
+		)
+	}
+
+	if c.hasCodeEntry() {
+		attrs = append(attrs,
+			datamodel.NewReadWriteAttribute(AttrWrongCodeEntryLimit, 0, viewPriv, adminPriv),
+			datamodel.NewReadWriteAttribute(AttrUserCodeTemporaryDisableTime, 0, viewPriv, adminPriv),
+		)
+	}
+
+	if c.hasFeature(FeatureCredentialsOverTheAirAccess) && c.hasFeature(FeaturePINCredential) {
+		attrs = append(attrs,
+			datamodel.NewReadWriteAttribute(AttrRequirePINforRemoteOperation, 0, viewPriv, adminPriv),
+		)
+	}
+
+	return datamodel.MergeAttributeLists(attrs)
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	operatePriv := datamodel.PrivilegeOperate
+	adminPriv := datamodel.PrivilegeAdminister
+	timed := datamodel.CmdQualityTimed
+
+	cmds := []datamodel.CommandEntry{
+		datamodel.NewCommandEntry(CmdLockDoor, timed, operatePriv),
+		datamodel.NewCommandEntry(CmdUnlockDoor, timed, operatePriv),
+	}
+
+	if c.hasFeature(FeatureUser) {
+		cmds = append(cmds,
+			datamodel.NewCommandEntry(CmdSetUser, timed, adminPriv),
+			datamodel.NewCommandEntry(CmdGetUser, 0, adminPriv),
+			datamodel.NewCommandEntry(CmdClearUser, timed, adminPriv),
+			datamodel.NewCommandEntry(CmdSetCredential, timed, adminPriv),
+			datamodel.NewCommandEntry(CmdGetCredentialStatus, 0, adminPriv),
+			datamodel.NewCommandEntry(CmdClearCredential, timed, adminPriv),
+		)
+	}
+
+	return cmds
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	if !c.hasFeature(FeatureUser) {
+		return nil
+	}
+	return []datamodel.CommandID{
+		CmdGetUserResponse,
+		CmdSetCredentialResponse,
+		CmdGetCredentialStatusResponse,
+	}
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	// Handle global attributes first
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	switch req.Path.Attribute {
+	case AttrLockState:
+		return w.PutUint(tlv.Anonymous(), uint64(c.lockState))
+	case AttrLockType:
+		return w.PutUint(tlv.Anonymous(), uint64(c.config.LockType))
+	case AttrActuatorEnabled:
+		return w.PutBool(tlv.Anonymous(), c.actuatorEnabled)
+	case AttrOperatingMode:
+		return w.PutUint(tlv.Anonymous(), uint64(c.operatingMode))
+	case AttrSupportedOperatingModes:
+		// Bitmap where a cleared bit means supported (Spec 5.2.9.24).
+		// Normal and NoRemoteLockUnlock are supported.
+		supported := uint64(1<<OperatingModeNormal | 1<<OperatingModeNoRemoteLockUnlock)
+		return w.PutUint(tlv.Anonymous(), 0xFFFF&^supported)
+	}
+
+	if c.hasFeature(FeatureUser) {
+		switch req.Path.Attribute {
+		case AttrNumberOfTotalUsersSupported:
+			return w.PutUint(tlv.Anonymous(), uint64(c.config.NumberOfTotalUsersSupported))
+		case AttrNumberOfCredentialsSupportedPerUser:
+			return w.PutUint(tlv.Anonymous(), uint64(c.config.NumberOfCredentialsSupportedPerUser))
+		}
+	}
+
+	if c.hasFeature(FeaturePINCredential) {
+		switch req.Path.Attribute {
+		case AttrNumberOfPINUsersSupported:
+			return w.PutUint(tlv.Anonymous(), uint64(c.config.NumberOfPINUsersSupported))
+		case AttrMaxPINCodeLength:
+			return w.PutUint(tlv.Anonymous(), uint64(c.config.MaxPINCodeLength))
+		case AttrMinPINCodeLength:
+			return w.PutUint(tlv.Anonymous(), uint64(c.config.MinPINCodeLength))
+		}
+	}
+
+	if c.hasFeature(FeatureRFIDCredential) {
+		switch req.Path.Attribute {
+		case AttrNumberOfRFIDUsersSupported:
+			return w.PutUint(tlv.Anonymous(), uint64(c.config.NumberOfRFIDUsersSupported))
+		case AttrMaxRFIDCodeLength:
+			return w.PutUint(tlv.Anonymous(), uint64(c.config.MaxRFIDCodeLength))
+		case AttrMinRFIDCodeLength:
+			return w.PutUint(tlv.Anonymous(), uint64(c.config.MinRFIDCodeLength))
+		}
+	}
+
+	if c.hasCodeEntry() {
+		switch req.Path.Attribute {
+		case AttrWrongCodeEntryLimit:
+			return w.PutUint(tlv.Anonymous(), uint64(c.wrongCodeEntryLimit))
+		case AttrUserCodeTemporaryDisableTime:
+			return w.PutUint(tlv.Anonymous(), uint64(c.userCodeTemporaryDisableTime))
+		}
+	}
+
+	if req.Path.Attribute == AttrRequirePINforRemoteOperation &&
+		c.hasFeature(FeatureCredentialsOverTheAirAccess) && c.hasFeature(FeaturePINCredential) {
+		return w.PutBool(tlv.Anonymous(), c.requirePINforRemoteOperation)
+	}
+
+	return datamodel.ErrUnsupportedAttribute
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	switch req.Path.Attribute {
+	case AttrOperatingMode:
+		return c.writeOperatingMode(r)
+	case AttrWrongCodeEntryLimit, AttrUserCodeTemporaryDisableTime:
+		if !c.hasCodeEntry() {
+			return datamodel.ErrUnsupportedWrite
+		}
+		return c.writeCodeEntrySetting(req.Path.Attribute, r)
+	case AttrRequirePINforRemoteOperation:
+		if !c.hasFeature(FeatureCredentialsOverTheAirAccess) || !c.hasFeature(FeaturePINCredential) {
+			return datamodel.ErrUnsupportedWrite
+		}
+		return c.writeRequirePINforRemoteOperation(r)
+	default:
+		return datamodel.ErrUnsupportedWrite
+	}
+}
+
+// writeOperatingMode handles writing the OperatingMode attribute.
+func (c *Cluster) writeOperatingMode(r *tlv.Reader) error {
+	if err := r.Next(); err != nil {
+		return err
+	}
+
+	val, err := r.Uint()
+	if err != nil {
+		return err
+	}
+
+	mode := OperatingMode(val)
+	if mode != OperatingModeNormal && mode != OperatingModeNoRemoteLockUnlock {
+		return datamodel.ErrConstraintError
+	}
+
+	c.mu.Lock()
+	c.operatingMode = mode
+	c.mu.Unlock()
+
+	c.IncrementDataVersion()
+	return nil
+}
+
+// writeCodeEntrySetting handles writing WrongCodeEntryLimit and
+// UserCodeTemporaryDisableTime (both uint8, range 1..255).
+func (c *Cluster) writeCodeEntrySetting(attr datamodel.AttributeID, r *tlv.Reader) error {
+	if err := r.Next(); err != nil {
+		return err
+	}
+
+	val, err := r.Uint()
+	if err != nil {
+		return err
+	}
+
+	if val < 1 || val > 0xFF {
+		return datamodel.ErrConstraintError
+	}
+
+	c.mu.Lock()
+	if attr == AttrWrongCodeEntryLimit {
+		c.wrongCodeEntryLimit = uint8(val)
+	} else {
+		c.userCodeTemporaryDisableTime = uint8(val)
+	}
+	c.mu.Unlock()
+
+	c.IncrementDataVersion()
+	return nil
+}
+
+// writeRequirePINforRemoteOperation handles writing RequirePINforRemoteOperation.
+func (c *Cluster) writeRequirePINforRemoteOperation(r *tlv.Reader) error {
+	if err := r.Next(); err != nil {
+		return err
+	}
+
+	val, err := r.Bool()
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.requirePINforRemoteOperation = val
+	c.mu.Unlock()
+
+	c.IncrementDataVersion()
+	return nil
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	switch req.Path.Command {
+	case CmdLockDoor:
+		return nil, c.handleLockDoor(req, r)
+	case CmdUnlockDoor:
+		return nil, c.handleUnlockDoor(req, r)
+	}
+
+	if !c.hasFeature(FeatureUser) {
+		return nil, datamodel.ErrUnsupportedCommand
+	}
+
+	switch req.Path.Command {
+	case CmdSetUser:
+		return nil, c.handleSetUser(req, r)
+	case CmdGetUser:
+		return c.handleGetUser(req, r)
+	case CmdClearUser:
+		return nil, c.handleClearUser(req, r)
+	case CmdSetCredential:
+		return c.handleSetCredential(req, r)
+	case CmdGetCredentialStatus:
+		return c.handleGetCredentialStatus(req, r)
+	case CmdClearCredential:
+		return nil, c.handleClearCredential(req, r)
+	default:
+		return nil, datamodel.ErrUnsupportedCommand
+	}
+}
+
+// GetLockState returns the current lock state.
+func (c *Cluster) GetLockState() LockState {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lockState
+}
+
+// SetLockState updates LockState from a local source (manual turn, auto
+// relock, keypad without code, ...) and emits a LockOperation event.
+func (c *Cluster) SetLockState(state LockState, source OperationSource) error {
+	op := LockOperationTypeLock
+	if state == LockStateUnlocked || state == LockStateUnlatched {
+		op = LockOperationTypeUnlock
+	}
+
+	c.setLockState(state)
+
+	return c.emit(EventLockOperation, datamodel.EventPriorityCritical, LockOperationEvent{
+		LockOperationType: op,
+		OperationSource:   source,
+	})
+}
+
+// SetActuatorEnabled updates the ActuatorEnabled attribute.
+func (c *Cluster) SetActuatorEnabled(enabled bool) {
+	c.mu.Lock()
+	changed := c.actuatorEnabled != enabled
+	c.actuatorEnabled = enabled
+	c.mu.Unlock()
+
+	if changed {
+		c.IncrementDataVersion()
+	}
+}
+
+// OperateWithCredential performs a lock or unlock presented locally with a
+// credential (keypad PIN entry, RFID tag). Invalid codes count towards
+// WrongCodeEntryLimit.
+func (c *Cluster) OperateWithCredential(op LockOperationType, source OperationSource, credType CredentialType, code []byte) error {
+	userIndex, credIndex, err := c.verifyCode(op, source, credType, code, nil)
+	if err != nil {
+		return err
+	}
+
+	return c.completeOperation(op, source, userIndex, []CredentialRef{{Type: credType, Index: credIndex}}, nil)
+}
+
+// Alarm emits a DoorLockAlarm event, e.g. when the bolt is jammed.
+func (c *Cluster) Alarm(code AlarmCode) error {
+	return c.emit(EventDoorLockAlarm, datamodel.EventPriorityCritical, DoorLockAlarmEvent{AlarmCode: code})
+}
+
+// IsCodeEntryDisabled reports whether code entry is currently disabled
+// because WrongCodeEntryLimit was reached.
+func (c *Cluster) IsCodeEntryDisabled() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.now().Before(c.disabledUntil)
+}
+
+// handleLockDoor handles the LockDoor command.
+//
+// Spec: Section 5.2.10.1
+func (c *Cluster) handleLockDoor(req datamodel.InvokeRequest, r *tlv.Reader) error {
+	return c.handleRemoteOperation(req, r, LockOperationTypeLock)
+}
+
+// handleUnlockDoor handles the UnlockDoor command.
+//
+// Spec: Section 5.2.10.2
+func (c *Cluster) handleUnlockDoor(req datamodel.InvokeRequest, r *tlv.Reader) error {
+	return c.handleRemoteOperation(req, r, LockOperationTypeUnlock)
+}
+
+// handleRemoteOperation implements the shared LockDoor/UnlockDoor flow.
+func (c *Cluster) handleRemoteOperation(req datamodel.InvokeRequest, r *tlv.Reader, op LockOperationType) error {
+	if err := clusters.RequireTimed(req); err != nil {
+		return err
+	}
+
+	var doorReq LockDoorRequest
+	if err := decodeLockDoorRequest(r, &doorReq); err != nil {
+		return err
+	}
+
+	c.mu.RLock()
+	mode := c.operatingMode
+	requirePIN := c.requirePINforRemoteOperation &&
+		c.hasFeature(FeatureCredentialsOverTheAirAccess) && c.hasFeature(FeaturePINCredential)
+	c.mu.RUnlock()
+
+	if mode == OperatingModeNoRemoteLockUnlock {
+		c.emitOperationError(op, OperationSourceRemote, OperationErrorRestricted, nil, &req)
+		return ErrRemoteOperationDisabled
+	}
+
+	var userIndex *uint16
+	var creds []CredentialRef
+
+	if doorReq.PINCode != nil || requirePIN {
+		if doorReq.PINCode == nil {
+			c.emitOperationError(op, OperationSourceRemote, OperationErrorInvalidCredential, nil, &req)
+			return ErrInvalidCredential
+		}
+		if !c.hasFeature(FeaturePINCredential) {
+			return datamodel.ErrInvalidCommand
+		}
+
+		idx, credIndex, err := c.verifyCode(op, OperationSourceRemote, CredentialTypePIN, doorReq.PINCode, &req)
+		if err != nil {
+			return err
+		}
+		userIndex = idx
+		creds = []CredentialRef{{Type: CredentialTypePIN, Index: credIndex}}
+	}
+
+	return c.completeOperation(op, OperationSourceRemote, userIndex, creds, &req)
+}
+
+// verifyCode validates a PIN/RFID code against the credential store and
+// applies the WrongCodeEntryLimit lockout. On success it returns the
+// owning user index (nil without USR) and the credential index.
+func (c *Cluster) verifyCode(op LockOperationType, source OperationSource, credType CredentialType, code []byte, req *datamodel.InvokeRequest) (*uint16, uint16, error) {
+	c.mu.RLock()
+	disabled := c.now().Before(c.disabledUntil)
+	c.mu.RUnlock()
+
+	if disabled {
+		c.emitOperationError(op, source, OperationErrorRestricted, nil, req)
+		return nil, 0, ErrCodeEntryDisabled
+	}
+
+	cred, err := c.matchCredential(credType, code)
+	if err != nil {
+		return nil, 0, err
+	}
+	if cred == nil {
+		c.emitOperationError(op, source, OperationErrorInvalidCredential, nil, req)
+		c.recordWrongCode()
+		return nil, 0, ErrInvalidCredential
+	}
+
+	c.mu.Lock()
+	c.wrongCodeCount = 0
+	c.mu.Unlock()
+
+	if !c.hasFeature(FeatureUser) {
+		return nil, cred.Index, nil
+	}
+
+	userIndex := cred.UserIndex
+	user, err := c.config.Store.GetUser(userIndex)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			c.emitOperationError(op, source, OperationErrorInvalidCredential, nil, req)
+			return nil, 0, ErrInvalidCredential
+		}
+		return nil, 0, err
+	}
+	if user.UserStatus == UserStatusOccupiedDisabled {
+		c.emitOperationError(op, source, OperationErrorDisabledUserDenied, &userIndex, req)
+		return nil, 0, ErrUserDisabled
+	}
+
+	return &userIndex, cred.Index, nil
+}
+
+// matchCredential finds the stored credential matching code.
+// Comparison is constant time with respect to the credential data.
+func (c *Cluster) matchCredential(credType CredentialType, code []byte) (*Credential, error) {
+	creds, err := c.config.Store.Credentials(credType)
+	if err != nil {
+		return nil, err
+	}
+
+	var match *Credential
+	for _, cred := range creds {
+		if subtle.ConstantTimeCompare(cred.Data, code) == 1 && match == nil {
+			match = cred
+		}
+	}
+	return match, nil
+}
+
+// recordWrongCode counts an invalid code entry and starts the lockout
+// once WrongCodeEntryLimit is reached.
+//
+// Spec: Section 5.2.9.34
+func (c *Cluster) recordWrongCode() {
+	c.mu.Lock()
+	c.wrongCodeCount++
+	limitReached := c.wrongCodeCount >= c.wrongCodeEntryLimit
+	if limitReached {
+		c.wrongCodeCount = 0
+		c.disabledUntil = c.now().Add(time.Duration(c.userCodeTemporaryDisableTime) * time.Second)
+	}
+	c.mu.Unlock()
+
+	if limitReached {
+		_ = c.Alarm(AlarmCodeWrongCodeEntryLimit)
+	}
+}
+
+// completeOperation applies a validated lock/unlock and emits LockOperation.
+func (c *Cluster) completeOperation(op LockOperationType, source OperationSource, userIndex *uint16, creds []CredentialRef, req *datamodel.InvokeRequest) error {
+	state := LockStateLocked
+	if op == LockOperationTypeUnlock {
+		state = LockStateUnlocked
+	}
+
+	c.setLockState(state)
+
+	ev := LockOperationEvent{
+		LockOperationType: op,
+		OperationSource:   source,
+		UserIndex:         userIndex,
+		Credentials:       creds,
+	}
+	ev.FabricIndex, ev.SourceNode = requestOrigin(req)
+
+	return c.emit(EventLockOperation, datamodel.EventPriorityCritical, ev)
+}
+
+// setLockState updates LockState and notifies the application.
+func (c *Cluster) setLockState(state LockState) {
+	c.mu.Lock()
+	changed := c.lockState != state
+	c.lockState = state
+	c.mu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	c.IncrementDataVersion()
+
+	if c.config.OnLockStateChange != nil {
+		c.config.OnLockStateChange(c.config.EndpointID, state)
+	}
+}
+
+// emitOperationError emits a LockOperationError event.
+func (c *Cluster) emitOperationError(op LockOperationType, source OperationSource, opErr OperationError, userIndex *uint16, req *datamodel.InvokeRequest) {
+	ev := LockOperationErrorEvent{
+		LockOperationType: op,
+		OperationSource:   source,
+		OperationError:    opErr,
+		UserIndex:         userIndex,
+	}
+	ev.FabricIndex, ev.SourceNode = requestOrigin(req)
+
+	_ = c.emit(EventLockOperationError, datamodel.EventPriorityCritical, ev)
+}
+
+// emit publishes an event if a publisher is bound.
+func (c *Cluster) emit(eventID datamodel.EventID, priority datamodel.EventPriority, payload interface{}) error {
+	if !c.EventSource.IsBound() {
+		return nil // No publisher, silently skip
+	}
+	_, err := c.EventSource.Emit(eventID, priority, payload)
+	return err
+}
+
+// requestOrigin extracts the accessing fabric and node of a remote request.
+// Both are nil for local operations.
+func requestOrigin(req *datamodel.InvokeRequest) (*fabric.FabricIndex, *uint64) {
+	if req == nil || req.Subject == nil {
+		return nil, nil
+	}
+	fabricIndex := req.Subject.FabricIndex
+	nodeID := req.Subject.NodeID
+	return &fabricIndex, &nodeID
+}
+
+// Errors returned by door lock operations.
+var (
+	ErrInvalidCredential       = errors.New("doorlock: invalid credential")
+	ErrCodeEntryDisabled       = errors.New("doorlock: code entry temporarily disabled")
+	ErrUserDisabled            = errors.New("doorlock: user disabled")
+	ErrRemoteOperationDisabled = errors.New("doorlock: remote lock operation disabled")
+	ErrOccupied                = errors.New("doorlock: user index occupied")
+)
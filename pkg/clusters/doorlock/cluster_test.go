@@ -0,0 +1,689 @@
+package doorlock
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/backkem/matter/pkg/clusters"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// mockEventPublisher implements EventPublisher for testing.
+type mockEventPublisher struct {
+	mu     sync.Mutex
+	events []publishedEvent
+}
+
+type publishedEvent struct {
+	eventID datamodel.EventID
+	data    interface{}
+}
+
+func (m *mockEventPublisher) PublishEvent(
+	endpoint datamodel.EndpointID,
+	cluster datamodel.ClusterID,
+	eventID datamodel.EventID,
+	priority datamodel.EventPriority,
+	data interface{},
+	fabricIndex uint8,
+) (datamodel.EventNumber, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, publishedEvent{eventID: eventID, data: data})
+	return datamodel.EventNumber(len(m.events)), nil
+}
+
+func (m *mockEventPublisher) count(id datamodel.EventID) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for _, e := range m.events {
+		if e.eventID == id {
+			n++
+		}
+	}
+	return n
+}
+
+func (m *mockEventPublisher) last(id datamodel.EventID) interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := len(m.events) - 1; i >= 0; i-- {
+		if m.events[i].eventID == id {
+			return m.events[i].data
+		}
+	}
+	return nil
+}
+
+const allFeatures = FeaturePINCredential | FeatureRFIDCredential | FeatureUser | FeatureCredentialsOverTheAirAccess
+
+func newTestCluster(pub *mockEventPublisher) *Cluster {
+	cfg := Config{
+		EndpointID:       1,
+		FeatureMap:       allFeatures,
+		InitialLockState: LockStateLocked,
+	}
+	if pub != nil {
+		cfg.EventPublisher = pub
+	}
+	return New(cfg)
+}
+
+// invoke runs a command with the payload built by encode.
+func invoke(t *testing.T, c *Cluster, cmd datamodel.CommandID, timed bool, encode func(w *tlv.Writer) error) ([]byte, error) {
+	t.Helper()
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		t.Fatal(err)
+	}
+	if encode != nil {
+		if err := encode(w); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.EndContainer(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := datamodel.InvokeRequest{
+		Path:    datamodel.ConcreteCommandPath{Endpoint: 1, Cluster: ClusterID, Command: cmd},
+		Subject: &datamodel.SubjectDescriptor{FabricIndex: 1, NodeID: 0x1234},
+	}
+	if timed {
+		req.InvokeFlags = datamodel.InvokeFlagTimed
+	}
+	return c.InvokeCommand(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes())))
+}
+
+func lockDoor(t *testing.T, c *Cluster, cmd datamodel.CommandID, pin []byte) error {
+	t.Helper()
+	_, err := invoke(t, c, cmd, true, func(w *tlv.Writer) error {
+		if pin == nil {
+			return nil
+		}
+		return w.PutBytes(tlv.ContextTag(0), pin)
+	})
+	return err
+}
+
+func setCredential(t *testing.T, c *Cluster, op DataOperationType, ref CredentialRef, data []byte, userIndex *uint16) SetCredentialResponse {
+	t.Helper()
+	respData, err := invoke(t, c, CmdSetCredential, true, func(w *tlv.Writer) error {
+		if err := w.PutUint(tlv.ContextTag(0), uint64(op)); err != nil {
+			return err
+		}
+		if err := marshalCredentialRef(w, tlv.ContextTag(1), ref); err != nil {
+			return err
+		}
+		if err := w.PutBytes(tlv.ContextTag(2), data); err != nil {
+			return err
+		}
+		if err := putNullableUint16(w, tlv.ContextTag(3), userIndex); err != nil {
+			return err
+		}
+		if err := w.PutNull(tlv.ContextTag(4)); err != nil {
+			return err
+		}
+		return w.PutNull(tlv.ContextTag(5))
+	})
+	if err != nil {
+		t.Fatalf("SetCredential failed: %v", err)
+	}
+	return decodeSetCredentialResponse(t, respData)
+}
+
+func decodeSetCredentialResponse(t *testing.T, data []byte) SetCredentialResponse {
+	t.Helper()
+	var resp SetCredentialResponse
+	r := tlv.NewReader(bytes.NewReader(data))
+	if err := r.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.EnterContainer(); err != nil {
+		t.Fatal(err)
+	}
+	for r.Next() == nil && !r.IsEndOfContainer() {
+		if r.Type() == tlv.ElementTypeNull {
+			continue
+		}
+		val, err := r.Uint()
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := uint16(val)
+		switch r.Tag().TagNumber() {
+		case 0:
+			resp.Status = DlStatus(val)
+		case 1:
+			resp.UserIndex = &v
+		case 2:
+			resp.NextCredentialIndex = &v
+		}
+	}
+	return resp
+}
+
+func readUint(t *testing.T, c *Cluster, attr datamodel.AttributeID) uint64 {
+	t.Helper()
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	req := datamodel.ReadAttributeRequest{
+		Path: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: attr},
+	}
+	if err := c.ReadAttribute(context.Background(), req, w); err != nil {
+		t.Fatalf("ReadAttribute(0x%04X) failed: %v", attr, err)
+	}
+	r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+	if err := r.Next(); err != nil {
+		t.Fatalf("failed to read value: %v", err)
+	}
+	v, err := r.Uint()
+	if err != nil {
+		t.Fatalf("failed to decode uint: %v", err)
+	}
+	return v
+}
+
+func TestNew_Defaults(t *testing.T) {
+	c := newTestCluster(nil)
+
+	if c.ID() != ClusterID {
+		t.Errorf("ID() = 0x%04X, want 0x%04X", c.ID(), ClusterID)
+	}
+	if got := readUint(t, c, AttrLockState); got != uint64(LockStateLocked) {
+		t.Errorf("LockState = %d, want Locked", got)
+	}
+	if got := readUint(t, c, AttrNumberOfTotalUsersSupported); got != uint64(DefaultNumberOfTotalUsersSupported) {
+		t.Errorf("NumberOfTotalUsersSupported = %d, want %d", got, DefaultNumberOfTotalUsersSupported)
+	}
+	if got := readUint(t, c, AttrWrongCodeEntryLimit); got != uint64(DefaultWrongCodeEntryLimit) {
+		t.Errorf("WrongCodeEntryLimit = %d, want %d", got, DefaultWrongCodeEntryLimit)
+	}
+	if got := readUint(t, c, AttrMinPINCodeLength); got != uint64(DefaultMinPINCodeLength) {
+		t.Errorf("MinPINCodeLength = %d, want %d", got, DefaultMinPINCodeLength)
+	}
+}
+
+func TestAttributeList_FeatureGating(t *testing.T) {
+	c := New(Config{EndpointID: 1})
+
+	for _, a := range c.AttributeList() {
+		switch a.ID {
+		case AttrNumberOfPINUsersSupported, AttrWrongCodeEntryLimit, AttrNumberOfTotalUsersSupported:
+			t.Errorf("attribute 0x%04X present without feature", a.ID)
+		}
+	}
+
+	if c.GeneratedCommandList() != nil {
+		t.Error("GeneratedCommandList should be empty without USR")
+	}
+
+	_, err := invoke(t, c, CmdGetUser, false, nil)
+	if !errors.Is(err, datamodel.ErrUnsupportedCommand) {
+		t.Errorf("GetUser without USR: err = %v, want ErrUnsupportedCommand", err)
+	}
+}
+
+func TestAcceptedCommandList_Timed(t *testing.T) {
+	c := newTestCluster(nil)
+
+	timed := map[datamodel.CommandID]bool{
+		CmdLockDoor:        true,
+		CmdUnlockDoor:      true,
+		CmdSetUser:         true,
+		CmdGetUser:         false,
+		CmdClearUser:       true,
+		CmdSetCredential:   true,
+		CmdClearCredential: true,
+	}
+
+	for _, cmd := range c.AcceptedCommandList() {
+		want, ok := timed[cmd.ID]
+		if !ok {
+			continue
+		}
+		if cmd.RequiresTimed() != want {
+			t.Errorf("command 0x%02X RequiresTimed = %v, want %v", cmd.ID, cmd.RequiresTimed(), want)
+		}
+	}
+}
+
+func TestLockDoor_RequiresTimed(t *testing.T) {
+	c := newTestCluster(nil)
+
+	_, err := invoke(t, c, CmdUnlockDoor, false, nil)
+	if !errors.Is(err, clusters.ErrTimedRequired) {
+		t.Fatalf("err = %v, want ErrTimedRequired", err)
+	}
+	if !errors.Is(err, datamodel.ErrTimedRequired) {
+		t.Error("error should wrap datamodel.ErrTimedRequired")
+	}
+	if c.GetLockState() != LockStateLocked {
+		t.Error("lock state should not change")
+	}
+}
+
+func TestLockDoor_NoPIN(t *testing.T) {
+	pub := &mockEventPublisher{}
+	c := newTestCluster(pub)
+
+	var changes []LockState
+	c.config.OnLockStateChange = func(_ datamodel.EndpointID, s LockState) {
+		changes = append(changes, s)
+	}
+
+	if err := lockDoor(t, c, CmdUnlockDoor, nil); err != nil {
+		t.Fatalf("UnlockDoor failed: %v", err)
+	}
+	if c.GetLockState() != LockStateUnlocked {
+		t.Errorf("LockState = %v, want Unlocked", c.GetLockState())
+	}
+	if len(changes) != 1 || changes[0] != LockStateUnlocked {
+		t.Errorf("OnLockStateChange calls = %v", changes)
+	}
+
+	ev, ok := pub.last(EventLockOperation).(LockOperationEvent)
+	if !ok {
+		t.Fatal("expected LockOperation event")
+	}
+	if ev.LockOperationType != LockOperationTypeUnlock || ev.OperationSource != OperationSourceRemote {
+		t.Errorf("event = %+v", ev)
+	}
+	if ev.FabricIndex == nil || *ev.FabricIndex != 1 || ev.SourceNode == nil || *ev.SourceNode != 0x1234 {
+		t.Errorf("event origin = %v/%v", ev.FabricIndex, ev.SourceNode)
+	}
+}
+
+func TestLockDoor_WithPIN(t *testing.T) {
+	pub := &mockEventPublisher{}
+	c := newTestCluster(pub)
+
+	resp := setCredential(t, c, DataOperationAdd, CredentialRef{Type: CredentialTypePIN, Index: 1}, []byte("1234"), nil)
+	if resp.Status != DlStatusSuccess || resp.UserIndex == nil || *resp.UserIndex != 1 {
+		t.Fatalf("SetCredential resp = %+v", resp)
+	}
+
+	if err := lockDoor(t, c, CmdUnlockDoor, []byte("1234")); err != nil {
+		t.Fatalf("UnlockDoor failed: %v", err)
+	}
+
+	ev := pub.last(EventLockOperation).(LockOperationEvent)
+	if ev.UserIndex == nil || *ev.UserIndex != 1 {
+		t.Errorf("UserIndex = %v, want 1", ev.UserIndex)
+	}
+	if len(ev.Credentials) != 1 || ev.Credentials[0] != (CredentialRef{Type: CredentialTypePIN, Index: 1}) {
+		t.Errorf("Credentials = %v", ev.Credentials)
+	}
+
+	err := lockDoor(t, c, CmdLockDoor, []byte("9999"))
+	if !errors.Is(err, ErrInvalidCredential) {
+		t.Fatalf("err = %v, want ErrInvalidCredential", err)
+	}
+	if c.GetLockState() != LockStateUnlocked {
+		t.Error("wrong PIN should not change lock state")
+	}
+	evErr := pub.last(EventLockOperationError).(LockOperationErrorEvent)
+	if evErr.OperationError != OperationErrorInvalidCredential {
+		t.Errorf("OperationError = %d, want InvalidCredential", evErr.OperationError)
+	}
+}
+
+func TestLockDoor_RequirePINforRemoteOperation(t *testing.T) {
+	c := newTestCluster(nil)
+	c.requirePINforRemoteOperation = true
+
+	if err := lockDoor(t, c, CmdUnlockDoor, nil); !errors.Is(err, ErrInvalidCredential) {
+		t.Fatalf("err = %v, want ErrInvalidCredential", err)
+	}
+}
+
+func TestLockDoor_DisabledUser(t *testing.T) {
+	c := newTestCluster(nil)
+
+	setCredential(t, c, DataOperationAdd, CredentialRef{Type: CredentialTypePIN, Index: 1}, []byte("1234"), nil)
+
+	user, _ := c.config.Store.GetUser(1)
+	user.UserStatus = UserStatusOccupiedDisabled
+	_ = c.config.Store.SetUser(user)
+
+	if err := lockDoor(t, c, CmdUnlockDoor, []byte("1234")); !errors.Is(err, ErrUserDisabled) {
+		t.Fatalf("err = %v, want ErrUserDisabled", err)
+	}
+}
+
+func TestWrongCodeEntryLimit(t *testing.T) {
+	pub := &mockEventPublisher{}
+	c := New(Config{
+		EndpointID:                   1,
+		FeatureMap:                   allFeatures,
+		WrongCodeEntryLimit:          3,
+		UserCodeTemporaryDisableTime: 10,
+		EventPublisher:               pub,
+	})
+
+	now := time.Unix(1000, 0)
+	c.now = func() time.Time { return now }
+
+	setCredential(t, c, DataOperationAdd, CredentialRef{Type: CredentialTypePIN, Index: 1}, []byte("1234"), nil)
+
+	for i := 0; i < 3; i++ {
+		if err := lockDoor(t, c, CmdUnlockDoor, []byte("0000")); !errors.Is(err, ErrInvalidCredential) {
+			t.Fatalf("attempt %d: err = %v, want ErrInvalidCredential", i, err)
+		}
+	}
+
+	if pub.count(EventDoorLockAlarm) != 1 {
+		t.Fatalf("DoorLockAlarm count = %d, want 1", pub.count(EventDoorLockAlarm))
+	}
+	if alarm := pub.last(EventDoorLockAlarm).(DoorLockAlarmEvent); alarm.AlarmCode != AlarmCodeWrongCodeEntryLimit {
+		t.Errorf("AlarmCode = %d, want WrongCodeEntryLimit", alarm.AlarmCode)
+	}
+	if !c.IsCodeEntryDisabled() {
+		t.Fatal("code entry should be disabled")
+	}
+
+	// Even the correct PIN is rejected during lockout
+	if err := lockDoor(t, c, CmdUnlockDoor, []byte("1234")); !errors.Is(err, ErrCodeEntryDisabled) {
+		t.Fatalf("err = %v, want ErrCodeEntryDisabled", err)
+	}
+
+	now = now.Add(10 * time.Second)
+	if c.IsCodeEntryDisabled() {
+		t.Fatal("lockout should have expired")
+	}
+	if err := lockDoor(t, c, CmdUnlockDoor, []byte("1234")); err != nil {
+		t.Fatalf("UnlockDoor after lockout failed: %v", err)
+	}
+}
+
+func TestOperateWithCredential_RFID(t *testing.T) {
+	pub := &mockEventPublisher{}
+	c := newTestCluster(pub)
+
+	tag := []byte("RFIDTAG001")
+	resp := setCredential(t, c, DataOperationAdd, CredentialRef{Type: CredentialTypeRFID, Index: 2}, tag, nil)
+	if resp.Status != DlStatusSuccess {
+		t.Fatalf("SetCredential status = %d", resp.Status)
+	}
+
+	if err := c.OperateWithCredential(LockOperationTypeUnlock, OperationSourceRFID, CredentialTypeRFID, tag); err != nil {
+		t.Fatalf("OperateWithCredential failed: %v", err)
+	}
+
+	ev := pub.last(EventLockOperation).(LockOperationEvent)
+	if ev.OperationSource != OperationSourceRFID || ev.FabricIndex != nil {
+		t.Errorf("event = %+v", ev)
+	}
+}
+
+func TestSetCredential_Errors(t *testing.T) {
+	c := newTestCluster(nil)
+	pin1 := CredentialRef{Type: CredentialTypePIN, Index: 1}
+	pin2 := CredentialRef{Type: CredentialTypePIN, Index: 2}
+
+	setCredential(t, c, DataOperationAdd, pin1, []byte("1234"), nil)
+
+	tests := []struct {
+		name string
+		op   DataOperationType
+		ref  CredentialRef
+		data []byte
+		want DlStatus
+	}{
+		{"occupied", DataOperationAdd, pin1, []byte("5678"), DlStatusOccupied},
+		{"duplicate", DataOperationAdd, pin2, []byte("1234"), DlStatusDuplicate},
+		{"too short", DataOperationAdd, pin2, []byte("12"), DlStatusInvalidField},
+		{"index out of range", DataOperationAdd, CredentialRef{Type: CredentialTypePIN, Index: 100}, []byte("5678"), DlStatusInvalidField},
+		{"unsupported type", DataOperationAdd, CredentialRef{Type: CredentialTypeFace, Index: 1}, []byte("5678"), DlStatusInvalidField},
+		{"modify missing", DataOperationModify, pin2, []byte("5678"), DlStatusInvalidField},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := setCredential(t, c, tt.op, tt.ref, tt.data, nil)
+			if resp.Status != tt.want {
+				t.Errorf("Status = 0x%02X, want 0x%02X", resp.Status, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetCredential_ModifyAndNextIndex(t *testing.T) {
+	c := newTestCluster(nil)
+	pin1 := CredentialRef{Type: CredentialTypePIN, Index: 1}
+
+	resp := setCredential(t, c, DataOperationAdd, pin1, []byte("1234"), nil)
+	if resp.NextCredentialIndex == nil || *resp.NextCredentialIndex != 2 {
+		t.Errorf("NextCredentialIndex = %v, want 2", resp.NextCredentialIndex)
+	}
+
+	userIndex := uint16(1)
+	resp = setCredential(t, c, DataOperationModify, pin1, []byte("5678"), &userIndex)
+	if resp.Status != DlStatusSuccess {
+		t.Fatalf("Modify status = 0x%02X", resp.Status)
+	}
+
+	if err := lockDoor(t, c, CmdUnlockDoor, []byte("1234")); !errors.Is(err, ErrInvalidCredential) {
+		t.Errorf("old PIN: err = %v, want ErrInvalidCredential", err)
+	}
+	if err := lockDoor(t, c, CmdUnlockDoor, []byte("5678")); err != nil {
+		t.Errorf("new PIN: err = %v", err)
+	}
+}
+
+func TestSetCredential_PerUserLimit(t *testing.T) {
+	c := New(Config{
+		EndpointID:                          1,
+		FeatureMap:                          allFeatures,
+		NumberOfCredentialsSupportedPerUser: 1,
+	})
+
+	setCredential(t, c, DataOperationAdd, CredentialRef{Type: CredentialTypePIN, Index: 1}, []byte("1234"), nil)
+
+	userIndex := uint16(1)
+	resp := setCredential(t, c, DataOperationAdd, CredentialRef{Type: CredentialTypePIN, Index: 2}, []byte("5678"), &userIndex)
+	if resp.Status != DlStatusResourceExhausted {
+		t.Errorf("Status = 0x%02X, want ResourceExhausted", resp.Status)
+	}
+}
+
+func TestSetUser_GetUser_ClearUser(t *testing.T) {
+	pub := &mockEventPublisher{}
+	c := newTestCluster(pub)
+
+	_, err := invoke(t, c, CmdSetUser, true, func(w *tlv.Writer) error {
+		if err := w.PutUint(tlv.ContextTag(0), uint64(DataOperationAdd)); err != nil {
+			return err
+		}
+		if err := w.PutUint(tlv.ContextTag(1), 3); err != nil {
+			return err
+		}
+		if err := w.PutString(tlv.ContextTag(2), "alice"); err != nil {
+			return err
+		}
+		for tag := uint8(3); tag <= 6; tag++ {
+			if err := w.PutNull(tlv.ContextTag(tag)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("SetUser failed: %v", err)
+	}
+	if pub.count(EventLockUserChange) != 1 {
+		t.Errorf("LockUserChange count = %d, want 1", pub.count(EventLockUserChange))
+	}
+
+	user, err := c.config.Store.GetUser(3)
+	if err != nil {
+		t.Fatalf("GetUser from store failed: %v", err)
+	}
+	if user.UserName != "alice" || user.UserStatus != UserStatusOccupiedEnabled || user.CreatorFabricIndex != 1 {
+		t.Errorf("user = %+v", user)
+	}
+
+	// Adding to an occupied index fails
+	_, err = invoke(t, c, CmdSetUser, true, func(w *tlv.Writer) error {
+		if err := w.PutUint(tlv.ContextTag(0), uint64(DataOperationAdd)); err != nil {
+			return err
+		}
+		return w.PutUint(tlv.ContextTag(1), 3)
+	})
+	if !errors.Is(err, ErrOccupied) {
+		t.Errorf("err = %v, want ErrOccupied", err)
+	}
+
+	respData, err := invoke(t, c, CmdGetUser, false, func(w *tlv.Writer) error {
+		return w.PutUint(tlv.ContextTag(0), 3)
+	})
+	if err != nil {
+		t.Fatalf("GetUser failed: %v", err)
+	}
+	r := tlv.NewReader(bytes.NewReader(respData))
+	if err := r.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.EnterContainer(); err != nil {
+		t.Fatal(err)
+	}
+	var name string
+	for r.Next() == nil && !r.IsEndOfContainer() {
+		if r.Tag().TagNumber() == 1 {
+			name, _ = r.String()
+		}
+	}
+	if name != "alice" {
+		t.Errorf("GetUserResponse UserName = %q, want alice", name)
+	}
+
+	_, err = invoke(t, c, CmdClearUser, true, func(w *tlv.Writer) error {
+		return w.PutUint(tlv.ContextTag(0), 3)
+	})
+	if err != nil {
+		t.Fatalf("ClearUser failed: %v", err)
+	}
+	if _, err := c.config.Store.GetUser(3); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("user should be cleared, err = %v", err)
+	}
+}
+
+func TestClearUser_RemovesCredentials(t *testing.T) {
+	c := newTestCluster(nil)
+
+	setCredential(t, c, DataOperationAdd, CredentialRef{Type: CredentialTypePIN, Index: 1}, []byte("1234"), nil)
+
+	_, err := invoke(t, c, CmdClearUser, true, func(w *tlv.Writer) error {
+		return w.PutUint(tlv.ContextTag(0), uint64(clearAllIndex))
+	})
+	if err != nil {
+		t.Fatalf("ClearUser failed: %v", err)
+	}
+
+	if _, err := c.config.Store.GetCredential(CredentialTypePIN, 1); !errors.Is(err, ErrCredentialNotFound) {
+		t.Errorf("credential should be cleared, err = %v", err)
+	}
+}
+
+func TestClearCredential_RemovesEmptyUser(t *testing.T) {
+	c := newTestCluster(nil)
+	pin1 := CredentialRef{Type: CredentialTypePIN, Index: 1}
+
+	setCredential(t, c, DataOperationAdd, pin1, []byte("1234"), nil)
+
+	_, err := invoke(t, c, CmdClearCredential, true, func(w *tlv.Writer) error {
+		return marshalCredentialRef(w, tlv.ContextTag(0), pin1)
+	})
+	if err != nil {
+		t.Fatalf("ClearCredential failed: %v", err)
+	}
+
+	if _, err := c.config.Store.GetUser(1); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("user without credentials should be removed, err = %v", err)
+	}
+}
+
+func TestGetCredentialStatus(t *testing.T) {
+	c := newTestCluster(nil)
+	pin1 := CredentialRef{Type: CredentialTypePIN, Index: 1}
+
+	setCredential(t, c, DataOperationAdd, pin1, []byte("1234"), nil)
+
+	respData, err := invoke(t, c, CmdGetCredentialStatus, false, func(w *tlv.Writer) error {
+		return marshalCredentialRef(w, tlv.ContextTag(0), pin1)
+	})
+	if err != nil {
+		t.Fatalf("GetCredentialStatus failed: %v", err)
+	}
+
+	r := tlv.NewReader(bytes.NewReader(respData))
+	if err := r.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.EnterContainer(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Next(); err != nil {
+		t.Fatal(err)
+	}
+	exists, err := r.Bool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists {
+		t.Error("CredentialExists = false, want true")
+	}
+}
+
+func TestWriteAttribute_WrongCodeEntryLimit(t *testing.T) {
+	c := newTestCluster(nil)
+
+	write := func(val uint64) error {
+		var buf bytes.Buffer
+		w := tlv.NewWriter(&buf)
+		if err := w.PutUint(tlv.Anonymous(), val); err != nil {
+			t.Fatal(err)
+		}
+		req := datamodel.WriteAttributeRequest{
+			Path: datamodel.ConcreteDataAttributePath{
+				ConcreteAttributePath: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: AttrWrongCodeEntryLimit},
+			},
+		}
+		return c.WriteAttribute(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes())))
+	}
+
+	if err := write(0); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("write 0: err = %v, want ErrConstraintError", err)
+	}
+	if err := write(8); err != nil {
+		t.Fatalf("write 8 failed: %v", err)
+	}
+	if got := readUint(t, c, AttrWrongCodeEntryLimit); got != 8 {
+		t.Errorf("WrongCodeEntryLimit = %d, want 8", got)
+	}
+}
+
+func TestMemoryCredentialStore_Isolation(t *testing.T) {
+	s := NewMemoryCredentialStore()
+
+	data := []byte("1234")
+	if err := s.SetCredential(&Credential{Type: CredentialTypePIN, Index: 1, Data: data}); err != nil {
+		t.Fatal(err)
+	}
+	data[0] = 'X'
+
+	cred, err := s.GetCredential(CredentialTypePIN, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(cred.Data) != "1234" {
+		t.Errorf("stored data = %q, want 1234", cred.Data)
+	}
+}
@@ -0,0 +1,1015 @@
+package doorlock
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/backkem/matter/pkg/clusters"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// LockDoorRequest represents the LockDoor and UnlockDoor command requests
+// (Spec 5.2.10.1, 5.2.10.2).
+type LockDoorRequest struct {
+	PINCode []byte // nil if omitted
+}
+
+// SetUserRequest represents the SetUser command request (Spec 5.2.10.34).
+// Nil fields were null in the request.
+type SetUserRequest struct {
+	OperationType  DataOperationType
+	UserIndex      uint16
+	UserName       *string
+	UserUniqueID   *uint32
+	UserStatus     *UserStatus
+	UserType       *UserType
+	CredentialRule *CredentialRule
+}
+
+// GetUserResponse represents the GetUserResponse command (Spec 5.2.10.36).
+// User is nil when the index is unoccupied.
+type GetUserResponse struct {
+	UserIndex     uint16
+	User          *User
+	NextUserIndex *uint16
+}
+
+// SetCredentialRequest represents the SetCredential command request (Spec 5.2.10.40).
+type SetCredentialRequest struct {
+	OperationType  DataOperationType
+	Credential     CredentialRef
+	CredentialData []byte
+	UserIndex      *uint16
+	UserStatus     *UserStatus
+	UserType       *UserType
+}
+
+// SetCredentialResponse represents the SetCredentialResponse command (Spec 5.2.10.41).
+type SetCredentialResponse struct {
+	Status              DlStatus
+	UserIndex           *uint16
+	NextCredentialIndex *uint16
+}
+
+// GetCredentialStatusResponse represents the GetCredentialStatusResponse command
+// (Spec 5.2.10.43).
+type GetCredentialStatusResponse struct {
+	CredentialExists        bool
+	UserIndex               *uint16
+	CreatorFabricIndex      *fabric.FabricIndex
+	LastModifiedFabricIndex *fabric.FabricIndex
+	NextCredentialIndex     *uint16
+}
+
+// handleSetUser handles the SetUser command.
+//
+// Spec: Section 5.2.10.34
+func (c *Cluster) handleSetUser(req datamodel.InvokeRequest, r *tlv.Reader) error {
+	if err := clusters.RequireTimed(req); err != nil {
+		return err
+	}
+
+	var setReq SetUserRequest
+	if err := decodeSetUserRequest(r, &setReq); err != nil {
+		return err
+	}
+
+	if !c.validUserIndex(setReq.UserIndex) {
+		return datamodel.ErrInvalidCommand
+	}
+
+	fabricIndex := req.FabricIndex()
+	store := c.config.Store
+
+	existing, err := store.GetUser(setReq.UserIndex)
+	if err != nil && !errors.Is(err, ErrUserNotFound) {
+		return err
+	}
+
+	var user *User
+	switch setReq.OperationType {
+	case DataOperationAdd:
+		if existing != nil {
+			return ErrOccupied
+		}
+		user = &User{
+			UserIndex:          setReq.UserIndex,
+			UserStatus:         UserStatusOccupiedEnabled,
+			UserType:           UserTypeUnrestricted,
+			CredentialRule:     CredentialRuleSingle,
+			CreatorFabricIndex: fabricIndex,
+		}
+
+	case DataOperationModify:
+		if existing == nil {
+			return datamodel.ErrInvalidCommand
+		}
+		user = existing
+
+	default:
+		return datamodel.ErrInvalidCommand
+	}
+
+	if setReq.UserName != nil {
+		if len(*setReq.UserName) > 10 {
+			return datamodel.ErrInvalidCommand
+		}
+		user.UserName = *setReq.UserName
+	}
+	if setReq.UserUniqueID != nil {
+		id := *setReq.UserUniqueID
+		user.UserUniqueID = &id
+	}
+	if setReq.UserStatus != nil {
+		if *setReq.UserStatus == UserStatusAvailable {
+			return datamodel.ErrInvalidCommand
+		}
+		user.UserStatus = *setReq.UserStatus
+	}
+	if setReq.UserType != nil {
+		user.UserType = *setReq.UserType
+	}
+	if setReq.CredentialRule != nil {
+		user.CredentialRule = *setReq.CredentialRule
+	}
+	user.LastModifiedFabricIndex = fabricIndex
+
+	if err := store.SetUser(user); err != nil {
+		return err
+	}
+
+	c.emitUserChange(LockDataTypeUserIndex, setReq.OperationType, setReq.UserIndex, setReq.UserIndex, &req)
+	return nil
+}
+
+// handleGetUser handles the GetUser command.
+//
+// Spec: Section 5.2.10.35
+func (c *Cluster) handleGetUser(req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	userIndex, err := decodeUserIndexRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if !c.validUserIndex(userIndex) {
+		return nil, datamodel.ErrInvalidCommand
+	}
+
+	user, err := c.config.Store.GetUser(userIndex)
+	if err != nil && !errors.Is(err, ErrUserNotFound) {
+		return nil, err
+	}
+
+	resp := GetUserResponse{
+		UserIndex: userIndex,
+		User:      user,
+	}
+
+	for next := userIndex + 1; next <= c.config.NumberOfTotalUsersSupported; next++ {
+		if _, err := c.config.Store.GetUser(next); errors.Is(err, ErrUserNotFound) {
+			resp.NextUserIndex = &next
+			break
+		}
+	}
+
+	return encodeGetUserResponse(resp)
+}
+
+// handleClearUser handles the ClearUser command.
+//
+// Spec: Section 5.2.10.37
+func (c *Cluster) handleClearUser(req datamodel.InvokeRequest, r *tlv.Reader) error {
+	if err := clusters.RequireTimed(req); err != nil {
+		return err
+	}
+
+	userIndex, err := decodeUserIndexRequest(r)
+	if err != nil {
+		return err
+	}
+
+	if userIndex == clearAllIndex {
+		for idx := uint16(1); idx <= c.config.NumberOfTotalUsersSupported; idx++ {
+			if err := c.clearUser(idx); err != nil {
+				return err
+			}
+		}
+		c.emitUserChange(LockDataTypeUserIndex, DataOperationClear, clearAllIndex, clearAllIndex, &req)
+		return nil
+	}
+
+	if !c.validUserIndex(userIndex) {
+		return datamodel.ErrInvalidCommand
+	}
+
+	if err := c.clearUser(userIndex); err != nil {
+		return err
+	}
+
+	c.emitUserChange(LockDataTypeUserIndex, DataOperationClear, userIndex, userIndex, &req)
+	return nil
+}
+
+// clearUser removes a user and all credentials associated with it.
+func (c *Cluster) clearUser(userIndex uint16) error {
+	user, err := c.config.Store.GetUser(userIndex)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range user.Credentials {
+		if err := c.config.Store.ClearCredential(ref.Type, ref.Index); err != nil {
+			return err
+		}
+	}
+	return c.config.Store.ClearUser(userIndex)
+}
+
+// handleSetCredential handles the SetCredential command.
+//
+// Spec: Section 5.2.10.40
+func (c *Cluster) handleSetCredential(req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	if err := clusters.RequireTimed(req); err != nil {
+		return nil, err
+	}
+
+	var setReq SetCredentialRequest
+	if err := decodeSetCredentialRequest(r, &setReq); err != nil {
+		return nil, err
+	}
+
+	resp := c.setCredential(req.FabricIndex(), &setReq)
+	resp.NextCredentialIndex = c.nextFreeCredentialIndex(setReq.Credential)
+
+	if resp.Status == DlStatusSuccess {
+		c.emitUserChange(credentialDataType(setReq.Credential.Type), setReq.OperationType, *resp.UserIndex, setReq.Credential.Index, &req)
+	}
+
+	return encodeSetCredentialResponse(resp)
+}
+
+// setCredential applies a SetCredential request and returns the response status.
+func (c *Cluster) setCredential(fabricIndex fabric.FabricIndex, setReq *SetCredentialRequest) SetCredentialResponse {
+	ref := setReq.Credential
+	store := c.config.Store
+
+	if !c.validCredential(ref) || !c.validCredentialData(ref.Type, setReq.CredentialData) {
+		return SetCredentialResponse{Status: DlStatusInvalidField}
+	}
+	if setReq.UserIndex != nil && !c.validUserIndex(*setReq.UserIndex) {
+		return SetCredentialResponse{Status: DlStatusInvalidField}
+	}
+
+	existing, err := store.GetCredential(ref.Type, ref.Index)
+	if err != nil && !errors.Is(err, ErrCredentialNotFound) {
+		return SetCredentialResponse{Status: DlStatusFailure}
+	}
+
+	duplicate, err := c.matchCredential(ref.Type, setReq.CredentialData)
+	if err != nil {
+		return SetCredentialResponse{Status: DlStatusFailure}
+	}
+	if duplicate != nil && duplicate.Index != ref.Index {
+		return SetCredentialResponse{Status: DlStatusDuplicate}
+	}
+
+	switch setReq.OperationType {
+	case DataOperationAdd:
+		if existing != nil {
+			return SetCredentialResponse{Status: DlStatusOccupied}
+		}
+		return c.addCredential(fabricIndex, setReq)
+
+	case DataOperationModify:
+		if existing == nil || setReq.UserIndex == nil || existing.UserIndex != *setReq.UserIndex {
+			return SetCredentialResponse{Status: DlStatusInvalidField}
+		}
+		existing.Data = setReq.CredentialData
+		existing.LastModifiedFabricIndex = fabricIndex
+		if err := store.SetCredential(existing); err != nil {
+			return SetCredentialResponse{Status: DlStatusFailure}
+		}
+		userIndex := existing.UserIndex
+		return SetCredentialResponse{Status: DlStatusSuccess, UserIndex: &userIndex}
+
+	default:
+		return SetCredentialResponse{Status: DlStatusInvalidField}
+	}
+}
+
+// addCredential stores a new credential, creating its user if needed.
+func (c *Cluster) addCredential(fabricIndex fabric.FabricIndex, setReq *SetCredentialRequest) SetCredentialResponse {
+	store := c.config.Store
+
+	var user *User
+	if setReq.UserIndex != nil {
+		u, err := store.GetUser(*setReq.UserIndex)
+		if err != nil && !errors.Is(err, ErrUserNotFound) {
+			return SetCredentialResponse{Status: DlStatusFailure}
+		}
+		if u != nil && (setReq.UserStatus != nil || setReq.UserType != nil) {
+			return SetCredentialResponse{Status: DlStatusInvalidField}
+		}
+		if u == nil {
+			user = c.newUser(*setReq.UserIndex, fabricIndex, setReq)
+		} else {
+			user = u
+		}
+	} else {
+		for idx := uint16(1); idx <= c.config.NumberOfTotalUsersSupported; idx++ {
+			if _, err := store.GetUser(idx); errors.Is(err, ErrUserNotFound) {
+				user = c.newUser(idx, fabricIndex, setReq)
+				break
+			}
+		}
+		if user == nil {
+			return SetCredentialResponse{Status: DlStatusResourceExhausted}
+		}
+	}
+
+	if len(user.Credentials) >= int(c.config.NumberOfCredentialsSupportedPerUser) {
+		return SetCredentialResponse{Status: DlStatusResourceExhausted}
+	}
+
+	cred := &Credential{
+		Type:                    setReq.Credential.Type,
+		Index:                   setReq.Credential.Index,
+		Data:                    setReq.CredentialData,
+		UserIndex:               user.UserIndex,
+		CreatorFabricIndex:      fabricIndex,
+		LastModifiedFabricIndex: fabricIndex,
+	}
+	if err := store.SetCredential(cred); err != nil {
+		return SetCredentialResponse{Status: DlStatusFailure}
+	}
+
+	user.Credentials = append(user.Credentials, setReq.Credential)
+	user.LastModifiedFabricIndex = fabricIndex
+	if err := store.SetUser(user); err != nil {
+		return SetCredentialResponse{Status: DlStatusFailure}
+	}
+
+	userIndex := user.UserIndex
+	return SetCredentialResponse{Status: DlStatusSuccess, UserIndex: &userIndex}
+}
+
+// newUser builds a user implicitly created by SetCredential.
+func (c *Cluster) newUser(index uint16, fabricIndex fabric.FabricIndex, setReq *SetCredentialRequest) *User {
+	user := &User{
+		UserIndex:          index,
+		UserStatus:         UserStatusOccupiedEnabled,
+		UserType:           UserTypeUnrestricted,
+		CredentialRule:     CredentialRuleSingle,
+		CreatorFabricIndex: fabricIndex,
+	}
+	if setReq.UserStatus != nil {
+		user.UserStatus = *setReq.UserStatus
+	}
+	if setReq.UserType != nil {
+		user.UserType = *setReq.UserType
+	}
+	return user
+}
+
+// handleGetCredentialStatus handles the GetCredentialStatus command.
+//
+// Spec: Section 5.2.10.42
+func (c *Cluster) handleGetCredentialStatus(req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	ref, err := decodeCredentialRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	if ref == nil || !c.validCredential(*ref) {
+		return nil, datamodel.ErrInvalidCommand
+	}
+
+	cred, err := c.config.Store.GetCredential(ref.Type, ref.Index)
+	if err != nil && !errors.Is(err, ErrCredentialNotFound) {
+		return nil, err
+	}
+
+	resp := GetCredentialStatusResponse{
+		NextCredentialIndex: c.nextFreeCredentialIndex(*ref),
+	}
+	if cred != nil {
+		resp.CredentialExists = true
+		resp.UserIndex = &cred.UserIndex
+		resp.CreatorFabricIndex = &cred.CreatorFabricIndex
+		resp.LastModifiedFabricIndex = &cred.LastModifiedFabricIndex
+	}
+
+	return encodeGetCredentialStatusResponse(resp)
+}
+
+// handleClearCredential handles the ClearCredential command.
+// A null Credential clears all credentials except ProgrammingPIN.
+//
+// Spec: Section 5.2.10.44
+func (c *Cluster) handleClearCredential(req datamodel.InvokeRequest, r *tlv.Reader) error {
+	if err := clusters.RequireTimed(req); err != nil {
+		return err
+	}
+
+	ref, err := decodeCredentialRequest(r)
+	if err != nil {
+		return err
+	}
+
+	if ref == nil {
+		for _, credType := range []CredentialType{CredentialTypePIN, CredentialTypeRFID} {
+			creds, err := c.config.Store.Credentials(credType)
+			if err != nil {
+				return err
+			}
+			for _, cred := range creds {
+				if err := c.clearCredential(CredentialRef{Type: cred.Type, Index: cred.Index}); err != nil {
+					return err
+				}
+			}
+			if len(creds) > 0 {
+				c.emitUserChange(credentialDataType(credType), DataOperationClear, clearAllIndex, clearAllIndex, &req)
+			}
+		}
+		return nil
+	}
+
+	if !c.validCredential(*ref) {
+		return datamodel.ErrInvalidCommand
+	}
+
+	cred, err := c.config.Store.GetCredential(ref.Type, ref.Index)
+	if errors.Is(err, ErrCredentialNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := c.clearCredential(*ref); err != nil {
+		return err
+	}
+
+	c.emitUserChange(credentialDataType(ref.Type), DataOperationClear, cred.UserIndex, ref.Index, &req)
+	return nil
+}
+
+// clearCredential removes a credential and detaches it from its user.
+// A user left without credentials is removed as well.
+func (c *Cluster) clearCredential(ref CredentialRef) error {
+	store := c.config.Store
+
+	cred, err := store.GetCredential(ref.Type, ref.Index)
+	if errors.Is(err, ErrCredentialNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := store.ClearCredential(ref.Type, ref.Index); err != nil {
+		return err
+	}
+
+	user, err := store.GetUser(cred.UserIndex)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	remaining := user.Credentials[:0]
+	for _, r := range user.Credentials {
+		if r != ref {
+			remaining = append(remaining, r)
+		}
+	}
+	if len(remaining) == 0 {
+		return store.ClearUser(user.UserIndex)
+	}
+	user.Credentials = remaining
+	return store.SetUser(user)
+}
+
+// validUserIndex returns true if index is within the user table.
+func (c *Cluster) validUserIndex(index uint16) bool {
+	return index >= 1 && index <= c.config.NumberOfTotalUsersSupported
+}
+
+// validCredential returns true if the credential type is supported and
+// the index is within its table.
+func (c *Cluster) validCredential(ref CredentialRef) bool {
+	slots := c.credentialSlots(ref.Type)
+	return ref.Index >= 1 && ref.Index <= slots
+}
+
+// credentialSlots returns the number of slots for a credential type,
+// or 0 if the type is not supported.
+func (c *Cluster) credentialSlots(credType CredentialType) uint16 {
+	switch {
+	case credType == CredentialTypePIN && c.hasFeature(FeaturePINCredential):
+		return c.config.NumberOfPINUsersSupported
+	case credType == CredentialTypeRFID && c.hasFeature(FeatureRFIDCredential):
+		return c.config.NumberOfRFIDUsersSupported
+	default:
+		return 0
+	}
+}
+
+// validCredentialData checks the credential length constraints.
+func (c *Cluster) validCredentialData(credType CredentialType, data []byte) bool {
+	switch credType {
+	case CredentialTypePIN:
+		return len(data) >= int(c.config.MinPINCodeLength) && len(data) <= int(c.config.MaxPINCodeLength)
+	case CredentialTypeRFID:
+		return len(data) >= int(c.config.MinRFIDCodeLength) && len(data) <= int(c.config.MaxRFIDCodeLength)
+	default:
+		return false
+	}
+}
+
+// nextFreeCredentialIndex returns the next unoccupied slot after ref, or nil.
+func (c *Cluster) nextFreeCredentialIndex(ref CredentialRef) *uint16 {
+	slots := c.credentialSlots(ref.Type)
+	for next := ref.Index + 1; next <= slots && next > ref.Index; next++ {
+		if _, err := c.config.Store.GetCredential(ref.Type, next); errors.Is(err, ErrCredentialNotFound) {
+			return &next
+		}
+	}
+	return nil
+}
+
+// credentialDataType maps a credential type to its LockDataType.
+func credentialDataType(credType CredentialType) LockDataType {
+	if credType == CredentialTypeRFID {
+		return LockDataTypeRFID
+	}
+	return LockDataTypePIN
+}
+
+// emitUserChange emits a LockUserChange event for a remote change.
+func (c *Cluster) emitUserChange(dataType LockDataType, op DataOperationType, userIndex, dataIndex uint16, req *datamodel.InvokeRequest) {
+	ev := LockUserChangeEvent{
+		LockDataType:      dataType,
+		DataOperationType: op,
+		OperationSource:   OperationSourceRemote,
+		UserIndex:         &userIndex,
+		DataIndex:         &dataIndex,
+	}
+	ev.FabricIndex, ev.SourceNode = requestOrigin(req)
+
+	_ = c.emit(EventLockUserChange, datamodel.EventPriorityInfo, ev)
+}
+
+// decodeLockDoorRequest decodes a LockDoor/UnlockDoor request from TLV.
+// The command fields are optional, so an empty payload is accepted.
+func decodeLockDoorRequest(r *tlv.Reader, req *LockDoorRequest) error {
+	if r == nil {
+		return nil
+	}
+
+	// Enter the structure
+	if err := r.Next(); err != nil {
+		return nil // No fields
+	}
+
+	if r.Type() != tlv.ElementTypeStruct {
+		return datamodel.ErrInvalidCommand
+	}
+
+	if err := r.EnterContainer(); err != nil {
+		return err
+	}
+
+	// Read fields
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+
+		tag := r.Tag()
+		if !tag.IsContext() {
+			continue
+		}
+
+		switch tag.TagNumber() {
+		case 0: // PINCode
+			val, err := r.Bytes()
+			if err != nil {
+				return err
+			}
+			req.PINCode = val
+		}
+	}
+
+	return r.ExitContainer()
+}
+
+// decodeSetUserRequest decodes a SetUser request from TLV.
+func decodeSetUserRequest(r *tlv.Reader, req *SetUserRequest) error {
+	// Enter the structure
+	if err := r.Next(); err != nil {
+		return err
+	}
+
+	if r.Type() != tlv.ElementTypeStruct {
+		return datamodel.ErrInvalidCommand
+	}
+
+	if err := r.EnterContainer(); err != nil {
+		return err
+	}
+
+	// Read fields
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+
+		tag := r.Tag()
+		if !tag.IsContext() || r.Type() == tlv.ElementTypeNull {
+			continue
+		}
+
+		switch tag.TagNumber() {
+		case 0: // OperationType
+			val, err := r.Uint()
+			if err != nil {
+				return err
+			}
+			req.OperationType = DataOperationType(val)
+		case 1: // UserIndex
+			val, err := r.Uint()
+			if err != nil {
+				return err
+			}
+			req.UserIndex = uint16(val)
+		case 2: // UserName
+			val, err := r.String()
+			if err != nil {
+				return err
+			}
+			req.UserName = &val
+		case 3: // UserUniqueID
+			val, err := r.Uint()
+			if err != nil {
+				return err
+			}
+			id := uint32(val)
+			req.UserUniqueID = &id
+		case 4: // UserStatus
+			val, err := r.Uint()
+			if err != nil {
+				return err
+			}
+			status := UserStatus(val)
+			req.UserStatus = &status
+		case 5: // UserType
+			val, err := r.Uint()
+			if err != nil {
+				return err
+			}
+			userType := UserType(val)
+			req.UserType = &userType
+		case 6: // CredentialRule
+			val, err := r.Uint()
+			if err != nil {
+				return err
+			}
+			rule := CredentialRule(val)
+			req.CredentialRule = &rule
+		}
+	}
+
+	return r.ExitContainer()
+}
+
+// decodeUserIndexRequest decodes a GetUser/ClearUser request from TLV.
+func decodeUserIndexRequest(r *tlv.Reader) (uint16, error) {
+	// Enter the structure
+	if err := r.Next(); err != nil {
+		return 0, err
+	}
+
+	if r.Type() != tlv.ElementTypeStruct {
+		return 0, datamodel.ErrInvalidCommand
+	}
+
+	if err := r.EnterContainer(); err != nil {
+		return 0, err
+	}
+
+	var userIndex uint16
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+
+		tag := r.Tag()
+		if tag.IsContext() && tag.TagNumber() == 0 { // UserIndex
+			val, err := r.Uint()
+			if err != nil {
+				return 0, err
+			}
+			userIndex = uint16(val)
+		}
+	}
+
+	return userIndex, r.ExitContainer()
+}
+
+// decodeSetCredentialRequest decodes a SetCredential request from TLV.
+func decodeSetCredentialRequest(r *tlv.Reader, req *SetCredentialRequest) error {
+	// Enter the structure
+	if err := r.Next(); err != nil {
+		return err
+	}
+
+	if r.Type() != tlv.ElementTypeStruct {
+		return datamodel.ErrInvalidCommand
+	}
+
+	if err := r.EnterContainer(); err != nil {
+		return err
+	}
+
+	// Read fields
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+
+		tag := r.Tag()
+		if !tag.IsContext() || r.Type() == tlv.ElementTypeNull {
+			continue
+		}
+
+		switch tag.TagNumber() {
+		case 0: // OperationType
+			val, err := r.Uint()
+			if err != nil {
+				return err
+			}
+			req.OperationType = DataOperationType(val)
+		case 1: // Credential
+			ref, err := decodeCredentialRef(r)
+			if err != nil {
+				return err
+			}
+			req.Credential = ref
+		case 2: // CredentialData
+			val, err := r.Bytes()
+			if err != nil {
+				return err
+			}
+			req.CredentialData = val
+		case 3: // UserIndex
+			val, err := r.Uint()
+			if err != nil {
+				return err
+			}
+			idx := uint16(val)
+			req.UserIndex = &idx
+		case 4: // UserStatus
+			val, err := r.Uint()
+			if err != nil {
+				return err
+			}
+			status := UserStatus(val)
+			req.UserStatus = &status
+		case 5: // UserType
+			val, err := r.Uint()
+			if err != nil {
+				return err
+			}
+			userType := UserType(val)
+			req.UserType = &userType
+		}
+	}
+
+	return r.ExitContainer()
+}
+
+// decodeCredentialRequest decodes a GetCredentialStatus/ClearCredential
+// request from TLV. Returns nil if the Credential field is null.
+func decodeCredentialRequest(r *tlv.Reader) (*CredentialRef, error) {
+	// Enter the structure
+	if err := r.Next(); err != nil {
+		return nil, err
+	}
+
+	if r.Type() != tlv.ElementTypeStruct {
+		return nil, datamodel.ErrInvalidCommand
+	}
+
+	if err := r.EnterContainer(); err != nil {
+		return nil, err
+	}
+
+	var result *CredentialRef
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+
+		tag := r.Tag()
+		if tag.IsContext() && tag.TagNumber() == 0 && r.Type() == tlv.ElementTypeStruct { // Credential
+			ref, err := decodeCredentialRef(r)
+			if err != nil {
+				return nil, err
+			}
+			result = &ref
+		}
+	}
+
+	return result, r.ExitContainer()
+}
+
+// decodeCredentialRef decodes a CredentialStruct at the current element.
+func decodeCredentialRef(r *tlv.Reader) (CredentialRef, error) {
+	var ref CredentialRef
+
+	if r.Type() != tlv.ElementTypeStruct {
+		return ref, datamodel.ErrInvalidCommand
+	}
+
+	if err := r.EnterContainer(); err != nil {
+		return ref, err
+	}
+
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+
+		tag := r.Tag()
+		if !tag.IsContext() {
+			continue
+		}
+
+		switch tag.TagNumber() {
+		case 0: // CredentialType
+			val, err := r.Uint()
+			if err != nil {
+				return ref, err
+			}
+			ref.Type = CredentialType(val)
+		case 1: // CredentialIndex
+			val, err := r.Uint()
+			if err != nil {
+				return ref, err
+			}
+			ref.Index = uint16(val)
+		}
+	}
+
+	return ref, r.ExitContainer()
+}
+
+// encodeGetUserResponse encodes a GetUserResponse to TLV.
+func encodeGetUserResponse(resp GetUserResponse) ([]byte, error) {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return nil, err
+	}
+
+	// UserIndex (field 0)
+	if err := w.PutUint(tlv.ContextTag(0), uint64(resp.UserIndex)); err != nil {
+		return nil, err
+	}
+
+	// Fields 1-8 are null for an unoccupied index
+	if resp.User == nil {
+		for tag := uint8(1); tag <= 8; tag++ {
+			if err := w.PutNull(tlv.ContextTag(tag)); err != nil {
+				return nil, err
+			}
+		}
+	} else if err := encodeUserFields(w, resp.User); err != nil {
+		return nil, err
+	}
+
+	// NextUserIndex (field 9)
+	if err := putNullableUint16(w, tlv.ContextTag(9), resp.NextUserIndex); err != nil {
+		return nil, err
+	}
+
+	if err := w.EndContainer(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// encodeUserFields writes GetUserResponse fields 1-8 for an occupied user.
+func encodeUserFields(w *tlv.Writer, user *User) error {
+	if err := w.PutString(tlv.ContextTag(1), user.UserName); err != nil {
+		return err
+	}
+	if user.UserUniqueID == nil {
+		if err := w.PutNull(tlv.ContextTag(2)); err != nil {
+			return err
+		}
+	} else if err := w.PutUint(tlv.ContextTag(2), uint64(*user.UserUniqueID)); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(3), uint64(user.UserStatus)); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(4), uint64(user.UserType)); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(5), uint64(user.CredentialRule)); err != nil {
+		return err
+	}
+	if len(user.Credentials) == 0 {
+		if err := w.PutNull(tlv.ContextTag(6)); err != nil {
+			return err
+		}
+	} else if err := marshalCredentialList(w, tlv.ContextTag(6), user.Credentials); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(7), uint64(user.CreatorFabricIndex)); err != nil {
+		return err
+	}
+	return w.PutUint(tlv.ContextTag(8), uint64(user.LastModifiedFabricIndex))
+}
+
+// encodeSetCredentialResponse encodes a SetCredentialResponse to TLV.
+func encodeSetCredentialResponse(resp SetCredentialResponse) ([]byte, error) {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return nil, err
+	}
+
+	// Status (field 0)
+	if err := w.PutUint(tlv.ContextTag(0), uint64(resp.Status)); err != nil {
+		return nil, err
+	}
+
+	// UserIndex (field 1)
+	if err := putNullableUint16(w, tlv.ContextTag(1), resp.UserIndex); err != nil {
+		return nil, err
+	}
+
+	// NextCredentialIndex (field 2)
+	if err := putNullableUint16(w, tlv.ContextTag(2), resp.NextCredentialIndex); err != nil {
+		return nil, err
+	}
+
+	if err := w.EndContainer(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// encodeGetCredentialStatusResponse encodes a GetCredentialStatusResponse to TLV.
+func encodeGetCredentialStatusResponse(resp GetCredentialStatusResponse) ([]byte, error) {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return nil, err
+	}
+
+	// CredentialExists (field 0)
+	if err := w.PutBool(tlv.ContextTag(0), resp.CredentialExists); err != nil {
+		return nil, err
+	}
+
+	// UserIndex, CreatorFabricIndex, LastModifiedFabricIndex (fields 1-3)
+	if err := putNullableUint16(w, tlv.ContextTag(1), resp.UserIndex); err != nil {
+		return nil, err
+	}
+	for i, idx := range []*fabric.FabricIndex{resp.CreatorFabricIndex, resp.LastModifiedFabricIndex} {
+		tag := tlv.ContextTag(uint8(2 + i))
+		if idx == nil {
+			if err := w.PutNull(tag); err != nil {
+				return nil, err
+			}
+		} else if err := w.PutUint(tag, uint64(*idx)); err != nil {
+			return nil, err
+		}
+	}
+
+	// NextCredentialIndex (field 4)
+	if err := putNullableUint16(w, tlv.ContextTag(4), resp.NextCredentialIndex); err != nil {
+		return nil, err
+	}
+
+	if err := w.EndContainer(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,248 @@
+package doorlock
+
+import (
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// AlarmCode identifies the cause of a DoorLockAlarm event (Spec 5.2.6.1).
+type AlarmCode uint8
+
+const (
+	AlarmCodeLockJammed             AlarmCode = 0
+	AlarmCodeLockFactoryReset       AlarmCode = 1
+	AlarmCodeLockRadioPowerCycled   AlarmCode = 3
+	AlarmCodeWrongCodeEntryLimit    AlarmCode = 4
+	AlarmCodeFrontEscutcheonRemoved AlarmCode = 5
+	AlarmCodeDoorForcedOpen         AlarmCode = 6
+	AlarmCodeDoorAjar               AlarmCode = 7
+	AlarmCodeForcedUser             AlarmCode = 8
+)
+
+// LockOperationType is the kind of operation reported in events (Spec 5.2.6.9).
+type LockOperationType uint8
+
+const (
+	LockOperationTypeLock               LockOperationType = 0
+	LockOperationTypeUnlock             LockOperationType = 1
+	LockOperationTypeNonAccessUserEvent LockOperationType = 2
+	LockOperationTypeForcedUserEvent    LockOperationType = 3
+	LockOperationTypeUnlatch            LockOperationType = 4
+)
+
+// OperationSource is the origin of a lock operation (Spec 5.2.6.12).
+type OperationSource uint8
+
+const (
+	OperationSourceUnspecified       OperationSource = 0
+	OperationSourceManual            OperationSource = 1
+	OperationSourceProprietaryRemote OperationSource = 2
+	OperationSourceKeypad            OperationSource = 3
+	OperationSourceAuto              OperationSource = 4
+	OperationSourceButton            OperationSource = 5
+	OperationSourceSchedule          OperationSource = 6
+	OperationSourceRemote            OperationSource = 7
+	OperationSourceRFID              OperationSource = 8
+	OperationSourceBiometric         OperationSource = 9
+)
+
+// OperationError is the reason a lock operation failed (Spec 5.2.6.11).
+type OperationError uint8
+
+const (
+	OperationErrorUnspecified         OperationError = 0
+	OperationErrorInvalidCredential   OperationError = 1
+	OperationErrorDisabledUserDenied  OperationError = 2
+	OperationErrorRestricted          OperationError = 3
+	OperationErrorInsufficientBattery OperationError = 4
+)
+
+// LockDataType identifies the data changed in a LockUserChange event (Spec 5.2.6.7).
+type LockDataType uint8
+
+const (
+	LockDataTypeUnspecified     LockDataType = 0
+	LockDataTypeProgrammingCode LockDataType = 1
+	LockDataTypeUserIndex       LockDataType = 2
+	LockDataTypePIN             LockDataType = 6
+	LockDataTypeRFID            LockDataType = 7
+	LockDataTypeFingerprint     LockDataType = 8
+	LockDataTypeFingerVein      LockDataType = 9
+	LockDataTypeFace            LockDataType = 10
+)
+
+// DoorLockAlarmEvent is emitted on a critical lock condition (Spec 5.2.11.1).
+// Priority: CRITICAL
+type DoorLockAlarmEvent struct {
+	AlarmCode AlarmCode
+}
+
+// MarshalTLV implements the TLVMarshaler interface.
+func (e DoorLockAlarmEvent) MarshalTLV(w *tlv.Writer) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(e.AlarmCode)); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// LockOperationEvent is emitted when the lock is operated (Spec 5.2.11.3).
+// Priority: CRITICAL
+type LockOperationEvent struct {
+	LockOperationType LockOperationType
+	OperationSource   OperationSource
+	UserIndex         *uint16
+	FabricIndex       *fabric.FabricIndex
+	SourceNode        *uint64
+	Credentials       []CredentialRef // nil omits the field
+}
+
+// MarshalTLV implements the TLVMarshaler interface.
+func (e LockOperationEvent) MarshalTLV(w *tlv.Writer) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(e.LockOperationType)); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(1), uint64(e.OperationSource)); err != nil {
+		return err
+	}
+	if err := marshalOrigin(w, 2, e.UserIndex, e.FabricIndex, e.SourceNode); err != nil {
+		return err
+	}
+	if e.Credentials != nil {
+		if err := marshalCredentialList(w, tlv.ContextTag(5), e.Credentials); err != nil {
+			return err
+		}
+	}
+	return w.EndContainer()
+}
+
+// LockOperationErrorEvent is emitted when a lock operation fails (Spec 5.2.11.4).
+// Priority: CRITICAL
+type LockOperationErrorEvent struct {
+	LockOperationType LockOperationType
+	OperationSource   OperationSource
+	OperationError    OperationError
+	UserIndex         *uint16
+	FabricIndex       *fabric.FabricIndex
+	SourceNode        *uint64
+	Credentials       []CredentialRef // nil omits the field
+}
+
+// MarshalTLV implements the TLVMarshaler interface.
+func (e LockOperationErrorEvent) MarshalTLV(w *tlv.Writer) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(e.LockOperationType)); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(1), uint64(e.OperationSource)); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(2), uint64(e.OperationError)); err != nil {
+		return err
+	}
+	if err := marshalOrigin(w, 3, e.UserIndex, e.FabricIndex, e.SourceNode); err != nil {
+		return err
+	}
+	if e.Credentials != nil {
+		if err := marshalCredentialList(w, tlv.ContextTag(6), e.Credentials); err != nil {
+			return err
+		}
+	}
+	return w.EndContainer()
+}
+
+// LockUserChangeEvent is emitted when a user or credential changes (Spec 5.2.11.5).
+// Priority: INFO, Conformance: USR
+type LockUserChangeEvent struct {
+	LockDataType      LockDataType
+	DataOperationType DataOperationType
+	OperationSource   OperationSource
+	UserIndex         *uint16
+	FabricIndex       *fabric.FabricIndex
+	SourceNode        *uint64
+	DataIndex         *uint16
+}
+
+// MarshalTLV implements the TLVMarshaler interface.
+func (e LockUserChangeEvent) MarshalTLV(w *tlv.Writer) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(e.LockDataType)); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(1), uint64(e.DataOperationType)); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(2), uint64(e.OperationSource)); err != nil {
+		return err
+	}
+	if err := marshalOrigin(w, 3, e.UserIndex, e.FabricIndex, e.SourceNode); err != nil {
+		return err
+	}
+	if err := putNullableUint16(w, tlv.ContextTag(6), e.DataIndex); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// marshalOrigin writes the nullable UserIndex, FabricIndex and SourceNode
+// fields shared by the lock events, starting at context tag first.
+func marshalOrigin(w *tlv.Writer, first uint8, userIndex *uint16, fabricIndex *fabric.FabricIndex, sourceNode *uint64) error {
+	if err := putNullableUint16(w, tlv.ContextTag(first), userIndex); err != nil {
+		return err
+	}
+	if fabricIndex == nil {
+		if err := w.PutNull(tlv.ContextTag(first + 1)); err != nil {
+			return err
+		}
+	} else if err := w.PutUint(tlv.ContextTag(first+1), uint64(*fabricIndex)); err != nil {
+		return err
+	}
+	if sourceNode == nil {
+		return w.PutNull(tlv.ContextTag(first + 2))
+	}
+	return w.PutUint(tlv.ContextTag(first+2), *sourceNode)
+}
+
+// putNullableUint16 writes v, or null if v is nil.
+func putNullableUint16(w *tlv.Writer, tag tlv.Tag, v *uint16) error {
+	if v == nil {
+		return w.PutNull(tag)
+	}
+	return w.PutUint(tag, uint64(*v))
+}
+
+// marshalCredentialList writes a list of CredentialStruct (Spec 5.2.9.23).
+func marshalCredentialList(w *tlv.Writer, tag tlv.Tag, creds []CredentialRef) error {
+	if err := w.StartArray(tag); err != nil {
+		return err
+	}
+	for _, cred := range creds {
+		if err := marshalCredentialRef(w, tlv.Anonymous(), cred); err != nil {
+			return err
+		}
+	}
+	return w.EndContainer()
+}
+
+// marshalCredentialRef writes a single CredentialStruct.
+func marshalCredentialRef(w *tlv.Writer, tag tlv.Tag, cred CredentialRef) error {
+	if err := w.StartStructure(tag); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(cred.Type)); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(1), uint64(cred.Index)); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
@@ -0,0 +1,201 @@
+package doorlock
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/backkem/matter/pkg/fabric"
+)
+
+// Storage errors.
+var (
+	// ErrUserNotFound is returned when no user occupies the requested index.
+	ErrUserNotFound = errors.New("doorlock: user not found")
+
+	// ErrCredentialNotFound is returned when no credential occupies the requested slot.
+	ErrCredentialNotFound = errors.New("doorlock: credential not found")
+)
+
+// CredentialRef identifies a credential slot (Spec 5.2.9.23 CredentialStruct).
+type CredentialRef struct {
+	Type  CredentialType
+	Index uint16
+}
+
+// User is an entry in the lock's user table.
+//
+// Spec: Section 5.2.10.34 (SetUser)
+type User struct {
+	UserIndex               uint16
+	UserName                string
+	UserUniqueID            *uint32
+	UserStatus              UserStatus
+	UserType                UserType
+	CredentialRule          CredentialRule
+	Credentials             []CredentialRef
+	CreatorFabricIndex      fabric.FabricIndex
+	LastModifiedFabricIndex fabric.FabricIndex
+}
+
+// Clone returns a deep copy of the user.
+func (u *User) Clone() *User {
+	clone := *u
+	if u.UserUniqueID != nil {
+		id := *u.UserUniqueID
+		clone.UserUniqueID = &id
+	}
+	if u.Credentials != nil {
+		clone.Credentials = make([]CredentialRef, len(u.Credentials))
+		copy(clone.Credentials, u.Credentials)
+	}
+	return &clone
+}
+
+// Credential is an entry in the lock's credential table.
+//
+// Data holds the secret (PIN digits, RFID UID). It is never returned
+// over the Interaction Model.
+//
+// Spec: Section 5.2.10.40 (SetCredential)
+type Credential struct {
+	Type                    CredentialType
+	Index                   uint16
+	Data                    []byte
+	UserIndex               uint16
+	CreatorFabricIndex      fabric.FabricIndex
+	LastModifiedFabricIndex fabric.FabricIndex
+}
+
+// Clone returns a deep copy of the credential.
+func (c *Credential) Clone() *Credential {
+	clone := *c
+	clone.Data = make([]byte, len(c.Data))
+	copy(clone.Data, c.Data)
+	return &clone
+}
+
+// CredentialStore persists the user and credential tables.
+//
+// Implementations back this with secure storage (secure element, encrypted
+// KVS, ...) since credential data is secret. All methods must be safe for
+// concurrent use and must not retain or return caller-owned slices.
+type CredentialStore interface {
+	// GetUser returns the user at index, or ErrUserNotFound.
+	GetUser(index uint16) (*User, error)
+
+	// SetUser stores or replaces the user at user.UserIndex.
+	SetUser(user *User) error
+
+	// ClearUser removes the user at index. Clearing an empty index is not an error.
+	ClearUser(index uint16) error
+
+	// GetCredential returns the credential in the given slot, or ErrCredentialNotFound.
+	GetCredential(credType CredentialType, index uint16) (*Credential, error)
+
+	// SetCredential stores or replaces the credential in its slot.
+	SetCredential(cred *Credential) error
+
+	// ClearCredential removes the credential in the given slot.
+	// Clearing an empty slot is not an error.
+	ClearCredential(credType CredentialType, index uint16) error
+
+	// Credentials returns all stored credentials of the given type, ordered by index.
+	Credentials(credType CredentialType) ([]*Credential, error)
+}
+
+// MemoryCredentialStore is an in-memory CredentialStore.
+// Useful for testing and development. Data is lost when the process exits.
+//
+// All methods are safe for concurrent use.
+type MemoryCredentialStore struct {
+	mu          sync.RWMutex
+	users       map[uint16]*User
+	credentials map[CredentialRef]*Credential
+}
+
+// NewMemoryCredentialStore creates a new in-memory credential store.
+func NewMemoryCredentialStore() *MemoryCredentialStore {
+	return &MemoryCredentialStore{
+		users:       make(map[uint16]*User),
+		credentials: make(map[CredentialRef]*Credential),
+	}
+}
+
+// GetUser implements CredentialStore.
+func (m *MemoryCredentialStore) GetUser(index uint16) (*User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	u, ok := m.users[index]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return u.Clone(), nil
+}
+
+// SetUser implements CredentialStore.
+func (m *MemoryCredentialStore) SetUser(user *User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.users[user.UserIndex] = user.Clone()
+	return nil
+}
+
+// ClearUser implements CredentialStore.
+func (m *MemoryCredentialStore) ClearUser(index uint16) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.users, index)
+	return nil
+}
+
+// GetCredential implements CredentialStore.
+func (m *MemoryCredentialStore) GetCredential(credType CredentialType, index uint16) (*Credential, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c, ok := m.credentials[CredentialRef{Type: credType, Index: index}]
+	if !ok {
+		return nil, ErrCredentialNotFound
+	}
+	return c.Clone(), nil
+}
+
+// SetCredential implements CredentialStore.
+func (m *MemoryCredentialStore) SetCredential(cred *Credential) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.credentials[CredentialRef{Type: cred.Type, Index: cred.Index}] = cred.Clone()
+	return nil
+}
+
+// ClearCredential implements CredentialStore.
+func (m *MemoryCredentialStore) ClearCredential(credType CredentialType, index uint16) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.credentials, CredentialRef{Type: credType, Index: index})
+	return nil
+}
+
+// Credentials implements CredentialStore.
+func (m *MemoryCredentialStore) Credentials(credType CredentialType) ([]*Credential, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]*Credential, 0)
+	for ref, c := range m.credentials {
+		if ref.Type == credType {
+			result = append(result, c.Clone())
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Index < result[j].Index })
+	return result, nil
+}
+
+// Verify MemoryCredentialStore implements CredentialStore.
+var _ CredentialStore = (*MemoryCredentialStore)(nil)
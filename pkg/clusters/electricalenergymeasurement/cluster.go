@@ -0,0 +1,531 @@
+// Package electricalenergymeasurement implements the Electrical Energy
+// Measurement Cluster (0x0091).
+//
+// The cluster reports energy imported from and exported to the grid, either
+// as cumulative totals since installation or last reset (CUME) or as the
+// energy of a measurement period (PERE). Each report updates the matching
+// attributes and emits a CumulativeEnergyMeasured or PeriodicEnergyMeasured
+// event.
+//
+// Cumulative values are monotonic: a report lower than the previous one is
+// rejected unless ResetCumulativeEnergy was called first, which also records
+// the reset time in CumulativeEnergyReset.
+//
+// Spec Reference: Section 2.12
+//
+// C++ Reference: src/app/clusters/electrical-energy-measurement-server/electrical-energy-measurement-server.cpp
+package electricalenergymeasurement
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/backkem/matter/pkg/clusters"
+	"github.com/backkem/matter/pkg/credentials"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x0091
+	ClusterRevision uint16              = 1
+)
+
+// Attribute IDs (Spec 2.12.6).
+const (
+	AttrAccuracy                 datamodel.AttributeID = 0x0000
+	AttrCumulativeEnergyImported datamodel.AttributeID = 0x0001
+	AttrCumulativeEnergyExported datamodel.AttributeID = 0x0002
+	AttrPeriodicEnergyImported   datamodel.AttributeID = 0x0003
+	AttrPeriodicEnergyExported   datamodel.AttributeID = 0x0004
+	AttrCumulativeEnergyReset    datamodel.AttributeID = 0x0005
+)
+
+// Event IDs (Spec 2.12.7).
+const (
+	EventCumulativeEnergyMeasured datamodel.EventID = 0x00
+	EventPeriodicEnergyMeasured   datamodel.EventID = 0x01
+)
+
+// Feature bits (Spec 2.12.4).
+type Feature uint32
+
+const (
+	// FeatureImportedEnergy reports energy consumed from the grid (IMPE).
+	FeatureImportedEnergy Feature = 1 << 0
+
+	// FeatureExportedEnergy reports energy delivered to the grid (EXPE).
+	FeatureExportedEnergy Feature = 1 << 1
+
+	// FeatureCumulativeEnergy reports cumulative totals (CUME).
+	FeatureCumulativeEnergy Feature = 1 << 2
+
+	// FeaturePeriodicEnergy reports per-period energy (PERE).
+	FeaturePeriodicEnergy Feature = 1 << 3
+)
+
+// Errors returned by configuration and the device-side API.
+var (
+	ErrInvalidFeatures     = errors.New("electricalenergymeasurement: invalid feature combination")
+	ErrInvalidAccuracy     = errors.New("electricalenergymeasurement: accuracy must be of type ElectricalEnergy")
+	ErrFeatureNotSupported = errors.New("electricalenergymeasurement: feature not supported")
+	ErrEnergyOutOfRange    = errors.New("electricalenergymeasurement: energy out of range")
+	ErrEnergyDecreased     = errors.New("electricalenergymeasurement: cumulative energy decreased without reset")
+)
+
+// EnergyMeasurement is an EnergyMeasurementStruct (Spec 2.12.5.1).
+// At least one of the timestamp or systime pairs should be set.
+type EnergyMeasurement struct {
+	// Energy in mWh.
+	Energy int64
+
+	// StartTimestamp and EndTimestamp are in seconds since the Matter epoch.
+	StartTimestamp *uint32
+	EndTimestamp   *uint32
+
+	// StartSystime and EndSystime are in milliseconds since boot.
+	StartSystime *uint64
+	EndSystime   *uint64
+}
+
+// Marshal writes an EnergyMeasurementStruct with the given tag.
+func (m EnergyMeasurement) Marshal(w *tlv.Writer, tag tlv.Tag) error {
+	if err := w.StartStructure(tag); err != nil {
+		return err
+	}
+	if err := w.PutInt(tlv.ContextTag(0), m.Energy); err != nil {
+		return err
+	}
+	timestamps := []*uint32{m.StartTimestamp, m.EndTimestamp}
+	for i, t := range timestamps {
+		if t == nil {
+			continue
+		}
+		if err := w.PutUint(tlv.ContextTag(uint8(1+i)), uint64(*t)); err != nil {
+			return err
+		}
+	}
+	systimes := []*uint64{m.StartSystime, m.EndSystime}
+	for i, s := range systimes {
+		if s == nil {
+			continue
+		}
+		if err := w.PutUint(tlv.ContextTag(uint8(3+i)), *s); err != nil {
+			return err
+		}
+	}
+	return w.EndContainer()
+}
+
+// CumulativeEnergyReset is a CumulativeEnergyResetStruct (Spec 2.12.5.2).
+// Nil fields were never reset.
+type CumulativeEnergyReset struct {
+	ImportedResetTimestamp *uint32
+	ExportedResetTimestamp *uint32
+	ImportedResetSystime   *uint64
+	ExportedResetSystime   *uint64
+}
+
+// marshal writes an anonymous CumulativeEnergyResetStruct.
+func (r CumulativeEnergyReset) marshal(w *tlv.Writer) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	timestamps := []*uint32{r.ImportedResetTimestamp, r.ExportedResetTimestamp}
+	for i, t := range timestamps {
+		tag := tlv.ContextTag(uint8(i))
+		var err error
+		if t == nil {
+			err = w.PutNull(tag)
+		} else {
+			err = w.PutUint(tag, uint64(*t))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	systimes := []*uint64{r.ImportedResetSystime, r.ExportedResetSystime}
+	for i, s := range systimes {
+		tag := tlv.ContextTag(uint8(2 + i))
+		var err error
+		if s == nil {
+			err = w.PutNull(tag)
+		} else {
+			err = w.PutUint(tag, *s)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return w.EndContainer()
+}
+
+// Config provides dependencies for the Electrical Energy Measurement cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to.
+	EndpointID datamodel.EndpointID
+
+	// FeatureMap indicates supported features. At least one of IMPE and
+	// EXPE, and at least one of CUME and PERE, must be set.
+	FeatureMap Feature
+
+	// Accuracy of the energy measurement. MeasurementType must be
+	// clusters.MeasurementTypeElectricalEnergy.
+	Accuracy clusters.MeasurementAccuracy
+
+	// EnableCumulativeEnergyReset adds the optional CumulativeEnergyReset
+	// attribute (CUME only).
+	EnableCumulativeEnergyReset bool
+
+	// EventPublisher for CumulativeEnergyMeasured and PeriodicEnergyMeasured.
+	// Optional - if nil, events are not emitted.
+	EventPublisher datamodel.EventPublisher
+}
+
+// Cluster implements the Electrical Energy Measurement cluster (0x0091).
+type Cluster struct {
+	*datamodel.ClusterBase
+	*datamodel.EventSource
+	config Config
+
+	// Mutable state (protected by mutex)
+	mu                 sync.Mutex
+	cumulativeImported *EnergyMeasurement
+	cumulativeExported *EnergyMeasurement
+	periodicImported   *EnergyMeasurement
+	periodicExported   *EnergyMeasurement
+	reset              CumulativeEnergyReset
+
+	// Pending resets allow the next cumulative report to decrease.
+	importedResetPending bool
+	exportedResetPending bool
+
+	// now returns the current time (for testing).
+	now func() time.Time
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Electrical Energy Measurement cluster. It returns an
+// error if the feature map or accuracy is invalid.
+func New(cfg Config) (*Cluster, error) {
+	if cfg.FeatureMap&(FeatureImportedEnergy|FeatureExportedEnergy) == 0 ||
+		cfg.FeatureMap&(FeatureCumulativeEnergy|FeaturePeriodicEnergy) == 0 {
+		return nil, ErrInvalidFeatures
+	}
+	if cfg.Accuracy.MeasurementType != clusters.MeasurementTypeElectricalEnergy {
+		return nil, ErrInvalidAccuracy
+	}
+	if err := cfg.Accuracy.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.FeatureMap&FeatureCumulativeEnergy == 0 {
+		cfg.EnableCumulativeEnergyReset = false
+	}
+
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		EventSource: datamodel.NewEventSource(),
+		config:      cfg,
+		now:         time.Now,
+	}
+
+	c.ClusterBase.SetFeatureMap(uint32(cfg.FeatureMap))
+
+	// Bind event source
+	if cfg.EventPublisher != nil {
+		c.EventSource.Bind(cfg.EndpointID, ClusterID, cfg.EventPublisher)
+		var events []datamodel.EventEntry
+		if c.hasFeature(FeatureCumulativeEnergy) {
+			events = append(events, datamodel.NewEventEntry(EventCumulativeEnergyMeasured, datamodel.EventPriorityInfo, datamodel.PrivilegeView, false))
+		}
+		if c.hasFeature(FeaturePeriodicEnergy) {
+			events = append(events, datamodel.NewEventEntry(EventPeriodicEnergyMeasured, datamodel.EventPriorityInfo, datamodel.PrivilegeView, false))
+		}
+		c.EventSource.RegisterEvents(events)
+	}
+
+	c.attrList = c.buildAttributeList()
+
+	return c, nil
+}
+
+// hasFeature returns true if the feature bit is set.
+func (c *Cluster) hasFeature(f Feature) bool {
+	return c.config.FeatureMap&f != 0
+}
+
+// buildAttributeList constructs the list of supported attributes.
+func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+	nullable := datamodel.AttrQualityNullable
+
+	attrs := []datamodel.AttributeEntry{
+		datamodel.NewReadOnlyAttribute(AttrAccuracy, datamodel.AttrQualityFixed, viewPriv),
+	}
+	imported := c.hasFeature(FeatureImportedEnergy)
+	exported := c.hasFeature(FeatureExportedEnergy)
+	if c.hasFeature(FeatureCumulativeEnergy) {
+		if imported {
+			attrs = append(attrs, datamodel.NewReadOnlyAttribute(AttrCumulativeEnergyImported, nullable, viewPriv))
+		}
+		if exported {
+			attrs = append(attrs, datamodel.NewReadOnlyAttribute(AttrCumulativeEnergyExported, nullable, viewPriv))
+		}
+	}
+	if c.hasFeature(FeaturePeriodicEnergy) {
+		if imported {
+			attrs = append(attrs, datamodel.NewReadOnlyAttribute(AttrPeriodicEnergyImported, nullable, viewPriv))
+		}
+		if exported {
+			attrs = append(attrs, datamodel.NewReadOnlyAttribute(AttrPeriodicEnergyExported, nullable, viewPriv))
+		}
+	}
+	if c.config.EnableCumulativeEnergyReset {
+		attrs = append(attrs, datamodel.NewReadOnlyAttribute(AttrCumulativeEnergyReset, nullable, viewPriv))
+	}
+
+	return datamodel.MergeAttributeLists(attrs)
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	return nil
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return nil
+}
+
+// hasAttribute returns true if attr is in the attribute list.
+func (c *Cluster) hasAttribute(attr datamodel.AttributeID) bool {
+	for _, a := range c.attrList {
+		if a.ID == attr {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	if !c.hasAttribute(req.Path.Attribute) {
+		return datamodel.ErrUnsupportedAttribute
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch req.Path.Attribute {
+	case AttrAccuracy:
+		return c.config.Accuracy.MarshalTLV(w)
+	case AttrCumulativeEnergyImported:
+		return putMeasurement(w, c.cumulativeImported)
+	case AttrCumulativeEnergyExported:
+		return putMeasurement(w, c.cumulativeExported)
+	case AttrPeriodicEnergyImported:
+		return putMeasurement(w, c.periodicImported)
+	case AttrPeriodicEnergyExported:
+		return putMeasurement(w, c.periodicExported)
+	case AttrCumulativeEnergyReset:
+		return c.reset.marshal(w)
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// putMeasurement writes a nullable EnergyMeasurementStruct.
+func putMeasurement(w *tlv.Writer, m *EnergyMeasurement) error {
+	if m == nil {
+		return w.PutNull(tlv.Anonymous())
+	}
+	return m.Marshal(w, tlv.Anonymous())
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	// All Electrical Energy Measurement attributes are read-only
+	return datamodel.ErrUnsupportedWrite
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	return nil, datamodel.ErrUnsupportedCommand
+}
+
+// checkReport validates a report against the IMPE/EXPE features and the
+// accuracy bounds.
+func (c *Cluster) checkReport(imported, exported *EnergyMeasurement) error {
+	if (imported != nil && !c.hasFeature(FeatureImportedEnergy)) ||
+		(exported != nil && !c.hasFeature(FeatureExportedEnergy)) {
+		return ErrFeatureNotSupported
+	}
+	for _, m := range []*EnergyMeasurement{imported, exported} {
+		if m != nil && (m.Energy < 0 || !c.config.Accuracy.InRange(m.Energy)) {
+			return ErrEnergyOutOfRange
+		}
+	}
+	return nil
+}
+
+// ReportCumulativeEnergy updates the cumulative totals and emits a
+// CumulativeEnergyMeasured event. A nil argument leaves that direction
+// unchanged and is omitted from the event. Returns ErrEnergyDecreased if a
+// total is lower than the previous one without an intervening reset.
+func (c *Cluster) ReportCumulativeEnergy(imported, exported *EnergyMeasurement) error {
+	if !c.hasFeature(FeatureCumulativeEnergy) {
+		return ErrFeatureNotSupported
+	}
+	if err := c.checkReport(imported, exported); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if imported != nil && !c.importedResetPending && c.cumulativeImported != nil &&
+		imported.Energy < c.cumulativeImported.Energy {
+		c.mu.Unlock()
+		return ErrEnergyDecreased
+	}
+	if exported != nil && !c.exportedResetPending && c.cumulativeExported != nil &&
+		exported.Energy < c.cumulativeExported.Energy {
+		c.mu.Unlock()
+		return ErrEnergyDecreased
+	}
+	if imported != nil {
+		v := *imported
+		c.cumulativeImported = &v
+		c.importedResetPending = false
+	}
+	if exported != nil {
+		v := *exported
+		c.cumulativeExported = &v
+		c.exportedResetPending = false
+	}
+	c.IncrementDataVersion()
+	c.mu.Unlock()
+
+	return c.emit(EventCumulativeEnergyMeasured, EnergyMeasuredEvent{
+		EnergyImported: imported,
+		EnergyExported: exported,
+	})
+}
+
+// ReportPeriodicEnergy updates the periodic measurements and emits a
+// PeriodicEnergyMeasured event. A nil argument leaves that direction
+// unchanged and is omitted from the event.
+func (c *Cluster) ReportPeriodicEnergy(imported, exported *EnergyMeasurement) error {
+	if !c.hasFeature(FeaturePeriodicEnergy) {
+		return ErrFeatureNotSupported
+	}
+	if err := c.checkReport(imported, exported); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if imported != nil {
+		v := *imported
+		c.periodicImported = &v
+	}
+	if exported != nil {
+		v := *exported
+		c.periodicExported = &v
+	}
+	c.IncrementDataVersion()
+	c.mu.Unlock()
+
+	return c.emit(EventPeriodicEnergyMeasured, EnergyMeasuredEvent{
+		EnergyImported: imported,
+		EnergyExported: exported,
+	})
+}
+
+// ResetCumulativeEnergy records a reset of the imported and/or exported
+// totals, e.g. after a meter replacement. The next cumulative report for a
+// reset direction may be lower than the previous one. systime is the
+// device uptime in milliseconds (nil if unknown).
+func (c *Cluster) ResetCumulativeEnergy(imported, exported bool, systime *uint64) error {
+	if !c.hasFeature(FeatureCumulativeEnergy) {
+		return ErrFeatureNotSupported
+	}
+	if (imported && !c.hasFeature(FeatureImportedEnergy)) ||
+		(exported && !c.hasFeature(FeatureExportedEnergy)) {
+		return ErrFeatureNotSupported
+	}
+
+	ts := credentials.TimeToMatterEpoch(c.now())
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if imported {
+		c.importedResetPending = true
+		c.reset.ImportedResetTimestamp = &ts
+		c.reset.ImportedResetSystime = copySystime(systime)
+	}
+	if exported {
+		c.exportedResetPending = true
+		c.reset.ExportedResetTimestamp = &ts
+		c.reset.ExportedResetSystime = copySystime(systime)
+	}
+	if c.config.EnableCumulativeEnergyReset && (imported || exported) {
+		c.IncrementDataVersion()
+	}
+	return nil
+}
+
+// copySystime returns a copy of an optional systime.
+func copySystime(s *uint64) *uint64 {
+	if s == nil {
+		return nil
+	}
+	v := *s
+	return &v
+}
+
+// CumulativeEnergyImported returns the imported total, or nil if unknown.
+func (c *Cluster) CumulativeEnergyImported() *EnergyMeasurement {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return copyMeasurement(c.cumulativeImported)
+}
+
+// CumulativeEnergyExported returns the exported total, or nil if unknown.
+func (c *Cluster) CumulativeEnergyExported() *EnergyMeasurement {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return copyMeasurement(c.cumulativeExported)
+}
+
+// copyMeasurement returns a shallow copy of m.
+func copyMeasurement(m *EnergyMeasurement) *EnergyMeasurement {
+	if m == nil {
+		return nil
+	}
+	v := *m
+	return &v
+}
+
+// emit emits an event if a publisher is bound.
+func (c *Cluster) emit(eventID datamodel.EventID, payload interface{}) error {
+	if !c.EventSource.IsBound() {
+		return nil // No publisher, silently skip
+	}
+	_, err := c.EventSource.Emit(eventID, datamodel.EventPriorityInfo, payload)
+	return err
+}
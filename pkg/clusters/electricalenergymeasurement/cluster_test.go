@@ -0,0 +1,228 @@
+package electricalenergymeasurement
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/backkem/matter/pkg/clusters"
+	"github.com/backkem/matter/pkg/credentials"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// mockEventPublisher implements EventPublisher for testing.
+type mockEventPublisher struct {
+	mu     sync.Mutex
+	events []publishedEvent
+}
+
+type publishedEvent struct {
+	id   datamodel.EventID
+	data interface{}
+}
+
+func (m *mockEventPublisher) PublishEvent(
+	endpoint datamodel.EndpointID,
+	cluster datamodel.ClusterID,
+	eventID datamodel.EventID,
+	priority datamodel.EventPriority,
+	data interface{},
+	fabricIndex uint8,
+) (datamodel.EventNumber, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, publishedEvent{id: eventID, data: data})
+	return datamodel.EventNumber(len(m.events)), nil
+}
+
+func energyAccuracy() clusters.MeasurementAccuracy {
+	fixed := uint64(10)
+	return clusters.MeasurementAccuracy{
+		MeasurementType:  clusters.MeasurementTypeElectricalEnergy,
+		Measured:         true,
+		MaxMeasuredValue: 1 << 40,
+		AccuracyRanges:   []clusters.MeasurementAccuracyRange{{RangeMax: 1 << 40, FixedMax: &fixed}},
+	}
+}
+
+func newMeter(t *testing.T, features Feature, pub datamodel.EventPublisher) *Cluster {
+	t.Helper()
+	c, err := New(Config{
+		EndpointID:                  1,
+		FeatureMap:                  features,
+		Accuracy:                    energyAccuracy(),
+		EnableCumulativeEnergyReset: true,
+		EventPublisher:              pub,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+func readAttr(t *testing.T, c *Cluster, attr datamodel.AttributeID) (*tlv.Reader, error) {
+	t.Helper()
+	var buf bytes.Buffer
+	req := datamodel.ReadAttributeRequest{
+		Path: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: attr},
+	}
+	if err := c.ReadAttribute(context.Background(), req, tlv.NewWriter(&buf)); err != nil {
+		return nil, err
+	}
+	r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+	r.Next()
+	return r, nil
+}
+
+func TestNew_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want error
+	}{
+		{"no direction", Config{FeatureMap: FeatureCumulativeEnergy, Accuracy: energyAccuracy()}, ErrInvalidFeatures},
+		{"no kind", Config{FeatureMap: FeatureImportedEnergy, Accuracy: energyAccuracy()}, ErrInvalidFeatures},
+		{"wrong accuracy type", Config{
+			FeatureMap: FeatureImportedEnergy | FeatureCumulativeEnergy,
+			Accuracy:   clusters.MeasurementAccuracy{MeasurementType: clusters.MeasurementTypeActivePower},
+		}, ErrInvalidAccuracy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg); !errors.Is(err, tt.want) {
+				t.Errorf("New() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttributeList(t *testing.T) {
+	c := newMeter(t, FeatureImportedEnergy|FeaturePeriodicEnergy, nil)
+
+	has := make(map[datamodel.AttributeID]bool)
+	for _, a := range c.AttributeList() {
+		has[a.ID] = true
+	}
+	if !has[AttrAccuracy] || !has[AttrPeriodicEnergyImported] {
+		t.Error("Accuracy and PeriodicEnergyImported should be present")
+	}
+	for _, attr := range []datamodel.AttributeID{AttrCumulativeEnergyImported, AttrPeriodicEnergyExported, AttrCumulativeEnergyReset} {
+		if has[attr] {
+			t.Errorf("attribute 0x%04X should not be present", attr)
+		}
+	}
+}
+
+func TestReportCumulativeEnergy(t *testing.T) {
+	pub := &mockEventPublisher{}
+	c := newMeter(t, FeatureImportedEnergy|FeatureCumulativeEnergy, pub)
+
+	r, err := readAttr(t, c, AttrCumulativeEnergyImported)
+	if err != nil {
+		t.Fatalf("read CumulativeEnergyImported: %v", err)
+	}
+	if r.Type() != tlv.ElementTypeNull {
+		t.Error("CumulativeEnergyImported should start null")
+	}
+
+	if err := c.ReportCumulativeEnergy(&EnergyMeasurement{Energy: 5000}, nil); err != nil {
+		t.Fatalf("ReportCumulativeEnergy() error = %v", err)
+	}
+	if got := c.CumulativeEnergyImported(); got == nil || got.Energy != 5000 {
+		t.Errorf("CumulativeEnergyImported = %v, want 5000", got)
+	}
+	if len(pub.events) != 1 || pub.events[0].id != EventCumulativeEnergyMeasured {
+		t.Fatalf("events = %v, want one CumulativeEnergyMeasured", pub.events)
+	}
+	if ev := pub.events[0].data.(EnergyMeasuredEvent); ev.EnergyExported != nil || ev.EnergyImported.Energy != 5000 {
+		t.Errorf("event = %+v", ev)
+	}
+
+	// Totals may not go down
+	if err := c.ReportCumulativeEnergy(&EnergyMeasurement{Energy: 4000}, nil); !errors.Is(err, ErrEnergyDecreased) {
+		t.Errorf("decrease error = %v, want ErrEnergyDecreased", err)
+	}
+
+	// Exported energy is not supported
+	if err := c.ReportCumulativeEnergy(nil, &EnergyMeasurement{Energy: 1}); !errors.Is(err, ErrFeatureNotSupported) {
+		t.Errorf("exported error = %v, want ErrFeatureNotSupported", err)
+	}
+
+	// Periodic reports are not supported
+	if err := c.ReportPeriodicEnergy(&EnergyMeasurement{Energy: 1}, nil); !errors.Is(err, ErrFeatureNotSupported) {
+		t.Errorf("periodic error = %v, want ErrFeatureNotSupported", err)
+	}
+}
+
+func TestResetCumulativeEnergy(t *testing.T) {
+	c := newMeter(t, FeatureImportedEnergy|FeatureCumulativeEnergy, nil)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	c.ReportCumulativeEnergy(&EnergyMeasurement{Energy: 5000}, nil)
+
+	systime := uint64(60_000)
+	if err := c.ResetCumulativeEnergy(true, false, &systime); err != nil {
+		t.Fatalf("ResetCumulativeEnergy() error = %v", err)
+	}
+	if err := c.ReportCumulativeEnergy(&EnergyMeasurement{Energy: 10}, nil); err != nil {
+		t.Fatalf("report after reset error = %v", err)
+	}
+	if err := c.ReportCumulativeEnergy(&EnergyMeasurement{Energy: 5}, nil); !errors.Is(err, ErrEnergyDecreased) {
+		t.Errorf("second decrease error = %v, want ErrEnergyDecreased", err)
+	}
+
+	r, err := readAttr(t, c, AttrCumulativeEnergyReset)
+	if err != nil {
+		t.Fatalf("read CumulativeEnergyReset: %v", err)
+	}
+	r.EnterContainer()
+	r.Next()
+	if v, _ := r.Uint(); uint32(v) != credentials.TimeToMatterEpoch(now) {
+		t.Errorf("ImportedResetTimestamp = %d, want %d", v, credentials.TimeToMatterEpoch(now))
+	}
+	r.Next()
+	if r.Type() != tlv.ElementTypeNull {
+		t.Error("ExportedResetTimestamp should be null")
+	}
+	r.Next()
+	if v, _ := r.Uint(); v != systime {
+		t.Errorf("ImportedResetSystime = %d, want %d", v, systime)
+	}
+}
+
+func TestReportPeriodicEnergy(t *testing.T) {
+	pub := &mockEventPublisher{}
+	c := newMeter(t, FeatureImportedEnergy|FeatureExportedEnergy|FeaturePeriodicEnergy, pub)
+
+	start, end := uint32(1000), uint32(1900)
+	m := &EnergyMeasurement{Energy: 120, StartTimestamp: &start, EndTimestamp: &end}
+	if err := c.ReportPeriodicEnergy(m, &EnergyMeasurement{Energy: 0}); err != nil {
+		t.Fatalf("ReportPeriodicEnergy() error = %v", err)
+	}
+	if len(pub.events) != 1 || pub.events[0].id != EventPeriodicEnergyMeasured {
+		t.Fatalf("events = %v, want one PeriodicEnergyMeasured", pub.events)
+	}
+	if err := c.ReportPeriodicEnergy(&EnergyMeasurement{Energy: -1}, nil); !errors.Is(err, ErrEnergyOutOfRange) {
+		t.Errorf("negative error = %v, want ErrEnergyOutOfRange", err)
+	}
+
+	r, err := readAttr(t, c, AttrPeriodicEnergyImported)
+	if err != nil {
+		t.Fatalf("read PeriodicEnergyImported: %v", err)
+	}
+	r.EnterContainer()
+	r.Next()
+	if v, _ := r.Int(); v != 120 {
+		t.Errorf("Energy = %d, want 120", v)
+	}
+	r.Next()
+	if r.Tag().TagNumber() != 1 {
+		t.Errorf("StartTimestamp tag = %d, want 1", r.Tag().TagNumber())
+	}
+}
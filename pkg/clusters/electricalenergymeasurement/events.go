@@ -0,0 +1,31 @@
+package electricalenergymeasurement
+
+import (
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// EnergyMeasuredEvent is the payload of both CumulativeEnergyMeasured
+// (Spec 2.12.7.1) and PeriodicEnergyMeasured (Spec 2.12.7.2).
+// Priority: INFO, Conformance: CUME / PERE
+type EnergyMeasuredEvent struct {
+	EnergyImported *EnergyMeasurement // optional
+	EnergyExported *EnergyMeasurement // optional
+}
+
+// MarshalTLV implements the TLVMarshaler interface.
+func (e EnergyMeasuredEvent) MarshalTLV(w *tlv.Writer) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	if e.EnergyImported != nil {
+		if err := e.EnergyImported.Marshal(w, tlv.ContextTag(0)); err != nil {
+			return err
+		}
+	}
+	if e.EnergyExported != nil {
+		if err := e.EnergyExported.Marshal(w, tlv.ContextTag(1)); err != nil {
+			return err
+		}
+	}
+	return w.EndContainer()
+}
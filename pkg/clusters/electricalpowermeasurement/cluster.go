@@ -0,0 +1,456 @@
+// Package electricalpowermeasurement implements the Electrical Power
+// Measurement Cluster (0x0090).
+//
+// The cluster reports instantaneous electrical measurements such as
+// voltage, current and active power. Which measurements a server supports
+// is declared through its Accuracy list: every supported measurement type
+// has exactly one MeasurementAccuracy entry, and the matching attribute is
+// present only if it does. ActivePower is mandatory.
+//
+// Device firmware reports readings with SetMeasurement; readings outside
+// the declared accuracy bounds are rejected.
+//
+// Spec Reference: Section 2.13
+//
+// C++ Reference: src/app/clusters/electrical-power-measurement-server/electrical-power-measurement-server.cpp
+package electricalpowermeasurement
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/backkem/matter/pkg/clusters"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x0090
+	ClusterRevision uint16              = 1
+)
+
+// Attribute IDs (Spec 2.13.6).
+const (
+	AttrPowerMode                datamodel.AttributeID = 0x0000
+	AttrNumberOfMeasurementTypes datamodel.AttributeID = 0x0001
+	AttrAccuracy                 datamodel.AttributeID = 0x0002
+	AttrRanges                   datamodel.AttributeID = 0x0003
+	AttrVoltage                  datamodel.AttributeID = 0x0004
+	AttrActiveCurrent            datamodel.AttributeID = 0x0005
+	AttrReactiveCurrent          datamodel.AttributeID = 0x0006
+	AttrApparentCurrent          datamodel.AttributeID = 0x0007
+	AttrActivePower              datamodel.AttributeID = 0x0008
+	AttrReactivePower            datamodel.AttributeID = 0x0009
+	AttrApparentPower            datamodel.AttributeID = 0x000A
+	AttrRMSVoltage               datamodel.AttributeID = 0x000B
+	AttrRMSCurrent               datamodel.AttributeID = 0x000C
+	AttrRMSPower                 datamodel.AttributeID = 0x000D
+	AttrFrequency                datamodel.AttributeID = 0x000E
+	AttrHarmonicCurrents         datamodel.AttributeID = 0x000F
+	AttrHarmonicPhases           datamodel.AttributeID = 0x0010
+	AttrPowerFactor              datamodel.AttributeID = 0x0011
+	AttrNeutralCurrent           datamodel.AttributeID = 0x0012
+)
+
+// Feature bits (Spec 2.13.4).
+type Feature uint32
+
+const (
+	// FeatureDirectCurrent supports DC measurements (DIRC).
+	FeatureDirectCurrent Feature = 1 << 0
+
+	// FeatureAlternatingCurrent supports AC measurements (ALTC).
+	FeatureAlternatingCurrent Feature = 1 << 1
+
+	// FeaturePolyphasePower supports polyphase measurements (POLY).
+	FeaturePolyphasePower Feature = 1 << 2
+
+	// FeatureHarmonics supports harmonic current measurements (HARM).
+	FeatureHarmonics Feature = 1 << 3
+
+	// FeaturePowerQuality supports harmonic phase measurements (PWRQ).
+	FeaturePowerQuality Feature = 1 << 4
+)
+
+// PowerMode is the PowerModeEnum (Spec 2.13.5.1).
+type PowerMode uint8
+
+const (
+	PowerModeUnknown PowerMode = 0
+	PowerModeDC      PowerMode = 1
+	PowerModeAC      PowerMode = 2
+)
+
+// HarmonicMeasurement is one entry of HarmonicCurrents or HarmonicPhases
+// (HarmonicMeasurementStruct, Spec 2.13.5.3).
+type HarmonicMeasurement struct {
+	// Order is the harmonic order (1 is the fundamental frequency).
+	Order uint8
+
+	// Measurement is the value for this order (nullable).
+	Measurement *int64
+}
+
+// Errors returned by configuration and the device-side API.
+var (
+	ErrInvalidFeatures          = errors.New("electricalpowermeasurement: invalid feature combination")
+	ErrMissingActivePower       = errors.New("electricalpowermeasurement: ActivePower accuracy required")
+	ErrUnsupportedMeasurement   = errors.New("electricalpowermeasurement: unsupported measurement type")
+	ErrMeasurementOutOfRange    = errors.New("electricalpowermeasurement: measurement out of range")
+	ErrFeatureNotSupported      = errors.New("electricalpowermeasurement: feature not supported")
+	ErrDuplicateMeasurementType = errors.New("electricalpowermeasurement: duplicate measurement type")
+)
+
+// measurementAttrs maps measurement types to their attribute and the
+// feature they require (0 if none).
+var measurementAttrs = map[clusters.MeasurementType]struct {
+	attr    datamodel.AttributeID
+	feature Feature
+}{
+	clusters.MeasurementTypeVoltage:         {AttrVoltage, 0},
+	clusters.MeasurementTypeActiveCurrent:   {AttrActiveCurrent, 0},
+	clusters.MeasurementTypeReactiveCurrent: {AttrReactiveCurrent, FeatureAlternatingCurrent},
+	clusters.MeasurementTypeApparentCurrent: {AttrApparentCurrent, FeatureAlternatingCurrent},
+	clusters.MeasurementTypeActivePower:     {AttrActivePower, 0},
+	clusters.MeasurementTypeReactivePower:   {AttrReactivePower, FeatureAlternatingCurrent},
+	clusters.MeasurementTypeApparentPower:   {AttrApparentPower, FeatureAlternatingCurrent},
+	clusters.MeasurementTypeRMSVoltage:      {AttrRMSVoltage, FeatureAlternatingCurrent},
+	clusters.MeasurementTypeRMSCurrent:      {AttrRMSCurrent, FeatureAlternatingCurrent},
+	clusters.MeasurementTypeRMSPower:        {AttrRMSPower, FeatureAlternatingCurrent},
+	clusters.MeasurementTypeFrequency:       {AttrFrequency, FeatureAlternatingCurrent},
+	clusters.MeasurementTypePowerFactor:     {AttrPowerFactor, FeatureAlternatingCurrent},
+	clusters.MeasurementTypeNeutralCurrent:  {AttrNeutralCurrent, FeaturePolyphasePower},
+}
+
+// Config provides dependencies for the Electrical Power Measurement cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to.
+	EndpointID datamodel.EndpointID
+
+	// FeatureMap indicates supported features. At least one of
+	// FeatureDirectCurrent and FeatureAlternatingCurrent must be set.
+	FeatureMap Feature
+
+	// PowerMode is the initial PowerMode. Defaults to DC or AC if only one
+	// of the current features is set.
+	PowerMode PowerMode
+
+	// Accuracy declares the supported measurement types. It must contain
+	// an ActivePower entry.
+	Accuracy []clusters.MeasurementAccuracy
+}
+
+// Cluster implements the Electrical Power Measurement cluster (0x0090).
+type Cluster struct {
+	*datamodel.ClusterBase
+	config Config
+
+	// Mutable state (protected by mutex)
+	mu               sync.Mutex
+	powerMode        PowerMode
+	values           map[clusters.MeasurementType]*int64 // nullable readings
+	harmonicCurrents []HarmonicMeasurement               // nil = null
+	harmonicPhases   []HarmonicMeasurement               // nil = null
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Electrical Power Measurement cluster. It returns an
+// error if the feature map or accuracy list is invalid.
+func New(cfg Config) (*Cluster, error) {
+	const ac = FeatureAlternatingCurrent
+	if cfg.FeatureMap&(FeatureDirectCurrent|ac) == 0 {
+		return nil, ErrInvalidFeatures
+	}
+	if cfg.FeatureMap&ac == 0 && cfg.FeatureMap&(FeaturePolyphasePower|FeatureHarmonics|FeaturePowerQuality) != 0 {
+		return nil, ErrInvalidFeatures
+	}
+
+	seen := make(map[clusters.MeasurementType]bool, len(cfg.Accuracy))
+	for _, a := range cfg.Accuracy {
+		if err := a.Validate(); err != nil {
+			return nil, err
+		}
+		m, ok := measurementAttrs[a.MeasurementType]
+		if !ok || (m.feature != 0 && cfg.FeatureMap&m.feature == 0) {
+			return nil, ErrUnsupportedMeasurement
+		}
+		if seen[a.MeasurementType] {
+			return nil, ErrDuplicateMeasurementType
+		}
+		seen[a.MeasurementType] = true
+	}
+	if !seen[clusters.MeasurementTypeActivePower] {
+		return nil, ErrMissingActivePower
+	}
+
+	if cfg.PowerMode == PowerModeUnknown {
+		switch cfg.FeatureMap & (FeatureDirectCurrent | ac) {
+		case FeatureDirectCurrent:
+			cfg.PowerMode = PowerModeDC
+		case ac:
+			cfg.PowerMode = PowerModeAC
+		}
+	}
+
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		config:      cfg,
+		powerMode:   cfg.PowerMode,
+		values:      make(map[clusters.MeasurementType]*int64),
+	}
+
+	c.ClusterBase.SetFeatureMap(uint32(cfg.FeatureMap))
+
+	c.attrList = c.buildAttributeList()
+
+	return c, nil
+}
+
+// hasFeature returns true if the feature bit is set.
+func (c *Cluster) hasFeature(f Feature) bool {
+	return c.config.FeatureMap&f != 0
+}
+
+// accuracy returns the accuracy entry for a measurement type.
+func (c *Cluster) accuracy(t clusters.MeasurementType) (clusters.MeasurementAccuracy, bool) {
+	for _, a := range c.config.Accuracy {
+		if a.MeasurementType == t {
+			return a, true
+		}
+	}
+	return clusters.MeasurementAccuracy{}, false
+}
+
+// measurementType returns the measurement type read by attr.
+func measurementType(attr datamodel.AttributeID) (clusters.MeasurementType, bool) {
+	for t, m := range measurementAttrs {
+		if m.attr == attr {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+// buildAttributeList constructs the list of supported attributes.
+func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+	nullable := datamodel.AttrQualityNullable
+
+	attrs := []datamodel.AttributeEntry{
+		datamodel.NewReadOnlyAttribute(AttrPowerMode, 0, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrNumberOfMeasurementTypes, datamodel.AttrQualityFixed, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrAccuracy, datamodel.AttrQualityList|datamodel.AttrQualityFixed, viewPriv),
+	}
+	for _, a := range c.config.Accuracy {
+		attrs = append(attrs, datamodel.NewReadOnlyAttribute(measurementAttrs[a.MeasurementType].attr, nullable, viewPriv))
+	}
+	if c.hasFeature(FeatureHarmonics) {
+		attrs = append(attrs, datamodel.NewReadOnlyAttribute(AttrHarmonicCurrents, datamodel.AttrQualityList|nullable, viewPriv))
+	}
+	if c.hasFeature(FeaturePowerQuality) {
+		attrs = append(attrs, datamodel.NewReadOnlyAttribute(AttrHarmonicPhases, datamodel.AttrQualityList|nullable, viewPriv))
+	}
+
+	return datamodel.MergeAttributeLists(attrs)
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	return nil
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return nil
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch req.Path.Attribute {
+	case AttrPowerMode:
+		return w.PutUint(tlv.Anonymous(), uint64(c.powerMode))
+
+	case AttrNumberOfMeasurementTypes:
+		return w.PutUint(tlv.Anonymous(), uint64(len(c.config.Accuracy)))
+
+	case AttrAccuracy:
+		if err := w.StartArray(tlv.Anonymous()); err != nil {
+			return err
+		}
+		for _, a := range c.config.Accuracy {
+			if err := a.MarshalTLV(w); err != nil {
+				return err
+			}
+		}
+		return w.EndContainer()
+
+	case AttrHarmonicCurrents:
+		if !c.hasFeature(FeatureHarmonics) {
+			return datamodel.ErrUnsupportedAttribute
+		}
+		return putHarmonics(w, c.harmonicCurrents)
+
+	case AttrHarmonicPhases:
+		if !c.hasFeature(FeaturePowerQuality) {
+			return datamodel.ErrUnsupportedAttribute
+		}
+		return putHarmonics(w, c.harmonicPhases)
+	}
+
+	t, ok := measurementType(req.Path.Attribute)
+	if !ok {
+		return datamodel.ErrUnsupportedAttribute
+	}
+	if _, ok := c.accuracy(t); !ok {
+		return datamodel.ErrUnsupportedAttribute
+	}
+	if v := c.values[t]; v != nil {
+		return w.PutInt(tlv.Anonymous(), *v)
+	}
+	return w.PutNull(tlv.Anonymous())
+}
+
+// putHarmonics writes a nullable list of HarmonicMeasurementStruct.
+func putHarmonics(w *tlv.Writer, list []HarmonicMeasurement) error {
+	if list == nil {
+		return w.PutNull(tlv.Anonymous())
+	}
+	if err := w.StartArray(tlv.Anonymous()); err != nil {
+		return err
+	}
+	for _, h := range list {
+		if err := w.StartStructure(tlv.Anonymous()); err != nil {
+			return err
+		}
+		if err := w.PutUint(tlv.ContextTag(0), uint64(h.Order)); err != nil {
+			return err
+		}
+		if h.Measurement == nil {
+			if err := w.PutNull(tlv.ContextTag(1)); err != nil {
+				return err
+			}
+		} else if err := w.PutInt(tlv.ContextTag(1), *h.Measurement); err != nil {
+			return err
+		}
+		if err := w.EndContainer(); err != nil {
+			return err
+		}
+	}
+	return w.EndContainer()
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	// All Electrical Power Measurement attributes are read-only
+	return datamodel.ErrUnsupportedWrite
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	return nil, datamodel.ErrUnsupportedCommand
+}
+
+// PowerMode returns PowerMode.
+func (c *Cluster) PowerMode() PowerMode {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.powerMode
+}
+
+// SetPowerMode sets PowerMode, e.g. when a device detects its supply.
+func (c *Cluster) SetPowerMode(mode PowerMode) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.powerMode == mode {
+		return
+	}
+	c.powerMode = mode
+	c.IncrementDataVersion()
+}
+
+// Measurement returns the current reading for a measurement type, or nil
+// if null or unsupported.
+func (c *Cluster) Measurement(t clusters.MeasurementType) *int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if v := c.values[t]; v != nil {
+		n := *v
+		return &n
+	}
+	return nil
+}
+
+// SetMeasurement records a reading; nil marks it unknown. Returns
+// ErrUnsupportedMeasurement if the type has no Accuracy entry and
+// ErrMeasurementOutOfRange if the reading lies outside its bounds.
+func (c *Cluster) SetMeasurement(t clusters.MeasurementType, value *int64) error {
+	a, ok := c.accuracy(t)
+	if !ok {
+		return ErrUnsupportedMeasurement
+	}
+	if value != nil && !a.InRange(*value) {
+		return ErrMeasurementOutOfRange
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	old := c.values[t]
+	if (old == nil && value == nil) || (old != nil && value != nil && *old == *value) {
+		return nil
+	}
+	if value != nil {
+		v := *value
+		value = &v
+	}
+	c.values[t] = value
+	c.IncrementDataVersion()
+	return nil
+}
+
+// SetActivePower records the ActivePower reading in mW.
+func (c *Cluster) SetActivePower(mW *int64) error {
+	return c.SetMeasurement(clusters.MeasurementTypeActivePower, mW)
+}
+
+// SetHarmonicCurrents sets HarmonicCurrents (HARM); nil sets it to null.
+func (c *Cluster) SetHarmonicCurrents(list []HarmonicMeasurement) error {
+	if !c.hasFeature(FeatureHarmonics) {
+		return ErrFeatureNotSupported
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.harmonicCurrents = list
+	c.IncrementDataVersion()
+	return nil
+}
+
+// SetHarmonicPhases sets HarmonicPhases (PWRQ); nil sets it to null.
+func (c *Cluster) SetHarmonicPhases(list []HarmonicMeasurement) error {
+	if !c.hasFeature(FeaturePowerQuality) {
+		return ErrFeatureNotSupported
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.harmonicPhases = list
+	c.IncrementDataVersion()
+	return nil
+}
@@ -0,0 +1,192 @@
+package electricalpowermeasurement
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/backkem/matter/pkg/clusters"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+func accuracy(t clusters.MeasurementType, min, max int64) clusters.MeasurementAccuracy {
+	pct := uint16(100)
+	return clusters.MeasurementAccuracy{
+		MeasurementType:  t,
+		Measured:         true,
+		MinMeasuredValue: min,
+		MaxMeasuredValue: max,
+		AccuracyRanges:   []clusters.MeasurementAccuracyRange{{RangeMin: min, RangeMax: max, PercentMax: &pct}},
+	}
+}
+
+func newPlug(t *testing.T) *Cluster {
+	t.Helper()
+	c, err := New(Config{
+		EndpointID: 1,
+		FeatureMap: FeatureAlternatingCurrent,
+		Accuracy: []clusters.MeasurementAccuracy{
+			accuracy(clusters.MeasurementTypeActivePower, -3_680_000, 3_680_000),
+			accuracy(clusters.MeasurementTypeRMSVoltage, 0, 260_000),
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+func readAttr(t *testing.T, c *Cluster, attr datamodel.AttributeID) (*tlv.Reader, error) {
+	t.Helper()
+	var buf bytes.Buffer
+	req := datamodel.ReadAttributeRequest{
+		Path: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: attr},
+	}
+	if err := c.ReadAttribute(context.Background(), req, tlv.NewWriter(&buf)); err != nil {
+		return nil, err
+	}
+	r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+	r.Next()
+	return r, nil
+}
+
+func TestNew_Validation(t *testing.T) {
+	power := accuracy(clusters.MeasurementTypeActivePower, 0, 1000)
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want error
+	}{
+		{"no current feature", Config{Accuracy: []clusters.MeasurementAccuracy{power}}, ErrInvalidFeatures},
+		{"harmonics without AC", Config{
+			FeatureMap: FeatureDirectCurrent | FeatureHarmonics,
+			Accuracy:   []clusters.MeasurementAccuracy{power},
+		}, ErrInvalidFeatures},
+		{"missing active power", Config{
+			FeatureMap: FeatureDirectCurrent,
+			Accuracy:   []clusters.MeasurementAccuracy{accuracy(clusters.MeasurementTypeVoltage, 0, 1000)},
+		}, ErrMissingActivePower},
+		{"AC measurement on DC", Config{
+			FeatureMap: FeatureDirectCurrent,
+			Accuracy:   []clusters.MeasurementAccuracy{power, accuracy(clusters.MeasurementTypeFrequency, 0, 1000)},
+		}, ErrUnsupportedMeasurement},
+		{"duplicate", Config{
+			FeatureMap: FeatureDirectCurrent,
+			Accuracy:   []clusters.MeasurementAccuracy{power, power},
+		}, ErrDuplicateMeasurementType},
+		{"invalid accuracy", Config{
+			FeatureMap: FeatureDirectCurrent,
+			Accuracy:   []clusters.MeasurementAccuracy{{MeasurementType: clusters.MeasurementTypeActivePower}},
+		}, clusters.ErrInvalidAccuracy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg); !errors.Is(err, tt.want) {
+				t.Errorf("New() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttributeList(t *testing.T) {
+	c := newPlug(t)
+
+	has := make(map[datamodel.AttributeID]bool)
+	for _, a := range c.AttributeList() {
+		has[a.ID] = true
+	}
+	for _, attr := range []datamodel.AttributeID{AttrPowerMode, AttrNumberOfMeasurementTypes, AttrAccuracy, AttrActivePower, AttrRMSVoltage} {
+		if !has[attr] {
+			t.Errorf("attribute 0x%04X missing", attr)
+		}
+	}
+	for _, attr := range []datamodel.AttributeID{AttrVoltage, AttrFrequency, AttrHarmonicCurrents} {
+		if has[attr] {
+			t.Errorf("attribute 0x%04X should not be present", attr)
+		}
+	}
+
+	if _, err := readAttr(t, c, AttrVoltage); !errors.Is(err, datamodel.ErrUnsupportedAttribute) {
+		t.Errorf("read Voltage error = %v, want ErrUnsupportedAttribute", err)
+	}
+	if c.PowerMode() != PowerModeAC {
+		t.Errorf("PowerMode = %d, want AC", c.PowerMode())
+	}
+}
+
+func TestSetMeasurement(t *testing.T) {
+	c := newPlug(t)
+
+	r, err := readAttr(t, c, AttrActivePower)
+	if err != nil {
+		t.Fatalf("read ActivePower: %v", err)
+	}
+	if r.Type() != tlv.ElementTypeNull {
+		t.Error("ActivePower should start null")
+	}
+
+	version := c.DataVersion()
+	power := int64(1_500_000)
+	if err := c.SetActivePower(&power); err != nil {
+		t.Fatalf("SetActivePower() error = %v", err)
+	}
+	if c.DataVersion() == version {
+		t.Error("DataVersion not incremented")
+	}
+
+	r, _ = readAttr(t, c, AttrActivePower)
+	if v, _ := r.Int(); v != power {
+		t.Errorf("ActivePower = %d, want %d", v, power)
+	}
+
+	// Same value does not bump the version
+	version = c.DataVersion()
+	c.SetActivePower(&power)
+	if c.DataVersion() != version {
+		t.Error("DataVersion incremented for unchanged value")
+	}
+
+	tooHigh := int64(4_000_000)
+	if err := c.SetActivePower(&tooHigh); !errors.Is(err, ErrMeasurementOutOfRange) {
+		t.Errorf("out of range error = %v, want ErrMeasurementOutOfRange", err)
+	}
+	if err := c.SetMeasurement(clusters.MeasurementTypeFrequency, &power); !errors.Is(err, ErrUnsupportedMeasurement) {
+		t.Errorf("unsupported error = %v, want ErrUnsupportedMeasurement", err)
+	}
+	if err := c.SetHarmonicCurrents(nil); !errors.Is(err, ErrFeatureNotSupported) {
+		t.Errorf("SetHarmonicCurrents error = %v, want ErrFeatureNotSupported", err)
+	}
+}
+
+func TestReadAccuracy(t *testing.T) {
+	c := newPlug(t)
+
+	r, err := readAttr(t, c, AttrNumberOfMeasurementTypes)
+	if err != nil {
+		t.Fatalf("read NumberOfMeasurementTypes: %v", err)
+	}
+	if v, _ := r.Uint(); v != 2 {
+		t.Errorf("NumberOfMeasurementTypes = %d, want 2", v)
+	}
+
+	r, err = readAttr(t, c, AttrAccuracy)
+	if err != nil {
+		t.Fatalf("read Accuracy: %v", err)
+	}
+	r.EnterContainer()
+	count := 0
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		r.Skip()
+		count++
+	}
+	if count != 2 {
+		t.Errorf("Accuracy entries = %d, want 2", count)
+	}
+}
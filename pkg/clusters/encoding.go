@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 
+	"github.com/backkem/matter/pkg/datamodel"
 	"github.com/backkem/matter/pkg/tlv"
 )
 
@@ -102,6 +103,16 @@ func EncodeResponse(resp TLVMarshaler) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// NewResponse builds the typed response of a ClusterWithCommandResponses
+// from a TLVMarshaler, which writes the anonymous fields structure.
+func NewResponse(command datamodel.CommandID, resp TLVMarshaler) (*datamodel.CommandResponse, error) {
+	data, err := EncodeResponse(resp)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return &datamodel.CommandResponse{Command: command, Fields: data}, nil
+}
+
 // DecodeRequest decodes a command request into a TLVUnmarshaler.
 func DecodeRequest(data []byte, req TLVUnmarshaler) error {
 	if len(data) == 0 {
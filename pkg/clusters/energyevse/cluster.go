@@ -0,0 +1,487 @@
+// Package energyevse implements the Energy EVSE Cluster (0x0099).
+//
+// The cluster controls an Electric Vehicle Supply Equipment (EV charger).
+// A client enables charging with EnableCharging, optionally until a given
+// time and within a current range, and stops it with Disable. The device
+// reports the vehicle side through PlugIn, Unplug and SetEVDemand, and the
+// energy delivered through AddSessionEnergy.
+//
+// The cluster combines both sides into the State attribute and emits the
+// session events: EVConnected when a vehicle is plugged in,
+// EnergyTransferStarted and EnergyTransferStopped around each charging
+// period, EVNotDetected when it is unplugged, and Fault on fault changes.
+//
+// The offered charge current is the lowest of the EnableCharging maximum,
+// the circuit capacity and the user's UserMaximumChargeCurrent.
+//
+// Optional features (charging preferences, SoC reporting, Plug and Charge,
+// RFID, V2X discharging) are not supported.
+//
+// Spec Reference: Section 9.3
+//
+// C++ Reference: src/app/clusters/energy-evse-server/energy-evse-server.cpp
+package energyevse
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x0099
+	ClusterRevision uint16              = 3
+)
+
+// Attribute IDs (Spec 9.3.8).
+const (
+	AttrState                    datamodel.AttributeID = 0x0000
+	AttrSupplyState              datamodel.AttributeID = 0x0001
+	AttrFaultState               datamodel.AttributeID = 0x0002
+	AttrChargingEnabledUntil     datamodel.AttributeID = 0x0003
+	AttrDischargingEnabledUntil  datamodel.AttributeID = 0x0004
+	AttrCircuitCapacity          datamodel.AttributeID = 0x0005
+	AttrMinimumChargeCurrent     datamodel.AttributeID = 0x0006
+	AttrMaximumChargeCurrent     datamodel.AttributeID = 0x0007
+	AttrMaximumDischargeCurrent  datamodel.AttributeID = 0x0008
+	AttrUserMaximumChargeCurrent datamodel.AttributeID = 0x0009
+	AttrRandomizationDelayWindow datamodel.AttributeID = 0x000A
+	AttrSessionID                datamodel.AttributeID = 0x0040
+	AttrSessionDuration          datamodel.AttributeID = 0x0041
+	AttrSessionEnergyCharged     datamodel.AttributeID = 0x0042
+	AttrSessionEnergyDischarged  datamodel.AttributeID = 0x0043
+)
+
+// Command IDs (Spec 9.3.9).
+const (
+	CmdDisable        datamodel.CommandID = 0x01
+	CmdEnableCharging datamodel.CommandID = 0x02
+)
+
+// Event IDs (Spec 9.3.10).
+const (
+	EventEVConnected           datamodel.EventID = 0x00
+	EventEVNotDetected         datamodel.EventID = 0x01
+	EventEnergyTransferStarted datamodel.EventID = 0x02
+	EventEnergyTransferStopped datamodel.EventID = 0x03
+	EventFault                 datamodel.EventID = 0x04
+)
+
+// Defaults and limits (Spec 9.3.8).
+const (
+	// DefaultMinimumChargeCurrent is the IEC 61851 minimum of 6 A, in mA.
+	DefaultMinimumChargeCurrent int64 = 6000
+
+	// DefaultRandomizationDelayWindow is 10 minutes, in seconds.
+	DefaultRandomizationDelayWindow uint32 = 600
+
+	// MaxRandomizationDelayWindow is 24 hours, in seconds.
+	MaxRandomizationDelayWindow uint32 = 86400
+)
+
+// State is the StateEnum (Spec 9.3.7.1).
+type State uint8
+
+const (
+	StateNotPluggedIn         State = 0x00
+	StatePluggedInNoDemand    State = 0x01
+	StatePluggedInDemand      State = 0x02
+	StatePluggedInCharging    State = 0x03
+	StatePluggedInDischarging State = 0x04
+	StateSessionEnding        State = 0x05
+	StateFault                State = 0x06
+)
+
+// SupplyState is the SupplyStateEnum (Spec 9.3.7.2).
+type SupplyState uint8
+
+const (
+	SupplyStateDisabled            SupplyState = 0x00
+	SupplyStateChargingEnabled     SupplyState = 0x01
+	SupplyStateDischargingEnabled  SupplyState = 0x02
+	SupplyStateDisabledError       SupplyState = 0x03
+	SupplyStateDisabledDiagnostics SupplyState = 0x04
+	SupplyStateEnabled             SupplyState = 0x05
+)
+
+// FaultState is the FaultStateEnum (Spec 9.3.7.3).
+type FaultState uint8
+
+const (
+	FaultStateNoError           FaultState = 0x00
+	FaultStateMeterFailure      FaultState = 0x01
+	FaultStateOverVoltage       FaultState = 0x02
+	FaultStateUnderVoltage      FaultState = 0x03
+	FaultStateOverCurrent       FaultState = 0x04
+	FaultStateContactWetFailure FaultState = 0x05
+	FaultStateContactDryFailure FaultState = 0x06
+	FaultStateGroundFault       FaultState = 0x07
+	FaultStatePowerLoss         FaultState = 0x08
+	FaultStatePowerQuality      FaultState = 0x09
+	FaultStatePilotShortCircuit FaultState = 0x0A
+	FaultStateEmergencyStop     FaultState = 0x0B
+	FaultStateEVDisconnected    FaultState = 0x0C
+	FaultStateWrongPowerSupply  FaultState = 0x0D
+	FaultStateLiveNeutralSwap   FaultState = 0x0E
+	FaultStateOverTemperature   FaultState = 0x0F
+	FaultStateOther             FaultState = 0xFF
+)
+
+// EnergyTransferStoppedReason is the EnergyTransferStoppedReasonEnum
+// (Spec 9.3.7.5).
+type EnergyTransferStoppedReason uint8
+
+const (
+	StoppedReasonEVStopped   EnergyTransferStoppedReason = 0x00
+	StoppedReasonEVSEStopped EnergyTransferStoppedReason = 0x01
+	StoppedReasonOther       EnergyTransferStoppedReason = 0x02
+)
+
+// Errors returned by commands and configuration.
+var (
+	// ErrFaulted is returned when charging is enabled while the EVSE is in
+	// a fault or diagnostics state.
+	ErrFaulted = errors.New("energyevse: supply disabled by fault or diagnostics")
+
+	ErrInvalidCircuitCapacity = errors.New("energyevse: circuit capacity must be positive")
+	ErrNotPluggedIn           = errors.New("energyevse: no vehicle plugged in")
+)
+
+// Delegate switches the charger hardware. Errors returned by the Handle
+// methods reject the command and are passed to the client.
+type Delegate interface {
+	// HandleEnableCharging allows charging with up to maximumCurrent (mA).
+	HandleEnableCharging(minimumCurrent, maximumCurrent int64) error
+
+	// HandleDisable stops the supply.
+	HandleDisable() error
+}
+
+// Config provides dependencies for the Energy EVSE cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to.
+	EndpointID datamodel.EndpointID
+
+	// CircuitCapacity is the installed supply capacity in mA.
+	CircuitCapacity int64
+
+	// InitialSessionID is the last session ID used (e.g. restored from
+	// storage). Nil means no session has taken place.
+	InitialSessionID *uint32
+
+	// Delegate switches the charger hardware (optional).
+	Delegate Delegate
+
+	// EventPublisher for session events.
+	// Optional - if nil, events are not emitted.
+	EventPublisher datamodel.EventPublisher
+
+	// OnStateChange is called when State changes (optional).
+	OnStateChange func(endpoint datamodel.EndpointID, state State)
+}
+
+// Cluster implements the Energy EVSE cluster (0x0099).
+type Cluster struct {
+	*datamodel.ClusterBase
+	*datamodel.EventSource
+	config Config
+
+	// cmdMu serializes commands and device updates.
+	cmdMu sync.Mutex
+
+	// Mutable state (protected by mutex)
+	mu                  sync.Mutex
+	pluggedIn           bool
+	demand              bool
+	state               State
+	supplyState         SupplyState
+	faultState          FaultState
+	preFaultSupply      SupplyState // restored when the fault clears
+	chargingUntil       *uint32     // epoch-s
+	minimumCurrent      int64
+	requestedMaximum    int64
+	userMaximum         int64
+	randomizationWindow uint32
+
+	// Session tracking
+	sessionID     *uint32
+	sessionStart  time.Time
+	sessionEnd    time.Time // zero while the session is active
+	sessionEnergy *int64    // mWh
+	transferStart int64     // sessionEnergy when the transfer started
+
+	// Charging expiry timer
+	untilTimer *time.Timer
+	untilGen   uint64
+
+	// now returns the current time (for testing).
+	now func() time.Time
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Energy EVSE cluster. It returns an error if the
+// circuit capacity is not positive.
+func New(cfg Config) (*Cluster, error) {
+	if cfg.CircuitCapacity <= 0 {
+		return nil, ErrInvalidCircuitCapacity
+	}
+
+	c := &Cluster{
+		ClusterBase:         datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		EventSource:         datamodel.NewEventSource(),
+		config:              cfg,
+		state:               StateNotPluggedIn,
+		supplyState:         SupplyStateDisabled,
+		minimumCurrent:      DefaultMinimumChargeCurrent,
+		userMaximum:         cfg.CircuitCapacity,
+		randomizationWindow: DefaultRandomizationDelayWindow,
+		now:                 time.Now,
+	}
+	if cfg.InitialSessionID != nil {
+		id := *cfg.InitialSessionID
+		c.sessionID = &id
+	}
+
+	// Bind event source
+	if cfg.EventPublisher != nil {
+		c.EventSource.Bind(cfg.EndpointID, ClusterID, cfg.EventPublisher)
+		c.EventSource.RegisterEvents([]datamodel.EventEntry{
+			datamodel.NewEventEntry(EventEVConnected, datamodel.EventPriorityInfo, datamodel.PrivilegeView, false),
+			datamodel.NewEventEntry(EventEVNotDetected, datamodel.EventPriorityInfo, datamodel.PrivilegeView, false),
+			datamodel.NewEventEntry(EventEnergyTransferStarted, datamodel.EventPriorityInfo, datamodel.PrivilegeView, false),
+			datamodel.NewEventEntry(EventEnergyTransferStopped, datamodel.EventPriorityInfo, datamodel.PrivilegeView, false),
+			datamodel.NewEventEntry(EventFault, datamodel.EventPriorityCritical, datamodel.PrivilegeView, false),
+		})
+	}
+
+	c.attrList = c.buildAttributeList()
+
+	return c, nil
+}
+
+// buildAttributeList constructs the list of supported attributes.
+func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+	managePriv := datamodel.PrivilegeManage
+	nullable := datamodel.AttrQualityNullable
+
+	return datamodel.MergeAttributeLists([]datamodel.AttributeEntry{
+		datamodel.NewReadOnlyAttribute(AttrState, nullable, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrSupplyState, 0, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrFaultState, 0, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrChargingEnabledUntil, nullable, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrCircuitCapacity, 0, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrMinimumChargeCurrent, 0, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrMaximumChargeCurrent, 0, viewPriv),
+		datamodel.NewReadWriteAttribute(AttrUserMaximumChargeCurrent, 0, viewPriv, managePriv),
+		datamodel.NewReadWriteAttribute(AttrRandomizationDelayWindow, 0, viewPriv, managePriv),
+		datamodel.NewReadOnlyAttribute(AttrSessionID, nullable, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrSessionDuration, nullable, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrSessionEnergyCharged, nullable, viewPriv),
+	})
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	operatePriv := datamodel.PrivilegeOperate
+	timed := datamodel.CmdQualityTimed
+	return []datamodel.CommandEntry{
+		datamodel.NewCommandEntry(CmdDisable, timed, operatePriv),
+		datamodel.NewCommandEntry(CmdEnableCharging, timed, operatePriv),
+	}
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return nil
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch req.Path.Attribute {
+	case AttrState:
+		return w.PutUint(tlv.Anonymous(), uint64(c.state))
+	case AttrSupplyState:
+		return w.PutUint(tlv.Anonymous(), uint64(c.supplyState))
+	case AttrFaultState:
+		return w.PutUint(tlv.Anonymous(), uint64(c.faultState))
+	case AttrChargingEnabledUntil:
+		if c.chargingUntil == nil {
+			return w.PutNull(tlv.Anonymous())
+		}
+		return w.PutUint(tlv.Anonymous(), uint64(*c.chargingUntil))
+	case AttrCircuitCapacity:
+		return w.PutInt(tlv.Anonymous(), c.config.CircuitCapacity)
+	case AttrMinimumChargeCurrent:
+		return w.PutInt(tlv.Anonymous(), c.minimumCurrent)
+	case AttrMaximumChargeCurrent:
+		return w.PutInt(tlv.Anonymous(), c.maximumCurrentLocked())
+	case AttrUserMaximumChargeCurrent:
+		return w.PutInt(tlv.Anonymous(), c.userMaximum)
+	case AttrRandomizationDelayWindow:
+		return w.PutUint(tlv.Anonymous(), uint64(c.randomizationWindow))
+	case AttrSessionID:
+		if c.sessionID == nil {
+			return w.PutNull(tlv.Anonymous())
+		}
+		return w.PutUint(tlv.Anonymous(), uint64(*c.sessionID))
+	case AttrSessionDuration:
+		if c.sessionID == nil {
+			return w.PutNull(tlv.Anonymous())
+		}
+		return w.PutUint(tlv.Anonymous(), uint64(c.sessionDurationLocked()))
+	case AttrSessionEnergyCharged:
+		if c.sessionEnergy == nil {
+			return w.PutNull(tlv.Anonymous())
+		}
+		return w.PutInt(tlv.Anonymous(), *c.sessionEnergy)
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	if err := r.Next(); err != nil {
+		return err
+	}
+
+	switch req.Path.Attribute {
+	case AttrUserMaximumChargeCurrent:
+		v, err := readInt(r)
+		if err != nil {
+			return err
+		}
+		return c.SetUserMaximumChargeCurrent(v)
+
+	case AttrRandomizationDelayWindow:
+		v, err := r.Uint()
+		if err != nil {
+			return err
+		}
+		if v > uint64(MaxRandomizationDelayWindow) {
+			return datamodel.ErrConstraintError
+		}
+		c.mu.Lock()
+		if c.randomizationWindow != uint32(v) {
+			c.randomizationWindow = uint32(v)
+			c.IncrementDataVersion()
+		}
+		c.mu.Unlock()
+		return nil
+
+	default:
+		return datamodel.ErrUnsupportedWrite
+	}
+}
+
+// maximumCurrentLocked returns the offered maximum charge current: the
+// lowest of the requested maximum, the circuit capacity and the user
+// limit. Caller must hold c.mu.
+func (c *Cluster) maximumCurrentLocked() int64 {
+	if c.supplyState != SupplyStateChargingEnabled {
+		return 0
+	}
+	max := c.requestedMaximum
+	if c.config.CircuitCapacity < max {
+		max = c.config.CircuitCapacity
+	}
+	if c.userMaximum < max {
+		max = c.userMaximum
+	}
+	return max
+}
+
+// sessionDurationLocked returns the session duration in seconds. Caller
+// must hold c.mu.
+func (c *Cluster) sessionDurationLocked() uint32 {
+	end := c.sessionEnd
+	if end.IsZero() {
+		end = c.now()
+	}
+	return uint32(end.Sub(c.sessionStart) / time.Second)
+}
+
+// State returns the current State.
+func (c *Cluster) State() State {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+// SupplyState returns the current SupplyState.
+func (c *Cluster) SupplyState() SupplyState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.supplyState
+}
+
+// MaximumChargeCurrent returns the offered maximum charge current in mA,
+// or 0 if charging is not enabled.
+func (c *Cluster) MaximumChargeCurrent() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.maximumCurrentLocked()
+}
+
+// SessionEnergyCharged returns the energy delivered in the current or
+// last session in mWh, or nil if no session has taken place.
+func (c *Cluster) SessionEnergyCharged() *int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sessionEnergy == nil {
+		return nil
+	}
+	v := *c.sessionEnergy
+	return &v
+}
+
+// SetUserMaximumChargeCurrent sets the user's charge current limit in mA.
+// It is clamped to the circuit capacity.
+func (c *Cluster) SetUserMaximumChargeCurrent(mA int64) error {
+	if mA < 0 {
+		return datamodel.ErrConstraintError
+	}
+	if mA > c.config.CircuitCapacity {
+		mA = c.config.CircuitCapacity
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.userMaximum == mA {
+		return nil
+	}
+	c.userMaximum = mA
+	c.IncrementDataVersion()
+	return nil
+}
+
+// emit emits an event if a publisher is bound.
+func (c *Cluster) emit(eventID datamodel.EventID, priority datamodel.EventPriority, payload interface{}) error {
+	if !c.EventSource.IsBound() {
+		return nil // No publisher, silently skip
+	}
+	_, err := c.EventSource.Emit(eventID, priority, payload)
+	return err
+}
@@ -0,0 +1,317 @@
+package energyevse
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/backkem/matter/pkg/credentials"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// mockEventPublisher implements EventPublisher for testing.
+type mockEventPublisher struct {
+	mu     sync.Mutex
+	events []publishedEvent
+}
+
+type publishedEvent struct {
+	id   datamodel.EventID
+	data interface{}
+}
+
+func (m *mockEventPublisher) PublishEvent(
+	endpoint datamodel.EndpointID,
+	cluster datamodel.ClusterID,
+	eventID datamodel.EventID,
+	priority datamodel.EventPriority,
+	data interface{},
+	fabricIndex uint8,
+) (datamodel.EventNumber, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, publishedEvent{id: eventID, data: data})
+	return datamodel.EventNumber(len(m.events)), nil
+}
+
+// take returns and clears the published events.
+func (m *mockEventPublisher) take() []publishedEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	events := m.events
+	m.events = nil
+	return events
+}
+
+// testDelegate records the offered current and optionally rejects.
+type testDelegate struct {
+	reject  error
+	offered int64
+}
+
+func (d *testDelegate) HandleEnableCharging(min, max int64) error {
+	if d.reject != nil {
+		return d.reject
+	}
+	d.offered = max
+	return nil
+}
+
+func (d *testDelegate) HandleDisable() error {
+	d.offered = 0
+	return nil
+}
+
+func newEVSE(t *testing.T, d Delegate, pub datamodel.EventPublisher) *Cluster {
+	t.Helper()
+	c, err := New(Config{
+		EndpointID:      1,
+		CircuitCapacity: 32000,
+		Delegate:        d,
+		EventPublisher:  pub,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+func encodeEnableCharging(until *uint32, min, max int64) *tlv.Reader {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	w.StartStructure(tlv.Anonymous())
+	if until == nil {
+		w.PutNull(tlv.ContextTag(0))
+	} else {
+		w.PutUint(tlv.ContextTag(0), uint64(*until))
+	}
+	w.PutInt(tlv.ContextTag(1), min)
+	w.PutInt(tlv.ContextTag(2), max)
+	w.EndContainer()
+	return tlv.NewReader(bytes.NewReader(buf.Bytes()))
+}
+
+func invoke(c *Cluster, cmd datamodel.CommandID, r *tlv.Reader, timed bool) error {
+	req := datamodel.InvokeRequest{
+		Path: datamodel.ConcreteCommandPath{Endpoint: 1, Cluster: ClusterID, Command: cmd},
+	}
+	if timed {
+		req.InvokeFlags = datamodel.InvokeFlagTimed
+	}
+	_, err := c.InvokeCommand(context.Background(), req, r)
+	return err
+}
+
+func eventIDs(events []publishedEvent) []datamodel.EventID {
+	ids := make([]datamodel.EventID, len(events))
+	for i, e := range events {
+		ids[i] = e.id
+	}
+	return ids
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(Config{}); !errors.Is(err, ErrInvalidCircuitCapacity) {
+		t.Errorf("New() error = %v, want ErrInvalidCircuitCapacity", err)
+	}
+}
+
+func TestEnableCharging_Command(t *testing.T) {
+	d := &testDelegate{}
+	c := newEVSE(t, d, nil)
+
+	if err := invoke(c, CmdEnableCharging, encodeEnableCharging(nil, 6000, 16000), false); !errors.Is(err, datamodel.ErrTimedRequired) {
+		t.Errorf("untimed error = %v, want ErrTimedRequired", err)
+	}
+	if err := invoke(c, CmdEnableCharging, encodeEnableCharging(nil, 16000, 6000), true); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("min > max error = %v, want ErrConstraintError", err)
+	}
+	if err := invoke(c, CmdEnableCharging, encodeEnableCharging(nil, 6000, 40000), true); err != nil {
+		t.Fatalf("EnableCharging error = %v", err)
+	}
+	if c.SupplyState() != SupplyStateChargingEnabled {
+		t.Errorf("SupplyState = %d, want ChargingEnabled", c.SupplyState())
+	}
+	// Limited by the 32 A circuit
+	if d.offered != 32000 || c.MaximumChargeCurrent() != 32000 {
+		t.Errorf("offered = %d, MaximumChargeCurrent = %d, want 32000", d.offered, c.MaximumChargeCurrent())
+	}
+
+	// The user limit applies too
+	c.SetUserMaximumChargeCurrent(10000)
+	if c.MaximumChargeCurrent() != 10000 {
+		t.Errorf("MaximumChargeCurrent = %d, want 10000", c.MaximumChargeCurrent())
+	}
+
+	if err := invoke(c, CmdDisable, nil, true); err != nil {
+		t.Fatalf("Disable error = %v", err)
+	}
+	if c.SupplyState() != SupplyStateDisabled || c.MaximumChargeCurrent() != 0 {
+		t.Errorf("after Disable: SupplyState = %d, MaximumChargeCurrent = %d", c.SupplyState(), c.MaximumChargeCurrent())
+	}
+}
+
+func TestChargingSession(t *testing.T) {
+	pub := &mockEventPublisher{}
+	c := newEVSE(t, nil, pub)
+	start := time.Date(2024, 3, 1, 18, 0, 0, 0, time.UTC)
+	now := start
+	c.now = func() time.Time { return now }
+
+	c.PlugIn()
+	if c.State() != StatePluggedInNoDemand {
+		t.Errorf("State = %d, want PluggedInNoDemand", c.State())
+	}
+	c.SetEVDemand(true)
+	if c.State() != StatePluggedInDemand {
+		t.Errorf("State = %d, want PluggedInDemand", c.State())
+	}
+	if ids := eventIDs(pub.take()); len(ids) != 1 || ids[0] != EventEVConnected {
+		t.Fatalf("events = %v, want [EVConnected]", ids)
+	}
+
+	c.EnableCharging(nil, 6000, 16000)
+	if c.State() != StatePluggedInCharging {
+		t.Fatalf("State = %d, want PluggedInCharging", c.State())
+	}
+	events := pub.take()
+	if len(events) != 1 || events[0].id != EventEnergyTransferStarted {
+		t.Fatalf("events = %v, want [EnergyTransferStarted]", eventIDs(events))
+	}
+	if ev := events[0].data.(EnergyTransferStartedEvent); ev.MaximumCurrent != 16000 || ev.SessionID != 0 {
+		t.Errorf("EnergyTransferStarted = %+v", ev)
+	}
+
+	c.AddSessionEnergy(7000)
+	now = start.Add(2 * time.Hour)
+	c.Unplug()
+
+	events = pub.take()
+	if ids := eventIDs(events); len(ids) != 2 || ids[0] != EventEnergyTransferStopped || ids[1] != EventEVNotDetected {
+		t.Fatalf("events = %v, want [EnergyTransferStopped EVNotDetected]", ids)
+	}
+	stopped := events[0].data.(EnergyTransferStoppedEvent)
+	if stopped.Reason != StoppedReasonEVStopped || stopped.EnergyTransferred != 7000 {
+		t.Errorf("EnergyTransferStopped = %+v", stopped)
+	}
+	gone := events[1].data.(EVNotDetectedEvent)
+	if gone.State != StatePluggedInCharging || gone.SessionDuration != 7200 || gone.SessionEnergyCharged != 7000 {
+		t.Errorf("EVNotDetected = %+v", gone)
+	}
+	if c.State() != StateNotPluggedIn {
+		t.Errorf("State = %d, want NotPluggedIn", c.State())
+	}
+
+	// Session attributes keep the last session
+	if e := c.SessionEnergyCharged(); e == nil || *e != 7000 {
+		t.Errorf("SessionEnergyCharged = %v, want 7000", e)
+	}
+
+	// The next session gets a new ID and starts from zero
+	c.PlugIn()
+	if ev := pub.take()[0].data.(EVConnectedEvent); ev.SessionID != 1 {
+		t.Errorf("SessionID = %d, want 1", ev.SessionID)
+	}
+	if e := c.SessionEnergyCharged(); e == nil || *e != 0 {
+		t.Errorf("SessionEnergyCharged = %v, want 0", e)
+	}
+}
+
+func TestChargingEnabledUntil(t *testing.T) {
+	pub := &mockEventPublisher{}
+	c := newEVSE(t, nil, pub)
+	now := time.Date(2024, 3, 1, 18, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	c.PlugIn()
+	c.SetEVDemand(true)
+
+	past := credentials.TimeToMatterEpoch(now) - 1
+	c.EnableCharging(&past, 6000, 16000)
+	if c.SupplyState() != SupplyStateDisabled {
+		t.Errorf("SupplyState = %d, want Disabled for a past time", c.SupplyState())
+	}
+
+	until := credentials.TimeToMatterEpoch(now) + 3600
+	c.EnableCharging(&until, 6000, 16000)
+	pub.take()
+
+	// A stale timer is ignored
+	c.expireCharging(c.untilGen - 1)
+	if c.State() != StatePluggedInCharging {
+		t.Fatal("stale timer stopped charging")
+	}
+
+	c.expireCharging(c.untilGen)
+	if c.SupplyState() != SupplyStateDisabled || c.State() != StatePluggedInDemand {
+		t.Errorf("after expiry: SupplyState = %d, State = %d", c.SupplyState(), c.State())
+	}
+	events := pub.take()
+	if len(events) != 1 || events[0].data.(EnergyTransferStoppedEvent).Reason != StoppedReasonEVSEStopped {
+		t.Errorf("events = %v, want EnergyTransferStopped(EVSEStopped)", eventIDs(events))
+	}
+}
+
+func TestFault(t *testing.T) {
+	pub := &mockEventPublisher{}
+	c := newEVSE(t, nil, pub)
+
+	c.PlugIn()
+	c.SetEVDemand(true)
+	c.EnableCharging(nil, 6000, 16000)
+	pub.take()
+
+	c.SetFault(FaultStateGroundFault)
+	if c.State() != StateFault || c.SupplyState() != SupplyStateDisabledError {
+		t.Errorf("State = %d, SupplyState = %d", c.State(), c.SupplyState())
+	}
+	events := pub.take()
+	if ids := eventIDs(events); len(ids) != 2 || ids[0] != EventEnergyTransferStopped || ids[1] != EventFault {
+		t.Fatalf("events = %v, want [EnergyTransferStopped Fault]", ids)
+	}
+	if f := events[1].data.(FaultEvent); f.PreviousFault != FaultStateNoError || f.CurrentFault != FaultStateGroundFault {
+		t.Errorf("Fault = %+v", f)
+	}
+
+	if err := c.EnableCharging(nil, 6000, 16000); !errors.Is(err, ErrFaulted) {
+		t.Errorf("EnableCharging in fault error = %v, want ErrFaulted", err)
+	}
+
+	// Clearing the fault resumes charging
+	c.SetFault(FaultStateNoError)
+	if c.State() != StatePluggedInCharging || c.SupplyState() != SupplyStateChargingEnabled {
+		t.Errorf("after clear: State = %d, SupplyState = %d", c.State(), c.SupplyState())
+	}
+}
+
+func TestWriteAttributes(t *testing.T) {
+	c := newEVSE(t, nil, nil)
+
+	write := func(attr datamodel.AttributeID, v uint64) error {
+		var buf bytes.Buffer
+		tlv.NewWriter(&buf).PutUint(tlv.Anonymous(), v)
+		req := datamodel.WriteAttributeRequest{
+			Path: datamodel.ConcreteDataAttributePath{
+				ConcreteAttributePath: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: attr},
+			},
+		}
+		return c.WriteAttribute(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes())))
+	}
+
+	if err := write(AttrRandomizationDelayWindow, 90000); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("RandomizationDelayWindow error = %v, want ErrConstraintError", err)
+	}
+	if err := write(AttrUserMaximumChargeCurrent, 50000); err != nil {
+		t.Fatalf("UserMaximumChargeCurrent error = %v", err)
+	}
+	if c.userMaximum != 32000 {
+		t.Errorf("UserMaximumChargeCurrent = %d, want clamped to 32000", c.userMaximum)
+	}
+	if err := write(AttrCircuitCapacity, 1); !errors.Is(err, datamodel.ErrUnsupportedWrite) {
+		t.Errorf("CircuitCapacity error = %v, want ErrUnsupportedWrite", err)
+	}
+}
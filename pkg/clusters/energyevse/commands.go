@@ -0,0 +1,222 @@
+package energyevse
+
+import (
+	"context"
+	"time"
+
+	"github.com/backkem/matter/pkg/clusters"
+	"github.com/backkem/matter/pkg/credentials"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// MaxChargeCurrent is the upper bound of the EnableCharging currents (80 A).
+const MaxChargeCurrent int64 = 80000
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	switch req.Path.Command {
+	case CmdDisable:
+		if err := clusters.RequireTimed(req); err != nil {
+			return nil, err
+		}
+		if err := c.Disable(); err != nil {
+			return nil, err
+		}
+
+	case CmdEnableCharging:
+		if err := clusters.RequireTimed(req); err != nil {
+			return nil, err
+		}
+		until, min, max, err := decodeEnableCharging(r)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.EnableCharging(until, min, max); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, datamodel.ErrUnsupportedCommand
+	}
+	return clusters.EmptyResponse(), nil
+}
+
+// decodeEnableCharging decodes an EnableCharging request (Spec 9.3.9.2).
+func decodeEnableCharging(r *tlv.Reader) (until *uint32, min, max int64, err error) {
+	if err := r.Next(); err != nil {
+		return nil, 0, 0, datamodel.ErrInvalidCommand
+	}
+	if err := r.EnterContainer(); err != nil {
+		return nil, 0, 0, datamodel.ErrInvalidCommand
+	}
+
+	var seen [3]bool
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		tag := r.Tag()
+		if !tag.IsContext() || tag.TagNumber() > 2 {
+			continue
+		}
+		n := tag.TagNumber()
+		seen[n] = true
+		switch n {
+		case 0:
+			if r.Type() == tlv.ElementTypeNull {
+				continue
+			}
+			v, err := r.Uint()
+			if err != nil || v > 0xFFFFFFFF {
+				return nil, 0, 0, datamodel.ErrInvalidCommand
+			}
+			u := uint32(v)
+			until = &u
+		case 1, 2:
+			v, err := readInt(r)
+			if err != nil {
+				return nil, 0, 0, datamodel.ErrInvalidCommand
+			}
+			if n == 1 {
+				min = v
+			} else {
+				max = v
+			}
+		}
+	}
+	if !seen[0] || !seen[1] || !seen[2] {
+		return nil, 0, 0, datamodel.ErrInvalidCommand
+	}
+	return until, min, max, nil
+}
+
+// readInt reads a signed or unsigned integer element.
+func readInt(r *tlv.Reader) (int64, error) {
+	if v, err := r.Uint(); err == nil {
+		if v > 1<<63-1 {
+			return 0, datamodel.ErrConstraintError
+		}
+		return int64(v), nil
+	}
+	return r.Int()
+}
+
+// EnableCharging handles the EnableCharging command: it allows charging
+// between minimumCurrent and maximumCurrent (mA) until the given time
+// (seconds since the Matter epoch; nil for indefinitely). A time in the
+// past disables charging.
+//
+// Spec: Section 9.3.9.2
+func (c *Cluster) EnableCharging(until *uint32, minimumCurrent, maximumCurrent int64) error {
+	if minimumCurrent < 0 || minimumCurrent > MaxChargeCurrent ||
+		maximumCurrent < minimumCurrent || maximumCurrent > MaxChargeCurrent {
+		return datamodel.ErrConstraintError
+	}
+
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	now := c.now()
+	if until != nil && *until <= credentials.TimeToMatterEpoch(now) {
+		return c.disable()
+	}
+
+	c.mu.Lock()
+	if c.faultState != FaultStateNoError || c.supplyState == SupplyStateDisabledDiagnostics {
+		c.mu.Unlock()
+		return ErrFaulted
+	}
+	offered := maximumCurrent
+	if c.config.CircuitCapacity < offered {
+		offered = c.config.CircuitCapacity
+	}
+	if c.userMaximum < offered {
+		offered = c.userMaximum
+	}
+	c.mu.Unlock()
+
+	if d := c.config.Delegate; d != nil {
+		if err := d.HandleEnableCharging(minimumCurrent, offered); err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	c.stopTimerLocked()
+	if until != nil {
+		u := *until
+		c.chargingUntil = &u
+		gen := c.untilGen
+		expiry := credentials.MatterEpochStart.Add(time.Duration(u) * time.Second)
+		c.untilTimer = time.AfterFunc(expiry.Sub(now), func() {
+			c.expireCharging(gen)
+		})
+	} else {
+		c.chargingUntil = nil
+	}
+	c.minimumCurrent = minimumCurrent
+	c.requestedMaximum = maximumCurrent
+	c.supplyState = SupplyStateChargingEnabled
+	c.IncrementDataVersion()
+	events, changed := c.applyLocked(StoppedReasonEVSEStopped)
+	c.mu.Unlock()
+
+	return c.publish(events, changed)
+}
+
+// Disable handles the Disable command: it stops charging.
+//
+// Spec: Section 9.3.9.1
+func (c *Cluster) Disable() error {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+	return c.disable()
+}
+
+// disable stops charging. Caller must hold c.cmdMu.
+func (c *Cluster) disable() error {
+	if d := c.config.Delegate; d != nil {
+		if err := d.HandleDisable(); err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	c.stopTimerLocked()
+	c.chargingUntil = nil
+	if c.faultState != FaultStateNoError {
+		c.preFaultSupply = SupplyStateDisabled
+	} else {
+		c.supplyState = SupplyStateDisabled
+	}
+	c.IncrementDataVersion()
+	events, changed := c.applyLocked(StoppedReasonEVSEStopped)
+	c.mu.Unlock()
+
+	return c.publish(events, changed)
+}
+
+// expireCharging disables charging when the ChargingEnabledUntil time set
+// as generation gen is reached.
+func (c *Cluster) expireCharging(gen uint64) {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	c.mu.Lock()
+	current := c.untilTimer != nil && c.untilGen == gen
+	c.mu.Unlock()
+	if current {
+		c.disable()
+	}
+}
+
+// stopTimerLocked cancels the ChargingEnabledUntil timer. Caller must
+// hold c.mu.
+func (c *Cluster) stopTimerLocked() {
+	if c.untilTimer != nil {
+		c.untilTimer.Stop()
+		c.untilTimer = nil
+	}
+	c.untilGen++
+}
@@ -0,0 +1,42 @@
+package energyevse
+
+// EVConnectedEvent is emitted when a vehicle is plugged in (Spec 9.3.10.1).
+// Priority: INFO, Conformance: M
+type EVConnectedEvent struct {
+	SessionID uint32 `tlv:"0"`
+}
+
+// EVNotDetectedEvent is emitted when the vehicle is unplugged (Spec 9.3.10.2).
+// Priority: INFO, Conformance: M
+type EVNotDetectedEvent struct {
+	SessionID            uint32 `tlv:"0"`
+	State                State  `tlv:"1"` // state before the vehicle was unplugged
+	SessionDuration      uint32 `tlv:"2"` // seconds
+	SessionEnergyCharged int64  `tlv:"3"` // mWh
+}
+
+// EnergyTransferStartedEvent is emitted when charging starts (Spec 9.3.10.3).
+// Priority: INFO, Conformance: M
+type EnergyTransferStartedEvent struct {
+	SessionID      uint32 `tlv:"0"`
+	State          State  `tlv:"1"`
+	MaximumCurrent int64  `tlv:"2"` // mA
+}
+
+// EnergyTransferStoppedEvent is emitted when charging stops (Spec 9.3.10.4).
+// Priority: INFO, Conformance: M
+type EnergyTransferStoppedEvent struct {
+	SessionID         uint32                      `tlv:"0"`
+	State             State                       `tlv:"1"`
+	Reason            EnergyTransferStoppedReason `tlv:"2"`
+	EnergyTransferred int64                       `tlv:"4"` // mWh since the transfer started; tag 3 is reserved
+}
+
+// FaultEvent is emitted when the fault state changes (Spec 9.3.10.5).
+// Priority: CRITICAL, Conformance: M
+type FaultEvent struct {
+	SessionID     *uint32    `tlv:"0,nullable"` // null if no vehicle is plugged in
+	State         State      `tlv:"1"`
+	PreviousFault FaultState `tlv:"2"`
+	CurrentFault  FaultState `tlv:"4"` // tag 3 is reserved
+}
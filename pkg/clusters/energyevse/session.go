@@ -0,0 +1,210 @@
+package energyevse
+
+import (
+	"time"
+
+	"github.com/backkem/matter/pkg/datamodel"
+)
+
+// pendingEvent is an event queued under the lock and emitted after it.
+type pendingEvent struct {
+	id       datamodel.EventID
+	priority datamodel.EventPriority
+	payload  interface{}
+}
+
+// PlugIn reports a vehicle being plugged in. It starts a new session and
+// emits EVConnected.
+func (c *Cluster) PlugIn() error {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	c.mu.Lock()
+	if c.pluggedIn {
+		c.mu.Unlock()
+		return nil
+	}
+	id := uint32(0)
+	if c.sessionID != nil {
+		id = *c.sessionID + 1
+	}
+	energy := int64(0)
+	c.sessionID = &id
+	c.sessionStart = c.now()
+	c.sessionEnd = time.Time{}
+	c.sessionEnergy = &energy
+	c.pluggedIn = true
+	c.demand = false
+	c.IncrementDataVersion()
+
+	events := []pendingEvent{{EventEVConnected, datamodel.EventPriorityInfo, EVConnectedEvent{SessionID: id}}}
+	more, changed := c.applyLocked(StoppedReasonEVStopped)
+	c.mu.Unlock()
+
+	return c.publish(append(events, more...), changed)
+}
+
+// Unplug reports the vehicle being unplugged. It ends any energy transfer
+// and the session, and emits EVNotDetected.
+func (c *Cluster) Unplug() error {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	c.mu.Lock()
+	if !c.pluggedIn {
+		c.mu.Unlock()
+		return ErrNotPluggedIn
+	}
+	prev := c.state
+	c.pluggedIn = false
+	c.demand = false
+	events, changed := c.applyLocked(StoppedReasonEVStopped)
+	c.sessionEnd = c.now()
+	c.IncrementDataVersion()
+	events = append(events, pendingEvent{EventEVNotDetected, datamodel.EventPriorityInfo, EVNotDetectedEvent{
+		SessionID:            *c.sessionID,
+		State:                prev,
+		SessionDuration:      c.sessionDurationLocked(),
+		SessionEnergyCharged: *c.sessionEnergy,
+	}})
+	c.mu.Unlock()
+
+	return c.publish(events, changed)
+}
+
+// SetEVDemand reports whether the plugged-in vehicle asks for energy.
+func (c *Cluster) SetEVDemand(demand bool) error {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	c.mu.Lock()
+	if !c.pluggedIn {
+		c.mu.Unlock()
+		return ErrNotPluggedIn
+	}
+	c.demand = demand
+	events, changed := c.applyLocked(StoppedReasonEVStopped)
+	c.mu.Unlock()
+
+	return c.publish(events, changed)
+}
+
+// AddSessionEnergy adds metered energy (mWh) to SessionEnergyCharged.
+func (c *Cluster) AddSessionEnergy(mWh int64) error {
+	if mWh < 0 {
+		return datamodel.ErrConstraintError
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.pluggedIn {
+		return ErrNotPluggedIn
+	}
+	if mWh == 0 {
+		return nil
+	}
+	*c.sessionEnergy += mWh
+	c.IncrementDataVersion()
+	return nil
+}
+
+// SetFault reports a fault, or its clearing with FaultStateNoError. A
+// fault disables the supply and stops any energy transfer; clearing it
+// restores the previous supply state. Each change emits a Fault event.
+func (c *Cluster) SetFault(fault FaultState) error {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	c.mu.Lock()
+	prev := c.faultState
+	if prev == fault {
+		c.mu.Unlock()
+		return nil
+	}
+	switch {
+	case prev == FaultStateNoError:
+		c.preFaultSupply = c.supplyState
+		c.supplyState = SupplyStateDisabledError
+	case fault == FaultStateNoError:
+		c.supplyState = c.preFaultSupply
+	}
+	c.faultState = fault
+	c.IncrementDataVersion()
+	events, changed := c.applyLocked(StoppedReasonOther)
+	var sessionID *uint32
+	if c.pluggedIn {
+		id := *c.sessionID
+		sessionID = &id
+	}
+	events = append(events, pendingEvent{EventFault, datamodel.EventPriorityCritical, FaultEvent{
+		SessionID:     sessionID,
+		State:         c.state,
+		PreviousFault: prev,
+		CurrentFault:  fault,
+	}})
+	c.mu.Unlock()
+
+	return c.publish(events, changed)
+}
+
+// deriveStateLocked computes State from the vehicle, supply and fault
+// state. Caller must hold c.mu.
+func (c *Cluster) deriveStateLocked() State {
+	switch {
+	case c.faultState != FaultStateNoError:
+		return StateFault
+	case !c.pluggedIn:
+		return StateNotPluggedIn
+	case !c.demand:
+		return StatePluggedInNoDemand
+	case c.supplyState == SupplyStateChargingEnabled:
+		return StatePluggedInCharging
+	default:
+		return StatePluggedInDemand
+	}
+}
+
+// applyLocked updates State and returns the energy transfer events the
+// change causes. reason is reported if a transfer stops. Caller must hold
+// c.mu.
+func (c *Cluster) applyLocked(reason EnergyTransferStoppedReason) ([]pendingEvent, bool) {
+	prev := c.state
+	next := c.deriveStateLocked()
+	if prev == next {
+		return nil, false
+	}
+	c.state = next
+	c.IncrementDataVersion()
+
+	var events []pendingEvent
+	if prev == StatePluggedInCharging {
+		events = append(events, pendingEvent{EventEnergyTransferStopped, datamodel.EventPriorityInfo, EnergyTransferStoppedEvent{
+			SessionID:         *c.sessionID,
+			State:             next,
+			Reason:            reason,
+			EnergyTransferred: *c.sessionEnergy - c.transferStart,
+		}})
+	}
+	if next == StatePluggedInCharging {
+		c.transferStart = *c.sessionEnergy
+		events = append(events, pendingEvent{EventEnergyTransferStarted, datamodel.EventPriorityInfo, EnergyTransferStartedEvent{
+			SessionID:      *c.sessionID,
+			State:          next,
+			MaximumCurrent: c.maximumCurrentLocked(),
+		}})
+	}
+	return events, true
+}
+
+// publish emits queued events and notifies a state change.
+func (c *Cluster) publish(events []pendingEvent, changed bool) error {
+	if changed && c.config.OnStateChange != nil {
+		c.config.OnStateChange(c.config.EndpointID, c.State())
+	}
+	for _, e := range events {
+		if err := c.emit(e.id, e.priority, e.payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,303 @@
+// Package generaldiagnostics implements the General Diagnostics Cluster
+// (0x0033).
+//
+// The cluster reports the node's network interfaces, reboot count and
+// uptime, and serves the TestEventTrigger command: a test harness holding
+// the node's enable key asks it to simulate an event, such as a fault or a
+// state change, so that certification tests exercise behavior they cannot
+// provoke otherwise. Triggers are dispatched to a TestEventTriggerHandler,
+// typically a TriggerRegistry of per-cluster delegates. The fault events
+// are not implemented.
+//
+// C++ Reference: src/app/clusters/general-diagnostics-server
+package generaldiagnostics
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x0033
+	ClusterRevision uint16              = 2
+)
+
+// Attribute IDs.
+const (
+	AttrNetworkInterfaces        datamodel.AttributeID = 0x0000
+	AttrRebootCount              datamodel.AttributeID = 0x0001
+	AttrUpTime                   datamodel.AttributeID = 0x0002
+	AttrTestEventTriggersEnabled datamodel.AttributeID = 0x0008
+)
+
+// Command IDs.
+const (
+	CmdTestEventTrigger     datamodel.CommandID = 0x00
+	CmdTimeSnapshot         datamodel.CommandID = 0x01
+	CmdTimeSnapshotResponse datamodel.CommandID = 0x02
+)
+
+// EnableKeySize is the size of the TestEventTrigger enable key.
+const EnableKeySize = 16
+
+// Errors returned by New and TestEventTriggerHandlers.
+var (
+	ErrInvalidEnableKey = errors.New("generaldiagnostics: enable key must be 16 bytes")
+
+	// ErrUnknownTrigger is returned by a TestEventTriggerHandler for a
+	// trigger it does not support. The command fails with INVALID_COMMAND.
+	ErrUnknownTrigger = errors.New("generaldiagnostics: unknown test event trigger")
+)
+
+// TestEventTriggerHandler simulates the events of test event triggers.
+type TestEventTriggerHandler interface {
+	// HandleTestEventTrigger simulates the event of a trigger. It returns
+	// ErrUnknownTrigger for triggers it does not support.
+	HandleTestEventTrigger(trigger uint64) error
+}
+
+// TestEventTriggerFunc adapts a function to a TestEventTriggerHandler.
+type TestEventTriggerFunc func(trigger uint64) error
+
+// HandleTestEventTrigger implements TestEventTriggerHandler.
+func (f TestEventTriggerFunc) HandleTestEventTrigger(trigger uint64) error {
+	return f(trigger)
+}
+
+// Config provides dependencies for the General Diagnostics cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to (the root).
+	EndpointID datamodel.EndpointID
+
+	// NetworkInterfaces returns the node's network interfaces.
+	// If nil, SystemNetworkInterfaces is used.
+	NetworkInterfaces func() ([]NetworkInterface, error)
+
+	// RebootCount is the number of times the node has rebooted.
+	RebootCount uint16
+
+	// EnableKey authorizes TestEventTrigger commands. It must be 16
+	// bytes, and not all zero, for test event triggers to be enabled.
+	// If nil, TestEventTrigger is rejected.
+	EnableKey []byte
+
+	// TestEventTriggers handles the triggers of TestEventTrigger.
+	// If nil, test event triggers are disabled.
+	TestEventTriggers TestEventTriggerHandler
+}
+
+// Cluster implements the General Diagnostics cluster (0x0033).
+type Cluster struct {
+	*datamodel.ClusterBase
+	config Config
+
+	// start is when the cluster was created, from which UpTime counts.
+	start time.Time
+
+	// now returns the current time (for testing).
+	now func() time.Time
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new General Diagnostics cluster.
+func New(cfg Config) (*Cluster, error) {
+	if cfg.EnableKey != nil && len(cfg.EnableKey) != EnableKeySize {
+		return nil, ErrInvalidEnableKey
+	}
+
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		config:      cfg,
+		now:         time.Now,
+	}
+	c.start = c.now()
+
+	viewPriv := datamodel.PrivilegeView
+	c.attrList = datamodel.MergeAttributeLists([]datamodel.AttributeEntry{
+		datamodel.NewReadOnlyAttribute(AttrNetworkInterfaces, datamodel.AttrQualityList, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrRebootCount, 0, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrUpTime, 0, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrTestEventTriggersEnabled, 0, viewPriv),
+	})
+
+	return c, nil
+}
+
+// TestEventTriggersEnabled reports whether TestEventTrigger commands are
+// accepted: an enable key other than all zeros and a handler are
+// configured.
+func (c *Cluster) TestEventTriggersEnabled() bool {
+	if c.config.TestEventTriggers == nil || c.config.EnableKey == nil {
+		return false
+	}
+	return !bytes.Equal(c.config.EnableKey, make([]byte, EnableKeySize))
+}
+
+// UpTime returns the time since the cluster was created.
+func (c *Cluster) UpTime() time.Duration {
+	return c.now().Sub(c.start)
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	return []datamodel.CommandEntry{
+		datamodel.NewCommandEntry(CmdTestEventTrigger, 0, datamodel.PrivilegeManage),
+		datamodel.NewCommandEntry(CmdTimeSnapshot, 0, datamodel.PrivilegeOperate),
+	}
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return []datamodel.CommandID{CmdTimeSnapshotResponse}
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	switch req.Path.Attribute {
+	case AttrNetworkInterfaces:
+		return c.readNetworkInterfaces(w)
+	case AttrRebootCount:
+		return w.PutUint(tlv.Anonymous(), uint64(c.config.RebootCount))
+	case AttrUpTime:
+		return w.PutUint(tlv.Anonymous(), uint64(c.UpTime()/time.Second))
+	case AttrTestEventTriggersEnabled:
+		return w.PutBool(tlv.Anonymous(), c.TestEventTriggersEnabled())
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// readNetworkInterfaces encodes the NetworkInterfaces attribute.
+func (c *Cluster) readNetworkInterfaces(w *tlv.Writer) error {
+	list := c.config.NetworkInterfaces
+	if list == nil {
+		list = SystemNetworkInterfaces
+	}
+	ifaces, err := list()
+	if err != nil {
+		return err
+	}
+
+	if err := w.StartArray(tlv.Anonymous()); err != nil {
+		return err
+	}
+	for _, iface := range ifaces {
+		if err := iface.encode(w); err != nil {
+			return err
+		}
+	}
+	return w.EndContainer()
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	return datamodel.ErrUnsupportedWrite
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	return datamodel.ResponseFields(c.InvokeCommandResponse(ctx, req, r))
+}
+
+// InvokeCommandResponse implements datamodel.ClusterWithCommandResponses.
+// TimeSnapshot is answered with a TimeSnapshotResponse; TestEventTrigger
+// with a status.
+func (c *Cluster) InvokeCommandResponse(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) (*datamodel.CommandResponse, error) {
+	switch req.Path.Command {
+	case CmdTestEventTrigger:
+		key, trigger, err := decodeTestEventTrigger(r)
+		if err != nil {
+			return nil, err
+		}
+		return nil, c.TestEventTrigger(key, trigger)
+	case CmdTimeSnapshot:
+		now := c.now()
+		return datamodel.NewCommandResponse(CmdTimeSnapshotResponse, func(w *tlv.Writer) error {
+			if err := w.PutUint(tlv.ContextTag(0), uint64(now.Sub(c.start).Milliseconds())); err != nil {
+				return err
+			}
+			return w.PutUint(tlv.ContextTag(1), uint64(now.UnixMilli()))
+		})
+	default:
+		return nil, datamodel.ErrUnsupportedCommand
+	}
+}
+
+// TestEventTrigger simulates the event of a trigger, if key matches the
+// enable key. A mismatched key, or disabled triggers, is a
+// ConstraintError; an unknown trigger an InvalidCommand.
+func (c *Cluster) TestEventTrigger(key []byte, trigger uint64) error {
+	if !c.TestEventTriggersEnabled() || subtle.ConstantTimeCompare(key, c.config.EnableKey) != 1 {
+		return datamodel.ErrConstraintError
+	}
+	err := c.config.TestEventTriggers.HandleTestEventTrigger(trigger)
+	if errors.Is(err, ErrUnknownTrigger) {
+		return datamodel.ErrInvalidCommand
+	}
+	return err
+}
+
+// decodeTestEventTrigger decodes the fields of TestEventTrigger:
+// EnableKey (0) and EventTrigger (1).
+func decodeTestEventTrigger(r *tlv.Reader) ([]byte, uint64, error) {
+	if err := r.Next(); err != nil {
+		return nil, 0, datamodel.ErrInvalidCommand
+	}
+	if err := r.EnterContainer(); err != nil {
+		return nil, 0, datamodel.ErrInvalidCommand
+	}
+
+	var key []byte
+	var trigger uint64
+	var hasKey, hasTrigger bool
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		tag := r.Tag()
+		if !tag.IsContext() {
+			continue
+		}
+		switch tag.TagNumber() {
+		case 0:
+			v, err := r.Bytes()
+			if err != nil {
+				return nil, 0, datamodel.ErrInvalidCommand
+			}
+			if len(v) != EnableKeySize {
+				return nil, 0, datamodel.ErrConstraintError
+			}
+			key, hasKey = v, true
+		case 1:
+			v, err := r.Uint()
+			if err != nil {
+				return nil, 0, datamodel.ErrInvalidCommand
+			}
+			trigger, hasTrigger = v, true
+		}
+	}
+	if !hasKey || !hasTrigger {
+		return nil, 0, datamodel.ErrInvalidCommand
+	}
+	return key, trigger, nil
+}
@@ -0,0 +1,186 @@
+package generaldiagnostics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+var testKey = []byte{
+	0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77,
+	0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff,
+}
+
+func invoke(c *Cluster, cmd datamodel.CommandID, encode func(w *tlv.Writer)) (*datamodel.CommandResponse, error) {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	w.StartStructure(tlv.Anonymous())
+	if encode != nil {
+		encode(w)
+	}
+	w.EndContainer()
+
+	req := datamodel.InvokeRequest{
+		Path: datamodel.ConcreteCommandPath{Endpoint: 0, Cluster: ClusterID, Command: cmd},
+	}
+	return c.InvokeCommandResponse(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes())))
+}
+
+func testEventTrigger(c *Cluster, key []byte, trigger uint64) error {
+	_, err := invoke(c, CmdTestEventTrigger, func(w *tlv.Writer) {
+		w.PutBytes(tlv.ContextTag(0), key)
+		w.PutUint(tlv.ContextTag(1), trigger)
+	})
+	return err
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(Config{EnableKey: []byte{1, 2, 3}}); !errors.Is(err, ErrInvalidEnableKey) {
+		t.Errorf("error = %v, want ErrInvalidEnableKey", err)
+	}
+}
+
+func TestTestEventTrigger(t *testing.T) {
+	var fired []uint64
+	handler := TestEventTriggerFunc(func(trigger uint64) error {
+		if trigger != 0x0099000000000001 {
+			return ErrUnknownTrigger
+		}
+		fired = append(fired, trigger)
+		return nil
+	})
+
+	c, err := New(Config{EnableKey: testKey, TestEventTriggers: handler})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if !c.TestEventTriggersEnabled() {
+		t.Fatal("TestEventTriggersEnabled() = false, want true")
+	}
+
+	if err := testEventTrigger(c, testKey, 0x0099000000000001); err != nil {
+		t.Errorf("TestEventTrigger() error = %v", err)
+	}
+	if len(fired) != 1 {
+		t.Errorf("fired = %v, want one trigger", fired)
+	}
+	if err := testEventTrigger(c, testKey, 0x0099000000000002); !errors.Is(err, datamodel.ErrInvalidCommand) {
+		t.Errorf("unknown trigger error = %v, want ErrInvalidCommand", err)
+	}
+	wrong := bytes.Repeat([]byte{0x01}, EnableKeySize)
+	if err := testEventTrigger(c, wrong, 0x0099000000000001); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("wrong key error = %v, want ErrConstraintError", err)
+	}
+	if err := testEventTrigger(c, testKey[:8], 0x0099000000000001); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("short key error = %v, want ErrConstraintError", err)
+	}
+
+	// An all-zero key disables triggers
+	zero := make([]byte, EnableKeySize)
+	c, _ = New(Config{EnableKey: zero, TestEventTriggers: handler})
+	if c.TestEventTriggersEnabled() {
+		t.Error("TestEventTriggersEnabled() = true with a zero key")
+	}
+	if err := testEventTrigger(c, zero, 0x0099000000000001); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("disabled error = %v, want ErrConstraintError", err)
+	}
+}
+
+func TestTimeSnapshot(t *testing.T) {
+	c, _ := New(Config{RebootCount: 3})
+	now := c.start.Add(90 * time.Second)
+	c.now = func() time.Time { return now }
+
+	resp, err := invoke(c, CmdTimeSnapshot, nil)
+	if err != nil {
+		t.Fatalf("TimeSnapshot error = %v", err)
+	}
+	if resp.Command != CmdTimeSnapshotResponse {
+		t.Errorf("response command = 0x%02x, want 0x%02x", resp.Command, CmdTimeSnapshotResponse)
+	}
+
+	r := tlv.NewReader(bytes.NewReader(resp.Fields))
+	r.Next()
+	r.EnterContainer()
+	r.Next()
+	if ms, _ := r.Uint(); ms != 90000 {
+		t.Errorf("SystemTimeMs = %d, want 90000", ms)
+	}
+	r.Next()
+	if ms, _ := r.Uint(); ms != uint64(now.UnixMilli()) {
+		t.Errorf("PosixTimeMs = %d, want %d", ms, now.UnixMilli())
+	}
+
+	if c.UpTime() != 90*time.Second {
+		t.Errorf("UpTime() = %v, want 90s", c.UpTime())
+	}
+}
+
+func TestNetworkInterfaces(t *testing.T) {
+	reachable := true
+	c, _ := New(Config{
+		NetworkInterfaces: func() ([]NetworkInterface, error) {
+			return []NetworkInterface{{
+				Name:                            "eth0",
+				IsOperational:                   true,
+				OffPremiseServicesReachableIPv6: &reachable,
+				HardwareAddress:                 []byte{0x02, 0x00, 0x00, 0x00, 0x00, 0x01},
+				IPv4Addresses:                   []net.IP{net.ParseIP("192.168.1.10")},
+				IPv6Addresses:                   []net.IP{net.ParseIP("fe80::1"), net.ParseIP("10.0.0.1")},
+				Type:                            InterfaceTypeEthernet,
+			}}, nil
+		},
+	})
+
+	var buf bytes.Buffer
+	req := datamodel.ReadAttributeRequest{
+		Path: datamodel.ConcreteAttributePath{Endpoint: 0, Cluster: ClusterID, Attribute: AttrNetworkInterfaces},
+	}
+	if err := c.ReadAttribute(context.Background(), req, tlv.NewWriter(&buf)); err != nil {
+		t.Fatalf("ReadAttribute() error = %v", err)
+	}
+
+	var got any
+	if err := tlv.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	list, _ := got.([]any)
+	if len(list) != 1 {
+		t.Fatalf("NetworkInterfaces = %#v, want 1 entry", got)
+	}
+	iface, _ := list[0].(map[tlv.Tag]any)
+	want := map[uint8]any{
+		0: "eth0",
+		1: true,
+		2: nil,
+		3: true,
+		4: []byte{0x02, 0x00, 0x00, 0x00, 0x00, 0x01},
+		5: []any{[]byte{192, 168, 1, 10}},
+		6: []any{[]byte(net.ParseIP("fe80::1").To16())}, // The IPv4 address is left out
+		7: uint64(InterfaceTypeEthernet),
+	}
+	for tag, v := range want {
+		if !reflect.DeepEqual(iface[tlv.ContextTag(tag)], v) {
+			t.Errorf("field %d = %#v, want %#v", tag, iface[tlv.ContextTag(tag)], v)
+		}
+	}
+}
+
+func TestSystemNetworkInterfaces(t *testing.T) {
+	ifaces, err := SystemNetworkInterfaces()
+	if err != nil {
+		t.Fatalf("SystemNetworkInterfaces() error = %v", err)
+	}
+	for _, iface := range ifaces {
+		if n := len(iface.HardwareAddress); n != 6 && n != 8 {
+			t.Errorf("%s: hardware address is %d bytes", iface.Name, n)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package generaldiagnostics
+
+import (
+	"net"
+
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// InterfaceType is the type of a network interface (InterfaceTypeEnum).
+type InterfaceType uint8
+
+// InterfaceType values.
+const (
+	InterfaceTypeUnspecified InterfaceType = 0
+	InterfaceTypeWiFi        InterfaceType = 1
+	InterfaceTypeEthernet    InterfaceType = 2
+	InterfaceTypeCellular    InterfaceType = 3
+	InterfaceTypeThread      InterfaceType = 4
+)
+
+// Limits of the NetworkInterface struct. Longer names and further
+// addresses are left out of the encoding.
+const (
+	maxInterfaceNameLength = 32
+	maxIPv4Addresses       = 4
+	maxIPv6Addresses       = 8
+)
+
+// NetworkInterface describes a network interface of the node, as reported
+// in the NetworkInterfaces attribute.
+type NetworkInterface struct {
+	// Name is the interface's name, e.g. "eth0".
+	Name string
+
+	// IsOperational reports whether the interface can send and receive.
+	IsOperational bool
+
+	// OffPremiseServicesReachableIPv4 and OffPremiseServicesReachableIPv6
+	// report whether services off the premises are reachable over the
+	// interface. If nil, reachability is unknown (null).
+	OffPremiseServicesReachableIPv4 *bool
+	OffPremiseServicesReachableIPv6 *bool
+
+	// HardwareAddress is the interface's 6-byte MAC or 8-byte EUI-64
+	// address.
+	HardwareAddress []byte
+
+	// IPv4Addresses and IPv6Addresses are the interface's addresses.
+	IPv4Addresses []net.IP
+	IPv6Addresses []net.IP
+
+	// Type is the interface's type.
+	Type InterfaceType
+}
+
+// encode writes the interface as a NetworkInterface struct.
+func (n NetworkInterface) encode(w *tlv.Writer) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	name := n.Name
+	if len(name) > maxInterfaceNameLength {
+		name = name[:maxInterfaceNameLength]
+	}
+	if err := w.PutString(tlv.ContextTag(0), name); err != nil {
+		return err
+	}
+	if err := w.PutBool(tlv.ContextTag(1), n.IsOperational); err != nil {
+		return err
+	}
+	for i, reachable := range []*bool{n.OffPremiseServicesReachableIPv4, n.OffPremiseServicesReachableIPv6} {
+		tag := tlv.ContextTag(uint8(2 + i))
+		var err error
+		if reachable == nil {
+			err = w.PutNull(tag)
+		} else {
+			err = w.PutBool(tag, *reachable)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := w.PutBytes(tlv.ContextTag(4), n.HardwareAddress); err != nil {
+		return err
+	}
+	if err := encodeAddresses(w, tlv.ContextTag(5), n.IPv4Addresses, net.IPv4len, maxIPv4Addresses); err != nil {
+		return err
+	}
+	if err := encodeAddresses(w, tlv.ContextTag(6), n.IPv6Addresses, net.IPv6len, maxIPv6Addresses); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(7), uint64(n.Type)); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// encodeAddresses writes up to max addresses of the given size as a list
+// of octet strings.
+func encodeAddresses(w *tlv.Writer, tag tlv.Tag, addrs []net.IP, size, max int) error {
+	if err := w.StartArray(tag); err != nil {
+		return err
+	}
+	n := 0
+	for _, ip := range addrs {
+		if size == net.IPv4len {
+			ip = ip.To4()
+		} else if ip.To4() != nil {
+			continue
+		}
+		if ip == nil || n == max {
+			continue
+		}
+		if err := w.PutBytes(tlv.Anonymous(), ip.To16()[net.IPv6len-size:]); err != nil {
+			return err
+		}
+		n++
+	}
+	return w.EndContainer()
+}
+
+// SystemNetworkInterfaces returns the host's network interfaces from
+// net.Interfaces, leaving out loopback interfaces and those without a MAC
+// or EUI-64 address. Their type and the reachability of off-premise
+// services are not known.
+func SystemNetworkInterfaces() ([]NetworkInterface, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []NetworkInterface
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		if len(iface.HardwareAddr) != 6 && len(iface.HardwareAddr) != 8 {
+			continue
+		}
+		ni := NetworkInterface{
+			Name:            iface.Name,
+			IsOperational:   iface.Flags&net.FlagUp != 0 && iface.Flags&net.FlagRunning != 0,
+			HardwareAddress: iface.HardwareAddr,
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			if ip4 := ipNet.IP.To4(); ip4 != nil {
+				ni.IPv4Addresses = append(ni.IPv4Addresses, ip4)
+			} else {
+				ni.IPv6Addresses = append(ni.IPv6Addresses, ipNet.IP)
+			}
+		}
+		out = append(out, ni)
+	}
+	return out, nil
+}
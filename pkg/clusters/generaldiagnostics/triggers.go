@@ -0,0 +1,82 @@
+package generaldiagnostics
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/backkem/matter/pkg/datamodel"
+)
+
+// ErrDelegateExists is returned by Register for a cluster that already has
+// a trigger delegate.
+var ErrDelegateExists = errors.New("generaldiagnostics: cluster already has a trigger delegate")
+
+// TriggerCluster returns the cluster a trigger belongs to: the upper 16
+// bits of a trigger are the ID of the cluster whose event it simulates, as
+// in the C++ SDK and the certification test suites. Only standard clusters
+// fit.
+func TriggerCluster(trigger uint64) datamodel.ClusterID {
+	return datamodel.ClusterID(trigger >> 48)
+}
+
+// Trigger returns the trigger of cluster with the given value in the lower
+// 48 bits.
+func Trigger(cluster datamodel.ClusterID, value uint64) uint64 {
+	return uint64(cluster&0xFFFF)<<48 | value&(1<<48-1)
+}
+
+// TriggerRegistry is a TestEventTriggerHandler dispatching each trigger to
+// the delegate registered for its cluster (see TriggerCluster), so that the
+// clusters of a device handle their own triggers. It is safe for
+// concurrent use.
+//
+// C++ Reference: src/app/TestEventTriggerDelegate.h
+type TriggerRegistry struct {
+	mu        sync.RWMutex
+	delegates map[datamodel.ClusterID]TestEventTriggerHandler
+	fallback  TestEventTriggerHandler
+}
+
+// NewTriggerRegistry creates an empty registry. Triggers of clusters
+// without a delegate go to fallback; if nil, they are unknown.
+func NewTriggerRegistry(fallback TestEventTriggerHandler) *TriggerRegistry {
+	return &TriggerRegistry{
+		delegates: make(map[datamodel.ClusterID]TestEventTriggerHandler),
+		fallback:  fallback,
+	}
+}
+
+// Register sets the delegate handling the triggers of cluster.
+func (r *TriggerRegistry) Register(cluster datamodel.ClusterID, delegate TestEventTriggerHandler) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.delegates[cluster]; ok {
+		return ErrDelegateExists
+	}
+	r.delegates[cluster] = delegate
+	return nil
+}
+
+// Unregister removes the delegate of cluster, if any.
+func (r *TriggerRegistry) Unregister(cluster datamodel.ClusterID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.delegates, cluster)
+}
+
+// HandleTestEventTrigger implements TestEventTriggerHandler.
+func (r *TriggerRegistry) HandleTestEventTrigger(trigger uint64) error {
+	r.mu.RLock()
+	delegate, ok := r.delegates[TriggerCluster(trigger)]
+	r.mu.RUnlock()
+
+	switch {
+	case ok:
+		return delegate.HandleTestEventTrigger(trigger)
+	case r.fallback != nil:
+		return r.fallback.HandleTestEventTrigger(trigger)
+	default:
+		return ErrUnknownTrigger
+	}
+}
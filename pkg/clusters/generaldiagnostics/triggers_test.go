@@ -0,0 +1,53 @@
+package generaldiagnostics
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTrigger(t *testing.T) {
+	trigger := Trigger(0x005C, 0x9C)
+	if trigger != 0x005C_0000_0000_009C {
+		t.Errorf("Trigger() = 0x%016X", trigger)
+	}
+	if got := TriggerCluster(trigger); got != 0x005C {
+		t.Errorf("TriggerCluster() = 0x%04X, want 0x005C", got)
+	}
+}
+
+func TestTriggerRegistry(t *testing.T) {
+	var got []uint64
+	record := TestEventTriggerFunc(func(trigger uint64) error {
+		got = append(got, trigger)
+		return nil
+	})
+
+	r := NewTriggerRegistry(nil)
+	if err := r.Register(0x005C, record); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := r.Register(0x005C, record); !errors.Is(err, ErrDelegateExists) {
+		t.Errorf("Register() duplicate error = %v, want ErrDelegateExists", err)
+	}
+
+	if err := r.HandleTestEventTrigger(Trigger(0x005C, 1)); err != nil {
+		t.Errorf("HandleTestEventTrigger() error = %v", err)
+	}
+	if err := r.HandleTestEventTrigger(Trigger(0x0101, 1)); !errors.Is(err, ErrUnknownTrigger) {
+		t.Errorf("unregistered cluster error = %v, want ErrUnknownTrigger", err)
+	}
+	if len(got) != 1 {
+		t.Errorf("delegate got %v, want one trigger", got)
+	}
+
+	r.Unregister(0x005C)
+	if err := r.HandleTestEventTrigger(Trigger(0x005C, 1)); !errors.Is(err, ErrUnknownTrigger) {
+		t.Errorf("unregistered delegate error = %v, want ErrUnknownTrigger", err)
+	}
+
+	// The fallback handles triggers of clusters without a delegate
+	r = NewTriggerRegistry(record)
+	if err := r.HandleTestEventTrigger(Trigger(0x0101, 1)); err != nil || len(got) != 2 {
+		t.Errorf("fallback error = %v, got %v", err, got)
+	}
+}
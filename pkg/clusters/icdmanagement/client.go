@@ -0,0 +1,225 @@
+package icdmanagement
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Encoding and decoding of the ICD Management commands and responses.
+// Clients encode the requests and decode the responses; the request
+// decoders and response encoders let tests stand in for an ICD.
+
+// ErrInvalidCommand is returned when command fields cannot be decoded.
+var ErrInvalidCommand = errors.New("icdmanagement: invalid command fields")
+
+// EncodeRegisterClientRequest encodes a RegisterClient request to TLV.
+func EncodeRegisterClientRequest(req *RegisterClientRequest) ([]byte, error) {
+	return encodeFields(func(w *tlv.Writer) error {
+		if err := w.PutUint(tlv.ContextTag(tagCheckInNodeID), req.CheckInNodeID); err != nil {
+			return err
+		}
+		if err := w.PutUint(tlv.ContextTag(tagMonitoredSubject), req.MonitoredSubject); err != nil {
+			return err
+		}
+		if err := w.PutBytes(tlv.ContextTag(tagKey), req.Key); err != nil {
+			return err
+		}
+		if req.VerificationKey != nil {
+			if err := w.PutBytes(tlv.ContextTag(tagVerificationKey), req.VerificationKey); err != nil {
+				return err
+			}
+		}
+		return w.PutUint(tlv.ContextTag(tagClientType), uint64(req.ClientType))
+	})
+}
+
+// DecodeRegisterClientRequest decodes a RegisterClient request from TLV.
+func DecodeRegisterClientRequest(data []byte) (*RegisterClientRequest, error) {
+	req := &RegisterClientRequest{}
+	err := decodeFields(data, func(tag uint64, r *tlv.Reader) error {
+		var err error
+		switch tag {
+		case tagCheckInNodeID:
+			req.CheckInNodeID, err = r.Uint()
+		case tagMonitoredSubject:
+			req.MonitoredSubject, err = r.Uint()
+		case tagKey:
+			req.Key, err = r.Bytes()
+		case tagVerificationKey:
+			req.VerificationKey, err = r.Bytes()
+		case tagClientType:
+			var v uint64
+			v, err = r.Uint()
+			req.ClientType = ClientType(v)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// EncodeRegisterClientResponse encodes a RegisterClientResponse to TLV.
+func EncodeRegisterClientResponse(resp *RegisterClientResponse) ([]byte, error) {
+	return encodeFields(func(w *tlv.Writer) error {
+		return w.PutUint(tlv.ContextTag(tagICDCounter), uint64(resp.ICDCounter))
+	})
+}
+
+// DecodeRegisterClientResponse decodes a RegisterClientResponse from TLV.
+func DecodeRegisterClientResponse(data []byte) (*RegisterClientResponse, error) {
+	resp := &RegisterClientResponse{}
+	err := decodeFields(data, func(tag uint64, r *tlv.Reader) error {
+		if tag != tagICDCounter {
+			return nil
+		}
+		v, err := r.Uint()
+		resp.ICDCounter = uint32(v)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// EncodeUnregisterClientRequest encodes an UnregisterClient request to TLV.
+func EncodeUnregisterClientRequest(req *UnregisterClientRequest) ([]byte, error) {
+	return encodeFields(func(w *tlv.Writer) error {
+		if err := w.PutUint(tlv.ContextTag(tagCheckInNodeID), req.CheckInNodeID); err != nil {
+			return err
+		}
+		if req.VerificationKey != nil {
+			return w.PutBytes(tlv.ContextTag(tagUnregisterVerificationKey), req.VerificationKey)
+		}
+		return nil
+	})
+}
+
+// DecodeUnregisterClientRequest decodes an UnregisterClient request from
+// TLV.
+func DecodeUnregisterClientRequest(data []byte) (*UnregisterClientRequest, error) {
+	req := &UnregisterClientRequest{}
+	err := decodeFields(data, func(tag uint64, r *tlv.Reader) error {
+		var err error
+		switch tag {
+		case tagCheckInNodeID:
+			req.CheckInNodeID, err = r.Uint()
+		case tagUnregisterVerificationKey:
+			req.VerificationKey, err = r.Bytes()
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// EncodeStayActiveRequest encodes a StayActiveRequest to TLV.
+func EncodeStayActiveRequest(req *StayActiveRequest) ([]byte, error) {
+	return encodeFields(func(w *tlv.Writer) error {
+		return w.PutUint(tlv.ContextTag(tagStayActiveDuration), uint64(req.StayActiveDuration))
+	})
+}
+
+// DecodeStayActiveRequest decodes a StayActiveRequest from TLV.
+func DecodeStayActiveRequest(data []byte) (*StayActiveRequest, error) {
+	req := &StayActiveRequest{}
+	err := decodeFields(data, func(tag uint64, r *tlv.Reader) error {
+		if tag != tagStayActiveDuration {
+			return nil
+		}
+		v, err := r.Uint()
+		req.StayActiveDuration = uint32(v)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// EncodeStayActiveResponse encodes a StayActiveResponse to TLV.
+func EncodeStayActiveResponse(resp *StayActiveResponse) ([]byte, error) {
+	return encodeFields(func(w *tlv.Writer) error {
+		return w.PutUint(tlv.ContextTag(tagPromisedActiveDuration), uint64(resp.PromisedActiveDuration))
+	})
+}
+
+// DecodeStayActiveResponse decodes a StayActiveResponse from TLV.
+func DecodeStayActiveResponse(data []byte) (*StayActiveResponse, error) {
+	resp := &StayActiveResponse{}
+	err := decodeFields(data, func(tag uint64, r *tlv.Reader) error {
+		if tag != tagPromisedActiveDuration {
+			return nil
+		}
+		v, err := r.Uint()
+		resp.PromisedActiveDuration = uint32(v)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// encodeFields encodes the fields written by fn as an anonymous
+// structure.
+func encodeFields(fn func(w *tlv.Writer) error) ([]byte, error) {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return nil, err
+	}
+	if err := fn(w); err != nil {
+		return nil, err
+	}
+	if err := w.EndContainer(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decodeFields calls fn with each context-tagged field of an anonymous
+// structure. Unknown fields are skipped.
+func decodeFields(data []byte, fn func(tag uint64, r *tlv.Reader) error) error {
+	r := tlv.NewReader(bytes.NewReader(data))
+
+	if err := r.Next(); err != nil {
+		return ErrInvalidCommand
+	}
+	if r.Type() != tlv.ElementTypeStruct {
+		return ErrInvalidCommand
+	}
+	if err := r.EnterContainer(); err != nil {
+		return err
+	}
+
+	for {
+		if err := r.Next(); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+		if r.IsEndOfContainer() {
+			break
+		}
+		tag := r.Tag()
+		if !tag.IsContext() {
+			continue
+		}
+		if err := fn(uint64(tag.TagNumber()), r); err != nil {
+			return ErrInvalidCommand
+		}
+	}
+
+	return r.ExitContainer()
+}
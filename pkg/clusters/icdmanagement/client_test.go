@@ -0,0 +1,99 @@
+package icdmanagement
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestRegisterClient_RoundTrip(t *testing.T) {
+	req := &RegisterClientRequest{
+		CheckInNodeID:    0x1122334455667788,
+		MonitoredSubject: 0x1122334455667788,
+		Key:              bytes.Repeat([]byte{0x01}, 16),
+		ClientType:       ClientTypePermanent,
+	}
+	data, err := EncodeRegisterClientRequest(req)
+	if err != nil {
+		t.Fatalf("EncodeRegisterClientRequest() error = %v", err)
+	}
+	got, err := DecodeRegisterClientRequest(data)
+	if err != nil {
+		t.Fatalf("DecodeRegisterClientRequest() error = %v", err)
+	}
+	if got.CheckInNodeID != req.CheckInNodeID || got.MonitoredSubject != req.MonitoredSubject ||
+		!bytes.Equal(got.Key, req.Key) || got.VerificationKey != nil || got.ClientType != req.ClientType {
+		t.Errorf("DecodeRegisterClientRequest() = %+v, want %+v", got, req)
+	}
+
+	// With a verification key
+	req.VerificationKey = bytes.Repeat([]byte{0x02}, 16)
+	data, _ = EncodeRegisterClientRequest(req)
+	if got, _ = DecodeRegisterClientRequest(data); !bytes.Equal(got.VerificationKey, req.VerificationKey) {
+		t.Errorf("VerificationKey = %x, want %x", got.VerificationKey, req.VerificationKey)
+	}
+
+	respData, err := EncodeRegisterClientResponse(&RegisterClientResponse{ICDCounter: 0xFFFFFFF0})
+	if err != nil {
+		t.Fatalf("EncodeRegisterClientResponse() error = %v", err)
+	}
+	resp, err := DecodeRegisterClientResponse(respData)
+	if err != nil {
+		t.Fatalf("DecodeRegisterClientResponse() error = %v", err)
+	}
+	if resp.ICDCounter != 0xFFFFFFF0 {
+		t.Errorf("ICDCounter = %#x, want 0xFFFFFFF0", resp.ICDCounter)
+	}
+}
+
+func TestUnregisterClient_RoundTrip(t *testing.T) {
+	req := &UnregisterClientRequest{CheckInNodeID: 42, VerificationKey: bytes.Repeat([]byte{0x03}, 16)}
+	data, err := EncodeUnregisterClientRequest(req)
+	if err != nil {
+		t.Fatalf("EncodeUnregisterClientRequest() error = %v", err)
+	}
+	got, err := DecodeUnregisterClientRequest(data)
+	if err != nil {
+		t.Fatalf("DecodeUnregisterClientRequest() error = %v", err)
+	}
+	if got.CheckInNodeID != 42 || !bytes.Equal(got.VerificationKey, req.VerificationKey) {
+		t.Errorf("DecodeUnregisterClientRequest() = %+v, want %+v", got, req)
+	}
+}
+
+func TestStayActive_RoundTrip(t *testing.T) {
+	data, err := EncodeStayActiveRequest(&StayActiveRequest{StayActiveDuration: 30000})
+	if err != nil {
+		t.Fatalf("EncodeStayActiveRequest() error = %v", err)
+	}
+	req, err := DecodeStayActiveRequest(data)
+	if err != nil {
+		t.Fatalf("DecodeStayActiveRequest() error = %v", err)
+	}
+	if req.StayActiveDuration != 30000 {
+		t.Errorf("StayActiveDuration = %d, want 30000", req.StayActiveDuration)
+	}
+
+	data, err = EncodeStayActiveResponse(&StayActiveResponse{PromisedActiveDuration: 20000})
+	if err != nil {
+		t.Fatalf("EncodeStayActiveResponse() error = %v", err)
+	}
+	resp, err := DecodeStayActiveResponse(data)
+	if err != nil {
+		t.Fatalf("DecodeStayActiveResponse() error = %v", err)
+	}
+	if resp.PromisedActiveDuration != 20000 {
+		t.Errorf("PromisedActiveDuration = %d, want 20000", resp.PromisedActiveDuration)
+	}
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	// A bare unsigned integer rather than a structure
+	if _, err := DecodeStayActiveResponse([]byte{0x04, 0x01}); !errors.Is(err, ErrInvalidCommand) {
+		t.Errorf("DecodeStayActiveResponse() error = %v, want ErrInvalidCommand", err)
+	}
+	// ICDCounter as a byte string
+	if _, err := DecodeRegisterClientResponse([]byte{0x15, 0x30, 0x00, 0x01, 0xAA, 0x18}); !errors.Is(err, ErrInvalidCommand) {
+		t.Errorf("DecodeRegisterClientResponse() error = %v, want ErrInvalidCommand", err)
+	}
+}
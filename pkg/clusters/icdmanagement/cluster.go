@@ -0,0 +1,156 @@
+// Package icdmanagement holds the commands of the ICD Management Cluster
+// (0x0046) that a client of an Intermittently Connected Device sends.
+//
+// A client registers with a Long Idle Time (LIT) ICD with RegisterClient,
+// handing it a symmetric key. The ICD then sends the client a Check-In
+// message, encrypted with that key, whenever it becomes active while the
+// client has no subscription to it (see securechannel.DecodeCheckIn). The
+// client may ask the ICD to stay active longer with StayActiveRequest.
+//
+// Only the encoding of the commands and their responses is implemented:
+// the node does not serve the cluster itself, so it cannot act as an ICD.
+//
+// C++ Reference: src/app/clusters/icd-management-server/
+package icdmanagement
+
+import (
+	"github.com/backkem/matter/pkg/datamodel"
+)
+
+// Cluster constants.
+const (
+	ClusterID datamodel.ClusterID = 0x0046
+)
+
+// Attribute IDs.
+const (
+	AttrIdleModeDuration                 datamodel.AttributeID = 0x0000
+	AttrActiveModeDuration               datamodel.AttributeID = 0x0001
+	AttrActiveModeThreshold              datamodel.AttributeID = 0x0002
+	AttrRegisteredClients                datamodel.AttributeID = 0x0003
+	AttrICDCounter                       datamodel.AttributeID = 0x0004
+	AttrClientsSupportedPerFabric        datamodel.AttributeID = 0x0005
+	AttrUserActiveModeTriggerHint        datamodel.AttributeID = 0x0006
+	AttrUserActiveModeTriggerInstruction datamodel.AttributeID = 0x0007
+	AttrOperatingMode                    datamodel.AttributeID = 0x0008
+	AttrMaximumCheckInBackOff            datamodel.AttributeID = 0x0009
+)
+
+// Command IDs.
+const (
+	CmdRegisterClient         datamodel.CommandID = 0x00
+	CmdRegisterClientResponse datamodel.CommandID = 0x01
+	CmdUnregisterClient       datamodel.CommandID = 0x02
+	CmdStayActiveRequest      datamodel.CommandID = 0x03
+	CmdStayActiveResponse     datamodel.CommandID = 0x04
+)
+
+// Feature bits.
+type Feature uint32
+
+const (
+	// FeatureCheckInProtocolSupport indicates the ICD sends Check-In
+	// messages to registered clients.
+	FeatureCheckInProtocolSupport Feature = 1 << 0 // CIP
+
+	// FeatureUserActiveModeTrigger indicates the ICD can be made active
+	// by a user action.
+	FeatureUserActiveModeTrigger Feature = 1 << 1 // UAT
+
+	// FeatureLongIdleTimeSupport indicates the ICD may operate as a LIT
+	// ICD.
+	FeatureLongIdleTimeSupport Feature = 1 << 2 // LITS
+
+	// FeatureDynamicSitLitSupport indicates the ICD switches between SIT
+	// and LIT operation at runtime.
+	FeatureDynamicSitLitSupport Feature = 1 << 3 // DSLS
+)
+
+// ClientType is the ClientTypeEnum of a registration.
+type ClientType uint8
+
+const (
+	// ClientTypePermanent is a client that stays registered, like a
+	// controller.
+	ClientTypePermanent ClientType = 0
+
+	// ClientTypeEphemeral is a client registered for a short interaction.
+	ClientTypeEphemeral ClientType = 1
+)
+
+// String returns the name of the client type.
+func (t ClientType) String() string {
+	switch t {
+	case ClientTypePermanent:
+		return "Permanent"
+	case ClientTypeEphemeral:
+		return "Ephemeral"
+	default:
+		return "Unknown"
+	}
+}
+
+// Command field tags.
+const (
+	// RegisterClient
+	tagCheckInNodeID    = 0
+	tagMonitoredSubject = 1
+	tagKey              = 2
+	tagVerificationKey  = 3
+	tagClientType       = 4
+
+	// UnregisterClient: tagCheckInNodeID, then
+	tagUnregisterVerificationKey = 1
+
+	// RegisterClientResponse
+	tagICDCounter = 0
+
+	// StayActiveRequest, StayActiveResponse
+	tagStayActiveDuration     = 0
+	tagPromisedActiveDuration = 0
+)
+
+// RegisterClientRequest registers a client to receive Check-In messages.
+type RegisterClientRequest struct {
+	// CheckInNodeID is the node the ICD sends Check-In messages to.
+	CheckInNodeID uint64
+
+	// MonitoredSubject is the subject whose subscriptions stop the ICD
+	// from sending Check-In messages, usually CheckInNodeID.
+	MonitoredSubject uint64
+
+	// Key is the 16-byte symmetric key of the Check-In messages.
+	Key []byte
+
+	// VerificationKey is the key of an existing registration being
+	// replaced. Required unless the client has Administer privilege.
+	VerificationKey []byte
+
+	ClientType ClientType
+}
+
+// RegisterClientResponse is the response to RegisterClient.
+type RegisterClientResponse struct {
+	// ICDCounter is the ICD's Check-In counter at registration.
+	ICDCounter uint32
+}
+
+// UnregisterClientRequest removes a client's registration.
+type UnregisterClientRequest struct {
+	CheckInNodeID   uint64
+	VerificationKey []byte
+}
+
+// StayActiveRequest asks the ICD to stay active.
+type StayActiveRequest struct {
+	// StayActiveDuration is the requested time, in milliseconds.
+	StayActiveDuration uint32
+}
+
+// StayActiveResponse is the response to StayActiveRequest.
+type StayActiveResponse struct {
+	// PromisedActiveDuration is how long, in milliseconds, the ICD
+	// promises to stay active. It may be shorter or longer than
+	// requested.
+	PromisedActiveDuration uint32
+}
@@ -0,0 +1,209 @@
+// Package illuminancemeasurement implements the Illuminance Measurement
+// Cluster (0x0400).
+//
+// MeasuredValue is logarithmic: 10000 × log10(lux) + 1, with 0 meaning
+// too low to be measured. Use LuxToMeasuredValue and MeasuredValueToLux
+// to convert. Changes smaller than Config.ReportThreshold are absorbed
+// without a data version bump.
+//
+// Spec Reference: Section 2.2
+//
+// C++ Reference: src/app/clusters/illuminance-measurement-server
+package illuminancemeasurement
+
+import (
+	"context"
+	"math"
+
+	"github.com/backkem/matter/pkg/clusters"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x0400
+	ClusterRevision uint16              = 3
+)
+
+// Attribute IDs (Spec 2.2.5).
+const (
+	AttrMeasuredValue                          = clusters.AttrMeasuredValue
+	AttrMinMeasuredValue                       = clusters.AttrMinMeasuredValue
+	AttrMaxMeasuredValue                       = clusters.AttrMaxMeasuredValue
+	AttrTolerance                              = clusters.AttrTolerance
+	AttrLightSensorType  datamodel.AttributeID = 0x0004
+)
+
+// LightSensorType identifies the sensor technology (Spec 2.2.4.1).
+type LightSensorType uint8
+
+const (
+	LightSensorTypePhotodiode LightSensorType = 0
+	LightSensorTypeCMOS       LightSensorType = 1
+)
+
+// Config provides dependencies for the Illuminance Measurement cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to.
+	EndpointID datamodel.EndpointID
+
+	// MinMeasuredValue and MaxMeasuredValue bound the sensor range in
+	// encoded units. nil reports the bound as unknown (null).
+	MinMeasuredValue *uint16
+	MaxMeasuredValue *uint16
+
+	// Tolerance is the sensor accuracy in encoded units (optional).
+	Tolerance *uint16
+
+	// LightSensorType is the sensor technology (optional).
+	// nil omits the attribute.
+	LightSensorType *LightSensorType
+
+	// ReportThreshold is the minimum change in encoded units that is
+	// reported to subscribers. Zero reports every change.
+	ReportThreshold uint16
+}
+
+// Cluster implements the Illuminance Measurement cluster (0x0400).
+type Cluster struct {
+	*datamodel.ClusterBase
+	config Config
+	value  *clusters.MeasuredValue
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Illuminance Measurement cluster.
+func New(cfg Config) *Cluster {
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		config:      cfg,
+		value: clusters.NewMeasuredValue(clusters.MeasuredValueConfig{
+			Min:             widen(cfg.MinMeasuredValue),
+			Max:             widen(cfg.MaxMeasuredValue),
+			Tolerance:       cfg.Tolerance,
+			ReportThreshold: uint64(cfg.ReportThreshold),
+		}),
+	}
+
+	attrs := c.value.AttributeList()
+	if cfg.LightSensorType != nil {
+		attrs = append(attrs, datamodel.NewReadOnlyAttribute(
+			AttrLightSensorType, datamodel.AttrQualityNullable|datamodel.AttrQualityFixed, datamodel.PrivilegeView))
+	}
+	c.attrList = datamodel.MergeAttributeLists(attrs)
+
+	return c
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	return nil
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return nil
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	if handled, err := c.value.ReadAttribute(req.Path.Attribute, w); handled {
+		return err
+	}
+
+	switch req.Path.Attribute {
+	case AttrLightSensorType:
+		if c.config.LightSensorType == nil {
+			return datamodel.ErrUnsupportedAttribute
+		}
+		return w.PutUint(tlv.Anonymous(), uint64(*c.config.LightSensorType))
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	// All Illuminance Measurement attributes are read-only
+	return datamodel.ErrUnsupportedWrite
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	return nil, datamodel.ErrUnsupportedCommand
+}
+
+// SetMeasuredValue records a new reading in encoded units; nil marks it
+// unknown. Returns clusters.ErrMeasurementOutOfRange if outside the
+// configured range.
+func (c *Cluster) SetMeasuredValue(value *uint16) error {
+	report, err := c.value.Set(widen(value))
+	if err != nil {
+		return err
+	}
+	if report {
+		c.IncrementDataVersion()
+	}
+	return nil
+}
+
+// SetLux records a new reading in lux.
+func (c *Cluster) SetLux(lux float64) error {
+	v := LuxToMeasuredValue(lux)
+	return c.SetMeasuredValue(&v)
+}
+
+// MeasuredValue returns the current reading in encoded units, or nil if unknown.
+func (c *Cluster) MeasuredValue() *uint16 {
+	v := c.value.Value()
+	if v == nil {
+		return nil
+	}
+	n := uint16(*v)
+	return &n
+}
+
+// LuxToMeasuredValue encodes an illuminance in lux (Spec 2.2.5.1).
+// Values below 1 lux encode as 0 ("too low to be measured"); values above
+// the encodable range saturate at 0xFFFE.
+func LuxToMeasuredValue(lux float64) uint16 {
+	if lux < 1 {
+		return 0
+	}
+	v := math.Round(10000*math.Log10(lux)) + 1
+	if v > 0xFFFE {
+		return 0xFFFE
+	}
+	return uint16(v)
+}
+
+// MeasuredValueToLux decodes an encoded illuminance to lux.
+func MeasuredValueToLux(v uint16) float64 {
+	if v == 0 {
+		return 0
+	}
+	return math.Pow(10, float64(v-1)/10000)
+}
+
+// widen converts a nullable uint16 to a nullable int64.
+func widen(v *uint16) *int64 {
+	if v == nil {
+		return nil
+	}
+	n := int64(*v)
+	return &n
+}
@@ -0,0 +1,73 @@
+package illuminancemeasurement
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+func TestLuxConversion(t *testing.T) {
+	tests := []struct {
+		lux  float64
+		want uint16
+	}{
+		{0, 0},
+		{0.5, 0},
+		{1, 1},
+		{10, 10001},
+		{1000, 30001},
+		{1e7, 0xFFFE},
+	}
+	for _, tt := range tests {
+		if got := LuxToMeasuredValue(tt.lux); got != tt.want {
+			t.Errorf("LuxToMeasuredValue(%v) = %d, want %d", tt.lux, got, tt.want)
+		}
+	}
+
+	if lux := MeasuredValueToLux(30001); math.Abs(lux-1000) > 0.01 {
+		t.Errorf("MeasuredValueToLux(30001) = %v, want 1000", lux)
+	}
+	if lux := MeasuredValueToLux(0); lux != 0 {
+		t.Errorf("MeasuredValueToLux(0) = %v, want 0", lux)
+	}
+}
+
+func TestLightSensorType(t *testing.T) {
+	read := func(c *Cluster, attr datamodel.AttributeID) (uint64, error) {
+		var buf bytes.Buffer
+		req := datamodel.ReadAttributeRequest{
+			Path: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: attr},
+		}
+		if err := c.ReadAttribute(context.Background(), req, tlv.NewWriter(&buf)); err != nil {
+			return 0, err
+		}
+		r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+		if err := r.Next(); err != nil {
+			return 0, err
+		}
+		return r.Uint()
+	}
+
+	c := New(Config{EndpointID: 1})
+	if _, err := read(c, AttrLightSensorType); !errors.Is(err, datamodel.ErrUnsupportedAttribute) {
+		t.Errorf("LightSensorType without config: err = %v, want ErrUnsupportedAttribute", err)
+	}
+
+	sensor := LightSensorTypeCMOS
+	c = New(Config{EndpointID: 1, LightSensorType: &sensor})
+	if v, err := read(c, AttrLightSensorType); err != nil || v != uint64(LightSensorTypeCMOS) {
+		t.Errorf("LightSensorType = %d, %v; want CMOS", v, err)
+	}
+
+	if err := c.SetLux(1000); err != nil {
+		t.Fatalf("SetLux failed: %v", err)
+	}
+	if v, err := read(c, AttrMeasuredValue); err != nil || v != 30001 {
+		t.Errorf("MeasuredValue = %d, %v; want 30001", v, err)
+	}
+}
@@ -0,0 +1,236 @@
+// Package keypadinput implements the Keypad Input Cluster (0x0509).
+//
+// The cluster accepts remote control keys, encoded as HDMI-CEC user
+// control codes, and forwards them to a Delegate that injects them into
+// the media device. Keys in the navigation, location and number groups
+// are rejected with UnsupportedKey unless the matching feature is set.
+// Together with Media Playback and Content Launcher it forms the core of
+// the Video Player device types.
+//
+// Spec Reference: Section 6.8
+//
+// C++ Reference: src/app/clusters/keypad-input-server
+package keypadinput
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x0509
+	ClusterRevision uint16              = 1
+)
+
+// Command IDs (Spec 6.8.6).
+const (
+	CmdSendKey         datamodel.CommandID = 0x00
+	CmdSendKeyResponse datamodel.CommandID = 0x01
+)
+
+// Feature bits (Spec 6.8.4).
+type Feature uint32
+
+const (
+	// FeatureNavigationKeyCodes supports the navigation keys (NV).
+	FeatureNavigationKeyCodes Feature = 1 << 0
+
+	// FeatureLocationKeys supports the home and settings keys (LK).
+	FeatureLocationKeys Feature = 1 << 1
+
+	// FeatureNumberKeys supports numeric input (NK).
+	FeatureNumberKeys Feature = 1 << 2
+)
+
+// Status is the result of a SendKey command (StatusEnum, Spec 6.8.5.2).
+type Status uint8
+
+const (
+	// StatusSuccess indicates the key was handled.
+	StatusSuccess Status = 0
+
+	// StatusUnsupportedKey indicates the key is not supported.
+	StatusUnsupportedKey Status = 1
+
+	// StatusInvalidKeyInCurrentState indicates the key cannot be handled
+	// in the current state, e.g. a number key without an input field.
+	StatusInvalidKeyInCurrentState Status = 2
+)
+
+// String returns the name of the status.
+func (s Status) String() string {
+	switch s {
+	case StatusSuccess:
+		return "Success"
+	case StatusUnsupportedKey:
+		return "UnsupportedKey"
+	case StatusInvalidKeyInCurrentState:
+		return "InvalidKeyInCurrentState"
+	default:
+		return "Unknown"
+	}
+}
+
+// Errors returned by New.
+var (
+	ErrNoDelegate = errors.New("keypadinput: delegate is required")
+)
+
+// Delegate injects keys into the media device.
+type Delegate interface {
+	// HandleSendKey handles a key press. Keys gated by an unsupported
+	// feature are rejected before the delegate is called.
+	HandleSendKey(key KeyCode) Status
+}
+
+// Config provides dependencies for the Keypad Input cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to.
+	EndpointID datamodel.EndpointID
+
+	// FeatureMap indicates supported key groups.
+	FeatureMap Feature
+
+	// Delegate handles key presses (required).
+	Delegate Delegate
+}
+
+// Cluster implements the Keypad Input cluster (0x0509).
+type Cluster struct {
+	*datamodel.ClusterBase
+	config Config
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Keypad Input cluster.
+func New(cfg Config) (*Cluster, error) {
+	if cfg.Delegate == nil {
+		return nil, ErrNoDelegate
+	}
+
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		config:      cfg,
+	}
+
+	c.ClusterBase.SetFeatureMap(uint32(cfg.FeatureMap))
+	c.attrList = datamodel.MergeAttributeLists(nil)
+
+	return c, nil
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	return []datamodel.CommandEntry{
+		datamodel.NewCommandEntry(CmdSendKey, 0, datamodel.PrivilegeOperate),
+	}
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return []datamodel.CommandID{CmdSendKeyResponse}
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+	return datamodel.ErrUnsupportedAttribute
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	return datamodel.ErrUnsupportedWrite
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	switch req.Path.Command {
+	case CmdSendKey:
+		key, err := decodeSendKey(r)
+		if err != nil {
+			return nil, err
+		}
+		return encodeSendKeyResponse(c.SendKey(key))
+	default:
+		return nil, datamodel.ErrUnsupportedCommand
+	}
+}
+
+// decodeSendKey decodes the KeyCode field (tag 0) of SendKey.
+func decodeSendKey(r *tlv.Reader) (KeyCode, error) {
+	if err := r.Next(); err != nil {
+		return 0, datamodel.ErrInvalidCommand
+	}
+	if err := r.EnterContainer(); err != nil {
+		return 0, datamodel.ErrInvalidCommand
+	}
+
+	var key *KeyCode
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		tag := r.Tag()
+		if tag.IsContext() && tag.TagNumber() == 0 {
+			v, err := r.Uint()
+			if err != nil || v > 0xFF {
+				return 0, datamodel.ErrInvalidCommand
+			}
+			k := KeyCode(v)
+			key = &k
+		}
+	}
+	if key == nil {
+		return 0, datamodel.ErrInvalidCommand
+	}
+	return *key, nil
+}
+
+// encodeSendKeyResponse encodes a SendKeyResponse (Spec 6.8.6.2).
+func encodeSendKeyResponse(status Status) ([]byte, error) {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return nil, err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(status)); err != nil {
+		return nil, err
+	}
+	if err := w.EndContainer(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// SendKey handles a key press. Undefined keys and keys whose feature is
+// not supported yield StatusUnsupportedKey; other keys are passed to the
+// delegate.
+//
+// Spec: Section 6.8.6.1
+func (c *Cluster) SendKey(key KeyCode) Status {
+	if !key.IsValid() {
+		return StatusUnsupportedKey
+	}
+	if f := key.Feature(); f != 0 && c.config.FeatureMap&f == 0 {
+		return StatusUnsupportedKey
+	}
+	return c.config.Delegate.HandleSendKey(key)
+}
@@ -0,0 +1,144 @@
+package keypadinput
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// mockDelegate records keys and returns a fixed status.
+type mockDelegate struct {
+	keys   []KeyCode
+	status Status
+}
+
+func (d *mockDelegate) HandleSendKey(key KeyCode) Status {
+	d.keys = append(d.keys, key)
+	return d.status
+}
+
+func sendKey(t *testing.T, c *Cluster, key uint64) Status {
+	t.Helper()
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	w.StartStructure(tlv.Anonymous())
+	w.PutUint(tlv.ContextTag(0), key)
+	w.EndContainer()
+
+	req := datamodel.InvokeRequest{
+		Path: datamodel.ConcreteCommandPath{Endpoint: 1, Cluster: ClusterID, Command: CmdSendKey},
+	}
+	resp, err := c.InvokeCommand(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes())))
+	if err != nil {
+		t.Fatalf("SendKey error = %v", err)
+	}
+
+	r := tlv.NewReader(bytes.NewReader(resp))
+	r.Next()
+	r.EnterContainer()
+	r.Next()
+	v, err := r.Uint()
+	if err != nil {
+		t.Fatalf("decode response error = %v", err)
+	}
+	return Status(v)
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(Config{}); !errors.Is(err, ErrNoDelegate) {
+		t.Errorf("error = %v, want ErrNoDelegate", err)
+	}
+}
+
+func TestSendKey_Features(t *testing.T) {
+	d := &mockDelegate{}
+	c, err := New(Config{EndpointID: 1, FeatureMap: FeatureNavigationKeyCodes, Delegate: d})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		key  KeyCode
+		want Status
+	}{
+		{KeyCodeUp, StatusSuccess},
+		{KeyCodeSelect, StatusSuccess},
+		{KeyCodeExit, StatusSuccess},
+		{KeyCodeRootMenu, StatusUnsupportedKey},
+		{KeyCodeNumbers5, StatusUnsupportedKey},
+		{KeyCodeVolumeUp, StatusSuccess},
+		{KeyCode(0x0E), StatusUnsupportedKey},
+	}
+	for _, tt := range tests {
+		if got := sendKey(t, c, uint64(tt.key)); got != tt.want {
+			t.Errorf("SendKey(%v) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+
+	want := []KeyCode{KeyCodeUp, KeyCodeSelect, KeyCodeExit, KeyCodeVolumeUp}
+	if len(d.keys) != len(want) {
+		t.Fatalf("delegate keys = %v, want %v", d.keys, want)
+	}
+	for i := range want {
+		if d.keys[i] != want[i] {
+			t.Errorf("delegate keys = %v, want %v", d.keys, want)
+			break
+		}
+	}
+}
+
+func TestSendKey_DelegateStatus(t *testing.T) {
+	d := &mockDelegate{status: StatusInvalidKeyInCurrentState}
+	c, _ := New(Config{EndpointID: 1, FeatureMap: FeatureNumberKeys, Delegate: d})
+	if got := sendKey(t, c, uint64(KeyCodeNumber0OrNumber10)); got != StatusInvalidKeyInCurrentState {
+		t.Errorf("SendKey = %v, want InvalidKeyInCurrentState", got)
+	}
+}
+
+func TestSendKey_Invalid(t *testing.T) {
+	c, _ := New(Config{EndpointID: 1, Delegate: &mockDelegate{}})
+	req := datamodel.InvokeRequest{
+		Path: datamodel.ConcreteCommandPath{Endpoint: 1, Cluster: ClusterID, Command: CmdSendKey},
+	}
+
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	w.StartStructure(tlv.Anonymous())
+	w.EndContainer()
+	if _, err := c.InvokeCommand(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes()))); !errors.Is(err, datamodel.ErrInvalidCommand) {
+		t.Errorf("missing key error = %v, want ErrInvalidCommand", err)
+	}
+}
+
+func TestKeyCode_Mapping(t *testing.T) {
+	if KeyCodeF1Blue.String() != "F1Blue" {
+		t.Errorf("String() = %q", KeyCodeF1Blue.String())
+	}
+	if KeyCode(0xFF).String() != "Unknown(0xFF)" {
+		t.Errorf("String() = %q", KeyCode(0xFF).String())
+	}
+	if k, ok := ParseKeyCode("ChannelUp"); !ok || k != KeyCodeChannelUp {
+		t.Errorf("ParseKeyCode(ChannelUp) = %v, %v", k, ok)
+	}
+	if _, ok := ParseKeyCode("Nope"); ok {
+		t.Error("ParseKeyCode accepted an unknown name")
+	}
+
+	features := map[KeyCode]Feature{
+		KeyCodeLeftDown:     FeatureNavigationKeyCodes,
+		KeyCodeContentsMenu: FeatureNavigationKeyCodes,
+		KeyCodeSetupMenu:    FeatureLocationKeys,
+		KeyCodeNumbers9:     FeatureNumberKeys,
+		KeyCodeNumber11:     0,
+		KeyCodePlay:         0,
+	}
+	for k, want := range features {
+		if got := k.Feature(); got != want {
+			t.Errorf("%v.Feature() = %d, want %d", k, got, want)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package keypadinput
+
+import "fmt"
+
+// KeyCode is a key from the HDMI-CEC User Control Codes
+// (CECKeyCodeEnum, Spec 6.8.5.1).
+type KeyCode uint8
+
+const (
+	KeyCodeSelect                    KeyCode = 0x00
+	KeyCodeUp                        KeyCode = 0x01
+	KeyCodeDown                      KeyCode = 0x02
+	KeyCodeLeft                      KeyCode = 0x03
+	KeyCodeRight                     KeyCode = 0x04
+	KeyCodeRightUp                   KeyCode = 0x05
+	KeyCodeRightDown                 KeyCode = 0x06
+	KeyCodeLeftUp                    KeyCode = 0x07
+	KeyCodeLeftDown                  KeyCode = 0x08
+	KeyCodeRootMenu                  KeyCode = 0x09
+	KeyCodeSetupMenu                 KeyCode = 0x0A
+	KeyCodeContentsMenu              KeyCode = 0x0B
+	KeyCodeFavoriteMenu              KeyCode = 0x0C
+	KeyCodeExit                      KeyCode = 0x0D
+	KeyCodeMediaTopMenu              KeyCode = 0x10
+	KeyCodeMediaContextSensitiveMenu KeyCode = 0x11
+	KeyCodeNumberEntryMode           KeyCode = 0x1D
+	KeyCodeNumber11                  KeyCode = 0x1E
+	KeyCodeNumber12                  KeyCode = 0x1F
+	KeyCodeNumber0OrNumber10         KeyCode = 0x20
+	KeyCodeNumbers1                  KeyCode = 0x21
+	KeyCodeNumbers2                  KeyCode = 0x22
+	KeyCodeNumbers3                  KeyCode = 0x23
+	KeyCodeNumbers4                  KeyCode = 0x24
+	KeyCodeNumbers5                  KeyCode = 0x25
+	KeyCodeNumbers6                  KeyCode = 0x26
+	KeyCodeNumbers7                  KeyCode = 0x27
+	KeyCodeNumbers8                  KeyCode = 0x28
+	KeyCodeNumbers9                  KeyCode = 0x29
+	KeyCodeDot                       KeyCode = 0x2A
+	KeyCodeEnter                     KeyCode = 0x2B
+	KeyCodeClear                     KeyCode = 0x2C
+	KeyCodeNextFavorite              KeyCode = 0x2F
+	KeyCodeChannelUp                 KeyCode = 0x30
+	KeyCodeChannelDown               KeyCode = 0x31
+	KeyCodePreviousChannel           KeyCode = 0x32
+	KeyCodeSoundSelect               KeyCode = 0x33
+	KeyCodeInputSelect               KeyCode = 0x34
+	KeyCodeDisplayInformation        KeyCode = 0x35
+	KeyCodeHelp                      KeyCode = 0x36
+	KeyCodePageUp                    KeyCode = 0x37
+	KeyCodePageDown                  KeyCode = 0x38
+	KeyCodePower                     KeyCode = 0x40
+	KeyCodeVolumeUp                  KeyCode = 0x41
+	KeyCodeVolumeDown                KeyCode = 0x42
+	KeyCodeMute                      KeyCode = 0x43
+	KeyCodePlay                      KeyCode = 0x44
+	KeyCodeStop                      KeyCode = 0x45
+	KeyCodePause                     KeyCode = 0x46
+	KeyCodeRecord                    KeyCode = 0x47
+	KeyCodeRewind                    KeyCode = 0x48
+	KeyCodeFastForward               KeyCode = 0x49
+	KeyCodeEject                     KeyCode = 0x4A
+	KeyCodeForward                   KeyCode = 0x4B
+	KeyCodeBackward                  KeyCode = 0x4C
+	KeyCodeStopRecord                KeyCode = 0x4D
+	KeyCodePauseRecord               KeyCode = 0x4E
+	KeyCodeReserved                  KeyCode = 0x4F
+	KeyCodeAngle                     KeyCode = 0x50
+	KeyCodeSubPicture                KeyCode = 0x51
+	KeyCodeVideoOnDemand             KeyCode = 0x52
+	KeyCodeElectronicProgramGuide    KeyCode = 0x53
+	KeyCodeTimerProgramming          KeyCode = 0x54
+	KeyCodeInitialConfiguration      KeyCode = 0x55
+	KeyCodeSelectBroadcastType       KeyCode = 0x56
+	KeyCodeSelectSoundPresentation   KeyCode = 0x57
+	KeyCodePlayFunction              KeyCode = 0x60
+	KeyCodePausePlayFunction         KeyCode = 0x61
+	KeyCodeRecordFunction            KeyCode = 0x62
+	KeyCodePauseRecordFunction       KeyCode = 0x63
+	KeyCodeStopFunction              KeyCode = 0x64
+	KeyCodeMuteFunction              KeyCode = 0x65
+	KeyCodeRestoreVolumeFunction     KeyCode = 0x66
+	KeyCodeTuneFunction              KeyCode = 0x67
+	KeyCodeSelectMediaFunction       KeyCode = 0x68
+	KeyCodeSelectAvInputFunction     KeyCode = 0x69
+	KeyCodeSelectAudioInputFunction  KeyCode = 0x6A
+	KeyCodePowerToggleFunction       KeyCode = 0x6B
+	KeyCodePowerOffFunction          KeyCode = 0x6C
+	KeyCodePowerOnFunction           KeyCode = 0x6D
+	KeyCodeF1Blue                    KeyCode = 0x71
+	KeyCodeF2Red                     KeyCode = 0x72
+	KeyCodeF3Green                   KeyCode = 0x73
+	KeyCodeF4Yellow                  KeyCode = 0x74
+	KeyCodeF5                        KeyCode = 0x75
+	KeyCodeData                      KeyCode = 0x76
+)
+
+// keyNames maps defined key codes to their spec names.
+var keyNames = map[KeyCode]string{
+	KeyCodeSelect:                    "Select",
+	KeyCodeUp:                        "Up",
+	KeyCodeDown:                      "Down",
+	KeyCodeLeft:                      "Left",
+	KeyCodeRight:                     "Right",
+	KeyCodeRightUp:                   "RightUp",
+	KeyCodeRightDown:                 "RightDown",
+	KeyCodeLeftUp:                    "LeftUp",
+	KeyCodeLeftDown:                  "LeftDown",
+	KeyCodeRootMenu:                  "RootMenu",
+	KeyCodeSetupMenu:                 "SetupMenu",
+	KeyCodeContentsMenu:              "ContentsMenu",
+	KeyCodeFavoriteMenu:              "FavoriteMenu",
+	KeyCodeExit:                      "Exit",
+	KeyCodeMediaTopMenu:              "MediaTopMenu",
+	KeyCodeMediaContextSensitiveMenu: "MediaContextSensitiveMenu",
+	KeyCodeNumberEntryMode:           "NumberEntryMode",
+	KeyCodeNumber11:                  "Number11",
+	KeyCodeNumber12:                  "Number12",
+	KeyCodeNumber0OrNumber10:         "Number0OrNumber10",
+	KeyCodeNumbers1:                  "Numbers1",
+	KeyCodeNumbers2:                  "Numbers2",
+	KeyCodeNumbers3:                  "Numbers3",
+	KeyCodeNumbers4:                  "Numbers4",
+	KeyCodeNumbers5:                  "Numbers5",
+	KeyCodeNumbers6:                  "Numbers6",
+	KeyCodeNumbers7:                  "Numbers7",
+	KeyCodeNumbers8:                  "Numbers8",
+	KeyCodeNumbers9:                  "Numbers9",
+	KeyCodeDot:                       "Dot",
+	KeyCodeEnter:                     "Enter",
+	KeyCodeClear:                     "Clear",
+	KeyCodeNextFavorite:              "NextFavorite",
+	KeyCodeChannelUp:                 "ChannelUp",
+	KeyCodeChannelDown:               "ChannelDown",
+	KeyCodePreviousChannel:           "PreviousChannel",
+	KeyCodeSoundSelect:               "SoundSelect",
+	KeyCodeInputSelect:               "InputSelect",
+	KeyCodeDisplayInformation:        "DisplayInformation",
+	KeyCodeHelp:                      "Help",
+	KeyCodePageUp:                    "PageUp",
+	KeyCodePageDown:                  "PageDown",
+	KeyCodePower:                     "Power",
+	KeyCodeVolumeUp:                  "VolumeUp",
+	KeyCodeVolumeDown:                "VolumeDown",
+	KeyCodeMute:                      "Mute",
+	KeyCodePlay:                      "Play",
+	KeyCodeStop:                      "Stop",
+	KeyCodePause:                     "Pause",
+	KeyCodeRecord:                    "Record",
+	KeyCodeRewind:                    "Rewind",
+	KeyCodeFastForward:               "FastForward",
+	KeyCodeEject:                     "Eject",
+	KeyCodeForward:                   "Forward",
+	KeyCodeBackward:                  "Backward",
+	KeyCodeStopRecord:                "StopRecord",
+	KeyCodePauseRecord:               "PauseRecord",
+	KeyCodeReserved:                  "Reserved",
+	KeyCodeAngle:                     "Angle",
+	KeyCodeSubPicture:                "SubPicture",
+	KeyCodeVideoOnDemand:             "VideoOnDemand",
+	KeyCodeElectronicProgramGuide:    "ElectronicProgramGuide",
+	KeyCodeTimerProgramming:          "TimerProgramming",
+	KeyCodeInitialConfiguration:      "InitialConfiguration",
+	KeyCodeSelectBroadcastType:       "SelectBroadcastType",
+	KeyCodeSelectSoundPresentation:   "SelectSoundPresentation",
+	KeyCodePlayFunction:              "PlayFunction",
+	KeyCodePausePlayFunction:         "PausePlayFunction",
+	KeyCodeRecordFunction:            "RecordFunction",
+	KeyCodePauseRecordFunction:       "PauseRecordFunction",
+	KeyCodeStopFunction:              "StopFunction",
+	KeyCodeMuteFunction:              "MuteFunction",
+	KeyCodeRestoreVolumeFunction:     "RestoreVolumeFunction",
+	KeyCodeTuneFunction:              "TuneFunction",
+	KeyCodeSelectMediaFunction:       "SelectMediaFunction",
+	KeyCodeSelectAvInputFunction:     "SelectAvInputFunction",
+	KeyCodeSelectAudioInputFunction:  "SelectAudioInputFunction",
+	KeyCodePowerToggleFunction:       "PowerToggleFunction",
+	KeyCodePowerOffFunction:          "PowerOffFunction",
+	KeyCodePowerOnFunction:           "PowerOnFunction",
+	KeyCodeF1Blue:                    "F1Blue",
+	KeyCodeF2Red:                     "F2Red",
+	KeyCodeF3Green:                   "F3Green",
+	KeyCodeF4Yellow:                  "F4Yellow",
+	KeyCodeF5:                        "F5",
+	KeyCodeData:                      "Data",
+}
+
+// String returns the spec name of the key code.
+func (k KeyCode) String() string {
+	if name, ok := keyNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown(0x%02X)", uint8(k))
+}
+
+// IsValid returns true if k is a defined key code.
+func (k KeyCode) IsValid() bool {
+	_, ok := keyNames[k]
+	return ok
+}
+
+// Feature returns the feature that gates the key, or 0 if the key is not
+// tied to a feature (Spec 6.8.4):
+//   - FeatureNavigationKeyCodes: Select, the directional keys, the contents
+//     menu and Exit
+//   - FeatureLocationKeys: RootMenu (home) and SetupMenu (settings)
+//   - FeatureNumberKeys: the number keys 0 to 9
+func (k KeyCode) Feature() Feature {
+	switch {
+	case k <= KeyCodeLeftDown, k == KeyCodeContentsMenu, k == KeyCodeExit:
+		return FeatureNavigationKeyCodes
+	case k == KeyCodeRootMenu, k == KeyCodeSetupMenu:
+		return FeatureLocationKeys
+	case k >= KeyCodeNumber0OrNumber10 && k <= KeyCodeNumbers9:
+		return FeatureNumberKeys
+	default:
+		return 0
+	}
+}
+
+// ParseKeyCode returns the key code with the given spec name, e.g. "Up"
+// or "Numbers5".
+func ParseKeyCode(name string) (KeyCode, bool) {
+	for k, n := range keyNames {
+		if n == name {
+			return k, true
+		}
+	}
+	return 0, false
+}
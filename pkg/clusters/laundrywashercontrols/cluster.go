@@ -0,0 +1,420 @@
+// Package laundrywashercontrols implements the Laundry Washer Controls
+// Cluster (0x0053).
+//
+// The cluster exposes the spin speed and number of rinses of a washer
+// cycle. Both are written directly by clients: SpinSpeedCurrent selects
+// an entry of the SpinSpeeds list (or null for no spin) and
+// NumberOfRinses one of the SupportedRinses values. A Delegate may veto
+// writes, e.g. once a cycle has passed the rinse phase.
+//
+// Spec Reference: Section 8.6
+//
+// C++ Reference: src/app/clusters/laundry-washer-controls-server/laundry-washer-controls-server.cpp
+package laundrywashercontrols
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x0053
+	ClusterRevision uint16              = 2
+)
+
+// Attribute IDs (Spec 8.6.6).
+const (
+	AttrSpinSpeeds       datamodel.AttributeID = 0x0000
+	AttrSpinSpeedCurrent datamodel.AttributeID = 0x0001
+	AttrNumberOfRinses   datamodel.AttributeID = 0x0002
+	AttrSupportedRinses  datamodel.AttributeID = 0x0003
+)
+
+// Feature bits (Spec 8.6.4).
+type Feature uint32
+
+const (
+	// FeatureSpin supports spin speed selection (SPIN).
+	FeatureSpin Feature = 1 << 0
+
+	// FeatureRinse supports selecting the number of rinses (RINSE).
+	FeatureRinse Feature = 1 << 1
+)
+
+// NumberOfRinses is a NumberOfRinsesEnum value (Spec 8.6.5.1).
+type NumberOfRinses uint8
+
+const (
+	RinsesNone   NumberOfRinses = 0
+	RinsesNormal NumberOfRinses = 1
+	RinsesExtra  NumberOfRinses = 2
+	RinsesMax    NumberOfRinses = 3
+)
+
+// Limits on the spin speed list (Spec 8.6.6.1).
+const (
+	MaxSpinSpeeds      = 16
+	MaxSpinSpeedLength = 64
+)
+
+// Errors returned by New.
+var (
+	ErrInvalidFeatures   = errors.New("laundrywashercontrols: at least one of SPIN and RINSE is required")
+	ErrInvalidSpinSpeeds = errors.New("laundrywashercontrols: invalid spin speed list")
+	ErrInvalidRinses     = errors.New("laundrywashercontrols: invalid supported rinses")
+)
+
+// Delegate lets the application accept or veto client writes.
+type Delegate interface {
+	// HandleSpinSpeed is called before SpinSpeedCurrent changes to a valid
+	// index (nil for no spin). Return an error to reject the write.
+	HandleSpinSpeed(index *uint8) error
+
+	// HandleNumberOfRinses is called before NumberOfRinses changes to a
+	// supported value. Return an error to reject the write.
+	HandleNumberOfRinses(rinses NumberOfRinses) error
+}
+
+// Config provides dependencies for the Laundry Washer Controls cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to.
+	EndpointID datamodel.EndpointID
+
+	// FeatureMap indicates supported features.
+	FeatureMap Feature
+
+	// SpinSpeeds names the supported spin speeds, e.g. "800", "1200" (SPIN).
+	SpinSpeeds []string
+
+	// InitialSpinSpeed is the SpinSpeedCurrent index at startup (nullable, SPIN).
+	InitialSpinSpeed *uint8
+
+	// SupportedRinses lists the selectable rinse counts (RINSE).
+	SupportedRinses []NumberOfRinses
+
+	// InitialRinses is NumberOfRinses at startup (RINSE).
+	// Defaults to the first supported value if not supported.
+	InitialRinses NumberOfRinses
+
+	// Delegate accepts or vetoes writes (optional).
+	Delegate Delegate
+
+	// OnChange is called when SpinSpeedCurrent or NumberOfRinses changes
+	// (optional).
+	OnChange func(endpoint datamodel.EndpointID)
+}
+
+// Cluster implements the Laundry Washer Controls cluster (0x0053).
+type Cluster struct {
+	*datamodel.ClusterBase
+	config Config
+
+	// Mutable state (protected by mutex)
+	mu        sync.Mutex
+	spinSpeed *uint8 // nullable
+	rinses    NumberOfRinses
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Laundry Washer Controls cluster. It returns an error
+// if no feature is set or a list is invalid.
+func New(cfg Config) (*Cluster, error) {
+	if cfg.FeatureMap&(FeatureSpin|FeatureRinse) == 0 {
+		return nil, ErrInvalidFeatures
+	}
+
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		config:      cfg,
+	}
+
+	if cfg.FeatureMap&FeatureSpin != 0 {
+		if len(cfg.SpinSpeeds) == 0 || len(cfg.SpinSpeeds) > MaxSpinSpeeds {
+			return nil, ErrInvalidSpinSpeeds
+		}
+		for _, s := range cfg.SpinSpeeds {
+			if s == "" || len(s) > MaxSpinSpeedLength || !utf8.ValidString(s) {
+				return nil, ErrInvalidSpinSpeeds
+			}
+		}
+		if cfg.InitialSpinSpeed != nil && int(*cfg.InitialSpinSpeed) < len(cfg.SpinSpeeds) {
+			v := *cfg.InitialSpinSpeed
+			c.spinSpeed = &v
+		}
+	}
+
+	if cfg.FeatureMap&FeatureRinse != 0 {
+		if len(cfg.SupportedRinses) == 0 || len(cfg.SupportedRinses) > 4 {
+			return nil, ErrInvalidRinses
+		}
+		for _, r := range cfg.SupportedRinses {
+			if r > RinsesMax {
+				return nil, ErrInvalidRinses
+			}
+		}
+		c.rinses = cfg.SupportedRinses[0]
+		if c.supportsRinses(cfg.InitialRinses) {
+			c.rinses = cfg.InitialRinses
+		}
+	}
+
+	c.ClusterBase.SetFeatureMap(uint32(cfg.FeatureMap))
+	c.attrList = c.buildAttributeList()
+
+	return c, nil
+}
+
+// hasFeature returns true if the feature bit is set.
+func (c *Cluster) hasFeature(f Feature) bool {
+	return c.config.FeatureMap&f != 0
+}
+
+// supportsRinses returns true if r is in SupportedRinses.
+func (c *Cluster) supportsRinses(r NumberOfRinses) bool {
+	for _, v := range c.config.SupportedRinses {
+		if v == r {
+			return true
+		}
+	}
+	return false
+}
+
+// buildAttributeList constructs the list of supported attributes.
+func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+	operatePriv := datamodel.PrivilegeOperate
+
+	var attrs []datamodel.AttributeEntry
+	if c.hasFeature(FeatureSpin) {
+		attrs = append(attrs,
+			datamodel.NewReadOnlyAttribute(AttrSpinSpeeds, datamodel.AttrQualityList, viewPriv),
+			datamodel.NewReadWriteAttribute(AttrSpinSpeedCurrent, datamodel.AttrQualityNullable, viewPriv, operatePriv),
+		)
+	}
+	if c.hasFeature(FeatureRinse) {
+		attrs = append(attrs,
+			datamodel.NewReadWriteAttribute(AttrNumberOfRinses, 0, viewPriv, operatePriv),
+			datamodel.NewReadOnlyAttribute(AttrSupportedRinses, datamodel.AttrQualityList, viewPriv),
+		)
+	}
+
+	return datamodel.MergeAttributeLists(attrs)
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	return nil
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return nil
+}
+
+// hasAttribute returns true if attr is in the attribute list.
+func (c *Cluster) hasAttribute(attr datamodel.AttributeID) bool {
+	for _, a := range c.attrList {
+		if a.ID == attr {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	if !c.hasAttribute(req.Path.Attribute) {
+		return datamodel.ErrUnsupportedAttribute
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch req.Path.Attribute {
+	case AttrSpinSpeeds:
+		if err := w.StartArray(tlv.Anonymous()); err != nil {
+			return err
+		}
+		for _, s := range c.config.SpinSpeeds {
+			if err := w.PutString(tlv.Anonymous(), s); err != nil {
+				return err
+			}
+		}
+		return w.EndContainer()
+	case AttrSpinSpeedCurrent:
+		if c.spinSpeed == nil {
+			return w.PutNull(tlv.Anonymous())
+		}
+		return w.PutUint(tlv.Anonymous(), uint64(*c.spinSpeed))
+	case AttrNumberOfRinses:
+		return w.PutUint(tlv.Anonymous(), uint64(c.rinses))
+	case AttrSupportedRinses:
+		if err := w.StartArray(tlv.Anonymous()); err != nil {
+			return err
+		}
+		for _, r := range c.config.SupportedRinses {
+			if err := w.PutUint(tlv.Anonymous(), uint64(r)); err != nil {
+				return err
+			}
+		}
+		return w.EndContainer()
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	if !c.hasAttribute(req.Path.Attribute) {
+		return datamodel.ErrUnsupportedAttribute
+	}
+
+	switch req.Path.Attribute {
+	case AttrSpinSpeedCurrent:
+		if err := r.Next(); err != nil {
+			return err
+		}
+		if r.Type() == tlv.ElementTypeNull {
+			return c.SetSpinSpeed(nil)
+		}
+		v, err := r.Uint()
+		if err != nil {
+			return err
+		}
+		if v > 0xFF {
+			return datamodel.ErrConstraintError
+		}
+		idx := uint8(v)
+		return c.SetSpinSpeed(&idx)
+	case AttrNumberOfRinses:
+		if err := r.Next(); err != nil {
+			return err
+		}
+		v, err := r.Uint()
+		if err != nil {
+			return err
+		}
+		if v > uint64(RinsesMax) {
+			return datamodel.ErrConstraintError
+		}
+		return c.SetNumberOfRinses(NumberOfRinses(v))
+	default:
+		return datamodel.ErrUnsupportedWrite
+	}
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	return nil, datamodel.ErrUnsupportedCommand
+}
+
+// SpinSpeed returns the SpinSpeedCurrent index, or nil for no spin.
+func (c *Cluster) SpinSpeed() *uint8 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.spinSpeed == nil {
+		return nil
+	}
+	v := *c.spinSpeed
+	return &v
+}
+
+// NumberOfRinses returns the NumberOfRinses attribute.
+func (c *Cluster) NumberOfRinses() NumberOfRinses {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rinses
+}
+
+// SetSpinSpeed sets SpinSpeedCurrent to an index into SpinSpeeds, or nil
+// for no spin. Returns ErrConstraintError for an index out of range.
+func (c *Cluster) SetSpinSpeed(index *uint8) error {
+	if !c.hasFeature(FeatureSpin) {
+		return datamodel.ErrUnsupportedAttribute
+	}
+	if index != nil && int(*index) >= len(c.config.SpinSpeeds) {
+		return datamodel.ErrConstraintError
+	}
+	if d := c.config.Delegate; d != nil {
+		if err := d.HandleSpinSpeed(index); err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	changed := !equalNullable(c.spinSpeed, index)
+	if changed {
+		if index == nil {
+			c.spinSpeed = nil
+		} else {
+			v := *index
+			c.spinSpeed = &v
+		}
+		c.IncrementDataVersion()
+	}
+	c.mu.Unlock()
+
+	c.notify(changed)
+	return nil
+}
+
+// SetNumberOfRinses sets NumberOfRinses. Returns ErrConstraintError for a
+// value not in SupportedRinses.
+func (c *Cluster) SetNumberOfRinses(rinses NumberOfRinses) error {
+	if !c.hasFeature(FeatureRinse) {
+		return datamodel.ErrUnsupportedAttribute
+	}
+	if !c.supportsRinses(rinses) {
+		return datamodel.ErrConstraintError
+	}
+	if d := c.config.Delegate; d != nil {
+		if err := d.HandleNumberOfRinses(rinses); err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	changed := c.rinses != rinses
+	if changed {
+		c.rinses = rinses
+		c.IncrementDataVersion()
+	}
+	c.mu.Unlock()
+
+	c.notify(changed)
+	return nil
+}
+
+// equalNullable compares two nullable values.
+func equalNullable(a, b *uint8) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// notify calls the OnChange callback if something changed.
+func (c *Cluster) notify(changed bool) {
+	if changed && c.config.OnChange != nil {
+		c.config.OnChange(c.config.EndpointID)
+	}
+}
@@ -0,0 +1,140 @@
+package laundrywashercontrols
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// testDelegate optionally rejects writes.
+type testDelegate struct {
+	reject error
+}
+
+func (d *testDelegate) HandleSpinSpeed(index *uint8) error               { return d.reject }
+func (d *testDelegate) HandleNumberOfRinses(rinses NumberOfRinses) error { return d.reject }
+
+func newWasher(t *testing.T, d Delegate) *Cluster {
+	t.Helper()
+	spin := uint8(1)
+	c, err := New(Config{
+		EndpointID:       1,
+		FeatureMap:       FeatureSpin | FeatureRinse,
+		SpinSpeeds:       []string{"Off", "800", "1200", "1600"},
+		InitialSpinSpeed: &spin,
+		SupportedRinses:  []NumberOfRinses{RinsesNormal, RinsesExtra},
+		InitialRinses:    RinsesMax,
+		Delegate:         d,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+// write encodes value with put and writes it to attr.
+func write(c *Cluster, attr datamodel.AttributeID, put func(w *tlv.Writer)) error {
+	var buf bytes.Buffer
+	put(tlv.NewWriter(&buf))
+	req := datamodel.WriteAttributeRequest{
+		Path: datamodel.ConcreteDataAttributePath{
+			ConcreteAttributePath: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: attr},
+		},
+	}
+	return c.WriteAttribute(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes())))
+}
+
+func putUint(v uint64) func(w *tlv.Writer) {
+	return func(w *tlv.Writer) { w.PutUint(tlv.Anonymous(), v) }
+}
+
+func TestNew_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want error
+	}{
+		{"no feature", Config{}, ErrInvalidFeatures},
+		{"no spin speeds", Config{FeatureMap: FeatureSpin}, ErrInvalidSpinSpeeds},
+		{"empty spin speed", Config{FeatureMap: FeatureSpin, SpinSpeeds: []string{""}}, ErrInvalidSpinSpeeds},
+		{"no rinses", Config{FeatureMap: FeatureRinse}, ErrInvalidRinses},
+		{"invalid rinse", Config{FeatureMap: FeatureRinse, SupportedRinses: []NumberOfRinses{4}}, ErrInvalidRinses},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg); !errors.Is(err, tt.want) {
+				t.Errorf("New() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	c := newWasher(t, nil)
+	if got := c.NumberOfRinses(); got != RinsesNormal {
+		t.Errorf("unsupported InitialRinses gave %d, want RinsesNormal", got)
+	}
+}
+
+func TestWriteSpinSpeed(t *testing.T) {
+	c := newWasher(t, nil)
+
+	if err := write(c, AttrSpinSpeedCurrent, putUint(3)); err != nil {
+		t.Fatalf("write error = %v", err)
+	}
+	if s := c.SpinSpeed(); s == nil || *s != 3 {
+		t.Errorf("SpinSpeed = %v, want 3", s)
+	}
+
+	if err := write(c, AttrSpinSpeedCurrent, putUint(4)); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("out of range error = %v, want ErrConstraintError", err)
+	}
+
+	if err := write(c, AttrSpinSpeedCurrent, func(w *tlv.Writer) { w.PutNull(tlv.Anonymous()) }); err != nil {
+		t.Fatalf("write null error = %v", err)
+	}
+	if s := c.SpinSpeed(); s != nil {
+		t.Errorf("SpinSpeed = %d, want null", *s)
+	}
+}
+
+func TestWriteNumberOfRinses(t *testing.T) {
+	d := &testDelegate{}
+	changes := 0
+	c := newWasher(t, d)
+	c.config.OnChange = func(datamodel.EndpointID) { changes++ }
+
+	if err := write(c, AttrNumberOfRinses, putUint(uint64(RinsesExtra))); err != nil {
+		t.Fatalf("write error = %v", err)
+	}
+	if c.NumberOfRinses() != RinsesExtra || changes != 1 {
+		t.Errorf("NumberOfRinses = %d, changes = %d", c.NumberOfRinses(), changes)
+	}
+
+	if err := write(c, AttrNumberOfRinses, putUint(uint64(RinsesMax))); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("unsupported value error = %v, want ErrConstraintError", err)
+	}
+
+	d.reject = datamodel.ErrInvalidInState
+	if err := write(c, AttrNumberOfRinses, putUint(uint64(RinsesNormal))); !errors.Is(err, datamodel.ErrInvalidInState) {
+		t.Errorf("vetoed error = %v, want ErrInvalidInState", err)
+	}
+	if c.NumberOfRinses() != RinsesExtra {
+		t.Errorf("NumberOfRinses = %d after veto, want RinsesExtra", c.NumberOfRinses())
+	}
+}
+
+func TestAttributeList_Features(t *testing.T) {
+	c, err := New(Config{FeatureMap: FeatureRinse, SupportedRinses: []NumberOfRinses{RinsesNormal}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if c.hasAttribute(AttrSpinSpeeds) || c.hasAttribute(AttrSpinSpeedCurrent) {
+		t.Error("spin attributes present without SPIN")
+	}
+	if err := write(c, AttrSpinSpeedCurrent, putUint(0)); !errors.Is(err, datamodel.ErrUnsupportedAttribute) {
+		t.Errorf("write error = %v, want ErrUnsupportedAttribute", err)
+	}
+}
@@ -0,0 +1,260 @@
+// Package localizationconfiguration implements the Localization
+// Configuration Cluster (0x002B).
+//
+// The node lists the locales it supports and exposes the active one, which
+// a commissioner or user may change to any supported locale. The active
+// locale is persisted so it survives a restart.
+//
+// The cluster lives on the root endpoint:
+//
+//	lc, _ := localizationconfiguration.New(localizationconfiguration.Config{
+//	    SupportedLocales: []string{"en-US", "de-DE"},
+//	    ActiveLocale:     "en-US",
+//	})
+//	node.GetEndpoint(matter.RootEndpointID).AddCluster(lc)
+//
+// Spec Reference: Section 11.3
+//
+// C++ Reference: src/app/clusters/localization-configuration-server
+package localizationconfiguration
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x002B
+	ClusterRevision uint16              = 1
+)
+
+// Attribute IDs (Spec 11.3.6).
+const (
+	AttrActiveLocale     datamodel.AttributeID = 0x0000
+	AttrSupportedLocales datamodel.AttributeID = 0x0001
+)
+
+// Limits (Spec 11.3.6).
+const (
+	MaxLocaleLength     = 35
+	MaxSupportedLocales = 32
+)
+
+// Errors returned by configuration.
+var (
+	ErrNoLocales       = errors.New("localizationconfiguration: at least one supported locale required")
+	ErrInvalidLocale   = errors.New("localizationconfiguration: locale must be 1-35 bytes")
+	ErrDuplicateLocale = errors.New("localizationconfiguration: duplicate locale")
+	ErrTooManyLocales  = errors.New("localizationconfiguration: at most 32 supported locales")
+	ErrUnsupported     = errors.New("localizationconfiguration: active locale not supported")
+)
+
+// Storage provides persistence for the active locale.
+type Storage interface {
+	// Load retrieves a value by key.
+	Load(key string) ([]byte, error)
+	// Store persists a value.
+	Store(key string, value []byte) error
+}
+
+// Config provides dependencies for the Localization Configuration cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to (normally 0).
+	EndpointID datamodel.EndpointID
+
+	// SupportedLocales lists the supported language tags such as "en-US".
+	// At least one is required.
+	SupportedLocales []string
+
+	// ActiveLocale is the initial locale if no persisted value exists.
+	// Defaults to the first supported locale.
+	ActiveLocale string
+
+	// Storage for persisting ActiveLocale (optional).
+	Storage Storage
+
+	// OnActiveLocaleChange is called when ActiveLocale changes (optional).
+	OnActiveLocaleChange func(locale string)
+}
+
+// Cluster implements the Localization Configuration cluster (0x002B).
+type Cluster struct {
+	*datamodel.ClusterBase
+	config Config
+
+	// Mutable state (protected by mutex)
+	mu     sync.RWMutex
+	active string
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Localization Configuration cluster. It returns an
+// error if the supported locales are invalid or do not include
+// ActiveLocale.
+func New(cfg Config) (*Cluster, error) {
+	if err := validateLocales(cfg.SupportedLocales); err != nil {
+		return nil, err
+	}
+	active := cfg.ActiveLocale
+	if active == "" {
+		active = cfg.SupportedLocales[0]
+	}
+	if !slices.Contains(cfg.SupportedLocales, active) {
+		return nil, ErrUnsupported
+	}
+
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		config:      cfg,
+		active:      active,
+	}
+	c.config.SupportedLocales = slices.Clone(cfg.SupportedLocales)
+
+	if cfg.Storage != nil {
+		if data, err := cfg.Storage.Load("activeLocale"); err == nil && slices.Contains(c.config.SupportedLocales, string(data)) {
+			c.active = string(data)
+		}
+	}
+
+	c.attrList = c.buildAttributeList()
+
+	return c, nil
+}
+
+// validateLocales checks the supported locale list.
+func validateLocales(locales []string) error {
+	if len(locales) == 0 {
+		return ErrNoLocales
+	}
+	if len(locales) > MaxSupportedLocales {
+		return ErrTooManyLocales
+	}
+	for i, l := range locales {
+		if len(l) == 0 || len(l) > MaxLocaleLength {
+			return ErrInvalidLocale
+		}
+		if slices.Contains(locales[:i], l) {
+			return ErrDuplicateLocale
+		}
+	}
+	return nil
+}
+
+// buildAttributeList constructs the list of supported attributes.
+func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+	managePriv := datamodel.PrivilegeManage
+
+	return datamodel.MergeAttributeLists([]datamodel.AttributeEntry{
+		datamodel.NewReadWriteAttribute(AttrActiveLocale, datamodel.AttrQualityNonVolatile, viewPriv, managePriv),
+		datamodel.NewReadOnlyAttribute(AttrSupportedLocales, datamodel.AttrQualityList|datamodel.AttrQualityFixed, viewPriv),
+	})
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	return nil
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return nil
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	switch req.Path.Attribute {
+	case AttrActiveLocale:
+		return w.PutString(tlv.Anonymous(), c.ActiveLocale())
+	case AttrSupportedLocales:
+		if err := w.StartArray(tlv.Anonymous()); err != nil {
+			return err
+		}
+		for _, l := range c.config.SupportedLocales {
+			if err := w.PutString(tlv.Anonymous(), l); err != nil {
+				return err
+			}
+		}
+		return w.EndContainer()
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	if req.Path.Attribute != AttrActiveLocale {
+		return datamodel.ErrUnsupportedWrite
+	}
+	if err := r.Next(); err != nil {
+		return err
+	}
+	locale, err := r.String()
+	if err != nil {
+		return err
+	}
+	return c.SetActiveLocale(locale)
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	return nil, datamodel.ErrUnsupportedCommand
+}
+
+// ActiveLocale returns the active locale.
+func (c *Cluster) ActiveLocale() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.active
+}
+
+// SupportedLocales returns the supported locales.
+func (c *Cluster) SupportedLocales() []string {
+	return slices.Clone(c.config.SupportedLocales)
+}
+
+// SetActiveLocale changes the active locale. Returns
+// datamodel.ErrConstraintError if the locale is not supported.
+//
+// Spec: Section 11.3.6.1
+func (c *Cluster) SetActiveLocale(locale string) error {
+	if !slices.Contains(c.config.SupportedLocales, locale) {
+		return datamodel.ErrConstraintError
+	}
+
+	c.mu.Lock()
+	if c.active == locale {
+		c.mu.Unlock()
+		return nil
+	}
+	c.active = locale
+	if c.config.Storage != nil {
+		_ = c.config.Storage.Store("activeLocale", []byte(locale))
+	}
+	c.IncrementDataVersion()
+	c.mu.Unlock()
+
+	if c.config.OnActiveLocaleChange != nil {
+		c.config.OnActiveLocaleChange(locale)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package localizationconfiguration
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+type mapStorage map[string][]byte
+
+func (s mapStorage) Load(key string) ([]byte, error) {
+	v, ok := s[key]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return v, nil
+}
+
+func (s mapStorage) Store(key string, value []byte) error {
+	s[key] = value
+	return nil
+}
+
+var testLocales = []string{"en-US", "de-DE", "fr-FR"}
+
+func writeLocale(c *Cluster, locale string) error {
+	var buf bytes.Buffer
+	tlv.NewWriter(&buf).PutString(tlv.Anonymous(), locale)
+	req := datamodel.WriteAttributeRequest{
+		Path: datamodel.ConcreteDataAttributePath{
+			ConcreteAttributePath: datamodel.ConcreteAttributePath{Endpoint: 0, Cluster: ClusterID, Attribute: AttrActiveLocale},
+		},
+	}
+	return c.WriteAttribute(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes())))
+}
+
+func TestNew_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want error
+	}{
+		{"default active", Config{SupportedLocales: testLocales}, nil},
+		{"explicit active", Config{SupportedLocales: testLocales, ActiveLocale: "de-DE"}, nil},
+		{"no locales", Config{}, ErrNoLocales},
+		{"empty locale", Config{SupportedLocales: []string{""}}, ErrInvalidLocale},
+		{"duplicate", Config{SupportedLocales: []string{"en-US", "en-US"}}, ErrDuplicateLocale},
+		{"active not supported", Config{SupportedLocales: testLocales, ActiveLocale: "ja-JP"}, ErrUnsupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg); !errors.Is(err, tt.want) {
+				t.Errorf("New() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteActiveLocale(t *testing.T) {
+	var changed []string
+	c, _ := New(Config{
+		SupportedLocales:     testLocales,
+		OnActiveLocaleChange: func(l string) { changed = append(changed, l) },
+	})
+	if got := c.ActiveLocale(); got != "en-US" {
+		t.Errorf("ActiveLocale = %q, want first supported en-US", got)
+	}
+
+	if err := writeLocale(c, "ja-JP"); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("write unsupported error = %v, want ErrConstraintError", err)
+	}
+
+	version := c.DataVersion()
+	if err := writeLocale(c, "fr-FR"); err != nil {
+		t.Fatalf("write error = %v", err)
+	}
+	if got := c.ActiveLocale(); got != "fr-FR" {
+		t.Errorf("ActiveLocale = %q, want fr-FR", got)
+	}
+	if c.DataVersion() == version {
+		t.Error("DataVersion should change")
+	}
+	if len(changed) != 1 || changed[0] != "fr-FR" {
+		t.Errorf("OnActiveLocaleChange calls = %v, want [fr-FR]", changed)
+	}
+}
+
+func TestReadSupportedLocales(t *testing.T) {
+	c, _ := New(Config{SupportedLocales: testLocales})
+
+	var buf bytes.Buffer
+	req := datamodel.ReadAttributeRequest{
+		Path: datamodel.ConcreteAttributePath{Endpoint: 0, Cluster: ClusterID, Attribute: AttrSupportedLocales},
+	}
+	if err := c.ReadAttribute(context.Background(), req, tlv.NewWriter(&buf)); err != nil {
+		t.Fatalf("ReadAttribute error = %v", err)
+	}
+
+	r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+	r.Next()
+	r.EnterContainer()
+	var got []string
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		s, _ := r.String()
+		got = append(got, s)
+	}
+	if len(got) != len(testLocales) || got[1] != "de-DE" {
+		t.Errorf("SupportedLocales = %v, want %v", got, testLocales)
+	}
+}
+
+func TestPersistence(t *testing.T) {
+	storage := mapStorage{}
+	c, _ := New(Config{SupportedLocales: testLocales, Storage: storage})
+	if err := c.SetActiveLocale("de-DE"); err != nil {
+		t.Fatalf("SetActiveLocale error = %v", err)
+	}
+
+	c, _ = New(Config{SupportedLocales: testLocales, Storage: storage})
+	if got := c.ActiveLocale(); got != "de-DE" {
+		t.Errorf("restored ActiveLocale = %q, want de-DE", got)
+	}
+
+	// A persisted locale that is no longer supported is ignored.
+	c, _ = New(Config{SupportedLocales: []string{"en-US"}, Storage: storage})
+	if got := c.ActiveLocale(); got != "en-US" {
+		t.Errorf("ActiveLocale = %q, want en-US", got)
+	}
+}
@@ -0,0 +1,165 @@
+package clusters
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Measurement attribute IDs shared by the simple measurement clusters
+// (Temperature, Pressure, Flow, Relative Humidity, Illuminance, ...).
+const (
+	AttrMeasuredValue    datamodel.AttributeID = 0x0000
+	AttrMinMeasuredValue datamodel.AttributeID = 0x0001
+	AttrMaxMeasuredValue datamodel.AttributeID = 0x0002
+	AttrTolerance        datamodel.AttributeID = 0x0003
+)
+
+// ErrMeasurementOutOfRange is returned when a reading lies outside
+// [MinMeasuredValue, MaxMeasuredValue].
+var ErrMeasurementOutOfRange = errors.New("measured value out of range")
+
+// MeasuredValueConfig configures a MeasuredValue.
+type MeasuredValueConfig struct {
+	// Signed selects signed (int16) over unsigned (uint16) encoding.
+	Signed bool
+
+	// Min and Max are MinMeasuredValue and MaxMeasuredValue.
+	// nil encodes null (unknown bound).
+	Min *int64
+	Max *int64
+
+	// Tolerance is the magnitude of the possible error of a reading.
+	// nil omits the Tolerance attribute.
+	Tolerance *uint16
+
+	// ReportThreshold is the minimum change of MeasuredValue, relative to the
+	// last reported value, that counts as a reportable change. Smaller changes
+	// update the attribute without bumping the cluster data version, so
+	// subscribers are not flooded by sensor noise. Zero reports every change.
+	ReportThreshold uint64
+}
+
+// MeasuredValue holds the MeasuredValue, MinMeasuredValue, MaxMeasuredValue
+// and Tolerance attributes of a measurement cluster. It is safe for
+// concurrent use.
+//
+// Clusters call Set with each new reading and bump their data version when
+// it returns true, and delegate reads of the shared attribute IDs to
+// ReadAttribute.
+type MeasuredValue struct {
+	config MeasuredValueConfig
+
+	mu       sync.RWMutex
+	value    *int64 // nullable
+	reported *int64 // value as of the last reportable change
+}
+
+// NewMeasuredValue creates a MeasuredValue. The initial value is null.
+func NewMeasuredValue(cfg MeasuredValueConfig) *MeasuredValue {
+	return &MeasuredValue{config: cfg}
+}
+
+// AttributeList returns the attribute entries for the measurement attributes.
+func (m *MeasuredValue) AttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+	attrs := []datamodel.AttributeEntry{
+		datamodel.NewReadOnlyAttribute(AttrMeasuredValue, datamodel.AttrQualityNullable, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrMinMeasuredValue, datamodel.AttrQualityNullable, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrMaxMeasuredValue, datamodel.AttrQualityNullable, viewPriv),
+	}
+	if m.config.Tolerance != nil {
+		attrs = append(attrs, datamodel.NewReadOnlyAttribute(AttrTolerance, 0, viewPriv))
+	}
+	return attrs
+}
+
+// Value returns the current reading, or nil if unknown.
+func (m *MeasuredValue) Value() *int64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.value == nil {
+		return nil
+	}
+	v := *m.value
+	return &v
+}
+
+// Set records a new reading; nil marks the value unknown.
+//
+// Returns true if the change is reportable: a transition to or from null,
+// or a change of at least ReportThreshold since the last reportable value.
+// Returns ErrMeasurementOutOfRange if the reading lies outside the bounds.
+func (m *MeasuredValue) Set(value *int64) (bool, error) {
+	if value != nil {
+		if m.config.Min != nil && *value < *m.config.Min {
+			return false, ErrMeasurementOutOfRange
+		}
+		if m.config.Max != nil && *value > *m.config.Max {
+			return false, ErrMeasurementOutOfRange
+		}
+		v := *value
+		value = &v
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.value = value
+	if !m.reportable(value) {
+		return false, nil
+	}
+	m.reported = value
+	return true, nil
+}
+
+// reportable compares value against the last reported value.
+// Caller must hold m.mu.
+func (m *MeasuredValue) reportable(value *int64) bool {
+	if value == nil || m.reported == nil {
+		return value != m.reported
+	}
+	diff := *value - *m.reported
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff == 0 {
+		return false
+	}
+	return uint64(diff) >= m.config.ReportThreshold
+}
+
+// ReadAttribute encodes one of the measurement attributes.
+// Returns handled=false for attribute IDs it does not own.
+func (m *MeasuredValue) ReadAttribute(attr datamodel.AttributeID, w *tlv.Writer) (bool, error) {
+	switch attr {
+	case AttrMeasuredValue:
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+		return true, m.put(w, m.value)
+	case AttrMinMeasuredValue:
+		return true, m.put(w, m.config.Min)
+	case AttrMaxMeasuredValue:
+		return true, m.put(w, m.config.Max)
+	case AttrTolerance:
+		if m.config.Tolerance == nil {
+			return true, datamodel.ErrUnsupportedAttribute
+		}
+		return true, w.PutUint(tlv.Anonymous(), uint64(*m.config.Tolerance))
+	default:
+		return false, nil
+	}
+}
+
+// put writes a nullable value with the configured signedness.
+func (m *MeasuredValue) put(w *tlv.Writer, v *int64) error {
+	if v == nil {
+		return w.PutNull(tlv.Anonymous())
+	}
+	if m.config.Signed {
+		return w.PutInt(tlv.Anonymous(), *v)
+	}
+	return w.PutUint(tlv.Anonymous(), uint64(*v))
+}
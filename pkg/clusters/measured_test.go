@@ -0,0 +1,100 @@
+package clusters
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+func int64p(v int64) *int64 { return &v }
+
+func TestMeasuredValue_ReportThreshold(t *testing.T) {
+	m := NewMeasuredValue(MeasuredValueConfig{Signed: true, ReportThreshold: 50})
+
+	steps := []struct {
+		value  *int64
+		report bool
+	}{
+		{int64p(2000), true},  // null -> value
+		{int64p(2030), false}, // below threshold
+		{int64p(2049), false}, // still measured against 2000
+		{int64p(2050), true},  // threshold reached
+		{int64p(2000), true},  // decrease by threshold
+		{int64p(2000), false}, // unchanged
+		{nil, true},           // value -> null
+		{nil, false},          // still null
+	}
+	for i, s := range steps {
+		report, err := m.Set(s.value)
+		if err != nil {
+			t.Fatalf("step %d: Set failed: %v", i, err)
+		}
+		if report != s.report {
+			t.Errorf("step %d: report = %v, want %v", i, report, s.report)
+		}
+	}
+
+	// Value always reflects the latest reading.
+	m.Set(int64p(2010))
+	m.Set(int64p(2020))
+	if v := m.Value(); v == nil || *v != 2020 {
+		t.Errorf("Value() = %v, want 2020", v)
+	}
+}
+
+func TestMeasuredValue_Range(t *testing.T) {
+	m := NewMeasuredValue(MeasuredValueConfig{Min: int64p(0), Max: int64p(10000)})
+
+	if _, err := m.Set(int64p(10001)); !errors.Is(err, ErrMeasurementOutOfRange) {
+		t.Errorf("above max: err = %v, want ErrMeasurementOutOfRange", err)
+	}
+	if _, err := m.Set(int64p(-1)); !errors.Is(err, ErrMeasurementOutOfRange) {
+		t.Errorf("below min: err = %v, want ErrMeasurementOutOfRange", err)
+	}
+	if v := m.Value(); v != nil {
+		t.Errorf("Value() = %v after rejected readings, want nil", *v)
+	}
+}
+
+func TestMeasuredValue_ReadAttribute(t *testing.T) {
+	tol := uint16(25)
+	m := NewMeasuredValue(MeasuredValueConfig{Signed: true, Min: int64p(-4000), Tolerance: &tol})
+	m.Set(int64p(-150))
+
+	read := func(attr datamodel.AttributeID) *tlv.Reader {
+		t.Helper()
+		var buf bytes.Buffer
+		handled, err := m.ReadAttribute(attr, tlv.NewWriter(&buf))
+		if !handled || err != nil {
+			t.Fatalf("ReadAttribute(0x%04X) = %v, %v", attr, handled, err)
+		}
+		r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+		if err := r.Next(); err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+
+	if v, err := read(AttrMeasuredValue).Int(); err != nil || v != -150 {
+		t.Errorf("MeasuredValue = %d, %v; want -150", v, err)
+	}
+	if v, err := read(AttrMinMeasuredValue).Int(); err != nil || v != -4000 {
+		t.Errorf("MinMeasuredValue = %d, %v; want -4000", v, err)
+	}
+	if r := read(AttrMaxMeasuredValue); r.Type() != tlv.ElementTypeNull {
+		t.Errorf("MaxMeasuredValue type = %v, want null", r.Type())
+	}
+	if v, err := read(AttrTolerance).Uint(); err != nil || v != 25 {
+		t.Errorf("Tolerance = %d, %v; want 25", v, err)
+	}
+
+	if handled, _ := m.ReadAttribute(0x0010, tlv.NewWriter(&bytes.Buffer{})); handled {
+		t.Error("unknown attribute should not be handled")
+	}
+	if len(m.AttributeList()) != 4 {
+		t.Errorf("AttributeList() has %d entries, want 4", len(m.AttributeList()))
+	}
+}
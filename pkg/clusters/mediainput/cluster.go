@@ -0,0 +1,431 @@
+// Package mediainput implements the Media Input Cluster (0x0507).
+//
+// The cluster lists the inputs of a media device (HDMI ports, tuners,
+// ...) and lets clients switch between them. Switching, showing the input
+// status overlay and renaming are delegated to the device.
+//
+// Spec Reference: Section 6.9
+//
+// C++ Reference: src/app/clusters/media-input-server
+package mediainput
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/backkem/matter/pkg/clusters"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x0507
+	ClusterRevision uint16              = 1
+)
+
+// Attribute IDs (Spec 6.9.5).
+const (
+	AttrInputList    datamodel.AttributeID = 0x0000
+	AttrCurrentInput datamodel.AttributeID = 0x0001
+)
+
+// Command IDs (Spec 6.9.6).
+const (
+	CmdSelectInput     datamodel.CommandID = 0x00
+	CmdShowInputStatus datamodel.CommandID = 0x01
+	CmdHideInputStatus datamodel.CommandID = 0x02
+	CmdRenameInput     datamodel.CommandID = 0x03
+)
+
+// Feature bits (Spec 6.9.4).
+type Feature uint32
+
+const (
+	// FeatureNameUpdates supports renaming inputs (NU).
+	FeatureNameUpdates Feature = 1 << 0
+)
+
+// InputType is the kind of input (InputTypeEnum, Spec 6.9.4.1).
+type InputType uint8
+
+const (
+	InputTypeInternal  InputType = 0
+	InputTypeAux       InputType = 1
+	InputTypeCoax      InputType = 2
+	InputTypeComposite InputType = 3
+	InputTypeHDMI      InputType = 4
+	InputTypeInput     InputType = 5
+	InputTypeLine      InputType = 6
+	InputTypeOptical   InputType = 7
+	InputTypeVideo     InputType = 8
+	InputTypeSCART     InputType = 9
+	InputTypeUSB       InputType = 10
+	InputTypeOther     InputType = 11
+)
+
+// InputInfo describes an input (InputInfoStruct, Spec 6.9.4.2).
+type InputInfo struct {
+	Index       uint8
+	InputType   InputType
+	Name        string
+	Description string
+}
+
+// MarshalTLV encodes the struct with the given tag.
+func (i InputInfo) MarshalTLV(w *tlv.Writer, tag tlv.Tag) error {
+	if err := w.StartStructure(tag); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(i.Index)); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(1), uint64(i.InputType)); err != nil {
+		return err
+	}
+	if err := w.PutString(tlv.ContextTag(2), i.Name); err != nil {
+		return err
+	}
+	if err := w.PutString(tlv.ContextTag(3), i.Description); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// Errors returned by New.
+var (
+	ErrNoInputs        = errors.New("mediainput: no inputs")
+	ErrDuplicateIndex  = errors.New("mediainput: duplicate input index")
+	ErrInvalidInput    = errors.New("mediainput: initial input not in input list")
+	ErrInvalidFeatures = errors.New("mediainput: unsupported feature")
+	ErrNoDelegate      = errors.New("mediainput: delegate is required")
+)
+
+// Delegate switches inputs on the media device. A returned error is
+// reported to the client as a failure status.
+type Delegate interface {
+	// HandleSelectInput switches to the input with the given index.
+	HandleSelectInput(index uint8) error
+
+	// HandleShowInputStatus shows the input status overlay.
+	HandleShowInputStatus() error
+
+	// HandleHideInputStatus hides the input status overlay.
+	HandleHideInputStatus() error
+
+	// HandleRenameInput renames an input (NU).
+	HandleRenameInput(index uint8, name string) error
+}
+
+// Config provides dependencies for the Media Input cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to.
+	EndpointID datamodel.EndpointID
+
+	// FeatureMap indicates supported features.
+	FeatureMap Feature
+
+	// Inputs is the input list. Indexes must be unique.
+	Inputs []InputInfo
+
+	// CurrentInput is the index of the selected input at startup.
+	CurrentInput uint8
+
+	// Delegate switches inputs (required).
+	Delegate Delegate
+}
+
+// Cluster implements the Media Input cluster (0x0507).
+type Cluster struct {
+	*datamodel.ClusterBase
+	config Config
+
+	// cmdMu serializes commands.
+	cmdMu sync.Mutex
+
+	// Mutable state (protected by mutex)
+	mu           sync.RWMutex
+	inputs       []InputInfo
+	currentInput uint8
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Media Input cluster.
+func New(cfg Config) (*Cluster, error) {
+	if cfg.FeatureMap&^FeatureNameUpdates != 0 {
+		return nil, ErrInvalidFeatures
+	}
+	if cfg.Delegate == nil {
+		return nil, ErrNoDelegate
+	}
+	if len(cfg.Inputs) == 0 {
+		return nil, ErrNoInputs
+	}
+	seen := make(map[uint8]bool, len(cfg.Inputs))
+	for _, in := range cfg.Inputs {
+		if seen[in.Index] {
+			return nil, ErrDuplicateIndex
+		}
+		seen[in.Index] = true
+	}
+	if !seen[cfg.CurrentInput] {
+		return nil, ErrInvalidInput
+	}
+
+	c := &Cluster{
+		ClusterBase:  datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		config:       cfg,
+		inputs:       append([]InputInfo(nil), cfg.Inputs...),
+		currentInput: cfg.CurrentInput,
+	}
+
+	c.ClusterBase.SetFeatureMap(uint32(cfg.FeatureMap))
+	c.attrList = c.buildAttributeList()
+
+	return c, nil
+}
+
+// buildAttributeList constructs the list of supported attributes.
+func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+
+	attrs := []datamodel.AttributeEntry{
+		datamodel.NewReadOnlyAttribute(AttrInputList, datamodel.AttrQualityList, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrCurrentInput, 0, viewPriv),
+	}
+
+	return datamodel.MergeAttributeLists(attrs)
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	operatePriv := datamodel.PrivilegeOperate
+
+	cmds := []datamodel.CommandEntry{
+		datamodel.NewCommandEntry(CmdSelectInput, 0, operatePriv),
+		datamodel.NewCommandEntry(CmdShowInputStatus, 0, operatePriv),
+		datamodel.NewCommandEntry(CmdHideInputStatus, 0, operatePriv),
+	}
+	if c.config.FeatureMap&FeatureNameUpdates != 0 {
+		cmds = append(cmds, datamodel.NewCommandEntry(CmdRenameInput, 0, datamodel.PrivilegeManage))
+	}
+	return cmds
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return nil
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	switch req.Path.Attribute {
+	case AttrInputList:
+		if err := w.StartArray(tlv.Anonymous()); err != nil {
+			return err
+		}
+		for _, in := range c.inputs {
+			if err := in.MarshalTLV(w, tlv.Anonymous()); err != nil {
+				return err
+			}
+		}
+		return w.EndContainer()
+	case AttrCurrentInput:
+		return w.PutUint(tlv.Anonymous(), uint64(c.currentInput))
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	return datamodel.ErrUnsupportedWrite
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	var err error
+	switch req.Path.Command {
+	case CmdSelectInput:
+		var index uint8
+		if index, _, err = decodeInputCommand(r, false); err == nil {
+			err = c.SelectInput(index)
+		}
+	case CmdShowInputStatus:
+		err = c.ShowInputStatus()
+	case CmdHideInputStatus:
+		err = c.HideInputStatus()
+	case CmdRenameInput:
+		if c.config.FeatureMap&FeatureNameUpdates == 0 {
+			return nil, datamodel.ErrUnsupportedCommand
+		}
+		var index uint8
+		var name string
+		if index, name, err = decodeInputCommand(r, true); err == nil {
+			err = c.RenameInput(index, name)
+		}
+	default:
+		return nil, datamodel.ErrUnsupportedCommand
+	}
+	if err != nil {
+		return nil, err
+	}
+	return clusters.EmptyResponse(), nil
+}
+
+// decodeInputCommand decodes SelectInput and RenameInput: the index
+// (tag 0) and, if withName, the name (tag 1).
+func decodeInputCommand(r *tlv.Reader, withName bool) (index uint8, name string, err error) {
+	if err := r.Next(); err != nil {
+		return 0, "", datamodel.ErrInvalidCommand
+	}
+	if err := r.EnterContainer(); err != nil {
+		return 0, "", datamodel.ErrInvalidCommand
+	}
+
+	var hasIndex, hasName bool
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		tag := r.Tag()
+		if !tag.IsContext() {
+			continue
+		}
+		switch tag.TagNumber() {
+		case 0:
+			v, err := r.Uint()
+			if err != nil || v > 0xFF {
+				return 0, "", datamodel.ErrInvalidCommand
+			}
+			index, hasIndex = uint8(v), true
+		case 1:
+			if name, err = r.String(); err != nil {
+				return 0, "", datamodel.ErrInvalidCommand
+			}
+			hasName = true
+		}
+	}
+	if !hasIndex || (withName && !hasName) {
+		return 0, "", datamodel.ErrInvalidCommand
+	}
+	return index, name, nil
+}
+
+// SelectInput handles SelectInput: the device switches to the input with
+// the given index.
+//
+// Spec: Section 6.9.6.1
+func (c *Cluster) SelectInput(index uint8) error {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	c.mu.RLock()
+	exists := c.indexLocked(index) >= 0
+	c.mu.RUnlock()
+	if !exists {
+		return datamodel.ErrConstraintError
+	}
+
+	if err := c.config.Delegate.HandleSelectInput(index); err != nil {
+		return err
+	}
+	c.SetCurrentInput(index)
+	return nil
+}
+
+// ShowInputStatus handles ShowInputStatus.
+//
+// Spec: Section 6.9.6.2
+func (c *Cluster) ShowInputStatus() error {
+	return c.config.Delegate.HandleShowInputStatus()
+}
+
+// HideInputStatus handles HideInputStatus.
+//
+// Spec: Section 6.9.6.3
+func (c *Cluster) HideInputStatus() error {
+	return c.config.Delegate.HandleHideInputStatus()
+}
+
+// RenameInput handles RenameInput (NU).
+//
+// Spec: Section 6.9.6.4
+func (c *Cluster) RenameInput(index uint8, name string) error {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	c.mu.RLock()
+	exists := c.indexLocked(index) >= 0
+	c.mu.RUnlock()
+	if !exists {
+		return datamodel.ErrConstraintError
+	}
+
+	if err := c.config.Delegate.HandleRenameInput(index, name); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if i := c.indexLocked(index); i >= 0 && c.inputs[i].Name != name {
+		c.inputs[i].Name = name
+		c.IncrementDataVersion()
+	}
+	return nil
+}
+
+// SetCurrentInput reports an input change made on the device itself,
+// e.g. with its remote. Unknown indexes are ignored.
+func (c *Cluster) SetCurrentInput(index uint8) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.currentInput == index || c.indexLocked(index) < 0 {
+		return
+	}
+	c.currentInput = index
+	c.IncrementDataVersion()
+}
+
+// CurrentInput returns the index of the selected input.
+func (c *Cluster) CurrentInput() uint8 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.currentInput
+}
+
+// Inputs returns the input list.
+func (c *Cluster) Inputs() []InputInfo {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]InputInfo(nil), c.inputs...)
+}
+
+// indexLocked returns the position of the input with the given index, or
+// -1. Caller must hold c.mu.
+func (c *Cluster) indexLocked(index uint8) int {
+	for i, in := range c.inputs {
+		if in.Index == index {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,175 @@
+package mediainput
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// mockDelegate records delegate calls.
+type mockDelegate struct {
+	selected []uint8
+	renamed  map[uint8]string
+	overlay  bool
+	fail     error
+}
+
+func (d *mockDelegate) HandleSelectInput(index uint8) error {
+	if d.fail != nil {
+		return d.fail
+	}
+	d.selected = append(d.selected, index)
+	return nil
+}
+
+func (d *mockDelegate) HandleShowInputStatus() error { d.overlay = true; return nil }
+func (d *mockDelegate) HandleHideInputStatus() error { d.overlay = false; return nil }
+
+func (d *mockDelegate) HandleRenameInput(index uint8, name string) error {
+	if d.renamed == nil {
+		d.renamed = make(map[uint8]string)
+	}
+	d.renamed[index] = name
+	return nil
+}
+
+var testInputs = []InputInfo{
+	{Index: 1, InputType: InputTypeHDMI, Name: "HDMI 1", Description: "Game console"},
+	{Index: 2, InputType: InputTypeHDMI, Name: "HDMI 2"},
+	{Index: 3, InputType: InputTypeInternal, Name: "TV"},
+}
+
+func invoke(c *Cluster, cmd datamodel.CommandID, index *uint64, name *string) error {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	w.StartStructure(tlv.Anonymous())
+	if index != nil {
+		w.PutUint(tlv.ContextTag(0), *index)
+	}
+	if name != nil {
+		w.PutString(tlv.ContextTag(1), *name)
+	}
+	w.EndContainer()
+
+	req := datamodel.InvokeRequest{
+		Path: datamodel.ConcreteCommandPath{Endpoint: 1, Cluster: ClusterID, Command: cmd},
+	}
+	_, err := c.InvokeCommand(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes())))
+	return err
+}
+
+func u64(v uint64) *uint64 { return &v }
+
+func TestNew_Validation(t *testing.T) {
+	d := &mockDelegate{}
+	tests := []struct {
+		name string
+		cfg  Config
+		want error
+	}{
+		{"valid", Config{Inputs: testInputs, CurrentInput: 3, Delegate: d}, nil},
+		{"no delegate", Config{Inputs: testInputs, CurrentInput: 3}, ErrNoDelegate},
+		{"no inputs", Config{Delegate: d}, ErrNoInputs},
+		{"duplicate index", Config{Inputs: []InputInfo{{Index: 1}, {Index: 1}}, CurrentInput: 1, Delegate: d}, ErrDuplicateIndex},
+		{"unknown current", Config{Inputs: testInputs, CurrentInput: 9, Delegate: d}, ErrInvalidInput},
+		{"unknown feature", Config{Inputs: testInputs, CurrentInput: 3, FeatureMap: 1 << 5, Delegate: d}, ErrInvalidFeatures},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg); !errors.Is(err, tt.want) {
+				t.Errorf("New() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectInput(t *testing.T) {
+	d := &mockDelegate{}
+	c, _ := New(Config{EndpointID: 1, Inputs: testInputs, CurrentInput: 3, Delegate: d})
+
+	if err := invoke(c, CmdSelectInput, u64(9), nil); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("unknown index error = %v, want ErrConstraintError", err)
+	}
+	if err := invoke(c, CmdSelectInput, nil, nil); !errors.Is(err, datamodel.ErrInvalidCommand) {
+		t.Errorf("missing index error = %v, want ErrInvalidCommand", err)
+	}
+	v := c.DataVersion()
+	if err := invoke(c, CmdSelectInput, u64(1), nil); err != nil {
+		t.Fatalf("SelectInput error = %v", err)
+	}
+	if c.CurrentInput() != 1 || len(d.selected) != 1 || c.DataVersion() == v {
+		t.Errorf("CurrentInput = %d, delegate = %v", c.CurrentInput(), d.selected)
+	}
+
+	d.fail = errors.New("switch failed")
+	if err := c.SelectInput(2); err == nil || c.CurrentInput() != 1 {
+		t.Errorf("failed switch: err = %v, CurrentInput = %d", err, c.CurrentInput())
+	}
+}
+
+func TestInputStatus(t *testing.T) {
+	d := &mockDelegate{}
+	c, _ := New(Config{EndpointID: 1, Inputs: testInputs, CurrentInput: 3, Delegate: d})
+
+	if err := invoke(c, CmdShowInputStatus, nil, nil); err != nil || !d.overlay {
+		t.Errorf("ShowInputStatus err = %v overlay = %v", err, d.overlay)
+	}
+	if err := invoke(c, CmdHideInputStatus, nil, nil); err != nil || d.overlay {
+		t.Errorf("HideInputStatus err = %v overlay = %v", err, d.overlay)
+	}
+}
+
+func TestRenameInput(t *testing.T) {
+	d := &mockDelegate{}
+	name := "Streaming box"
+
+	c, _ := New(Config{EndpointID: 1, Inputs: testInputs, CurrentInput: 3, Delegate: d})
+	if err := invoke(c, CmdRenameInput, u64(2), &name); !errors.Is(err, datamodel.ErrUnsupportedCommand) {
+		t.Errorf("RenameInput without NU error = %v", err)
+	}
+
+	c, _ = New(Config{EndpointID: 1, FeatureMap: FeatureNameUpdates, Inputs: testInputs, CurrentInput: 3, Delegate: d})
+	if err := invoke(c, CmdRenameInput, u64(2), nil); !errors.Is(err, datamodel.ErrInvalidCommand) {
+		t.Errorf("missing name error = %v", err)
+	}
+	if err := invoke(c, CmdRenameInput, u64(2), &name); err != nil {
+		t.Fatalf("RenameInput error = %v", err)
+	}
+	if got := c.Inputs()[1].Name; got != name || d.renamed[2] != name {
+		t.Errorf("Name = %q, delegate = %v", got, d.renamed)
+	}
+	if testInputs[1].Name != "HDMI 2" {
+		t.Error("config input list was modified")
+	}
+}
+
+func TestReadInputList(t *testing.T) {
+	c, _ := New(Config{EndpointID: 1, Inputs: testInputs, CurrentInput: 3, Delegate: &mockDelegate{}})
+
+	var buf bytes.Buffer
+	req := datamodel.ReadAttributeRequest{
+		Path: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: AttrInputList},
+	}
+	if err := c.ReadAttribute(context.Background(), req, tlv.NewWriter(&buf)); err != nil {
+		t.Fatalf("ReadAttribute error = %v", err)
+	}
+
+	r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+	r.Next()
+	r.EnterContainer()
+	n := 0
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		r.Skip()
+		n++
+	}
+	if n != len(testInputs) {
+		t.Errorf("InputList has %d entries, want %d", n, len(testInputs))
+	}
+}
@@ -0,0 +1,509 @@
+// Package mediaplayback implements the Media Playback Cluster (0x0506).
+//
+// The cluster tracks the playback state of the current media and
+// forwards transport commands (play, pause, seek, ...) to a Delegate that
+// drives the actual player. With the Advanced Seek feature it also
+// reports the media duration, seek range and a sampled playback position
+// that clients extrapolate with PlaybackSpeed.
+//
+// The player reports its own transitions, e.g. buffering or reaching the
+// end of the media, through SetState, SetMedia and SetPosition.
+//
+// Spec Reference: Section 6.10
+//
+// C++ Reference: src/app/clusters/media-playback-server
+package mediaplayback
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/backkem/matter/pkg/credentials"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x0506
+	ClusterRevision uint16              = 2
+)
+
+// Attribute IDs (Spec 6.10.6).
+const (
+	AttrCurrentState    datamodel.AttributeID = 0x0000
+	AttrStartTime       datamodel.AttributeID = 0x0001
+	AttrDuration        datamodel.AttributeID = 0x0002
+	AttrSampledPosition datamodel.AttributeID = 0x0003
+	AttrPlaybackSpeed   datamodel.AttributeID = 0x0004
+	AttrSeekRangeEnd    datamodel.AttributeID = 0x0005
+	AttrSeekRangeStart  datamodel.AttributeID = 0x0006
+)
+
+// Command IDs (Spec 6.10.7).
+const (
+	CmdPlay             datamodel.CommandID = 0x00
+	CmdPause            datamodel.CommandID = 0x01
+	CmdStop             datamodel.CommandID = 0x02
+	CmdStartOver        datamodel.CommandID = 0x03
+	CmdPrevious         datamodel.CommandID = 0x04
+	CmdNext             datamodel.CommandID = 0x05
+	CmdRewind           datamodel.CommandID = 0x06
+	CmdFastForward      datamodel.CommandID = 0x07
+	CmdSkipForward      datamodel.CommandID = 0x08
+	CmdSkipBackward     datamodel.CommandID = 0x09
+	CmdPlaybackResponse datamodel.CommandID = 0x0A
+	CmdSeek             datamodel.CommandID = 0x0B
+)
+
+// Feature bits (Spec 6.10.4).
+type Feature uint32
+
+const (
+	// FeatureAdvancedSeek supports position, duration and Seek (AS).
+	FeatureAdvancedSeek Feature = 1 << 0
+
+	// FeatureVariableSpeed supports Rewind and FastForward (VS).
+	FeatureVariableSpeed Feature = 1 << 1
+
+	// FeatureTextTracks, FeatureAudioTracks and FeatureAudioAdvance are
+	// defined by the spec but not supported by this implementation.
+	FeatureTextTracks   Feature = 1 << 2
+	FeatureAudioTracks  Feature = 1 << 3
+	FeatureAudioAdvance Feature = 1 << 4
+)
+
+// PlaybackState is the state of the media (PlaybackStateEnum, Spec 6.10.5.1).
+type PlaybackState uint8
+
+const (
+	PlaybackStatePlaying    PlaybackState = 0
+	PlaybackStatePaused     PlaybackState = 1
+	PlaybackStateNotPlaying PlaybackState = 2
+	PlaybackStateBuffering  PlaybackState = 3
+)
+
+// String returns the name of the playback state.
+func (s PlaybackState) String() string {
+	switch s {
+	case PlaybackStatePlaying:
+		return "Playing"
+	case PlaybackStatePaused:
+		return "Paused"
+	case PlaybackStateNotPlaying:
+		return "NotPlaying"
+	case PlaybackStateBuffering:
+		return "Buffering"
+	default:
+		return "Unknown"
+	}
+}
+
+// Status is the result of a playback command (StatusEnum, Spec 6.10.5.2).
+type Status uint8
+
+const (
+	StatusSuccess                Status = 0
+	StatusInvalidStateForCommand Status = 1
+	StatusNotAllowed             Status = 2
+	StatusNotActive              Status = 3
+	StatusSpeedOutOfRange        Status = 4
+	StatusSeekOutOfRange         Status = 5
+)
+
+// String returns the name of the status.
+func (s Status) String() string {
+	switch s {
+	case StatusSuccess:
+		return "Success"
+	case StatusInvalidStateForCommand:
+		return "InvalidStateForCommand"
+	case StatusNotAllowed:
+		return "NotAllowed"
+	case StatusNotActive:
+		return "NotActive"
+	case StatusSpeedOutOfRange:
+		return "SpeedOutOfRange"
+	case StatusSeekOutOfRange:
+		return "SeekOutOfRange"
+	default:
+		return "Unknown"
+	}
+}
+
+// PlaybackPosition is a position sample (PlaybackPositionStruct,
+// Spec 6.10.5.3).
+type PlaybackPosition struct {
+	// UpdatedAt is the sample time in microseconds since the Matter epoch.
+	UpdatedAt uint64
+
+	// Position is the playback position in milliseconds, or nil if unknown.
+	Position *uint64
+}
+
+// MarshalTLV encodes the struct with the given tag.
+func (p PlaybackPosition) MarshalTLV(w *tlv.Writer, tag tlv.Tag) error {
+	if err := w.StartStructure(tag); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), p.UpdatedAt); err != nil {
+		return err
+	}
+	if p.Position == nil {
+		if err := w.PutNull(tlv.ContextTag(1)); err != nil {
+			return err
+		}
+	} else if err := w.PutUint(tlv.ContextTag(1), *p.Position); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// DefaultMaxSpeed is used if Config.MaxSpeed is zero.
+const DefaultMaxSpeed = 16
+
+// Errors returned by New.
+var (
+	ErrInvalidFeatures = errors.New("mediaplayback: unsupported feature")
+	ErrNoDelegate      = errors.New("mediaplayback: delegate is required")
+)
+
+// Delegate drives the media player. Each method returns the status sent
+// back to the client; the cluster updates its state only on
+// StatusSuccess.
+type Delegate interface {
+	// HandlePlay starts playback at the given speed: 1 for Play, above 1
+	// for FastForward and negative for Rewind.
+	HandlePlay(speed float32) Status
+
+	// HandlePause pauses playback.
+	HandlePause() Status
+
+	// HandleStop stops playback and returns to the media's start.
+	HandleStop() Status
+
+	// HandleSeek moves to position (ms). Used by Seek, StartOver and the
+	// skip commands.
+	HandleSeek(position uint64) Status
+
+	// HandlePrevious and HandleNext move to the previous or next media
+	// item, e.g. a chapter or episode.
+	HandlePrevious() Status
+	HandleNext() Status
+}
+
+// Config provides dependencies for the Media Playback cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to.
+	EndpointID datamodel.EndpointID
+
+	// FeatureMap indicates supported features. Only AdvancedSeek and
+	// VariableSpeed are supported.
+	FeatureMap Feature
+
+	// MaxSpeed is the highest absolute speed reached by repeated
+	// FastForward or Rewind (VS). Defaults to DefaultMaxSpeed.
+	MaxSpeed float32
+
+	// Delegate drives the player (required).
+	Delegate Delegate
+}
+
+// Cluster implements the Media Playback cluster (0x0506).
+type Cluster struct {
+	*datamodel.ClusterBase
+	config Config
+
+	// cmdMu serializes commands.
+	cmdMu sync.Mutex
+
+	// Mutable state (protected by mutex)
+	mu        sync.RWMutex
+	state     PlaybackState
+	startTime *uint64 // epoch-us
+	duration  *uint64 // ms
+	sample    *PlaybackPosition
+	speed     float32
+	seekStart *uint64 // ms
+	seekEnd   *uint64 // ms
+
+	// now returns the current time; replaced in tests.
+	now func() time.Time
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Media Playback cluster. Playback starts NotPlaying
+// with no media.
+func New(cfg Config) (*Cluster, error) {
+	if cfg.FeatureMap&(FeatureTextTracks|FeatureAudioTracks|FeatureAudioAdvance) != 0 {
+		return nil, ErrInvalidFeatures
+	}
+	if cfg.Delegate == nil {
+		return nil, ErrNoDelegate
+	}
+	if cfg.MaxSpeed <= 0 {
+		cfg.MaxSpeed = DefaultMaxSpeed
+	}
+
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		config:      cfg,
+		state:       PlaybackStateNotPlaying,
+		now:         time.Now,
+	}
+
+	c.ClusterBase.SetFeatureMap(uint32(cfg.FeatureMap))
+	c.attrList = c.buildAttributeList()
+
+	return c, nil
+}
+
+// hasFeature returns true if the feature bit is set.
+func (c *Cluster) hasFeature(f Feature) bool {
+	return c.config.FeatureMap&f != 0
+}
+
+// buildAttributeList constructs the list of supported attributes.
+func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+	nullable := datamodel.AttrQualityNullable
+
+	attrs := []datamodel.AttributeEntry{
+		datamodel.NewReadOnlyAttribute(AttrCurrentState, 0, viewPriv),
+	}
+	if c.hasFeature(FeatureAdvancedSeek) {
+		attrs = append(attrs,
+			datamodel.NewReadOnlyAttribute(AttrStartTime, nullable, viewPriv),
+			datamodel.NewReadOnlyAttribute(AttrDuration, nullable, viewPriv),
+			datamodel.NewReadOnlyAttribute(AttrSampledPosition, nullable, viewPriv),
+			datamodel.NewReadOnlyAttribute(AttrPlaybackSpeed, 0, viewPriv),
+			datamodel.NewReadOnlyAttribute(AttrSeekRangeEnd, nullable, viewPriv),
+			datamodel.NewReadOnlyAttribute(AttrSeekRangeStart, nullable, viewPriv),
+		)
+	}
+
+	return datamodel.MergeAttributeLists(attrs)
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	operatePriv := datamodel.PrivilegeOperate
+
+	cmds := []datamodel.CommandEntry{
+		datamodel.NewCommandEntry(CmdPlay, 0, operatePriv),
+		datamodel.NewCommandEntry(CmdPause, 0, operatePriv),
+		datamodel.NewCommandEntry(CmdStop, 0, operatePriv),
+		datamodel.NewCommandEntry(CmdStartOver, 0, operatePriv),
+		datamodel.NewCommandEntry(CmdPrevious, 0, operatePriv),
+		datamodel.NewCommandEntry(CmdNext, 0, operatePriv),
+		datamodel.NewCommandEntry(CmdSkipForward, 0, operatePriv),
+		datamodel.NewCommandEntry(CmdSkipBackward, 0, operatePriv),
+	}
+	if c.hasFeature(FeatureVariableSpeed) {
+		cmds = append(cmds,
+			datamodel.NewCommandEntry(CmdRewind, 0, operatePriv),
+			datamodel.NewCommandEntry(CmdFastForward, 0, operatePriv),
+		)
+	}
+	if c.hasFeature(FeatureAdvancedSeek) {
+		cmds = append(cmds, datamodel.NewCommandEntry(CmdSeek, 0, operatePriv))
+	}
+	return cmds
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return []datamodel.CommandID{CmdPlaybackResponse}
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	if req.Path.Attribute != AttrCurrentState && !c.hasFeature(FeatureAdvancedSeek) {
+		return datamodel.ErrUnsupportedAttribute
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	switch req.Path.Attribute {
+	case AttrCurrentState:
+		return w.PutUint(tlv.Anonymous(), uint64(c.state))
+	case AttrStartTime:
+		return putNullableUint(w, c.startTime)
+	case AttrDuration:
+		return putNullableUint(w, c.duration)
+	case AttrSampledPosition:
+		if c.sample == nil {
+			return w.PutNull(tlv.Anonymous())
+		}
+		return c.sample.MarshalTLV(w, tlv.Anonymous())
+	case AttrPlaybackSpeed:
+		return w.PutFloat32(tlv.Anonymous(), c.speed)
+	case AttrSeekRangeEnd:
+		return putNullableUint(w, c.seekEnd)
+	case AttrSeekRangeStart:
+		return putNullableUint(w, c.seekStart)
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// putNullableUint writes a nullable unsigned value.
+func putNullableUint(w *tlv.Writer, v *uint64) error {
+	if v == nil {
+		return w.PutNull(tlv.Anonymous())
+	}
+	return w.PutUint(tlv.Anonymous(), *v)
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	return datamodel.ErrUnsupportedWrite
+}
+
+// CurrentState returns the playback state.
+func (c *Cluster) CurrentState() PlaybackState {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.state
+}
+
+// PlaybackSpeed returns the playback speed.
+func (c *Cluster) PlaybackSpeed() float32 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.speed
+}
+
+// Position returns the current playback position in milliseconds,
+// extrapolated from the last sample with the playback speed and clamped
+// to the media. Returns nil if the position is unknown.
+func (c *Cluster) Position() *uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.positionLocked()
+}
+
+// positionLocked implements Position. Caller must hold c.mu.
+func (c *Cluster) positionLocked() *uint64 {
+	if c.sample == nil || c.sample.Position == nil {
+		return nil
+	}
+	elapsed := float64(c.epochMicros()-c.sample.UpdatedAt) / 1000
+	pos := float64(*c.sample.Position) + elapsed*float64(c.speed)
+	if pos < 0 {
+		pos = 0
+	}
+	p := uint64(pos)
+	if c.duration != nil && p > *c.duration {
+		p = *c.duration
+	}
+	return &p
+}
+
+// epochMicros returns the current time in microseconds since the Matter
+// epoch.
+func (c *Cluster) epochMicros() uint64 {
+	d := c.now().Sub(credentials.MatterEpochStart)
+	if d < 0 {
+		return 0
+	}
+	return uint64(d.Microseconds())
+}
+
+// SetMedia loads new media: playback is reset to NotPlaying at position
+// 0. duration is in milliseconds and nil for live or unknown-length
+// media; the seek range spans the whole media if the duration is known.
+func (c *Cluster) SetMedia(duration *uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	zero := uint64(0)
+	c.state = PlaybackStateNotPlaying
+	c.speed = 0
+	c.duration = cloneUint(duration)
+	c.startTime = nil
+	c.seekStart, c.seekEnd = nil, nil
+	if duration != nil {
+		c.seekStart = &zero
+		c.seekEnd = cloneUint(duration)
+	}
+	c.setSampleLocked(&zero)
+	c.IncrementDataVersion()
+}
+
+// SetState reports a state change made by the player, e.g. Buffering
+// while loading or NotPlaying at the end of the media. The position is
+// resampled; speed drops to 0 unless the new state is Playing.
+func (c *Cluster) SetState(state PlaybackState) error {
+	if state > PlaybackStateBuffering {
+		return datamodel.ErrConstraintError
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if state == c.state {
+		return nil
+	}
+	speed := c.speed
+	if state != PlaybackStatePlaying {
+		speed = 0
+	} else if speed == 0 {
+		speed = 1
+	}
+	c.transitionLocked(state, speed, c.positionLocked())
+	return nil
+}
+
+// SetPosition reports the player's actual position in milliseconds,
+// correcting drift from the extrapolated position.
+func (c *Cluster) SetPosition(position uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setSampleLocked(&position)
+	c.IncrementDataVersion()
+}
+
+// transitionLocked applies a state, speed and position change. Caller
+// must hold c.mu.
+func (c *Cluster) transitionLocked(state PlaybackState, speed float32, position *uint64) {
+	if state == PlaybackStatePlaying && c.startTime == nil && c.hasFeature(FeatureAdvancedSeek) {
+		start := c.epochMicros()
+		c.startTime = &start
+	}
+	c.state = state
+	c.speed = speed
+	c.setSampleLocked(position)
+	c.IncrementDataVersion()
+}
+
+// setSampleLocked records a position sample taken now. Caller must hold
+// c.mu.
+func (c *Cluster) setSampleLocked(position *uint64) {
+	c.sample = &PlaybackPosition{UpdatedAt: c.epochMicros(), Position: cloneUint(position)}
+}
+
+// cloneUint copies a nullable value.
+func cloneUint(v *uint64) *uint64 {
+	if v == nil {
+		return nil
+	}
+	n := *v
+	return &n
+}
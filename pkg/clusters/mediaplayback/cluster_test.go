@@ -0,0 +1,271 @@
+package mediaplayback
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/backkem/matter/pkg/credentials"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// mockPlayer records delegate calls and returns a fixed status.
+type mockPlayer struct {
+	calls  []string
+	speeds []float32
+	seeks  []uint64
+	status Status
+}
+
+func (p *mockPlayer) HandlePlay(speed float32) Status {
+	p.calls = append(p.calls, "play")
+	p.speeds = append(p.speeds, speed)
+	return p.status
+}
+
+func (p *mockPlayer) HandlePause() Status {
+	p.calls = append(p.calls, "pause")
+	return p.status
+}
+
+func (p *mockPlayer) HandleStop() Status {
+	p.calls = append(p.calls, "stop")
+	return p.status
+}
+
+func (p *mockPlayer) HandleSeek(position uint64) Status {
+	p.calls = append(p.calls, "seek")
+	p.seeks = append(p.seeks, position)
+	return p.status
+}
+
+func (p *mockPlayer) HandlePrevious() Status {
+	p.calls = append(p.calls, "previous")
+	return p.status
+}
+
+func (p *mockPlayer) HandleNext() Status {
+	p.calls = append(p.calls, "next")
+	return p.status
+}
+
+// testClock is a settable clock.
+type testClock struct{ t time.Time }
+
+func (c *testClock) now() time.Time { return c.t }
+
+func newCluster(t *testing.T, features Feature) (*Cluster, *mockPlayer, *testClock) {
+	t.Helper()
+	p := &mockPlayer{}
+	c, err := New(Config{EndpointID: 1, FeatureMap: features, MaxSpeed: 8, Delegate: p})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	clock := &testClock{t: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c.now = clock.now
+	return c, p, clock
+}
+
+func invoke(t *testing.T, c *Cluster, cmd datamodel.CommandID, arg *uint64) Status {
+	t.Helper()
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	w.StartStructure(tlv.Anonymous())
+	if arg != nil {
+		w.PutUint(tlv.ContextTag(0), *arg)
+	}
+	w.EndContainer()
+
+	req := datamodel.InvokeRequest{
+		Path: datamodel.ConcreteCommandPath{Endpoint: 1, Cluster: ClusterID, Command: cmd},
+	}
+	resp, err := c.InvokeCommand(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes())))
+	if err != nil {
+		t.Fatalf("invoke 0x%02X error = %v", cmd, err)
+	}
+	r := tlv.NewReader(bytes.NewReader(resp))
+	r.Next()
+	r.EnterContainer()
+	r.Next()
+	v, _ := r.Uint()
+	return Status(v)
+}
+
+func u64(v uint64) *uint64 { return &v }
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(Config{Delegate: &mockPlayer{}, FeatureMap: FeatureTextTracks}); !errors.Is(err, ErrInvalidFeatures) {
+		t.Errorf("text tracks error = %v, want ErrInvalidFeatures", err)
+	}
+	if _, err := New(Config{}); !errors.Is(err, ErrNoDelegate) {
+		t.Errorf("no delegate error = %v, want ErrNoDelegate", err)
+	}
+}
+
+func TestPlayPauseStop(t *testing.T) {
+	c, p, clock := newCluster(t, FeatureAdvancedSeek)
+	c.SetMedia(u64(60000))
+
+	if s := invoke(t, c, CmdPause, nil); s != StatusInvalidStateForCommand {
+		t.Errorf("Pause while stopped = %v, want InvalidStateForCommand", s)
+	}
+	if s := invoke(t, c, CmdPlay, nil); s != StatusSuccess {
+		t.Fatalf("Play = %v", s)
+	}
+	if c.CurrentState() != PlaybackStatePlaying || c.PlaybackSpeed() != 1 {
+		t.Errorf("state = %v speed = %v, want Playing at 1", c.CurrentState(), c.PlaybackSpeed())
+	}
+
+	// Position advances with the clock
+	clock.t = clock.t.Add(10 * time.Second)
+	if pos := c.Position(); pos == nil || *pos != 10000 {
+		t.Errorf("Position() = %v, want 10000", pos)
+	}
+
+	if s := invoke(t, c, CmdPause, nil); s != StatusSuccess {
+		t.Fatalf("Pause = %v", s)
+	}
+	clock.t = clock.t.Add(10 * time.Second)
+	if pos := c.Position(); pos == nil || *pos != 10000 {
+		t.Errorf("Position() while paused = %v, want 10000", pos)
+	}
+	if c.sample.UpdatedAt != uint64(clock.t.Add(-10*time.Second).Sub(credentials.MatterEpochStart).Microseconds()) {
+		t.Error("sample not taken at pause time")
+	}
+
+	if s := invoke(t, c, CmdStop, nil); s != StatusSuccess {
+		t.Fatalf("Stop = %v", s)
+	}
+	if c.CurrentState() != PlaybackStateNotPlaying || *c.Position() != 0 {
+		t.Errorf("after Stop state = %v position = %d", c.CurrentState(), *c.Position())
+	}
+
+	want := []string{"play", "pause", "stop"}
+	if len(p.calls) != len(want) {
+		t.Errorf("delegate calls = %v, want %v", p.calls, want)
+	}
+}
+
+func TestDelegateRejects(t *testing.T) {
+	c, p, _ := newCluster(t, 0)
+	p.status = StatusNotAllowed
+	if s := c.Play(); s != StatusNotAllowed {
+		t.Errorf("Play = %v, want NotAllowed", s)
+	}
+	if c.CurrentState() != PlaybackStateNotPlaying {
+		t.Errorf("state = %v after rejected Play", c.CurrentState())
+	}
+}
+
+func TestVariableSpeed(t *testing.T) {
+	c, p, _ := newCluster(t, FeatureVariableSpeed)
+
+	if s := invoke(t, c, CmdFastForward, nil); s != StatusInvalidStateForCommand {
+		t.Errorf("FastForward while stopped = %v", s)
+	}
+	c.Play()
+	for _, want := range []float32{2, 4, 8} {
+		if s := invoke(t, c, CmdFastForward, nil); s != StatusSuccess || c.PlaybackSpeed() != want {
+			t.Errorf("FastForward = %v speed %v, want %v", s, c.PlaybackSpeed(), want)
+		}
+	}
+	if s := c.FastForward(); s != StatusSpeedOutOfRange {
+		t.Errorf("FastForward beyond max = %v, want SpeedOutOfRange", s)
+	}
+	if s := invoke(t, c, CmdRewind, nil); s != StatusSuccess || c.PlaybackSpeed() != -2 {
+		t.Errorf("Rewind = %v speed %v, want -2", s, c.PlaybackSpeed())
+	}
+	if p.speeds[len(p.speeds)-1] != -2 {
+		t.Errorf("delegate speed = %v, want -2", p.speeds[len(p.speeds)-1])
+	}
+
+	// Without VS the commands are not accepted
+	c2, _, _ := newCluster(t, 0)
+	req := datamodel.InvokeRequest{
+		Path: datamodel.ConcreteCommandPath{Endpoint: 1, Cluster: ClusterID, Command: CmdRewind},
+	}
+	if _, err := c2.InvokeCommand(context.Background(), req, nil); !errors.Is(err, datamodel.ErrUnsupportedCommand) {
+		t.Errorf("Rewind without VS error = %v", err)
+	}
+}
+
+func TestSeekAndSkip(t *testing.T) {
+	c, p, _ := newCluster(t, FeatureAdvancedSeek)
+	c.SetMedia(u64(60000))
+	c.Play()
+
+	if s := invoke(t, c, CmdSeek, u64(70000)); s != StatusSeekOutOfRange {
+		t.Errorf("Seek past end = %v, want SeekOutOfRange", s)
+	}
+	if s := invoke(t, c, CmdSeek, u64(30000)); s != StatusSuccess || *c.Position() != 30000 {
+		t.Errorf("Seek = %v position %v", s, c.Position())
+	}
+	if s := invoke(t, c, CmdSkipForward, u64(45000)); s != StatusSuccess || *c.Position() != 60000 {
+		t.Errorf("SkipForward = %v position %d, want clamped to 60000", s, *c.Position())
+	}
+	if s := invoke(t, c, CmdSkipBackward, u64(100000)); s != StatusSuccess || *c.Position() != 0 {
+		t.Errorf("SkipBackward = %v position %d, want 0", s, *c.Position())
+	}
+	if s := invoke(t, c, CmdStartOver, nil); s != StatusSuccess {
+		t.Errorf("StartOver = %v", s)
+	}
+	want := []uint64{30000, 60000, 0, 0}
+	if len(p.seeks) != len(want) {
+		t.Fatalf("delegate seeks = %v, want %v", p.seeks, want)
+	}
+	for i := range want {
+		if p.seeks[i] != want[i] {
+			t.Errorf("delegate seeks = %v, want %v", p.seeks, want)
+			break
+		}
+	}
+}
+
+func TestReadSampledPosition(t *testing.T) {
+	c, _, _ := newCluster(t, FeatureAdvancedSeek)
+	c.SetMedia(u64(60000))
+	c.SetPosition(1500)
+
+	var buf bytes.Buffer
+	req := datamodel.ReadAttributeRequest{
+		Path: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: AttrSampledPosition},
+	}
+	if err := c.ReadAttribute(context.Background(), req, tlv.NewWriter(&buf)); err != nil {
+		t.Fatalf("ReadAttribute error = %v", err)
+	}
+	r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+	r.Next()
+	r.EnterContainer()
+	r.Next()
+	r.Next()
+	if pos, _ := r.Uint(); pos != 1500 {
+		t.Errorf("Position = %d, want 1500", pos)
+	}
+
+	// Attributes of AS are hidden without the feature
+	c2, _, _ := newCluster(t, 0)
+	req.Path.Attribute = AttrDuration
+	if err := c2.ReadAttribute(context.Background(), req, tlv.NewWriter(&buf)); !errors.Is(err, datamodel.ErrUnsupportedAttribute) {
+		t.Errorf("Duration without AS error = %v", err)
+	}
+}
+
+func TestSetState(t *testing.T) {
+	c, _, _ := newCluster(t, 0)
+	v := c.DataVersion()
+	if err := c.SetState(PlaybackStateBuffering); err != nil {
+		t.Fatalf("SetState error = %v", err)
+	}
+	if c.CurrentState() != PlaybackStateBuffering || c.DataVersion() == v {
+		t.Error("state change not applied")
+	}
+	if err := c.SetState(PlaybackStatePlaying); err != nil || c.PlaybackSpeed() != 1 {
+		t.Errorf("SetState(Playing) err = %v speed = %v", err, c.PlaybackSpeed())
+	}
+	if err := c.SetState(PlaybackState(9)); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("invalid state error = %v", err)
+	}
+}
@@ -0,0 +1,346 @@
+package mediaplayback
+
+import (
+	"context"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	return datamodel.ResponseFields(c.InvokeCommandResponse(ctx, req, r))
+}
+
+// InvokeCommandResponse implements datamodel.ClusterWithCommandResponses.
+// Every command is answered with a PlaybackResponse.
+func (c *Cluster) InvokeCommandResponse(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) (*datamodel.CommandResponse, error) {
+	var status Status
+	switch req.Path.Command {
+	case CmdPlay:
+		status = c.Play()
+	case CmdPause:
+		status = c.Pause()
+	case CmdStop:
+		status = c.Stop()
+	case CmdStartOver:
+		status = c.StartOver()
+	case CmdPrevious:
+		status = c.Previous()
+	case CmdNext:
+		status = c.Next()
+	case CmdRewind, CmdFastForward:
+		if !c.hasFeature(FeatureVariableSpeed) {
+			return nil, datamodel.ErrUnsupportedCommand
+		}
+		if req.Path.Command == CmdRewind {
+			status = c.Rewind()
+		} else {
+			status = c.FastForward()
+		}
+	case CmdSkipForward, CmdSkipBackward:
+		delta, err := decodeUintField(r)
+		if err != nil {
+			return nil, err
+		}
+		if req.Path.Command == CmdSkipForward {
+			status = c.SkipForward(delta)
+		} else {
+			status = c.SkipBackward(delta)
+		}
+	case CmdSeek:
+		if !c.hasFeature(FeatureAdvancedSeek) {
+			return nil, datamodel.ErrUnsupportedCommand
+		}
+		position, err := decodeUintField(r)
+		if err != nil {
+			return nil, err
+		}
+		status = c.Seek(position)
+	default:
+		return nil, datamodel.ErrUnsupportedCommand
+	}
+	return newPlaybackResponse(status)
+}
+
+// decodeUintField decodes a command with a single unsigned field at tag 0.
+func decodeUintField(r *tlv.Reader) (uint64, error) {
+	if err := r.Next(); err != nil {
+		return 0, datamodel.ErrInvalidCommand
+	}
+	if err := r.EnterContainer(); err != nil {
+		return 0, datamodel.ErrInvalidCommand
+	}
+
+	var value *uint64
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		tag := r.Tag()
+		if tag.IsContext() && tag.TagNumber() == 0 {
+			v, err := r.Uint()
+			if err != nil {
+				return 0, datamodel.ErrInvalidCommand
+			}
+			value = &v
+		}
+	}
+	if value == nil {
+		return 0, datamodel.ErrInvalidCommand
+	}
+	return *value, nil
+}
+
+// newPlaybackResponse builds a PlaybackResponse (Spec 6.10.7.11).
+func newPlaybackResponse(status Status) (*datamodel.CommandResponse, error) {
+	return datamodel.NewCommandResponse(CmdPlaybackResponse, func(w *tlv.Writer) error {
+		return w.PutUint(tlv.ContextTag(0), uint64(status))
+	})
+}
+
+// Play handles the Play command: playback continues at normal speed.
+//
+// Spec: Section 6.10.7.1
+func (c *Cluster) Play() Status {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	c.mu.RLock()
+	playing := c.state == PlaybackStatePlaying && c.speed == 1
+	c.mu.RUnlock()
+	if playing {
+		return StatusSuccess
+	}
+	return c.playLocked(1)
+}
+
+// Pause handles the Pause command. Pausing stopped media is rejected.
+//
+// Spec: Section 6.10.7.2
+func (c *Cluster) Pause() Status {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	c.mu.RLock()
+	state := c.state
+	c.mu.RUnlock()
+	switch state {
+	case PlaybackStatePaused:
+		return StatusSuccess
+	case PlaybackStateNotPlaying:
+		return StatusInvalidStateForCommand
+	}
+
+	if s := c.config.Delegate.HandlePause(); s != StatusSuccess {
+		return s
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.transitionLocked(PlaybackStatePaused, 0, c.positionLocked())
+	return StatusSuccess
+}
+
+// Stop handles the Stop command: playback stops and returns to the start.
+//
+// Spec: Section 6.10.7.3
+func (c *Cluster) Stop() Status {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	if s := c.config.Delegate.HandleStop(); s != StatusSuccess {
+		return s
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	zero := uint64(0)
+	c.transitionLocked(PlaybackStateNotPlaying, 0, &zero)
+	return StatusSuccess
+}
+
+// StartOver handles the StartOver command: playback restarts from the
+// beginning in the current state.
+//
+// Spec: Section 6.10.7.4
+func (c *Cluster) StartOver() Status {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+	return c.seekLocked(0)
+}
+
+// Previous handles the Previous command.
+//
+// Spec: Section 6.10.7.5
+func (c *Cluster) Previous() Status {
+	return c.changeItem(c.config.Delegate.HandlePrevious)
+}
+
+// Next handles the Next command.
+//
+// Spec: Section 6.10.7.6
+func (c *Cluster) Next() Status {
+	return c.changeItem(c.config.Delegate.HandleNext)
+}
+
+// changeItem moves to another media item with handle; the position
+// restarts at 0.
+func (c *Cluster) changeItem(handle func() Status) Status {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	if s := handle(); s != StatusSuccess {
+		return s
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setSampleLocked(new(uint64))
+	c.IncrementDataVersion()
+	return StatusSuccess
+}
+
+// FastForward handles the FastForward command (VS). Playback moves
+// forward at twice normal speed, doubling on each repeat up to
+// Config.MaxSpeed.
+//
+// Spec: Section 6.10.7.8
+func (c *Cluster) FastForward() Status {
+	return c.changeSpeed(func(speed float32) float32 {
+		if speed <= 1 {
+			return 2
+		}
+		return speed * 2
+	})
+}
+
+// Rewind handles the Rewind command (VS). Playback moves backward at
+// twice normal speed, doubling on each repeat up to Config.MaxSpeed.
+//
+// Spec: Section 6.10.7.7
+func (c *Cluster) Rewind() Status {
+	return c.changeSpeed(func(speed float32) float32 {
+		if speed >= -1 {
+			return -2
+		}
+		return speed * 2
+	})
+}
+
+// changeSpeed applies the speed returned by next. Stopped media is
+// rejected, as is a speed beyond Config.MaxSpeed.
+func (c *Cluster) changeSpeed(next func(speed float32) float32) Status {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	c.mu.RLock()
+	state, speed := c.state, c.speed
+	c.mu.RUnlock()
+	if state == PlaybackStateNotPlaying {
+		return StatusInvalidStateForCommand
+	}
+
+	speed = next(speed)
+	if speed > c.config.MaxSpeed || speed < -c.config.MaxSpeed {
+		return StatusSpeedOutOfRange
+	}
+	return c.playLocked(speed)
+}
+
+// playLocked starts playback at speed. Caller must hold c.cmdMu.
+func (c *Cluster) playLocked(speed float32) Status {
+	if s := c.config.Delegate.HandlePlay(speed); s != StatusSuccess {
+		return s
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.transitionLocked(PlaybackStatePlaying, speed, c.positionLocked())
+	return StatusSuccess
+}
+
+// SkipForward handles the SkipForward command: the position moves
+// forward by delta milliseconds, stopping at the end of the seek range.
+//
+// Spec: Section 6.10.7.9
+func (c *Cluster) SkipForward(delta uint64) Status {
+	return c.skip(func(pos uint64) uint64 {
+		if pos+delta < pos {
+			return ^uint64(0)
+		}
+		return pos + delta
+	})
+}
+
+// SkipBackward handles the SkipBackward command: the position moves
+// backward by delta milliseconds, stopping at the start of the seek
+// range.
+//
+// Spec: Section 6.10.7.10
+func (c *Cluster) SkipBackward(delta uint64) Status {
+	return c.skip(func(pos uint64) uint64 {
+		if delta > pos {
+			return 0
+		}
+		return pos - delta
+	})
+}
+
+// skip seeks to the position returned by move, clamped to the seek range.
+func (c *Cluster) skip(move func(pos uint64) uint64) Status {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	c.mu.RLock()
+	state, pos := c.state, c.positionLocked()
+	start, end := cloneUint(c.seekStart), cloneUint(c.seekEnd)
+	c.mu.RUnlock()
+	if state == PlaybackStateNotPlaying || pos == nil {
+		return StatusInvalidStateForCommand
+	}
+
+	target := move(*pos)
+	if start != nil && target < *start {
+		target = *start
+	}
+	if end != nil && target > *end {
+		target = *end
+	}
+	return c.seekLocked(target)
+}
+
+// Seek handles the Seek command (AS). Positions outside the seek range
+// are rejected.
+//
+// Spec: Section 6.10.7.12
+func (c *Cluster) Seek(position uint64) Status {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	c.mu.RLock()
+	state := c.state
+	outOfRange := (c.seekStart != nil && position < *c.seekStart) ||
+		(c.seekEnd != nil && position > *c.seekEnd)
+	c.mu.RUnlock()
+	if state == PlaybackStateNotPlaying {
+		return StatusInvalidStateForCommand
+	}
+	if outOfRange {
+		return StatusSeekOutOfRange
+	}
+	return c.seekLocked(position)
+}
+
+// seekLocked moves to position. Caller must hold c.cmdMu.
+func (c *Cluster) seekLocked(position uint64) Status {
+	if s := c.config.Delegate.HandleSeek(position); s != StatusSuccess {
+		return s
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setSampleLocked(&position)
+	c.IncrementDataVersion()
+	return StatusSuccess
+}
@@ -0,0 +1,451 @@
+// Package modebase implements the Mode Base cluster pattern and the state
+// shared with the Mode Select cluster.
+//
+// Mode Base is not a cluster on its own: RVC Run Mode, RVC Clean Mode,
+// Dishwasher Mode, Laundry Washer Mode and others are derived from it and
+// differ only in cluster ID, revision and the derived mode tags they
+// define. A single Cluster implementation is instantiated from a
+// Definition describing the derived cluster.
+//
+// ChangeToMode requests are validated against the supported mode list and
+// may be vetoed by a Delegate, e.g. when the device cannot change mode in
+// its current state.
+//
+// Spec Reference: Section 1.10
+//
+// C++ Reference: src/app/clusters/mode-base-server/mode-base-server.cpp
+package modebase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Attribute IDs (Spec 1.10.6).
+const (
+	AttrSupportedModes datamodel.AttributeID = 0x0000
+	AttrCurrentMode    datamodel.AttributeID = 0x0001
+	AttrStartUpMode    datamodel.AttributeID = 0x0002
+	AttrOnMode         datamodel.AttributeID = 0x0003
+)
+
+// Command IDs (Spec 1.10.7).
+const (
+	CmdChangeToMode         datamodel.CommandID = 0x00
+	CmdChangeToModeResponse datamodel.CommandID = 0x01
+)
+
+// Feature bits (Spec 1.10.4).
+type Feature uint32
+
+const (
+	// FeatureOnOff enables the OnMode attribute (DEPONOFF).
+	FeatureOnOff Feature = 1 << 0
+)
+
+// ChangeStatus is the status in a ChangeToModeResponse (Spec 1.10.7.2.1).
+// Derived clusters define additional values in 0x40-0x7F.
+type ChangeStatus uint8
+
+const (
+	ChangeStatusSuccess         ChangeStatus = 0x00
+	ChangeStatusUnsupportedMode ChangeStatus = 0x01
+	ChangeStatusGenericFailure  ChangeStatus = 0x02
+	ChangeStatusInvalidInMode   ChangeStatus = 0x03
+)
+
+// valid returns true if the status is a common or derived-cluster status.
+func (s ChangeStatus) valid() bool {
+	return s <= ChangeStatusInvalidInMode || (s >= 0x40 && s <= 0x7F)
+}
+
+// Definition describes a cluster derived from Mode Base.
+type Definition struct {
+	// ClusterID of the derived cluster.
+	ClusterID datamodel.ClusterID
+
+	// Revision of the derived cluster.
+	Revision uint16
+
+	// Name of the derived cluster.
+	Name string
+
+	// Tags are the derived mode tag values (0x4000-0x7FFF) in addition to
+	// the common tags.
+	Tags []uint16
+
+	// StartUpMode is true if the derived cluster allows the StartUpMode
+	// and OnMode attributes.
+	StartUpMode bool
+}
+
+// validTag returns true for common tags and the derived cluster's tags.
+func (d Definition) validTag(v uint16) bool {
+	if v <= ModeTagDay {
+		return true
+	}
+	if v < derivedTagMin {
+		return false
+	}
+	for _, t := range d.Tags {
+		if t == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Delegate lets the application accept or veto mode changes.
+type Delegate interface {
+	// HandleChangeToMode is called before CurrentMode changes to a
+	// supported, different mode. Return ChangeStatusSuccess to accept,
+	// or another status with optional text to reject.
+	HandleChangeToMode(newMode uint8) (ChangeStatus, string)
+}
+
+// Config provides dependencies for a Mode Base derived cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to.
+	EndpointID datamodel.EndpointID
+
+	// Definition selects the derived cluster.
+	Definition Definition
+
+	// FeatureMap indicates supported features.
+	FeatureMap Feature
+
+	// Modes is the list of supported modes.
+	Modes []ModeOption
+
+	// InitialMode is CurrentMode if nothing is persisted and StartUpMode is null.
+	InitialMode uint8
+
+	// StartUpMode is the initial StartUpMode value (nullable).
+	StartUpMode *uint8
+
+	// OnMode is the initial OnMode value (nullable, FeatureOnOff).
+	OnMode *uint8
+
+	// Delegate accepts or vetoes mode changes (optional).
+	Delegate Delegate
+
+	// Storage for persisting state (optional).
+	Storage Storage
+
+	// OnModeChange is called when CurrentMode changes (optional).
+	OnModeChange func(endpoint datamodel.EndpointID, mode uint8)
+}
+
+// Cluster implements a cluster derived from Mode Base.
+type Cluster struct {
+	*datamodel.ClusterBase
+	config Config
+	modes  *Modes
+
+	// changeMu serializes ChangeToMode so the delegate sees a stable CurrentMode.
+	changeMu sync.Mutex
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a Mode Base derived cluster. It returns an error if the mode
+// list is invalid for the definition.
+func New(cfg Config) (*Cluster, error) {
+	if err := ValidateModes(cfg.Modes, cfg.Definition.validTag); err != nil {
+		return nil, err
+	}
+	if !cfg.Definition.StartUpMode {
+		cfg.FeatureMap &^= FeatureOnOff
+		cfg.StartUpMode = nil
+	}
+	if cfg.FeatureMap&FeatureOnOff == 0 {
+		cfg.OnMode = nil
+	}
+
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(cfg.Definition.ClusterID, cfg.EndpointID, cfg.Definition.Revision),
+		config:      cfg,
+		modes: NewModes(ModesConfig{
+			Modes:       cfg.Modes,
+			InitialMode: cfg.InitialMode,
+			StartUpMode: cfg.StartUpMode,
+			OnMode:      cfg.OnMode,
+			Storage:     cfg.Storage,
+		}),
+	}
+
+	c.ClusterBase.SetFeatureMap(uint32(cfg.FeatureMap))
+
+	c.attrList = c.buildAttributeList()
+
+	return c, nil
+}
+
+// buildAttributeList constructs the list of supported attributes.
+func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+	operatePriv := datamodel.PrivilegeOperate
+
+	attrs := []datamodel.AttributeEntry{
+		datamodel.NewReadOnlyAttribute(AttrSupportedModes, datamodel.AttrQualityList|datamodel.AttrQualityFixed, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrCurrentMode, datamodel.AttrQualityNonVolatile|datamodel.AttrQualityScene, viewPriv),
+	}
+
+	if c.config.Definition.StartUpMode {
+		attrs = append(attrs,
+			datamodel.NewReadWriteAttribute(AttrStartUpMode, datamodel.AttrQualityNullable|datamodel.AttrQualityNonVolatile, viewPriv, operatePriv),
+		)
+	}
+
+	if c.config.FeatureMap&FeatureOnOff != 0 {
+		attrs = append(attrs,
+			datamodel.NewReadWriteAttribute(AttrOnMode, datamodel.AttrQualityNullable|datamodel.AttrQualityNonVolatile, viewPriv, operatePriv),
+		)
+	}
+
+	return datamodel.MergeAttributeLists(attrs)
+}
+
+// Definition returns the derived cluster definition.
+func (c *Cluster) Definition() Definition {
+	return c.config.Definition
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	return []datamodel.CommandEntry{
+		datamodel.NewCommandEntry(CmdChangeToMode, 0, datamodel.PrivilegeOperate),
+	}
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return []datamodel.CommandID{CmdChangeToModeResponse}
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	switch req.Path.Attribute {
+	case AttrSupportedModes:
+		return MarshalModeOptions(w, c.modes.SupportedModes(), false)
+	case AttrCurrentMode:
+		return w.PutUint(tlv.Anonymous(), uint64(c.modes.Current()))
+	case AttrStartUpMode:
+		if !c.config.Definition.StartUpMode {
+			return datamodel.ErrUnsupportedAttribute
+		}
+		return PutNullableMode(w, c.modes.StartUpMode())
+	case AttrOnMode:
+		if c.config.FeatureMap&FeatureOnOff == 0 {
+			return datamodel.ErrUnsupportedAttribute
+		}
+		return PutNullableMode(w, c.modes.OnMode())
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	var set func(*uint8) error
+	switch req.Path.Attribute {
+	case AttrStartUpMode:
+		if !c.config.Definition.StartUpMode {
+			return datamodel.ErrUnsupportedWrite
+		}
+		set = c.modes.SetStartUpMode
+	case AttrOnMode:
+		if c.config.FeatureMap&FeatureOnOff == 0 {
+			return datamodel.ErrUnsupportedWrite
+		}
+		set = c.modes.SetOnMode
+	default:
+		return datamodel.ErrUnsupportedWrite
+	}
+
+	mode, err := ReadNullableMode(r)
+	if err != nil {
+		if errors.Is(err, ErrUnsupportedMode) {
+			return datamodel.ErrConstraintError
+		}
+		return err
+	}
+	if err := set(mode); err != nil {
+		return datamodel.ErrConstraintError
+	}
+
+	c.IncrementDataVersion()
+	return nil
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	switch req.Path.Command {
+	case CmdChangeToMode:
+		newMode, err := DecodeChangeToMode(r)
+		if err != nil {
+			return nil, err
+		}
+		status, text := c.ChangeToMode(newMode)
+		return encodeChangeToModeResponse(status, text)
+	default:
+		return nil, datamodel.ErrUnsupportedCommand
+	}
+}
+
+// CurrentMode returns CurrentMode.
+func (c *Cluster) CurrentMode() uint8 {
+	return c.modes.Current()
+}
+
+// SupportedModes returns the supported mode list.
+func (c *Cluster) SupportedModes() []ModeOption {
+	return c.modes.SupportedModes()
+}
+
+// ChangeToMode validates and applies a mode change, as the ChangeToMode
+// command does.
+//
+// Spec: Section 1.10.7.1
+func (c *Cluster) ChangeToMode(newMode uint8) (ChangeStatus, string) {
+	c.changeMu.Lock()
+	defer c.changeMu.Unlock()
+
+	if !c.modes.Supports(newMode) {
+		return ChangeStatusUnsupportedMode, ""
+	}
+	if newMode == c.modes.Current() {
+		return ChangeStatusSuccess, ""
+	}
+
+	if c.config.Delegate != nil {
+		status, text := c.config.Delegate.HandleChangeToMode(newMode)
+		if !status.valid() {
+			status = ChangeStatusGenericFailure
+		}
+		if status != ChangeStatusSuccess {
+			return status, text
+		}
+	}
+
+	c.setCurrentMode(newMode)
+	return ChangeStatusSuccess, ""
+}
+
+// UpdateCurrentMode sets CurrentMode from the device side, e.g. when an RVC
+// finishes cleaning and returns to idle. The delegate is not consulted.
+func (c *Cluster) UpdateCurrentMode(mode uint8) error {
+	c.changeMu.Lock()
+	defer c.changeMu.Unlock()
+
+	if !c.modes.Supports(mode) {
+		return ErrUnsupportedMode
+	}
+	c.setCurrentMode(mode)
+	return nil
+}
+
+// ApplyOnMode sets CurrentMode to OnMode, if non-null. Call it when the
+// On/Off cluster on the same endpoint turns on (FeatureOnOff).
+//
+// Spec: Section 1.10.6.4
+func (c *Cluster) ApplyOnMode() {
+	if c.config.FeatureMap&FeatureOnOff == 0 {
+		return
+	}
+	if mode := c.modes.OnMode(); mode != nil {
+		_ = c.UpdateCurrentMode(*mode)
+	}
+}
+
+// setCurrentMode stores CurrentMode and notifies on change.
+func (c *Cluster) setCurrentMode(mode uint8) {
+	changed, err := c.modes.SetCurrent(mode)
+	if err != nil || !changed {
+		return
+	}
+
+	c.IncrementDataVersion()
+	if c.config.OnModeChange != nil {
+		c.config.OnModeChange(c.config.EndpointID, mode)
+	}
+}
+
+// DecodeChangeToMode decodes the NewMode field of a ChangeToMode request.
+func DecodeChangeToMode(r *tlv.Reader) (uint8, error) {
+	if err := r.Next(); err != nil {
+		return 0, datamodel.ErrInvalidCommand
+	}
+	if err := r.EnterContainer(); err != nil {
+		return 0, datamodel.ErrInvalidCommand
+	}
+
+	var newMode uint8
+	found := false
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		tag := r.Tag()
+		if !tag.IsContext() || tag.TagNumber() != 0 {
+			continue
+		}
+		v, err := r.Uint()
+		if err != nil || v > 0xFF {
+			return 0, datamodel.ErrInvalidCommand
+		}
+		newMode = uint8(v)
+		found = true
+	}
+	if !found {
+		return 0, datamodel.ErrInvalidCommand
+	}
+	return newMode, nil
+}
+
+// encodeChangeToModeResponse encodes a ChangeToModeResponse (Spec 1.10.7.2).
+func encodeChangeToModeResponse(status ChangeStatus, text string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return nil, err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(status)); err != nil {
+		return nil, err
+	}
+	if text != "" {
+		if len(text) > MaxLabelLength {
+			text = text[:MaxLabelLength]
+			for !utf8.ValidString(text) {
+				text = text[:len(text)-1]
+			}
+		}
+		if err := w.PutString(tlv.ContextTag(1), text); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.EndContainer(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
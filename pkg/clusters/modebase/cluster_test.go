@@ -0,0 +1,295 @@
+package modebase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+type memStorage map[string][]byte
+
+func (s memStorage) Load(key string) ([]byte, error) {
+	v, ok := s[key]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return v, nil
+}
+
+func (s memStorage) Store(key string, value []byte) error {
+	s[key] = value
+	return nil
+}
+
+type vetoDelegate struct {
+	status ChangeStatus
+	text   string
+	calls  int
+}
+
+func (d *vetoDelegate) HandleChangeToMode(newMode uint8) (ChangeStatus, string) {
+	d.calls++
+	return d.status, d.text
+}
+
+func u8p(v uint8) *uint8 { return &v }
+
+func rvcModes() []ModeOption {
+	return []ModeOption{
+		{Label: "Idle", Mode: 0, ModeTags: []ModeTag{{Value: RVCRunModeTagIdle}}},
+		{Label: "Cleaning", Mode: 1, ModeTags: []ModeTag{{Value: RVCRunModeTagCleaning}}},
+		{Label: "Mapping", Mode: 2, ModeTags: []ModeTag{{Value: RVCRunModeTagMapping}, {Value: ModeTagQuiet}}},
+	}
+}
+
+func washerModes() []ModeOption {
+	return []ModeOption{
+		{Label: "Normal", Mode: 0, ModeTags: []ModeTag{{Value: LaundryWasherModeTagNormal}}},
+		{Label: "Delicate", Mode: 1, ModeTags: []ModeTag{{Value: LaundryWasherModeTagDelicate}}},
+		{Label: "Heavy", Mode: 2, ModeTags: []ModeTag{{Value: LaundryWasherModeTagHeavy}}},
+	}
+}
+
+func invokeChangeToMode(t *testing.T, c *Cluster, mode uint8) (ChangeStatus, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	w.StartStructure(tlv.Anonymous())
+	w.PutUint(tlv.ContextTag(0), uint64(mode))
+	w.EndContainer()
+
+	req := datamodel.InvokeRequest{
+		Path: datamodel.ConcreteCommandPath{Endpoint: 1, Cluster: c.ID(), Command: CmdChangeToMode},
+	}
+	resp, err := c.InvokeCommand(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes())))
+	if err != nil {
+		t.Fatalf("InvokeCommand failed: %v", err)
+	}
+
+	r := tlv.NewReader(bytes.NewReader(resp))
+	if err := r.Next(); err != nil {
+		t.Fatal(err)
+	}
+	r.EnterContainer()
+	var status ChangeStatus
+	var text string
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		switch r.Tag().TagNumber() {
+		case 0:
+			v, _ := r.Uint()
+			status = ChangeStatus(v)
+		case 1:
+			text, _ = r.String()
+		}
+	}
+	return status, text
+}
+
+func TestValidateModes(t *testing.T) {
+	mfg := uint16(0xFFF1)
+	tests := []struct {
+		name  string
+		modes []ModeOption
+		want  error
+	}{
+		{"empty", nil, ErrNoModes},
+		{"duplicate mode", []ModeOption{{Label: "A", Mode: 1}, {Label: "B", Mode: 1}}, ErrDuplicateMode},
+		{"duplicate label", []ModeOption{{Label: "A", Mode: 1}, {Label: "A", Mode: 2}}, ErrDuplicateLabel},
+		{"empty label", []ModeOption{{Label: "", Mode: 1}}, ErrInvalidLabel},
+		{"too many tags", []ModeOption{{Label: "A", Mode: 1, ModeTags: make([]ModeTag, 9)}}, ErrTooManyTags},
+		{"foreign derived tag", []ModeOption{{Label: "A", Mode: 1, ModeTags: []ModeTag{{Value: 0x4005}}}}, ErrInvalidTag},
+		{"reserved tag", []ModeOption{{Label: "A", Mode: 1, ModeTags: []ModeTag{{Value: 0x0100}}}}, ErrInvalidTag},
+		{"mfg tag out of range", []ModeOption{{Label: "A", Mode: 1, ModeTags: []ModeTag{{MfgCode: &mfg, Value: 0x0001}}}}, ErrInvalidTag},
+		{"mfg tag", []ModeOption{{Label: "A", Mode: 1, ModeTags: []ModeTag{{MfgCode: &mfg, Value: 0x8001}}}}, nil},
+		{"valid", rvcModes(), nil},
+	}
+
+	for _, tt := range tests {
+		if err := ValidateModes(tt.modes, RVCRunMode.validTag); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	if _, err := New(Config{EndpointID: 1, Definition: RefrigeratorAndTemperatureControlledCabinetMode, Modes: rvcModes()}); !errors.Is(err, ErrInvalidTag) {
+		t.Errorf("New with RVC mapping tag on refrigerator: err = %v, want ErrInvalidTag", err)
+	}
+}
+
+func TestChangeToMode(t *testing.T) {
+	d := &vetoDelegate{status: ChangeStatusSuccess}
+	var notified []uint8
+	c, err := New(Config{
+		EndpointID: 1,
+		Definition: RVCRunMode,
+		Modes:      rvcModes(),
+		Delegate:   d,
+		OnModeChange: func(_ datamodel.EndpointID, mode uint8) {
+			notified = append(notified, mode)
+		},
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if c.ID() != 0x0054 {
+		t.Errorf("ID() = 0x%04X, want 0x0054", c.ID())
+	}
+
+	if status, _ := invokeChangeToMode(t, c, 9); status != ChangeStatusUnsupportedMode {
+		t.Errorf("unsupported: status = %d, want UnsupportedMode", status)
+	}
+	if status, _ := invokeChangeToMode(t, c, 0); status != ChangeStatusSuccess || d.calls != 0 {
+		t.Errorf("same mode: status = %d, calls = %d; want Success without delegate", status, d.calls)
+	}
+
+	d.status, d.text = RVCRunStatusDustBinMissing, "dust bin missing"
+	status, text := invokeChangeToMode(t, c, 1)
+	if status != RVCRunStatusDustBinMissing || text != "dust bin missing" {
+		t.Errorf("veto: status = 0x%02X %q", status, text)
+	}
+	if c.CurrentMode() != 0 {
+		t.Errorf("CurrentMode = %d after veto, want 0", c.CurrentMode())
+	}
+
+	d.status = ChangeStatus(0x20)
+	if status, _ := invokeChangeToMode(t, c, 1); status != ChangeStatusGenericFailure {
+		t.Errorf("reserved status: got 0x%02X, want GenericFailure", status)
+	}
+
+	d.status = ChangeStatusSuccess
+	if status, _ := invokeChangeToMode(t, c, 1); status != ChangeStatusSuccess {
+		t.Errorf("accept: status = %d", status)
+	}
+	if c.CurrentMode() != 1 || len(notified) != 1 || notified[0] != 1 {
+		t.Errorf("CurrentMode = %d, notified = %v; want 1, [1]", c.CurrentMode(), notified)
+	}
+
+	if err := c.UpdateCurrentMode(0); err != nil || c.CurrentMode() != 0 {
+		t.Errorf("UpdateCurrentMode: err = %v, mode = %d", err, c.CurrentMode())
+	}
+}
+
+func TestRVCHasNoStartUpMode(t *testing.T) {
+	c, err := New(Config{
+		EndpointID:  1,
+		Definition:  RVCRunMode,
+		FeatureMap:  FeatureOnOff,
+		Modes:       rvcModes(),
+		StartUpMode: u8p(1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range c.AttributeList() {
+		if a.ID == AttrStartUpMode || a.ID == AttrOnMode {
+			t.Errorf("attribute 0x%04X should be absent", a.ID)
+		}
+	}
+	if c.FeatureMap() != 0 {
+		t.Errorf("FeatureMap = %d, want 0", c.FeatureMap())
+	}
+	if c.CurrentMode() != 0 {
+		t.Errorf("CurrentMode = %d, want 0", c.CurrentMode())
+	}
+}
+
+func TestStartUpModePersistence(t *testing.T) {
+	store := memStorage{}
+	cfg := Config{
+		EndpointID: 1,
+		Definition: LaundryWasherMode,
+		FeatureMap: FeatureOnOff,
+		Modes:      washerModes(),
+		Storage:    store,
+	}
+	c, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write := func(attr datamodel.AttributeID, v *uint8) error {
+		var buf bytes.Buffer
+		w := tlv.NewWriter(&buf)
+		if v == nil {
+			w.PutNull(tlv.Anonymous())
+		} else {
+			w.PutUint(tlv.Anonymous(), uint64(*v))
+		}
+		req := datamodel.WriteAttributeRequest{
+			Path: datamodel.ConcreteDataAttributePath{
+				ConcreteAttributePath: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: c.ID(), Attribute: attr},
+			},
+		}
+		return c.WriteAttribute(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes())))
+	}
+
+	if err := write(AttrStartUpMode, u8p(7)); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("write unsupported StartUpMode: err = %v, want ErrConstraintError", err)
+	}
+	if err := write(AttrStartUpMode, u8p(2)); err != nil {
+		t.Fatalf("write StartUpMode failed: %v", err)
+	}
+	if err := write(AttrOnMode, u8p(1)); err != nil {
+		t.Fatalf("write OnMode failed: %v", err)
+	}
+	c.ChangeToMode(1)
+
+	// Restart: StartUpMode wins over the persisted CurrentMode.
+	c2, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c2.CurrentMode() != 2 {
+		t.Errorf("CurrentMode after restart = %d, want 2", c2.CurrentMode())
+	}
+	c2.ApplyOnMode()
+	if c2.CurrentMode() != 1 {
+		t.Errorf("CurrentMode after ApplyOnMode = %d, want 1", c2.CurrentMode())
+	}
+
+	// Null StartUpMode: persisted CurrentMode is kept.
+	if err := write(AttrStartUpMode, nil); err != nil {
+		t.Fatal(err)
+	}
+	c.ChangeToMode(0)
+	c3, _ := New(cfg)
+	if c3.CurrentMode() != 0 {
+		t.Errorf("CurrentMode with null StartUpMode = %d, want 0", c3.CurrentMode())
+	}
+}
+
+func TestReadSupportedModes(t *testing.T) {
+	c, err := New(Config{EndpointID: 1, Definition: RVCRunMode, Modes: rvcModes()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	req := datamodel.ReadAttributeRequest{
+		Path: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: c.ID(), Attribute: AttrSupportedModes},
+	}
+	if err := c.ReadAttribute(context.Background(), req, tlv.NewWriter(&buf)); err != nil {
+		t.Fatalf("ReadAttribute failed: %v", err)
+	}
+
+	r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+	r.Next()
+	r.EnterContainer()
+	count := 0
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		count++
+		r.Skip()
+	}
+	if count != 3 {
+		t.Errorf("SupportedModes has %d entries, want 3", count)
+	}
+}
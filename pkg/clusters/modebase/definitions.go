@@ -0,0 +1,116 @@
+package modebase
+
+// Derived mode tags and status codes are defined per cluster in the
+// Application Cluster specification.
+
+// Laundry Washer Mode tags (Spec 8.3.7.1).
+const (
+	LaundryWasherModeTagNormal   uint16 = 0x4000
+	LaundryWasherModeTagDelicate uint16 = 0x4001
+	LaundryWasherModeTagHeavy    uint16 = 0x4002
+	LaundryWasherModeTagWhites   uint16 = 0x4003
+)
+
+// Refrigerator and Temperature Controlled Cabinet Mode tags (Spec 8.7.7.1).
+const (
+	RefrigeratorModeTagRapidCool   uint16 = 0x4000
+	RefrigeratorModeTagRapidFreeze uint16 = 0x4001
+)
+
+// RVC Run Mode tags and status codes (Spec 7.2.7).
+const (
+	RVCRunModeTagIdle     uint16 = 0x4000
+	RVCRunModeTagCleaning uint16 = 0x4001
+	RVCRunModeTagMapping  uint16 = 0x4002
+
+	RVCRunStatusStuck                 ChangeStatus = 0x41
+	RVCRunStatusDustBinMissing        ChangeStatus = 0x42
+	RVCRunStatusDustBinFull           ChangeStatus = 0x43
+	RVCRunStatusWaterTankEmpty        ChangeStatus = 0x44
+	RVCRunStatusWaterTankMissing      ChangeStatus = 0x45
+	RVCRunStatusWaterTankLidOpen      ChangeStatus = 0x46
+	RVCRunStatusMopCleaningPadMissing ChangeStatus = 0x47
+	RVCRunStatusBatteryLow            ChangeStatus = 0x48
+)
+
+// RVC Clean Mode tags and status codes (Spec 7.3.7).
+const (
+	RVCCleanModeTagDeepClean uint16 = 0x4000
+	RVCCleanModeTagVacuum    uint16 = 0x4001
+	RVCCleanModeTagMop       uint16 = 0x4002
+
+	RVCCleanStatusCleaningInProgress ChangeStatus = 0x40
+)
+
+// Dishwasher Mode tags (Spec 8.4.7.1).
+const (
+	DishwasherModeTagNormal uint16 = 0x4000
+	DishwasherModeTagHeavy  uint16 = 0x4001
+	DishwasherModeTagLight  uint16 = 0x4002
+)
+
+// Oven Mode tags (Spec 8.11.7.1).
+const (
+	OvenModeTagBake            uint16 = 0x4000
+	OvenModeTagConvection      uint16 = 0x4001
+	OvenModeTagGrill           uint16 = 0x4002
+	OvenModeTagRoast           uint16 = 0x4003
+	OvenModeTagClean           uint16 = 0x4004
+	OvenModeTagConvectionBake  uint16 = 0x4005
+	OvenModeTagConvectionRoast uint16 = 0x4006
+	OvenModeTagWarming         uint16 = 0x4007
+	OvenModeTagProofing        uint16 = 0x4008
+	OvenModeTagSteam           uint16 = 0x4009
+)
+
+// Definitions of the clusters derived from Mode Base.
+var (
+	LaundryWasherMode = Definition{
+		ClusterID:   0x0051,
+		Revision:    3,
+		Name:        "Laundry Washer Mode",
+		Tags:        []uint16{LaundryWasherModeTagNormal, LaundryWasherModeTagDelicate, LaundryWasherModeTagHeavy, LaundryWasherModeTagWhites},
+		StartUpMode: true,
+	}
+
+	RefrigeratorAndTemperatureControlledCabinetMode = Definition{
+		ClusterID:   0x0052,
+		Revision:    3,
+		Name:        "Refrigerator And Temperature Controlled Cabinet Mode",
+		Tags:        []uint16{RefrigeratorModeTagRapidCool, RefrigeratorModeTagRapidFreeze},
+		StartUpMode: true,
+	}
+
+	RVCRunMode = Definition{
+		ClusterID: 0x0054,
+		Revision:  3,
+		Name:      "RVC Run Mode",
+		Tags:      []uint16{RVCRunModeTagIdle, RVCRunModeTagCleaning, RVCRunModeTagMapping},
+	}
+
+	RVCCleanMode = Definition{
+		ClusterID: 0x0055,
+		Revision:  3,
+		Name:      "RVC Clean Mode",
+		Tags:      []uint16{RVCCleanModeTagDeepClean, RVCCleanModeTagVacuum, RVCCleanModeTagMop},
+	}
+
+	DishwasherMode = Definition{
+		ClusterID:   0x0059,
+		Revision:    3,
+		Name:        "Dishwasher Mode",
+		Tags:        []uint16{DishwasherModeTagNormal, DishwasherModeTagHeavy, DishwasherModeTagLight},
+		StartUpMode: true,
+	}
+
+	OvenMode = Definition{
+		ClusterID: 0x0049,
+		Revision:  2,
+		Name:      "Oven Mode",
+		Tags: []uint16{
+			OvenModeTagBake, OvenModeTagConvection, OvenModeTagGrill, OvenModeTagRoast, OvenModeTagClean,
+			OvenModeTagConvectionBake, OvenModeTagConvectionRoast, OvenModeTagWarming, OvenModeTagProofing, OvenModeTagSteam,
+		},
+		StartUpMode: true,
+	}
+)
@@ -0,0 +1,372 @@
+package modebase
+
+import (
+	"errors"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Common mode tag values shared by all derived clusters (Spec 1.10.8).
+// Derived cluster tags start at 0x4000; manufacturer tags at 0x8000.
+const (
+	ModeTagAuto      uint16 = 0x0000
+	ModeTagQuick     uint16 = 0x0001
+	ModeTagQuiet     uint16 = 0x0002
+	ModeTagLowNoise  uint16 = 0x0003
+	ModeTagLowEnergy uint16 = 0x0004
+	ModeTagVacation  uint16 = 0x0005
+	ModeTagMin       uint16 = 0x0006
+	ModeTagMax       uint16 = 0x0007
+	ModeTagNight     uint16 = 0x0008
+	ModeTagDay       uint16 = 0x0009
+)
+
+// Mode tag ranges (Spec 1.10.8).
+const (
+	derivedTagMin uint16 = 0x4000
+	mfgTagMin     uint16 = 0x8000
+	mfgTagMax     uint16 = 0xBFFF
+)
+
+// Limits on mode lists (Spec 1.10.5.1).
+const (
+	MaxLabelLength = 64
+	MaxModeTags    = 8
+)
+
+// Errors returned by mode list validation and mode changes.
+var (
+	ErrNoModes         = errors.New("modebase: no supported modes")
+	ErrDuplicateMode   = errors.New("modebase: duplicate mode value")
+	ErrDuplicateLabel  = errors.New("modebase: duplicate mode label")
+	ErrInvalidLabel    = errors.New("modebase: invalid mode label")
+	ErrTooManyTags     = errors.New("modebase: too many mode tags")
+	ErrInvalidTag      = errors.New("modebase: invalid mode tag")
+	ErrUnsupportedMode = errors.New("modebase: unsupported mode")
+)
+
+// ModeTag is a semantic tag describing a mode (Spec 1.10.5.2).
+type ModeTag struct {
+	// MfgCode is the vendor ID for manufacturer-specific tags (optional).
+	MfgCode *uint16
+
+	// Value is the tag value.
+	Value uint16
+}
+
+// ModeOption describes one supported mode (Spec 1.10.5.1).
+type ModeOption struct {
+	// Label is a human readable name, at most 64 bytes.
+	Label string
+
+	// Mode is the value used in CurrentMode and ChangeToMode.
+	Mode uint8
+
+	// ModeTags describe the mode semantically (at most 8).
+	ModeTags []ModeTag
+}
+
+// ValidateModes checks a mode list: at least one mode, unique mode values
+// and labels, label length, and tag count. If validTag is non-nil, every
+// tag without a MfgCode must satisfy it.
+func ValidateModes(modes []ModeOption, validTag func(uint16) bool) error {
+	if len(modes) == 0 {
+		return ErrNoModes
+	}
+	values := make(map[uint8]bool, len(modes))
+	labels := make(map[string]bool, len(modes))
+	for _, m := range modes {
+		if values[m.Mode] {
+			return ErrDuplicateMode
+		}
+		values[m.Mode] = true
+
+		if m.Label == "" || len(m.Label) > MaxLabelLength || !utf8.ValidString(m.Label) {
+			return ErrInvalidLabel
+		}
+		if labels[m.Label] {
+			return ErrDuplicateLabel
+		}
+		labels[m.Label] = true
+
+		if len(m.ModeTags) > MaxModeTags {
+			return ErrTooManyTags
+		}
+		for _, tag := range m.ModeTags {
+			if tag.MfgCode != nil {
+				if tag.Value < mfgTagMin || tag.Value > mfgTagMax {
+					return ErrInvalidTag
+				}
+				continue
+			}
+			if validTag != nil && !validTag(tag.Value) {
+				return ErrInvalidTag
+			}
+		}
+	}
+	return nil
+}
+
+// Storage provides persistence for mode state.
+type Storage interface {
+	// Load retrieves a value by key.
+	Load(key string) ([]byte, error)
+	// Store persists a value.
+	Store(key string, value []byte) error
+}
+
+// Storage keys.
+const (
+	keyCurrentMode = "currentMode"
+	keyStartUpMode = "startUpMode"
+	keyOnMode      = "onMode"
+)
+
+// Modes holds the mode list and the CurrentMode, StartUpMode and OnMode
+// state shared by Mode Select and the Mode Base derived clusters.
+// It is safe for concurrent use.
+type Modes struct {
+	modes   []ModeOption
+	storage Storage
+
+	mu          sync.RWMutex
+	currentMode uint8
+	startUpMode *uint8 // nullable
+	onMode      *uint8 // nullable
+}
+
+// ModesConfig configures a Modes state.
+type ModesConfig struct {
+	// Modes is the list of supported modes. It must be validated by the caller.
+	Modes []ModeOption
+
+	// InitialMode is CurrentMode if nothing is persisted and StartUpMode is null.
+	// Defaults to the first supported mode if not supported.
+	InitialMode uint8
+
+	// StartUpMode is the initial StartUpMode value (nullable).
+	StartUpMode *uint8
+
+	// OnMode is the initial OnMode value (nullable).
+	OnMode *uint8
+
+	// Storage for persisting state (optional).
+	Storage Storage
+}
+
+// NewModes creates the mode state and resolves CurrentMode at startup.
+//
+// Spec: Section 1.10.6.3
+func NewModes(cfg ModesConfig) *Modes {
+	m := &Modes{
+		modes:       cfg.Modes,
+		storage:     cfg.Storage,
+		currentMode: cfg.InitialMode,
+		startUpMode: m8(cfg.StartUpMode),
+		onMode:      m8(cfg.OnMode),
+	}
+
+	if m.storage != nil {
+		if data, err := m.storage.Load(keyCurrentMode); err == nil && len(data) == 1 {
+			m.currentMode = data[0]
+		}
+		m.startUpMode = m.loadNullable(keyStartUpMode, m.startUpMode)
+		m.onMode = m.loadNullable(keyOnMode, m.onMode)
+	}
+
+	if m.startUpMode != nil && m.Supports(*m.startUpMode) {
+		m.currentMode = *m.startUpMode
+	}
+	if !m.Supports(m.currentMode) && len(m.modes) > 0 {
+		m.currentMode = m.modes[0].Mode
+	}
+
+	return m
+}
+
+// loadNullable loads a nullable mode; an empty value means null.
+func (m *Modes) loadNullable(key string, def *uint8) *uint8 {
+	data, err := m.storage.Load(key)
+	if err != nil {
+		return def
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	v := data[0]
+	return &v
+}
+
+// storeNullable persists a nullable mode.
+func (m *Modes) storeNullable(key string, v *uint8) {
+	if m.storage == nil {
+		return
+	}
+	var data []byte
+	if v != nil {
+		data = []byte{*v}
+	}
+	_ = m.storage.Store(key, data)
+}
+
+// SupportedModes returns the supported mode list.
+func (m *Modes) SupportedModes() []ModeOption {
+	return m.modes
+}
+
+// Supports returns true if mode is in the supported mode list.
+func (m *Modes) Supports(mode uint8) bool {
+	for _, o := range m.modes {
+		if o.Mode == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// Current returns CurrentMode.
+func (m *Modes) Current() uint8 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.currentMode
+}
+
+// SetCurrent sets CurrentMode. Returns true if the value changed.
+func (m *Modes) SetCurrent(mode uint8) (bool, error) {
+	if !m.Supports(mode) {
+		return false, ErrUnsupportedMode
+	}
+
+	m.mu.Lock()
+	changed := m.currentMode != mode
+	m.currentMode = mode
+	m.mu.Unlock()
+
+	if changed && m.storage != nil {
+		_ = m.storage.Store(keyCurrentMode, []byte{mode})
+	}
+	return changed, nil
+}
+
+// StartUpMode returns StartUpMode, or nil if null.
+func (m *Modes) StartUpMode() *uint8 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m8(m.startUpMode)
+}
+
+// SetStartUpMode sets StartUpMode; nil sets it to null.
+func (m *Modes) SetStartUpMode(mode *uint8) error {
+	if mode != nil && !m.Supports(*mode) {
+		return ErrUnsupportedMode
+	}
+
+	m.mu.Lock()
+	m.startUpMode = m8(mode)
+	m.mu.Unlock()
+
+	m.storeNullable(keyStartUpMode, mode)
+	return nil
+}
+
+// OnMode returns OnMode, or nil if null.
+func (m *Modes) OnMode() *uint8 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m8(m.onMode)
+}
+
+// SetOnMode sets OnMode; nil sets it to null.
+func (m *Modes) SetOnMode(mode *uint8) error {
+	if mode != nil && !m.Supports(*mode) {
+		return ErrUnsupportedMode
+	}
+
+	m.mu.Lock()
+	m.onMode = m8(mode)
+	m.mu.Unlock()
+
+	m.storeNullable(keyOnMode, mode)
+	return nil
+}
+
+// MarshalModeOptions writes a list of ModeOptionStruct. Mode Select's
+// SemanticTagStruct requires MfgCode (0 for standard tags), while Mode
+// Base's ModeTagStruct omits it for standard tags; mandatoryMfgCode
+// selects the former.
+func MarshalModeOptions(w *tlv.Writer, modes []ModeOption, mandatoryMfgCode bool) error {
+	if err := w.StartArray(tlv.Anonymous()); err != nil {
+		return err
+	}
+	for _, o := range modes {
+		if err := w.StartStructure(tlv.Anonymous()); err != nil {
+			return err
+		}
+		if err := w.PutString(tlv.ContextTag(0), o.Label); err != nil {
+			return err
+		}
+		if err := w.PutUint(tlv.ContextTag(1), uint64(o.Mode)); err != nil {
+			return err
+		}
+		if err := w.StartArray(tlv.ContextTag(2)); err != nil {
+			return err
+		}
+		for _, tag := range o.ModeTags {
+			if err := w.StartStructure(tlv.Anonymous()); err != nil {
+				return err
+			}
+			if tag.MfgCode != nil {
+				if err := w.PutUint(tlv.ContextTag(0), uint64(*tag.MfgCode)); err != nil {
+					return err
+				}
+			} else if mandatoryMfgCode {
+				if err := w.PutUint(tlv.ContextTag(0), 0); err != nil {
+					return err
+				}
+			}
+			if err := w.PutUint(tlv.ContextTag(1), uint64(tag.Value)); err != nil {
+				return err
+			}
+			if err := w.EndContainer(); err != nil {
+				return err
+			}
+		}
+		if err := w.EndContainer(); err != nil {
+			return err
+		}
+		if err := w.EndContainer(); err != nil {
+			return err
+		}
+	}
+	return w.EndContainer()
+}
+
+// PutNullableMode writes a nullable mode value.
+func PutNullableMode(w *tlv.Writer, v *uint8) error {
+	return datamodel.NullableFromPtr(v).MarshalTLV(w)
+}
+
+// ReadNullableMode reads a nullable mode value from an attribute write.
+// 0xFF is reserved for null and is not a mode.
+func ReadNullableMode(r *tlv.Reader) (*uint8, error) {
+	mode, err := datamodel.DecodeNullable[uint8](r, datamodel.AttrQualityNullable)
+	if errors.Is(err, datamodel.ErrConstraintError) {
+		return nil, ErrUnsupportedMode
+	}
+	if err != nil {
+		return nil, err
+	}
+	return mode.Ptr(), nil
+}
+
+// m8 copies a nullable mode.
+func m8(v *uint8) *uint8 {
+	if v == nil {
+		return nil
+	}
+	n := *v
+	return &n
+}
@@ -0,0 +1,298 @@
+// Package modeselect implements the Mode Select Cluster (0x0050).
+//
+// Mode Select exposes a manufacturer-defined list of modes, optionally
+// tagged from a standard namespace. It shares mode list validation and
+// CurrentMode/StartUpMode/OnMode state with the Mode Base clusters in
+// package modebase, but uses its own attribute layout and answers
+// ChangeToMode with a plain status instead of a response command.
+//
+// Spec Reference: Section 1.9
+//
+// C++ Reference: src/app/clusters/mode-select-server/mode-select-server.cpp
+package modeselect
+
+import (
+	"context"
+	"errors"
+
+	"github.com/backkem/matter/pkg/clusters/modebase"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x0050
+	ClusterRevision uint16              = 2
+)
+
+// Attribute IDs (Spec 1.9.6).
+const (
+	AttrDescription       datamodel.AttributeID = 0x0000
+	AttrStandardNamespace datamodel.AttributeID = 0x0001
+	AttrSupportedModes    datamodel.AttributeID = 0x0002
+	AttrCurrentMode       datamodel.AttributeID = 0x0003
+	AttrStartUpMode       datamodel.AttributeID = 0x0004
+	AttrOnMode            datamodel.AttributeID = 0x0005
+)
+
+// Command IDs (Spec 1.9.7).
+const (
+	CmdChangeToMode datamodel.CommandID = 0x00
+)
+
+// Feature bits (Spec 1.9.4).
+type Feature uint32
+
+const (
+	// FeatureOnOff enables the OnMode attribute (DEPONOFF).
+	FeatureOnOff Feature = 1 << 0
+)
+
+// ErrInvalidDescription is returned when Description exceeds 64 bytes.
+var ErrInvalidDescription = errors.New("modeselect: description too long")
+
+// Config provides dependencies for the Mode Select cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to.
+	EndpointID datamodel.EndpointID
+
+	// FeatureMap indicates supported features.
+	FeatureMap Feature
+
+	// Description is a human readable description of the cluster instance.
+	Description string
+
+	// StandardNamespace is the semantic tag namespace of the modes' tags
+	// (nullable).
+	StandardNamespace *uint16
+
+	// Modes is the list of supported modes. Tags are SemanticTags from
+	// StandardNamespace or manufacturer tags.
+	Modes []modebase.ModeOption
+
+	// InitialMode is CurrentMode if nothing is persisted and StartUpMode is null.
+	InitialMode uint8
+
+	// StartUpMode enables the StartUpMode attribute with this initial value.
+	// Use EnableStartUpMode to expose it while null.
+	StartUpMode *uint8
+
+	// EnableStartUpMode exposes the StartUpMode attribute.
+	EnableStartUpMode bool
+
+	// OnMode is the initial OnMode value (nullable, FeatureOnOff).
+	OnMode *uint8
+
+	// Storage for persisting state (optional).
+	Storage modebase.Storage
+
+	// OnModeChange is called when CurrentMode changes (optional).
+	OnModeChange func(endpoint datamodel.EndpointID, mode uint8)
+}
+
+// Cluster implements the Mode Select cluster (0x0050).
+type Cluster struct {
+	*datamodel.ClusterBase
+	config Config
+	modes  *modebase.Modes
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Mode Select cluster. It returns an error if the mode
+// list or description is invalid.
+func New(cfg Config) (*Cluster, error) {
+	if len(cfg.Description) > modebase.MaxLabelLength {
+		return nil, ErrInvalidDescription
+	}
+	if err := modebase.ValidateModes(cfg.Modes, nil); err != nil {
+		return nil, err
+	}
+	if cfg.StartUpMode != nil {
+		cfg.EnableStartUpMode = true
+	}
+	if cfg.FeatureMap&FeatureOnOff == 0 {
+		cfg.OnMode = nil
+	}
+
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		config:      cfg,
+		modes: modebase.NewModes(modebase.ModesConfig{
+			Modes:       cfg.Modes,
+			InitialMode: cfg.InitialMode,
+			StartUpMode: cfg.StartUpMode,
+			OnMode:      cfg.OnMode,
+			Storage:     cfg.Storage,
+		}),
+	}
+
+	c.ClusterBase.SetFeatureMap(uint32(cfg.FeatureMap))
+
+	c.attrList = c.buildAttributeList()
+
+	return c, nil
+}
+
+// buildAttributeList constructs the list of supported attributes.
+func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+	operatePriv := datamodel.PrivilegeOperate
+	nullable := datamodel.AttrQualityNullable | datamodel.AttrQualityNonVolatile
+
+	attrs := []datamodel.AttributeEntry{
+		datamodel.NewReadOnlyAttribute(AttrDescription, datamodel.AttrQualityFixed, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrStandardNamespace, datamodel.AttrQualityNullable|datamodel.AttrQualityFixed, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrSupportedModes, datamodel.AttrQualityList|datamodel.AttrQualityFixed, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrCurrentMode, datamodel.AttrQualityNonVolatile|datamodel.AttrQualityScene, viewPriv),
+	}
+
+	if c.config.EnableStartUpMode {
+		attrs = append(attrs, datamodel.NewReadWriteAttribute(AttrStartUpMode, nullable, viewPriv, operatePriv))
+	}
+	if c.config.FeatureMap&FeatureOnOff != 0 {
+		attrs = append(attrs, datamodel.NewReadWriteAttribute(AttrOnMode, nullable, viewPriv, operatePriv))
+	}
+
+	return datamodel.MergeAttributeLists(attrs)
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	return []datamodel.CommandEntry{
+		datamodel.NewCommandEntry(CmdChangeToMode, 0, datamodel.PrivilegeOperate),
+	}
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return nil
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	switch req.Path.Attribute {
+	case AttrDescription:
+		return w.PutString(tlv.Anonymous(), c.config.Description)
+	case AttrStandardNamespace:
+		if c.config.StandardNamespace == nil {
+			return w.PutNull(tlv.Anonymous())
+		}
+		return w.PutUint(tlv.Anonymous(), uint64(*c.config.StandardNamespace))
+	case AttrSupportedModes:
+		return modebase.MarshalModeOptions(w, c.modes.SupportedModes(), true)
+	case AttrCurrentMode:
+		return w.PutUint(tlv.Anonymous(), uint64(c.modes.Current()))
+	case AttrStartUpMode:
+		if !c.config.EnableStartUpMode {
+			return datamodel.ErrUnsupportedAttribute
+		}
+		return modebase.PutNullableMode(w, c.modes.StartUpMode())
+	case AttrOnMode:
+		if c.config.FeatureMap&FeatureOnOff == 0 {
+			return datamodel.ErrUnsupportedAttribute
+		}
+		return modebase.PutNullableMode(w, c.modes.OnMode())
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	var set func(*uint8) error
+	switch req.Path.Attribute {
+	case AttrStartUpMode:
+		if !c.config.EnableStartUpMode {
+			return datamodel.ErrUnsupportedWrite
+		}
+		set = c.modes.SetStartUpMode
+	case AttrOnMode:
+		if c.config.FeatureMap&FeatureOnOff == 0 {
+			return datamodel.ErrUnsupportedWrite
+		}
+		set = c.modes.SetOnMode
+	default:
+		return datamodel.ErrUnsupportedWrite
+	}
+
+	mode, err := modebase.ReadNullableMode(r)
+	if err != nil {
+		if errors.Is(err, modebase.ErrUnsupportedMode) {
+			return datamodel.ErrConstraintError
+		}
+		return err
+	}
+	if err := set(mode); err != nil {
+		return datamodel.ErrConstraintError
+	}
+
+	c.IncrementDataVersion()
+	return nil
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	switch req.Path.Command {
+	case CmdChangeToMode:
+		newMode, err := modebase.DecodeChangeToMode(r)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.ChangeToMode(newMode); err != nil {
+			return nil, datamodel.ErrConstraintError
+		}
+		return nil, nil
+	default:
+		return nil, datamodel.ErrUnsupportedCommand
+	}
+}
+
+// CurrentMode returns CurrentMode.
+func (c *Cluster) CurrentMode() uint8 {
+	return c.modes.Current()
+}
+
+// ChangeToMode sets CurrentMode. Returns modebase.ErrUnsupportedMode if
+// the mode is not in SupportedModes.
+//
+// Spec: Section 1.9.7.1
+func (c *Cluster) ChangeToMode(newMode uint8) error {
+	changed, err := c.modes.SetCurrent(newMode)
+	if err != nil {
+		return err
+	}
+	if changed {
+		c.IncrementDataVersion()
+		if c.config.OnModeChange != nil {
+			c.config.OnModeChange(c.config.EndpointID, newMode)
+		}
+	}
+	return nil
+}
+
+// ApplyOnMode sets CurrentMode to OnMode, if non-null. Call it when the
+// On/Off cluster on the same endpoint turns on (FeatureOnOff).
+//
+// Spec: Section 1.9.6.6
+func (c *Cluster) ApplyOnMode() {
+	if c.config.FeatureMap&FeatureOnOff == 0 {
+		return
+	}
+	if mode := c.modes.OnMode(); mode != nil {
+		_ = c.ChangeToMode(*mode)
+	}
+}
@@ -0,0 +1,148 @@
+package modeselect
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/backkem/matter/pkg/clusters/modebase"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+func u8p(v uint8) *uint8 { return &v }
+
+func testModes() []modebase.ModeOption {
+	return []modebase.ModeOption{
+		{Label: "Black", Mode: 0, ModeTags: []modebase.ModeTag{{Value: 0}}},
+		{Label: "Cappuccino", Mode: 4, ModeTags: []modebase.ModeTag{{Value: 0}}},
+		{Label: "Espresso", Mode: 7, ModeTags: []modebase.ModeTag{{Value: 1}}},
+	}
+}
+
+func invoke(c *Cluster, mode uint8) error {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	w.StartStructure(tlv.Anonymous())
+	w.PutUint(tlv.ContextTag(0), uint64(mode))
+	w.EndContainer()
+
+	req := datamodel.InvokeRequest{
+		Path: datamodel.ConcreteCommandPath{Endpoint: 1, Cluster: ClusterID, Command: CmdChangeToMode},
+	}
+	resp, err := c.InvokeCommand(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes())))
+	if resp != nil {
+		return errors.New("unexpected response payload")
+	}
+	return err
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(Config{EndpointID: 1}); !errors.Is(err, modebase.ErrNoModes) {
+		t.Errorf("no modes: err = %v, want ErrNoModes", err)
+	}
+	long := string(bytes.Repeat([]byte("x"), 65))
+	if _, err := New(Config{EndpointID: 1, Description: long, Modes: testModes()}); !errors.Is(err, ErrInvalidDescription) {
+		t.Errorf("long description: err = %v, want ErrInvalidDescription", err)
+	}
+}
+
+func TestChangeToMode(t *testing.T) {
+	var notified []uint8
+	c, err := New(Config{
+		EndpointID:  1,
+		Description: "Coffee",
+		Modes:       testModes(),
+		InitialMode: 4,
+		OnModeChange: func(_ datamodel.EndpointID, mode uint8) {
+			notified = append(notified, mode)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.CurrentMode() != 4 {
+		t.Errorf("CurrentMode = %d, want 4", c.CurrentMode())
+	}
+
+	if err := invoke(c, 3); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("unsupported mode: err = %v, want ErrConstraintError", err)
+	}
+	if err := invoke(c, 7); err != nil {
+		t.Errorf("ChangeToMode(7) failed: %v", err)
+	}
+	if c.CurrentMode() != 7 || len(notified) != 1 {
+		t.Errorf("CurrentMode = %d, notified = %v; want 7, [7]", c.CurrentMode(), notified)
+	}
+}
+
+func TestAttributes(t *testing.T) {
+	ns := uint16(0x0041)
+	c, err := New(Config{
+		EndpointID:        1,
+		FeatureMap:        FeatureOnOff,
+		Description:       "Coffee",
+		StandardNamespace: &ns,
+		Modes:             testModes(),
+		OnMode:            u8p(7),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	has := make(map[datamodel.AttributeID]bool)
+	for _, a := range c.AttributeList() {
+		has[a.ID] = true
+	}
+	if !has[AttrOnMode] || has[AttrStartUpMode] {
+		t.Errorf("OnMode present = %v, StartUpMode present = %v; want true, false", has[AttrOnMode], has[AttrStartUpMode])
+	}
+
+	read := func(attr datamodel.AttributeID) *tlv.Reader {
+		var buf bytes.Buffer
+		req := datamodel.ReadAttributeRequest{
+			Path: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: attr},
+		}
+		if err := c.ReadAttribute(context.Background(), req, tlv.NewWriter(&buf)); err != nil {
+			t.Fatalf("read 0x%04X failed: %v", attr, err)
+		}
+		r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+		r.Next()
+		return r
+	}
+
+	if s, _ := read(AttrDescription).String(); s != "Coffee" {
+		t.Errorf("Description = %q", s)
+	}
+	if v, _ := read(AttrStandardNamespace).Uint(); v != 0x0041 {
+		t.Errorf("StandardNamespace = 0x%04X", v)
+	}
+
+	// SemanticTagStruct always carries MfgCode.
+	r := read(AttrSupportedModes)
+	r.EnterContainer() // list
+	r.Next()
+	r.EnterContainer() // first ModeOptionStruct
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		if r.Tag().TagNumber() != 2 {
+			continue
+		}
+		r.EnterContainer() // SemanticTags
+		r.Next()
+		r.EnterContainer() // first tag
+		r.Next()
+		if !r.Tag().IsContext() || r.Tag().TagNumber() != 0 {
+			t.Error("semantic tag should start with MfgCode")
+		}
+		break
+	}
+
+	c.ApplyOnMode()
+	if c.CurrentMode() != 7 {
+		t.Errorf("CurrentMode after ApplyOnMode = %d, want 7", c.CurrentMode())
+	}
+}
@@ -0,0 +1,407 @@
+// Package occupancysensing implements the Occupancy Sensing Cluster (0x0406).
+//
+// The device reports detections via SetOccupied. With HoldTime configured,
+// the cluster keeps reporting occupied for HoldTime seconds after the last
+// detection before falling back to unoccupied, and emits OccupancyChanged
+// on every transition.
+//
+// Spec Reference: Section 2.7
+//
+// C++ Reference: src/app/clusters/occupancy-sensor-server
+package occupancysensing
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x0406
+	ClusterRevision uint16              = 5
+)
+
+// Attribute IDs (Spec 2.7.6).
+const (
+	AttrOccupancy                 datamodel.AttributeID = 0x0000
+	AttrOccupancySensorType       datamodel.AttributeID = 0x0001
+	AttrOccupancySensorTypeBitmap datamodel.AttributeID = 0x0002
+	AttrHoldTime                  datamodel.AttributeID = 0x0003
+	AttrHoldTimeLimits            datamodel.AttributeID = 0x0004
+)
+
+// Event IDs (Spec 2.7.7).
+const (
+	EventOccupancyChanged datamodel.EventID = 0x00
+)
+
+// Feature bits (Spec 2.7.4).
+type Feature uint32
+
+const (
+	// FeatureOther indicates a sensing technology not listed below (OTHER).
+	FeatureOther Feature = 1 << 0
+
+	// FeaturePassiveInfrared indicates a PIR sensor (PIR).
+	FeaturePassiveInfrared Feature = 1 << 1
+
+	// FeatureUltrasonic indicates an ultrasonic sensor (US).
+	FeatureUltrasonic Feature = 1 << 2
+
+	// FeaturePhysicalContact indicates a physical contact sensor (PHY).
+	FeaturePhysicalContact Feature = 1 << 3
+
+	// FeatureActiveInfrared indicates an active infrared sensor (AIR).
+	FeatureActiveInfrared Feature = 1 << 4
+
+	// FeatureRadar indicates a radar sensor (RAD).
+	FeatureRadar Feature = 1 << 5
+
+	// FeatureRFSensing indicates an RF sensing sensor (RFS).
+	FeatureRFSensing Feature = 1 << 6
+
+	// FeatureVision indicates a vision-based sensor (VIS).
+	FeatureVision Feature = 1 << 7
+)
+
+// OccupancySensorType is the legacy sensor type enum (Spec 2.7.5.3).
+type OccupancySensorType uint8
+
+const (
+	OccupancySensorTypePIR              OccupancySensorType = 0
+	OccupancySensorTypeUltrasonic       OccupancySensorType = 1
+	OccupancySensorTypePIRAndUltrasonic OccupancySensorType = 2
+	OccupancySensorTypePhysicalContact  OccupancySensorType = 3
+)
+
+// OccupancySensorTypeBitmap bits (Spec 2.7.5.2).
+const (
+	SensorTypeBitmapPIR             uint8 = 1 << 0
+	SensorTypeBitmapUltrasonic      uint8 = 1 << 1
+	SensorTypeBitmapPhysicalContact uint8 = 1 << 2
+)
+
+// HoldTimeLimits bounds the HoldTime attribute, in seconds (Spec 2.7.5.4).
+type HoldTimeLimits struct {
+	Min     uint16
+	Max     uint16
+	Default uint16
+}
+
+// Config provides dependencies for the Occupancy Sensing cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to.
+	EndpointID datamodel.EndpointID
+
+	// FeatureMap indicates the sensing technologies. At least one bit
+	// must be set; defaults to FeatureOther if zero.
+	FeatureMap Feature
+
+	// HoldTimeLimits enables the HoldTime and HoldTimeLimits attributes
+	// (optional). HoldTime starts at Default.
+	HoldTimeLimits *HoldTimeLimits
+
+	// EventPublisher for OccupancyChanged events.
+	// Optional - if nil, events are not emitted.
+	EventPublisher datamodel.EventPublisher
+}
+
+// Cluster implements the Occupancy Sensing cluster (0x0406).
+type Cluster struct {
+	*datamodel.ClusterBase
+	*datamodel.EventSource
+	config Config
+
+	// Mutable state (protected by mutex)
+	mu        sync.Mutex
+	occupied  bool
+	holdTime  uint16
+	holdTimer *time.Timer
+
+	// holdUnit is the duration of one HoldTime unit; overridden in tests.
+	holdUnit time.Duration
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Occupancy Sensing cluster.
+func New(cfg Config) *Cluster {
+	if cfg.FeatureMap == 0 {
+		cfg.FeatureMap = FeatureOther
+	}
+
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		EventSource: datamodel.NewEventSource(),
+		config:      cfg,
+		holdUnit:    time.Second,
+	}
+	if cfg.HoldTimeLimits != nil {
+		c.holdTime = cfg.HoldTimeLimits.Default
+	}
+
+	c.ClusterBase.SetFeatureMap(uint32(cfg.FeatureMap))
+
+	// Bind event source
+	if cfg.EventPublisher != nil {
+		c.EventSource.Bind(cfg.EndpointID, ClusterID, cfg.EventPublisher)
+		c.EventSource.RegisterEvent(datamodel.NewEventEntry(
+			EventOccupancyChanged,
+			datamodel.EventPriorityInfo,
+			datamodel.PrivilegeView,
+			false,
+		))
+	}
+
+	c.attrList = c.buildAttributeList()
+
+	return c
+}
+
+// hasFeature returns true if the feature bit is set.
+func (c *Cluster) hasFeature(f Feature) bool {
+	return c.config.FeatureMap&f != 0
+}
+
+// buildAttributeList constructs the list of supported attributes.
+func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+
+	attrs := []datamodel.AttributeEntry{
+		datamodel.NewReadOnlyAttribute(AttrOccupancy, 0, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrOccupancySensorType, datamodel.AttrQualityFixed, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrOccupancySensorTypeBitmap, datamodel.AttrQualityFixed, viewPriv),
+	}
+
+	if c.config.HoldTimeLimits != nil {
+		attrs = append(attrs,
+			datamodel.NewReadWriteAttribute(AttrHoldTime, 0, viewPriv, datamodel.PrivilegeManage),
+			datamodel.NewReadOnlyAttribute(AttrHoldTimeLimits, 0, viewPriv),
+		)
+	}
+
+	return datamodel.MergeAttributeLists(attrs)
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	// Occupancy Sensing cluster has no commands
+	return nil
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return nil
+}
+
+// sensorTypeBitmap derives OccupancySensorTypeBitmap from the feature map.
+//
+// Spec: Section 2.7.6.3
+func (c *Cluster) sensorTypeBitmap() uint8 {
+	var bitmap uint8
+	if c.hasFeature(FeaturePassiveInfrared) {
+		bitmap |= SensorTypeBitmapPIR
+	}
+	if c.hasFeature(FeatureUltrasonic) {
+		bitmap |= SensorTypeBitmapUltrasonic
+	}
+	if c.hasFeature(FeaturePhysicalContact) {
+		bitmap |= SensorTypeBitmapPhysicalContact
+	}
+	return bitmap
+}
+
+// sensorType derives the legacy OccupancySensorType from the feature map.
+//
+// Spec: Section 2.7.6.2
+func (c *Cluster) sensorType() OccupancySensorType {
+	pir := c.hasFeature(FeaturePassiveInfrared)
+	us := c.hasFeature(FeatureUltrasonic)
+	switch {
+	case pir && us:
+		return OccupancySensorTypePIRAndUltrasonic
+	case us:
+		return OccupancySensorTypeUltrasonic
+	case c.hasFeature(FeaturePhysicalContact) && !pir:
+		return OccupancySensorTypePhysicalContact
+	default:
+		return OccupancySensorTypePIR
+	}
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	switch req.Path.Attribute {
+	case AttrOccupancy:
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return w.PutUint(tlv.Anonymous(), occupancyBitmap(c.occupied))
+
+	case AttrOccupancySensorType:
+		return w.PutUint(tlv.Anonymous(), uint64(c.sensorType()))
+
+	case AttrOccupancySensorTypeBitmap:
+		return w.PutUint(tlv.Anonymous(), uint64(c.sensorTypeBitmap()))
+
+	case AttrHoldTime:
+		if c.config.HoldTimeLimits == nil {
+			return datamodel.ErrUnsupportedAttribute
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return w.PutUint(tlv.Anonymous(), uint64(c.holdTime))
+
+	case AttrHoldTimeLimits:
+		limits := c.config.HoldTimeLimits
+		if limits == nil {
+			return datamodel.ErrUnsupportedAttribute
+		}
+		if err := w.StartStructure(tlv.Anonymous()); err != nil {
+			return err
+		}
+		if err := w.PutUint(tlv.ContextTag(0), uint64(limits.Min)); err != nil {
+			return err
+		}
+		if err := w.PutUint(tlv.ContextTag(1), uint64(limits.Max)); err != nil {
+			return err
+		}
+		if err := w.PutUint(tlv.ContextTag(2), uint64(limits.Default)); err != nil {
+			return err
+		}
+		return w.EndContainer()
+
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	if req.Path.Attribute != AttrHoldTime || c.config.HoldTimeLimits == nil {
+		return datamodel.ErrUnsupportedWrite
+	}
+
+	if err := r.Next(); err != nil {
+		return err
+	}
+	val, err := r.Uint()
+	if err != nil {
+		return err
+	}
+	limits := c.config.HoldTimeLimits
+	if val < uint64(limits.Min) || val > uint64(limits.Max) {
+		return datamodel.ErrConstraintError
+	}
+
+	c.mu.Lock()
+	c.holdTime = uint16(val)
+	c.mu.Unlock()
+
+	c.IncrementDataVersion()
+	return nil
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	return nil, datamodel.ErrUnsupportedCommand
+}
+
+// Occupied returns the current Occupancy state.
+func (c *Cluster) Occupied() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.occupied
+}
+
+// SetOccupied reports the raw sensor state.
+//
+// A detection takes effect immediately. Loss of detection is delayed by
+// HoldTime, and is cancelled by a new detection within that window.
+//
+// Spec: Section 2.7.6.1, 2.7.6.4
+func (c *Cluster) SetOccupied(occupied bool) error {
+	c.mu.Lock()
+	if c.holdTimer != nil {
+		c.holdTimer.Stop()
+		c.holdTimer = nil
+	}
+	if !occupied && c.occupied && c.holdTime > 0 {
+		c.holdTimer = time.AfterFunc(time.Duration(c.holdTime)*c.holdUnit, c.holdExpired)
+		c.mu.Unlock()
+		return nil
+	}
+	c.mu.Unlock()
+
+	return c.setOccupancy(occupied)
+}
+
+// holdExpired clears Occupancy once HoldTime has passed without detection.
+func (c *Cluster) holdExpired() {
+	c.mu.Lock()
+	c.holdTimer = nil
+	c.mu.Unlock()
+
+	_ = c.setOccupancy(false)
+}
+
+// setOccupancy updates Occupancy and emits OccupancyChanged on change.
+func (c *Cluster) setOccupancy(occupied bool) error {
+	c.mu.Lock()
+	if c.occupied == occupied {
+		c.mu.Unlock()
+		return nil
+	}
+	c.occupied = occupied
+	c.mu.Unlock()
+
+	c.IncrementDataVersion()
+
+	if !c.EventSource.IsBound() {
+		return nil
+	}
+	_, err := c.EventSource.Emit(EventOccupancyChanged, datamodel.EventPriorityInfo, OccupancyChangedEvent{
+		Occupancy: uint8(occupancyBitmap(occupied)),
+	})
+	return err
+}
+
+// occupancyBitmap encodes the Occupancy bitmap (bit 0: occupied).
+func occupancyBitmap(occupied bool) uint64 {
+	if occupied {
+		return 1
+	}
+	return 0
+}
+
+// OccupancyChangedEvent is emitted when Occupancy changes (Spec 2.7.7.1).
+// Priority: INFO
+type OccupancyChangedEvent struct {
+	Occupancy uint8
+}
+
+// MarshalTLV implements the TLVMarshaler interface.
+func (e OccupancyChangedEvent) MarshalTLV(w *tlv.Writer) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(e.Occupancy)); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
@@ -0,0 +1,167 @@
+package occupancysensing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// mockEventPublisher implements EventPublisher for testing.
+type mockEventPublisher struct {
+	mu     sync.Mutex
+	events []OccupancyChangedEvent
+}
+
+func (m *mockEventPublisher) PublishEvent(
+	endpoint datamodel.EndpointID,
+	cluster datamodel.ClusterID,
+	eventID datamodel.EventID,
+	priority datamodel.EventPriority,
+	data interface{},
+	fabricIndex uint8,
+) (datamodel.EventNumber, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, data.(OccupancyChangedEvent))
+	return datamodel.EventNumber(len(m.events)), nil
+}
+
+func (m *mockEventPublisher) count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.events)
+}
+
+func readUint(t *testing.T, c *Cluster, attr datamodel.AttributeID) uint64 {
+	t.Helper()
+	var buf bytes.Buffer
+	req := datamodel.ReadAttributeRequest{
+		Path: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: attr},
+	}
+	if err := c.ReadAttribute(context.Background(), req, tlv.NewWriter(&buf)); err != nil {
+		t.Fatalf("ReadAttribute(0x%04X) failed: %v", attr, err)
+	}
+	r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+	if err := r.Next(); err != nil {
+		t.Fatalf("failed to read value: %v", err)
+	}
+	v, err := r.Uint()
+	if err != nil {
+		t.Fatalf("failed to decode uint: %v", err)
+	}
+	return v
+}
+
+func TestSensorType(t *testing.T) {
+	tests := []struct {
+		features Feature
+		typ      OccupancySensorType
+		bitmap   uint8
+	}{
+		{FeaturePassiveInfrared, OccupancySensorTypePIR, SensorTypeBitmapPIR},
+		{FeatureUltrasonic, OccupancySensorTypeUltrasonic, SensorTypeBitmapUltrasonic},
+		{FeaturePassiveInfrared | FeatureUltrasonic, OccupancySensorTypePIRAndUltrasonic, SensorTypeBitmapPIR | SensorTypeBitmapUltrasonic},
+		{FeaturePhysicalContact, OccupancySensorTypePhysicalContact, SensorTypeBitmapPhysicalContact},
+		{FeatureRadar, OccupancySensorTypePIR, 0},
+	}
+	for _, tt := range tests {
+		c := New(Config{EndpointID: 1, FeatureMap: tt.features})
+		if got := readUint(t, c, AttrOccupancySensorType); got != uint64(tt.typ) {
+			t.Errorf("features 0x%02X: OccupancySensorType = %d, want %d", tt.features, got, tt.typ)
+		}
+		if got := readUint(t, c, AttrOccupancySensorTypeBitmap); got != uint64(tt.bitmap) {
+			t.Errorf("features 0x%02X: OccupancySensorTypeBitmap = 0x%02X, want 0x%02X", tt.features, got, tt.bitmap)
+		}
+	}
+}
+
+func TestSetOccupied_Events(t *testing.T) {
+	pub := &mockEventPublisher{}
+	c := New(Config{EndpointID: 1, FeatureMap: FeaturePassiveInfrared, EventPublisher: pub})
+
+	c.SetOccupied(true)
+	c.SetOccupied(true)
+	if readUint(t, c, AttrOccupancy) != 1 {
+		t.Error("Occupancy should be 1")
+	}
+	c.SetOccupied(false)
+	if c.Occupied() {
+		t.Error("Occupancy should clear immediately without HoldTime")
+	}
+	if pub.count() != 2 {
+		t.Errorf("got %d events, want 2", pub.count())
+	}
+}
+
+func TestSetOccupied_HoldTime(t *testing.T) {
+	c := New(Config{
+		EndpointID:     1,
+		FeatureMap:     FeaturePassiveInfrared,
+		HoldTimeLimits: &HoldTimeLimits{Min: 1, Max: 300, Default: 2},
+	})
+	c.holdUnit = 10 * time.Millisecond
+
+	c.SetOccupied(true)
+	c.SetOccupied(false)
+	if !c.Occupied() {
+		t.Fatal("Occupancy should be held")
+	}
+
+	// A new detection cancels the pending clear.
+	c.SetOccupied(true)
+	time.Sleep(40 * time.Millisecond)
+	if !c.Occupied() {
+		t.Fatal("Occupancy cleared despite new detection")
+	}
+
+	c.SetOccupied(false)
+	time.Sleep(60 * time.Millisecond)
+	if c.Occupied() {
+		t.Error("Occupancy should clear after HoldTime")
+	}
+}
+
+func TestWriteHoldTime(t *testing.T) {
+	c := New(Config{
+		EndpointID:     1,
+		HoldTimeLimits: &HoldTimeLimits{Min: 10, Max: 300, Default: 30},
+	})
+
+	write := func(val uint64) error {
+		var buf bytes.Buffer
+		w := tlv.NewWriter(&buf)
+		if err := w.PutUint(tlv.Anonymous(), val); err != nil {
+			t.Fatal(err)
+		}
+		req := datamodel.WriteAttributeRequest{
+			Path: datamodel.ConcreteDataAttributePath{
+				ConcreteAttributePath: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: AttrHoldTime},
+			},
+		}
+		return c.WriteAttribute(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes())))
+	}
+
+	if got := readUint(t, c, AttrHoldTime); got != 30 {
+		t.Errorf("HoldTime = %d, want 30", got)
+	}
+	if err := write(5); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("write 5: err = %v, want ErrConstraintError", err)
+	}
+	if err := write(60); err != nil {
+		t.Fatalf("write 60 failed: %v", err)
+	}
+	if got := readUint(t, c, AttrHoldTime); got != 60 {
+		t.Errorf("HoldTime = %d, want 60", got)
+	}
+
+	c = New(Config{EndpointID: 1})
+	if err := write(60); !errors.Is(err, datamodel.ErrUnsupportedWrite) {
+		t.Errorf("write without HoldTime: err = %v, want ErrUnsupportedWrite", err)
+	}
+}
@@ -11,7 +11,9 @@ package onoff
 import (
 	"context"
 	"sync"
+	"time"
 
+	"github.com/backkem/matter/pkg/clusters"
 	"github.com/backkem/matter/pkg/datamodel"
 	"github.com/backkem/matter/pkg/tlv"
 )
@@ -110,12 +112,7 @@ func (e EffectIdentifier) String() string {
 }
 
 // Storage provides persistence for On/Off cluster state.
-type Storage interface {
-	// Load retrieves a value by key.
-	Load(key string) ([]byte, error)
-	// Store persists a value.
-	Store(key string, value []byte) error
-}
+type Storage = datamodel.AttributeStorage
 
 // StateChangeCallback is called when the on/off state changes.
 type StateChangeCallback func(endpoint datamodel.EndpointID, newState bool)
@@ -146,97 +143,83 @@ type Cluster struct {
 
 	// Mutable state (protected by mutex)
 	mu    sync.RWMutex
-	onOff bool
+	onOff *datamodel.PersistedAttribute[bool]
 
 	// Lighting feature attributes (LT)
 	globalSceneControl bool
 	onTime             uint16
 	offWaitTime        uint16
-	startUpOnOff       *StartUpOnOff // nullable
+	startUpOnOff       *datamodel.PersistedAttribute[*StartUpOnOff] // nullable
 
-	// Cached attribute list
+	// Cached attribute and command lists
 	attrList []datamodel.AttributeEntry
+	cmdList  []datamodel.CommandEntry
 }
 
 // New creates a new On/Off cluster.
 func New(cfg Config) *Cluster {
 	c := &Cluster{
-		ClusterBase:        datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		ClusterBase:        datamodel.NewClusterBaseWithFeatures(ClusterID, cfg.EndpointID, ClusterRevision, uint32(cfg.FeatureMap)),
 		config:             cfg,
-		onOff:              cfg.InitialOnOff,
 		globalSceneControl: true, // Default per spec
 		onTime:             0,
 		offWaitTime:        0,
 	}
 
-	// Set feature map
-	c.ClusterBase.SetFeatureMap(uint32(cfg.FeatureMap))
-
-	// Load persisted state if storage available
-	if cfg.Storage != nil {
-		c.loadPersistedState()
-	}
-
-	// Build attribute list
+	// Persisted state, loaded if storage is available
+	path := datamodel.ConcreteAttributePath{Endpoint: cfg.EndpointID, Cluster: ClusterID}
+	path.Attribute = AttrOnOff
+	c.onOff = datamodel.NewPersistedAttribute(datamodel.PersistedAttributeConfig[bool]{
+		Storage: cfg.Storage,
+		Path:    path,
+		Default: cfg.InitialOnOff,
+	})
+	path.Attribute = AttrStartUpOnOff
+	c.startUpOnOff = datamodel.NewPersistedAttribute(datamodel.PersistedAttributeConfig[*StartUpOnOff]{
+		Storage: cfg.Storage,
+		Path:    path,
+	})
+
+	// Build attribute and command lists
 	c.attrList = c.buildAttributeList()
+	c.cmdList = c.buildCommandList()
 
 	return c
 }
 
-// loadPersistedState loads state from storage.
-func (c *Cluster) loadPersistedState() {
-	if c.config.Storage == nil {
-		return
-	}
-
-	// Load OnOff
-	if data, err := c.config.Storage.Load("onoff"); err == nil && len(data) == 1 {
-		c.onOff = data[0] != 0
-	}
-
-	// Load StartUpOnOff if lighting feature enabled
-	if c.config.FeatureMap&FeatureLighting != 0 {
-		if data, err := c.config.Storage.Load("startupOnOff"); err == nil && len(data) == 1 {
-			val := StartUpOnOff(data[0])
-			c.startUpOnOff = &val
-		}
-	}
-}
-
-// saveOnOff persists the on/off state.
-func (c *Cluster) saveOnOff() {
-	if c.config.Storage == nil {
-		return
-	}
-	val := byte(0)
-	if c.onOff {
-		val = 1
-	}
-	_ = c.config.Storage.Store("onoff", []byte{val})
-}
-
 // buildAttributeList constructs the list of supported attributes.
 func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
 	viewPriv := datamodel.PrivilegeView
 	managePriv := datamodel.PrivilegeManage
+	lighting := uint32(FeatureLighting)
 
-	attrs := []datamodel.AttributeEntry{
+	return c.BuildAttributeList([]datamodel.AttributeEntry{
 		// Mandatory attribute
 		datamodel.NewReadOnlyAttribute(AttrOnOff, 0, viewPriv),
-	}
 
-	// Lighting feature attributes
-	if c.config.FeatureMap&FeatureLighting != 0 {
-		attrs = append(attrs,
-			datamodel.NewReadOnlyAttribute(AttrGlobalSceneControl, 0, viewPriv),
-			datamodel.NewReadWriteAttribute(AttrOnTime, 0, viewPriv, managePriv),
-			datamodel.NewReadWriteAttribute(AttrOffWaitTime, 0, viewPriv, managePriv),
-			datamodel.NewReadWriteAttribute(AttrStartUpOnOff, datamodel.AttrQualityNullable|datamodel.AttrQualityNonVolatile, viewPriv, managePriv),
-		)
-	}
+		// Lighting feature attributes
+		datamodel.NewReadOnlyAttribute(AttrGlobalSceneControl, 0, viewPriv).WithFeatures(lighting),
+		datamodel.NewReadWriteAttribute(AttrOnTime, 0, viewPriv, managePriv).WithFeatures(lighting),
+		datamodel.NewReadWriteAttribute(AttrOffWaitTime, 0, viewPriv, managePriv).WithFeatures(lighting),
+		datamodel.NewReadWriteAttribute(AttrStartUpOnOff, datamodel.AttrQualityNullable|datamodel.AttrQualityNonVolatile, viewPriv, managePriv).WithFeatures(lighting),
+	})
+}
+
+// buildCommandList constructs the list of accepted commands.
+func (c *Cluster) buildCommandList() []datamodel.CommandEntry {
+	operatePriv := datamodel.PrivilegeOperate
+	lighting := uint32(FeatureLighting)
 
-	// Add global attributes
-	return datamodel.MergeAttributeLists(attrs)
+	return c.BuildAcceptedCommandList([]datamodel.CommandEntry{
+		datamodel.NewCommandEntry(CmdOff, 0, operatePriv),
+		datamodel.NewCommandEntry(CmdOn, 0, operatePriv),
+		datamodel.NewCommandEntry(CmdToggle, 0, operatePriv),
+
+		// Lighting feature commands
+		datamodel.NewCommandEntry(CmdOffWithEffect, 0, operatePriv).WithFeatures(lighting),
+		datamodel.NewCommandEntry(CmdOnWithRecallGlobalScene, 0, operatePriv).WithFeatures(lighting),
+		datamodel.NewCommandEntry(CmdOnWithTimedOff, 0, operatePriv).WithFeatures(lighting),
+	})
 }
 
 // AttributeList implements datamodel.Cluster.
@@ -246,24 +229,7 @@ func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
 
 // AcceptedCommandList implements datamodel.Cluster.
 func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
-	operatePriv := datamodel.PrivilegeOperate
-
-	cmds := []datamodel.CommandEntry{
-		datamodel.NewCommandEntry(CmdOff, 0, operatePriv),
-		datamodel.NewCommandEntry(CmdOn, 0, operatePriv),
-		datamodel.NewCommandEntry(CmdToggle, 0, operatePriv),
-	}
-
-	// Lighting feature commands
-	if c.config.FeatureMap&FeatureLighting != 0 {
-		cmds = append(cmds,
-			datamodel.NewCommandEntry(CmdOffWithEffect, 0, operatePriv),
-			datamodel.NewCommandEntry(CmdOnWithRecallGlobalScene, 0, operatePriv),
-			datamodel.NewCommandEntry(CmdOnWithTimedOff, 0, operatePriv),
-		)
-	}
-
-	return cmds
+	return c.cmdList
 }
 
 // GeneratedCommandList implements datamodel.Cluster.
@@ -281,39 +247,29 @@ func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttribute
 		return err
 	}
 
+	// Attributes of features the cluster lacks are not in the list
+	if datamodel.FindAttribute(c.attrList, req.Path.Attribute) == nil {
+		return datamodel.ErrUnsupportedAttribute
+	}
+
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
 	switch req.Path.Attribute {
 	case AttrOnOff:
-		return w.PutBool(tlv.Anonymous(), c.onOff)
+		return w.PutBool(tlv.Anonymous(), c.onOff.Get())
 
 	case AttrGlobalSceneControl:
-		if c.config.FeatureMap&FeatureLighting == 0 {
-			return datamodel.ErrUnsupportedAttribute
-		}
 		return w.PutBool(tlv.Anonymous(), c.globalSceneControl)
 
 	case AttrOnTime:
-		if c.config.FeatureMap&FeatureLighting == 0 {
-			return datamodel.ErrUnsupportedAttribute
-		}
 		return w.PutUint(tlv.Anonymous(), uint64(c.onTime))
 
 	case AttrOffWaitTime:
-		if c.config.FeatureMap&FeatureLighting == 0 {
-			return datamodel.ErrUnsupportedAttribute
-		}
 		return w.PutUint(tlv.Anonymous(), uint64(c.offWaitTime))
 
 	case AttrStartUpOnOff:
-		if c.config.FeatureMap&FeatureLighting == 0 {
-			return datamodel.ErrUnsupportedAttribute
-		}
-		if c.startUpOnOff == nil {
-			return w.PutNull(tlv.Anonymous())
-		}
-		return w.PutUint(tlv.Anonymous(), uint64(*c.startUpOnOff))
+		return datamodel.NullableFromPtr(c.startUpOnOff.Get()).MarshalTLV(w)
 
 	default:
 		return datamodel.ErrUnsupportedAttribute
@@ -322,6 +278,10 @@ func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttribute
 
 // WriteAttribute implements datamodel.Cluster.
 func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	if datamodel.FindAttribute(c.attrList, req.Path.Attribute) == nil {
+		return datamodel.ErrUnsupportedWrite
+	}
+
 	switch req.Path.Attribute {
 	case AttrOnTime:
 		return c.writeOnTime(r)
@@ -336,10 +296,6 @@ func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttribu
 
 // writeOnTime handles writing the OnTime attribute.
 func (c *Cluster) writeOnTime(r *tlv.Reader) error {
-	if c.config.FeatureMap&FeatureLighting == 0 {
-		return datamodel.ErrUnsupportedWrite
-	}
-
 	if err := r.Next(); err != nil {
 		return err
 	}
@@ -357,16 +313,12 @@ func (c *Cluster) writeOnTime(r *tlv.Reader) error {
 	c.onTime = uint16(val)
 	c.mu.Unlock()
 
-	c.IncrementDataVersion()
+	c.MarkDirty(AttrOnTime)
 	return nil
 }
 
 // writeOffWaitTime handles writing the OffWaitTime attribute.
 func (c *Cluster) writeOffWaitTime(r *tlv.Reader) error {
-	if c.config.FeatureMap&FeatureLighting == 0 {
-		return datamodel.ErrUnsupportedWrite
-	}
-
 	if err := r.Next(); err != nil {
 		return err
 	}
@@ -384,26 +336,18 @@ func (c *Cluster) writeOffWaitTime(r *tlv.Reader) error {
 	c.offWaitTime = uint16(val)
 	c.mu.Unlock()
 
-	c.IncrementDataVersion()
+	c.MarkDirty(AttrOffWaitTime)
 	return nil
 }
 
 // writeStartUpOnOff handles writing the StartUpOnOff attribute.
 func (c *Cluster) writeStartUpOnOff(r *tlv.Reader) error {
-	if c.config.FeatureMap&FeatureLighting == 0 {
-		return datamodel.ErrUnsupportedWrite
-	}
-
 	if err := r.Next(); err != nil {
 		return err
 	}
 
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	if r.Type() == tlv.ElementTypeNull {
-		c.startUpOnOff = nil
-	} else {
+	var startUp *StartUpOnOff
+	if r.Type() != tlv.ElementTypeNull {
 		val, err := r.Uint()
 		if err != nil {
 			return err
@@ -413,20 +357,20 @@ func (c *Cluster) writeStartUpOnOff(r *tlv.Reader) error {
 			return datamodel.ErrConstraintError
 		}
 		s := StartUpOnOff(val)
-		c.startUpOnOff = &s
-
-		// Persist
-		if c.config.Storage != nil {
-			_ = c.config.Storage.Store("startupOnOff", []byte{byte(s)})
-		}
+		startUp = &s
 	}
+	_ = c.startUpOnOff.Set(startUp)
 
-	c.IncrementDataVersion()
+	c.MarkDirty(AttrStartUpOnOff)
 	return nil
 }
 
 // InvokeCommand implements datamodel.Cluster.
 func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	if datamodel.FindCommand(c.cmdList, req.Path.Command) == nil {
+		return nil, datamodel.ErrUnsupportedCommand
+	}
+
 	switch req.Path.Command {
 	case CmdOff:
 		return nil, c.handleOff()
@@ -454,14 +398,14 @@ func (c *Cluster) handleOff() error {
 // handleOn handles the On command.
 func (c *Cluster) handleOn() error {
 	// Check OffOnly feature - if set, On command is not supported
-	if c.config.FeatureMap&FeatureOffOnly != 0 {
+	if c.HasFeature(uint32(FeatureOffOnly)) {
 		return datamodel.ErrUnsupportedCommand
 	}
 
 	c.setOnOff(true)
 
 	// Per spec: when turning on with lighting feature
-	if c.config.FeatureMap&FeatureLighting != 0 {
+	if c.HasFeature(uint32(FeatureLighting)) {
 		c.mu.Lock()
 		if c.onTime == 0 {
 			c.offWaitTime = 0
@@ -475,9 +419,7 @@ func (c *Cluster) handleOn() error {
 
 // handleToggle handles the Toggle command.
 func (c *Cluster) handleToggle() error {
-	c.mu.RLock()
-	currentState := c.onOff
-	c.mu.RUnlock()
+	currentState := c.onOff.Get()
 
 	if currentState {
 		return c.handleOff()
@@ -487,10 +429,6 @@ func (c *Cluster) handleToggle() error {
 
 // handleOffWithEffect handles the OffWithEffect command.
 func (c *Cluster) handleOffWithEffect(r *tlv.Reader) error {
-	if c.config.FeatureMap&FeatureLighting == 0 {
-		return datamodel.ErrUnsupportedCommand
-	}
-
 	// Decode the command
 	var effectID EffectIdentifier
 	var effectVariant uint8
@@ -547,10 +485,6 @@ func (c *Cluster) handleOffWithEffect(r *tlv.Reader) error {
 
 // handleOnWithRecallGlobalScene handles the OnWithRecallGlobalScene command.
 func (c *Cluster) handleOnWithRecallGlobalScene() error {
-	if c.config.FeatureMap&FeatureLighting == 0 {
-		return datamodel.ErrUnsupportedCommand
-	}
-
 	c.mu.Lock()
 	// If GlobalSceneControl is true, do nothing
 	if c.globalSceneControl {
@@ -568,10 +502,6 @@ func (c *Cluster) handleOnWithRecallGlobalScene() error {
 
 // handleOnWithTimedOff handles the OnWithTimedOff command.
 func (c *Cluster) handleOnWithTimedOff(r *tlv.Reader) error {
-	if c.config.FeatureMap&FeatureLighting == 0 {
-		return datamodel.ErrUnsupportedCommand
-	}
-
 	// Decode the command
 	var onOffControl uint8
 	var onTime uint16
@@ -625,12 +555,12 @@ func (c *Cluster) handleOnWithTimedOff(r *tlv.Reader) error {
 	defer c.mu.Unlock()
 
 	// If AcceptOnlyWhenOn is set and device is off, reject
-	if acceptOnlyWhenOn && !c.onOff {
+	if acceptOnlyWhenOn && !c.onOff.Get() {
 		return nil // No error, just no-op
 	}
 
 	// Set OnTime and OffWaitTime
-	if c.onOff {
+	if c.onOff.Get() {
 		// Already on - use max of current and new values
 		if onTime > c.onTime {
 			c.onTime = onTime
@@ -654,19 +584,15 @@ func (c *Cluster) handleOnWithTimedOff(r *tlv.Reader) error {
 // setOnOff sets the on/off state and triggers callbacks.
 func (c *Cluster) setOnOff(newState bool) {
 	c.mu.Lock()
-	oldState := c.onOff
-	if oldState == newState {
+	if c.onOff.Get() == newState {
 		c.mu.Unlock()
 		return
 	}
-	c.onOff = newState
+	_ = c.onOff.Set(newState) // Persisted as well
 	c.mu.Unlock()
 
-	// Persist
-	c.saveOnOff()
-
-	// Increment data version
-	c.IncrementDataVersion()
+	// Report the change to subscribers
+	c.MarkDirty(AttrOnOff)
 
 	// Callback
 	if c.config.OnStateChange != nil {
@@ -676,12 +602,50 @@ func (c *Cluster) setOnOff(newState bool) {
 
 // GetOnOff returns the current on/off state.
 func (c *Cluster) GetOnOff() bool {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	return c.onOff
+	return c.onOff.Get()
 }
 
 // SetOnOff sets the on/off state directly (for external control).
 func (c *Cluster) SetOnOff(newState bool) {
 	c.setOnOff(newState)
 }
+
+// Compile-time check that Cluster takes part in scenes.
+var _ clusters.SceneParticipant = (*Cluster)(nil)
+
+// CaptureScene implements clusters.SceneParticipant. A scene stores the
+// OnOff attribute.
+func (c *Cluster) CaptureScene() clusters.ExtensionFieldSet {
+	var v uint64
+	if c.onOff.Get() {
+		v = 1
+	}
+	return clusters.ExtensionFieldSet{
+		ClusterID: ClusterID,
+		AttributeValueList: []clusters.AttributeValuePair{
+			{AttributeID: AttrOnOff, Type: clusters.SceneValueUnsigned8, Value: v},
+		},
+	}
+}
+
+// RecallScene implements clusters.SceneParticipant. On/off switches at
+// once, so the transition time is ignored.
+func (c *Cluster) RecallScene(efs clusters.ExtensionFieldSet, transitionTime time.Duration) error {
+	if efs.ClusterID != ClusterID {
+		return clusters.ErrInvalidExtensionFieldSet
+	}
+	for _, p := range efs.AttributeValueList {
+		if p.AttributeID != AttrOnOff || p.Type != clusters.SceneValueUnsigned8 || p.Value > 1 {
+			return clusters.ErrInvalidExtensionFieldSet
+		}
+	}
+
+	if p, ok := efs.Find(AttrOnOff); ok {
+		on := p.Value == 1
+		if on && c.HasFeature(uint32(FeatureOffOnly)) {
+			return clusters.ErrInvalidExtensionFieldSet
+		}
+		c.setOnOff(on)
+	}
+	return nil
+}
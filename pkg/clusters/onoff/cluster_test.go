@@ -6,6 +6,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/backkem/matter/pkg/clusters"
 	"github.com/backkem/matter/pkg/datamodel"
 	"github.com/backkem/matter/pkg/tlv"
 )
@@ -488,10 +489,11 @@ func TestWriteStartUpOnOff(t *testing.T) {
 	}
 
 	// Verify persisted
-	if data, err := storage.Load("startupOnOff"); err != nil {
+	key := datamodel.AttributeKey(req.Path.ConcreteAttributePath)
+	if data, err := storage.Load(key); err != nil {
 		t.Errorf("StartUpOnOff not persisted: %v", err)
-	} else if data[0] != byte(StartUpOnOffOn) {
-		t.Errorf("expected StartUpOnOff=%d, got %d", StartUpOnOffOn, data[0])
+	} else if !bytes.Equal(data, writeData) {
+		t.Errorf("expected StartUpOnOff=%x, got %x", writeData, data)
 	}
 }
 
@@ -775,3 +777,37 @@ func encodeOnWithTimedOffCommand(onOffControl uint8, onTime, offWaitTime uint16)
 	_ = w.EndContainer()
 	return buf.Bytes()
 }
+
+func TestScene_CaptureRecall(t *testing.T) {
+	cluster := createTestCluster(0)
+	cluster.SetOnOff(true)
+
+	scene := cluster.CaptureScene()
+	if scene.ClusterID != ClusterID {
+		t.Fatalf("ClusterID = %v, want %v", scene.ClusterID, ClusterID)
+	}
+	if p, ok := scene.Find(AttrOnOff); !ok || p.Value != 1 {
+		t.Fatalf("OnOff pair = %+v, %v, want on", p, ok)
+	}
+
+	cluster.SetOnOff(false)
+	if err := cluster.RecallScene(scene, 0); err != nil {
+		t.Fatalf("RecallScene failed: %v", err)
+	}
+	if !cluster.GetOnOff() {
+		t.Error("OnOff = false after recalling an on scene")
+	}
+
+	// Field sets of other clusters, or of attributes it does not store
+	bad := []clusters.ExtensionFieldSet{
+		{ClusterID: 0x0008},
+		{ClusterID: ClusterID, AttributeValueList: []clusters.AttributeValuePair{
+			{AttributeID: AttrOnTime, Type: clusters.SceneValueUnsigned16, Value: 10},
+		}},
+	}
+	for _, efs := range bad {
+		if err := cluster.RecallScene(efs, 0); !errors.Is(err, clusters.ErrInvalidExtensionFieldSet) {
+			t.Errorf("RecallScene(%+v) = %v, want ErrInvalidExtensionFieldSet", efs, err)
+		}
+	}
+}
@@ -0,0 +1,739 @@
+// Package operationalcredentials implements the Node Operational
+// Credentials Cluster (0x003E).
+//
+// The cluster lets a commissioner attest the device and install its
+// operational credentials: the device answers AttestationRequest and
+// CertificateChainRequest with its attestation credentials, generates an
+// operational key on CSRRequest, and joins the fabric on AddNOC. An
+// administrator renews the credentials of its own fabric with CSRRequest
+// and UpdateNOC over CASE. The cluster also serves the fabric table and
+// lets administrators relabel and leave fabrics.
+//
+// Installing credentials requires an armed fail-safe. The fabric added by
+// AddNOC is removed by the node if the fail-safe expires, and
+// OnFailSafeExpired reverts an UpdateNOC; OnFailSafeEnded drops an
+// operational key no NOC was added for, and the key an UpdateNOC
+// replaced.
+//
+// Spec Reference: Section 11.18
+//
+// C++ Reference: src/app/clusters/operational-credentials-server/operational-credentials-server.cpp
+package operationalcredentials
+
+import (
+	"bytes"
+	"context"
+	gocrypto "crypto"
+	"errors"
+	"sync"
+
+	"github.com/backkem/matter/pkg/crypto"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x003E
+	ClusterRevision uint16              = 1
+)
+
+// Attribute IDs (Spec 11.18.5).
+const (
+	AttrNOCs                    datamodel.AttributeID = 0x0000
+	AttrFabrics                 datamodel.AttributeID = 0x0001
+	AttrSupportedFabrics        datamodel.AttributeID = 0x0002
+	AttrCommissionedFabrics     datamodel.AttributeID = 0x0003
+	AttrTrustedRootCertificates datamodel.AttributeID = 0x0004
+	AttrCurrentFabricIndex      datamodel.AttributeID = 0x0005
+)
+
+// Command IDs (Spec 11.18.6).
+const (
+	CmdAttestationRequest        datamodel.CommandID = 0x00
+	CmdAttestationResponse       datamodel.CommandID = 0x01
+	CmdCertificateChainRequest   datamodel.CommandID = 0x02
+	CmdCertificateChainResponse  datamodel.CommandID = 0x03
+	CmdCSRRequest                datamodel.CommandID = 0x04
+	CmdCSRResponse               datamodel.CommandID = 0x05
+	CmdAddNOC                    datamodel.CommandID = 0x06
+	CmdUpdateNOC                 datamodel.CommandID = 0x07
+	CmdNOCResponse               datamodel.CommandID = 0x08
+	CmdUpdateFabricLabel         datamodel.CommandID = 0x09
+	CmdRemoveFabric              datamodel.CommandID = 0x0A
+	CmdAddTrustedRootCertificate datamodel.CommandID = 0x0B
+)
+
+// NOCStatus is the status of a NOCResponse (NodeOperationalCertStatusEnum).
+type NOCStatus uint8
+
+// NOCStatus values (Spec 11.18.4.3).
+const (
+	NOCStatusOK                  NOCStatus = 0
+	NOCStatusInvalidPublicKey    NOCStatus = 1
+	NOCStatusInvalidNodeOpID     NOCStatus = 2
+	NOCStatusInvalidNOC          NOCStatus = 3
+	NOCStatusMissingCsr          NOCStatus = 4
+	NOCStatusTableFull           NOCStatus = 5
+	NOCStatusInvalidAdminSubject NOCStatus = 6
+	NOCStatusFabricConflict      NOCStatus = 9
+	NOCStatusLabelConflict       NOCStatus = 10
+	NOCStatusInvalidFabricIndex  NOCStatus = 11
+)
+
+// CertificateChainType selects the certificate of a
+// CertificateChainRequest (CertificateChainTypeEnum).
+type CertificateChainType uint8
+
+// CertificateChainType values (Spec 11.18.4.2).
+const (
+	CertificateChainTypeDAC CertificateChainType = 1
+	CertificateChainTypePAI CertificateChainType = 2
+)
+
+// Limits (Spec 11.18.6).
+const (
+	NonceLength    = 32
+	MaxLabelLength = fabric.MaxLabelSize
+)
+
+// Errors returned by New.
+var (
+	ErrNoFabricTable = errors.New("operationalcredentials: fabric table is required")
+	ErrNoManager     = errors.New("operationalcredentials: fabric manager is required")
+)
+
+// AttestationCredentials are the device attestation credentials the
+// device proves its identity with.
+type AttestationCredentials struct {
+	// DAC is the Device Attestation Certificate (DER encoded).
+	DAC []byte
+
+	// PAI is the Product Attestation Intermediate certificate (DER
+	// encoded).
+	PAI []byte
+
+	// CertificationDeclaration is the CMS-signed Certification
+	// Declaration of the product.
+	CertificationDeclaration []byte
+
+	// Key is the DAC's private key. Attestation and CSR responses are
+	// signed with it.
+	Key gocrypto.Signer
+}
+
+// FabricManager joins and leaves fabrics on behalf of the cluster. The
+// node implements it, keeping the fabric table, its storage and the
+// access control list in step.
+type FabricManager interface {
+	// AddFabric joins the node to a fabric whose credentials AddNOC
+	// installed, granting caseAdminSubject Administer privilege over
+	// CASE on it.
+	AddFabric(info *fabric.FabricInfo, caseAdminSubject uint64) (fabric.FabricIndex, error)
+
+	// UpdateFabric replaces the credentials of a fabric with those of
+	// info, which has the fabric's index: on UpdateNOC, and to revert it.
+	UpdateFabric(info *fabric.FabricInfo) error
+
+	// RemoveFabric leaves a fabric.
+	RemoveFabric(index fabric.FabricIndex) error
+
+	// SetFabricLabel sets the label of a fabric.
+	SetFabricLabel(index fabric.FabricIndex, label string) error
+}
+
+// FailSafeContext reports the fail-safe state of the node.
+// generalcommissioning.FailSafeManager satisfies it.
+type FailSafeContext interface {
+	// IsArmed returns true if the fail-safe timer is currently armed.
+	IsArmed() bool
+}
+
+// Config provides dependencies for the Operational Credentials cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to.
+	EndpointID datamodel.EndpointID
+
+	// Fabrics is the node's fabric table (required). CSRRequest
+	// generates operational keys in its keystore; without one it fails.
+	Fabrics *fabric.Table
+
+	// Manager joins and leaves fabrics (required).
+	Manager FabricManager
+
+	// FailSafe reports whether the fail-safe is armed. If nil, the
+	// fail-safe is treated as never armed and CSRRequest, AddNOC and
+	// AddTrustedRootCertificate always fail.
+	FailSafe FailSafeContext
+
+	// Attestation holds the device attestation credentials. If nil,
+	// AttestationRequest, CertificateChainRequest and CSRRequest fail.
+	Attestation *AttestationCredentials
+}
+
+// Cluster implements the Operational Credentials cluster (0x003E).
+type Cluster struct {
+	*datamodel.ClusterBase
+	config Config
+
+	// State of the commissioning under the fail-safe (protected by mutex)
+	mu               sync.Mutex
+	pendingKey       fabric.KeyHandle   // Key generated by CSRRequest
+	pendingForUpdate bool               // The CSRRequest was for UpdateNOC
+	pendingRoot      []byte             // Root certificate of AddTrustedRootCertificate
+	nocAdded         bool               // AddNOC or UpdateNOC succeeded
+	updated          *fabric.FabricInfo // The fabric as it was before UpdateNOC
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Operational Credentials cluster.
+func New(cfg Config) (*Cluster, error) {
+	if cfg.Fabrics == nil {
+		return nil, ErrNoFabricTable
+	}
+	if cfg.Manager == nil {
+		return nil, ErrNoManager
+	}
+
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		config:      cfg,
+	}
+	c.attrList = c.buildAttributeList()
+	return c, nil
+}
+
+// buildAttributeList constructs the list of supported attributes.
+func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+	adminPriv := datamodel.PrivilegeAdminister
+
+	attrs := []datamodel.AttributeEntry{
+		datamodel.NewReadOnlyAttribute(AttrNOCs,
+			datamodel.AttrQualityList|datamodel.AttrQualityFabricScoped|datamodel.AttrQualityFabricSensitive, adminPriv),
+		datamodel.NewReadOnlyAttribute(AttrFabrics, datamodel.AttrQualityList|datamodel.AttrQualityFabricScoped, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrSupportedFabrics, 0, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrCommissionedFabrics, 0, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrTrustedRootCertificates, datamodel.AttrQualityList, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrCurrentFabricIndex, 0, viewPriv),
+	}
+
+	return datamodel.MergeAttributeLists(attrs)
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	adminPriv := datamodel.PrivilegeAdminister
+	return []datamodel.CommandEntry{
+		datamodel.NewCommandEntry(CmdAttestationRequest, 0, adminPriv),
+		datamodel.NewCommandEntry(CmdCertificateChainRequest, 0, adminPriv),
+		datamodel.NewCommandEntry(CmdCSRRequest, 0, adminPriv),
+		datamodel.NewCommandEntry(CmdAddNOC, 0, adminPriv),
+		datamodel.NewCommandEntry(CmdUpdateNOC, datamodel.CmdQualityFabricScoped, adminPriv),
+		datamodel.NewCommandEntry(CmdUpdateFabricLabel, datamodel.CmdQualityFabricScoped, adminPriv),
+		datamodel.NewCommandEntry(CmdRemoveFabric, 0, adminPriv),
+		datamodel.NewCommandEntry(CmdAddTrustedRootCertificate, 0, adminPriv),
+	}
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return []datamodel.CommandID{
+		CmdAttestationResponse,
+		CmdCertificateChainResponse,
+		CmdCSRResponse,
+		CmdNOCResponse,
+	}
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	fabrics := c.config.Fabrics
+	switch req.Path.Attribute {
+	case AttrNOCs:
+		return c.writeFabricList(&req, w, encodeNOC)
+	case AttrFabrics:
+		return c.writeFabricList(&req, w, encodeFabricDescriptor)
+	case AttrSupportedFabrics:
+		return w.PutUint(tlv.Anonymous(), uint64(fabrics.SupportedFabrics()))
+	case AttrCommissionedFabrics:
+		return w.PutUint(tlv.Anonymous(), uint64(fabrics.CommissionedFabrics()))
+	case AttrTrustedRootCertificates:
+		if err := w.StartArray(tlv.Anonymous()); err != nil {
+			return err
+		}
+		for _, root := range fabrics.GetTrustedRootCertificates() {
+			if err := w.PutBytes(tlv.Anonymous(), root); err != nil {
+				return err
+			}
+		}
+		return w.EndContainer()
+	case AttrCurrentFabricIndex:
+		return w.PutUint(tlv.Anonymous(), uint64(req.FabricIndex()))
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// writeFabricList writes a fabric-scoped list with an entry per fabric:
+// only the accessing fabric's if the read is fabric-filtered.
+func (c *Cluster) writeFabricList(req *datamodel.ReadAttributeRequest, w *tlv.Writer,
+	encode func(w *tlv.Writer, info *fabric.FabricInfo) error) error {
+	if err := w.StartArray(tlv.Anonymous()); err != nil {
+		return err
+	}
+	for _, info := range c.config.Fabrics.List() {
+		if req.IsFabricFiltered() && info.FabricIndex != req.FabricIndex() {
+			continue
+		}
+		if err := encode(w, info); err != nil {
+			return err
+		}
+	}
+	return w.EndContainer()
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	return datamodel.ErrUnsupportedWrite
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	return datamodel.ResponseFields(c.InvokeCommandResponse(ctx, req, r))
+}
+
+// InvokeCommandResponse implements datamodel.ClusterWithCommandResponses.
+func (c *Cluster) InvokeCommandResponse(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) (*datamodel.CommandResponse, error) {
+	switch req.Path.Command {
+	case CmdAttestationRequest:
+		nonce, _, err := decodeNonceRequest(r)
+		if err != nil {
+			return nil, err
+		}
+		return c.handleAttestationRequest(&req, nonce)
+
+	case CmdCertificateChainRequest:
+		certType, err := decodeCertificateChainRequest(r)
+		if err != nil {
+			return nil, err
+		}
+		return c.handleCertificateChainRequest(certType)
+
+	case CmdCSRRequest:
+		nonce, forUpdate, err := decodeNonceRequest(r)
+		if err != nil {
+			return nil, err
+		}
+		return c.handleCSRRequest(&req, nonce, forUpdate)
+
+	case CmdAddTrustedRootCertificate:
+		root, err := decodeAddTrustedRootCertificate(r)
+		if err != nil {
+			return nil, err
+		}
+		return nil, c.handleAddTrustedRootCertificate(root)
+
+	case CmdAddNOC:
+		args, err := decodeAddNOC(r)
+		if err != nil {
+			return nil, err
+		}
+		return c.handleAddNOC(&req, args)
+
+	case CmdUpdateNOC:
+		noc, icac, err := decodeUpdateNOC(r)
+		if err != nil {
+			return nil, err
+		}
+		return c.handleUpdateNOC(&req, noc, icac)
+
+	case CmdUpdateFabricLabel:
+		label, err := decodeUpdateFabricLabel(r)
+		if err != nil {
+			return nil, err
+		}
+		return c.handleUpdateFabricLabel(&req, label)
+
+	case CmdRemoveFabric:
+		index, err := decodeRemoveFabric(r)
+		if err != nil {
+			return nil, err
+		}
+		return c.handleRemoveFabric(index)
+
+	default:
+		return nil, datamodel.ErrUnsupportedCommand
+	}
+}
+
+// handleAttestationRequest signs the attestation elements with the DAC
+// key, bound to the session by its attestation challenge.
+//
+// Spec: Section 11.18.6.1
+func (c *Cluster) handleAttestationRequest(req *datamodel.InvokeRequest, nonce []byte) (*datamodel.CommandResponse, error) {
+	att := c.config.Attestation
+	if att == nil {
+		return nil, datamodel.ErrUnsupportedCommand
+	}
+	elements, err := encodeAttestationElements(att.CertificationDeclaration, nonce)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := c.signWithChallenge(req, elements)
+	if err != nil {
+		return nil, err
+	}
+	return newElementsResponse(CmdAttestationResponse, elements, signature)
+}
+
+// handleCertificateChainRequest returns the DAC or PAI.
+//
+// Spec: Section 11.18.6.3
+func (c *Cluster) handleCertificateChainRequest(certType CertificateChainType) (*datamodel.CommandResponse, error) {
+	att := c.config.Attestation
+	if att == nil {
+		return nil, datamodel.ErrUnsupportedCommand
+	}
+	var cert []byte
+	switch certType {
+	case CertificateChainTypeDAC:
+		cert = att.DAC
+	case CertificateChainTypePAI:
+		cert = att.PAI
+	default:
+		return nil, datamodel.ErrInvalidCommand
+	}
+	return datamodel.NewCommandResponse(CmdCertificateChainResponse, func(w *tlv.Writer) error {
+		return w.PutBytes(tlv.ContextTag(0), cert)
+	})
+}
+
+// handleCSRRequest generates an operational key in the keystore and
+// returns a CSR for it, signed with the DAC key. A key generated by an
+// earlier CSRRequest under the same fail-safe is replaced. A key for
+// UpdateNOC replaces the key of the accessing fabric, so it can only be
+// requested over CASE.
+//
+// Spec: Section 11.18.6.5
+func (c *Cluster) handleCSRRequest(req *datamodel.InvokeRequest, nonce []byte, forUpdate bool) (*datamodel.CommandResponse, error) {
+	if !c.failSafeArmed() {
+		return nil, datamodel.ErrFailsafeRequired
+	}
+	if forUpdate && !req.FabricIndex().IsValid() {
+		return nil, datamodel.ErrInvalidCommand
+	}
+	keystore := c.config.Fabrics.Keystore()
+	if c.config.Attestation == nil || keystore == nil {
+		return nil, datamodel.ErrUnsupportedCommand
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.nocAdded {
+		return nil, datamodel.ErrConstraintError
+	}
+
+	handle, _, err := keystore.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	signer, err := keystore.Signer(handle)
+	if err != nil {
+		keystore.DeleteKey(handle)
+		return nil, err
+	}
+	csr, err := crypto.P256CreateCSR(signer)
+	if err != nil {
+		keystore.DeleteKey(handle)
+		return nil, err
+	}
+	elements, err := encodeNOCSRElements(csr, nonce)
+	if err != nil {
+		keystore.DeleteKey(handle)
+		return nil, err
+	}
+	signature, err := c.signWithChallenge(req, elements)
+	if err != nil {
+		keystore.DeleteKey(handle)
+		return nil, err
+	}
+
+	if c.pendingKey != "" {
+		keystore.DeleteKey(c.pendingKey)
+	}
+	c.pendingKey = handle
+	c.pendingForUpdate = forUpdate
+	return newElementsResponse(CmdCSRResponse, elements, signature)
+}
+
+// handleAddTrustedRootCertificate stores the root certificate the next
+// AddNOC chains to.
+//
+// Spec: Section 11.18.6.13
+func (c *Cluster) handleAddTrustedRootCertificate(root []byte) error {
+	if !c.failSafeArmed() {
+		return datamodel.ErrFailsafeRequired
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pendingRoot != nil || c.nocAdded {
+		return datamodel.ErrConstraintError
+	}
+	c.pendingRoot = append([]byte(nil), root...)
+	return nil
+}
+
+// handleAddNOC joins the node to the fabric of the NOC, with the key of
+// the preceding CSRRequest. The session the NOC arrived on is bound to
+// the new fabric. Failures the commissioner can act on are reported in
+// the NOCResponse status.
+//
+// Spec: Section 11.18.6.8
+func (c *Cluster) handleAddNOC(req *datamodel.InvokeRequest, args *addNOCArgs) (*datamodel.CommandResponse, error) {
+	if !c.failSafeArmed() {
+		return nil, datamodel.ErrFailsafeRequired
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.nocAdded {
+		return nil, datamodel.ErrConstraintError
+	}
+	if c.pendingKey == "" {
+		return newNOCResponse(NOCStatusMissingCsr, 0)
+	}
+	if c.pendingForUpdate {
+		return nil, datamodel.ErrConstraintError
+	}
+	if c.pendingRoot == nil {
+		return newNOCResponse(NOCStatusInvalidNOC, 0)
+	}
+
+	fabrics := c.config.Fabrics
+	if fabrics.CommissionedFabrics() >= fabrics.SupportedFabrics() {
+		return newNOCResponse(NOCStatusTableFull, 0)
+	}
+	index, err := fabrics.AllocateFabricIndex()
+	if err != nil {
+		return newNOCResponse(NOCStatusTableFull, 0)
+	}
+	info, err := fabric.NewFabricInfo(index, c.pendingRoot, args.NOC, args.ICAC, fabric.VendorID(args.AdminVendorID), args.IPK)
+	if err != nil {
+		return newNOCResponse(NOCStatusInvalidNOC, 0)
+	}
+	if status := c.checkNOCKey(info); status != NOCStatusOK {
+		return newNOCResponse(status, 0)
+	}
+	if _, exists := fabrics.FindByRootAndFabricID(info.RootPublicKey, info.FabricID); exists {
+		return newNOCResponse(NOCStatusFabricConflict, 0)
+	}
+	if args.CaseAdminSubject == 0 {
+		return newNOCResponse(NOCStatusInvalidAdminSubject, 0)
+	}
+
+	info.KeyHandle = c.pendingKey
+	index, err = c.config.Manager.AddFabric(info, args.CaseAdminSubject)
+	if err != nil {
+		return newNOCResponse(NOCStatusTableFull, 0)
+	}
+	c.pendingKey = ""
+	c.pendingRoot = nil
+	c.nocAdded = true
+
+	if req.Session != nil {
+		req.Session.SetFabricIndex(index)
+	}
+	c.IncrementDataVersion()
+	return newNOCResponse(NOCStatusOK, index)
+}
+
+// handleUpdateNOC replaces the NOC and ICAC of the accessing fabric with
+// ones for the key of the preceding CSRRequest, which was for UpdateNOC.
+// The new NOC must chain to the fabric's root and keep its fabric ID; it
+// may assign another node ID. The replaced key is kept until the
+// fail-safe ends, so an expired fail-safe can revert the update.
+//
+// Spec: Section 11.18.6.9
+func (c *Cluster) handleUpdateNOC(req *datamodel.InvokeRequest, noc, icac []byte) (*datamodel.CommandResponse, error) {
+	if !c.failSafeArmed() {
+		return nil, datamodel.ErrFailsafeRequired
+	}
+	index := req.FabricIndex()
+	if !index.IsValid() {
+		return nil, datamodel.ErrUnsupportedAccess
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.nocAdded || c.pendingRoot != nil {
+		return nil, datamodel.ErrConstraintError
+	}
+	if c.pendingKey == "" {
+		return newNOCResponse(NOCStatusMissingCsr, 0)
+	}
+	if !c.pendingForUpdate {
+		return nil, datamodel.ErrConstraintError
+	}
+
+	previous, ok := c.config.Fabrics.Get(index)
+	if !ok {
+		return newNOCResponse(NOCStatusInvalidFabricIndex, 0)
+	}
+	info, err := fabric.NewFabricInfo(index, previous.RootCert, noc, icac, previous.VendorID, previous.IPK)
+	if err != nil || info.FabricID != previous.FabricID {
+		return newNOCResponse(NOCStatusInvalidNOC, 0)
+	}
+	if status := c.checkNOCKey(info); status != NOCStatusOK {
+		return newNOCResponse(status, 0)
+	}
+
+	info.Label = previous.Label
+	info.KeyHandle = c.pendingKey
+	if err := c.config.Manager.UpdateFabric(info); err != nil {
+		return newNOCResponse(NOCStatusInvalidFabricIndex, 0)
+	}
+	c.pendingKey = ""
+	c.pendingForUpdate = false
+	c.nocAdded = true
+	c.updated = previous
+
+	c.IncrementDataVersion()
+	return newNOCResponse(NOCStatusOK, index)
+}
+
+// checkNOCKey checks that the NOC certifies the pending operational key.
+// Caller must hold c.mu.
+func (c *Cluster) checkNOCKey(info *fabric.FabricInfo) NOCStatus {
+	signer, err := c.config.Fabrics.Keystore().Signer(c.pendingKey)
+	if err != nil {
+		return NOCStatusMissingCsr
+	}
+	pub, err := crypto.P256SignerPublicKey(signer)
+	if err != nil {
+		return NOCStatusInvalidPublicKey
+	}
+	noc, err := fabric.ParseCertificate(info.NOC)
+	if err != nil || !bytes.Equal(noc.ECPubKey, pub) {
+		return NOCStatusInvalidPublicKey
+	}
+	return NOCStatusOK
+}
+
+// handleUpdateFabricLabel sets the label of the accessing fabric.
+//
+// Spec: Section 11.18.6.11
+func (c *Cluster) handleUpdateFabricLabel(req *datamodel.InvokeRequest, label string) (*datamodel.CommandResponse, error) {
+	index := req.FabricIndex()
+	if !index.IsValid() {
+		return nil, datamodel.ErrUnsupportedAccess
+	}
+	if len(label) > MaxLabelLength {
+		return nil, datamodel.ErrConstraintError
+	}
+	if c.config.Fabrics.IsLabelInUse(label, index) {
+		return newNOCResponse(NOCStatusLabelConflict, 0)
+	}
+	if err := c.config.Manager.SetFabricLabel(index, label); err != nil {
+		return newNOCResponse(NOCStatusInvalidFabricIndex, 0)
+	}
+	c.IncrementDataVersion()
+	return newNOCResponse(NOCStatusOK, index)
+}
+
+// handleRemoveFabric removes a fabric from the node.
+//
+// Spec: Section 11.18.6.12
+func (c *Cluster) handleRemoveFabric(index fabric.FabricIndex) (*datamodel.CommandResponse, error) {
+	if _, ok := c.config.Fabrics.Get(index); !ok {
+		return newNOCResponse(NOCStatusInvalidFabricIndex, 0)
+	}
+	if err := c.config.Manager.RemoveFabric(index); err != nil {
+		return newNOCResponse(NOCStatusInvalidFabricIndex, 0)
+	}
+	c.IncrementDataVersion()
+	return newNOCResponse(NOCStatusOK, index)
+}
+
+// OnFailSafeEnded clears the state of the commissioning under the
+// fail-safe, once it expired or commissioning completed. An operational
+// key generated by CSRRequest that no NOC was added for is deleted, as is
+// the key an UpdateNOC replaced; a fabric added by AddNOC is the node's
+// to keep or remove.
+func (c *Cluster) OnFailSafeEnded() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deleteKey(c.pendingKey)
+	if c.updated != nil {
+		c.deleteKey(c.updated.KeyHandle)
+	}
+	c.pendingKey = ""
+	c.pendingForUpdate = false
+	c.pendingRoot = nil
+	c.nocAdded = false
+	c.updated = nil
+	c.IncrementDataVersion()
+}
+
+// OnFailSafeExpired reverts an UpdateNOC made under the fail-safe,
+// deleting the key it installed, then clears the state as OnFailSafeEnded
+// does.
+//
+// Spec: Section 11.10.7.2.2 (fail-safe expiry)
+func (c *Cluster) OnFailSafeExpired() {
+	c.mu.Lock()
+	if previous := c.updated; previous != nil {
+		c.updated = nil
+		current, ok := c.config.Fabrics.Get(previous.FabricIndex)
+		if ok && c.config.Manager.UpdateFabric(previous) == nil {
+			c.deleteKey(current.KeyHandle)
+		}
+	}
+	c.mu.Unlock()
+
+	c.OnFailSafeEnded()
+}
+
+// deleteKey deletes an operational key from the keystore, if any.
+func (c *Cluster) deleteKey(handle fabric.KeyHandle) {
+	if keystore := c.config.Fabrics.Keystore(); keystore != nil && handle != "" {
+		keystore.DeleteKey(handle)
+	}
+}
+
+// failSafeArmed reports whether the fail-safe is armed.
+func (c *Cluster) failSafeArmed() bool {
+	return c.config.FailSafe != nil && c.config.FailSafe.IsArmed()
+}
+
+// signWithChallenge signs elements followed by the attestation challenge
+// of the request's session with the DAC key.
+//
+// Spec: Section 11.18.4.7
+func (c *Cluster) signWithChallenge(req *datamodel.InvokeRequest, elements []byte) ([]byte, error) {
+	if req.Session == nil {
+		return nil, datamodel.ErrUnsupportedAccess
+	}
+	challenge := req.Session.AttestationChallenge()
+	if challenge == nil {
+		return nil, datamodel.ErrUnsupportedAccess
+	}
+	msg := make([]byte, 0, len(elements)+len(challenge))
+	msg = append(append(msg, elements...), challenge...)
+	return crypto.P256SignWith(c.config.Attestation.Key, msg)
+}
@@ -0,0 +1,670 @@
+package operationalcredentials
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"testing"
+
+	"github.com/backkem/matter/pkg/commissioning"
+	"github.com/backkem/matter/pkg/crypto"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// mockFabricManager implements FabricManager on a fabric table.
+type mockFabricManager struct {
+	table    *fabric.Table
+	subjects map[fabric.FabricIndex]uint64
+}
+
+func (m *mockFabricManager) AddFabric(info *fabric.FabricInfo, caseAdminSubject uint64) (fabric.FabricIndex, error) {
+	if err := m.table.Add(info); err != nil {
+		return 0, err
+	}
+	m.subjects[info.FabricIndex] = caseAdminSubject
+	return info.FabricIndex, nil
+}
+
+func (m *mockFabricManager) UpdateFabric(info *fabric.FabricInfo) error {
+	return m.table.Update(info.FabricIndex, func(f *fabric.FabricInfo) error {
+		*f = *info.Clone()
+		return nil
+	})
+}
+
+func (m *mockFabricManager) RemoveFabric(index fabric.FabricIndex) error {
+	return m.table.Remove(index)
+}
+
+func (m *mockFabricManager) SetFabricLabel(index fabric.FabricIndex, label string) error {
+	return m.table.UpdateLabel(index, label)
+}
+
+// mockFailSafe implements FailSafeContext.
+type mockFailSafe struct {
+	armed bool
+}
+
+func (m *mockFailSafe) IsArmed() bool { return m.armed }
+
+// mockSession implements datamodel.SecureSession.
+type mockSession struct {
+	challenge   []byte
+	fabricIndex fabric.FabricIndex
+}
+
+func (s *mockSession) AttestationChallenge() []byte            { return s.challenge }
+func (s *mockSession) SetFabricIndex(index fabric.FabricIndex) { s.fabricIndex = index }
+
+type testCluster struct {
+	*Cluster
+	table    *fabric.Table
+	manager  *mockFabricManager
+	failSafe *mockFailSafe
+	session  *mockSession
+	subject  *datamodel.SubjectDescriptor // The accessing fabric, if over CASE
+	dacKey   *ecdsa.PrivateKey
+}
+
+func newTestCluster(t *testing.T) *testCluster {
+	t.Helper()
+	keystore, err := fabric.NewKeystore(fabric.KeystoreConfig{})
+	if err != nil {
+		t.Fatalf("NewKeystore failed: %v", err)
+	}
+	dacKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+
+	tc := &testCluster{
+		table:    fabric.NewTable(fabric.TableConfig{Keystore: keystore}),
+		failSafe: &mockFailSafe{},
+		session:  &mockSession{challenge: bytes.Repeat([]byte{0xA5}, 16)},
+		dacKey:   dacKey,
+	}
+	tc.manager = &mockFabricManager{table: tc.table, subjects: make(map[fabric.FabricIndex]uint64)}
+	tc.Cluster, err = New(Config{
+		Fabrics:  tc.table,
+		Manager:  tc.manager,
+		FailSafe: tc.failSafe,
+		Attestation: &AttestationCredentials{
+			DAC:                      []byte{0x01},
+			PAI:                      []byte{0x02},
+			CertificationDeclaration: []byte{0x03},
+			Key:                      dacKey,
+		},
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return tc
+}
+
+// invoke invokes a command with the fields written by encode.
+func (tc *testCluster) invoke(cmd datamodel.CommandID, encode func(w *tlv.Writer) error) (*datamodel.CommandResponse, error) {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	w.StartStructure(tlv.Anonymous())
+	if err := encode(w); err != nil {
+		return nil, err
+	}
+	w.EndContainer()
+
+	req := datamodel.InvokeRequest{
+		Path:    datamodel.ConcreteCommandPath{Cluster: ClusterID, Command: cmd},
+		Session: tc.session,
+		Subject: tc.subject,
+	}
+	return tc.InvokeCommandResponse(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes())))
+}
+
+// requestCSR invokes CSRRequest and returns the public key of the CSR,
+// after checking the response's signature.
+func (tc *testCluster) requestCSR(t *testing.T, forUpdate bool) []byte {
+	t.Helper()
+	nonce := bytes.Repeat([]byte{0x5A}, NonceLength)
+	resp, err := tc.invoke(CmdCSRRequest, func(w *tlv.Writer) error {
+		if err := w.PutBytes(tlv.ContextTag(0), nonce); err != nil {
+			return err
+		}
+		return w.PutBool(tlv.ContextTag(1), forUpdate)
+	})
+	if err != nil {
+		t.Fatalf("CSRRequest failed: %v", err)
+	}
+	if resp.Command != CmdCSRResponse {
+		t.Fatalf("response command = %v, want CSRResponse", resp.Command)
+	}
+	fields := decodeTestFields(t, resp.Fields)
+
+	// The elements are signed with the DAC key, bound to the session
+	pub, err := crypto.P256SignerPublicKey(tc.dacKey)
+	if err != nil {
+		t.Fatalf("P256SignerPublicKey failed: %v", err)
+	}
+	msg := append(append([]byte(nil), fields[0]...), tc.session.challenge...)
+	if ok, err := crypto.P256Verify(pub, msg, fields[1]); err != nil || !ok {
+		t.Fatalf("CSRResponse signature does not verify: %v", err)
+	}
+
+	elements := decodeTestFields(t, fields[0])
+	if !bytes.Equal(elements[2], nonce) {
+		t.Errorf("CSR nonce = %x, want %x", elements[2], nonce)
+	}
+	csr, err := x509.ParseCertificateRequest(elements[1])
+	if err != nil {
+		t.Fatalf("ParseCertificateRequest failed: %v", err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		t.Fatalf("CSR signature: %v", err)
+	}
+	key, err := csr.PublicKey.(*ecdsa.PublicKey).ECDH()
+	if err != nil {
+		t.Fatalf("CSR public key: %v", err)
+	}
+	return key.Bytes()
+}
+
+func (tc *testCluster) addRoot(t *testing.T, root []byte) error {
+	t.Helper()
+	_, err := tc.invoke(CmdAddTrustedRootCertificate, func(w *tlv.Writer) error {
+		return w.PutBytes(tlv.ContextTag(0), root)
+	})
+	return err
+}
+
+func (tc *testCluster) addNOC(t *testing.T, noc []byte, ipk [fabric.IPKSize]byte) (NOCStatus, fabric.FabricIndex) {
+	t.Helper()
+	resp, err := tc.invoke(CmdAddNOC, func(w *tlv.Writer) error {
+		w.PutBytes(tlv.ContextTag(0), noc)
+		w.PutBytes(tlv.ContextTag(2), ipk[:])
+		w.PutUint(tlv.ContextTag(3), 0x1122)
+		return w.PutUint(tlv.ContextTag(4), 0xFFF1)
+	})
+	if err != nil {
+		t.Fatalf("AddNOC failed: %v", err)
+	}
+	return decodeNOCResponse(t, resp)
+}
+
+func (tc *testCluster) updateNOC(t *testing.T, noc []byte) (NOCStatus, fabric.FabricIndex) {
+	t.Helper()
+	resp, err := tc.invoke(CmdUpdateNOC, func(w *tlv.Writer) error {
+		return w.PutBytes(tlv.ContextTag(0), noc)
+	})
+	if err != nil {
+		t.Fatalf("UpdateNOC failed: %v", err)
+	}
+	return decodeNOCResponse(t, resp)
+}
+
+// decodeNOCResponse returns the status and fabric index of a NOCResponse.
+func decodeNOCResponse(t *testing.T, resp *datamodel.CommandResponse) (NOCStatus, fabric.FabricIndex) {
+	t.Helper()
+	if resp.Command != CmdNOCResponse {
+		t.Fatalf("response command = %v, want NOCResponse", resp.Command)
+	}
+	r := tlv.NewReader(bytes.NewReader(resp.Fields))
+	r.Next()
+	r.EnterContainer()
+	var status, index uint64
+	for r.Next() == nil && !r.IsEndOfContainer() {
+		switch r.Tag().TagNumber() {
+		case 0:
+			status, _ = r.Uint()
+		case 1:
+			index, _ = r.Uint()
+		}
+	}
+	return NOCStatus(status), fabric.FabricIndex(index)
+}
+
+// decodeTestFields returns the octet string fields of a structure by tag.
+func decodeTestFields(t *testing.T, data []byte) map[uint8][]byte {
+	t.Helper()
+	r := tlv.NewReader(bytes.NewReader(data))
+	if err := r.Next(); err != nil {
+		t.Fatalf("Next failed: %v", err)
+	}
+	if err := r.EnterContainer(); err != nil {
+		t.Fatalf("EnterContainer failed: %v", err)
+	}
+	fields := make(map[uint8][]byte)
+	for r.Next() == nil && !r.IsEndOfContainer() {
+		if v, err := r.Bytes(); err == nil {
+			fields[uint8(r.Tag().TagNumber())] = v
+		}
+	}
+	return fields
+}
+
+func TestClusterID(t *testing.T) {
+	tc := newTestCluster(t)
+	if tc.ID() != ClusterID {
+		t.Errorf("expected cluster ID 0x%04X, got 0x%04X", ClusterID, tc.ID())
+	}
+}
+
+func TestNew_RequiresDependencies(t *testing.T) {
+	if _, err := New(Config{Manager: &mockFabricManager{}}); err != ErrNoFabricTable {
+		t.Errorf("New without fabric table: %v, want %v", err, ErrNoFabricTable)
+	}
+	if _, err := New(Config{Fabrics: fabric.NewTable(fabric.DefaultTableConfig())}); err != ErrNoManager {
+		t.Errorf("New without manager: %v, want %v", err, ErrNoManager)
+	}
+}
+
+func TestAttestationRequest(t *testing.T) {
+	tc := newTestCluster(t)
+	nonce := bytes.Repeat([]byte{0x11}, NonceLength)
+	resp, err := tc.invoke(CmdAttestationRequest, func(w *tlv.Writer) error {
+		return w.PutBytes(tlv.ContextTag(0), nonce)
+	})
+	if err != nil {
+		t.Fatalf("AttestationRequest failed: %v", err)
+	}
+	fields := decodeTestFields(t, resp.Fields)
+
+	pub, _ := crypto.P256SignerPublicKey(tc.dacKey)
+	msg := append(append([]byte(nil), fields[0]...), tc.session.challenge...)
+	if ok, err := crypto.P256Verify(pub, msg, fields[1]); err != nil || !ok {
+		t.Errorf("AttestationResponse signature does not verify: %v", err)
+	}
+	elements := decodeTestFields(t, fields[0])
+	if !bytes.Equal(elements[1], []byte{0x03}) || !bytes.Equal(elements[2], nonce) {
+		t.Errorf("attestation elements = %x, want the CD and the nonce", elements)
+	}
+
+	// The signature binds the response to a session
+	tc.session.challenge = nil
+	_, err = tc.invoke(CmdAttestationRequest, func(w *tlv.Writer) error {
+		return w.PutBytes(tlv.ContextTag(0), nonce)
+	})
+	if !errors.Is(err, datamodel.ErrUnsupportedAccess) {
+		t.Errorf("AttestationRequest without challenge: %v, want %v", err, datamodel.ErrUnsupportedAccess)
+	}
+}
+
+func TestCertificateChainRequest(t *testing.T) {
+	tc := newTestCluster(t)
+	for certType, want := range map[CertificateChainType][]byte{
+		CertificateChainTypeDAC: {0x01},
+		CertificateChainTypePAI: {0x02},
+	} {
+		resp, err := tc.invoke(CmdCertificateChainRequest, func(w *tlv.Writer) error {
+			return w.PutUint(tlv.ContextTag(0), uint64(certType))
+		})
+		if err != nil {
+			t.Fatalf("CertificateChainRequest(%d) failed: %v", certType, err)
+		}
+		if got := decodeTestFields(t, resp.Fields)[0]; !bytes.Equal(got, want) {
+			t.Errorf("certificate %d = %x, want %x", certType, got, want)
+		}
+	}
+	_, err := tc.invoke(CmdCertificateChainRequest, func(w *tlv.Writer) error {
+		return w.PutUint(tlv.ContextTag(0), 3)
+	})
+	if !errors.Is(err, datamodel.ErrInvalidCommand) {
+		t.Errorf("CertificateChainRequest(3): %v, want %v", err, datamodel.ErrInvalidCommand)
+	}
+}
+
+func TestAddNOC(t *testing.T) {
+	tc := newTestCluster(t)
+	ca, err := commissioning.NewCertificateAuthority(commissioning.CAConfig{})
+	if err != nil {
+		t.Fatalf("NewCertificateAuthority failed: %v", err)
+	}
+
+	// Installing credentials requires the fail-safe
+	_, err = tc.invoke(CmdCSRRequest, func(w *tlv.Writer) error {
+		return w.PutBytes(tlv.ContextTag(0), make([]byte, NonceLength))
+	})
+	if !errors.Is(err, datamodel.ErrFailsafeRequired) {
+		t.Fatalf("CSRRequest without fail-safe: %v, want %v", err, datamodel.ErrFailsafeRequired)
+	}
+	if err := tc.addRoot(t, ca.RootCert()); !errors.Is(err, datamodel.ErrFailsafeRequired) {
+		t.Fatalf("AddTrustedRootCertificate without fail-safe: %v, want %v", err, datamodel.ErrFailsafeRequired)
+	}
+	tc.failSafe.armed = true
+
+	if status, _ := tc.addNOC(t, []byte{0x15}, ca.IPK()); status != NOCStatusMissingCsr {
+		t.Errorf("AddNOC before CSRRequest: status %v, want MissingCsr", status)
+	}
+	publicKey := tc.requestCSR(t, false)
+	noc, err := ca.IssueNOC(publicKey, 0x42)
+	if err != nil {
+		t.Fatalf("IssueNOC failed: %v", err)
+	}
+	if status, _ := tc.addNOC(t, noc, ca.IPK()); status != NOCStatusInvalidNOC {
+		t.Errorf("AddNOC before AddTrustedRootCertificate: status %v, want InvalidNOC", status)
+	}
+	if err := tc.addRoot(t, ca.RootCert()); err != nil {
+		t.Fatalf("AddTrustedRootCertificate failed: %v", err)
+	}
+
+	// A NOC for another key is rejected
+	otherKey, _ := crypto.P256GenerateKeyPair()
+	otherPub, _ := crypto.P256SignerPublicKey(otherKey)
+	otherNOC, err := ca.IssueNOC(otherPub, 0x43)
+	if err != nil {
+		t.Fatalf("IssueNOC failed: %v", err)
+	}
+	if status, _ := tc.addNOC(t, otherNOC, ca.IPK()); status != NOCStatusInvalidPublicKey {
+		t.Errorf("AddNOC for another key: status %v, want InvalidPublicKey", status)
+	}
+
+	status, index := tc.addNOC(t, noc, ca.IPK())
+	if status != NOCStatusOK || !index.IsValid() {
+		t.Fatalf("AddNOC: status %v, index %v", status, index)
+	}
+	info, ok := tc.table.Get(index)
+	if !ok {
+		t.Fatal("fabric not added")
+	}
+	if info.NodeID != 0x42 || info.FabricID != ca.FabricID() {
+		t.Errorf("fabric = %v/%v, want %v/0x42", info.FabricID, info.NodeID, ca.FabricID())
+	}
+	if _, err := tc.table.OperationalKey(index); err != nil {
+		t.Errorf("fabric has no operational key: %v", err)
+	}
+	if tc.manager.subjects[index] != 0x1122 {
+		t.Errorf("CASE admin subject = %#x, want 0x1122", tc.manager.subjects[index])
+	}
+	if tc.session.fabricIndex != index {
+		t.Errorf("session bound to fabric %v, want %v", tc.session.fabricIndex, index)
+	}
+
+	// One NOC per fail-safe
+	if _, err := tc.invoke(CmdAddNOC, func(w *tlv.Writer) error {
+		w.PutBytes(tlv.ContextTag(0), noc)
+		w.PutBytes(tlv.ContextTag(2), make([]byte, fabric.IPKSize))
+		w.PutUint(tlv.ContextTag(3), 0x1122)
+		return w.PutUint(tlv.ContextTag(4), 0xFFF1)
+	}); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("second AddNOC: %v, want %v", err, datamodel.ErrConstraintError)
+	}
+
+	// The fabric stays once the fail-safe ends
+	tc.OnFailSafeEnded()
+	if tc.table.CommissionedFabrics() != 1 {
+		t.Errorf("CommissionedFabrics = %d after the fail-safe ended, want 1", tc.table.CommissionedFabrics())
+	}
+}
+
+func TestOnFailSafeEnded_DeletesPendingKey(t *testing.T) {
+	tc := newTestCluster(t)
+	tc.failSafe.armed = true
+	tc.requestCSR(t, false)
+	handle := tc.pendingKey
+
+	// A second CSRRequest replaces the key
+	tc.requestCSR(t, false)
+	if tc.pendingKey == handle {
+		t.Fatal("second CSRRequest kept the key")
+	}
+	keystore := tc.table.Keystore()
+	if _, err := keystore.Signer(handle); err == nil {
+		t.Error("replaced key not deleted")
+	}
+
+	handle = tc.pendingKey
+	tc.OnFailSafeEnded()
+	if _, err := keystore.Signer(handle); err == nil {
+		t.Error("pending key not deleted when the fail-safe ended")
+	}
+}
+
+// newUpdateTestCluster returns a test cluster on fabric 1 of ca, accessed
+// over CASE, with its operational key in the keystore.
+func newUpdateTestCluster(t *testing.T, ca *commissioning.CertificateAuthority) (*testCluster, fabric.KeyHandle) {
+	t.Helper()
+	tc := newTestCluster(t)
+	keystore := tc.table.Keystore()
+	handle, _, err := keystore.GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	key, _ := keystore.Signer(handle)
+	info, err := ca.IssueFabricInfo(1, key)
+	if err != nil {
+		t.Fatalf("IssueFabricInfo failed: %v", err)
+	}
+	info.KeyHandle = handle
+	if err := tc.table.Add(info); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	tc.failSafe.armed = true
+	tc.subject = &datamodel.SubjectDescriptor{FabricIndex: 1}
+	return tc, handle
+}
+
+func TestUpdateNOC(t *testing.T) {
+	ca, err := commissioning.NewCertificateAuthority(commissioning.CAConfig{})
+	if err != nil {
+		t.Fatalf("NewCertificateAuthority failed: %v", err)
+	}
+	tc, oldKey := newUpdateTestCluster(t, ca)
+	keystore := tc.table.Keystore()
+
+	// A key for UpdateNOC is only requested over CASE
+	tc.subject = nil
+	_, err = tc.invoke(CmdCSRRequest, func(w *tlv.Writer) error {
+		w.PutBytes(tlv.ContextTag(0), make([]byte, NonceLength))
+		return w.PutBool(tlv.ContextTag(1), true)
+	})
+	if !errors.Is(err, datamodel.ErrInvalidCommand) {
+		t.Errorf("CSRRequest for UpdateNOC over PASE: %v, want %v", err, datamodel.ErrInvalidCommand)
+	}
+	tc.subject = &datamodel.SubjectDescriptor{FabricIndex: 1}
+
+	if status, _ := tc.updateNOC(t, []byte{0x15}); status != NOCStatusMissingCsr {
+		t.Errorf("UpdateNOC before CSRRequest: status %v, want MissingCsr", status)
+	}
+
+	// UpdateNOC needs a CSR for UpdateNOC, and AddNOC one that is not
+	publicKey := tc.requestCSR(t, false)
+	noc, err := ca.IssueNOC(publicKey, 0x43)
+	if err != nil {
+		t.Fatalf("IssueNOC failed: %v", err)
+	}
+	if _, err := tc.invoke(CmdUpdateNOC, func(w *tlv.Writer) error {
+		return w.PutBytes(tlv.ContextTag(0), noc)
+	}); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("UpdateNOC after CSRRequest for AddNOC: %v, want %v", err, datamodel.ErrConstraintError)
+	}
+	publicKey = tc.requestCSR(t, true)
+	if _, err := tc.invoke(CmdAddNOC, func(w *tlv.Writer) error {
+		w.PutBytes(tlv.ContextTag(0), noc)
+		w.PutBytes(tlv.ContextTag(2), make([]byte, fabric.IPKSize))
+		w.PutUint(tlv.ContextTag(3), 0x1122)
+		return w.PutUint(tlv.ContextTag(4), 0xFFF1)
+	}); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("AddNOC after CSRRequest for UpdateNOC: %v, want %v", err, datamodel.ErrConstraintError)
+	}
+
+	// The NOC must chain to the fabric's root
+	other, _ := commissioning.NewCertificateAuthority(commissioning.CAConfig{FabricID: ca.FabricID()})
+	otherNOC, err := other.IssueNOC(publicKey, 0x43)
+	if err != nil {
+		t.Fatalf("IssueNOC failed: %v", err)
+	}
+	if status, _ := tc.updateNOC(t, otherNOC); status != NOCStatusInvalidNOC {
+		t.Errorf("UpdateNOC from another root: status %v, want InvalidNOC", status)
+	}
+
+	noc, err = ca.IssueNOC(publicKey, 0x43)
+	if err != nil {
+		t.Fatalf("IssueNOC failed: %v", err)
+	}
+	status, index := tc.updateNOC(t, noc)
+	if status != NOCStatusOK || index != 1 {
+		t.Fatalf("UpdateNOC: status %v, index %v", status, index)
+	}
+	info, _ := tc.table.Get(1)
+	if info.NodeID != 0x43 || info.KeyHandle == oldKey {
+		t.Errorf("fabric node ID %v, key %q after UpdateNOC", info.NodeID, info.KeyHandle)
+	}
+	if _, err := tc.table.OperationalKey(1); err != nil {
+		t.Errorf("fabric has no operational key: %v", err)
+	}
+
+	// The replaced key is kept until the fail-safe ends
+	if _, err := keystore.Signer(oldKey); err != nil {
+		t.Errorf("replaced key deleted under the fail-safe: %v", err)
+	}
+	tc.OnFailSafeEnded()
+	if _, err := keystore.Signer(oldKey); err == nil {
+		t.Error("replaced key not deleted when the fail-safe ended")
+	}
+	if info, _ := tc.table.Get(1); info.NodeID != 0x43 {
+		t.Errorf("node ID = %v after the fail-safe ended, want 0x43", info.NodeID)
+	}
+}
+
+func TestUpdateNOC_RevertedOnFailSafeExpiry(t *testing.T) {
+	ca, err := commissioning.NewCertificateAuthority(commissioning.CAConfig{})
+	if err != nil {
+		t.Fatalf("NewCertificateAuthority failed: %v", err)
+	}
+	tc, oldKey := newUpdateTestCluster(t, ca)
+	keystore := tc.table.Keystore()
+
+	previous, _ := tc.table.Get(1)
+	noc, err := ca.IssueNOC(tc.requestCSR(t, true), previous.NodeID+1)
+	if err != nil {
+		t.Fatalf("IssueNOC failed: %v", err)
+	}
+	if status, _ := tc.updateNOC(t, noc); status != NOCStatusOK {
+		t.Fatalf("UpdateNOC: status %v", status)
+	}
+	info, _ := tc.table.Get(1)
+	newKey := info.KeyHandle
+
+	tc.OnFailSafeExpired()
+	info, _ = tc.table.Get(1)
+	if info.NodeID != previous.NodeID || info.KeyHandle != oldKey {
+		t.Errorf("fabric node ID %v, key %q after expiry, want the previous credentials", info.NodeID, info.KeyHandle)
+	}
+	if _, err := keystore.Signer(oldKey); err != nil {
+		t.Errorf("previous key deleted: %v", err)
+	}
+	if _, err := keystore.Signer(newKey); err == nil {
+		t.Error("key of the reverted NOC not deleted")
+	}
+}
+
+func TestUpdateFabricLabelAndRemoveFabric(t *testing.T) {
+	tc := newTestCluster(t)
+	ca, err := commissioning.NewCertificateAuthority(commissioning.CAConfig{})
+	if err != nil {
+		t.Fatalf("NewCertificateAuthority failed: %v", err)
+	}
+	key, _ := crypto.P256GenerateKeyPair()
+	info, err := ca.IssueFabricInfo(1, key)
+	if err != nil {
+		t.Fatalf("IssueFabricInfo failed: %v", err)
+	}
+	if err := tc.table.Add(info); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	invoke := func(cmd datamodel.CommandID, index fabric.FabricIndex, encode func(w *tlv.Writer) error) NOCStatus {
+		t.Helper()
+		var buf bytes.Buffer
+		w := tlv.NewWriter(&buf)
+		w.StartStructure(tlv.Anonymous())
+		encode(w)
+		w.EndContainer()
+		resp, err := tc.InvokeCommandResponse(context.Background(), datamodel.InvokeRequest{
+			Path:    datamodel.ConcreteCommandPath{Cluster: ClusterID, Command: cmd},
+			Subject: &datamodel.SubjectDescriptor{FabricIndex: index},
+		}, tlv.NewReader(bytes.NewReader(buf.Bytes())))
+		if err != nil {
+			t.Fatalf("command %v failed: %v", cmd, err)
+		}
+		r := tlv.NewReader(bytes.NewReader(resp.Fields))
+		r.Next()
+		r.EnterContainer()
+		r.Next()
+		status, _ := r.Uint()
+		return NOCStatus(status)
+	}
+
+	if status := invoke(CmdUpdateFabricLabel, 1, func(w *tlv.Writer) error {
+		return w.PutString(tlv.ContextTag(0), "Home")
+	}); status != NOCStatusOK {
+		t.Errorf("UpdateFabricLabel: status %v", status)
+	}
+	if got, _ := tc.table.Get(1); got.Label != "Home" {
+		t.Errorf("label = %q, want Home", got.Label)
+	}
+
+	if status := invoke(CmdRemoveFabric, 1, func(w *tlv.Writer) error {
+		return w.PutUint(tlv.ContextTag(0), 2)
+	}); status != NOCStatusInvalidFabricIndex {
+		t.Errorf("RemoveFabric(2): status %v, want InvalidFabricIndex", status)
+	}
+	if status := invoke(CmdRemoveFabric, 1, func(w *tlv.Writer) error {
+		return w.PutUint(tlv.ContextTag(0), 1)
+	}); status != NOCStatusOK {
+		t.Errorf("RemoveFabric(1): status %v", status)
+	}
+	if tc.table.CommissionedFabrics() != 0 {
+		t.Error("fabric not removed")
+	}
+}
+
+func TestReadFabrics_FabricFiltered(t *testing.T) {
+	tc := newTestCluster(t)
+	for i := fabric.FabricIndex(1); i <= 2; i++ {
+		ca, err := commissioning.NewCertificateAuthority(commissioning.CAConfig{})
+		if err != nil {
+			t.Fatalf("NewCertificateAuthority failed: %v", err)
+		}
+		key, _ := crypto.P256GenerateKeyPair()
+		info, err := ca.IssueFabricInfo(i, key)
+		if err != nil {
+			t.Fatalf("IssueFabricInfo failed: %v", err)
+		}
+		if err := tc.table.Add(info); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+
+	count := func(filtered bool) int {
+		var buf bytes.Buffer
+		req := datamodel.ReadAttributeRequest{
+			Path:    datamodel.ConcreteAttributePath{Cluster: ClusterID, Attribute: AttrFabrics},
+			Subject: &datamodel.SubjectDescriptor{FabricIndex: 2},
+		}
+		if filtered {
+			req.ReadFlags |= datamodel.ReadFlagFabricFiltered
+		}
+		if err := tc.ReadAttribute(context.Background(), req, tlv.NewWriter(&buf)); err != nil {
+			t.Fatalf("ReadAttribute failed: %v", err)
+		}
+		r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+		r.Next()
+		r.EnterContainer()
+		n := 0
+		for r.Next() == nil && !r.IsEndOfContainer() {
+			n++
+			r.Skip()
+		}
+		return n
+	}
+	if n := count(false); n != 2 {
+		t.Errorf("unfiltered read: %d fabrics, want 2", n)
+	}
+	if n := count(true); n != 1 {
+		t.Errorf("fabric-filtered read: %d fabrics, want 1", n)
+	}
+}
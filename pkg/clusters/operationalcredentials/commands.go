@@ -0,0 +1,325 @@
+package operationalcredentials
+
+import (
+	"bytes"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// tagFabricIndex is the context tag of the FabricIndex field of
+// fabric-scoped structs.
+const tagFabricIndex = 0xFE
+
+// addNOCArgs holds the fields of an AddNOC command (Spec 11.18.6.8).
+type addNOCArgs struct {
+	NOC              []byte
+	ICAC             []byte
+	IPK              [fabric.IPKSize]byte
+	CaseAdminSubject uint64
+	AdminVendorID    uint16
+}
+
+// decodeFields enters the command's structure and calls field for each of
+// its context-tagged fields. Decode errors map to InvalidCommand.
+func decodeFields(r *tlv.Reader, field func(tag uint8) error) error {
+	if err := r.Next(); err != nil {
+		return datamodel.ErrInvalidCommand
+	}
+	if err := r.EnterContainer(); err != nil {
+		return datamodel.ErrInvalidCommand
+	}
+
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		tag := r.Tag()
+		if !tag.IsContext() {
+			continue
+		}
+		if err := field(uint8(tag.TagNumber())); err != nil {
+			return datamodel.ErrInvalidCommand
+		}
+	}
+	return nil
+}
+
+// decodeNonceRequest decodes the 32-byte nonce (tag 0) of
+// AttestationRequest and CSRRequest, and the IsForUpdateNOC field (tag 1)
+// of CSRRequest.
+func decodeNonceRequest(r *tlv.Reader) (nonce []byte, forUpdate bool, err error) {
+	err = decodeFields(r, func(tag uint8) (err error) {
+		switch tag {
+		case 0:
+			nonce, err = r.Bytes()
+		case 1:
+			forUpdate, err = r.Bool()
+		}
+		return err
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if len(nonce) != NonceLength {
+		return nil, false, datamodel.ErrInvalidCommand
+	}
+	return nonce, forUpdate, nil
+}
+
+// decodeCertificateChainRequest decodes CertificateChainRequest: the
+// certificate type (tag 0).
+func decodeCertificateChainRequest(r *tlv.Reader) (CertificateChainType, error) {
+	var certType uint64
+	err := decodeFields(r, func(tag uint8) error {
+		if tag != 0 {
+			return nil
+		}
+		v, err := r.Uint()
+		certType = v
+		return err
+	})
+	return CertificateChainType(certType), err
+}
+
+// decodeAddTrustedRootCertificate decodes AddTrustedRootCertificate: the
+// root certificate (tag 0).
+func decodeAddTrustedRootCertificate(r *tlv.Reader) ([]byte, error) {
+	var root []byte
+	err := decodeFields(r, func(tag uint8) error {
+		if tag != 0 {
+			return nil
+		}
+		v, err := r.Bytes()
+		root = v
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(root) == 0 {
+		return nil, datamodel.ErrInvalidCommand
+	}
+	return root, nil
+}
+
+// decodeAddNOC decodes AddNOC: the NOC (tag 0), an optional ICAC (tag 1),
+// the IPK (tag 2), the CASE admin subject (tag 3) and the admin vendor ID
+// (tag 4).
+func decodeAddNOC(r *tlv.Reader) (*addNOCArgs, error) {
+	args := &addNOCArgs{}
+	var ipk []byte
+	var hasSubject, hasVendor bool
+	err := decodeFields(r, func(tag uint8) error {
+		var err error
+		switch tag {
+		case 0:
+			args.NOC, err = r.Bytes()
+		case 1:
+			args.ICAC, err = r.Bytes()
+		case 2:
+			ipk, err = r.Bytes()
+		case 3:
+			args.CaseAdminSubject, err = r.Uint()
+			hasSubject = true
+		case 4:
+			var v uint64
+			v, err = r.Uint()
+			args.AdminVendorID = uint16(v)
+			hasVendor = true
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if args.NOC == nil || len(ipk) != fabric.IPKSize || !hasSubject || !hasVendor {
+		return nil, datamodel.ErrInvalidCommand
+	}
+	copy(args.IPK[:], ipk)
+	return args, nil
+}
+
+// decodeUpdateNOC decodes UpdateNOC: the NOC (tag 0) and an optional ICAC
+// (tag 1).
+func decodeUpdateNOC(r *tlv.Reader) (noc, icac []byte, err error) {
+	err = decodeFields(r, func(tag uint8) (err error) {
+		switch tag {
+		case 0:
+			noc, err = r.Bytes()
+		case 1:
+			icac, err = r.Bytes()
+		}
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if noc == nil {
+		return nil, nil, datamodel.ErrInvalidCommand
+	}
+	return noc, icac, nil
+}
+
+// decodeUpdateFabricLabel decodes UpdateFabricLabel: the label (tag 0).
+func decodeUpdateFabricLabel(r *tlv.Reader) (string, error) {
+	var label string
+	found := false
+	err := decodeFields(r, func(tag uint8) error {
+		if tag != 0 {
+			return nil
+		}
+		v, err := r.String()
+		label, found = v, true
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", datamodel.ErrInvalidCommand
+	}
+	return label, nil
+}
+
+// decodeRemoveFabric decodes RemoveFabric: the fabric index (tag 0).
+func decodeRemoveFabric(r *tlv.Reader) (fabric.FabricIndex, error) {
+	var index uint64
+	err := decodeFields(r, func(tag uint8) error {
+		if tag != 0 {
+			return nil
+		}
+		v, err := r.Uint()
+		index = v
+		return err
+	})
+	return fabric.FabricIndex(index), err
+}
+
+// encodeAttestationElements encodes the attestation elements: the
+// certification declaration (tag 1), the nonce (tag 2) and a timestamp
+// (tag 3), left 0 as the device has no trusted time.
+//
+// Spec: Section 11.18.4.6
+func encodeAttestationElements(cd, nonce []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return nil, err
+	}
+	if err := w.PutBytes(tlv.ContextTag(1), cd); err != nil {
+		return nil, err
+	}
+	if err := w.PutBytes(tlv.ContextTag(2), nonce); err != nil {
+		return nil, err
+	}
+	if err := w.PutUint(tlv.ContextTag(3), 0); err != nil {
+		return nil, err
+	}
+	if err := w.EndContainer(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeNOCSRElements encodes the NOCSR elements: the CSR (tag 1) and
+// the nonce (tag 2).
+//
+// Spec: Section 11.18.4.9
+func encodeNOCSRElements(csr, nonce []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return nil, err
+	}
+	if err := w.PutBytes(tlv.ContextTag(1), csr); err != nil {
+		return nil, err
+	}
+	if err := w.PutBytes(tlv.ContextTag(2), nonce); err != nil {
+		return nil, err
+	}
+	if err := w.EndContainer(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// newElementsResponse builds an AttestationResponse or CSRResponse: the
+// elements (tag 0) and their signature (tag 1).
+func newElementsResponse(cmd datamodel.CommandID, elements, signature []byte) (*datamodel.CommandResponse, error) {
+	return datamodel.NewCommandResponse(cmd, func(w *tlv.Writer) error {
+		if err := w.PutBytes(tlv.ContextTag(0), elements); err != nil {
+			return err
+		}
+		return w.PutBytes(tlv.ContextTag(1), signature)
+	})
+}
+
+// newNOCResponse builds a NOCResponse (Spec 11.18.6.10). The fabric index
+// is included if valid.
+func newNOCResponse(status NOCStatus, index fabric.FabricIndex) (*datamodel.CommandResponse, error) {
+	return datamodel.NewCommandResponse(CmdNOCResponse, func(w *tlv.Writer) error {
+		if err := w.PutUint(tlv.ContextTag(0), uint64(status)); err != nil {
+			return err
+		}
+		if index.IsValid() {
+			return w.PutUint(tlv.ContextTag(1), uint64(index))
+		}
+		return nil
+	})
+}
+
+// encodeNOC writes a fabric's NOCStruct with its fabric index.
+//
+// Spec: Section 11.18.4.4
+func encodeNOC(w *tlv.Writer, info *fabric.FabricInfo) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	if err := w.PutBytes(tlv.ContextTag(1), info.NOC); err != nil {
+		return err
+	}
+	var err error
+	if info.HasICAC() {
+		err = w.PutBytes(tlv.ContextTag(2), info.ICAC)
+	} else {
+		err = w.PutNull(tlv.ContextTag(2))
+	}
+	if err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(tagFabricIndex), uint64(info.FabricIndex)); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// encodeFabricDescriptor writes a fabric's FabricDescriptorStruct with
+// its fabric index.
+//
+// Spec: Section 11.18.4.5
+func encodeFabricDescriptor(w *tlv.Writer, info *fabric.FabricInfo) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	if err := w.PutBytes(tlv.ContextTag(1), info.RootPublicKey[:]); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(2), uint64(info.VendorID)); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(3), uint64(info.FabricID)); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(4), uint64(info.NodeID)); err != nil {
+		return err
+	}
+	if err := w.PutString(tlv.ContextTag(5), info.Label); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(tagFabricIndex), uint64(info.FabricIndex)); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
@@ -0,0 +1,683 @@
+// Package operationalstate implements the Operational State Cluster (0x0060)
+// and the clusters derived from it, such as RVC Operational State (0x0061).
+//
+// The cluster reports the operational state of a device (Stopped, Running,
+// Paused, Error, plus derived states such as Docked for robot vacuums) and
+// accepts Pause, Stop, Start and Resume commands. The derived RVC cluster
+// replaces Start and Stop with GoHome. Commands are checked against the
+// current state; a Delegate may veto them with an ErrorState, otherwise
+// the cluster applies the resulting state transition.
+//
+// Appliance clusters (laundry washer, dishwasher, oven cavity) embed a
+// Cluster built from the matching Definition and drive it from the device
+// side: SetCurrentPhase and SetCountdownTime track progress, SetError
+// reports failures (emitting an OperationalError event), and
+// CompleteOperation ends an operation, emitting OperationCompletion with
+// the running and paused time measured by the cluster.
+//
+// Spec Reference: Section 1.14
+//
+// C++ Reference: src/app/clusters/operational-state-server/operational-state-server.cpp
+package operationalstate
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Attribute IDs (Spec 1.14.5).
+const (
+	AttrPhaseList            datamodel.AttributeID = 0x0000
+	AttrCurrentPhase         datamodel.AttributeID = 0x0001
+	AttrCountdownTime        datamodel.AttributeID = 0x0002
+	AttrOperationalStateList datamodel.AttributeID = 0x0003
+	AttrOperationalState     datamodel.AttributeID = 0x0004
+	AttrOperationalError     datamodel.AttributeID = 0x0005
+)
+
+// Command IDs (Spec 1.14.6).
+const (
+	CmdPause                      datamodel.CommandID = 0x00
+	CmdStop                       datamodel.CommandID = 0x01
+	CmdStart                      datamodel.CommandID = 0x02
+	CmdResume                     datamodel.CommandID = 0x03
+	CmdOperationalCommandResponse datamodel.CommandID = 0x04
+	CmdGoHome                     datamodel.CommandID = 0x80 // RVC Operational State only
+)
+
+// Event IDs (Spec 1.14.7).
+const (
+	EventOperationalError    datamodel.EventID = 0x00
+	EventOperationCompletion datamodel.EventID = 0x01
+)
+
+// MaxLabelLength is the maximum length of state and phase labels.
+const MaxLabelLength = 64
+
+// State is an OperationalStateEnum value (Spec 1.14.4.1).
+// Derived clusters define additional values in 0x40-0x7F; manufacturer
+// states use 0x80-0xBF.
+type State uint8
+
+const (
+	StateStopped State = 0x00
+	StateRunning State = 0x01
+	StatePaused  State = 0x02
+	StateError   State = 0x03
+)
+
+// ErrorStateID is an ErrorStateEnum value (Spec 1.14.4.2).
+// Derived clusters define additional values in 0x40-0x7F; manufacturer
+// errors use 0x80-0xBF.
+type ErrorStateID uint8
+
+const (
+	ErrorNoError                   ErrorStateID = 0x00
+	ErrorUnableToStartOrResume     ErrorStateID = 0x01
+	ErrorUnableToCompleteOperation ErrorStateID = 0x02
+	ErrorCommandInvalidInState     ErrorStateID = 0x03
+)
+
+// ErrorState describes an error (Spec 1.14.4.4).
+type ErrorState struct {
+	// ID identifies the error.
+	ID ErrorStateID
+
+	// Label is required for manufacturer errors, otherwise optional.
+	Label string
+
+	// Details is an optional description of the error.
+	Details string
+}
+
+// NoError is the ErrorState reported when there is no error.
+var NoError = ErrorState{ID: ErrorNoError}
+
+// StateOption describes a supported operational state (Spec 1.14.4.3).
+type StateOption struct {
+	// ID identifies the state.
+	ID State
+
+	// Label is required for manufacturer states, otherwise optional.
+	Label string
+}
+
+// Errors returned by configuration and the device-side API.
+var (
+	ErrInvalidState     = errors.New("operationalstate: invalid operational state")
+	ErrUnsupportedState = errors.New("operationalstate: unsupported operational state")
+	ErrInvalidPhase     = errors.New("operationalstate: invalid phase")
+	ErrInvalidLabel     = errors.New("operationalstate: invalid label")
+	ErrInvalidError     = errors.New("operationalstate: invalid error state")
+)
+
+// Delegate lets the application carry out or veto commands. Each method is
+// called only when the command is valid in the current state; return
+// NoError to accept it, after which the cluster applies the transition.
+type Delegate interface {
+	// HandlePause is called for Pause.
+	HandlePause() ErrorState
+
+	// HandleResume is called for Resume.
+	HandleResume() ErrorState
+
+	// HandleStart is called for Start.
+	HandleStart() ErrorState
+
+	// HandleStop is called for Stop.
+	HandleStop() ErrorState
+}
+
+// GoHomeDelegate is implemented by delegates of clusters whose Definition
+// has GoHome set.
+type GoHomeDelegate interface {
+	// HandleGoHome is called for GoHome.
+	HandleGoHome() ErrorState
+}
+
+// Config provides dependencies for an Operational State cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to.
+	EndpointID datamodel.EndpointID
+
+	// Definition selects the base or a derived cluster.
+	// Defaults to OperationalState if zero.
+	Definition Definition
+
+	// States is the list of supported states. Defaults to the mandatory
+	// states of the definition if empty. Must contain all of them.
+	States []StateOption
+
+	// Commands selects the optional commands. Defaults to all commands
+	// the definition allows; bits it does not allow are ignored.
+	Commands Commands
+
+	// InitialState is the OperationalState at startup. Defaults to Stopped.
+	InitialState State
+
+	// PhaseList names the phases of an operation (nullable).
+	PhaseList []string
+
+	// Delegate carries out or vetoes commands (optional).
+	Delegate Delegate
+
+	// EventPublisher for OperationalError and OperationCompletion events.
+	// Optional - if nil, events are not emitted.
+	EventPublisher datamodel.EventPublisher
+
+	// OnStateChange is called when OperationalState changes (optional).
+	OnStateChange func(endpoint datamodel.EndpointID, state State)
+}
+
+// Cluster implements an Operational State cluster.
+type Cluster struct {
+	*datamodel.ClusterBase
+	*datamodel.EventSource
+	config Config
+
+	// cmdMu serializes commands so the delegate sees a stable state.
+	cmdMu sync.Mutex
+
+	// Mutable state (protected by mutex)
+	mu            sync.Mutex
+	state         State
+	opError       ErrorState
+	currentPhase  *uint8
+	countdownTime *uint32
+
+	// countdownReported is the CountdownTime last reported to subscribers.
+	countdownReported *uint32
+
+	// Operation timing for OperationCompletion
+	opStart     time.Time // zero if no operation is in progress
+	pausedSince time.Time // zero if not paused
+	pausedTotal time.Duration
+
+	// now returns the current time (for testing).
+	now func() time.Time
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates an Operational State cluster. It returns an error if the
+// state list or phase list is invalid for the definition.
+func New(cfg Config) (*Cluster, error) {
+	if cfg.Definition.ClusterID == 0 {
+		cfg.Definition = OperationalState
+	}
+	if cfg.Commands == 0 {
+		cfg.Commands = cfg.Definition.Commands
+	}
+	cfg.Commands &= cfg.Definition.Commands
+	if len(cfg.States) == 0 {
+		cfg.States = cfg.Definition.defaultStates()
+	}
+	if err := cfg.Definition.validateStates(cfg.States); err != nil {
+		return nil, err
+	}
+	for _, p := range cfg.PhaseList {
+		if !validLabel(p) || p == "" {
+			return nil, ErrInvalidPhase
+		}
+	}
+	if len(cfg.PhaseList) > 32 {
+		return nil, ErrInvalidPhase
+	}
+
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(cfg.Definition.ClusterID, cfg.EndpointID, cfg.Definition.Revision),
+		EventSource: datamodel.NewEventSource(),
+		config:      cfg,
+		state:       cfg.InitialState,
+		opError:     NoError,
+		now:         time.Now,
+	}
+	if !c.supportsState(c.state) || c.state == StateError {
+		c.state = StateStopped
+	}
+	if len(cfg.PhaseList) > 0 {
+		zero := uint8(0)
+		c.currentPhase = &zero
+	}
+
+	// Bind event source
+	if cfg.EventPublisher != nil {
+		c.EventSource.Bind(cfg.EndpointID, cfg.Definition.ClusterID, cfg.EventPublisher)
+		c.EventSource.RegisterEvents([]datamodel.EventEntry{
+			datamodel.NewEventEntry(EventOperationalError, datamodel.EventPriorityCritical, datamodel.PrivilegeView, false),
+			datamodel.NewEventEntry(EventOperationCompletion, datamodel.EventPriorityInfo, datamodel.PrivilegeView, false),
+		})
+	}
+
+	c.attrList = c.buildAttributeList()
+
+	return c, nil
+}
+
+// validLabel returns true if s is a valid state, error or phase label.
+func validLabel(s string) bool {
+	return len(s) <= MaxLabelLength && utf8.ValidString(s)
+}
+
+// buildAttributeList constructs the list of supported attributes.
+func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+
+	attrs := []datamodel.AttributeEntry{
+		datamodel.NewReadOnlyAttribute(AttrPhaseList, datamodel.AttrQualityList|datamodel.AttrQualityNullable, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrCurrentPhase, datamodel.AttrQualityNullable, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrCountdownTime, datamodel.AttrQualityNullable, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrOperationalStateList, datamodel.AttrQualityList, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrOperationalState, 0, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrOperationalError, 0, viewPriv),
+	}
+
+	return datamodel.MergeAttributeLists(attrs)
+}
+
+// Definition returns the cluster definition.
+func (c *Cluster) Definition() Definition {
+	return c.config.Definition
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	operatePriv := datamodel.PrivilegeOperate
+	var cmds []datamodel.CommandEntry
+	if c.hasCommands(CommandsPauseResume) {
+		cmds = append(cmds,
+			datamodel.NewCommandEntry(CmdPause, 0, operatePriv),
+			datamodel.NewCommandEntry(CmdResume, 0, operatePriv),
+		)
+	}
+	if c.hasCommands(CommandsStartStop) {
+		cmds = append(cmds,
+			datamodel.NewCommandEntry(CmdStop, 0, operatePriv),
+			datamodel.NewCommandEntry(CmdStart, 0, operatePriv),
+		)
+	}
+	if c.hasCommands(CommandsGoHome) {
+		cmds = append(cmds, datamodel.NewCommandEntry(CmdGoHome, 0, operatePriv))
+	}
+	return cmds
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	if c.config.Commands == 0 {
+		return nil
+	}
+	return []datamodel.CommandID{CmdOperationalCommandResponse}
+}
+
+// hasCommands returns true if the command group is enabled.
+func (c *Cluster) hasCommands(cmds Commands) bool {
+	return c.config.Commands&cmds != 0
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch req.Path.Attribute {
+	case AttrPhaseList:
+		if c.config.PhaseList == nil {
+			return w.PutNull(tlv.Anonymous())
+		}
+		if err := w.StartArray(tlv.Anonymous()); err != nil {
+			return err
+		}
+		for _, p := range c.config.PhaseList {
+			if err := w.PutString(tlv.Anonymous(), p); err != nil {
+				return err
+			}
+		}
+		return w.EndContainer()
+
+	case AttrCurrentPhase:
+		if c.currentPhase == nil {
+			return w.PutNull(tlv.Anonymous())
+		}
+		return w.PutUint(tlv.Anonymous(), uint64(*c.currentPhase))
+
+	case AttrCountdownTime:
+		if c.countdownTime == nil {
+			return w.PutNull(tlv.Anonymous())
+		}
+		return w.PutUint(tlv.Anonymous(), uint64(*c.countdownTime))
+
+	case AttrOperationalStateList:
+		if err := w.StartArray(tlv.Anonymous()); err != nil {
+			return err
+		}
+		for _, s := range c.config.States {
+			if err := w.StartStructure(tlv.Anonymous()); err != nil {
+				return err
+			}
+			if err := w.PutUint(tlv.ContextTag(0), uint64(s.ID)); err != nil {
+				return err
+			}
+			if s.Label != "" {
+				if err := w.PutString(tlv.ContextTag(1), s.Label); err != nil {
+					return err
+				}
+			}
+			if err := w.EndContainer(); err != nil {
+				return err
+			}
+		}
+		return w.EndContainer()
+
+	case AttrOperationalState:
+		return w.PutUint(tlv.Anonymous(), uint64(c.state))
+
+	case AttrOperationalError:
+		return c.opError.marshal(w, tlv.Anonymous())
+
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	// All Operational State attributes are read-only
+	return datamodel.ErrUnsupportedWrite
+}
+
+// marshal writes an ErrorStateStruct.
+func (e ErrorState) marshal(w *tlv.Writer, tag tlv.Tag) error {
+	if err := w.StartStructure(tag); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(e.ID)); err != nil {
+		return err
+	}
+	if e.Label != "" {
+		if err := w.PutString(tlv.ContextTag(1), e.Label); err != nil {
+			return err
+		}
+	}
+	if e.Details != "" {
+		if err := w.PutString(tlv.ContextTag(2), e.Details); err != nil {
+			return err
+		}
+	}
+	return w.EndContainer()
+}
+
+// supportsState returns true if the state is in OperationalStateList.
+func (c *Cluster) supportsState(s State) bool {
+	for _, o := range c.config.States {
+		if o.ID == s {
+			return true
+		}
+	}
+	return false
+}
+
+// OperationalState returns OperationalState.
+func (c *Cluster) OperationalState() State {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+// OperationalError returns OperationalError.
+func (c *Cluster) OperationalError() ErrorState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.opError
+}
+
+// SetOperationalState sets OperationalState from the device side, e.g.
+// when an RVC reaches its dock. Moving out of the Error state clears
+// OperationalError. Use SetError to enter the Error state.
+func (c *Cluster) SetOperationalState(s State) error {
+	if s == StateError {
+		return ErrInvalidState
+	}
+	if !c.supportsState(s) {
+		return ErrUnsupportedState
+	}
+
+	c.mu.Lock()
+	changed := c.setStateLocked(s)
+	c.mu.Unlock()
+
+	c.notifyState(s, changed)
+	return nil
+}
+
+// setStateLocked applies a state transition, clearing any error when the
+// Error state is left. Returns true if the state changed.
+// Must be called with mu held.
+func (c *Cluster) setStateLocked(s State) bool {
+	if s == c.state {
+		return false
+	}
+	if c.state == StateError && s != StateError {
+		c.opError = NoError
+	}
+	c.trackTimeLocked(s)
+	c.state = s
+	c.IncrementDataVersion()
+	return true
+}
+
+// trackTimeLocked updates operation timing for a transition to s. An
+// operation starts when Running is entered from outside an operation;
+// time spent Paused is accumulated separately.
+// Must be called with mu held.
+func (c *Cluster) trackTimeLocked(s State) {
+	now := c.now()
+	if c.state == StatePaused && !c.pausedSince.IsZero() {
+		c.pausedTotal += now.Sub(c.pausedSince)
+		c.pausedSince = time.Time{}
+	}
+	switch s {
+	case StateRunning:
+		if c.opStart.IsZero() {
+			c.opStart = now
+			c.pausedTotal = 0
+		}
+	case StatePaused:
+		if !c.opStart.IsZero() {
+			c.pausedSince = now
+		}
+	}
+}
+
+// finishTimingLocked ends the current operation and returns its total
+// and paused time in seconds, or nil if no operation was in progress.
+// Must be called with mu held.
+func (c *Cluster) finishTimingLocked() (total, paused *uint32) {
+	if c.opStart.IsZero() {
+		return nil, nil
+	}
+	now := c.now()
+	pausedTotal := c.pausedTotal
+	if !c.pausedSince.IsZero() {
+		pausedTotal += now.Sub(c.pausedSince)
+	}
+	t := uint32(now.Sub(c.opStart) / time.Second)
+	p := uint32(pausedTotal / time.Second)
+
+	c.opStart = time.Time{}
+	c.pausedSince = time.Time{}
+	c.pausedTotal = 0
+	return &t, &p
+}
+
+// notifyState calls OnStateChange after a transition.
+func (c *Cluster) notifyState(s State, changed bool) {
+	if changed && c.config.OnStateChange != nil {
+		c.config.OnStateChange(c.config.EndpointID, s)
+	}
+}
+
+// SetError moves the cluster to the Error state, sets OperationalError and
+// emits an OperationalError event.
+//
+// Spec: Section 1.14.7.1
+func (c *Cluster) SetError(e ErrorState) error {
+	if e.ID == ErrorNoError {
+		return ErrInvalidError
+	}
+	if !c.config.Definition.validError(e.ID) {
+		return ErrInvalidError
+	}
+	if !validLabel(e.Label) || !validLabel(e.Details) {
+		return ErrInvalidLabel
+	}
+
+	c.mu.Lock()
+	c.opError = e
+	changed := c.state != StateError
+	c.trackTimeLocked(StateError)
+	c.state = StateError
+	c.IncrementDataVersion()
+	c.mu.Unlock()
+
+	c.notifyState(StateError, changed)
+	return c.emit(EventOperationalError, datamodel.EventPriorityCritical, OperationalErrorEvent{ErrorState: e})
+}
+
+// CompleteOperation ends the current operation: it moves the cluster to
+// next, clears CountdownTime and emits an OperationCompletion event with
+// the time spent since the operation started and the part of it spent
+// Paused. Pass a non-zero errorCode if the operation ended abnormally.
+//
+// Spec: Section 1.14.7.2
+func (c *Cluster) CompleteOperation(errorCode ErrorStateID, next State) error {
+	if next == StateError {
+		return ErrInvalidState
+	}
+	if !c.supportsState(next) {
+		return ErrUnsupportedState
+	}
+
+	c.mu.Lock()
+	total, paused := c.finishTimingLocked()
+	changed := c.setStateLocked(next)
+	c.mu.Unlock()
+
+	c.notifyState(next, changed)
+	c.SetCountdownTime(nil)
+	return c.emit(EventOperationCompletion, datamodel.EventPriorityInfo, OperationCompletionEvent{
+		CompletionErrorCode:  errorCode,
+		TotalOperationalTime: total,
+		PausedTime:           paused,
+	})
+}
+
+// SetCurrentPhase sets CurrentPhase as an index into PhaseList.
+func (c *Cluster) SetCurrentPhase(phase uint8) error {
+	if int(phase) >= len(c.config.PhaseList) {
+		return ErrInvalidPhase
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.currentPhase != nil && *c.currentPhase == phase {
+		return nil
+	}
+	c.currentPhase = &phase
+	c.IncrementDataVersion()
+	return nil
+}
+
+// SetCountdownTime sets the estimated seconds until the operation
+// completes; nil sets it to null. The value is always updated, but only
+// changes to or from null or zero, or by more than
+// countdownReportThreshold since the last report, are reported.
+//
+// Spec: Section 1.14.5.3
+func (c *Cluster) SetCountdownTime(seconds *uint32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if seconds != nil {
+		v := *seconds
+		seconds = &v
+	}
+	c.countdownTime = seconds
+
+	if countdownReportable(c.countdownReported, seconds) {
+		c.countdownReported = seconds
+		c.IncrementDataVersion()
+	}
+}
+
+// countdownReportThreshold is the change in CountdownTime, in seconds,
+// beyond which an update is reported.
+const countdownReportThreshold = 10
+
+// countdownReportable returns true if a CountdownTime change from the last
+// reported value old to cur must be reported.
+func countdownReportable(old, cur *uint32) bool {
+	if old == nil || cur == nil {
+		return old != cur
+	}
+	if *old == *cur {
+		return false
+	}
+	if *old == 0 || *cur == 0 {
+		return true
+	}
+	diff := int64(*old) - int64(*cur)
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff > countdownReportThreshold
+}
+
+// CountdownTime returns CountdownTime, or nil if null.
+func (c *Cluster) CountdownTime() *uint32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.countdownTime == nil {
+		return nil
+	}
+	v := *c.countdownTime
+	return &v
+}
+
+// CurrentPhase returns CurrentPhase, or nil if null.
+func (c *Cluster) CurrentPhase() *uint8 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.currentPhase == nil {
+		return nil
+	}
+	v := *c.currentPhase
+	return &v
+}
+
+// emit emits an event if a publisher is bound.
+func (c *Cluster) emit(eventID datamodel.EventID, priority datamodel.EventPriority, payload interface{}) error {
+	if !c.EventSource.IsBound() {
+		return nil // No publisher, silently skip
+	}
+	_, err := c.EventSource.Emit(eventID, priority, payload)
+	return err
+}
@@ -0,0 +1,381 @@
+package operationalstate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// mockEventPublisher implements EventPublisher for testing.
+type mockEventPublisher struct {
+	mu     sync.Mutex
+	events []publishedEvent
+}
+
+type publishedEvent struct {
+	id       datamodel.EventID
+	priority datamodel.EventPriority
+	data     interface{}
+}
+
+func (m *mockEventPublisher) PublishEvent(
+	endpoint datamodel.EndpointID,
+	cluster datamodel.ClusterID,
+	eventID datamodel.EventID,
+	priority datamodel.EventPriority,
+	data interface{},
+	fabricIndex uint8,
+) (datamodel.EventNumber, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, publishedEvent{id: eventID, priority: priority, data: data})
+	return datamodel.EventNumber(len(m.events)), nil
+}
+
+// testDelegate records calls and optionally vetoes them.
+type testDelegate struct {
+	veto  ErrorState
+	calls []string
+}
+
+func (d *testDelegate) handle(name string) ErrorState {
+	d.calls = append(d.calls, name)
+	return d.veto
+}
+
+func (d *testDelegate) HandlePause() ErrorState  { return d.handle("pause") }
+func (d *testDelegate) HandleResume() ErrorState { return d.handle("resume") }
+func (d *testDelegate) HandleStart() ErrorState  { return d.handle("start") }
+func (d *testDelegate) HandleStop() ErrorState   { return d.handle("stop") }
+func (d *testDelegate) HandleGoHome() ErrorState { return d.handle("gohome") }
+
+func invoke(t *testing.T, c *Cluster, cmd datamodel.CommandID) (ErrorStateID, error) {
+	t.Helper()
+	req := datamodel.InvokeRequest{
+		Path: datamodel.ConcreteCommandPath{Endpoint: 1, Cluster: c.ID(), Command: cmd},
+	}
+	resp, err := c.InvokeCommand(context.Background(), req, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	r := tlv.NewReader(bytes.NewReader(resp))
+	r.Next()
+	r.EnterContainer() // response
+	r.Next()
+	r.EnterContainer() // CommandResponseState
+	r.Next()
+	v, err := r.Uint()
+	if err != nil {
+		t.Fatalf("failed to decode ErrorStateID: %v", err)
+	}
+	return ErrorStateID(v), nil
+}
+
+func TestNew_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want error
+	}{
+		{"defaults", Config{}, nil},
+		{"missing mandatory", Config{States: []StateOption{{ID: StateStopped}}}, ErrInvalidState},
+		{"rvc state on base", Config{States: append(OperationalState.defaultStates(), StateOption{ID: RVCStateDocked})}, ErrInvalidState},
+		{"unlabeled mfg state", Config{States: append(OperationalState.defaultStates(), StateOption{ID: 0x80})}, ErrInvalidLabel},
+		{"labeled mfg state", Config{States: append(OperationalState.defaultStates(), StateOption{ID: 0x80, Label: "Preheating"})}, nil},
+		{"empty phase", Config{PhaseList: []string{"Wash", ""}}, ErrInvalidPhase},
+		{"rvc defaults", Config{Definition: RVCOperationalState}, nil},
+	}
+
+	for _, tt := range tests {
+		if _, err := New(tt.cfg); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestStateMachine(t *testing.T) {
+	d := &testDelegate{}
+	var states []State
+	c, err := New(Config{
+		EndpointID: 1,
+		Delegate:   d,
+		OnStateChange: func(_ datamodel.EndpointID, s State) {
+			states = append(states, s)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.ID() != 0x0060 {
+		t.Errorf("ID() = 0x%04X, want 0x0060", c.ID())
+	}
+
+	steps := []struct {
+		cmd       datamodel.CommandID
+		wantErr   ErrorStateID
+		wantState State
+	}{
+		{CmdPause, ErrorCommandInvalidInState, StateStopped},
+		{CmdResume, ErrorCommandInvalidInState, StateStopped},
+		{CmdStart, ErrorNoError, StateRunning},
+		{CmdStart, ErrorNoError, StateRunning}, // no-op
+		{CmdPause, ErrorNoError, StatePaused},
+		{CmdPause, ErrorNoError, StatePaused}, // no-op
+		{CmdResume, ErrorNoError, StateRunning},
+		{CmdStop, ErrorNoError, StateStopped},
+	}
+	for i, s := range steps {
+		got, err := invoke(t, c, s.cmd)
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		if got != s.wantErr || c.OperationalState() != s.wantState {
+			t.Errorf("step %d (cmd 0x%02X): error = %d, state = %d; want %d, %d",
+				i, s.cmd, got, c.OperationalState(), s.wantErr, s.wantState)
+		}
+	}
+
+	if len(d.calls) != 4 {
+		t.Errorf("delegate calls = %v, want start, pause, resume, stop", d.calls)
+	}
+	if len(states) != 4 {
+		t.Errorf("state changes = %v, want 4", states)
+	}
+
+	if _, err := invoke(t, c, CmdGoHome); !errors.Is(err, datamodel.ErrUnsupportedCommand) {
+		t.Errorf("GoHome on base cluster: err = %v, want ErrUnsupportedCommand", err)
+	}
+}
+
+func TestDelegateVeto(t *testing.T) {
+	d := &testDelegate{veto: ErrorState{ID: ErrorUnableToStartOrResume, Details: "door open"}}
+	c, _ := New(Config{EndpointID: 1, Delegate: d})
+
+	got, err := invoke(t, c, CmdStart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != ErrorUnableToStartOrResume || c.OperationalState() != StateStopped {
+		t.Errorf("veto: error = %d, state = %d", got, c.OperationalState())
+	}
+}
+
+func TestRVC(t *testing.T) {
+	d := &testDelegate{}
+	c, err := New(Config{EndpointID: 1, Definition: RVCOperationalState, Delegate: d, InitialState: RVCStateDocked})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, cmd := range []datamodel.CommandID{CmdStart, CmdStop} {
+		if _, err := invoke(t, c, cmd); !errors.Is(err, datamodel.ErrUnsupportedCommand) {
+			t.Errorf("cmd 0x%02X: err = %v, want ErrUnsupportedCommand", cmd, err)
+		}
+	}
+
+	if got, _ := invoke(t, c, CmdGoHome); got != ErrorCommandInvalidInState {
+		t.Errorf("GoHome while docked: error = %d, want CommandInvalidInState", got)
+	}
+	if got, _ := invoke(t, c, CmdResume); got != ErrorNoError || c.OperationalState() != StateRunning {
+		t.Errorf("Resume from dock: error = %d, state = %d", got, c.OperationalState())
+	}
+	if got, _ := invoke(t, c, CmdGoHome); got != ErrorNoError || c.OperationalState() != RVCStateSeekingCharger {
+		t.Errorf("GoHome: error = %d, state = %d", got, c.OperationalState())
+	}
+	if got, _ := invoke(t, c, CmdPause); got != ErrorNoError || c.OperationalState() != StatePaused {
+		t.Errorf("Pause while seeking charger: error = %d, state = %d", got, c.OperationalState())
+	}
+	if d.calls[len(d.calls)-2] != "gohome" {
+		t.Errorf("delegate calls = %v", d.calls)
+	}
+}
+
+func TestSetError(t *testing.T) {
+	pub := &mockEventPublisher{}
+	c, err := New(Config{
+		EndpointID:     1,
+		Definition:     RVCOperationalState,
+		InitialState:   StateRunning,
+		EventPublisher: pub,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.SetError(ErrorState{ID: 0x20}); !errors.Is(err, ErrInvalidError) {
+		t.Errorf("reserved error: err = %v, want ErrInvalidError", err)
+	}
+	if err := c.SetError(ErrorState{ID: RVCErrorStuck, Details: "wheel blocked"}); err != nil {
+		t.Fatalf("SetError failed: %v", err)
+	}
+	if c.OperationalState() != StateError || c.OperationalError().ID != RVCErrorStuck {
+		t.Errorf("state = %d, error = %d", c.OperationalState(), c.OperationalError().ID)
+	}
+	if len(pub.events) != 1 || pub.events[0].id != EventOperationalError || pub.events[0].priority != datamodel.EventPriorityCritical {
+		t.Fatalf("events = %+v, want one critical OperationalError", pub.events)
+	}
+
+	// Pause and Resume are invalid in Error.
+	if got, _ := invoke(t, c, CmdResume); got != ErrorCommandInvalidInState {
+		t.Errorf("Resume in Error: error = %d", got)
+	}
+
+	// Going home clears the error.
+	if got, _ := invoke(t, c, CmdGoHome); got != ErrorNoError {
+		t.Errorf("GoHome in Error: error = %d", got)
+	}
+	if c.OperationalError().ID != ErrorNoError {
+		t.Errorf("OperationalError = %d after leaving Error", c.OperationalError().ID)
+	}
+
+	if err := c.CompleteOperation(ErrorNoError, RVCStateDocked); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := pub.events[1].data.(OperationCompletionEvent); !ok || c.OperationalState() != RVCStateDocked {
+		t.Errorf("completion event = %+v, state = %d", pub.events[1].data, c.OperationalState())
+	}
+}
+
+func TestCompleteOperation_Timing(t *testing.T) {
+	pub := &mockEventPublisher{}
+	c, err := New(Config{EndpointID: 1, EventPublisher: pub})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1000, 0)
+	c.now = func() time.Time { return now }
+
+	c.Start()
+	now = now.Add(10 * time.Minute)
+	c.Pause()
+	now = now.Add(2 * time.Minute)
+	c.Resume()
+	now = now.Add(3 * time.Minute)
+	c.Pause()
+	now = now.Add(time.Minute)
+
+	if err := c.CompleteOperation(ErrorNoError, StateStopped); err != nil {
+		t.Fatal(err)
+	}
+	ev := pub.events[0].data.(OperationCompletionEvent)
+	if ev.TotalOperationalTime == nil || *ev.TotalOperationalTime != 16*60 {
+		t.Errorf("TotalOperationalTime = %v, want 960", ev.TotalOperationalTime)
+	}
+	if ev.PausedTime == nil || *ev.PausedTime != 3*60 {
+		t.Errorf("PausedTime = %v, want 180", ev.PausedTime)
+	}
+
+	// Without a running operation, the times are omitted.
+	c.CompleteOperation(ErrorUnableToCompleteOperation, StateStopped)
+	ev = pub.events[1].data.(OperationCompletionEvent)
+	if ev.TotalOperationalTime != nil || ev.PausedTime != nil {
+		t.Errorf("times = %v, %v; want nil", ev.TotalOperationalTime, ev.PausedTime)
+	}
+}
+
+func TestCountdownTime_Reporting(t *testing.T) {
+	c, _ := New(Config{EndpointID: 1})
+	u32 := func(v uint32) *uint32 { return &v }
+
+	steps := []struct {
+		value      *uint32
+		reportable bool
+	}{
+		{u32(600), true}, // null -> value
+		{u32(595), false},
+		{u32(589), true}, // more than 10s since last report
+		{u32(0), true},
+		{nil, true},
+		{nil, false},
+	}
+	for i, s := range steps {
+		before := c.DataVersion()
+		c.SetCountdownTime(s.value)
+		if got := c.DataVersion() != before; got != s.reportable {
+			t.Errorf("step %d: reported = %v, want %v", i, got, s.reportable)
+		}
+	}
+}
+
+func TestCommands(t *testing.T) {
+	oven, err := New(Config{EndpointID: 1, Definition: OvenCavityOperationalState})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := invoke(t, oven, CmdPause); !errors.Is(err, datamodel.ErrUnsupportedCommand) {
+		t.Errorf("oven Pause: err = %v, want ErrUnsupportedCommand", err)
+	}
+	if got, _ := invoke(t, oven, CmdStart); got != ErrorNoError || oven.OperationalState() != StateRunning {
+		t.Errorf("oven Start: error = %d, state = %d", got, oven.OperationalState())
+	}
+
+	// A plain Operational State without Start/Stop, e.g. a dishwasher that
+	// is only started locally.
+	c, _ := New(Config{EndpointID: 1, Commands: CommandsPauseResume | CommandsGoHome})
+	cmds := c.AcceptedCommandList()
+	if len(cmds) != 2 || cmds[0].ID != CmdPause || cmds[1].ID != CmdResume {
+		t.Errorf("AcceptedCommandList = %+v, want Pause, Resume", cmds)
+	}
+	if _, err := invoke(t, c, CmdStart); !errors.Is(err, datamodel.ErrUnsupportedCommand) {
+		t.Errorf("Start: err = %v, want ErrUnsupportedCommand", err)
+	}
+}
+
+func TestReadAttributes(t *testing.T) {
+	c, err := New(Config{EndpointID: 1, PhaseList: []string{"Pre-wash", "Wash", "Rinse"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetCurrentPhase(3); !errors.Is(err, ErrInvalidPhase) {
+		t.Errorf("SetCurrentPhase(3): err = %v, want ErrInvalidPhase", err)
+	}
+	c.SetCurrentPhase(1)
+
+	read := func(attr datamodel.AttributeID) *tlv.Reader {
+		var buf bytes.Buffer
+		req := datamodel.ReadAttributeRequest{
+			Path: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: c.ID(), Attribute: attr},
+		}
+		if err := c.ReadAttribute(context.Background(), req, tlv.NewWriter(&buf)); err != nil {
+			t.Fatalf("read 0x%04X failed: %v", attr, err)
+		}
+		r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+		r.Next()
+		return r
+	}
+
+	if v, _ := read(AttrCurrentPhase).Uint(); v != 1 {
+		t.Errorf("CurrentPhase = %d, want 1", v)
+	}
+	if r := read(AttrCountdownTime); r.Type() != tlv.ElementTypeNull {
+		t.Error("CountdownTime should be null")
+	}
+
+	r := read(AttrOperationalStateList)
+	r.EnterContainer()
+	n := 0
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		n++
+		r.Skip()
+	}
+	if n != 4 {
+		t.Errorf("OperationalStateList has %d entries, want 4", n)
+	}
+
+	r = read(AttrOperationalError)
+	r.EnterContainer()
+	r.Next()
+	if v, _ := r.Uint(); v != uint64(ErrorNoError) {
+		t.Errorf("OperationalError.ErrorStateID = %d, want 0", v)
+	}
+}
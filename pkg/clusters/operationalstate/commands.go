@@ -0,0 +1,142 @@
+package operationalstate
+
+import (
+	"context"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	return datamodel.ResponseFields(c.InvokeCommandResponse(ctx, req, r))
+}
+
+// InvokeCommandResponse implements datamodel.ClusterWithCommandResponses.
+// Every command is answered with an OperationalCommandResponse.
+func (c *Cluster) InvokeCommandResponse(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) (*datamodel.CommandResponse, error) {
+	var result ErrorState
+	switch req.Path.Command {
+	case CmdPause, CmdResume:
+		if !c.hasCommands(CommandsPauseResume) {
+			return nil, datamodel.ErrUnsupportedCommand
+		}
+		if req.Path.Command == CmdPause {
+			result = c.Pause()
+		} else {
+			result = c.Resume()
+		}
+	case CmdStart, CmdStop:
+		if !c.hasCommands(CommandsStartStop) {
+			return nil, datamodel.ErrUnsupportedCommand
+		}
+		if req.Path.Command == CmdStart {
+			result = c.Start()
+		} else {
+			result = c.Stop()
+		}
+	case CmdGoHome:
+		if !c.hasCommands(CommandsGoHome) {
+			return nil, datamodel.ErrUnsupportedCommand
+		}
+		result = c.GoHome()
+	default:
+		return nil, datamodel.ErrUnsupportedCommand
+	}
+	return newOperationalCommandResponse(result)
+}
+
+// Pause handles the Pause command. It is a no-op in Paused and is
+// accepted in Running and the definition's PauseStates.
+//
+// Spec: Section 1.14.6.1
+func (c *Cluster) Pause() ErrorState {
+	return c.run(func(s State) (bool, bool) {
+		return s == StatePaused, s == StateRunning || containsState(c.config.Definition.PauseStates, s)
+	}, func(d Delegate) ErrorState { return d.HandlePause() }, StatePaused)
+}
+
+// Resume handles the Resume command. It is a no-op in Running and is
+// accepted in Paused and the definition's ResumeStates.
+//
+// Spec: Section 1.14.6.4
+func (c *Cluster) Resume() ErrorState {
+	return c.run(func(s State) (bool, bool) {
+		return s == StateRunning, s == StatePaused || containsState(c.config.Definition.ResumeStates, s)
+	}, func(d Delegate) ErrorState { return d.HandleResume() }, StateRunning)
+}
+
+// Start handles the Start command. It is a no-op in Running.
+//
+// Spec: Section 1.14.6.3
+func (c *Cluster) Start() ErrorState {
+	return c.run(func(s State) (bool, bool) {
+		return s == StateRunning, true
+	}, func(d Delegate) ErrorState { return d.HandleStart() }, StateRunning)
+}
+
+// Stop handles the Stop command. It is a no-op in Stopped.
+//
+// Spec: Section 1.14.6.2
+func (c *Cluster) Stop() ErrorState {
+	return c.run(func(s State) (bool, bool) {
+		return s == StateStopped, true
+	}, func(d Delegate) ErrorState { return d.HandleStop() }, StateStopped)
+}
+
+// GoHome handles the RVC GoHome command. It is a no-op while already
+// heading home and invalid in the definition's HomeStates.
+//
+// Spec: Section 7.4.5.1
+func (c *Cluster) GoHome() ErrorState {
+	def := c.config.Definition
+	return c.run(func(s State) (bool, bool) {
+		return s == def.HomeState, !containsState(def.HomeStates, s)
+	}, func(d Delegate) ErrorState {
+		if gh, ok := d.(GoHomeDelegate); ok {
+			return gh.HandleGoHome()
+		}
+		return NoError
+	}, def.HomeState)
+}
+
+// run checks a command against the current state, consults the delegate
+// and applies the transition to next. check reports whether the command is
+// a no-op and whether it is valid in the given state.
+func (c *Cluster) run(check func(State) (noop, valid bool), call func(Delegate) ErrorState, next State) ErrorState {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	c.mu.Lock()
+	state := c.state
+	c.mu.Unlock()
+
+	noop, valid := check(state)
+	if noop {
+		return NoError
+	}
+	if !valid || !c.supportsState(next) {
+		return ErrorState{ID: ErrorCommandInvalidInState}
+	}
+
+	if c.config.Delegate != nil {
+		if result := call(c.config.Delegate); result.ID != ErrorNoError {
+			return result
+		}
+	}
+
+	c.mu.Lock()
+	changed := c.setStateLocked(next)
+	c.mu.Unlock()
+
+	c.notifyState(next, changed)
+	return NoError
+}
+
+// newOperationalCommandResponse builds an OperationalCommandResponse
+// (Spec 1.14.6.5).
+func newOperationalCommandResponse(result ErrorState) (*datamodel.CommandResponse, error) {
+	return datamodel.NewCommandResponse(CmdOperationalCommandResponse, func(w *tlv.Writer) error {
+		return result.marshal(w, tlv.ContextTag(0))
+	})
+}
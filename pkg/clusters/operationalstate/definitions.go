@@ -0,0 +1,202 @@
+package operationalstate
+
+import "github.com/backkem/matter/pkg/datamodel"
+
+// RVC Operational State states (Spec 7.4.4.1).
+const (
+	RVCStateSeekingCharger   State = 0x40
+	RVCStateCharging         State = 0x41
+	RVCStateDocked           State = 0x42
+	RVCStateEmptyingDustBin  State = 0x43
+	RVCStateCleaningMop      State = 0x44
+	RVCStateFillingWaterTank State = 0x45
+	RVCStateUpdatingMaps     State = 0x46
+)
+
+// RVC Operational State errors (Spec 7.4.4.2).
+const (
+	RVCErrorFailedToFindChargingDock ErrorStateID = 0x40
+	RVCErrorStuck                    ErrorStateID = 0x41
+	RVCErrorDustBinMissing           ErrorStateID = 0x42
+	RVCErrorDustBinFull              ErrorStateID = 0x43
+	RVCErrorWaterTankEmpty           ErrorStateID = 0x44
+	RVCErrorWaterTankMissing         ErrorStateID = 0x45
+	RVCErrorWaterTankLidOpen         ErrorStateID = 0x46
+	RVCErrorMopCleaningPadMissing    ErrorStateID = 0x47
+)
+
+// Commands is a set of optional command groups.
+type Commands uint8
+
+const (
+	// CommandsPauseResume enables Pause and Resume.
+	CommandsPauseResume Commands = 1 << 0
+
+	// CommandsStartStop enables Start and Stop.
+	CommandsStartStop Commands = 1 << 1
+
+	// CommandsGoHome enables the RVC GoHome command.
+	CommandsGoHome Commands = 1 << 2
+)
+
+// Value ranges shared by states and errors (Spec 1.14.4).
+const (
+	derivedMin = 0x40
+	derivedMax = 0x7F
+	mfgMin     = 0x80
+	mfgMax     = 0xBF
+)
+
+// Definition describes the Operational State cluster or a cluster derived
+// from it.
+type Definition struct {
+	// ClusterID of the cluster.
+	ClusterID datamodel.ClusterID
+
+	// Revision of the cluster.
+	Revision uint16
+
+	// Name of the cluster.
+	Name string
+
+	// States are the derived states (0x40-0x7F) the cluster defines.
+	States []State
+
+	// MandatoryStates must appear in every OperationalStateList.
+	MandatoryStates []State
+
+	// Errors are the derived errors (0x40-0x7F) the cluster defines.
+	Errors []ErrorStateID
+
+	// PauseStates are derived states, besides Running, from which Pause
+	// is accepted.
+	PauseStates []State
+
+	// ResumeStates are derived states, besides Paused, from which Resume
+	// is accepted.
+	ResumeStates []State
+
+	// Commands are the command groups the cluster allows. Config.Commands
+	// selects a subset of them.
+	Commands Commands
+
+	// HomeState is the state entered on an accepted GoHome.
+	HomeState State
+
+	// HomeStates are states in which GoHome is invalid because the device
+	// is already at home.
+	HomeStates []State
+}
+
+// Definitions of the Operational State cluster and its derived clusters.
+var (
+	OperationalState = Definition{
+		ClusterID:       0x0060,
+		Revision:        2,
+		Name:            "Operational State",
+		MandatoryStates: []State{StateStopped, StateRunning, StatePaused, StateError},
+		Commands:        CommandsPauseResume | CommandsStartStop,
+	}
+
+	OvenCavityOperationalState = Definition{
+		ClusterID:       0x0048,
+		Revision:        2,
+		Name:            "Oven Cavity Operational State",
+		MandatoryStates: []State{StateStopped, StateRunning, StateError},
+		Commands:        CommandsStartStop,
+	}
+
+	RVCOperationalState = Definition{
+		ClusterID: 0x0061,
+		Revision:  2,
+		Name:      "RVC Operational State",
+		States: []State{
+			RVCStateSeekingCharger, RVCStateCharging, RVCStateDocked, RVCStateEmptyingDustBin,
+			RVCStateCleaningMop, RVCStateFillingWaterTank, RVCStateUpdatingMaps,
+		},
+		MandatoryStates: []State{
+			StateStopped, StateRunning, StatePaused, StateError,
+			RVCStateSeekingCharger, RVCStateCharging, RVCStateDocked,
+		},
+		Errors: []ErrorStateID{
+			RVCErrorFailedToFindChargingDock, RVCErrorStuck, RVCErrorDustBinMissing, RVCErrorDustBinFull,
+			RVCErrorWaterTankEmpty, RVCErrorWaterTankMissing, RVCErrorWaterTankLidOpen, RVCErrorMopCleaningPadMissing,
+		},
+		PauseStates:  []State{RVCStateSeekingCharger},
+		ResumeStates: []State{RVCStateCharging, RVCStateDocked},
+		Commands:     CommandsPauseResume | CommandsGoHome,
+		HomeState:    RVCStateSeekingCharger,
+		HomeStates:   []State{RVCStateCharging, RVCStateDocked},
+	}
+)
+
+// defaultStates returns the mandatory states without labels.
+func (d Definition) defaultStates() []StateOption {
+	states := make([]StateOption, len(d.MandatoryStates))
+	for i, s := range d.MandatoryStates {
+		states[i] = StateOption{ID: s}
+	}
+	return states
+}
+
+// validateStates checks a state list: unique IDs, only common, derived or
+// labeled manufacturer states, and all mandatory states present.
+func (d Definition) validateStates(states []StateOption) error {
+	seen := make(map[State]bool, len(states))
+	for _, s := range states {
+		if seen[s.ID] {
+			return ErrInvalidState
+		}
+		seen[s.ID] = true
+
+		if !validLabel(s.Label) {
+			return ErrInvalidLabel
+		}
+		switch {
+		case s.ID <= StateError:
+		case s.ID >= derivedMin && s.ID <= derivedMax:
+			if !containsState(d.States, s.ID) {
+				return ErrInvalidState
+			}
+		case s.ID >= mfgMin && s.ID <= mfgMax:
+			if s.Label == "" {
+				return ErrInvalidLabel
+			}
+		default:
+			return ErrInvalidState
+		}
+	}
+	for _, s := range d.MandatoryStates {
+		if !seen[s] {
+			return ErrInvalidState
+		}
+	}
+	return nil
+}
+
+// validError returns true for common, derived and manufacturer errors.
+func (d Definition) validError(id ErrorStateID) bool {
+	switch {
+	case id <= ErrorCommandInvalidInState:
+		return true
+	case id >= derivedMin && id <= derivedMax:
+		for _, e := range d.Errors {
+			if e == id {
+				return true
+			}
+		}
+		return false
+	default:
+		return id >= mfgMin && id <= mfgMax
+	}
+}
+
+// containsState returns true if s is in states.
+func containsState(states []State, s State) bool {
+	for _, v := range states {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package operationalstate
+
+import (
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// OperationalErrorEvent is emitted when the device enters the Error state (Spec 1.14.7.1).
+// Priority: CRITICAL, Conformance: M
+type OperationalErrorEvent struct {
+	ErrorState ErrorState
+}
+
+// MarshalTLV implements the TLVMarshaler interface.
+func (e OperationalErrorEvent) MarshalTLV(w *tlv.Writer) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	if err := e.ErrorState.marshal(w, tlv.ContextTag(0)); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// OperationCompletionEvent is emitted when an operation ends (Spec 1.14.7.2).
+// Priority: INFO, Conformance: O
+type OperationCompletionEvent struct {
+	CompletionErrorCode  ErrorStateID
+	TotalOperationalTime *uint32 // seconds, optional
+	PausedTime           *uint32 // seconds, optional
+}
+
+// MarshalTLV implements the TLVMarshaler interface.
+func (e OperationCompletionEvent) MarshalTLV(w *tlv.Writer) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(e.CompletionErrorCode)); err != nil {
+		return err
+	}
+	if e.TotalOperationalTime != nil {
+		if err := w.PutUint(tlv.ContextTag(1), uint64(*e.TotalOperationalTime)); err != nil {
+			return err
+		}
+	}
+	if e.PausedTime != nil {
+		if err := w.PutUint(tlv.ContextTag(2), uint64(*e.PausedTime)); err != nil {
+			return err
+		}
+	}
+	return w.EndContainer()
+}
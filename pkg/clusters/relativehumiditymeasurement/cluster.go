@@ -0,0 +1,149 @@
+// Package relativehumiditymeasurement implements the Relative Humidity
+// Measurement Cluster (0x0405).
+//
+// Readings are in units of 0.01% relative humidity (0 to 10000). The
+// device pushes readings via SetMeasuredValue; changes smaller than
+// Config.ReportThreshold are absorbed without a data version bump.
+//
+// Spec Reference: Section 2.6
+//
+// C++ Reference: src/app/clusters/relative-humidity-measurement-server
+package relativehumiditymeasurement
+
+import (
+	"context"
+
+	"github.com/backkem/matter/pkg/clusters"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x0405
+	ClusterRevision uint16              = 3
+)
+
+// Attribute IDs (Spec 2.6.4).
+const (
+	AttrMeasuredValue    = clusters.AttrMeasuredValue
+	AttrMinMeasuredValue = clusters.AttrMinMeasuredValue
+	AttrMaxMeasuredValue = clusters.AttrMaxMeasuredValue
+	AttrTolerance        = clusters.AttrTolerance
+)
+
+// Config provides dependencies for the Relative Humidity Measurement cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to.
+	EndpointID datamodel.EndpointID
+
+	// MinMeasuredValue and MaxMeasuredValue bound the sensor range in
+	// 0.01%. nil reports the bound as unknown (null).
+	MinMeasuredValue *uint16
+	MaxMeasuredValue *uint16
+
+	// Tolerance is the sensor accuracy in 0.01% (optional).
+	Tolerance *uint16
+
+	// ReportThreshold is the minimum change in 0.01% that is reported
+	// to subscribers. Zero reports every change.
+	ReportThreshold uint16
+}
+
+// Cluster implements the Relative Humidity Measurement cluster (0x0405).
+type Cluster struct {
+	*datamodel.ClusterBase
+	value *clusters.MeasuredValue
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Relative Humidity Measurement cluster.
+func New(cfg Config) *Cluster {
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		value: clusters.NewMeasuredValue(clusters.MeasuredValueConfig{
+			Min:             widen(cfg.MinMeasuredValue),
+			Max:             widen(cfg.MaxMeasuredValue),
+			Tolerance:       cfg.Tolerance,
+			ReportThreshold: uint64(cfg.ReportThreshold),
+		}),
+	}
+
+	c.attrList = datamodel.MergeAttributeLists(c.value.AttributeList())
+
+	return c
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	return nil
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return nil
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	if handled, err := c.value.ReadAttribute(req.Path.Attribute, w); handled {
+		return err
+	}
+	return datamodel.ErrUnsupportedAttribute
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	// All Relative Humidity Measurement attributes are read-only
+	return datamodel.ErrUnsupportedWrite
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	return nil, datamodel.ErrUnsupportedCommand
+}
+
+// SetMeasuredValue records a new reading in 0.01%; nil marks it unknown.
+// Returns clusters.ErrMeasurementOutOfRange if outside the configured range.
+func (c *Cluster) SetMeasuredValue(value *uint16) error {
+	report, err := c.value.Set(widen(value))
+	if err != nil {
+		return err
+	}
+	if report {
+		c.IncrementDataVersion()
+	}
+	return nil
+}
+
+// MeasuredValue returns the current reading in 0.01%, or nil if unknown.
+func (c *Cluster) MeasuredValue() *uint16 {
+	v := c.value.Value()
+	if v == nil {
+		return nil
+	}
+	n := uint16(*v)
+	return &n
+}
+
+// widen converts a nullable uint16 to a nullable int64.
+func widen(v *uint16) *int64 {
+	if v == nil {
+		return nil
+	}
+	n := int64(*v)
+	return &n
+}
@@ -0,0 +1,52 @@
+package relativehumiditymeasurement
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+func uint16p(v uint16) *uint16 { return &v }
+
+func TestSetMeasuredValue(t *testing.T) {
+	c := New(Config{
+		EndpointID:       1,
+		MinMeasuredValue: uint16p(0),
+		MaxMeasuredValue: uint16p(10000),
+		Tolerance:        uint16p(200),
+	})
+
+	if c.ID() != ClusterID {
+		t.Errorf("ID() = 0x%04X, want 0x%04X", c.ID(), ClusterID)
+	}
+	if err := c.SetMeasuredValue(uint16p(4550)); err != nil {
+		t.Fatalf("SetMeasuredValue failed: %v", err)
+	}
+	if err := c.SetMeasuredValue(uint16p(10001)); err == nil {
+		t.Error("expected error above MaxMeasuredValue")
+	}
+
+	for attr, want := range map[datamodel.AttributeID]uint64{
+		AttrMeasuredValue:    4550,
+		AttrMaxMeasuredValue: 10000,
+		AttrTolerance:        200,
+	} {
+		var buf bytes.Buffer
+		req := datamodel.ReadAttributeRequest{
+			Path: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: attr},
+		}
+		if err := c.ReadAttribute(context.Background(), req, tlv.NewWriter(&buf)); err != nil {
+			t.Fatalf("ReadAttribute(0x%04X) failed: %v", attr, err)
+		}
+		r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+		if err := r.Next(); err != nil {
+			t.Fatal(err)
+		}
+		if v, err := r.Uint(); err != nil || v != want {
+			t.Errorf("attribute 0x%04X = %d, %v; want %d", attr, v, err, want)
+		}
+	}
+}
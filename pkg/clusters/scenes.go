@@ -0,0 +1,258 @@
+package clusters
+
+import (
+	"errors"
+	"time"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// ErrInvalidExtensionFieldSet is returned when a scene extension field set
+// is malformed or names attributes its cluster cannot recall.
+var ErrInvalidExtensionFieldSet = errors.New("invalid scene extension field set")
+
+// SceneValueType selects the field an AttributeValuePairStruct carries its
+// value in. The value is the field's context tag.
+type SceneValueType uint8
+
+const (
+	SceneValueUnsigned8  SceneValueType = 1
+	SceneValueSigned8    SceneValueType = 2
+	SceneValueUnsigned16 SceneValueType = 3
+	SceneValueSigned16   SceneValueType = 4
+	SceneValueUnsigned32 SceneValueType = 5
+	SceneValueSigned32   SceneValueType = 6
+	SceneValueUnsigned64 SceneValueType = 7
+	SceneValueSigned64   SceneValueType = 8
+)
+
+// signed reports whether the value type is a signed integer.
+func (t SceneValueType) signed() bool {
+	return t%2 == 0
+}
+
+// AttributeValuePair is the value of one attribute in a scene
+// (AttributeValuePairStruct of the Scenes Management cluster).
+type AttributeValuePair struct {
+	AttributeID datamodel.AttributeID
+	Type        SceneValueType
+
+	// Value holds the value's bits; signed values are two's complement.
+	Value uint64
+}
+
+// Signed returns the value as a signed integer.
+func (p AttributeValuePair) Signed() int64 {
+	return int64(p.Value)
+}
+
+// ExtensionFieldSet is the state a scene stores for one cluster
+// (ExtensionFieldSetStruct of the Scenes Management cluster).
+type ExtensionFieldSet struct {
+	ClusterID          datamodel.ClusterID
+	AttributeValueList []AttributeValuePair
+}
+
+// Find returns the pair of the given attribute.
+func (s ExtensionFieldSet) Find(attr datamodel.AttributeID) (AttributeValuePair, bool) {
+	for _, p := range s.AttributeValueList {
+		if p.AttributeID == attr {
+			return p, true
+		}
+	}
+	return AttributeValuePair{}, false
+}
+
+// MarshalTLV writes an ExtensionFieldSetStruct with an anonymous tag.
+func (s ExtensionFieldSet) MarshalTLV(w *tlv.Writer) error {
+	return s.Marshal(w, tlv.Anonymous())
+}
+
+// Marshal writes an ExtensionFieldSetStruct with the given tag.
+func (s ExtensionFieldSet) Marshal(w *tlv.Writer, tag tlv.Tag) error {
+	if err := w.StartStructure(tag); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(s.ClusterID)); err != nil {
+		return err
+	}
+	if err := w.StartArray(tlv.ContextTag(1)); err != nil {
+		return err
+	}
+	for _, p := range s.AttributeValueList {
+		if err := p.marshal(w); err != nil {
+			return err
+		}
+	}
+	if err := w.EndContainer(); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// marshal writes an anonymous AttributeValuePairStruct.
+func (p AttributeValuePair) marshal(w *tlv.Writer) error {
+	if p.Type < SceneValueUnsigned8 || p.Type > SceneValueSigned64 {
+		return ErrInvalidExtensionFieldSet
+	}
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(p.AttributeID)); err != nil {
+		return err
+	}
+	var err error
+	if p.Type.signed() {
+		err = w.PutInt(tlv.ContextTag(uint8(p.Type)), p.Signed())
+	} else {
+		err = w.PutUint(tlv.ContextTag(uint8(p.Type)), p.Value)
+	}
+	if err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// UnmarshalTLV reads an ExtensionFieldSetStruct. The reader must be
+// positioned on the structure.
+func (s *ExtensionFieldSet) UnmarshalTLV(r *tlv.Reader) error {
+	if r.Type() != tlv.ElementTypeStruct {
+		return ErrInvalidExtensionFieldSet
+	}
+	if err := r.EnterContainer(); err != nil {
+		return err
+	}
+
+	*s = ExtensionFieldSet{}
+	hasCluster := false
+	for {
+		if err := r.Next(); err != nil {
+			return err
+		}
+		if r.IsEndOfContainer() {
+			break
+		}
+		tag := r.Tag()
+		if !tag.IsContext() {
+			continue
+		}
+		switch tag.TagNumber() {
+		case 0: // ClusterID
+			v, err := r.Uint()
+			if err != nil {
+				return err
+			}
+			s.ClusterID = datamodel.ClusterID(v)
+			hasCluster = true
+		case 1: // AttributeValueList
+			if err := s.unmarshalPairs(r); err != nil {
+				return err
+			}
+		}
+	}
+	if err := r.ExitContainer(); err != nil {
+		return err
+	}
+	if !hasCluster {
+		return ErrMissingField
+	}
+	return nil
+}
+
+// unmarshalPairs reads the AttributeValueList.
+func (s *ExtensionFieldSet) unmarshalPairs(r *tlv.Reader) error {
+	if err := r.EnterContainer(); err != nil {
+		return err
+	}
+	for {
+		if err := r.Next(); err != nil {
+			return err
+		}
+		if r.IsEndOfContainer() {
+			break
+		}
+		var p AttributeValuePair
+		if err := p.unmarshal(r); err != nil {
+			return err
+		}
+		s.AttributeValueList = append(s.AttributeValueList, p)
+	}
+	return r.ExitContainer()
+}
+
+// unmarshal reads an AttributeValuePairStruct, which carries exactly one
+// value field.
+func (p *AttributeValuePair) unmarshal(r *tlv.Reader) error {
+	if r.Type() != tlv.ElementTypeStruct {
+		return ErrInvalidExtensionFieldSet
+	}
+	if err := r.EnterContainer(); err != nil {
+		return err
+	}
+	hasAttr := false
+	for {
+		if err := r.Next(); err != nil {
+			return err
+		}
+		if r.IsEndOfContainer() {
+			break
+		}
+		tag := r.Tag()
+		if !tag.IsContext() {
+			continue
+		}
+		n := tag.TagNumber()
+		switch {
+		case n == 0: // AttributeID
+			v, err := r.Uint()
+			if err != nil {
+				return err
+			}
+			p.AttributeID = datamodel.AttributeID(v)
+			hasAttr = true
+		case n <= uint32(SceneValueSigned64):
+			if p.Type != 0 {
+				return ErrInvalidExtensionFieldSet
+			}
+			p.Type = SceneValueType(n)
+			if p.Type.signed() {
+				v, err := r.Int()
+				if err != nil {
+					return err
+				}
+				p.Value = uint64(v)
+			} else {
+				v, err := r.Uint()
+				if err != nil {
+					return err
+				}
+				p.Value = v
+			}
+		}
+	}
+	if err := r.ExitContainer(); err != nil {
+		return err
+	}
+	if !hasAttr || p.Type == 0 {
+		return ErrMissingField
+	}
+	return nil
+}
+
+// SceneParticipant is implemented by clusters whose state the Scenes
+// Management cluster stores and recalls. Each cluster describes its own
+// scene-able attributes as an ExtensionFieldSet, so the scenes server
+// handles the TLV encoding of every cluster the same way.
+type SceneParticipant interface {
+	// CaptureScene returns the cluster's current scene-able state, for
+	// StoreScene.
+	CaptureScene() ExtensionFieldSet
+
+	// RecallScene applies a stored field set, moving to it over
+	// transitionTime where the cluster supports transitions. Attributes
+	// missing from the set keep their value. It returns
+	// ErrInvalidExtensionFieldSet if the set is for another cluster or
+	// carries an attribute the cluster cannot recall.
+	RecallScene(efs ExtensionFieldSet, transitionTime time.Duration) error
+}
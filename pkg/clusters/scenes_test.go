@@ -0,0 +1,72 @@
+package clusters
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+func TestExtensionFieldSet_RoundTrip(t *testing.T) {
+	efs := ExtensionFieldSet{
+		ClusterID: 0x0300,
+		AttributeValueList: []AttributeValuePair{
+			{AttributeID: 0x0003, Type: SceneValueUnsigned16, Value: 0x616B},
+			{AttributeID: 0x0010, Type: SceneValueSigned8, Value: uint64(0xFFFFFFFFFFFFFFFE)}, // -2
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := efs.MarshalTLV(tlv.NewWriter(&buf)); err != nil {
+		t.Fatalf("MarshalTLV failed: %v", err)
+	}
+
+	r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+	if err := r.Next(); err != nil {
+		t.Fatalf("Next failed: %v", err)
+	}
+	var got ExtensionFieldSet
+	if err := got.UnmarshalTLV(r); err != nil {
+		t.Fatalf("UnmarshalTLV failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, efs) {
+		t.Errorf("round trip = %+v, want %+v", got, efs)
+	}
+	if p, ok := got.Find(0x0010); !ok || p.Signed() != -2 {
+		t.Errorf("Find(0x0010) = %+v, %v, want -2", p, ok)
+	}
+	if _, ok := got.Find(0x0004); ok {
+		t.Error("Find(0x0004) found a pair that is not in the set")
+	}
+}
+
+func TestExtensionFieldSet_Invalid(t *testing.T) {
+	efs := ExtensionFieldSet{
+		ClusterID:          0x0006,
+		AttributeValueList: []AttributeValuePair{{AttributeID: 0x0000}},
+	}
+	if err := efs.MarshalTLV(tlv.NewWriter(&bytes.Buffer{})); !errors.Is(err, ErrInvalidExtensionFieldSet) {
+		t.Errorf("MarshalTLV without a value type = %v, want ErrInvalidExtensionFieldSet", err)
+	}
+
+	// A pair without a value
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	_ = w.StartStructure(tlv.Anonymous())
+	_ = w.PutUint(tlv.ContextTag(0), 0x0006)
+	_ = w.StartArray(tlv.ContextTag(1))
+	_ = w.StartStructure(tlv.Anonymous())
+	_ = w.PutUint(tlv.ContextTag(0), 0x0000)
+	_ = w.EndContainer()
+	_ = w.EndContainer()
+	_ = w.EndContainer()
+
+	r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+	_ = r.Next()
+	var got ExtensionFieldSet
+	if err := got.UnmarshalTLV(r); !errors.Is(err, ErrMissingField) {
+		t.Errorf("UnmarshalTLV = %v, want ErrMissingField", err)
+	}
+}
@@ -0,0 +1,556 @@
+// Package servicearea implements the Service Area Cluster (0x0150).
+//
+// A device such as a robotic vacuum cleaner describes the areas it can
+// serve, optionally grouped into maps. Clients select the areas for the
+// next operation with SelectAreas and may skip the current area with
+// SkipArea; the Delegate decides whether a request fits the device's
+// current mode. The device reports where it is and, with the PROG feature,
+// its progress through the selected areas.
+//
+// Spec Reference: Section 1.17
+//
+// C++ Reference: src/app/clusters/service-area-server
+package servicearea
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x0150
+	ClusterRevision uint16              = 1
+)
+
+// Attribute IDs (Spec 1.17.6).
+const (
+	AttrSupportedAreas   datamodel.AttributeID = 0x0000
+	AttrSupportedMaps    datamodel.AttributeID = 0x0001
+	AttrSelectedAreas    datamodel.AttributeID = 0x0002
+	AttrCurrentArea      datamodel.AttributeID = 0x0003
+	AttrEstimatedEndTime datamodel.AttributeID = 0x0004
+	AttrProgress         datamodel.AttributeID = 0x0005
+)
+
+// Command IDs (Spec 1.17.7).
+const (
+	CmdSelectAreas         datamodel.CommandID = 0x00
+	CmdSelectAreasResponse datamodel.CommandID = 0x01
+	CmdSkipArea            datamodel.CommandID = 0x02
+	CmdSkipAreaResponse    datamodel.CommandID = 0x03
+)
+
+// Feature bits (Spec 1.17.4).
+type Feature uint32
+
+const (
+	// FeatureSelectWhileRunning allows SelectAreas while operating (SELRUN).
+	FeatureSelectWhileRunning Feature = 1 << 0
+
+	// FeatureProgressReporting adds the Progress attribute (PROG).
+	FeatureProgressReporting Feature = 1 << 1
+
+	// FeatureMaps groups areas into maps (MAPS).
+	FeatureMaps Feature = 1 << 2
+)
+
+// Errors returned by configuration and the device-side API.
+var (
+	ErrInvalidArea         = errors.New("servicearea: invalid area")
+	ErrDuplicateArea       = errors.New("servicearea: duplicate area ID")
+	ErrInvalidMap          = errors.New("servicearea: invalid map")
+	ErrDuplicateMap        = errors.New("servicearea: duplicate map ID or name")
+	ErrUnknownArea         = errors.New("servicearea: area not supported")
+	ErrTooManyEntries      = errors.New("servicearea: too many entries")
+	ErrFeatureNotSupported = errors.New("servicearea: feature or attribute not supported")
+)
+
+// Delegate connects the cluster to the device's operation.
+type Delegate interface {
+	// HandleSelectAreas is called with a validated, duplicate-free set of
+	// supported areas before SelectedAreas changes. A non-Success status
+	// rejects the request, e.g. InvalidInMode while operating without
+	// FeatureSelectWhileRunning, or InvalidSet if the areas cannot be
+	// served together.
+	HandleSelectAreas(areas []uint32) (SelectAreasStatus, string)
+
+	// HandleSkipArea stops serving the area and moves on. A non-Success
+	// status rejects the request, e.g. InvalidInMode while idle.
+	HandleSkipArea(areaID uint32) (SkipAreaStatus, string)
+}
+
+// Config provides dependencies for the Service Area cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to.
+	EndpointID datamodel.EndpointID
+
+	// FeatureMap indicates supported features.
+	FeatureMap Feature
+
+	// SupportedMaps lists the maps (MAPS only).
+	SupportedMaps []Map
+
+	// SupportedAreas lists the areas the device can serve.
+	SupportedAreas []Area
+
+	// Optional attributes
+	EnableCurrentArea      bool
+	EnableEstimatedEndTime bool // requires EnableCurrentArea
+
+	// EnableSkipArea adds the SkipArea command.
+	EnableSkipArea bool
+
+	// Delegate decides on area requests (optional).
+	Delegate Delegate
+
+	// OnSelectedAreasChange is called when SelectedAreas changes (optional).
+	OnSelectedAreasChange func(endpoint datamodel.EndpointID, areas []uint32)
+}
+
+// Cluster implements the Service Area cluster (0x0150).
+type Cluster struct {
+	*datamodel.ClusterBase
+	config Config
+
+	// cmdMu serializes commands.
+	cmdMu sync.Mutex
+
+	// Mutable state (protected by mutex)
+	mu               sync.RWMutex
+	maps             []Map
+	areas            []Area
+	selected         []uint32
+	currentArea      *uint32
+	estimatedEndTime *uint32
+	progress         []Progress
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Service Area cluster. It returns an error if the maps
+// or areas are invalid.
+func New(cfg Config) (*Cluster, error) {
+	if cfg.FeatureMap&FeatureMaps == 0 && len(cfg.SupportedMaps) > 0 {
+		return nil, ErrFeatureNotSupported
+	}
+	if cfg.EnableEstimatedEndTime && !cfg.EnableCurrentArea {
+		return nil, ErrFeatureNotSupported
+	}
+
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		config:      cfg,
+	}
+	if err := c.validateMaps(cfg.SupportedMaps); err != nil {
+		return nil, err
+	}
+	c.maps = append([]Map(nil), cfg.SupportedMaps...)
+	if err := c.validateAreasLocked(cfg.SupportedAreas); err != nil {
+		return nil, err
+	}
+	c.areas = append([]Area(nil), cfg.SupportedAreas...)
+
+	c.ClusterBase.SetFeatureMap(uint32(cfg.FeatureMap))
+
+	c.attrList = c.buildAttributeList()
+
+	return c, nil
+}
+
+// hasFeature returns true if the feature bit is set.
+func (c *Cluster) hasFeature(f Feature) bool {
+	return c.config.FeatureMap&f != 0
+}
+
+// validateMaps checks a SupportedMaps list: IDs and names are unique.
+func (c *Cluster) validateMaps(maps []Map) error {
+	if len(maps) > MaxMaps {
+		return ErrTooManyEntries
+	}
+	ids := make(map[uint32]bool, len(maps))
+	names := make(map[string]bool, len(maps))
+	for _, m := range maps {
+		if m.Name == "" || len(m.Name) > MaxMapNameLength {
+			return ErrInvalidMap
+		}
+		if ids[m.MapID] || names[m.Name] {
+			return ErrDuplicateMap
+		}
+		ids[m.MapID] = true
+		names[m.Name] = true
+	}
+	return nil
+}
+
+// validateAreasLocked checks a SupportedAreas list against the current
+// maps. Caller must hold c.mu (or be constructing the cluster).
+func (c *Cluster) validateAreasLocked(areas []Area) error {
+	if len(areas) > MaxAreas {
+		return ErrTooManyEntries
+	}
+	ids := make(map[uint32]bool, len(areas))
+	for _, a := range areas {
+		if ids[a.AreaID] {
+			return ErrDuplicateArea
+		}
+		ids[a.AreaID] = true
+
+		info := a.AreaInfo
+		if info.LocationInfo == nil && info.LandmarkInfo == nil {
+			return ErrInvalidArea
+		}
+		if info.LocationInfo != nil && len(info.LocationInfo.LocationName) > MaxLocationNameLength {
+			return ErrInvalidArea
+		}
+		if c.hasFeature(FeatureMaps) {
+			if a.MapID == nil || !c.hasMapLocked(*a.MapID) {
+				return ErrInvalidMap
+			}
+		} else if a.MapID != nil {
+			return ErrInvalidMap
+		}
+	}
+	return nil
+}
+
+// hasMapLocked returns true if the map is supported. Caller must hold c.mu.
+func (c *Cluster) hasMapLocked(id uint32) bool {
+	for _, m := range c.maps {
+		if m.MapID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAreaLocked returns true if the area is supported. Caller must hold c.mu.
+func (c *Cluster) hasAreaLocked(id uint32) bool {
+	for _, a := range c.areas {
+		if a.AreaID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// buildAttributeList constructs the list of supported attributes.
+func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+	list := datamodel.AttrQualityList
+	nullable := datamodel.AttrQualityNullable
+
+	attrs := []datamodel.AttributeEntry{
+		datamodel.NewReadOnlyAttribute(AttrSupportedAreas, list, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrSelectedAreas, list, viewPriv),
+	}
+	if c.hasFeature(FeatureMaps) {
+		attrs = append(attrs, datamodel.NewReadOnlyAttribute(AttrSupportedMaps, list, viewPriv))
+	}
+	if c.config.EnableCurrentArea {
+		attrs = append(attrs, datamodel.NewReadOnlyAttribute(AttrCurrentArea, nullable, viewPriv))
+	}
+	if c.config.EnableEstimatedEndTime {
+		attrs = append(attrs, datamodel.NewReadOnlyAttribute(AttrEstimatedEndTime, nullable, viewPriv))
+	}
+	if c.hasFeature(FeatureProgressReporting) {
+		attrs = append(attrs, datamodel.NewReadOnlyAttribute(AttrProgress, list, viewPriv))
+	}
+
+	return datamodel.MergeAttributeLists(attrs)
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	cmds := []datamodel.CommandEntry{
+		datamodel.NewCommandEntry(CmdSelectAreas, 0, datamodel.PrivilegeOperate),
+	}
+	if c.config.EnableSkipArea {
+		cmds = append(cmds, datamodel.NewCommandEntry(CmdSkipArea, 0, datamodel.PrivilegeOperate))
+	}
+	return cmds
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	cmds := []datamodel.CommandID{CmdSelectAreasResponse}
+	if c.config.EnableSkipArea {
+		cmds = append(cmds, CmdSkipAreaResponse)
+	}
+	return cmds
+}
+
+// hasAttribute returns true if attr is in the attribute list.
+func (c *Cluster) hasAttribute(attr datamodel.AttributeID) bool {
+	for _, a := range c.attrList {
+		if a.ID == attr {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	if !c.hasAttribute(req.Path.Attribute) {
+		return datamodel.ErrUnsupportedAttribute
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	switch req.Path.Attribute {
+	case AttrSupportedAreas:
+		return writeList(w, c.areas)
+	case AttrSupportedMaps:
+		return writeList(w, c.maps)
+	case AttrSelectedAreas:
+		if err := w.StartArray(tlv.Anonymous()); err != nil {
+			return err
+		}
+		for _, id := range c.selected {
+			if err := w.PutUint(tlv.Anonymous(), uint64(id)); err != nil {
+				return err
+			}
+		}
+		return w.EndContainer()
+	case AttrCurrentArea:
+		return putNullableUint(w, tlv.Anonymous(), c.currentArea)
+	case AttrEstimatedEndTime:
+		return putNullableUint(w, tlv.Anonymous(), c.estimatedEndTime)
+	case AttrProgress:
+		return writeList(w, c.progress)
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	return datamodel.ErrUnsupportedWrite
+}
+
+// SupportedAreas returns the supported areas.
+func (c *Cluster) SupportedAreas() []Area {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]Area(nil), c.areas...)
+}
+
+// SelectedAreas returns the areas selected for the next operation. An
+// empty list means the device serves all areas.
+func (c *Cluster) SelectedAreas() []uint32 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]uint32(nil), c.selected...)
+}
+
+// SetSupportedMaps replaces the maps (MAPS only). Areas on removed maps
+// are removed as well.
+func (c *Cluster) SetSupportedMaps(maps []Map) error {
+	if !c.hasFeature(FeatureMaps) {
+		return ErrFeatureNotSupported
+	}
+	if err := c.validateMaps(maps); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.maps = append([]Map(nil), maps...)
+	var areas []Area
+	for _, a := range c.areas {
+		if c.hasMapLocked(*a.MapID) {
+			areas = append(areas, a)
+		}
+	}
+	c.IncrementDataVersion()
+	c.mu.Unlock()
+
+	return c.SetSupportedAreas(areas)
+}
+
+// SetSupportedAreas replaces the areas. Removed areas are dropped from
+// SelectedAreas, CurrentArea and Progress.
+func (c *Cluster) SetSupportedAreas(areas []Area) error {
+	c.mu.Lock()
+	if err := c.validateAreasLocked(areas); err != nil {
+		c.mu.Unlock()
+		return err
+	}
+	c.areas = append([]Area(nil), areas...)
+
+	selected := c.selected[:0:0]
+	for _, id := range c.selected {
+		if c.hasAreaLocked(id) {
+			selected = append(selected, id)
+		}
+	}
+	selectionChanged := len(selected) != len(c.selected)
+	c.selected = selected
+	if c.currentArea != nil && !c.hasAreaLocked(*c.currentArea) {
+		c.currentArea = nil
+		c.estimatedEndTime = nil
+	}
+	progress := c.progress[:0:0]
+	for _, p := range c.progress {
+		if c.hasAreaLocked(p.AreaID) {
+			progress = append(progress, p)
+		}
+	}
+	c.progress = progress
+	c.IncrementDataVersion()
+	c.mu.Unlock()
+
+	if selectionChanged {
+		c.notifySelection(selected)
+	}
+	return nil
+}
+
+// SetCurrentArea reports the area the device is in, or nil if unknown.
+// Clearing the area also clears EstimatedEndTime.
+func (c *Cluster) SetCurrentArea(areaID *uint32) error {
+	if !c.config.EnableCurrentArea {
+		return ErrFeatureNotSupported
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if areaID != nil && !c.hasAreaLocked(*areaID) {
+		return ErrUnknownArea
+	}
+	if equalUint32(c.currentArea, areaID) {
+		return nil
+	}
+	c.currentArea = copyUint32(areaID)
+	if areaID == nil {
+		c.estimatedEndTime = nil
+	}
+	c.IncrementDataVersion()
+	return nil
+}
+
+// SetEstimatedEndTime reports when the device expects to finish the
+// current area, in seconds since the Matter epoch. Requires a current area.
+func (c *Cluster) SetEstimatedEndTime(epochSeconds *uint32) error {
+	if !c.config.EnableEstimatedEndTime {
+		return ErrFeatureNotSupported
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if epochSeconds != nil && c.currentArea == nil {
+		return datamodel.ErrInvalidInState
+	}
+	if equalUint32(c.estimatedEndTime, epochSeconds) {
+		return nil
+	}
+	c.estimatedEndTime = copyUint32(epochSeconds)
+	c.IncrementDataVersion()
+	return nil
+}
+
+// SetProgress replaces the progress list (PROG only). Each entry must
+// refer to a distinct supported area.
+func (c *Cluster) SetProgress(progress []Progress) error {
+	if !c.hasFeature(FeatureProgressReporting) {
+		return ErrFeatureNotSupported
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	seen := make(map[uint32]bool, len(progress))
+	for _, p := range progress {
+		if !c.hasAreaLocked(p.AreaID) {
+			return ErrUnknownArea
+		}
+		if seen[p.AreaID] || p.Status > OperationalStatusCompleted {
+			return ErrInvalidArea
+		}
+		seen[p.AreaID] = true
+	}
+	c.progress = append([]Progress(nil), progress...)
+	c.IncrementDataVersion()
+	return nil
+}
+
+// SetAreaStatus updates the status of one area in the progress list
+// (PROG only).
+func (c *Cluster) SetAreaStatus(areaID uint32, status OperationalStatus) error {
+	if !c.hasFeature(FeatureProgressReporting) {
+		return ErrFeatureNotSupported
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i := range c.progress {
+		if c.progress[i].AreaID == areaID {
+			if c.progress[i].Status != status {
+				c.progress[i].Status = status
+				c.IncrementDataVersion()
+			}
+			return nil
+		}
+	}
+	return ErrUnknownArea
+}
+
+// notifySelection calls OnSelectedAreasChange.
+func (c *Cluster) notifySelection(areas []uint32) {
+	if c.config.OnSelectedAreasChange != nil {
+		c.config.OnSelectedAreasChange(c.config.EndpointID, append([]uint32(nil), areas...))
+	}
+}
+
+// marshaler is implemented by the attribute struct types.
+type marshaler interface {
+	MarshalTLV(w *tlv.Writer) error
+}
+
+// writeList writes a list attribute of structs.
+func writeList[T marshaler](w *tlv.Writer, items []T) error {
+	if err := w.StartArray(tlv.Anonymous()); err != nil {
+		return err
+	}
+	for _, item := range items {
+		if err := item.MarshalTLV(w); err != nil {
+			return err
+		}
+	}
+	return w.EndContainer()
+}
+
+func copyUint32(v *uint32) *uint32 {
+	if v == nil {
+		return nil
+	}
+	c := *v
+	return &c
+}
+
+func equalUint32(a, b *uint32) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
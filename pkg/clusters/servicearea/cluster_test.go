@@ -0,0 +1,391 @@
+package servicearea
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// testDelegate records calls and returns configured statuses.
+type testDelegate struct {
+	selectStatus SelectAreasStatus
+	skipStatus   SkipAreaStatus
+	selected     [][]uint32
+	skipped      []uint32
+}
+
+func (d *testDelegate) HandleSelectAreas(areas []uint32) (SelectAreasStatus, string) {
+	if d.selectStatus != SelectAreasStatusSuccess {
+		return d.selectStatus, "rejected"
+	}
+	d.selected = append(d.selected, areas)
+	return SelectAreasStatusSuccess, ""
+}
+
+func (d *testDelegate) HandleSkipArea(areaID uint32) (SkipAreaStatus, string) {
+	if d.skipStatus != SkipAreaStatusSuccess {
+		return d.skipStatus, "rejected"
+	}
+	d.skipped = append(d.skipped, areaID)
+	return SkipAreaStatusSuccess, ""
+}
+
+func u32(v uint32) *uint32 { return &v }
+
+func room(id uint32, name string) Area {
+	return Area{AreaID: id, AreaInfo: AreaInfo{LocationInfo: &LocationDescriptor{LocationName: name}}}
+}
+
+var testAreas = []Area{room(1, "Kitchen"), room(2, "Living Room"), room(3, "Bedroom")}
+
+// invoke sends a command with a single context-tag-0 field written by put
+// and returns the decoded response status and text.
+func invoke(t *testing.T, c *Cluster, cmd datamodel.CommandID, put func(w *tlv.Writer)) (uint8, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	w.StartStructure(tlv.Anonymous())
+	put(w)
+	w.EndContainer()
+
+	req := datamodel.InvokeRequest{
+		Path: datamodel.ConcreteCommandPath{Endpoint: 1, Cluster: ClusterID, Command: cmd},
+	}
+	resp, err := c.InvokeCommand(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes())))
+	if err != nil {
+		t.Fatalf("InvokeCommand(0x%02X) error = %v", cmd, err)
+	}
+
+	r := tlv.NewReader(bytes.NewReader(resp))
+	r.Next()
+	r.EnterContainer()
+	var status uint8
+	var text string
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		switch r.Tag().TagNumber() {
+		case 0:
+			v, _ := r.Uint()
+			status = uint8(v)
+		case 1:
+			text, _ = r.String()
+		}
+	}
+	return status, text
+}
+
+func selectAreas(t *testing.T, c *Cluster, areas ...uint32) SelectAreasStatus {
+	t.Helper()
+	status, _ := invoke(t, c, CmdSelectAreas, func(w *tlv.Writer) {
+		w.StartArray(tlv.ContextTag(0))
+		for _, id := range areas {
+			w.PutUint(tlv.Anonymous(), uint64(id))
+		}
+		w.EndContainer()
+	})
+	return SelectAreasStatus(status)
+}
+
+func skipArea(t *testing.T, c *Cluster, area uint32) SkipAreaStatus {
+	t.Helper()
+	status, _ := invoke(t, c, CmdSkipArea, func(w *tlv.Writer) {
+		w.PutUint(tlv.ContextTag(0), uint64(area))
+	})
+	return SkipAreaStatus(status)
+}
+
+func TestNew_Validation(t *testing.T) {
+	maps := []Map{{MapID: 1, Name: "Ground Floor"}}
+	onMap := func(a Area, m uint32) Area { a.MapID = &m; return a }
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want error
+	}{
+		{"areas", Config{SupportedAreas: testAreas}, nil},
+		{"duplicate area", Config{SupportedAreas: []Area{room(1, "A"), room(1, "B")}}, ErrDuplicateArea},
+		{"no area info", Config{SupportedAreas: []Area{{AreaID: 1}}}, ErrInvalidArea},
+		{"landmark only", Config{SupportedAreas: []Area{{AreaID: 1, AreaInfo: AreaInfo{LandmarkInfo: &LandmarkInfo{}}}}}, nil},
+		{"maps without MAPS", Config{SupportedMaps: maps}, ErrFeatureNotSupported},
+		{"map ID without MAPS", Config{SupportedAreas: []Area{onMap(room(1, "A"), 1)}}, ErrInvalidMap},
+		{"maps", Config{FeatureMap: FeatureMaps, SupportedMaps: maps, SupportedAreas: []Area{onMap(room(1, "A"), 1)}}, nil},
+		{"unknown map", Config{FeatureMap: FeatureMaps, SupportedMaps: maps, SupportedAreas: []Area{onMap(room(1, "A"), 2)}}, ErrInvalidMap},
+		{"null map with MAPS", Config{FeatureMap: FeatureMaps, SupportedMaps: maps, SupportedAreas: []Area{room(1, "A")}}, ErrInvalidMap},
+		{"duplicate map name", Config{FeatureMap: FeatureMaps, SupportedMaps: []Map{{1, "A"}, {2, "A"}}}, ErrDuplicateMap},
+		{"end time without current area", Config{EnableEstimatedEndTime: true}, ErrFeatureNotSupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg); !errors.Is(err, tt.want) {
+				t.Errorf("New() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttributeList_Features(t *testing.T) {
+	c, _ := New(Config{})
+	for _, attr := range []datamodel.AttributeID{AttrSupportedMaps, AttrCurrentArea, AttrEstimatedEndTime, AttrProgress} {
+		if c.hasAttribute(attr) {
+			t.Errorf("attribute 0x%04X should not be present", attr)
+		}
+	}
+	if len(c.GeneratedCommandList()) != 1 {
+		t.Errorf("GeneratedCommandList = %v, want SelectAreasResponse only", c.GeneratedCommandList())
+	}
+
+	c, _ = New(Config{
+		FeatureMap:             FeatureMaps | FeatureProgressReporting,
+		EnableCurrentArea:      true,
+		EnableEstimatedEndTime: true,
+		EnableSkipArea:         true,
+	})
+	for _, attr := range []datamodel.AttributeID{AttrSupportedMaps, AttrCurrentArea, AttrEstimatedEndTime, AttrProgress} {
+		if !c.hasAttribute(attr) {
+			t.Errorf("attribute 0x%04X should be present", attr)
+		}
+	}
+	if len(c.AcceptedCommandList()) != 2 {
+		t.Errorf("AcceptedCommandList = %v, want SelectAreas and SkipArea", c.AcceptedCommandList())
+	}
+}
+
+func TestSelectAreas(t *testing.T) {
+	d := &testDelegate{}
+	var notified [][]uint32
+	c, _ := New(Config{
+		EndpointID:     1,
+		SupportedAreas: testAreas,
+		Delegate:       d,
+		OnSelectedAreasChange: func(_ datamodel.EndpointID, areas []uint32) {
+			notified = append(notified, areas)
+		},
+	})
+
+	if got := selectAreas(t, c, 9); got != SelectAreasStatusUnsupportedArea {
+		t.Errorf("unknown area status = %d, want UnsupportedArea", got)
+	}
+
+	version := c.DataVersion()
+	if got := selectAreas(t, c, 2, 1, 2); got != SelectAreasStatusSuccess {
+		t.Fatalf("status = %d, want Success", got)
+	}
+	if got := c.SelectedAreas(); !slices.Equal(got, []uint32{2, 1}) {
+		t.Errorf("SelectedAreas = %v, want [2 1]", got)
+	}
+	if c.DataVersion() == version {
+		t.Error("DataVersion should change")
+	}
+	if len(notified) != 1 {
+		t.Errorf("OnSelectedAreasChange calls = %d, want 1", len(notified))
+	}
+
+	// Same set in another order: no delegate call, no change.
+	version = c.DataVersion()
+	if got := selectAreas(t, c, 1, 2); got != SelectAreasStatusSuccess {
+		t.Errorf("status = %d, want Success", got)
+	}
+	if len(d.selected) != 1 || c.DataVersion() != version {
+		t.Error("reselecting the same areas should be a no-op")
+	}
+
+	d.selectStatus = SelectAreasStatusInvalidInMode
+	status, text := invoke(t, c, CmdSelectAreas, func(w *tlv.Writer) {
+		w.StartArray(tlv.ContextTag(0))
+		w.PutUint(tlv.Anonymous(), 3)
+		w.EndContainer()
+	})
+	if SelectAreasStatus(status) != SelectAreasStatusInvalidInMode || text != "rejected" {
+		t.Errorf("response = (%d, %q), want (InvalidInMode, \"rejected\")", status, text)
+	}
+	if got := c.SelectedAreas(); !slices.Equal(got, []uint32{2, 1}) {
+		t.Errorf("SelectedAreas = %v after rejection, want [2 1]", got)
+	}
+
+	// An empty list selects all areas.
+	d.selectStatus = SelectAreasStatusSuccess
+	if got := selectAreas(t, c); got != SelectAreasStatusSuccess {
+		t.Errorf("status = %d, want Success", got)
+	}
+	if got := c.SelectedAreas(); len(got) != 0 {
+		t.Errorf("SelectedAreas = %v, want empty", got)
+	}
+}
+
+func TestSkipArea(t *testing.T) {
+	d := &testDelegate{}
+	c, _ := New(Config{
+		FeatureMap:     FeatureProgressReporting,
+		SupportedAreas: testAreas,
+		EnableSkipArea: true,
+		Delegate:       d,
+	})
+
+	if got := skipArea(t, c, 1); got != SkipAreaStatusInvalidAreaList {
+		t.Errorf("empty selection status = %d, want InvalidAreaList", got)
+	}
+
+	c.SelectAreas([]uint32{1, 2})
+	if err := c.SetProgress([]Progress{
+		{AreaID: 1, Status: OperationalStatusOperating},
+		{AreaID: 2, Status: OperationalStatusPending},
+	}); err != nil {
+		t.Fatalf("SetProgress error = %v", err)
+	}
+
+	if got := skipArea(t, c, 9); got != SkipAreaStatusInvalidSkippedArea {
+		t.Errorf("unknown area status = %d, want InvalidSkippedArea", got)
+	}
+
+	d.skipStatus = SkipAreaStatusInvalidInMode
+	if got := skipArea(t, c, 1); got != SkipAreaStatusInvalidInMode {
+		t.Errorf("status = %d, want InvalidInMode", got)
+	}
+
+	d.skipStatus = SkipAreaStatusSuccess
+	if got := skipArea(t, c, 1); got != SkipAreaStatusSuccess {
+		t.Fatalf("status = %d, want Success", got)
+	}
+	if !slices.Equal(d.skipped, []uint32{1}) {
+		t.Errorf("delegate skipped = %v, want [1]", d.skipped)
+	}
+
+	r := readAttr(t, c, AttrProgress)
+	r.EnterContainer()
+	r.Next()
+	r.EnterContainer()
+	r.Next()
+	r.Next()
+	if v, _ := r.Uint(); OperationalStatus(v) != OperationalStatusSkipped {
+		t.Errorf("progress status = %d, want Skipped", v)
+	}
+}
+
+func TestSkipArea_NotEnabled(t *testing.T) {
+	c, _ := New(Config{SupportedAreas: testAreas})
+	req := datamodel.InvokeRequest{
+		Path: datamodel.ConcreteCommandPath{Endpoint: 1, Cluster: ClusterID, Command: CmdSkipArea},
+	}
+	if _, err := c.InvokeCommand(context.Background(), req, nil); err != datamodel.ErrUnsupportedCommand {
+		t.Errorf("InvokeCommand error = %v, want ErrUnsupportedCommand", err)
+	}
+}
+
+func TestSetSupportedAreas_Prunes(t *testing.T) {
+	c, _ := New(Config{
+		FeatureMap:        FeatureProgressReporting,
+		SupportedAreas:    testAreas,
+		EnableCurrentArea: true,
+	})
+	c.SelectAreas([]uint32{1, 3})
+	c.SetCurrentArea(u32(3))
+	c.SetProgress([]Progress{{AreaID: 1}, {AreaID: 3}})
+
+	if err := c.SetSupportedAreas(testAreas[:2]); err != nil {
+		t.Fatalf("SetSupportedAreas error = %v", err)
+	}
+	if got := c.SelectedAreas(); !slices.Equal(got, []uint32{1}) {
+		t.Errorf("SelectedAreas = %v, want [1]", got)
+	}
+	if r := readAttr(t, c, AttrCurrentArea); r.Type() != tlv.ElementTypeNull {
+		t.Error("CurrentArea should be null after its area was removed")
+	}
+	if err := c.SetCurrentArea(u32(3)); !errors.Is(err, ErrUnknownArea) {
+		t.Errorf("SetCurrentArea(removed) error = %v, want ErrUnknownArea", err)
+	}
+}
+
+func TestReadSupportedAreas(t *testing.T) {
+	floor := int16(1)
+	pos := RelativePositionNextTo
+	c, _ := New(Config{SupportedAreas: []Area{
+		{AreaID: 7, AreaInfo: AreaInfo{
+			LocationInfo: &LocationDescriptor{LocationName: "Kitchen", FloorNumber: &floor},
+			LandmarkInfo: &LandmarkInfo{LandmarkTag: 4, RelativePositionTag: &pos},
+		}},
+	}})
+
+	r := readAttr(t, c, AttrSupportedAreas)
+	r.EnterContainer()
+	r.Next()
+	r.EnterContainer()
+
+	r.Next() // AreaID
+	if v, _ := r.Uint(); v != 7 {
+		t.Errorf("AreaID = %d, want 7", v)
+	}
+	r.Next() // MapID
+	if r.Type() != tlv.ElementTypeNull {
+		t.Error("MapID should be null without MAPS")
+	}
+	r.Next() // AreaInfo
+	r.EnterContainer()
+	r.Next() // LocationInfo
+	r.EnterContainer()
+	r.Next()
+	if s, _ := r.String(); s != "Kitchen" {
+		t.Errorf("LocationName = %q, want Kitchen", s)
+	}
+	r.Next()
+	if v, _ := r.Int(); v != 1 {
+		t.Errorf("FloorNumber = %d, want 1", v)
+	}
+	r.Next()
+	if r.Type() != tlv.ElementTypeNull {
+		t.Error("AreaType should be null")
+	}
+	r.ExitContainer()
+	r.Next() // LandmarkInfo
+	r.EnterContainer()
+	r.Next()
+	if v, _ := r.Uint(); v != 4 {
+		t.Errorf("LandmarkTag = %d, want 4", v)
+	}
+	r.Next()
+	if v, _ := r.Uint(); RelativePositionTag(v) != RelativePositionNextTo {
+		t.Errorf("RelativePositionTag = %d, want NextTo", v)
+	}
+}
+
+func TestEncodeResponse_TruncatesText(t *testing.T) {
+	long := make([]byte, MaxStatusTextLength+10)
+	for i := range long {
+		long[i] = 'x'
+	}
+	resp, err := encodeResponse(0, string(long))
+	if err != nil {
+		t.Fatalf("encodeResponse error = %v", err)
+	}
+	r := tlv.NewReader(bytes.NewReader(resp))
+	r.Next()
+	r.EnterContainer()
+	r.Next()
+	r.Next()
+	if s, _ := r.String(); len(s) != MaxStatusTextLength {
+		t.Errorf("StatusText length = %d, want %d", len(s), MaxStatusTextLength)
+	}
+}
+
+func readAttr(t *testing.T, c *Cluster, attr datamodel.AttributeID) *tlv.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	req := datamodel.ReadAttributeRequest{
+		Path: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: attr},
+	}
+	if err := c.ReadAttribute(context.Background(), req, tlv.NewWriter(&buf)); err != nil {
+		t.Fatalf("ReadAttribute(0x%04X) error = %v", attr, err)
+	}
+	r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+	if err := r.Next(); err != nil {
+		t.Fatalf("Next error = %v", err)
+	}
+	return r
+}
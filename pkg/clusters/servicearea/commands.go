@@ -0,0 +1,236 @@
+package servicearea
+
+import (
+	"bytes"
+	"context"
+	"slices"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	switch req.Path.Command {
+	case CmdSelectAreas:
+		areas, err := decodeSelectAreas(r)
+		if err != nil {
+			return nil, err
+		}
+		status, text := c.SelectAreas(areas)
+		return encodeResponse(uint8(status), text)
+	case CmdSkipArea:
+		if !c.config.EnableSkipArea {
+			return nil, datamodel.ErrUnsupportedCommand
+		}
+		areaID, err := decodeSkipArea(r)
+		if err != nil {
+			return nil, err
+		}
+		status, text := c.SkipArea(areaID)
+		return encodeResponse(uint8(status), text)
+	default:
+		return nil, datamodel.ErrUnsupportedCommand
+	}
+}
+
+// decodeSelectAreas decodes the NewAreas list of a SelectAreas command.
+func decodeSelectAreas(r *tlv.Reader) ([]uint32, error) {
+	if r == nil {
+		return nil, datamodel.ErrInvalidCommand
+	}
+	if err := r.Next(); err != nil {
+		return nil, datamodel.ErrInvalidCommand
+	}
+	if err := r.EnterContainer(); err != nil {
+		return nil, datamodel.ErrInvalidCommand
+	}
+
+	var areas []uint32
+	found := false
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		tag := r.Tag()
+		if !tag.IsContext() || tag.TagNumber() != 0 {
+			continue
+		}
+		found = true
+		if err := r.EnterContainer(); err != nil {
+			return nil, datamodel.ErrInvalidCommand
+		}
+		for {
+			if err := r.Next(); err != nil || r.IsEndOfContainer() {
+				break
+			}
+			v, err := r.Uint()
+			if err != nil {
+				return nil, datamodel.ErrInvalidCommand
+			}
+			if v > 0xFFFFFFFF {
+				return nil, datamodel.ErrConstraintError
+			}
+			areas = append(areas, uint32(v))
+		}
+		if err := r.ExitContainer(); err != nil {
+			return nil, datamodel.ErrInvalidCommand
+		}
+	}
+	if !found {
+		return nil, datamodel.ErrInvalidCommand
+	}
+	return areas, nil
+}
+
+// decodeSkipArea decodes the SkippedArea field of a SkipArea command.
+func decodeSkipArea(r *tlv.Reader) (uint32, error) {
+	if r == nil {
+		return 0, datamodel.ErrInvalidCommand
+	}
+	if err := r.Next(); err != nil {
+		return 0, datamodel.ErrInvalidCommand
+	}
+	if err := r.EnterContainer(); err != nil {
+		return 0, datamodel.ErrInvalidCommand
+	}
+
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		tag := r.Tag()
+		if !tag.IsContext() || tag.TagNumber() != 0 {
+			continue
+		}
+		v, err := r.Uint()
+		if err != nil {
+			return 0, datamodel.ErrInvalidCommand
+		}
+		if v > 0xFFFFFFFF {
+			return 0, datamodel.ErrConstraintError
+		}
+		return uint32(v), nil
+	}
+	return 0, datamodel.ErrInvalidCommand
+}
+
+// SelectAreas validates and applies a new area selection, as the
+// SelectAreas command does. Duplicate IDs are ignored and an empty list
+// selects all areas. Selecting the current selection again succeeds
+// without consulting the Delegate.
+//
+// Spec: Section 1.17.7.1
+func (c *Cluster) SelectAreas(areas []uint32) (SelectAreasStatus, string) {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	var unique []uint32
+	for _, id := range areas {
+		if !slices.Contains(unique, id) {
+			unique = append(unique, id)
+		}
+	}
+
+	c.mu.RLock()
+	for _, id := range unique {
+		if !c.hasAreaLocked(id) {
+			c.mu.RUnlock()
+			return SelectAreasStatusUnsupportedArea, "area not supported"
+		}
+	}
+	same := sameSet(unique, c.selected)
+	c.mu.RUnlock()
+	if same {
+		return SelectAreasStatusSuccess, ""
+	}
+
+	if d := c.config.Delegate; d != nil {
+		if status, text := d.HandleSelectAreas(slices.Clone(unique)); status != SelectAreasStatusSuccess {
+			return status, text
+		}
+	}
+
+	c.mu.Lock()
+	c.selected = unique
+	c.IncrementDataVersion()
+	c.mu.Unlock()
+
+	c.notifySelection(unique)
+	return SelectAreasStatusSuccess, ""
+}
+
+// SkipArea asks the device to stop serving an area and move on, as the
+// SkipArea command does. With FeatureProgressReporting the area's
+// progress entry is marked Skipped.
+//
+// Spec: Section 1.17.7.3
+func (c *Cluster) SkipArea(areaID uint32) (SkipAreaStatus, string) {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	c.mu.RLock()
+	empty := len(c.selected) == 0
+	supported := c.hasAreaLocked(areaID)
+	c.mu.RUnlock()
+
+	if empty {
+		return SkipAreaStatusInvalidAreaList, "no areas selected"
+	}
+	if !supported {
+		return SkipAreaStatusInvalidSkippedArea, "area not supported"
+	}
+
+	if d := c.config.Delegate; d != nil {
+		if status, text := d.HandleSkipArea(areaID); status != SkipAreaStatusSuccess {
+			return status, text
+		}
+	}
+
+	if c.hasFeature(FeatureProgressReporting) {
+		c.mu.Lock()
+		for i := range c.progress {
+			if c.progress[i].AreaID == areaID && c.progress[i].Status != OperationalStatusSkipped {
+				c.progress[i].Status = OperationalStatusSkipped
+				c.IncrementDataVersion()
+			}
+		}
+		c.mu.Unlock()
+	}
+	return SkipAreaStatusSuccess, ""
+}
+
+// sameSet reports whether a and b hold the same IDs.
+func sameSet(a, b []uint32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, id := range a {
+		if !slices.Contains(b, id) {
+			return false
+		}
+	}
+	return true
+}
+
+// encodeResponse encodes a SelectAreasResponse or SkipAreaResponse
+// (Spec 1.17.7.2, 1.17.7.4); both carry a status and a status text.
+func encodeResponse(status uint8, text string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return nil, err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(status)); err != nil {
+		return nil, err
+	}
+	if err := w.PutString(tlv.ContextTag(1), truncate(text, MaxStatusTextLength)); err != nil {
+		return nil, err
+	}
+	if err := w.EndContainer(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,255 @@
+package servicearea
+
+import (
+	"unicode/utf8"
+
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Length limits.
+const (
+	MaxLocationNameLength = 128
+	MaxMapNameLength      = 64
+	MaxStatusTextLength   = 256
+	MaxAreas              = 255
+	MaxMaps               = 255
+)
+
+// AreaTypeTag is a tag from the Common Area namespace describing the kind
+// of area, such as a kitchen or a hallway.
+type AreaTypeTag uint8
+
+// LandmarkTag is a tag from the Common Landmark namespace, such as a
+// table or a sofa.
+type LandmarkTag uint8
+
+// RelativePositionTag is a tag from the Common Relative Position
+// namespace, locating an area relative to a landmark.
+type RelativePositionTag uint8
+
+const (
+	RelativePositionUnder   RelativePositionTag = 0x00
+	RelativePositionNextTo  RelativePositionTag = 0x01
+	RelativePositionAround  RelativePositionTag = 0x02
+	RelativePositionOn      RelativePositionTag = 0x03
+	RelativePositionAbove   RelativePositionTag = 0x04
+	RelativePositionFrontOf RelativePositionTag = 0x05
+	RelativePositionBehind  RelativePositionTag = 0x06
+)
+
+// OperationalStatus is the OperationalStatusEnum (Spec 1.17.5.1).
+type OperationalStatus uint8
+
+const (
+	OperationalStatusPending   OperationalStatus = 0x00
+	OperationalStatusOperating OperationalStatus = 0x01
+	OperationalStatusSkipped   OperationalStatus = 0x02
+	OperationalStatusCompleted OperationalStatus = 0x03
+)
+
+// SelectAreasStatus is the SelectAreasStatus enum (Spec 1.17.5.2).
+type SelectAreasStatus uint8
+
+const (
+	SelectAreasStatusSuccess         SelectAreasStatus = 0x00
+	SelectAreasStatusUnsupportedArea SelectAreasStatus = 0x01
+	SelectAreasStatusInvalidInMode   SelectAreasStatus = 0x02
+	SelectAreasStatusInvalidSet      SelectAreasStatus = 0x03
+)
+
+// SkipAreaStatus is the SkipAreaStatus enum (Spec 1.17.5.3).
+type SkipAreaStatus uint8
+
+const (
+	SkipAreaStatusSuccess            SkipAreaStatus = 0x00
+	SkipAreaStatusInvalidAreaList    SkipAreaStatus = 0x01
+	SkipAreaStatusInvalidInMode      SkipAreaStatus = 0x02
+	SkipAreaStatusInvalidSkippedArea SkipAreaStatus = 0x03
+)
+
+// LocationDescriptor is the global LocationDescriptorStruct.
+type LocationDescriptor struct {
+	LocationName string
+	FloorNumber  *int16       // nullable
+	AreaType     *AreaTypeTag // nullable
+}
+
+// MarshalTLV implements the TLVMarshaler interface.
+func (l LocationDescriptor) MarshalTLV(w *tlv.Writer) error {
+	return l.marshal(w, tlv.Anonymous())
+}
+
+func (l LocationDescriptor) marshal(w *tlv.Writer, tag tlv.Tag) error {
+	if err := w.StartStructure(tag); err != nil {
+		return err
+	}
+	if err := w.PutString(tlv.ContextTag(0), l.LocationName); err != nil {
+		return err
+	}
+	if l.FloorNumber != nil {
+		if err := w.PutInt(tlv.ContextTag(1), int64(*l.FloorNumber)); err != nil {
+			return err
+		}
+	} else if err := w.PutNull(tlv.ContextTag(1)); err != nil {
+		return err
+	}
+	if err := putNullableUint(w, tlv.ContextTag(2), l.AreaType); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// LandmarkInfo is the LandmarkInfoStruct (Spec 1.17.5.4).
+type LandmarkInfo struct {
+	LandmarkTag         LandmarkTag
+	RelativePositionTag *RelativePositionTag // nullable
+}
+
+// MarshalTLV implements the TLVMarshaler interface.
+func (l LandmarkInfo) MarshalTLV(w *tlv.Writer) error {
+	return l.marshal(w, tlv.Anonymous())
+}
+
+func (l LandmarkInfo) marshal(w *tlv.Writer, tag tlv.Tag) error {
+	if err := w.StartStructure(tag); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(l.LandmarkTag)); err != nil {
+		return err
+	}
+	if err := putNullableUint(w, tlv.ContextTag(1), l.RelativePositionTag); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// AreaInfo is the AreaInfoStruct (Spec 1.17.5.5). At least one of
+// LocationInfo and LandmarkInfo is required.
+type AreaInfo struct {
+	LocationInfo *LocationDescriptor // nullable
+	LandmarkInfo *LandmarkInfo       // nullable
+}
+
+// MarshalTLV implements the TLVMarshaler interface.
+func (a AreaInfo) MarshalTLV(w *tlv.Writer) error {
+	return a.marshal(w, tlv.Anonymous())
+}
+
+func (a AreaInfo) marshal(w *tlv.Writer, tag tlv.Tag) error {
+	if err := w.StartStructure(tag); err != nil {
+		return err
+	}
+	if a.LocationInfo != nil {
+		if err := a.LocationInfo.marshal(w, tlv.ContextTag(0)); err != nil {
+			return err
+		}
+	} else if err := w.PutNull(tlv.ContextTag(0)); err != nil {
+		return err
+	}
+	if a.LandmarkInfo != nil {
+		if err := a.LandmarkInfo.marshal(w, tlv.ContextTag(1)); err != nil {
+			return err
+		}
+	} else if err := w.PutNull(tlv.ContextTag(1)); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// Area is the AreaStruct (Spec 1.17.5.6).
+type Area struct {
+	AreaID   uint32
+	MapID    *uint32 // nullable; required with the MAPS feature
+	AreaInfo AreaInfo
+}
+
+// MarshalTLV implements the TLVMarshaler interface.
+func (a Area) MarshalTLV(w *tlv.Writer) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(a.AreaID)); err != nil {
+		return err
+	}
+	if err := putNullableUint(w, tlv.ContextTag(1), a.MapID); err != nil {
+		return err
+	}
+	if err := a.AreaInfo.marshal(w, tlv.ContextTag(2)); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// Map is the MapStruct (Spec 1.17.5.7).
+type Map struct {
+	MapID uint32
+	Name  string
+}
+
+// MarshalTLV implements the TLVMarshaler interface.
+func (m Map) MarshalTLV(w *tlv.Writer) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(m.MapID)); err != nil {
+		return err
+	}
+	if err := w.PutString(tlv.ContextTag(1), m.Name); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// Progress is the ProgressStruct (Spec 1.17.5.8).
+type Progress struct {
+	AreaID uint32
+	Status OperationalStatus
+
+	// Optional times in seconds; nil omits the field.
+	TotalOperationalTime *uint32
+	EstimatedTime        *uint32
+}
+
+// MarshalTLV implements the TLVMarshaler interface.
+func (p Progress) MarshalTLV(w *tlv.Writer) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(p.AreaID)); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(1), uint64(p.Status)); err != nil {
+		return err
+	}
+	if p.TotalOperationalTime != nil {
+		if err := w.PutUint(tlv.ContextTag(2), uint64(*p.TotalOperationalTime)); err != nil {
+			return err
+		}
+	}
+	if p.EstimatedTime != nil {
+		if err := w.PutUint(tlv.ContextTag(3), uint64(*p.EstimatedTime)); err != nil {
+			return err
+		}
+	}
+	return w.EndContainer()
+}
+
+// putNullableUint writes *v under tag, or null if v is nil.
+func putNullableUint[T ~uint8 | ~uint32](w *tlv.Writer, tag tlv.Tag, v *T) error {
+	if v == nil {
+		return w.PutNull(tag)
+	}
+	return w.PutUint(tag, uint64(*v))
+}
+
+// truncate shortens s to at most n bytes on a UTF-8 boundary.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	s = s[:n]
+	for !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,481 @@
+// Package smokecoalarm implements the Smoke CO Alarm Cluster (0x005C).
+//
+// The detector hardware reports its sensor readings through setters such
+// as SetSmokeState, SetCOState and SetBatteryAlert; the cluster derives
+// ExpressedState, the single condition the device is currently
+// signalling, by walking a priority list of conditions. Each alarm
+// condition emits its event when it becomes active, and AllClear is
+// emitted once no smoke or CO alarm remains.
+//
+// Clients may request a self-test; the Delegate starts it on the hardware
+// and the device reports its end with CompleteSelfTest.
+//
+// Spec Reference: Section 2.11
+//
+// C++ Reference: src/app/clusters/smoke-co-alarm-server/smoke-co-alarm-server.cpp
+package smokecoalarm
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/backkem/matter/pkg/clusters"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x005C
+	ClusterRevision uint16              = 1
+)
+
+// Attribute IDs (Spec 2.11.6).
+const (
+	AttrExpressedState         datamodel.AttributeID = 0x0000
+	AttrSmokeState             datamodel.AttributeID = 0x0001
+	AttrCOState                datamodel.AttributeID = 0x0002
+	AttrBatteryAlert           datamodel.AttributeID = 0x0003
+	AttrDeviceMuted            datamodel.AttributeID = 0x0004
+	AttrTestInProgress         datamodel.AttributeID = 0x0005
+	AttrHardwareFaultAlert     datamodel.AttributeID = 0x0006
+	AttrEndOfServiceAlert      datamodel.AttributeID = 0x0007
+	AttrInterconnectSmokeAlarm datamodel.AttributeID = 0x0008
+	AttrInterconnectCOAlarm    datamodel.AttributeID = 0x0009
+	AttrContaminationState     datamodel.AttributeID = 0x000A
+	AttrSmokeSensitivityLevel  datamodel.AttributeID = 0x000B
+	AttrExpiryDate             datamodel.AttributeID = 0x000C
+)
+
+// Command IDs (Spec 2.11.7).
+const (
+	CmdSelfTestRequest datamodel.CommandID = 0x00
+)
+
+// Event IDs (Spec 2.11.8).
+const (
+	EventSmokeAlarm             datamodel.EventID = 0x00
+	EventCOAlarm                datamodel.EventID = 0x01
+	EventLowBattery             datamodel.EventID = 0x02
+	EventHardwareFault          datamodel.EventID = 0x03
+	EventEndOfService           datamodel.EventID = 0x04
+	EventSelfTestComplete       datamodel.EventID = 0x05
+	EventAlarmMuted             datamodel.EventID = 0x06
+	EventMuteEnded              datamodel.EventID = 0x07
+	EventInterconnectSmokeAlarm datamodel.EventID = 0x08
+	EventInterconnectCOAlarm    datamodel.EventID = 0x09
+	EventAllClear               datamodel.EventID = 0x0A
+)
+
+// Feature bits (Spec 2.11.4).
+type Feature uint32
+
+const (
+	// FeatureSmokeAlarm supports smoke detection (SMOKE).
+	FeatureSmokeAlarm Feature = 1 << 0
+
+	// FeatureCOAlarm supports carbon monoxide detection (CO).
+	FeatureCOAlarm Feature = 1 << 1
+)
+
+// AlarmState is the AlarmStateEnum (Spec 2.11.5.1).
+type AlarmState uint8
+
+const (
+	AlarmStateNormal   AlarmState = 0x00
+	AlarmStateWarning  AlarmState = 0x01
+	AlarmStateCritical AlarmState = 0x02
+)
+
+// SensitivityLevel is the SensitivityEnum (Spec 2.11.5.2).
+type SensitivityLevel uint8
+
+const (
+	SensitivityHigh     SensitivityLevel = 0x00
+	SensitivityStandard SensitivityLevel = 0x01
+	SensitivityLow      SensitivityLevel = 0x02
+)
+
+// ExpressedState is the ExpressedStateEnum (Spec 2.11.5.3).
+type ExpressedState uint8
+
+const (
+	ExpressedStateNormal            ExpressedState = 0x00
+	ExpressedStateSmokeAlarm        ExpressedState = 0x01
+	ExpressedStateCOAlarm           ExpressedState = 0x02
+	ExpressedStateBatteryAlert      ExpressedState = 0x03
+	ExpressedStateTesting           ExpressedState = 0x04
+	ExpressedStateHardwareFault     ExpressedState = 0x05
+	ExpressedStateEndOfService      ExpressedState = 0x06
+	ExpressedStateInterconnectSmoke ExpressedState = 0x07
+	ExpressedStateInterconnectCO    ExpressedState = 0x08
+)
+
+// MuteState is the MuteStateEnum (Spec 2.11.5.4).
+type MuteState uint8
+
+const (
+	MuteStateNotMuted MuteState = 0x00
+	MuteStateMuted    MuteState = 0x01
+)
+
+// EndOfService is the EndOfServiceEnum (Spec 2.11.5.5).
+type EndOfService uint8
+
+const (
+	EndOfServiceNormal  EndOfService = 0x00
+	EndOfServiceExpired EndOfService = 0x01
+)
+
+// ContaminationState is the ContaminationStateEnum (Spec 2.11.5.6).
+type ContaminationState uint8
+
+const (
+	ContaminationNormal   ContaminationState = 0x00
+	ContaminationLow      ContaminationState = 0x01
+	ContaminationWarning  ContaminationState = 0x02
+	ContaminationCritical ContaminationState = 0x03
+)
+
+// DefaultExpressedStatePriority is the order in which conditions are
+// expressed, highest first. Normal is expressed when none is active.
+var DefaultExpressedStatePriority = []ExpressedState{
+	ExpressedStateSmokeAlarm,
+	ExpressedStateInterconnectSmoke,
+	ExpressedStateCOAlarm,
+	ExpressedStateInterconnectCO,
+	ExpressedStateHardwareFault,
+	ExpressedStateTesting,
+	ExpressedStateEndOfService,
+	ExpressedStateBatteryAlert,
+}
+
+// Errors returned by configuration and the device-side API.
+var (
+	ErrInvalidFeatures     = errors.New("smokecoalarm: at least one of SMOKE and CO required")
+	ErrInvalidPriority     = errors.New("smokecoalarm: invalid expressed state priority")
+	ErrFeatureNotSupported = errors.New("smokecoalarm: feature or attribute not supported")
+)
+
+// Delegate connects the cluster to the detector hardware.
+type Delegate interface {
+	// HandleSelfTest starts a self-test. The device reports its end with
+	// Cluster.CompleteSelfTest. An error rejects the request.
+	HandleSelfTest() error
+
+	// HandleSmokeSensitivityLevel applies a new smoke sensitivity. An
+	// error rejects the write.
+	HandleSmokeSensitivityLevel(level SensitivityLevel) error
+}
+
+// Config provides dependencies for the Smoke CO Alarm cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to.
+	EndpointID datamodel.EndpointID
+
+	// FeatureMap indicates supported features. At least one of
+	// FeatureSmokeAlarm and FeatureCOAlarm must be set.
+	FeatureMap Feature
+
+	// ExpressedStatePriority overrides DefaultExpressedStatePriority.
+	ExpressedStatePriority []ExpressedState
+
+	// Optional attributes
+	EnableDeviceMuted        bool
+	EnableInterconnect       bool // InterconnectSmokeAlarm/InterconnectCOAlarm
+	EnableContaminationState bool // SMOKE only
+	EnableSmokeSensitivity   bool // SMOKE only
+	ExpiryDate               *uint32
+
+	// EnableSelfTest adds the SelfTestRequest command.
+	EnableSelfTest bool
+
+	// Delegate connects to the hardware (optional).
+	Delegate Delegate
+
+	// EventPublisher for alarm events.
+	// Optional - if nil, events are not emitted.
+	EventPublisher datamodel.EventPublisher
+
+	// OnExpressedStateChange is called when ExpressedState changes (optional).
+	OnExpressedStateChange func(endpoint datamodel.EndpointID, state ExpressedState)
+}
+
+// Cluster implements the Smoke CO Alarm cluster (0x005C).
+type Cluster struct {
+	*datamodel.ClusterBase
+	*datamodel.EventSource
+	config Config
+
+	// Mutable state (protected by mutex)
+	mu                sync.Mutex
+	expressed         ExpressedState
+	smokeState        AlarmState
+	coState           AlarmState
+	batteryAlert      AlarmState
+	deviceMuted       MuteState
+	testInProgress    bool
+	hardwareFault     bool
+	endOfService      EndOfService
+	interconnectSmoke AlarmState
+	interconnectCO    AlarmState
+	contamination     ContaminationState
+	sensitivity       SensitivityLevel
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Smoke CO Alarm cluster. It returns an error if no
+// detection feature is set or the priority list is invalid.
+func New(cfg Config) (*Cluster, error) {
+	if cfg.FeatureMap&(FeatureSmokeAlarm|FeatureCOAlarm) == 0 {
+		return nil, ErrInvalidFeatures
+	}
+	if cfg.ExpressedStatePriority == nil {
+		cfg.ExpressedStatePriority = DefaultExpressedStatePriority
+	}
+	seen := make(map[ExpressedState]bool)
+	for _, s := range cfg.ExpressedStatePriority {
+		if s == ExpressedStateNormal || s > ExpressedStateInterconnectCO || seen[s] {
+			return nil, ErrInvalidPriority
+		}
+		seen[s] = true
+	}
+	if cfg.FeatureMap&FeatureSmokeAlarm == 0 {
+		cfg.EnableContaminationState = false
+		cfg.EnableSmokeSensitivity = false
+	}
+
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		EventSource: datamodel.NewEventSource(),
+		config:      cfg,
+		sensitivity: SensitivityStandard,
+	}
+
+	c.ClusterBase.SetFeatureMap(uint32(cfg.FeatureMap))
+
+	// Bind event source
+	if cfg.EventPublisher != nil {
+		c.EventSource.Bind(cfg.EndpointID, ClusterID, cfg.EventPublisher)
+		c.EventSource.RegisterEvents(c.eventList())
+	}
+
+	c.attrList = c.buildAttributeList()
+
+	return c, nil
+}
+
+// hasFeature returns true if the feature bit is set.
+func (c *Cluster) hasFeature(f Feature) bool {
+	return c.config.FeatureMap&f != 0
+}
+
+// eventList returns the events supported by the configuration.
+func (c *Cluster) eventList() []datamodel.EventEntry {
+	critical := func(id datamodel.EventID) datamodel.EventEntry {
+		return datamodel.NewEventEntry(id, datamodel.EventPriorityCritical, datamodel.PrivilegeView, false)
+	}
+	info := func(id datamodel.EventID) datamodel.EventEntry {
+		return datamodel.NewEventEntry(id, datamodel.EventPriorityInfo, datamodel.PrivilegeView, false)
+	}
+
+	var events []datamodel.EventEntry
+	if c.hasFeature(FeatureSmokeAlarm) {
+		events = append(events, critical(EventSmokeAlarm))
+	}
+	if c.hasFeature(FeatureCOAlarm) {
+		events = append(events, critical(EventCOAlarm))
+	}
+	events = append(events,
+		info(EventLowBattery),
+		info(EventHardwareFault),
+		info(EventEndOfService),
+		info(EventSelfTestComplete),
+	)
+	if c.config.EnableDeviceMuted {
+		events = append(events, info(EventAlarmMuted), info(EventMuteEnded))
+	}
+	if c.config.EnableInterconnect {
+		if c.hasFeature(FeatureSmokeAlarm) {
+			events = append(events, critical(EventInterconnectSmokeAlarm))
+		}
+		if c.hasFeature(FeatureCOAlarm) {
+			events = append(events, critical(EventInterconnectCOAlarm))
+		}
+	}
+	return append(events, info(EventAllClear))
+}
+
+// buildAttributeList constructs the list of supported attributes.
+func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+
+	attrs := []datamodel.AttributeEntry{
+		datamodel.NewReadOnlyAttribute(AttrExpressedState, 0, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrBatteryAlert, 0, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrTestInProgress, 0, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrHardwareFaultAlert, 0, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrEndOfServiceAlert, 0, viewPriv),
+	}
+	if c.hasFeature(FeatureSmokeAlarm) {
+		attrs = append(attrs, datamodel.NewReadOnlyAttribute(AttrSmokeState, 0, viewPriv))
+	}
+	if c.hasFeature(FeatureCOAlarm) {
+		attrs = append(attrs, datamodel.NewReadOnlyAttribute(AttrCOState, 0, viewPriv))
+	}
+	if c.config.EnableDeviceMuted {
+		attrs = append(attrs, datamodel.NewReadOnlyAttribute(AttrDeviceMuted, 0, viewPriv))
+	}
+	if c.config.EnableInterconnect {
+		if c.hasFeature(FeatureSmokeAlarm) {
+			attrs = append(attrs, datamodel.NewReadOnlyAttribute(AttrInterconnectSmokeAlarm, 0, viewPriv))
+		}
+		if c.hasFeature(FeatureCOAlarm) {
+			attrs = append(attrs, datamodel.NewReadOnlyAttribute(AttrInterconnectCOAlarm, 0, viewPriv))
+		}
+	}
+	if c.config.EnableContaminationState {
+		attrs = append(attrs, datamodel.NewReadOnlyAttribute(AttrContaminationState, 0, viewPriv))
+	}
+	if c.config.EnableSmokeSensitivity {
+		attrs = append(attrs, datamodel.NewReadWriteAttribute(AttrSmokeSensitivityLevel, 0, viewPriv, datamodel.PrivilegeManage))
+	}
+	if c.config.ExpiryDate != nil {
+		attrs = append(attrs, datamodel.NewReadOnlyAttribute(AttrExpiryDate, datamodel.AttrQualityFixed, viewPriv))
+	}
+
+	return datamodel.MergeAttributeLists(attrs)
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	if !c.config.EnableSelfTest {
+		return nil
+	}
+	return []datamodel.CommandEntry{
+		datamodel.NewCommandEntry(CmdSelfTestRequest, 0, datamodel.PrivilegeOperate),
+	}
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return nil
+}
+
+// hasAttribute returns true if attr is in the attribute list.
+func (c *Cluster) hasAttribute(attr datamodel.AttributeID) bool {
+	for _, a := range c.attrList {
+		if a.ID == attr {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	if !c.hasAttribute(req.Path.Attribute) {
+		return datamodel.ErrUnsupportedAttribute
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch req.Path.Attribute {
+	case AttrExpressedState:
+		return w.PutUint(tlv.Anonymous(), uint64(c.expressed))
+	case AttrSmokeState:
+		return w.PutUint(tlv.Anonymous(), uint64(c.smokeState))
+	case AttrCOState:
+		return w.PutUint(tlv.Anonymous(), uint64(c.coState))
+	case AttrBatteryAlert:
+		return w.PutUint(tlv.Anonymous(), uint64(c.batteryAlert))
+	case AttrDeviceMuted:
+		return w.PutUint(tlv.Anonymous(), uint64(c.deviceMuted))
+	case AttrTestInProgress:
+		return w.PutBool(tlv.Anonymous(), c.testInProgress)
+	case AttrHardwareFaultAlert:
+		return w.PutBool(tlv.Anonymous(), c.hardwareFault)
+	case AttrEndOfServiceAlert:
+		return w.PutUint(tlv.Anonymous(), uint64(c.endOfService))
+	case AttrInterconnectSmokeAlarm:
+		return w.PutUint(tlv.Anonymous(), uint64(c.interconnectSmoke))
+	case AttrInterconnectCOAlarm:
+		return w.PutUint(tlv.Anonymous(), uint64(c.interconnectCO))
+	case AttrContaminationState:
+		return w.PutUint(tlv.Anonymous(), uint64(c.contamination))
+	case AttrSmokeSensitivityLevel:
+		return w.PutUint(tlv.Anonymous(), uint64(c.sensitivity))
+	case AttrExpiryDate:
+		return w.PutUint(tlv.Anonymous(), uint64(*c.config.ExpiryDate))
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	if req.Path.Attribute != AttrSmokeSensitivityLevel || !c.config.EnableSmokeSensitivity {
+		return datamodel.ErrUnsupportedWrite
+	}
+
+	if err := r.Next(); err != nil {
+		return err
+	}
+	v, err := r.Uint()
+	if err != nil {
+		return err
+	}
+	if v > uint64(SensitivityLow) {
+		return datamodel.ErrConstraintError
+	}
+	level := SensitivityLevel(v)
+
+	if d := c.config.Delegate; d != nil {
+		if err := d.HandleSmokeSensitivityLevel(level); err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sensitivity != level {
+		c.sensitivity = level
+		c.IncrementDataVersion()
+	}
+	return nil
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	if req.Path.Command != CmdSelfTestRequest || !c.config.EnableSelfTest {
+		return nil, datamodel.ErrUnsupportedCommand
+	}
+	if err := c.SelfTestRequest(); err != nil {
+		return nil, err
+	}
+	return clusters.EmptyResponse(), nil
+}
+
+// emit emits an event if a publisher is bound.
+func (c *Cluster) emit(eventID datamodel.EventID, priority datamodel.EventPriority, payload interface{}) error {
+	if !c.EventSource.IsBound() {
+		return nil // No publisher, silently skip
+	}
+	_, err := c.EventSource.Emit(eventID, priority, payload)
+	return err
+}
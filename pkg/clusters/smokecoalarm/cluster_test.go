@@ -0,0 +1,276 @@
+package smokecoalarm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// mockEventPublisher implements EventPublisher for testing.
+type mockEventPublisher struct {
+	mu     sync.Mutex
+	events []publishedEvent
+}
+
+type publishedEvent struct {
+	id       datamodel.EventID
+	priority datamodel.EventPriority
+	data     interface{}
+}
+
+func (m *mockEventPublisher) PublishEvent(
+	endpoint datamodel.EndpointID,
+	cluster datamodel.ClusterID,
+	eventID datamodel.EventID,
+	priority datamodel.EventPriority,
+	data interface{},
+	fabricIndex uint8,
+) (datamodel.EventNumber, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, publishedEvent{id: eventID, priority: priority, data: data})
+	return datamodel.EventNumber(len(m.events)), nil
+}
+
+// take returns and clears the published event IDs.
+func (m *mockEventPublisher) take() []datamodel.EventID {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := make([]datamodel.EventID, len(m.events))
+	for i, e := range m.events {
+		ids[i] = e.id
+	}
+	m.events = nil
+	return ids
+}
+
+// testDelegate records calls and optionally rejects them.
+type testDelegate struct {
+	reject      error
+	selfTests   int
+	sensitivity SensitivityLevel
+}
+
+func (d *testDelegate) HandleSelfTest() error {
+	if d.reject != nil {
+		return d.reject
+	}
+	d.selfTests++
+	return nil
+}
+
+func (d *testDelegate) HandleSmokeSensitivityLevel(level SensitivityLevel) error {
+	if d.reject != nil {
+		return d.reject
+	}
+	d.sensitivity = level
+	return nil
+}
+
+func newAlarm(t *testing.T, d Delegate, pub datamodel.EventPublisher) *Cluster {
+	t.Helper()
+	c, err := New(Config{
+		EndpointID:             1,
+		FeatureMap:             FeatureSmokeAlarm | FeatureCOAlarm,
+		EnableDeviceMuted:      true,
+		EnableInterconnect:     true,
+		EnableSmokeSensitivity: true,
+		EnableSelfTest:         true,
+		Delegate:               d,
+		EventPublisher:         pub,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+func equalIDs(a, b []datamodel.EventID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(Config{}); !errors.Is(err, ErrInvalidFeatures) {
+		t.Errorf("no features error = %v, want ErrInvalidFeatures", err)
+	}
+	_, err := New(Config{
+		FeatureMap:             FeatureCOAlarm,
+		ExpressedStatePriority: []ExpressedState{ExpressedStateCOAlarm, ExpressedStateCOAlarm},
+	})
+	if !errors.Is(err, ErrInvalidPriority) {
+		t.Errorf("duplicate priority error = %v, want ErrInvalidPriority", err)
+	}
+
+	// CO-only detectors have no smoke attributes
+	c, err := New(Config{FeatureMap: FeatureCOAlarm, EnableSmokeSensitivity: true})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	for _, a := range c.AttributeList() {
+		if a.ID == AttrSmokeState || a.ID == AttrSmokeSensitivityLevel {
+			t.Errorf("attribute 0x%04X should not be present", a.ID)
+		}
+	}
+	if err := c.SetSmokeState(AlarmStateWarning); !errors.Is(err, ErrFeatureNotSupported) {
+		t.Errorf("SetSmokeState error = %v, want ErrFeatureNotSupported", err)
+	}
+}
+
+func TestExpressedStatePriority(t *testing.T) {
+	c := newAlarm(t, nil, nil)
+
+	steps := []struct {
+		name string
+		do   func() error
+		want ExpressedState
+	}{
+		{"battery", func() error { return c.SetBatteryAlert(AlarmStateWarning) }, ExpressedStateBatteryAlert},
+		{"end of service", func() error { return c.SetEndOfService(EndOfServiceExpired) }, ExpressedStateEndOfService},
+		{"fault", func() error { return c.SetHardwareFault(true) }, ExpressedStateHardwareFault},
+		{"interconnect CO", func() error { return c.SetInterconnectCOAlarm(AlarmStateWarning) }, ExpressedStateInterconnectCO},
+		{"CO", func() error { return c.SetCOState(AlarmStateCritical) }, ExpressedStateCOAlarm},
+		{"smoke", func() error { return c.SetSmokeState(AlarmStateWarning) }, ExpressedStateSmokeAlarm},
+		{"smoke clears", func() error { return c.SetSmokeState(AlarmStateNormal) }, ExpressedStateCOAlarm},
+		{"CO clears", func() error { return c.SetCOState(AlarmStateNormal) }, ExpressedStateInterconnectCO},
+		{"interconnect clears", func() error { return c.SetInterconnectCOAlarm(AlarmStateNormal) }, ExpressedStateHardwareFault},
+	}
+	for _, s := range steps {
+		if err := s.do(); err != nil {
+			t.Fatalf("%s: error = %v", s.name, err)
+		}
+		if got := c.ExpressedState(); got != s.want {
+			t.Errorf("%s: ExpressedState = %d, want %d", s.name, got, s.want)
+		}
+	}
+}
+
+func TestCustomPriority(t *testing.T) {
+	c, err := New(Config{
+		FeatureMap:             FeatureSmokeAlarm,
+		ExpressedStatePriority: []ExpressedState{ExpressedStateBatteryAlert, ExpressedStateSmokeAlarm},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	c.SetSmokeState(AlarmStateWarning)
+	c.SetBatteryAlert(AlarmStateCritical)
+	if got := c.ExpressedState(); got != ExpressedStateBatteryAlert {
+		t.Errorf("ExpressedState = %d, want BatteryAlert", got)
+	}
+
+	// Conditions missing from the list are never expressed
+	c.SetBatteryAlert(AlarmStateNormal)
+	c.SetSmokeState(AlarmStateNormal)
+	c.SetHardwareFault(true)
+	if got := c.ExpressedState(); got != ExpressedStateNormal {
+		t.Errorf("ExpressedState = %d, want Normal", got)
+	}
+}
+
+func TestAlarmEvents(t *testing.T) {
+	pub := &mockEventPublisher{}
+	c := newAlarm(t, nil, pub)
+
+	c.SetSmokeState(AlarmStateWarning)
+	c.SetSmokeState(AlarmStateCritical)
+	c.SetDeviceMuted(MuteStateMuted)
+	c.SetCOState(AlarmStateWarning)
+	c.SetSmokeState(AlarmStateNormal)
+	c.SetCOState(AlarmStateNormal)
+
+	want := []datamodel.EventID{
+		EventSmokeAlarm, EventSmokeAlarm, EventAlarmMuted, EventCOAlarm, EventAllClear,
+	}
+	if got := pub.take(); !equalIDs(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+
+	c.SetBatteryAlert(AlarmStateWarning)
+	c.SetHardwareFault(true)
+	c.SetEndOfService(EndOfServiceExpired)
+	c.SetInterconnectSmokeAlarm(AlarmStateCritical)
+	c.SetInterconnectSmokeAlarm(AlarmStateNormal)
+	want = []datamodel.EventID{
+		EventLowBattery, EventHardwareFault, EventEndOfService, EventInterconnectSmokeAlarm, EventAllClear,
+	}
+	if got := pub.take(); !equalIDs(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
+
+func TestSelfTest(t *testing.T) {
+	d := &testDelegate{}
+	pub := &mockEventPublisher{}
+	c := newAlarm(t, d, pub)
+
+	invoke := func() error {
+		req := datamodel.InvokeRequest{
+			Path: datamodel.ConcreteCommandPath{Endpoint: 1, Cluster: ClusterID, Command: CmdSelfTestRequest},
+		}
+		_, err := c.InvokeCommand(context.Background(), req, nil)
+		return err
+	}
+
+	if err := invoke(); err != nil {
+		t.Fatalf("SelfTestRequest error = %v", err)
+	}
+	if !c.TestInProgress() || c.ExpressedState() != ExpressedStateTesting || d.selfTests != 1 {
+		t.Errorf("TestInProgress = %v, ExpressedState = %d, selfTests = %d", c.TestInProgress(), c.ExpressedState(), d.selfTests)
+	}
+	if err := invoke(); !errors.Is(err, datamodel.ErrBusy) {
+		t.Errorf("second request error = %v, want ErrBusy", err)
+	}
+
+	c.CompleteSelfTest()
+	if c.TestInProgress() || c.ExpressedState() != ExpressedStateNormal {
+		t.Error("test should have completed")
+	}
+	if got := pub.take(); !equalIDs(got, []datamodel.EventID{EventSelfTestComplete}) {
+		t.Errorf("events = %v, want [SelfTestComplete]", got)
+	}
+
+	// No tests while alarming
+	c.SetCOState(AlarmStateWarning)
+	if err := invoke(); !errors.Is(err, datamodel.ErrBusy) {
+		t.Errorf("request during alarm error = %v, want ErrBusy", err)
+	}
+}
+
+func TestWriteSensitivity(t *testing.T) {
+	d := &testDelegate{}
+	c := newAlarm(t, d, nil)
+
+	write := func(v uint64) error {
+		var buf bytes.Buffer
+		tlv.NewWriter(&buf).PutUint(tlv.Anonymous(), v)
+		req := datamodel.WriteAttributeRequest{
+			Path: datamodel.ConcreteDataAttributePath{
+				ConcreteAttributePath: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: AttrSmokeSensitivityLevel},
+			},
+		}
+		return c.WriteAttribute(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes())))
+	}
+
+	if err := write(uint64(SensitivityHigh)); err != nil {
+		t.Fatalf("write error = %v", err)
+	}
+	if d.sensitivity != SensitivityHigh || c.sensitivity != SensitivityHigh {
+		t.Errorf("sensitivity = %d/%d, want High", d.sensitivity, c.sensitivity)
+	}
+	if err := write(3); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("invalid level error = %v, want ErrConstraintError", err)
+	}
+}
@@ -0,0 +1,34 @@
+package smokecoalarm
+
+import (
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// AlarmEvent is the payload of the SmokeAlarm, COAlarm, LowBattery,
+// InterconnectSmokeAlarm and InterconnectCOAlarm events (Spec 2.11.8).
+type AlarmEvent struct {
+	AlarmSeverityLevel AlarmState
+}
+
+// MarshalTLV implements the TLVMarshaler interface.
+func (e AlarmEvent) MarshalTLV(w *tlv.Writer) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(e.AlarmSeverityLevel)); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// EmptyEvent is the payload of the events without fields: HardwareFault,
+// EndOfService, SelfTestComplete, AlarmMuted, MuteEnded and AllClear.
+type EmptyEvent struct{}
+
+// MarshalTLV implements the TLVMarshaler interface.
+func (e EmptyEvent) MarshalTLV(w *tlv.Writer) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
@@ -0,0 +1,287 @@
+package smokecoalarm
+
+import (
+	"github.com/backkem/matter/pkg/datamodel"
+)
+
+// pendingEvent is an event queued under the lock and emitted after it.
+type pendingEvent struct {
+	id       datamodel.EventID
+	priority datamodel.EventPriority
+	payload  interface{}
+}
+
+// alarmEvent queues an alarm event if state is not Normal.
+func alarmEvent(id datamodel.EventID, priority datamodel.EventPriority, state AlarmState) []pendingEvent {
+	if state == AlarmStateNormal {
+		return nil
+	}
+	return []pendingEvent{{id, priority, AlarmEvent{AlarmSeverityLevel: state}}}
+}
+
+// ExpressedState returns the current ExpressedState.
+func (c *Cluster) ExpressedState() ExpressedState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.expressed
+}
+
+// TestInProgress returns true while a self-test runs.
+func (c *Cluster) TestInProgress() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.testInProgress
+}
+
+// SetSmokeState reports the smoke sensor state (SMOKE). Entering Warning
+// or Critical emits a SmokeAlarm event.
+func (c *Cluster) SetSmokeState(state AlarmState) error {
+	if !c.hasFeature(FeatureSmokeAlarm) {
+		return ErrFeatureNotSupported
+	}
+	return c.setAlarm(&c.smokeState, state, EventSmokeAlarm)
+}
+
+// SetCOState reports the CO sensor state (CO). Entering Warning or
+// Critical emits a COAlarm event.
+func (c *Cluster) SetCOState(state AlarmState) error {
+	if !c.hasFeature(FeatureCOAlarm) {
+		return ErrFeatureNotSupported
+	}
+	return c.setAlarm(&c.coState, state, EventCOAlarm)
+}
+
+// SetInterconnectSmokeAlarm reports a smoke alarm relayed from an
+// interconnected device.
+func (c *Cluster) SetInterconnectSmokeAlarm(state AlarmState) error {
+	if !c.config.EnableInterconnect || !c.hasFeature(FeatureSmokeAlarm) {
+		return ErrFeatureNotSupported
+	}
+	return c.setAlarm(&c.interconnectSmoke, state, EventInterconnectSmokeAlarm)
+}
+
+// SetInterconnectCOAlarm reports a CO alarm relayed from an
+// interconnected device.
+func (c *Cluster) SetInterconnectCOAlarm(state AlarmState) error {
+	if !c.config.EnableInterconnect || !c.hasFeature(FeatureCOAlarm) {
+		return ErrFeatureNotSupported
+	}
+	return c.setAlarm(&c.interconnectCO, state, EventInterconnectCOAlarm)
+}
+
+// setAlarm updates one of the smoke or CO alarm states.
+func (c *Cluster) setAlarm(field *AlarmState, state AlarmState, eventID datamodel.EventID) error {
+	if state > AlarmStateCritical {
+		return datamodel.ErrConstraintError
+	}
+	return c.update(func() []pendingEvent {
+		if *field == state {
+			return nil
+		}
+		*field = state
+		c.IncrementDataVersion()
+		return alarmEvent(eventID, datamodel.EventPriorityCritical, state)
+	})
+}
+
+// SetBatteryAlert reports the battery level. Entering Warning or Critical
+// emits a LowBattery event.
+func (c *Cluster) SetBatteryAlert(state AlarmState) error {
+	if state > AlarmStateCritical {
+		return datamodel.ErrConstraintError
+	}
+	return c.update(func() []pendingEvent {
+		if c.batteryAlert == state {
+			return nil
+		}
+		c.batteryAlert = state
+		c.IncrementDataVersion()
+		return alarmEvent(EventLowBattery, datamodel.EventPriorityInfo, state)
+	})
+}
+
+// SetHardwareFault reports a hardware fault. Raising it emits a
+// HardwareFault event.
+func (c *Cluster) SetHardwareFault(fault bool) error {
+	return c.update(func() []pendingEvent {
+		if c.hardwareFault == fault {
+			return nil
+		}
+		c.hardwareFault = fault
+		c.IncrementDataVersion()
+		if !fault {
+			return nil
+		}
+		return []pendingEvent{{EventHardwareFault, datamodel.EventPriorityInfo, EmptyEvent{}}}
+	})
+}
+
+// SetEndOfService reports the end of the device's service life. Expiry
+// emits an EndOfService event.
+func (c *Cluster) SetEndOfService(state EndOfService) error {
+	if state > EndOfServiceExpired {
+		return datamodel.ErrConstraintError
+	}
+	return c.update(func() []pendingEvent {
+		if c.endOfService == state {
+			return nil
+		}
+		c.endOfService = state
+		c.IncrementDataVersion()
+		if state != EndOfServiceExpired {
+			return nil
+		}
+		return []pendingEvent{{EventEndOfService, datamodel.EventPriorityInfo, EmptyEvent{}}}
+	})
+}
+
+// SetDeviceMuted reports the user muting or unmuting the alarm, emitting
+// AlarmMuted or MuteEnded.
+func (c *Cluster) SetDeviceMuted(state MuteState) error {
+	if !c.config.EnableDeviceMuted {
+		return ErrFeatureNotSupported
+	}
+	if state > MuteStateMuted {
+		return datamodel.ErrConstraintError
+	}
+	return c.update(func() []pendingEvent {
+		if c.deviceMuted == state {
+			return nil
+		}
+		c.deviceMuted = state
+		c.IncrementDataVersion()
+		id := EventMuteEnded
+		if state == MuteStateMuted {
+			id = EventAlarmMuted
+		}
+		return []pendingEvent{{id, datamodel.EventPriorityInfo, EmptyEvent{}}}
+	})
+}
+
+// SetContaminationState reports sensor contamination (SMOKE).
+func (c *Cluster) SetContaminationState(state ContaminationState) error {
+	if !c.config.EnableContaminationState {
+		return ErrFeatureNotSupported
+	}
+	if state > ContaminationCritical {
+		return datamodel.ErrConstraintError
+	}
+	return c.update(func() []pendingEvent {
+		if c.contamination != state {
+			c.contamination = state
+			c.IncrementDataVersion()
+		}
+		return nil
+	})
+}
+
+// SelfTestRequest handles the SelfTestRequest command. Returns ErrBusy
+// while an alarm is expressed or a test already runs.
+//
+// Spec: Section 2.11.7.1
+func (c *Cluster) SelfTestRequest() error {
+	switch c.ExpressedState() {
+	case ExpressedStateSmokeAlarm, ExpressedStateCOAlarm, ExpressedStateTesting,
+		ExpressedStateInterconnectSmoke, ExpressedStateInterconnectCO:
+		return datamodel.ErrBusy
+	}
+
+	if d := c.config.Delegate; d != nil {
+		if err := d.HandleSelfTest(); err != nil {
+			return err
+		}
+	}
+
+	return c.update(func() []pendingEvent {
+		if !c.testInProgress {
+			c.testInProgress = true
+			c.IncrementDataVersion()
+		}
+		return nil
+	})
+}
+
+// CompleteSelfTest reports the end of a self-test and emits
+// SelfTestComplete.
+func (c *Cluster) CompleteSelfTest() error {
+	return c.update(func() []pendingEvent {
+		if !c.testInProgress {
+			return nil
+		}
+		c.testInProgress = false
+		c.IncrementDataVersion()
+		return []pendingEvent{{EventSelfTestComplete, datamodel.EventPriorityInfo, EmptyEvent{}}}
+	})
+}
+
+// update applies change under the lock, recomputes ExpressedState and
+// emits the resulting events. AllClear follows once the last smoke or CO
+// alarm is cleared.
+func (c *Cluster) update(change func() []pendingEvent) error {
+	c.mu.Lock()
+	wasAlarm := c.alarmActiveLocked()
+	events := change()
+	if wasAlarm && !c.alarmActiveLocked() {
+		events = append(events, pendingEvent{EventAllClear, datamodel.EventPriorityInfo, EmptyEvent{}})
+	}
+	expressed := c.expressedStateLocked()
+	changed := expressed != c.expressed
+	if changed {
+		c.expressed = expressed
+		c.IncrementDataVersion()
+	}
+	c.mu.Unlock()
+
+	if changed && c.config.OnExpressedStateChange != nil {
+		c.config.OnExpressedStateChange(c.config.EndpointID, expressed)
+	}
+	for _, e := range events {
+		if err := c.emit(e.id, e.priority, e.payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// alarmActiveLocked returns true if any smoke or CO alarm is active.
+// Caller must hold c.mu.
+func (c *Cluster) alarmActiveLocked() bool {
+	return c.smokeState != AlarmStateNormal || c.coState != AlarmStateNormal ||
+		c.interconnectSmoke != AlarmStateNormal || c.interconnectCO != AlarmStateNormal
+}
+
+// expressedStateLocked returns the highest priority active condition.
+// Caller must hold c.mu.
+func (c *Cluster) expressedStateLocked() ExpressedState {
+	for _, s := range c.config.ExpressedStatePriority {
+		if c.activeLocked(s) {
+			return s
+		}
+	}
+	return ExpressedStateNormal
+}
+
+// activeLocked returns true if the condition for s is present. Caller
+// must hold c.mu.
+func (c *Cluster) activeLocked(s ExpressedState) bool {
+	switch s {
+	case ExpressedStateSmokeAlarm:
+		return c.smokeState != AlarmStateNormal
+	case ExpressedStateCOAlarm:
+		return c.coState != AlarmStateNormal
+	case ExpressedStateBatteryAlert:
+		return c.batteryAlert != AlarmStateNormal
+	case ExpressedStateTesting:
+		return c.testInProgress
+	case ExpressedStateHardwareFault:
+		return c.hardwareFault
+	case ExpressedStateEndOfService:
+		return c.endOfService == EndOfServiceExpired
+	case ExpressedStateInterconnectSmoke:
+		return c.interconnectSmoke != AlarmStateNormal
+	case ExpressedStateInterconnectCO:
+		return c.interconnectCO != AlarmStateNormal
+	default:
+		return false
+	}
+}
@@ -0,0 +1,484 @@
+// Package switchcluster implements the Switch Cluster (0x003B).
+//
+// The Switch cluster exposes the state of a physical switch (latching or
+// momentary) and reports position changes through events. Device firmware
+// drives the cluster via Press, LongPress and Release; the cluster takes
+// care of CurrentPosition and of emitting the spec-defined event sequence
+// for the configured feature set.
+//
+// The package is named switchcluster because "switch" is a Go keyword.
+//
+// Spec Reference: Section 1.13
+//
+// C++ Reference: src/app/clusters/switch-server/switch-server.cpp
+package switchcluster
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x003B
+	ClusterRevision uint16              = 2
+)
+
+// Attribute IDs (Spec 1.13.5).
+const (
+	AttrNumberOfPositions datamodel.AttributeID = 0x0000
+	AttrCurrentPosition   datamodel.AttributeID = 0x0001
+	AttrMultiPressMax     datamodel.AttributeID = 0x0002
+)
+
+// Event IDs (Spec 1.13.7).
+const (
+	EventSwitchLatched      datamodel.EventID = 0x00
+	EventInitialPress       datamodel.EventID = 0x01
+	EventLongPress          datamodel.EventID = 0x02
+	EventShortRelease       datamodel.EventID = 0x03
+	EventLongRelease        datamodel.EventID = 0x04
+	EventMultiPressOngoing  datamodel.EventID = 0x05
+	EventMultiPressComplete datamodel.EventID = 0x06
+)
+
+// Feature bits (Spec 1.13.4).
+type Feature uint32
+
+const (
+	// FeatureLatchingSwitch indicates a switch that maintains its position (LS).
+	FeatureLatchingSwitch Feature = 1 << 0
+
+	// FeatureMomentarySwitch indicates a switch that returns to its default
+	// position when released (MS).
+	FeatureMomentarySwitch Feature = 1 << 1
+
+	// FeatureMomentarySwitchRelease enables ShortRelease events (MSR).
+	FeatureMomentarySwitchRelease Feature = 1 << 2
+
+	// FeatureMomentarySwitchLongPress enables LongPress and LongRelease events (MSL).
+	FeatureMomentarySwitchLongPress Feature = 1 << 3
+
+	// FeatureMomentarySwitchMultiPress enables multi-press detection (MSM).
+	FeatureMomentarySwitchMultiPress Feature = 1 << 4
+
+	// FeatureActionSwitch indicates the switch reports only the outcome of
+	// a press sequence, suppressing MultiPressOngoing events (AS).
+	FeatureActionSwitch Feature = 1 << 5
+)
+
+// Default values.
+const (
+	// DefaultMultiPressMax is the default MultiPressMax value (Spec 1.13.5.3).
+	DefaultMultiPressMax uint8 = 2
+
+	// DefaultMultiPressWindow is the idle time after a release that ends a
+	// multi-press sequence.
+	DefaultMultiPressWindow = 500 * time.Millisecond
+)
+
+// Config provides dependencies for the Switch cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to.
+	EndpointID datamodel.EndpointID
+
+	// FeatureMap indicates supported features.
+	// Exactly one of FeatureLatchingSwitch or FeatureMomentarySwitch must be set.
+	FeatureMap Feature
+
+	// NumberOfPositions is the number of positions the switch can be in (min 2).
+	// Defaults to 2 if zero.
+	NumberOfPositions uint8
+
+	// MultiPressMax is the maximum number of presses counted in a multi-press
+	// sequence (min 2). Only used with FeatureMomentarySwitchMultiPress.
+	// Defaults to DefaultMultiPressMax if zero.
+	MultiPressMax uint8
+
+	// MultiPressWindow is the idle time after a release that ends a
+	// multi-press sequence. Defaults to DefaultMultiPressWindow if zero.
+	MultiPressWindow time.Duration
+
+	// EventPublisher for switch events.
+	// Optional - if nil, events are not emitted.
+	EventPublisher datamodel.EventPublisher
+
+	// OnPositionChange is called when CurrentPosition changes (optional).
+	OnPositionChange func(endpoint datamodel.EndpointID, position uint8)
+}
+
+// Cluster implements the Switch cluster (0x003B).
+type Cluster struct {
+	*datamodel.ClusterBase
+	*datamodel.EventSource
+	config Config
+
+	// Mutable state (protected by mutex)
+	mu              sync.Mutex
+	currentPosition uint8
+
+	// Momentary press tracking
+	pressed       bool
+	longPressed   bool
+	pressPosition uint8
+
+	// Multi-press tracking (MSM)
+	pressCount     uint8
+	multiPressPos  uint8
+	multiPressTime *time.Timer
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Switch cluster.
+func New(cfg Config) *Cluster {
+	if cfg.NumberOfPositions < 2 {
+		cfg.NumberOfPositions = 2
+	}
+	if cfg.MultiPressMax < 2 {
+		cfg.MultiPressMax = DefaultMultiPressMax
+	}
+	if cfg.MultiPressWindow == 0 {
+		cfg.MultiPressWindow = DefaultMultiPressWindow
+	}
+
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		EventSource: datamodel.NewEventSource(),
+		config:      cfg,
+	}
+
+	c.ClusterBase.SetFeatureMap(uint32(cfg.FeatureMap))
+
+	// Bind event source
+	if cfg.EventPublisher != nil {
+		c.EventSource.Bind(cfg.EndpointID, ClusterID, cfg.EventPublisher)
+		c.registerEvents()
+	}
+
+	c.attrList = c.buildAttributeList()
+
+	return c
+}
+
+// hasFeature returns true if the feature bit is set.
+func (c *Cluster) hasFeature(f Feature) bool {
+	return c.config.FeatureMap&f != 0
+}
+
+// registerEvents registers the events enabled by the feature map.
+func (c *Cluster) registerEvents() {
+	register := func(id datamodel.EventID) {
+		c.EventSource.RegisterEvent(datamodel.NewEventEntry(
+			id,
+			datamodel.EventPriorityInfo,
+			datamodel.PrivilegeView,
+			false,
+		))
+	}
+
+	if c.hasFeature(FeatureLatchingSwitch) {
+		register(EventSwitchLatched)
+	}
+	if c.hasFeature(FeatureMomentarySwitch) {
+		register(EventInitialPress)
+	}
+	if c.hasFeature(FeatureMomentarySwitchLongPress) {
+		register(EventLongPress)
+		register(EventLongRelease)
+	}
+	if c.hasFeature(FeatureMomentarySwitchRelease) {
+		register(EventShortRelease)
+	}
+	if c.hasFeature(FeatureMomentarySwitchMultiPress) {
+		if !c.hasFeature(FeatureActionSwitch) {
+			register(EventMultiPressOngoing)
+		}
+		register(EventMultiPressComplete)
+	}
+}
+
+// buildAttributeList constructs the list of supported attributes.
+func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+
+	attrs := []datamodel.AttributeEntry{
+		datamodel.NewReadOnlyAttribute(AttrNumberOfPositions, datamodel.AttrQualityFixed, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrCurrentPosition, 0, viewPriv),
+	}
+
+	if c.hasFeature(FeatureMomentarySwitchMultiPress) {
+		attrs = append(attrs,
+			datamodel.NewReadOnlyAttribute(AttrMultiPressMax, datamodel.AttrQualityFixed, viewPriv),
+		)
+	}
+
+	return datamodel.MergeAttributeLists(attrs)
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	// Switch cluster has no commands
+	return nil
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return nil
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	switch req.Path.Attribute {
+	case AttrNumberOfPositions:
+		return w.PutUint(tlv.Anonymous(), uint64(c.config.NumberOfPositions))
+
+	case AttrCurrentPosition:
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return w.PutUint(tlv.Anonymous(), uint64(c.currentPosition))
+
+	case AttrMultiPressMax:
+		if !c.hasFeature(FeatureMomentarySwitchMultiPress) {
+			return datamodel.ErrUnsupportedAttribute
+		}
+		return w.PutUint(tlv.Anonymous(), uint64(c.config.MultiPressMax))
+
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	// All Switch attributes are read-only
+	return datamodel.ErrUnsupportedWrite
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	return nil, datamodel.ErrUnsupportedCommand
+}
+
+// CurrentPosition returns the current switch position.
+func (c *Cluster) CurrentPosition() uint8 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.currentPosition
+}
+
+// Press reports that the switch moved to the given position.
+//
+// For a latching switch this emits SwitchLatched. For a momentary switch it
+// emits InitialPress and, with the MultiPress feature, MultiPressOngoing for
+// subsequent presses in the same sequence.
+//
+// Spec: Section 1.13.7.1, 1.13.7.2, 1.13.7.6
+func (c *Cluster) Press(position uint8) error {
+	if position >= c.config.NumberOfPositions {
+		return ErrInvalidPosition
+	}
+
+	if c.hasFeature(FeatureLatchingSwitch) {
+		if !c.setPosition(position) {
+			return nil
+		}
+		return c.emit(EventSwitchLatched, SwitchLatchedEvent{NewPosition: position})
+	}
+
+	if !c.hasFeature(FeatureMomentarySwitch) {
+		return ErrFeatureNotSupported
+	}
+
+	c.mu.Lock()
+	if c.pressed {
+		c.mu.Unlock()
+		return ErrAlreadyPressed
+	}
+	c.pressed = true
+	c.longPressed = false
+	c.pressPosition = position
+
+	// Track multi-press sequence
+	var ongoing *MultiPressOngoingEvent
+	if c.hasFeature(FeatureMomentarySwitchMultiPress) {
+		if c.multiPressTime != nil {
+			c.multiPressTime.Stop()
+			c.multiPressTime = nil
+		}
+		if c.pressCount > 0 && c.multiPressPos != position {
+			// Pressing a different position starts a new sequence
+			c.pressCount = 0
+		}
+		c.multiPressPos = position
+		if c.pressCount < 0xFF {
+			c.pressCount++
+		}
+		if c.pressCount > 1 && !c.hasFeature(FeatureActionSwitch) {
+			ongoing = &MultiPressOngoingEvent{
+				NewPosition:                   position,
+				CurrentNumberOfPressesCounted: c.pressCount,
+			}
+		}
+	}
+	c.mu.Unlock()
+
+	c.setPosition(position)
+
+	if err := c.emit(EventInitialPress, InitialPressEvent{NewPosition: position}); err != nil {
+		return err
+	}
+	if ongoing != nil {
+		return c.emit(EventMultiPressOngoing, *ongoing)
+	}
+	return nil
+}
+
+// LongPress reports that the currently pressed position has been held long
+// enough to count as a long press. A long press aborts any multi-press
+// sequence in progress.
+//
+// Spec: Section 1.13.7.3
+func (c *Cluster) LongPress() error {
+	if !c.hasFeature(FeatureMomentarySwitchLongPress) {
+		return ErrFeatureNotSupported
+	}
+
+	c.mu.Lock()
+	if !c.pressed {
+		c.mu.Unlock()
+		return ErrNotPressed
+	}
+	if c.longPressed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.longPressed = true
+	position := c.pressPosition
+	c.resetMultiPressLocked()
+	c.mu.Unlock()
+
+	return c.emit(EventLongPress, LongPressEvent{NewPosition: position})
+}
+
+// Release reports that a momentary switch returned to its default position (0).
+//
+// Emits LongRelease after a long press, otherwise ShortRelease. With the
+// MultiPress feature, MultiPressComplete is emitted once MultiPressWindow
+// elapses without another press.
+//
+// Spec: Section 1.13.7.4, 1.13.7.5, 1.13.7.7
+func (c *Cluster) Release() error {
+	if !c.hasFeature(FeatureMomentarySwitch) {
+		return ErrFeatureNotSupported
+	}
+
+	c.mu.Lock()
+	if !c.pressed {
+		c.mu.Unlock()
+		return ErrNotPressed
+	}
+	c.pressed = false
+	previous := c.pressPosition
+	wasLong := c.longPressed
+	c.longPressed = false
+
+	if !wasLong && c.hasFeature(FeatureMomentarySwitchMultiPress) && c.pressCount > 0 {
+		c.multiPressTime = time.AfterFunc(c.config.MultiPressWindow, c.completeMultiPress)
+	}
+	c.mu.Unlock()
+
+	c.setPosition(0)
+
+	if wasLong {
+		return c.emit(EventLongRelease, LongReleaseEvent{PreviousPosition: previous})
+	}
+	if c.hasFeature(FeatureMomentarySwitchRelease) {
+		return c.emit(EventShortRelease, ShortReleaseEvent{PreviousPosition: previous})
+	}
+	return nil
+}
+
+// completeMultiPress ends the current multi-press sequence.
+func (c *Cluster) completeMultiPress() {
+	c.mu.Lock()
+	if c.pressed || c.pressCount == 0 {
+		c.mu.Unlock()
+		return
+	}
+	count := c.pressCount
+	position := c.multiPressPos
+	c.resetMultiPressLocked()
+	c.mu.Unlock()
+
+	// Per spec, a count above MultiPressMax is reported as zero
+	if count > c.config.MultiPressMax {
+		count = 0
+	}
+
+	_ = c.emit(EventMultiPressComplete, MultiPressCompleteEvent{
+		PreviousPosition:            position,
+		TotalNumberOfPressesCounted: count,
+	})
+}
+
+// resetMultiPressLocked clears multi-press tracking. Caller must hold mu.
+func (c *Cluster) resetMultiPressLocked() {
+	if c.multiPressTime != nil {
+		c.multiPressTime.Stop()
+		c.multiPressTime = nil
+	}
+	c.pressCount = 0
+}
+
+// setPosition updates CurrentPosition. Returns true if the value changed.
+func (c *Cluster) setPosition(position uint8) bool {
+	c.mu.Lock()
+	if c.currentPosition == position {
+		c.mu.Unlock()
+		return false
+	}
+	c.currentPosition = position
+	c.mu.Unlock()
+
+	c.IncrementDataVersion()
+
+	if c.config.OnPositionChange != nil {
+		c.config.OnPositionChange(c.config.EndpointID, position)
+	}
+	return true
+}
+
+// emit publishes an event if a publisher is bound.
+func (c *Cluster) emit(eventID datamodel.EventID, payload interface{}) error {
+	if !c.EventSource.IsBound() {
+		return nil // No publisher, silently skip
+	}
+	_, err := c.EventSource.Emit(eventID, datamodel.EventPriorityInfo, payload)
+	return err
+}
+
+// Errors returned by the switch API.
+var (
+	ErrInvalidPosition     = errors.New("switch: position out of range")
+	ErrFeatureNotSupported = errors.New("switch: feature not supported")
+	ErrAlreadyPressed      = errors.New("switch: already pressed")
+	ErrNotPressed          = errors.New("switch: not pressed")
+)
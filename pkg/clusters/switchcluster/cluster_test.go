@@ -0,0 +1,357 @@
+package switchcluster
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// mockEventPublisher implements EventPublisher for testing.
+type mockEventPublisher struct {
+	mu     sync.Mutex
+	events []publishedEvent
+}
+
+type publishedEvent struct {
+	eventID datamodel.EventID
+	data    interface{}
+}
+
+func (m *mockEventPublisher) PublishEvent(
+	endpoint datamodel.EndpointID,
+	cluster datamodel.ClusterID,
+	eventID datamodel.EventID,
+	priority datamodel.EventPriority,
+	data interface{},
+	fabricIndex uint8,
+) (datamodel.EventNumber, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, publishedEvent{eventID: eventID, data: data})
+	return datamodel.EventNumber(len(m.events)), nil
+}
+
+func (m *mockEventPublisher) ids() []datamodel.EventID {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := make([]datamodel.EventID, len(m.events))
+	for i, e := range m.events {
+		ids[i] = e.eventID
+	}
+	return ids
+}
+
+func (m *mockEventPublisher) last() publishedEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.events[len(m.events)-1]
+}
+
+func equalIDs(a, b []datamodel.EventID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func readUint(t *testing.T, c *Cluster, attr datamodel.AttributeID) uint64 {
+	t.Helper()
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	req := datamodel.ReadAttributeRequest{
+		Path: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: attr},
+	}
+	if err := c.ReadAttribute(context.Background(), req, w); err != nil {
+		t.Fatalf("ReadAttribute(0x%04X) failed: %v", attr, err)
+	}
+	r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+	if err := r.Next(); err != nil {
+		t.Fatalf("failed to read value: %v", err)
+	}
+	val, err := r.Uint()
+	if err != nil {
+		t.Fatalf("failed to decode uint: %v", err)
+	}
+	return val
+}
+
+func TestClusterID(t *testing.T) {
+	c := New(Config{EndpointID: 1, FeatureMap: FeatureLatchingSwitch})
+	if c.ID() != ClusterID {
+		t.Errorf("expected cluster ID 0x%04X, got 0x%04X", ClusterID, c.ID())
+	}
+	if c.FeatureMap() != uint32(FeatureLatchingSwitch) {
+		t.Errorf("expected feature map 0x%X, got 0x%X", FeatureLatchingSwitch, c.FeatureMap())
+	}
+}
+
+func TestReadAttributes(t *testing.T) {
+	c := New(Config{
+		EndpointID:        1,
+		FeatureMap:        FeatureMomentarySwitch | FeatureMomentarySwitchRelease | FeatureMomentarySwitchMultiPress,
+		NumberOfPositions: 3,
+		MultiPressMax:     4,
+	})
+
+	if got := readUint(t, c, AttrNumberOfPositions); got != 3 {
+		t.Errorf("NumberOfPositions = %d, want 3", got)
+	}
+	if got := readUint(t, c, AttrCurrentPosition); got != 0 {
+		t.Errorf("CurrentPosition = %d, want 0", got)
+	}
+	if got := readUint(t, c, AttrMultiPressMax); got != 4 {
+		t.Errorf("MultiPressMax = %d, want 4", got)
+	}
+}
+
+func TestAttributeList_MultiPressMax(t *testing.T) {
+	c := New(Config{EndpointID: 1, FeatureMap: FeatureMomentarySwitch})
+	if datamodel.FindAttribute(c.AttributeList(), AttrMultiPressMax) != nil {
+		t.Error("MultiPressMax should not be present without MSM")
+	}
+
+	var buf bytes.Buffer
+	req := datamodel.ReadAttributeRequest{
+		Path: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: AttrMultiPressMax},
+	}
+	if err := c.ReadAttribute(context.Background(), req, tlv.NewWriter(&buf)); !errors.Is(err, datamodel.ErrUnsupportedAttribute) {
+		t.Errorf("expected ErrUnsupportedAttribute, got %v", err)
+	}
+
+	c = New(Config{EndpointID: 1, FeatureMap: FeatureMomentarySwitch | FeatureMomentarySwitchMultiPress})
+	if datamodel.FindAttribute(c.AttributeList(), AttrMultiPressMax) == nil {
+		t.Error("MultiPressMax should be present with MSM")
+	}
+}
+
+func TestLatchingSwitch(t *testing.T) {
+	pub := &mockEventPublisher{}
+	c := New(Config{
+		EndpointID:        1,
+		FeatureMap:        FeatureLatchingSwitch,
+		NumberOfPositions: 3,
+		EventPublisher:    pub,
+	})
+
+	version := c.DataVersion()
+	if err := c.Press(2); err != nil {
+		t.Fatalf("Press failed: %v", err)
+	}
+	if c.CurrentPosition() != 2 {
+		t.Errorf("CurrentPosition = %d, want 2", c.CurrentPosition())
+	}
+	if c.DataVersion() == version {
+		t.Error("expected data version to change")
+	}
+
+	// Same position again is a no-op
+	if err := c.Press(2); err != nil {
+		t.Fatalf("Press failed: %v", err)
+	}
+
+	if !equalIDs(pub.ids(), []datamodel.EventID{EventSwitchLatched}) {
+		t.Fatalf("unexpected events: %v", pub.ids())
+	}
+	ev, ok := pub.last().data.(SwitchLatchedEvent)
+	if !ok || ev.NewPosition != 2 {
+		t.Errorf("unexpected event payload: %+v", pub.last().data)
+	}
+
+	if err := c.Press(3); !errors.Is(err, ErrInvalidPosition) {
+		t.Errorf("expected ErrInvalidPosition, got %v", err)
+	}
+	if err := c.Release(); !errors.Is(err, ErrFeatureNotSupported) {
+		t.Errorf("expected ErrFeatureNotSupported, got %v", err)
+	}
+}
+
+func TestMomentaryShortPress(t *testing.T) {
+	pub := &mockEventPublisher{}
+	c := New(Config{
+		EndpointID:     1,
+		FeatureMap:     FeatureMomentarySwitch | FeatureMomentarySwitchRelease,
+		EventPublisher: pub,
+	})
+
+	if err := c.Press(1); err != nil {
+		t.Fatalf("Press failed: %v", err)
+	}
+	if c.CurrentPosition() != 1 {
+		t.Errorf("CurrentPosition = %d, want 1", c.CurrentPosition())
+	}
+	if err := c.Press(1); !errors.Is(err, ErrAlreadyPressed) {
+		t.Errorf("expected ErrAlreadyPressed, got %v", err)
+	}
+	if err := c.Release(); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if c.CurrentPosition() != 0 {
+		t.Errorf("CurrentPosition = %d, want 0", c.CurrentPosition())
+	}
+
+	want := []datamodel.EventID{EventInitialPress, EventShortRelease}
+	if !equalIDs(pub.ids(), want) {
+		t.Fatalf("events = %v, want %v", pub.ids(), want)
+	}
+	ev, ok := pub.last().data.(ShortReleaseEvent)
+	if !ok || ev.PreviousPosition != 1 {
+		t.Errorf("unexpected event payload: %+v", pub.last().data)
+	}
+
+	if err := c.Release(); !errors.Is(err, ErrNotPressed) {
+		t.Errorf("expected ErrNotPressed, got %v", err)
+	}
+}
+
+func TestMomentaryLongPress(t *testing.T) {
+	pub := &mockEventPublisher{}
+	c := New(Config{
+		EndpointID:     1,
+		FeatureMap:     FeatureMomentarySwitch | FeatureMomentarySwitchRelease | FeatureMomentarySwitchLongPress,
+		EventPublisher: pub,
+	})
+
+	if err := c.LongPress(); !errors.Is(err, ErrNotPressed) {
+		t.Errorf("expected ErrNotPressed, got %v", err)
+	}
+
+	_ = c.Press(1)
+	if err := c.LongPress(); err != nil {
+		t.Fatalf("LongPress failed: %v", err)
+	}
+	_ = c.Release()
+
+	want := []datamodel.EventID{EventInitialPress, EventLongPress, EventLongRelease}
+	if !equalIDs(pub.ids(), want) {
+		t.Fatalf("events = %v, want %v", pub.ids(), want)
+	}
+}
+
+func TestLongPress_NotSupported(t *testing.T) {
+	c := New(Config{EndpointID: 1, FeatureMap: FeatureMomentarySwitch})
+	_ = c.Press(1)
+	if err := c.LongPress(); !errors.Is(err, ErrFeatureNotSupported) {
+		t.Errorf("expected ErrFeatureNotSupported, got %v", err)
+	}
+}
+
+func TestMultiPress(t *testing.T) {
+	pub := &mockEventPublisher{}
+	c := New(Config{
+		EndpointID:       1,
+		FeatureMap:       FeatureMomentarySwitch | FeatureMomentarySwitchRelease | FeatureMomentarySwitchMultiPress,
+		MultiPressMax:    3,
+		MultiPressWindow: 20 * time.Millisecond,
+		EventPublisher:   pub,
+	})
+
+	for i := 0; i < 2; i++ {
+		if err := c.Press(1); err != nil {
+			t.Fatalf("Press failed: %v", err)
+		}
+		if err := c.Release(); err != nil {
+			t.Fatalf("Release failed: %v", err)
+		}
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if ids := pub.ids(); len(ids) > 0 && ids[len(ids)-1] == EventMultiPressComplete {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	want := []datamodel.EventID{
+		EventInitialPress, EventShortRelease,
+		EventInitialPress, EventMultiPressOngoing, EventShortRelease,
+		EventMultiPressComplete,
+	}
+	if !equalIDs(pub.ids(), want) {
+		t.Fatalf("events = %v, want %v", pub.ids(), want)
+	}
+	ev, ok := pub.last().data.(MultiPressCompleteEvent)
+	if !ok || ev.PreviousPosition != 1 || ev.TotalNumberOfPressesCounted != 2 {
+		t.Errorf("unexpected event payload: %+v", pub.last().data)
+	}
+}
+
+func TestMultiPress_ExceedsMax(t *testing.T) {
+	pub := &mockEventPublisher{}
+	c := New(Config{
+		EndpointID:       1,
+		FeatureMap:       FeatureMomentarySwitch | FeatureMomentarySwitchMultiPress | FeatureActionSwitch,
+		MultiPressMax:    2,
+		MultiPressWindow: 20 * time.Millisecond,
+		EventPublisher:   pub,
+	})
+
+	for i := 0; i < 3; i++ {
+		_ = c.Press(1)
+		_ = c.Release()
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) && len(pub.ids()) < 4 {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// ActionSwitch suppresses MultiPressOngoing
+	want := []datamodel.EventID{EventInitialPress, EventInitialPress, EventInitialPress, EventMultiPressComplete}
+	if !equalIDs(pub.ids(), want) {
+		t.Fatalf("events = %v, want %v", pub.ids(), want)
+	}
+	ev := pub.last().data.(MultiPressCompleteEvent)
+	if ev.TotalNumberOfPressesCounted != 0 {
+		t.Errorf("TotalNumberOfPressesCounted = %d, want 0", ev.TotalNumberOfPressesCounted)
+	}
+}
+
+func TestEventTLV(t *testing.T) {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	ev := MultiPressCompleteEvent{PreviousPosition: 1, TotalNumberOfPressesCounted: 3}
+	if err := ev.MarshalTLV(w); err != nil {
+		t.Fatalf("MarshalTLV failed: %v", err)
+	}
+
+	r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+	if err := r.Next(); err != nil || r.Type() != tlv.ElementTypeStruct {
+		t.Fatalf("expected struct, err=%v", err)
+	}
+	if err := r.EnterContainer(); err != nil {
+		t.Fatal(err)
+	}
+	fields := map[uint64]uint64{}
+	for r.Next() == nil && r.Type() != tlv.ElementTypeEnd {
+		v, err := r.Uint()
+		if err != nil {
+			t.Fatal(err)
+		}
+		fields[uint64(r.Tag().TagNumber())] = v
+	}
+	if fields[0] != 1 || fields[1] != 3 {
+		t.Errorf("unexpected fields: %v", fields)
+	}
+}
+
+func TestNoPublisher(t *testing.T) {
+	c := New(Config{EndpointID: 1, FeatureMap: FeatureMomentarySwitch | FeatureMomentarySwitchRelease})
+	if err := c.Press(1); err != nil {
+		t.Fatalf("Press without publisher failed: %v", err)
+	}
+	if err := c.Release(); err != nil {
+		t.Fatalf("Release without publisher failed: %v", err)
+	}
+}
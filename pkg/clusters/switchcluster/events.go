@@ -0,0 +1,114 @@
+package switchcluster
+
+import (
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// SwitchLatchedEvent is emitted when a latching switch moves to a new position (Spec 1.13.7.1).
+// Priority: INFO, Conformance: LS
+type SwitchLatchedEvent struct {
+	NewPosition uint8
+}
+
+// MarshalTLV implements the TLVMarshaler interface.
+func (e SwitchLatchedEvent) MarshalTLV(w *tlv.Writer) error {
+	return marshalPosition(w, e.NewPosition)
+}
+
+// InitialPressEvent is emitted when a momentary switch starts to be pressed (Spec 1.13.7.2).
+// Priority: INFO, Conformance: MS
+type InitialPressEvent struct {
+	NewPosition uint8
+}
+
+// MarshalTLV implements the TLVMarshaler interface.
+func (e InitialPressEvent) MarshalTLV(w *tlv.Writer) error {
+	return marshalPosition(w, e.NewPosition)
+}
+
+// LongPressEvent is emitted when a momentary switch has been pressed for a long time (Spec 1.13.7.3).
+// Priority: INFO, Conformance: MSL
+type LongPressEvent struct {
+	NewPosition uint8
+}
+
+// MarshalTLV implements the TLVMarshaler interface.
+func (e LongPressEvent) MarshalTLV(w *tlv.Writer) error {
+	return marshalPosition(w, e.NewPosition)
+}
+
+// ShortReleaseEvent is emitted when a momentary switch is released after a short press (Spec 1.13.7.4).
+// Priority: INFO, Conformance: MSR
+type ShortReleaseEvent struct {
+	PreviousPosition uint8
+}
+
+// MarshalTLV implements the TLVMarshaler interface.
+func (e ShortReleaseEvent) MarshalTLV(w *tlv.Writer) error {
+	return marshalPosition(w, e.PreviousPosition)
+}
+
+// LongReleaseEvent is emitted when a momentary switch is released after a long press (Spec 1.13.7.5).
+// Priority: INFO, Conformance: MSL
+type LongReleaseEvent struct {
+	PreviousPosition uint8
+}
+
+// MarshalTLV implements the TLVMarshaler interface.
+func (e LongReleaseEvent) MarshalTLV(w *tlv.Writer) error {
+	return marshalPosition(w, e.PreviousPosition)
+}
+
+// MultiPressOngoingEvent is emitted for each additional press in a multi-press sequence (Spec 1.13.7.6).
+// Priority: INFO, Conformance: MSM & !AS
+type MultiPressOngoingEvent struct {
+	NewPosition                   uint8
+	CurrentNumberOfPressesCounted uint8
+}
+
+// MarshalTLV implements the TLVMarshaler interface.
+func (e MultiPressOngoingEvent) MarshalTLV(w *tlv.Writer) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(e.NewPosition)); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(1), uint64(e.CurrentNumberOfPressesCounted)); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// MultiPressCompleteEvent is emitted when a multi-press sequence ends (Spec 1.13.7.7).
+// A TotalNumberOfPressesCounted of 0 indicates more presses than MultiPressMax.
+// Priority: INFO, Conformance: MSM
+type MultiPressCompleteEvent struct {
+	PreviousPosition            uint8
+	TotalNumberOfPressesCounted uint8
+}
+
+// MarshalTLV implements the TLVMarshaler interface.
+func (e MultiPressCompleteEvent) MarshalTLV(w *tlv.Writer) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(e.PreviousPosition)); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(1), uint64(e.TotalNumberOfPressesCounted)); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// marshalPosition writes a single-field position event payload.
+func marshalPosition(w *tlv.Writer, position uint8) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(position)); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
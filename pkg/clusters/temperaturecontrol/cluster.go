@@ -0,0 +1,456 @@
+// Package temperaturecontrol implements the Temperature Control Cluster
+// (0x0056).
+//
+// Appliances such as laundry washers, dishwashers and ovens use it to
+// expose a cycle temperature. The cluster works in one of two exclusive
+// ways: with the TemperatureNumber feature the setpoint is a temperature
+// within [MinTemperature, MaxTemperature], optionally on a Step grid; with
+// the TemperatureLevel feature the client picks one of a list of named
+// levels such as "Cold" or "Hot".
+//
+// SetTemperature requests are range checked and may be vetoed by a
+// Delegate, e.g. while a cycle is running.
+//
+// Spec Reference: Section 8.2
+//
+// C++ Reference: src/app/clusters/temperature-control-server/supported-temperature-levels-manager.h
+package temperaturecontrol
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/backkem/matter/pkg/clusters"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x0056
+	ClusterRevision uint16              = 1
+)
+
+// Attribute IDs (Spec 8.2.5).
+const (
+	AttrTemperatureSetpoint        datamodel.AttributeID = 0x0000
+	AttrMinTemperature             datamodel.AttributeID = 0x0001
+	AttrMaxTemperature             datamodel.AttributeID = 0x0002
+	AttrStep                       datamodel.AttributeID = 0x0003
+	AttrSelectedTemperatureLevel   datamodel.AttributeID = 0x0004
+	AttrSupportedTemperatureLevels datamodel.AttributeID = 0x0005
+)
+
+// Command IDs (Spec 8.2.6).
+const (
+	CmdSetTemperature datamodel.CommandID = 0x00
+)
+
+// Feature bits (Spec 8.2.4).
+type Feature uint32
+
+const (
+	// FeatureTemperatureNumber uses a numeric setpoint (TN).
+	FeatureTemperatureNumber Feature = 1 << 0
+
+	// FeatureTemperatureLevel uses named temperature levels (TL).
+	FeatureTemperatureLevel Feature = 1 << 1
+
+	// FeatureTemperatureStep restricts the setpoint to Step increments (STEP).
+	FeatureTemperatureStep Feature = 1 << 2
+)
+
+// Limits on the supported level list (Spec 8.2.5.6).
+const (
+	MaxLevels      = 32
+	MaxLevelLength = 16
+)
+
+// Errors returned by New.
+var (
+	ErrInvalidFeatures = errors.New("temperaturecontrol: exactly one of TN and TL is required, STEP requires TN")
+	ErrInvalidRange    = errors.New("temperaturecontrol: invalid temperature range")
+	ErrInvalidLevels   = errors.New("temperaturecontrol: invalid temperature levels")
+)
+
+// Delegate lets the application carry out or veto setpoint changes.
+type Delegate interface {
+	// HandleSetTemperature is called for a valid SetTemperature request.
+	// Exactly one of temperature (TN, in 0.01°C) and level (TL, an index
+	// into the supported levels) is non-nil. Return
+	// datamodel.ErrInvalidInState to reject the change.
+	HandleSetTemperature(temperature *int16, level *uint8) error
+}
+
+// Config provides dependencies for the Temperature Control cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to.
+	EndpointID datamodel.EndpointID
+
+	// FeatureMap indicates supported features.
+	FeatureMap Feature
+
+	// MinTemperature and MaxTemperature bound the setpoint in 0.01°C (TN).
+	MinTemperature int16
+	MaxTemperature int16
+
+	// Step is the setpoint increment in 0.01°C (STEP). Must be positive.
+	Step int16
+
+	// InitialSetpoint is the setpoint at startup (TN).
+	// Clamped to [MinTemperature, MaxTemperature].
+	InitialSetpoint int16
+
+	// SupportedLevels names the temperature levels (TL).
+	SupportedLevels []string
+
+	// InitialLevel is the selected level index at startup (TL).
+	InitialLevel uint8
+
+	// Delegate carries out or vetoes setpoint changes (optional).
+	Delegate Delegate
+
+	// OnChange is called when the setpoint or selected level changes
+	// (optional).
+	OnChange func(endpoint datamodel.EndpointID)
+}
+
+// Cluster implements the Temperature Control cluster (0x0056).
+type Cluster struct {
+	*datamodel.ClusterBase
+	config Config
+
+	// Mutable state (protected by mutex)
+	mu       sync.Mutex
+	setpoint int16
+	level    uint8
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Temperature Control cluster. It returns an error if
+// the features, range or level list are invalid.
+func New(cfg Config) (*Cluster, error) {
+	tn := cfg.FeatureMap&FeatureTemperatureNumber != 0
+	tl := cfg.FeatureMap&FeatureTemperatureLevel != 0
+	step := cfg.FeatureMap&FeatureTemperatureStep != 0
+	if tn == tl || (step && !tn) {
+		return nil, ErrInvalidFeatures
+	}
+
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		config:      cfg,
+	}
+
+	if tn {
+		if cfg.MinTemperature > cfg.MaxTemperature {
+			return nil, ErrInvalidRange
+		}
+		if step && (cfg.Step <= 0 || int32(cfg.Step) > int32(cfg.MaxTemperature)-int32(cfg.MinTemperature)) {
+			return nil, ErrInvalidRange
+		}
+		c.setpoint = clamp(cfg.InitialSetpoint, cfg.MinTemperature, cfg.MaxTemperature)
+	}
+	if tl {
+		if len(cfg.SupportedLevels) == 0 || len(cfg.SupportedLevels) > MaxLevels {
+			return nil, ErrInvalidLevels
+		}
+		for _, l := range cfg.SupportedLevels {
+			if l == "" || len(l) > MaxLevelLength || !utf8.ValidString(l) {
+				return nil, ErrInvalidLevels
+			}
+		}
+		if int(cfg.InitialLevel) < len(cfg.SupportedLevels) {
+			c.level = cfg.InitialLevel
+		}
+	}
+
+	c.ClusterBase.SetFeatureMap(uint32(cfg.FeatureMap))
+	c.attrList = c.buildAttributeList()
+
+	return c, nil
+}
+
+// clamp limits v to [lo, hi].
+func clamp(v, lo, hi int16) int16 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// hasFeature returns true if the feature bit is set.
+func (c *Cluster) hasFeature(f Feature) bool {
+	return c.config.FeatureMap&f != 0
+}
+
+// buildAttributeList constructs the list of supported attributes.
+func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+
+	var attrs []datamodel.AttributeEntry
+	if c.hasFeature(FeatureTemperatureNumber) {
+		attrs = append(attrs,
+			datamodel.NewReadOnlyAttribute(AttrTemperatureSetpoint, 0, viewPriv),
+			datamodel.NewReadOnlyAttribute(AttrMinTemperature, datamodel.AttrQualityFixed, viewPriv),
+			datamodel.NewReadOnlyAttribute(AttrMaxTemperature, datamodel.AttrQualityFixed, viewPriv),
+		)
+	}
+	if c.hasFeature(FeatureTemperatureStep) {
+		attrs = append(attrs, datamodel.NewReadOnlyAttribute(AttrStep, datamodel.AttrQualityFixed, viewPriv))
+	}
+	if c.hasFeature(FeatureTemperatureLevel) {
+		attrs = append(attrs,
+			datamodel.NewReadOnlyAttribute(AttrSelectedTemperatureLevel, 0, viewPriv),
+			datamodel.NewReadOnlyAttribute(AttrSupportedTemperatureLevels, datamodel.AttrQualityList, viewPriv),
+		)
+	}
+
+	return datamodel.MergeAttributeLists(attrs)
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	return []datamodel.CommandEntry{
+		datamodel.NewCommandEntry(CmdSetTemperature, 0, datamodel.PrivilegeOperate),
+	}
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return nil
+}
+
+// hasAttribute returns true if attr is in the attribute list.
+func (c *Cluster) hasAttribute(attr datamodel.AttributeID) bool {
+	for _, a := range c.attrList {
+		if a.ID == attr {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	if !c.hasAttribute(req.Path.Attribute) {
+		return datamodel.ErrUnsupportedAttribute
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch req.Path.Attribute {
+	case AttrTemperatureSetpoint:
+		return w.PutInt(tlv.Anonymous(), int64(c.setpoint))
+	case AttrMinTemperature:
+		return w.PutInt(tlv.Anonymous(), int64(c.config.MinTemperature))
+	case AttrMaxTemperature:
+		return w.PutInt(tlv.Anonymous(), int64(c.config.MaxTemperature))
+	case AttrStep:
+		return w.PutInt(tlv.Anonymous(), int64(c.config.Step))
+	case AttrSelectedTemperatureLevel:
+		return w.PutUint(tlv.Anonymous(), uint64(c.level))
+	case AttrSupportedTemperatureLevels:
+		if err := w.StartArray(tlv.Anonymous()); err != nil {
+			return err
+		}
+		for _, l := range c.config.SupportedLevels {
+			if err := w.PutString(tlv.Anonymous(), l); err != nil {
+				return err
+			}
+		}
+		return w.EndContainer()
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	return datamodel.ErrUnsupportedWrite
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	if req.Path.Command != CmdSetTemperature {
+		return nil, datamodel.ErrUnsupportedCommand
+	}
+	temperature, level, err := decodeSetTemperature(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.SetTemperature(temperature, level); err != nil {
+		return nil, err
+	}
+	return clusters.EmptyResponse(), nil
+}
+
+// decodeSetTemperature decodes the SetTemperature request fields.
+func decodeSetTemperature(r *tlv.Reader) (temperature *int16, level *uint8, err error) {
+	if err := r.Next(); err != nil {
+		return nil, nil, datamodel.ErrInvalidCommand
+	}
+	if err := r.EnterContainer(); err != nil {
+		return nil, nil, datamodel.ErrInvalidCommand
+	}
+
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		tag := r.Tag()
+		if !tag.IsContext() {
+			continue
+		}
+		switch tag.TagNumber() {
+		case 0:
+			v, err := readInt(r)
+			if err != nil || v < -32768 || v > 32767 {
+				return nil, nil, datamodel.ErrConstraintError
+			}
+			t := int16(v)
+			temperature = &t
+		case 1:
+			v, err := r.Uint()
+			if err != nil || v > 0xFF {
+				return nil, nil, datamodel.ErrConstraintError
+			}
+			l := uint8(v)
+			level = &l
+		}
+	}
+	return temperature, level, nil
+}
+
+// readInt reads a signed or unsigned integer element.
+func readInt(r *tlv.Reader) (int64, error) {
+	if v, err := r.Uint(); err == nil {
+		if v > 1<<63-1 {
+			return 0, datamodel.ErrConstraintError
+		}
+		return int64(v), nil
+	}
+	return r.Int()
+}
+
+// SetTemperature handles the SetTemperature command. The field matching
+// the cluster's feature is required; the other is ignored.
+//
+// Spec: Section 8.2.6.1
+func (c *Cluster) SetTemperature(temperature *int16, level *uint8) error {
+	if c.hasFeature(FeatureTemperatureNumber) {
+		if temperature == nil {
+			return datamodel.ErrInvalidCommand
+		}
+		t := *temperature
+		if t < c.config.MinTemperature || t > c.config.MaxTemperature {
+			return datamodel.ErrConstraintError
+		}
+		if c.hasFeature(FeatureTemperatureStep) && (int32(t)-int32(c.config.MinTemperature))%int32(c.config.Step) != 0 {
+			return datamodel.ErrConstraintError
+		}
+		level = nil
+	} else {
+		if level == nil {
+			return datamodel.ErrInvalidCommand
+		}
+		if int(*level) >= len(c.config.SupportedLevels) {
+			return datamodel.ErrConstraintError
+		}
+		temperature = nil
+	}
+
+	if d := c.config.Delegate; d != nil {
+		if err := d.HandleSetTemperature(temperature, level); err != nil {
+			return err
+		}
+	}
+
+	if temperature != nil {
+		return c.SetSetpoint(*temperature)
+	}
+	return c.SetSelectedLevel(*level)
+}
+
+// Setpoint returns the TemperatureSetpoint in 0.01°C (TN).
+func (c *Cluster) Setpoint() int16 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.setpoint
+}
+
+// SelectedLevel returns the SelectedTemperatureLevel index (TL).
+func (c *Cluster) SelectedLevel() uint8 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.level
+}
+
+// SetSetpoint updates the setpoint from the device side, e.g. when a
+// program selects its own temperature. Returns ErrConstraintError outside
+// the supported range.
+func (c *Cluster) SetSetpoint(t int16) error {
+	if !c.hasFeature(FeatureTemperatureNumber) {
+		return datamodel.ErrUnsupportedAttribute
+	}
+	if t < c.config.MinTemperature || t > c.config.MaxTemperature {
+		return datamodel.ErrConstraintError
+	}
+	c.mu.Lock()
+	changed := c.setpoint != t
+	if changed {
+		c.setpoint = t
+		c.IncrementDataVersion()
+	}
+	c.mu.Unlock()
+
+	c.notify(changed)
+	return nil
+}
+
+// SetSelectedLevel updates the selected level from the device side.
+// Returns ErrConstraintError for an unknown level.
+func (c *Cluster) SetSelectedLevel(level uint8) error {
+	if !c.hasFeature(FeatureTemperatureLevel) {
+		return datamodel.ErrUnsupportedAttribute
+	}
+	if int(level) >= len(c.config.SupportedLevels) {
+		return datamodel.ErrConstraintError
+	}
+	c.mu.Lock()
+	changed := c.level != level
+	if changed {
+		c.level = level
+		c.IncrementDataVersion()
+	}
+	c.mu.Unlock()
+
+	c.notify(changed)
+	return nil
+}
+
+// notify calls the OnChange callback if something changed.
+func (c *Cluster) notify(changed bool) {
+	if changed && c.config.OnChange != nil {
+		c.config.OnChange(c.config.EndpointID)
+	}
+}
@@ -0,0 +1,200 @@
+package temperaturecontrol
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// testDelegate records requests and optionally rejects them.
+type testDelegate struct {
+	reject      error
+	temperature *int16
+	level       *uint8
+}
+
+func (d *testDelegate) HandleSetTemperature(temperature *int16, level *uint8) error {
+	if d.reject != nil {
+		return d.reject
+	}
+	d.temperature, d.level = temperature, level
+	return nil
+}
+
+func encodeSetTemperature(temperature *int16, level *uint8) *tlv.Reader {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	w.StartStructure(tlv.Anonymous())
+	if temperature != nil {
+		w.PutInt(tlv.ContextTag(0), int64(*temperature))
+	}
+	if level != nil {
+		w.PutUint(tlv.ContextTag(1), uint64(*level))
+	}
+	w.EndContainer()
+	return tlv.NewReader(bytes.NewReader(buf.Bytes()))
+}
+
+func invoke(c *Cluster, temperature *int16, level *uint8) error {
+	req := datamodel.InvokeRequest{
+		Path: datamodel.ConcreteCommandPath{Endpoint: 1, Cluster: ClusterID, Command: CmdSetTemperature},
+	}
+	_, err := c.InvokeCommand(context.Background(), req, encodeSetTemperature(temperature, level))
+	return err
+}
+
+func i16(v int16) *int16 { return &v }
+func u8(v uint8) *uint8  { return &v }
+
+func TestNew_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want error
+	}{
+		{"no feature", Config{}, ErrInvalidFeatures},
+		{"TN and TL", Config{FeatureMap: FeatureTemperatureNumber | FeatureTemperatureLevel}, ErrInvalidFeatures},
+		{"STEP without TN", Config{FeatureMap: FeatureTemperatureLevel | FeatureTemperatureStep, SupportedLevels: []string{"Hot"}}, ErrInvalidFeatures},
+		{"inverted range", Config{FeatureMap: FeatureTemperatureNumber, MinTemperature: 100, MaxTemperature: 0}, ErrInvalidRange},
+		{"zero step", Config{FeatureMap: FeatureTemperatureNumber | FeatureTemperatureStep, MaxTemperature: 100}, ErrInvalidRange},
+		{"no levels", Config{FeatureMap: FeatureTemperatureLevel}, ErrInvalidLevels},
+		{"long level", Config{FeatureMap: FeatureTemperatureLevel, SupportedLevels: []string{"Much Too Hot For Delicates"}}, ErrInvalidLevels},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg); !errors.Is(err, tt.want) {
+				t.Errorf("New() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetTemperature_Number(t *testing.T) {
+	d := &testDelegate{}
+	changes := 0
+	c, err := New(Config{
+		EndpointID:      1,
+		FeatureMap:      FeatureTemperatureNumber | FeatureTemperatureStep,
+		MinTemperature:  3000,
+		MaxTemperature:  9000,
+		Step:            1000,
+		InitialSetpoint: 12000,
+		Delegate:        d,
+		OnChange:        func(datamodel.EndpointID) { changes++ },
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if c.Setpoint() != 9000 {
+		t.Errorf("initial Setpoint = %d, want clamped 9000", c.Setpoint())
+	}
+
+	if err := invoke(c, i16(4000), nil); err != nil {
+		t.Fatalf("SetTemperature error = %v", err)
+	}
+	if c.Setpoint() != 4000 || d.temperature == nil || *d.temperature != 4000 || changes != 1 {
+		t.Errorf("Setpoint = %d, delegate = %v, changes = %d", c.Setpoint(), d.temperature, changes)
+	}
+
+	tests := []struct {
+		name        string
+		temperature *int16
+		level       *uint8
+		want        error
+	}{
+		{"missing", nil, u8(0), datamodel.ErrInvalidCommand},
+		{"below min", i16(2000), nil, datamodel.ErrConstraintError},
+		{"above max", i16(10000), nil, datamodel.ErrConstraintError},
+		{"off step", i16(4500), nil, datamodel.ErrConstraintError},
+	}
+	for _, tt := range tests {
+		if err := invoke(c, tt.temperature, tt.level); !errors.Is(err, tt.want) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	d.reject = datamodel.ErrInvalidInState
+	if err := invoke(c, i16(6000), nil); !errors.Is(err, datamodel.ErrInvalidInState) {
+		t.Errorf("vetoed error = %v, want ErrInvalidInState", err)
+	}
+	if c.Setpoint() != 4000 {
+		t.Errorf("Setpoint = %d after veto, want 4000", c.Setpoint())
+	}
+}
+
+func TestSetTemperature_Level(t *testing.T) {
+	d := &testDelegate{}
+	c, err := New(Config{
+		EndpointID:      1,
+		FeatureMap:      FeatureTemperatureLevel,
+		SupportedLevels: []string{"Cold", "Warm", "Hot"},
+		InitialLevel:    1,
+		Delegate:        d,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if err := invoke(c, nil, u8(2)); err != nil {
+		t.Fatalf("SetTemperature error = %v", err)
+	}
+	if c.SelectedLevel() != 2 || d.level == nil || *d.level != 2 || d.temperature != nil {
+		t.Errorf("SelectedLevel = %d, delegate = %v/%v", c.SelectedLevel(), d.level, d.temperature)
+	}
+	if err := invoke(c, nil, u8(3)); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("unknown level error = %v, want ErrConstraintError", err)
+	}
+	if err := invoke(c, i16(4000), nil); !errors.Is(err, datamodel.ErrInvalidCommand) {
+		t.Errorf("number on TL error = %v, want ErrInvalidCommand", err)
+	}
+}
+
+func TestReadAttributes(t *testing.T) {
+	c, err := New(Config{
+		EndpointID:      1,
+		FeatureMap:      FeatureTemperatureLevel,
+		SupportedLevels: []string{"Cold", "Hot"},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	read := func(attr datamodel.AttributeID) (*tlv.Reader, error) {
+		var buf bytes.Buffer
+		req := datamodel.ReadAttributeRequest{
+			Path: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: attr},
+		}
+		if err := c.ReadAttribute(context.Background(), req, tlv.NewWriter(&buf)); err != nil {
+			return nil, err
+		}
+		r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+		return r, r.Next()
+	}
+
+	if _, err := read(AttrTemperatureSetpoint); !errors.Is(err, datamodel.ErrUnsupportedAttribute) {
+		t.Errorf("TemperatureSetpoint error = %v, want ErrUnsupportedAttribute", err)
+	}
+
+	r, err := read(AttrSupportedTemperatureLevels)
+	if err != nil {
+		t.Fatalf("read error = %v", err)
+	}
+	if err := r.EnterContainer(); err != nil {
+		t.Fatalf("EnterContainer error = %v", err)
+	}
+	var levels []string
+	for r.Next() == nil && !r.IsEndOfContainer() {
+		s, err := r.String()
+		if err != nil {
+			t.Fatalf("String error = %v", err)
+		}
+		levels = append(levels, s)
+	}
+	if len(levels) != 2 || levels[0] != "Cold" || levels[1] != "Hot" {
+		t.Errorf("SupportedTemperatureLevels = %v", levels)
+	}
+}
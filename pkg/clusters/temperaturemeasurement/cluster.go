@@ -0,0 +1,150 @@
+// Package temperaturemeasurement implements the Temperature Measurement
+// Cluster (0x0402).
+//
+// Readings are in units of 0.01°C. The device pushes readings via
+// SetMeasuredValue; changes smaller than Config.ReportThreshold are
+// absorbed without a data version bump so subscribers are not flooded.
+//
+// Spec Reference: Section 2.3
+//
+// C++ Reference: src/app/clusters/temperature-measurement-server
+package temperaturemeasurement
+
+import (
+	"context"
+
+	"github.com/backkem/matter/pkg/clusters"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x0402
+	ClusterRevision uint16              = 4
+)
+
+// Attribute IDs (Spec 2.3.4).
+const (
+	AttrMeasuredValue    = clusters.AttrMeasuredValue
+	AttrMinMeasuredValue = clusters.AttrMinMeasuredValue
+	AttrMaxMeasuredValue = clusters.AttrMaxMeasuredValue
+	AttrTolerance        = clusters.AttrTolerance
+)
+
+// Config provides dependencies for the Temperature Measurement cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to.
+	EndpointID datamodel.EndpointID
+
+	// MinMeasuredValue and MaxMeasuredValue bound the sensor range in
+	// 0.01°C. nil reports the bound as unknown (null).
+	MinMeasuredValue *int16
+	MaxMeasuredValue *int16
+
+	// Tolerance is the sensor accuracy in 0.01°C (optional).
+	Tolerance *uint16
+
+	// ReportThreshold is the minimum change in 0.01°C that is reported
+	// to subscribers. Zero reports every change.
+	ReportThreshold uint16
+}
+
+// Cluster implements the Temperature Measurement cluster (0x0402).
+type Cluster struct {
+	*datamodel.ClusterBase
+	value *clusters.MeasuredValue
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Temperature Measurement cluster.
+func New(cfg Config) *Cluster {
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		value: clusters.NewMeasuredValue(clusters.MeasuredValueConfig{
+			Signed:          true,
+			Min:             widen(cfg.MinMeasuredValue),
+			Max:             widen(cfg.MaxMeasuredValue),
+			Tolerance:       cfg.Tolerance,
+			ReportThreshold: uint64(cfg.ReportThreshold),
+		}),
+	}
+
+	c.attrList = datamodel.MergeAttributeLists(c.value.AttributeList())
+
+	return c
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	return nil
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return nil
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	if handled, err := c.value.ReadAttribute(req.Path.Attribute, w); handled {
+		return err
+	}
+	return datamodel.ErrUnsupportedAttribute
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	// All Temperature Measurement attributes are read-only
+	return datamodel.ErrUnsupportedWrite
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	return nil, datamodel.ErrUnsupportedCommand
+}
+
+// SetMeasuredValue records a new reading in 0.01°C; nil marks it unknown.
+// Returns clusters.ErrMeasurementOutOfRange if outside the configured range.
+func (c *Cluster) SetMeasuredValue(value *int16) error {
+	report, err := c.value.Set(widen(value))
+	if err != nil {
+		return err
+	}
+	if report {
+		c.IncrementDataVersion()
+	}
+	return nil
+}
+
+// MeasuredValue returns the current reading in 0.01°C, or nil if unknown.
+func (c *Cluster) MeasuredValue() *int16 {
+	v := c.value.Value()
+	if v == nil {
+		return nil
+	}
+	n := int16(*v)
+	return &n
+}
+
+// widen converts a nullable int16 to a nullable int64.
+func widen(v *int16) *int64 {
+	if v == nil {
+		return nil
+	}
+	n := int64(*v)
+	return &n
+}
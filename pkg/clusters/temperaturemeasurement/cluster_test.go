@@ -0,0 +1,78 @@
+package temperaturemeasurement
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/backkem/matter/pkg/clusters"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+func int16p(v int16) *int16 { return &v }
+
+func readAttr(t *testing.T, c *Cluster, attr datamodel.AttributeID) *tlv.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	req := datamodel.ReadAttributeRequest{
+		Path: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: attr},
+	}
+	if err := c.ReadAttribute(context.Background(), req, tlv.NewWriter(&buf)); err != nil {
+		t.Fatalf("ReadAttribute(0x%04X) failed: %v", attr, err)
+	}
+	r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+	if err := r.Next(); err != nil {
+		t.Fatalf("failed to read value: %v", err)
+	}
+	return r
+}
+
+func TestNew_Defaults(t *testing.T) {
+	c := New(Config{EndpointID: 1, MinMeasuredValue: int16p(-4000), MaxMeasuredValue: int16p(8500)})
+
+	if c.ID() != ClusterID {
+		t.Errorf("ID() = 0x%04X, want 0x%04X", c.ID(), ClusterID)
+	}
+	if r := readAttr(t, c, AttrMeasuredValue); r.Type() != tlv.ElementTypeNull {
+		t.Error("MeasuredValue should be null initially")
+	}
+	if v, _ := readAttr(t, c, AttrMinMeasuredValue).Int(); v != -4000 {
+		t.Errorf("MinMeasuredValue = %d, want -4000", v)
+	}
+	for _, a := range c.AttributeList() {
+		if a.ID == AttrTolerance {
+			t.Error("Tolerance present without configuration")
+		}
+	}
+}
+
+func TestSetMeasuredValue(t *testing.T) {
+	c := New(Config{EndpointID: 1, MaxMeasuredValue: int16p(8500), ReportThreshold: 10})
+
+	if err := c.SetMeasuredValue(int16p(-550)); err != nil {
+		t.Fatalf("SetMeasuredValue failed: %v", err)
+	}
+	if v, _ := readAttr(t, c, AttrMeasuredValue).Int(); v != -550 {
+		t.Errorf("MeasuredValue = %d, want -550", v)
+	}
+
+	// Small changes do not bump the data version.
+	dv := c.DataVersion()
+	c.SetMeasuredValue(int16p(-545))
+	if c.DataVersion() != dv {
+		t.Error("data version changed below report threshold")
+	}
+	c.SetMeasuredValue(int16p(-540))
+	if c.DataVersion() == dv {
+		t.Error("data version unchanged at report threshold")
+	}
+
+	if err := c.SetMeasuredValue(int16p(9000)); !errors.Is(err, clusters.ErrMeasurementOutOfRange) {
+		t.Errorf("above max: err = %v, want ErrMeasurementOutOfRange", err)
+	}
+	if v := c.MeasuredValue(); v == nil || *v != -540 {
+		t.Errorf("MeasuredValue() = %v, want -540", v)
+	}
+}
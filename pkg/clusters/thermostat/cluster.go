@@ -0,0 +1,667 @@
+// Package thermostat implements the Thermostat Cluster (0x0201).
+//
+// The Thermostat cluster exposes heating and cooling setpoints, the system
+// mode and the control sequence of an HVAC controller. Setpoint writes are
+// checked against the configured limits and, with the Auto feature, against
+// MinSetpointDeadBand. A Delegate bridges setpoint and mode changes to the
+// actual HVAC hardware, while the device reports measurements back through
+// SetLocalTemperature, SetOccupied and SetRunningMode.
+//
+// All temperatures are in units of 0.01°C, as on the wire.
+//
+// Spec Reference: Section 4.3
+//
+// C++ Reference: src/app/clusters/thermostat-server/thermostat-server.cpp
+package thermostat
+
+import (
+	"context"
+	"sync"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x0201
+	ClusterRevision uint16              = 7
+)
+
+// Attribute IDs (Spec 4.3.9).
+const (
+	AttrLocalTemperature           datamodel.AttributeID = 0x0000
+	AttrOccupancy                  datamodel.AttributeID = 0x0002
+	AttrAbsMinHeatSetpointLimit    datamodel.AttributeID = 0x0003
+	AttrAbsMaxHeatSetpointLimit    datamodel.AttributeID = 0x0004
+	AttrAbsMinCoolSetpointLimit    datamodel.AttributeID = 0x0005
+	AttrAbsMaxCoolSetpointLimit    datamodel.AttributeID = 0x0006
+	AttrOccupiedCoolingSetpoint    datamodel.AttributeID = 0x0011
+	AttrOccupiedHeatingSetpoint    datamodel.AttributeID = 0x0012
+	AttrUnoccupiedCoolingSetpoint  datamodel.AttributeID = 0x0013
+	AttrUnoccupiedHeatingSetpoint  datamodel.AttributeID = 0x0014
+	AttrMinHeatSetpointLimit       datamodel.AttributeID = 0x0015
+	AttrMaxHeatSetpointLimit       datamodel.AttributeID = 0x0016
+	AttrMinCoolSetpointLimit       datamodel.AttributeID = 0x0017
+	AttrMaxCoolSetpointLimit       datamodel.AttributeID = 0x0018
+	AttrMinSetpointDeadBand        datamodel.AttributeID = 0x0019
+	AttrControlSequenceOfOperation datamodel.AttributeID = 0x001B
+	AttrSystemMode                 datamodel.AttributeID = 0x001C
+	AttrThermostatRunningMode      datamodel.AttributeID = 0x001E
+)
+
+// Command IDs (Spec 4.3.10).
+const (
+	CmdSetpointRaiseLower datamodel.CommandID = 0x00
+)
+
+// Feature bits (Spec 4.3.4).
+type Feature uint32
+
+const (
+	// FeatureHeating indicates the thermostat can heat (HEAT).
+	FeatureHeating Feature = 1 << 0
+
+	// FeatureCooling indicates the thermostat can cool (COOL).
+	FeatureCooling Feature = 1 << 1
+
+	// FeatureOccupancy enables occupancy sensing and unoccupied setpoints (OCC).
+	FeatureOccupancy Feature = 1 << 2
+
+	// FeatureScheduleConfiguration enables weekly schedules (SCH).
+	FeatureScheduleConfiguration Feature = 1 << 3
+
+	// FeatureSetback enables setback (SB).
+	FeatureSetback Feature = 1 << 4
+
+	// FeatureAutoMode enables automatic switching between heating and
+	// cooling, and MinSetpointDeadBand (AUTO).
+	FeatureAutoMode Feature = 1 << 5
+
+	// FeatureLocalTemperatureNotExposed hides LocalTemperature (LTNE).
+	FeatureLocalTemperatureNotExposed Feature = 1 << 6
+)
+
+// SystemMode is the thermostat operating mode (Spec 4.3.8.23).
+type SystemMode uint8
+
+const (
+	SystemModeOff           SystemMode = 0
+	SystemModeAuto          SystemMode = 1
+	SystemModeCool          SystemMode = 3
+	SystemModeHeat          SystemMode = 4
+	SystemModeEmergencyHeat SystemMode = 5
+	SystemModePrecooling    SystemMode = 6
+	SystemModeFanOnly       SystemMode = 7
+	SystemModeDry           SystemMode = 8
+	SystemModeSleep         SystemMode = 9
+)
+
+// String returns the name of the system mode.
+func (m SystemMode) String() string {
+	switch m {
+	case SystemModeOff:
+		return "Off"
+	case SystemModeAuto:
+		return "Auto"
+	case SystemModeCool:
+		return "Cool"
+	case SystemModeHeat:
+		return "Heat"
+	case SystemModeEmergencyHeat:
+		return "EmergencyHeat"
+	case SystemModePrecooling:
+		return "Precooling"
+	case SystemModeFanOnly:
+		return "FanOnly"
+	case SystemModeDry:
+		return "Dry"
+	case SystemModeSleep:
+		return "Sleep"
+	default:
+		return "Unknown"
+	}
+}
+
+// ControlSequenceOfOperation describes the HVAC equipment (Spec 4.3.8.14).
+type ControlSequenceOfOperation uint8
+
+const (
+	ControlSequenceCoolingOnly                 ControlSequenceOfOperation = 0
+	ControlSequenceCoolingWithReheat           ControlSequenceOfOperation = 1
+	ControlSequenceHeatingOnly                 ControlSequenceOfOperation = 2
+	ControlSequenceHeatingWithReheat           ControlSequenceOfOperation = 3
+	ControlSequenceCoolingAndHeating           ControlSequenceOfOperation = 4
+	ControlSequenceCoolingAndHeatingWithReheat ControlSequenceOfOperation = 5
+)
+
+// RunningMode is the mode the thermostat is currently running in (Spec 4.3.8.29).
+type RunningMode uint8
+
+const (
+	RunningModeOff  RunningMode = 0
+	RunningModeCool RunningMode = 3
+	RunningModeHeat RunningMode = 4
+)
+
+// SetpointRaiseLowerMode selects the setpoints adjusted by SetpointRaiseLower
+// (Spec 4.3.8.28).
+type SetpointRaiseLowerMode uint8
+
+const (
+	SetpointRaiseLowerHeat SetpointRaiseLowerMode = 0
+	SetpointRaiseLowerCool SetpointRaiseLowerMode = 1
+	SetpointRaiseLowerBoth SetpointRaiseLowerMode = 2
+)
+
+// Default values (Spec 4.3.9).
+const (
+	DefaultAbsMinHeatSetpointLimit int16 = 700
+	DefaultAbsMaxHeatSetpointLimit int16 = 3000
+	DefaultAbsMinCoolSetpointLimit int16 = 1600
+	DefaultAbsMaxCoolSetpointLimit int16 = 3200
+	DefaultOccupiedHeatingSetpoint int16 = 2000
+	DefaultOccupiedCoolingSetpoint int16 = 2600
+
+	// DefaultMinSetpointDeadBand is 2.5°C in units of 0.1°C.
+	DefaultMinSetpointDeadBand int8 = 25
+)
+
+// Limits holds the absolute setpoint limits fixed by the hardware.
+// A zero Limits selects the spec defaults.
+type Limits struct {
+	AbsMinHeat int16
+	AbsMaxHeat int16
+	AbsMinCool int16
+	AbsMaxCool int16
+}
+
+// Delegate bridges the Thermostat cluster to HVAC hardware.
+// Methods are called after the attribute has been updated and must not
+// call back into the cluster synchronously.
+type Delegate interface {
+	// OnSetpointsChanged is called when the active heating or cooling
+	// setpoint changes.
+	OnSetpointsChanged(heating, cooling int16)
+
+	// OnSystemModeChanged is called when SystemMode changes.
+	OnSystemModeChanged(mode SystemMode)
+}
+
+// Config provides dependencies for the Thermostat cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to.
+	EndpointID datamodel.EndpointID
+
+	// FeatureMap indicates supported features.
+	// At least one of FeatureHeating or FeatureCooling must be set.
+	// FeatureAutoMode requires both.
+	FeatureMap Feature
+
+	// Limits are the absolute setpoint limits of the hardware.
+	Limits Limits
+
+	// MinSetpointDeadBand is the minimum heat/cool setpoint difference in
+	// units of 0.1°C (AUTO). Defaults to DefaultMinSetpointDeadBand if zero.
+	MinSetpointDeadBand int8
+
+	// ControlSequenceOfOperation describes the HVAC equipment.
+	// If nil or incompatible with FeatureMap, the sequence matching
+	// FeatureMap is used.
+	ControlSequenceOfOperation *ControlSequenceOfOperation
+
+	// InitialSystemMode is the SystemMode at startup.
+	InitialSystemMode SystemMode
+
+	// Delegate receives setpoint and mode changes (optional).
+	Delegate Delegate
+}
+
+// Cluster implements the Thermostat cluster (0x0201).
+type Cluster struct {
+	*datamodel.ClusterBase
+	config Config
+
+	// Mutable state (protected by mutex)
+	mu                        sync.RWMutex
+	localTemperature          *int16 // nullable
+	occupied                  bool
+	occupiedHeatingSetpoint   int16
+	occupiedCoolingSetpoint   int16
+	unoccupiedHeatingSetpoint int16
+	unoccupiedCoolingSetpoint int16
+	minHeatSetpointLimit      int16
+	maxHeatSetpointLimit      int16
+	minCoolSetpointLimit      int16
+	maxCoolSetpointLimit      int16
+	controlSequence           ControlSequenceOfOperation
+	systemMode                SystemMode
+	runningMode               RunningMode
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Thermostat cluster.
+func New(cfg Config) *Cluster {
+	if cfg.Limits == (Limits{}) {
+		cfg.Limits = Limits{
+			AbsMinHeat: DefaultAbsMinHeatSetpointLimit,
+			AbsMaxHeat: DefaultAbsMaxHeatSetpointLimit,
+			AbsMinCool: DefaultAbsMinCoolSetpointLimit,
+			AbsMaxCool: DefaultAbsMaxCoolSetpointLimit,
+		}
+	}
+	if cfg.MinSetpointDeadBand == 0 {
+		cfg.MinSetpointDeadBand = DefaultMinSetpointDeadBand
+	}
+
+	c := &Cluster{
+		ClusterBase:          datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		config:               cfg,
+		occupied:             true,
+		minHeatSetpointLimit: cfg.Limits.AbsMinHeat,
+		maxHeatSetpointLimit: cfg.Limits.AbsMaxHeat,
+		minCoolSetpointLimit: cfg.Limits.AbsMinCool,
+		maxCoolSetpointLimit: cfg.Limits.AbsMaxCool,
+		systemMode:           cfg.InitialSystemMode,
+	}
+
+	c.occupiedHeatingSetpoint = clamp(DefaultOccupiedHeatingSetpoint, c.minHeatSetpointLimit, c.maxHeatSetpointLimit)
+	c.occupiedCoolingSetpoint = clamp(DefaultOccupiedCoolingSetpoint, c.minCoolSetpointLimit, c.maxCoolSetpointLimit)
+	c.unoccupiedHeatingSetpoint = c.occupiedHeatingSetpoint
+	c.unoccupiedCoolingSetpoint = c.occupiedCoolingSetpoint
+
+	c.controlSequence = c.defaultControlSequence()
+	if seq := cfg.ControlSequenceOfOperation; seq != nil && c.validControlSequence(*seq) {
+		c.controlSequence = *seq
+	}
+
+	c.ClusterBase.SetFeatureMap(uint32(cfg.FeatureMap))
+
+	c.attrList = c.buildAttributeList()
+
+	return c
+}
+
+// hasFeature returns true if the feature bit is set.
+func (c *Cluster) hasFeature(f Feature) bool {
+	return c.config.FeatureMap&f != 0
+}
+
+// defaultControlSequence returns the control sequence matching the feature map.
+func (c *Cluster) defaultControlSequence() ControlSequenceOfOperation {
+	switch {
+	case c.hasFeature(FeatureHeating) && c.hasFeature(FeatureCooling):
+		return ControlSequenceCoolingAndHeating
+	case c.hasFeature(FeatureHeating):
+		return ControlSequenceHeatingOnly
+	default:
+		return ControlSequenceCoolingOnly
+	}
+}
+
+// buildAttributeList constructs the list of supported attributes.
+func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+	operatePriv := datamodel.PrivilegeOperate
+	managePriv := datamodel.PrivilegeManage
+
+	attrs := []datamodel.AttributeEntry{
+		datamodel.NewReadWriteAttribute(AttrControlSequenceOfOperation, 0, viewPriv, managePriv),
+		datamodel.NewReadWriteAttribute(AttrSystemMode, 0, viewPriv, managePriv),
+	}
+
+	if !c.hasFeature(FeatureLocalTemperatureNotExposed) {
+		attrs = append(attrs,
+			datamodel.NewReadOnlyAttribute(AttrLocalTemperature, datamodel.AttrQualityNullable, viewPriv),
+		)
+	}
+
+	if c.hasFeature(FeatureOccupancy) {
+		attrs = append(attrs,
+			datamodel.NewReadOnlyAttribute(AttrOccupancy, 0, viewPriv),
+		)
+	}
+
+	if c.hasFeature(FeatureHeating) {
+		attrs = append(attrs,
+			datamodel.NewReadOnlyAttribute(AttrAbsMinHeatSetpointLimit, datamodel.AttrQualityFixed, viewPriv),
+			datamodel.NewReadOnlyAttribute(AttrAbsMaxHeatSetpointLimit, datamodel.AttrQualityFixed, viewPriv),
+			datamodel.NewReadWriteAttribute(AttrOccupiedHeatingSetpoint, datamodel.AttrQualityScene, viewPriv, operatePriv),
+			datamodel.NewReadWriteAttribute(AttrMinHeatSetpointLimit, 0, viewPriv, managePriv),
+			datamodel.NewReadWriteAttribute(AttrMaxHeatSetpointLimit, 0, viewPriv, managePriv),
+		)
+		if c.hasFeature(FeatureOccupancy) {
+			attrs = append(attrs,
+				datamodel.NewReadWriteAttribute(AttrUnoccupiedHeatingSetpoint, 0, viewPriv, operatePriv),
+			)
+		}
+	}
+
+	if c.hasFeature(FeatureCooling) {
+		attrs = append(attrs,
+			datamodel.NewReadOnlyAttribute(AttrAbsMinCoolSetpointLimit, datamodel.AttrQualityFixed, viewPriv),
+			datamodel.NewReadOnlyAttribute(AttrAbsMaxCoolSetpointLimit, datamodel.AttrQualityFixed, viewPriv),
+			datamodel.NewReadWriteAttribute(AttrOccupiedCoolingSetpoint, datamodel.AttrQualityScene, viewPriv, operatePriv),
+			datamodel.NewReadWriteAttribute(AttrMinCoolSetpointLimit, 0, viewPriv, managePriv),
+			datamodel.NewReadWriteAttribute(AttrMaxCoolSetpointLimit, 0, viewPriv, managePriv),
+		)
+		if c.hasFeature(FeatureOccupancy) {
+			attrs = append(attrs,
+				datamodel.NewReadWriteAttribute(AttrUnoccupiedCoolingSetpoint, 0, viewPriv, operatePriv),
+			)
+		}
+	}
+
+	if c.hasFeature(FeatureAutoMode) {
+		attrs = append(attrs,
+			datamodel.NewReadOnlyAttribute(AttrMinSetpointDeadBand, datamodel.AttrQualityFixed, viewPriv),
+			datamodel.NewReadOnlyAttribute(AttrThermostatRunningMode, 0, viewPriv),
+		)
+	}
+
+	return datamodel.MergeAttributeLists(attrs)
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	return []datamodel.CommandEntry{
+		datamodel.NewCommandEntry(CmdSetpointRaiseLower, 0, datamodel.PrivilegeOperate),
+	}
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return nil
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	// Handle global attributes first
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	if !c.hasAttribute(req.Path.Attribute) {
+		return datamodel.ErrUnsupportedAttribute
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	switch req.Path.Attribute {
+	case AttrLocalTemperature:
+		if c.localTemperature == nil {
+			return w.PutNull(tlv.Anonymous())
+		}
+		return w.PutInt(tlv.Anonymous(), int64(*c.localTemperature))
+	case AttrOccupancy:
+		var bitmap uint64
+		if c.occupied {
+			bitmap = 1
+		}
+		return w.PutUint(tlv.Anonymous(), bitmap)
+	case AttrAbsMinHeatSetpointLimit:
+		return w.PutInt(tlv.Anonymous(), int64(c.config.Limits.AbsMinHeat))
+	case AttrAbsMaxHeatSetpointLimit:
+		return w.PutInt(tlv.Anonymous(), int64(c.config.Limits.AbsMaxHeat))
+	case AttrAbsMinCoolSetpointLimit:
+		return w.PutInt(tlv.Anonymous(), int64(c.config.Limits.AbsMinCool))
+	case AttrAbsMaxCoolSetpointLimit:
+		return w.PutInt(tlv.Anonymous(), int64(c.config.Limits.AbsMaxCool))
+	case AttrOccupiedCoolingSetpoint:
+		return w.PutInt(tlv.Anonymous(), int64(c.occupiedCoolingSetpoint))
+	case AttrOccupiedHeatingSetpoint:
+		return w.PutInt(tlv.Anonymous(), int64(c.occupiedHeatingSetpoint))
+	case AttrUnoccupiedCoolingSetpoint:
+		return w.PutInt(tlv.Anonymous(), int64(c.unoccupiedCoolingSetpoint))
+	case AttrUnoccupiedHeatingSetpoint:
+		return w.PutInt(tlv.Anonymous(), int64(c.unoccupiedHeatingSetpoint))
+	case AttrMinHeatSetpointLimit:
+		return w.PutInt(tlv.Anonymous(), int64(c.minHeatSetpointLimit))
+	case AttrMaxHeatSetpointLimit:
+		return w.PutInt(tlv.Anonymous(), int64(c.maxHeatSetpointLimit))
+	case AttrMinCoolSetpointLimit:
+		return w.PutInt(tlv.Anonymous(), int64(c.minCoolSetpointLimit))
+	case AttrMaxCoolSetpointLimit:
+		return w.PutInt(tlv.Anonymous(), int64(c.maxCoolSetpointLimit))
+	case AttrMinSetpointDeadBand:
+		return w.PutInt(tlv.Anonymous(), int64(c.config.MinSetpointDeadBand))
+	case AttrControlSequenceOfOperation:
+		return w.PutUint(tlv.Anonymous(), uint64(c.controlSequence))
+	case AttrSystemMode:
+		return w.PutUint(tlv.Anonymous(), uint64(c.systemMode))
+	case AttrThermostatRunningMode:
+		return w.PutUint(tlv.Anonymous(), uint64(c.runningMode))
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// hasAttribute returns true if the attribute is in the attribute list.
+func (c *Cluster) hasAttribute(id datamodel.AttributeID) bool {
+	for _, a := range c.attrList {
+		if a.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	attr := req.Path.Attribute
+	if !c.hasAttribute(attr) {
+		return datamodel.ErrUnsupportedWrite
+	}
+
+	if err := r.Next(); err != nil {
+		return err
+	}
+
+	switch attr {
+	case AttrSystemMode:
+		val, err := r.Uint()
+		if err != nil {
+			return err
+		}
+		return c.SetSystemMode(SystemMode(val))
+
+	case AttrControlSequenceOfOperation:
+		val, err := r.Uint()
+		if err != nil {
+			return err
+		}
+		return c.setControlSequence(ControlSequenceOfOperation(val))
+
+	case AttrOccupiedHeatingSetpoint, AttrOccupiedCoolingSetpoint,
+		AttrUnoccupiedHeatingSetpoint, AttrUnoccupiedCoolingSetpoint:
+		val, err := r.Int()
+		if err != nil {
+			return err
+		}
+		return c.SetSetpoint(attr, int16(val))
+
+	case AttrMinHeatSetpointLimit, AttrMaxHeatSetpointLimit,
+		AttrMinCoolSetpointLimit, AttrMaxCoolSetpointLimit:
+		val, err := r.Int()
+		if err != nil {
+			return err
+		}
+		return c.setSetpointLimit(attr, int16(val))
+
+	default:
+		return datamodel.ErrUnsupportedWrite
+	}
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	switch req.Path.Command {
+	case CmdSetpointRaiseLower:
+		return nil, c.handleSetpointRaiseLower(r)
+	default:
+		return nil, datamodel.ErrUnsupportedCommand
+	}
+}
+
+// SetSystemMode changes SystemMode. The mode must be compatible with the
+// ControlSequenceOfOperation and feature map.
+func (c *Cluster) SetSystemMode(mode SystemMode) error {
+	c.mu.Lock()
+	if !c.systemModeAllowed(mode) {
+		c.mu.Unlock()
+		return datamodel.ErrConstraintError
+	}
+	changed := c.systemMode != mode
+	c.systemMode = mode
+	c.mu.Unlock()
+
+	if !changed {
+		return nil
+	}
+
+	c.IncrementDataVersion()
+	if c.config.Delegate != nil {
+		c.config.Delegate.OnSystemModeChanged(mode)
+	}
+	return nil
+}
+
+// GetSystemMode returns the current SystemMode.
+func (c *Cluster) GetSystemMode() SystemMode {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.systemMode
+}
+
+// systemModeAllowed checks SystemMode against the control sequence.
+// Caller must hold c.mu.
+//
+// Spec: Section 4.3.9.23
+func (c *Cluster) systemModeAllowed(mode SystemMode) bool {
+	heating := c.controlSequence >= ControlSequenceHeatingOnly
+	cooling := c.controlSequence <= ControlSequenceCoolingWithReheat ||
+		c.controlSequence >= ControlSequenceCoolingAndHeating
+
+	switch mode {
+	case SystemModeOff, SystemModeFanOnly, SystemModeDry, SystemModeSleep:
+		return true
+	case SystemModeAuto:
+		return c.hasFeature(FeatureAutoMode)
+	case SystemModeHeat, SystemModeEmergencyHeat:
+		return heating
+	case SystemModeCool, SystemModePrecooling:
+		return cooling
+	default:
+		return false
+	}
+}
+
+// setControlSequence handles writes of ControlSequenceOfOperation.
+func (c *Cluster) setControlSequence(seq ControlSequenceOfOperation) error {
+	if !c.validControlSequence(seq) {
+		return datamodel.ErrConstraintError
+	}
+
+	c.mu.Lock()
+	c.controlSequence = seq
+	c.mu.Unlock()
+
+	c.IncrementDataVersion()
+	return nil
+}
+
+// validControlSequence checks a control sequence against the feature map.
+func (c *Cluster) validControlSequence(seq ControlSequenceOfOperation) bool {
+	switch seq {
+	case ControlSequenceCoolingOnly, ControlSequenceCoolingWithReheat:
+		return c.hasFeature(FeatureCooling)
+	case ControlSequenceHeatingOnly, ControlSequenceHeatingWithReheat:
+		return c.hasFeature(FeatureHeating)
+	case ControlSequenceCoolingAndHeating, ControlSequenceCoolingAndHeatingWithReheat:
+		return c.hasFeature(FeatureHeating) && c.hasFeature(FeatureCooling)
+	default:
+		return false
+	}
+}
+
+// SetLocalTemperature updates LocalTemperature. nil marks the reading invalid.
+func (c *Cluster) SetLocalTemperature(temp *int16) {
+	c.mu.Lock()
+	if temp != nil {
+		v := *temp
+		temp = &v
+	}
+	c.localTemperature = temp
+	c.mu.Unlock()
+
+	c.IncrementDataVersion()
+}
+
+// SetOccupied updates the Occupancy attribute (OCC). When occupancy
+// changes, the active setpoints switch between the occupied and
+// unoccupied pairs.
+func (c *Cluster) SetOccupied(occupied bool) {
+	c.mu.Lock()
+	changed := c.occupied != occupied
+	c.occupied = occupied
+	heat, cool := c.activeSetpointsLocked()
+	c.mu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	c.IncrementDataVersion()
+	if c.hasFeature(FeatureOccupancy) {
+		c.notifySetpoints(heat, cool)
+	}
+}
+
+// SetRunningMode updates ThermostatRunningMode (AUTO).
+func (c *Cluster) SetRunningMode(mode RunningMode) {
+	c.mu.Lock()
+	changed := c.runningMode != mode
+	c.runningMode = mode
+	c.mu.Unlock()
+
+	if changed {
+		c.IncrementDataVersion()
+	}
+}
+
+// ActiveSetpoints returns the heating and cooling setpoints currently in
+// effect, taking occupancy into account.
+func (c *Cluster) ActiveSetpoints() (heating, cooling int16) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.activeSetpointsLocked()
+}
+
+// activeSetpointsLocked returns the active setpoints. Caller must hold c.mu.
+func (c *Cluster) activeSetpointsLocked() (heating, cooling int16) {
+	if c.hasFeature(FeatureOccupancy) && !c.occupied {
+		return c.unoccupiedHeatingSetpoint, c.unoccupiedCoolingSetpoint
+	}
+	return c.occupiedHeatingSetpoint, c.occupiedCoolingSetpoint
+}
+
+// clamp limits v to [lo, hi].
+func clamp(v, lo, hi int16) int16 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
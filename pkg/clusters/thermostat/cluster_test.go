@@ -0,0 +1,320 @@
+package thermostat
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// mockDelegate records calls from the cluster.
+type mockDelegate struct {
+	setpoints [][2]int16
+	modes     []SystemMode
+}
+
+func (m *mockDelegate) OnSetpointsChanged(heating, cooling int16) {
+	m.setpoints = append(m.setpoints, [2]int16{heating, cooling})
+}
+
+func (m *mockDelegate) OnSystemModeChanged(mode SystemMode) {
+	m.modes = append(m.modes, mode)
+}
+
+const heatCool = FeatureHeating | FeatureCooling
+
+func readInt(t *testing.T, c *Cluster, attr datamodel.AttributeID) int64 {
+	t.Helper()
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	req := datamodel.ReadAttributeRequest{
+		Path: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: attr},
+	}
+	if err := c.ReadAttribute(context.Background(), req, w); err != nil {
+		t.Fatalf("ReadAttribute(0x%04X) failed: %v", attr, err)
+	}
+	r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+	if err := r.Next(); err != nil {
+		t.Fatalf("failed to read value: %v", err)
+	}
+	if r.Type().IsUnsignedInt() {
+		v, err := r.Uint()
+		if err != nil {
+			t.Fatalf("failed to decode uint: %v", err)
+		}
+		return int64(v)
+	}
+	v, err := r.Int()
+	if err != nil {
+		t.Fatalf("failed to decode int: %v", err)
+	}
+	return v
+}
+
+func writeInt(c *Cluster, attr datamodel.AttributeID, val int64) error {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	if err := w.PutInt(tlv.Anonymous(), val); err != nil {
+		return err
+	}
+	req := datamodel.WriteAttributeRequest{
+		Path: datamodel.ConcreteDataAttributePath{
+			ConcreteAttributePath: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: attr},
+		},
+	}
+	return c.WriteAttribute(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes())))
+}
+
+func writeUint(c *Cluster, attr datamodel.AttributeID, val uint64) error {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	if err := w.PutUint(tlv.Anonymous(), val); err != nil {
+		return err
+	}
+	req := datamodel.WriteAttributeRequest{
+		Path: datamodel.ConcreteDataAttributePath{
+			ConcreteAttributePath: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: attr},
+		},
+	}
+	return c.WriteAttribute(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes())))
+}
+
+func raiseLower(t *testing.T, c *Cluster, mode SetpointRaiseLowerMode, amount int8) error {
+	t.Helper()
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	w.StartStructure(tlv.Anonymous())
+	w.PutUint(tlv.ContextTag(0), uint64(mode))
+	w.PutInt(tlv.ContextTag(1), int64(amount))
+	w.EndContainer()
+
+	req := datamodel.InvokeRequest{
+		Path: datamodel.ConcreteCommandPath{Endpoint: 1, Cluster: ClusterID, Command: CmdSetpointRaiseLower},
+	}
+	_, err := c.InvokeCommand(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes())))
+	return err
+}
+
+func hasAttr(c *Cluster, id datamodel.AttributeID) bool {
+	for _, a := range c.AttributeList() {
+		if a.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func TestNew_Defaults(t *testing.T) {
+	c := New(Config{EndpointID: 1, FeatureMap: heatCool})
+
+	if c.ID() != ClusterID {
+		t.Errorf("ID() = 0x%04X, want 0x%04X", c.ID(), ClusterID)
+	}
+	if got := readInt(t, c, AttrOccupiedHeatingSetpoint); got != int64(DefaultOccupiedHeatingSetpoint) {
+		t.Errorf("OccupiedHeatingSetpoint = %d, want %d", got, DefaultOccupiedHeatingSetpoint)
+	}
+	if got := readInt(t, c, AttrMaxCoolSetpointLimit); got != int64(DefaultAbsMaxCoolSetpointLimit) {
+		t.Errorf("MaxCoolSetpointLimit = %d, want %d", got, DefaultAbsMaxCoolSetpointLimit)
+	}
+	if got := readInt(t, c, AttrControlSequenceOfOperation); got != int64(ControlSequenceCoolingAndHeating) {
+		t.Errorf("ControlSequenceOfOperation = %d, want CoolingAndHeating", got)
+	}
+
+	// LocalTemperature starts null.
+	var buf bytes.Buffer
+	req := datamodel.ReadAttributeRequest{
+		Path: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: AttrLocalTemperature},
+	}
+	if err := c.ReadAttribute(context.Background(), req, tlv.NewWriter(&buf)); err != nil {
+		t.Fatal(err)
+	}
+	r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+	if err := r.Next(); err != nil || r.Type() != tlv.ElementTypeNull {
+		t.Errorf("LocalTemperature should be null initially")
+	}
+
+	temp := int16(2150)
+	c.SetLocalTemperature(&temp)
+	if got := readInt(t, c, AttrLocalTemperature); got != 2150 {
+		t.Errorf("LocalTemperature = %d, want 2150", got)
+	}
+}
+
+func TestAttributeList_FeatureGating(t *testing.T) {
+	c := New(Config{EndpointID: 1, FeatureMap: FeatureHeating})
+
+	if !hasAttr(c, AttrOccupiedHeatingSetpoint) {
+		t.Error("OccupiedHeatingSetpoint missing with HEAT")
+	}
+	for _, id := range []datamodel.AttributeID{
+		AttrOccupiedCoolingSetpoint, AttrOccupancy, AttrUnoccupiedHeatingSetpoint, AttrMinSetpointDeadBand,
+	} {
+		if hasAttr(c, id) {
+			t.Errorf("attribute 0x%04X present without feature", id)
+		}
+	}
+	if got := readInt(t, c, AttrControlSequenceOfOperation); got != int64(ControlSequenceHeatingOnly) {
+		t.Errorf("ControlSequenceOfOperation = %d, want HeatingOnly", got)
+	}
+
+	var buf bytes.Buffer
+	req := datamodel.ReadAttributeRequest{
+		Path: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: AttrOccupiedCoolingSetpoint},
+	}
+	if err := c.ReadAttribute(context.Background(), req, tlv.NewWriter(&buf)); !errors.Is(err, datamodel.ErrUnsupportedAttribute) {
+		t.Errorf("read cooling setpoint: err = %v, want ErrUnsupportedAttribute", err)
+	}
+}
+
+func TestWriteSetpoint_Limits(t *testing.T) {
+	d := &mockDelegate{}
+	c := New(Config{EndpointID: 1, FeatureMap: heatCool, Delegate: d})
+
+	if err := writeInt(c, AttrOccupiedHeatingSetpoint, 600); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("below AbsMin: err = %v, want ErrConstraintError", err)
+	}
+	if err := writeInt(c, AttrOccupiedHeatingSetpoint, 2200); err != nil {
+		t.Fatalf("write 2200 failed: %v", err)
+	}
+	if len(d.setpoints) != 1 || d.setpoints[0] != [2]int16{2200, DefaultOccupiedCoolingSetpoint} {
+		t.Errorf("delegate setpoints = %v", d.setpoints)
+	}
+
+	// Narrow the limit; the setpoint is clamped.
+	if err := writeInt(c, AttrMaxHeatSetpointLimit, 2100); err != nil {
+		t.Fatalf("write MaxHeatSetpointLimit failed: %v", err)
+	}
+	if got := readInt(t, c, AttrOccupiedHeatingSetpoint); got != 2100 {
+		t.Errorf("OccupiedHeatingSetpoint = %d, want 2100 after clamp", got)
+	}
+	if err := writeInt(c, AttrOccupiedHeatingSetpoint, 2200); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("above MaxHeatSetpointLimit: err = %v, want ErrConstraintError", err)
+	}
+
+	// Limits may not exceed the absolute limits.
+	if err := writeInt(c, AttrMaxHeatSetpointLimit, 3100); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("MaxHeat above AbsMax: err = %v, want ErrConstraintError", err)
+	}
+	if err := writeInt(c, AttrMinHeatSetpointLimit, 2500); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("MinHeat above MaxHeat: err = %v, want ErrConstraintError", err)
+	}
+}
+
+func TestWriteSetpoint_DeadBand(t *testing.T) {
+	c := New(Config{EndpointID: 1, FeatureMap: heatCool | FeatureAutoMode})
+
+	if got := readInt(t, c, AttrMinSetpointDeadBand); got != int64(DefaultMinSetpointDeadBand) {
+		t.Errorf("MinSetpointDeadBand = %d, want %d", got, DefaultMinSetpointDeadBand)
+	}
+
+	// Cooling 2600, dead band 2.5°C: heating may be at most 2350.
+	if err := writeInt(c, AttrOccupiedHeatingSetpoint, 2400); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("dead band violation: err = %v, want ErrConstraintError", err)
+	}
+	if err := writeInt(c, AttrOccupiedHeatingSetpoint, 2350); err != nil {
+		t.Errorf("write at dead band failed: %v", err)
+	}
+}
+
+func TestSetpointRaiseLower(t *testing.T) {
+	d := &mockDelegate{}
+	c := New(Config{EndpointID: 1, FeatureMap: heatCool | FeatureAutoMode, Delegate: d})
+
+	// Raise heat by 5°C: 2000 -> 2500 pushes cooling to 2750.
+	if err := raiseLower(t, c, SetpointRaiseLowerHeat, 50); err != nil {
+		t.Fatalf("SetpointRaiseLower failed: %v", err)
+	}
+	heat, cool := c.ActiveSetpoints()
+	if heat != 2500 || cool != 2750 {
+		t.Errorf("setpoints = %d/%d, want 2500/2750", heat, cool)
+	}
+
+	// Lower both by 1°C.
+	if err := raiseLower(t, c, SetpointRaiseLowerBoth, -10); err != nil {
+		t.Fatalf("SetpointRaiseLower failed: %v", err)
+	}
+	heat, cool = c.ActiveSetpoints()
+	if heat != 2400 || cool != 2650 {
+		t.Errorf("setpoints = %d/%d, want 2400/2650", heat, cool)
+	}
+
+	// Raising cooling beyond the limit clamps.
+	if err := raiseLower(t, c, SetpointRaiseLowerCool, 127); err != nil {
+		t.Fatalf("SetpointRaiseLower failed: %v", err)
+	}
+	if _, cool = c.ActiveSetpoints(); cool != DefaultAbsMaxCoolSetpointLimit {
+		t.Errorf("cooling = %d, want %d", cool, DefaultAbsMaxCoolSetpointLimit)
+	}
+
+	if len(d.setpoints) != 3 {
+		t.Errorf("delegate notified %d times, want 3", len(d.setpoints))
+	}
+
+	// Cool mode on a heat-only thermostat is rejected.
+	h := New(Config{EndpointID: 1, FeatureMap: FeatureHeating})
+	if err := raiseLower(t, h, SetpointRaiseLowerCool, 10); !errors.Is(err, datamodel.ErrInvalidCommand) {
+		t.Errorf("cool on heat-only: err = %v, want ErrInvalidCommand", err)
+	}
+}
+
+func TestSystemMode(t *testing.T) {
+	d := &mockDelegate{}
+	c := New(Config{EndpointID: 1, FeatureMap: FeatureHeating, Delegate: d})
+
+	if err := writeUint(c, AttrSystemMode, uint64(SystemModeCool)); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("Cool on heat-only: err = %v, want ErrConstraintError", err)
+	}
+	if err := writeUint(c, AttrSystemMode, uint64(SystemModeAuto)); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("Auto without AUTO: err = %v, want ErrConstraintError", err)
+	}
+	if err := writeUint(c, AttrSystemMode, uint64(SystemModeHeat)); err != nil {
+		t.Fatalf("write Heat failed: %v", err)
+	}
+	if c.GetSystemMode() != SystemModeHeat {
+		t.Errorf("SystemMode = %v, want Heat", c.GetSystemMode())
+	}
+	if len(d.modes) != 1 || d.modes[0] != SystemModeHeat {
+		t.Errorf("delegate modes = %v", d.modes)
+	}
+
+	if err := writeUint(c, AttrControlSequenceOfOperation, uint64(ControlSequenceCoolingOnly)); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("CoolingOnly on heat-only: err = %v, want ErrConstraintError", err)
+	}
+}
+
+func TestOccupancy(t *testing.T) {
+	d := &mockDelegate{}
+	c := New(Config{EndpointID: 1, FeatureMap: heatCool | FeatureOccupancy, Delegate: d})
+
+	if err := writeInt(c, AttrUnoccupiedHeatingSetpoint, 1600); err != nil {
+		t.Fatalf("write UnoccupiedHeatingSetpoint failed: %v", err)
+	}
+	if len(d.setpoints) != 0 {
+		t.Error("delegate notified for inactive setpoint")
+	}
+
+	c.SetOccupied(false)
+	if got := readInt(t, c, AttrOccupancy); got != 0 {
+		t.Errorf("Occupancy = %d, want 0", got)
+	}
+	if heat, _ := c.ActiveSetpoints(); heat != 1600 {
+		t.Errorf("active heating = %d, want 1600", heat)
+	}
+	if len(d.setpoints) != 1 {
+		t.Errorf("delegate notified %d times, want 1", len(d.setpoints))
+	}
+
+	// SetpointRaiseLower adjusts the unoccupied setpoints while unoccupied.
+	if err := raiseLower(t, c, SetpointRaiseLowerHeat, 10); err != nil {
+		t.Fatal(err)
+	}
+	if got := readInt(t, c, AttrUnoccupiedHeatingSetpoint); got != 1700 {
+		t.Errorf("UnoccupiedHeatingSetpoint = %d, want 1700", got)
+	}
+	if got := readInt(t, c, AttrOccupiedHeatingSetpoint); got != int64(DefaultOccupiedHeatingSetpoint) {
+		t.Errorf("OccupiedHeatingSetpoint changed to %d", got)
+	}
+}
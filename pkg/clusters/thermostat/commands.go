@@ -0,0 +1,261 @@
+package thermostat
+
+import (
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// handleSetpointRaiseLower handles the SetpointRaiseLower command.
+//
+// Spec: Section 4.3.10.1
+func (c *Cluster) handleSetpointRaiseLower(r *tlv.Reader) error {
+	var mode SetpointRaiseLowerMode
+	var amount int8
+	var hasMode, hasAmount bool
+
+	if err := r.Next(); err != nil {
+		return datamodel.ErrInvalidCommand
+	}
+	if err := r.EnterContainer(); err != nil {
+		return datamodel.ErrInvalidCommand
+	}
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		tag := r.Tag()
+		if !tag.IsContext() {
+			continue
+		}
+		switch tag.TagNumber() {
+		case 0:
+			v, err := r.Uint()
+			if err != nil {
+				return datamodel.ErrInvalidCommand
+			}
+			mode = SetpointRaiseLowerMode(v)
+			hasMode = true
+		case 1:
+			v, err := r.Int()
+			if err != nil {
+				return datamodel.ErrInvalidCommand
+			}
+			amount = int8(v)
+			hasAmount = true
+		}
+	}
+	if !hasMode || !hasAmount {
+		return datamodel.ErrInvalidCommand
+	}
+
+	return c.SetpointRaiseLower(mode, amount)
+}
+
+// SetpointRaiseLower adjusts the active setpoints by amount, in units of
+// 0.1°C. Results are clamped to the setpoint limits; with the Auto feature
+// the other setpoint is pushed along to keep MinSetpointDeadBand.
+func (c *Cluster) SetpointRaiseLower(mode SetpointRaiseLowerMode, amount int8) error {
+	delta := int32(amount) * 10
+
+	c.mu.Lock()
+	heat, cool := c.activeSetpointsLocked()
+	switch mode {
+	case SetpointRaiseLowerHeat:
+		if !c.hasFeature(FeatureHeating) {
+			c.mu.Unlock()
+			return datamodel.ErrInvalidCommand
+		}
+		heat = clamp32(int32(heat)+delta, c.minHeatSetpointLimit, c.maxHeatSetpointLimit)
+		heat, cool = c.enforceDeadBand(heat, cool, true)
+	case SetpointRaiseLowerCool:
+		if !c.hasFeature(FeatureCooling) {
+			c.mu.Unlock()
+			return datamodel.ErrInvalidCommand
+		}
+		cool = clamp32(int32(cool)+delta, c.minCoolSetpointLimit, c.maxCoolSetpointLimit)
+		heat, cool = c.enforceDeadBand(heat, cool, false)
+	case SetpointRaiseLowerBoth:
+		if c.hasFeature(FeatureHeating) {
+			heat = clamp32(int32(heat)+delta, c.minHeatSetpointLimit, c.maxHeatSetpointLimit)
+		}
+		if c.hasFeature(FeatureCooling) {
+			cool = clamp32(int32(cool)+delta, c.minCoolSetpointLimit, c.maxCoolSetpointLimit)
+		}
+		// Keep the setpoint in the direction of travel.
+		heat, cool = c.enforceDeadBand(heat, cool, amount > 0)
+	default:
+		c.mu.Unlock()
+		return datamodel.ErrInvalidCommand
+	}
+	changed := c.setActiveSetpointsLocked(heat, cool)
+	c.mu.Unlock()
+
+	if changed {
+		c.setpointsChanged(heat, cool)
+	}
+	return nil
+}
+
+// SetSetpoint writes one of the occupied or unoccupied setpoint attributes.
+// The value must lie within the setpoint limits and, with the Auto feature,
+// keep MinSetpointDeadBand to the paired setpoint.
+//
+// Spec: Section 4.3.9.16 - 4.3.9.19
+func (c *Cluster) SetSetpoint(attr datamodel.AttributeID, value int16) error {
+	c.mu.Lock()
+
+	var target *int16
+	var heat, cool int16
+	switch attr {
+	case AttrOccupiedHeatingSetpoint:
+		target, heat, cool = &c.occupiedHeatingSetpoint, value, c.occupiedCoolingSetpoint
+	case AttrUnoccupiedHeatingSetpoint:
+		target, heat, cool = &c.unoccupiedHeatingSetpoint, value, c.unoccupiedCoolingSetpoint
+	case AttrOccupiedCoolingSetpoint:
+		target, heat, cool = &c.occupiedCoolingSetpoint, c.occupiedHeatingSetpoint, value
+	case AttrUnoccupiedCoolingSetpoint:
+		target, heat, cool = &c.unoccupiedCoolingSetpoint, c.unoccupiedHeatingSetpoint, value
+	default:
+		c.mu.Unlock()
+		return datamodel.ErrUnsupportedAttribute
+	}
+
+	heating := attr == AttrOccupiedHeatingSetpoint || attr == AttrUnoccupiedHeatingSetpoint
+	if heating && (value < c.minHeatSetpointLimit || value > c.maxHeatSetpointLimit) {
+		c.mu.Unlock()
+		return datamodel.ErrConstraintError
+	}
+	if !heating && (value < c.minCoolSetpointLimit || value > c.maxCoolSetpointLimit) {
+		c.mu.Unlock()
+		return datamodel.ErrConstraintError
+	}
+	if c.hasFeature(FeatureAutoMode) && int32(heat) > int32(cool)-c.deadBand() {
+		c.mu.Unlock()
+		return datamodel.ErrConstraintError
+	}
+
+	oldHeat, oldCool := c.activeSetpointsLocked()
+	*target = value
+	newHeat, newCool := c.activeSetpointsLocked()
+	c.mu.Unlock()
+
+	c.IncrementDataVersion()
+	if newHeat != oldHeat || newCool != oldCool {
+		c.notifySetpoints(newHeat, newCool)
+	}
+	return nil
+}
+
+// setSetpointLimit handles writes of the Min/Max Heat/Cool setpoint limits.
+// Limits must stay within the absolute limits and ordered; with the Auto
+// feature the heat limits must stay a dead band below the cool limits.
+// Setpoints that fall outside the new limits are clamped.
+//
+// Spec: Section 4.3.9.20 - 4.3.9.23
+func (c *Cluster) setSetpointLimit(attr datamodel.AttributeID, value int16) error {
+	c.mu.Lock()
+
+	minHeat, maxHeat := c.minHeatSetpointLimit, c.maxHeatSetpointLimit
+	minCool, maxCool := c.minCoolSetpointLimit, c.maxCoolSetpointLimit
+	switch attr {
+	case AttrMinHeatSetpointLimit:
+		minHeat = value
+	case AttrMaxHeatSetpointLimit:
+		maxHeat = value
+	case AttrMinCoolSetpointLimit:
+		minCool = value
+	case AttrMaxCoolSetpointLimit:
+		maxCool = value
+	}
+
+	lim := c.config.Limits
+	valid := minHeat >= lim.AbsMinHeat && maxHeat <= lim.AbsMaxHeat && minHeat <= maxHeat &&
+		minCool >= lim.AbsMinCool && maxCool <= lim.AbsMaxCool && minCool <= maxCool
+	if valid && c.hasFeature(FeatureAutoMode) {
+		db := c.deadBand()
+		valid = int32(minHeat) <= int32(minCool)-db && int32(maxHeat) <= int32(maxCool)-db
+	}
+	if !valid {
+		c.mu.Unlock()
+		return datamodel.ErrConstraintError
+	}
+
+	oldHeat, oldCool := c.activeSetpointsLocked()
+	c.minHeatSetpointLimit, c.maxHeatSetpointLimit = minHeat, maxHeat
+	c.minCoolSetpointLimit, c.maxCoolSetpointLimit = minCool, maxCool
+	c.occupiedHeatingSetpoint = clamp(c.occupiedHeatingSetpoint, minHeat, maxHeat)
+	c.unoccupiedHeatingSetpoint = clamp(c.unoccupiedHeatingSetpoint, minHeat, maxHeat)
+	c.occupiedCoolingSetpoint = clamp(c.occupiedCoolingSetpoint, minCool, maxCool)
+	c.unoccupiedCoolingSetpoint = clamp(c.unoccupiedCoolingSetpoint, minCool, maxCool)
+	newHeat, newCool := c.activeSetpointsLocked()
+	c.mu.Unlock()
+
+	c.IncrementDataVersion()
+	if newHeat != oldHeat || newCool != oldCool {
+		c.notifySetpoints(newHeat, newCool)
+	}
+	return nil
+}
+
+// enforceDeadBand moves one setpoint so that cool - heat >= MinSetpointDeadBand
+// when the Auto feature is supported. If keepHeat is true the cooling setpoint
+// is moved, otherwise the heating setpoint. If the moved setpoint hits its
+// limit, the kept setpoint yields instead. Caller must hold c.mu.
+func (c *Cluster) enforceDeadBand(heat, cool int16, keepHeat bool) (int16, int16) {
+	if !c.hasFeature(FeatureAutoMode) {
+		return heat, cool
+	}
+	db := c.deadBand()
+	if int32(cool)-int32(heat) >= db {
+		return heat, cool
+	}
+	if keepHeat {
+		cool = clamp32(int32(heat)+db, c.minCoolSetpointLimit, c.maxCoolSetpointLimit)
+		heat = clamp32(int32(cool)-db, c.minHeatSetpointLimit, c.maxHeatSetpointLimit)
+	} else {
+		heat = clamp32(int32(cool)-db, c.minHeatSetpointLimit, c.maxHeatSetpointLimit)
+		cool = clamp32(int32(heat)+db, c.minCoolSetpointLimit, c.maxCoolSetpointLimit)
+	}
+	return heat, cool
+}
+
+// setActiveSetpointsLocked stores the active setpoints, honouring occupancy.
+// Returns true if either value changed. Caller must hold c.mu.
+func (c *Cluster) setActiveSetpointsLocked(heat, cool int16) bool {
+	oldHeat, oldCool := c.activeSetpointsLocked()
+	if c.hasFeature(FeatureOccupancy) && !c.occupied {
+		c.unoccupiedHeatingSetpoint, c.unoccupiedCoolingSetpoint = heat, cool
+	} else {
+		c.occupiedHeatingSetpoint, c.occupiedCoolingSetpoint = heat, cool
+	}
+	return heat != oldHeat || cool != oldCool
+}
+
+// setpointsChanged bumps the data version and notifies the delegate.
+func (c *Cluster) setpointsChanged(heat, cool int16) {
+	c.IncrementDataVersion()
+	c.notifySetpoints(heat, cool)
+}
+
+// notifySetpoints forwards active setpoints to the delegate, if any.
+func (c *Cluster) notifySetpoints(heat, cool int16) {
+	if c.config.Delegate != nil {
+		c.config.Delegate.OnSetpointsChanged(heat, cool)
+	}
+}
+
+// deadBand returns MinSetpointDeadBand in units of 0.01°C.
+func (c *Cluster) deadBand() int32 {
+	return int32(c.config.MinSetpointDeadBand) * 10
+}
+
+// clamp32 limits v to [lo, hi] and narrows it to int16.
+func clamp32(v int32, lo, hi int16) int16 {
+	if v < int32(lo) {
+		return lo
+	}
+	if v > int32(hi) {
+		return hi
+	}
+	return int16(v)
+}
@@ -0,0 +1,529 @@
+// Package threadborderroutermanagement implements the Thread Border Router
+// Management Cluster (0x0452).
+//
+// The cluster lets a commissioner read and configure the Thread network
+// of a border router. Dataset operations are delegated to a Backend,
+// modeled on the OpenThread border router agent (otbr-agent).
+//
+// Setting the active dataset requires an armed fail-safe: the dataset is
+// applied immediately but reverted if the fail-safe expires before
+// commissioning completes. The node reports both outcomes through
+// OnFailSafeExpired and OnCommissioningComplete.
+//
+// Spec Reference: Section 10.4
+//
+// C++ Reference: src/app/clusters/thread-border-router-management-server/thread-border-router-management-server.cpp
+package threadborderroutermanagement
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/backkem/matter/pkg/clusters"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x0452
+	ClusterRevision uint16              = 1
+)
+
+// Attribute IDs (Spec 10.4.5).
+const (
+	AttrBorderRouterName        datamodel.AttributeID = 0x0000
+	AttrBorderAgentID           datamodel.AttributeID = 0x0001
+	AttrThreadVersion           datamodel.AttributeID = 0x0002
+	AttrInterfaceEnabled        datamodel.AttributeID = 0x0003
+	AttrActiveDatasetTimestamp  datamodel.AttributeID = 0x0004
+	AttrPendingDatasetTimestamp datamodel.AttributeID = 0x0005
+)
+
+// Command IDs (Spec 10.4.6).
+const (
+	CmdGetActiveDatasetRequest  datamodel.CommandID = 0x00
+	CmdGetPendingDatasetRequest datamodel.CommandID = 0x01
+	CmdDatasetResponse          datamodel.CommandID = 0x02
+	CmdSetActiveDatasetRequest  datamodel.CommandID = 0x03
+	CmdSetPendingDatasetRequest datamodel.CommandID = 0x04
+)
+
+// Feature bits (Spec 10.4.4).
+type Feature uint32
+
+const (
+	// FeaturePANChange supports changing the network through a pending
+	// dataset (PC).
+	FeaturePANChange Feature = 1 << 0
+)
+
+// Limits (Spec 10.4.5).
+const (
+	MaxBorderRouterNameLength = 63
+	BorderAgentIDLength       = 16
+)
+
+// Errors returned by New.
+var (
+	ErrInvalidName          = errors.New("threadborderroutermanagement: invalid border router name")
+	ErrInvalidBorderAgentID = errors.New("threadborderroutermanagement: border agent ID must be 16 bytes")
+	ErrNoBackend            = errors.New("threadborderroutermanagement: backend is required")
+)
+
+// Backend manages the Thread interface of the border router, in the
+// manner of the otbr-agent API. Datasets are TLV encoded Thread
+// operational datasets.
+type Backend interface {
+	// ActiveDataset returns the active dataset, or nil if there is none.
+	ActiveDataset() ([]byte, error)
+
+	// PendingDataset returns the pending dataset, or nil if there is none.
+	PendingDataset() ([]byte, error)
+
+	// SetActiveDataset configures the active dataset and brings the
+	// Thread interface up. It returns once the interface is enabled.
+	SetActiveDataset(dataset []byte) error
+
+	// SetPendingDataset schedules a network change with a pending dataset.
+	SetPendingDataset(dataset []byte) error
+
+	// ClearActiveDataset brings the Thread interface down and removes the
+	// active dataset. Used to revert SetActiveDataset on fail-safe expiry.
+	ClearActiveDataset() error
+
+	// InterfaceEnabled returns true if the Thread interface is up.
+	InterfaceEnabled() bool
+}
+
+// FailSafeContext reports the fail-safe state of the node.
+// generalcommissioning.FailSafeManager satisfies it.
+type FailSafeContext interface {
+	// IsArmed returns true if the fail-safe timer is currently armed.
+	IsArmed() bool
+}
+
+// Config provides dependencies for the Thread Border Router Management
+// cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to.
+	EndpointID datamodel.EndpointID
+
+	// FeatureMap indicates supported features.
+	FeatureMap Feature
+
+	// BorderRouterName is the name advertised by the border agent, at most
+	// 63 bytes, e.g. "Living Room Hub._meshcop._udp".
+	BorderRouterName string
+
+	// BorderAgentID is the 16-byte border agent ID.
+	BorderAgentID []byte
+
+	// ThreadVersion is the Thread protocol version, e.g. 4 for Thread 1.3.
+	ThreadVersion uint16
+
+	// Backend manages the Thread interface (required).
+	Backend Backend
+
+	// FailSafe reports whether the fail-safe is armed. If nil, the
+	// fail-safe is treated as never armed and SetActiveDatasetRequest
+	// always fails.
+	FailSafe FailSafeContext
+
+	// SetBreadcrumb updates the General Commissioning Breadcrumb
+	// attribute (optional).
+	SetBreadcrumb func(value uint64)
+}
+
+// Cluster implements the Thread Border Router Management cluster (0x0452).
+type Cluster struct {
+	*datamodel.ClusterBase
+	config Config
+
+	// cmdMu serializes dataset changes.
+	cmdMu sync.Mutex
+
+	// Mutable state (protected by mutex)
+	mu               sync.Mutex
+	activeTimestamp  *uint64
+	pendingTimestamp *uint64
+	interfaceEnabled bool
+
+	// revertOnExpiry is true if the active dataset was set under the
+	// current fail-safe and must be cleared if it expires.
+	revertOnExpiry bool
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Thread Border Router Management cluster. The dataset
+// timestamps are read from the backend.
+func New(cfg Config) (*Cluster, error) {
+	if cfg.Backend == nil {
+		return nil, ErrNoBackend
+	}
+	if len(cfg.BorderRouterName) > MaxBorderRouterNameLength || !utf8.ValidString(cfg.BorderRouterName) {
+		return nil, ErrInvalidName
+	}
+	if len(cfg.BorderAgentID) != BorderAgentIDLength {
+		return nil, ErrInvalidBorderAgentID
+	}
+
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		config:      cfg,
+	}
+
+	c.ClusterBase.SetFeatureMap(uint32(cfg.FeatureMap))
+	c.attrList = c.buildAttributeList()
+
+	if err := c.Refresh(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// buildAttributeList constructs the list of supported attributes.
+func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+
+	attrs := []datamodel.AttributeEntry{
+		datamodel.NewReadOnlyAttribute(AttrBorderRouterName, 0, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrBorderAgentID, 0, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrThreadVersion, 0, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrInterfaceEnabled, 0, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrActiveDatasetTimestamp, datamodel.AttrQualityNullable, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrPendingDatasetTimestamp, datamodel.AttrQualityNullable, viewPriv),
+	}
+
+	return datamodel.MergeAttributeLists(attrs)
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	managePriv := datamodel.PrivilegeManage
+	cmds := []datamodel.CommandEntry{
+		datamodel.NewCommandEntry(CmdGetActiveDatasetRequest, 0, managePriv),
+		datamodel.NewCommandEntry(CmdGetPendingDatasetRequest, 0, managePriv),
+		datamodel.NewCommandEntry(CmdSetActiveDatasetRequest, 0, managePriv),
+	}
+	if c.config.FeatureMap&FeaturePANChange != 0 {
+		cmds = append(cmds, datamodel.NewCommandEntry(CmdSetPendingDatasetRequest, 0, managePriv))
+	}
+	return cmds
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return []datamodel.CommandID{CmdDatasetResponse}
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch req.Path.Attribute {
+	case AttrBorderRouterName:
+		return w.PutString(tlv.Anonymous(), c.config.BorderRouterName)
+	case AttrBorderAgentID:
+		return w.PutBytes(tlv.Anonymous(), c.config.BorderAgentID)
+	case AttrThreadVersion:
+		return w.PutUint(tlv.Anonymous(), uint64(c.config.ThreadVersion))
+	case AttrInterfaceEnabled:
+		return w.PutBool(tlv.Anonymous(), c.interfaceEnabled)
+	case AttrActiveDatasetTimestamp:
+		return putNullableUint(w, c.activeTimestamp)
+	case AttrPendingDatasetTimestamp:
+		return putNullableUint(w, c.pendingTimestamp)
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// putNullableUint writes a nullable unsigned value.
+func putNullableUint(w *tlv.Writer, v *uint64) error {
+	if v == nil {
+		return w.PutNull(tlv.Anonymous())
+	}
+	return w.PutUint(tlv.Anonymous(), *v)
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	return datamodel.ErrUnsupportedWrite
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	return datamodel.ResponseFields(c.InvokeCommandResponse(ctx, req, r))
+}
+
+// InvokeCommandResponse implements datamodel.ClusterWithCommandResponses.
+// Both dataset requests are answered with a DatasetResponse.
+func (c *Cluster) InvokeCommandResponse(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) (*datamodel.CommandResponse, error) {
+	switch req.Path.Command {
+	case CmdGetActiveDatasetRequest, CmdGetPendingDatasetRequest:
+		// Datasets carry the network key and are only handed out over CASE
+		if req.Subject != nil && req.Subject.AuthMode != datamodel.AuthModeCASE {
+			return nil, datamodel.ErrUnsupportedAccess
+		}
+		get := c.config.Backend.ActiveDataset
+		if req.Path.Command == CmdGetPendingDatasetRequest {
+			get = c.config.Backend.PendingDataset
+		}
+		dataset, err := get()
+		if err != nil {
+			return nil, err
+		}
+		if len(dataset) == 0 {
+			return nil, datamodel.ErrNotFound
+		}
+		return newDatasetResponse(dataset)
+
+	case CmdSetActiveDatasetRequest:
+		dataset, breadcrumb, err := decodeSetDataset(r)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.SetActiveDataset(dataset, breadcrumb); err != nil {
+			return nil, err
+		}
+		return nil, nil
+
+	case CmdSetPendingDatasetRequest:
+		if c.config.FeatureMap&FeaturePANChange == 0 {
+			return nil, datamodel.ErrUnsupportedCommand
+		}
+		dataset, _, err := decodeSetDataset(r)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.SetPendingDataset(dataset); err != nil {
+			return nil, err
+		}
+		return nil, nil
+
+	default:
+		return nil, datamodel.ErrUnsupportedCommand
+	}
+}
+
+// decodeSetDataset decodes SetActiveDatasetRequest and
+// SetPendingDatasetRequest: the dataset (tag 0) and, for the active
+// dataset, an optional breadcrumb (tag 1).
+func decodeSetDataset(r *tlv.Reader) (dataset []byte, breadcrumb *uint64, err error) {
+	if err := r.Next(); err != nil {
+		return nil, nil, datamodel.ErrInvalidCommand
+	}
+	if err := r.EnterContainer(); err != nil {
+		return nil, nil, datamodel.ErrInvalidCommand
+	}
+
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		tag := r.Tag()
+		if !tag.IsContext() {
+			continue
+		}
+		switch tag.TagNumber() {
+		case 0:
+			if dataset, err = r.Bytes(); err != nil {
+				return nil, nil, datamodel.ErrInvalidCommand
+			}
+		case 1:
+			v, err := r.Uint()
+			if err != nil {
+				return nil, nil, datamodel.ErrInvalidCommand
+			}
+			breadcrumb = &v
+		}
+	}
+	if dataset == nil {
+		return nil, nil, datamodel.ErrInvalidCommand
+	}
+	return dataset, breadcrumb, nil
+}
+
+// newDatasetResponse builds a DatasetResponse (Spec 10.4.6.3).
+func newDatasetResponse(dataset []byte) (*datamodel.CommandResponse, error) {
+	return datamodel.NewCommandResponse(CmdDatasetResponse, func(w *tlv.Writer) error {
+		return w.PutBytes(tlv.ContextTag(0), dataset)
+	})
+}
+
+// SetActiveDataset handles SetActiveDatasetRequest. It requires an armed
+// fail-safe and no active dataset; the dataset must carry an Active
+// Timestamp. On success the breadcrumb, if given, is stored.
+//
+// Spec: Section 10.4.6.4
+func (c *Cluster) SetActiveDataset(dataset []byte, breadcrumb *uint64) error {
+	if c.config.FailSafe == nil || !c.config.FailSafe.IsArmed() {
+		return datamodel.ErrFailsafeRequired
+	}
+	if !c.cmdMu.TryLock() {
+		return datamodel.ErrBusy
+	}
+	defer c.cmdMu.Unlock()
+
+	c.mu.Lock()
+	hasActive := c.activeTimestamp != nil
+	c.mu.Unlock()
+	if hasActive {
+		return datamodel.ErrInvalidInState
+	}
+
+	ds, err := clusters.ParseThreadDataset(dataset)
+	if err != nil || ds.ActiveTimestamp == nil {
+		return datamodel.ErrInvalidCommand
+	}
+
+	if err := c.config.Backend.SetActiveDataset(dataset); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.activeTimestamp = ds.ActiveTimestamp
+	c.interfaceEnabled = c.config.Backend.InterfaceEnabled()
+	c.revertOnExpiry = true
+	c.IncrementDataVersion()
+	c.mu.Unlock()
+
+	if breadcrumb != nil && c.config.SetBreadcrumb != nil {
+		c.config.SetBreadcrumb(*breadcrumb)
+	}
+	return nil
+}
+
+// SetPendingDataset handles SetPendingDatasetRequest (PC). It requires an
+// active dataset; the pending dataset must carry a Pending Timestamp and
+// a Delay Timer.
+//
+// Spec: Section 10.4.6.5
+func (c *Cluster) SetPendingDataset(dataset []byte) error {
+	if !c.cmdMu.TryLock() {
+		return datamodel.ErrBusy
+	}
+	defer c.cmdMu.Unlock()
+
+	c.mu.Lock()
+	hasActive := c.activeTimestamp != nil
+	c.mu.Unlock()
+	if !hasActive {
+		return datamodel.ErrInvalidInState
+	}
+
+	ds, err := clusters.ParseThreadDataset(dataset)
+	if err != nil || ds.PendingTimestamp == nil || ds.DelayTimer == nil {
+		return datamodel.ErrInvalidCommand
+	}
+
+	if err := c.config.Backend.SetPendingDataset(dataset); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.pendingTimestamp = ds.PendingTimestamp
+	c.IncrementDataVersion()
+	c.mu.Unlock()
+	return nil
+}
+
+// OnFailSafeExpired reverts an active dataset set under the expired
+// fail-safe: the backend clears it and the Thread interface goes down.
+func (c *Cluster) OnFailSafeExpired() error {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	c.mu.Lock()
+	revert := c.revertOnExpiry
+	c.revertOnExpiry = false
+	c.mu.Unlock()
+	if !revert {
+		return nil
+	}
+
+	if err := c.config.Backend.ClearActiveDataset(); err != nil {
+		return err
+	}
+	return c.refreshLocked()
+}
+
+// OnCommissioningComplete commits an active dataset set under the
+// fail-safe so it is kept when the fail-safe is disarmed.
+func (c *Cluster) OnCommissioningComplete() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.revertOnExpiry = false
+}
+
+// Refresh re-reads the dataset timestamps and interface state from the
+// backend. Call it when the Thread stack changes on its own, e.g. when a
+// pending dataset becomes active.
+func (c *Cluster) Refresh() error {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+	return c.refreshLocked()
+}
+
+// refreshLocked implements Refresh. Caller must hold c.cmdMu.
+func (c *Cluster) refreshLocked() error {
+	active, err := c.timestamp(c.config.Backend.ActiveDataset, func(d *clusters.ThreadDataset) *uint64 { return d.ActiveTimestamp })
+	if err != nil {
+		return err
+	}
+	pending, err := c.timestamp(c.config.Backend.PendingDataset, func(d *clusters.ThreadDataset) *uint64 { return d.PendingTimestamp })
+	if err != nil {
+		return err
+	}
+	enabled := c.config.Backend.InterfaceEnabled()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !equalNullable(c.activeTimestamp, active) || !equalNullable(c.pendingTimestamp, pending) || c.interfaceEnabled != enabled {
+		c.activeTimestamp = active
+		c.pendingTimestamp = pending
+		c.interfaceEnabled = enabled
+		c.IncrementDataVersion()
+	}
+	return nil
+}
+
+// timestamp reads a dataset with get and extracts a timestamp from it.
+// A missing dataset yields nil.
+func (c *Cluster) timestamp(get func() ([]byte, error), field func(*clusters.ThreadDataset) *uint64) (*uint64, error) {
+	raw, err := get()
+	if err != nil || len(raw) == 0 {
+		return nil, err
+	}
+	ds, err := clusters.ParseThreadDataset(raw)
+	if err != nil {
+		return nil, err
+	}
+	return field(ds), nil
+}
+
+// equalNullable compares two nullable values.
+func equalNullable(a, b *uint64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,227 @@
+package threadborderroutermanagement
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// activeDataset is the OpenThread demo dataset (Active Timestamp 0x10000).
+var activeDataset, _ = hex.DecodeString("0e08000000000001000000030000" +
+	"0f0208111111112222222203" + "0e4f70656e54687265616444656d6f")
+
+// pendingDataset adds a Pending Timestamp and a 30 s Delay Timer.
+var pendingDataset, _ = hex.DecodeString("0e080000000000020000" + "3308000000000002000034040000753000030000" + "0f")
+
+// mockBackend is an in-memory Thread stack.
+type mockBackend struct {
+	active, pending []byte
+	enabled         bool
+	fail            error
+}
+
+func (b *mockBackend) ActiveDataset() ([]byte, error)  { return b.active, nil }
+func (b *mockBackend) PendingDataset() ([]byte, error) { return b.pending, nil }
+func (b *mockBackend) InterfaceEnabled() bool          { return b.enabled }
+
+func (b *mockBackend) SetActiveDataset(dataset []byte) error {
+	if b.fail != nil {
+		return b.fail
+	}
+	b.active, b.enabled = dataset, true
+	return nil
+}
+
+func (b *mockBackend) SetPendingDataset(dataset []byte) error {
+	b.pending = dataset
+	return nil
+}
+
+func (b *mockBackend) ClearActiveDataset() error {
+	b.active, b.enabled = nil, false
+	return nil
+}
+
+// mockFailSafe is a settable fail-safe state.
+type mockFailSafe struct{ armed bool }
+
+func (f *mockFailSafe) IsArmed() bool { return f.armed }
+
+func newCluster(t *testing.T, b *mockBackend, fs FailSafeContext, breadcrumb *uint64) *Cluster {
+	t.Helper()
+	c, err := New(Config{
+		EndpointID:       1,
+		FeatureMap:       FeaturePANChange,
+		BorderRouterName: "Test BR._meshcop._udp",
+		BorderAgentID:    make([]byte, 16),
+		ThreadVersion:    4,
+		Backend:          b,
+		FailSafe:         fs,
+		SetBreadcrumb:    func(v uint64) { *breadcrumb = v },
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+func invoke(c *Cluster, cmd datamodel.CommandID, dataset []byte, auth datamodel.AuthMode) ([]byte, error) {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	w.StartStructure(tlv.Anonymous())
+	if dataset != nil {
+		w.PutBytes(tlv.ContextTag(0), dataset)
+		if cmd == CmdSetActiveDatasetRequest {
+			w.PutUint(tlv.ContextTag(1), 7)
+		}
+	}
+	w.EndContainer()
+	req := datamodel.InvokeRequest{
+		Path:    datamodel.ConcreteCommandPath{Endpoint: 1, Cluster: ClusterID, Command: cmd},
+		Subject: &datamodel.SubjectDescriptor{FabricIndex: 1, AuthMode: auth},
+	}
+	return c.InvokeCommand(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes())))
+}
+
+func readNullable(t *testing.T, c *Cluster, attr datamodel.AttributeID) *uint64 {
+	t.Helper()
+	var buf bytes.Buffer
+	req := datamodel.ReadAttributeRequest{
+		Path: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: attr},
+	}
+	if err := c.ReadAttribute(context.Background(), req, tlv.NewWriter(&buf)); err != nil {
+		t.Fatalf("ReadAttribute error = %v", err)
+	}
+	r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+	r.Next()
+	if r.Type() == tlv.ElementTypeNull {
+		return nil
+	}
+	v, err := r.Uint()
+	if err != nil {
+		t.Fatalf("Uint error = %v", err)
+	}
+	return &v
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(Config{BorderAgentID: make([]byte, 16)}); !errors.Is(err, ErrNoBackend) {
+		t.Errorf("no backend error = %v, want ErrNoBackend", err)
+	}
+	if _, err := New(Config{Backend: &mockBackend{}, BorderAgentID: make([]byte, 8)}); !errors.Is(err, ErrInvalidBorderAgentID) {
+		t.Errorf("short agent ID error = %v, want ErrInvalidBorderAgentID", err)
+	}
+
+	// Existing datasets are picked up from the backend
+	var bc uint64
+	c := newCluster(t, &mockBackend{active: activeDataset, enabled: true}, nil, &bc)
+	if ts := readNullable(t, c, AttrActiveDatasetTimestamp); ts == nil || *ts != 0x10000 {
+		t.Errorf("ActiveDatasetTimestamp = %v, want 0x10000", ts)
+	}
+}
+
+func TestSetActiveDataset_FailSafe(t *testing.T) {
+	b := &mockBackend{}
+	fs := &mockFailSafe{}
+	var breadcrumb uint64
+	c := newCluster(t, b, fs, &breadcrumb)
+
+	if _, err := invoke(c, CmdSetActiveDatasetRequest, activeDataset, datamodel.AuthModeCASE); !errors.Is(err, datamodel.ErrFailsafeRequired) {
+		t.Fatalf("without fail-safe error = %v, want ErrFailsafeRequired", err)
+	}
+
+	fs.armed = true
+	if _, err := invoke(c, CmdSetActiveDatasetRequest, []byte{0x0e}, datamodel.AuthModeCASE); !errors.Is(err, datamodel.ErrInvalidCommand) {
+		t.Errorf("malformed dataset error = %v, want ErrInvalidCommand", err)
+	}
+	if _, err := invoke(c, CmdSetActiveDatasetRequest, activeDataset, datamodel.AuthModeCASE); err != nil {
+		t.Fatalf("SetActiveDatasetRequest error = %v", err)
+	}
+	if !b.enabled || breadcrumb != 7 {
+		t.Errorf("enabled = %v, breadcrumb = %d", b.enabled, breadcrumb)
+	}
+	if ts := readNullable(t, c, AttrActiveDatasetTimestamp); ts == nil || *ts != 0x10000 {
+		t.Errorf("ActiveDatasetTimestamp = %v, want 0x10000", ts)
+	}
+
+	// An active dataset cannot be replaced
+	if _, err := invoke(c, CmdSetActiveDatasetRequest, activeDataset, datamodel.AuthModeCASE); !errors.Is(err, datamodel.ErrInvalidInState) {
+		t.Errorf("second set error = %v, want ErrInvalidInState", err)
+	}
+
+	// Fail-safe expiry reverts the dataset
+	if err := c.OnFailSafeExpired(); err != nil {
+		t.Fatalf("OnFailSafeExpired error = %v", err)
+	}
+	if b.active != nil || b.enabled {
+		t.Error("dataset not reverted")
+	}
+	if ts := readNullable(t, c, AttrActiveDatasetTimestamp); ts != nil {
+		t.Errorf("ActiveDatasetTimestamp = %d after revert, want null", *ts)
+	}
+
+	// Once commissioning completes the dataset is kept
+	if _, err := invoke(c, CmdSetActiveDatasetRequest, activeDataset, datamodel.AuthModeCASE); err != nil {
+		t.Fatalf("SetActiveDatasetRequest error = %v", err)
+	}
+	c.OnCommissioningComplete()
+	c.OnFailSafeExpired()
+	if b.active == nil {
+		t.Error("committed dataset was reverted")
+	}
+}
+
+func TestGetDataset(t *testing.T) {
+	b := &mockBackend{}
+	var bc uint64
+	c := newCluster(t, b, nil, &bc)
+
+	if _, err := invoke(c, CmdGetActiveDatasetRequest, nil, datamodel.AuthModeCASE); !errors.Is(err, datamodel.ErrNotFound) {
+		t.Errorf("no dataset error = %v, want ErrNotFound", err)
+	}
+
+	b.active = activeDataset
+	if _, err := invoke(c, CmdGetActiveDatasetRequest, nil, datamodel.AuthModePASE); !errors.Is(err, datamodel.ErrUnsupportedAccess) {
+		t.Errorf("over PASE error = %v, want ErrUnsupportedAccess", err)
+	}
+
+	resp, err := invoke(c, CmdGetActiveDatasetRequest, nil, datamodel.AuthModeCASE)
+	if err != nil {
+		t.Fatalf("GetActiveDatasetRequest error = %v", err)
+	}
+	r := tlv.NewReader(bytes.NewReader(resp))
+	r.Next()
+	r.EnterContainer()
+	r.Next()
+	got, _ := r.Bytes()
+	if !bytes.Equal(got, activeDataset) {
+		t.Errorf("dataset = %x, want %x", got, activeDataset)
+	}
+}
+
+func TestSetPendingDataset(t *testing.T) {
+	b := &mockBackend{}
+	var bc uint64
+	c := newCluster(t, b, nil, &bc)
+
+	if _, err := invoke(c, CmdSetPendingDatasetRequest, pendingDataset, datamodel.AuthModeCASE); !errors.Is(err, datamodel.ErrInvalidInState) {
+		t.Errorf("without active dataset error = %v, want ErrInvalidInState", err)
+	}
+
+	b.active, b.enabled = activeDataset, true
+	c.Refresh()
+	if _, err := invoke(c, CmdSetPendingDatasetRequest, activeDataset, datamodel.AuthModeCASE); !errors.Is(err, datamodel.ErrInvalidCommand) {
+		t.Errorf("no pending timestamp error = %v, want ErrInvalidCommand", err)
+	}
+	if _, err := invoke(c, CmdSetPendingDatasetRequest, pendingDataset, datamodel.AuthModeCASE); err != nil {
+		t.Fatalf("SetPendingDatasetRequest error = %v", err)
+	}
+	if ts := readNullable(t, c, AttrPendingDatasetTimestamp); ts == nil || *ts != 0x20000 {
+		t.Errorf("PendingDatasetTimestamp = %v, want 0x20000", ts)
+	}
+}
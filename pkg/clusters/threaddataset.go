@@ -0,0 +1,123 @@
+package clusters
+
+import (
+	"encoding/binary"
+	"errors"
+	"unicode/utf8"
+)
+
+// MaxThreadDatasetLength is the maximum length of a Thread operational
+// dataset carried in Matter commands and attributes.
+const MaxThreadDatasetLength = 254
+
+// Thread MeshCoP TLV types used in operational datasets
+// (Thread 1.3.0, Section 8.10).
+const (
+	threadTLVChannel          = 0
+	threadTLVPANID            = 1
+	threadTLVExtendedPANID    = 2
+	threadTLVNetworkName      = 3
+	threadTLVActiveTimestamp  = 14
+	threadTLVPendingTimestamp = 51
+	threadTLVDelayTimer       = 52
+)
+
+// ErrInvalidThreadDataset is returned when a Thread operational dataset
+// is malformed.
+var ErrInvalidThreadDataset = errors.New("invalid thread operational dataset")
+
+// ThreadDataset holds the fields of a Thread operational dataset that
+// Matter clusters inspect. The dataset itself stays opaque: Raw is passed
+// to the Thread stack unchanged.
+type ThreadDataset struct {
+	// Raw is the TLV encoded dataset.
+	Raw []byte
+
+	// ActiveTimestamp is the Active Timestamp TLV as a 64-bit value
+	// (seconds << 16 | ticks << 1 | authoritative), or nil if absent.
+	ActiveTimestamp *uint64
+
+	// PendingTimestamp is the Pending Timestamp TLV, or nil if absent.
+	PendingTimestamp *uint64
+
+	// DelayTimer is the Delay Timer TLV in milliseconds, or nil if absent.
+	DelayTimer *uint32
+
+	// ExtendedPANID is the 8-byte Extended PAN ID, or nil if absent.
+	ExtendedPANID []byte
+
+	// NetworkName is the network name, or "" if absent.
+	NetworkName string
+
+	// Channel is the channel number, or nil if absent.
+	Channel *uint16
+
+	// PANID is the PAN ID, or nil if absent.
+	PANID *uint16
+}
+
+// ParseThreadDataset parses a TLV encoded Thread operational dataset.
+// It checks the TLV framing and the length of the fields it extracts;
+// other TLVs are skipped. Returns ErrInvalidThreadDataset if the dataset
+// is empty, too long or malformed.
+func ParseThreadDataset(b []byte) (*ThreadDataset, error) {
+	if len(b) == 0 || len(b) > MaxThreadDatasetLength {
+		return nil, ErrInvalidThreadDataset
+	}
+
+	d := &ThreadDataset{Raw: b}
+	for len(b) > 0 {
+		if len(b) < 2 || b[1] == 0xFF {
+			return nil, ErrInvalidThreadDataset
+		}
+		typ, n := b[0], int(b[1])
+		if len(b) < 2+n {
+			return nil, ErrInvalidThreadDataset
+		}
+		v := b[2 : 2+n]
+		b = b[2+n:]
+
+		switch typ {
+		case threadTLVChannel:
+			// Channel page (1 byte) followed by the channel
+			if n != 3 {
+				return nil, ErrInvalidThreadDataset
+			}
+			ch := binary.BigEndian.Uint16(v[1:])
+			d.Channel = &ch
+		case threadTLVPANID:
+			if n != 2 {
+				return nil, ErrInvalidThreadDataset
+			}
+			id := binary.BigEndian.Uint16(v)
+			d.PANID = &id
+		case threadTLVExtendedPANID:
+			if n != 8 {
+				return nil, ErrInvalidThreadDataset
+			}
+			d.ExtendedPANID = v
+		case threadTLVNetworkName:
+			if n == 0 || n > 16 || !utf8.Valid(v) {
+				return nil, ErrInvalidThreadDataset
+			}
+			d.NetworkName = string(v)
+		case threadTLVActiveTimestamp, threadTLVPendingTimestamp:
+			if n != 8 {
+				return nil, ErrInvalidThreadDataset
+			}
+			ts := binary.BigEndian.Uint64(v)
+			if typ == threadTLVActiveTimestamp {
+				d.ActiveTimestamp = &ts
+			} else {
+				d.PendingTimestamp = &ts
+			}
+		case threadTLVDelayTimer:
+			if n != 4 {
+				return nil, ErrInvalidThreadDataset
+			}
+			t := binary.BigEndian.Uint32(v)
+			d.DelayTimer = &t
+		}
+	}
+	return d, nil
+}
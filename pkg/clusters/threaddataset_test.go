@@ -0,0 +1,67 @@
+package clusters
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// testThreadDataset is the OpenThread demo dataset.
+const testThreadDataset = "0e080000000000010000000300000f35060004001fffe0020811111111222222220708fdad70bfe5aa15dd" +
+	"051000112233445566778899aabbccddeeff030e4f70656e54687265616444656d6f010212340410445f2b5ca6f2a93a55ce570a70efeecb" +
+	"0c0402a0f7f8"
+
+func TestParseThreadDataset(t *testing.T) {
+	raw, _ := hex.DecodeString(testThreadDataset)
+	d, err := ParseThreadDataset(raw)
+	if err != nil {
+		t.Fatalf("ParseThreadDataset error = %v", err)
+	}
+
+	if d.ActiveTimestamp == nil || *d.ActiveTimestamp != 0x10000 {
+		t.Errorf("ActiveTimestamp = %v, want 0x10000", d.ActiveTimestamp)
+	}
+	if d.PendingTimestamp != nil || d.DelayTimer != nil {
+		t.Error("unexpected pending fields")
+	}
+	if d.Channel == nil || *d.Channel != 15 {
+		t.Errorf("Channel = %v, want 15", d.Channel)
+	}
+	if d.PANID == nil || *d.PANID != 0x1234 {
+		t.Errorf("PANID = %v, want 0x1234", d.PANID)
+	}
+	if !bytes.Equal(d.ExtendedPANID, []byte{0x11, 0x11, 0x11, 0x11, 0x22, 0x22, 0x22, 0x22}) {
+		t.Errorf("ExtendedPANID = %x", d.ExtendedPANID)
+	}
+	if d.NetworkName != "OpenThreadDemo" {
+		t.Errorf("NetworkName = %q", d.NetworkName)
+	}
+}
+
+func TestParseThreadDataset_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		hex  string
+	}{
+		{"empty", ""},
+		{"truncated header", "0e"},
+		{"truncated value", "0e08000000"},
+		{"bad timestamp length", "0e0400000001"},
+		{"bad extended pan id length", "02021111"},
+		{"empty network name", "0300"},
+		{"extended length", "03ff0001"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, _ := hex.DecodeString(tt.hex)
+			if _, err := ParseThreadDataset(raw); !errors.Is(err, ErrInvalidThreadDataset) {
+				t.Errorf("error = %v, want ErrInvalidThreadDataset", err)
+			}
+		})
+	}
+
+	if _, err := ParseThreadDataset(make([]byte, MaxThreadDatasetLength+1)); !errors.Is(err, ErrInvalidThreadDataset) {
+		t.Errorf("too long error = %v, want ErrInvalidThreadDataset", err)
+	}
+}
@@ -0,0 +1,535 @@
+// Package threadnetworkdirectory implements the Thread Network Directory
+// Cluster (0x0453).
+//
+// The cluster stores the operational datasets of Thread networks known to
+// a border router or controller so that other Matter nodes can join them.
+// Networks are keyed by Extended PAN ID; one of them may be marked as
+// preferred.
+//
+// Spec Reference: Section 10.5
+//
+// C++ Reference: src/app/clusters/thread-network-directory-server/thread-network-directory-server.cpp
+package threadnetworkdirectory
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/backkem/matter/pkg/clusters"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x0453
+	ClusterRevision uint16              = 1
+)
+
+// Attribute IDs (Spec 10.5.5).
+const (
+	AttrPreferredExtendedPanID datamodel.AttributeID = 0x0000
+	AttrThreadNetworks         datamodel.AttributeID = 0x0001
+	AttrThreadNetworkTableSize datamodel.AttributeID = 0x0002
+)
+
+// Command IDs (Spec 10.5.6).
+const (
+	CmdAddNetwork                 datamodel.CommandID = 0x00
+	CmdRemoveNetwork              datamodel.CommandID = 0x01
+	CmdGetOperationalDataset      datamodel.CommandID = 0x02
+	CmdOperationalDatasetResponse datamodel.CommandID = 0x03
+)
+
+// Limits (Spec 10.5.5).
+const (
+	ExtendedPanIDLength = 8
+
+	// MinThreadNetworkTableSize is the minimum number of networks a
+	// directory must be able to hold.
+	MinThreadNetworkTableSize = 2
+
+	// DefaultThreadNetworkTableSize is used if Config.TableSize is zero.
+	DefaultThreadNetworkTableSize = 10
+)
+
+// Errors returned by New.
+var (
+	ErrInvalidTableSize = errors.New("threadnetworkdirectory: table size must be at least 2")
+)
+
+// Storage provides persistence for the directory.
+type Storage interface {
+	// Load retrieves a value by key.
+	Load(key string) ([]byte, error)
+	// Store persists a value.
+	Store(key string, value []byte) error
+}
+
+// Storage keys.
+const (
+	keyNetworks  = "networks"
+	keyPreferred = "preferredExtendedPanID"
+)
+
+// ThreadNetwork describes a stored network (ThreadNetworkStruct, Spec 10.5.4.1).
+type ThreadNetwork struct {
+	ExtendedPanID   []byte
+	NetworkName     string
+	Channel         uint16
+	ActiveTimestamp uint64
+}
+
+// MarshalTLV encodes the struct with the given tag.
+func (n ThreadNetwork) MarshalTLV(w *tlv.Writer, tag tlv.Tag) error {
+	if err := w.StartStructure(tag); err != nil {
+		return err
+	}
+	if err := w.PutBytes(tlv.ContextTag(0), n.ExtendedPanID); err != nil {
+		return err
+	}
+	if err := w.PutString(tlv.ContextTag(1), n.NetworkName); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(2), uint64(n.Channel)); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(3), n.ActiveTimestamp); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
+
+// Config provides dependencies for the Thread Network Directory cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to.
+	EndpointID datamodel.EndpointID
+
+	// TableSize is the maximum number of stored networks
+	// (ThreadNetworkTableSize). Defaults to DefaultThreadNetworkTableSize.
+	TableSize uint8
+
+	// Storage for persisting the directory (optional).
+	Storage Storage
+}
+
+// entry is a stored network.
+type entry struct {
+	info    ThreadNetwork
+	dataset []byte
+}
+
+// Cluster implements the Thread Network Directory cluster (0x0453).
+type Cluster struct {
+	*datamodel.ClusterBase
+	config Config
+
+	// Mutable state (protected by mutex)
+	mu        sync.RWMutex
+	networks  []entry
+	preferred []byte // nullable
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Thread Network Directory cluster. Stored networks are
+// loaded from Config.Storage.
+func New(cfg Config) (*Cluster, error) {
+	if cfg.TableSize == 0 {
+		cfg.TableSize = DefaultThreadNetworkTableSize
+	}
+	if cfg.TableSize < MinThreadNetworkTableSize {
+		return nil, ErrInvalidTableSize
+	}
+
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		config:      cfg,
+	}
+
+	c.attrList = c.buildAttributeList()
+	c.load()
+
+	return c, nil
+}
+
+// buildAttributeList constructs the list of supported attributes.
+func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+	managePriv := datamodel.PrivilegeManage
+
+	attrs := []datamodel.AttributeEntry{
+		datamodel.NewReadWriteAttribute(AttrPreferredExtendedPanID, datamodel.AttrQualityNullable, viewPriv, managePriv),
+		datamodel.NewReadOnlyAttribute(AttrThreadNetworks, datamodel.AttrQualityList, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrThreadNetworkTableSize, datamodel.AttrQualityFixed, viewPriv),
+	}
+
+	return datamodel.MergeAttributeLists(attrs)
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	managePriv := datamodel.PrivilegeManage
+	timed := datamodel.CmdQualityTimed
+
+	return []datamodel.CommandEntry{
+		datamodel.NewCommandEntry(CmdAddNetwork, timed, managePriv),
+		datamodel.NewCommandEntry(CmdRemoveNetwork, timed, managePriv),
+		datamodel.NewCommandEntry(CmdGetOperationalDataset, 0, managePriv),
+	}
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return []datamodel.CommandID{CmdOperationalDatasetResponse}
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	switch req.Path.Attribute {
+	case AttrPreferredExtendedPanID:
+		if c.preferred == nil {
+			return w.PutNull(tlv.Anonymous())
+		}
+		return w.PutBytes(tlv.Anonymous(), c.preferred)
+	case AttrThreadNetworks:
+		if err := w.StartArray(tlv.Anonymous()); err != nil {
+			return err
+		}
+		for _, e := range c.networks {
+			if err := e.info.MarshalTLV(w, tlv.Anonymous()); err != nil {
+				return err
+			}
+		}
+		return w.EndContainer()
+	case AttrThreadNetworkTableSize:
+		return w.PutUint(tlv.Anonymous(), uint64(c.config.TableSize))
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	switch req.Path.Attribute {
+	case AttrPreferredExtendedPanID:
+		if err := r.Next(); err != nil {
+			return err
+		}
+		if r.Type() == tlv.ElementTypeNull {
+			return c.SetPreferredExtendedPanID(nil)
+		}
+		v, err := r.Bytes()
+		if err != nil {
+			return err
+		}
+		return c.SetPreferredExtendedPanID(v)
+	case AttrThreadNetworks, AttrThreadNetworkTableSize:
+		return datamodel.ErrUnsupportedWrite
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	switch req.Path.Command {
+	case CmdAddNetwork:
+		if err := clusters.RequireTimed(req); err != nil {
+			return nil, err
+		}
+		dataset, err := decodeBytesField(r)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.AddNetwork(dataset); err != nil {
+			return nil, err
+		}
+		return clusters.EmptyResponse(), nil
+
+	case CmdRemoveNetwork:
+		if err := clusters.RequireTimed(req); err != nil {
+			return nil, err
+		}
+		extPanID, err := decodeBytesField(r)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.RemoveNetwork(extPanID); err != nil {
+			return nil, err
+		}
+		return clusters.EmptyResponse(), nil
+
+	case CmdGetOperationalDataset:
+		// Datasets carry the network key and are only handed out over CASE
+		if req.Subject != nil && req.Subject.AuthMode != datamodel.AuthModeCASE {
+			return nil, datamodel.ErrUnsupportedAccess
+		}
+		extPanID, err := decodeBytesField(r)
+		if err != nil {
+			return nil, err
+		}
+		dataset, err := c.OperationalDataset(extPanID)
+		if err != nil {
+			return nil, err
+		}
+		return encodeOperationalDatasetResponse(dataset)
+
+	default:
+		return nil, datamodel.ErrUnsupportedCommand
+	}
+}
+
+// decodeBytesField decodes a command with a single octet string field
+// at tag 0.
+func decodeBytesField(r *tlv.Reader) ([]byte, error) {
+	if err := r.Next(); err != nil {
+		return nil, datamodel.ErrInvalidCommand
+	}
+	if err := r.EnterContainer(); err != nil {
+		return nil, datamodel.ErrInvalidCommand
+	}
+
+	var value []byte
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		tag := r.Tag()
+		if tag.IsContext() && tag.TagNumber() == 0 {
+			v, err := r.Bytes()
+			if err != nil {
+				return nil, datamodel.ErrInvalidCommand
+			}
+			value = v
+		}
+	}
+	if value == nil {
+		return nil, datamodel.ErrInvalidCommand
+	}
+	return value, nil
+}
+
+// encodeOperationalDatasetResponse encodes an OperationalDatasetResponse
+// (Spec 10.5.6.4).
+func encodeOperationalDatasetResponse(dataset []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return nil, err
+	}
+	if err := w.PutBytes(tlv.ContextTag(0), dataset); err != nil {
+		return nil, err
+	}
+	if err := w.EndContainer(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// parseNetwork validates a dataset for the directory. It must carry an
+// Active Timestamp, Extended PAN ID, Network Name and Channel.
+func parseNetwork(dataset []byte) (ThreadNetwork, bool) {
+	ds, err := clusters.ParseThreadDataset(dataset)
+	if err != nil || ds.ActiveTimestamp == nil || ds.ExtendedPANID == nil ||
+		ds.NetworkName == "" || ds.Channel == nil {
+		return ThreadNetwork{}, false
+	}
+	return ThreadNetwork{
+		ExtendedPanID:   ds.ExtendedPANID,
+		NetworkName:     ds.NetworkName,
+		Channel:         *ds.Channel,
+		ActiveTimestamp: *ds.ActiveTimestamp,
+	}, true
+}
+
+// AddNetwork handles AddNetwork. A network with the same Extended PAN ID
+// is replaced only if the new dataset has a later Active Timestamp.
+//
+// Spec: Section 10.5.6.1
+func (c *Cluster) AddNetwork(dataset []byte) error {
+	info, ok := parseNetwork(dataset)
+	if !ok {
+		return datamodel.ErrConstraintError
+	}
+	dataset = bytes.Clone(dataset)
+	info.ExtendedPanID = bytes.Clone(info.ExtendedPanID)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if i := c.indexLocked(info.ExtendedPanID); i >= 0 {
+		if info.ActiveTimestamp <= c.networks[i].info.ActiveTimestamp {
+			return datamodel.ErrInvalidInState
+		}
+		c.networks[i] = entry{info: info, dataset: dataset}
+	} else {
+		if len(c.networks) >= int(c.config.TableSize) {
+			return datamodel.ErrResourceExhausted
+		}
+		c.networks = append(c.networks, entry{info: info, dataset: dataset})
+	}
+
+	c.IncrementDataVersion()
+	c.storeNetworksLocked()
+	return nil
+}
+
+// RemoveNetwork handles RemoveNetwork. The preferred network cannot be
+// removed.
+//
+// Spec: Section 10.5.6.2
+func (c *Cluster) RemoveNetwork(extPanID []byte) error {
+	if len(extPanID) != ExtendedPanIDLength {
+		return datamodel.ErrConstraintError
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	i := c.indexLocked(extPanID)
+	if i < 0 {
+		return datamodel.ErrNotFound
+	}
+	if c.preferred != nil && bytes.Equal(c.preferred, extPanID) {
+		return datamodel.ErrConstraintError
+	}
+
+	c.networks = append(c.networks[:i], c.networks[i+1:]...)
+	c.IncrementDataVersion()
+	c.storeNetworksLocked()
+	return nil
+}
+
+// OperationalDataset returns the stored dataset for the given Extended
+// PAN ID, or datamodel.ErrNotFound.
+//
+// Spec: Section 10.5.6.3
+func (c *Cluster) OperationalDataset(extPanID []byte) ([]byte, error) {
+	if len(extPanID) != ExtendedPanIDLength {
+		return nil, datamodel.ErrConstraintError
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	i := c.indexLocked(extPanID)
+	if i < 0 {
+		return nil, datamodel.ErrNotFound
+	}
+	return c.networks[i].dataset, nil
+}
+
+// SetPreferredExtendedPanID sets PreferredExtendedPanID. A non-null value
+// must name a stored network.
+func (c *Cluster) SetPreferredExtendedPanID(extPanID []byte) error {
+	if extPanID != nil && len(extPanID) != ExtendedPanIDLength {
+		return datamodel.ErrConstraintError
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if extPanID != nil && c.indexLocked(extPanID) < 0 {
+		return datamodel.ErrConstraintError
+	}
+	if bytes.Equal(c.preferred, extPanID) && (c.preferred == nil) == (extPanID == nil) {
+		return nil
+	}
+
+	c.preferred = bytes.Clone(extPanID)
+	c.IncrementDataVersion()
+	if c.config.Storage != nil {
+		_ = c.config.Storage.Store(keyPreferred, c.preferred)
+	}
+	return nil
+}
+
+// PreferredExtendedPanID returns the preferred network, or nil.
+func (c *Cluster) PreferredExtendedPanID() []byte {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return bytes.Clone(c.preferred)
+}
+
+// Networks returns the stored networks.
+func (c *Cluster) Networks() []ThreadNetwork {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	out := make([]ThreadNetwork, len(c.networks))
+	for i, e := range c.networks {
+		out[i] = e.info
+	}
+	return out
+}
+
+// indexLocked returns the index of the network with the given Extended
+// PAN ID, or -1. Caller must hold c.mu.
+func (c *Cluster) indexLocked(extPanID []byte) int {
+	for i, e := range c.networks {
+		if bytes.Equal(e.info.ExtendedPanID, extPanID) {
+			return i
+		}
+	}
+	return -1
+}
+
+// load restores the directory from storage. Entries that no longer parse
+// are dropped.
+func (c *Cluster) load() {
+	if c.config.Storage == nil {
+		return
+	}
+
+	if data, err := c.config.Storage.Load(keyNetworks); err == nil {
+		// Length-prefixed datasets
+		for len(data) > 0 {
+			n := int(data[0])
+			if len(data) < 1+n {
+				break
+			}
+			dataset := data[1 : 1+n]
+			data = data[1+n:]
+			if info, ok := parseNetwork(dataset); ok && len(c.networks) < int(c.config.TableSize) {
+				c.networks = append(c.networks, entry{info: info, dataset: dataset})
+			}
+		}
+	}
+
+	if data, err := c.config.Storage.Load(keyPreferred); err == nil && c.indexLocked(data) >= 0 {
+		c.preferred = data
+	}
+}
+
+// storeNetworksLocked persists the directory. Caller must hold c.mu.
+func (c *Cluster) storeNetworksLocked() {
+	if c.config.Storage == nil {
+		return
+	}
+	var data []byte
+	for _, e := range c.networks {
+		data = append(data, byte(len(e.dataset)))
+		data = append(data, e.dataset...)
+	}
+	_ = c.config.Storage.Store(keyNetworks, data)
+}
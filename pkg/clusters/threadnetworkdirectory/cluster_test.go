@@ -0,0 +1,182 @@
+package threadnetworkdirectory
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// dataset builds a minimal dataset: Active Timestamp, Channel 15,
+// Extended PAN ID and Network Name "OpenThreadDemo".
+func dataset(t *testing.T, ts byte, extPanID string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString("0e0800000000000" + string("0123456789abcdef"[ts]) + "0000" +
+		"000300000f" + "0208" + extPanID + "030e4f70656e54687265616444656d6f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+const (
+	panA = "1111111122222222"
+	panB = "3333333344444444"
+	panC = "5555555566666666"
+)
+
+func mustHex(s string) []byte {
+	b, _ := hex.DecodeString(s)
+	return b
+}
+
+// memStorage is an in-memory Storage.
+type memStorage map[string][]byte
+
+func (m memStorage) Load(key string) ([]byte, error) {
+	v, ok := m[key]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return v, nil
+}
+
+func (m memStorage) Store(key string, value []byte) error {
+	m[key] = bytes.Clone(value)
+	return nil
+}
+
+func invoke(c *Cluster, cmd datamodel.CommandID, value []byte, timed bool, auth datamodel.AuthMode) ([]byte, error) {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	w.StartStructure(tlv.Anonymous())
+	w.PutBytes(tlv.ContextTag(0), value)
+	w.EndContainer()
+	req := datamodel.InvokeRequest{
+		Path:    datamodel.ConcreteCommandPath{Endpoint: 1, Cluster: ClusterID, Command: cmd},
+		Subject: &datamodel.SubjectDescriptor{FabricIndex: 1, AuthMode: auth},
+	}
+	if timed {
+		req.InvokeFlags = datamodel.InvokeFlagTimed
+	}
+	return c.InvokeCommand(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes())))
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(Config{TableSize: 1}); !errors.Is(err, ErrInvalidTableSize) {
+		t.Errorf("error = %v, want ErrInvalidTableSize", err)
+	}
+	c, err := New(Config{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if c.config.TableSize != DefaultThreadNetworkTableSize {
+		t.Errorf("TableSize = %d, want default", c.config.TableSize)
+	}
+}
+
+func TestAddNetwork(t *testing.T) {
+	c, _ := New(Config{EndpointID: 1, TableSize: 2})
+
+	if _, err := invoke(c, CmdAddNetwork, dataset(t, 1, panA), false, datamodel.AuthModeCASE); err == nil {
+		t.Error("untimed AddNetwork succeeded")
+	}
+	if _, err := invoke(c, CmdAddNetwork, mustHex("000300000f"), true, datamodel.AuthModeCASE); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("incomplete dataset error = %v, want ErrConstraintError", err)
+	}
+	if _, err := invoke(c, CmdAddNetwork, dataset(t, 1, panA), true, datamodel.AuthModeCASE); err != nil {
+		t.Fatalf("AddNetwork error = %v", err)
+	}
+
+	// Same network: only a newer dataset replaces it
+	if err := c.AddNetwork(dataset(t, 1, panA)); !errors.Is(err, datamodel.ErrInvalidInState) {
+		t.Errorf("same timestamp error = %v, want ErrInvalidInState", err)
+	}
+	if err := c.AddNetwork(dataset(t, 2, panA)); err != nil {
+		t.Fatalf("newer dataset error = %v", err)
+	}
+	if n := c.Networks(); len(n) != 1 || n[0].ActiveTimestamp != 0x20000 || n[0].Channel != 15 || n[0].NetworkName != "OpenThreadDemo" {
+		t.Errorf("Networks() = %+v", n)
+	}
+
+	if err := c.AddNetwork(dataset(t, 1, panB)); err != nil {
+		t.Fatalf("AddNetwork error = %v", err)
+	}
+	if err := c.AddNetwork(dataset(t, 1, panC)); !errors.Is(err, datamodel.ErrResourceExhausted) {
+		t.Errorf("full table error = %v, want ErrResourceExhausted", err)
+	}
+}
+
+func TestRemoveNetwork_Preferred(t *testing.T) {
+	c, _ := New(Config{EndpointID: 1})
+	c.AddNetwork(dataset(t, 1, panA))
+	c.AddNetwork(dataset(t, 1, panB))
+
+	if err := c.SetPreferredExtendedPanID(mustHex(panC)); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("unknown preferred error = %v, want ErrConstraintError", err)
+	}
+	if err := c.SetPreferredExtendedPanID(mustHex(panA)); err != nil {
+		t.Fatalf("SetPreferredExtendedPanID error = %v", err)
+	}
+
+	if _, err := invoke(c, CmdRemoveNetwork, mustHex(panA), true, datamodel.AuthModeCASE); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("remove preferred error = %v, want ErrConstraintError", err)
+	}
+	if _, err := invoke(c, CmdRemoveNetwork, mustHex(panC), true, datamodel.AuthModeCASE); !errors.Is(err, datamodel.ErrNotFound) {
+		t.Errorf("remove unknown error = %v, want ErrNotFound", err)
+	}
+	if _, err := invoke(c, CmdRemoveNetwork, mustHex(panB), true, datamodel.AuthModeCASE); err != nil {
+		t.Fatalf("RemoveNetwork error = %v", err)
+	}
+	if n := c.Networks(); len(n) != 1 {
+		t.Errorf("len(Networks()) = %d, want 1", len(n))
+	}
+}
+
+func TestGetOperationalDataset(t *testing.T) {
+	c, _ := New(Config{EndpointID: 1})
+	want := dataset(t, 1, panA)
+	c.AddNetwork(want)
+
+	if _, err := invoke(c, CmdGetOperationalDataset, mustHex(panA), false, datamodel.AuthModePASE); !errors.Is(err, datamodel.ErrUnsupportedAccess) {
+		t.Errorf("over PASE error = %v, want ErrUnsupportedAccess", err)
+	}
+	if _, err := invoke(c, CmdGetOperationalDataset, mustHex(panB), false, datamodel.AuthModeCASE); !errors.Is(err, datamodel.ErrNotFound) {
+		t.Errorf("unknown network error = %v, want ErrNotFound", err)
+	}
+
+	resp, err := invoke(c, CmdGetOperationalDataset, mustHex(panA), false, datamodel.AuthModeCASE)
+	if err != nil {
+		t.Fatalf("GetOperationalDataset error = %v", err)
+	}
+	r := tlv.NewReader(bytes.NewReader(resp))
+	r.Next()
+	r.EnterContainer()
+	r.Next()
+	if got, _ := r.Bytes(); !bytes.Equal(got, want) {
+		t.Errorf("dataset = %x, want %x", got, want)
+	}
+}
+
+func TestStorage(t *testing.T) {
+	store := memStorage{}
+	c, _ := New(Config{EndpointID: 1, Storage: store})
+	c.AddNetwork(dataset(t, 1, panA))
+	c.AddNetwork(dataset(t, 1, panB))
+	c.SetPreferredExtendedPanID(mustHex(panB))
+
+	restored, err := New(Config{EndpointID: 1, Storage: store})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if n := restored.Networks(); len(n) != 2 || !bytes.Equal(n[1].ExtendedPanID, mustHex(panB)) {
+		t.Errorf("restored Networks() = %+v", n)
+	}
+	if got := restored.PreferredExtendedPanID(); !bytes.Equal(got, mustHex(panB)) {
+		t.Errorf("restored preferred = %x, want %s", got, panB)
+	}
+}
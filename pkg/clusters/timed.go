@@ -1,7 +1,7 @@
 package clusters
 
 import (
-	"errors"
+	"fmt"
 
 	"github.com/backkem/matter/pkg/datamodel"
 	"github.com/backkem/matter/pkg/im/message"
@@ -10,8 +10,9 @@ import (
 // Timed command errors.
 var (
 	// ErrTimedRequired is returned when a command requires timed invocation
-	// but the request was not part of a timed interaction.
-	ErrTimedRequired = errors.New("command requires timed invocation")
+	// but the request was not part of a timed interaction. It wraps
+	// datamodel.ErrTimedRequired so the IM layer reports NEEDS_TIMED_INTERACTION.
+	ErrTimedRequired = fmt.Errorf("command requires timed invocation: %w", datamodel.ErrTimedRequired)
 )
 
 // RequireTimed checks if the invoke request is part of a timed interaction.
@@ -0,0 +1,397 @@
+// Package timeformatlocalization implements the Time Format Localization
+// Cluster (0x002C).
+//
+// The node exposes how it displays time: a 12 or 24 hour clock and, with
+// the CALFMT feature, the calendar in use out of the calendars it
+// supports. Both preferences are writable and persisted. UseActiveLocale
+// defers the choice to the Localization Configuration cluster's active
+// locale.
+//
+// Spec Reference: Section 11.4
+//
+// C++ Reference: src/app/clusters/time-format-localization-server
+package timeformatlocalization
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x002C
+	ClusterRevision uint16              = 1
+)
+
+// Attribute IDs (Spec 11.4.6).
+const (
+	AttrHourFormat             datamodel.AttributeID = 0x0000
+	AttrActiveCalendarType     datamodel.AttributeID = 0x0001
+	AttrSupportedCalendarTypes datamodel.AttributeID = 0x0002
+)
+
+// Feature bits (Spec 11.4.4).
+type Feature uint32
+
+const (
+	// FeatureCalendarFormat adds the calendar attributes (CALFMT).
+	FeatureCalendarFormat Feature = 1 << 0
+)
+
+// HourFormat is the HourFormatEnum (Spec 11.4.5.1).
+type HourFormat uint8
+
+const (
+	HourFormat12hr            HourFormat = 0x00
+	HourFormat24hr            HourFormat = 0x01
+	HourFormatUseActiveLocale HourFormat = 0xFF
+)
+
+// CalendarType is the CalendarTypeEnum (Spec 11.4.5.2).
+type CalendarType uint8
+
+const (
+	CalendarTypeBuddhist        CalendarType = 0x00
+	CalendarTypeChinese         CalendarType = 0x01
+	CalendarTypeCoptic          CalendarType = 0x02
+	CalendarTypeEthiopian       CalendarType = 0x03
+	CalendarTypeGregorian       CalendarType = 0x04
+	CalendarTypeHebrew          CalendarType = 0x05
+	CalendarTypeIndian          CalendarType = 0x06
+	CalendarTypeIslamic         CalendarType = 0x07
+	CalendarTypeJapanese        CalendarType = 0x08
+	CalendarTypeKorean          CalendarType = 0x09
+	CalendarTypePersian         CalendarType = 0x0A
+	CalendarTypeTaiwanese       CalendarType = 0x0B
+	CalendarTypeUseActiveLocale CalendarType = 0xFF
+)
+
+// Errors returned by configuration and the device-side API.
+var (
+	ErrNoCalendarTypes       = errors.New("timeformatlocalization: at least one supported calendar type required")
+	ErrInvalidCalendarType   = errors.New("timeformatlocalization: invalid calendar type")
+	ErrDuplicateCalendarType = errors.New("timeformatlocalization: duplicate calendar type")
+	ErrUnsupportedCalendar   = errors.New("timeformatlocalization: active calendar type not supported")
+	ErrFeatureNotSupported   = errors.New("timeformatlocalization: feature not supported")
+)
+
+// Storage provides persistence for the time format preferences.
+type Storage interface {
+	// Load retrieves a value by key.
+	Load(key string) ([]byte, error)
+	// Store persists a value.
+	Store(key string, value []byte) error
+}
+
+// Config provides dependencies for the Time Format Localization cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to (normally 0).
+	EndpointID datamodel.EndpointID
+
+	// FeatureMap indicates supported features.
+	FeatureMap Feature
+
+	// HourFormat is the initial hour format if no persisted value exists.
+	HourFormat HourFormat
+
+	// SupportedCalendarTypes lists the calendars the node can display
+	// (CALFMT only). At least one is required.
+	SupportedCalendarTypes []CalendarType
+
+	// ActiveCalendarType is the initial calendar if no persisted value
+	// exists (CALFMT only). Defaults to the first supported calendar.
+	ActiveCalendarType *CalendarType
+
+	// Storage for persisting HourFormat and ActiveCalendarType (optional).
+	Storage Storage
+}
+
+// Cluster implements the Time Format Localization cluster (0x002C).
+type Cluster struct {
+	*datamodel.ClusterBase
+	config Config
+
+	// Mutable state (protected by mutex)
+	mu         sync.RWMutex
+	hourFormat HourFormat
+	calendar   CalendarType
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Time Format Localization cluster. It returns an error
+// if the calendar configuration is invalid.
+func New(cfg Config) (*Cluster, error) {
+	if !validHourFormat(cfg.HourFormat) {
+		return nil, datamodel.ErrConstraintError
+	}
+
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		config:      cfg,
+		hourFormat:  cfg.HourFormat,
+	}
+
+	if cfg.FeatureMap&FeatureCalendarFormat != 0 {
+		if err := validateCalendarTypes(cfg.SupportedCalendarTypes); err != nil {
+			return nil, err
+		}
+		c.config.SupportedCalendarTypes = slices.Clone(cfg.SupportedCalendarTypes)
+		c.calendar = cfg.SupportedCalendarTypes[0]
+		if cfg.ActiveCalendarType != nil {
+			if !c.supportsCalendar(*cfg.ActiveCalendarType) {
+				return nil, ErrUnsupportedCalendar
+			}
+			c.calendar = *cfg.ActiveCalendarType
+		}
+	} else if len(cfg.SupportedCalendarTypes) > 0 || cfg.ActiveCalendarType != nil {
+		return nil, ErrFeatureNotSupported
+	}
+
+	if cfg.Storage != nil {
+		c.loadPersistedState()
+	}
+
+	c.ClusterBase.SetFeatureMap(uint32(cfg.FeatureMap))
+
+	c.attrList = c.buildAttributeList()
+
+	return c, nil
+}
+
+// validateCalendarTypes checks the supported calendar list.
+func validateCalendarTypes(types []CalendarType) error {
+	if len(types) == 0 {
+		return ErrNoCalendarTypes
+	}
+	for i, t := range types {
+		if t > CalendarTypeTaiwanese {
+			return ErrInvalidCalendarType
+		}
+		if slices.Contains(types[:i], t) {
+			return ErrDuplicateCalendarType
+		}
+	}
+	return nil
+}
+
+// validHourFormat reports whether f is a defined hour format.
+func validHourFormat(f HourFormat) bool {
+	return f <= HourFormat24hr || f == HourFormatUseActiveLocale
+}
+
+// supportsCalendar reports whether t may become the active calendar.
+// UseActiveLocale is always accepted.
+func (c *Cluster) supportsCalendar(t CalendarType) bool {
+	return t == CalendarTypeUseActiveLocale || slices.Contains(c.config.SupportedCalendarTypes, t)
+}
+
+// hasFeature returns true if the feature bit is set.
+func (c *Cluster) hasFeature(f Feature) bool {
+	return c.config.FeatureMap&f != 0
+}
+
+// loadPersistedState loads HourFormat and ActiveCalendarType from storage.
+// Values that are no longer valid are ignored.
+func (c *Cluster) loadPersistedState() {
+	if data, err := c.config.Storage.Load("hourFormat"); err == nil && len(data) == 1 {
+		if f := HourFormat(data[0]); validHourFormat(f) {
+			c.hourFormat = f
+		}
+	}
+	if !c.hasFeature(FeatureCalendarFormat) {
+		return
+	}
+	if data, err := c.config.Storage.Load("activeCalendarType"); err == nil && len(data) == 1 {
+		if t := CalendarType(data[0]); c.supportsCalendar(t) {
+			c.calendar = t
+		}
+	}
+}
+
+// persist stores a single-byte value if storage is configured.
+func (c *Cluster) persist(key string, value byte) {
+	if c.config.Storage != nil {
+		_ = c.config.Storage.Store(key, []byte{value})
+	}
+}
+
+// buildAttributeList constructs the list of supported attributes.
+func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+	managePriv := datamodel.PrivilegeManage
+
+	attrs := []datamodel.AttributeEntry{
+		datamodel.NewReadWriteAttribute(AttrHourFormat, datamodel.AttrQualityNonVolatile, viewPriv, managePriv),
+	}
+	if c.hasFeature(FeatureCalendarFormat) {
+		attrs = append(attrs,
+			datamodel.NewReadWriteAttribute(AttrActiveCalendarType, datamodel.AttrQualityNonVolatile, viewPriv, managePriv),
+			datamodel.NewReadOnlyAttribute(AttrSupportedCalendarTypes, datamodel.AttrQualityList|datamodel.AttrQualityFixed, viewPriv),
+		)
+	}
+
+	return datamodel.MergeAttributeLists(attrs)
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	return nil
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return nil
+}
+
+// hasAttribute returns true if attr is in the attribute list.
+func (c *Cluster) hasAttribute(attr datamodel.AttributeID) bool {
+	for _, a := range c.attrList {
+		if a.ID == attr {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	if !c.hasAttribute(req.Path.Attribute) {
+		return datamodel.ErrUnsupportedAttribute
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	switch req.Path.Attribute {
+	case AttrHourFormat:
+		return w.PutUint(tlv.Anonymous(), uint64(c.hourFormat))
+	case AttrActiveCalendarType:
+		return w.PutUint(tlv.Anonymous(), uint64(c.calendar))
+	case AttrSupportedCalendarTypes:
+		if err := w.StartArray(tlv.Anonymous()); err != nil {
+			return err
+		}
+		for _, t := range c.config.SupportedCalendarTypes {
+			if err := w.PutUint(tlv.Anonymous(), uint64(t)); err != nil {
+				return err
+			}
+		}
+		return w.EndContainer()
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	if !c.hasAttribute(req.Path.Attribute) {
+		return datamodel.ErrUnsupportedAttribute
+	}
+	if err := r.Next(); err != nil {
+		return err
+	}
+
+	switch req.Path.Attribute {
+	case AttrHourFormat:
+		v, err := r.Uint()
+		if err != nil {
+			return err
+		}
+		if v > 0xFF {
+			return datamodel.ErrConstraintError
+		}
+		return c.SetHourFormat(HourFormat(v))
+	case AttrActiveCalendarType:
+		v, err := r.Uint()
+		if err != nil {
+			return err
+		}
+		if v > 0xFF {
+			return datamodel.ErrConstraintError
+		}
+		return c.SetActiveCalendarType(CalendarType(v))
+	default:
+		return datamodel.ErrUnsupportedWrite
+	}
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	return nil, datamodel.ErrUnsupportedCommand
+}
+
+// HourFormat returns the hour format.
+func (c *Cluster) HourFormat() HourFormat {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.hourFormat
+}
+
+// SetHourFormat changes the hour format. Returns
+// datamodel.ErrConstraintError for an undefined value.
+//
+// Spec: Section 11.4.6.1
+func (c *Cluster) SetHourFormat(f HourFormat) error {
+	if !validHourFormat(f) {
+		return datamodel.ErrConstraintError
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.hourFormat != f {
+		c.hourFormat = f
+		c.persist("hourFormat", byte(f))
+		c.IncrementDataVersion()
+	}
+	return nil
+}
+
+// ActiveCalendarType returns the active calendar (CALFMT only).
+func (c *Cluster) ActiveCalendarType() CalendarType {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.calendar
+}
+
+// SetActiveCalendarType changes the active calendar (CALFMT only).
+// Returns datamodel.ErrConstraintError if the calendar is not in
+// SupportedCalendarTypes.
+//
+// Spec: Section 11.4.6.2
+func (c *Cluster) SetActiveCalendarType(t CalendarType) error {
+	if !c.hasFeature(FeatureCalendarFormat) {
+		return ErrFeatureNotSupported
+	}
+	if !c.supportsCalendar(t) {
+		return datamodel.ErrConstraintError
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.calendar != t {
+		c.calendar = t
+		c.persist("activeCalendarType", byte(t))
+		c.IncrementDataVersion()
+	}
+	return nil
+}
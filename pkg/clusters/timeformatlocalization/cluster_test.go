@@ -0,0 +1,129 @@
+package timeformatlocalization
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+type mapStorage map[string][]byte
+
+func (s mapStorage) Load(key string) ([]byte, error) {
+	v, ok := s[key]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return v, nil
+}
+
+func (s mapStorage) Store(key string, value []byte) error {
+	s[key] = value
+	return nil
+}
+
+var testCalendars = []CalendarType{CalendarTypeGregorian, CalendarTypeJapanese}
+
+func writeUint(c *Cluster, attr datamodel.AttributeID, v uint64) error {
+	var buf bytes.Buffer
+	tlv.NewWriter(&buf).PutUint(tlv.Anonymous(), v)
+	req := datamodel.WriteAttributeRequest{
+		Path: datamodel.ConcreteDataAttributePath{
+			ConcreteAttributePath: datamodel.ConcreteAttributePath{Endpoint: 0, Cluster: ClusterID, Attribute: attr},
+		},
+	}
+	return c.WriteAttribute(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes())))
+}
+
+func TestNew_Validation(t *testing.T) {
+	japanese := CalendarTypeJapanese
+	korean := CalendarTypeKorean
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want error
+	}{
+		{"hour format only", Config{HourFormat: HourFormat24hr}, nil},
+		{"invalid hour format", Config{HourFormat: 5}, datamodel.ErrConstraintError},
+		{"calendars", Config{FeatureMap: FeatureCalendarFormat, SupportedCalendarTypes: testCalendars, ActiveCalendarType: &japanese}, nil},
+		{"no calendars", Config{FeatureMap: FeatureCalendarFormat}, ErrNoCalendarTypes},
+		{"duplicate calendar", Config{FeatureMap: FeatureCalendarFormat, SupportedCalendarTypes: []CalendarType{4, 4}}, ErrDuplicateCalendarType},
+		{"UseActiveLocale in list", Config{FeatureMap: FeatureCalendarFormat, SupportedCalendarTypes: []CalendarType{CalendarTypeUseActiveLocale}}, ErrInvalidCalendarType},
+		{"active not supported", Config{FeatureMap: FeatureCalendarFormat, SupportedCalendarTypes: testCalendars, ActiveCalendarType: &korean}, ErrUnsupportedCalendar},
+		{"calendars without CALFMT", Config{SupportedCalendarTypes: testCalendars}, ErrFeatureNotSupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg); !errors.Is(err, tt.want) {
+				t.Errorf("New() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteHourFormat(t *testing.T) {
+	c, _ := New(Config{})
+
+	if err := writeUint(c, AttrHourFormat, 2); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("write 2 error = %v, want ErrConstraintError", err)
+	}
+	if err := writeUint(c, AttrHourFormat, uint64(HourFormatUseActiveLocale)); err != nil {
+		t.Fatalf("write UseActiveLocale error = %v", err)
+	}
+	if got := c.HourFormat(); got != HourFormatUseActiveLocale {
+		t.Errorf("HourFormat = %d, want UseActiveLocale", got)
+	}
+	if err := writeUint(c, AttrActiveCalendarType, 4); !errors.Is(err, datamodel.ErrUnsupportedAttribute) {
+		t.Errorf("write calendar without CALFMT error = %v, want ErrUnsupportedAttribute", err)
+	}
+}
+
+func TestWriteActiveCalendarType(t *testing.T) {
+	c, _ := New(Config{FeatureMap: FeatureCalendarFormat, SupportedCalendarTypes: testCalendars})
+	if got := c.ActiveCalendarType(); got != CalendarTypeGregorian {
+		t.Errorf("ActiveCalendarType = %d, want first supported Gregorian", got)
+	}
+
+	if err := writeUint(c, AttrActiveCalendarType, uint64(CalendarTypeHebrew)); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("write unsupported error = %v, want ErrConstraintError", err)
+	}
+
+	version := c.DataVersion()
+	if err := writeUint(c, AttrActiveCalendarType, uint64(CalendarTypeJapanese)); err != nil {
+		t.Fatalf("write error = %v", err)
+	}
+	if got := c.ActiveCalendarType(); got != CalendarTypeJapanese {
+		t.Errorf("ActiveCalendarType = %d, want Japanese", got)
+	}
+	if c.DataVersion() == version {
+		t.Error("DataVersion should change")
+	}
+}
+
+func TestPersistence(t *testing.T) {
+	storage := mapStorage{}
+	cfg := Config{FeatureMap: FeatureCalendarFormat, SupportedCalendarTypes: testCalendars, Storage: storage}
+
+	c, _ := New(cfg)
+	c.SetHourFormat(HourFormat24hr)
+	c.SetActiveCalendarType(CalendarTypeJapanese)
+
+	c, _ = New(cfg)
+	if got := c.HourFormat(); got != HourFormat24hr {
+		t.Errorf("restored HourFormat = %d, want 24hr", got)
+	}
+	if got := c.ActiveCalendarType(); got != CalendarTypeJapanese {
+		t.Errorf("restored ActiveCalendarType = %d, want Japanese", got)
+	}
+
+	// A persisted calendar that is no longer supported is ignored.
+	cfg.SupportedCalendarTypes = []CalendarType{CalendarTypeGregorian}
+	c, _ = New(cfg)
+	if got := c.ActiveCalendarType(); got != CalendarTypeGregorian {
+		t.Errorf("ActiveCalendarType = %d, want Gregorian", got)
+	}
+}
@@ -0,0 +1,269 @@
+// Package unitlocalization implements the Unit Localization Cluster
+// (0x002D).
+//
+// With the TEMP feature the node exposes the unit it displays
+// temperatures in. The unit is writable, limited to the supported units,
+// and persisted.
+//
+// Spec Reference: Section 11.5
+//
+// C++ Reference: src/app/clusters/unit-localization-server
+package unitlocalization
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x002D
+	ClusterRevision uint16              = 2
+)
+
+// Attribute IDs (Spec 11.5.6).
+const (
+	AttrTemperatureUnit           datamodel.AttributeID = 0x0000
+	AttrSupportedTemperatureUnits datamodel.AttributeID = 0x0001
+)
+
+// Feature bits (Spec 11.5.4).
+type Feature uint32
+
+const (
+	// FeatureTemperatureUnit adds the temperature unit attributes (TEMP).
+	FeatureTemperatureUnit Feature = 1 << 0
+)
+
+// TempUnit is the TempUnitEnum (Spec 11.5.5.1).
+type TempUnit uint8
+
+const (
+	TempUnitFahrenheit TempUnit = 0x00
+	TempUnitCelsius    TempUnit = 0x01
+	TempUnitKelvin     TempUnit = 0x02
+)
+
+// Errors returned by configuration and the device-side API.
+var (
+	ErrInvalidTempUnits    = errors.New("unitlocalization: supported temperature units must be 2-3 distinct units")
+	ErrUnsupportedTempUnit = errors.New("unitlocalization: temperature unit not supported")
+	ErrFeatureNotSupported = errors.New("unitlocalization: feature not supported")
+)
+
+// Storage provides persistence for the temperature unit.
+type Storage interface {
+	// Load retrieves a value by key.
+	Load(key string) ([]byte, error)
+	// Store persists a value.
+	Store(key string, value []byte) error
+}
+
+// Config provides dependencies for the Unit Localization cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to (normally 0).
+	EndpointID datamodel.EndpointID
+
+	// FeatureMap indicates supported features.
+	FeatureMap Feature
+
+	// SupportedTemperatureUnits lists the units the node can display
+	// (TEMP only). Defaults to all three units.
+	SupportedTemperatureUnits []TempUnit
+
+	// TemperatureUnit is the initial unit if no persisted value exists
+	// (TEMP only).
+	TemperatureUnit TempUnit
+
+	// Storage for persisting TemperatureUnit (optional).
+	Storage Storage
+}
+
+// Cluster implements the Unit Localization cluster (0x002D).
+type Cluster struct {
+	*datamodel.ClusterBase
+	config Config
+
+	// Mutable state (protected by mutex)
+	mu   sync.RWMutex
+	unit TempUnit
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Unit Localization cluster. It returns an error if the
+// temperature unit configuration is invalid.
+func New(cfg Config) (*Cluster, error) {
+	c := &Cluster{
+		ClusterBase: datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		config:      cfg,
+		unit:        cfg.TemperatureUnit,
+	}
+
+	if c.hasFeature(FeatureTemperatureUnit) {
+		units := cfg.SupportedTemperatureUnits
+		if units == nil {
+			units = []TempUnit{TempUnitFahrenheit, TempUnitCelsius, TempUnitKelvin}
+		}
+		if err := validateTempUnits(units); err != nil {
+			return nil, err
+		}
+		c.config.SupportedTemperatureUnits = slices.Clone(units)
+		if !slices.Contains(units, cfg.TemperatureUnit) {
+			return nil, ErrUnsupportedTempUnit
+		}
+		if cfg.Storage != nil {
+			if data, err := cfg.Storage.Load("temperatureUnit"); err == nil && len(data) == 1 && slices.Contains(units, TempUnit(data[0])) {
+				c.unit = TempUnit(data[0])
+			}
+		}
+	} else if len(cfg.SupportedTemperatureUnits) > 0 {
+		return nil, ErrFeatureNotSupported
+	}
+
+	c.ClusterBase.SetFeatureMap(uint32(cfg.FeatureMap))
+
+	c.attrList = c.buildAttributeList()
+
+	return c, nil
+}
+
+// validateTempUnits checks the supported unit list.
+func validateTempUnits(units []TempUnit) error {
+	if len(units) < 2 || len(units) > 3 {
+		return ErrInvalidTempUnits
+	}
+	for i, u := range units {
+		if u > TempUnitKelvin || slices.Contains(units[:i], u) {
+			return ErrInvalidTempUnits
+		}
+	}
+	return nil
+}
+
+// hasFeature returns true if the feature bit is set.
+func (c *Cluster) hasFeature(f Feature) bool {
+	return c.config.FeatureMap&f != 0
+}
+
+// buildAttributeList constructs the list of supported attributes.
+func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+	managePriv := datamodel.PrivilegeManage
+
+	var attrs []datamodel.AttributeEntry
+	if c.hasFeature(FeatureTemperatureUnit) {
+		attrs = append(attrs,
+			datamodel.NewReadWriteAttribute(AttrTemperatureUnit, datamodel.AttrQualityNonVolatile, viewPriv, managePriv),
+			datamodel.NewReadOnlyAttribute(AttrSupportedTemperatureUnits, datamodel.AttrQualityList|datamodel.AttrQualityFixed, viewPriv),
+		)
+	}
+
+	return datamodel.MergeAttributeLists(attrs)
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	return nil
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return nil
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	if !c.hasFeature(FeatureTemperatureUnit) {
+		return datamodel.ErrUnsupportedAttribute
+	}
+
+	switch req.Path.Attribute {
+	case AttrTemperatureUnit:
+		return w.PutUint(tlv.Anonymous(), uint64(c.TemperatureUnit()))
+	case AttrSupportedTemperatureUnits:
+		if err := w.StartArray(tlv.Anonymous()); err != nil {
+			return err
+		}
+		for _, u := range c.config.SupportedTemperatureUnits {
+			if err := w.PutUint(tlv.Anonymous(), uint64(u)); err != nil {
+				return err
+			}
+		}
+		return w.EndContainer()
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	if req.Path.Attribute != AttrTemperatureUnit || !c.hasFeature(FeatureTemperatureUnit) {
+		return datamodel.ErrUnsupportedWrite
+	}
+	if err := r.Next(); err != nil {
+		return err
+	}
+	v, err := r.Uint()
+	if err != nil {
+		return err
+	}
+	if v > 0xFF {
+		return datamodel.ErrConstraintError
+	}
+	return c.SetTemperatureUnit(TempUnit(v))
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	return nil, datamodel.ErrUnsupportedCommand
+}
+
+// TemperatureUnit returns the temperature unit (TEMP only).
+func (c *Cluster) TemperatureUnit() TempUnit {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.unit
+}
+
+// SetTemperatureUnit changes the temperature unit (TEMP only). Returns
+// datamodel.ErrConstraintError if the unit is not supported.
+//
+// Spec: Section 11.5.6.1
+func (c *Cluster) SetTemperatureUnit(u TempUnit) error {
+	if !c.hasFeature(FeatureTemperatureUnit) {
+		return ErrFeatureNotSupported
+	}
+	if !slices.Contains(c.config.SupportedTemperatureUnits, u) {
+		return datamodel.ErrConstraintError
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.unit != u {
+		c.unit = u
+		if c.config.Storage != nil {
+			_ = c.config.Storage.Store("temperatureUnit", []byte{byte(u)})
+		}
+		c.IncrementDataVersion()
+	}
+	return nil
+}
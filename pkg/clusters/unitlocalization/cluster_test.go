@@ -0,0 +1,114 @@
+package unitlocalization
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+type mapStorage map[string][]byte
+
+func (s mapStorage) Load(key string) ([]byte, error) {
+	v, ok := s[key]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return v, nil
+}
+
+func (s mapStorage) Store(key string, value []byte) error {
+	s[key] = value
+	return nil
+}
+
+func writeUnit(c *Cluster, v uint64) error {
+	var buf bytes.Buffer
+	tlv.NewWriter(&buf).PutUint(tlv.Anonymous(), v)
+	req := datamodel.WriteAttributeRequest{
+		Path: datamodel.ConcreteDataAttributePath{
+			ConcreteAttributePath: datamodel.ConcreteAttributePath{Endpoint: 0, Cluster: ClusterID, Attribute: AttrTemperatureUnit},
+		},
+	}
+	return c.WriteAttribute(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes())))
+}
+
+func TestNew_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want error
+	}{
+		{"no features", Config{}, nil},
+		{"default units", Config{FeatureMap: FeatureTemperatureUnit, TemperatureUnit: TempUnitKelvin}, nil},
+		{"single unit", Config{FeatureMap: FeatureTemperatureUnit, SupportedTemperatureUnits: []TempUnit{TempUnitCelsius}}, ErrInvalidTempUnits},
+		{"duplicate units", Config{FeatureMap: FeatureTemperatureUnit, SupportedTemperatureUnits: []TempUnit{1, 1}}, ErrInvalidTempUnits},
+		{"unit not supported", Config{
+			FeatureMap:                FeatureTemperatureUnit,
+			SupportedTemperatureUnits: []TempUnit{TempUnitCelsius, TempUnitFahrenheit},
+			TemperatureUnit:           TempUnitKelvin,
+		}, ErrUnsupportedTempUnit},
+		{"units without TEMP", Config{SupportedTemperatureUnits: []TempUnit{0, 1}}, ErrFeatureNotSupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg); !errors.Is(err, tt.want) {
+				t.Errorf("New() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteTemperatureUnit(t *testing.T) {
+	c, _ := New(Config{
+		FeatureMap:                FeatureTemperatureUnit,
+		SupportedTemperatureUnits: []TempUnit{TempUnitCelsius, TempUnitFahrenheit},
+		TemperatureUnit:           TempUnitCelsius,
+	})
+
+	if err := writeUnit(c, uint64(TempUnitKelvin)); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("write unsupported error = %v, want ErrConstraintError", err)
+	}
+
+	version := c.DataVersion()
+	if err := writeUnit(c, uint64(TempUnitFahrenheit)); err != nil {
+		t.Fatalf("write error = %v", err)
+	}
+	if got := c.TemperatureUnit(); got != TempUnitFahrenheit {
+		t.Errorf("TemperatureUnit = %d, want Fahrenheit", got)
+	}
+	if c.DataVersion() == version {
+		t.Error("DataVersion should change")
+	}
+}
+
+func TestWithoutFeature(t *testing.T) {
+	c, _ := New(Config{})
+	if len(c.AttributeList()) == 0 {
+		t.Fatal("global attributes should be listed")
+	}
+	for _, a := range c.AttributeList() {
+		if a.ID == AttrTemperatureUnit {
+			t.Error("TemperatureUnit should not be present without TEMP")
+		}
+	}
+	if err := writeUnit(c, 0); !errors.Is(err, datamodel.ErrUnsupportedWrite) {
+		t.Errorf("write error = %v, want ErrUnsupportedWrite", err)
+	}
+}
+
+func TestPersistence(t *testing.T) {
+	storage := mapStorage{}
+	cfg := Config{FeatureMap: FeatureTemperatureUnit, TemperatureUnit: TempUnitCelsius, Storage: storage}
+
+	c, _ := New(cfg)
+	c.SetTemperatureUnit(TempUnitKelvin)
+
+	c, _ = New(cfg)
+	if got := c.TemperatureUnit(); got != TempUnitKelvin {
+		t.Errorf("restored TemperatureUnit = %d, want Kelvin", got)
+	}
+}
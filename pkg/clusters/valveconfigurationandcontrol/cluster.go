@@ -0,0 +1,532 @@
+// Package valveconfigurationandcontrol implements the Valve Configuration
+// and Control Cluster (0x0081).
+//
+// Clients open the valve for an optional duration and, with the LVL
+// feature, to a target level; the cluster closes it again when the
+// duration elapses. The Delegate drives the actuator and the device
+// reports the valve's progress with SetCurrentState and SetCurrentLevel.
+// Without a Delegate the valve is assumed to move instantly.
+//
+// Spec Reference: Section 4.6
+//
+// C++ Reference: src/app/clusters/valve-configuration-and-control-server
+package valveconfigurationandcontrol
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/backkem/matter/pkg/credentials"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x0081
+	ClusterRevision uint16              = 1
+)
+
+// Attribute IDs (Spec 4.6.7).
+const (
+	AttrOpenDuration        datamodel.AttributeID = 0x0000
+	AttrDefaultOpenDuration datamodel.AttributeID = 0x0001
+	AttrAutoCloseTime       datamodel.AttributeID = 0x0002
+	AttrRemainingDuration   datamodel.AttributeID = 0x0003
+	AttrCurrentState        datamodel.AttributeID = 0x0004
+	AttrTargetState         datamodel.AttributeID = 0x0005
+	AttrCurrentLevel        datamodel.AttributeID = 0x0006
+	AttrTargetLevel         datamodel.AttributeID = 0x0007
+	AttrDefaultOpenLevel    datamodel.AttributeID = 0x0008
+	AttrValveFault          datamodel.AttributeID = 0x0009
+	AttrLevelStep           datamodel.AttributeID = 0x000A
+)
+
+// Command IDs (Spec 4.6.8).
+const (
+	CmdOpen  datamodel.CommandID = 0x00
+	CmdClose datamodel.CommandID = 0x01
+)
+
+// Event IDs (Spec 4.6.9).
+const (
+	EventValveStateChanged datamodel.EventID = 0x00
+	EventValveFault        datamodel.EventID = 0x01
+)
+
+// Feature bits (Spec 4.6.4).
+type Feature uint32
+
+const (
+	// FeatureTimeSync supports AutoCloseTime in UTC (TS).
+	FeatureTimeSync Feature = 1 << 0
+
+	// FeatureLevel supports opening to a level (LVL).
+	FeatureLevel Feature = 1 << 1
+)
+
+// ValveState is the ValveStateEnum (Spec 4.6.6.2).
+type ValveState uint8
+
+const (
+	ValveStateClosed        ValveState = 0x00
+	ValveStateOpen          ValveState = 0x01
+	ValveStateTransitioning ValveState = 0x02
+)
+
+// ValveFault is the ValveFaultBitmap (Spec 4.6.6.1).
+type ValveFault uint16
+
+const (
+	ValveFaultGeneralFault    ValveFault = 1 << 0
+	ValveFaultBlocked         ValveFault = 1 << 1
+	ValveFaultLeaking         ValveFault = 1 << 2
+	ValveFaultNotConnected    ValveFault = 1 << 3
+	ValveFaultShortCircuit    ValveFault = 1 << 4
+	ValveFaultCurrentExceeded ValveFault = 1 << 5
+)
+
+// StatusCodeFailureDueToFault is the cluster-specific status returned when
+// a fault prevents the valve from moving (Spec 4.6.5).
+const StatusCodeFailureDueToFault uint8 = 0x02
+
+// Level limits.
+const (
+	MaxLevel     uint8 = 100
+	MaxLevelStep uint8 = 50
+)
+
+// Errors returned by commands and configuration.
+var (
+	// ErrFailureDueToFault is returned by a Delegate when a fault prevents
+	// the valve from moving.
+	ErrFailureDueToFault = errors.New("valveconfigurationandcontrol: failure due to fault")
+
+	ErrInvalidConfig       = errors.New("valveconfigurationandcontrol: invalid configuration")
+	ErrFeatureNotSupported = errors.New("valveconfigurationandcontrol: feature not supported")
+)
+
+// Delegate drives the valve actuator.
+type Delegate interface {
+	// HandleOpenValve starts opening the valve to level percent (100
+	// without LVL). The device reports progress with SetCurrentState and
+	// SetCurrentLevel. An error rejects the command.
+	HandleOpenValve(level uint8) error
+
+	// HandleCloseValve starts closing the valve. An error rejects the
+	// command.
+	HandleCloseValve() error
+}
+
+// Config provides dependencies for the Valve Configuration and Control
+// cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to.
+	EndpointID datamodel.EndpointID
+
+	// FeatureMap indicates supported features.
+	FeatureMap Feature
+
+	// DefaultOpenDuration is the initial open duration in seconds used
+	// when Open omits it. Nil opens the valve until closed.
+	DefaultOpenDuration *uint32
+
+	// DefaultOpenLevel adds the DefaultOpenLevel attribute with this
+	// initial level (LVL only, 1-100).
+	DefaultOpenLevel *uint8
+
+	// LevelStep adds the LevelStep attribute (LVL only, 1-50). Target
+	// levels must be a multiple of the step or 100.
+	LevelStep *uint8
+
+	// EnableValveFault adds the ValveFault attribute.
+	EnableValveFault bool
+
+	// Delegate drives the actuator (optional).
+	Delegate Delegate
+
+	// EventPublisher for valve events.
+	// Optional - if nil, events are not emitted.
+	EventPublisher datamodel.EventPublisher
+}
+
+// OpenRequest holds the fields of the Open command.
+type OpenRequest struct {
+	// OpenDuration overrides DefaultOpenDuration if HasOpenDuration is set.
+	// A nil OpenDuration keeps the valve open until closed.
+	HasOpenDuration bool
+	OpenDuration    *uint32
+
+	// TargetLevel in percent (LVL only). Nil uses DefaultOpenLevel, or 100.
+	TargetLevel *uint8
+}
+
+// Cluster implements the Valve Configuration and Control cluster (0x0081).
+type Cluster struct {
+	*datamodel.ClusterBase
+	*datamodel.EventSource
+	config Config
+
+	// cmdMu serializes commands.
+	cmdMu sync.Mutex
+
+	// Mutable state (protected by mutex)
+	mu                  sync.Mutex
+	openDuration        *uint32
+	defaultOpenDuration *uint32
+	autoCloseTime       *uint64
+	closeAt             time.Time // zero when no auto-close is pending
+	currentState        *ValveState
+	targetState         *ValveState
+	currentLevel        *uint8
+	targetLevel         *uint8
+	defaultOpenLevel    uint8
+	fault               ValveFault
+	closeTimer          *time.Timer
+	closeGen            uint64
+
+	// now returns the current time; replaced in tests.
+	now func() time.Time
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Valve Configuration and Control cluster. It returns an
+// error if the level configuration is invalid.
+func New(cfg Config) (*Cluster, error) {
+	if cfg.FeatureMap&FeatureLevel == 0 && (cfg.DefaultOpenLevel != nil || cfg.LevelStep != nil) {
+		return nil, ErrFeatureNotSupported
+	}
+	if s := cfg.LevelStep; s != nil && (*s == 0 || *s > MaxLevelStep) {
+		return nil, ErrInvalidConfig
+	}
+	if d := cfg.DefaultOpenDuration; d != nil && *d == 0 {
+		return nil, ErrInvalidConfig
+	}
+
+	closed := ValveStateClosed
+	c := &Cluster{
+		ClusterBase:         datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		EventSource:         datamodel.NewEventSource(),
+		config:              cfg,
+		defaultOpenDuration: copyUint32(cfg.DefaultOpenDuration),
+		currentState:        &closed,
+		defaultOpenLevel:    MaxLevel,
+		now:                 time.Now,
+	}
+	if cfg.FeatureMap&FeatureLevel != 0 {
+		zero := uint8(0)
+		c.currentLevel = &zero
+	}
+	if cfg.DefaultOpenLevel != nil {
+		if !c.validLevel(*cfg.DefaultOpenLevel) {
+			return nil, ErrInvalidConfig
+		}
+		c.defaultOpenLevel = *cfg.DefaultOpenLevel
+	}
+
+	c.ClusterBase.SetFeatureMap(uint32(cfg.FeatureMap))
+
+	// Bind event source
+	if cfg.EventPublisher != nil {
+		c.EventSource.Bind(cfg.EndpointID, ClusterID, cfg.EventPublisher)
+		c.EventSource.RegisterEvents(c.eventList())
+	}
+
+	c.attrList = c.buildAttributeList()
+
+	return c, nil
+}
+
+// hasFeature returns true if the feature bit is set.
+func (c *Cluster) hasFeature(f Feature) bool {
+	return c.config.FeatureMap&f != 0
+}
+
+// validLevel reports whether level is an allowed target level.
+func (c *Cluster) validLevel(level uint8) bool {
+	if level == 0 || level > MaxLevel {
+		return false
+	}
+	if s := c.config.LevelStep; s != nil && level != MaxLevel && level%*s != 0 {
+		return false
+	}
+	return true
+}
+
+// eventList returns the events supported by the configuration.
+func (c *Cluster) eventList() []datamodel.EventEntry {
+	events := []datamodel.EventEntry{
+		datamodel.NewEventEntry(EventValveStateChanged, datamodel.EventPriorityInfo, datamodel.PrivilegeView, false),
+	}
+	if c.config.EnableValveFault {
+		events = append(events,
+			datamodel.NewEventEntry(EventValveFault, datamodel.EventPriorityInfo, datamodel.PrivilegeView, false))
+	}
+	return events
+}
+
+// buildAttributeList constructs the list of supported attributes.
+func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+	managePriv := datamodel.PrivilegeManage
+	nullable := datamodel.AttrQualityNullable
+
+	attrs := []datamodel.AttributeEntry{
+		datamodel.NewReadOnlyAttribute(AttrOpenDuration, nullable, viewPriv),
+		datamodel.NewReadWriteAttribute(AttrDefaultOpenDuration, nullable, viewPriv, managePriv),
+		datamodel.NewReadOnlyAttribute(AttrRemainingDuration, nullable|datamodel.AttrQualityQuieter, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrCurrentState, nullable, viewPriv),
+		datamodel.NewReadOnlyAttribute(AttrTargetState, nullable, viewPriv),
+	}
+	if c.hasFeature(FeatureTimeSync) {
+		attrs = append(attrs, datamodel.NewReadOnlyAttribute(AttrAutoCloseTime, nullable, viewPriv))
+	}
+	if c.hasFeature(FeatureLevel) {
+		attrs = append(attrs,
+			datamodel.NewReadOnlyAttribute(AttrCurrentLevel, nullable, viewPriv),
+			datamodel.NewReadOnlyAttribute(AttrTargetLevel, nullable, viewPriv),
+		)
+	}
+	if c.config.DefaultOpenLevel != nil {
+		attrs = append(attrs, datamodel.NewReadWriteAttribute(AttrDefaultOpenLevel, 0, viewPriv, managePriv))
+	}
+	if c.config.EnableValveFault {
+		attrs = append(attrs, datamodel.NewReadOnlyAttribute(AttrValveFault, 0, viewPriv))
+	}
+	if c.config.LevelStep != nil {
+		attrs = append(attrs, datamodel.NewReadOnlyAttribute(AttrLevelStep, datamodel.AttrQualityFixed, viewPriv))
+	}
+
+	return datamodel.MergeAttributeLists(attrs)
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	return []datamodel.CommandEntry{
+		datamodel.NewCommandEntry(CmdOpen, 0, datamodel.PrivilegeOperate),
+		datamodel.NewCommandEntry(CmdClose, 0, datamodel.PrivilegeOperate),
+	}
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return nil
+}
+
+// hasAttribute returns true if attr is in the attribute list.
+func (c *Cluster) hasAttribute(attr datamodel.AttributeID) bool {
+	for _, a := range c.attrList {
+		if a.ID == attr {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	if !c.hasAttribute(req.Path.Attribute) {
+		return datamodel.ErrUnsupportedAttribute
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch req.Path.Attribute {
+	case AttrOpenDuration:
+		return putNullableUint(w, c.openDuration)
+	case AttrDefaultOpenDuration:
+		return putNullableUint(w, c.defaultOpenDuration)
+	case AttrAutoCloseTime:
+		return putNullableUint(w, c.autoCloseTime)
+	case AttrRemainingDuration:
+		return putNullableUint(w, c.remainingLocked())
+	case AttrCurrentState:
+		return putNullableUint(w, c.currentState)
+	case AttrTargetState:
+		return putNullableUint(w, c.targetState)
+	case AttrCurrentLevel:
+		return putNullableUint(w, c.currentLevel)
+	case AttrTargetLevel:
+		return putNullableUint(w, c.targetLevel)
+	case AttrDefaultOpenLevel:
+		return w.PutUint(tlv.Anonymous(), uint64(c.defaultOpenLevel))
+	case AttrValveFault:
+		return w.PutUint(tlv.Anonymous(), uint64(c.fault))
+	case AttrLevelStep:
+		return w.PutUint(tlv.Anonymous(), uint64(*c.config.LevelStep))
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	attr := req.Path.Attribute
+	if (attr != AttrDefaultOpenDuration && attr != AttrDefaultOpenLevel) || !c.hasAttribute(attr) {
+		return datamodel.ErrUnsupportedWrite
+	}
+
+	if err := r.Next(); err != nil {
+		return err
+	}
+
+	if attr == AttrDefaultOpenDuration {
+		if r.Type() == tlv.ElementTypeNull {
+			return c.SetDefaultOpenDuration(nil)
+		}
+		v, err := r.Uint()
+		if err != nil {
+			return err
+		}
+		if v == 0 || v > 0xFFFFFFFE {
+			return datamodel.ErrConstraintError
+		}
+		d := uint32(v)
+		return c.SetDefaultOpenDuration(&d)
+	}
+
+	v, err := r.Uint()
+	if err != nil {
+		return err
+	}
+	if v > uint64(MaxLevel) {
+		return datamodel.ErrConstraintError
+	}
+	return c.SetDefaultOpenLevel(uint8(v))
+}
+
+// SetDefaultOpenDuration sets the duration in seconds used when Open
+// omits it; nil opens the valve until closed.
+func (c *Cluster) SetDefaultOpenDuration(d *uint32) error {
+	if d != nil && *d == 0 {
+		return datamodel.ErrConstraintError
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !equalUint32(c.defaultOpenDuration, d) {
+		c.defaultOpenDuration = copyUint32(d)
+		c.IncrementDataVersion()
+	}
+	return nil
+}
+
+// SetDefaultOpenLevel sets the level used when Open omits TargetLevel.
+func (c *Cluster) SetDefaultOpenLevel(level uint8) error {
+	if c.config.DefaultOpenLevel == nil {
+		return ErrFeatureNotSupported
+	}
+	if !c.validLevel(level) {
+		return datamodel.ErrConstraintError
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.defaultOpenLevel != level {
+		c.defaultOpenLevel = level
+		c.IncrementDataVersion()
+	}
+	return nil
+}
+
+// CurrentState returns the current valve state, or nil if unknown.
+func (c *Cluster) CurrentState() *ValveState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.currentState == nil {
+		return nil
+	}
+	s := *c.currentState
+	return &s
+}
+
+// CurrentLevel returns the current valve level (LVL), or nil if unknown.
+func (c *Cluster) CurrentLevel() *uint8 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.currentLevel == nil {
+		return nil
+	}
+	l := *c.currentLevel
+	return &l
+}
+
+// ValveFault returns the active faults.
+func (c *Cluster) ValveFault() ValveFault {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.fault
+}
+
+// remainingLocked returns the seconds until auto-close, rounded up, or
+// nil if none is pending. Caller must hold c.mu.
+func (c *Cluster) remainingLocked() *uint32 {
+	if c.closeAt.IsZero() {
+		return nil
+	}
+	d := c.closeAt.Sub(c.now())
+	if d < 0 {
+		d = 0
+	}
+	secs := uint32((d + time.Second - 1) / time.Second)
+	return &secs
+}
+
+// epochMicros returns t in microseconds since the Matter epoch.
+func epochMicros(t time.Time) uint64 {
+	d := t.Sub(credentials.MatterEpochStart)
+	if d < 0 {
+		return 0
+	}
+	return uint64(d.Microseconds())
+}
+
+// emit emits an event if a publisher is bound.
+func (c *Cluster) emit(eventID datamodel.EventID, payload interface{}) error {
+	if !c.EventSource.IsBound() {
+		return nil // No publisher, silently skip
+	}
+	_, err := c.EventSource.Emit(eventID, datamodel.EventPriorityInfo, payload)
+	return err
+}
+
+// putNullableUint writes *v, or null if v is nil.
+func putNullableUint[T ~uint8 | ~uint32 | ~uint64](w *tlv.Writer, v *T) error {
+	if v == nil {
+		return w.PutNull(tlv.Anonymous())
+	}
+	return w.PutUint(tlv.Anonymous(), uint64(*v))
+}
+
+func copyUint32(v *uint32) *uint32 {
+	if v == nil {
+		return nil
+	}
+	c := *v
+	return &c
+}
+
+func equalUint32(a, b *uint32) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,382 @@
+package valveconfigurationandcontrol
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// mockEventPublisher implements EventPublisher for testing.
+type mockEventPublisher struct {
+	mu     sync.Mutex
+	events []publishedEvent
+}
+
+type publishedEvent struct {
+	id   datamodel.EventID
+	data interface{}
+}
+
+func (m *mockEventPublisher) PublishEvent(
+	endpoint datamodel.EndpointID,
+	cluster datamodel.ClusterID,
+	eventID datamodel.EventID,
+	priority datamodel.EventPriority,
+	data interface{},
+	fabricIndex uint8,
+) (datamodel.EventNumber, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, publishedEvent{id: eventID, data: data})
+	return datamodel.EventNumber(len(m.events)), nil
+}
+
+// take returns and clears the published events.
+func (m *mockEventPublisher) take() []publishedEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	events := m.events
+	m.events = nil
+	return events
+}
+
+// testDelegate records calls and optionally rejects them.
+type testDelegate struct {
+	reject error
+	opened []uint8
+	closed int
+}
+
+func (d *testDelegate) HandleOpenValve(level uint8) error {
+	if d.reject != nil {
+		return d.reject
+	}
+	d.opened = append(d.opened, level)
+	return nil
+}
+
+func (d *testDelegate) HandleCloseValve() error {
+	if d.reject != nil {
+		return d.reject
+	}
+	d.closed++
+	return nil
+}
+
+// fakeClock is a settable time source.
+type fakeClock struct{ t time.Time }
+
+func (f *fakeClock) now() time.Time { return f.t }
+
+func u8(v uint8) *uint8    { return &v }
+func u32(v uint32) *uint32 { return &v }
+
+func readAttr(t *testing.T, c *Cluster, attr datamodel.AttributeID) *tlv.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	req := datamodel.ReadAttributeRequest{
+		Path: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: attr},
+	}
+	if err := c.ReadAttribute(context.Background(), req, tlv.NewWriter(&buf)); err != nil {
+		t.Fatalf("ReadAttribute(0x%04X) error = %v", attr, err)
+	}
+	r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+	if err := r.Next(); err != nil {
+		t.Fatalf("Next error = %v", err)
+	}
+	return r
+}
+
+// readNullable reads a nullable unsigned attribute.
+func readNullable(t *testing.T, c *Cluster, attr datamodel.AttributeID) *uint64 {
+	t.Helper()
+	r := readAttr(t, c, attr)
+	if r.Type() == tlv.ElementTypeNull {
+		return nil
+	}
+	v, err := r.Uint()
+	if err != nil {
+		t.Fatalf("Uint error = %v", err)
+	}
+	return &v
+}
+
+func invokeOpen(c *Cluster, duration *uint32, null bool, level *uint8) error {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	w.StartStructure(tlv.Anonymous())
+	if null {
+		w.PutNull(tlv.ContextTag(0))
+	} else if duration != nil {
+		w.PutUint(tlv.ContextTag(0), uint64(*duration))
+	}
+	if level != nil {
+		w.PutUint(tlv.ContextTag(1), uint64(*level))
+	}
+	w.EndContainer()
+
+	req := datamodel.InvokeRequest{
+		Path: datamodel.ConcreteCommandPath{Endpoint: 1, Cluster: ClusterID, Command: CmdOpen},
+	}
+	_, err := c.InvokeCommand(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes())))
+	return err
+}
+
+func TestNew_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want error
+	}{
+		{"minimal", Config{}, nil},
+		{"level", Config{FeatureMap: FeatureLevel, DefaultOpenLevel: u8(50), LevelStep: u8(10)}, nil},
+		{"level without LVL", Config{DefaultOpenLevel: u8(50)}, ErrFeatureNotSupported},
+		{"zero step", Config{FeatureMap: FeatureLevel, LevelStep: u8(0)}, ErrInvalidConfig},
+		{"step too large", Config{FeatureMap: FeatureLevel, LevelStep: u8(51)}, ErrInvalidConfig},
+		{"default level off step", Config{FeatureMap: FeatureLevel, DefaultOpenLevel: u8(55), LevelStep: u8(10)}, ErrInvalidConfig},
+		{"zero duration", Config{DefaultOpenDuration: u32(0)}, ErrInvalidConfig},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg); !errors.Is(err, tt.want) {
+				t.Errorf("New() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttributeList_Features(t *testing.T) {
+	c, _ := New(Config{})
+	for _, attr := range []datamodel.AttributeID{AttrAutoCloseTime, AttrCurrentLevel, AttrValveFault, AttrLevelStep} {
+		if c.hasAttribute(attr) {
+			t.Errorf("attribute 0x%04X should not be present", attr)
+		}
+	}
+
+	c, _ = New(Config{FeatureMap: FeatureTimeSync | FeatureLevel, LevelStep: u8(10), EnableValveFault: true})
+	for _, attr := range []datamodel.AttributeID{AttrAutoCloseTime, AttrCurrentLevel, AttrTargetLevel, AttrValveFault, AttrLevelStep} {
+		if !c.hasAttribute(attr) {
+			t.Errorf("attribute 0x%04X should be present", attr)
+		}
+	}
+}
+
+func TestOpenClose_Instant(t *testing.T) {
+	pub := &mockEventPublisher{}
+	c, _ := New(Config{EndpointID: 1, FeatureMap: FeatureLevel, EventPublisher: pub})
+
+	if err := invokeOpen(c, nil, false, u8(60)); err != nil {
+		t.Fatalf("Open error = %v", err)
+	}
+	if v := readNullable(t, c, AttrCurrentState); v == nil || ValveState(*v) != ValveStateOpen {
+		t.Errorf("CurrentState = %v, want Open", v)
+	}
+	if v := readNullable(t, c, AttrCurrentLevel); v == nil || *v != 60 {
+		t.Errorf("CurrentLevel = %v, want 60", v)
+	}
+	if v := readNullable(t, c, AttrTargetState); v != nil {
+		t.Errorf("TargetState = %d, want null once reached", *v)
+	}
+	if v := readNullable(t, c, AttrOpenDuration); v != nil {
+		t.Errorf("OpenDuration = %d, want null", *v)
+	}
+
+	events := pub.take()
+	if len(events) != 1 || events[0].id != EventValveStateChanged {
+		t.Fatalf("events = %+v, want one ValveStateChanged", events)
+	}
+	e := events[0].data.(ValveStateChangedEvent)
+	if e.ValveState != ValveStateOpen || e.ValveLevel == nil || *e.ValveLevel != 60 {
+		t.Errorf("event = %+v", e)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close error = %v", err)
+	}
+	if s := c.CurrentState(); s == nil || *s != ValveStateClosed {
+		t.Errorf("CurrentState = %v, want Closed", s)
+	}
+}
+
+func TestOpen_LevelValidation(t *testing.T) {
+	c, _ := New(Config{EndpointID: 1, FeatureMap: FeatureLevel, LevelStep: u8(25)})
+
+	for _, level := range []uint8{0, 30, 101} {
+		if err := invokeOpen(c, nil, false, u8(level)); !errors.Is(err, datamodel.ErrConstraintError) {
+			t.Errorf("Open(level %d) error = %v, want ErrConstraintError", level, err)
+		}
+	}
+	if err := invokeOpen(c, u32(0), false, nil); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("Open(duration 0) error = %v, want ErrConstraintError", err)
+	}
+	if err := invokeOpen(c, nil, false, u8(75)); err != nil {
+		t.Errorf("Open(level 75) error = %v", err)
+	}
+}
+
+func TestOpen_Duration(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	d := &testDelegate{}
+	c, _ := New(Config{
+		EndpointID:          1,
+		FeatureMap:          FeatureTimeSync,
+		DefaultOpenDuration: u32(600),
+		Delegate:            d,
+	})
+	c.now = clock.now
+
+	// Field omitted: DefaultOpenDuration applies.
+	if err := invokeOpen(c, nil, false, nil); err != nil {
+		t.Fatalf("Open error = %v", err)
+	}
+	if len(d.opened) != 1 || d.opened[0] != MaxLevel {
+		t.Errorf("HandleOpenValve calls = %v, want [100]", d.opened)
+	}
+	if v := readNullable(t, c, AttrCurrentState); v == nil || ValveState(*v) != ValveStateTransitioning {
+		t.Errorf("CurrentState = %v, want Transitioning", v)
+	}
+	if v := readNullable(t, c, AttrOpenDuration); v == nil || *v != 600 {
+		t.Errorf("OpenDuration = %v, want 600", v)
+	}
+	clock.t = clock.t.Add(100*time.Second + time.Millisecond)
+	if v := readNullable(t, c, AttrRemainingDuration); v == nil || *v != 500 {
+		t.Errorf("RemainingDuration = %v, want 500", v)
+	}
+	want := epochMicros(time.Date(2025, 1, 1, 0, 10, 0, 0, time.UTC))
+	if v := readNullable(t, c, AttrAutoCloseTime); v == nil || *v != want {
+		t.Errorf("AutoCloseTime = %v, want %d", v, want)
+	}
+
+	// Device reports arrival.
+	c.SetCurrentState(ValveStateOpen)
+	if v := readNullable(t, c, AttrTargetState); v != nil {
+		t.Errorf("TargetState = %d, want null once reached", *v)
+	}
+
+	// Null: open until closed.
+	if err := invokeOpen(c, nil, true, nil); err != nil {
+		t.Fatalf("Open error = %v", err)
+	}
+	for _, attr := range []datamodel.AttributeID{AttrOpenDuration, AttrRemainingDuration, AttrAutoCloseTime} {
+		if v := readNullable(t, c, attr); v != nil {
+			t.Errorf("attribute 0x%04X = %d, want null", attr, *v)
+		}
+	}
+}
+
+func TestOpen_AutoClose(t *testing.T) {
+	d := &testDelegate{}
+	c, _ := New(Config{EndpointID: 1, Delegate: d})
+
+	// Expire a short duration directly through the timer path.
+	c.Open(OpenRequest{HasOpenDuration: true, OpenDuration: u32(1)})
+	c.mu.Lock()
+	gen := c.closeGen
+	c.mu.Unlock()
+	c.expireOpen(gen)
+
+	if d.closed != 1 {
+		t.Errorf("HandleCloseValve calls = %d, want 1", d.closed)
+	}
+	if v := readNullable(t, c, AttrTargetState); v == nil || ValveState(*v) != ValveStateClosed {
+		t.Errorf("TargetState = %v, want Closed", v)
+	}
+
+	// A stale timer from a replaced open does nothing.
+	c.Open(OpenRequest{HasOpenDuration: true, OpenDuration: u32(60)})
+	c.expireOpen(gen)
+	if d.closed != 1 {
+		t.Errorf("stale expiry closed the valve")
+	}
+	c.Close()
+}
+
+func TestOpen_DelegateRejects(t *testing.T) {
+	d := &testDelegate{reject: ErrFailureDueToFault}
+	c, _ := New(Config{EndpointID: 1, Delegate: d})
+	version := c.DataVersion()
+
+	if err := invokeOpen(c, nil, false, nil); !errors.Is(err, ErrFailureDueToFault) {
+		t.Errorf("Open error = %v, want ErrFailureDueToFault", err)
+	}
+	if c.DataVersion() != version {
+		t.Error("rejected Open should not change state")
+	}
+}
+
+func TestSetValveFault(t *testing.T) {
+	pub := &mockEventPublisher{}
+	c, _ := New(Config{EndpointID: 1, EnableValveFault: true, EventPublisher: pub})
+
+	c.SetValveFault(ValveFaultBlocked)
+	c.SetValveFault(ValveFaultBlocked | ValveFaultLeaking)
+	c.SetValveFault(ValveFaultLeaking) // cleared only, no event
+	c.SetValveFault(0)
+
+	events := pub.take()
+	if len(events) != 2 {
+		t.Fatalf("events = %+v, want 2 ValveFault events", events)
+	}
+	if e := events[1].data.(ValveFaultEvent); e.ValveFault != ValveFaultBlocked|ValveFaultLeaking {
+		t.Errorf("second event fault = %#x", e.ValveFault)
+	}
+	if v := readNullable(t, c, AttrValveFault); v == nil || *v != 0 {
+		t.Errorf("ValveFault = %v, want 0", v)
+	}
+
+	c, _ = New(Config{EndpointID: 1})
+	if err := c.SetValveFault(ValveFaultBlocked); !errors.Is(err, ErrFeatureNotSupported) {
+		t.Errorf("SetValveFault error = %v, want ErrFeatureNotSupported", err)
+	}
+}
+
+func TestWriteDefaults(t *testing.T) {
+	c, _ := New(Config{EndpointID: 1, FeatureMap: FeatureLevel, DefaultOpenLevel: u8(100), LevelStep: u8(10)})
+
+	write := func(attr datamodel.AttributeID, put func(w *tlv.Writer) error) error {
+		var buf bytes.Buffer
+		put(tlv.NewWriter(&buf))
+		req := datamodel.WriteAttributeRequest{
+			Path: datamodel.ConcreteDataAttributePath{
+				ConcreteAttributePath: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: attr},
+			},
+		}
+		return c.WriteAttribute(context.Background(), req, tlv.NewReader(bytes.NewReader(buf.Bytes())))
+	}
+
+	if err := write(AttrDefaultOpenDuration, func(w *tlv.Writer) error { return w.PutUint(tlv.Anonymous(), 30) }); err != nil {
+		t.Fatalf("write DefaultOpenDuration error = %v", err)
+	}
+	if v := readNullable(t, c, AttrDefaultOpenDuration); v == nil || *v != 30 {
+		t.Errorf("DefaultOpenDuration = %v, want 30", v)
+	}
+	if err := write(AttrDefaultOpenDuration, func(w *tlv.Writer) error { return w.PutNull(tlv.Anonymous()) }); err != nil {
+		t.Fatalf("write null DefaultOpenDuration error = %v", err)
+	}
+	if err := write(AttrDefaultOpenDuration, func(w *tlv.Writer) error { return w.PutUint(tlv.Anonymous(), 0) }); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("write DefaultOpenDuration 0 error = %v, want ErrConstraintError", err)
+	}
+
+	if err := write(AttrDefaultOpenLevel, func(w *tlv.Writer) error { return w.PutUint(tlv.Anonymous(), 45) }); !errors.Is(err, datamodel.ErrConstraintError) {
+		t.Errorf("write DefaultOpenLevel 45 error = %v, want ErrConstraintError", err)
+	}
+	if err := write(AttrDefaultOpenLevel, func(w *tlv.Writer) error { return w.PutUint(tlv.Anonymous(), 40) }); err != nil {
+		t.Fatalf("write DefaultOpenLevel error = %v", err)
+	}
+
+	// Open without a level uses the default.
+	c.Open(OpenRequest{})
+	if l := c.CurrentLevel(); l == nil || *l != 40 {
+		t.Errorf("CurrentLevel = %v, want 40", l)
+	}
+
+	if err := write(AttrCurrentState, func(w *tlv.Writer) error { return w.PutUint(tlv.Anonymous(), 1) }); !errors.Is(err, datamodel.ErrUnsupportedWrite) {
+		t.Errorf("write CurrentState error = %v, want ErrUnsupportedWrite", err)
+	}
+}
@@ -0,0 +1,312 @@
+package valveconfigurationandcontrol
+
+import (
+	"context"
+	"time"
+
+	"github.com/backkem/matter/pkg/clusters"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	var err error
+	switch req.Path.Command {
+	case CmdOpen:
+		var open OpenRequest
+		if open, err = decodeOpen(r); err != nil {
+			return nil, err
+		}
+		err = c.Open(open)
+	case CmdClose:
+		err = c.Close()
+	default:
+		return nil, datamodel.ErrUnsupportedCommand
+	}
+	if err != nil {
+		return nil, err
+	}
+	return clusters.EmptyResponse(), nil
+}
+
+// decodeOpen decodes the Open command fields. An empty payload is
+// accepted since both fields are optional.
+func decodeOpen(r *tlv.Reader) (OpenRequest, error) {
+	var req OpenRequest
+	if r == nil {
+		return req, nil
+	}
+	if err := r.Next(); err != nil {
+		return req, nil
+	}
+	if err := r.EnterContainer(); err != nil {
+		return req, datamodel.ErrInvalidCommand
+	}
+
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		tag := r.Tag()
+		if !tag.IsContext() {
+			continue
+		}
+		switch tag.TagNumber() {
+		case 0: // OpenDuration
+			req.HasOpenDuration = true
+			if r.Type() == tlv.ElementTypeNull {
+				break
+			}
+			v, err := r.Uint()
+			if err != nil {
+				return req, datamodel.ErrInvalidCommand
+			}
+			if v > 0xFFFFFFFF {
+				return req, datamodel.ErrConstraintError
+			}
+			d := uint32(v)
+			req.OpenDuration = &d
+		case 1: // TargetLevel
+			v, err := r.Uint()
+			if err != nil {
+				return req, datamodel.ErrInvalidCommand
+			}
+			if v > uint64(MaxLevel) {
+				return req, datamodel.ErrConstraintError
+			}
+			l := uint8(v)
+			req.TargetLevel = &l
+		}
+	}
+	return req, nil
+}
+
+// Open opens the valve. If an open duration applies, the valve closes
+// automatically when it elapses; opening again restarts the countdown.
+// Returns ErrConstraintError for a zero duration or an invalid level.
+//
+// Spec: Section 4.6.8.1
+func (c *Cluster) Open(req OpenRequest) error {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	if req.OpenDuration != nil && *req.OpenDuration == 0 {
+		return datamodel.ErrConstraintError
+	}
+
+	c.mu.Lock()
+	duration := c.defaultOpenDuration
+	level := c.defaultOpenLevel
+	c.mu.Unlock()
+
+	if req.HasOpenDuration {
+		duration = req.OpenDuration
+	}
+	if c.hasFeature(FeatureLevel) && req.TargetLevel != nil {
+		if !c.validLevel(*req.TargetLevel) {
+			return datamodel.ErrConstraintError
+		}
+		level = *req.TargetLevel
+	}
+	if !c.hasFeature(FeatureLevel) {
+		level = MaxLevel
+	}
+
+	if d := c.config.Delegate; d != nil {
+		if err := d.HandleOpenValve(level); err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	c.stopCloseTimerLocked()
+	c.openDuration = copyUint32(duration)
+	c.autoCloseTime = nil
+	if duration != nil {
+		now := c.now()
+		c.closeAt = now.Add(time.Duration(*duration) * time.Second)
+		if c.hasFeature(FeatureTimeSync) {
+			t := epochMicros(c.closeAt)
+			c.autoCloseTime = &t
+		}
+		gen := c.closeGen
+		c.closeTimer = time.AfterFunc(c.closeAt.Sub(now), func() {
+			c.expireOpen(gen)
+		})
+	}
+	open := ValveStateOpen
+	c.targetState = &open
+	if c.hasFeature(FeatureLevel) {
+		c.targetLevel = &level
+	}
+	there := c.atLocked(ValveStateOpen, level)
+	c.IncrementDataVersion()
+	c.mu.Unlock()
+
+	if c.config.Delegate == nil || there {
+		return c.moveTo(ValveStateOpen, level)
+	}
+	return c.SetCurrentState(ValveStateTransitioning)
+}
+
+// Close closes the valve and cancels any pending auto-close.
+//
+// Spec: Section 4.6.8.2
+func (c *Cluster) Close() error {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+	return c.close()
+}
+
+// close closes the valve. Caller must hold c.cmdMu.
+func (c *Cluster) close() error {
+	if d := c.config.Delegate; d != nil {
+		if err := d.HandleCloseValve(); err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	c.stopCloseTimerLocked()
+	c.openDuration = nil
+	c.autoCloseTime = nil
+	closed := ValveStateClosed
+	c.targetState = &closed
+	if c.hasFeature(FeatureLevel) {
+		zero := uint8(0)
+		c.targetLevel = &zero
+	}
+	there := c.atLocked(ValveStateClosed, 0)
+	c.IncrementDataVersion()
+	c.mu.Unlock()
+
+	if c.config.Delegate == nil || there {
+		return c.moveTo(ValveStateClosed, 0)
+	}
+	return c.SetCurrentState(ValveStateTransitioning)
+}
+
+// expireOpen closes the valve opened as generation gen when its open
+// duration elapses.
+func (c *Cluster) expireOpen(gen uint64) {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	c.mu.Lock()
+	current := c.closeTimer != nil && c.closeGen == gen
+	c.mu.Unlock()
+	if current {
+		c.close()
+	}
+}
+
+// stopCloseTimerLocked cancels a pending auto-close. Caller must hold c.mu.
+func (c *Cluster) stopCloseTimerLocked() {
+	if c.closeTimer != nil {
+		c.closeTimer.Stop()
+		c.closeTimer = nil
+	}
+	c.closeGen++
+	c.closeAt = time.Time{}
+}
+
+// atLocked reports whether the valve already is in state at level.
+// Caller must hold c.mu.
+func (c *Cluster) atLocked(state ValveState, level uint8) bool {
+	if c.currentState == nil || *c.currentState != state {
+		return false
+	}
+	return c.currentLevel == nil || *c.currentLevel == level
+}
+
+// moveTo reports that the valve reached state and level at once.
+func (c *Cluster) moveTo(state ValveState, level uint8) error {
+	if c.hasFeature(FeatureLevel) {
+		if err := c.SetCurrentLevel(level); err != nil {
+			return err
+		}
+	}
+	return c.SetCurrentState(state)
+}
+
+// SetCurrentState reports the valve state from the device. Reaching the
+// target state clears TargetState. A change emits ValveStateChanged.
+func (c *Cluster) SetCurrentState(state ValveState) error {
+	if state > ValveStateTransitioning {
+		return datamodel.ErrConstraintError
+	}
+
+	c.mu.Lock()
+	changed := c.currentState == nil || *c.currentState != state
+	reached := c.targetState != nil && *c.targetState == state
+	if !changed && !reached {
+		c.mu.Unlock()
+		return nil
+	}
+	c.currentState = &state
+	if reached {
+		c.targetState = nil
+	}
+	event := ValveStateChangedEvent{ValveState: state}
+	if c.currentLevel != nil {
+		l := *c.currentLevel
+		event.ValveLevel = &l
+	}
+	c.IncrementDataVersion()
+	c.mu.Unlock()
+
+	if !changed {
+		return nil
+	}
+	return c.emit(EventValveStateChanged, event)
+}
+
+// SetCurrentLevel reports the valve level from the device (LVL only).
+// Reaching the target level clears TargetLevel.
+func (c *Cluster) SetCurrentLevel(level uint8) error {
+	if !c.hasFeature(FeatureLevel) {
+		return ErrFeatureNotSupported
+	}
+	if level > MaxLevel {
+		return datamodel.ErrConstraintError
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	changed := c.currentLevel == nil || *c.currentLevel != level
+	reached := c.targetLevel != nil && *c.targetLevel == level
+	if !changed && !reached {
+		return nil
+	}
+	c.currentLevel = &level
+	if reached {
+		c.targetLevel = nil
+	}
+	c.IncrementDataVersion()
+	return nil
+}
+
+// SetValveFault reports the active faults. A ValveFault event is emitted
+// when new faults appear.
+func (c *Cluster) SetValveFault(fault ValveFault) error {
+	if !c.config.EnableValveFault {
+		return ErrFeatureNotSupported
+	}
+
+	c.mu.Lock()
+	if c.fault == fault {
+		c.mu.Unlock()
+		return nil
+	}
+	raised := fault&^c.fault != 0
+	c.fault = fault
+	c.IncrementDataVersion()
+	c.mu.Unlock()
+
+	if !raised {
+		return nil
+	}
+	return c.emit(EventValveFault, ValveFaultEvent{ValveFault: fault})
+}
@@ -0,0 +1,44 @@
+package valveconfigurationandcontrol
+
+import (
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// ValveStateChangedEvent is the payload of the ValveStateChanged event
+// (Spec 4.6.9.1).
+type ValveStateChangedEvent struct {
+	ValveState ValveState
+	ValveLevel *uint8 // LVL only
+}
+
+// MarshalTLV implements the TLVMarshaler interface.
+func (e ValveStateChangedEvent) MarshalTLV(w *tlv.Writer) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(e.ValveState)); err != nil {
+		return err
+	}
+	if e.ValveLevel != nil {
+		if err := w.PutUint(tlv.ContextTag(1), uint64(*e.ValveLevel)); err != nil {
+			return err
+		}
+	}
+	return w.EndContainer()
+}
+
+// ValveFaultEvent is the payload of the ValveFault event (Spec 4.6.9.2).
+type ValveFaultEvent struct {
+	ValveFault ValveFault
+}
+
+// MarshalTLV implements the TLVMarshaler interface.
+func (e ValveFaultEvent) MarshalTLV(w *tlv.Writer) error {
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return err
+	}
+	if err := w.PutUint(tlv.ContextTag(0), uint64(e.ValveFault)); err != nil {
+		return err
+	}
+	return w.EndContainer()
+}
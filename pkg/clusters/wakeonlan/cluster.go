@@ -0,0 +1,185 @@
+// Package wakeonlan implements the Wake On LAN Cluster (0x0503).
+//
+// The cluster publishes the addresses a client needs to wake a media
+// device from standby with a magic packet. Both attributes are optional
+// and omitted when not configured.
+//
+// Spec Reference: Section 1.12
+//
+// C++ Reference: src/app/clusters/wake-on-lan-server
+package wakeonlan
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Cluster constants.
+const (
+	ClusterID       datamodel.ClusterID = 0x0503
+	ClusterRevision uint16              = 1
+)
+
+// Attribute IDs (Spec 1.12.4).
+const (
+	AttrMACAddress       datamodel.AttributeID = 0x0000
+	AttrLinkLocalAddress datamodel.AttributeID = 0x0001
+)
+
+// Errors returned by New and SetLinkLocalAddress.
+var (
+	ErrInvalidMACAddress       = errors.New("wakeonlan: MAC address must be 48 bits")
+	ErrInvalidLinkLocalAddress = errors.New("wakeonlan: not an IPv6 link-local address")
+)
+
+// Config provides dependencies for the Wake On LAN cluster.
+type Config struct {
+	// EndpointID is the endpoint this cluster belongs to.
+	EndpointID datamodel.EndpointID
+
+	// MACAddress is the 48-bit MAC address of the interface that listens
+	// for magic packets (optional). nil omits the attribute.
+	MACAddress net.HardwareAddr
+
+	// LinkLocalAddress is the IPv6 link-local address of that interface
+	// (optional). nil omits the attribute.
+	LinkLocalAddress net.IP
+}
+
+// Cluster implements the Wake On LAN cluster (0x0503).
+type Cluster struct {
+	*datamodel.ClusterBase
+	config Config
+
+	// Mutable state (protected by mutex)
+	mu               sync.RWMutex
+	linkLocalAddress net.IP
+
+	// Cached attribute list
+	attrList []datamodel.AttributeEntry
+}
+
+// New creates a new Wake On LAN cluster.
+func New(cfg Config) (*Cluster, error) {
+	if cfg.MACAddress != nil && len(cfg.MACAddress) != 6 {
+		return nil, ErrInvalidMACAddress
+	}
+	if cfg.LinkLocalAddress != nil && !isLinkLocal(cfg.LinkLocalAddress) {
+		return nil, ErrInvalidLinkLocalAddress
+	}
+
+	c := &Cluster{
+		ClusterBase:      datamodel.NewClusterBase(ClusterID, cfg.EndpointID, ClusterRevision),
+		config:           cfg,
+		linkLocalAddress: cfg.LinkLocalAddress.To16(),
+	}
+
+	c.attrList = c.buildAttributeList()
+
+	return c, nil
+}
+
+// isLinkLocal returns true if ip is an IPv6 link-local unicast address.
+func isLinkLocal(ip net.IP) bool {
+	return ip.To4() == nil && ip.To16() != nil && ip.IsLinkLocalUnicast()
+}
+
+// buildAttributeList constructs the list of supported attributes.
+func (c *Cluster) buildAttributeList() []datamodel.AttributeEntry {
+	viewPriv := datamodel.PrivilegeView
+
+	var attrs []datamodel.AttributeEntry
+	if c.config.MACAddress != nil {
+		attrs = append(attrs, datamodel.NewReadOnlyAttribute(AttrMACAddress, 0, viewPriv))
+	}
+	if c.config.LinkLocalAddress != nil {
+		attrs = append(attrs, datamodel.NewReadOnlyAttribute(AttrLinkLocalAddress, 0, viewPriv))
+	}
+
+	return datamodel.MergeAttributeLists(attrs)
+}
+
+// AttributeList implements datamodel.Cluster.
+func (c *Cluster) AttributeList() []datamodel.AttributeEntry {
+	return c.attrList
+}
+
+// AcceptedCommandList implements datamodel.Cluster.
+func (c *Cluster) AcceptedCommandList() []datamodel.CommandEntry {
+	return nil
+}
+
+// GeneratedCommandList implements datamodel.Cluster.
+func (c *Cluster) GeneratedCommandList() []datamodel.CommandID {
+	return nil
+}
+
+// ReadAttribute implements datamodel.Cluster.
+func (c *Cluster) ReadAttribute(ctx context.Context, req datamodel.ReadAttributeRequest, w *tlv.Writer) error {
+	handled, err := c.ReadGlobalAttribute(ctx, req.Path.Attribute, w,
+		c.attrList, c.AcceptedCommandList(), c.GeneratedCommandList())
+	if handled || err != nil {
+		return err
+	}
+
+	switch req.Path.Attribute {
+	case AttrMACAddress:
+		if c.config.MACAddress == nil {
+			return datamodel.ErrUnsupportedAttribute
+		}
+		return w.PutString(tlv.Anonymous(), MACAddressString(c.config.MACAddress))
+	case AttrLinkLocalAddress:
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		if c.linkLocalAddress == nil {
+			return datamodel.ErrUnsupportedAttribute
+		}
+		return w.PutBytes(tlv.Anonymous(), c.linkLocalAddress)
+	default:
+		return datamodel.ErrUnsupportedAttribute
+	}
+}
+
+// WriteAttribute implements datamodel.Cluster.
+func (c *Cluster) WriteAttribute(ctx context.Context, req datamodel.WriteAttributeRequest, r *tlv.Reader) error {
+	return datamodel.ErrUnsupportedWrite
+}
+
+// InvokeCommand implements datamodel.Cluster.
+func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+	return nil, datamodel.ErrUnsupportedCommand
+}
+
+// SetLinkLocalAddress updates LinkLocalAddress, e.g. after the interface
+// address changes. The attribute must have been configured in New.
+func (c *Cluster) SetLinkLocalAddress(ip net.IP) error {
+	if c.config.LinkLocalAddress == nil {
+		return datamodel.ErrUnsupportedAttribute
+	}
+	if !isLinkLocal(ip) {
+		return ErrInvalidLinkLocalAddress
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.linkLocalAddress.Equal(ip) {
+		return nil
+	}
+	c.linkLocalAddress = ip.To16()
+	c.IncrementDataVersion()
+	return nil
+}
+
+// MACAddressString formats a MAC address as the attribute encodes it:
+// upper-case hex without separators, e.g. "12345678ABCD".
+func MACAddressString(mac net.HardwareAddr) string {
+	return strings.ToUpper(hex.EncodeToString(mac))
+}
@@ -0,0 +1,110 @@
+package wakeonlan
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+func readAttr(t *testing.T, c *Cluster, attr datamodel.AttributeID) (*tlv.Reader, error) {
+	t.Helper()
+	var buf bytes.Buffer
+	req := datamodel.ReadAttributeRequest{
+		Path: datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: ClusterID, Attribute: attr},
+	}
+	if err := c.ReadAttribute(context.Background(), req, tlv.NewWriter(&buf)); err != nil {
+		return nil, err
+	}
+	r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+	if err := r.Next(); err != nil {
+		t.Fatalf("Next error = %v", err)
+	}
+	return r, nil
+}
+
+func TestNew_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want error
+	}{
+		{"empty", Config{}, nil},
+		{"valid", Config{
+			MACAddress:       net.HardwareAddr{0x12, 0x34, 0x56, 0x78, 0xAB, 0xCD},
+			LinkLocalAddress: net.ParseIP("fe80::1"),
+		}, nil},
+		{"EUI-64 MAC", Config{MACAddress: make(net.HardwareAddr, 8)}, ErrInvalidMACAddress},
+		{"global address", Config{LinkLocalAddress: net.ParseIP("2001:db8::1")}, ErrInvalidLinkLocalAddress},
+		{"IPv4 link-local", Config{LinkLocalAddress: net.ParseIP("169.254.0.1")}, ErrInvalidLinkLocalAddress},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg); !errors.Is(err, tt.want) {
+				t.Errorf("New() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadAttributes(t *testing.T) {
+	c, err := New(Config{
+		EndpointID:       1,
+		MACAddress:       net.HardwareAddr{0x12, 0x34, 0x56, 0x78, 0xAB, 0xCD},
+		LinkLocalAddress: net.ParseIP("fe80::1"),
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	r, err := readAttr(t, c, AttrMACAddress)
+	if err != nil {
+		t.Fatalf("read MACAddress error = %v", err)
+	}
+	if s, _ := r.String(); s != "12345678ABCD" {
+		t.Errorf("MACAddress = %q, want 12345678ABCD", s)
+	}
+
+	r, err = readAttr(t, c, AttrLinkLocalAddress)
+	if err != nil {
+		t.Fatalf("read LinkLocalAddress error = %v", err)
+	}
+	if b, _ := r.Bytes(); !net.IP(b).Equal(net.ParseIP("fe80::1")) || len(b) != 16 {
+		t.Errorf("LinkLocalAddress = %x", b)
+	}
+}
+
+func TestOptionalAttributes(t *testing.T) {
+	c, _ := New(Config{EndpointID: 1})
+	if len(c.AttributeList()) != len(datamodel.MergeAttributeLists(nil)) {
+		t.Error("optional attributes listed without configuration")
+	}
+	if _, err := readAttr(t, c, AttrMACAddress); !errors.Is(err, datamodel.ErrUnsupportedAttribute) {
+		t.Errorf("read MACAddress error = %v, want ErrUnsupportedAttribute", err)
+	}
+	if err := c.SetLinkLocalAddress(net.ParseIP("fe80::2")); !errors.Is(err, datamodel.ErrUnsupportedAttribute) {
+		t.Errorf("SetLinkLocalAddress error = %v, want ErrUnsupportedAttribute", err)
+	}
+}
+
+func TestSetLinkLocalAddress(t *testing.T) {
+	c, _ := New(Config{EndpointID: 1, LinkLocalAddress: net.ParseIP("fe80::1")})
+	v := c.DataVersion()
+
+	if err := c.SetLinkLocalAddress(net.ParseIP("2001:db8::1")); !errors.Is(err, ErrInvalidLinkLocalAddress) {
+		t.Errorf("global address error = %v, want ErrInvalidLinkLocalAddress", err)
+	}
+	if err := c.SetLinkLocalAddress(net.ParseIP("fe80::1")); err != nil || c.DataVersion() != v {
+		t.Errorf("unchanged address: err = %v, version bumped = %v", err, c.DataVersion() != v)
+	}
+	if err := c.SetLinkLocalAddress(net.ParseIP("fe80::2")); err != nil {
+		t.Fatalf("SetLinkLocalAddress error = %v", err)
+	}
+	if c.DataVersion() == v {
+		t.Error("DataVersion not incremented")
+	}
+}
@@ -28,22 +28,31 @@ type ProviderDelegate interface {
 	OnSessionEnded(ctx context.Context, sessionID uint16, reason WebRTCEndReasonEnum) error
 }
 
-// RequestorDelegate is implemented by the application layer to handle
-// WebRTC signaling events on the Requestor (controller) side.
-type RequestorDelegate interface {
-	// OnOffer is called when an Offer command is received from the Provider.
-	// This happens in the SolicitOffer flow when the Provider sends its offer.
-	OnOffer(ctx context.Context, sessionID uint16, sdp string, iceServers []ICEServerStruct, iceTransportPolicy string) error
-
-	// OnAnswer is called when an Answer command is received from the Provider.
-	// This happens after the Requestor sent a ProvideOffer.
-	OnAnswer(ctx context.Context, sessionID uint16, sdp string) error
-
-	// OnICECandidates is called when ICECandidates command is received.
-	OnICECandidates(ctx context.Context, sessionID uint16, candidates []ICECandidateStruct) error
+// JSEPDelegate is implemented by the application layer to run the JSEP
+// (RFC 9429) offer/answer exchange on the Requestor (controller) side.
+//
+// The delegate owns the PeerConnection of each session. The Requestor
+// cluster validates incoming commands against its session table and hands
+// the remote descriptions and candidates to the delegate; answers returned
+// by OnOffer are sent back to the Provider with ProvideAnswer.
+type JSEPDelegate interface {
+	// OnOffer applies a remote SDP offer and returns the local answer.
+	// It is called for the SolicitOffer flow and, with
+	// OfferRequest.Renegotiation set, when the Provider renegotiates an
+	// established session (e.g. to add or change streams).
+	OnOffer(ctx context.Context, session *WebRTCSessionStruct, req *OfferRequest) (answerSDP string, err error)
+
+	// OnAnswer applies the remote SDP answer to a local offer sent with
+	// ProvideOffer.
+	OnAnswer(ctx context.Context, session *WebRTCSessionStruct, sdp string) error
+
+	// OnICECandidates adds remote ICE candidates to the session.
+	OnICECandidates(ctx context.Context, session *WebRTCSessionStruct, candidates []ICECandidateStruct) error
 
-	// OnEnd is called when End command is received from the Provider.
-	OnEnd(ctx context.Context, sessionID uint16, reason WebRTCEndReasonEnum) error
+	// OnEnd is called when a session ended, either by an End command from
+	// the Provider or by Requestor.EndSession. The delegate should close
+	// the PeerConnection.
+	OnEnd(ctx context.Context, session *WebRTCSessionStruct, reason WebRTCEndReasonEnum)
 }
 
 // SolicitOfferRequest contains the parameters for a SolicitOffer command.
@@ -73,6 +82,19 @@ type ProvideOfferRequest struct {
 	SFrameConfig        *SFrameStruct
 }
 
+// OfferRequest contains the parameters of an Offer command received by the
+// Requestor.
+type OfferRequest struct {
+	SessionID          uint16
+	SDP                string
+	ICEServers         []ICEServerStruct
+	ICETransportPolicy string
+
+	// Renegotiation is true if the session already completed an
+	// offer/answer exchange.
+	Renegotiation bool
+}
+
 // ProvideOfferResult is returned by the delegate after processing an offer.
 type ProvideOfferResult struct {
 	AnswerSDP     string  // SDP answer to send back
@@ -0,0 +1,470 @@
+package pionjsep
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	webrtctransport "github.com/backkem/matter/pkg/clusters/webrtc-transport"
+	"github.com/pion/logging"
+	"github.com/pion/webrtc/v4"
+)
+
+// DefaultDataChannelLabel is the label of the data channel created in
+// data-channel-only mode.
+const DefaultDataChannelLabel = "matter"
+
+// Errors returned by the Delegate.
+var (
+	ErrUnknownSession = errors.New("pionjsep: no peer connection for session")
+	ErrSessionExists  = errors.New("pionjsep: session already has a peer connection")
+	ErrOfferAttached  = errors.New("pionjsep: offer already attached or closed")
+)
+
+// Config configures a Delegate.
+type Config struct {
+	// Configuration is the base PeerConnection configuration. ICE servers
+	// and transport policy received in an Offer command are added to it.
+	Configuration webrtc.Configuration
+
+	// API is used to create PeerConnections.
+	// If nil, a default API with the default codecs is used.
+	API *webrtc.API
+
+	// DataChannelOnly makes local offers carry a single data channel and
+	// no media sections.
+	DataChannelOnly bool
+
+	// DataChannelLabel is the label of the data channel in data-channel-only
+	// mode. Defaults to DefaultDataChannelLabel.
+	DataChannelLabel string
+
+	// OnTrack is called when a remote media track arrives on a session.
+	OnTrack func(sessionID uint16, track *webrtc.TrackRemote, receiver *webrtc.RTPReceiver)
+
+	// OnDataChannel is called for every data channel of a session, both
+	// the local one created in data-channel-only mode and channels opened
+	// by the remote peer.
+	OnDataChannel func(sessionID uint16, dc *webrtc.DataChannel)
+
+	// OnLocalCandidates enables trickle ICE. It is called with each
+	// gathered local candidate, typically to forward it with
+	// Requestor.SendICECandidates. If nil, candidates are gathered up front
+	// and embedded in the SDP.
+	OnLocalCandidates func(sessionID uint16, candidates []webrtctransport.ICECandidateStruct)
+
+	// OnConnectionStateChange is called when a session's PeerConnection
+	// changes state.
+	OnConnectionStateChange func(sessionID uint16, state webrtc.PeerConnectionState)
+
+	// LoggerFactory is the factory for creating loggers.
+	// If nil, logging is disabled.
+	LoggerFactory logging.LoggerFactory
+}
+
+// Delegate implements webrtctransport.JSEPDelegate with pion/webrtc.
+// It is safe for concurrent use.
+type Delegate struct {
+	config Config
+	api    *webrtc.API
+	log    logging.LeveledLogger
+
+	mu    sync.Mutex
+	peers map[uint16]*peer
+}
+
+// peer is the PeerConnection of one session.
+type peer struct {
+	pc      *webrtc.PeerConnection
+	localDC *webrtc.DataChannel
+
+	mu        sync.Mutex
+	sessionID uint16
+	attached  bool
+	pending   []webrtctransport.ICECandidateStruct // local candidates gathered before attach
+}
+
+// Offer is a local offer created for a ProvideOffer command.
+type Offer struct {
+	// SDP is the local SDP offer.
+	SDP string
+
+	peer *peer
+}
+
+// Close releases the PeerConnection of an offer that was never attached,
+// e.g. because the ProvideOffer command failed.
+func (o *Offer) Close() error {
+	o.peer.mu.Lock()
+	attached := o.peer.attached
+	o.peer.mu.Unlock()
+	if attached {
+		return ErrOfferAttached
+	}
+	return o.peer.pc.Close()
+}
+
+// Compile-time interface check.
+var _ webrtctransport.JSEPDelegate = (*Delegate)(nil)
+
+// New creates a new Delegate.
+func New(config Config) *Delegate {
+	if config.DataChannelLabel == "" {
+		config.DataChannelLabel = DefaultDataChannelLabel
+	}
+
+	api := config.API
+	if api == nil {
+		api = webrtc.NewAPI()
+	}
+
+	var log logging.LeveledLogger
+	if config.LoggerFactory != nil {
+		log = config.LoggerFactory.NewLogger("pionjsep")
+	}
+
+	return &Delegate{
+		config: config,
+		api:    api,
+		log:    log,
+		peers:  make(map[uint16]*peer),
+	}
+}
+
+// OnOffer implements webrtctransport.JSEPDelegate.
+func (d *Delegate) OnOffer(ctx context.Context, session *webrtctransport.WebRTCSessionStruct, req *webrtctransport.OfferRequest) (string, error) {
+	d.mu.Lock()
+	p := d.peers[session.ID]
+	if p != nil && !req.Renegotiation {
+		d.mu.Unlock()
+		return "", ErrSessionExists
+	}
+	if p == nil && req.Renegotiation {
+		d.mu.Unlock()
+		return "", ErrUnknownSession
+	}
+	created := false
+	if p == nil {
+		var err error
+		p, err = d.newPeer(d.configuration(req.ICEServers, req.ICETransportPolicy))
+		if err != nil {
+			d.mu.Unlock()
+			return "", err
+		}
+		p.sessionID = session.ID
+		p.attached = true
+		d.peers[session.ID] = p
+		created = true
+	}
+	d.mu.Unlock()
+
+	sdp, err := d.answer(ctx, p.pc, req.SDP)
+	if err != nil {
+		if created {
+			d.removePeer(session.ID)
+		}
+		return "", err
+	}
+	return sdp, nil
+}
+
+// OnAnswer implements webrtctransport.JSEPDelegate.
+func (d *Delegate) OnAnswer(ctx context.Context, session *webrtctransport.WebRTCSessionStruct, sdp string) error {
+	p := d.peer(session.ID)
+	if p == nil {
+		return ErrUnknownSession
+	}
+	return p.pc.SetRemoteDescription(webrtc.SessionDescription{
+		Type: webrtc.SDPTypeAnswer,
+		SDP:  sdp,
+	})
+}
+
+// OnICECandidates implements webrtctransport.JSEPDelegate.
+func (d *Delegate) OnICECandidates(ctx context.Context, session *webrtctransport.WebRTCSessionStruct, candidates []webrtctransport.ICECandidateStruct) error {
+	p := d.peer(session.ID)
+	if p == nil {
+		return ErrUnknownSession
+	}
+	for _, c := range candidates {
+		if err := p.pc.AddICECandidate(webrtc.ICECandidateInit{
+			Candidate:     c.Candidate,
+			SDPMid:        c.SDPMid,
+			SDPMLineIndex: c.SDPMLineIndex,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OnEnd implements webrtctransport.JSEPDelegate.
+func (d *Delegate) OnEnd(ctx context.Context, session *webrtctransport.WebRTCSessionStruct, reason webrtctransport.WebRTCEndReasonEnum) {
+	if d.log != nil {
+		d.log.Debugf("session %d ended: %v", session.ID, reason)
+	}
+	d.removePeer(session.ID)
+}
+
+// CreateOffer creates a PeerConnection and its local offer for a
+// ProvideOffer command. In data-channel-only mode the offer carries a data
+// channel; otherwise it receives one video and one audio stream.
+//
+// Once the ProvideOfferResponse assigns a session ID, call Attach before
+// adding the session to the Requestor. If the command fails, call
+// Offer.Close.
+func (d *Delegate) CreateOffer(ctx context.Context) (*Offer, error) {
+	p, err := d.newPeer(d.config.Configuration)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.addLocalMedia(p); err != nil {
+		p.pc.Close()
+		return nil, err
+	}
+
+	sdp, err := d.offer(ctx, p.pc)
+	if err != nil {
+		p.pc.Close()
+		return nil, err
+	}
+	return &Offer{SDP: sdp, peer: p}, nil
+}
+
+// Attach binds an offer created with CreateOffer to a session ID.
+// Local candidates gathered so far are delivered to OnLocalCandidates.
+func (d *Delegate) Attach(sessionID uint16, offer *Offer) error {
+	p := offer.peer
+
+	d.mu.Lock()
+	if _, ok := d.peers[sessionID]; ok {
+		d.mu.Unlock()
+		return ErrSessionExists
+	}
+	p.mu.Lock()
+	if p.attached {
+		p.mu.Unlock()
+		d.mu.Unlock()
+		return ErrOfferAttached
+	}
+	p.sessionID = sessionID
+	p.attached = true
+	pending := p.pending
+	p.pending = nil
+	p.mu.Unlock()
+	d.peers[sessionID] = p
+	d.mu.Unlock()
+
+	if p.localDC != nil && d.config.OnDataChannel != nil {
+		d.config.OnDataChannel(sessionID, p.localDC)
+	}
+	if len(pending) > 0 && d.config.OnLocalCandidates != nil {
+		d.config.OnLocalCandidates(sessionID, pending)
+	}
+	return nil
+}
+
+// Renegotiate creates a new local offer on an established session, to be
+// sent with a ProvideOffer command carrying the session ID.
+func (d *Delegate) Renegotiate(ctx context.Context, sessionID uint16) (string, error) {
+	p := d.peer(sessionID)
+	if p == nil {
+		return "", ErrUnknownSession
+	}
+	return d.offer(ctx, p.pc)
+}
+
+// PeerConnection returns the PeerConnection of a session, or nil.
+func (d *Delegate) PeerConnection(sessionID uint16) *webrtc.PeerConnection {
+	p := d.peer(sessionID)
+	if p == nil {
+		return nil
+	}
+	return p.pc
+}
+
+// Close closes all PeerConnections.
+func (d *Delegate) Close() error {
+	d.mu.Lock()
+	peers := d.peers
+	d.peers = make(map[uint16]*peer)
+	d.mu.Unlock()
+
+	var errs []error
+	for _, p := range peers {
+		if err := p.pc.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (d *Delegate) peer(sessionID uint16) *peer {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.peers[sessionID]
+}
+
+func (d *Delegate) removePeer(sessionID uint16) {
+	d.mu.Lock()
+	p := d.peers[sessionID]
+	delete(d.peers, sessionID)
+	d.mu.Unlock()
+
+	if p != nil {
+		if err := p.pc.Close(); err != nil && d.log != nil {
+			d.log.Warnf("session %d: close peer connection: %v", sessionID, err)
+		}
+	}
+}
+
+// configuration returns the base configuration extended with the ICE
+// parameters of an Offer command.
+func (d *Delegate) configuration(servers []webrtctransport.ICEServerStruct, policy string) webrtc.Configuration {
+	cfg := d.config.Configuration
+	cfg.ICEServers = append([]webrtc.ICEServer(nil), cfg.ICEServers...)
+	for _, s := range servers {
+		server := webrtc.ICEServer{URLs: s.URLs}
+		if s.Username != nil {
+			server.Username = *s.Username
+		}
+		if s.Credential != nil {
+			server.Credential = *s.Credential
+		}
+		cfg.ICEServers = append(cfg.ICEServers, server)
+	}
+	if policy != "" {
+		cfg.ICETransportPolicy = webrtc.NewICETransportPolicy(policy)
+	}
+	return cfg
+}
+
+// newPeer creates an unattached PeerConnection with the session callbacks
+// installed. Callbacks fire only once the peer is attached to a session.
+func (d *Delegate) newPeer(cfg webrtc.Configuration) (*peer, error) {
+	pc, err := d.api.NewPeerConnection(cfg)
+	if err != nil {
+		return nil, err
+	}
+	p := &peer{pc: pc}
+
+	pc.OnICECandidate(func(c *webrtc.ICECandidate) {
+		if c == nil || d.config.OnLocalCandidates == nil {
+			return
+		}
+		init := c.ToJSON()
+		cand := webrtctransport.ICECandidateStruct{
+			Candidate:     init.Candidate,
+			SDPMid:        init.SDPMid,
+			SDPMLineIndex: init.SDPMLineIndex,
+		}
+		p.mu.Lock()
+		if !p.attached {
+			p.pending = append(p.pending, cand)
+			p.mu.Unlock()
+			return
+		}
+		id := p.sessionID
+		p.mu.Unlock()
+		d.config.OnLocalCandidates(id, []webrtctransport.ICECandidateStruct{cand})
+	})
+
+	pc.OnTrack(func(track *webrtc.TrackRemote, receiver *webrtc.RTPReceiver) {
+		if id, ok := p.session(); ok && d.config.OnTrack != nil {
+			d.config.OnTrack(id, track, receiver)
+		}
+	})
+
+	pc.OnDataChannel(func(dc *webrtc.DataChannel) {
+		if id, ok := p.session(); ok && d.config.OnDataChannel != nil {
+			d.config.OnDataChannel(id, dc)
+		}
+	})
+
+	pc.OnConnectionStateChange(func(state webrtc.PeerConnectionState) {
+		id, ok := p.session()
+		if !ok {
+			return
+		}
+		if d.log != nil {
+			d.log.Debugf("session %d: connection state %s", id, state)
+		}
+		if d.config.OnConnectionStateChange != nil {
+			d.config.OnConnectionStateChange(id, state)
+		}
+	})
+
+	return p, nil
+}
+
+// addLocalMedia adds the sections of a local offer.
+func (d *Delegate) addLocalMedia(p *peer) error {
+	if d.config.DataChannelOnly {
+		dc, err := p.pc.CreateDataChannel(d.config.DataChannelLabel, nil)
+		if err != nil {
+			return err
+		}
+		p.localDC = dc
+		return nil
+	}
+
+	recvOnly := webrtc.RTPTransceiverInit{Direction: webrtc.RTPTransceiverDirectionRecvonly}
+	for _, kind := range []webrtc.RTPCodecType{webrtc.RTPCodecTypeVideo, webrtc.RTPCodecTypeAudio} {
+		if _, err := p.pc.AddTransceiverFromKind(kind, recvOnly); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// offer creates and applies a local offer and returns its SDP.
+func (d *Delegate) offer(ctx context.Context, pc *webrtc.PeerConnection) (string, error) {
+	offer, err := pc.CreateOffer(nil)
+	if err != nil {
+		return "", err
+	}
+	return d.setLocal(ctx, pc, offer)
+}
+
+// answer applies a remote offer and returns the SDP of the local answer.
+func (d *Delegate) answer(ctx context.Context, pc *webrtc.PeerConnection, sdp string) (string, error) {
+	if err := pc.SetRemoteDescription(webrtc.SessionDescription{
+		Type: webrtc.SDPTypeOffer,
+		SDP:  sdp,
+	}); err != nil {
+		return "", err
+	}
+	answer, err := pc.CreateAnswer(nil)
+	if err != nil {
+		return "", err
+	}
+	return d.setLocal(ctx, pc, answer)
+}
+
+// setLocal applies a local description. Without trickle ICE it waits for
+// gathering to finish so the returned SDP contains all candidates.
+func (d *Delegate) setLocal(ctx context.Context, pc *webrtc.PeerConnection, desc webrtc.SessionDescription) (string, error) {
+	var gathered <-chan struct{}
+	if d.config.OnLocalCandidates == nil {
+		gathered = webrtc.GatheringCompletePromise(pc)
+	}
+	if err := pc.SetLocalDescription(desc); err != nil {
+		return "", err
+	}
+	if gathered != nil {
+		select {
+		case <-gathered:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	return pc.LocalDescription().SDP, nil
+}
+
+// session returns the session ID of an attached peer.
+func (p *peer) session() (uint16, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.sessionID, p.attached
+}
@@ -0,0 +1,271 @@
+package pionjsep
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	webrtctransport "github.com/backkem/matter/pkg/clusters/webrtc-transport"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+	"github.com/pion/webrtc/v4"
+)
+
+const (
+	testPeerNodeID = 100
+	testFabric     = 1
+)
+
+// invoke sends a Requestor command as the test Provider.
+func invoke(t *testing.T, r *webrtctransport.Requestor, cmd uint32, payload []byte, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("encode command 0x%02x: %v", cmd, err)
+	}
+	req := datamodel.InvokeRequest{
+		Path: datamodel.ConcreteCommandPath{
+			Endpoint: 1,
+			Cluster:  datamodel.ClusterID(webrtctransport.RequestorClusterID),
+			Command:  datamodel.CommandID(cmd),
+		},
+		Subject: &datamodel.SubjectDescriptor{FabricIndex: testFabric, NodeID: testPeerNodeID},
+	}
+	if _, err := r.InvokeCommand(context.Background(), req, tlv.NewReader(bytes.NewReader(payload))); err != nil {
+		t.Fatalf("invoke command 0x%02x: %v", cmd, err)
+	}
+}
+
+// offerSDP creates a local offer on pc and returns it after ICE gathering.
+func offerSDP(t *testing.T, pc *webrtc.PeerConnection) string {
+	t.Helper()
+	offer, err := pc.CreateOffer(nil)
+	if err != nil {
+		t.Fatalf("CreateOffer: %v", err)
+	}
+	gathered := webrtc.GatheringCompletePromise(pc)
+	if err := pc.SetLocalDescription(offer); err != nil {
+		t.Fatalf("SetLocalDescription: %v", err)
+	}
+	<-gathered
+	return pc.LocalDescription().SDP
+}
+
+func setRemote(t *testing.T, pc *webrtc.PeerConnection, typ webrtc.SDPType, sdp string) {
+	t.Helper()
+	if err := pc.SetRemoteDescription(webrtc.SessionDescription{Type: typ, SDP: sdp}); err != nil {
+		t.Fatalf("SetRemoteDescription(%s): %v", typ, err)
+	}
+}
+
+func waitFor[T any](t *testing.T, ch <-chan T, what string) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(10 * time.Second):
+		t.Fatalf("timeout waiting for %s", what)
+		var zero T
+		return zero
+	}
+}
+
+func newSession(id uint16) *webrtctransport.WebRTCSessionStruct {
+	return &webrtctransport.WebRTCSessionStruct{
+		ID:          id,
+		PeerNodeID:  testPeerNodeID,
+		StreamUsage: webrtctransport.StreamUsageLiveView,
+		FabricIndex: testFabric,
+	}
+}
+
+func TestDelegate_ProvideOfferDataChannel(t *testing.T) {
+	ctx := context.Background()
+
+	received := make(chan string, 1)
+	d := New(Config{
+		DataChannelOnly: true,
+		OnDataChannel: func(sessionID uint16, dc *webrtc.DataChannel) {
+			if sessionID != 5 {
+				t.Errorf("OnDataChannel session = %d, want 5", sessionID)
+			}
+			dc.OnMessage(func(msg webrtc.DataChannelMessage) {
+				received <- string(msg.Data)
+			})
+		},
+	})
+	defer d.Close()
+
+	r := webrtctransport.NewRequestor(webrtctransport.RequestorConfig{
+		EndpointID: 1,
+		Delegate:   d,
+	})
+
+	offer, err := d.CreateOffer(ctx)
+	if err != nil {
+		t.Fatalf("CreateOffer: %v", err)
+	}
+
+	// Provider answers the ProvideOffer and assigns session 5.
+	provider, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+	if err != nil {
+		t.Fatalf("NewPeerConnection: %v", err)
+	}
+	defer provider.Close()
+
+	opened := make(chan *webrtc.DataChannel, 1)
+	provider.OnDataChannel(func(dc *webrtc.DataChannel) {
+		if dc.Label() != DefaultDataChannelLabel {
+			t.Errorf("data channel label = %q, want %q", dc.Label(), DefaultDataChannelLabel)
+		}
+		dc.OnOpen(func() { opened <- dc })
+	})
+	setRemote(t, provider, webrtc.SDPTypeOffer, offer.SDP)
+	answer, err := provider.CreateAnswer(nil)
+	if err != nil {
+		t.Fatalf("CreateAnswer: %v", err)
+	}
+	gathered := webrtc.GatheringCompletePromise(provider)
+	if err := provider.SetLocalDescription(answer); err != nil {
+		t.Fatalf("SetLocalDescription: %v", err)
+	}
+	<-gathered
+
+	if err := d.Attach(5, offer); err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+	if err := r.AddSession(newSession(5)); err != nil {
+		t.Fatalf("AddSession: %v", err)
+	}
+
+	payload, err := webrtctransport.EncodeAnswer(5, provider.LocalDescription().SDP)
+	invoke(t, r, webrtctransport.CmdAnswer, payload, err)
+
+	dc := waitFor(t, opened, "provider data channel")
+	if err := dc.SendText("hello"); err != nil {
+		t.Fatalf("SendText: %v", err)
+	}
+	if msg := waitFor(t, received, "message"); msg != "hello" {
+		t.Errorf("received %q, want %q", msg, "hello")
+	}
+
+	// End closes the PeerConnection.
+	pc := d.PeerConnection(5)
+	payload, err = webrtctransport.EncodeEnd(5, webrtctransport.WebRTCEndReasonUserHangup)
+	invoke(t, r, webrtctransport.CmdEnd, payload, err)
+	if d.PeerConnection(5) != nil {
+		t.Error("PeerConnection should be removed after End")
+	}
+	if pc.ConnectionState() != webrtc.PeerConnectionStateClosed {
+		t.Errorf("connection state = %s, want closed", pc.ConnectionState())
+	}
+}
+
+func TestDelegate_OfferRenegotiation(t *testing.T) {
+	channels := make(chan string, 2)
+	d := New(Config{
+		OnDataChannel: func(sessionID uint16, dc *webrtc.DataChannel) {
+			channels <- dc.Label()
+		},
+	})
+	defer d.Close()
+
+	answers := make(chan string, 2)
+	r := webrtctransport.NewRequestor(webrtctransport.RequestorConfig{
+		EndpointID: 1,
+		Delegate:   d,
+		OnSendAnswer: func(ctx context.Context, session *webrtctransport.WebRTCSessionStruct, sdp string) error {
+			answers <- sdp
+			return nil
+		},
+	})
+	if err := r.AddSession(newSession(7)); err != nil {
+		t.Fatalf("AddSession: %v", err)
+	}
+
+	provider, err := webrtc.NewPeerConnection(webrtc.Configuration{})
+	if err != nil {
+		t.Fatalf("NewPeerConnection: %v", err)
+	}
+	defer provider.Close()
+
+	// Initial offer from the Provider (SolicitOffer flow).
+	if _, err := provider.CreateDataChannel("first", nil); err != nil {
+		t.Fatalf("CreateDataChannel: %v", err)
+	}
+	payload, err := webrtctransport.EncodeOffer(7, offerSDP(t, provider), nil, "")
+	invoke(t, r, webrtctransport.CmdOffer, payload, err)
+	setRemote(t, provider, webrtc.SDPTypeAnswer, waitFor(t, answers, "answer"))
+
+	if label := waitFor(t, channels, "first data channel"); label != "first" {
+		t.Errorf("data channel = %q, want %q", label, "first")
+	}
+	pc := d.PeerConnection(7)
+
+	// Renegotiation adds a second channel on the same PeerConnection.
+	if _, err := provider.CreateDataChannel("second", nil); err != nil {
+		t.Fatalf("CreateDataChannel: %v", err)
+	}
+	payload, err = webrtctransport.EncodeOffer(7, offerSDP(t, provider), nil, "")
+	invoke(t, r, webrtctransport.CmdOffer, payload, err)
+	setRemote(t, provider, webrtc.SDPTypeAnswer, waitFor(t, answers, "renegotiation answer"))
+
+	if label := waitFor(t, channels, "second data channel"); label != "second" {
+		t.Errorf("data channel = %q, want %q", label, "second")
+	}
+	if d.PeerConnection(7) != pc {
+		t.Error("renegotiation should reuse the PeerConnection")
+	}
+}
+
+func TestDelegate_TrickleBeforeAttach(t *testing.T) {
+	candidates := make(chan []webrtctransport.ICECandidateStruct, 16)
+	d := New(Config{
+		DataChannelOnly: true,
+		OnLocalCandidates: func(sessionID uint16, c []webrtctransport.ICECandidateStruct) {
+			if sessionID != 3 {
+				t.Errorf("OnLocalCandidates session = %d, want 3", sessionID)
+			}
+			candidates <- c
+		},
+	})
+	defer d.Close()
+
+	offer, err := d.CreateOffer(context.Background())
+	if err != nil {
+		t.Fatalf("CreateOffer: %v", err)
+	}
+
+	// Let gathering run while the offer is unattached.
+	time.Sleep(200 * time.Millisecond)
+
+	if err := d.Attach(3, offer); err != nil {
+		t.Fatalf("Attach: %v", err)
+	}
+	if c := waitFor(t, candidates, "local candidates"); len(c) == 0 || c[0].Candidate == "" {
+		t.Errorf("unexpected candidates %+v", c)
+	}
+
+	if err := d.Attach(3, offer); err != ErrSessionExists {
+		t.Errorf("second Attach: expected ErrSessionExists, got %v", err)
+	}
+	if err := offer.Close(); err != ErrOfferAttached {
+		t.Errorf("Close after Attach: expected ErrOfferAttached, got %v", err)
+	}
+}
+
+func TestDelegate_UnknownSession(t *testing.T) {
+	d := New(Config{})
+	ctx := context.Background()
+	s := newSession(9)
+
+	if err := d.OnAnswer(ctx, s, "v=0"); err != ErrUnknownSession {
+		t.Errorf("OnAnswer: expected ErrUnknownSession, got %v", err)
+	}
+	if _, err := d.OnOffer(ctx, s, &webrtctransport.OfferRequest{SessionID: 9, Renegotiation: true}); err != ErrUnknownSession {
+		t.Errorf("OnOffer renegotiation: expected ErrUnknownSession, got %v", err)
+	}
+	if _, err := d.Renegotiate(ctx, 9); err != ErrUnknownSession {
+		t.Errorf("Renegotiate: expected ErrUnknownSession, got %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+// Package pionjsep provides a JSEPDelegate for the WebRTC Transport
+// Requestor cluster built on pion/webrtc.
+//
+// The Delegate owns one PeerConnection per Matter WebRTC session and runs the
+// offer/answer exchange, ICE and DTLS for it, so a controller gets a working
+// media pipeline by wiring the Delegate into a Requestor:
+//
+//	peer := pionjsep.New(pionjsep.Config{
+//	    OnTrack: func(sessionID uint16, track *webrtc.TrackRemote, _ *webrtc.RTPReceiver) { ... },
+//	})
+//	requestor := webrtctransport.NewRequestor(webrtctransport.RequestorConfig{
+//	    EndpointID:   1,
+//	    Delegate:     peer,
+//	    OnSendAnswer: sendProvideAnswer,
+//	})
+//
+// # Offer Flows
+//
+// In the SolicitOffer flow the Provider sends the offer and the Delegate
+// answers it from OnOffer. In the ProvideOffer flow the controller offers:
+// CreateOffer returns the local SDP for the ProvideOffer command, and Attach
+// binds it to the session ID from the ProvideOfferResponse.
+//
+// # Data-Channel-Only Mode
+//
+// With Config.DataChannelOnly set, local offers carry a single SCTP data
+// channel and no media sections. This is useful for testing the signaling
+// and ICE path without cameras or codecs.
+//
+// # ICE
+//
+// If Config.OnLocalCandidates is nil, the Delegate waits for ICE gathering
+// to complete and embeds all candidates in the SDP. Otherwise candidates are
+// trickled through the callback, typically to Requestor.SendICECandidates.
+package pionjsep
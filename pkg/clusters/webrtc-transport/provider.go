@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"sync"
+	"time"
 
 	"github.com/backkem/matter/pkg/datamodel"
 	"github.com/backkem/matter/pkg/fabric"
@@ -30,6 +31,31 @@ type ProviderConfig struct {
 
 	// OnSendEnd is called when the Provider needs to send an End to the Requestor.
 	OnSendEnd func(ctx context.Context, session *WebRTCSessionStruct, reason WebRTCEndReasonEnum) error
+
+	// ICEBatchInterval is how long QueueICECandidates collects candidates
+	// before sending them in one command.
+	// Defaults to DefaultICEBatchInterval if zero.
+	ICEBatchInterval time.Duration
+
+	// ICESendRetries is how many times a failed ICECandidates command of
+	// QueueICECandidates is retried before the session is ended with
+	// ICEFailed. Defaults to DefaultICESendRetries if zero.
+	ICESendRetries int
+
+	// ICERetryInterval is the wait before the first retry, doubling with
+	// each one. Defaults to DefaultICERetryInterval if zero.
+	ICERetryInterval time.Duration
+
+	// ICEGatheringTimeout ends a session with ICETimeout if the
+	// application does not report ICEGatheringComplete or ICEConnected
+	// within it of the session's start.
+	// Optional - if zero, gathering is not timed.
+	ICEGatheringTimeout time.Duration
+
+	// ICEConnectTimeout ends a session with ICEFailed if the application
+	// does not report ICEConnected within it of the session's start.
+	// Optional - if zero, connectivity is not timed.
+	ICEConnectTimeout time.Duration
 }
 
 // Provider implements the WebRTC Transport Provider cluster (0x0553).
@@ -41,6 +67,7 @@ type Provider struct {
 	sessions        map[uint16]*WebRTCSessionStruct // sessionID -> session
 	nextSessionID   uint16
 	currentSessions []WebRTCSessionStruct
+	ice             map[uint16]*iceState // sessionID -> trickle ICE state
 
 	attrList []datamodel.AttributeEntry
 }
@@ -51,6 +78,7 @@ func NewProvider(cfg ProviderConfig) *Provider {
 		ClusterBase:   datamodel.NewClusterBase(datamodel.ClusterID(ProviderClusterID), cfg.EndpointID, ProviderClusterRevision),
 		config:        cfg,
 		sessions:      make(map[uint16]*WebRTCSessionStruct),
+		ice:           make(map[uint16]*iceState),
 		nextSessionID: 0,
 	}
 
@@ -221,6 +249,10 @@ func (p *Provider) handleSolicitOffer(ctx context.Context, req datamodel.InvokeR
 		return nil, err
 	}
 
+	p.mu.Lock()
+	p.startICE(sessionID)
+	p.mu.Unlock()
+
 	// Encode SolicitOfferResponse
 	return encodeSolicitOfferResponse(sessionID, deferredOffer, session.VideoStreamID, session.AudioStreamID)
 }
@@ -294,6 +326,9 @@ func (p *Provider) handleProvideOffer(ctx context.Context, req datamodel.InvokeR
 
 	// Update session with allocated stream IDs
 	p.mu.Lock()
+	if offerReq.SessionID == nil {
+		p.startICE(sessionID)
+	}
 	if result.VideoStreamID != nil {
 		session.VideoStreamID = result.VideoStreamID
 	}
@@ -432,6 +467,7 @@ func (p *Provider) handleEndSession(ctx context.Context, req datamodel.InvokeReq
 
 	// Remove session
 	delete(p.sessions, sessionID)
+	p.stopICE(sessionID)
 	p.mu.Unlock()
 
 	// Call delegate if set
@@ -484,6 +520,7 @@ func (p *Provider) EndSession(ctx context.Context, sessionID uint16, reason WebR
 		return ErrSessionNotFound
 	}
 	delete(p.sessions, sessionID)
+	p.stopICE(sessionID)
 	p.mu.Unlock()
 
 	if p.config.OnSendEnd != nil {
@@ -492,6 +529,33 @@ func (p *Provider) EndSession(ctx context.Context, sessionID uint16, reason WebR
 	return nil
 }
 
+// RemoveFabric removes all sessions on a fabric without sending End, as
+// the fabric's peers are no longer reachable. The delegate is notified for
+// each session.
+func (p *Provider) RemoveFabric(ctx context.Context, fabricIndex fabric.FabricIndex) {
+	p.mu.Lock()
+	var removed []uint16
+	for id, session := range p.sessions {
+		if fabric.FabricIndex(session.FabricIndex) == fabricIndex {
+			delete(p.sessions, id)
+			p.stopICE(id)
+			removed = append(removed, id)
+		}
+	}
+	p.mu.Unlock()
+
+	if p.config.Delegate != nil {
+		for _, id := range removed {
+			_ = p.config.Delegate.OnSessionEnded(ctx, id, WebRTCEndReasonUnknownReason)
+		}
+	}
+}
+
+// FabricRemoved implements datamodel.FabricRemovalListener.
+func (p *Provider) FabricRemoved(fabricIndex fabric.FabricIndex) {
+	p.RemoveFabric(context.Background(), fabricIndex)
+}
+
 // GetSession returns a session by ID.
 func (p *Provider) GetSession(sessionID uint16) *WebRTCSessionStruct {
 	p.mu.RLock()
@@ -14,17 +14,32 @@ type RequestorConfig struct {
 	// EndpointID is the endpoint this cluster belongs to.
 	EndpointID datamodel.EndpointID
 
-	// Delegate handles WebRTC signaling events.
-	Delegate RequestorDelegate
+	// Delegate runs the JSEP offer/answer exchange for each session.
+	Delegate JSEPDelegate
+
+	// OnSendAnswer is called when the Requestor needs to send an Answer to the Provider.
+	// This callback should invoke the ProvideAnswer command on the Provider cluster.
+	OnSendAnswer func(ctx context.Context, session *WebRTCSessionStruct, sdp string) error
+
+	// OnSendICECandidates is called when the Requestor needs to send ICE candidates to the Provider.
+	OnSendICECandidates func(ctx context.Context, session *WebRTCSessionStruct, candidates []ICECandidateStruct) error
+
+	// OnSendEndSession is called when the Requestor needs to send an EndSession to the Provider.
+	OnSendEndSession func(ctx context.Context, session *WebRTCSessionStruct, reason WebRTCEndReasonEnum) error
 }
 
 // Requestor implements the WebRTC Transport Requestor cluster (0x0554).
+//
+// Sessions are created by the Provider (in ProvideOfferResponse or
+// SolicitOfferResponse) and registered with AddSession. Incoming commands
+// are only accepted from the session's peer node on the session's fabric.
 type Requestor struct {
 	*datamodel.ClusterBase
 	config RequestorConfig
 
 	mu              sync.RWMutex
 	sessions        map[uint16]*WebRTCSessionStruct // sessionID -> session
+	negotiated      map[uint16]bool                 // sessionID -> offer/answer completed
 	currentSessions []WebRTCSessionStruct
 
 	attrList []datamodel.AttributeEntry
@@ -36,6 +51,7 @@ func NewRequestor(cfg RequestorConfig) *Requestor {
 		ClusterBase: datamodel.NewClusterBase(datamodel.ClusterID(RequestorClusterID), cfg.EndpointID, RequestorClusterRevision),
 		config:      cfg,
 		sessions:    make(map[uint16]*WebRTCSessionStruct),
+		negotiated:  make(map[uint16]bool),
 	}
 
 	r.attrList = r.buildAttributeList()
@@ -129,12 +145,9 @@ func (r *Requestor) InvokeCommand(ctx context.Context, req datamodel.InvokeReque
 	}
 }
 
-// handleOffer handles the Offer command from the Provider.
-func (r *Requestor) handleOffer(ctx context.Context, req datamodel.InvokeRequest, rd *tlv.Reader) ([]byte, error) {
-	if r.config.Delegate == nil {
-		return nil, ErrNoDelegate
-	}
-
+// lookupSession returns a copy of the session if it exists and belongs to
+// the subject of the request.
+func (r *Requestor) lookupSession(req datamodel.InvokeRequest, sessionID uint16) (*WebRTCSessionStruct, error) {
 	// Get subject info
 	var sourceNodeID uint64
 	var fabricIndex uint8
@@ -143,16 +156,10 @@ func (r *Requestor) handleOffer(ctx context.Context, req datamodel.InvokeRequest
 		fabricIndex = uint8(req.Subject.FabricIndex)
 	}
 
-	// Decode command fields
-	sessionID, sdp, iceServers, iceTransportPolicy, err := decodeOffer(rd)
-	if err != nil {
-		return nil, err
-	}
-
-	// Lookup session
 	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	session := r.sessions[sessionID]
-	r.mu.RUnlock()
 	if session == nil {
 		return nil, ErrSessionNotFound
 	}
@@ -162,10 +169,52 @@ func (r *Requestor) handleOffer(ctx context.Context, req datamodel.InvokeRequest
 		return nil, ErrUnauthorized
 	}
 
+	s := *session
+	return &s, nil
+}
+
+// handleOffer handles the Offer command from the Provider. This is either
+// the offer of the SolicitOffer flow or a renegotiation of an established
+// session. The answer from the delegate is sent with ProvideAnswer.
+func (r *Requestor) handleOffer(ctx context.Context, req datamodel.InvokeRequest, rd *tlv.Reader) ([]byte, error) {
+	if r.config.Delegate == nil {
+		return nil, ErrNoDelegate
+	}
+
+	// Decode command fields
+	sessionID, sdp, iceServers, iceTransportPolicy, err := decodeOffer(rd)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := r.lookupSession(req, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	renegotiation := r.negotiated[sessionID]
+	r.mu.RUnlock()
+
 	// Call delegate
-	if err := r.config.Delegate.OnOffer(ctx, sessionID, sdp, iceServers, iceTransportPolicy); err != nil {
+	answer, err := r.config.Delegate.OnOffer(ctx, session, &OfferRequest{
+		SessionID:          sessionID,
+		SDP:                sdp,
+		ICEServers:         iceServers,
+		ICETransportPolicy: iceTransportPolicy,
+		Renegotiation:      renegotiation,
+	})
+	if err != nil {
 		return nil, err
 	}
+	r.setNegotiated(sessionID)
+
+	// Send Answer asynchronously (after returning response)
+	if r.config.OnSendAnswer != nil && answer != "" {
+		go func() {
+			_ = r.config.OnSendAnswer(context.Background(), session, answer)
+		}()
+	}
 
 	return nil, nil // Status-only response
 }
@@ -176,76 +225,55 @@ func (r *Requestor) handleAnswer(ctx context.Context, req datamodel.InvokeReques
 		return nil, ErrNoDelegate
 	}
 
-	// Get subject info
-	var sourceNodeID uint64
-	var fabricIndex uint8
-	if req.Subject != nil {
-		sourceNodeID = req.Subject.NodeID
-		fabricIndex = uint8(req.Subject.FabricIndex)
-	}
-
 	// Decode command fields
 	sessionID, sdp, err := decodeAnswer(rd)
 	if err != nil {
 		return nil, err
 	}
 
-	// Lookup session
-	r.mu.RLock()
-	session := r.sessions[sessionID]
-	r.mu.RUnlock()
-	if session == nil {
-		return nil, ErrSessionNotFound
-	}
-
-	// Verify peer
-	if session.PeerNodeID != sourceNodeID || session.FabricIndex != fabricIndex {
-		return nil, ErrUnauthorized
+	session, err := r.lookupSession(req, sessionID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Call delegate
-	if err := r.config.Delegate.OnAnswer(ctx, sessionID, sdp); err != nil {
+	if err := r.config.Delegate.OnAnswer(ctx, session, sdp); err != nil {
 		return nil, err
 	}
+	r.setNegotiated(sessionID)
 
 	return nil, nil // Status-only response
 }
 
+// setNegotiated marks a session as having completed an offer/answer
+// exchange. Later offers are renegotiations.
+func (r *Requestor) setNegotiated(sessionID uint16) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.sessions[sessionID]; ok {
+		r.negotiated[sessionID] = true
+	}
+}
+
 // handleICECandidates handles the ICECandidates command from the Provider.
 func (r *Requestor) handleICECandidates(ctx context.Context, req datamodel.InvokeRequest, rd *tlv.Reader) ([]byte, error) {
 	if r.config.Delegate == nil {
 		return nil, ErrNoDelegate
 	}
 
-	// Get subject info
-	var sourceNodeID uint64
-	var fabricIndex uint8
-	if req.Subject != nil {
-		sourceNodeID = req.Subject.NodeID
-		fabricIndex = uint8(req.Subject.FabricIndex)
-	}
-
 	// Decode command fields
 	sessionID, candidates, err := decodeRequestorICECandidates(rd)
 	if err != nil {
 		return nil, err
 	}
 
-	// Lookup session
-	r.mu.RLock()
-	session := r.sessions[sessionID]
-	r.mu.RUnlock()
-	if session == nil {
-		return nil, ErrSessionNotFound
-	}
-
-	// Verify peer
-	if session.PeerNodeID != sourceNodeID || session.FabricIndex != fabricIndex {
-		return nil, ErrUnauthorized
+	session, err := r.lookupSession(req, sessionID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Call delegate
-	if err := r.config.Delegate.OnICECandidates(ctx, sessionID, candidates); err != nil {
+	if err := r.config.Delegate.OnICECandidates(ctx, session, candidates); err != nil {
 		return nil, err
 	}
 
@@ -254,51 +282,39 @@ func (r *Requestor) handleICECandidates(ctx context.Context, req datamodel.Invok
 
 // handleEnd handles the End command from the Provider.
 func (r *Requestor) handleEnd(ctx context.Context, req datamodel.InvokeRequest, rd *tlv.Reader) ([]byte, error) {
-	// Get subject info
-	var sourceNodeID uint64
-	var fabricIndex uint8
-	if req.Subject != nil {
-		sourceNodeID = req.Subject.NodeID
-		fabricIndex = uint8(req.Subject.FabricIndex)
-	}
-
 	// Decode command fields
 	sessionID, reason, err := decodeEnd(rd)
 	if err != nil {
 		return nil, err
 	}
 
-	// Lookup and remove session
-	r.mu.Lock()
-	session := r.sessions[sessionID]
-	if session == nil {
-		r.mu.Unlock()
-		return nil, ErrSessionNotFound
-	}
-
-	// Verify peer
-	if session.PeerNodeID != sourceNodeID || session.FabricIndex != fabricIndex {
-		r.mu.Unlock()
-		return nil, ErrUnauthorized
+	session, err := r.lookupSession(req, sessionID)
+	if err != nil {
+		return nil, err
 	}
-
-	delete(r.sessions, sessionID)
-	r.mu.Unlock()
+	r.RemoveSession(sessionID)
 
 	// Call delegate if set
 	if r.config.Delegate != nil {
-		_ = r.config.Delegate.OnEnd(ctx, sessionID, reason)
+		r.config.Delegate.OnEnd(ctx, session, reason)
 	}
 
 	return nil, nil // Status-only response
 }
 
 // AddSession adds a session to the Requestor's session list.
-// Called when the Requestor initiates a connection (after receiving ProvideOfferResponse).
-func (r *Requestor) AddSession(session *WebRTCSessionStruct) {
+// Called when the Requestor initiates a connection (after receiving
+// ProvideOfferResponse or SolicitOfferResponse). Session IDs are assigned
+// by Providers; ErrSessionExists is returned if the ID is already in use.
+func (r *Requestor) AddSession(session *WebRTCSessionStruct) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	if _, exists := r.sessions[session.ID]; exists {
+		return ErrSessionExists
+	}
 	r.sessions[session.ID] = session
+	r.IncrementDataVersion()
+	return nil
 }
 
 // GetSession returns a session by ID.
@@ -308,11 +324,84 @@ func (r *Requestor) GetSession(sessionID uint16) *WebRTCSessionStruct {
 	return r.sessions[sessionID]
 }
 
+// Sessions returns copies of the sessions on a fabric.
+func (r *Requestor) Sessions(fabricIndex fabric.FabricIndex) []WebRTCSessionStruct {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var sessions []WebRTCSessionStruct
+	for _, session := range r.sessions {
+		if fabric.FabricIndex(session.FabricIndex) == fabricIndex {
+			sessions = append(sessions, *session)
+		}
+	}
+	return sessions
+}
+
 // RemoveSession removes a session by ID.
 func (r *Requestor) RemoveSession(sessionID uint16) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	if _, exists := r.sessions[sessionID]; !exists {
+		return
+	}
 	delete(r.sessions, sessionID)
+	delete(r.negotiated, sessionID)
+	r.IncrementDataVersion()
+}
+
+// RemoveFabric removes all sessions on a fabric, e.g. when the fabric is
+// removed from the node. The delegate is notified for each session.
+func (r *Requestor) RemoveFabric(ctx context.Context, fabricIndex fabric.FabricIndex) {
+	for _, session := range r.Sessions(fabricIndex) {
+		r.RemoveSession(session.ID)
+		if r.config.Delegate != nil {
+			s := session
+			r.config.Delegate.OnEnd(ctx, &s, WebRTCEndReasonUnknownReason)
+		}
+	}
+}
+
+// FabricRemoved implements datamodel.FabricRemovalListener.
+func (r *Requestor) FabricRemoved(fabricIndex fabric.FabricIndex) {
+	r.RemoveFabric(context.Background(), fabricIndex)
+}
+
+// SendICECandidates sends local ICE candidates to the Provider.
+// Called by the application when new ICE candidates are gathered.
+func (r *Requestor) SendICECandidates(ctx context.Context, sessionID uint16, candidates []ICECandidateStruct) error {
+	if r.config.OnSendICECandidates == nil {
+		return ErrNoDelegate
+	}
+
+	r.mu.RLock()
+	session := r.sessions[sessionID]
+	r.mu.RUnlock()
+	if session == nil {
+		return ErrSessionNotFound
+	}
+
+	return r.config.OnSendICECandidates(ctx, session, candidates)
+}
+
+// EndSession ends a session, notifies the delegate and sends EndSession
+// to the Provider.
+func (r *Requestor) EndSession(ctx context.Context, sessionID uint16, reason WebRTCEndReasonEnum) error {
+	r.mu.RLock()
+	session := r.sessions[sessionID]
+	r.mu.RUnlock()
+	if session == nil {
+		return ErrSessionNotFound
+	}
+	r.RemoveSession(sessionID)
+
+	if r.config.Delegate != nil {
+		r.config.Delegate.OnEnd(ctx, session, reason)
+	}
+	if r.config.OnSendEndSession != nil {
+		return r.config.OnSendEndSession(ctx, session, reason)
+	}
+	return nil
 }
 
 // --- TLV Decoding Helpers ---
@@ -1,52 +1,68 @@
 package webrtctransport
 
 import (
+	"bytes"
 	"context"
 	"testing"
 
 	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/tlv"
 )
 
-// mockRequestorDelegate implements RequestorDelegate for testing.
-type mockRequestorDelegate struct {
-	onOffer         func(ctx context.Context, sessionID uint16, sdp string, iceServers []ICEServerStruct, iceTransportPolicy string) error
-	onAnswer        func(ctx context.Context, sessionID uint16, sdp string) error
-	onICECandidates func(ctx context.Context, sessionID uint16, candidates []ICECandidateStruct) error
-	onEnd           func(ctx context.Context, sessionID uint16, reason WebRTCEndReasonEnum) error
+// mockJSEPDelegate implements JSEPDelegate for testing.
+type mockJSEPDelegate struct {
+	onOffer         func(ctx context.Context, session *WebRTCSessionStruct, req *OfferRequest) (string, error)
+	onAnswer        func(ctx context.Context, session *WebRTCSessionStruct, sdp string) error
+	onICECandidates func(ctx context.Context, session *WebRTCSessionStruct, candidates []ICECandidateStruct) error
+	onEnd           func(ctx context.Context, session *WebRTCSessionStruct, reason WebRTCEndReasonEnum)
 }
 
-func (m *mockRequestorDelegate) OnOffer(ctx context.Context, sessionID uint16, sdp string, iceServers []ICEServerStruct, iceTransportPolicy string) error {
+func (m *mockJSEPDelegate) OnOffer(ctx context.Context, session *WebRTCSessionStruct, req *OfferRequest) (string, error) {
 	if m.onOffer != nil {
-		return m.onOffer(ctx, sessionID, sdp, iceServers, iceTransportPolicy)
+		return m.onOffer(ctx, session, req)
 	}
-	return nil
+	return "", nil
 }
 
-func (m *mockRequestorDelegate) OnAnswer(ctx context.Context, sessionID uint16, sdp string) error {
+func (m *mockJSEPDelegate) OnAnswer(ctx context.Context, session *WebRTCSessionStruct, sdp string) error {
 	if m.onAnswer != nil {
-		return m.onAnswer(ctx, sessionID, sdp)
+		return m.onAnswer(ctx, session, sdp)
 	}
 	return nil
 }
 
-func (m *mockRequestorDelegate) OnICECandidates(ctx context.Context, sessionID uint16, candidates []ICECandidateStruct) error {
+func (m *mockJSEPDelegate) OnICECandidates(ctx context.Context, session *WebRTCSessionStruct, candidates []ICECandidateStruct) error {
 	if m.onICECandidates != nil {
-		return m.onICECandidates(ctx, sessionID, candidates)
+		return m.onICECandidates(ctx, session, candidates)
 	}
 	return nil
 }
 
-func (m *mockRequestorDelegate) OnEnd(ctx context.Context, sessionID uint16, reason WebRTCEndReasonEnum) error {
+func (m *mockJSEPDelegate) OnEnd(ctx context.Context, session *WebRTCSessionStruct, reason WebRTCEndReasonEnum) {
 	if m.onEnd != nil {
-		return m.onEnd(ctx, sessionID, reason)
+		m.onEnd(ctx, session, reason)
 	}
-	return nil
+}
+
+// invokeRequestor invokes a Requestor command as the given peer.
+func invokeRequestor(r *Requestor, cmd uint32, peer uint64, fabricIndex fabric.FabricIndex, payload []byte) error {
+	req := datamodel.InvokeRequest{
+		Path: datamodel.ConcreteCommandPath{
+			Endpoint: 1,
+			Cluster:  datamodel.ClusterID(RequestorClusterID),
+			Command:  datamodel.CommandID(cmd),
+		},
+		Subject: &datamodel.SubjectDescriptor{FabricIndex: fabricIndex, NodeID: peer},
+	}
+	_, err := r.InvokeCommand(context.Background(), req, tlv.NewReader(bytes.NewReader(payload)))
+	return err
 }
 
 func TestRequestor_NewRequestor(t *testing.T) {
 	cfg := RequestorConfig{
 		EndpointID: 2,
-		Delegate:   &mockRequestorDelegate{},
+		Delegate:   &mockJSEPDelegate{},
 	}
 
 	r := NewRequestor(cfg)
@@ -172,3 +188,137 @@ func TestRequestor_AddMultipleSessions(t *testing.T) {
 		t.Error("session 3 should still exist")
 	}
 }
+
+func TestRequestor_AddDuplicateSession(t *testing.T) {
+	r := NewRequestor(RequestorConfig{EndpointID: 1})
+
+	if err := r.AddSession(&WebRTCSessionStruct{ID: 1, PeerNodeID: 100, FabricIndex: 1}); err != nil {
+		t.Fatalf("AddSession failed: %v", err)
+	}
+	if err := r.AddSession(&WebRTCSessionStruct{ID: 1, PeerNodeID: 200, FabricIndex: 2}); err != ErrSessionExists {
+		t.Errorf("expected ErrSessionExists, got %v", err)
+	}
+}
+
+func TestRequestor_OfferAndRenegotiation(t *testing.T) {
+	var offers []*OfferRequest
+	answers := make(chan string, 2)
+
+	r := NewRequestor(RequestorConfig{
+		EndpointID: 1,
+		Delegate: &mockJSEPDelegate{
+			onOffer: func(ctx context.Context, session *WebRTCSessionStruct, req *OfferRequest) (string, error) {
+				offers = append(offers, req)
+				return "answer", nil
+			},
+		},
+		OnSendAnswer: func(ctx context.Context, session *WebRTCSessionStruct, sdp string) error {
+			answers <- sdp
+			return nil
+		},
+	})
+	r.AddSession(&WebRTCSessionStruct{ID: 7, PeerNodeID: 100, FabricIndex: 1})
+
+	payload, _ := EncodeOffer(7, "offer-1", nil, "")
+	if err := invokeRequestor(r, CmdOffer, 100, 1, payload); err != nil {
+		t.Fatalf("Offer failed: %v", err)
+	}
+	payload, _ = EncodeOffer(7, "offer-2", nil, "")
+	if err := invokeRequestor(r, CmdOffer, 100, 1, payload); err != nil {
+		t.Fatalf("re-Offer failed: %v", err)
+	}
+
+	if len(offers) != 2 {
+		t.Fatalf("expected 2 offers, got %d", len(offers))
+	}
+	if offers[0].SDP != "offer-1" || offers[0].Renegotiation {
+		t.Errorf("first offer = %+v, want initial offer", offers[0])
+	}
+	if offers[1].SDP != "offer-2" || !offers[1].Renegotiation {
+		t.Errorf("second offer = %+v, want renegotiation", offers[1])
+	}
+	for i := 0; i < 2; i++ {
+		if sdp := <-answers; sdp != "answer" {
+			t.Errorf("sent answer %q, want %q", sdp, "answer")
+		}
+	}
+}
+
+func TestRequestor_VerifiesPeer(t *testing.T) {
+	var answered bool
+	r := NewRequestor(RequestorConfig{
+		EndpointID: 1,
+		Delegate: &mockJSEPDelegate{
+			onAnswer: func(ctx context.Context, session *WebRTCSessionStruct, sdp string) error {
+				answered = true
+				return nil
+			},
+		},
+	})
+	r.AddSession(&WebRTCSessionStruct{ID: 7, PeerNodeID: 100, FabricIndex: 1})
+
+	payload, _ := EncodeAnswer(7, "answer")
+	if err := invokeRequestor(r, CmdAnswer, 100, 2, payload); err != ErrUnauthorized {
+		t.Errorf("wrong fabric: expected ErrUnauthorized, got %v", err)
+	}
+	if err := invokeRequestor(r, CmdAnswer, 200, 1, payload); err != ErrUnauthorized {
+		t.Errorf("wrong peer: expected ErrUnauthorized, got %v", err)
+	}
+	payload, _ = EncodeAnswer(8, "answer")
+	if err := invokeRequestor(r, CmdAnswer, 100, 1, payload); err != ErrSessionNotFound {
+		t.Errorf("unknown session: expected ErrSessionNotFound, got %v", err)
+	}
+	if answered {
+		t.Error("delegate should not be called for rejected commands")
+	}
+}
+
+func TestRequestor_End(t *testing.T) {
+	var ended []uint16
+	var endSent []WebRTCEndReasonEnum
+	r := NewRequestor(RequestorConfig{
+		EndpointID: 1,
+		Delegate: &mockJSEPDelegate{
+			onEnd: func(ctx context.Context, session *WebRTCSessionStruct, reason WebRTCEndReasonEnum) {
+				ended = append(ended, session.ID)
+			},
+		},
+		OnSendEndSession: func(ctx context.Context, session *WebRTCSessionStruct, reason WebRTCEndReasonEnum) error {
+			endSent = append(endSent, reason)
+			return nil
+		},
+	})
+	r.AddSession(&WebRTCSessionStruct{ID: 1, PeerNodeID: 100, FabricIndex: 1})
+	r.AddSession(&WebRTCSessionStruct{ID: 2, PeerNodeID: 100, FabricIndex: 1})
+	r.AddSession(&WebRTCSessionStruct{ID: 3, PeerNodeID: 300, FabricIndex: 2})
+
+	// End from the Provider
+	payload, _ := EncodeEnd(1, WebRTCEndReasonUserHangup)
+	if err := invokeRequestor(r, CmdEnd, 100, 1, payload); err != nil {
+		t.Fatalf("End failed: %v", err)
+	}
+	if r.GetSession(1) != nil {
+		t.Error("session 1 should be removed")
+	}
+
+	// End locally
+	if err := r.EndSession(context.Background(), 2, WebRTCEndReasonUserHangup); err != nil {
+		t.Fatalf("EndSession failed: %v", err)
+	}
+	if len(endSent) != 1 || endSent[0] != WebRTCEndReasonUserHangup {
+		t.Errorf("EndSession sent %v", endSent)
+	}
+
+	// Fabric removal
+	if got := len(r.Sessions(2)); got != 1 {
+		t.Fatalf("expected 1 session on fabric 2, got %d", got)
+	}
+	r.RemoveFabric(context.Background(), 2)
+	if len(r.Sessions(2)) != 0 {
+		t.Error("fabric 2 sessions should be removed")
+	}
+
+	if len(ended) != 3 || ended[0] != 1 || ended[1] != 2 || ended[2] != 3 {
+		t.Errorf("delegate OnEnd calls = %v, want [1 2 3]", ended)
+	}
+}
@@ -0,0 +1,225 @@
+package webrtctransport
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Trickle ICE defaults of the Provider.
+const (
+	// DefaultICEBatchInterval is how long the Provider collects local
+	// candidates before sending them in one ICECandidates command.
+	DefaultICEBatchInterval = 100 * time.Millisecond
+
+	// DefaultICESendRetries is how many times a failed ICECandidates
+	// command is retried.
+	DefaultICESendRetries = 3
+
+	// DefaultICERetryInterval is the wait before the first retry of a
+	// failed ICECandidates command. It doubles with each retry.
+	DefaultICERetryInterval = 500 * time.Millisecond
+)
+
+// iceState is the trickle ICE state of one Provider session.
+type iceState struct {
+	pending []ICECandidateStruct
+	batch   *time.Timer
+	sending bool
+
+	gathering *time.Timer
+	connect   *time.Timer
+
+	// done is closed when the session ends, to stop retries.
+	done chan struct{}
+}
+
+// startICE starts the trickle ICE state of a new session and arms its
+// gathering and connectivity timeouts. Caller must hold p.mu.
+func (p *Provider) startICE(sessionID uint16) {
+	st := &iceState{done: make(chan struct{})}
+	if t := p.config.ICEGatheringTimeout; t > 0 {
+		st.gathering = time.AfterFunc(t, func() {
+			p.endICE(sessionID, st, WebRTCEndReasonICETimeout)
+		})
+	}
+	if t := p.config.ICEConnectTimeout; t > 0 {
+		st.connect = time.AfterFunc(t, func() {
+			p.endICE(sessionID, st, WebRTCEndReasonICEFailed)
+		})
+	}
+	p.ice[sessionID] = st
+}
+
+// stopICE drops the trickle ICE state of an ended session. Caller must hold
+// p.mu.
+func (p *Provider) stopICE(sessionID uint16) {
+	st := p.ice[sessionID]
+	if st == nil {
+		return
+	}
+	delete(p.ice, sessionID)
+	for _, t := range []*time.Timer{st.batch, st.gathering, st.connect} {
+		if t != nil {
+			t.Stop()
+		}
+	}
+	close(st.done)
+}
+
+// QueueICECandidates queues local ICE candidates gathered for a session.
+// Candidates queued within ICEBatchInterval are sent together in one
+// ICECandidates command through OnSendICECandidates, which is retried if
+// it fails. Batches are sent in order.
+//
+// Use SendICECandidates to send candidates at once instead.
+func (p *Provider) QueueICECandidates(sessionID uint16, candidates ...ICECandidateStruct) error {
+	if p.config.OnSendICECandidates == nil {
+		return ErrNoDelegate
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	st := p.ice[sessionID]
+	if st == nil {
+		return ErrSessionNotFound
+	}
+	st.pending = append(st.pending, candidates...)
+	if st.batch == nil && !st.sending {
+		st.batch = time.AfterFunc(p.iceBatchInterval(), func() {
+			p.flushICE(sessionID, st)
+		})
+	}
+	return nil
+}
+
+// ICEGatheringComplete reports that the session gathered all its local
+// candidates. Queued candidates are sent at once and the gathering timeout
+// stops.
+func (p *Provider) ICEGatheringComplete(sessionID uint16) error {
+	p.mu.Lock()
+	st := p.ice[sessionID]
+	if st == nil {
+		p.mu.Unlock()
+		return ErrSessionNotFound
+	}
+	if st.gathering != nil {
+		st.gathering.Stop()
+		st.gathering = nil
+	}
+	p.mu.Unlock()
+
+	go p.flushICE(sessionID, st)
+	return nil
+}
+
+// ICEConnected reports that the session's ICE connectivity checks
+// succeeded. The gathering and connectivity timeouts stop.
+func (p *Provider) ICEConnected(sessionID uint16) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	st := p.ice[sessionID]
+	if st == nil {
+		return ErrSessionNotFound
+	}
+	for _, t := range []*time.Timer{st.gathering, st.connect} {
+		if t != nil {
+			t.Stop()
+		}
+	}
+	st.gathering, st.connect = nil, nil
+	return nil
+}
+
+// flushICE sends the queued candidates of a session, then any queued
+// while they were sent. A batch whose retries all fail ends the session
+// with ICEFailed, as the Requestor cannot complete ICE without it.
+func (p *Provider) flushICE(sessionID uint16, st *iceState) {
+	for {
+		p.mu.Lock()
+		if p.ice[sessionID] != st || st.sending {
+			p.mu.Unlock()
+			return
+		}
+		if st.batch != nil {
+			st.batch.Stop()
+			st.batch = nil
+		}
+		batch := st.pending
+		st.pending = nil
+		session := p.sessions[sessionID]
+		if len(batch) == 0 || session == nil {
+			p.mu.Unlock()
+			return
+		}
+		st.sending = true
+		p.mu.Unlock()
+
+		err := p.sendICEWithRetry(session, st, batch)
+
+		p.mu.Lock()
+		st.sending = false
+		p.mu.Unlock()
+
+		if err != nil {
+			p.endICE(sessionID, st, WebRTCEndReasonICEFailed)
+			return
+		}
+	}
+}
+
+// sendICEWithRetry sends a batch of candidates, retrying with a doubling
+// wait. It stops early if the session ends.
+func (p *Provider) sendICEWithRetry(session *WebRTCSessionStruct, st *iceState, batch []ICECandidateStruct) error {
+	retries := p.config.ICESendRetries
+	if retries == 0 {
+		retries = DefaultICESendRetries
+	}
+	wait := p.config.ICERetryInterval
+	if wait == 0 {
+		wait = DefaultICERetryInterval
+	}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = p.config.OnSendICECandidates(context.Background(), session, batch); err == nil {
+			return nil
+		}
+		if attempt >= retries {
+			return err
+		}
+		select {
+		case <-time.After(wait):
+			wait *= 2
+		case <-st.done:
+			return nil
+		}
+	}
+}
+
+// endICE ends a session whose ICE did not complete, notifying both
+// the Requestor and the delegate. It does nothing if the session already
+// ended.
+func (p *Provider) endICE(sessionID uint16, st *iceState, reason WebRTCEndReasonEnum) {
+	p.mu.Lock()
+	if p.ice[sessionID] != st {
+		p.mu.Unlock()
+		return
+	}
+	p.mu.Unlock()
+
+	if err := p.EndSession(context.Background(), sessionID, reason); errors.Is(err, ErrSessionNotFound) {
+		return
+	}
+	if p.config.Delegate != nil {
+		_ = p.config.Delegate.OnSessionEnded(context.Background(), sessionID, reason)
+	}
+}
+
+// iceBatchInterval returns the configured batch interval or its default.
+func (p *Provider) iceBatchInterval() time.Duration {
+	if p.config.ICEBatchInterval > 0 {
+		return p.config.ICEBatchInterval
+	}
+	return DefaultICEBatchInterval
+}
@@ -0,0 +1,294 @@
+package webrtctransport
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// trickleRecorder records the commands a Provider sends.
+type trickleRecorder struct {
+	mu      sync.Mutex
+	batches [][]ICECandidateStruct
+	fails   int
+	ended   []WebRTCEndReasonEnum
+	endedCh chan struct{}
+}
+
+func newTrickleRecorder() *trickleRecorder {
+	return &trickleRecorder{endedCh: make(chan struct{}, 1)}
+}
+
+func (r *trickleRecorder) sendICE(ctx context.Context, s *WebRTCSessionStruct, candidates []ICECandidateStruct) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fails > 0 {
+		r.fails--
+		return errors.New("peer unreachable")
+	}
+	r.batches = append(r.batches, candidates)
+	return nil
+}
+
+func (r *trickleRecorder) sendEnd(ctx context.Context, s *WebRTCSessionStruct, reason WebRTCEndReasonEnum) error {
+	r.mu.Lock()
+	r.ended = append(r.ended, reason)
+	r.mu.Unlock()
+	r.endedCh <- struct{}{}
+	return nil
+}
+
+func (r *trickleRecorder) snapshot() ([][]ICECandidateStruct, []WebRTCEndReasonEnum) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([][]ICECandidateStruct(nil), r.batches...), append([]WebRTCEndReasonEnum(nil), r.ended...)
+}
+
+// addTrickleSession adds a session as handleProvideOffer would.
+func addTrickleSession(p *Provider, id uint16) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sessions[id] = &WebRTCSessionStruct{ID: id, PeerNodeID: 12345, FabricIndex: 1}
+	p.startICE(id)
+}
+
+func candidate(s string) ICECandidateStruct {
+	return ICECandidateStruct{Candidate: s}
+}
+
+func TestProvider_QueueICECandidates_Batches(t *testing.T) {
+	rec := newTrickleRecorder()
+	p := NewProvider(ProviderConfig{
+		EndpointID:          1,
+		OnSendICECandidates: rec.sendICE,
+		ICEBatchInterval:    20 * time.Millisecond,
+	})
+	addTrickleSession(p, 1)
+
+	if err := p.QueueICECandidates(1, candidate("a"), candidate("b")); err != nil {
+		t.Fatalf("QueueICECandidates: %v", err)
+	}
+	if err := p.QueueICECandidates(1, candidate("c")); err != nil {
+		t.Fatalf("QueueICECandidates: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	batches, _ := rec.snapshot()
+	if len(batches) != 1 || len(batches[0]) != 3 {
+		t.Fatalf("batches = %v, want one batch of 3 candidates", batches)
+	}
+
+	// Gathering complete sends at once
+	if err := p.QueueICECandidates(1, candidate("d")); err != nil {
+		t.Fatalf("QueueICECandidates: %v", err)
+	}
+	if err := p.ICEGatheringComplete(1); err != nil {
+		t.Fatalf("ICEGatheringComplete: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if batches, _ := rec.snapshot(); len(batches) != 2 || batches[1][0].Candidate != "d" {
+		t.Errorf("batches = %v, want the last candidate sent on gathering complete", batches)
+	}
+
+	if err := p.QueueICECandidates(2, candidate("e")); err != ErrSessionNotFound {
+		t.Errorf("QueueICECandidates(unknown session) = %v, want %v", err, ErrSessionNotFound)
+	}
+}
+
+func TestProvider_QueueICECandidates_Retry(t *testing.T) {
+	rec := newTrickleRecorder()
+	rec.fails = 2
+	p := NewProvider(ProviderConfig{
+		EndpointID:          1,
+		OnSendICECandidates: rec.sendICE,
+		OnSendEnd:           rec.sendEnd,
+		ICEBatchInterval:    time.Millisecond,
+		ICERetryInterval:    5 * time.Millisecond,
+	})
+	addTrickleSession(p, 1)
+
+	if err := p.QueueICECandidates(1, candidate("a")); err != nil {
+		t.Fatalf("QueueICECandidates: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	batches, ended := rec.snapshot()
+	if len(batches) != 1 || len(ended) != 0 {
+		t.Errorf("batches = %v, ended = %v; want the batch delivered on retry", batches, ended)
+	}
+}
+
+func TestProvider_QueueICECandidates_DeliveryFailure(t *testing.T) {
+	rec := newTrickleRecorder()
+	rec.fails = 10
+	delegateReason := make(chan WebRTCEndReasonEnum, 1)
+	p := NewProvider(ProviderConfig{
+		EndpointID: 1,
+		Delegate: &mockProviderDelegate{
+			onSessionEnded: func(ctx context.Context, sessionID uint16, reason WebRTCEndReasonEnum) error {
+				delegateReason <- reason
+				return nil
+			},
+		},
+		OnSendICECandidates: rec.sendICE,
+		OnSendEnd:           rec.sendEnd,
+		ICEBatchInterval:    time.Millisecond,
+		ICESendRetries:      2,
+		ICERetryInterval:    time.Millisecond,
+	})
+	addTrickleSession(p, 1)
+
+	if err := p.QueueICECandidates(1, candidate("a")); err != nil {
+		t.Fatalf("QueueICECandidates: %v", err)
+	}
+	select {
+	case <-rec.endedCh:
+	case <-time.After(time.Second):
+		t.Fatal("session not ended after the retries failed")
+	}
+
+	_, ended := rec.snapshot()
+	if len(ended) != 1 || ended[0] != WebRTCEndReasonICEFailed {
+		t.Errorf("ended = %v, want ICEFailed", ended)
+	}
+	if p.GetSession(1) != nil {
+		t.Error("session still present")
+	}
+	if reason := <-delegateReason; reason != WebRTCEndReasonICEFailed {
+		t.Errorf("delegate reason = %v, want ICEFailed", reason)
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if rec.fails != 10-3 {
+		t.Errorf("%d attempts, want 3", 10-rec.fails)
+	}
+}
+
+func TestProvider_ICETimeouts(t *testing.T) {
+	tests := []struct {
+		name      string
+		gathering time.Duration
+		connect   time.Duration
+		want      WebRTCEndReasonEnum
+	}{
+		{"gathering", 20 * time.Millisecond, time.Second, WebRTCEndReasonICETimeout},
+		{"connectivity", 0, 20 * time.Millisecond, WebRTCEndReasonICEFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := newTrickleRecorder()
+			p := NewProvider(ProviderConfig{
+				EndpointID:          1,
+				OnSendEnd:           rec.sendEnd,
+				ICEGatheringTimeout: tt.gathering,
+				ICEConnectTimeout:   tt.connect,
+			})
+			addTrickleSession(p, 1)
+
+			select {
+			case <-rec.endedCh:
+			case <-time.After(time.Second):
+				t.Fatal("session not ended on timeout")
+			}
+			if _, ended := rec.snapshot(); len(ended) != 1 || ended[0] != tt.want {
+				t.Errorf("ended = %v, want %v", ended, tt.want)
+			}
+		})
+	}
+}
+
+func TestProvider_ICEConnected(t *testing.T) {
+	rec := newTrickleRecorder()
+	p := NewProvider(ProviderConfig{
+		EndpointID:          1,
+		OnSendEnd:           rec.sendEnd,
+		ICEGatheringTimeout: 20 * time.Millisecond,
+		ICEConnectTimeout:   20 * time.Millisecond,
+	})
+	addTrickleSession(p, 1)
+
+	if err := p.ICEConnected(1); err != nil {
+		t.Fatalf("ICEConnected: %v", err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if _, ended := rec.snapshot(); len(ended) != 0 || p.GetSession(1) == nil {
+		t.Errorf("ended = %v, want the connected session kept", ended)
+	}
+}
+
+func TestProvider_ProvideOfferStartsICE(t *testing.T) {
+	p := NewProvider(ProviderConfig{
+		EndpointID:          1,
+		Delegate:            &mockProviderDelegate{},
+		OnSendICECandidates: newTrickleRecorder().sendICE,
+	})
+
+	payload, err := EncodeProvideOffer(nil, "v=0\r\ntest offer", StreamUsageLiveView, 1, nil, nil, nil, "", false)
+	if err != nil {
+		t.Fatalf("EncodeProvideOffer: %v", err)
+	}
+	req := datamodel.InvokeRequest{
+		Path: datamodel.ConcreteCommandPath{Endpoint: 1, Cluster: datamodel.ClusterID(ProviderClusterID), Command: datamodel.CommandID(CmdProvideOffer)},
+	}
+	resp, err := p.InvokeCommand(context.Background(), req, tlv.NewReader(bytes.NewReader(payload)))
+	if err != nil {
+		t.Fatalf("ProvideOffer: %v", err)
+	}
+	sessionID, _, _, err := DecodeProvideOfferResponse(resp)
+	if err != nil {
+		t.Fatalf("DecodeProvideOfferResponse: %v", err)
+	}
+
+	if err := p.QueueICECandidates(sessionID, candidate("a")); err != nil {
+		t.Errorf("QueueICECandidates on a new session: %v", err)
+	}
+	if err := p.EndSession(context.Background(), sessionID, WebRTCEndReasonUserHangup); err != nil {
+		t.Fatalf("EndSession: %v", err)
+	}
+	if err := p.QueueICECandidates(sessionID, candidate("b")); err != ErrSessionNotFound {
+		t.Errorf("QueueICECandidates after EndSession = %v, want %v", err, ErrSessionNotFound)
+	}
+}
+
+func TestProvider_RemoveFabric(t *testing.T) {
+	var ended []uint16
+	rec := newTrickleRecorder()
+	p := NewProvider(ProviderConfig{
+		EndpointID: 1,
+		Delegate: &mockProviderDelegate{
+			onSessionEnded: func(ctx context.Context, sessionID uint16, reason WebRTCEndReasonEnum) error {
+				ended = append(ended, sessionID)
+				return nil
+			},
+		},
+		OnSendEnd:           rec.sendEnd,
+		OnSendICECandidates: rec.sendICE,
+	})
+	addTrickleSession(p, 1)
+	addTrickleSession(p, 2)
+	p.mu.Lock()
+	p.sessions[2].FabricIndex = 2
+	p.mu.Unlock()
+
+	p.FabricRemoved(1)
+
+	if p.GetSession(1) != nil || p.GetSession(2) == nil {
+		t.Error("want only the session of the removed fabric dropped")
+	}
+	if len(ended) != 1 || ended[0] != 1 {
+		t.Errorf("delegate notified of %v, want [1]", ended)
+	}
+	if err := p.QueueICECandidates(1, candidate("a")); err != ErrSessionNotFound {
+		t.Errorf("QueueICECandidates after removal = %v, want %v", err, ErrSessionNotFound)
+	}
+	if _, sent := rec.snapshot(); len(sent) != 0 {
+		t.Errorf("End sent for %v, want none", sent)
+	}
+}
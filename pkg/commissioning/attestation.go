@@ -79,7 +79,7 @@ const (
 	CmdCSRRequest               uint32 = 0x04
 	CmdCSRResponse              uint32 = 0x05
 	CmdAddNOC                   uint32 = 0x06
-	CmdUpdateNOC                uint32 = 0x09
+	CmdUpdateNOC                uint32 = 0x07
 	CmdNOCResponse              uint32 = 0x08
 )
 
@@ -177,7 +177,11 @@ func PerformDeviceAttestation(
 		PAI:                  pai,
 	}
 
-	return verifier.Verify(ctx, info)
+	result, err := verifier.Verify(ctx, info)
+	if result != nil {
+		result.DAC = dac
+	}
+	return result, err
 }
 
 // attestationResponse holds the decoded AttestationResponse.
@@ -0,0 +1,167 @@
+package commissioning
+
+import (
+	"context"
+	"sync"
+
+	"github.com/backkem/matter/pkg/commissioning/payload"
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/transport"
+)
+
+// DefaultBatchConcurrency is the default number of devices a batch
+// commissions at once.
+const DefaultBatchConcurrency = 4
+
+// BatchConfig configures CommissionBatch.
+type BatchConfig struct {
+	// Commissioner is the configuration every operation shares: the
+	// resolver, secure channel, session and exchange managers, and the
+	// fabric the devices join with the CA issuing their NOCs. Its
+	// callbacks are called for every operation, after the batch's own.
+	Commissioner CommissionerConfig
+
+	// Addresses are the addresses of the devices, by the position of
+	// their payloads, e.g. for devices on a transport.PipeNetwork. If
+	// nil, the devices are discovered by their discriminators.
+	Addresses []transport.PeerAddress
+
+	// Concurrency bounds the devices commissioned at once.
+	// Defaults to DefaultBatchConcurrency if zero.
+	Concurrency int
+
+	// OnProgress is called as each operation moves to a new state.
+	// Operations run concurrently: it must be safe for concurrent use.
+	OnProgress func(progress BatchProgress)
+
+	// OnResult is called as each operation ends, in the order they end.
+	// It must be safe for concurrent use.
+	OnResult func(result BatchResult)
+}
+
+// BatchProgress is the progress of one operation of a batch.
+type BatchProgress struct {
+	// Index is the position of the device's payload in the batch.
+	Index int
+
+	// Payload is the onboarding payload being commissioned.
+	Payload *payload.SetupPayload
+
+	// State is the commissioning state the operation entered.
+	State CommissionerState
+
+	// Percent is the progress of the operation, from 0 to 100.
+	Percent int
+
+	// Message describes the current step.
+	Message string
+}
+
+// BatchResult is the outcome of one operation of a batch.
+type BatchResult struct {
+	// Index is the position of the device's payload in the batch.
+	Index int
+
+	// Payload is the onboarding payload that was commissioned.
+	Payload *payload.SetupPayload
+
+	// NodeID is the operational node ID of the device, if commissioning
+	// succeeded.
+	NodeID fabric.NodeID
+
+	// Err is why commissioning failed, or nil.
+	Err error
+}
+
+// CommissionBatch commissions several devices concurrently, e.g. while an
+// installer sets up a building. Each payload gets a Commissioner of its
+// own, sharing config.Commissioner's transports, resolver, fabric and CA,
+// which gives each device a node ID of its own. At most
+// config.Concurrency run at once. A failed device does not stop
+// the others; cancelling ctx stops all of them.
+//
+// The results are returned in the order of payloads.
+func CommissionBatch(ctx context.Context, config BatchConfig, payloads []*payload.SetupPayload) []BatchResult {
+	concurrency := config.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+
+	results := make([]BatchResult, len(payloads))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, p := range payloads {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			// Devices not started yet are not commissioned
+			for j := i; j < len(payloads); j++ {
+				results[j] = BatchResult{Index: j, Payload: payloads[j], Err: ErrCancelled}
+				if config.OnResult != nil {
+					config.OnResult(results[j])
+				}
+			}
+			wg.Wait()
+			return results
+		}
+
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			results[i] = commissionBatchDevice(ctx, config, i, p)
+			if config.OnResult != nil {
+				config.OnResult(results[i])
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// commissionBatchDevice commissions the device at index i of a batch.
+func commissionBatchDevice(ctx context.Context, config BatchConfig, i int, p *payload.SetupPayload) BatchResult {
+	result := BatchResult{Index: i, Payload: p}
+
+	// The state follows the progress report of its step
+	var percent int
+	var message string
+	cc := config.Commissioner
+	user := cc.Callbacks
+	cc.Callbacks.OnProgress = func(pct int, msg string) {
+		percent, message = pct, msg
+		if user.OnProgress != nil {
+			user.OnProgress(pct, msg)
+		}
+	}
+	cc.Callbacks.OnStateChanged = func(state CommissionerState) {
+		if config.OnProgress != nil {
+			config.OnProgress(BatchProgress{
+				Index:   i,
+				Payload: p,
+				State:   state,
+				Percent: percent,
+				Message: message,
+			})
+		}
+		if user.OnStateChanged != nil {
+			user.OnStateChanged(state)
+		}
+	}
+	cc.Callbacks.OnCommissioningComplete = func(nodeID fabric.NodeID) {
+		result.NodeID = nodeID
+		if user.OnCommissioningComplete != nil {
+			user.OnCommissioningComplete(nodeID)
+		}
+	}
+
+	c := NewCommissioner(cc)
+	if i < len(config.Addresses) {
+		result.Err = c.CommissionAtAddress(ctx, config.Addresses[i], p)
+	} else {
+		result.Err = c.CommissionFromPayload(ctx, p)
+	}
+	return result
+}
@@ -0,0 +1,92 @@
+package commissioning
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/backkem/matter/pkg/commissioning/payload"
+	"github.com/backkem/matter/pkg/discovery"
+)
+
+func TestCommissionBatch(t *testing.T) {
+	// Nothing answers discovery, so every operation fails there
+	resolver, err := discovery.NewResolver(discovery.ResolverConfig{
+		MDNSResolver: discovery.NewMockMDNSResolver(),
+	})
+	if err != nil {
+		t.Fatalf("NewResolver failed: %v", err)
+	}
+
+	var payloads []*payload.SetupPayload
+	for i := range 6 {
+		payloads = append(payloads, &payload.SetupPayload{
+			Discriminator: payload.NewLongDiscriminator(uint16(3840 + i)),
+			Passcode:      20202021,
+		})
+	}
+
+	var mu sync.Mutex
+	active, maxActive := 0, 0
+	var progress []BatchProgress
+	var ended []int
+	results := CommissionBatch(context.Background(), BatchConfig{
+		Commissioner: CommissionerConfig{
+			Resolver:         resolver,
+			DiscoveryTimeout: 50 * time.Millisecond,
+		},
+		Concurrency: 2,
+		OnProgress: func(p BatchProgress) {
+			mu.Lock()
+			progress = append(progress, p)
+			if p.State == CommissionerStateDiscovering {
+				active++
+				maxActive = max(maxActive, active)
+			}
+			mu.Unlock()
+			// Long enough for the operations to overlap
+			time.Sleep(20 * time.Millisecond)
+		},
+		OnResult: func(r BatchResult) {
+			mu.Lock()
+			defer mu.Unlock()
+			active--
+			ended = append(ended, r.Index)
+		},
+	}, payloads)
+
+	if len(results) != len(payloads) || len(ended) != len(payloads) {
+		t.Fatalf("got %d results, %d ended, want %d", len(results), len(ended), len(payloads))
+	}
+	for i, r := range results {
+		if r.Index != i || r.Payload != payloads[i] || r.Err == nil {
+			t.Errorf("results[%d] = %+v, want a failure of device %d", i, r, i)
+		}
+	}
+	if maxActive != 2 {
+		t.Errorf("%d operations ran at once, want 2", maxActive)
+	}
+	if len(progress) != len(payloads) {
+		t.Fatalf("progress = %+v, want one report per device", progress)
+	}
+	if p := progress[0]; p.Percent != 5 || p.Message == "" {
+		t.Errorf("progress = %+v, want the discovery step", p)
+	}
+}
+
+func TestCommissionBatch_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	payloads := []*payload.SetupPayload{
+		{Discriminator: payload.NewLongDiscriminator(3840)},
+		{Discriminator: payload.NewLongDiscriminator(3841)},
+	}
+	results := CommissionBatch(ctx, BatchConfig{Concurrency: 1}, payloads)
+	for i, r := range results {
+		if r.Err == nil {
+			t.Errorf("results[%d].Err = nil after cancellation", i)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package commissioning
+
+import (
+	gocrypto "crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/backkem/matter/pkg/credentials"
+	"github.com/backkem/matter/pkg/crypto"
+	"github.com/backkem/matter/pkg/fabric"
+)
+
+// Defaults of CAConfig.
+const (
+	// DefaultCAFabricID is the fabric a CertificateAuthority issues for.
+	DefaultCAFabricID fabric.FabricID = 1
+
+	// DefaultCAVendorID is the admin vendor ID installed on devices: the
+	// test vendor 0xFFF1.
+	DefaultCAVendorID fabric.VendorID = 0xFFF1
+)
+
+// certificateClockSkew backdates the certificates the CA issues, so peers
+// whose clocks run behind accept them.
+const certificateClockSkew = time.Hour
+
+// CA errors.
+var (
+	// ErrNodeIDsExhausted indicates the CA has issued every operational
+	// node ID.
+	ErrNodeIDsExhausted = errors.New("commissioning: operational node IDs exhausted")
+
+	// ErrInvalidPublicKey indicates a public key that is not an
+	// uncompressed P-256 point.
+	ErrInvalidPublicKey = errors.New("commissioning: invalid public key")
+)
+
+// CAConfig configures a CertificateAuthority.
+type CAConfig struct {
+	// FabricID is the fabric the CA issues NOCs for.
+	// Defaults to DefaultCAFabricID if zero.
+	FabricID fabric.FabricID
+
+	// RootKey signs the root certificate and the NOCs.
+	// If nil, a key is generated.
+	RootKey gocrypto.Signer
+
+	// IPK is the fabric's epoch key, installed on devices with their NOC.
+	// If zero, a random key is generated.
+	IPK [fabric.IPKSize]byte
+
+	// VendorID is the admin vendor ID installed on devices.
+	// Defaults to DefaultCAVendorID if zero.
+	VendorID fabric.VendorID
+
+	// FirstNodeID is the first node ID the CA hands out.
+	// Defaults to 1 if zero.
+	FirstNodeID fabric.NodeID
+}
+
+// CertificateAuthority issues the operational certificates of a fabric
+// from a root certificate (RCAC), without intermediate. Node IDs are
+// handed out in sequence, so commissioners sharing a CA, e.g. the
+// operations of a batch, give each device a node ID of its own.
+//
+// C++ Reference: src/controller/ExampleOperationalCredentialsIssuer.cpp
+type CertificateAuthority struct {
+	config      CAConfig
+	rootCert    []byte
+	rootSubject credentials.DistinguishedName
+	rootKeyID   [20]byte
+
+	mu         sync.Mutex
+	nextNodeID fabric.NodeID
+	serial     uint64
+}
+
+// NewCertificateAuthority creates a CA and its self-signed root
+// certificate.
+func NewCertificateAuthority(config CAConfig) (*CertificateAuthority, error) {
+	if config.FabricID == 0 {
+		config.FabricID = DefaultCAFabricID
+	}
+	if config.VendorID == 0 {
+		config.VendorID = DefaultCAVendorID
+	}
+	if config.FirstNodeID == 0 {
+		config.FirstNodeID = 1
+	}
+	if config.RootKey == nil {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		config.RootKey = key
+	}
+	if config.IPK == ([fabric.IPKSize]byte{}) {
+		if _, err := rand.Read(config.IPK[:]); err != nil {
+			return nil, err
+		}
+	}
+
+	rootPub, err := crypto.P256SignerPublicKey(config.RootKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ca := &CertificateAuthority{
+		config:     config,
+		rootKeyID:  sha1.Sum(rootPub),
+		nextNodeID: config.FirstNodeID,
+	}
+
+	// A random root ID tells the roots of separate CAs apart
+	var rcacID [8]byte
+	if _, err := rand.Read(rcacID[:]); err != nil {
+		return nil, err
+	}
+	ca.rootSubject = credentials.DistinguishedName{
+		credentials.NewDNUint64(credentials.TagDNMatterRCACID, binary.BigEndian.Uint64(rcacID[:])),
+	}
+	rcac := ca.newCertificate(ca.rootSubject, rootPub)
+	rcac.Extensions = credentials.Extensions{
+		BasicConstraints: &credentials.BasicConstraints{IsCA: true},
+		KeyUsage:         &credentials.KeyUsageExt{Usage: credentials.KeyUsageKeyCertSign | credentials.KeyUsageCRLSign},
+		SubjectKeyID:     &credentials.SubjectKeyIDExt{KeyID: ca.rootKeyID},
+		AuthorityKeyID:   &credentials.AuthorityKeyIDExt{KeyID: ca.rootKeyID},
+	}
+	if ca.rootCert, err = ca.sign(rcac); err != nil {
+		return nil, err
+	}
+	return ca, nil
+}
+
+// RootCert returns the root certificate in Matter TLV encoding.
+func (ca *CertificateAuthority) RootCert() []byte {
+	return ca.rootCert
+}
+
+// FabricID returns the fabric the CA issues NOCs for.
+func (ca *CertificateAuthority) FabricID() fabric.FabricID {
+	return ca.config.FabricID
+}
+
+// IPK returns the fabric's epoch key.
+func (ca *CertificateAuthority) IPK() [fabric.IPKSize]byte {
+	return ca.config.IPK
+}
+
+// VendorID returns the admin vendor ID installed on devices.
+func (ca *CertificateAuthority) VendorID() fabric.VendorID {
+	return ca.config.VendorID
+}
+
+// AllocateNodeID returns the next node ID. No two calls return the same
+// ID.
+//
+// Returns ErrNodeIDsExhausted once the operational node IDs run out.
+func (ca *CertificateAuthority) AllocateNodeID() (fabric.NodeID, error) {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	if !ca.nextNodeID.IsOperational() {
+		return 0, ErrNodeIDsExhausted
+	}
+	nodeID := ca.nextNodeID
+	ca.nextNodeID++
+	return nodeID, nil
+}
+
+// IssueNOC issues a NOC for a node's operational public key (65 bytes,
+// uncompressed).
+func (ca *CertificateAuthority) IssueNOC(publicKey []byte, nodeID fabric.NodeID) ([]byte, error) {
+	if len(publicKey) != crypto.P256PublicKeySizeBytes || publicKey[0] != 0x04 {
+		return nil, ErrInvalidPublicKey
+	}
+	if !nodeID.IsOperational() {
+		return nil, fabric.ErrInvalidNodeID
+	}
+
+	noc := ca.newCertificate(credentials.DistinguishedName{
+		credentials.NewDNUint64(credentials.TagDNMatterNodeID, uint64(nodeID)),
+		credentials.NewDNUint64(credentials.TagDNMatterFabricID, uint64(ca.config.FabricID)),
+	}, publicKey)
+	noc.Extensions = credentials.Extensions{
+		BasicConstraints: &credentials.BasicConstraints{IsCA: false},
+		KeyUsage:         &credentials.KeyUsageExt{Usage: credentials.KeyUsageDigitalSignature},
+		ExtendedKeyUsage: &credentials.ExtendedKeyUsageExt{
+			KeyPurposes: []credentials.KeyPurposeID{credentials.KeyPurposeClientAuth, credentials.KeyPurposeServerAuth},
+		},
+		SubjectKeyID:   &credentials.SubjectKeyIDExt{KeyID: sha1.Sum(publicKey)},
+		AuthorityKeyID: &credentials.AuthorityKeyIDExt{KeyID: ca.rootKeyID},
+	}
+	return ca.sign(noc)
+}
+
+// IssueFabricInfo issues a NOC with the next node ID for the operational
+// key of a controller and returns the fabric info it joins the fabric
+// with, at index. The key handle is left for the caller to set.
+func (ca *CertificateAuthority) IssueFabricInfo(index fabric.FabricIndex, operationalKey gocrypto.Signer) (*fabric.FabricInfo, error) {
+	publicKey, err := crypto.P256SignerPublicKey(operationalKey)
+	if err != nil {
+		return nil, err
+	}
+	nodeID, err := ca.AllocateNodeID()
+	if err != nil {
+		return nil, err
+	}
+	noc, err := ca.IssueNOC(publicKey, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	return fabric.NewFabricInfo(index, ca.rootCert, noc, nil, ca.config.VendorID, ca.config.IPK)
+}
+
+// newCertificate returns an unsigned certificate issued by the root, with
+// a fresh serial number, valid from now on without expiry.
+func (ca *CertificateAuthority) newCertificate(subject credentials.DistinguishedName, publicKey []byte) *credentials.Certificate {
+	ca.mu.Lock()
+	ca.serial++
+	serial := ca.serial
+	ca.mu.Unlock()
+
+	var serialNum [8]byte
+	binary.BigEndian.PutUint64(serialNum[:], serial)
+
+	return &credentials.Certificate{
+		SerialNum:  serialNum[:],
+		SigAlgo:    credentials.SignatureAlgoECDSASHA256,
+		Issuer:     ca.rootSubject,
+		NotBefore:  credentials.TimeToMatterEpoch(time.Now().Add(-certificateClockSkew)),
+		NotAfter:   0, // No well-defined expiration
+		Subject:    subject,
+		PubKeyAlgo: credentials.PublicKeyAlgoEC,
+		ECCurveID:  credentials.EllipticCurvePrime256v1,
+		ECPubKey:   publicKey,
+	}
+}
+
+// sign signs a certificate with the root key and encodes it.
+func (ca *CertificateAuthority) sign(cert *credentials.Certificate) ([]byte, error) {
+	tbs, err := cert.TBSData()
+	if err != nil {
+		return nil, err
+	}
+	if cert.Signature, err = crypto.P256SignWith(ca.config.RootKey, tbs); err != nil {
+		return nil, err
+	}
+	return cert.EncodeTLV()
+}
@@ -0,0 +1,270 @@
+package commissioning
+
+import (
+	"context"
+	gocrypto "crypto"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/backkem/matter/pkg/exchange"
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/logger"
+	"github.com/backkem/matter/pkg/message"
+	"github.com/backkem/matter/pkg/securechannel"
+	casesession "github.com/backkem/matter/pkg/securechannel/case"
+	"github.com/backkem/matter/pkg/session"
+	"github.com/backkem/matter/pkg/transport"
+	"github.com/pion/logging"
+)
+
+// DefaultCASETimeout is the default timeout for CASE establishment.
+const DefaultCASETimeout = 30 * time.Second
+
+// CASE protocol errors.
+var (
+	ErrCASETimeout  = errors.New("case: handshake timeout")
+	ErrCASEProtocol = errors.New("case: protocol error")
+	ErrCASECanceled = errors.New("case: handshake canceled")
+)
+
+// CASEClient handles CASE session establishment as the initiator.
+//
+// The CASE flow (initiator perspective):
+//  1. Send Sigma1
+//  2. Receive Sigma2, send Sigma3
+//  3. Receive StatusReport (success/failure)
+//
+// With resumption, the responder may answer Sigma1 with Sigma2Resume
+// instead, which the initiator confirms with a success StatusReport.
+//
+// Spec: Section 4.14.2 (CASE)
+type CASEClient struct {
+	exchangeManager *exchange.Manager
+	secureChannel   *securechannel.Manager
+	sessionManager  *session.Manager
+	timeout         time.Duration
+	log             logging.LeveledLogger
+}
+
+// CASEClientConfig configures the CASEClient.
+type CASEClientConfig struct {
+	ExchangeManager *exchange.Manager
+	SecureChannel   *securechannel.Manager
+	SessionManager  *session.Manager
+	Timeout         time.Duration
+
+	// LoggerFactory is the factory for creating loggers.
+	// If nil, logging is disabled.
+	LoggerFactory logging.LoggerFactory
+}
+
+// NewCASEClient creates a new CASE client.
+func NewCASEClient(config CASEClientConfig) *CASEClient {
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = DefaultCASETimeout
+	}
+
+	c := &CASEClient{
+		exchangeManager: config.ExchangeManager,
+		secureChannel:   config.SecureChannel,
+		sessionManager:  config.SessionManager,
+		timeout:         timeout,
+	}
+
+	if config.LoggerFactory != nil {
+		c.log = config.LoggerFactory.NewLogger("case")
+	}
+
+	return c
+}
+
+// Establish performs the CASE handshake with a node on our fabric and
+// returns the established secure session. If resumption is not nil, the
+// handshake tries to resume that previous session.
+func (c *CASEClient) Establish(
+	ctx context.Context,
+	peerAddr transport.PeerAddress,
+	fabricInfo *fabric.FabricInfo,
+	operationalKey gocrypto.Signer,
+	peerNodeID fabric.NodeID,
+	resumption *casesession.ResumptionInfo,
+) (*session.SecureContext, error) {
+	if c.log != nil {
+		c.log.Infof("starting CASE with node 0x%016X at %s", logger.Redact(uint64(peerNodeID)), peerAddr.Addr)
+	}
+
+	// Apply timeout
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	// Sessions to the peer before the handshake, to tell the new one apart
+	existing := make(map[uint16]bool)
+	for _, sess := range c.sessionManager.FindSecureContextByPeer(fabricInfo.FabricIndex, peerNodeID) {
+		existing[sess.LocalSessionID()] = true
+	}
+
+	unsecuredSess, err := c.sessionManager.CreateUnsecuredInitiatorContext()
+	if err != nil {
+		return nil, err
+	}
+	defer c.sessionManager.RemoveUnsecuredContext(unsecuredSess.EphemeralNodeID())
+
+	handler := newCASEHandler(c.secureChannel)
+	exch, err := c.exchangeManager.NewExchange(
+		unsecuredSess,
+		0, // Session ID 0 for unsecured
+		peerAddr,
+		message.ProtocolSecureChannel,
+		handler,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer exch.Close()
+
+	// Step 1: Send Sigma1
+	sigma1, err := c.secureChannel.StartCASE(exch.ID, fabricInfo, operationalKey, uint64(peerNodeID), resumption)
+	if err != nil {
+		return nil, err
+	}
+	if err := exch.SendMessage(uint8(securechannel.OpcodeCASESigma1), sigma1, true); err != nil {
+		return nil, err
+	}
+
+	// Step 2: Wait for Sigma2 and send Sigma3, or for Sigma2Resume and
+	// confirm the resumed session
+	next, err := handler.waitForNextMessage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if next == nil {
+		return nil, ErrCASEProtocol
+	}
+	if err := exch.SendMessage(uint8(next.Opcode), next.Payload, true); err != nil {
+		return nil, err
+	}
+
+	// Step 3: Wait for the StatusReport completing a full handshake
+	if next.Opcode == securechannel.OpcodeCASESigma3 {
+		if _, err := handler.waitForNextMessage(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	var secureCtx *session.SecureContext
+	for _, sess := range c.sessionManager.FindSecureContextByPeer(fabricInfo.FabricIndex, peerNodeID) {
+		if !existing[sess.LocalSessionID()] && sess.SessionType() == session.SessionTypeCASE {
+			secureCtx = sess
+			break
+		}
+	}
+	if secureCtx == nil {
+		return nil, ErrCASEProtocol
+	}
+	return secureCtx, nil
+}
+
+// caseHandler handles CASE response messages.
+type caseHandler struct {
+	secureChannel *securechannel.Manager
+
+	// Channel for passing processed messages (next message to send or nil on complete)
+	msgCh chan caseResult
+
+	mu   sync.Mutex
+	done bool
+}
+
+type caseResult struct {
+	nextMsg *securechannel.Message
+	err     error
+}
+
+func newCASEHandler(secureChannel *securechannel.Manager) *caseHandler {
+	return &caseHandler{
+		secureChannel: secureChannel,
+		msgCh:         make(chan caseResult, 1),
+	}
+}
+
+// OnMessage implements exchange.ExchangeDelegate.
+func (h *caseHandler) OnMessage(
+	ctx *exchange.ExchangeContext,
+	header *message.ProtocolHeader,
+	payload []byte,
+) ([]byte, error) {
+	h.mu.Lock()
+	if h.done {
+		h.mu.Unlock()
+		return nil, nil
+	}
+	h.mu.Unlock()
+
+	opcode := securechannel.Opcode(header.ProtocolOpcode)
+	if opcode == securechannel.OpcodeStandaloneAck ||
+		opcode == securechannel.OpcodeMsgCounterSyncReq ||
+		opcode == securechannel.OpcodeMsgCounterSyncResp {
+		return nil, nil
+	}
+
+	nextMsg, err := h.secureChannel.Route(ctx.ID, &securechannel.Message{
+		Opcode:  opcode,
+		Payload: payload,
+	})
+	if err != nil {
+		h.sendResult(caseResult{err: err})
+		return nil, err
+	}
+
+	if opcode == securechannel.OpcodeStatusReport {
+		status, err := securechannel.DecodeStatusReport(payload)
+		if err != nil {
+			h.sendResult(caseResult{err: err})
+			return nil, err
+		}
+		if !status.IsSuccess() {
+			h.sendResult(caseResult{err: ErrCASEProtocol})
+			return nil, ErrCASEProtocol
+		}
+
+		h.mu.Lock()
+		h.done = true
+		h.mu.Unlock()
+
+		h.sendResult(caseResult{nextMsg: nil})
+		return nil, nil
+	}
+
+	h.sendResult(caseResult{nextMsg: nextMsg})
+	return nil, nil
+}
+
+// OnClose implements exchange.ExchangeDelegate.
+func (h *caseHandler) OnClose(ctx *exchange.ExchangeContext) {
+	h.sendResult(caseResult{err: ErrCASECanceled})
+}
+
+func (h *caseHandler) sendResult(result caseResult) {
+	select {
+	case h.msgCh <- result:
+	default:
+		// Channel full, drop
+	}
+}
+
+func (h *caseHandler) waitForNextMessage(ctx context.Context) (*securechannel.Message, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ErrCASETimeout
+	case result := <-h.msgCh:
+		if result.err != nil {
+			return nil, result.err
+		}
+		return result.nextMsg, nil
+	}
+}
@@ -1,7 +1,14 @@
 package commissioning
 
 import (
+	"bytes"
 	"context"
+	gocrypto "crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"net"
 	"sync"
@@ -9,6 +16,7 @@ import (
 
 	"github.com/backkem/matter/pkg/clusters/generalcommissioning"
 	"github.com/backkem/matter/pkg/commissioning/payload"
+	"github.com/backkem/matter/pkg/crypto"
 	"github.com/backkem/matter/pkg/discovery"
 	"github.com/backkem/matter/pkg/exchange"
 	"github.com/backkem/matter/pkg/fabric"
@@ -16,6 +24,7 @@ import (
 	"github.com/backkem/matter/pkg/securechannel"
 	"github.com/backkem/matter/pkg/session"
 	"github.com/backkem/matter/pkg/transport"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // DefaultCommissioningTimeout is the default timeout for the entire
@@ -43,13 +52,26 @@ type CommissionerConfig struct {
 	// Required for IM commands (ArmFailSafe, CommissioningComplete, etc.).
 	ExchangeManager *exchange.Manager
 
-	// FabricInfo for the commissioner's fabric.
-	// Used when adding the device to the fabric.
+	// FabricInfo for the commissioner's fabric, issued by CA.
+	// Devices grant its node ID Administer privilege, and the
+	// commissioner establishes CASE sessions with them as this node.
 	FabricInfo *fabric.FabricInfo
 
+	// OperationalKey is the private key of FabricInfo's NOC.
+	OperationalKey gocrypto.Signer
+
+	// CA issues the NOCs installed on devices. Commissioners sharing a
+	// CA, like the operations of a batch, give each device a node ID of
+	// its own. Required, with FabricInfo and OperationalKey, to
+	// commission devices over the exchange manager.
+	CA *CertificateAuthority
+
 	// Callbacks for commissioning events.
 	Callbacks CommissionerCallbacks
 
+	// Hooks run custom logic at stages of the commissioning flow.
+	Hooks CommissionerHooks
+
 	// Timeout for overall commissioning process.
 	// Defaults to DefaultCommissioningTimeout if zero.
 	Timeout time.Duration
@@ -66,6 +88,17 @@ type CommissionerConfig struct {
 	// If nil, NewAcceptAllVerifier() is used (accepts all devices).
 	// See docs/pkgs/attestation.md for design rationale.
 	AttestationVerifier AttestationVerifier
+
+	// RevocationSet lists the revoked DACs and PAIs, e.g. read from the
+	// DCL with ReadRevocationSet. A device whose attestation chain is
+	// revoked is only commissioned if Callbacks.OnRevokedDevice agrees.
+	// If nil, revocation is not checked.
+	RevocationSet *RevocationSet
+
+	// TracerProvider creates a span for the commissioning flow with a
+	// child span per step; IM requests nest under the step spans.
+	// If nil, the global provider is used (a no-op unless set).
+	TracerProvider trace.TracerProvider
 }
 
 // CommissionerCallbacks provides event callbacks during commissioning.
@@ -82,6 +115,13 @@ type CommissionerCallbacks struct {
 	// If nil, attestation is automatically accepted.
 	OnDeviceAttestationResult func(result *AttestationResult) bool
 
+	// OnRevokedDevice is the policy for a device whose DAC or PAI is in
+	// the RevocationSet, called with result.Revocation before
+	// OnDeviceAttestationResult. Return true to continue commissioning,
+	// false to abort with ErrDeviceRevoked.
+	// If nil, commissioning of revoked devices is aborted.
+	OnRevokedDevice func(result *AttestationResult) bool
+
 	// OnCommissioningComplete is called when commissioning succeeds.
 	OnCommissioningComplete func(nodeID fabric.NodeID)
 
@@ -109,6 +149,14 @@ type AttestationResult struct {
 	// AttestationNonce is the nonce used for attestation.
 	AttestationNonce []byte
 
+	// DAC is the device's attestation certificate (DER encoded). Its key
+	// signs the device's CSR.
+	DAC []byte
+
+	// Revocation is the revocation status of the DAC and PAI, checked if
+	// the commissioner has a RevocationSet.
+	Revocation RevocationStatus
+
 	// Error is set if attestation verification failed.
 	Error error
 }
@@ -140,6 +188,7 @@ type Commissioner struct {
 	config   CommissionerConfig
 	state    CommissionerState
 	imClient *im.Client
+	tracer   trace.Tracer
 	mu       sync.RWMutex
 
 	// Current commissioning context
@@ -172,6 +221,7 @@ func NewCommissioner(config CommissionerConfig) *Commissioner {
 	c := &Commissioner{
 		config: config,
 		state:  CommissionerStateIdle,
+		tracer: newTracer(config.TracerProvider),
 	}
 
 	// Create IM client if exchange manager is provided
@@ -179,6 +229,7 @@ func NewCommissioner(config CommissionerConfig) *Commissioner {
 		c.imClient = im.NewClient(im.ClientConfig{
 			ExchangeManager: config.ExchangeManager,
 			Timeout:         config.PASETimeout, // Use PASE timeout for IM requests
+			TracerProvider:  config.TracerProvider,
 		})
 	}
 
@@ -246,6 +297,19 @@ func (c *Commissioner) CommissionFromManualCode(ctx context.Context, code string
 //  8. Establish CASE session
 //  9. Send CommissioningComplete
 func (c *Commissioner) CommissionFromPayload(ctx context.Context, p *payload.SetupPayload) error {
+	return c.commission(ctx, p, nil)
+}
+
+// CommissionAtAddress commissions a device at a known address, e.g. one
+// the user entered or a node on a transport.PipeNetwork, skipping
+// discovery. The flow is otherwise that of CommissionFromPayload.
+func (c *Commissioner) CommissionAtAddress(ctx context.Context, addr transport.PeerAddress, p *payload.SetupPayload) error {
+	return c.commission(ctx, p, &addr)
+}
+
+// commission runs the commissioning flow for a device, at addr if not
+// nil.
+func (c *Commissioner) commission(ctx context.Context, p *payload.SetupPayload, addr *transport.PeerAddress) error {
 	c.mu.Lock()
 	if c.state != CommissionerStateIdle {
 		c.mu.Unlock()
@@ -259,8 +323,15 @@ func (c *Commissioner) CommissionFromPayload(ctx context.Context, p *payload.Set
 	c.cancelFunc = cancel
 	defer cancel()
 
+	ctx, span := c.tracer.Start(ctx, "commissioning",
+		trace.WithAttributes(
+			attrVendorID.Int(int(p.VendorID)),
+			attrProductID.Int(int(p.ProductID)),
+		))
+
 	// Run the commissioning flow
-	err := c.runCommissioningFlow(ctx, p)
+	err := c.runCommissioningFlow(ctx, p, addr)
+	endSpan(span, err)
 
 	// Handle result
 	c.mu.Lock()
@@ -282,35 +353,45 @@ func (c *Commissioner) CommissionFromPayload(ctx context.Context, p *payload.Set
 	return err
 }
 
-// runCommissioningFlow executes the commissioning steps.
-func (c *Commissioner) runCommissioningFlow(ctx context.Context, p *payload.SetupPayload) error {
+// runCommissioningFlow executes the commissioning steps. The device is
+// discovered unless its address is given.
+func (c *Commissioner) runCommissioningFlow(ctx context.Context, p *payload.SetupPayload, addr *transport.PeerAddress) error {
 	var err error
 
 	// Step 1: Discover device
-	c.progress(5, "Discovering device...")
-	c.setState(CommissionerStateDiscovering)
-	device, err := c.discoverDevice(ctx, p)
-	if err != nil {
-		return err
-	}
-	c.mu.Lock()
-	c.currentDevice = device
-	// Store peer address for IM communication
-	if len(device.IPs) > 0 {
-		c.peerAddress = transport.PeerAddress{
-			Addr: &net.UDPAddr{
-				IP:   device.IPs[0],
-				Port: device.Port,
-			},
-			TransportType: transport.TransportTypeUDP,
+	if addr == nil {
+		c.progress(5, "Discovering device...")
+		c.setState(CommissionerStateDiscovering)
+		var device *discovery.ResolvedService
+		err = c.step(ctx, "discovery", func(ctx context.Context) (err error) {
+			device, err = c.discoverDevice(ctx, p)
+			return err
+		})
+		if err != nil {
+			return err
 		}
+		deviceAddr, ok := deviceAddress(device)
+		if !ok {
+			return ErrDeviceNotFound
+		}
+		addr = &deviceAddr
+		c.mu.Lock()
+		c.currentDevice = device
+		c.mu.Unlock()
 	}
+	// Store peer address for IM communication
+	c.mu.Lock()
+	c.peerAddress = *addr
 	c.mu.Unlock()
 
 	// Step 2: Establish PASE session
 	c.progress(15, "Establishing PASE session...")
 	c.setState(CommissionerStatePASE)
-	paseSession, err := c.establishPASE(ctx, device, p)
+	var paseSession *session.SecureContext
+	err = c.step(ctx, "pase", func(ctx context.Context) (err error) {
+		paseSession, err = c.establishPASE(ctx, *addr, p)
+		return err
+	})
 	if err != nil {
 		return err
 	}
@@ -318,46 +399,85 @@ func (c *Commissioner) runCommissioningFlow(ctx context.Context, p *payload.Setu
 	c.paseSession = paseSession
 	c.mu.Unlock()
 
+	return c.commissionOverPASE(ctx, paseSession)
+}
+
+// commissionOverPASE executes the commissioning steps that follow PASE
+// session establishment.
+func (c *Commissioner) commissionOverPASE(ctx context.Context, paseSession *session.SecureContext) error {
+	var err error
+
 	// Step 3: Arm fail-safe
 	c.progress(25, "Arming fail-safe timer...")
 	c.setState(CommissionerStateArmingFailSafe)
-	if err := c.armFailSafe(ctx, paseSession); err != nil {
+	if err := c.step(ctx, "arm_fail_safe", func(ctx context.Context) error {
+		return c.armFailSafe(ctx, paseSession)
+	}); err != nil {
 		return err
 	}
 
 	// Step 4: Device attestation
 	c.progress(35, "Verifying device attestation...")
 	c.setState(CommissionerStateDeviceAttestation)
-	if err := c.performDeviceAttestation(ctx, paseSession); err != nil {
+	var attestation *AttestationResult
+	err = c.step(ctx, "attestation", func(ctx context.Context) (err error) {
+		attestation, err = c.performDeviceAttestation(ctx, paseSession)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	stage := Stage{Session: paseSession, Attestation: attestation}
+	if err := c.runHook(ctx, "after_attestation", c.config.Hooks.AfterAttestation, stage); err != nil {
 		return err
 	}
 
 	// Step 5: Request CSR and add NOC
 	c.progress(50, "Installing operational credentials...")
 	c.setState(CommissionerStateCSRRequest)
-	nodeID, err := c.requestCSRAndAddNOC(ctx, paseSession)
+	if err := c.runHook(ctx, "before_noc", c.config.Hooks.BeforeNOC, stage); err != nil {
+		return err
+	}
+	var nodeID fabric.NodeID
+	err = c.step(ctx, "noc", func(ctx context.Context) (err error) {
+		nodeID, err = c.requestCSRAndAddNOC(ctx, paseSession, attestation)
+		return err
+	})
 	if err != nil {
 		return err
 	}
+	trace.SpanFromContext(ctx).SetAttributes(attrNodeID.Int64(int64(nodeID)))
 
 	// Step 6: Configure network (if needed)
 	c.progress(65, "Configuring operational network...")
 	c.setState(CommissionerStateNetworkConfig)
-	if err := c.configureNetwork(ctx, paseSession); err != nil {
+	if err := c.step(ctx, "network_config", func(ctx context.Context) error {
+		return c.configureNetwork(ctx, paseSession)
+	}); err != nil {
+		return err
+	}
+	stage.NodeID = nodeID
+	if err := c.runHook(ctx, "after_network_config", c.config.Hooks.AfterNetworkConfig, stage); err != nil {
 		return err
 	}
 
 	// Step 7: Operational discovery
 	c.progress(75, "Discovering on operational network...")
 	c.setState(CommissionerStateOperationalDiscovery)
-	if err := c.discoverOperational(ctx, nodeID); err != nil {
+	if err := c.step(ctx, "operational_discovery", func(ctx context.Context) error {
+		return c.discoverOperational(ctx, nodeID)
+	}); err != nil {
 		return err
 	}
 
 	// Step 8: Establish CASE session
 	c.progress(85, "Establishing CASE session...")
 	c.setState(CommissionerStateCASE)
-	caseSession, err := c.establishCASE(ctx, nodeID)
+	var caseSession *session.SecureContext
+	err = c.step(ctx, "case", func(ctx context.Context) (err error) {
+		caseSession, err = c.establishCASE(ctx, nodeID)
+		return err
+	})
 	if err != nil {
 		return err
 	}
@@ -367,7 +487,9 @@ func (c *Commissioner) runCommissioningFlow(ctx context.Context, p *payload.Setu
 
 	// Step 9: Commissioning complete
 	c.progress(95, "Completing commissioning...")
-	if err := c.sendCommissioningComplete(ctx, caseSession); err != nil {
+	if err := c.step(ctx, "complete", func(ctx context.Context) error {
+		return c.sendCommissioningComplete(ctx, caseSession)
+	}); err != nil {
 		return err
 	}
 
@@ -424,8 +546,22 @@ func (c *Commissioner) discoverDevice(ctx context.Context, p *payload.SetupPaylo
 	}
 }
 
+// deviceAddress returns the address to reach a discovered device at: its
+// most preferred address, a link-local one scoped to an interface of the
+// host.
+func deviceAddress(device *discovery.ResolvedService) (transport.PeerAddress, bool) {
+	local, _ := discovery.InterfaceAddresses(nil)
+	if addrs := device.UDPAddrs(local); len(addrs) > 0 {
+		return transport.NewUDPPeerAddress(addrs[0]), true
+	}
+	if ip := device.PreferredIP(); ip != nil {
+		return transport.NewUDPPeerAddress(&net.UDPAddr{IP: ip, Port: device.Port}), true
+	}
+	return transport.PeerAddress{}, false
+}
+
 // establishPASE establishes a PASE session with the device.
-func (c *Commissioner) establishPASE(ctx context.Context, device *discovery.ResolvedService, p *payload.SetupPayload) (*session.SecureContext, error) {
+func (c *Commissioner) establishPASE(ctx context.Context, peerAddr transport.PeerAddress, p *payload.SetupPayload) (*session.SecureContext, error) {
 	if c.config.SecureChannel == nil {
 		return nil, ErrNilConfig
 	}
@@ -436,20 +572,6 @@ func (c *Commissioner) establishPASE(ctx context.Context, device *discovery.Reso
 		return nil, ErrNilConfig
 	}
 
-	// Get device address
-	if len(device.IPs) == 0 {
-		return nil, ErrDeviceNotFound
-	}
-
-	// Build peer address
-	peerAddr := transport.PeerAddress{
-		Addr: &net.UDPAddr{
-			IP:   device.IPs[0],
-			Port: device.Port,
-		},
-		TransportType: transport.TransportTypeUDP,
-	}
-
 	// Create PASE client
 	paseClient := NewPASEClient(PASEClientConfig{
 		ExchangeManager: c.config.ExchangeManager,
@@ -539,7 +661,7 @@ func (c *Commissioner) armFailSafe(ctx context.Context, sess *session.SecureCont
 //
 // The verifier determines how strict the verification is.
 // See docs/pkgs/attestation.md for the pluggable design.
-func (c *Commissioner) performDeviceAttestation(ctx context.Context, sess *session.SecureContext) error {
+func (c *Commissioner) performDeviceAttestation(ctx context.Context, sess *session.SecureContext) (*AttestationResult, error) {
 	// Skip if no IM client (testing mode without full stack)
 	if c.imClient == nil {
 		result := &AttestationResult{
@@ -548,26 +670,31 @@ func (c *Commissioner) performDeviceAttestation(ctx context.Context, sess *sessi
 		}
 		if c.config.Callbacks.OnDeviceAttestationResult != nil {
 			if !c.config.Callbacks.OnDeviceAttestationResult(result) {
-				return ErrAttestationFailed
+				return nil, ErrAttestationFailed
 			}
 		}
-		return nil
+		return result, nil
 	}
 
 	c.mu.RLock()
 	peerAddr := c.peerAddress
 	c.mu.RUnlock()
 
-	// Execute the attestation protocol and verify using the configured verifier
+	// Execute the attestation protocol and verify using the configured
+	// verifier, then against the revocation set
+	verifier := c.config.AttestationVerifier
+	if c.config.RevocationSet != nil {
+		verifier = revocationVerifier{verifier: verifier, set: c.config.RevocationSet}
+	}
 	attestResult, err := PerformDeviceAttestation(
 		ctx,
 		c.imClient,
 		sess,
 		peerAddr,
-		c.config.AttestationVerifier,
+		verifier,
 	)
 	if err != nil {
-		return fmt.Errorf("device attestation: %w", err)
+		return nil, fmt.Errorf("device attestation: %w", err)
 	}
 
 	// Convert to the commissioner's result type
@@ -578,62 +705,248 @@ func (c *Commissioner) performDeviceAttestation(ctx context.Context, sess *sessi
 		ProductID:              attestResult.ProductID,
 		CertificateDeclaration: attestResult.CertificateDeclaration,
 		AttestationNonce:       attestResult.AttestationNonce,
+		DAC:                    attestResult.DAC,
+		Revocation:             attestResult.Revocation,
+	}
+
+	if err := c.checkRevocation(result); err != nil {
+		return nil, err
 	}
 
 	// Check with callback if provided
 	if c.config.Callbacks.OnDeviceAttestationResult != nil {
 		if !c.config.Callbacks.OnDeviceAttestationResult(result) {
-			return ErrAttestationFailed
+			return nil, ErrAttestationFailed
 		}
 	}
 
-	return nil
+	return result, nil
 }
 
-// requestCSRAndAddNOC requests CSR and installs operational credentials.
-func (c *Commissioner) requestCSRAndAddNOC(ctx context.Context, sess *session.SecureContext) (fabric.NodeID, error) {
-	// TODO: Implement CSR request and NOC installation
-	// This requires:
-	// 1. Send CSRRequest command
-	// 2. Parse CSR response
-	// 3. Generate NOC from CSR
-	// 4. Send AddNOC command
+// checkRevocation applies the OnRevokedDevice policy to a device whose
+// attestation chain is revoked.
+func (c *Commissioner) checkRevocation(result *AttestationResult) error {
+	if !result.Revocation.Revoked() {
+		return nil
+	}
+	if c.config.Callbacks.OnRevokedDevice != nil && c.config.Callbacks.OnRevokedDevice(result) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrDeviceRevoked, result.Revocation)
+}
 
-	_ = ctx
-	_ = sess
+// requestCSRAndAddNOC installs the device's operational credentials and
+// returns its node ID:
+//  1. Send CSRRequest with a random nonce
+//  2. Check the nonce and, if the DAC is known, the attestation signature
+//     of the CSRResponse
+//  3. Issue a NOC for the CSR's key with a node ID from the CA
+//  4. Send AddTrustedRootCertificate with the CA's root certificate
+//  5. Send AddNOC, granting the commissioner's node ID Administer privilege
+//
+// Spec Reference: Section 11.18.6.5-13
+func (c *Commissioner) requestCSRAndAddNOC(ctx context.Context, sess *session.SecureContext, attestation *AttestationResult) (fabric.NodeID, error) {
+	ca := c.config.CA
+	if c.imClient == nil {
+		// No IM client - skip (for testing without full stack)
+		if ca != nil {
+			return ca.AllocateNodeID()
+		}
+		return fabric.NodeIDMinOperational, nil
+	}
+	if ca == nil || c.config.FabricInfo == nil {
+		return 0, ErrNilConfig
+	}
 
-	// Return a placeholder node ID
-	return fabric.NodeID(0x0001), nil
+	c.mu.RLock()
+	peerAddr := c.peerAddress
+	c.mu.RUnlock()
+
+	publicKey, err := c.requestCSR(ctx, sess, peerAddr, attestation)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrCSRFailed, err)
+	}
+
+	nodeID, err := ca.AllocateNodeID()
+	if err != nil {
+		return 0, err
+	}
+	noc, err := ca.IssueNOC(publicKey, nodeID)
+	if err != nil {
+		return 0, fmt.Errorf("%w: issue NOC: %v", ErrAddNOCFailed, err)
+	}
+
+	reqData, err := encodeAddTrustedRootCertificate(ca.RootCert())
+	if err != nil {
+		return 0, err
+	}
+	if _, err := c.imClient.InvokeRequest(ctx, sess, peerAddr, 0,
+		OperationalCredentialsClusterID, CmdAddTrustedRootCertificate, reqData); err != nil {
+		return 0, fmt.Errorf("%w: invoke AddTrustedRootCertificate: %v", ErrAddNOCFailed, err)
+	}
+
+	reqData, err = encodeAddNOC(noc, nil, ca.IPK(), uint64(c.config.FabricInfo.NodeID), ca.VendorID())
+	if err != nil {
+		return 0, err
+	}
+	respData, err := c.imClient.InvokeRequest(ctx, sess, peerAddr, 0,
+		OperationalCredentialsClusterID, CmdAddNOC, reqData)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invoke AddNOC: %v", ErrAddNOCFailed, err)
+	}
+	resp, err := decodeNOCResponse(respData)
+	if err != nil {
+		return 0, fmt.Errorf("%w: decode NOCResponse: %v", ErrAddNOCFailed, err)
+	}
+	if resp.Status != NOCStatusOK {
+		return 0, fmt.Errorf("%w: %s (%s)", ErrAddNOCFailed, resp.Status, resp.DebugText)
+	}
+
+	return nodeID, nil
+}
+
+// requestCSR sends CSRRequest and returns the public key of the CSR, in
+// uncompressed format.
+//
+// Spec Reference: Section 11.18.6.5 "CSRRequest"
+func (c *Commissioner) requestCSR(ctx context.Context, sess *session.SecureContext, peerAddr transport.PeerAddress, attestation *AttestationResult) ([]byte, error) {
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	reqData, err := encodeCSRRequest(nonce)
+	if err != nil {
+		return nil, err
+	}
+	respData, err := c.imClient.InvokeRequest(ctx, sess, peerAddr, 0,
+		OperationalCredentialsClusterID, CmdCSRRequest, reqData)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := decodeCSRResponse(respData)
+	if err != nil {
+		return nil, err
+	}
+
+	// The device signs the elements with its DAC key, bound to the session
+	if attestation != nil && attestation.DAC != nil {
+		if err := verifyAttestationSignature(attestation.DAC, resp.Elements, sess.AttestationChallenge(), resp.Signature); err != nil {
+			return nil, err
+		}
+	}
+
+	csrDER, csrNonce, err := decodeNOCSRElements(resp.Elements)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(csrNonce, nonce) {
+		return nil, errors.New("CSR nonce mismatch")
+	}
+	csr, err := x509.ParseCertificateRequest(csrDER)
+	if err != nil {
+		return nil, err
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, err
+	}
+	pub, ok := csr.PublicKey.(*ecdsa.PublicKey)
+	if !ok || pub.Curve != elliptic.P256() {
+		return nil, ErrInvalidPublicKey
+	}
+	ecdhPub, err := pub.ECDH()
+	if err != nil {
+		return nil, ErrInvalidPublicKey
+	}
+	return ecdhPub.Bytes(), nil
+}
+
+// verifyAttestationSignature verifies a signature of the DAC's key over
+// elements followed by the session's attestation challenge.
+func verifyAttestationSignature(dacDER, elements, challenge, signature []byte) error {
+	dac, err := x509.ParseCertificate(dacDER)
+	if err != nil {
+		return err
+	}
+	pub, ok := dac.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return ErrInvalidPublicKey
+	}
+	ecdhPub, err := pub.ECDH()
+	if err != nil {
+		return ErrInvalidPublicKey
+	}
+	msg := append(append([]byte(nil), elements...), challenge...)
+	valid, err := crypto.P256Verify(ecdhPub.Bytes(), msg, signature)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return errors.New("invalid attestation signature")
+	}
+	return nil
 }
 
 // configureNetwork configures the operational network on the device.
 func (c *Commissioner) configureNetwork(ctx context.Context, sess *session.SecureContext) error {
 	// TODO: Implement network configuration
-	// This requires NetworkCommissioning cluster commands
+	// This requires NetworkCommissioning cluster commands. Devices on the
+	// network they were commissioned over, e.g. Ethernet, need none.
 
 	_ = ctx
 	_ = sess
 	return nil
 }
 
-// discoverOperational discovers the device on the operational network.
+// discoverOperational finds the device on the operational network by its
+// operational instance name, and talks to it at the address found from
+// then on. Without a resolver, or if the device does not advertise in
+// time, it is reached at the address it was commissioned at.
 func (c *Commissioner) discoverOperational(ctx context.Context, nodeID fabric.NodeID) error {
-	// TODO: Implement operational discovery
-	// Browse for _matter._tcp with the node's operational instance name
+	if c.config.Resolver == nil || c.config.FabricInfo == nil {
+		return nil
+	}
 
-	_ = ctx
-	_ = nodeID
+	ctx, cancel := context.WithTimeout(ctx, c.config.DiscoveryTimeout)
+	defer cancel()
+
+	device, err := c.config.Resolver.LookupOperational(ctx, c.config.FabricInfo.CompressedFabricID, nodeID)
+	if err != nil {
+		return nil
+	}
+	if addr, ok := deviceAddress(device); ok {
+		c.mu.Lock()
+		c.peerAddress = addr
+		c.mu.Unlock()
+	}
 	return nil
 }
 
-// establishCASE establishes a CASE session with the commissioned device.
+// establishCASE establishes a CASE session with the commissioned device,
+// as the commissioner's node on its fabric.
 func (c *Commissioner) establishCASE(ctx context.Context, nodeID fabric.NodeID) (*session.SecureContext, error) {
-	// TODO: Implement CASE session establishment
-	// This requires the device to be on the operational network
+	if c.imClient == nil {
+		// No IM client - skip (for testing without full stack)
+		return nil, nil
+	}
+	if c.config.FabricInfo == nil || c.config.OperationalKey == nil || c.config.SecureChannel == nil {
+		return nil, ErrNilConfig
+	}
 
-	_ = ctx
-	_ = nodeID
-	return nil, nil
+	c.mu.RLock()
+	peerAddr := c.peerAddress
+	c.mu.RUnlock()
+
+	caseClient := NewCASEClient(CASEClientConfig{
+		ExchangeManager: c.config.ExchangeManager,
+		SecureChannel:   c.config.SecureChannel,
+		SessionManager:  c.config.SessionManager,
+		Timeout:         c.config.PASETimeout,
+	})
+	sess, err := caseClient.Establish(ctx, peerAddr, c.config.FabricInfo, c.config.OperationalKey, nodeID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCASEFailed, err)
+	}
+	return sess, nil
 }
 
 // sendCommissioningComplete sends the CommissioningComplete command.
@@ -646,11 +959,6 @@ func (c *Commissioner) sendCommissioningComplete(ctx context.Context, sess *sess
 		return nil
 	}
 
-	if sess == nil {
-		// Session can be nil in the skeleton implementation
-		return nil
-	}
-
 	c.mu.RLock()
 	peerAddr := c.peerAddress
 	c.mu.RUnlock()
@@ -5,11 +5,27 @@ import (
 	"sync"
 	"time"
 
+	"github.com/backkem/matter/pkg/clock"
 	"github.com/backkem/matter/pkg/discovery"
 	"github.com/backkem/matter/pkg/securechannel/pase"
 	"github.com/backkem/matter/pkg/session"
 )
 
+// DefaultMaxPASEAttempts is the number of failed PASE attempts after which
+// a commissioning window closes.
+//
+// C++ Reference: kMaxFailedCommissioningAttempts
+const DefaultMaxPASEAttempts = 20
+
+// DefaultPASERetryDelay is the time a commissioning window refuses PASE
+// after the first failed attempt. The delay doubles with each further
+// failed attempt, up to DefaultMaxPASERetryDelay.
+const DefaultPASERetryDelay = time.Second
+
+// DefaultMaxPASERetryDelay bounds the time a commissioning window refuses
+// PASE after a failed attempt.
+const DefaultMaxPASERetryDelay = 10 * time.Second
+
 // CommissioningWindowConfig configures a commissioning window.
 type CommissioningWindowConfig struct {
 	// Timeout is the duration of the commissioning window.
@@ -28,6 +44,29 @@ type CommissioningWindowConfig struct {
 	// DeviceName for DNS-SD (optional, max 32 chars).
 	DeviceName string
 
+	// Mode is the commissioning mode advertised while the window is open:
+	// CommissioningModeBasic for a window using the node's own passcode,
+	// CommissioningModeEnhanced for one opened by an administrator with
+	// its own PAKE parameters. Defaults to CommissioningModeBasic.
+	Mode discovery.CommissioningMode
+
+	// MaxPASEAttempts is the number of failed PASE attempts after which
+	// the window closes. Defaults to DefaultMaxPASEAttempts if zero.
+	MaxPASEAttempts int
+
+	// PASERetryDelay is the time PASE is refused after the first failed
+	// attempt; it doubles with each further one. Defaults to
+	// DefaultPASERetryDelay if zero.
+	PASERetryDelay time.Duration
+
+	// MaxPASERetryDelay bounds the time PASE is refused after a failed
+	// attempt. Defaults to DefaultMaxPASERetryDelay if zero.
+	MaxPASERetryDelay time.Duration
+
+	// FailedPASEAttempts is the number of attempts that already failed,
+	// e.g. before a reboot, so that a restart does not reset the count.
+	FailedPASEAttempts int
+
 	// Verifier for PASE authentication.
 	// If nil, must be set via SetVerifier before opening.
 	Verifier *pase.Verifier
@@ -42,6 +81,10 @@ type CommissioningWindowConfig struct {
 	// If nil, a default advertiser will be created.
 	Advertiser *discovery.Advertiser
 
+	// Clock times the window and the fail-safe.
+	// If nil, the real clock is used.
+	Clock clock.Clock
+
 	// OnStateChanged is called when the commissioning state changes.
 	OnStateChanged func(state DeviceCommissioningState)
 
@@ -51,7 +94,10 @@ type CommissioningWindowConfig struct {
 	// OnCommissioningComplete is called when commissioning completes.
 	OnCommissioningComplete func()
 
-	// OnWindowClosed is called when the commissioning window closes.
+	// OnWindowClosed is called when the commissioning window closes. The
+	// reason is nil for Close, ErrCommissioningTimeout when the window
+	// expired and ErrPASEAttemptsExceeded after too many failed PASE
+	// attempts.
 	OnWindowClosed func(reason error)
 }
 
@@ -61,13 +107,16 @@ type CommissioningWindowConfig struct {
 // accepts commissioning attempts. The device advertises itself via DNS-SD
 // and responds to PASE session requests.
 type CommissioningWindow struct {
-	config    CommissioningWindowConfig
-	state     DeviceCommissioningState
-	failSafe  *FailSafeTimer
-	mu        sync.RWMutex
-	closeCh   chan struct{}
-	closeOnce sync.Once
-	closeErr  error
+	config         CommissioningWindowConfig
+	clock          clock.Clock
+	state          DeviceCommissioningState
+	failSafe       *FailSafeTimer
+	deadline       time.Time
+	failedAttempts int
+	mu             sync.RWMutex
+	closeCh        chan struct{}
+	closeOnce      sync.Once
+	closeErr       error
 }
 
 // NewCommissioningWindow creates a new commissioning window.
@@ -78,15 +127,29 @@ func NewCommissioningWindow(config CommissioningWindowConfig) (*CommissioningWin
 	if config.Iterations == 0 {
 		config.Iterations = 1000 // Default iterations
 	}
+	if config.Mode == discovery.CommissioningModeDisabled {
+		config.Mode = discovery.CommissioningModeBasic
+	}
+	if config.MaxPASEAttempts <= 0 {
+		config.MaxPASEAttempts = DefaultMaxPASEAttempts
+	}
+	if config.PASERetryDelay <= 0 {
+		config.PASERetryDelay = DefaultPASERetryDelay
+	}
+	if config.MaxPASERetryDelay <= 0 {
+		config.MaxPASERetryDelay = DefaultMaxPASERetryDelay
+	}
 
 	w := &CommissioningWindow{
-		config:  config,
-		state:   DeviceStateUncommissioned,
-		closeCh: make(chan struct{}),
+		config:         config,
+		clock:          clock.OrReal(config.Clock),
+		state:          DeviceStateUncommissioned,
+		failedAttempts: config.FailedPASEAttempts,
+		closeCh:        make(chan struct{}),
 	}
 
 	// Create fail-safe timer
-	w.failSafe = NewFailSafeTimer(func() {
+	w.failSafe = newFailSafeTimer(w.clock, func() {
 		w.onFailSafeExpired()
 	})
 
@@ -103,18 +166,24 @@ func (w *CommissioningWindow) Open(ctx context.Context) error {
 		w.mu.Unlock()
 		return ErrWindowAlreadyOpen
 	}
+	if w.failedAttempts >= w.config.MaxPASEAttempts {
+		w.mu.Unlock()
+		w.closeWithError(ErrPASEAttemptsExceeded)
+		return ErrPASEAttemptsExceeded
+	}
+	w.deadline = w.clock.Now().Add(w.config.Timeout)
 	w.setState(DeviceStateAdvertising)
 	w.mu.Unlock()
 
 	// Start timeout timer
-	timer := time.NewTimer(w.config.Timeout)
+	timer := w.clock.NewTimer(w.config.Timeout)
 	defer timer.Stop()
 
 	select {
 	case <-ctx.Done():
 		w.closeWithError(ctx.Err())
 		return ctx.Err()
-	case <-timer.C:
+	case <-timer.C():
 		w.closeWithError(ErrCommissioningTimeout)
 		return ErrCommissioningTimeout
 	case <-w.closeCh:
@@ -161,6 +230,52 @@ func (w *CommissioningWindow) setState(state DeviceCommissioningState) {
 	}
 }
 
+// Mode returns the commissioning mode the window advertises.
+func (w *CommissioningWindow) Mode() discovery.CommissioningMode {
+	return w.config.Mode
+}
+
+// Remaining returns the time until the window expires, or 0 if it is not
+// open.
+func (w *CommissioningWindow) Remaining() time.Duration {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if !w.state.IsCommissionable() {
+		return 0
+	}
+	return max(w.deadline.Sub(w.clock.Now()), 0)
+}
+
+// FailedPASEAttempts returns the number of failed PASE attempts, counting
+// from CommissioningWindowConfig.FailedPASEAttempts.
+func (w *CommissioningWindow) FailedPASEAttempts() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.failedAttempts
+}
+
+// RetryDelay returns the time PASE is refused after the last failed
+// attempt, or 0 if no attempt failed.
+func (w *CommissioningWindow) RetryDelay() time.Duration {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.retryDelayLocked()
+}
+
+// retryDelayLocked returns PASERetryDelay doubled for each failed attempt
+// after the first, up to MaxPASERetryDelay. Caller must hold w.mu.
+func (w *CommissioningWindow) retryDelayLocked() time.Duration {
+	if w.failedAttempts <= 0 {
+		return 0
+	}
+	delay := w.config.PASERetryDelay
+	for i := 1; i < w.failedAttempts && delay < w.config.MaxPASERetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, w.config.MaxPASERetryDelay)
+}
+
 // Verifier returns the PASE verifier for this window.
 func (w *CommissioningWindow) Verifier() *pase.Verifier {
 	w.mu.RLock()
@@ -223,14 +338,33 @@ func (w *CommissioningWindow) OnPASEComplete(sess *session.SecureContext) error
 }
 
 // OnPASEFailed handles PASE session failure.
-// Returns the window to the advertising state.
-func (w *CommissioningWindow) OnPASEFailed() {
+//
+// It returns the window to the advertising state and counts the failed
+// attempt. After MaxPASEAttempts failures the window closes with
+// ErrPASEAttemptsExceeded and OnPASEFailed returns 0; otherwise it returns
+// the time for which the caller should refuse PASE, which grows with each
+// failed attempt to slow down passcode guessing.
+//
+// C++ Reference: CommissioningWindowManager::HandleFailedAttempt
+func (w *CommissioningWindow) OnPASEFailed() time.Duration {
 	w.mu.Lock()
-	defer w.mu.Unlock()
-
+	if !w.state.IsCommissionable() {
+		w.mu.Unlock()
+		return 0
+	}
+	w.failedAttempts++
 	if w.state == DeviceStatePASEPending {
 		w.setState(DeviceStateAdvertising)
 	}
+	exceeded := w.failedAttempts >= w.config.MaxPASEAttempts
+	delay := w.retryDelayLocked()
+	w.mu.Unlock()
+
+	if exceeded {
+		w.closeWithError(ErrPASEAttemptsExceeded)
+		return 0
+	}
+	return delay
 }
 
 // ArmFailSafe arms the fail-safe timer with the given timeout.
@@ -286,14 +420,21 @@ type FailSafeTimer struct {
 	armed     bool
 	onExpire  func()
 	mu        sync.Mutex
-	timer     *time.Timer
+	clock     clock.Clock
+	timer     clock.Timer
 }
 
 // NewFailSafeTimer creates a new fail-safe timer.
 // The onExpire callback is called when the timer expires.
 func NewFailSafeTimer(onExpire func()) *FailSafeTimer {
+	return newFailSafeTimer(clock.Real{}, onExpire)
+}
+
+// newFailSafeTimer creates a fail-safe timer that runs on clk.
+func newFailSafeTimer(clk clock.Clock, onExpire func()) *FailSafeTimer {
 	return &FailSafeTimer{
 		onExpire: onExpire,
+		clock:    clk,
 	}
 }
 
@@ -309,10 +450,10 @@ func (f *FailSafeTimer) Arm(timeout time.Duration) {
 	}
 
 	f.timeout = timeout
-	f.expiresAt = time.Now().Add(timeout)
+	f.expiresAt = f.clock.Now().Add(timeout)
 	f.armed = true
 
-	f.timer = time.AfterFunc(timeout, func() {
+	f.timer = f.clock.AfterFunc(timeout, func() {
 		f.mu.Lock()
 		wasArmed := f.armed
 		f.armed = false
@@ -353,7 +494,7 @@ func (f *FailSafeTimer) RemainingTime() time.Duration {
 		return 0
 	}
 
-	remaining := time.Until(f.expiresAt)
+	remaining := f.expiresAt.Sub(f.clock.Now())
 	if remaining < 0 {
 		return 0
 	}
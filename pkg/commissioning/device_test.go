@@ -5,6 +5,9 @@ import (
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/backkem/matter/pkg/clock"
+	"github.com/backkem/matter/pkg/discovery"
 )
 
 func TestNewCommissioningWindow(t *testing.T) {
@@ -116,9 +119,11 @@ func TestCommissioningWindowClose(t *testing.T) {
 }
 
 func TestCommissioningWindowTimeout(t *testing.T) {
+	clk := clock.NewFakeClock(time.Time{})
 	config := CommissioningWindowConfig{
-		Timeout:    50 * time.Millisecond,
+		Timeout:    3 * time.Minute,
 		Iterations: 1000,
+		Clock:      clk,
 	}
 
 	window, err := NewCommissioningWindow(config)
@@ -126,18 +131,39 @@ func TestCommissioningWindowTimeout(t *testing.T) {
 		t.Fatalf("NewCommissioningWindow() error: %v", err)
 	}
 
-	ctx := context.Background()
-	err = window.Open(ctx)
+	done := make(chan error, 1)
+	go func() { done <- window.Open(context.Background()) }()
 
-	if err != ErrCommissioningTimeout {
-		t.Errorf("Open() error = %v, want %v", err, ErrCommissioningTimeout)
+	clk.BlockUntil(1)
+	if got := window.Remaining(); got != 3*time.Minute {
+		t.Errorf("Remaining() = %v, want 3m", got)
+	}
+	clk.Advance(time.Minute)
+	if got := window.Remaining(); got != 2*time.Minute {
+		t.Errorf("Remaining() = %v after 1m, want 2m", got)
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("Open() returned %v before the timeout", err)
+	default:
+	}
+
+	clk.Advance(2 * time.Minute)
+	select {
+	case err := <-done:
+		if err != ErrCommissioningTimeout {
+			t.Errorf("Open() error = %v, want %v", err, ErrCommissioningTimeout)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Open() did not return after the timeout")
 	}
 }
 
 func TestFailSafeTimer(t *testing.T) {
 	t.Run("basic arm and disarm", func(t *testing.T) {
+		clk := clock.NewFakeClock(time.Time{})
 		var expired atomic.Bool
-		timer := NewFailSafeTimer(func() {
+		timer := newFailSafeTimer(clk, func() {
 			expired.Store(true)
 		})
 
@@ -145,15 +171,15 @@ func TestFailSafeTimer(t *testing.T) {
 			t.Error("IsArmed() = true before Arm()")
 		}
 
-		timer.Arm(100 * time.Millisecond)
+		timer.Arm(60 * time.Second)
 
 		if !timer.IsArmed() {
 			t.Error("IsArmed() = false after Arm()")
 		}
 
-		remaining := timer.RemainingTime()
-		if remaining <= 0 || remaining > 100*time.Millisecond {
-			t.Errorf("RemainingTime() = %v, want 0-100ms", remaining)
+		clk.Advance(20 * time.Second)
+		if remaining := timer.RemainingTime(); remaining != 40*time.Second {
+			t.Errorf("RemainingTime() = %v, want 40s", remaining)
 		}
 
 		timer.Disarm()
@@ -162,8 +188,8 @@ func TestFailSafeTimer(t *testing.T) {
 			t.Error("IsArmed() = true after Disarm()")
 		}
 
-		// Wait to ensure callback doesn't fire
-		time.Sleep(150 * time.Millisecond)
+		// The callback must not fire once disarmed
+		clk.Advance(time.Minute)
 
 		if expired.Load() {
 			t.Error("Timer expired after Disarm()")
@@ -171,16 +197,20 @@ func TestFailSafeTimer(t *testing.T) {
 	})
 
 	t.Run("expiration callback", func(t *testing.T) {
+		clk := clock.NewFakeClock(time.Time{})
 		var expired atomic.Bool
-		timer := NewFailSafeTimer(func() {
+		timer := newFailSafeTimer(clk, func() {
 			expired.Store(true)
 		})
 
-		timer.Arm(50 * time.Millisecond)
+		timer.Arm(60 * time.Second)
 
-		// Wait for expiration
-		time.Sleep(100 * time.Millisecond)
+		clk.Advance(60*time.Second - time.Millisecond)
+		if expired.Load() {
+			t.Error("Timer callback called before expiration")
+		}
 
+		clk.Advance(time.Millisecond)
 		if !expired.Load() {
 			t.Error("Timer callback not called after expiration")
 		}
@@ -191,31 +221,42 @@ func TestFailSafeTimer(t *testing.T) {
 	})
 
 	t.Run("re-arm resets timer", func(t *testing.T) {
+		clk := clock.NewFakeClock(time.Time{})
 		var expired atomic.Bool
-		timer := NewFailSafeTimer(func() {
+		timer := newFailSafeTimer(clk, func() {
 			expired.Store(true)
 		})
 
-		timer.Arm(50 * time.Millisecond)
+		timer.Arm(50 * time.Second)
 
-		// Wait 30ms then re-arm
-		time.Sleep(30 * time.Millisecond)
-		timer.Arm(100 * time.Millisecond)
+		clk.Advance(30 * time.Second)
+		timer.Arm(100 * time.Second)
 
-		// Wait 60ms (would have expired with original 50ms)
-		time.Sleep(60 * time.Millisecond)
+		// Past the original expiry
+		clk.Advance(60 * time.Second)
 
 		if expired.Load() {
 			t.Error("Timer expired before re-armed timeout")
 		}
 
-		// Now wait for actual expiration
-		time.Sleep(60 * time.Millisecond)
+		clk.Advance(40 * time.Second)
 
 		if !expired.Load() {
 			t.Error("Timer callback not called after re-armed expiration")
 		}
 	})
+
+	t.Run("real clock", func(t *testing.T) {
+		expired := make(chan struct{})
+		timer := NewFailSafeTimer(func() { close(expired) })
+		timer.Arm(10 * time.Millisecond)
+
+		select {
+		case <-expired:
+		case <-time.After(time.Second):
+			t.Error("Timer callback not called on the real clock")
+		}
+	})
 }
 
 func TestCommissioningWindowArmFailSafe(t *testing.T) {
@@ -250,3 +291,105 @@ func TestCommissioningWindowArmFailSafe(t *testing.T) {
 	// Disarm before expiration
 	window.DisarmFailSafe()
 }
+
+func TestCommissioningWindowPASEAttempts(t *testing.T) {
+	closed := make(chan error, 1)
+	config := CommissioningWindowConfig{
+		Timeout:         time.Second,
+		Iterations:      1000,
+		MaxPASEAttempts: 3,
+		PASERetryDelay:  5 * time.Millisecond,
+		OnWindowClosed: func(reason error) {
+			closed <- reason
+		},
+	}
+
+	window, err := NewCommissioningWindow(config)
+	if err != nil {
+		t.Fatalf("NewCommissioningWindow() error: %v", err)
+	}
+	if window.Mode() != discovery.CommissioningModeBasic {
+		t.Errorf("Mode() = %v, want %v", window.Mode(), discovery.CommissioningModeBasic)
+	}
+	if window.OnPASEFailed() != 0 || window.FailedPASEAttempts() != 0 {
+		t.Error("failed attempt counted before Open()")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go window.Open(ctx)
+
+	time.Sleep(10 * time.Millisecond)
+
+	if r := window.Remaining(); r <= 0 || r > time.Second {
+		t.Errorf("Remaining() = %v, want within the timeout", r)
+	}
+
+	for i := 1; i < 3; i++ {
+		window.OnPASERequest()
+		want := 5 * time.Millisecond << (i - 1)
+		if delay := window.OnPASEFailed(); delay != want {
+			t.Errorf("OnPASEFailed() = %v after %d attempts, want %v", delay, i, want)
+		}
+		if window.State() != DeviceStateAdvertising {
+			t.Errorf("State after OnPASEFailed() = %v, want %v", window.State(), DeviceStateAdvertising)
+		}
+	}
+
+	// The last attempt closes the window
+	if delay := window.OnPASEFailed(); delay != 0 {
+		t.Errorf("OnPASEFailed() = %v on the last attempt, want 0", delay)
+	}
+	select {
+	case reason := <-closed:
+		if reason != ErrPASEAttemptsExceeded {
+			t.Errorf("close reason = %v, want %v", reason, ErrPASEAttemptsExceeded)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("window not closed after the last attempt")
+	}
+	if window.FailedPASEAttempts() != 3 {
+		t.Errorf("FailedPASEAttempts() = %d, want 3", window.FailedPASEAttempts())
+	}
+	if window.Remaining() != 0 {
+		t.Errorf("Remaining() = %v after close, want 0", window.Remaining())
+	}
+}
+
+func TestCommissioningWindowResumedPASEAttempts(t *testing.T) {
+	window, err := NewCommissioningWindow(CommissioningWindowConfig{
+		Timeout:            time.Second,
+		Iterations:         1000,
+		PASERetryDelay:     time.Second,
+		MaxPASERetryDelay:  3 * time.Second,
+		FailedPASEAttempts: 2,
+	})
+	if err != nil {
+		t.Fatalf("NewCommissioningWindow() error: %v", err)
+	}
+	if delay := window.RetryDelay(); delay != 2*time.Second {
+		t.Errorf("RetryDelay() = %v with 2 failed attempts, want 2s", delay)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go window.Open(ctx)
+	time.Sleep(10 * time.Millisecond)
+
+	// The delay is bounded
+	if delay := window.OnPASEFailed(); delay != 3*time.Second {
+		t.Errorf("OnPASEFailed() = %v, want the 3s bound", delay)
+	}
+
+	// A window whose attempts are used up does not open
+	exhausted, err := NewCommissioningWindow(CommissioningWindowConfig{
+		Iterations:         1000,
+		FailedPASEAttempts: DefaultMaxPASEAttempts,
+	})
+	if err != nil {
+		t.Fatalf("NewCommissioningWindow() error: %v", err)
+	}
+	if err := exhausted.Open(ctx); err != ErrPASEAttemptsExceeded {
+		t.Errorf("Open() error = %v, want %v", err, ErrPASEAttemptsExceeded)
+	}
+}
@@ -22,6 +22,13 @@ var (
 	// ErrAttestationFailed indicates device attestation verification failed.
 	ErrAttestationFailed = errors.New("commissioning: device attestation failed")
 
+	// ErrDeviceRevoked indicates the device's DAC or PAI is revoked.
+	ErrDeviceRevoked = errors.New("commissioning: device attestation certificate revoked")
+
+	// ErrInvalidRevocationSet indicates a revocation set could not be
+	// parsed.
+	ErrInvalidRevocationSet = errors.New("commissioning: invalid revocation set")
+
 	// ErrCSRFailed indicates the CSR request failed.
 	ErrCSRFailed = errors.New("commissioning: CSR request failed")
 
@@ -40,6 +47,9 @@ var (
 	// ErrCommissioningCompleteFailed indicates the CommissioningComplete command failed.
 	ErrCommissioningCompleteFailed = errors.New("commissioning: commissioning complete command failed")
 
+	// ErrHookFailed indicates a CommissionerHooks hook aborted commissioning.
+	ErrHookFailed = errors.New("commissioning: commissioning hook failed")
+
 	// ErrCommissioningTimeout indicates the overall commissioning timeout was exceeded.
 	ErrCommissioningTimeout = errors.New("commissioning: operation timed out")
 
@@ -49,6 +59,10 @@ var (
 	// ErrWindowClosed indicates the commissioning window has been closed.
 	ErrWindowClosed = errors.New("commissioning: window closed")
 
+	// ErrPASEAttemptsExceeded indicates a commissioning window closed after
+	// too many failed PASE attempts.
+	ErrPASEAttemptsExceeded = errors.New("commissioning: too many failed PASE attempts")
+
 	// ErrWindowAlreadyOpen indicates a commissioning window is already open.
 	ErrWindowAlreadyOpen = errors.New("commissioning: window already open")
 
@@ -0,0 +1,84 @@
+package commissioning
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/backkem/matter/pkg/commissioning/payload"
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/im"
+	"github.com/backkem/matter/pkg/session"
+	"github.com/backkem/matter/pkg/transport"
+)
+
+// CommissionerHooks run custom logic at stages of the commissioning flow,
+// so that an ecosystem can, e.g., ask the user for consent, look the
+// device up in the Distributed Compliance Ledger or configure
+// vendor-specific clusters without reimplementing the flow.
+//
+// A hook runs while the fail-safe is armed. If it returns an error,
+// commissioning fails with ErrHookFailed, wrapping the error, and the
+// device rolls back when its fail-safe expires.
+type CommissionerHooks struct {
+	// AfterAttestation runs once the device attestation has been verified
+	// and accepted by OnDeviceAttestationResult.
+	AfterAttestation func(ctx context.Context, stage *Stage) error
+
+	// BeforeNOC runs before the operational credentials are requested and
+	// installed.
+	BeforeNOC func(ctx context.Context, stage *Stage) error
+
+	// AfterNetworkConfig runs once the operational network is configured,
+	// before the device is looked for on it. It is the last point at which
+	// the device is reachable over the PASE session.
+	AfterNetworkConfig func(ctx context.Context, stage *Stage) error
+}
+
+// Stage describes the device being commissioned to a hook.
+type Stage struct {
+	// Name is the stage: "after_attestation", "before_noc" or
+	// "after_network_config".
+	Name string
+
+	// Payload is the onboarding payload being commissioned.
+	Payload *payload.SetupPayload
+
+	// Session is the PASE session to the device.
+	Session *session.SecureContext
+
+	// PeerAddress is the address of the device.
+	PeerAddress transport.PeerAddress
+
+	// Client sends Interaction Model requests to the device over Session,
+	// e.g. to configure vendor-specific clusters. It is nil if the
+	// commissioner has no ExchangeManager.
+	Client *im.Client
+
+	// Attestation is the result of device attestation.
+	Attestation *AttestationResult
+
+	// NodeID is the operational node ID of the device. It is zero before
+	// the operational credentials are installed.
+	NodeID fabric.NodeID
+}
+
+// runHook runs a hook, if set, in a step of its own. Each hook gets its
+// own copy of the stage.
+func (c *Commissioner) runHook(ctx context.Context, name string, hook func(context.Context, *Stage) error, stage Stage) error {
+	if hook == nil {
+		return nil
+	}
+	stage.Name = name
+	c.mu.RLock()
+	stage.Payload = c.currentPayload
+	stage.PeerAddress = c.peerAddress
+	c.mu.RUnlock()
+	stage.Client = c.imClient
+
+	return c.step(ctx, "hook."+stage.Name, func(ctx context.Context) error {
+		if err := hook(ctx, &stage); err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrHookFailed, stage.Name, err)
+		}
+		return nil
+	})
+}
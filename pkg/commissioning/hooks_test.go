@@ -0,0 +1,80 @@
+package commissioning
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/backkem/matter/pkg/fabric"
+)
+
+func TestCommissionerHooks(t *testing.T) {
+	var stages []string
+	var nodeIDs []fabric.NodeID
+	record := func(ctx context.Context, stage *Stage) error {
+		if stage.Attestation == nil || !stage.Attestation.Verified {
+			t.Errorf("%s: Attestation = %+v", stage.Name, stage.Attestation)
+		}
+		stages = append(stages, stage.Name)
+		nodeIDs = append(nodeIDs, stage.NodeID)
+		return nil
+	}
+
+	var completed bool
+	c := NewCommissioner(CommissionerConfig{
+		Hooks: CommissionerHooks{
+			AfterAttestation:   record,
+			BeforeNOC:          record,
+			AfterNetworkConfig: record,
+		},
+		Callbacks: CommissionerCallbacks{
+			OnCommissioningComplete: func(fabric.NodeID) { completed = true },
+		},
+	})
+	if err := c.commissionOverPASE(context.Background(), nil); err != nil {
+		t.Fatalf("commissionOverPASE() error = %v", err)
+	}
+
+	want := []string{"after_attestation", "before_noc", "after_network_config"}
+	if len(stages) != len(want) {
+		t.Fatalf("stages = %v, want %v", stages, want)
+	}
+	for i := range want {
+		if stages[i] != want[i] {
+			t.Errorf("stages[%d] = %q, want %q", i, stages[i], want[i])
+		}
+	}
+	if nodeIDs[1] != 0 || nodeIDs[2] == 0 {
+		t.Errorf("node IDs = %v, want none before the NOC and one after", nodeIDs)
+	}
+	if !completed {
+		t.Error("OnCommissioningComplete not called")
+	}
+}
+
+func TestCommissionerHooks_Abort(t *testing.T) {
+	errDeclined := errors.New("user declined")
+	var afterNetwork bool
+	c := NewCommissioner(CommissionerConfig{
+		Hooks: CommissionerHooks{
+			BeforeNOC: func(ctx context.Context, stage *Stage) error {
+				return errDeclined
+			},
+			AfterNetworkConfig: func(ctx context.Context, stage *Stage) error {
+				afterNetwork = true
+				return nil
+			},
+		},
+	})
+
+	err := c.commissionOverPASE(context.Background(), nil)
+	if !errors.Is(err, ErrHookFailed) || !errors.Is(err, errDeclined) {
+		t.Fatalf("commissionOverPASE() error = %v, want ErrHookFailed wrapping the hook's error", err)
+	}
+	if afterNetwork {
+		t.Error("commissioning continued after a hook failed")
+	}
+	if c.State() != CommissionerStateCSRRequest {
+		t.Errorf("State() = %v, want %v", c.State(), CommissionerStateCSRRequest)
+	}
+}
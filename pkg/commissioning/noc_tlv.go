@@ -0,0 +1,269 @@
+package commissioning
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// TLV encoding/decoding for Operational Credentials NOC commands.
+// Spec Reference: Section 11.18.6.5-13
+
+// Operational Credentials command IDs of the NOC installation.
+const (
+	CmdAddTrustedRootCertificate uint32 = 0x0B
+)
+
+// NOCStatus is the status of a NOCResponse.
+//
+// Spec: Section 11.18.4.3 (NodeOperationalCertStatusEnum)
+type NOCStatus uint8
+
+// NOCStatus values.
+const (
+	NOCStatusOK                  NOCStatus = 0
+	NOCStatusInvalidPublicKey    NOCStatus = 1
+	NOCStatusInvalidNodeOpID     NOCStatus = 2
+	NOCStatusInvalidNOC          NOCStatus = 3
+	NOCStatusMissingCsr          NOCStatus = 4
+	NOCStatusTableFull           NOCStatus = 5
+	NOCStatusInvalidAdminSubject NOCStatus = 6
+	NOCStatusFabricConflict      NOCStatus = 9
+	NOCStatusLabelConflict       NOCStatus = 10
+	NOCStatusInvalidFabricIndex  NOCStatus = 11
+)
+
+// String returns the name of the status.
+func (s NOCStatus) String() string {
+	switch s {
+	case NOCStatusOK:
+		return "OK"
+	case NOCStatusInvalidPublicKey:
+		return "InvalidPublicKey"
+	case NOCStatusInvalidNodeOpID:
+		return "InvalidNodeOpId"
+	case NOCStatusInvalidNOC:
+		return "InvalidNOC"
+	case NOCStatusMissingCsr:
+		return "MissingCsr"
+	case NOCStatusTableFull:
+		return "TableFull"
+	case NOCStatusInvalidAdminSubject:
+		return "InvalidAdminSubject"
+	case NOCStatusFabricConflict:
+		return "FabricConflict"
+	case NOCStatusLabelConflict:
+		return "LabelConflict"
+	case NOCStatusInvalidFabricIndex:
+		return "InvalidFabricIndex"
+	default:
+		return "Unknown"
+	}
+}
+
+// encodeCSRRequest encodes a CSRRequest command.
+//
+// Spec: Section 11.18.6.5
+// Fields:
+//   - CSRNonce (tag 0): 32-byte random nonce
+func encodeCSRRequest(nonce []byte) ([]byte, error) {
+	if len(nonce) != 32 {
+		return nil, errors.New("CSR nonce must be 32 bytes")
+	}
+	return encodeStruct(func(w *tlv.Writer) error {
+		return w.PutBytes(tlv.ContextTag(0), nonce)
+	})
+}
+
+// csrResponse holds the decoded CSRResponse.
+type csrResponse struct {
+	Elements  []byte
+	Signature []byte
+}
+
+// decodeCSRResponse decodes a CSRResponse command.
+//
+// Spec: Section 11.18.6.6
+// Fields:
+//   - NOCSRElements (tag 0): TLV-encoded CSR and nonce
+//   - AttestationSignature (tag 1): ECDSA signature with the DAC key
+func decodeCSRResponse(data []byte) (*csrResponse, error) {
+	resp := &csrResponse{}
+	err := decodeStruct(data, func(r *tlv.Reader, tag uint64) (err error) {
+		switch tag {
+		case 0: // NOCSRElements
+			resp.Elements, err = r.Bytes()
+		case 1: // AttestationSignature
+			resp.Signature, err = r.Bytes()
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Elements == nil || resp.Signature == nil {
+		return nil, errors.New("missing required fields in CSRResponse")
+	}
+	return resp, nil
+}
+
+// decodeNOCSRElements decodes the NOCSR elements of a CSRResponse.
+//
+// Spec: Section 11.18.4.9
+// Fields:
+//   - csr (tag 1): DER-encoded PKCS #10 CSR
+//   - CSRNonce (tag 2): the nonce of the CSRRequest
+func decodeNOCSRElements(data []byte) (csr, nonce []byte, err error) {
+	err = decodeStruct(data, func(r *tlv.Reader, tag uint64) (err error) {
+		switch tag {
+		case 1: // csr
+			csr, err = r.Bytes()
+		case 2: // CSRNonce
+			nonce, err = r.Bytes()
+		}
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if csr == nil || nonce == nil {
+		return nil, nil, errors.New("missing required fields in NOCSR elements")
+	}
+	return csr, nonce, nil
+}
+
+// encodeAddTrustedRootCertificate encodes an AddTrustedRootCertificate
+// command.
+//
+// Spec: Section 11.18.6.13
+// Fields:
+//   - RootCACertificate (tag 0): Matter TLV-encoded RCAC
+func encodeAddTrustedRootCertificate(rootCert []byte) ([]byte, error) {
+	return encodeStruct(func(w *tlv.Writer) error {
+		return w.PutBytes(tlv.ContextTag(0), rootCert)
+	})
+}
+
+// encodeAddNOC encodes an AddNOC command.
+//
+// Spec: Section 11.18.6.8
+// Fields:
+//   - NOCValue (tag 0): Matter TLV-encoded NOC
+//   - ICACValue (tag 1): Matter TLV-encoded ICAC (optional)
+//   - IPKValue (tag 2): 16-byte epoch key
+//   - CaseAdminSubject (tag 3): node ID or CAT granted Administer
+//   - AdminVendorId (tag 4): vendor ID of the administrator
+func encodeAddNOC(noc, icac []byte, ipk [fabric.IPKSize]byte, caseAdminSubject uint64, adminVendorID fabric.VendorID) ([]byte, error) {
+	return encodeStruct(func(w *tlv.Writer) error {
+		if err := w.PutBytes(tlv.ContextTag(0), noc); err != nil {
+			return err
+		}
+		if icac != nil {
+			if err := w.PutBytes(tlv.ContextTag(1), icac); err != nil {
+				return err
+			}
+		}
+		if err := w.PutBytes(tlv.ContextTag(2), ipk[:]); err != nil {
+			return err
+		}
+		if err := w.PutUint(tlv.ContextTag(3), caseAdminSubject); err != nil {
+			return err
+		}
+		return w.PutUint(tlv.ContextTag(4), uint64(adminVendorID))
+	})
+}
+
+// nocResponse holds the decoded NOCResponse.
+type nocResponse struct {
+	Status      NOCStatus
+	FabricIndex fabric.FabricIndex
+	DebugText   string
+}
+
+// decodeNOCResponse decodes a NOCResponse command.
+//
+// Spec: Section 11.18.6.10
+// Fields:
+//   - StatusCode (tag 0): NodeOperationalCertStatusEnum
+//   - FabricIndex (tag 1): fabric the NOC was added on (optional)
+//   - DebugText (tag 2): optional
+func decodeNOCResponse(data []byte) (*nocResponse, error) {
+	resp := &nocResponse{}
+	hasStatus := false
+	err := decodeStruct(data, func(r *tlv.Reader, tag uint64) error {
+		switch tag {
+		case 0: // StatusCode
+			v, err := r.Uint()
+			resp.Status, hasStatus = NOCStatus(v), true
+			return err
+		case 1: // FabricIndex
+			v, err := r.Uint()
+			resp.FabricIndex = fabric.FabricIndex(v)
+			return err
+		case 2: // DebugText
+			v, err := r.String()
+			resp.DebugText = v
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !hasStatus {
+		return nil, errors.New("missing required fields in NOCResponse")
+	}
+	return resp, nil
+}
+
+// encodeStruct encodes a command structure with the fields written by
+// fields.
+func encodeStruct(fields func(w *tlv.Writer) error) ([]byte, error) {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return nil, err
+	}
+	if err := fields(w); err != nil {
+		return nil, err
+	}
+	if err := w.EndContainer(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decodeStruct enters the structure of a command and calls field for
+// each of its context-tagged fields.
+func decodeStruct(data []byte, field func(r *tlv.Reader, tag uint64) error) error {
+	r := tlv.NewReader(bytes.NewReader(data))
+
+	if err := r.Next(); err != nil {
+		return err
+	}
+	if r.Type() != tlv.ElementTypeStruct {
+		return errors.New("expected structure")
+	}
+	if err := r.EnterContainer(); err != nil {
+		return err
+	}
+
+	for {
+		if err := r.Next(); err != nil || r.IsEndOfContainer() {
+			break
+		}
+		tag := r.Tag()
+		if !tag.IsContext() {
+			continue
+		}
+		if err := field(r, uint64(tag.TagNumber())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
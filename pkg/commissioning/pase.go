@@ -132,6 +132,7 @@ func (c *PASEClient) Establish(
 	if err != nil {
 		return nil, err
 	}
+	localSessionID, _ := c.secureChannel.HandshakeSessionID(exchangeID)
 
 	// Send PBKDFParamRequest
 	err = exch.SendMessage(uint8(securechannel.OpcodePBKDFParamRequest), pbkdfReq, true)
@@ -175,18 +176,11 @@ func (c *PASEClient) Establish(
 		return nil, err
 	}
 
-	// Find the established PASE session from the session manager.
-	// The secure channel manager creates the session when processing StatusReport
-	// and notifies via callback, but we need to get the actual session object.
-	var secureCtx *session.SecureContext
-	c.sessionManager.ForEachSecureSession(func(sess *session.SecureContext) bool {
-		if sess.SessionType() == session.SessionTypePASE {
-			secureCtx = sess
-			return false // Stop iteration
-		}
-		return true // Continue
-	})
-
+	// The secure channel manager creates the session when processing
+	// StatusReport, under the session ID it allocated for the handshake.
+	// Other commissioners sharing the managers may establish sessions at
+	// the same time.
+	secureCtx := c.sessionManager.FindSecureContext(localSessionID)
 	if secureCtx == nil {
 		return nil, ErrPASEProtocol
 	}
@@ -2,6 +2,7 @@ package payload
 
 import (
 	"crypto/rand"
+	"encoding/binary"
 	"errors"
 )
 
@@ -40,6 +41,21 @@ func DefaultPBKDFParams() (*PBKDFParams, error) {
 	}, nil
 }
 
+// GeneratePasscode returns a random valid setup passcode, e.g. for a
+// commissioning window opened for another commissioner (Section 5.1.7).
+func GeneratePasscode() (uint32, error) {
+	var buf [4]byte
+	for {
+		if _, err := rand.Read(buf[:]); err != nil {
+			return 0, err
+		}
+		passcode := binary.LittleEndian.Uint32(buf[:])%PasscodeMax + PasscodeMin
+		if ValidatePasscode(passcode) == nil {
+			return passcode, nil
+		}
+	}
+}
+
 // ExtractPBKDFParams extracts PBKDF parameters from a SetupPayload.
 //
 // If the payload contains optional TLV data with PBKDF parameters,
@@ -31,6 +31,23 @@ func TestDefaultPBKDFParams(t *testing.T) {
 	}
 }
 
+func TestGeneratePasscode(t *testing.T) {
+	seen := make(map[uint32]bool)
+	for i := 0; i < 100; i++ {
+		passcode, err := GeneratePasscode()
+		if err != nil {
+			t.Fatalf("GeneratePasscode() error: %v", err)
+		}
+		if err := ValidatePasscode(passcode); err != nil {
+			t.Fatalf("GeneratePasscode() = %d: %v", passcode, err)
+		}
+		seen[passcode] = true
+	}
+	if len(seen) < 90 {
+		t.Errorf("GeneratePasscode() returned only %d distinct passcodes", len(seen))
+	}
+}
+
 func TestExtractPBKDFParams(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -0,0 +1,146 @@
+package commissioning
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// testChain is a PAA, PAI and DAC.
+type testChain struct {
+	paa, pai, dac *x509.Certificate
+}
+
+func newTestChain(t *testing.T) testChain {
+	t.Helper()
+
+	issue := func(cn string, serial int64, skid byte, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		template := &x509.Certificate{
+			SerialNumber:          big.NewInt(serial),
+			Subject:               pkix.Name{CommonName: cn},
+			NotBefore:             time.Now().Add(-time.Hour),
+			NotAfter:              time.Now().Add(time.Hour),
+			SubjectKeyId:          []byte{skid, skid, skid, skid},
+			BasicConstraintsValid: true,
+			IsCA:                  parent == nil || cn == "PAI",
+		}
+		if parent == nil {
+			parent, parentKey = template, key
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert, key
+	}
+
+	paa, paaKey := issue("PAA", 1, 0xAA, nil, nil)
+	pai, paiKey := issue("PAI", 0x1234, 0xBB, paa, paaKey)
+	dac, _ := issue("DAC", 0xABCDEF, 0xCC, pai, paiKey)
+	return testChain{paa: paa, pai: pai, dac: dac}
+}
+
+// revocationJSON returns a DCL revocation set revoking serial, issued by
+// issuer.
+func revocationJSON(issuer *x509.Certificate, issuerName []byte, serial string) []byte {
+	return []byte(fmt.Sprintf(`[{
+		"type": "revocation_set",
+		"issuer_subject_key_id": %q,
+		"issuer_name": %q,
+		"revoked_serial_numbers": [%q],
+		"crl_signer_cert": %q
+	}]`, hex.EncodeToString(issuer.SubjectKeyId), base64.StdEncoding.EncodeToString(issuerName),
+		serial, base64.StdEncoding.EncodeToString(issuer.Raw)))
+}
+
+func TestRevocationSet(t *testing.T) {
+	chain := newTestChain(t)
+
+	tests := []struct {
+		name string
+		json []byte
+		want RevocationStatus
+	}{
+		{"DAC", revocationJSON(chain.pai, chain.pai.RawSubject, "00ABCDEF"), RevocationStatus{DACRevoked: true}},
+		{"PAI", revocationJSON(chain.paa, chain.paa.RawSubject, "1234"), RevocationStatus{PAIRevoked: true}},
+		{"other serial", revocationJSON(chain.pai, chain.pai.RawSubject, "01"), RevocationStatus{}},
+		{"other issuer name", revocationJSON(chain.pai, chain.paa.RawSubject, "ABCDEF"), RevocationStatus{}},
+		{"other type", []byte(`[{"type": "other", "issuer_subject_key_id": "00"}]`), RevocationStatus{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := ParseRevocationSet(tt.json)
+			if err != nil {
+				t.Fatalf("ParseRevocationSet() error = %v", err)
+			}
+			got, err := set.Check(chain.dac.Raw, chain.pai.Raw)
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Check() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRevocationSet_Invalid(t *testing.T) {
+	for _, data := range []string{
+		`{}`,
+		`[{"type": "revocation_set", "issuer_subject_key_id": "zz"}]`,
+		`[{"type": "revocation_set", "issuer_subject_key_id": "AA", "revoked_serial_numbers": ["xyz"]}]`,
+	} {
+		if _, err := ParseRevocationSet([]byte(data)); !errors.Is(err, ErrInvalidRevocationSet) {
+			t.Errorf("ParseRevocationSet(%s) error = %v, want ErrInvalidRevocationSet", data, err)
+		}
+	}
+}
+
+func TestRevocationPolicy(t *testing.T) {
+	chain := newTestChain(t)
+	set, err := ParseRevocationSet(revocationJSON(chain.pai, chain.pai.RawSubject, "ABCDEF"))
+	if err != nil {
+		t.Fatalf("ParseRevocationSet() error = %v", err)
+	}
+
+	verifier := revocationVerifier{verifier: NewAcceptAllVerifier(), set: set}
+	result, err := verifier.Verify(context.Background(), &AttestationInfo{DAC: chain.dac.Raw, PAI: chain.pai.Raw})
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if !result.Revocation.Checked || !result.Revocation.DACRevoked {
+		t.Fatalf("Revocation = %+v, want checked and DAC revoked", result.Revocation)
+	}
+
+	// Revoked devices are refused unless the policy accepts them
+	c := &Commissioner{}
+	if err := c.checkRevocation(result); !errors.Is(err, ErrDeviceRevoked) {
+		t.Errorf("checkRevocation() error = %v, want ErrDeviceRevoked", err)
+	}
+	var asked bool
+	c.config.Callbacks.OnRevokedDevice = func(*AttestationResult) bool {
+		asked = true
+		return true
+	}
+	if err := c.checkRevocation(result); err != nil || !asked {
+		t.Errorf("checkRevocation() with accepting policy = %v, asked %v", err, asked)
+	}
+}
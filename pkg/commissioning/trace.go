@@ -0,0 +1,46 @@
+package commissioning
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the instrumentation scope of commissioning spans.
+const TracerName = "github.com/backkem/matter/pkg/commissioning"
+
+// Span attribute keys.
+const (
+	attrVendorID  = attribute.Key("matter.vendor.id")
+	attrProductID = attribute.Key("matter.product.id")
+	attrNodeID    = attribute.Key("matter.node.id")
+)
+
+// newTracer returns the commissioning tracer from tp, or from the global
+// provider if tp is nil.
+func newTracer(tp trace.TracerProvider) trace.Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return tp.Tracer(TracerName)
+}
+
+// step runs fn in a child span of ctx named "commissioning.<name>".
+func (c *Commissioner) step(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	ctx, span := c.tracer.Start(ctx, "commissioning."+name)
+	err := fn(ctx)
+	endSpan(span, err)
+	return err
+}
+
+// endSpan records err, if any, and ends the span.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,51 @@
+package commissioning
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/backkem/matter/pkg/commissioning/payload"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestCommissionerTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	c := NewCommissioner(CommissionerConfig{
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)),
+	})
+
+	// Without a resolver the flow fails in the discovery step.
+	err := c.CommissionFromPayload(context.Background(), &payload.SetupPayload{
+		VendorID:  0xFFF1,
+		ProductID: 0x8000,
+	})
+	if !errors.Is(err, ErrNilConfig) {
+		t.Fatalf("CommissionFromPayload error = %v, want ErrNilConfig", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	step, root := spans[0], spans[1]
+	if step.Name() != "commissioning.discovery" || root.Name() != "commissioning" {
+		t.Fatalf("span names = %q, %q", step.Name(), root.Name())
+	}
+	if step.Parent().SpanID() != root.SpanContext().SpanID() {
+		t.Error("discovery span is not a child of the commissioning span")
+	}
+	if step.Status().Code != codes.Error || root.Status().Code != codes.Error {
+		t.Errorf("status = %v, %v; want errors", step.Status().Code, root.Status().Code)
+	}
+
+	attrs := make(map[string]int64)
+	for _, kv := range root.Attributes() {
+		attrs[string(kv.Key)] = kv.Value.AsInt64()
+	}
+	if attrs["matter.vendor.id"] != 0xFFF1 || attrs["matter.product.id"] != 0x8000 {
+		t.Errorf("root attributes = %v", attrs)
+	}
+}
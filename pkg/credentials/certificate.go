@@ -99,6 +99,15 @@ func (c *Certificate) EncodeTLV() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// TBSData returns the data the certificate's signature covers: its TLV
+// encoding with the signature left empty. Issuers sign it with the
+// SHA-256 ECDSA signature algorithm of the certificate.
+func (c *Certificate) TBSData() ([]byte, error) {
+	tbs := *c
+	tbs.Signature = nil
+	return tbs.EncodeTLV()
+}
+
 // WriteTLV writes the certificate to a TLV writer.
 func (c *Certificate) WriteTLV(w *tlv.Writer) error {
 	// Start the top-level structure (anonymous tag)
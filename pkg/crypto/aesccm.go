@@ -14,6 +14,7 @@ import (
 	"crypto/subtle"
 	"encoding/binary"
 	"errors"
+	"sync"
 )
 
 // AES-CCM constants from Matter Specification Section 3.6.
@@ -44,6 +45,7 @@ var (
 )
 
 // AESCCM represents an AES-128-CCM cipher instance with configurable parameters.
+// It is safe for concurrent use.
 type AESCCM struct {
 	block    cipher.Block
 	tagSize  int // M: authentication tag size (4, 6, 8, 10, 12, 14, or 16)
@@ -112,6 +114,13 @@ func (c *AESCCM) TagSize() int {
 //
 // Returns ciphertext || tag (plaintext length + tagSize bytes for tag).
 func (c *AESCCM) Seal(nonce, plaintext, aad []byte) ([]byte, error) {
+	return c.SealTo(nil, nonce, plaintext, aad)
+}
+
+// SealTo is like Seal, but appends ciphertext || tag to dst and returns the
+// updated slice. To encrypt in place, pass plaintext[:0] as dst; with
+// enough capacity for the tag, SealTo does not allocate.
+func (c *AESCCM) SealTo(dst, nonce, plaintext, aad []byte) ([]byte, error) {
 	if len(nonce) != c.NonceSize() {
 		return nil, ErrAESCCMInvalidNonceSize
 	}
@@ -122,22 +131,24 @@ func (c *AESCCM) Seal(nonce, plaintext, aad []byte) ([]byte, error) {
 		return nil, ErrAESCCMPlaintextTooLong
 	}
 
-	// Compute the authentication tag (T)
-	tag := c.computeTag(nonce, plaintext, aad)
+	sc := ccmScratchPool.Get().(*ccmScratch)
+	defer ccmScratchPool.Put(sc)
+
+	// Compute the authentication tag (T) before plaintext may be overwritten
+	c.computeTag(sc, nonce, plaintext, aad)
 
-	// Generate the keystream and encrypt
-	ciphertext := make([]byte, len(plaintext)+c.tagSize)
+	ret, out := sliceForAppend(dst, len(plaintext)+c.tagSize)
 
 	// Encrypt the tag with S_0
-	s0 := c.generateS0(nonce)
+	c.generateS0(sc, nonce)
 	for i := 0; i < c.tagSize; i++ {
-		ciphertext[len(plaintext)+i] = tag[i] ^ s0[i]
+		out[len(plaintext)+i] = sc.mac[i] ^ sc.s0[i]
 	}
 
 	// Encrypt the plaintext with CTR mode starting from counter 1
-	c.ctrEncrypt(nonce, ciphertext[:len(plaintext)], plaintext)
+	c.ctrEncrypt(sc, nonce, out[:len(plaintext)], plaintext)
 
-	return ciphertext, nil
+	return ret, nil
 }
 
 // Open decrypts and verifies ciphertext with associated data.
@@ -150,6 +161,14 @@ func (c *AESCCM) Seal(nonce, plaintext, aad []byte) ([]byte, error) {
 //
 // Returns the decrypted plaintext, or an error if authentication fails.
 func (c *AESCCM) Open(nonce, ciphertext, aad []byte) ([]byte, error) {
+	return c.OpenTo(nil, nonce, ciphertext, aad)
+}
+
+// OpenTo is like Open, but appends the plaintext to dst and returns the
+// updated slice. To decrypt in place, pass ciphertext[:0] as dst; with
+// enough capacity, OpenTo does not allocate. If authentication fails, the
+// plaintext written to dst is cleared.
+func (c *AESCCM) OpenTo(dst, nonce, ciphertext, aad []byte) ([]byte, error) {
 	if len(nonce) != c.NonceSize() {
 		return nil, ErrAESCCMInvalidNonceSize
 	}
@@ -162,36 +181,68 @@ func (c *AESCCM) Open(nonce, ciphertext, aad []byte) ([]byte, error) {
 	encryptedData := ciphertext[:len(ciphertext)-c.tagSize]
 	encryptedTag := ciphertext[len(ciphertext)-c.tagSize:]
 
-	// Decrypt the tag with S_0
-	s0 := c.generateS0(nonce)
-	receivedTag := make([]byte, c.tagSize)
+	sc := ccmScratchPool.Get().(*ccmScratch)
+	defer ccmScratchPool.Put(sc)
+
+	// Decrypt the tag with S_0, before an in-place decryption can reach it
+	c.generateS0(sc, nonce)
+	var receivedTag [aesBlockSize]byte
 	for i := 0; i < c.tagSize; i++ {
-		receivedTag[i] = encryptedTag[i] ^ s0[i]
+		receivedTag[i] = encryptedTag[i] ^ sc.s0[i]
 	}
 
 	// Decrypt the plaintext with CTR mode
-	plaintext := make([]byte, len(encryptedData))
-	c.ctrEncrypt(nonce, plaintext, encryptedData)
+	ret, plaintext := sliceForAppend(dst, len(encryptedData))
+	c.ctrEncrypt(sc, nonce, plaintext, encryptedData)
 
 	// Compute the expected tag
-	expectedTag := c.computeTag(nonce, plaintext, aad)
+	c.computeTag(sc, nonce, plaintext, aad)
 
 	// Verify the tag using constant-time comparison
-	if subtle.ConstantTimeCompare(receivedTag, expectedTag[:c.tagSize]) != 1 {
+	if subtle.ConstantTimeCompare(receivedTag[:c.tagSize], sc.mac[:c.tagSize]) != 1 {
+		clear(plaintext)
 		return nil, ErrAESCCMAuthFailed
 	}
 
-	return plaintext, nil
+	return ret, nil
+}
+
+// ccmScratch holds the blocks of one Seal or Open. The blocks are handed to
+// the cipher.Block interface and would escape to the heap if they lived on
+// the stack, so they are pooled instead.
+type ccmScratch struct {
+	mac   [aesBlockSize]byte
+	s0    [aesBlockSize]byte
+	block [aesBlockSize]byte
+	ctr   [aesBlockSize]byte
+}
+
+var ccmScratchPool = sync.Pool{
+	New: func() any { return new(ccmScratch) },
 }
 
-// computeTag computes the CBC-MAC authentication tag.
+// sliceForAppend extends in by n bytes, reusing its capacity if possible.
+// Returns the extended slice and the n bytes appended.
+func sliceForAppend(in []byte, n int) (head, tail []byte) {
+	if total := len(in) + n; cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+	tail = head[len(in):]
+	return
+}
+
+// computeTag computes the CBC-MAC authentication tag into sc.mac.
 // This follows NIST 800-38C Section 6.1 and RFC 3610 Section 2.2.
-func (c *AESCCM) computeTag(nonce, plaintext, aad []byte) []byte {
+func (c *AESCCM) computeTag(sc *ccmScratch, nonce, plaintext, aad []byte) {
 	// Build B_0 (first block)
 	// Flags = Reserved(1) || Adata(1) || M'(3) || L'(3)
 	// M' = (tagSize - 2) / 2
 	// L' = L - 1
-	var b0 [aesBlockSize]byte
+	b0 := &sc.block
+	*b0 = [aesBlockSize]byte{}
 	flags := byte(0)
 	if len(aad) > 0 {
 		flags |= 1 << 6 // Adata flag
@@ -206,7 +257,7 @@ func (c *AESCCM) computeTag(nonce, plaintext, aad []byte) []byte {
 	c.putLength(b0[1+nonceSize:], len(plaintext))
 
 	// Initialize CBC-MAC with B_0
-	mac := make([]byte, aesBlockSize)
+	mac := sc.mac[:]
 	c.block.Encrypt(mac, b0[:])
 
 	// Process AAD if present
@@ -215,7 +266,8 @@ func (c *AESCCM) computeTag(nonce, plaintext, aad []byte) []byte {
 		// For 0 < l(a) < 2^16 - 2^8: encode as 2 bytes
 		// For 2^16 - 2^8 <= l(a) < 2^32: encode as 0xFFFE || 4 bytes
 		// For 2^32 <= l(a) < 2^64: encode as 0xFFFF || 8 bytes
-		var aadBlock [aesBlockSize]byte
+		aadBlock := &sc.block
+		*aadBlock = [aesBlockSize]byte{}
 		aadLen := len(aad)
 		var headerLen int
 
@@ -248,66 +300,63 @@ func (c *AESCCM) computeTag(nonce, plaintext, aad []byte) []byte {
 		c.block.Encrypt(mac, mac)
 
 		// Process remaining AAD
-		remaining := aad[firstBlockAAD:]
-		for len(remaining) > 0 {
-			var block [aesBlockSize]byte
-			n := copy(block[:], remaining)
-			remaining = remaining[n:]
-
-			for i := 0; i < aesBlockSize; i++ {
-				mac[i] ^= block[i]
-			}
-			c.block.Encrypt(mac, mac)
-		}
+		c.macBlocks(mac, aad[firstBlockAAD:])
 	}
 
 	// Process plaintext
-	remaining := plaintext
-	for len(remaining) > 0 {
-		var block [aesBlockSize]byte
-		n := copy(block[:], remaining)
-		remaining = remaining[n:]
+	c.macBlocks(mac, plaintext)
+}
 
+// macBlocks feeds data into the CBC-MAC, zero-padding the last block.
+func (c *AESCCM) macBlocks(mac, data []byte) {
+	for len(data) >= aesBlockSize {
 		for i := 0; i < aesBlockSize; i++ {
-			mac[i] ^= block[i]
+			mac[i] ^= data[i]
+		}
+		c.block.Encrypt(mac, mac)
+		data = data[aesBlockSize:]
+	}
+	if len(data) > 0 {
+		for i := range data {
+			mac[i] ^= data[i]
 		}
 		c.block.Encrypt(mac, mac)
 	}
-
-	return mac[:c.tagSize]
 }
 
-// generateS0 generates the S_0 keystream block for tag encryption.
-// S_0 = E(K, A_0) where A_0 is the first counter block with counter = 0.
-func (c *AESCCM) generateS0(nonce []byte) []byte {
+// generateS0 generates the S_0 keystream block for tag encryption into
+// sc.s0. S_0 = E(K, A_0) where A_0 is the first counter block with
+// counter = 0.
+func (c *AESCCM) generateS0(sc *ccmScratch, nonce []byte) {
 	// A_0 format:
 	// Flags = Reserved(2) || 0(3) || L'(3)
 	// L' = L - 1
-	var a0 [aesBlockSize]byte
+	a0 := &sc.ctr
+	*a0 = [aesBlockSize]byte{}
 	a0[0] = byte(c.lenSize - 1) // L' in bits 0-2, other bits are 0
 	nonceSize := c.NonceSize()
 	copy(a0[1:1+nonceSize], nonce)
 	// Counter = 0 (last L bytes are zero)
 
-	s0 := make([]byte, aesBlockSize)
-	c.block.Encrypt(s0, a0[:])
-	return s0
+	c.block.Encrypt(sc.s0[:], a0[:])
 }
 
 // ctrEncrypt encrypts/decrypts data using CTR mode starting from counter 1.
+// dst and src may overlap exactly.
 // This uses the counter generation function from NIST 800-38C Appendix A.3.
-func (c *AESCCM) ctrEncrypt(nonce []byte, dst, src []byte) {
+func (c *AESCCM) ctrEncrypt(sc *ccmScratch, nonce []byte, dst, src []byte) {
 	// Build the initial counter block A_1
-	var ctr [aesBlockSize]byte
+	ctr := &sc.ctr
+	*ctr = [aesBlockSize]byte{}
 	ctr[0] = byte(c.lenSize - 1) // L' in bits 0-2
 	nonceSize := c.NonceSize()
 	copy(ctr[1:1+nonceSize], nonce)
 	// Start with counter = 1 in the last L bytes
 	ctr[aesBlockSize-1] = 1
 
-	var keystream [aesBlockSize]byte
+	keystream := sc.block[:]
 	for i := 0; i < len(src); i += aesBlockSize {
-		c.block.Encrypt(keystream[:], ctr[:])
+		c.block.Encrypt(keystream, ctr[:])
 
 		// XOR plaintext with keystream
 		end := i + aesBlockSize
@@ -313,6 +313,61 @@ func TestAESCCMAuthenticationFailure(t *testing.T) {
 	}
 }
 
+func TestAESCCMInPlace(t *testing.T) {
+	key := make([]byte, AESCCMKeySize)
+	nonce := make([]byte, AESCCMNonceSize)
+	plaintext := []byte("in-place message, longer than one block")
+	aad := []byte("aad")
+
+	ccm, err := NewAESCCM(key)
+	if err != nil {
+		t.Fatalf("NewAESCCM failed: %v", err)
+	}
+	want, err := ccm.Seal(nonce, plaintext, aad)
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+
+	buf := make([]byte, len(plaintext), len(plaintext)+AESCCMTagSize)
+	copy(buf, plaintext)
+	sealed, err := ccm.SealTo(buf[:0], nonce, buf, aad)
+	if err != nil {
+		t.Fatalf("SealTo failed: %v", err)
+	}
+	if !bytes.Equal(sealed, want) {
+		t.Errorf("SealTo in place = %x, want %x", sealed, want)
+	}
+	if &sealed[0] != &buf[0] {
+		t.Error("SealTo did not reuse dst")
+	}
+
+	opened, err := ccm.OpenTo(sealed[:0], nonce, sealed, aad)
+	if err != nil {
+		t.Fatalf("OpenTo failed: %v", err)
+	}
+	if !bytes.Equal(opened, plaintext) {
+		t.Errorf("OpenTo in place = %q, want %q", opened, plaintext)
+	}
+
+	// A failed open clears the decrypted bytes
+	sealed, _ = ccm.SealTo(buf[:0], nonce, plaintext, aad)
+	sealed[len(sealed)-1] ^= 0x01
+	if _, err := ccm.OpenTo(sealed[:0], nonce, sealed, aad); err != ErrAESCCMAuthFailed {
+		t.Fatalf("OpenTo with tampered tag: got error %v, want ErrAESCCMAuthFailed", err)
+	}
+	if !bytes.Equal(sealed[:len(plaintext)], make([]byte, len(plaintext))) {
+		t.Error("OpenTo left plaintext behind after authentication failure")
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		sealed, _ := ccm.SealTo(buf[:0], nonce, buf[:len(plaintext)], aad)
+		_, _ = ccm.OpenTo(sealed[:0], nonce, sealed, aad)
+	})
+	if allocs != 0 {
+		t.Errorf("SealTo/OpenTo in place allocated %v times, want 0", allocs)
+	}
+}
+
 func TestAESCCMInvalidNonce(t *testing.T) {
 	key := make([]byte, AESCCMKeySize)
 	ccm, err := NewAESCCM(key)
@@ -541,3 +596,35 @@ func BenchmarkAESCCMOpen(b *testing.B) {
 		_, _ = ccm.Open(nonce, ciphertext, aad)
 	}
 }
+
+func BenchmarkAESCCMSealTo(b *testing.B) {
+	key := make([]byte, AESCCMKeySize)
+	nonce := make([]byte, AESCCMNonceSize)
+	buf := make([]byte, 256+AESCCMTagSize)
+	aad := make([]byte, 32)
+
+	ccm, _ := NewAESCCM(key)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = ccm.SealTo(buf[:0], nonce, buf[:256], aad)
+	}
+}
+
+func BenchmarkAESCCMOpenTo(b *testing.B) {
+	key := make([]byte, AESCCMKeySize)
+	nonce := make([]byte, AESCCMNonceSize)
+	plaintext := make([]byte, 256)
+	aad := make([]byte, 32)
+
+	ccm, _ := NewAESCCM(key)
+	ciphertext, _ := ccm.Seal(nonce, plaintext, aad)
+	buf := make([]byte, 0, len(plaintext))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = ccm.OpenTo(buf, nonce, ciphertext, aad)
+	}
+}
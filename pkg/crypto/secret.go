@@ -0,0 +1,64 @@
+package crypto
+
+import (
+	"math/big"
+	"sync"
+)
+
+// Zeroize overwrites buffers holding secrets, such as keys and shared
+// secrets, with zeros once they are no longer needed, so that they do not
+// linger in memory.
+func Zeroize(bufs ...[]byte) {
+	for _, b := range bufs {
+		clear(b)
+	}
+}
+
+// ZeroizeInt overwrites a secret scalar with zeros and sets it to zero.
+func ZeroizeInt(x *big.Int) {
+	if x == nil {
+		return
+	}
+	clear(x.Bits())
+	x.SetInt64(0)
+}
+
+// SecretBuffer holds a secret, such as a shared secret kept for session
+// resumption, and wipes it on Close. It holds its own copy, so the caller
+// may zeroize the slice it was created from.
+type SecretBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+// NewSecretBuffer creates a SecretBuffer holding a copy of secret.
+func NewSecretBuffer(secret []byte) *SecretBuffer {
+	return &SecretBuffer{buf: append([]byte(nil), secret...)}
+}
+
+// Bytes returns a copy of the secret, or nil once the buffer is closed.
+// The caller zeroizes the copy when done with it.
+func (s *SecretBuffer) Bytes() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.buf == nil {
+		return nil
+	}
+	return append([]byte(nil), s.buf...)
+}
+
+// Len returns the length of the secret, or zero once the buffer is closed.
+func (s *SecretBuffer) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.buf)
+}
+
+// Close wipes the secret. It is safe to call more than once.
+func (s *SecretBuffer) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	Zeroize(s.buf)
+	s.buf = nil
+	return nil
+}
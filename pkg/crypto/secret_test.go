@@ -0,0 +1,64 @@
+package crypto
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+func TestZeroize(t *testing.T) {
+	a := []byte{1, 2, 3}
+	b := []byte{4, 5}
+	Zeroize(a, nil, b)
+	if !bytes.Equal(a, []byte{0, 0, 0}) || !bytes.Equal(b, []byte{0, 0}) {
+		t.Errorf("Zeroize() left % X, % X", a, b)
+	}
+
+	x := new(big.Int).SetBytes(bytes.Repeat([]byte{0xAB}, 32))
+	words := x.Bits()
+	ZeroizeInt(x)
+	if x.Sign() != 0 {
+		t.Errorf("ZeroizeInt() left %v", x)
+	}
+	for _, w := range words {
+		if w != 0 {
+			t.Fatal("ZeroizeInt() did not wipe the backing words")
+		}
+	}
+	ZeroizeInt(nil)
+}
+
+func TestSecretBuffer(t *testing.T) {
+	secret := []byte{0xAA, 0xBB, 0xCC}
+	buf := NewSecretBuffer(secret)
+
+	// The buffer holds its own copy
+	Zeroize(secret)
+	if got := buf.Bytes(); !bytes.Equal(got, []byte{0xAA, 0xBB, 0xCC}) {
+		t.Fatalf("Bytes() = % X, want AA BB CC", got)
+	}
+	if buf.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", buf.Len())
+	}
+
+	// Bytes returns a copy
+	out := buf.Bytes()
+	out[0] = 0
+	if got := buf.Bytes(); got[0] != 0xAA {
+		t.Error("modifying Bytes() changed the secret")
+	}
+
+	inner := buf.buf
+	if err := buf.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if !bytes.Equal(inner, []byte{0, 0, 0}) {
+		t.Errorf("Close() left % X", inner)
+	}
+	if buf.Bytes() != nil || buf.Len() != 0 {
+		t.Errorf("Bytes() = % X, Len() = %d after Close()", buf.Bytes(), buf.Len())
+	}
+	if err := buf.Close(); err != nil {
+		t.Errorf("second Close() error = %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package crypto
+
+import (
+	gocrypto "crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// Signer errors.
+var (
+	ErrP256InvalidSigner    = errors.New("p256: signer is not a P-256 ECDSA key")
+	ErrP256InvalidSignature = errors.New("p256: signer returned an invalid signature")
+)
+
+// Public returns the public key of the key pair, an *ecdsa.PublicKey.
+// With Sign, it makes P256KeyPair a crypto.Signer.
+func (kp *P256KeyPair) Public() gocrypto.PublicKey {
+	return &kp.ecdsaPrivate.PublicKey
+}
+
+// Sign signs a digest with the private key, returning an ASN.1 DER
+// signature as crypto.Signer requires. Matter signatures are produced with
+// P256SignWith, which takes any crypto.Signer.
+func (kp *P256KeyPair) Sign(random io.Reader, digest []byte, opts gocrypto.SignerOpts) ([]byte, error) {
+	return kp.ecdsaPrivate.Sign(random, digest, opts)
+}
+
+// P256SignWith signs a message with a P-256 crypto.Signer, like P256Sign.
+// The signer may keep its private key in a secure element, a PSA key
+// store or a TPM: only the SHA-256 digest of the message is passed to it.
+//
+// Returns a 64-byte signature (r || s), each component zero-padded to 32 bytes.
+func P256SignWith(signer gocrypto.Signer, message []byte) ([]byte, error) {
+	if kp, ok := signer.(*P256KeyPair); ok {
+		return P256Sign(kp, message)
+	}
+	if _, err := P256SignerPublicKey(signer); err != nil {
+		return nil, err
+	}
+
+	hash := SHA256(message)
+	der, err := signer.Sign(rand.Reader, hash[:], gocrypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("ECDSA sign failed: %w", err)
+	}
+
+	var parsed struct{ R, S *big.Int }
+	if rest, err := asn1.Unmarshal(der, &parsed); err != nil || len(rest) > 0 {
+		return nil, ErrP256InvalidSignature
+	}
+	if parsed.R.Sign() <= 0 || parsed.S.Sign() <= 0 ||
+		parsed.R.BitLen() > P256GroupSizeBits || parsed.S.BitLen() > P256GroupSizeBits {
+		return nil, ErrP256InvalidSignature
+	}
+
+	sig := make([]byte, P256SignatureSizeBytes)
+	parsed.R.FillBytes(sig[:P256GroupSizeBytes])
+	parsed.S.FillBytes(sig[P256GroupSizeBytes:])
+	return sig, nil
+}
+
+// P256SignerPublicKey returns the public key of a P-256 crypto.Signer in
+// uncompressed format (65 bytes), to match it against a certificate.
+func P256SignerPublicKey(signer gocrypto.Signer) ([]byte, error) {
+	pub, ok := signer.Public().(*ecdsa.PublicKey)
+	if !ok || pub.Curve != elliptic.P256() {
+		return nil, ErrP256InvalidSigner
+	}
+	result := make([]byte, P256PublicKeySizeBytes)
+	result[0] = 0x04
+	pub.X.FillBytes(result[1 : 1+P256GroupSizeBytes])
+	pub.Y.FillBytes(result[1+P256GroupSizeBytes:])
+	return result, nil
+}
+
+// P256CreateCSR creates a PKCS #10 certificate signing request for the
+// public key of a P-256 crypto.Signer, signed with ecdsa-with-SHA256, as
+// a node returns in its NOCSR elements. The subject carries no
+// information: the administrator chooses the NOC's subject.
+func P256CreateCSR(signer gocrypto.Signer) ([]byte, error) {
+	if _, err := P256SignerPublicKey(signer); err != nil {
+		return nil, err
+	}
+	template := &x509.CertificateRequest{
+		Subject:            pkix.Name{Organization: []string{"CSR"}},
+		SignatureAlgorithm: x509.ECDSAWithSHA256,
+	}
+	return x509.CreateCertificateRequest(rand.Reader, template, signer)
+}
@@ -0,0 +1,76 @@
+package crypto
+
+import (
+	"bytes"
+	gocrypto "crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"io"
+	"testing"
+)
+
+// opaqueSigner hides an ECDSA key behind crypto.Signer, as a secure
+// element does.
+type opaqueSigner struct {
+	key *ecdsa.PrivateKey
+}
+
+func (s opaqueSigner) Public() gocrypto.PublicKey { return &s.key.PublicKey }
+
+func (s opaqueSigner) Sign(random io.Reader, digest []byte, opts gocrypto.SignerOpts) ([]byte, error) {
+	return s.key.Sign(random, digest, opts)
+}
+
+func TestP256SignWith(t *testing.T) {
+	kp, err := P256GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("P256GenerateKeyPair() error = %v", err)
+	}
+	message := []byte("Sigma2 TBS data")
+
+	for _, signer := range []gocrypto.Signer{kp, opaqueSigner{kp.ecdsaPrivate}} {
+		pub, err := P256SignerPublicKey(signer)
+		if err != nil || !bytes.Equal(pub, kp.P256PublicKey()) {
+			t.Fatalf("P256SignerPublicKey(%T) = %x, %v, want %x", signer, pub, err, kp.P256PublicKey())
+		}
+		sig, err := P256SignWith(signer, message)
+		if err != nil {
+			t.Fatalf("P256SignWith(%T) error = %v", signer, err)
+		}
+		if len(sig) != P256SignatureSizeBytes {
+			t.Fatalf("P256SignWith(%T) signature length = %d, want %d", signer, len(sig), P256SignatureSizeBytes)
+		}
+		if ok, err := P256Verify(pub, message, sig); !ok || err != nil {
+			t.Errorf("P256Verify(P256SignWith(%T)) = %v, %v, want true", signer, ok, err)
+		}
+	}
+
+	p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if _, err := P256SignWith(opaqueSigner{p384}, message); err != ErrP256InvalidSigner {
+		t.Errorf("P256SignWith(P-384) error = %v, want %v", err, ErrP256InvalidSigner)
+	}
+}
+
+func TestP256CreateCSR(t *testing.T) {
+	kp, _ := P256GenerateKeyPair()
+	der, err := P256CreateCSR(opaqueSigner{kp.ecdsaPrivate})
+	if err != nil {
+		t.Fatalf("P256CreateCSR() error = %v", err)
+	}
+
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		t.Fatalf("ParseCertificateRequest() error = %v", err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		t.Errorf("CheckSignature() error = %v", err)
+	}
+	if csr.SignatureAlgorithm != x509.ECDSAWithSHA256 {
+		t.Errorf("SignatureAlgorithm = %v, want %v", csr.SignatureAlgorithm, x509.ECDSAWithSHA256)
+	}
+	if pub, ok := csr.PublicKey.(*ecdsa.PublicKey); !ok || !pub.Equal(kp.Public()) {
+		t.Errorf("PublicKey = %v, want the signer's", csr.PublicKey)
+	}
+}
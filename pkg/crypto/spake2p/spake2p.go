@@ -109,6 +109,7 @@ const (
 	stateShareGenerated
 	stateSharedSecretComputed
 	stateConfirmed
+	stateZeroized
 )
 
 // Errors
@@ -338,6 +339,18 @@ func (s *SPAKE2P) SharedSecret() []byte {
 	return copyBytes(s.Ke)
 }
 
+// Zeroize wipes the secret scalars, the shared values and the derived
+// keys. The instance cannot be used afterwards. Call it once the session
+// keys are derived, or when the handshake fails.
+func (s *SPAKE2P) Zeroize() {
+	crypto.ZeroizeInt(s.w0)
+	crypto.ZeroizeInt(s.w1)
+	crypto.ZeroizeInt(s.myRandom)
+	crypto.Zeroize(s.Z, s.V, s.Ka, s.Ke, s.KcA, s.KcB)
+	s.Z, s.V, s.Ka, s.Ke, s.KcA, s.KcB = nil, nil, nil, nil, nil, nil
+	s.state = stateZeroized
+}
+
 // computeProverSecrets computes Z and V for the prover.
 // Z = x*(Y - w0*N), V = w1*(Y - w0*N)
 func (s *SPAKE2P) computeProverSecrets(Y *point) ([]byte, []byte, error) {
@@ -4,280 +4,13 @@ import (
 	"bytes"
 	"io"
 	"testing"
-)
-
-// SDK test vectors from connectedhomeip/src/crypto/tests/SPAKE2P_RFC_test_vectors.h
-// Kept in byte array format for easier validation against source.
-
-// Shared values across all vectors (same w0, w1, L)
-var (
-	tvContext = []byte("SPAKE2+-P256-SHA256-HKDF draft-01")
-	tvW0      = []byte{
-		0xe6, 0x88, 0x7c, 0xf9, 0xbd, 0xfb, 0x75, 0x79, 0xc6, 0x9b, 0xf4,
-		0x79, 0x28, 0xa8, 0x45, 0x14, 0xb5, 0xe3, 0x55, 0xac, 0x03, 0x48,
-		0x63, 0xf7, 0xff, 0xaf, 0x43, 0x90, 0xe6, 0x7d, 0x79, 0x8c,
-	}
-	tvW1 = []byte{
-		0x24, 0xb5, 0xae, 0x4a, 0xbd, 0xa8, 0x68, 0xec, 0x93, 0x36, 0xff,
-		0xc3, 0xb7, 0x8e, 0xe3, 0x1c, 0x57, 0x55, 0xbe, 0xf1, 0x75, 0x92,
-		0x27, 0xef, 0x53, 0x72, 0xca, 0x13, 0x9b, 0x94, 0xe5, 0x12,
-	}
-	tvL = []byte{
-		0x04, 0x95, 0x64, 0x5c, 0xfb, 0x74, 0xdf, 0x6e, 0x58, 0xf9, 0x74, 0x8b, 0xb8,
-		0x3a, 0x86, 0x62, 0x0b, 0xab, 0x7c, 0x82, 0xe1, 0x07, 0xf5, 0x7d, 0x68, 0x70,
-		0xda, 0x8c, 0xbc, 0xb2, 0xff, 0x9f, 0x70, 0x63, 0xa1, 0x4b, 0x64, 0x02, 0xc6,
-		0x2f, 0x99, 0xaf, 0xcb, 0x97, 0x06, 0xa4, 0xd1, 0xa1, 0x43, 0x27, 0x32, 0x59,
-		0xfe, 0x76, 0xf1, 0xc6, 0x05, 0xa3, 0x63, 0x97, 0x45, 0xa9, 0x21, 0x54, 0xb9,
-	}
-)
-
-// Vector 1: Both identities present (client/server)
-var (
-	tv1_context    = tvContext
-	tv1_idProver   = []byte("client")
-	tv1_idVerifier = []byte("server")
-	tv1_w0         = tvW0
-	tv1_w1         = tvW1
-	tv1_L          = tvL
-	tv1_x          = []byte{
-		0x8b, 0x0f, 0x3f, 0x38, 0x39, 0x05, 0xcf, 0x3a, 0x3b, 0xb9, 0x55,
-		0xef, 0x8f, 0xb6, 0x2e, 0x24, 0x84, 0x9d, 0xd3, 0x49, 0xa0, 0x5c,
-		0xa7, 0x9a, 0xaf, 0xb1, 0x80, 0x41, 0xd3, 0x0c, 0xbd, 0xb6,
-	}
-	tv1_X = []byte{
-		0x04, 0xaf, 0x09, 0x98, 0x7a, 0x59, 0x3d, 0x3b, 0xac, 0x86, 0x94, 0xb1, 0x23,
-		0x83, 0x94, 0x22, 0xc3, 0xcc, 0x87, 0xe3, 0x7d, 0x6b, 0x41, 0xc1, 0xd6, 0x30,
-		0xf0, 0x00, 0xdd, 0x64, 0x98, 0x0e, 0x53, 0x7a, 0xe7, 0x04, 0xbc, 0xed, 0xe0,
-		0x4e, 0xa3, 0xbe, 0xc9, 0xb7, 0x47, 0x5b, 0x32, 0xfa, 0x2c, 0xa3, 0xb6, 0x84,
-		0xbe, 0x14, 0xd1, 0x16, 0x45, 0xe3, 0x8e, 0xa6, 0x60, 0x9e, 0xb3, 0x9e, 0x7e,
-	}
-	tv1_y = []byte{
-		0x2e, 0x08, 0x95, 0xb0, 0xe7, 0x63, 0xd6, 0xd5, 0xa9, 0x56, 0x44,
-		0x33, 0xe6, 0x4a, 0xc3, 0xca, 0xc7, 0x4f, 0xf8, 0x97, 0xf6, 0xc3,
-		0x44, 0x52, 0x47, 0xba, 0x1b, 0xab, 0x40, 0x08, 0x2a, 0x91,
-	}
-	tv1_Y = []byte{
-		0x04, 0x41, 0x75, 0x92, 0x62, 0x0a, 0xeb, 0xf9, 0xfd, 0x20, 0x36, 0x16, 0xbb,
-		0xb9, 0xf1, 0x21, 0xb7, 0x30, 0xc2, 0x58, 0xb2, 0x86, 0xf8, 0x90, 0xc5, 0xf1,
-		0x9f, 0xea, 0x83, 0x3a, 0x9c, 0x90, 0x0c, 0xbe, 0x90, 0x57, 0xbc, 0x54, 0x9a,
-		0x3e, 0x19, 0x97, 0x5b, 0xe9, 0x92, 0x7f, 0x0e, 0x76, 0x14, 0xf0, 0x8d, 0x1f,
-		0x0a, 0x10, 0x8e, 0xed, 0xe5, 0xfd, 0x7e, 0xb5, 0x62, 0x45, 0x84, 0xa4, 0xf4,
-	}
-	tv1_Z = []byte{
-		0x04, 0x71, 0xa3, 0x52, 0x82, 0xd2, 0x02, 0x6f, 0x36, 0xbf, 0x3c, 0xeb, 0x38,
-		0xfc, 0xf8, 0x7e, 0x31, 0x12, 0xa4, 0x45, 0x2f, 0x46, 0xe9, 0xf7, 0xb4, 0x7f,
-		0xd7, 0x69, 0xcf, 0xb5, 0x70, 0x14, 0x5b, 0x62, 0x58, 0x9c, 0x76, 0xb7, 0xaa,
-		0x1e, 0xb6, 0x08, 0x0a, 0x83, 0x2e, 0x53, 0x32, 0xc3, 0x68, 0x98, 0x42, 0x69,
-		0x12, 0xe2, 0x9c, 0x40, 0xef, 0x9e, 0x9c, 0x74, 0x2e, 0xee, 0x82, 0xbf, 0x30,
-	}
-	tv1_V = []byte{
-		0x04, 0x67, 0x18, 0x98, 0x1b, 0xf1, 0x5b, 0xc4, 0xdb, 0x53, 0x8f, 0xc1, 0xf1,
-		0xc1, 0xd0, 0x58, 0xcb, 0x0e, 0xec, 0xec, 0xf1, 0xdb, 0xe1, 0xb1, 0xea, 0x08,
-		0xa4, 0xe2, 0x52, 0x75, 0xd3, 0x82, 0xe8, 0x2b, 0x34, 0x8c, 0x81, 0x31, 0xd8,
-		0xed, 0x66, 0x9d, 0x16, 0x9c, 0x2e, 0x03, 0xa8, 0x58, 0xdb, 0x7c, 0xf6, 0xca,
-		0x28, 0x53, 0xa4, 0x07, 0x12, 0x51, 0xa3, 0x9f, 0xbe, 0x8c, 0xfc, 0x39, 0xbc,
-	}
-	tv1_Ka = []byte{
-		0xf9, 0xca, 0xb9, 0xad, 0xcc, 0x0e, 0xd8, 0xe5,
-		0xa4, 0xdb, 0x11, 0xa8, 0x50, 0x59, 0x14, 0xb2,
-	}
-	tv1_Ke = []byte{
-		0x80, 0x1d, 0xb2, 0x97, 0x65, 0x48, 0x16, 0xeb,
-		0x4f, 0x02, 0x86, 0x81, 0x29, 0xb9, 0xdc, 0x89,
-	}
-	tv1_KcA = []byte{
-		0x0d, 0x24, 0x8d, 0x7d, 0x19, 0x23, 0x4f, 0x14,
-		0x86, 0xb2, 0xef, 0xba, 0x51, 0x79, 0xc5, 0x2d,
-	}
-	tv1_KcB = []byte{
-		0x55, 0x62, 0x91, 0xdf, 0x26, 0xd7, 0x05, 0xa2,
-		0xca, 0xed, 0xd6, 0x47, 0x4d, 0xd0, 0x07, 0x9b,
-	}
-	tv1_MAC_KcA = []byte{
-		0xd4, 0x37, 0x6f, 0x2d, 0xa9, 0xc7, 0x22, 0x26, 0xdd, 0x15, 0x1b,
-		0x77, 0xc2, 0x91, 0x90, 0x71, 0x15, 0x5f, 0xc2, 0x2a, 0x20, 0x68,
-		0xd9, 0x0b, 0x5f, 0xaa, 0x6c, 0x78, 0xc1, 0x1e, 0x77, 0xdd,
-	}
-	tv1_MAC_KcB = []byte{
-		0x06, 0x60, 0xa6, 0x80, 0x66, 0x3e, 0x8c, 0x56, 0x95, 0x95, 0x6f,
-		0xb2, 0x2d, 0xff, 0x29, 0x8b, 0x1d, 0x07, 0xa5, 0x26, 0xcf, 0x3c,
-		0xc5, 0x91, 0xad, 0xfe, 0xcd, 0x1f, 0x6e, 0xf6, 0xe0, 0x2e,
-	}
-)
-
-// Vector 2: Empty verifier identity (client/"")
-var (
-	tv2_idProver   = []byte("client")
-	tv2_idVerifier = []byte{}
-	tv2_x          = []byte{
-		0xec, 0x82, 0xd9, 0x25, 0x83, 0x37, 0xf6, 0x12, 0x39, 0xc9, 0xcd,
-		0x68, 0xe8, 0xe5, 0x32, 0xa3, 0xa6, 0xb8, 0x3d, 0x12, 0xd2, 0xb1,
-		0xca, 0x5d, 0x54, 0x3f, 0x44, 0xde, 0xf1, 0x7d, 0xfb, 0x8d,
-	}
-	tv2_X = []byte{
-		0x04, 0x23, 0x07, 0x79, 0x96, 0x08, 0x24, 0x07, 0x6d, 0x36, 0x66, 0xa7, 0x41,
-		0x8e, 0x4d, 0x43, 0x3e, 0x2f, 0xa1, 0x5b, 0x06, 0x17, 0x6e, 0xab, 0xdd, 0x57,
-		0x2f, 0x43, 0xa3, 0x2e, 0xcc, 0x79, 0xa1, 0x92, 0xb2, 0x43, 0xd2, 0x62, 0x43,
-		0x10, 0xa7, 0x35, 0x62, 0x73, 0xb8, 0x6e, 0x5f, 0xd9, 0xbd, 0x62, 0x7d, 0x3a,
-		0xde, 0x76, 0x2b, 0xae, 0xff, 0x1a, 0x32, 0x0d, 0x4a, 0xd7, 0xa4, 0xe4, 0x7f,
-	}
-	tv2_y = []byte{
-		0xea, 0xc3, 0xf7, 0xde, 0x4b, 0x19, 0x8d, 0x5f, 0xe2, 0x5c, 0x44,
-		0x3c, 0x0c, 0xd4, 0x96, 0x38, 0x07, 0xad, 0xd7, 0x67, 0x81, 0x5d,
-		0xd0, 0x2a, 0x6f, 0x01, 0x33, 0xb4, 0xbc, 0x2c, 0x9e, 0xb0,
-	}
-	tv2_Y = []byte{
-		0x04, 0x45, 0x58, 0x64, 0x2e, 0x71, 0xb6, 0x16, 0xb2, 0x48, 0xc9, 0x58, 0x3b,
-		0xd6, 0xd7, 0xaa, 0x1b, 0x39, 0x52, 0xc6, 0xdf, 0x6a, 0x9f, 0x74, 0x92, 0xa0,
-		0x60, 0x35, 0xca, 0x5d, 0x92, 0x52, 0x2d, 0x84, 0x44, 0x3d, 0xe7, 0xaa, 0x20,
-		0xa5, 0x93, 0x80, 0xfa, 0x4d, 0xe6, 0xb7, 0x43, 0x8d, 0x92, 0x5d, 0xbf, 0xb7,
-		0xf1, 0xcf, 0xe6, 0x0d, 0x79, 0xac, 0xf9, 0x61, 0xee, 0x33, 0x98, 0x8c, 0x7d,
-	}
-	tv2_Z = []byte{
-		0x04, 0xb4, 0xe8, 0x77, 0x0f, 0x19, 0xf5, 0x8d, 0xdf, 0x83, 0xf9, 0x22, 0x0c,
-		0x3a, 0x93, 0x05, 0x79, 0x26, 0x65, 0xe0, 0xc6, 0x09, 0x89, 0xe6, 0xee, 0x9d,
-		0x7f, 0xa4, 0x49, 0xc7, 0x75, 0xd6, 0x39, 0x5f, 0x6f, 0x25, 0xf3, 0x07, 0xe3,
-		0x90, 0x3a, 0xc0, 0x45, 0xa0, 0x13, 0xfb, 0xb5, 0xa6, 0x76, 0xe8, 0x72, 0xa6,
-		0xab, 0xfc, 0xf4, 0xd7, 0xbb, 0x5a, 0xac, 0x69, 0xef, 0xd6, 0x14, 0x0e, 0xed,
-	}
-	tv2_V = []byte{
-		0x04, 0x14, 0x1d, 0xb8, 0x3b, 0xc7, 0xd9, 0x6f, 0x41, 0xb6, 0x36, 0x62, 0x2e,
-		0x7a, 0x5c, 0x55, 0x2a, 0xd8, 0x32, 0x11, 0xff, 0x55, 0x31, 0x9a, 0xc2, 0x5e,
-		0xd0, 0xa0, 0x9f, 0x08, 0x18, 0xbd, 0x94, 0x2e, 0x81, 0x50, 0x31, 0x9b, 0xfb,
-		0xfa, 0x68, 0x61, 0x83, 0x80, 0x6d, 0xc6, 0x19, 0x11, 0x18, 0x3f, 0x6a, 0x0f,
-		0x59, 0x56, 0x15, 0x60, 0x23, 0xd9, 0x6e, 0x0f, 0x93, 0xd2, 0x75, 0xbf, 0x50,
-	}
-	tv2_Ke = []byte{
-		0x69, 0x89, 0xd8, 0xf9, 0x17, 0x7e, 0xf7, 0xdf,
-		0x67, 0xda, 0x43, 0x79, 0x87, 0xf0, 0x72, 0x55,
-	}
-	tv2_MAC_KcA = []byte{
-		0xe1, 0xb9, 0x25, 0x88, 0x07, 0xba, 0x47, 0x50, 0xda, 0xe1, 0xd7,
-		0xf3, 0xc3, 0xc2, 0x94, 0xf1, 0x3d, 0xc4, 0xfa, 0x60, 0xcd, 0xe3,
-		0x46, 0xd5, 0xde, 0x7d, 0x20, 0x0e, 0x2f, 0x8f, 0xd3, 0xfc,
-	}
-	tv2_MAC_KcB = []byte{
-		0xb9, 0xc3, 0x9d, 0xfa, 0x49, 0xc4, 0x77, 0x57, 0xde, 0x77, 0x8d,
-		0x9b, 0xed, 0xea, 0xca, 0x24, 0x48, 0xb9, 0x05, 0xbe, 0x19, 0xa4,
-		0x3b, 0x94, 0xee, 0x24, 0xb7, 0x70, 0x20, 0x81, 0x35, 0xe3,
-	}
-)
 
-// Vector 3: Empty prover identity (""/server)
-var (
-	tv3_idProver   = []byte{}
-	tv3_idVerifier = []byte("server")
-	tv3_x          = []byte{
-		0xba, 0x0f, 0x0f, 0x5b, 0x78, 0xef, 0x23, 0xfd, 0x07, 0x86, 0x8e,
-		0x46, 0xae, 0xca, 0x63, 0xb5, 0x1f, 0xda, 0x51, 0x9a, 0x34, 0x20,
-		0x50, 0x1a, 0xcb, 0xe2, 0x3d, 0x53, 0xc2, 0x91, 0x87, 0x48,
-	}
-	tv3_X = []byte{
-		0x04, 0xc1, 0x4d, 0x28, 0xf4, 0x37, 0x0f, 0xea, 0x20, 0x74, 0x51, 0x06, 0xce,
-		0xa5, 0x8b, 0xcf, 0xb6, 0x0f, 0x29, 0x49, 0xfa, 0x4e, 0x13, 0x1b, 0x9a, 0xff,
-		0x5e, 0xa1, 0x3f, 0xd5, 0xaa, 0x79, 0xd5, 0x07, 0xae, 0x1d, 0x22, 0x9e, 0x44,
-		0x7e, 0x00, 0x0f, 0x15, 0xeb, 0x78, 0xa9, 0xa3, 0x2c, 0x2b, 0x88, 0x65, 0x2e,
-		0x34, 0x11, 0x64, 0x20, 0x43, 0xc1, 0xb2, 0xb7, 0x99, 0x2c, 0xf2, 0xd4, 0xde,
-	}
-	tv3_y = []byte{
-		0x39, 0x39, 0x7f, 0xbe, 0x6d, 0xb4, 0x7e, 0x9f, 0xbd, 0x1a, 0x26,
-		0x3d, 0x79, 0xf5, 0xd0, 0xaa, 0xa4, 0x4d, 0xf2, 0x6c, 0xe7, 0x55,
-		0xf7, 0x8e, 0x09, 0x26, 0x44, 0xb4, 0x34, 0x53, 0x3a, 0x42,
-	}
-	tv3_Y = []byte{
-		0x04, 0xd1, 0xbe, 0xe3, 0x12, 0x0f, 0xd8, 0x7e, 0x86, 0xfe, 0x18, 0x9c, 0xb9,
-		0x52, 0xdc, 0x68, 0x88, 0x23, 0x08, 0x0e, 0x62, 0x52, 0x4d, 0xd2, 0xc0, 0x8d,
-		0xff, 0xe3, 0xd2, 0x2a, 0x0a, 0x89, 0x86, 0xaa, 0x64, 0xc9, 0xfe, 0x01, 0x91,
-		0x03, 0x3c, 0xaf, 0xbc, 0x9b, 0xca, 0xef, 0xc8, 0xe2, 0xba, 0x8b, 0xa8, 0x60,
-		0xcd, 0x12, 0x7a, 0xf9, 0xef, 0xdd, 0x7f, 0x1c, 0x3a, 0x41, 0x92, 0x0f, 0xe8,
-	}
-	tv3_Z = []byte{
-		0x04, 0xaa, 0xc7, 0x1c, 0xf4, 0xc8, 0xdf, 0x81, 0x81, 0xb8, 0x67, 0xc9, 0xec,
-		0xbe, 0xe9, 0xd0, 0x96, 0x3c, 0xaf, 0x51, 0xf1, 0x53, 0x4a, 0x82, 0x34, 0x29,
-		0xc2, 0x6f, 0xe5, 0x24, 0x83, 0x13, 0xff, 0xc5, 0xc5, 0xe4, 0x4e, 0xa8, 0x16,
-		0x21, 0x61, 0xab, 0x6b, 0x3d, 0x73, 0xb8, 0x77, 0x04, 0xa4, 0x58, 0x89, 0xbf,
-		0x63, 0x43, 0xd9, 0x6f, 0xa9, 0x6c, 0xd1, 0x64, 0x1e, 0xfa, 0x71, 0x60, 0x7c,
-	}
-	tv3_V = []byte{
-		0x04, 0xc7, 0xc9, 0x50, 0x53, 0x65, 0xf7, 0xce, 0x57, 0x29, 0x3c, 0x92, 0xa3,
-		0x7f, 0x1b, 0xbd, 0xc6, 0x8e, 0x03, 0x22, 0x90, 0x1e, 0x61, 0xed, 0xef, 0x59,
-		0xfe, 0xe7, 0x87, 0x6b, 0x17, 0xb0, 0x63, 0xe0, 0xfa, 0x4a, 0x12, 0x6e, 0xae,
-		0x0a, 0x67, 0x1b, 0x37, 0xf1, 0x46, 0x4c, 0xf1, 0xcc, 0xad, 0x59, 0x1c, 0x33,
-		0xae, 0x94, 0x4e, 0x3b, 0x1f, 0x31, 0x8d, 0x76, 0xe3, 0x6f, 0xea, 0x99, 0x66,
-	}
-	tv3_Ke = []byte{
-		0x2e, 0xa4, 0x0e, 0x4b, 0xad, 0xfa, 0x54, 0x52,
-		0xb5, 0x74, 0x4d, 0xc5, 0x98, 0x3e, 0x99, 0xba,
-	}
-	tv3_MAC_KcA = []byte{
-		0xe5, 0x64, 0xc9, 0x3b, 0x30, 0x15, 0xef, 0xb9, 0x46, 0xdc, 0x16,
-		0xd6, 0x42, 0xbb, 0xe7, 0xd1, 0xc8, 0xda, 0x5b, 0xe1, 0x64, 0xed,
-		0x9f, 0xc3, 0xba, 0xe4, 0xe0, 0xff, 0x86, 0xe1, 0xbd, 0x3c,
-	}
-	tv3_MAC_KcB = []byte{
-		0x07, 0x2a, 0x94, 0xd9, 0xa5, 0x4e, 0xdc, 0x20, 0x1d, 0x88, 0x91,
-		0x53, 0x4c, 0x23, 0x17, 0xca, 0xdf, 0x3e, 0xa3, 0x79, 0x28, 0x27,
-		0xf4, 0x79, 0xe8, 0x73, 0xf9, 0x3e, 0x90, 0xf2, 0x15, 0x52,
-	}
+	"github.com/backkem/matter/pkg/testvectors"
 )
 
-// Vector 4: Both identities empty (""/"")
-var (
-	tv4_idProver   = []byte{}
-	tv4_idVerifier = []byte{}
-	tv4_x          = []byte{
-		0x5b, 0x47, 0x86, 0x19, 0x80, 0x4f, 0x49, 0x38, 0xd3, 0x61, 0xfb,
-		0xba, 0x3a, 0x20, 0x64, 0x87, 0x25, 0x22, 0x2f, 0x0a, 0x54, 0xcc,
-		0x4c, 0x87, 0x61, 0x39, 0xef, 0xe7, 0xd9, 0xa2, 0x17, 0x86,
-	}
-	tv4_X = []byte{
-		0x04, 0xa6, 0xdb, 0x23, 0xd0, 0x01, 0x72, 0x3f, 0xb0, 0x1f, 0xcf, 0xc9, 0xd0,
-		0x87, 0x46, 0xc3, 0xc2, 0xa0, 0xa3, 0xfe, 0xff, 0x86, 0x35, 0xd2, 0x9c, 0xad,
-		0x28, 0x53, 0xe7, 0x35, 0x86, 0x23, 0x42, 0x5c, 0xf3, 0x97, 0x12, 0xe9, 0x28,
-		0x05, 0x45, 0x61, 0xba, 0x71, 0xe2, 0xdc, 0x11, 0xf3, 0x00, 0xf1, 0x76, 0x0e,
-		0x71, 0xeb, 0x17, 0x70, 0x21, 0xa8, 0xf8, 0x5e, 0x78, 0x68, 0x90, 0x71, 0xcd,
-	}
-	tv4_y = []byte{
-		0x76, 0x67, 0x70, 0xda, 0xd8, 0xc8, 0xee, 0xcb, 0xa9, 0x36, 0x82,
-		0x3c, 0x0a, 0xed, 0x04, 0x4b, 0x8c, 0x3c, 0x4f, 0x76, 0x55, 0xe8,
-		0xbe, 0xec, 0x44, 0xa1, 0x5d, 0xcb, 0xca, 0xf7, 0x8e, 0x5e,
-	}
-	tv4_Y = []byte{
-		0x04, 0x39, 0x0d, 0x29, 0xbf, 0x18, 0x5c, 0x3a, 0xbf, 0x99, 0xf1, 0x50, 0xae,
-		0x7c, 0x13, 0x38, 0x8c, 0x82, 0xb6, 0xbe, 0x0c, 0x07, 0xb1, 0xb8, 0xd9, 0x0d,
-		0x26, 0x85, 0x3e, 0x84, 0x37, 0x4b, 0xbd, 0xc8, 0x2b, 0xec, 0xdb, 0x97, 0x8c,
-		0xa3, 0x79, 0x2f, 0x47, 0x24, 0x24, 0x10, 0x6a, 0x25, 0x78, 0x01, 0x27, 0x52,
-		0xc1, 0x19, 0x38, 0xfc, 0xf6, 0x0a, 0x41, 0xdf, 0x75, 0xff, 0x7c, 0xf9, 0x47,
-	}
-	tv4_Z = []byte{
-		0x04, 0x0a, 0x15, 0x0d, 0x9a, 0x62, 0xf5, 0x14, 0xc9, 0xa1, 0xfe, 0xdd, 0x78,
-		0x2a, 0x02, 0x40, 0xa3, 0x42, 0x72, 0x10, 0x46, 0xce, 0xfb, 0x11, 0x11, 0xc3,
-		0xad, 0xb3, 0xbe, 0x89, 0x3c, 0xe9, 0xfc, 0xd2, 0xff, 0xa1, 0x37, 0x92, 0x2f,
-		0xcf, 0x8a, 0x58, 0x8d, 0x0f, 0x76, 0xba, 0x9c, 0x55, 0xc8, 0x5d, 0xa2, 0xaf,
-		0x3f, 0x1c, 0x78, 0x9c, 0xa1, 0x79, 0x76, 0x81, 0x03, 0x87, 0xfb, 0x1d, 0x7e,
-	}
-	tv4_V = []byte{
-		0x04, 0xf8, 0xe2, 0x47, 0xcc, 0x26, 0x3a, 0x18, 0x46, 0x27, 0x2f, 0x5a, 0x3b,
-		0x61, 0xb6, 0x8a, 0xa6, 0x0a, 0x5a, 0x26, 0x65, 0xd1, 0x0c, 0xd2, 0x2c, 0x89,
-		0xcd, 0x6b, 0xad, 0x05, 0xdc, 0x0e, 0x5e, 0x65, 0x0f, 0x21, 0xff, 0x01, 0x71,
-		0x86, 0xcc, 0x92, 0x65, 0x1a, 0x4c, 0xd7, 0xe6, 0x6c, 0xe8, 0x8f, 0x52, 0x92,
-		0x99, 0xf3, 0x40, 0xea, 0x80, 0xfb, 0x90, 0xa9, 0xba, 0xd0, 0x94, 0xe1, 0xa6,
-	}
-	tv4_Ke = []byte{
-		0xea, 0x32, 0x76, 0xd6, 0x83, 0x34, 0x57, 0x60,
-		0x97, 0xe0, 0x4b, 0x19, 0xee, 0x5a, 0x3a, 0x8b,
-	}
-	tv4_MAC_KcA = []byte{
-		0x71, 0xd9, 0x41, 0x27, 0x79, 0xb6, 0xc4, 0x5a, 0x2c, 0x61, 0x5c,
-		0x9d, 0xf3, 0xf1, 0xfd, 0x93, 0xdc, 0x0a, 0xaf, 0x63, 0x10, 0x4d,
-		0xa8, 0xec, 0xe4, 0xaa, 0x1b, 0x5a, 0x3a, 0x41, 0x5f, 0xea,
-	}
-	tv4_MAC_KcB = []byte{
-		0x09, 0x5d, 0xc0, 0x40, 0x03, 0x55, 0xcc, 0x23, 0x3f, 0xde, 0x74,
-		0x37, 0x81, 0x18, 0x15, 0xb3, 0xc1, 0x52, 0x4a, 0xae, 0x80, 0xfd,
-		0x4e, 0x68, 0x10, 0xcf, 0x53, 0x1c, 0xf1, 0x1d, 0x20, 0xe3,
-	}
-)
+// tv1 is the first SDK test vector (client/server identities), the only
+// one that records Ka, KcA and KcB.
+var tv1 = testvectors.SPAKE2P[0]
 
 // deterministicReader returns a reader that provides the given bytes.
 type deterministicReader struct {
@@ -300,26 +33,26 @@ func (r *deterministicReader) Read(p []byte) (n int, err error) {
 
 func TestSPAKE2PVector1(t *testing.T) {
 	// Create prover with deterministic random
-	prover, err := NewProver(tv1_context, tv1_idProver, tv1_idVerifier, tv1_w0, tv1_w1)
+	prover, err := NewProver(tv1.Context, tv1.ProverIdentity, tv1.VerifierIdentity, tv1.W0, tv1.W1)
 	if err != nil {
 		t.Fatalf("NewProver failed: %v", err)
 	}
-	prover.SetRandom(newDeterministicReader(tv1_x))
+	prover.SetRandom(newDeterministicReader(tv1.ProverRandom))
 
 	// Create verifier with deterministic random
-	verifier, err := NewVerifier(tv1_context, tv1_idProver, tv1_idVerifier, tv1_w0, tv1_L)
+	verifier, err := NewVerifier(tv1.Context, tv1.ProverIdentity, tv1.VerifierIdentity, tv1.W0, tv1.L)
 	if err != nil {
 		t.Fatalf("NewVerifier failed: %v", err)
 	}
-	verifier.SetRandom(newDeterministicReader(tv1_y))
+	verifier.SetRandom(newDeterministicReader(tv1.VerifierRandom))
 
 	// Prover generates X
 	X, err := prover.GenerateShare()
 	if err != nil {
 		t.Fatalf("Prover.GenerateShare failed: %v", err)
 	}
-	if !bytes.Equal(X, tv1_X) {
-		t.Errorf("X mismatch\ngot:  %x\nwant: %x", X, tv1_X)
+	if !bytes.Equal(X, tv1.ProverShare) {
+		t.Errorf("X mismatch\ngot:  %x\nwant: %x", X, tv1.ProverShare)
 	}
 
 	// Verifier generates Y
@@ -327,8 +60,8 @@ func TestSPAKE2PVector1(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Verifier.GenerateShare failed: %v", err)
 	}
-	if !bytes.Equal(Y, tv1_Y) {
-		t.Errorf("Y mismatch\ngot:  %x\nwant: %x", Y, tv1_Y)
+	if !bytes.Equal(Y, tv1.VerifierShare) {
+		t.Errorf("Y mismatch\ngot:  %x\nwant: %x", Y, tv1.VerifierShare)
 	}
 
 	// Verifier processes X
@@ -337,11 +70,11 @@ func TestSPAKE2PVector1(t *testing.T) {
 	}
 
 	// Check verifier's Z and V
-	if !bytes.Equal(verifier.Z, tv1_Z) {
-		t.Errorf("Verifier Z mismatch\ngot:  %x\nwant: %x", verifier.Z, tv1_Z)
+	if !bytes.Equal(verifier.Z, tv1.Z) {
+		t.Errorf("Verifier Z mismatch\ngot:  %x\nwant: %x", verifier.Z, tv1.Z)
 	}
-	if !bytes.Equal(verifier.V, tv1_V) {
-		t.Errorf("Verifier V mismatch\ngot:  %x\nwant: %x", verifier.V, tv1_V)
+	if !bytes.Equal(verifier.V, tv1.V) {
+		t.Errorf("Verifier V mismatch\ngot:  %x\nwant: %x", verifier.V, tv1.V)
 	}
 
 	// Prover processes Y
@@ -350,33 +83,33 @@ func TestSPAKE2PVector1(t *testing.T) {
 	}
 
 	// Check prover's Z and V
-	if !bytes.Equal(prover.Z, tv1_Z) {
-		t.Errorf("Prover Z mismatch\ngot:  %x\nwant: %x", prover.Z, tv1_Z)
+	if !bytes.Equal(prover.Z, tv1.Z) {
+		t.Errorf("Prover Z mismatch\ngot:  %x\nwant: %x", prover.Z, tv1.Z)
 	}
-	if !bytes.Equal(prover.V, tv1_V) {
-		t.Errorf("Prover V mismatch\ngot:  %x\nwant: %x", prover.V, tv1_V)
+	if !bytes.Equal(prover.V, tv1.V) {
+		t.Errorf("Prover V mismatch\ngot:  %x\nwant: %x", prover.V, tv1.V)
 	}
 
 	// Check derived keys
-	if !bytes.Equal(prover.Ka, tv1_Ka) {
-		t.Errorf("Prover Ka mismatch\ngot:  %x\nwant: %x", prover.Ka, tv1_Ka)
+	if !bytes.Equal(prover.Ka, tv1.Ka) {
+		t.Errorf("Prover Ka mismatch\ngot:  %x\nwant: %x", prover.Ka, tv1.Ka)
 	}
-	if !bytes.Equal(prover.Ke, tv1_Ke) {
-		t.Errorf("Prover Ke mismatch\ngot:  %x\nwant: %x", prover.Ke, tv1_Ke)
+	if !bytes.Equal(prover.Ke, tv1.Ke) {
+		t.Errorf("Prover Ke mismatch\ngot:  %x\nwant: %x", prover.Ke, tv1.Ke)
 	}
-	if !bytes.Equal(prover.KcA, tv1_KcA) {
-		t.Errorf("Prover KcA mismatch\ngot:  %x\nwant: %x", prover.KcA, tv1_KcA)
+	if !bytes.Equal(prover.KcA, tv1.KcA) {
+		t.Errorf("Prover KcA mismatch\ngot:  %x\nwant: %x", prover.KcA, tv1.KcA)
 	}
-	if !bytes.Equal(prover.KcB, tv1_KcB) {
-		t.Errorf("Prover KcB mismatch\ngot:  %x\nwant: %x", prover.KcB, tv1_KcB)
+	if !bytes.Equal(prover.KcB, tv1.KcB) {
+		t.Errorf("Prover KcB mismatch\ngot:  %x\nwant: %x", prover.KcB, tv1.KcB)
 	}
 
 	// Check verifier's keys match
-	if !bytes.Equal(verifier.Ka, tv1_Ka) {
-		t.Errorf("Verifier Ka mismatch\ngot:  %x\nwant: %x", verifier.Ka, tv1_Ka)
+	if !bytes.Equal(verifier.Ka, tv1.Ka) {
+		t.Errorf("Verifier Ka mismatch\ngot:  %x\nwant: %x", verifier.Ka, tv1.Ka)
 	}
-	if !bytes.Equal(verifier.Ke, tv1_Ke) {
-		t.Errorf("Verifier Ke mismatch\ngot:  %x\nwant: %x", verifier.Ke, tv1_Ke)
+	if !bytes.Equal(verifier.Ke, tv1.Ke) {
+		t.Errorf("Verifier Ke mismatch\ngot:  %x\nwant: %x", verifier.Ke, tv1.Ke)
 	}
 
 	// Get confirmations
@@ -384,16 +117,16 @@ func TestSPAKE2PVector1(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Verifier.Confirmation failed: %v", err)
 	}
-	if !bytes.Equal(confirmV, tv1_MAC_KcB) {
-		t.Errorf("confirmV mismatch\ngot:  %x\nwant: %x", confirmV, tv1_MAC_KcB)
+	if !bytes.Equal(confirmV, tv1.VerifierConfirmation) {
+		t.Errorf("confirmV mismatch\ngot:  %x\nwant: %x", confirmV, tv1.VerifierConfirmation)
 	}
 
 	confirmP, err := prover.Confirmation()
 	if err != nil {
 		t.Fatalf("Prover.Confirmation failed: %v", err)
 	}
-	if !bytes.Equal(confirmP, tv1_MAC_KcA) {
-		t.Errorf("confirmP mismatch\ngot:  %x\nwant: %x", confirmP, tv1_MAC_KcA)
+	if !bytes.Equal(confirmP, tv1.ProverConfirmation) {
+		t.Errorf("confirmP mismatch\ngot:  %x\nwant: %x", confirmP, tv1.ProverConfirmation)
 	}
 
 	// Verify confirmations
@@ -410,102 +143,44 @@ func TestSPAKE2PVector1(t *testing.T) {
 	if !bytes.Equal(proverSecret, verifierSecret) {
 		t.Errorf("Shared secrets don't match\nprover:   %x\nverifier: %x", proverSecret, verifierSecret)
 	}
-	if !bytes.Equal(proverSecret, tv1_Ke) {
-		t.Errorf("Shared secret mismatch\ngot:  %x\nwant: %x", proverSecret, tv1_Ke)
+	if !bytes.Equal(proverSecret, tv1.Ke) {
+		t.Errorf("Shared secret mismatch\ngot:  %x\nwant: %x", proverSecret, tv1.Ke)
 	}
 }
 
 // TestSPAKE2PVectors tests all SDK RFC test vectors using a table-driven approach.
 // This covers edge cases with empty identity strings.
 func TestSPAKE2PVectors(t *testing.T) {
-	tests := []struct {
-		name       string
-		idProver   []byte
-		idVerifier []byte
-		x          []byte // prover random
-		y          []byte // verifier random
-		wantX      []byte
-		wantY      []byte
-		wantZ      []byte
-		wantV      []byte
-		wantKe     []byte
-		wantMacA   []byte
-		wantMacB   []byte
-	}{
-		{
-			name:       "Vector2_EmptyVerifier",
-			idProver:   tv2_idProver,
-			idVerifier: tv2_idVerifier,
-			x:          tv2_x,
-			y:          tv2_y,
-			wantX:      tv2_X,
-			wantY:      tv2_Y,
-			wantZ:      tv2_Z,
-			wantV:      tv2_V,
-			wantKe:     tv2_Ke,
-			wantMacA:   tv2_MAC_KcA,
-			wantMacB:   tv2_MAC_KcB,
-		},
-		{
-			name:       "Vector3_EmptyProver",
-			idProver:   tv3_idProver,
-			idVerifier: tv3_idVerifier,
-			x:          tv3_x,
-			y:          tv3_y,
-			wantX:      tv3_X,
-			wantY:      tv3_Y,
-			wantZ:      tv3_Z,
-			wantV:      tv3_V,
-			wantKe:     tv3_Ke,
-			wantMacA:   tv3_MAC_KcA,
-			wantMacB:   tv3_MAC_KcB,
-		},
-		{
-			name:       "Vector4_BothEmpty",
-			idProver:   tv4_idProver,
-			idVerifier: tv4_idVerifier,
-			x:          tv4_x,
-			y:          tv4_y,
-			wantX:      tv4_X,
-			wantY:      tv4_Y,
-			wantZ:      tv4_Z,
-			wantV:      tv4_V,
-			wantKe:     tv4_Ke,
-			wantMacA:   tv4_MAC_KcA,
-			wantMacB:   tv4_MAC_KcB,
-		},
-	}
-
-	for _, tc := range tests {
-		t.Run(tc.name, func(t *testing.T) {
+	for _, tc := range testvectors.SPAKE2P {
+		t.Run(tc.Name, func(t *testing.T) {
 			// Create prover and verifier with deterministic random
-			prover, err := NewProver(tvContext, tc.idProver, tc.idVerifier, tvW0, tvW1)
+			prover, err := NewProver(tc.Context, tc.ProverIdentity, tc.VerifierIdentity, tc.W0, tc.W1)
 			if err != nil {
 				t.Fatalf("NewProver failed: %v", err)
 			}
-			prover.SetRandom(newDeterministicReader(tc.x))
+			prover.SetRandom(newDeterministicReader(tc.ProverRandom))
 
-			verifier, err := NewVerifier(tvContext, tc.idProver, tc.idVerifier, tvW0, tvL)
+			verifier, err := NewVerifier(tc.Context, tc.ProverIdentity, tc.VerifierIdentity, tc.W0, tc.L)
 			if err != nil {
 				t.Fatalf("NewVerifier failed: %v", err)
 			}
-			verifier.SetRandom(newDeterministicReader(tc.y))
+			verifier.SetRandom(newDeterministicReader(tc.VerifierRandom))
 
 			// Generate shares
 			X, err := prover.GenerateShare()
 			if err != nil {
 				t.Fatalf("Prover.GenerateShare failed: %v", err)
 			}
-			if !bytes.Equal(X, tc.wantX) {
-				t.Errorf("X mismatch\ngot:  %x\nwant: %x", X, tc.wantX)
+			if !bytes.Equal(X, tc.ProverShare) {
+				t.Errorf("X mismatch\ngot:  %x\nwant: %x", X, tc.ProverShare)
 			}
 
 			Y, err := verifier.GenerateShare()
 			if err != nil {
 				t.Fatalf("Verifier.GenerateShare failed: %v", err)
 			}
-			if !bytes.Equal(Y, tc.wantY) {
-				t.Errorf("Y mismatch\ngot:  %x\nwant: %x", Y, tc.wantY)
+			if !bytes.Equal(Y, tc.VerifierShare) {
+				t.Errorf("Y mismatch\ngot:  %x\nwant: %x", Y, tc.VerifierShare)
 			}
 
 			// Process peer shares
@@ -517,27 +192,27 @@ func TestSPAKE2PVectors(t *testing.T) {
 			}
 
 			// Check Z and V
-			if !bytes.Equal(verifier.Z, tc.wantZ) {
-				t.Errorf("Z mismatch\ngot:  %x\nwant: %x", verifier.Z, tc.wantZ)
+			if !bytes.Equal(verifier.Z, tc.Z) {
+				t.Errorf("Z mismatch\ngot:  %x\nwant: %x", verifier.Z, tc.Z)
 			}
-			if !bytes.Equal(verifier.V, tc.wantV) {
-				t.Errorf("V mismatch\ngot:  %x\nwant: %x", verifier.V, tc.wantV)
+			if !bytes.Equal(verifier.V, tc.V) {
+				t.Errorf("V mismatch\ngot:  %x\nwant: %x", verifier.V, tc.V)
 			}
 
 			// Check Ke
-			if !bytes.Equal(prover.Ke, tc.wantKe) {
-				t.Errorf("Ke mismatch\ngot:  %x\nwant: %x", prover.Ke, tc.wantKe)
+			if !bytes.Equal(prover.Ke, tc.Ke) {
+				t.Errorf("Ke mismatch\ngot:  %x\nwant: %x", prover.Ke, tc.Ke)
 			}
 
 			// Check confirmations
 			confirmV, _ := verifier.Confirmation()
-			if !bytes.Equal(confirmV, tc.wantMacB) {
-				t.Errorf("MAC_KcB mismatch\ngot:  %x\nwant: %x", confirmV, tc.wantMacB)
+			if !bytes.Equal(confirmV, tc.VerifierConfirmation) {
+				t.Errorf("MAC_KcB mismatch\ngot:  %x\nwant: %x", confirmV, tc.VerifierConfirmation)
 			}
 
 			confirmP, _ := prover.Confirmation()
-			if !bytes.Equal(confirmP, tc.wantMacA) {
-				t.Errorf("MAC_KcA mismatch\ngot:  %x\nwant: %x", confirmP, tc.wantMacA)
+			if !bytes.Equal(confirmP, tc.ProverConfirmation) {
+				t.Errorf("MAC_KcA mismatch\ngot:  %x\nwant: %x", confirmP, tc.ProverConfirmation)
 			}
 
 			// Verify mutual confirmation
@@ -558,12 +233,12 @@ func TestSPAKE2PVectors(t *testing.T) {
 
 func TestSPAKE2PRoundtrip(t *testing.T) {
 	// Use real random values for a complete protocol run
-	prover, err := NewProver(tv1_context, tv1_idProver, tv1_idVerifier, tv1_w0, tv1_w1)
+	prover, err := NewProver(tv1.Context, tv1.ProverIdentity, tv1.VerifierIdentity, tv1.W0, tv1.W1)
 	if err != nil {
 		t.Fatalf("NewProver failed: %v", err)
 	}
 
-	verifier, err := NewVerifier(tv1_context, tv1_idProver, tv1_idVerifier, tv1_w0, tv1_L)
+	verifier, err := NewVerifier(tv1.Context, tv1.ProverIdentity, tv1.VerifierIdentity, tv1.W0, tv1.L)
 	if err != nil {
 		t.Fatalf("NewVerifier failed: %v", err)
 	}
@@ -609,6 +284,37 @@ func TestSPAKE2PRoundtrip(t *testing.T) {
 	}
 }
 
+func TestSPAKE2PZeroize(t *testing.T) {
+	prover, _ := NewProver(tv1.Context, tv1.ProverIdentity, tv1.VerifierIdentity, tv1.W0, tv1.W1)
+	verifier, _ := NewVerifier(tv1.Context, tv1.ProverIdentity, tv1.VerifierIdentity, tv1.W0, tv1.L)
+	X, _ := prover.GenerateShare()
+	Y, _ := verifier.GenerateShare()
+	if err := verifier.ProcessPeerShare(X); err != nil {
+		t.Fatalf("Verifier.ProcessPeerShare failed: %v", err)
+	}
+	if err := prover.ProcessPeerShare(Y); err != nil {
+		t.Fatalf("Prover.ProcessPeerShare failed: %v", err)
+	}
+	ke := prover.Ke
+
+	prover.Zeroize()
+	if !bytes.Equal(ke, make([]byte, len(ke))) {
+		t.Errorf("Ke = % X after Zeroize", ke)
+	}
+	if prover.w0.Sign() != 0 || prover.w1.Sign() != 0 || prover.myRandom.Sign() != 0 {
+		t.Error("secret scalars not zeroized")
+	}
+	if secret := prover.SharedSecret(); secret != nil {
+		t.Errorf("SharedSecret() = % X after Zeroize", secret)
+	}
+	if _, err := prover.Confirmation(); err != ErrInvalidState {
+		t.Errorf("Confirmation() after Zeroize error = %v, want %v", err, ErrInvalidState)
+	}
+	if _, err := prover.GenerateShare(); err != ErrInvalidState {
+		t.Errorf("GenerateShare() after Zeroize error = %v, want %v", err, ErrInvalidState)
+	}
+}
+
 func TestNewProverInvalidInputs(t *testing.T) {
 	validW0 := make([]byte, 32)
 	validW1 := make([]byte, 32)
@@ -644,8 +350,8 @@ func TestNewVerifierInvalidInputs(t *testing.T) {
 		L    []byte
 		err  error
 	}{
-		{"w0 too short", make([]byte, 31), tv1_L, ErrInvalidW0Size},
-		{"w0 too long", make([]byte, 33), tv1_L, ErrInvalidW0Size},
+		{"w0 too short", make([]byte, 31), tv1.L, ErrInvalidW0Size},
+		{"w0 too long", make([]byte, 33), tv1.L, ErrInvalidW0Size},
 		{"L too short", validW0, make([]byte, 64), ErrInvalidLSize},
 		{"L too long", validW0, make([]byte, 66), ErrInvalidLSize},
 	}
@@ -661,7 +367,7 @@ func TestNewVerifierInvalidInputs(t *testing.T) {
 }
 
 func TestInvalidStateTransitions(t *testing.T) {
-	prover, _ := NewProver([]byte("ctx"), nil, nil, tv1_w0, tv1_w1)
+	prover, _ := NewProver([]byte("ctx"), nil, nil, tv1.W0, tv1.W1)
 
 	// Can't process peer share before generating own share
 	if err := prover.ProcessPeerShare(make([]byte, 65)); err != ErrInvalidState {
@@ -675,8 +381,8 @@ func TestInvalidStateTransitions(t *testing.T) {
 }
 
 func TestWrongConfirmation(t *testing.T) {
-	prover, _ := NewProver(tv1_context, tv1_idProver, tv1_idVerifier, tv1_w0, tv1_w1)
-	verifier, _ := NewVerifier(tv1_context, tv1_idProver, tv1_idVerifier, tv1_w0, tv1_L)
+	prover, _ := NewProver(tv1.Context, tv1.ProverIdentity, tv1.VerifierIdentity, tv1.W0, tv1.W1)
+	verifier, _ := NewVerifier(tv1.Context, tv1.ProverIdentity, tv1.VerifierIdentity, tv1.W0, tv1.L)
 
 	X, _ := prover.GenerateShare()
 	Y, _ := verifier.GenerateShare()
@@ -18,6 +18,9 @@ type ClusterBase struct {
 	revision    uint16
 	featureMap  uint32
 	dataVersion atomic.Uint32
+
+	// changeListener is notified by MarkDirty.
+	changeListener atomic.Pointer[AttributeChangeListener]
 }
 
 // NewClusterBase creates a new cluster base with the given parameters.
@@ -68,6 +71,28 @@ func (c *ClusterBase) IncrementDataVersion() {
 	c.dataVersion.Add(1)
 }
 
+// MarkDirty records a change of an attribute's value: it increments the
+// data version and notifies the change listener, which reports the
+// attribute to subscribers.
+// Call this instead of IncrementDataVersion when an attribute changes.
+func (c *ClusterBase) MarkDirty(attrID AttributeID) {
+	c.IncrementDataVersion()
+	if listener := c.changeListener.Load(); listener != nil {
+		(*listener).OnAttributeChanged(c.AttributePath(attrID))
+	}
+}
+
+// SetAttributeChangeListener sets the listener notified by MarkDirty.
+// The node sets it when the cluster's endpoint is added; nil clears it.
+// Implements AttributeChangeSource.
+func (c *ClusterBase) SetAttributeChangeListener(listener AttributeChangeListener) {
+	if listener == nil {
+		c.changeListener.Store(nil)
+		return
+	}
+	c.changeListener.Store(&listener)
+}
+
 // SetDataVersion sets the data version to a specific value.
 // Use IncrementDataVersion for normal updates; this is for initialization.
 func (c *ClusterBase) SetDataVersion(version DataVersion) {
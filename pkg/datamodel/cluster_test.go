@@ -59,6 +59,37 @@ func TestClusterBase_DataVersion(t *testing.T) {
 	}
 }
 
+func TestClusterBase_MarkDirty(t *testing.T) {
+	cb := NewClusterBase(ClusterOnOff, 1, 1)
+	initial := cb.DataVersion()
+
+	// Without a listener, only the data version changes
+	cb.MarkDirty(0x0000)
+	if cb.DataVersion() != initial+1 {
+		t.Errorf("DataVersion() = %v, want %v", cb.DataVersion(), initial+1)
+	}
+
+	var changed []ConcreteAttributePath
+	cb.SetAttributeChangeListener(AttributeChangeListenerFunc(func(path ConcreteAttributePath) {
+		changed = append(changed, path)
+	}))
+	cb.MarkDirty(0x4001)
+
+	want := ConcreteAttributePath{Endpoint: 1, Cluster: ClusterOnOff, Attribute: 0x4001}
+	if len(changed) != 1 || changed[0] != want {
+		t.Errorf("changed = %v, want [%v]", changed, want)
+	}
+	if cb.DataVersion() != initial+2 {
+		t.Errorf("DataVersion() = %v, want %v", cb.DataVersion(), initial+2)
+	}
+
+	cb.SetAttributeChangeListener(nil)
+	cb.MarkDirty(0x0000)
+	if len(changed) != 1 {
+		t.Errorf("cleared listener was notified")
+	}
+}
+
 func TestClusterBase_Path(t *testing.T) {
 	cb := NewClusterBase(ClusterOnOff, 2, 1)
 
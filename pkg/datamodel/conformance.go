@@ -0,0 +1,241 @@
+package datamodel
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ClusterRequirement is a server cluster that a device type requires on
+// its endpoint, with the features and elements the device type makes
+// mandatory beyond the cluster's own conformance.
+type ClusterRequirement struct {
+	// Cluster is the required server cluster.
+	Cluster ClusterID
+
+	// Features are FeatureMap bits that must be set.
+	Features uint32
+
+	// Attributes must be in the cluster's AttributeList.
+	Attributes []AttributeID
+
+	// Commands must be in the cluster's AcceptedCommandList.
+	Commands []CommandID
+}
+
+// DeviceTypeRequirement lists the mandatory server clusters of a device
+// type.
+type DeviceTypeRequirement struct {
+	// Name is the device type name, used in conformance issues.
+	Name string
+
+	// Clusters are the mandatory server clusters.
+	Clusters []ClusterRequirement
+}
+
+// DeviceLibrary maps device types to their requirements. Device types
+// that are not in the library are not checked.
+type DeviceLibrary map[DeviceTypeID]DeviceTypeRequirement
+
+// Feature bits made mandatory by device types in DefaultDeviceLibrary.
+const (
+	featureOnOffLighting         uint32 = 1 << 0 // On/Off LT
+	featureLevelControlOnOff     uint32 = 1 << 0 // Level Control OO
+	featureLevelControlLighting  uint32 = 1 << 1 // Level Control LT
+	featureColorControlXY        uint32 = 1 << 3 // Color Control XY
+	featureColorControlColorTemp uint32 = 1 << 4 // Color Control CT
+)
+
+// attrColorControlRemainingTime is the RemainingTime attribute of the
+// Color Control cluster.
+const attrColorControlRemainingTime AttributeID = 0x0002
+
+// DefaultDeviceLibrary returns the requirements of common lighting,
+// switch, sensor and utility device types. The Root Node device type is
+// not included: the node builds its root endpoint itself.
+//
+// The returned library is a copy; callers may add their own device types.
+func DefaultDeviceLibrary() DeviceLibrary {
+	identify := ClusterRequirement{Cluster: ClusterIdentify}
+	lightBase := []ClusterRequirement{
+		identify,
+		{Cluster: ClusterGroups},
+		{Cluster: ClusterScenesManagement},
+		{Cluster: ClusterOnOff, Features: featureOnOffLighting},
+	}
+	levelControl := ClusterRequirement{
+		Cluster:  ClusterLevelControl,
+		Features: featureLevelControlOnOff | featureLevelControlLighting,
+	}
+	light := func(extra ...ClusterRequirement) []ClusterRequirement {
+		return append(append([]ClusterRequirement(nil), lightBase...), extra...)
+	}
+
+	return DeviceLibrary{
+		DeviceTypeOnOffLight: {
+			Name:     "On/Off Light",
+			Clusters: light(),
+		},
+		DeviceTypeDimmableLight: {
+			Name:     "Dimmable Light",
+			Clusters: light(levelControl),
+		},
+		DeviceTypeColorTemperatureLight: {
+			Name: "Color Temperature Light",
+			Clusters: light(levelControl, ClusterRequirement{
+				Cluster:    ClusterColorControl,
+				Features:   featureColorControlColorTemp,
+				Attributes: []AttributeID{attrColorControlRemainingTime},
+			}),
+		},
+		DeviceTypeExtendedColorLight: {
+			Name: "Extended Color Light",
+			Clusters: light(levelControl, ClusterRequirement{
+				Cluster:    ClusterColorControl,
+				Features:   featureColorControlXY | featureColorControlColorTemp,
+				Attributes: []AttributeID{attrColorControlRemainingTime},
+			}),
+		},
+		DeviceTypeOnOffLightSwitch: {
+			Name:     "On/Off Light Switch",
+			Clusters: []ClusterRequirement{identify},
+		},
+		DeviceTypeDimmerSwitch: {
+			Name:     "Dimmer Switch",
+			Clusters: []ClusterRequirement{identify},
+		},
+		DeviceTypeColorDimmerSwitch: {
+			Name:     "Color Dimmer Switch",
+			Clusters: []ClusterRequirement{identify},
+		},
+		DeviceTypeContactSensor: {
+			Name:     "Contact Sensor",
+			Clusters: []ClusterRequirement{identify, {Cluster: ClusterBooleanState}},
+		},
+		DeviceTypeTemperatureSensor: {
+			Name:     "Temperature Sensor",
+			Clusters: []ClusterRequirement{identify, {Cluster: ClusterTemperatureMeasurement}},
+		},
+		DeviceTypeOccupancySensor: {
+			Name:     "Occupancy Sensor",
+			Clusters: []ClusterRequirement{identify, {Cluster: ClusterOccupancySensing}},
+		},
+		DeviceTypePowerSource: {
+			Name:     "Power Source",
+			Clusters: []ClusterRequirement{{Cluster: ClusterPowerSource}},
+		},
+	}
+}
+
+// ConformanceIssue is a requirement an endpoint does not meet.
+type ConformanceIssue struct {
+	Endpoint       EndpointID
+	DeviceType     DeviceTypeID // Zero for requirements of every endpoint
+	DeviceTypeName string
+	Cluster        ClusterID // Zero if the issue is not about a cluster
+	Reason         string
+}
+
+// String describes the issue, e.g. "endpoint 1 (On/Off Light): cluster
+// 0x0006: missing mandatory features 0x00000001".
+func (i ConformanceIssue) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "endpoint %d", i.Endpoint)
+	if i.DeviceTypeName != "" {
+		fmt.Fprintf(&b, " (%s)", i.DeviceTypeName)
+	} else if i.DeviceType != 0 {
+		fmt.Fprintf(&b, " (device type 0x%04X)", uint32(i.DeviceType))
+	}
+	if i.Cluster != 0 {
+		fmt.Fprintf(&b, ": cluster 0x%04X", uint32(i.Cluster))
+	}
+	b.WriteString(": ")
+	b.WriteString(i.Reason)
+	return b.String()
+}
+
+// ConformanceError lists the conformance issues of a node. It matches
+// ErrNonConformant with errors.Is.
+type ConformanceError struct {
+	Issues []ConformanceIssue
+}
+
+// Error implements error.
+func (e *ConformanceError) Error() string {
+	msgs := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		msgs[i] = issue.String()
+	}
+	return fmt.Sprintf("%v: %s", ErrNonConformant, strings.Join(msgs, "; "))
+}
+
+// Unwrap returns ErrNonConformant.
+func (e *ConformanceError) Unwrap() error {
+	return ErrNonConformant
+}
+
+// CheckEndpoint returns the requirements of the endpoint's device types
+// that its server clusters do not meet. Every endpoint needs at least one
+// device type and a Descriptor cluster.
+func (l DeviceLibrary) CheckEndpoint(ep Endpoint) []ConformanceIssue {
+	var issues []ConformanceIssue
+	issue := func(dt DeviceTypeID, cluster ClusterID, format string, args ...any) {
+		issues = append(issues, ConformanceIssue{
+			Endpoint:       ep.ID(),
+			DeviceType:     dt,
+			DeviceTypeName: l[dt].Name,
+			Cluster:        cluster,
+			Reason:         fmt.Sprintf(format, args...),
+		})
+	}
+
+	deviceTypes := ep.GetDeviceTypes()
+	if len(deviceTypes) == 0 {
+		issue(0, 0, "no device type")
+	}
+	if ep.GetCluster(ClusterDescriptor) == nil {
+		issue(0, ClusterDescriptor, "missing mandatory cluster")
+	}
+
+	for _, dt := range deviceTypes {
+		req, ok := l[dt.DeviceTypeID]
+		if !ok {
+			continue
+		}
+		for _, cr := range req.Clusters {
+			c := ep.GetCluster(cr.Cluster)
+			if c == nil {
+				issue(dt.DeviceTypeID, cr.Cluster, "missing mandatory cluster")
+				continue
+			}
+			if missing := cr.Features &^ c.FeatureMap(); missing != 0 {
+				issue(dt.DeviceTypeID, cr.Cluster, "missing mandatory features 0x%08X", missing)
+			}
+			attrs := c.AttributeList()
+			for _, id := range cr.Attributes {
+				if FindAttribute(attrs, id) == nil {
+					issue(dt.DeviceTypeID, cr.Cluster, "missing mandatory attribute 0x%04X", uint32(id))
+				}
+			}
+			cmds := c.AcceptedCommandList()
+			for _, id := range cr.Commands {
+				if FindCommand(cmds, id) == nil {
+					issue(dt.DeviceTypeID, cr.Cluster, "missing mandatory command 0x%02X", uint32(id))
+				}
+			}
+		}
+	}
+	return issues
+}
+
+// CheckNode checks every endpoint of a node. It returns a
+// *ConformanceError listing all issues, or nil if the node conforms.
+func (l DeviceLibrary) CheckNode(node Node) error {
+	var issues []ConformanceIssue
+	for _, ep := range node.GetEndpoints() {
+		issues = append(issues, l.CheckEndpoint(ep)...)
+	}
+	if len(issues) == 0 {
+		return nil
+	}
+	return &ConformanceError{Issues: issues}
+}
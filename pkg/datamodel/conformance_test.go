@@ -0,0 +1,126 @@
+package datamodel
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type featureCluster struct {
+	mockCluster
+	features uint32
+	attrs    []AttributeEntry
+	cmds     []CommandEntry
+}
+
+func (c *featureCluster) FeatureMap() uint32                  { return c.features }
+func (c *featureCluster) AttributeList() []AttributeEntry     { return MergeAttributeLists(c.attrs) }
+func (c *featureCluster) AcceptedCommandList() []CommandEntry { return c.cmds }
+
+func newConformanceEndpoint(t *testing.T, id EndpointID, dt DeviceTypeID, clusters ...Cluster) *BasicEndpoint {
+	t.Helper()
+	ep := NewEndpoint(id)
+	if dt != 0 {
+		ep.AddDeviceType(DeviceTypeEntry{DeviceTypeID: dt, Revision: 1})
+	}
+	for _, c := range append([]Cluster{&mockCluster{id: ClusterDescriptor}}, clusters...) {
+		if err := ep.AddCluster(c); err != nil {
+			t.Fatalf("AddCluster(0x%04X) error = %v", uint32(c.ID()), err)
+		}
+	}
+	return ep
+}
+
+func TestDeviceLibrary_CheckEndpoint(t *testing.T) {
+	lib := DefaultDeviceLibrary()
+	lightClusters := func(onOffFeatures, levelFeatures uint32) []Cluster {
+		return []Cluster{
+			&mockCluster{id: ClusterIdentify},
+			&mockCluster{id: ClusterGroups},
+			&mockCluster{id: ClusterScenesManagement},
+			&featureCluster{mockCluster: mockCluster{id: ClusterOnOff}, features: onOffFeatures},
+			&featureCluster{mockCluster: mockCluster{id: ClusterLevelControl}, features: levelFeatures},
+		}
+	}
+
+	ep := newConformanceEndpoint(t, 1, DeviceTypeDimmableLight, lightClusters(0x1, 0x3)...)
+	if issues := lib.CheckEndpoint(ep); len(issues) != 0 {
+		t.Errorf("conformant Dimmable Light: issues = %v", issues)
+	}
+
+	ep = newConformanceEndpoint(t, 1, DeviceTypeDimmableLight, lightClusters(0x1, 0x1)...)
+	issues := lib.CheckEndpoint(ep)
+	if len(issues) != 1 || issues[0].Cluster != ClusterLevelControl {
+		t.Fatalf("missing Lighting feature: issues = %v", issues)
+	}
+	if want := "endpoint 1 (Dimmable Light): cluster 0x0008: missing mandatory features 0x00000002"; issues[0].String() != want {
+		t.Errorf("String() = %q, want %q", issues[0].String(), want)
+	}
+
+	ep = newConformanceEndpoint(t, 1, DeviceTypeTemperatureSensor)
+	issues = lib.CheckEndpoint(ep)
+	if len(issues) != 2 || issues[0].Cluster != ClusterIdentify || issues[1].Cluster != ClusterTemperatureMeasurement {
+		t.Errorf("missing clusters: issues = %v", issues)
+	}
+
+	// Device types outside the library are not checked
+	ep = newConformanceEndpoint(t, 1, 0xFFF10001)
+	if issues := lib.CheckEndpoint(ep); len(issues) != 0 {
+		t.Errorf("unknown device type: issues = %v", issues)
+	}
+
+	// Every endpoint needs a device type and a Descriptor
+	if issues := lib.CheckEndpoint(NewEndpoint(2)); len(issues) != 2 {
+		t.Errorf("empty endpoint: issues = %v", issues)
+	}
+}
+
+func TestDeviceLibrary_Elements(t *testing.T) {
+	const deviceType DeviceTypeID = 0xFFF10001
+	lib := DeviceLibrary{
+		deviceType: {
+			Name: "Sample",
+			Clusters: []ClusterRequirement{{
+				Cluster:    ClusterOnOff,
+				Attributes: []AttributeID{0x4000},
+				Commands:   []CommandID{0x40},
+			}},
+		},
+	}
+
+	conformant := &featureCluster{
+		mockCluster: mockCluster{id: ClusterOnOff},
+		attrs:       []AttributeEntry{{ID: 0x4000}},
+		cmds:        []CommandEntry{{ID: 0x40}},
+	}
+	if issues := lib.CheckEndpoint(newConformanceEndpoint(t, 1, deviceType, conformant)); len(issues) != 0 {
+		t.Errorf("conformant cluster: issues = %v", issues)
+	}
+
+	bare := &featureCluster{mockCluster: mockCluster{id: ClusterOnOff}}
+	issues := lib.CheckEndpoint(newConformanceEndpoint(t, 1, deviceType, bare))
+	if len(issues) != 2 ||
+		!strings.Contains(issues[0].Reason, "attribute 0x4000") ||
+		!strings.Contains(issues[1].Reason, "command 0x40") {
+		t.Errorf("missing elements: issues = %v", issues)
+	}
+}
+
+func TestDeviceLibrary_CheckNode(t *testing.T) {
+	lib := DefaultDeviceLibrary()
+	node := NewNode()
+	node.AddEndpoint(newConformanceEndpoint(t, 0, DeviceTypeRootNode))
+	if err := lib.CheckNode(node); err != nil {
+		t.Fatalf("CheckNode() error = %v", err)
+	}
+
+	node.AddEndpoint(newConformanceEndpoint(t, 1, DeviceTypeContactSensor, &mockCluster{id: ClusterIdentify}))
+	err := lib.CheckNode(node)
+	var cerr *ConformanceError
+	if !errors.Is(err, ErrNonConformant) || !errors.As(err, &cerr) {
+		t.Fatalf("CheckNode() error = %v, want a ConformanceError", err)
+	}
+	if len(cerr.Issues) != 1 || cerr.Issues[0].Cluster != ClusterBooleanState {
+		t.Errorf("Issues = %v", cerr.Issues)
+	}
+}
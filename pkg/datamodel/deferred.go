@@ -0,0 +1,61 @@
+package datamodel
+
+import "sync"
+
+// DeferredResponse is the response of a command that completes after
+// InvokeCommand returns, e.g. Network Commissioning ConnectNetwork, which
+// takes seconds. The cluster returns it as the error of InvokeCommand and
+// calls Complete from another goroutine once the command is done:
+//
+//	resp := datamodel.NewDeferredResponse()
+//	go func() {
+//	    resp.Complete(c.connect(ssid, credentials))
+//	}()
+//	return nil, resp
+//
+// The IM engine keeps the exchange open and sends the InvokeResponse when
+// Complete is called, or a Failure status if it is not called in time.
+type DeferredResponse struct {
+	once sync.Once
+	done chan struct{}
+
+	data []byte
+	err  error
+}
+
+// NewDeferredResponse creates a response to complete later.
+func NewDeferredResponse() *DeferredResponse {
+	return &DeferredResponse{done: make(chan struct{})}
+}
+
+// Error implements error, so a cluster can return the response from
+// InvokeCommand.
+func (d *DeferredResponse) Error() string {
+	return "command response deferred"
+}
+
+// Complete sets the command's response: the TLV-encoded response fields,
+// or the error to answer with a status. Only the first call has effect;
+// it returns false if the response was already complete, e.g. because
+// the engine gave up waiting.
+func (d *DeferredResponse) Complete(data []byte, err error) bool {
+	completed := false
+	d.once.Do(func() {
+		d.data, d.err = data, err
+		close(d.done)
+		completed = true
+	})
+	return completed
+}
+
+// Done returns a channel that is closed when the response is complete.
+func (d *DeferredResponse) Done() <-chan struct{} {
+	return d.done
+}
+
+// Result returns the response fields and error. It is valid once Done is
+// closed.
+func (d *DeferredResponse) Result() ([]byte, error) {
+	<-d.done
+	return d.data, d.err
+}
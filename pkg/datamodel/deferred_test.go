@@ -0,0 +1,34 @@
+package datamodel
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDeferredResponse(t *testing.T) {
+	d := NewDeferredResponse()
+	var err error = d
+	var target *DeferredResponse
+	if !errors.As(err, &target) || target != d {
+		t.Fatal("a returned DeferredResponse is not found with errors.As")
+	}
+
+	select {
+	case <-d.Done():
+		t.Fatal("Done closed before Complete")
+	default:
+	}
+
+	if !d.Complete([]byte{0x15, 0x18}, nil) {
+		t.Error("first Complete had no effect")
+	}
+	if d.Complete(nil, ErrBusy) {
+		t.Error("second Complete took effect")
+	}
+
+	<-d.Done()
+	data, err := d.Result()
+	if err != nil || len(data) != 2 {
+		t.Errorf("Result() = %x, %v; want the first response", data, err)
+	}
+}
@@ -58,11 +58,15 @@ func (e *BasicEndpoint) SetCompositionPattern(pattern EndpointComposition) {
 }
 
 // AddCluster registers a cluster with the endpoint.
-// Returns ErrClusterExists if a cluster with the same ID already exists.
+// Returns ErrClusterExists if a cluster with the same ID already exists, or
+// the error of ValidateCluster if the cluster has an invalid ID.
 func (e *BasicEndpoint) AddCluster(c Cluster) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
+	if err := ValidateCluster(c); err != nil {
+		return err
+	}
 	id := c.ID()
 	if _, exists := e.clusters[id]; exists {
 		return ErrClusterExists
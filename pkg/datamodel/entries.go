@@ -17,6 +17,10 @@ type AttributeEntry struct {
 	// WritePrivilege is the minimum privilege required to write this attribute.
 	// nil indicates the attribute is not writable.
 	WritePrivilege *Privilege
+
+	// Features makes the attribute conditional: it exists only if the
+	// cluster's FeatureMap has any of these bits. Zero means always.
+	Features uint32
 }
 
 // IsReadable returns true if the attribute can be read.
@@ -71,6 +75,10 @@ type CommandEntry struct {
 
 	// InvokePrivilege is the minimum privilege required to invoke this command.
 	InvokePrivilege Privilege
+
+	// Features makes the command conditional: it exists only if the
+	// cluster's FeatureMap has any of these bits. Zero means always.
+	Features uint32
 }
 
 // HasQuality returns true if the command has the specified quality flag(s).
@@ -16,6 +16,23 @@ var (
 	// ErrClusterExists indicates a cluster with the same ID already exists.
 	ErrClusterExists = errors.New("cluster already exists")
 
+	// ErrInvalidClusterID indicates a cluster ID outside the standard and
+	// manufacturer-specific ranges.
+	ErrInvalidClusterID = errors.New("invalid cluster ID")
+
+	// ErrInvalidAttributeID indicates an attribute ID outside the valid ranges.
+	ErrInvalidAttributeID = errors.New("invalid attribute ID")
+
+	// ErrInvalidCommandID indicates a command ID outside the valid ranges.
+	ErrInvalidCommandID = errors.New("invalid command ID")
+
+	// ErrInvalidEventID indicates an event ID outside the valid ranges.
+	ErrInvalidEventID = errors.New("invalid event ID")
+
+	// ErrNonConformant indicates an endpoint composition that does not meet
+	// the requirements of its device types.
+	ErrNonConformant = errors.New("non-conformant endpoint composition")
+
 	// ErrAttributeNotFound indicates the requested attribute does not exist.
 	ErrAttributeNotFound = errors.New("attribute not found")
 
@@ -55,6 +72,15 @@ var (
 	// ErrBusy indicates the resource is busy with another operation.
 	ErrBusy = errors.New("resource busy")
 
+	// ErrFailsafeRequired indicates the operation requires an armed fail-safe.
+	ErrFailsafeRequired = errors.New("failsafe required")
+
+	// ErrNotFound indicates the referenced item does not exist.
+	ErrNotFound = errors.New("not found")
+
+	// ErrAlreadyExists indicates the item to be added already exists.
+	ErrAlreadyExists = errors.New("already exists")
+
 	// ErrConstraintError indicates a constraint violation.
 	ErrConstraintError = errors.New("constraint error")
 
@@ -0,0 +1,58 @@
+package datamodel
+
+// NewClusterBaseWithFeatures creates a cluster base whose FeatureMap is
+// the union of the given feature bits.
+func NewClusterBaseWithFeatures(id ClusterID, endpointID EndpointID, revision uint16, features ...uint32) *ClusterBase {
+	c := NewClusterBase(id, endpointID, revision)
+	for _, f := range features {
+		c.featureMap |= f
+	}
+	return c
+}
+
+// HasFeature returns true if the FeatureMap has all bits of feature.
+func (c *ClusterBase) HasFeature(feature uint32) bool {
+	return c.featureMap&feature == feature
+}
+
+// hasAnyFeature returns true if an element conditional on features exists.
+func (c *ClusterBase) hasAnyFeature(features uint32) bool {
+	return features == 0 || c.featureMap&features != 0
+}
+
+// BuildAttributeList returns the AttributeList of a cluster with the given
+// attributes: those whose features the cluster has, followed by the global
+// attributes. Build it after the FeatureMap is set.
+func (c *ClusterBase) BuildAttributeList(attrs []AttributeEntry) []AttributeEntry {
+	supported := make([]AttributeEntry, 0, len(attrs))
+	for _, a := range attrs {
+		if c.hasAnyFeature(a.Features) {
+			supported = append(supported, a)
+		}
+	}
+	return MergeAttributeLists(supported)
+}
+
+// BuildAcceptedCommandList returns the commands whose features the
+// cluster has.
+func (c *ClusterBase) BuildAcceptedCommandList(cmds []CommandEntry) []CommandEntry {
+	supported := make([]CommandEntry, 0, len(cmds))
+	for _, cmd := range cmds {
+		if c.hasAnyFeature(cmd.Features) {
+			supported = append(supported, cmd)
+		}
+	}
+	return supported
+}
+
+// WithFeatures returns the entry made conditional on any of features.
+func (a AttributeEntry) WithFeatures(features uint32) AttributeEntry {
+	a.Features = features
+	return a
+}
+
+// WithFeatures returns the entry made conditional on any of features.
+func (c CommandEntry) WithFeatures(features uint32) CommandEntry {
+	c.Features = features
+	return c
+}
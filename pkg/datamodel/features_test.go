@@ -0,0 +1,40 @@
+package datamodel
+
+import "testing"
+
+func TestClusterBase_Features(t *testing.T) {
+	const (
+		featureA uint32 = 1 << 0
+		featureB uint32 = 1 << 1
+		featureC uint32 = 1 << 2
+	)
+	c := NewClusterBaseWithFeatures(ClusterOnOff, 1, 6, featureA, featureC)
+
+	if c.FeatureMap() != featureA|featureC {
+		t.Errorf("FeatureMap() = %#x, want %#x", c.FeatureMap(), featureA|featureC)
+	}
+	if !c.HasFeature(featureA) || c.HasFeature(featureB) || c.HasFeature(featureA|featureB) {
+		t.Error("HasFeature does not match the declared features")
+	}
+
+	priv := PrivilegeView
+	attrs := c.BuildAttributeList([]AttributeEntry{
+		NewReadOnlyAttribute(0x0000, 0, priv),
+		NewReadOnlyAttribute(0x0001, 0, priv).WithFeatures(featureA),
+		NewReadOnlyAttribute(0x0002, 0, priv).WithFeatures(featureB),
+		NewReadOnlyAttribute(0x0003, 0, priv).WithFeatures(featureB | featureC),
+	})
+	for id, want := range map[AttributeID]bool{0x0000: true, 0x0001: true, 0x0002: false, 0x0003: true, GlobalAttrFeatureMap: true} {
+		if got := FindAttribute(attrs, id) != nil; got != want {
+			t.Errorf("attribute %#x listed = %v, want %v", id, got, want)
+		}
+	}
+
+	cmds := c.BuildAcceptedCommandList([]CommandEntry{
+		NewCommandEntry(0x00, 0, PrivilegeOperate),
+		NewCommandEntry(0x40, 0, PrivilegeOperate).WithFeatures(featureB),
+	})
+	if len(cmds) != 1 || cmds[0].ID != 0x00 {
+		t.Errorf("BuildAcceptedCommandList() = %+v, want command 0x00 only", cmds)
+	}
+}
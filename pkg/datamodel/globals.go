@@ -154,8 +154,23 @@ const (
 	// ClusterLevelControl is the Level Control cluster ID.
 	ClusterLevelControl ClusterID = 0x0008
 
+	// ClusterPowerSource is the Power Source cluster ID.
+	ClusterPowerSource ClusterID = 0x002F
+
+	// ClusterBooleanState is the Boolean State cluster ID.
+	ClusterBooleanState ClusterID = 0x0045
+
+	// ClusterScenesManagement is the Scenes Management cluster ID.
+	ClusterScenesManagement ClusterID = 0x0062
+
 	// ClusterColorControl is the Color Control cluster ID.
 	ClusterColorControl ClusterID = 0x0300
+
+	// ClusterTemperatureMeasurement is the Temperature Measurement cluster ID.
+	ClusterTemperatureMeasurement ClusterID = 0x0402
+
+	// ClusterOccupancySensing is the Occupancy Sensing cluster ID.
+	ClusterOccupancySensing ClusterID = 0x0406
 )
 
 // Well-known device type IDs
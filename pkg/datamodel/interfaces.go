@@ -3,6 +3,7 @@ package datamodel
 import (
 	"context"
 
+	"github.com/backkem/matter/pkg/fabric"
 	"github.com/backkem/matter/pkg/tlv"
 )
 
@@ -125,6 +126,14 @@ type ClusterWithListNotification interface {
 	ListAttributeWriteNotification(path ConcreteAttributePath, op ListWriteOperation, fabricIndex uint8)
 }
 
+// FabricRemovalListener is an optional interface for clusters and other
+// owners of fabric-scoped data, e.g. bindings, scenes or application
+// stores, that must drop the data of a fabric the node leaves.
+type FabricRemovalListener interface {
+	// FabricRemoved is called after the fabric is removed from the node.
+	FabricRemoved(fabricIndex fabric.FabricIndex)
+}
+
 // AttributeChangeListener is notified when attribute values change.
 // Used for subscription reporting.
 type AttributeChangeListener interface {
@@ -132,6 +141,22 @@ type AttributeChangeListener interface {
 	OnAttributeChanged(path ConcreteAttributePath)
 }
 
+// AttributeChangeListenerFunc adapts a function to AttributeChangeListener.
+type AttributeChangeListenerFunc func(path ConcreteAttributePath)
+
+// OnAttributeChanged calls f(path).
+func (f AttributeChangeListenerFunc) OnAttributeChanged(path ConcreteAttributePath) {
+	f(path)
+}
+
+// AttributeChangeSource is implemented by clusters that report their
+// attribute changes. ClusterBase implements it.
+type AttributeChangeSource interface {
+	// SetAttributeChangeListener sets the listener for the cluster's
+	// attribute changes; nil clears it.
+	SetAttributeChangeListener(listener AttributeChangeListener)
+}
+
 // DataModelProvider combines the Node interface with change notification.
 // This is the main interface used by the Interaction Model engine.
 type DataModelProvider interface {
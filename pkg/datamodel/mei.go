@@ -0,0 +1,152 @@
+package datamodel
+
+// Cluster, attribute, command and event IDs are Manufacturer Extensible
+// Identifiers (MEIs): the upper 16 bits are a vendor prefix, the lower 16
+// bits a suffix. Standard IDs have vendor prefix 0; manufacturer-specific
+// IDs carry the vendor ID of the manufacturer that defined them.
+//
+// The valid suffix range depends on the kind of ID:
+//
+//	Cluster    0x0000-0x7FFF standard (prefix 0 only)
+//	           0xFC00-0xFFFE manufacturer-specific (non-zero prefix only)
+//	Attribute  0x0000-0x4FFF non-global, 0xF000-0xFFFE global
+//	Command    0x00-0xDF non-global, 0xE0-0xFF global
+//	Event      0x00-0xFF
+const (
+	// VendorPrefixStandard is the vendor prefix of standard IDs.
+	VendorPrefixStandard uint16 = 0x0000
+
+	// vendorPrefixReserved is not a valid vendor prefix.
+	vendorPrefixReserved uint16 = 0xFFFF
+
+	// ManufacturerClusterSuffixMin is the lowest suffix of a
+	// manufacturer-specific cluster ID.
+	ManufacturerClusterSuffixMin uint16 = 0xFC00
+
+	// ManufacturerClusterSuffixMax is the highest suffix of a
+	// manufacturer-specific cluster ID.
+	ManufacturerClusterSuffixMax uint16 = 0xFFFE
+
+	standardClusterSuffixMax uint16 = 0x7FFF
+	attributeSuffixMax       uint16 = 0x4FFF
+	globalAttributeSuffixMin uint16 = 0xF000
+	globalAttributeSuffixMax uint16 = 0xFFFE
+	commandSuffixMax         uint16 = 0x00FF
+	eventSuffixMax           uint16 = 0x00FF
+)
+
+// VendorPrefix returns the vendor prefix (upper 16 bits) of an MEI.
+func VendorPrefix(id uint32) uint16 {
+	return uint16(id >> 16)
+}
+
+// IDSuffix returns the suffix (lower 16 bits) of an MEI.
+func IDSuffix(id uint32) uint16 {
+	return uint16(id)
+}
+
+// MakeMEI combines a vendor prefix and a suffix into an MEI.
+func MakeMEI(vendorPrefix, suffix uint16) uint32 {
+	return uint32(vendorPrefix)<<16 | uint32(suffix)
+}
+
+// ManufacturerClusterID returns the ID of a manufacturer-specific cluster,
+// e.g. ManufacturerClusterID(0xFFF1, 0xFC00) for 0xFFF1FC00. The result
+// is only valid for suffixes 0xFC00-0xFFFE; see IsValidClusterID.
+func ManufacturerClusterID(vendorID, suffix uint16) ClusterID {
+	return ClusterID(MakeMEI(vendorID, suffix))
+}
+
+// ManufacturerAttributeID returns the ID of a manufacturer-specific
+// attribute.
+func ManufacturerAttributeID(vendorID, suffix uint16) AttributeID {
+	return AttributeID(MakeMEI(vendorID, suffix))
+}
+
+// ManufacturerCommandID returns the ID of a manufacturer-specific command.
+func ManufacturerCommandID(vendorID, suffix uint16) CommandID {
+	return CommandID(MakeMEI(vendorID, suffix))
+}
+
+// ManufacturerEventID returns the ID of a manufacturer-specific event.
+func ManufacturerEventID(vendorID, suffix uint16) EventID {
+	return EventID(MakeMEI(vendorID, suffix))
+}
+
+// IsManufacturerSpecific returns true if an MEI has a vendor prefix.
+func IsManufacturerSpecific(id uint32) bool {
+	return VendorPrefix(id) != VendorPrefixStandard
+}
+
+// IsValidClusterID returns true if id is a standard cluster ID or a
+// manufacturer-specific cluster ID in the 0xFC00-0xFFFE suffix range.
+func IsValidClusterID(id ClusterID) bool {
+	prefix, suffix := VendorPrefix(uint32(id)), IDSuffix(uint32(id))
+	switch prefix {
+	case VendorPrefixStandard:
+		return suffix <= standardClusterSuffixMax
+	case vendorPrefixReserved:
+		return false
+	default:
+		return suffix >= ManufacturerClusterSuffixMin && suffix <= ManufacturerClusterSuffixMax
+	}
+}
+
+// IsValidAttributeID returns true if id has a valid vendor prefix and a
+// suffix in the non-global or global attribute range.
+func IsValidAttributeID(id AttributeID) bool {
+	if VendorPrefix(uint32(id)) == vendorPrefixReserved {
+		return false
+	}
+	suffix := IDSuffix(uint32(id))
+	return suffix <= attributeSuffixMax ||
+		(suffix >= globalAttributeSuffixMin && suffix <= globalAttributeSuffixMax)
+}
+
+// IsValidCommandID returns true if id has a valid vendor prefix and a
+// suffix in the command range.
+func IsValidCommandID(id CommandID) bool {
+	return VendorPrefix(uint32(id)) != vendorPrefixReserved && IDSuffix(uint32(id)) <= commandSuffixMax
+}
+
+// IsValidEventID returns true if id has a valid vendor prefix and a
+// suffix in the event range.
+func IsValidEventID(id EventID) bool {
+	return VendorPrefix(uint32(id)) != vendorPrefixReserved && IDSuffix(uint32(id)) <= eventSuffixMax
+}
+
+// ValidateCluster checks the cluster ID and the IDs of the attributes,
+// commands and events of a cluster. It returns ErrInvalidClusterID,
+// ErrInvalidAttributeID, ErrInvalidCommandID or ErrInvalidEventID for the
+// first invalid ID.
+//
+// A standard cluster may carry manufacturer-specific attributes, commands
+// and events; a manufacturer-specific cluster may carry standard ones.
+func ValidateCluster(c Cluster) error {
+	if !IsValidClusterID(c.ID()) {
+		return ErrInvalidClusterID
+	}
+	for _, attr := range c.AttributeList() {
+		if !IsValidAttributeID(attr.ID) {
+			return ErrInvalidAttributeID
+		}
+	}
+	for _, cmd := range c.AcceptedCommandList() {
+		if !IsValidCommandID(cmd.ID) {
+			return ErrInvalidCommandID
+		}
+	}
+	for _, id := range c.GeneratedCommandList() {
+		if !IsValidCommandID(id) {
+			return ErrInvalidCommandID
+		}
+	}
+	if ce, ok := c.(ClusterWithEvents); ok {
+		for _, ev := range ce.EventList() {
+			if !IsValidEventID(ev.ID) {
+				return ErrInvalidEventID
+			}
+		}
+	}
+	return nil
+}
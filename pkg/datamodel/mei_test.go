@@ -0,0 +1,144 @@
+package datamodel
+
+import "testing"
+
+func TestMEI(t *testing.T) {
+	id := ManufacturerClusterID(0xFFF1, 0xFC00)
+	if id != 0xFFF1FC00 {
+		t.Fatalf("ManufacturerClusterID() = 0x%08X, want 0xFFF1FC00", uint32(id))
+	}
+	if VendorPrefix(uint32(id)) != 0xFFF1 || IDSuffix(uint32(id)) != 0xFC00 {
+		t.Errorf("VendorPrefix, IDSuffix = 0x%04X, 0x%04X", VendorPrefix(uint32(id)), IDSuffix(uint32(id)))
+	}
+	if !IsManufacturerSpecific(uint32(id)) || IsManufacturerSpecific(uint32(ClusterOnOff)) {
+		t.Error("IsManufacturerSpecific() mismatch")
+	}
+}
+
+func TestIsValidClusterID(t *testing.T) {
+	tests := []struct {
+		id   ClusterID
+		want bool
+	}{
+		{ClusterOnOff, true},
+		{0x00007FFF, true},
+		{0x00008000, false},
+		{0x0000FC00, false}, // manufacturer range needs a vendor prefix
+		{0xFFF1FC00, true},
+		{0xFFF1FFFE, true},
+		{0xFFF1FFFF, false},
+		{0xFFF10006, false}, // standard range with a vendor prefix
+		{0xFFFFFC00, false},
+	}
+	for _, tt := range tests {
+		if got := IsValidClusterID(tt.id); got != tt.want {
+			t.Errorf("IsValidClusterID(0x%08X) = %v, want %v", uint32(tt.id), got, tt.want)
+		}
+	}
+}
+
+func TestIsValidAttributeCommandEventID(t *testing.T) {
+	attrs := []struct {
+		id   AttributeID
+		want bool
+	}{
+		{0x0000, true},
+		{0x4FFF, true},
+		{0x5000, false},
+		{GlobalAttrClusterRevision, true},
+		{0xFFFF, false},
+		{0xFFF10000, true},
+		{0xFFF1F000, true},
+		{0xFFFF0000, false},
+	}
+	for _, tt := range attrs {
+		if got := IsValidAttributeID(tt.id); got != tt.want {
+			t.Errorf("IsValidAttributeID(0x%08X) = %v, want %v", uint32(tt.id), got, tt.want)
+		}
+	}
+
+	cmds := []struct {
+		id   CommandID
+		want bool
+	}{
+		{0x00, true},
+		{GlobalCmdAtomicRequest, true},
+		{0x0100, false},
+		{0xFFF10001, true},
+		{0xFFF10100, false},
+	}
+	for _, tt := range cmds {
+		if got := IsValidCommandID(tt.id); got != tt.want {
+			t.Errorf("IsValidCommandID(0x%08X) = %v, want %v", uint32(tt.id), got, tt.want)
+		}
+	}
+
+	if !IsValidEventID(ManufacturerEventID(0xFFF1, 0x01)) || IsValidEventID(0x0100) {
+		t.Error("IsValidEventID() mismatch")
+	}
+}
+
+type meiCluster struct {
+	mockCluster
+	attrs  []AttributeEntry
+	cmds   []CommandEntry
+	genCmd []CommandID
+	events []EventEntry
+}
+
+func (c *meiCluster) AttributeList() []AttributeEntry     { return MergeAttributeLists(c.attrs) }
+func (c *meiCluster) AcceptedCommandList() []CommandEntry { return c.cmds }
+func (c *meiCluster) GeneratedCommandList() []CommandID   { return c.genCmd }
+func (c *meiCluster) EventList() []EventEntry             { return c.events }
+
+func TestValidateCluster(t *testing.T) {
+	vendorCluster := ManufacturerClusterID(0xFFF1, 0xFC00)
+	tests := []struct {
+		name    string
+		cluster *meiCluster
+		want    error
+	}{
+		{"manufacturer cluster", &meiCluster{
+			mockCluster: mockCluster{id: vendorCluster},
+			attrs:       []AttributeEntry{{ID: 0x0000}, {ID: ManufacturerAttributeID(0xFFF1, 0x0001)}},
+			cmds:        []CommandEntry{{ID: 0x00}},
+			genCmd:      []CommandID{0x01},
+			events:      []EventEntry{{ID: 0x00}},
+		}, nil},
+		{"manufacturer attribute on standard cluster", &meiCluster{
+			mockCluster: mockCluster{id: ClusterOnOff},
+			attrs:       []AttributeEntry{{ID: ManufacturerAttributeID(0xFFF1, 0x0000)}},
+		}, nil},
+		{"invalid cluster", &meiCluster{mockCluster: mockCluster{id: 0xFFF10001}}, ErrInvalidClusterID},
+		{"invalid attribute", &meiCluster{
+			mockCluster: mockCluster{id: vendorCluster},
+			attrs:       []AttributeEntry{{ID: 0xFFF15000}},
+		}, ErrInvalidAttributeID},
+		{"invalid command", &meiCluster{
+			mockCluster: mockCluster{id: vendorCluster},
+			cmds:        []CommandEntry{{ID: 0xFFFF0000}},
+		}, ErrInvalidCommandID},
+		{"invalid generated command", &meiCluster{
+			mockCluster: mockCluster{id: vendorCluster},
+			genCmd:      []CommandID{0x0100},
+		}, ErrInvalidCommandID},
+		{"invalid event", &meiCluster{
+			mockCluster: mockCluster{id: vendorCluster},
+			events:      []EventEntry{{ID: 0x0100}},
+		}, ErrInvalidEventID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateCluster(tt.cluster); err != tt.want {
+				t.Errorf("ValidateCluster() = %v, want %v", err, tt.want)
+			}
+			ep := NewEndpoint(1)
+			if err := ep.AddCluster(tt.cluster); err != tt.want {
+				t.Errorf("AddCluster() = %v, want %v", err, tt.want)
+			}
+			if got := ep.HasCluster(tt.cluster.ID()); got != (tt.want == nil) {
+				t.Errorf("HasCluster() = %v", got)
+			}
+		})
+	}
+}
@@ -20,6 +20,10 @@ func NewNode() *BasicNode {
 
 // AddEndpoint registers an endpoint with the node.
 // Returns ErrEndpointExists if an endpoint with the same ID already exists.
+//
+// The endpoint's clusters that implement AttributeChangeSource report
+// their changes through NotifyAttributeChanged. Clusters added to the
+// endpoint afterwards are not bound.
 func (n *BasicNode) AddEndpoint(ep Endpoint) error {
 	n.mu.Lock()
 	defer n.mu.Unlock()
@@ -31,6 +35,7 @@ func (n *BasicNode) AddEndpoint(ep Endpoint) error {
 
 	n.endpoints[id] = ep
 	n.order = append(n.order, id)
+	bindClusters(ep, AttributeChangeListenerFunc(n.NotifyAttributeChanged))
 	return nil
 }
 
@@ -40,10 +45,12 @@ func (n *BasicNode) RemoveEndpoint(id EndpointID) error {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
-	if _, exists := n.endpoints[id]; !exists {
+	ep, exists := n.endpoints[id]
+	if !exists {
 		return ErrEndpointNotFound
 	}
 
+	bindClusters(ep, nil)
 	delete(n.endpoints, id)
 
 	// Remove from order slice
@@ -57,6 +64,15 @@ func (n *BasicNode) RemoveEndpoint(id EndpointID) error {
 	return nil
 }
 
+// bindClusters sets the change listener of an endpoint's clusters.
+func bindClusters(ep Endpoint, listener AttributeChangeListener) {
+	for _, c := range ep.GetClusters() {
+		if source, ok := c.(AttributeChangeSource); ok {
+			source.SetAttributeChangeListener(listener)
+		}
+	}
+}
+
 // GetEndpoint returns the endpoint with the given ID, or nil if not found.
 func (n *BasicNode) GetEndpoint(id EndpointID) Endpoint {
 	n.mu.RLock()
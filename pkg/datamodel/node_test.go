@@ -197,8 +197,58 @@ func TestBasicNode_Concurrent(t *testing.T) {
 	wg.Wait()
 }
 
+func TestBasicNode_BindsClusterChanges(t *testing.T) {
+	node := NewNode()
+
+	var changed []ConcreteAttributePath
+	node.SetAttributeChangeListener(&mockAttributeChangeListener{
+		onChanged: func(path ConcreteAttributePath) { changed = append(changed, path) },
+	})
+
+	cluster := &changeSourceCluster{ClusterBase: NewClusterBase(ClusterOnOff, 1, 1)}
+	ep := NewEndpoint(1)
+	ep.AddCluster(cluster)
+	if err := node.AddEndpoint(ep); err != nil {
+		t.Fatalf("AddEndpoint failed: %v", err)
+	}
+
+	cluster.MarkDirty(0x0000)
+	want := ConcreteAttributePath{Endpoint: 1, Cluster: ClusterOnOff, Attribute: 0x0000}
+	if len(changed) != 1 || changed[0] != want {
+		t.Fatalf("changed = %v, want [%v]", changed, want)
+	}
+
+	// A removed endpoint's clusters no longer report
+	node.RemoveEndpoint(1)
+	cluster.MarkDirty(0x0000)
+	if len(changed) != 1 {
+		t.Errorf("removed endpoint's cluster reported a change")
+	}
+}
+
 // Mock types for testing
 
+// changeSourceCluster is a cluster that reports changes via ClusterBase.
+type changeSourceCluster struct {
+	*ClusterBase
+}
+
+func (c *changeSourceCluster) AttributeList() []AttributeEntry     { return nil }
+func (c *changeSourceCluster) AcceptedCommandList() []CommandEntry { return nil }
+func (c *changeSourceCluster) GeneratedCommandList() []CommandID   { return nil }
+
+func (c *changeSourceCluster) ReadAttribute(_ context.Context, _ ReadAttributeRequest, _ *tlv.Writer) error {
+	return nil
+}
+
+func (c *changeSourceCluster) WriteAttribute(_ context.Context, _ WriteAttributeRequest, _ *tlv.Reader) error {
+	return nil
+}
+
+func (c *changeSourceCluster) InvokeCommand(_ context.Context, _ InvokeRequest, _ *tlv.Reader) ([]byte, error) {
+	return nil, nil
+}
+
 type mockCluster struct {
 	id         ClusterID
 	endpointID EndpointID
@@ -0,0 +1,276 @@
+package datamodel
+
+import (
+	"errors"
+	"math"
+	"reflect"
+
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// ErrUnsupportedValueType is returned when a Nullable, Optional or
+// PersistedAttribute holds a type it cannot encode.
+var ErrUnsupportedValueType = errors.New("unsupported attribute value type")
+
+// Nullable is a value of a nullable attribute or field (the X quality),
+// encoded as a TLV null when it has no value. Use it instead of a zero
+// value or an omitted element to mean null.
+//
+// T is a boolean, integer, string or []byte type. The zero Nullable is
+// null.
+type Nullable[T any] struct {
+	value T
+	valid bool
+}
+
+// Null returns a null value.
+func Null[T any]() Nullable[T] {
+	return Nullable[T]{}
+}
+
+// NonNull returns a non-null value.
+func NonNull[T any](v T) Nullable[T] {
+	return Nullable[T]{value: v, valid: true}
+}
+
+// NullableFromPtr returns the value p points to, or null if p is nil.
+func NullableFromPtr[T any](p *T) Nullable[T] {
+	if p == nil {
+		return Null[T]()
+	}
+	return NonNull(*p)
+}
+
+// IsNull reports whether the value is null.
+func (n Nullable[T]) IsNull() bool {
+	return !n.valid
+}
+
+// Get returns the value and whether it is non-null.
+func (n Nullable[T]) Get() (T, bool) {
+	return n.value, n.valid
+}
+
+// Ptr returns a pointer to a copy of the value, or nil if null.
+func (n Nullable[T]) Ptr() *T {
+	if !n.valid {
+		return nil
+	}
+	v := n.value
+	return &v
+}
+
+// Validate checks that a non-null integer is not the value a nullable
+// integer type reserves for null: the maximum of an unsigned type, the
+// minimum of a signed one. Returns ErrConstraintError if it is.
+func (n Nullable[T]) Validate() error {
+	if !n.valid {
+		return nil
+	}
+	v := reflect.ValueOf(n.value)
+	switch v.Kind() {
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		if v.Uint() == math.MaxUint64>>(64-v.Type().Bits()) {
+			return ErrConstraintError
+		}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+		if v.Int() == math.MinInt64>>(64-v.Type().Bits()) {
+			return ErrConstraintError
+		}
+	}
+	return nil
+}
+
+// MarshalTLV writes the value, or null, with an anonymous tag.
+func (n Nullable[T]) MarshalTLV(w *tlv.Writer) error {
+	return n.Marshal(w, tlv.Anonymous())
+}
+
+// Marshal writes the value, or null, with the given tag.
+func (n Nullable[T]) Marshal(w *tlv.Writer, tag tlv.Tag) error {
+	if !n.valid {
+		return w.PutNull(tag)
+	}
+	return encodeValue(w, tag, reflect.ValueOf(n.value))
+}
+
+// UnmarshalTLV reads the element the reader is positioned on.
+func (n *Nullable[T]) UnmarshalTLV(r *tlv.Reader) error {
+	if r.Type() == tlv.ElementTypeNull {
+		*n = Null[T]()
+		return nil
+	}
+	var v T
+	if err := decodeValue(r, reflect.ValueOf(&v).Elem()); err != nil {
+		return err
+	}
+	*n = NonNull(v)
+	return nil
+}
+
+// DecodeNullable reads the next element as the new value of an attribute
+// with the given qualities, e.g. in WriteAttribute. It returns
+// ErrConstraintError for a null written to an attribute without
+// AttrQualityNullable, or for a value reserved for null.
+func DecodeNullable[T any](r *tlv.Reader, quality AttributeQuality) (Nullable[T], error) {
+	var n Nullable[T]
+	if err := r.Next(); err != nil {
+		return n, err
+	}
+	if err := n.UnmarshalTLV(r); err != nil {
+		return n, err
+	}
+	if n.IsNull() && quality&AttrQualityNullable == 0 {
+		return n, ErrConstraintError
+	}
+	return n, n.Validate()
+}
+
+// Optional is a value of an optional field (the O conformance of a struct
+// field or command argument), omitted from the TLV encoding when absent.
+// An optional nullable field is an Optional[Nullable[T]].
+//
+// T is a boolean, integer, string or []byte type, or a Nullable. The zero
+// Optional is absent.
+type Optional[T any] struct {
+	value   T
+	present bool
+}
+
+// None returns an absent value.
+func None[T any]() Optional[T] {
+	return Optional[T]{}
+}
+
+// Some returns a present value.
+func Some[T any](v T) Optional[T] {
+	return Optional[T]{value: v, present: true}
+}
+
+// IsPresent reports whether the value is present.
+func (o Optional[T]) IsPresent() bool {
+	return o.present
+}
+
+// Get returns the value and whether it is present.
+func (o Optional[T]) Get() (T, bool) {
+	return o.value, o.present
+}
+
+// Marshal writes the value with the given tag, or nothing if absent.
+func (o Optional[T]) Marshal(w *tlv.Writer, tag tlv.Tag) error {
+	if !o.present {
+		return nil
+	}
+	return encodeValue(w, tag, reflect.ValueOf(o.value))
+}
+
+// UnmarshalTLV reads the element the reader is positioned on; a decoded
+// field is present.
+func (o *Optional[T]) UnmarshalTLV(r *tlv.Reader) error {
+	var v T
+	if err := decodeValue(r, reflect.ValueOf(&v).Elem()); err != nil {
+		return err
+	}
+	*o = Some(v)
+	return nil
+}
+
+// taggedMarshaler is implemented by Nullable.
+type taggedMarshaler interface {
+	Marshal(w *tlv.Writer, tag tlv.Tag) error
+}
+
+// elementUnmarshaler is implemented by *Nullable.
+type elementUnmarshaler interface {
+	UnmarshalTLV(r *tlv.Reader) error
+}
+
+// encodeValue writes v as a TLV element. A nil pointer is written as null.
+func encodeValue(w *tlv.Writer, tag tlv.Tag, v reflect.Value) error {
+	if m, ok := v.Interface().(taggedMarshaler); ok {
+		return m.Marshal(w, tag)
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return w.PutNull(tag)
+		}
+		return encodeValue(w, tag, v.Elem())
+	case reflect.Bool:
+		return w.PutBool(tag, v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return w.PutInt(tag, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return w.PutUint(tag, v.Uint())
+	case reflect.String:
+		return w.PutString(tag, v.String())
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return w.PutBytes(tag, v.Bytes())
+		}
+	}
+	return ErrUnsupportedValueType
+}
+
+// decodeValue reads the element the reader is positioned on into v, which
+// must be settable. A null sets a pointer to nil.
+func decodeValue(r *tlv.Reader, v reflect.Value) error {
+	if u, ok := v.Addr().Interface().(elementUnmarshaler); ok {
+		return u.UnmarshalTLV(r)
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		if r.Type() == tlv.ElementTypeNull {
+			v.SetZero()
+			return nil
+		}
+		elem := reflect.New(v.Type().Elem())
+		if err := decodeValue(r, elem.Elem()); err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.Bool:
+		b, err := r.Bool()
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := r.Int()
+		if err != nil {
+			return err
+		}
+		if v.OverflowInt(n) {
+			return ErrConstraintError
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := r.Uint()
+		if err != nil {
+			return err
+		}
+		if v.OverflowUint(n) {
+			return ErrConstraintError
+		}
+		v.SetUint(n)
+	case reflect.String:
+		s, err := r.String()
+		if err != nil {
+			return err
+		}
+		v.SetString(s)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return ErrUnsupportedValueType
+		}
+		b, err := r.Bytes()
+		if err != nil {
+			return err
+		}
+		v.SetBytes(b)
+	default:
+		return ErrUnsupportedValueType
+	}
+	return nil
+}
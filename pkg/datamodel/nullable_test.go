@@ -0,0 +1,142 @@
+package datamodel
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+func TestNullable_Encoding(t *testing.T) {
+	tests := []struct {
+		name string
+		n    Nullable[uint8]
+		want []byte
+	}{
+		{"null", Null[uint8](), []byte{0x14}},
+		{"zero is not null", NonNull[uint8](0), []byte{0x04, 0x00}},
+		{"value", NonNull[uint8](7), []byte{0x04, 0x07}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.n.MarshalTLV(tlv.NewWriter(&buf)); err != nil {
+				t.Fatalf("MarshalTLV failed: %v", err)
+			}
+			if !bytes.Equal(buf.Bytes(), tt.want) {
+				t.Errorf("MarshalTLV = %x, want %x", buf.Bytes(), tt.want)
+			}
+
+			r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+			_ = r.Next()
+			var got Nullable[uint8]
+			if err := got.UnmarshalTLV(r); err != nil {
+				t.Fatalf("UnmarshalTLV failed: %v", err)
+			}
+			if got != tt.n {
+				t.Errorf("round trip = %+v, want %+v", got, tt.n)
+			}
+		})
+	}
+
+	if p := NullableFromPtr[int16](nil); !p.IsNull() || p.Ptr() != nil {
+		t.Errorf("NullableFromPtr(nil) = %+v, want null", p)
+	}
+	v := int16(-5)
+	if p := NullableFromPtr(&v); p.IsNull() || *p.Ptr() != -5 {
+		t.Errorf("NullableFromPtr(-5) = %+v, want -5", p)
+	}
+}
+
+func TestNullable_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		ok   bool
+	}{
+		{"uint8 max", NonNull[uint8](0xFF).Validate(), false},
+		{"uint8 below max", NonNull[uint8](0xFE).Validate(), true},
+		{"uint16 max", NonNull[uint16](0xFFFF).Validate(), false},
+		{"int16 min", NonNull[int16](-0x8000).Validate(), false},
+		{"int16 above min", NonNull[int16](-0x7FFF).Validate(), true},
+		{"uint64 max", NonNull[uint64](1<<64 - 1).Validate(), false},
+		{"null", Null[uint8]().Validate(), true},
+		{"string", NonNull("x").Validate(), true},
+	}
+	for _, tt := range tests {
+		if tt.ok && tt.err != nil {
+			t.Errorf("%s: Validate() = %v, want nil", tt.name, tt.err)
+		}
+		if !tt.ok && !errors.Is(tt.err, ErrConstraintError) {
+			t.Errorf("%s: Validate() = %v, want ErrConstraintError", tt.name, tt.err)
+		}
+	}
+}
+
+func TestDecodeNullable(t *testing.T) {
+	encode := func(put func(w *tlv.Writer)) *tlv.Reader {
+		var buf bytes.Buffer
+		put(tlv.NewWriter(&buf))
+		return tlv.NewReader(bytes.NewReader(buf.Bytes()))
+	}
+	null := func(w *tlv.Writer) { _ = w.PutNull(tlv.Anonymous()) }
+
+	if n, err := DecodeNullable[uint8](encode(null), AttrQualityNullable); err != nil || !n.IsNull() {
+		t.Errorf("DecodeNullable(null) = %+v, %v, want null", n, err)
+	}
+	if _, err := DecodeNullable[uint8](encode(null), 0); !errors.Is(err, ErrConstraintError) {
+		t.Errorf("null for a non-nullable attribute = %v, want ErrConstraintError", err)
+	}
+	reserved := func(w *tlv.Writer) { _ = w.PutUint(tlv.Anonymous(), 0xFF) }
+	if _, err := DecodeNullable[uint8](encode(reserved), AttrQualityNullable); !errors.Is(err, ErrConstraintError) {
+		t.Errorf("DecodeNullable(0xFF) = %v, want ErrConstraintError", err)
+	}
+	tooBig := func(w *tlv.Writer) { _ = w.PutUint(tlv.Anonymous(), 0x100) }
+	if _, err := DecodeNullable[uint8](encode(tooBig), AttrQualityNullable); !errors.Is(err, ErrConstraintError) {
+		t.Errorf("DecodeNullable(0x100) = %v, want ErrConstraintError", err)
+	}
+}
+
+func TestOptional(t *testing.T) {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	_ = w.StartStructure(tlv.Anonymous())
+	_ = None[uint16]().Marshal(w, tlv.ContextTag(0))
+	_ = Some[uint16](300).Marshal(w, tlv.ContextTag(1))
+	_ = Some(Null[uint8]()).Marshal(w, tlv.ContextTag(2))
+	_ = w.EndContainer()
+
+	// The absent field is omitted; the optional null is present
+	want := []byte{0x15, 0x25, 0x01, 0x2c, 0x01, 0x34, 0x02, 0x18}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Fatalf("encoding = %x, want %x", buf.Bytes(), want)
+	}
+
+	r := tlv.NewReader(bytes.NewReader(buf.Bytes()))
+	_ = r.Next()
+	_ = r.EnterContainer()
+	var count Optional[uint16]
+	var level Optional[Nullable[uint8]]
+	for r.Next() == nil && !r.IsEndOfContainer() {
+		switch r.Tag().TagNumber() {
+		case 1:
+			if err := count.UnmarshalTLV(r); err != nil {
+				t.Fatalf("UnmarshalTLV failed: %v", err)
+			}
+		case 2:
+			if err := level.UnmarshalTLV(r); err != nil {
+				t.Fatalf("UnmarshalTLV failed: %v", err)
+			}
+		}
+	}
+	if v, ok := count.Get(); !ok || v != 300 {
+		t.Errorf("count = %v, %v, want 300", v, ok)
+	}
+	if v, ok := level.Get(); !ok || !v.IsNull() {
+		t.Errorf("level = %+v, %v, want a present null", v, ok)
+	}
+	if None[int8]().IsPresent() {
+		t.Error("None is present")
+	}
+}
@@ -167,6 +167,23 @@ type InvokeRequest struct {
 	// Subject contains authentication info for the request source.
 	// nil for internal operations.
 	Subject *SubjectDescriptor
+
+	// Session is the secure session the request arrived on.
+	// nil for internal operations.
+	Session SecureSession
+}
+
+// SecureSession is the secure session a command arrived on, for the
+// commands that act on the session itself, such as the CSRRequest that
+// signs its attestation challenge or the AddNOC that binds a PASE
+// session to the new fabric.
+type SecureSession interface {
+	// AttestationChallenge returns the challenge derived with the
+	// session keys.
+	AttestationChallenge() []byte
+
+	// SetFabricIndex binds the session to a fabric.
+	SetFabricIndex(index fabric.FabricIndex)
 }
 
 // FabricIndex returns the accessing fabric index, or 0 if none.
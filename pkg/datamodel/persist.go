@@ -0,0 +1,166 @@
+package datamodel
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// DefaultPersistDelay is how long a deferred PersistedAttribute waits
+// before storing a changed value.
+const DefaultPersistDelay = time.Second
+
+// AttributeStorage is the key/value store persisted attributes are kept in.
+type AttributeStorage interface {
+	// Load retrieves a value by key.
+	Load(key string) ([]byte, error)
+	// Store persists a value.
+	Store(key string, value []byte) error
+}
+
+// PersistMode selects when a PersistedAttribute writes its value.
+type PersistMode uint8
+
+const (
+	// PersistWriteThrough stores the value on every Set.
+	PersistWriteThrough PersistMode = iota
+
+	// PersistDeferred stores the value a delay after it changes, so that
+	// attributes that change quickly (levels, colors, counters) write
+	// once per burst. Flush stores a pending value at once.
+	PersistDeferred
+)
+
+// AttributeKey returns the storage key of an attribute.
+func AttributeKey(path ConcreteAttributePath) string {
+	return fmt.Sprintf("attr/%d/%04x/%04x", path.Endpoint, path.Cluster, path.Attribute)
+}
+
+// PersistedAttributeConfig configures a PersistedAttribute.
+type PersistedAttributeConfig[T any] struct {
+	// Storage holds the value. If nil, the value lives in memory only.
+	Storage AttributeStorage
+
+	// Path is the attribute; its storage key is AttributeKey(Path).
+	Path ConcreteAttributePath
+
+	// Default is the value until one is stored.
+	Default T
+
+	// Mode selects when the value is written.
+	Mode PersistMode
+
+	// Delay is how long a deferred attribute waits before storing.
+	// Defaults to DefaultPersistDelay if zero.
+	Delay time.Duration
+}
+
+// PersistedAttribute holds the value of an attribute that survives
+// restarts. It loads the stored value when created and writes changes
+// back to storage, so clusters need not encode and store each attribute
+// themselves.
+//
+// T is a boolean, integer, string or []byte type, or a Nullable or pointer
+// of one for nullable attributes. Values are stored as anonymous TLV
+// elements.
+//
+// PersistedAttribute is safe for concurrent use.
+type PersistedAttribute[T any] struct {
+	storage AttributeStorage
+	key     string
+	mode    PersistMode
+	delay   time.Duration
+
+	mu    sync.RWMutex
+	value T
+	dirty bool
+	timer *time.Timer
+}
+
+// NewPersistedAttribute creates a persisted attribute, loading its stored
+// value. A missing or undecodable value leaves the default.
+func NewPersistedAttribute[T any](cfg PersistedAttributeConfig[T]) *PersistedAttribute[T] {
+	a := &PersistedAttribute[T]{
+		storage: cfg.Storage,
+		key:     AttributeKey(cfg.Path),
+		mode:    cfg.Mode,
+		delay:   cfg.Delay,
+		value:   cfg.Default,
+	}
+	if a.delay == 0 {
+		a.delay = DefaultPersistDelay
+	}
+	if a.storage != nil {
+		if data, err := a.storage.Load(a.key); err == nil {
+			var v T
+			r := tlv.NewReader(bytes.NewReader(data))
+			if r.Next() == nil && decodeValue(r, reflect.ValueOf(&v).Elem()) == nil {
+				a.value = v
+			}
+		}
+	}
+	return a
+}
+
+// Key returns the attribute's storage key.
+func (a *PersistedAttribute[T]) Key() string {
+	return a.key
+}
+
+// Get returns the current value.
+func (a *PersistedAttribute[T]) Get() T {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.value
+}
+
+// Set changes the value. A write-through attribute stores it before
+// returning, and returns the storage error; a deferred one schedules the
+// write.
+func (a *PersistedAttribute[T]) Set(v T) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.value = v
+	if a.storage == nil {
+		return nil
+	}
+	if a.mode == PersistDeferred {
+		a.dirty = true
+		if a.timer == nil {
+			a.timer = time.AfterFunc(a.delay, func() { _ = a.Flush() })
+		}
+		return nil
+	}
+	return a.storeLocked()
+}
+
+// Flush stores a value a deferred attribute has not written yet.
+func (a *PersistedAttribute[T]) Flush() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.timer != nil {
+		a.timer.Stop()
+		a.timer = nil
+	}
+	if !a.dirty || a.storage == nil {
+		return nil
+	}
+	return a.storeLocked()
+}
+
+// storeLocked writes the value. Caller must hold a.mu.
+func (a *PersistedAttribute[T]) storeLocked() error {
+	var buf bytes.Buffer
+	if err := encodeValue(tlv.NewWriter(&buf), tlv.Anonymous(), reflect.ValueOf(&a.value).Elem()); err != nil {
+		return err
+	}
+	if err := a.storage.Store(a.key, buf.Bytes()); err != nil {
+		return err
+	}
+	a.dirty = false
+	return nil
+}
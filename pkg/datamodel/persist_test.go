@@ -0,0 +1,160 @@
+package datamodel
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memStorage is an AttributeStorage in memory.
+type memStorage struct {
+	mu     sync.Mutex
+	data   map[string][]byte
+	stores int
+}
+
+func newMemStorage() *memStorage {
+	return &memStorage{data: make(map[string][]byte)}
+}
+
+func (s *memStorage) Load(key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v, ok := s.data[key]; ok {
+		return v, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func (s *memStorage) Store(key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = value
+	s.stores++
+	return nil
+}
+
+func (s *memStorage) storeCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stores
+}
+
+func TestAttributeKey(t *testing.T) {
+	path := ConcreteAttributePath{Endpoint: 1, Cluster: 0x0006, Attribute: 0x4003}
+	if got, want := AttributeKey(path), "attr/1/0006/4003"; got != want {
+		t.Errorf("AttributeKey() = %q, want %q", got, want)
+	}
+}
+
+func TestPersistedAttribute_WriteThrough(t *testing.T) {
+	storage := newMemStorage()
+	cfg := PersistedAttributeConfig[uint32]{
+		Storage: storage,
+		Path:    ConcreteAttributePath{Endpoint: 0, Cluster: 0x0028, Attribute: 0x0013},
+		Default: 1,
+	}
+
+	a := NewPersistedAttribute(cfg)
+	if a.Get() != 1 {
+		t.Fatalf("Get() = %d, want the default", a.Get())
+	}
+	if err := a.Set(70000); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if storage.storeCount() != 1 {
+		t.Errorf("%d stores, want 1", storage.storeCount())
+	}
+
+	if got := NewPersistedAttribute(cfg).Get(); got != 70000 {
+		t.Errorf("reloaded value = %d, want 70000", got)
+	}
+
+	// A value that does not fit the type keeps the default
+	storage.data[a.Key()] = []byte{0x07, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	if got := NewPersistedAttribute(cfg).Get(); got != 1 {
+		t.Errorf("overflowing value loaded as %d, want the default", got)
+	}
+}
+
+func TestPersistedAttribute_Types(t *testing.T) {
+	storage := newMemStorage()
+	path := ConcreteAttributePath{Endpoint: 1, Cluster: 0x0006}
+
+	type startUp uint8
+	path.Attribute = 0x4003
+	nullable := PersistedAttributeConfig[*startUp]{Storage: storage, Path: path}
+	on := startUp(1)
+	_ = NewPersistedAttribute(nullable).Set(&on)
+	if got := NewPersistedAttribute(nullable).Get(); got == nil || *got != on {
+		t.Errorf("nullable value = %v, want %d", got, on)
+	}
+	_ = NewPersistedAttribute(nullable).Set(nil)
+	if got := NewPersistedAttribute(nullable).Get(); got != nil {
+		t.Errorf("nullable value = %d, want null", *got)
+	}
+
+	path.Attribute = 0x0005
+	label := PersistedAttributeConfig[string]{Storage: storage, Path: path}
+	_ = NewPersistedAttribute(label).Set("kitchen")
+	if got := NewPersistedAttribute(label).Get(); got != "kitchen" {
+		t.Errorf("string value = %q, want kitchen", got)
+	}
+
+	path.Attribute = 0x0006
+	temp := PersistedAttributeConfig[int16]{Storage: storage, Path: path}
+	_ = NewPersistedAttribute(temp).Set(-250)
+	if got := NewPersistedAttribute(temp).Get(); got != -250 {
+		t.Errorf("signed value = %d, want -250", got)
+	}
+
+	path.Attribute = 0x0007
+	unsupported := NewPersistedAttribute(PersistedAttributeConfig[[]string]{Storage: storage, Path: path})
+	if err := unsupported.Set([]string{"a"}); !errors.Is(err, ErrUnsupportedValueType) {
+		t.Errorf("Set([]string) = %v, want ErrUnsupportedValueType", err)
+	}
+}
+
+func TestPersistedAttribute_Deferred(t *testing.T) {
+	storage := newMemStorage()
+	cfg := PersistedAttributeConfig[uint8]{
+		Storage: storage,
+		Path:    ConcreteAttributePath{Endpoint: 1, Cluster: 0x0008, Attribute: 0x0000},
+		Mode:    PersistDeferred,
+		Delay:   time.Hour,
+	}
+
+	a := NewPersistedAttribute(cfg)
+	for level := range uint8(10) {
+		_ = a.Set(level)
+	}
+	if storage.storeCount() != 0 {
+		t.Fatalf("%d stores before the delay, want 0", storage.storeCount())
+	}
+	if err := a.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if storage.storeCount() != 1 {
+		t.Errorf("%d stores after Flush, want 1", storage.storeCount())
+	}
+	if got := NewPersistedAttribute(cfg).Get(); got != 9 {
+		t.Errorf("reloaded value = %d, want 9", got)
+	}
+
+	// The delay stores the last value of a burst
+	cfg.Delay = 10 * time.Millisecond
+	a = NewPersistedAttribute(cfg)
+	_ = a.Set(20)
+	_ = a.Set(30)
+	deadline := time.Now().Add(5 * time.Second)
+	for storage.storeCount() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if storage.storeCount() != 2 {
+		t.Fatalf("%d stores after the delay, want 2", storage.storeCount())
+	}
+	if got := NewPersistedAttribute(cfg).Get(); got != 30 {
+		t.Errorf("reloaded value = %d, want 30", got)
+	}
+}
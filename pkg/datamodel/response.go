@@ -0,0 +1,85 @@
+package datamodel
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// CommandResponse is the typed response of a command: the response
+// command, from the cluster's GeneratedCommandList, and its fields. A nil
+// CommandResponse answers the command with a Success status.
+type CommandResponse struct {
+	// Command is the ID of the response command.
+	Command CommandID
+
+	// Fields are the TLV-encoded command fields, an anonymous structure.
+	Fields []byte
+}
+
+// NewCommandResponse builds a response command. encode writes the
+// command's fields, with context tags, into the fields structure; it may
+// be nil for a response without fields.
+//
+//	return datamodel.NewCommandResponse(CmdSendKeyResponse, func(w *tlv.Writer) error {
+//	    return w.PutUint(tlv.ContextTag(0), uint64(status))
+//	})
+func NewCommandResponse(command CommandID, encode func(w *tlv.Writer) error) (*CommandResponse, error) {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return nil, err
+	}
+	if encode != nil {
+		if err := encode(w); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.EndContainer(); err != nil {
+		return nil, err
+	}
+
+	return &CommandResponse{Command: command, Fields: buf.Bytes()}, nil
+}
+
+// ClusterWithCommandResponses is an optional interface for clusters whose
+// commands return typed responses. Dispatchers invoke commands through it
+// rather than InvokeCommand, so that the InvokeResponse names the right
+// response command.
+type ClusterWithCommandResponses interface {
+	Cluster
+
+	// InvokeCommandResponse executes a command, like InvokeCommand, and
+	// returns its response, or nil for a status-only response.
+	InvokeCommandResponse(ctx context.Context, req InvokeRequest, r *tlv.Reader) (*CommandResponse, error)
+}
+
+// InvokeCommandResponse invokes a command of a cluster and returns its
+// typed response. Clusters without ClusterWithCommandResponses are
+// invoked through InvokeCommand; their response command is taken to be the
+// one following the request command, as is the case for most clusters.
+func InvokeCommandResponse(ctx context.Context, c Cluster, req InvokeRequest, r *tlv.Reader) (*CommandResponse, error) {
+	if typed, ok := c.(ClusterWithCommandResponses); ok {
+		return typed.InvokeCommandResponse(ctx, req, r)
+	}
+	data, err := c.InvokeCommand(ctx, req, r)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return &CommandResponse{Command: req.Path.Command + 1, Fields: data}, nil
+}
+
+// ResponseFields returns the fields of a typed response, for the
+// InvokeCommand of a ClusterWithCommandResponses:
+//
+//	func (c *Cluster) InvokeCommand(ctx context.Context, req datamodel.InvokeRequest, r *tlv.Reader) ([]byte, error) {
+//	    return datamodel.ResponseFields(c.InvokeCommandResponse(ctx, req, r))
+//	}
+func ResponseFields(resp *CommandResponse, err error) ([]byte, error) {
+	if err != nil || resp == nil {
+		return nil, err
+	}
+	return resp.Fields, nil
+}
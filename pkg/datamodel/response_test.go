@@ -0,0 +1,71 @@
+package datamodel
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// typedTestCluster is a routerTestCluster with typed command responses.
+type typedTestCluster struct {
+	routerTestCluster
+}
+
+func (c *typedTestCluster) InvokeCommandResponse(ctx context.Context, req InvokeRequest, r *tlv.Reader) (*CommandResponse, error) {
+	return NewCommandResponse(0x04, func(w *tlv.Writer) error {
+		return w.PutUint(tlv.ContextTag(0), 7)
+	})
+}
+
+func TestNewCommandResponse(t *testing.T) {
+	resp, err := NewCommandResponse(0x01, func(w *tlv.Writer) error {
+		return w.PutUint(tlv.ContextTag(0), 2)
+	})
+	if err != nil {
+		t.Fatalf("NewCommandResponse() error = %v", err)
+	}
+	want := []byte{0x15, 0x24, 0x00, 0x02, 0x18}
+	if resp.Command != 0x01 || !bytes.Equal(resp.Fields, want) {
+		t.Errorf("NewCommandResponse() = 0x%02x %x, want 0x01 %x", resp.Command, resp.Fields, want)
+	}
+
+	// A response without fields is an empty structure
+	resp, _ = NewCommandResponse(0x02, nil)
+	if !bytes.Equal(resp.Fields, []byte{0x15, 0x18}) {
+		t.Errorf("Fields = %x, want 1518", resp.Fields)
+	}
+}
+
+func TestInvokeCommandResponse(t *testing.T) {
+	ctx := context.Background()
+	req := InvokeRequest{Path: ConcreteCommandPath{Endpoint: 1, Cluster: 0x0060, Command: 0x01}}
+
+	// Typed clusters name their response command
+	typed := &typedTestCluster{}
+	resp, err := InvokeCommandResponse(ctx, typed, req, nil)
+	if err != nil || resp == nil || resp.Command != 0x04 {
+		t.Errorf("InvokeCommandResponse() = %+v, %v, want command 0x04", resp, err)
+	}
+	if data, _ := typed.InvokeCommandResponse(ctx, req, nil); !bytes.Equal(data.Fields, resp.Fields) {
+		t.Errorf("Fields = %x, want %x", resp.Fields, data.Fields)
+	}
+
+	// Others respond with the following command, or a status
+	legacy := &routerTestCluster{invokeFunc: func(context.Context, InvokeRequest, *tlv.Reader) ([]byte, error) {
+		return []byte{0x15, 0x18}, nil
+	}}
+	resp, err = InvokeCommandResponse(ctx, legacy, req, nil)
+	if err != nil || resp == nil || resp.Command != 0x02 {
+		t.Errorf("InvokeCommandResponse() = %+v, %v, want command 0x02", resp, err)
+	}
+	legacy.invokeFunc = nil
+	if resp, err := InvokeCommandResponse(ctx, legacy, req, nil); resp != nil || err != nil {
+		t.Errorf("InvokeCommandResponse() = %+v, %v, want nil", resp, err)
+	}
+
+	if data, err := ResponseFields(NewCommandResponse(0x04, nil)); err != nil || !bytes.Equal(data, []byte{0x15, 0x18}) {
+		t.Errorf("ResponseFields() = %x, %v", data, err)
+	}
+}
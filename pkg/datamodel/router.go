@@ -127,6 +127,17 @@ func (r *Router) InvokeCommand(ctx context.Context, req InvokeRequest, reader *t
 	return cluster.InvokeCommand(ctx, req, reader)
 }
 
+// InvokeCommandResponse invokes a command on the appropriate cluster and
+// returns its typed response, see InvokeCommandResponse.
+func (r *Router) InvokeCommandResponse(ctx context.Context, req InvokeRequest, reader *tlv.Reader) (*CommandResponse, error) {
+	cluster, err := r.GetCluster(req.Path.Endpoint, req.Path.Cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	return InvokeCommandResponse(ctx, cluster, req, reader)
+}
+
 // RouterNode wraps a Router to implement the Node interface.
 // This allows the Router to be used where a Node is expected.
 type RouterNode struct {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"net"
 	"sync"
@@ -41,6 +42,10 @@ type activeService struct {
 	server       MDNSServer
 	serviceType  ServiceType
 	instanceName string
+
+	// Registration arguments, to register the service again
+	service string
+	txt     []string
 }
 
 // AdvertiserConfig holds configuration for the Advertiser.
@@ -178,7 +183,9 @@ func (a *Advertiser) StartCommissionable(txt CommissionableTXT) error {
 	a.services[ServiceTypeCommissionable] = &activeService{
 		server:       server,
 		serviceType:  ServiceTypeCommissionable,
-		instanceName:  instanceName,
+		instanceName: instanceName,
+		service:      service,
+		txt:          txtRecords,
 	}
 
 	return nil
@@ -201,12 +208,13 @@ func (a *Advertiser) StartOperational(compressedFabricID [8]byte, nodeID fabric.
 
 	instanceName := OperationalInstanceName(compressedFabricID, nodeID)
 
+	txtRecords := txt.Encode()
 	server, err := a.factory.Register(
 		instanceName,
 		ServiceOperational,
 		DefaultDomain,
 		a.config.Port,
-		txt.Encode(),
+		txtRecords,
 		a.config.Interfaces,
 	)
 	if err != nil {
@@ -217,6 +225,8 @@ func (a *Advertiser) StartOperational(compressedFabricID [8]byte, nodeID fabric.
 		server:       server,
 		serviceType:  ServiceTypeOperational,
 		instanceName: instanceName,
+		service:      ServiceOperational,
+		txt:          txtRecords,
 	}
 
 	return nil
@@ -264,12 +274,13 @@ func (a *Advertiser) StartCommissioner(txt CommissionerTXT) error {
 		service += "," + st + "._sub." + ServiceCommissioner
 	}
 
+	txtRecords := txt.Encode()
 	server, err := a.factory.Register(
 		instanceName,
 		service,
 		DefaultDomain,
 		a.config.Port,
-		txt.Encode(),
+		txtRecords,
 		a.config.Interfaces,
 	)
 	if err != nil {
@@ -280,6 +291,8 @@ func (a *Advertiser) StartCommissioner(txt CommissionerTXT) error {
 		server:       server,
 		serviceType:  ServiceTypeCommissioner,
 		instanceName: instanceName,
+		service:      service,
+		txt:          txtRecords,
 	}
 
 	return nil
@@ -305,6 +318,40 @@ func (a *Advertiser) Stop(serviceType ServiceType) error {
 	return nil
 }
 
+// Readvertise registers the active services again under the same instance
+// names and TXT records, so that they are announced with the host's
+// current addresses, e.g. after an interface address changed. A service
+// that fails to register again stops being advertised; the errors are
+// joined.
+func (a *Advertiser) Readvertise() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.closed {
+		return ErrClosed
+	}
+
+	var errs []error
+	for serviceType, svc := range a.services {
+		svc.server.Shutdown()
+		server, err := a.factory.Register(
+			svc.instanceName,
+			svc.service,
+			DefaultDomain,
+			a.config.Port,
+			svc.txt,
+			a.config.Interfaces,
+		)
+		if err != nil {
+			delete(a.services, serviceType)
+			errs = append(errs, fmt.Errorf("advertiser: mDNS registration failed for %s: %w", svc.service, err))
+			continue
+		}
+		svc.server = server
+	}
+	return errors.Join(errs...)
+}
+
 // StopAll stops all active service advertisements.
 func (a *Advertiser) StopAll() {
 	a.mu.Lock()
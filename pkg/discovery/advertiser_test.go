@@ -202,6 +202,43 @@ func TestAdvertiser_StartOperational(t *testing.T) {
 	})
 }
 
+func TestAdvertiser_Readvertise(t *testing.T) {
+	factory := newMockMDNSServerFactory()
+	adv, err := NewAdvertiser(AdvertiserConfig{
+		Port:          5540,
+		ServerFactory: factory,
+	})
+	if err != nil {
+		t.Fatalf("NewAdvertiser() error = %v", err)
+	}
+
+	txt := CommissionableTXT{Discriminator: 3840, VendorID: 0xFFF1, ProductID: 0x8001, CommissioningMode: CommissioningModeBasic}
+	if err := adv.StartCommissionable(txt); err != nil {
+		t.Fatalf("StartCommissionable() error = %v", err)
+	}
+	instance := adv.GetInstanceName(ServiceTypeCommissionable)
+	service, txtRecords := factory.lastArgs.service, factory.lastArgs.txt
+
+	if err := adv.Readvertise(); err != nil {
+		t.Fatalf("Readvertise() error = %v", err)
+	}
+	if len(factory.servers) != 2 || !factory.servers[0].shutdownCalled {
+		t.Fatalf("servers = %d, first shut down = %v; want the service registered again", len(factory.servers), factory.servers[0].shutdownCalled)
+	}
+	if factory.lastArgs.instance != instance || factory.lastArgs.service != service || len(factory.lastArgs.txt) != len(txtRecords) {
+		t.Errorf("registered again as %q %q, want %q %q", factory.lastArgs.instance, factory.lastArgs.service, instance, service)
+	}
+
+	// A failed registration stops the service
+	factory.shouldFail = true
+	if err := adv.Readvertise(); err == nil {
+		t.Error("Readvertise() succeeded with a failing factory")
+	}
+	if adv.IsAdvertising(ServiceTypeCommissionable) {
+		t.Error("service still advertised after failed registration")
+	}
+}
+
 func TestAdvertiser_StartCommissioner(t *testing.T) {
 	factory := newMockMDNSServerFactory()
 	adv, err := NewAdvertiser(AdvertiserConfig{
@@ -42,6 +42,9 @@ var (
 	// ErrInvalidInstanceName is returned when the instance name format is invalid.
 	ErrInvalidInstanceName = errors.New("discovery: invalid instance name format")
 
+	// ErrNoResolver is returned when a NodeResolver is created without a Resolver.
+	ErrNoResolver = errors.New("discovery: resolver is required")
+
 	// ErrInvalidTXTRecord is returned when a TXT record has invalid format.
 	ErrInvalidTXTRecord = errors.New("discovery: invalid TXT record format")
 )
@@ -47,6 +47,7 @@ type Manager struct {
 	config     ManagerConfig
 	advertiser *Advertiser
 	resolver   *Resolver
+	nodes      *NodeResolver
 	log        logging.LeveledLogger
 
 	mu     sync.RWMutex
@@ -88,10 +89,21 @@ func NewManager(config ManagerConfig) (*Manager, error) {
 		return nil, err
 	}
 
+	// Create operational node resolver
+	nodes, err := NewNodeResolver(NodeResolverConfig{
+		Resolver:      resolver,
+		Interfaces:    config.Interfaces,
+		LoggerFactory: config.LoggerFactory,
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	m := &Manager{
 		config:     config,
 		advertiser: advertiser,
 		resolver:   resolver,
+		nodes:      nodes,
 	}
 
 	if config.LoggerFactory != nil {
@@ -185,6 +197,22 @@ func (m *Manager) StopAdvertising(serviceType ServiceType) error {
 	return m.advertiser.Stop(serviceType)
 }
 
+// Readvertise announces the active services again with the host's current
+// addresses. Call it when the host's interface addresses change.
+func (m *Manager) Readvertise() error {
+	m.mu.RLock()
+	if m.closed {
+		m.mu.RUnlock()
+		return ErrClosed
+	}
+	m.mu.RUnlock()
+
+	if m.log != nil {
+		m.log.Info("Re-announcing services after address change")
+	}
+	return m.advertiser.Readvertise()
+}
+
 // StopAllAdvertising stops all active service advertisements.
 func (m *Manager) StopAllAdvertising() {
 	m.mu.RLock()
@@ -291,6 +319,20 @@ func (m *Manager) LookupOperational(ctx context.Context, compressedFabricID [8]b
 	return m.resolver.LookupOperational(ctx, compressedFabricID, nodeID)
 }
 
+// ResolveNode resolves an operational node to its addresses, caching the
+// result. Report addresses that fail with NodeResolver().MarkFailed.
+// Spec Section 4.3.2
+func (m *Manager) ResolveNode(ctx context.Context, compressedFabricID [8]byte, nodeID fabric.NodeID) (*ResolvedNode, error) {
+	m.mu.RLock()
+	if m.closed {
+		m.mu.RUnlock()
+		return nil, ErrClosed
+	}
+	m.mu.RUnlock()
+
+	return m.nodes.Resolve(ctx, compressedFabricID, nodeID)
+}
+
 // DiscoverCommissionableNode finds a commissionable node by discriminator.
 // This is a convenience method that browses and returns the first match.
 func (m *Manager) DiscoverCommissionableNode(ctx context.Context, discriminator uint16) (*ResolvedService, error) {
@@ -313,3 +355,8 @@ func (m *Manager) Advertiser() *Advertiser {
 func (m *Manager) Resolver() *Resolver {
 	return m.resolver
 }
+
+// NodeResolver returns the operational node resolver.
+func (m *Manager) NodeResolver() *NodeResolver {
+	return m.nodes
+}
@@ -0,0 +1,361 @@
+package discovery
+
+import (
+	"context"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/pion/logging"
+)
+
+// DefaultNodeTTL is how long a resolved node is cached when its DNS-SD
+// response carries no TTL. It matches the TTL operational nodes advertise
+// their SRV records with.
+const DefaultNodeTTL = 120 * time.Second
+
+// LocalAddress is an address of the local host, on one of its interfaces.
+type LocalAddress struct {
+	IP        net.IP
+	Interface string
+}
+
+// NodeResolverConfig holds configuration for the NodeResolver.
+type NodeResolverConfig struct {
+	// Resolver performs the DNS-SD lookups. Required.
+	Resolver *Resolver
+
+	// DefaultTTL is how long a node is cached when its response carries
+	// no TTL. If zero, DefaultNodeTTL is used.
+	DefaultTTL time.Duration
+
+	// Interfaces restricts the interfaces link-local addresses are
+	// reached through. If nil, all interfaces are used.
+	Interfaces []net.Interface
+
+	// LocalAddresses returns the local host's addresses, to order a node's
+	// addresses by reachability. If nil, the addresses of Interfaces are
+	// used.
+	LocalAddresses func() ([]LocalAddress, error)
+
+	// Now returns the current time (for testing). If nil, time.Now is used.
+	Now func() time.Time
+
+	// LoggerFactory is the factory for creating loggers.
+	// If nil, logging is disabled.
+	LoggerFactory logging.LoggerFactory
+}
+
+// ResolvedNode is an operational node resolved to its addresses.
+type ResolvedNode struct {
+	CompressedFabricID [8]byte
+	NodeID             fabric.NodeID
+
+	// Addresses are the node's addresses, most preferred first. Addresses
+	// that failed are moved last.
+	Addresses []*net.UDPAddr
+
+	// Text contains the node's TXT record key-value pairs.
+	Text map[string]string
+
+	// Expires is when the resolution expires and the node is resolved
+	// again.
+	Expires time.Time
+}
+
+// nodeKey identifies an operational node.
+type nodeKey struct {
+	compressedFabricID [8]byte
+	nodeID             fabric.NodeID
+}
+
+// cachedNode is a cache entry of the NodeResolver.
+type cachedNode struct {
+	node   ResolvedNode
+	failed map[string]struct{}
+}
+
+// NodeResolver resolves operational nodes to their addresses and caches
+// the result for the TTL of the DNS-SD response.
+//
+// A node's addresses are ordered by preference: addresses reachable from
+// a local address of the same scope first, then global over unique local
+// over link-local IPv6, then IPv4. A link-local address is listed once
+// per interface it may be reached through.
+//
+// When CASE or message delivery to an address fails, MarkFailed moves it
+// last; once all of a node's addresses have failed, the next Resolve
+// looks the node up again.
+//
+// Spec: Section 4.3.2 (operational discovery)
+// C++ Reference: AddressResolve::Resolver
+type NodeResolver struct {
+	config   NodeResolverConfig
+	resolver *Resolver
+	log      logging.LeveledLogger
+
+	mu    sync.Mutex
+	nodes map[nodeKey]*cachedNode
+}
+
+// NewNodeResolver creates a new NodeResolver with the given configuration.
+func NewNodeResolver(config NodeResolverConfig) (*NodeResolver, error) {
+	if config.Resolver == nil {
+		return nil, ErrNoResolver
+	}
+	if config.DefaultTTL == 0 {
+		config.DefaultTTL = DefaultNodeTTL
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	if config.LocalAddresses == nil {
+		ifaces := config.Interfaces
+		config.LocalAddresses = func() ([]LocalAddress, error) {
+			return InterfaceAddresses(ifaces)
+		}
+	}
+
+	r := &NodeResolver{
+		config:   config,
+		resolver: config.Resolver,
+		nodes:    make(map[nodeKey]*cachedNode),
+	}
+	if config.LoggerFactory != nil {
+		r.log = config.LoggerFactory.NewLogger("discovery")
+	}
+	return r, nil
+}
+
+// Resolve returns the addresses of an operational node, from the cache
+// while the resolution is fresh and some address has not failed, or else
+// by a DNS-SD lookup.
+func (r *NodeResolver) Resolve(ctx context.Context, compressedFabricID [8]byte, nodeID fabric.NodeID) (*ResolvedNode, error) {
+	key := nodeKey{compressedFabricID: compressedFabricID, nodeID: nodeID}
+
+	r.mu.Lock()
+	if entry, ok := r.nodes[key]; ok {
+		if r.config.Now().Before(entry.node.Expires) && len(entry.failed) < len(entry.node.Addresses) {
+			node := entry.resolved()
+			r.mu.Unlock()
+			return node, nil
+		}
+		delete(r.nodes, key)
+	}
+	r.mu.Unlock()
+
+	svc, err := r.resolver.LookupOperational(ctx, compressedFabricID, nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	local, err := r.config.LocalAddresses()
+	if err != nil && r.log != nil {
+		r.log.Warnf("node resolver: local addresses: %v", err)
+	}
+	addrs := orderAddresses(svc.IPs, svc.Port, local)
+	if len(addrs) == 0 {
+		return nil, ErrServiceNotFound
+	}
+
+	ttl := svc.TTL
+	if ttl == 0 {
+		ttl = r.config.DefaultTTL
+	}
+	entry := &cachedNode{
+		node: ResolvedNode{
+			CompressedFabricID: compressedFabricID,
+			NodeID:             nodeID,
+			Addresses:          addrs,
+			Text:               svc.Text,
+			Expires:            r.config.Now().Add(ttl),
+		},
+		failed: make(map[string]struct{}),
+	}
+	if r.log != nil {
+		r.log.Debugf("node resolver: %s resolved to %d addresses", svc.InstanceName, len(addrs))
+	}
+
+	r.mu.Lock()
+	r.nodes[key] = entry
+	node := entry.resolved()
+	r.mu.Unlock()
+	return node, nil
+}
+
+// MarkFailed records that CASE or message delivery to one of a node's
+// addresses failed. The address is tried last from now on; once all the
+// node's addresses have failed, the node is resolved again.
+func (r *NodeResolver) MarkFailed(compressedFabricID [8]byte, nodeID fabric.NodeID, addr net.Addr) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.nodes[nodeKey{compressedFabricID: compressedFabricID, nodeID: nodeID}]
+	if !ok {
+		return
+	}
+	for _, a := range entry.node.Addresses {
+		if a.String() == addr.String() {
+			entry.failed[a.String()] = struct{}{}
+			return
+		}
+	}
+}
+
+// Invalidate drops a node from the cache, so that the next Resolve looks
+// it up again.
+func (r *NodeResolver) Invalidate(compressedFabricID [8]byte, nodeID fabric.NodeID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.nodes, nodeKey{compressedFabricID: compressedFabricID, nodeID: nodeID})
+}
+
+// InvalidateAll drops every node from the cache, e.g. after the local
+// host's addresses changed, which changes the reachability of the cached
+// addresses.
+func (r *NodeResolver) InvalidateAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nodes = make(map[nodeKey]*cachedNode)
+}
+
+// resolved returns a copy of the entry's node with the failed addresses
+// last.
+func (c *cachedNode) resolved() *ResolvedNode {
+	node := c.node
+	node.Addresses = make([]*net.UDPAddr, 0, len(c.node.Addresses))
+	var failed []*net.UDPAddr
+	for _, addr := range c.node.Addresses {
+		if _, ok := c.failed[addr.String()]; ok {
+			failed = append(failed, addr)
+		} else {
+			node.Addresses = append(node.Addresses, addr)
+		}
+	}
+	node.Addresses = append(node.Addresses, failed...)
+	return &node
+}
+
+// orderAddresses turns a node's IPs into UDP addresses, ordered by
+// preference from the local addresses. Without local addresses, all IPs
+// are assumed reachable.
+//
+// This follows RFC 6724 destination address selection loosely: an
+// address without a local address of the same scope is avoided (rule 2),
+// then IPv6 is preferred over IPv4 and wider scopes over narrower ones
+// (rule 6, as SortIPsByPreference).
+func orderAddresses(ips []net.IP, port int, local []LocalAddress) []*net.UDPAddr {
+	type candidate struct {
+		addr        *net.UDPAddr
+		unreachable bool
+		priority    int
+	}
+
+	// Interfaces with a link-local IPv6 address
+	var zones []string
+	seen := make(map[string]bool)
+	for _, l := range local {
+		if l.IP.To4() == nil && l.IP.IsLinkLocalUnicast() && !seen[l.Interface] {
+			seen[l.Interface] = true
+			zones = append(zones, l.Interface)
+		}
+	}
+
+	var candidates []candidate
+	for _, ip := range ips {
+		unreachable := len(local) > 0 && !hasScope(local, ip)
+		priority := ipPriority(ip)
+		if priority >= 80 {
+			// Loopback and multicast are never a node's address
+			continue
+		}
+		if ip.To4() == nil && ip.IsLinkLocalUnicast() && len(zones) > 0 {
+			for _, zone := range zones {
+				candidates = append(candidates, candidate{
+					addr:        &net.UDPAddr{IP: ip, Port: port, Zone: zone},
+					unreachable: unreachable,
+					priority:    priority,
+				})
+			}
+			continue
+		}
+		candidates = append(candidates, candidate{
+			addr:        &net.UDPAddr{IP: ip, Port: port},
+			unreachable: unreachable,
+			priority:    priority,
+		})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].unreachable != candidates[j].unreachable {
+			return !candidates[i].unreachable
+		}
+		return candidates[i].priority < candidates[j].priority
+	})
+
+	addrs := make([]*net.UDPAddr, len(candidates))
+	for i, c := range candidates {
+		addrs[i] = c.addr
+	}
+	return addrs
+}
+
+// hasScope reports whether a local address has the scope of ip.
+func hasScope(local []LocalAddress, ip net.IP) bool {
+	scope := addressScope(ip)
+	for _, l := range local {
+		if addressScope(l.IP) == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// addressScope classifies an address for scope matching: IPv4, IPv6
+// link-local, unique local or global.
+func addressScope(ip net.IP) int {
+	switch {
+	case ip.To4() != nil:
+		return 0
+	case ip.IsLinkLocalUnicast():
+		return 1
+	case isUniqueLocal(ip):
+		return 2
+	default:
+		return 3
+	}
+}
+
+// InterfaceAddresses returns the unicast addresses of the interfaces in
+// ifaces that are up, or of all such interfaces if ifaces is nil.
+// Loopback interfaces are skipped.
+func InterfaceAddresses(ifaces []net.Interface) ([]LocalAddress, error) {
+	if ifaces == nil {
+		var err error
+		ifaces, err = net.Interfaces()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var local []LocalAddress
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || ipNet.IP.IsLoopback() || ipNet.IP.IsMulticast() {
+				continue
+			}
+			local = append(local, LocalAddress{IP: ipNet.IP, Interface: iface.Name})
+		}
+	}
+	return local, nil
+}
@@ -0,0 +1,219 @@
+package discovery
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/grandcat/zeroconf"
+)
+
+func newTestNodeResolver(t *testing.T, mock *MockMDNSResolver, local []LocalAddress, now *time.Time) *NodeResolver {
+	t.Helper()
+	resolver, err := NewResolver(ResolverConfig{MDNSResolver: mock, LookupTimeout: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewResolver() error = %v", err)
+	}
+	r, err := NewNodeResolver(NodeResolverConfig{
+		Resolver:       resolver,
+		LocalAddresses: func() ([]LocalAddress, error) { return local, nil },
+		Now:            func() time.Time { return *now },
+	})
+	if err != nil {
+		t.Fatalf("NewNodeResolver() error = %v", err)
+	}
+	return r
+}
+
+// mockNode creates a mock operational service entry for a node.
+func mockNode(cfid [8]byte, nodeID uint64, port int, ip net.IP) *zeroconf.ServiceEntry {
+	entry := MockOperationalService(cfid, nodeID, port, ip)
+	entry.Instance = OperationalInstanceName(cfid, fabric.NodeID(nodeID))
+	return entry
+}
+
+func TestNodeResolver_Cache(t *testing.T) {
+	cfid := [8]byte{0x87, 0xE1, 0xB0, 0x04, 0xE2, 0x35, 0xA1, 0x30}
+	now := time.Unix(1000, 0)
+	mock := NewMockMDNSResolver()
+	entry := mockNode(cfid, 0x22, 5540, net.ParseIP("192.168.1.10"))
+	entry.TTL = 60
+	mock.RegisterService(ServiceOperational, entry)
+
+	r := newTestNodeResolver(t, mock, nil, &now)
+	ctx := context.Background()
+
+	node, err := r.Resolve(ctx, cfid, 0x22)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if len(node.Addresses) != 1 || node.Addresses[0].String() != "192.168.1.10:5540" {
+		t.Fatalf("Addresses = %v, want [192.168.1.10:5540]", node.Addresses)
+	}
+	if want := now.Add(60 * time.Second); !node.Expires.Equal(want) {
+		t.Errorf("Expires = %v, want %v", node.Expires, want)
+	}
+
+	// Cached while fresh, even once the node is gone
+	mock.ClearServices()
+	if _, err := r.Resolve(ctx, cfid, 0x22); err != nil {
+		t.Fatalf("cached Resolve() error = %v", err)
+	}
+
+	// Resolved again once expired
+	now = now.Add(61 * time.Second)
+	if _, err := r.Resolve(ctx, cfid, 0x22); err != ErrTimeout && err != ErrServiceNotFound {
+		t.Fatalf("expired Resolve() error = %v, want lookup failure", err)
+	}
+}
+
+func TestNodeResolver_MarkFailed(t *testing.T) {
+	cfid := [8]byte{1, 2, 3, 4, 5, 6, 7, 8}
+	now := time.Unix(1000, 0)
+	mock := NewMockMDNSResolver()
+	entry := mockNode(cfid, 0x22, 5540, net.ParseIP("192.168.1.10"))
+	entry.AddrIPv6 = []net.IP{net.ParseIP("2001:db8::10")}
+	mock.RegisterService(ServiceOperational, entry)
+
+	r := newTestNodeResolver(t, mock, nil, &now)
+	ctx := context.Background()
+
+	node, err := r.Resolve(ctx, cfid, 0x22)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if len(node.Addresses) != 2 || !node.Addresses[0].IP.Equal(net.ParseIP("2001:db8::10")) {
+		t.Fatalf("Addresses = %v, want IPv6 first", node.Addresses)
+	}
+
+	// A failed address is tried last
+	r.MarkFailed(cfid, 0x22, node.Addresses[0])
+	node, err = r.Resolve(ctx, cfid, 0x22)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if !node.Addresses[0].IP.Equal(net.ParseIP("192.168.1.10")) {
+		t.Errorf("Addresses = %v, want failed address last", node.Addresses)
+	}
+
+	// Once all failed, the node is looked up again
+	r.MarkFailed(cfid, 0x22, node.Addresses[0])
+	mock.ClearServices()
+	mock.RegisterService(ServiceOperational, mockNode(cfid, 0x22, 5541, net.ParseIP("192.168.1.11")))
+	node, err = r.Resolve(ctx, cfid, 0x22)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if len(node.Addresses) != 1 || node.Addresses[0].String() != "192.168.1.11:5541" {
+		t.Errorf("Addresses = %v, want re-resolved address", node.Addresses)
+	}
+
+	// Invalidate forces a lookup
+	r.Invalidate(cfid, 0x22)
+	mock.ClearServices()
+	if _, err := r.Resolve(ctx, cfid, 0x22); err == nil {
+		t.Error("Resolve() after Invalidate succeeded from the cache")
+	}
+}
+
+// TestNodeResolver_LinkLocal verifies that a link-local-only node is
+// resolved to one scoped address per interface, and that a failure on one
+// interface does not mark the others failed.
+func TestNodeResolver_LinkLocal(t *testing.T) {
+	cfid := [8]byte{1, 2, 3, 4, 5, 6, 7, 8}
+	now := time.Unix(1000, 0)
+	mock := NewMockMDNSResolver()
+	entry := mockNode(cfid, 0x22, 5540, nil)
+	entry.AddrIPv4 = nil
+	entry.AddrIPv6 = []net.IP{net.ParseIP("fe80::1")}
+	mock.RegisterService(ServiceOperational, entry)
+
+	local := []LocalAddress{
+		{IP: net.ParseIP("fe80::a"), Interface: "eth0"},
+		{IP: net.ParseIP("fe80::b"), Interface: "wpan0"},
+	}
+	r := newTestNodeResolver(t, mock, local, &now)
+	ctx := context.Background()
+
+	node, err := r.Resolve(ctx, cfid, 0x22)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	want := []string{"[fe80::1%eth0]:5540", "[fe80::1%wpan0]:5540"}
+	if len(node.Addresses) != 2 || node.Addresses[0].String() != want[0] || node.Addresses[1].String() != want[1] {
+		t.Fatalf("Addresses = %v, want %v", node.Addresses, want)
+	}
+
+	r.MarkFailed(cfid, 0x22, node.Addresses[0])
+	node, err = r.Resolve(ctx, cfid, 0x22)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if node.Addresses[0].String() != want[1] || node.Addresses[1].String() != want[0] {
+		t.Errorf("Addresses = %v, want %s first", node.Addresses, want[1])
+	}
+
+	svc := ResolvedService{IPs: []net.IP{net.ParseIP("fe80::1")}, Port: 5540}
+	if addrs := svc.UDPAddrs(local); len(addrs) != 2 || addrs[1].Zone != "wpan0" {
+		t.Errorf("UDPAddrs() = %v, want %v", addrs, want)
+	}
+}
+
+func TestOrderAddresses(t *testing.T) {
+	ips := []net.IP{
+		net.ParseIP("192.168.1.10"),
+		net.ParseIP("fe80::1"),
+		net.ParseIP("fd00::1"),
+		net.ParseIP("2001:db8::1"),
+	}
+
+	tests := []struct {
+		name  string
+		local []LocalAddress
+		want  []string
+	}{
+		{
+			name: "no local addresses",
+			want: []string{"[2001:db8::1]:5540", "[fd00::1]:5540", "[fe80::1]:5540", "192.168.1.10:5540"},
+		},
+		{
+			name: "link-local and ULA only",
+			local: []LocalAddress{
+				{IP: net.ParseIP("fe80::a"), Interface: "eth0"},
+				{IP: net.ParseIP("fd00::a"), Interface: "eth0"},
+			},
+			want: []string{"[fd00::1]:5540", "[fe80::1%eth0]:5540", "[2001:db8::1]:5540", "192.168.1.10:5540"},
+		},
+		{
+			name: "link-local on two interfaces",
+			local: []LocalAddress{
+				{IP: net.ParseIP("fe80::a"), Interface: "eth0"},
+				{IP: net.ParseIP("fe80::b"), Interface: "wlan0"},
+				{IP: net.ParseIP("192.168.1.2"), Interface: "eth0"},
+			},
+			want: []string{"[fe80::1%eth0]:5540", "[fe80::1%wlan0]:5540", "192.168.1.10:5540", "[2001:db8::1]:5540", "[fd00::1]:5540"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addrs := orderAddresses(ips, 5540, tt.local)
+			if len(addrs) != len(tt.want) {
+				t.Fatalf("orderAddresses() = %v, want %v", addrs, tt.want)
+			}
+			for i, addr := range addrs {
+				if addr.String() != tt.want[i] {
+					t.Errorf("address %d = %s, want %s", i, addr, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNewNodeResolver_NoResolver(t *testing.T) {
+	if _, err := NewNodeResolver(NodeResolverConfig{}); err != ErrNoResolver {
+		t.Errorf("NewNodeResolver() error = %v, want ErrNoResolver", err)
+	}
+}
@@ -34,6 +34,10 @@ type ResolvedService struct {
 
 	// Text contains the raw TXT record key-value pairs.
 	Text map[string]string
+
+	// TTL is the time to live of the service's records, or zero if the
+	// response did not carry one.
+	TTL time.Duration
 }
 
 // PreferredIP returns the most preferred IP address (first in the sorted list).
@@ -45,6 +49,15 @@ func (r *ResolvedService) PreferredIP() net.IP {
 	return nil
 }
 
+// UDPAddrs returns the service's addresses with its port, ordered by
+// preference from the local addresses, as NodeResolver orders a node's. A
+// link-local IPv6 address is listed once per local interface with a
+// link-local address, scoped to it, as the interface the service was
+// found on is not known. Local addresses come from InterfaceAddresses.
+func (r *ResolvedService) UDPAddrs(local []LocalAddress) []*net.UDPAddr {
+	return orderAddresses(r.IPs, r.Port, local)
+}
+
 // IPv6Addresses returns only IPv6 addresses from the service.
 func (r *ResolvedService) IPv6Addresses() []net.IP {
 	return FilterIPv6(r.IPs)
@@ -277,6 +290,7 @@ func entryToResolvedService(entry *zeroconf.ServiceEntry, serviceType ServiceTyp
 		Port:         entry.Port,
 		IPs:          sortedIPs,
 		Text:         txtMap,
+		TTL:          time.Duration(entry.TTL) * time.Second,
 	}
 }
 
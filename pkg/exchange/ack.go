@@ -2,7 +2,8 @@ package exchange
 
 import (
 	"sync"
-	"time"
+
+	"github.com/backkem/matter/pkg/clock"
 )
 
 // AckEntry represents a pending acknowledgement for a received reliable message.
@@ -25,7 +26,7 @@ type AckEntry struct {
 
 	// Timer for standalone ACK timeout.
 	// Fires after MRP_STANDALONE_ACK_TIMEOUT if no piggyback opportunity.
-	timer *time.Timer
+	timer clock.Timer
 
 	// callback is invoked when the timer expires.
 	callback func()
@@ -48,6 +49,9 @@ type AckTable struct {
 	// Only one pending ACK per exchange.
 	entries map[exchangeKey]*AckEntry
 
+	// clock runs the standalone ACK timers.
+	clock clock.Clock
+
 	mu sync.Mutex
 }
 
@@ -61,8 +65,14 @@ type exchangeKey struct {
 
 // NewAckTable creates a new acknowledgement table.
 func NewAckTable() *AckTable {
+	return newAckTable(clock.Real{})
+}
+
+// newAckTable creates an acknowledgement table whose timers run on clk.
+func newAckTable(clk clock.Clock) *AckTable {
 	return &AckTable{
 		entries: make(map[exchangeKey]*AckEntry),
+		clock:   clk,
 	}
 }
 
@@ -101,7 +111,7 @@ func (t *AckTable) Add(key exchangeKey, messageCounter uint32, onTimeout func())
 	}
 
 	// Start timer
-	entry.timer = time.AfterFunc(MRPStandaloneAckTimeout, func() {
+	entry.timer = t.clock.AfterFunc(MRPStandaloneAckTimeout, func() {
 		t.mu.Lock()
 		// Verify entry still exists and hasn't been superseded
 		current, ok := t.entries[key]
@@ -4,6 +4,8 @@ import (
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/backkem/matter/pkg/clock"
 )
 
 func TestAckTableAddAndGet(t *testing.T) {
@@ -128,7 +130,8 @@ func TestAckTableRemove(t *testing.T) {
 }
 
 func TestAckTableTimeout(t *testing.T) {
-	table := NewAckTable()
+	clk := clock.NewFakeClock(time.Time{})
+	table := newAckTable(clk)
 
 	key := exchangeKey{
 		localSessionID: 1,
@@ -141,8 +144,11 @@ func TestAckTableTimeout(t *testing.T) {
 		called.Add(1)
 	})
 
-	// Wait for timeout (200ms + buffer)
-	time.Sleep(250 * time.Millisecond)
+	clk.Advance(MRPStandaloneAckTimeout - time.Millisecond)
+	if called.Load() != 0 {
+		t.Errorf("callback called %d times before the timeout, want 0", called.Load())
+	}
+	clk.Advance(time.Millisecond)
 
 	if called.Load() != 1 {
 		t.Errorf("callback called %d times, want 1", called.Load())
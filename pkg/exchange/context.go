@@ -2,7 +2,9 @@ package exchange
 
 import (
 	"sync"
+	"time"
 
+	"github.com/backkem/matter/pkg/clock"
 	"github.com/backkem/matter/pkg/message"
 	"github.com/backkem/matter/pkg/session"
 	"github.com/backkem/matter/pkg/transport"
@@ -80,6 +82,10 @@ type ExchangeContext struct {
 	// delegate receives messages from upper layer.
 	delegate ExchangeDelegate
 
+	// handler is the protocol handler that accepted the exchange's
+	// unsolicited first message (nil for initiator exchanges).
+	handler ProtocolHandler
+
 	// manager is the parent manager (for sending, MRP tables).
 	manager *Manager
 
@@ -91,6 +97,18 @@ type ExchangeContext struct {
 	pendingRetransmitCounter uint32
 	hasPendingRetransmit     bool
 
+	// responseTimeout is the time to wait for an expected response
+	// (0 for the MRP-derived default).
+	responseTimeout time.Duration
+
+	// responseTimer runs while a response is expected (nil otherwise).
+	// responseGen invalidates the callbacks of stopped timers.
+	responseTimer clock.Timer
+	responseGen   uint64
+
+	// lastActivity is when a message was last sent or received.
+	lastActivity time.Time
+
 	mu sync.Mutex
 }
 
@@ -281,6 +299,103 @@ func (c *ExchangeContext) SendMessage(opcode uint8, payload []byte, reliable boo
 	return manager.sendMessage(c, proto, payload)
 }
 
+// SetResponseTimeout sets how long SendMessageExpectResponse waits for the
+// response. Zero, the default, selects a timeout derived from the
+// session's MRP parameters: the time for the message and the response to
+// be delivered with all their retransmissions, plus
+// ExpectedProcessingTime.
+func (c *ExchangeContext) SetResponseTimeout(timeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.responseTimeout = timeout
+}
+
+// ResponseTimeout returns how long SendMessageExpectResponse waits for the
+// response.
+func (c *ExchangeContext) ResponseTimeout() time.Duration {
+	c.mu.Lock()
+	timeout := c.responseTimeout
+	sess := c.session
+	transportType := c.peerAddress.TransportType
+	c.mu.Unlock()
+
+	if timeout > 0 {
+		return timeout
+	}
+	return defaultResponseTimeout(sess, transportType)
+}
+
+// SendMessageExpectResponse sends a message like SendMessage and waits for
+// the peer to respond on the exchange. If no message arrives within
+// ResponseTimeout, the delegate's OnResponseTimeout is called, if it
+// implements ResponseTimeoutDelegate, and the exchange is closed.
+func (c *ExchangeContext) SendMessageExpectResponse(opcode uint8, payload []byte, reliable bool) error {
+	// Armed first, as the response may arrive before SendMessage returns
+	c.startResponseTimer(c.ResponseTimeout())
+	if err := c.SendMessage(opcode, payload, reliable); err != nil {
+		c.stopResponseTimer()
+		return err
+	}
+	return nil
+}
+
+// IsResponseExpected returns true while the exchange waits for the
+// response to SendMessageExpectResponse.
+func (c *ExchangeContext) IsResponseExpected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.responseTimer != nil
+}
+
+// startResponseTimer arms the response timer, replacing a running one.
+func (c *ExchangeContext) startResponseTimer(timeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.manager == nil {
+		return
+	}
+	if c.responseTimer != nil {
+		c.responseTimer.Stop()
+	}
+	c.responseGen++
+	gen := c.responseGen
+	c.responseTimer = c.manager.clock.AfterFunc(timeout, func() {
+		c.onResponseTimeout(gen)
+	})
+}
+
+// stopResponseTimer stops the response timer, as the response arrived or
+// the exchange is gone.
+func (c *ExchangeContext) stopResponseTimer() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.responseTimer != nil {
+		c.responseTimer.Stop()
+		c.responseTimer = nil
+	}
+	c.responseGen++
+}
+
+// onResponseTimeout is called when the response timer of generation gen
+// expires. It notifies the delegate and closes the exchange.
+func (c *ExchangeContext) onResponseTimeout(gen uint64) {
+	c.mu.Lock()
+	if gen != c.responseGen || c.State == ExchangeStateClosed {
+		c.mu.Unlock()
+		return
+	}
+	c.responseTimer = nil
+	delegate := c.delegate
+	c.mu.Unlock()
+
+	if d, ok := delegate.(ResponseTimeoutDelegate); ok {
+		d.OnResponseTimeout(c)
+	}
+	_ = c.Close()
+}
+
 // Close initiates exchange closure.
 // Per Spec 4.10.5.3:
 //  1. Flush pending acknowledgements (send standalone ACK if needed)
@@ -338,6 +453,21 @@ func (c *ExchangeContext) onRetransmitComplete() {
 	c.mu.Unlock()
 }
 
+// touch records a message sent or received at now.
+func (c *ExchangeContext) touch(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastActivity = now
+}
+
+// isIdle returns true if the exchange has had no message for timeout and
+// awaits neither an acknowledgement nor a response.
+func (c *ExchangeContext) isIdle(now time.Time, timeout time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return now.Sub(c.lastActivity) >= timeout && !c.hasPendingRetransmit && c.responseTimer == nil
+}
+
 // HasDelegate returns true if this exchange has a delegate set.
 func (c *ExchangeContext) HasDelegate() bool {
 	c.mu.Lock()
@@ -345,6 +475,13 @@ func (c *ExchangeContext) HasDelegate() bool {
 	return c.delegate != nil
 }
 
+// protocolHandler returns the handler that accepted the exchange.
+func (c *ExchangeContext) protocolHandler() ProtocolHandler {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.handler
+}
+
 // handleMessage processes an incoming message on this exchange.
 // Called by Manager after MRP processing.
 func (c *ExchangeContext) handleMessage(proto *message.ProtocolHeader, payload []byte) ([]byte, error) {
@@ -1,13 +1,18 @@
 package exchange
 
 import (
+	"bytes"
 	"net"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/backkem/matter/pkg/clock"
+	"github.com/backkem/matter/pkg/fabric"
 	"github.com/backkem/matter/pkg/message"
+	"github.com/backkem/matter/pkg/metrics"
+	"github.com/backkem/matter/pkg/securechannel"
 	"github.com/backkem/matter/pkg/session"
 	"github.com/backkem/matter/pkg/transport"
 )
@@ -572,6 +577,451 @@ func TestE2E_PacketLoss_ScriptedDrop(t *testing.T) {
 	t.Logf("Delivered %d packets", delivered)
 }
 
+// TestE2E_Metrics verifies sent and retransmitted messages are counted.
+func TestE2E_Metrics(t *testing.T) {
+	// Packets are never processed, so the reliable message is never acked
+	f0, f1 := transport.NewPipeFactoryPairWithConfig(transport.PipeConfig{
+		AutoProcess: false,
+	})
+	defer f0.Pipe().Close()
+
+	conn0, _ := f0.CreateUDPConn(5540)
+	_, _ = f1.CreateUDPConn(5540)
+
+	mgr0, err := createTestTransportManager(conn0, noopHandler)
+	if err != nil {
+		t.Fatalf("CreateTransportManager: %v", err)
+	}
+
+	rec := metrics.NewRecorder()
+	exchMgr := NewManager(ManagerConfig{
+		TransportManager: mgr0,
+		Metrics:          rec,
+	})
+	defer exchMgr.Close()
+
+	sess := newTestSession(1, 2)
+	peerAddr := transport.NewUDPPeerAddress(f1.LocalAddr())
+	ctx, err := exchMgr.NewExchange(sess, sess.sessionID, peerAddr, message.ProtocolSecureChannel, nil)
+	if err != nil {
+		t.Fatalf("NewExchange: %v", err)
+	}
+
+	if err := ctx.SendMessage(0x01, []byte("unacked"), true); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for rec.Value(metrics.MRPRetransmits) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	retransmits := rec.Value(metrics.MRPRetransmits)
+	if retransmits == 0 {
+		t.Fatal("expected at least one retransmission")
+	}
+	if got := rec.Value(metrics.MessagesSent, metrics.L(metrics.LabelSession, "secure")); got < retransmits+1 {
+		t.Errorf("messages sent = %v, want at least %v", got, retransmits+1)
+	}
+}
+
+// TestE2E_PeerActiveRetransmitInterval verifies that MRP retransmits to a
+// peer after its ActiveInterval while it is active, i.e. a message was
+// received from it within its ActiveThreshold, and after its IdleInterval
+// otherwise.
+func TestE2E_PeerActiveRetransmitInterval(t *testing.T) {
+	params := session.Params{
+		IdleInterval:    500 * time.Millisecond,
+		ActiveInterval:  100 * time.Millisecond,
+		ActiveThreshold: time.Second,
+	}
+	// The first retransmission of the active interval, with margin and
+	// jitter, is due by 137.5ms; that of the idle interval not before 550ms.
+	const wait = 140 * time.Millisecond
+
+	for _, active := range []bool{true, false} {
+		f0, f1 := transport.NewPipeFactoryPairWithConfig(transport.PipeConfig{
+			AutoProcess: false,
+		})
+		conn0, _ := f0.CreateUDPConn(5540)
+		_, _ = f1.CreateUDPConn(5540)
+		mgr0, err := createTestTransportManager(conn0, noopHandler)
+		if err != nil {
+			t.Fatalf("CreateTransportManager: %v", err)
+		}
+
+		clk := clock.NewFakeClock(time.Unix(0, 0))
+		rec := metrics.NewRecorder()
+		exchMgr := NewManager(ManagerConfig{
+			TransportManager: mgr0,
+			Metrics:          rec,
+			Clock:            clk,
+		})
+
+		local, _ := session.NewSecureContext(session.SecureContextConfig{
+			SessionType:    session.SessionTypePASE,
+			Role:           session.SessionRoleInitiator,
+			LocalSessionID: 1,
+			PeerSessionID:  2,
+			I2RKey:         make([]byte, session.SessionKeySize),
+			R2IKey:         make([]byte, session.SessionKeySize),
+			Params:         params,
+			Clock:          clk,
+		})
+		peer, _ := session.NewSecureContext(session.SecureContextConfig{
+			SessionType:    session.SessionTypePASE,
+			Role:           session.SessionRoleResponder,
+			LocalSessionID: 2,
+			PeerSessionID:  1,
+			I2RKey:         make([]byte, session.SessionKeySize),
+			R2IKey:         make([]byte, session.SessionKeySize),
+		})
+
+		clk.Advance(params.ActiveThreshold)
+		if active {
+			data, _ := peer.Encrypt(&message.MessageHeader{}, &message.ProtocolHeader{}, nil, false)
+			if _, err := local.Decrypt(data); err != nil {
+				t.Fatalf("Decrypt: %v", err)
+			}
+		}
+
+		peerAddr := transport.NewUDPPeerAddress(f1.LocalAddr())
+		ctx, err := exchMgr.NewExchange(local, local.LocalSessionID(), peerAddr, message.ProtocolSecureChannel, nil)
+		if err != nil {
+			t.Fatalf("NewExchange: %v", err)
+		}
+		if err := ctx.SendMessage(0x01, []byte("unacked"), true); err != nil {
+			t.Fatalf("SendMessage: %v", err)
+		}
+
+		clk.Advance(wait)
+		deadline := time.Now().Add(200 * time.Millisecond)
+		for rec.Value(metrics.MRPRetransmits) == 0 && active && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if got := rec.Value(metrics.MRPRetransmits) > 0; got != active {
+			t.Errorf("peer active = %v: retransmitted after %v = %v, want %v", active, wait, got, active)
+		}
+
+		exchMgr.Close()
+		f0.Pipe().Close()
+	}
+}
+
+// TestE2E_GroupMessage verifies a group message is sent once, encrypted
+// for the group and without MRP.
+func TestE2E_GroupMessage(t *testing.T) {
+	f0, f1 := transport.NewPipeFactoryPairWithConfig(transport.PipeConfig{
+		AutoProcess: false,
+	})
+	defer f0.Pipe().Close()
+
+	conn0, _ := f0.CreateUDPConn(5540)
+	conn1, _ := f1.CreateUDPConn(5540)
+
+	mgr0, err := createTestTransportManager(conn0, noopHandler)
+	if err != nil {
+		t.Fatalf("CreateTransportManager: %v", err)
+	}
+	received := make(chan []byte, 4)
+	mgr1, err := createTestTransportManager(conn1, func(msg *transport.ReceivedMessage) {
+		received <- bytes.Clone(msg.Data)
+	})
+	if err != nil {
+		t.Fatalf("CreateTransportManager: %v", err)
+	}
+	if err := mgr1.JoinGroup(1, 0x0101); err != nil {
+		t.Fatalf("JoinGroup: %v", err)
+	}
+	if err := mgr1.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer mgr1.Stop()
+
+	rec := metrics.NewRecorder()
+	exchMgr := NewManager(ManagerConfig{
+		TransportManager: mgr0,
+		Metrics:          rec,
+	})
+	defer exchMgr.Close()
+
+	key := make([]byte, session.SessionKeySize)
+	group, err := session.NewGroupContext(session.GroupContextConfig{
+		SourceNodeID:   0x1234,
+		FabricIndex:    1,
+		GroupID:        0x0101,
+		GroupSessionID: 0x5A5A,
+		OperationalKey: key,
+		Counter:        message.NewMessageCounter(),
+	})
+	if err != nil {
+		t.Fatalf("NewGroupContext: %v", err)
+	}
+
+	peerAddr := transport.NewUDPPeerAddress(f1.LocalAddr())
+	if err := exchMgr.SendGroupMessage(group, peerAddr, message.ProtocolInteractionModel, 0x08, []byte("group")); err != nil {
+		t.Fatalf("SendGroupMessage: %v", err)
+	}
+	if n := f0.Pipe().Process(); n != 1 {
+		t.Fatalf("delivered %d packets, want 1", n)
+	}
+
+	var data []byte
+	select {
+	case data = <-received:
+	case <-time.After(time.Second):
+		t.Fatal("group message not received")
+	}
+
+	frame, err := group.Decrypt(data)
+	if err != nil {
+		t.Fatalf("Decrypt: %v", err)
+	}
+	if frame.Header.DestinationGroupID != 0x0101 {
+		t.Errorf("DestinationGroupID = 0x%04x, want 0x0101", frame.Header.DestinationGroupID)
+	}
+	if frame.Protocol.Reliability || !frame.Protocol.Initiator {
+		t.Errorf("protocol flags R=%v I=%v, want R=false I=true", frame.Protocol.Reliability, frame.Protocol.Initiator)
+	}
+	if string(frame.Payload) != "group" {
+		t.Errorf("payload = %q, want %q", frame.Payload, "group")
+	}
+
+	if exchMgr.ExchangeCount() != 0 {
+		t.Errorf("ExchangeCount() = %d, want 0", exchMgr.ExchangeCount())
+	}
+	if got := rec.Value(metrics.MessagesSent, metrics.L(metrics.LabelSession, "group")); got != 1 {
+		t.Errorf("group messages sent = %v, want 1", got)
+	}
+}
+
+// groupHandler records the group messages handed to a protocol handler.
+type groupHandler struct {
+	messages chan *ExchangeContext
+	opcode   uint8
+	sendErr  error
+}
+
+func (h *groupHandler) OnMessage(ctx *ExchangeContext, opcode uint8, payload []byte) ([]byte, error) {
+	return nil, nil
+}
+
+func (h *groupHandler) OnUnsolicited(ctx *ExchangeContext, opcode uint8, payload []byte) ([]byte, error) {
+	h.opcode = opcode
+	h.sendErr = ctx.SendMessage(opcode, payload, false)
+	h.messages <- ctx
+	return []byte("ignored"), nil
+}
+
+// TestE2E_GroupMessage_Receive verifies a group message is decrypted with
+// the group's key and dispatched once, without an exchange or a response.
+func TestE2E_GroupMessage_Receive(t *testing.T) {
+	f0, f1 := transport.NewPipeFactoryPair()
+	defer f0.Pipe().Close()
+
+	conn0, _ := f0.CreateUDPConn(5540)
+	conn1, _ := f1.CreateUDPConn(5540)
+
+	mgr0, err := createTestTransportManager(conn0, noopHandler)
+	if err != nil {
+		t.Fatalf("CreateTransportManager: %v", err)
+	}
+	if err := mgr0.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer mgr0.Stop()
+	sender := NewManager(ManagerConfig{TransportManager: mgr0})
+	defer sender.Close()
+
+	var receiver *Manager
+	mgr1, err := createTestTransportManager(conn1, func(msg *transport.ReceivedMessage) {
+		receiver.OnMessageReceived(msg)
+	})
+	if err != nil {
+		t.Fatalf("CreateTransportManager: %v", err)
+	}
+	sessions := session.NewManager(session.ManagerConfig{})
+	rec := metrics.NewRecorder()
+	receiver = NewManager(ManagerConfig{
+		TransportManager: mgr1,
+		SessionManager:   sessions,
+		Metrics:          rec,
+	})
+	defer receiver.Close()
+	handler := &groupHandler{messages: make(chan *ExchangeContext, 4)}
+	receiver.RegisterProtocol(message.ProtocolInteractionModel, handler)
+	if err := mgr1.JoinGroup(1, 0x0101); err != nil {
+		t.Fatalf("JoinGroup: %v", err)
+	}
+	if err := mgr1.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer mgr1.Stop()
+
+	key := make([]byte, session.SessionKeySize)
+	if err := sessions.AddGroupKey(session.GroupKey{
+		FabricIndex:    1,
+		GroupID:        0x0101,
+		GroupSessionID: 0x5A5A,
+		OperationalKey: key,
+	}); err != nil {
+		t.Fatalf("AddGroupKey: %v", err)
+	}
+	group, err := session.NewGroupContext(session.GroupContextConfig{
+		SourceNodeID:   0x1234,
+		FabricIndex:    1,
+		GroupID:        0x0101,
+		GroupSessionID: 0x5A5A,
+		OperationalKey: key,
+		Counter:        message.NewMessageCounter(),
+	})
+	if err != nil {
+		t.Fatalf("NewGroupContext: %v", err)
+	}
+
+	peerAddr := transport.NewGroupPeerAddress(1, 0x0101)
+	if err := sender.SendGroupMessage(group, peerAddr, message.ProtocolInteractionModel, 0x08, []byte("group")); err != nil {
+		t.Fatalf("SendGroupMessage: %v", err)
+	}
+
+	var ctx *ExchangeContext
+	select {
+	case ctx = <-handler.messages:
+	case <-time.After(time.Second):
+		t.Fatal("group message not dispatched")
+	}
+	received, ok := ctx.Session().(*session.GroupContext)
+	if !ok {
+		t.Fatalf("Session() = %T, want *session.GroupContext", ctx.Session())
+	}
+	if received.GroupID() != 0x0101 || received.SourceNodeID() != 0x1234 || received.FabricIndex() != 1 {
+		t.Errorf("group = 0x%04x from 0x%x on fabric %d, want 0x0101 from 0x1234 on fabric 1",
+			received.GroupID(), received.SourceNodeID(), received.FabricIndex())
+	}
+	if handler.sendErr != ErrGroupResponse {
+		t.Errorf("SendMessage() error = %v, want %v", handler.sendErr, ErrGroupResponse)
+	}
+	if receiver.ExchangeCount() != 0 {
+		t.Errorf("ExchangeCount() = %d, want 0", receiver.ExchangeCount())
+	}
+	if got := rec.Value(metrics.MessagesReceived, metrics.L(metrics.LabelSession, "group")); got != 1 {
+		t.Errorf("group messages received = %v, want 1", got)
+	}
+
+	// Messages for groups not joined are dropped
+	other, err := session.NewGroupContext(session.GroupContextConfig{
+		SourceNodeID:   0x1234,
+		FabricIndex:    1,
+		GroupID:        0x0202,
+		GroupSessionID: 0x5A5A,
+		OperationalKey: key,
+		Counter:        message.NewMessageCounter(),
+	})
+	if err != nil {
+		t.Fatalf("NewGroupContext: %v", err)
+	}
+	if err := sender.SendGroupMessage(other, transport.NewGroupPeerAddress(1, 0x0202), message.ProtocolInteractionModel, 0x08, nil); err != nil {
+		t.Fatalf("SendGroupMessage: %v", err)
+	}
+	select {
+	case <-handler.messages:
+		t.Error("message for another group dispatched")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// TestE2E_GroupControlMessage_CounterSync verifies a group control message
+// from an unsynchronized peer is dropped and starts counter
+// synchronization, after which the peer's control messages are dispatched.
+func TestE2E_GroupControlMessage_CounterSync(t *testing.T) {
+	f0, f1 := transport.NewPipeFactoryPair()
+	defer f0.Pipe().Close()
+
+	conn0, _ := f0.CreateUDPConn(5540)
+	conn1, _ := f1.CreateUDPConn(5540)
+
+	key := make([]byte, session.SessionKeySize)
+	newNode := func(conn net.PacketConn, nodeID fabric.NodeID) (*Manager, *session.Manager, *transport.Manager) {
+		var exch *Manager
+		mgr, err := createTestTransportManager(conn, func(msg *transport.ReceivedMessage) {
+			exch.OnMessageReceived(msg)
+		})
+		if err != nil {
+			t.Fatalf("CreateTransportManager: %v", err)
+		}
+		sessions := session.NewManager(session.ManagerConfig{})
+		if err := sessions.AddGroupKey(session.GroupKey{
+			FabricIndex:    1,
+			GroupID:        0x0101,
+			GroupSessionID: 0x5A5A,
+			OperationalKey: key,
+			LocalNodeID:    nodeID,
+		}); err != nil {
+			t.Fatalf("AddGroupKey: %v", err)
+		}
+		exch = NewManager(ManagerConfig{
+			TransportManager: mgr,
+			SessionManager:   sessions,
+			CounterSync:      securechannel.NewCounterSync(securechannel.CounterSyncConfig{SessionManager: sessions}),
+		})
+		if err := mgr.JoinGroup(1, 0x0101); err != nil {
+			t.Fatalf("JoinGroup: %v", err)
+		}
+		if err := mgr.Start(); err != nil {
+			t.Fatalf("Start: %v", err)
+		}
+		return exch, sessions, mgr
+	}
+
+	sender, senderSessions, mgr0 := newNode(conn0, 0x1234)
+	defer sender.Close()
+	defer mgr0.Stop()
+	receiver, _, mgr1 := newNode(conn1, 0x5678)
+	defer receiver.Close()
+	defer mgr1.Stop()
+	handler := &groupHandler{messages: make(chan *ExchangeContext, 4)}
+	receiver.RegisterProtocol(message.ProtocolInteractionModel, handler)
+
+	group, err := session.NewGroupContext(session.GroupContextConfig{
+		SourceNodeID:   0x1234,
+		FabricIndex:    1,
+		GroupID:        0x0101,
+		GroupSessionID: 0x5A5A,
+		OperationalKey: key,
+		Counter:        senderSessions.GroupControlCounter(),
+		Control:        true,
+	})
+	if err != nil {
+		t.Fatalf("NewGroupContext: %v", err)
+	}
+	peerAddr := transport.NewUDPPeerAddress(f1.LocalAddr())
+	if err := sender.SendGroupMessage(group, peerAddr, message.ProtocolInteractionModel, 0x08, []byte("first")); err != nil {
+		t.Fatalf("SendGroupMessage: %v", err)
+	}
+
+	// The first message is dropped; later ones are dispatched once the
+	// counters are synchronized
+	deadline := time.After(2 * time.Second)
+	for dispatched := 0; dispatched == 0; {
+		select {
+		case ctx := <-handler.messages:
+			if received := ctx.Session().(*session.GroupContext); received.SourceNodeID() != 0x1234 {
+				t.Errorf("SourceNodeID() = 0x%x, want 0x1234", received.SourceNodeID())
+			}
+			if handler.opcode != 0x09 {
+				t.Errorf("dispatched opcode 0x%02x, want the first message dropped", handler.opcode)
+			}
+			dispatched++
+		case <-time.After(20 * time.Millisecond):
+			if err := sender.SendGroupMessage(group, peerAddr, message.ProtocolInteractionModel, 0x09, []byte("next")); err != nil {
+				t.Fatalf("SendGroupMessage: %v", err)
+			}
+		case <-deadline:
+			t.Fatal("control message not dispatched after synchronization")
+		}
+	}
+}
+
 // TestE2E_NetworkCondition_DropRate tests behavior under packet loss.
 func TestE2E_NetworkCondition_DropRate(t *testing.T) {
 	if testing.Short() {
@@ -727,6 +1177,83 @@ func TestE2E_ExchangeClose(t *testing.T) {
 	t.Log("Exchange close lifecycle correct")
 }
 
+// closeDelegate counts the exchanges closed under it.
+type closeDelegate struct {
+	closed int32
+}
+
+func (d *closeDelegate) OnMessage(ctx *ExchangeContext, header *message.ProtocolHeader, payload []byte) ([]byte, error) {
+	return nil, nil
+}
+
+func (d *closeDelegate) OnClose(ctx *ExchangeContext) {
+	atomic.AddInt32(&d.closed, 1)
+}
+
+// TestE2E_AbortSessionExchanges verifies that closing a session closes its
+// exchanges and only those.
+func TestE2E_AbortSessionExchanges(t *testing.T) {
+	f0, _ := transport.NewPipeFactoryPair()
+	defer f0.Pipe().Close()
+
+	conn0, _ := f0.CreateUDPConn(5540)
+	mgr0, err := createTestTransportManager(conn0, noopHandler)
+	if err != nil {
+		t.Fatalf("CreateTransportManager: %v", err)
+	}
+
+	exchMgr := NewManager(ManagerConfig{
+		TransportManager: mgr0,
+	})
+	peerAddr := transport.NewUDPPeerAddress(f0.PeerAddr())
+
+	closing := newTestSession(1, 2)
+	other := newTestSession(3, 4)
+	delegate := &closeDelegate{}
+	var contexts []*ExchangeContext
+	for range 2 {
+		ctx, err := exchMgr.NewExchange(closing, closing.sessionID, peerAddr, message.ProtocolSecureChannel, delegate)
+		if err != nil {
+			t.Fatalf("NewExchange: %v", err)
+		}
+		contexts = append(contexts, ctx)
+	}
+	kept, err := exchMgr.NewExchange(other, other.sessionID, peerAddr, message.ProtocolSecureChannel, delegate)
+	if err != nil {
+		t.Fatalf("NewExchange: %v", err)
+	}
+
+	if n := exchMgr.SessionExchangeCount(closing.sessionID); n != 2 {
+		t.Errorf("SessionExchangeCount() = %d, want 2", n)
+	}
+	if addr, ok := exchMgr.SessionPeerAddress(closing.sessionID); !ok || addr != peerAddr {
+		t.Errorf("SessionPeerAddress() = %v, %v, want %v", addr, ok, peerAddr)
+	}
+
+	exchMgr.AbortSessionExchanges(closing.sessionID)
+
+	if _, ok := exchMgr.SessionPeerAddress(closing.sessionID); ok {
+		t.Error("closed session should have no peer address")
+	}
+	for _, ctx := range contexts {
+		if !ctx.IsClosed() {
+			t.Error("exchange on the closed session should be closed")
+		}
+	}
+	if kept.IsClosed() {
+		t.Error("exchange on another session should stay open")
+	}
+	if n := atomic.LoadInt32(&delegate.closed); n != 2 {
+		t.Errorf("OnClose calls = %d, want 2", n)
+	}
+	if exchMgr.ExchangeCount() != 1 {
+		t.Errorf("ExchangeCount() = %d, want 1", exchMgr.ExchangeCount())
+	}
+	if n := exchMgr.SessionExchangeCount(closing.sessionID); n != 0 {
+		t.Errorf("SessionExchangeCount() = %d after abort, want 0", n)
+	}
+}
+
 // TestE2E_MultipleExchanges verifies concurrent exchanges work correctly.
 func TestE2E_MultipleExchanges(t *testing.T) {
 	f0, f1 := transport.NewPipeFactoryPair()
@@ -956,3 +1483,489 @@ func TestE2E_Bidirectional(t *testing.T) {
 
 	t.Log("Bidirectional exchange communication successful!")
 }
+
+// recordingDelegate passes the messages of an exchange to a channel.
+type recordingDelegate struct {
+	messages chan message.ProtocolHeader
+	payloads chan []byte
+}
+
+func newRecordingDelegate() *recordingDelegate {
+	return &recordingDelegate{
+		messages: make(chan message.ProtocolHeader, 10),
+		payloads: make(chan []byte, 10),
+	}
+}
+
+func (d *recordingDelegate) OnMessage(ctx *ExchangeContext, header *message.ProtocolHeader, payload []byte) ([]byte, error) {
+	d.messages <- *header
+	d.payloads <- append([]byte(nil), payload...)
+	return nil, nil
+}
+
+func (d *recordingDelegate) OnClose(ctx *ExchangeContext) {}
+
+// addSecureSessions adds a secure session between the managers of the
+// pair, which unlike the pair's test sessions carries responses back to
+// the sender. Manager 0 reaches it with local session ID 1.
+func addSecureSessions(t *testing.T, pair *TestManagerPair, clk clock.Clock) [2]*session.SecureContext {
+	t.Helper()
+	var sessions [2]*session.SecureContext
+	for i, role := range []session.SessionRole{session.SessionRoleInitiator, session.SessionRoleResponder} {
+		var err error
+		sessions[i], err = session.NewSecureContext(session.SecureContextConfig{
+			SessionType:    session.SessionTypePASE,
+			Role:           role,
+			LocalSessionID: uint16(i + 1),
+			PeerSessionID:  uint16(2 - i),
+			I2RKey:         make([]byte, session.SessionKeySize),
+			R2IKey:         make([]byte, session.SessionKeySize),
+			Clock:          clk,
+		})
+		if err != nil {
+			t.Fatalf("NewSecureContext: %v", err)
+		}
+		if err := pair.SessionManager(i).AddSecureContext(sessions[i]); err != nil {
+			t.Fatalf("AddSecureContext: %v", err)
+		}
+	}
+	return sessions
+}
+
+// TestE2E_UnsolicitedHandlers verifies that handlers sharing a protocol
+// each receive the unsolicited messages of their opcodes, and that a
+// message no handler claims is rejected with an Unsupported status report.
+func TestE2E_UnsolicitedHandlers(t *testing.T) {
+	pair, err := NewTestManagerPair(TestManagerPairConfig{})
+	if err != nil {
+		t.Fatalf("NewTestManagerPair: %v", err)
+	}
+	defer pair.Close()
+
+	low := make(chan ReceivedMessage, 10)
+	high := make(chan ReceivedMessage, 10)
+	receiver := pair.Manager(1)
+	if err := receiver.RegisterUnsolicitedHandler(message.ProtocolForTesting, Opcodes(0x01, 0x0F),
+		&TestProtocolHandler{onReceive: func(msg ReceivedMessage) { low <- msg }}); err != nil {
+		t.Fatalf("RegisterUnsolicitedHandler: %v", err)
+	}
+	if err := receiver.RegisterUnsolicitedHandler(message.ProtocolForTesting, Opcode(0x10),
+		&TestProtocolHandler{onReceive: func(msg ReceivedMessage) { high <- msg }}); err != nil {
+		t.Fatalf("RegisterUnsolicitedHandler: %v", err)
+	}
+	if err := receiver.RegisterUnsolicitedHandler(message.ProtocolForTesting, Opcode(0x05), &TestProtocolHandler{}); err != ErrHandlerRegistered {
+		t.Errorf("RegisterUnsolicitedHandler(claimed opcode) error = %v, want %v", err, ErrHandlerRegistered)
+	}
+
+	sessions := addSecureSessions(t, pair, nil)
+
+	send := func(protocolID message.ProtocolID, opcode uint8) *recordingDelegate {
+		t.Helper()
+		delegate := newRecordingDelegate()
+		ctx, err := pair.Manager(0).NewExchange(sessions[0], 1, pair.PeerAddress(1, false), protocolID, delegate)
+		if err != nil {
+			t.Fatalf("NewExchange: %v", err)
+		}
+		if err := ctx.SendMessage(opcode, []byte{opcode}, true); err != nil {
+			t.Fatalf("SendMessage: %v", err)
+		}
+		return delegate
+	}
+	wait := func(ch chan ReceivedMessage, opcode uint8) {
+		t.Helper()
+		select {
+		case msg := <-ch:
+			if msg.Opcode != opcode || !msg.Unsolicited {
+				t.Errorf("received opcode 0x%02x (unsolicited %v), want unsolicited 0x%02x", msg.Opcode, msg.Unsolicited, opcode)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("opcode 0x%02x not dispatched", opcode)
+		}
+	}
+
+	send(message.ProtocolForTesting, 0x03)
+	wait(low, 0x03)
+	send(message.ProtocolForTesting, 0x10)
+	wait(high, 0x10)
+
+	for _, tt := range []struct {
+		name       string
+		protocolID message.ProtocolID
+		opcode     uint8
+	}{
+		{"unclaimed opcode", message.ProtocolForTesting, 0x20},
+		{"unknown protocol", message.ProtocolBDX, 0x01},
+	} {
+		delegate := send(tt.protocolID, tt.opcode)
+		select {
+		case header := <-delegate.messages:
+			payload := <-delegate.payloads
+			if header.ProtocolID != message.ProtocolSecureChannel || header.ProtocolOpcode != uint8(securechannel.OpcodeStatusReport) {
+				t.Fatalf("%s: response protocol 0x%04x opcode 0x%02x, want a status report", tt.name, uint16(header.ProtocolID), header.ProtocolOpcode)
+			}
+			if !header.Acknowledgement {
+				t.Errorf("%s: status report does not acknowledge the message", tt.name)
+			}
+			status, err := securechannel.DecodeStatusReport(payload)
+			if err != nil || status.GeneralCode != securechannel.GeneralCodeUnsupported {
+				t.Errorf("%s: status = %+v, %v, want Unsupported", tt.name, status, err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s: no status report", tt.name)
+		}
+	}
+
+	// Once unregistered, the opcodes are rejected too
+	if err := receiver.UnregisterUnsolicitedHandler(message.ProtocolForTesting, Opcode(0x10)); err != nil {
+		t.Fatalf("UnregisterUnsolicitedHandler: %v", err)
+	}
+	delegate := send(message.ProtocolForTesting, 0x10)
+	select {
+	case header := <-delegate.messages:
+		if header.ProtocolOpcode != uint8(securechannel.OpcodeStatusReport) {
+			t.Errorf("response opcode 0x%02x, want a status report", header.ProtocolOpcode)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no status report after unregistering")
+	}
+	if err := receiver.UnregisterUnsolicitedHandler(message.ProtocolForTesting, Opcode(0x10)); err != ErrNoHandler {
+		t.Errorf("UnregisterUnsolicitedHandler(again) error = %v, want %v", err, ErrNoHandler)
+	}
+}
+
+// echoHandler answers every message with its payload.
+type echoHandler struct{}
+
+func (echoHandler) OnMessage(ctx *ExchangeContext, opcode uint8, payload []byte) ([]byte, error) {
+	return payload, nil
+}
+
+func (echoHandler) OnUnsolicited(ctx *ExchangeContext, opcode uint8, payload []byte) ([]byte, error) {
+	return payload, nil
+}
+
+// timeoutDelegate records response timeouts and the close of its exchange.
+type timeoutDelegate struct {
+	*recordingDelegate
+	timeouts chan struct{}
+	closed   chan struct{}
+}
+
+func newTimeoutDelegate() *timeoutDelegate {
+	return &timeoutDelegate{
+		recordingDelegate: newRecordingDelegate(),
+		timeouts:          make(chan struct{}, 1),
+		closed:            make(chan struct{}),
+	}
+}
+
+func (d *timeoutDelegate) OnResponseTimeout(ctx *ExchangeContext) {
+	d.timeouts <- struct{}{}
+}
+
+func (d *timeoutDelegate) OnClose(ctx *ExchangeContext) {
+	close(d.closed)
+}
+
+// TestE2E_ResponseTimeout verifies that an exchange awaiting a response
+// notifies its delegate and closes once the response timeout passes, and
+// that a response stops the timer.
+func TestE2E_ResponseTimeout(t *testing.T) {
+	clk := clock.NewFakeClock(time.Unix(0, 0))
+	pair, err := NewTestManagerPair(TestManagerPairConfig{Clock: clk})
+	if err != nil {
+		t.Fatalf("NewTestManagerPair: %v", err)
+	}
+	defer pair.Close()
+
+	silent := make(chan ReceivedMessage, 10)
+	receiver := pair.Manager(1)
+	if err := receiver.RegisterUnsolicitedHandler(message.ProtocolForTesting, Opcode(0x01),
+		&TestProtocolHandler{onReceive: func(msg ReceivedMessage) { silent <- msg }}); err != nil {
+		t.Fatalf("RegisterUnsolicitedHandler: %v", err)
+	}
+	if err := receiver.RegisterUnsolicitedHandler(message.ProtocolForTesting, Opcode(0x02), echoHandler{}); err != nil {
+		t.Fatalf("RegisterUnsolicitedHandler: %v", err)
+	}
+	sessions := addSecureSessions(t, pair, clk)
+
+	newExchange := func(delegate ExchangeDelegate) *ExchangeContext {
+		t.Helper()
+		ctx, err := pair.Manager(0).NewExchange(sessions[0], 1, pair.PeerAddress(1, false), message.ProtocolForTesting, delegate)
+		if err != nil {
+			t.Fatalf("NewExchange: %v", err)
+		}
+		return ctx
+	}
+
+	// The default covers both messages with all their retransmissions
+	delegate := newTimeoutDelegate()
+	ctx := newExchange(delegate)
+	params := sessions[0].GetParams()
+	baseInterval := params.IdleInterval
+	if sessions[0].IsPeerActive() {
+		baseInterval = params.ActiveInterval
+	}
+	if got, want := ctx.ResponseTimeout(), 2*mrpTransmitWindow(baseInterval)+ExpectedProcessingTime; got != want {
+		t.Errorf("default ResponseTimeout() = %v, want %v", got, want)
+	}
+
+	// No response
+	ctx.SetResponseTimeout(time.Second)
+	if err := ctx.SendMessageExpectResponse(0x01, []byte("ping"), true); err != nil {
+		t.Fatalf("SendMessageExpectResponse: %v", err)
+	}
+	select {
+	case <-silent:
+	case <-time.After(time.Second):
+		t.Fatal("request not received")
+	}
+	clk.Advance(time.Second - time.Millisecond)
+	select {
+	case <-delegate.timeouts:
+		t.Fatal("response timeout before it was due")
+	default:
+	}
+	if !ctx.IsResponseExpected() {
+		t.Error("IsResponseExpected() = false while waiting")
+	}
+	clk.Advance(time.Millisecond)
+	select {
+	case <-delegate.timeouts:
+	case <-time.After(time.Second):
+		t.Fatal("OnResponseTimeout not called")
+	}
+	select {
+	case <-delegate.closed:
+	case <-time.After(time.Second):
+		t.Fatal("exchange not closed after the response timeout")
+	}
+
+	// A response stops the timer
+	delegate = newTimeoutDelegate()
+	ctx = newExchange(delegate)
+	ctx.SetResponseTimeout(time.Second)
+	if err := ctx.SendMessageExpectResponse(0x02, []byte("echo"), true); err != nil {
+		t.Fatalf("SendMessageExpectResponse: %v", err)
+	}
+	select {
+	case <-delegate.messages:
+	case <-time.After(time.Second):
+		t.Fatal("response not received")
+	}
+	if ctx.IsResponseExpected() {
+		t.Error("IsResponseExpected() = true after the response")
+	}
+	clk.Advance(2 * time.Second)
+	select {
+	case <-delegate.timeouts:
+		t.Error("OnResponseTimeout called after the response")
+	default:
+	}
+	if ctx.IsClosed() {
+		t.Error("exchange closed after the response")
+	}
+}
+
+// TestE2E_ExchangeLimits verifies that unsolicited exchanges beyond
+// MaxExchanges are refused with Busy, and that idle responder exchanges
+// are closed to make room.
+func TestE2E_ExchangeLimits(t *testing.T) {
+	clk := clock.NewFakeClock(time.Unix(0, 0))
+	pair, err := NewTestManagerPair(TestManagerPairConfig{Clock: clk})
+	if err != nil {
+		t.Fatalf("NewTestManagerPair: %v", err)
+	}
+	defer pair.Close()
+
+	const idleTimeout = time.Minute
+	rec := metrics.NewRecorder()
+	exhausted := make(chan transport.PeerAddress, 1)
+	receiver := pair.Manager(1)
+	receiver.maxExchanges = 1
+	receiver.idleTimeout = idleTimeout
+	receiver.metrics = rec
+	receiver.config.OnExchangesExhausted = func(peerAddress transport.PeerAddress) {
+		exhausted <- peerAddress
+	}
+
+	received := make(chan ReceivedMessage, 10)
+	if err := receiver.RegisterUnsolicitedHandler(message.ProtocolForTesting, AllOpcodes,
+		&TestProtocolHandler{onReceive: func(msg ReceivedMessage) { received <- msg }}); err != nil {
+		t.Fatalf("RegisterUnsolicitedHandler: %v", err)
+	}
+	sessions := addSecureSessions(t, pair, clk)
+
+	send := func() *recordingDelegate {
+		t.Helper()
+		delegate := newRecordingDelegate()
+		ctx, err := pair.Manager(0).NewExchange(sessions[0], 1, pair.PeerAddress(1, false), message.ProtocolForTesting, delegate)
+		if err != nil {
+			t.Fatalf("NewExchange: %v", err)
+		}
+		if err := ctx.SendMessage(0x01, []byte("hello"), true); err != nil {
+			t.Fatalf("SendMessage: %v", err)
+		}
+		return delegate
+	}
+	waitReceived := func() {
+		t.Helper()
+		select {
+		case <-received:
+		case <-time.After(time.Second):
+			t.Fatal("message not received")
+		}
+	}
+
+	// The first exchange is held open by the silent handler
+	send()
+	waitReceived()
+
+	// The second is refused
+	delegate := send()
+	select {
+	case header := <-delegate.messages:
+		status, err := securechannel.DecodeStatusReport(<-delegate.payloads)
+		if header.ProtocolOpcode != uint8(securechannel.OpcodeStatusReport) || err != nil || !status.IsBusy() {
+			t.Fatalf("response opcode 0x%02x, status %+v, %v, want Busy", header.ProtocolOpcode, status, err)
+		}
+		if got := time.Duration(status.BusyWaitTime()) * time.Millisecond; got != BusyWaitTime {
+			t.Errorf("BusyWaitTime() = %v, want %v", got, BusyWaitTime)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no status report for the refused exchange")
+	}
+	select {
+	case <-exhausted:
+	case <-time.After(time.Second):
+		t.Error("OnExchangesExhausted not called")
+	}
+	if got := rec.Value(metrics.ExchangesRefused); got != 1 {
+		t.Errorf("%s = %v, want 1", metrics.ExchangesRefused, got)
+	}
+	if _, err := receiver.NewExchange(sessions[1], 2, pair.PeerAddress(0, false), message.ProtocolForTesting, nil); err != ErrTooManyExchanges {
+		t.Errorf("NewExchange() with a full table error = %v, want %v", err, ErrTooManyExchanges)
+	}
+
+	// The idle exchange is closed once the idle timeout passes
+	clk.Advance(idleTimeout - time.Millisecond)
+	if n := receiver.ExchangeCount(); n != 1 {
+		t.Fatalf("ExchangeCount() before the idle timeout = %d, want 1", n)
+	}
+	clk.Advance(time.Millisecond)
+	if n := receiver.ExchangeCount(); n != 0 {
+		t.Fatalf("ExchangeCount() after the idle timeout = %d, want 0", n)
+	}
+	if got := rec.Value(metrics.ExchangesReaped); got != 1 {
+		t.Errorf("%s = %v, want 1", metrics.ExchangesReaped, got)
+	}
+
+	// Which makes room for a new one
+	send()
+	waitReceived()
+}
+
+// recordingConn is a net.PacketConn that records the destination of each
+// packet written and receives nothing.
+type recordingConn struct {
+	mu     sync.Mutex
+	dsts   []string
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newRecordingConn() *recordingConn {
+	return &recordingConn{closed: make(chan struct{})}
+}
+
+func (c *recordingConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	<-c.closed
+	return 0, nil, net.ErrClosed
+}
+
+func (c *recordingConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	c.mu.Lock()
+	c.dsts = append(c.dsts, addr.String())
+	c.mu.Unlock()
+	return len(b), nil
+}
+
+func (c *recordingConn) destinations() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.dsts...)
+}
+
+func (c *recordingConn) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return nil
+}
+
+func (c *recordingConn) LocalAddr() net.Addr {
+	return &net.UDPAddr{IP: net.IPv6unspecified, Port: transport.DefaultPort}
+}
+
+func (c *recordingConn) SetDeadline(t time.Time) error      { return nil }
+func (c *recordingConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *recordingConn) SetWriteDeadline(t time.Time) error { return nil }
+
+// TestE2E_LinkLocalRetransmit verifies that MRP retransmits a message to
+// the link-local peer address it was first sent to, zone included, when
+// the same fe80:: address is reached through several interfaces.
+func TestE2E_LinkLocalRetransmit(t *testing.T) {
+	conn := newRecordingConn()
+	mgr, err := createTestTransportManager(conn, noopHandler)
+	if err != nil {
+		t.Fatalf("CreateTransportManager: %v", err)
+	}
+	defer mgr.Stop()
+	if err := mgr.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	clk := clock.NewFakeClock(time.Unix(0, 0))
+	rec := metrics.NewRecorder()
+	exchMgr := NewManager(ManagerConfig{
+		TransportManager: mgr,
+		Metrics:          rec,
+		Clock:            clk,
+	})
+	defer exchMgr.Close()
+
+	zones := []string{"eth0", "wpan0"}
+	for i, zone := range zones {
+		peerAddr, err := transport.NewScopedUDPPeerAddress(net.ParseIP("fe80::1"), transport.DefaultPort, zone)
+		if err != nil {
+			t.Fatalf("NewScopedUDPPeerAddress: %v", err)
+		}
+		sess := newTestSession(uint16(i+1), uint16(i+10))
+		ctx, err := exchMgr.NewExchange(sess, sess.LocalSessionID(), peerAddr, message.ProtocolForTesting, nil)
+		if err != nil {
+			t.Fatalf("NewExchange: %v", err)
+		}
+		if err := ctx.SendMessage(0x01, []byte("unacked"), true); err != nil {
+			t.Fatalf("SendMessage: %v", err)
+		}
+	}
+
+	// Past the first retransmission of both
+	clk.Advance(time.Second)
+	deadline := time.Now().Add(time.Second)
+	for rec.Value(metrics.MRPRetransmits) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	sent := make(map[string]int)
+	for _, dst := range conn.destinations() {
+		sent[dst]++
+	}
+	for _, zone := range zones {
+		dst := "[fe80::1%" + zone + "]:5540"
+		if sent[dst] < 2 {
+			t.Errorf("%d packets sent to %s, want the message and a retransmission", sent[dst], dst)
+		}
+	}
+	if len(sent) != len(zones) {
+		t.Errorf("packets sent to %v, want only the scoped peer addresses", sent)
+	}
+}
@@ -13,9 +13,21 @@ var (
 	// ErrNoHandler is returned when no protocol handler is registered for a message.
 	ErrNoHandler = errors.New("exchange: no handler registered for protocol")
 
+	// ErrHandlerRegistered is returned when registering a handler for an
+	// opcode another handler of the protocol claims.
+	ErrHandlerRegistered = errors.New("exchange: handler already registered for opcode")
+
+	// ErrInvalidOpcodeRange is returned for an opcode range whose first
+	// opcode follows its last.
+	ErrInvalidOpcodeRange = errors.New("exchange: invalid opcode range")
+
 	// ErrExchangeExists is returned when trying to create a duplicate exchange.
 	ErrExchangeExists = errors.New("exchange: exchange already exists")
 
+	// ErrTooManyExchanges is returned when an exchange is refused because
+	// the manager's MaxExchanges are open.
+	ErrTooManyExchanges = errors.New("exchange: too many exchanges")
+
 	// ErrExchangeNotFound is returned when an exchange cannot be found.
 	ErrExchangeNotFound = errors.New("exchange: exchange not found")
 
@@ -39,6 +51,10 @@ var (
 	// ErrInvalidMessage is returned for malformed or invalid messages.
 	ErrInvalidMessage = errors.New("exchange: invalid message")
 
+	// ErrGroupResponse is returned when sending on the exchange of a
+	// received group message, which has no peer to respond to.
+	ErrGroupResponse = errors.New("exchange: cannot respond to a group message")
+
 	// ErrUnsolicitedNotInitiator is returned for unsolicited messages without I flag.
 	ErrUnsolicitedNotInitiator = errors.New("exchange: unsolicited message must have I flag set")
 )
@@ -0,0 +1,90 @@
+package exchange
+
+import (
+	"github.com/backkem/matter/pkg/message"
+)
+
+// OpcodeRange is an inclusive range of protocol opcodes a handler claims.
+type OpcodeRange struct {
+	First uint8
+	Last  uint8
+}
+
+// AllOpcodes claims every opcode of a protocol.
+var AllOpcodes = OpcodeRange{First: 0x00, Last: 0xFF}
+
+// Opcodes returns the range from first to last, inclusive.
+func Opcodes(first, last uint8) OpcodeRange {
+	return OpcodeRange{First: first, Last: last}
+}
+
+// Opcode returns the range holding only opcode.
+func Opcode(opcode uint8) OpcodeRange {
+	return OpcodeRange{First: opcode, Last: opcode}
+}
+
+// Contains reports whether opcode is in the range.
+func (r OpcodeRange) Contains(opcode uint8) bool {
+	return opcode >= r.First && opcode <= r.Last
+}
+
+// overlaps reports whether the ranges share an opcode.
+func (r OpcodeRange) overlaps(o OpcodeRange) bool {
+	return r.First <= o.Last && o.First <= r.Last
+}
+
+// unsolicitedHandler is a handler with the opcodes it claims.
+type unsolicitedHandler struct {
+	opcodes OpcodeRange
+	handler ProtocolHandler
+}
+
+// handlerTable maps a protocol and opcode to the handler that claims
+// them. A protocol holds few handlers, so each is kept in a slice.
+type handlerTable map[message.ProtocolID][]unsolicitedHandler
+
+// register adds a handler, failing if another claims one of its opcodes.
+func (t handlerTable) register(protocolID message.ProtocolID, opcodes OpcodeRange, handler ProtocolHandler) error {
+	if opcodes.First > opcodes.Last || handler == nil {
+		return ErrInvalidOpcodeRange
+	}
+	for _, h := range t[protocolID] {
+		if h.opcodes.overlaps(opcodes) {
+			return ErrHandlerRegistered
+		}
+	}
+	t[protocolID] = append(t[protocolID], unsolicitedHandler{opcodes: opcodes, handler: handler})
+	return nil
+}
+
+// unregister removes the handler registered for exactly opcodes.
+func (t handlerTable) unregister(protocolID message.ProtocolID, opcodes OpcodeRange) bool {
+	handlers := t[protocolID]
+	for i, h := range handlers {
+		if h.opcodes == opcodes {
+			handlers = append(handlers[:i:i], handlers[i+1:]...)
+			if len(handlers) == 0 {
+				delete(t, protocolID)
+			} else {
+				t[protocolID] = handlers
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// lookup returns the handler for a message. Only protocols of the Matter
+// namespace are registered: a vendor-specific protocol ID does not match
+// the Matter protocol of the same number.
+func (t handlerTable) lookup(proto *message.ProtocolHeader) (ProtocolHandler, bool) {
+	if proto.ProtocolVendorID != message.VendorIDMatter {
+		return nil, false
+	}
+	for _, h := range t[proto.ProtocolID] {
+		if h.opcodes.Contains(proto.ProtocolOpcode) {
+			return h.handler, true
+		}
+	}
+	return nil, false
+}
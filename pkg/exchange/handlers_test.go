@@ -0,0 +1,61 @@
+package exchange
+
+import (
+	"testing"
+
+	"github.com/backkem/matter/pkg/message"
+)
+
+func TestHandlerTable(t *testing.T) {
+	reads := &TestProtocolHandler{}
+	writes := &TestProtocolHandler{}
+	table := make(handlerTable)
+
+	if err := table.register(message.ProtocolForTesting, Opcodes(0x01, 0x0F), reads); err != nil {
+		t.Fatalf("register() error = %v", err)
+	}
+	if err := table.register(message.ProtocolForTesting, Opcode(0x10), writes); err != nil {
+		t.Fatalf("register() error = %v", err)
+	}
+	if err := table.register(message.ProtocolForTesting, Opcodes(0x0F, 0x20), writes); err != ErrHandlerRegistered {
+		t.Errorf("register(overlapping) error = %v, want %v", err, ErrHandlerRegistered)
+	}
+	if err := table.register(message.ProtocolForTesting, Opcodes(0x30, 0x20), writes); err != ErrInvalidOpcodeRange {
+		t.Errorf("register(reversed) error = %v, want %v", err, ErrInvalidOpcodeRange)
+	}
+
+	tests := []struct {
+		name   string
+		proto  message.ProtocolHeader
+		want   ProtocolHandler
+		wantOK bool
+	}{
+		{"first of range", message.ProtocolHeader{ProtocolID: message.ProtocolForTesting, ProtocolOpcode: 0x01}, reads, true},
+		{"last of range", message.ProtocolHeader{ProtocolID: message.ProtocolForTesting, ProtocolOpcode: 0x0F}, reads, true},
+		{"single opcode", message.ProtocolHeader{ProtocolID: message.ProtocolForTesting, ProtocolOpcode: 0x10}, writes, true},
+		{"unclaimed opcode", message.ProtocolHeader{ProtocolID: message.ProtocolForTesting, ProtocolOpcode: 0x11}, nil, false},
+		{"unknown protocol", message.ProtocolHeader{ProtocolID: message.ProtocolBDX, ProtocolOpcode: 0x01}, nil, false},
+		{"vendor protocol", message.ProtocolHeader{ProtocolID: message.ProtocolForTesting, ProtocolOpcode: 0x01, ProtocolVendorID: 0xFFF1}, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := table.lookup(&tt.proto)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("lookup() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+
+	if table.unregister(message.ProtocolForTesting, Opcodes(0x01, 0x02)) {
+		t.Error("unregister() of a range never registered succeeded")
+	}
+	if !table.unregister(message.ProtocolForTesting, Opcodes(0x01, 0x0F)) {
+		t.Fatal("unregister() failed")
+	}
+	if _, ok := table.lookup(&message.ProtocolHeader{ProtocolID: message.ProtocolForTesting, ProtocolOpcode: 0x01}); ok {
+		t.Error("lookup() found an unregistered handler")
+	}
+	if err := table.register(message.ProtocolForTesting, Opcodes(0x01, 0x0F), writes); err != nil {
+		t.Errorf("register() after unregister error = %v", err)
+	}
+}
@@ -1,12 +1,16 @@
 package exchange
 
 import (
+	"bytes"
 	"crypto/rand"
 	"encoding/binary"
 	"sync"
+	"time"
 
+	"github.com/backkem/matter/pkg/clock"
 	"github.com/backkem/matter/pkg/fabric"
 	"github.com/backkem/matter/pkg/message"
+	"github.com/backkem/matter/pkg/metrics"
 	"github.com/backkem/matter/pkg/securechannel"
 	"github.com/backkem/matter/pkg/session"
 	"github.com/backkem/matter/pkg/transport"
@@ -14,7 +18,8 @@ import (
 )
 
 // ProtocolHandler handles messages for a specific protocol.
-// Register handlers with Manager.RegisterProtocol().
+// Register handlers with Manager.RegisterProtocol() or, for some of a
+// protocol's opcodes, Manager.RegisterUnsolicitedHandler().
 type ProtocolHandler interface {
 	// OnMessage handles a message on an existing exchange.
 	// Returns response payload (if any) and error.
@@ -36,19 +41,62 @@ type ManagerConfig struct {
 	// LoggerFactory is the factory for creating loggers.
 	// If nil, logging is disabled.
 	LoggerFactory logging.LoggerFactory
+
+	// Metrics receives message and MRP counts.
+	// If nil, metrics are disabled.
+	Metrics metrics.Collector
+
+	// Clock runs the MRP retransmission, standalone ACK and response
+	// timers.
+	// If nil, the real clock is used.
+	Clock clock.Clock
+
+	// MaxExchanges is the most exchanges open at once. Beyond it, new
+	// exchanges are refused: NewExchange fails with ErrTooManyExchanges
+	// and unsolicited messages are answered with a Busy status report.
+	// Default: DefaultMaxExchanges
+	MaxExchanges int
+
+	// ExchangeIdleTimeout is how long a responder exchange may go without
+	// sending or receiving a message before it is closed, so that a peer
+	// that stops responding cannot hold exchanges open. Exchanges awaiting
+	// an acknowledgement or a response are left to MRP and their response
+	// timeout.
+	// Default: DefaultExchangeIdleTimeout
+	ExchangeIdleTimeout time.Duration
+
+	// OnExchangesExhausted is called with the peer's address when an
+	// exchange is refused because MaxExchanges are open.
+	// If nil, refusals are only logged and counted.
+	OnExchangesExhausted func(peerAddress transport.PeerAddress)
+
+	// CounterSync synchronizes the control counters of group peers, for
+	// group control messages.
+	// If nil, control messages from unsynchronized peers are dropped
+	// without synchronizing and counter synchronization requests are not
+	// answered.
+	CounterSync *securechannel.CounterSync
+
+	// DisableGroupLoopback stops the node from processing the group
+	// messages it sends to groups it is a member of. By default they are
+	// processed once, as if received, and the copies the multicast group
+	// loops back are dropped either way.
+	DisableGroupLoopback bool
 }
 
 // Manager coordinates message exchanges and MRP.
 // It routes messages between transport/session layers and protocol handlers.
 type Manager struct {
-	config ManagerConfig
-	log    logging.LeveledLogger
+	config  ManagerConfig
+	log     logging.LeveledLogger
+	metrics metrics.Collector
+	clock   clock.Clock
 
 	// exchanges maps {sessionID, exchangeID, role} to exchange context.
 	exchanges map[exchangeKey]*ExchangeContext
 
-	// handlers maps protocol ID to handler.
-	handlers map[message.ProtocolID]ProtocolHandler
+	// handlers maps protocol ID and opcode to handler.
+	handlers handlerTable
 
 	// ackTable tracks pending ACKs for received reliable messages.
 	ackTable *AckTable
@@ -56,6 +104,16 @@ type Manager struct {
 	// retransmitTable tracks pending retransmissions.
 	retransmitTable *RetransmitTable
 
+	// maxExchanges and idleTimeout bound the exchange table. reapTimer
+	// runs while responder exchanges are open, to close idle ones.
+	maxExchanges int
+	idleTimeout  time.Duration
+	reapTimer    clock.Timer
+
+	// peers maps secure sessions to the address their peer was last
+	// heard from or sent to.
+	peers map[uint16]transport.PeerAddress
+
 	// nextExchangeID is the next exchange ID to allocate (for initiator).
 	// Per Spec 4.10.2: First is random, subsequent increment by 1.
 	nextExchangeID uint16
@@ -65,13 +123,27 @@ type Manager struct {
 
 // NewManager creates a new exchange manager.
 func NewManager(config ManagerConfig) *Manager {
+	clk := clock.OrReal(config.Clock)
 	m := &Manager{
 		config:          config,
+		metrics:         metrics.OrNop(config.Metrics),
+		clock:           clk,
 		exchanges:       make(map[exchangeKey]*ExchangeContext),
-		handlers:        make(map[message.ProtocolID]ProtocolHandler),
-		ackTable:        NewAckTable(),
-		retransmitTable: NewRetransmitTable(),
+		peers:           make(map[uint16]transport.PeerAddress),
+		handlers:        make(handlerTable),
+		ackTable:        newAckTable(clk),
+		retransmitTable: newRetransmitTable(clk),
+		maxExchanges:    config.MaxExchanges,
+		idleTimeout:     config.ExchangeIdleTimeout,
+	}
+	if m.maxExchanges <= 0 {
+		m.maxExchanges = DefaultMaxExchanges
+	}
+	if m.idleTimeout <= 0 {
+		m.idleTimeout = DefaultExchangeIdleTimeout
 	}
+	// Messages from secure sessions are in pooled buffers
+	m.retransmitTable.release = session.ReleaseMessageBuffer
 
 	if config.LoggerFactory != nil {
 		m.log = config.LoggerFactory.NewLogger("exchange")
@@ -86,11 +158,42 @@ func NewManager(config ManagerConfig) *Manager {
 	return m
 }
 
-// RegisterProtocol registers a handler for a protocol ID.
+// RegisterProtocol registers a handler for every opcode of a protocol,
+// replacing the handlers registered for it.
 func (m *Manager) RegisterProtocol(protocolID message.ProtocolID, handler ProtocolHandler) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.handlers[protocolID] = handler
+	delete(m.handlers, protocolID)
+	_ = m.handlers.register(protocolID, AllOpcodes, handler)
+}
+
+// RegisterUnsolicitedHandler registers a handler for the unsolicited
+// messages of a protocol whose opcodes are in a range, so several handlers
+// can share a protocol. An unsolicited message starts a responder exchange
+// bound to the handler that claims its opcode, which also receives the
+// later messages of the exchange unless it sets a delegate. Returns
+// ErrHandlerRegistered if another handler claims one of the opcodes.
+//
+// An unsolicited message of a protocol and opcode no handler claims is
+// rejected with an Unsupported status report.
+//
+// Spec: Section 4.10.5.2 (unsolicited message processing)
+func (m *Manager) RegisterUnsolicitedHandler(protocolID message.ProtocolID, opcodes OpcodeRange, handler ProtocolHandler) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.handlers.register(protocolID, opcodes, handler)
+}
+
+// UnregisterUnsolicitedHandler removes the handler registered for exactly
+// the opcode range of a protocol. Existing exchanges keep their handler.
+// Returns ErrNoHandler if none is.
+func (m *Manager) UnregisterUnsolicitedHandler(protocolID message.ProtocolID, opcodes OpcodeRange) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.handlers.unregister(protocolID, opcodes) {
+		return ErrNoHandler
+	}
+	return nil
 }
 
 // NewExchange creates a new exchange as initiator.
@@ -103,6 +206,11 @@ func (m *Manager) NewExchange(
 	delegate ExchangeDelegate,
 ) (*ExchangeContext, error) {
 	m.mu.Lock()
+	if len(m.exchanges) >= m.maxExchanges {
+		m.mu.Unlock()
+		m.exchangesExhausted(peerAddress)
+		return nil, ErrTooManyExchanges
+	}
 	defer m.mu.Unlock()
 
 	// Allocate exchange ID
@@ -132,9 +240,111 @@ func (m *Manager) NewExchange(
 	})
 
 	m.exchanges[key] = ctx
+	if localSessionID != 0 {
+		m.peers[localSessionID] = peerAddress
+	}
 	return ctx, nil
 }
 
+// SendGroupMessage sends a message to the members of a group, on a new
+// exchange that expects no reply. Group messages are not acknowledged, so
+// the message is sent once, without MRP.
+//
+// If the node is itself a member of the group, the message is also
+// dispatched to the node's protocol handler, unless DisableGroupLoopback
+// is set.
+//
+// See Spec Section 4.16.2 (groupcast) and 4.12.1 (MRP applies to unicast).
+func (m *Manager) SendGroupMessage(
+	group *session.GroupContext,
+	peerAddress transport.PeerAddress,
+	protocolID message.ProtocolID,
+	opcode uint8,
+	payload []byte,
+) error {
+	proto := &message.ProtocolHeader{
+		ProtocolID:     protocolID,
+		ProtocolOpcode: opcode,
+		ExchangeID:     m.allocateExchangeID(),
+		Initiator:      true,
+	}
+
+	encoded, err := group.Encrypt(&message.MessageHeader{}, proto, payload, false)
+	if err != nil {
+		return err
+	}
+
+	if err := m.config.TransportManager.Send(encoded, peerAddress); err != nil {
+		return err
+	}
+	m.metrics.Add(metrics.MessagesSent, 1, sessionLabel(group))
+
+	if !m.config.DisableGroupLoopback {
+		m.loopbackGroupMessage(group, peerAddress, proto, payload)
+	}
+	return nil
+}
+
+// loopbackGroupMessage dispatches a group message the node sent to a
+// group it is a member of, as if received from the group. It runs on its
+// own goroutine, like received messages, so that a handler that sends
+// group messages does not reenter itself.
+func (m *Manager) loopbackGroupMessage(
+	group *session.GroupContext,
+	peerAddress transport.PeerAddress,
+	proto *message.ProtocolHeader,
+	payload []byte,
+) {
+	if m.config.SessionManager == nil {
+		return
+	}
+	key, ok := m.config.SessionManager.FindGroupKey(group.FabricIndex(), group.GroupID(), group.GroupSessionID())
+	if !ok {
+		return
+	}
+	received, err := session.NewGroupContext(session.GroupContextConfig{
+		SourceNodeID:   group.SourceNodeID(),
+		FabricIndex:    key.FabricIndex,
+		GroupID:        key.GroupID,
+		GroupSessionID: key.GroupSessionID,
+		OperationalKey: key.OperationalKey,
+		LocalNodeID:    key.LocalNodeID,
+	})
+	if err != nil {
+		return
+	}
+	frame := &message.Frame{
+		Header: message.MessageHeader{
+			SessionID:          key.GroupSessionID,
+			SessionType:        message.SessionTypeGroup,
+			SourcePresent:      true,
+			SourceNodeID:       uint64(group.SourceNodeID()),
+			DestinationType:    message.DestinationGroupID,
+			DestinationGroupID: key.GroupID,
+		},
+		Protocol: *proto,
+		Payload:  bytes.Clone(payload),
+	}
+
+	if m.log != nil {
+		m.log.Debugf("looping back group message: group=0x%04x, opcode=0x%02x", key.GroupID, proto.ProtocolOpcode)
+	}
+	go func() {
+		if err := m.dispatchGroupMessage(received, frame, peerAddress); err != nil && m.log != nil {
+			m.log.Debugf("looped back group message: %v", err)
+		}
+	}()
+}
+
+// allocateExchangeID returns the ID of a new exchange the node initiates.
+func (m *Manager) allocateExchangeID() uint16 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := m.nextExchangeID
+	m.nextExchangeID++
+	return id
+}
+
 // OnMessageReceived processes an incoming message from transport.
 // This is the main entry point for the receive path.
 //
@@ -164,6 +374,10 @@ func (m *Manager) OnMessageReceived(msg *transport.ReceivedMessage) error {
 			header.SessionID, header.SourcePresent, header.MessageCounter)
 	}
 
+	if header.SessionType == message.SessionTypeGroup {
+		return m.handleGroupMessage(msg)
+	}
+
 	// Look up session
 	var sess SessionContext
 	var frame *message.Frame
@@ -274,13 +488,187 @@ func (m *Manager) OnMessageReceived(msg *transport.ReceivedMessage) error {
 		if err != nil {
 			return err
 		}
+
+		m.mu.Lock()
+		m.peers[header.SessionID] = msg.PeerAddr
+		m.mu.Unlock()
 	}
 
 	return m.processFrame(frame, msg.PeerAddr, sess)
 }
 
+// handleGroupMessage processes a message sent to a group the node is a
+// member of. It is decrypted with the group's key and handed to the
+// protocol handler on an exchange that is not registered: group messages
+// only start exchanges, carry no acknowledgements and get no response.
+//
+// Spec: Section 4.16.3 (group message reception)
+func (m *Manager) handleGroupMessage(msg *transport.ReceivedMessage) error {
+	group, frame, err := m.config.SessionManager.DecryptGroupMessage(msg.Data)
+	if err == session.ErrOwnGroupMessage {
+		// The multicast group looped our message back; it was
+		// dispatched when sent
+		if m.log != nil {
+			m.log.Tracef("dropping own group message from %v", msg.PeerAddr)
+		}
+		return nil
+	}
+	unsynchronized := err == session.ErrGroupCounterNotSynchronized
+	if err != nil && !unsynchronized {
+		if m.log != nil {
+			m.log.Debugf("dropping group message: %v", err)
+		}
+		return err
+	}
+
+	proto := &frame.Protocol
+	if unsynchronized && !isCounterSyncMessage(proto) {
+		return m.requestCounterSync(group, msg.PeerAddr)
+	}
+	m.metrics.Add(metrics.MessagesReceived, 1, sessionLabel(group))
+
+	if isCounterSyncMessage(proto) {
+		// Counter synchronization messages are accepted from
+		// unsynchronized peers: the challenge makes them fresh
+		return m.handleCounterSync(group, frame, msg.PeerAddr)
+	}
+	return m.dispatchGroupMessage(group, frame, msg.PeerAddr)
+}
+
+// dispatchGroupMessage hands a decrypted group message to its protocol
+// handler.
+func (m *Manager) dispatchGroupMessage(group *session.GroupContext, frame *message.Frame, peerAddr transport.PeerAddress) error {
+	proto := &frame.Protocol
+	if !proto.Initiator || proto.Reliability {
+		// Spec 4.12.1: MRP is not used for group messages
+		if m.log != nil {
+			m.log.Warnf("dropping group message with initiator=%v, reliability=%v", proto.Initiator, proto.Reliability)
+		}
+		return ErrInvalidMessage
+	}
+
+	m.mu.RLock()
+	handler, hasHandler := m.handlers.lookup(proto)
+	m.mu.RUnlock()
+	if !hasHandler {
+		return ErrNoHandler
+	}
+
+	if m.log != nil {
+		m.log.Debugf("dispatching group message: group=0x%04x, source=0x%016x, protocolID=0x%04x (%s), opcode=0x%02x",
+			group.GroupID(), uint64(group.SourceNodeID()), uint16(proto.ProtocolID), proto.ProtocolID.String(), proto.ProtocolOpcode)
+	}
+
+	ctx := NewExchangeContext(ExchangeContextConfig{
+		ID:             proto.ExchangeID,
+		Role:           ExchangeRoleResponder,
+		ProtocolID:     proto.ProtocolID,
+		LocalSessionID: frame.Header.SessionID,
+		Session:        group,
+		PeerAddress:    peerAddr,
+		Manager:        m,
+	})
+	response, err := handler.OnUnsolicited(ctx, proto.ProtocolOpcode, frame.Payload)
+	if response != nil && m.log != nil {
+		m.log.Debugf("dropping response to group message: opcode=0x%02x", proto.ProtocolOpcode)
+	}
+	return err
+}
+
+// isCounterSyncMessage reports whether a message belongs to the Message
+// Counter Synchronization Protocol.
+func isCounterSyncMessage(proto *message.ProtocolHeader) bool {
+	if proto.ProtocolID != message.ProtocolSecureChannel {
+		return false
+	}
+	opcode := securechannel.Opcode(proto.ProtocolOpcode)
+	return opcode == securechannel.OpcodeMsgCounterSyncReq || opcode == securechannel.OpcodeMsgCounterSyncResp
+}
+
+// requestCounterSync drops a group control message from a peer whose
+// control counter is not synchronized, and asks the peer for its counter
+// unless a request is pending. The message is not processed later: it was
+// sent with a counter the synchronized one covers. The peer's later
+// control messages are accepted once the response arrives.
+//
+// Spec: Section 4.6 (message counter synchronization)
+func (m *Manager) requestCounterSync(group *session.GroupContext, peerAddr transport.PeerAddress) error {
+	cs := m.config.CounterSync
+	if cs == nil {
+		if m.log != nil {
+			m.log.Debugf("dropping group control message from unsynchronized peer 0x%016x", uint64(group.SourceNodeID()))
+		}
+		return session.ErrGroupCounterNotSynchronized
+	}
+
+	req, err := cs.Request(group.FabricIndex(), group.SourceNodeID())
+	if err != nil || req == nil {
+		return err
+	}
+	if m.log != nil {
+		m.log.Debugf("synchronizing control counter of group peer 0x%016x", uint64(group.SourceNodeID()))
+	}
+	return m.sendCounterSync(group, peerAddr, &message.ProtocolHeader{
+		ProtocolID:     message.ProtocolSecureChannel,
+		ProtocolOpcode: uint8(securechannel.OpcodeMsgCounterSyncReq),
+		ExchangeID:     m.allocateExchangeID(),
+		Initiator:      true,
+	}, req)
+}
+
+// handleCounterSync processes a MsgCounterSyncReq or MsgCounterSyncRsp
+// received with a group key. A request is answered with the node's group
+// control counter; a response synchronizes the peer's counter.
+func (m *Manager) handleCounterSync(group *session.GroupContext, frame *message.Frame, peerAddr transport.PeerAddress) error {
+	cs := m.config.CounterSync
+	if cs == nil {
+		return ErrNoHandler
+	}
+
+	proto := &frame.Protocol
+	switch securechannel.Opcode(proto.ProtocolOpcode) {
+	case securechannel.OpcodeMsgCounterSyncReq:
+		resp, err := cs.HandleRequest(frame.Payload)
+		if err != nil {
+			return err
+		}
+		return m.sendCounterSync(group, peerAddr, &message.ProtocolHeader{
+			ProtocolID:     message.ProtocolSecureChannel,
+			ProtocolOpcode: uint8(securechannel.OpcodeMsgCounterSyncResp),
+			ExchangeID:     proto.ExchangeID,
+		}, resp)
+
+	default:
+		err := cs.HandleResponse(group.FabricIndex(), group.SourceNodeID(), frame.Payload)
+		if err != nil && m.log != nil {
+			m.log.Debugf("dropping counter sync response from 0x%016x: %v", uint64(group.SourceNodeID()), err)
+		}
+		return err
+	}
+}
+
+// sendCounterSync sends a counter synchronization message to the sender
+// of a group message, with the group's key.
+func (m *Manager) sendCounterSync(group *session.GroupContext, peerAddr transport.PeerAddress, proto *message.ProtocolHeader, payload []byte) error {
+	reply, err := m.config.SessionManager.ControlReplyContext(group)
+	if err != nil {
+		return err
+	}
+	encoded, err := reply.EncryptTo(group.SourceNodeID(), proto, payload)
+	if err != nil {
+		return err
+	}
+	if err := m.config.TransportManager.Send(encoded, peerAddr); err != nil {
+		return err
+	}
+	m.metrics.Add(metrics.MessagesSent, 1, sessionLabel(reply))
+	return nil
+}
+
 // processFrame handles a decoded frame.
 func (m *Manager) processFrame(frame *message.Frame, peerAddr transport.PeerAddress, sess SessionContext) error {
+	m.metrics.Add(metrics.MessagesReceived, 1, sessionLabel(sess))
+
 	proto := &frame.Protocol
 
 	// Determine our role: if I flag set, sender is initiator, we are responder
@@ -331,6 +719,10 @@ func (m *Manager) processFrame(frame *message.Frame, peerAddr transport.PeerAddr
 		m.scheduleAck(ctx, frame.Header.MessageCounter)
 	}
 
+	// The peer responded
+	ctx.stopResponseTimer()
+	ctx.touch(m.clock.Now())
+
 	// Dispatch to exchange or protocol handler
 	var response []byte
 	var err error
@@ -340,12 +732,16 @@ func (m *Manager) processFrame(frame *message.Frame, peerAddr transport.PeerAddr
 		response, err = ctx.handleMessage(proto, frame.Payload)
 	} else {
 		// For responder exchanges created from unsolicited messages,
-		// route subsequent messages through the protocol handler
-		m.mu.RLock()
-		handler, hasHandler := m.handlers[proto.ProtocolID]
-		m.mu.RUnlock()
+		// route subsequent messages through the protocol handler that
+		// accepted the exchange
+		handler := ctx.protocolHandler()
+		if handler == nil {
+			m.mu.RLock()
+			handler, _ = m.handlers.lookup(proto)
+			m.mu.RUnlock()
+		}
 
-		if hasHandler {
+		if handler != nil {
 			response, err = handler.OnMessage(ctx, proto.ProtocolOpcode, frame.Payload)
 		}
 	}
@@ -382,8 +778,9 @@ func (m *Manager) handleUnsolicited(
 
 	// Per Spec 4.10.5.2:
 	// 1. If I flag set + registered protocol → create exchange
-	// 2. If R flag set → send standalone ACK, drop
-	// 3. Otherwise → drop
+	// 2. If I flag set + unknown protocol → reject with status
+	// 3. If R flag set → send standalone ACK, drop
+	// 4. Otherwise → drop
 
 	if !proto.Initiator {
 		// Not from initiator - check if needs ACK
@@ -398,7 +795,7 @@ func (m *Manager) handleUnsolicited(
 
 	// Check for registered protocol handler
 	m.mu.RLock()
-	handler, hasHandler := m.handlers[proto.ProtocolID]
+	handler, hasHandler := m.handlers.lookup(&proto)
 	numHandlers := len(m.handlers)
 	m.mu.RUnlock()
 
@@ -408,13 +805,12 @@ func (m *Manager) handleUnsolicited(
 	}
 
 	if !hasHandler {
-		// No handler - send ACK if requested, then drop
 		if m.log != nil {
-			m.log.Warnf("no handler registered for protocol 0x%04x (%s)", uint16(proto.ProtocolID), proto.ProtocolID.String())
-		}
-		if proto.Reliability {
-			m.sendStandaloneAckForUnsolicited(frame, peerAddr, sess)
+			m.log.Warnf("no handler registered for protocol 0x%04x (%s), opcode 0x%02x",
+				uint16(proto.ProtocolID), proto.ProtocolID.String(), proto.ProtocolOpcode)
 		}
+		unsupported := securechannel.NewSecureChannelStatusReport(securechannel.GeneralCodeUnsupported, securechannel.ProtocolCodeGeneralFailure)
+		m.rejectUnsolicited(frame, peerAddr, sess, key, unsupported)
 		return ErrNoHandler
 	}
 
@@ -430,9 +826,18 @@ func (m *Manager) handleUnsolicited(
 		PeerAddress:    peerAddr,
 		Manager:        m,
 	})
+	ctx.handler = handler
+	ctx.lastActivity = m.clock.Now()
 
 	m.mu.Lock()
+	if len(m.exchanges) >= m.maxExchanges {
+		m.mu.Unlock()
+		m.exchangesExhausted(peerAddr)
+		m.rejectUnsolicited(frame, peerAddr, sess, key, securechannel.Busy(uint16(BusyWaitTime.Milliseconds())))
+		return ErrTooManyExchanges
+	}
 	m.exchanges[key] = ctx
+	m.armReaperLocked()
 	m.mu.Unlock()
 
 	// Schedule ACK if reliable
@@ -463,6 +868,44 @@ func (m *Manager) handleUnsolicited(
 	return nil
 }
 
+// rejectUnsolicited answers an unsolicited message that starts no exchange
+// with a status report, Unsupported if no handler claims it or Busy if the
+// exchange table is full. The message is acknowledged if it asked to be,
+// on a responder exchange closed right after.
+func (m *Manager) rejectUnsolicited(
+	frame *message.Frame,
+	peerAddr transport.PeerAddress,
+	sess SessionContext,
+	key exchangeKey,
+	status *securechannel.StatusReport,
+) {
+	ctx := NewExchangeContext(ExchangeContextConfig{
+		ID:             frame.Protocol.ExchangeID,
+		Role:           ExchangeRoleResponder,
+		ProtocolID:     message.ProtocolSecureChannel,
+		LocalSessionID: key.localSessionID,
+		Session:        sess,
+		PeerAddress:    peerAddr,
+		Manager:        m,
+	})
+
+	m.mu.Lock()
+	if _, exists := m.exchanges[key]; exists {
+		m.mu.Unlock()
+		return
+	}
+	m.exchanges[key] = ctx
+	m.mu.Unlock()
+
+	if frame.Protocol.Reliability {
+		m.scheduleAck(ctx, frame.Header.MessageCounter)
+	}
+	if err := ctx.SendMessage(uint8(securechannel.OpcodeStatusReport), status.Encode(), false); err != nil && m.log != nil {
+		m.log.Debugf("failed to reject unsolicited message: %v", err)
+	}
+	_ = ctx.Close()
+}
+
 // handleReceivedAck processes an incoming ACK.
 func (m *Manager) handleReceivedAck(ackedCounter uint32) {
 	entry := m.retransmitTable.Ack(ackedCounter)
@@ -575,6 +1018,9 @@ func (m *Manager) sendMessage(ctx *ExchangeContext, proto *message.ProtocolHeade
 	if sess == nil {
 		return ErrSessionNotFound
 	}
+	if _, isGroup := sess.(*session.GroupContext); isGroup {
+		return ErrGroupResponse
+	}
 
 	// Check for pending ACK to piggyback
 	// For unsecured sessions, don't piggyback - always use standalone ACKs
@@ -591,6 +1037,7 @@ func (m *Manager) sendMessage(ctx *ExchangeContext, proto *message.ProtocolHeade
 		key := ctx.GetKey()
 		m.ackTable.MarkAcked(key)
 	}
+	ctx.touch(m.clock.Now())
 
 	return m.sendMessageInternal(ctx, proto, payload)
 }
@@ -621,32 +1068,39 @@ func (m *Manager) sendMessageInternal(ctx *ExchangeContext, proto *message.Proto
 		return err
 	}
 
-	// Track for retransmission if reliable
-	if proto.Reliability {
-		peerAddr := ctx.PeerAddress()
-		params := sess.GetParams()
+	// Send unreliable messages via transport, then release their buffer
+	peerAddr := ctx.PeerAddress()
+	if !proto.Reliability {
+		err = m.send(encoded, peerAddr, sess)
+		session.ReleaseMessageBuffer(encoded)
+		return err
+	}
 
-		// Determine base interval (idle vs active)
-		baseInterval := params.IdleInterval
-		if secureSession.IsPeerActive() {
-			baseInterval = params.ActiveInterval
-		}
+	// Track reliable messages for retransmission
+	params := sess.GetParams()
 
-		key := ctx.GetKey()
-		err = m.retransmitTable.Add(key, header.MessageCounter, encoded, peerAddr, baseInterval,
-			func(entry *RetransmitEntry) {
-				m.onRetransmitTimeout(entry)
-			})
-		if err != nil {
-			return err
-		}
+	// Determine base interval (idle vs active)
+	baseInterval := params.IdleInterval
+	if secureSession.IsPeerActive() {
+		baseInterval = params.ActiveInterval
+	}
 
-		ctx.SetPendingRetransmit(header.MessageCounter)
+	key := ctx.GetKey()
+	entry, err := m.retransmitTable.addSending(key, header.MessageCounter, encoded, peerAddr, baseInterval,
+		func(entry *RetransmitEntry) {
+			m.onRetransmitTimeout(entry)
+		})
+	if err != nil {
+		session.ReleaseMessageBuffer(encoded)
+		return err
 	}
 
-	// Send via transport
-	peerAddr := ctx.PeerAddress()
-	return m.config.TransportManager.Send(encoded, peerAddr)
+	ctx.SetPendingRetransmit(header.MessageCounter)
+
+	// Send via transport; the table releases the buffer once acknowledged
+	err = m.send(encoded, peerAddr, sess)
+	m.retransmitTable.sendDone(entry)
+	return err
 }
 
 // onRetransmitTimeout handles retransmission timer expiry.
@@ -680,14 +1134,37 @@ func (m *Manager) onRetransmitTimeout(entry *RetransmitEntry) {
 	}
 
 	// Schedule retransmit
-	if !m.retransmitTable.ScheduleRetransmit(entry.MessageCounter, baseInterval) {
+	if !m.retransmitTable.scheduleSend(entry, baseInterval) {
 		// Max retries exceeded
+		m.metrics.Add(metrics.MRPDeliveryFailures, 1)
 		ctx.onRetransmitComplete()
 		return
 	}
 
-	// Retransmit the message
-	_ = m.config.TransportManager.Send(entry.Message, entry.PeerAddress)
+	// Retransmit the message, counted once it is handed to the transport
+	_ = m.send(entry.Message, entry.PeerAddress, sess)
+	m.retransmitTable.sendDone(entry)
+	m.metrics.Add(metrics.MRPRetransmits, 1)
+}
+
+// send hands an encoded message to the transport and counts it.
+func (m *Manager) send(encoded []byte, peerAddr transport.PeerAddress, sess SessionContext) error {
+	if err := m.config.TransportManager.Send(encoded, peerAddr); err != nil {
+		return err
+	}
+	m.metrics.Add(metrics.MessagesSent, 1, sessionLabel(sess))
+	return nil
+}
+
+// sessionLabel returns the metrics label for a session.
+func sessionLabel(sess SessionContext) metrics.Label {
+	if _, ok := sess.(SecureSessionContext); ok {
+		return metrics.L(metrics.LabelSession, "secure")
+	}
+	if _, ok := sess.(*session.GroupContext); ok {
+		return metrics.L(metrics.LabelSession, "group")
+	}
+	return metrics.L(metrics.LabelSession, "unsecured")
 }
 
 // removeExchange removes an exchange from the manager.
@@ -698,9 +1175,10 @@ func (m *Manager) removeExchange(ctx *ExchangeContext) {
 	delete(m.exchanges, key)
 	m.mu.Unlock()
 
-	// Clean up tables
+	// Clean up tables and the response timer
 	m.ackTable.Remove(key)
 	m.retransmitTable.Remove(key)
+	ctx.stopResponseTimer()
 
 	// Notify delegate
 	if delegate := ctx.GetDelegate(); delegate != nil {
@@ -771,7 +1249,7 @@ func (m *Manager) sendUnsecuredMessage(ctx *ExchangeContext, sess SessionContext
 		baseInterval := params.IdleInterval
 
 		key := ctx.GetKey()
-		err = m.retransmitTable.Add(key, counter, encoded, peerAddr, baseInterval,
+		entry, err := m.retransmitTable.addSending(key, counter, encoded, peerAddr, baseInterval,
 			func(entry *RetransmitEntry) {
 				m.onRetransmitTimeout(entry)
 			})
@@ -780,11 +1258,12 @@ func (m *Manager) sendUnsecuredMessage(ctx *ExchangeContext, sess SessionContext
 		}
 
 		ctx.SetPendingRetransmit(counter)
+		defer m.retransmitTable.sendDone(entry)
 	}
 
 	// Send via transport
 	peerAddr := ctx.PeerAddress()
-	return m.config.TransportManager.Send(encoded, peerAddr)
+	return m.send(encoded, peerAddr, sess)
 }
 
 // GetExchange returns an exchange by key, if it exists.
@@ -802,6 +1281,98 @@ func (m *Manager) GetExchange(localSessionID, exchangeID uint16, role ExchangeRo
 	return ctx, exists
 }
 
+// SessionPeerAddress returns the address the peer of a secure session was
+// last heard from, or last sent to on a new exchange.
+func (m *Manager) SessionPeerAddress(localSessionID uint16) (transport.PeerAddress, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	addr, ok := m.peers[localSessionID]
+	return addr, ok
+}
+
+// AbortSessionExchanges closes the exchanges of a secure session that was
+// removed, e.g. after the peer sent CloseSession. Pending acknowledgements
+// and retransmissions are dropped, as the session can no longer carry
+// them, and each delegate is notified with OnClose.
+func (m *Manager) AbortSessionExchanges(localSessionID uint16) {
+	m.mu.Lock()
+	delete(m.peers, localSessionID)
+	var aborted []*ExchangeContext
+	for key, ctx := range m.exchanges {
+		if key.localSessionID == localSessionID {
+			aborted = append(aborted, ctx)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, ctx := range aborted {
+		m.abortExchange(ctx)
+	}
+}
+
+// abortExchange closes an exchange at once, dropping its pending
+// acknowledgement and retransmission.
+func (m *Manager) abortExchange(ctx *ExchangeContext) {
+	ctx.mu.Lock()
+	ctx.State = ExchangeStateClosed
+	ctx.mu.Unlock()
+	m.removeExchange(ctx)
+}
+
+// exchangesExhausted reports an exchange refused because the exchange
+// table is full.
+func (m *Manager) exchangesExhausted(peerAddr transport.PeerAddress) {
+	m.metrics.Add(metrics.ExchangesRefused, 1)
+	if m.log != nil {
+		m.log.Warnf("exchange table full (%d exchanges), refused exchange with %s", m.maxExchanges, peerAddr)
+	}
+	if m.config.OnExchangesExhausted != nil {
+		m.config.OnExchangesExhausted(peerAddr)
+	}
+}
+
+// armReaperLocked starts the idle exchange reaper if it is not running.
+// m.mu must be held.
+func (m *Manager) armReaperLocked() {
+	if m.reapTimer == nil {
+		m.reapTimer = m.clock.AfterFunc(m.idleTimeout/2, m.reapIdleExchanges)
+	}
+}
+
+// reapIdleExchanges closes the responder exchanges idle for longer than
+// the idle timeout. It runs every half of the timeout while responder
+// exchanges are open.
+func (m *Manager) reapIdleExchanges() {
+	now := m.clock.Now()
+
+	m.mu.Lock()
+	m.reapTimer = nil
+	var idle []*ExchangeContext
+	responders := 0
+	for key, ctx := range m.exchanges {
+		if key.role != ExchangeRoleResponder {
+			continue
+		}
+		if ctx.isIdle(now, m.idleTimeout) {
+			idle = append(idle, ctx)
+		} else {
+			responders++
+		}
+	}
+	if responders > 0 {
+		m.armReaperLocked()
+	}
+	m.mu.Unlock()
+
+	for _, ctx := range idle {
+		if m.log != nil {
+			m.log.Debugf("closing idle exchange %d", ctx.ID)
+		}
+		m.metrics.Add(metrics.ExchangesReaped, 1)
+		m.abortExchange(ctx)
+	}
+}
+
 // ExchangeCount returns the number of active exchanges.
 func (m *Manager) ExchangeCount() int {
 	m.mu.RLock()
@@ -809,6 +1380,20 @@ func (m *Manager) ExchangeCount() int {
 	return len(m.exchanges)
 }
 
+// SessionExchangeCount returns the number of active exchanges on a
+// session.
+func (m *Manager) SessionExchangeCount(localSessionID uint16) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	n := 0
+	for key := range m.exchanges {
+		if key.localSessionID == localSessionID {
+			n++
+		}
+	}
+	return n
+}
+
 // Close shuts down the manager and all exchanges.
 func (m *Manager) Close() {
 	m.mu.Lock()
@@ -816,6 +1401,10 @@ func (m *Manager) Close() {
 	for _, ctx := range m.exchanges {
 		exchanges = append(exchanges, ctx)
 	}
+	if m.reapTimer != nil {
+		m.reapTimer.Stop()
+		m.reapTimer = nil
+	}
 	m.mu.Unlock()
 
 	// Close all exchanges
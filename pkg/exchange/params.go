@@ -41,3 +41,18 @@ const (
 // Per Spec 4.10.5.2: "A node SHOULD limit itself to a maximum of 5 concurrent
 // exchanges over a unicast session" to prevent exhausting the message counter window.
 const MaxConcurrentExchanges = 5
+
+// Exchange table limits.
+const (
+	// DefaultMaxExchanges is the default limit of exchanges open at once,
+	// over all sessions.
+	DefaultMaxExchanges = 64
+
+	// DefaultExchangeIdleTimeout is the default time a responder exchange
+	// may go without a message before it is closed.
+	DefaultExchangeIdleTimeout = 2 * time.Minute
+
+	// BusyWaitTime is the minimum wait a peer is asked for when its
+	// exchange is refused with a Busy status report.
+	BusyWaitTime = time.Second
+)
@@ -4,6 +4,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/backkem/matter/pkg/clock"
 	"github.com/backkem/matter/pkg/transport"
 )
 
@@ -34,10 +35,16 @@ type RetransmitEntry struct {
 	SendCount int
 
 	// Timer for retransmission timeout.
-	timer *time.Timer
+	timer clock.Timer
 
 	// callback is invoked when retransmission timer expires.
 	callback func()
+
+	// sending counts the transmissions of Message in progress, and removed
+	// is set once the entry leaves the table. Message is released when
+	// both allow it. Guarded by the table's lock.
+	sending int
+	removed bool
 }
 
 // Stop cancels the retransmission timer if running.
@@ -63,15 +70,29 @@ type RetransmitTable struct {
 	// backoff calculates retransmission timeouts.
 	backoff *BackoffCalculator
 
+	// clock runs the retransmission timers.
+	clock clock.Clock
+
+	// release, if set, is handed each message once it is no longer
+	// retransmitted or being sent.
+	release func([]byte)
+
 	mu sync.Mutex
 }
 
 // NewRetransmitTable creates a new retransmission table.
 func NewRetransmitTable() *RetransmitTable {
+	return newRetransmitTable(clock.Real{})
+}
+
+// newRetransmitTable creates a retransmission table whose timers run on
+// clk.
+func newRetransmitTable(clk clock.Clock) *RetransmitTable {
 	return &RetransmitTable{
 		entries:    make(map[uint32]*RetransmitEntry),
 		byExchange: make(map[exchangeKey]*RetransmitEntry),
 		backoff:    NewBackoffCalculator(nil),
+		clock:      clk,
 	}
 }
 
@@ -95,12 +116,38 @@ func (t *RetransmitTable) Add(
 	baseInterval time.Duration,
 	onTimeout func(entry *RetransmitEntry),
 ) error {
+	_, err := t.add(key, messageCounter, message, peerAddress, baseInterval, onTimeout, false)
+	return err
+}
+
+// addSending is like Add, for a message whose initial transmission
+// follows. The caller calls sendDone once the message has been sent.
+func (t *RetransmitTable) addSending(
+	key exchangeKey,
+	messageCounter uint32,
+	message []byte,
+	peerAddress transport.PeerAddress,
+	baseInterval time.Duration,
+	onTimeout func(entry *RetransmitEntry),
+) (*RetransmitEntry, error) {
+	return t.add(key, messageCounter, message, peerAddress, baseInterval, onTimeout, true)
+}
+
+func (t *RetransmitTable) add(
+	key exchangeKey,
+	messageCounter uint32,
+	message []byte,
+	peerAddress transport.PeerAddress,
+	baseInterval time.Duration,
+	onTimeout func(entry *RetransmitEntry),
+	sending bool,
+) (*RetransmitEntry, error) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
 	// Check for existing entry on this exchange
 	if _, exists := t.byExchange[key]; exists {
-		return ErrPendingRetransmit
+		return nil, ErrPendingRetransmit
 	}
 
 	// Create entry
@@ -111,12 +158,15 @@ func (t *RetransmitTable) Add(
 		PeerAddress:    peerAddress,
 		SendCount:      1, // Initial transmission
 	}
+	if sending {
+		entry.sending = 1
+	}
 
 	// Calculate initial backoff
 	backoffTime := t.backoff.Calculate(baseInterval, 0)
 
 	// Start timer
-	entry.timer = time.AfterFunc(backoffTime, func() {
+	entry.timer = t.clock.AfterFunc(backoffTime, func() {
 		if onTimeout != nil {
 			onTimeout(entry)
 		}
@@ -130,7 +180,7 @@ func (t *RetransmitTable) Add(
 	t.entries[messageCounter] = entry
 	t.byExchange[key] = entry
 
-	return nil
+	return entry, nil
 }
 
 // Ack removes an entry when acknowledgement received.
@@ -144,9 +194,7 @@ func (t *RetransmitTable) Ack(messageCounter uint32) *RetransmitEntry {
 		return nil
 	}
 
-	entry.Stop()
-	delete(t.entries, messageCounter)
-	delete(t.byExchange, entry.ExchangeKey)
+	t.removeLocked(entry)
 
 	return entry
 }
@@ -168,15 +216,31 @@ func (t *RetransmitTable) ScheduleRetransmit(
 	if !ok {
 		return false
 	}
+	return t.scheduleLocked(entry, baseInterval)
+}
+
+// scheduleSend is like ScheduleRetransmit for entry, whose retransmission
+// follows. The caller calls sendDone once the message has been sent.
+func (t *RetransmitTable) scheduleSend(entry *RetransmitEntry, baseInterval time.Duration) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if entry.removed || !t.scheduleLocked(entry, baseInterval) {
+		return false
+	}
+	entry.sending++
+	return true
+}
 
+// scheduleLocked schedules the next retransmission of entry, or removes
+// it once max retransmissions are exceeded.
+func (t *RetransmitTable) scheduleLocked(entry *RetransmitEntry, baseInterval time.Duration) bool {
 	entry.SendCount++
 
 	// Check max retransmissions
 	if entry.SendCount >= MRPMaxTransmissions {
 		// Max retries exceeded - remove entry
-		entry.Stop()
-		delete(t.entries, messageCounter)
-		delete(t.byExchange, entry.ExchangeKey)
+		t.removeLocked(entry)
 		return false
 	}
 
@@ -185,11 +249,40 @@ func (t *RetransmitTable) ScheduleRetransmit(
 
 	// Restart timer
 	entry.Stop()
-	entry.timer = time.AfterFunc(backoffTime, entry.callback)
+	entry.timer = t.clock.AfterFunc(backoffTime, entry.callback)
 
 	return true
 }
 
+// sendDone records that a transmission of entry's message, announced by
+// addSending or scheduleSend, has finished.
+func (t *RetransmitTable) sendDone(entry *RetransmitEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry.sending--
+	t.releaseLocked(entry)
+}
+
+// removeLocked removes entry from the table.
+func (t *RetransmitTable) removeLocked(entry *RetransmitEntry) {
+	entry.Stop()
+	delete(t.entries, entry.MessageCounter)
+	delete(t.byExchange, entry.ExchangeKey)
+	entry.removed = true
+	t.releaseLocked(entry)
+}
+
+// releaseLocked releases entry's message once it has left the table and
+// no transmission of it is in progress.
+func (t *RetransmitTable) releaseLocked(entry *RetransmitEntry) {
+	if t.release == nil || !entry.removed || entry.sending > 0 || entry.Message == nil {
+		return
+	}
+	t.release(entry.Message)
+	entry.Message = nil
+}
+
 // GetByCounter returns the entry for a message counter.
 func (t *RetransmitTable) GetByCounter(messageCounter uint32) (*RetransmitEntry, bool) {
 	t.mu.Lock()
@@ -228,9 +321,7 @@ func (t *RetransmitTable) Remove(key exchangeKey) {
 		return
 	}
 
-	entry.Stop()
-	delete(t.entries, entry.MessageCounter)
-	delete(t.byExchange, key)
+	t.removeLocked(entry)
 }
 
 // RemoveByCounter removes an entry by message counter.
@@ -243,9 +334,7 @@ func (t *RetransmitTable) RemoveByCounter(messageCounter uint32) {
 		return
 	}
 
-	entry.Stop()
-	delete(t.entries, messageCounter)
-	delete(t.byExchange, entry.ExchangeKey)
+	t.removeLocked(entry)
 }
 
 // Count returns the number of pending retransmit entries.
@@ -260,11 +349,9 @@ func (t *RetransmitTable) Clear() {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	for counter, entry := range t.entries {
-		entry.Stop()
-		delete(t.entries, counter)
+	for _, entry := range t.entries {
+		t.removeLocked(entry)
 	}
-	t.byExchange = make(map[exchangeKey]*RetransmitEntry)
 }
 
 // ForEach iterates over all entries.
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/backkem/matter/pkg/clock"
 	"github.com/backkem/matter/pkg/transport"
 )
 
@@ -117,7 +118,8 @@ func TestRetransmitTableAck(t *testing.T) {
 }
 
 func TestRetransmitTableTimeout(t *testing.T) {
-	table := NewRetransmitTable()
+	clk := clock.NewFakeClock(time.Time{})
+	table := newRetransmitTable(clk)
 
 	key := exchangeKey{
 		localSessionID: 1,
@@ -126,7 +128,7 @@ func TestRetransmitTableTimeout(t *testing.T) {
 	}
 
 	peerAddr := makeTestPeerAddress()
-	baseInterval := 100 * time.Millisecond // Short for test
+	baseInterval := 300 * time.Millisecond
 
 	var called atomic.Int32
 	var calledEntry *RetransmitEntry
@@ -136,8 +138,13 @@ func TestRetransmitTableTimeout(t *testing.T) {
 		calledEntry = entry
 	})
 
-	// Wait for timeout (100ms * 1.1 * 1.25 = ~138ms max, use 200ms buffer)
-	time.Sleep(200 * time.Millisecond)
+	// Not before the minimum backoff, but by the maximum
+	backoff := NewBackoffCalculator(nil)
+	clk.Advance(backoff.CalculateMin(baseInterval, 0) - time.Millisecond)
+	if called.Load() != 0 {
+		t.Errorf("callback called %d times before the backoff, want 0", called.Load())
+	}
+	clk.Advance(backoff.CalculateMax(baseInterval, 0) - backoff.CalculateMin(baseInterval, 0) + time.Millisecond)
 
 	if called.Load() != 1 {
 		t.Errorf("callback called %d times, want 1", called.Load())
@@ -252,6 +259,51 @@ func TestRetransmitTableRemove(t *testing.T) {
 	}
 }
 
+func TestRetransmitTableRelease(t *testing.T) {
+	table := NewRetransmitTable()
+	var released [][]byte
+	table.release = func(b []byte) { released = append(released, b) }
+
+	key := exchangeKey{
+		localSessionID: 1,
+		exchangeID:     100,
+		role:           ExchangeRoleInitiator,
+	}
+	peerAddr := makeTestPeerAddress()
+
+	// A message acknowledged while being sent is released once sent.
+	entry, err := table.addSending(key, 1, []byte("msg1"), peerAddr, time.Hour, nil)
+	if err != nil {
+		t.Fatalf("addSending failed: %v", err)
+	}
+	table.Ack(1)
+	if len(released) != 0 {
+		t.Fatal("message released while being sent")
+	}
+	table.sendDone(entry)
+	if len(released) != 1 || string(released[0]) != "msg1" {
+		t.Fatalf("released = %q, want [msg1]", released)
+	}
+
+	// A message retransmitted while removed is not sent again.
+	entry, _ = table.addSending(key, 2, []byte("msg2"), peerAddr, time.Hour, nil)
+	table.sendDone(entry)
+	table.Remove(key)
+	if len(released) != 2 {
+		t.Fatalf("released %d messages after remove, want 2", len(released))
+	}
+	if table.scheduleSend(entry, time.Hour) {
+		t.Error("scheduleSend succeeded for a removed entry")
+	}
+
+	// Messages still in the table are released on Clear.
+	table.Add(key, 3, []byte("msg3"), peerAddr, time.Hour, nil)
+	table.Clear()
+	if len(released) != 3 || table.Count() != 0 {
+		t.Errorf("released %d messages, count %d after clear, want 3, 0", len(released), table.Count())
+	}
+}
+
 func TestRetransmitTableCount(t *testing.T) {
 	table := NewRetransmitTable()
 
@@ -4,6 +4,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/backkem/matter/pkg/clock"
 	"github.com/backkem/matter/pkg/fabric"
 	"github.com/backkem/matter/pkg/message"
 	"github.com/backkem/matter/pkg/session"
@@ -53,6 +54,8 @@ type TestManagerPairConfig struct {
 	UDP bool
 	// TCP enables TCP transport
 	TCP bool
+	// Clock runs the managers' timers (default: the real clock)
+	Clock clock.Clock
 }
 
 // exchangeHandlerWrapper routes transport messages to exchange manager.
@@ -123,6 +126,7 @@ func NewTestManagerPair(config TestManagerPairConfig) (*TestManagerPair, error)
 		pair.managers[i] = NewManager(ManagerConfig{
 			SessionManager:   pair.sessionMgrs[i],
 			TransportManager: transportPair.Manager(i),
+			Clock:            config.Clock,
 		})
 		pair.handlerWrapper[i].manager = pair.managers[i]
 		pair.managers[i].RegisterProtocol(message.ProtocolSecureChannel, pair.handlers[i])
@@ -0,0 +1,60 @@
+package exchange
+
+import (
+	"time"
+
+	"github.com/backkem/matter/pkg/transport"
+)
+
+// ExpectedProcessingTime is the time a peer is allowed for processing a
+// message before it sends the response. It is part of the default
+// response timeout.
+const ExpectedProcessingTime = 2 * time.Second
+
+// tcpTransmitWindow bounds the delivery of a message over TCP, which has
+// no MRP retransmissions to derive it from.
+const tcpTransmitWindow = 30 * time.Second
+
+// ResponseTimeoutDelegate is implemented by an ExchangeDelegate that wants
+// to know when a response expected with SendMessageExpectResponse did not
+// arrive in time.
+type ResponseTimeoutDelegate interface {
+	// OnResponseTimeout is called when the response timeout expires,
+	// before the exchange is closed.
+	OnResponseTimeout(ctx *ExchangeContext)
+}
+
+// defaultResponseTimeout computes the time to wait for the response to a
+// message on a session: the time the message takes to be delivered with
+// all its MRP retransmissions, ExpectedProcessingTime, and the same again
+// for the response.
+//
+// The peer's active interval is used if the peer is active, its idle
+// interval otherwise, as for retransmissions (Spec Section 4.12.8).
+func defaultResponseTimeout(sess SessionContext, transportType transport.TransportType) time.Duration {
+	if sess == nil {
+		return ExpectedProcessingTime
+	}
+
+	window := tcpTransmitWindow
+	if transportType == transport.TransportTypeUDP {
+		params := sess.GetParams()
+		baseInterval := params.IdleInterval
+		if secureSession, ok := sess.(SecureSessionContext); ok && secureSession.IsPeerActive() {
+			baseInterval = params.ActiveInterval
+		}
+		window = mrpTransmitWindow(baseInterval)
+	}
+	return 2*window + ExpectedProcessingTime
+}
+
+// mrpTransmitWindow returns the longest time MRP spends on a message
+// before giving up: the maximum backoff of each of its transmissions.
+func mrpTransmitWindow(baseInterval time.Duration) time.Duration {
+	var calc BackoffCalculator
+	var window time.Duration
+	for attempt := 0; attempt < MRPMaxTransmissions; attempt++ {
+		window += calc.CalculateMax(baseInterval, attempt)
+	}
+	return window
+}
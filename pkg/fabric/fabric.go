@@ -112,6 +112,13 @@ const (
 	VendorIDTestVendor4 VendorID = 0xFFF4
 )
 
+// IsValid returns true if the vendor ID can identify a device or fabric:
+// it is neither unspecified nor above the test vendor IDs, which are
+// reserved.
+func (v VendorID) IsValid() bool {
+	return v != VendorIDUnspecified && v <= VendorIDTestVendor4
+}
+
 // String returns a string representation of the vendor ID.
 func (v VendorID) String() string {
 	return fmt.Sprintf("VendorID(0x%04X)", uint16(v))
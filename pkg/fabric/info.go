@@ -54,6 +54,10 @@ type FabricInfo struct {
 	// IPK is the Identity Protection Key epoch key (16 bytes).
 	// This is Group Key Set 0, provided in the AddNOC command.
 	IPK [IPKSize]byte
+
+	// KeyHandle references the operational key of the NOC in the table's
+	// OperationalKeystore. Empty if the key is held elsewhere.
+	KeyHandle KeyHandle
 }
 
 // NewFabricInfo creates a FabricInfo from the provided certificates and parameters.
@@ -188,6 +192,7 @@ func (f *FabricInfo) Clone() *FabricInfo {
 		RootPublicKey:      f.RootPublicKey,
 		CompressedFabricID: f.CompressedFabricID,
 		IPK:                f.IPK,
+		KeyHandle:          f.KeyHandle,
 	}
 
 	clone.RootCert = make([]byte, len(f.RootCert))
@@ -0,0 +1,226 @@
+package fabric
+
+import (
+	gocrypto "crypto"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/backkem/matter/pkg/crypto"
+)
+
+// Keystore errors.
+var (
+	// ErrKeyNotFound is returned for a handle without a key.
+	ErrKeyNotFound = errors.New("fabric: operational key not found")
+	// ErrKeyExportDisabled is returned by ExportKey unless the keystore
+	// allows exporting keys.
+	ErrKeyExportDisabled = errors.New("fabric: operational key export disabled")
+	// ErrInvalidKeyEncryptionKey is returned for a key encryption key
+	// that is not 16 bytes.
+	ErrInvalidKeyEncryptionKey = errors.New("fabric: key encryption key must be 16 bytes")
+	// ErrNoKeystore is returned by Table.OperationalKey when the table
+	// has no keystore.
+	ErrNoKeystore = errors.New("fabric: no operational keystore")
+)
+
+// KeyHandle references an operational key in an OperationalKeystore. The
+// fabric table holds handles, never private keys. The empty handle
+// references no key.
+type KeyHandle string
+
+// OperationalKeystore holds the P-256 operational keys of a node's
+// fabrics. The private keys stay in the keystore: callers sign through the
+// crypto.Signer of a handle. Implementations may keep the keys in a secure
+// element, a PSA key store or a TPM.
+//
+// All methods must be safe for concurrent use.
+//
+// C++ Reference: src/crypto/OperationalKeystore.h
+type OperationalKeystore interface {
+	// GenerateKey generates a key, e.g. for the CSR of a new fabric, and
+	// returns its handle and public key, an *ecdsa.PublicKey.
+	GenerateKey() (KeyHandle, gocrypto.PublicKey, error)
+
+	// Signer returns the signer of a key, for CASE and CSRs.
+	Signer(h KeyHandle) (gocrypto.Signer, error)
+
+	// DeleteKey deletes a key, e.g. when its fabric is removed.
+	DeleteKey(h KeyHandle) error
+
+	// ExportKey returns the 32-byte private key. Keystores refuse with
+	// ErrKeyExportDisabled unless configured otherwise.
+	ExportKey(h KeyHandle) ([]byte, error)
+}
+
+// KeyStorage persists the keys of a Keystore. The keys it is given are
+// sealed if the Keystore has a key encryption key.
+//
+// matter.Storage implementations implement it to keep operational keys
+// with the fabrics.
+type KeyStorage interface {
+	// LoadOperationalKey returns the stored key of a handle, or
+	// ErrKeyNotFound.
+	LoadOperationalKey(h KeyHandle) ([]byte, error)
+	SaveOperationalKey(h KeyHandle, key []byte) error
+	DeleteOperationalKey(h KeyHandle) error
+}
+
+// KeystoreConfig configures a Keystore.
+type KeystoreConfig struct {
+	// Storage persists the keys.
+	// If nil, keys are kept in memory only.
+	Storage KeyStorage
+
+	// EncryptionKey (16 bytes) seals the stored keys with AES-CCM, bound
+	// to their handles. It should itself come from a secure element or
+	// the platform's key store.
+	// If nil, keys are stored in the clear.
+	EncryptionKey []byte
+
+	// AllowExport permits ExportKey, e.g. to migrate keys to another
+	// keystore. Default: false.
+	AllowExport bool
+}
+
+// keyHandleSize is the number of random bytes in a handle.
+const keyHandleSize = 8
+
+// Keystore is an OperationalKeystore of software keys, persisted to a
+// KeyStorage. Loaded keys are cached in memory.
+type Keystore struct {
+	config KeystoreConfig
+
+	mu   sync.Mutex
+	keys map[KeyHandle]*crypto.P256KeyPair
+}
+
+// NewKeystore creates a keystore.
+func NewKeystore(config KeystoreConfig) (*Keystore, error) {
+	if config.EncryptionKey != nil && len(config.EncryptionKey) != crypto.AESCCMKeySize {
+		return nil, ErrInvalidKeyEncryptionKey
+	}
+	return &Keystore{
+		config: config,
+		keys:   make(map[KeyHandle]*crypto.P256KeyPair),
+	}, nil
+}
+
+// GenerateKey implements OperationalKeystore.
+func (k *Keystore) GenerateKey() (KeyHandle, gocrypto.PublicKey, error) {
+	var id [keyHandleSize]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", nil, fmt.Errorf("fabric: generate key handle: %w", err)
+	}
+	h := KeyHandle(hex.EncodeToString(id[:]))
+
+	kp, err := crypto.P256GenerateKeyPair()
+	if err != nil {
+		return "", nil, err
+	}
+	if k.config.Storage != nil {
+		sealed, err := k.seal(h, kp.P256PrivateKey())
+		if err != nil {
+			return "", nil, err
+		}
+		if err := k.config.Storage.SaveOperationalKey(h, sealed); err != nil {
+			return "", nil, err
+		}
+	}
+
+	k.mu.Lock()
+	k.keys[h] = kp
+	k.mu.Unlock()
+	return h, kp.Public(), nil
+}
+
+// Signer implements OperationalKeystore.
+func (k *Keystore) Signer(h KeyHandle) (gocrypto.Signer, error) {
+	return k.key(h)
+}
+
+// DeleteKey implements OperationalKeystore.
+func (k *Keystore) DeleteKey(h KeyHandle) error {
+	k.mu.Lock()
+	delete(k.keys, h)
+	k.mu.Unlock()
+
+	if k.config.Storage != nil {
+		return k.config.Storage.DeleteOperationalKey(h)
+	}
+	return nil
+}
+
+// ExportKey implements OperationalKeystore.
+func (k *Keystore) ExportKey(h KeyHandle) ([]byte, error) {
+	if !k.config.AllowExport {
+		return nil, ErrKeyExportDisabled
+	}
+	kp, err := k.key(h)
+	if err != nil {
+		return nil, err
+	}
+	return kp.P256PrivateKey(), nil
+}
+
+// key returns the key of a handle, loading it from storage.
+func (k *Keystore) key(h KeyHandle) (*crypto.P256KeyPair, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if kp, ok := k.keys[h]; ok {
+		return kp, nil
+	}
+	if h == "" || k.config.Storage == nil {
+		return nil, ErrKeyNotFound
+	}
+
+	stored, err := k.config.Storage.LoadOperationalKey(h)
+	if err != nil {
+		return nil, err
+	}
+	private, err := k.open(h, stored)
+	if err != nil {
+		return nil, err
+	}
+	kp, err := crypto.P256KeyPairFromPrivateKey(private)
+	if err != nil {
+		return nil, fmt.Errorf("fabric: stored key %s: %w", h, err)
+	}
+	k.keys[h] = kp
+	return kp, nil
+}
+
+// seal encrypts a private key for storage as nonce || ciphertext, with the
+// handle as additional data.
+func (k *Keystore) seal(h KeyHandle, private []byte) ([]byte, error) {
+	if k.config.EncryptionKey == nil {
+		return private, nil
+	}
+	nonce := make([]byte, crypto.AESCCMNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	ciphertext, err := crypto.AESCCM128Encrypt(k.config.EncryptionKey, nonce, private, []byte(h))
+	if err != nil {
+		return nil, err
+	}
+	return append(nonce, ciphertext...), nil
+}
+
+// open decrypts a key sealed by seal.
+func (k *Keystore) open(h KeyHandle, stored []byte) ([]byte, error) {
+	if k.config.EncryptionKey == nil {
+		return stored, nil
+	}
+	if len(stored) < crypto.AESCCMNonceSize {
+		return nil, fmt.Errorf("fabric: stored key %s is truncated", h)
+	}
+	private, err := crypto.AESCCM128Decrypt(k.config.EncryptionKey, stored[:crypto.AESCCMNonceSize], stored[crypto.AESCCMNonceSize:], []byte(h))
+	if err != nil {
+		return nil, fmt.Errorf("fabric: stored key %s: %w", h, err)
+	}
+	return private, nil
+}
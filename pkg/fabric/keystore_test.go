@@ -0,0 +1,165 @@
+package fabric
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"testing"
+
+	"github.com/backkem/matter/pkg/crypto"
+)
+
+// memoryKeyStorage is a KeyStorage in memory.
+type memoryKeyStorage map[KeyHandle][]byte
+
+func (s memoryKeyStorage) LoadOperationalKey(h KeyHandle) ([]byte, error) {
+	key, ok := s[h]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return key, nil
+}
+
+func (s memoryKeyStorage) SaveOperationalKey(h KeyHandle, key []byte) error {
+	s[h] = key
+	return nil
+}
+
+func (s memoryKeyStorage) DeleteOperationalKey(h KeyHandle) error {
+	delete(s, h)
+	return nil
+}
+
+func TestKeystore(t *testing.T) {
+	storage := memoryKeyStorage{}
+	kek := bytes.Repeat([]byte{0x42}, 16)
+	ks, err := NewKeystore(KeystoreConfig{Storage: storage, EncryptionKey: kek})
+	if err != nil {
+		t.Fatalf("NewKeystore() error = %v", err)
+	}
+
+	h, pub, err := ks.GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	if len(storage[h]) == 0 {
+		t.Fatal("key not persisted")
+	}
+
+	// The stored key is sealed
+	signer, _ := ks.Signer(h)
+	private := signer.(*crypto.P256KeyPair).P256PrivateKey()
+	if bytes.Contains(storage[h], private) {
+		t.Error("stored key is in the clear")
+	}
+
+	// A new keystore loads and opens the key
+	reloaded, _ := NewKeystore(KeystoreConfig{Storage: storage, EncryptionKey: kek})
+	signer, err = reloaded.Signer(h)
+	if err != nil {
+		t.Fatalf("Signer() after reload error = %v", err)
+	}
+	if !signer.Public().(*ecdsa.PublicKey).Equal(pub) {
+		t.Error("reloaded key differs")
+	}
+	sig, err := crypto.P256SignWith(signer, []byte("message"))
+	if err != nil {
+		t.Fatalf("P256SignWith() error = %v", err)
+	}
+	if ok, _ := crypto.P256Verify(ecdsaPublicKeyBytes(pub.(*ecdsa.PublicKey)), []byte("message"), sig); !ok {
+		t.Error("signature does not verify")
+	}
+
+	// The wrong key encryption key cannot open it
+	wrong, _ := NewKeystore(KeystoreConfig{Storage: storage, EncryptionKey: bytes.Repeat([]byte{0x01}, 16)})
+	if _, err := wrong.Signer(h); err == nil {
+		t.Error("Signer() with wrong key encryption key succeeded")
+	}
+
+	// Export is disabled by default
+	if _, err := ks.ExportKey(h); !errors.Is(err, ErrKeyExportDisabled) {
+		t.Errorf("ExportKey() error = %v, want ErrKeyExportDisabled", err)
+	}
+	exporting, _ := NewKeystore(KeystoreConfig{Storage: storage, EncryptionKey: kek, AllowExport: true})
+	if key, err := exporting.ExportKey(h); err != nil || !bytes.Equal(key, private) {
+		t.Errorf("ExportKey() = %x, %v", key, err)
+	}
+
+	if err := ks.DeleteKey(h); err != nil {
+		t.Fatalf("DeleteKey() error = %v", err)
+	}
+	if _, err := ks.Signer(h); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Signer() after delete error = %v, want ErrKeyNotFound", err)
+	}
+
+	if _, err := NewKeystore(KeystoreConfig{EncryptionKey: kek[:8]}); !errors.Is(err, ErrInvalidKeyEncryptionKey) {
+		t.Errorf("NewKeystore() short key error = %v, want ErrInvalidKeyEncryptionKey", err)
+	}
+}
+
+func TestTable_OperationalKey(t *testing.T) {
+	ks, _ := NewKeystore(KeystoreConfig{})
+	h, pub, _ := ks.GenerateKey()
+
+	table := NewTable(TableConfig{Keystore: ks})
+	table.Add(&FabricInfo{FabricIndex: 1, FabricID: 1, KeyHandle: h})
+	table.Add(&FabricInfo{FabricIndex: 2, FabricID: 2})
+
+	signer, err := table.OperationalKey(1)
+	if err != nil {
+		t.Fatalf("OperationalKey() error = %v", err)
+	}
+	if !signer.Public().(*ecdsa.PublicKey).Equal(pub) {
+		t.Error("OperationalKey() returned another key")
+	}
+	if _, err := table.OperationalKey(2); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("OperationalKey() without handle error = %v, want ErrKeyNotFound", err)
+	}
+	if _, err := table.OperationalKey(3); !errors.Is(err, ErrFabricNotFound) {
+		t.Errorf("OperationalKey() unknown fabric error = %v, want ErrFabricNotFound", err)
+	}
+	if _, err := NewTable(DefaultTableConfig()).OperationalKey(1); !errors.Is(err, ErrNoKeystore) {
+		t.Errorf("OperationalKey() without keystore error = %v, want ErrNoKeystore", err)
+	}
+
+	// Removing the fabric deletes its key
+	if err := table.Remove(1); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if _, err := ks.Signer(h); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Signer() after Remove error = %v, want ErrKeyNotFound", err)
+	}
+}
+
+// failingKeyStorage is a KeyStorage that cannot delete keys.
+type failingKeyStorage struct {
+	memoryKeyStorage
+}
+
+func (s failingKeyStorage) DeleteOperationalKey(h KeyHandle) error {
+	return errors.New("storage offline")
+}
+
+func TestTable_RemoveKeyNotDeleted(t *testing.T) {
+	ks, _ := NewKeystore(KeystoreConfig{Storage: failingKeyStorage{memoryKeyStorage{}}})
+	h, _, _ := ks.GenerateKey()
+	table := NewTable(TableConfig{Keystore: ks})
+	table.Add(&FabricInfo{FabricIndex: 1, FabricID: 1, KeyHandle: h})
+
+	// The fabric is removed even though its key stays behind
+	if err := table.Remove(1); !errors.Is(err, ErrKeyNotDeleted) {
+		t.Errorf("Remove() error = %v, want ErrKeyNotDeleted", err)
+	}
+	if _, ok := table.Get(1); ok {
+		t.Error("fabric not removed")
+	}
+	if err := table.Remove(1); !errors.Is(err, ErrFabricNotFound) {
+		t.Errorf("second Remove() error = %v, want ErrFabricNotFound", err)
+	}
+}
+
+// ecdsaPublicKeyBytes returns the uncompressed encoding of a public key.
+func ecdsaPublicKeyBytes(pub *ecdsa.PublicKey) []byte {
+	b, _ := pub.Bytes()
+	return b
+}
@@ -238,6 +238,25 @@ func TestFabricID_IsValid(t *testing.T) {
 	}
 }
 
+func TestVendorID_IsValid(t *testing.T) {
+	tests := []struct {
+		id    VendorID
+		valid bool
+	}{
+		{VendorIDUnspecified, false},
+		{VendorID(1), true},
+		{VendorIDTestVendor4, true},
+		{VendorID(0xFFF5), false},
+		{VendorID(0xFFFF), false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.id.IsValid(); got != tt.valid {
+			t.Errorf("%v.IsValid() = %v, want %v", tt.id, got, tt.valid)
+		}
+	}
+}
+
 func TestNodeID_IsOperational(t *testing.T) {
 	tests := []struct {
 		id          NodeID
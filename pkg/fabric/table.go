@@ -1,6 +1,7 @@
 package fabric
 
 import (
+	gocrypto "crypto"
 	"errors"
 	"fmt"
 	"sync"
@@ -18,6 +19,9 @@ var (
 	ErrLabelConflict = errors.New("fabric: label already in use")
 	// ErrFabricIndexInUse is returned when a fabric index is already in use.
 	ErrFabricIndexInUse = errors.New("fabric: fabric index already in use")
+	// ErrKeyNotDeleted is returned when a fabric was removed but its
+	// operational key could not be deleted from the keystore.
+	ErrKeyNotDeleted = errors.New("fabric: operational key of removed fabric not deleted")
 )
 
 // TableConfig configures the fabric table.
@@ -25,6 +29,11 @@ type TableConfig struct {
 	// MaxFabrics is the maximum number of fabrics supported (SupportedFabrics attribute).
 	// Valid range: 5-254. Default: 5.
 	MaxFabrics uint8
+
+	// Keystore holds the operational keys the fabrics reference by
+	// KeyHandle. Remove deletes the key of a fabric.
+	// If nil, the table holds no keys.
+	Keystore OperationalKeystore
 }
 
 // DefaultTableConfig returns the default table configuration.
@@ -95,21 +104,56 @@ func (t *Table) Add(info *FabricInfo) error {
 	return nil
 }
 
-// Remove removes a fabric from the table by index.
+// Remove removes a fabric from the table by index, and its operational
+// key from the keystore.
 //
-// Returns ErrFabricNotFound if the fabric doesn't exist.
+// Returns ErrFabricNotFound if the fabric doesn't exist. The fabric is
+// removed even if its key cannot be deleted; the error then wraps
+// ErrKeyNotDeleted.
 func (t *Table) Remove(index FabricIndex) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	if _, exists := t.fabrics[index]; !exists {
+	info, exists := t.fabrics[index]
+	if !exists {
 		return ErrFabricNotFound
 	}
 
 	delete(t.fabrics, index)
+	if t.config.Keystore != nil && info.KeyHandle != "" {
+		if err := t.config.Keystore.DeleteKey(info.KeyHandle); err != nil {
+			return fmt.Errorf("%w: %v", ErrKeyNotDeleted, err)
+		}
+	}
 	return nil
 }
 
+// OperationalKey returns the signer of a fabric's operational key, through
+// the table's keystore.
+//
+// Returns ErrNoKeystore if the table has no keystore, ErrFabricNotFound if
+// the fabric doesn't exist and ErrKeyNotFound if the fabric has no key.
+func (t *Table) OperationalKey(index FabricIndex) (gocrypto.Signer, error) {
+	t.mu.RLock()
+	info, exists := t.fabrics[index]
+	t.mu.RUnlock()
+
+	switch {
+	case t.config.Keystore == nil:
+		return nil, ErrNoKeystore
+	case !exists:
+		return nil, ErrFabricNotFound
+	case info.KeyHandle == "":
+		return nil, ErrKeyNotFound
+	}
+	return t.config.Keystore.Signer(info.KeyHandle)
+}
+
+// Keystore returns the table's operational keystore, or nil.
+func (t *Table) Keystore() OperationalKeystore {
+	return t.config.Keystore
+}
+
 // Get returns a fabric by index.
 //
 // Returns (nil, false) if the fabric doesn't exist.
@@ -0,0 +1,208 @@
+package im
+
+import (
+	"context"
+
+	"github.com/backkem/matter/pkg/acl"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/exchange"
+	"github.com/backkem/matter/pkg/im/message"
+	"github.com/backkem/matter/pkg/session"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// AccessChecker decides whether a subject holds a privilege on a path.
+// It is implemented by *acl.Checker and *acl.Manager.
+type AccessChecker interface {
+	Check(subject acl.SubjectDescriptor, target acl.RequestPath, required acl.Privilege) acl.Result
+}
+
+// PrivilegeResolver is implemented by Dispatchers that know the privilege
+// each attribute and command requires from cluster metadata. The Engine
+// falls back to DefaultPrivilege for Dispatchers without it, and for paths
+// the resolver does not know.
+type PrivilegeResolver interface {
+	// RequiredPrivilege returns the privilege required for the request
+	// type on the path, and false if the path is unknown.
+	RequiredPrivilege(path acl.RequestPath) (acl.Privilege, bool)
+}
+
+// DefaultPrivilege returns the privilege an operation requires when the
+// cluster does not say otherwise: View to read, Operate to write or invoke,
+// as for an attribute or command without an access quality.
+func DefaultPrivilege(requestType acl.RequestType) acl.Privilege {
+	switch requestType {
+	case acl.RequestTypeAttributeWrite, acl.RequestTypeCommandInvoke:
+		return acl.PrivilegeOperate
+	default:
+		return acl.PrivilegeView
+	}
+}
+
+// subjectDescriptor derives the Incoming Subject Descriptor from the
+// session of an exchange. Exchanges without a secure session yield the
+// zero descriptor, which no ACL entry matches.
+//
+// Spec: Section 6.6.6.1.3
+func subjectDescriptor(ctx *exchange.ExchangeContext) acl.SubjectDescriptor {
+	if ctx == nil {
+		return acl.SubjectDescriptor{}
+	}
+	if group, ok := ctx.Session().(*session.GroupContext); ok {
+		// Group messages are authorized by the group, not the sender
+		return acl.SubjectDescriptor{
+			FabricIndex: group.FabricIndex(),
+			AuthMode:    acl.AuthModeGroup,
+			Subject:     acl.NodeIDFromGroupID(group.GroupID()),
+		}
+	}
+	sess, ok := ctx.Session().(*session.SecureContext)
+	if !ok {
+		return acl.SubjectDescriptor{}
+	}
+
+	switch sess.SessionType() {
+	case session.SessionTypePASE:
+		// PASE sessions only exist while commissioning and carry
+		// implicit Administer privilege (Spec 6.6.2.9).
+		return acl.SubjectDescriptor{
+			FabricIndex:     sess.FabricIndex(),
+			AuthMode:        acl.AuthModePASE,
+			Subject:         acl.NodeIDFromPAKEKeyID(0),
+			IsCommissioning: true,
+		}
+	case session.SessionTypeCASE:
+		subject := acl.SubjectDescriptor{
+			FabricIndex: sess.FabricIndex(),
+			AuthMode:    acl.AuthModeCASE,
+			Subject:     uint64(sess.PeerNodeID()),
+		}
+		for i, tag := range sess.CaseAuthTags() {
+			if i == len(subject.CATs) {
+				break
+			}
+			subject.CATs[i] = acl.CASEAuthTag(tag)
+		}
+		return subject
+	default:
+		return acl.SubjectDescriptor{}
+	}
+}
+
+// accessDispatcher binds a Dispatcher to the subject of one request. It
+// attaches the request context to each operation and checks access before
+// passing it on; denied operations fail with ErrAccessDenied, and those
+// forbidden by the Access Restriction List with ErrAccessRestricted.
+//
+// C++ Reference: InteractionModelEngine CheckAccess
+type accessDispatcher struct {
+	Dispatcher
+	checker AccessChecker
+	rc      *RequestContext
+}
+
+// ReadAttribute implements Dispatcher.
+func (d *accessDispatcher) ReadAttribute(ctx context.Context, req *AttributeReadRequest, w *tlv.Writer) error {
+	req.IMContext = d.rc
+	if err := d.check(attributeRequestPath(req.Path, acl.RequestTypeAttributeRead)); err != nil {
+		return err
+	}
+	return d.Dispatcher.ReadAttribute(ctx, req, w)
+}
+
+// WriteAttribute implements Dispatcher.
+func (d *accessDispatcher) WriteAttribute(ctx context.Context, req *AttributeWriteRequest, r *tlv.Reader) error {
+	req.IMContext = d.rc
+	if err := d.check(attributeRequestPath(req.Path, acl.RequestTypeAttributeWrite)); err != nil {
+		return err
+	}
+	return d.Dispatcher.WriteAttribute(ctx, req, r)
+}
+
+// InvokeCommand implements Dispatcher.
+func (d *accessDispatcher) InvokeCommand(ctx context.Context, req *CommandInvokeRequest, r *tlv.Reader) ([]byte, error) {
+	if err := d.checkInvoke(req); err != nil {
+		return nil, err
+	}
+	return d.Dispatcher.InvokeCommand(ctx, req, r)
+}
+
+// InvokeCommandResponse implements CommandResponder. If the wrapped
+// Dispatcher is not a CommandResponder, the response command is taken to
+// follow the request command.
+func (d *accessDispatcher) InvokeCommandResponse(ctx context.Context, req *CommandInvokeRequest, r *tlv.Reader) (*datamodel.CommandResponse, error) {
+	if err := d.checkInvoke(req); err != nil {
+		return nil, err
+	}
+	if responder, ok := d.Dispatcher.(CommandResponder); ok {
+		return responder.InvokeCommandResponse(ctx, req, r)
+	}
+	data, err := d.Dispatcher.InvokeCommand(ctx, req, r)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return &datamodel.CommandResponse{Command: datamodel.CommandID(req.Path.Command) + 1, Fields: data}, nil
+}
+
+// checkInvoke sets the request context of an invoke and checks access to
+// the command.
+func (d *accessDispatcher) checkInvoke(req *CommandInvokeRequest) error {
+	req.IMContext = d.rc
+	command := uint32(req.Path.Command)
+	return d.check(acl.RequestPath{
+		Cluster:     uint32(req.Path.Cluster),
+		Endpoint:    uint16(req.Path.Endpoint),
+		RequestType: acl.RequestTypeCommandInvoke,
+		EntityID:    &command,
+	})
+}
+
+// check returns nil if the subject holds the privilege the path requires
+// and no access restriction forbids it.
+func (d *accessDispatcher) check(path acl.RequestPath) error {
+	if d.checker == nil {
+		return nil
+	}
+	required := DefaultPrivilege(path.RequestType)
+	if resolver, ok := d.Dispatcher.(PrivilegeResolver); ok {
+		if p, ok := resolver.RequiredPrivilege(path); ok {
+			required = p
+		}
+	}
+	switch d.checker.Check(d.rc.Subject, path, required) {
+	case acl.ResultAllowed:
+		return nil
+	case acl.ResultRestricted:
+		return ErrAccessRestricted
+	default:
+		return ErrAccessDenied
+	}
+}
+
+// attributeRequestPath converts a concrete attribute path to an ACL
+// request path.
+func attributeRequestPath(p message.AttributePathIB, requestType acl.RequestType) acl.RequestPath {
+	path := acl.RequestPath{RequestType: requestType}
+	if p.Endpoint != nil {
+		path.Endpoint = uint16(*p.Endpoint)
+	}
+	if p.Cluster != nil {
+		path.Cluster = uint32(*p.Cluster)
+	}
+	if p.Attribute != nil {
+		attribute := uint32(*p.Attribute)
+		path.EntityID = &attribute
+	}
+	return path
+}
+
+// eventRequestPath converts an event path to an ACL request path.
+func eventRequestPath(p EventPath) acl.RequestPath {
+	event := uint32(p.EventID)
+	return acl.RequestPath{
+		Cluster:     uint32(p.ClusterID),
+		Endpoint:    uint16(p.EndpointID),
+		RequestType: acl.RequestTypeEventRead,
+		EntityID:    &event,
+	}
+}
@@ -0,0 +1,164 @@
+package im
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/backkem/matter/pkg/acl"
+	imsg "github.com/backkem/matter/pkg/im/message"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// privilegedDispatcher adds per-request-type privileges to a MockDispatcher.
+type privilegedDispatcher struct {
+	*MockDispatcher
+	privileges map[acl.RequestType]acl.Privilege
+}
+
+func (d privilegedDispatcher) RequiredPrivilege(path acl.RequestPath) (acl.Privilege, bool) {
+	p, ok := d.privileges[path.RequestType]
+	return p, ok
+}
+
+func TestAccessDispatcher(t *testing.T) {
+	checker := acl.NewChecker(nil)
+	checker.SetEntries([]acl.Entry{{
+		FabricIndex: 1,
+		Privilege:   acl.PrivilegeOperate,
+		AuthMode:    acl.AuthModeCASE,
+		Subjects:    []uint64{0x1000},
+	}})
+
+	mock := NewMockDispatcher()
+	mock.SetReadResult(true, nil)
+	d := &accessDispatcher{
+		Dispatcher: privilegedDispatcher{
+			MockDispatcher: mock,
+			privileges:     map[acl.RequestType]acl.Privilege{acl.RequestTypeAttributeWrite: acl.PrivilegeManage},
+		},
+		checker: checker,
+		rc: NewRequestContext(nil, acl.SubjectDescriptor{
+			FabricIndex: 1,
+			AuthMode:    acl.AuthModeCASE,
+			Subject:     0x1000,
+		}),
+	}
+
+	ep, cl, attr := imsg.EndpointID(1), imsg.ClusterID(0x0006), imsg.AttributeID(0)
+	path := imsg.AttributePathIB{Endpoint: &ep, Cluster: &cl, Attribute: &attr}
+
+	// Operate covers the default View for reads and Operate for invokes.
+	readReq := &AttributeReadRequest{Path: path}
+	if err := d.ReadAttribute(context.Background(), readReq, tlv.NewWriter(&discardWriter{})); err != nil {
+		t.Errorf("ReadAttribute error = %v, want nil", err)
+	}
+	if readReq.IMContext != d.rc {
+		t.Error("ReadAttribute did not attach the request context")
+	}
+	invokeReq := &CommandInvokeRequest{Path: imsg.CommandPathIB{Endpoint: 1, Cluster: 0x0006, Command: 2}}
+	if _, err := d.InvokeCommand(context.Background(), invokeReq, nil); err != nil {
+		t.Errorf("InvokeCommand error = %v, want nil", err)
+	}
+
+	// The resolver raises writes to Manage.
+	writeReq := &AttributeWriteRequest{Path: path}
+	if err := d.WriteAttribute(context.Background(), writeReq, nil); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("WriteAttribute error = %v, want ErrAccessDenied", err)
+	}
+	if got := len(mock.WriteCalls()); got != 0 {
+		t.Errorf("denied write reached the dispatcher %d times", got)
+	}
+
+	// The Access Restriction List forbids what the ACL allows.
+	checker.SetRestrictions([]acl.RestrictionEntry{{
+		FabricIndex:  1,
+		Endpoint:     1,
+		Cluster:      0x0006,
+		Restrictions: []acl.Restriction{{Type: acl.RestrictionCommandForbidden}},
+	}})
+	if _, err := d.InvokeCommand(context.Background(), invokeReq, nil); !errors.Is(err, ErrAccessRestricted) {
+		t.Errorf("restricted InvokeCommand error = %v, want ErrAccessRestricted", err)
+	}
+
+	// Another fabric has no entry.
+	d.rc.Subject.FabricIndex = 2
+	if err := d.ReadAttribute(context.Background(), readReq, tlv.NewWriter(&discardWriter{})); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("ReadAttribute on fabric 2 error = %v, want ErrAccessDenied", err)
+	}
+}
+
+// discardWriter is an io.Writer that drops everything.
+type discardWriter struct{}
+
+func (*discardWriter) Write(p []byte) (int, error) { return len(p), nil }
+
+// TestE2E_AccessControl checks CASE requests against the server's ACL.
+func TestE2E_AccessControl(t *testing.T) {
+	checker := acl.NewChecker(nil)
+	checker.SetEntries([]acl.Entry{{
+		FabricIndex: 1,
+		Privilege:   acl.PrivilegeView,
+		AuthMode:    acl.AuthModeCASE,
+		Subjects:    []uint64{uint64(SecureTestNodeIDs[0])},
+	}})
+
+	mock := NewMockDispatcher()
+	mock.SetReadResult(true, nil)
+
+	pair, err := NewSecureTestIMPair(SecureTestIMPairConfig{
+		Dispatchers: [2]Dispatcher{nil, mock},
+		FabricIndex: 1,
+		ACLChecker:  checker,
+	})
+	if err != nil {
+		t.Fatalf("NewSecureTestIMPair: %v", err)
+	}
+	defer pair.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// View is enough to read.
+	if _, err := pair.Client(0).ReadAttribute(ctx, pair.Session(0), pair.PeerAddress(1), 1, 0x0006, 0x0000); err != nil {
+		t.Fatalf("ReadAttribute: %v", err)
+	}
+	if calls := mock.ReadCalls(); len(calls) != 1 {
+		t.Fatalf("read calls = %d, want 1", len(calls))
+	}
+
+	// Invoking requires Operate.
+	result, err := pair.Client(0).InvokeWithStatus(ctx, pair.Session(0), pair.PeerAddress(1), 1, 0x0006, 0x02, nil)
+	if err != nil {
+		t.Fatalf("InvokeWithStatus: %v", err)
+	}
+	if !result.HasStatus || result.Status != imsg.StatusUnsupportedAccess {
+		t.Errorf("invoke status = %v, want UnsupportedAccess", result.Status)
+	}
+	if got := len(mock.InvokeCalls()); got != 0 {
+		t.Errorf("denied invoke reached the dispatcher %d times", got)
+	}
+
+	// Granting Operate on the cluster allows it.
+	if err := checker.AddEntry(acl.Entry{
+		FabricIndex: 1,
+		Privilege:   acl.PrivilegeOperate,
+		AuthMode:    acl.AuthModeCASE,
+		Subjects:    []uint64{uint64(SecureTestNodeIDs[0])},
+		Targets:     []acl.Target{acl.NewTargetCluster(0x0006)},
+	}); err != nil {
+		t.Fatalf("AddEntry: %v", err)
+	}
+	result, err = pair.Client(0).InvokeWithStatus(ctx, pair.Session(0), pair.PeerAddress(1), 1, 0x0006, 0x02, nil)
+	if err != nil {
+		t.Fatalf("InvokeWithStatus: %v", err)
+	}
+	if result.HasStatus && result.Status != imsg.StatusSuccess {
+		t.Errorf("invoke status = %v, want Success", result.Status)
+	}
+	if got := len(mock.InvokeCalls()); got != 1 {
+		t.Errorf("invoke calls = %d, want 1", got)
+	}
+
+}
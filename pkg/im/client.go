@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"sync"
 	"time"
 
@@ -14,6 +15,7 @@ import (
 	"github.com/backkem/matter/pkg/tlv"
 	"github.com/backkem/matter/pkg/transport"
 	"github.com/pion/logging"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Client errors.
@@ -22,11 +24,15 @@ var (
 	ErrClientClosed       = errors.New("im: client closed")
 	ErrUnexpectedResponse = errors.New("im: unexpected response type")
 	ErrCommandFailed      = errors.New("im: command failed")
+	ErrTimedRequestFailed = errors.New("im: timed request failed")
 )
 
 // DefaultRequestTimeout is the default timeout for IM requests.
 const DefaultRequestTimeout = 30 * time.Second
 
+// DefaultTimedTimeout is the default timed window for timed invokes.
+const DefaultTimedTimeout = 10 * time.Second
+
 // Client provides client-side IM operations for sending cluster commands.
 // It wraps the exchange layer to provide a synchronous request-response API.
 //
@@ -37,7 +43,13 @@ const DefaultRequestTimeout = 30 * time.Second
 type Client struct {
 	exchangeManager *exchange.Manager
 	timeout         time.Duration
+	responseTimeout time.Duration
+	busyRetry       RetryPolicy
+	versionRetries  int
+	engine          *Engine
+	livenessMargin  time.Duration
 	log             logging.LeveledLogger
+	tracer          trace.Tracer
 }
 
 // ClientConfig configures the Client.
@@ -49,9 +61,38 @@ type ClientConfig struct {
 	// Timeout for requests. Defaults to DefaultRequestTimeout if zero.
 	Timeout time.Duration
 
+	// ResponseTimeout is how long each message of a request waits for the
+	// peer's response on the exchange. Zero selects the exchange's
+	// default, derived from the session's MRP parameters.
+	ResponseTimeout time.Duration
+
+	// BusyRetry retries requests the peer refuses with Busy; once its
+	// attempts run out the request fails with a *BusyError. The zero
+	// value does not retry. See DefaultRetryPolicy.
+	BusyRetry RetryPolicy
+
+	// RetryOnVersionMismatch is how many times UpdateAttribute reads the
+	// attribute again and reapplies its mutation when the write fails
+	// with DataVersionMismatch. Zero does not retry.
+	RetryOnVersionMismatch int
+
+	// Engine receives the reports of the client's subscriptions, on the
+	// exchanges the peers open, and routes them to the subscriptions.
+	// Optional - if nil, Subscribe fails with ErrNoEngine.
+	Engine *Engine
+
+	// LivenessMargin is the time a subscription's peer has beyond
+	// MaxInterval for its next report, before the subscription is lost.
+	// Defaults to DefaultLivenessMargin if zero.
+	LivenessMargin time.Duration
+
 	// LoggerFactory creates loggers for the client.
 	// If nil, logging is disabled.
 	LoggerFactory logging.LoggerFactory
+
+	// TracerProvider creates a client span per request.
+	// If nil, the global provider is used (a no-op unless set).
+	TracerProvider trace.TracerProvider
 }
 
 // NewClient creates a new IM client.
@@ -61,9 +102,20 @@ func NewClient(config ClientConfig) *Client {
 		timeout = DefaultRequestTimeout
 	}
 
+	livenessMargin := config.LivenessMargin
+	if livenessMargin == 0 {
+		livenessMargin = DefaultLivenessMargin
+	}
+
 	c := &Client{
 		exchangeManager: config.ExchangeManager,
 		timeout:         timeout,
+		responseTimeout: config.ResponseTimeout,
+		busyRetry:       config.BusyRetry,
+		versionRetries:  config.RetryOnVersionMismatch,
+		engine:          config.Engine,
+		livenessMargin:  livenessMargin,
+		tracer:          newTracer(config.TracerProvider),
 	}
 
 	if config.LoggerFactory != nil {
@@ -93,7 +145,12 @@ func (c *Client) InvokeRequest(
 	clusterID uint32,
 	commandID uint32,
 	requestData []byte,
-) ([]byte, error) {
+) (data []byte, err error) {
+	ctx, span := c.tracer.Start(ctx, "im.invoke",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(commandAttributes(endpointID, clusterID, commandID)...))
+	defer func() { endSpan(span, err) }()
+
 	// Apply timeout
 	if _, hasDeadline := ctx.Deadline(); !hasDeadline {
 		var cancel context.CancelFunc
@@ -128,45 +185,25 @@ func (c *Client) InvokeRequest(
 			endpointID, clusterID, commandID)
 	}
 
-	// Create response handler
-	handler := newInvokeResponseHandler(c.log)
-
-	// Create exchange
-	exch, err := c.exchangeManager.NewExchange(
-		sess,
-		sess.LocalSessionID(),
-		peerAddr,
-		ProtocolID,
-		handler,
-	)
-	if err != nil {
-		return nil, err
-	}
-	defer exch.Close()
-
-	// Send request
-	err = exch.SendMessage(uint8(imsg.OpcodeInvokeRequest), payload, true)
+	err = c.withBusyRetry(ctx, func() error {
+		result, err := c.invoke(ctx, sess, peerAddr, payload, 0)
+		if err != nil {
+			return err
+		}
+		if res := result.invokeResult; res != nil && res.HasStatus && res.Status == imsg.StatusBusy {
+			return ErrBusy
+		}
+		data = result.data
+		return nil
+	})
 	if err != nil {
-		return nil, err
-	}
-
-	// Wait for response
-	select {
-	case <-ctx.Done():
 		if c.log != nil {
-			c.log.Warnf("InvokeRequest timeout: endpoint=%d, cluster=0x%04x, command=0x%02x",
-				endpointID, clusterID, commandID)
+			c.log.Warnf("InvokeRequest error: endpoint=%d, cluster=0x%04x, command=0x%02x: %v",
+				endpointID, clusterID, commandID, err)
 		}
-		return nil, ErrClientTimeout
-	case result := <-handler.resultCh:
-		if result.err != nil {
-			if c.log != nil {
-				c.log.Warnf("InvokeRequest error: %v", result.err)
-			}
-			return nil, result.err
-		}
-		return result.data, nil
+		return nil, err
 	}
+	return data, nil
 }
 
 // InvokeResult is the result of an invoke operation.
@@ -193,7 +230,102 @@ func (c *Client) InvokeWithStatus(
 	clusterID uint32,
 	commandID uint32,
 	requestData []byte,
-) (*InvokeResult, error) {
+) (invokeResult *InvokeResult, err error) {
+	return c.invokeWithStatus(ctx, sess, peerAddr, endpointID, clusterID, commandID, requestData, 0)
+}
+
+// TimedInvokeWithStatus sends a command as a timed invoke and returns the
+// full result including status. A TimedRequest action opens a window of
+// timedTimeout on the exchange, which the InvokeRequest must arrive within.
+// Use it for commands that require timed invocation.
+//
+// Returns ErrTimedRequestFailed if the device rejects the TimedRequest.
+//
+// Spec: Section 8.7.2
+func (c *Client) TimedInvokeWithStatus(
+	ctx context.Context,
+	sess *session.SecureContext,
+	peerAddr transport.PeerAddress,
+	endpointID uint16,
+	clusterID uint32,
+	commandID uint32,
+	requestData []byte,
+	timedTimeout time.Duration,
+) (invokeResult *InvokeResult, err error) {
+	if timedTimeout <= 0 {
+		timedTimeout = DefaultTimedTimeout
+	}
+	return c.invokeWithStatus(ctx, sess, peerAddr, endpointID, clusterID, commandID, requestData, timedTimeout)
+}
+
+// GroupInvoke sends a command to the members of a group, on every
+// endpoint that is in the group. The InvokeRequest suppresses responses,
+// so it returns once the message is sent: members never confirm group
+// commands. Use InvokeWithStatus on each member when confirmation matters.
+//
+// The group context must have a message counter; see
+// session.GroupContextConfig.
+//
+// Spec: Section 8.9.2 (group invoke)
+func (c *Client) GroupInvoke(
+	ctx context.Context,
+	group *session.GroupContext,
+	peerAddr transport.PeerAddress,
+	clusterID uint32,
+	commandID uint32,
+	requestData []byte,
+) (err error) {
+	_, span := c.tracer.Start(ctx, "im.invoke",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(groupCommandAttributes(group.GroupID(), clusterID, commandID)...))
+	defer func() { endSpan(span, err) }()
+
+	req := &imsg.InvokeRequestMessage{
+		SuppressResponse: true,
+		InvokeRequests: []imsg.CommandDataIB{
+			{
+				Path: imsg.CommandPathIB{
+					Cluster:          imsg.ClusterID(clusterID),
+					Command:          imsg.CommandID(commandID),
+					WildcardEndpoint: true,
+				},
+				Fields: requestData,
+			},
+		},
+	}
+
+	payload, err := EncodeInvokeRequest(req)
+	if err != nil {
+		return err
+	}
+
+	if c.log != nil {
+		c.log.Debugf("GroupInvoke: group=0x%04x, cluster=0x%04x, command=0x%02x",
+			group.GroupID(), clusterID, commandID)
+	}
+
+	return c.exchangeManager.SendGroupMessage(group, peerAddr, ProtocolID, uint8(imsg.OpcodeInvokeRequest), payload)
+}
+
+// invokeWithStatus sends a command and waits for the result. If
+// timedTimeout is non-zero, the invoke is preceded by a TimedRequest.
+func (c *Client) invokeWithStatus(
+	ctx context.Context,
+	sess *session.SecureContext,
+	peerAddr transport.PeerAddress,
+	endpointID uint16,
+	clusterID uint32,
+	commandID uint32,
+	requestData []byte,
+	timedTimeout time.Duration,
+) (invokeResult *InvokeResult, err error) {
+	timed := timedTimeout > 0
+
+	ctx, span := c.tracer.Start(ctx, "im.invoke",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(commandAttributes(endpointID, clusterID, commandID)...))
+	defer func() { endSpan(span, err) }()
+
 	// Apply timeout
 	if _, hasDeadline := ctx.Deadline(); !hasDeadline {
 		var cancel context.CancelFunc
@@ -204,7 +336,7 @@ func (c *Client) InvokeWithStatus(
 	// Build InvokeRequestMessage
 	req := &imsg.InvokeRequestMessage{
 		SuppressResponse: false,
-		TimedRequest:     false,
+		TimedRequest:     timed,
 		InvokeRequests: []imsg.CommandDataIB{
 			{
 				Path: imsg.CommandPathIB{
@@ -224,12 +356,46 @@ func (c *Client) InvokeWithStatus(
 	}
 
 	if c.log != nil {
-		c.log.Debugf("InvokeWithStatus: endpoint=%d, cluster=0x%04x, command=0x%02x",
-			endpointID, clusterID, commandID)
+		c.log.Debugf("InvokeWithStatus: endpoint=%d, cluster=0x%04x, command=0x%02x, timed=%v",
+			endpointID, clusterID, commandID, timed)
 	}
 
+	err = c.withBusyRetry(ctx, func() error {
+		result, err := c.invoke(ctx, sess, peerAddr, payload, timedTimeout)
+		if err != nil {
+			return err
+		}
+		invokeResult = result.invokeResult
+		if c.retriesBusy() && invokeResult.HasStatus && invokeResult.Status == imsg.StatusBusy {
+			return ErrBusy
+		}
+		return nil
+	})
+	if err != nil {
+		if c.log != nil {
+			c.log.Warnf("InvokeWithStatus error: endpoint=%d, cluster=0x%04x, command=0x%02x: %v",
+				endpointID, clusterID, commandID, err)
+		}
+		return nil, err
+	}
+	return invokeResult, nil
+}
+
+// invoke sends an encoded InvokeRequest on a new exchange and waits for
+// the result. If timedTimeout is non-zero, the invoke is preceded by a
+// TimedRequest.
+func (c *Client) invoke(
+	ctx context.Context,
+	sess *session.SecureContext,
+	peerAddr transport.PeerAddress,
+	payload []byte,
+	timedTimeout time.Duration,
+) (responseResult, error) {
 	// Create response handler
 	handler := newInvokeResponseHandler(c.log)
+	if timedTimeout > 0 {
+		handler.expectTimedStatus()
+	}
 
 	// Create exchange
 	exch, err := c.exchangeManager.NewExchange(
@@ -240,32 +406,71 @@ func (c *Client) InvokeWithStatus(
 		handler,
 	)
 	if err != nil {
-		return nil, err
+		return responseResult{}, err
 	}
 	defer exch.Close()
+	exch.SetResponseTimeout(c.responseTimeout)
+
+	// Open the timed window on the exchange first
+	if timedTimeout > 0 {
+		if err := c.sendTimedRequest(ctx, exch, handler, timedTimeout); err != nil {
+			return responseResult{}, err
+		}
+	}
 
 	// Send request
-	err = exch.SendMessage(uint8(imsg.OpcodeInvokeRequest), payload, true)
+	err = exch.SendMessageExpectResponse(uint8(imsg.OpcodeInvokeRequest), payload, true)
 	if err != nil {
-		return nil, err
+		return responseResult{}, err
 	}
 
 	// Wait for response
 	select {
 	case <-ctx.Done():
-		if c.log != nil {
-			c.log.Warnf("InvokeWithStatus timeout: endpoint=%d, cluster=0x%04x, command=0x%02x",
-				endpointID, clusterID, commandID)
+		return responseResult{}, ErrClientTimeout
+	case result := <-handler.resultCh:
+		return result, result.err
+	}
+}
+
+// sendTimedRequest sends a TimedRequest on the exchange and waits for the
+// device's StatusResponse.
+func (c *Client) sendTimedRequest(
+	ctx context.Context,
+	exch *exchange.ExchangeContext,
+	handler *invokeResponseHandler,
+	timeout time.Duration,
+) error {
+	ms := timeout.Milliseconds()
+	if ms > 0xFFFF {
+		ms = 0xFFFF
+	}
+	payload, err := EncodeMessage((&imsg.TimedRequestMessage{Timeout: uint16(ms)}).Encode)
+	if err != nil {
+		return err
+	}
+
+	if err := exch.SendMessageExpectResponse(uint8(imsg.OpcodeTimedRequest), payload, true); err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		return ErrClientTimeout
+	case status := <-handler.timedResult:
+		switch status {
+		case imsg.StatusSuccess:
+			return nil
+		case imsg.StatusBusy:
+			return fmt.Errorf("%w: %w", ErrTimedRequestFailed, ErrBusy)
+		default:
+			return fmt.Errorf("%w: %s", ErrTimedRequestFailed, status)
 		}
-		return nil, ErrClientTimeout
 	case result := <-handler.resultCh:
 		if result.err != nil {
-			if c.log != nil {
-				c.log.Warnf("InvokeWithStatus error: %v", result.err)
-			}
-			return nil, result.err
+			return result.err
 		}
-		return result.invokeResult, nil
+		return ErrUnexpectedResponse
 	}
 }
 
@@ -278,6 +483,45 @@ func (c *Client) ReadAttribute(
 	clusterID uint32,
 	attributeID uint32,
 ) ([]byte, error) {
+	attr, err := c.readAttribute(ctx, sess, peerAddr, endpointID, clusterID, attributeID)
+	if err != nil {
+		return nil, err
+	}
+	return attr.Data, nil
+}
+
+// ReadAttributeWithVersion reads a single attribute from a cluster, with
+// the cluster's data version, e.g. to write the attribute back with
+// WriteAttributeWithVersion.
+func (c *Client) ReadAttributeWithVersion(
+	ctx context.Context,
+	sess *session.SecureContext,
+	peerAddr transport.PeerAddress,
+	endpointID uint16,
+	clusterID uint32,
+	attributeID uint32,
+) ([]byte, imsg.DataVersion, error) {
+	attr, err := c.readAttribute(ctx, sess, peerAddr, endpointID, clusterID, attributeID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return attr.Data, attr.DataVersion, nil
+}
+
+// readAttribute reads a single attribute from a cluster.
+func (c *Client) readAttribute(
+	ctx context.Context,
+	sess *session.SecureContext,
+	peerAddr transport.PeerAddress,
+	endpointID uint16,
+	clusterID uint32,
+	attributeID uint32,
+) (attr *imsg.AttributeDataIB, err error) {
+	ctx, span := c.tracer.Start(ctx, "im.read",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attributeAttributes(endpointID, clusterID, attributeID)...))
+	defer func() { endSpan(span, err) }()
+
 	// Apply timeout
 	if _, hasDeadline := ctx.Deadline(); !hasDeadline {
 		var cancel context.CancelFunc
@@ -301,55 +545,44 @@ func (c *Client) ReadAttribute(
 		FabricFiltered: true,
 	}
 
-	// Encode to TLV
-	payload, err := EncodeReadRequest(req)
-	if err != nil {
-		return nil, err
-	}
-
 	if c.log != nil {
 		c.log.Debugf("ReadAttribute: endpoint=%d, cluster=0x%04x, attribute=0x%04x",
 			endpointID, clusterID, attributeID)
 	}
 
-	// Create response handler
-	handler := newReadResponseHandler(c.log)
-
-	// Create exchange
-	exch, err := c.exchangeManager.NewExchange(
-		sess,
-		sess.LocalSessionID(),
-		peerAddr,
-		ProtocolID,
-		handler,
-	)
+	stream, err := c.openRead(ctx, sess, peerAddr, req)
 	if err != nil {
 		return nil, err
 	}
-	defer exch.Close()
+	defer stream.Close()
 
-	// Send request
-	err = exch.SendMessage(uint8(imsg.OpcodeReadRequest), payload, true)
-	if err != nil {
+	// The attribute is in the first chunk
+	if !stream.Next() {
+		err = stream.Err()
+		if err == nil {
+			err = ErrUnexpectedResponse
+		}
+		if c.log != nil {
+			c.log.Warnf("ReadAttribute error: endpoint=%d, cluster=0x%04x, attribute=0x%04x: %v",
+				endpointID, clusterID, attributeID, err)
+		}
 		return nil, err
 	}
 
-	// Wait for response
-	select {
-	case <-ctx.Done():
-		if c.log != nil {
-			c.log.Warnf("ReadAttribute timeout: endpoint=%d, cluster=0x%04x, attribute=0x%04x",
-				endpointID, clusterID, attributeID)
-		}
-		return nil, ErrClientTimeout
-	case result := <-handler.resultCh:
-		if result.err != nil {
-			if c.log != nil {
-				c.log.Warnf("ReadAttribute error: %v", result.err)
-			}
-			return nil, result.err
-		}
-		return result.data, nil
+	resp := stream.Report()
+	if len(resp.AttributeReports) == 0 {
+		return nil, ErrUnexpectedResponse
+	}
+
+	first := resp.AttributeReports[0]
+	switch {
+	case first.AttributeData != nil:
+		return first.AttributeData, nil
+	case first.AttributeStatus != nil:
+		// Attribute access failed - Status is a value, not pointer
+		return nil, errors.New("im: attribute read failed: " + first.AttributeStatus.Status.Status.String())
+	default:
+		return nil, ErrUnexpectedResponse
 	}
 }
 
@@ -365,6 +598,14 @@ type invokeResponseHandler struct {
 	resultCh chan responseResult
 	once     sync.Once
 	log      logging.LeveledLogger
+
+	// timedCh receives the status of a preceding TimedRequest.
+	// Non-nil while that status is pending.
+	timedMu sync.Mutex
+	timedCh chan imsg.Status
+
+	// timedResult is the channel sendTimedRequest waits on.
+	timedResult chan imsg.Status
 }
 
 func newInvokeResponseHandler(log logging.LeveledLogger) *invokeResponseHandler {
@@ -408,6 +649,11 @@ func (h *invokeResponseHandler) OnClose(ctx *exchange.ExchangeContext) {
 	h.sendError(ErrClientClosed)
 }
 
+// OnResponseTimeout implements exchange.ResponseTimeoutDelegate.
+func (h *invokeResponseHandler) OnResponseTimeout(ctx *exchange.ExchangeContext) {
+	h.sendError(ErrClientTimeout)
+}
+
 func (h *invokeResponseHandler) handleInvokeResponse(payload []byte) {
 	resp, err := DecodeInvokeResponse(payload)
 	if err != nil {
@@ -447,6 +693,15 @@ func (h *invokeResponseHandler) handleInvokeResponse(payload []byte) {
 	})
 }
 
+// expectTimedStatus routes the next StatusResponse to timedCh, as the
+// reply to a TimedRequest.
+func (h *invokeResponseHandler) expectTimedStatus() {
+	h.timedMu.Lock()
+	h.timedCh = make(chan imsg.Status, 1)
+	h.timedResult = h.timedCh
+	h.timedMu.Unlock()
+}
+
 func (h *invokeResponseHandler) handleStatusResponse(payload []byte) {
 	statusMsg, err := DecodeStatusResponse(payload)
 	if err != nil {
@@ -454,6 +709,16 @@ func (h *invokeResponseHandler) handleStatusResponse(payload []byte) {
 		return
 	}
 
+	// Reply to a TimedRequest
+	h.timedMu.Lock()
+	timedCh := h.timedCh
+	h.timedCh = nil
+	h.timedMu.Unlock()
+	if timedCh != nil {
+		timedCh <- statusMsg.Status
+		return
+	}
+
 	// Status response typically indicates an error
 	h.once.Do(func() {
 		h.resultCh <- responseResult{
@@ -471,98 +736,6 @@ func (h *invokeResponseHandler) sendError(err error) {
 	})
 }
 
-// readResponseHandler handles ReportData messages.
-type readResponseHandler struct {
-	resultCh chan responseResult
-	once     sync.Once
-	log      logging.LeveledLogger
-}
-
-func newReadResponseHandler(log logging.LeveledLogger) *readResponseHandler {
-	return &readResponseHandler{
-		resultCh: make(chan responseResult, 1),
-		log:      log,
-	}
-}
-
-// OnMessage implements exchange.ExchangeDelegate.
-func (h *readResponseHandler) OnMessage(
-	ctx *exchange.ExchangeContext,
-	header *message.ProtocolHeader,
-	payload []byte,
-) ([]byte, error) {
-	opcode := imsg.Opcode(header.ProtocolOpcode)
-
-	if h.log != nil {
-		h.log.Tracef("readResponseHandler received opcode=%d (%s), exchangeID=%d, payloadLen=%d",
-			opcode, opcode.String(), header.ExchangeID, len(payload))
-	}
-
-	switch opcode {
-	case imsg.OpcodeReportData:
-		h.handleReportData(payload)
-	case imsg.OpcodeStatusResponse:
-		h.handleStatusResponse(payload)
-	default:
-		if h.log != nil {
-			h.log.Warnf("readResponseHandler unexpected opcode=%d (%s), expected ReportData or StatusResponse",
-				opcode, opcode.String())
-		}
-		h.sendError(ErrUnexpectedResponse)
-	}
-
-	return nil, nil
-}
-
-// OnClose implements exchange.ExchangeDelegate.
-func (h *readResponseHandler) OnClose(ctx *exchange.ExchangeContext) {
-	h.sendError(ErrClientClosed)
-}
-
-func (h *readResponseHandler) handleReportData(payload []byte) {
-	resp, err := DecodeReportData(payload)
-	if err != nil {
-		h.sendError(err)
-		return
-	}
-
-	// Extract first attribute report
-	if len(resp.AttributeReports) == 0 {
-		h.sendError(ErrUnexpectedResponse)
-		return
-	}
-
-	first := resp.AttributeReports[0]
-
-	// Check if it's data or status
-	if first.AttributeData != nil {
-		h.once.Do(func() {
-			h.resultCh <- responseResult{data: first.AttributeData.Data}
-		})
-	} else if first.AttributeStatus != nil {
-		// Attribute access failed - Status is a value, not pointer
-		h.sendError(errors.New("im: attribute read failed: " + first.AttributeStatus.Status.Status.String()))
-	} else {
-		h.sendError(ErrUnexpectedResponse)
-	}
-}
-
-func (h *readResponseHandler) handleStatusResponse(payload []byte) {
-	statusMsg, err := DecodeStatusResponse(payload)
-	if err != nil {
-		h.sendError(err)
-		return
-	}
-
-	h.sendError(errors.New("im: read failed with status: " + statusMsg.Status.String()))
-}
-
-func (h *readResponseHandler) sendError(err error) {
-	h.once.Do(func() {
-		h.resultCh <- responseResult{err: err}
-	})
-}
-
 // EncodeInvokeRequest encodes an InvokeRequestMessage to TLV.
 func EncodeInvokeRequest(req *imsg.InvokeRequestMessage) ([]byte, error) {
 	var buf bytes.Buffer
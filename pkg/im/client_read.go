@@ -0,0 +1,386 @@
+package im
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/backkem/matter/pkg/exchange"
+	imsg "github.com/backkem/matter/pkg/im/message"
+	"github.com/backkem/matter/pkg/message"
+	"github.com/backkem/matter/pkg/session"
+	"github.com/backkem/matter/pkg/transport"
+	"github.com/pion/logging"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ReadStream iterates over the ReportData messages of a read, one chunk at
+// a time. The next chunk is only requested once the previous one has been
+// consumed, so a large read is never held in memory as a whole.
+//
+// Usage:
+//
+//	stream, err := client.ReadStream(ctx, sess, peerAddr, req)
+//	if err != nil {
+//		return err
+//	}
+//	defer stream.Close()
+//	for stream.Next() {
+//		for _, report := range stream.Report().AttributeReports {
+//			// ...
+//		}
+//	}
+//	if err := stream.Err(); err != nil {
+//		return err
+//	}
+//
+// Spec: Section 8.4.3 (read transaction), 8.2.3.1 (chunking)
+type ReadStream struct {
+	ctx      context.Context
+	client   *Client
+	sess     *session.SecureContext
+	peerAddr transport.PeerAddress
+	payload  []byte
+
+	exch    *exchange.ExchangeContext
+	handler *readStreamHandler
+	timeout time.Duration
+	span    trace.Span
+	log     logging.LeveledLogger
+
+	report *imsg.ReportDataMessage
+	err    error
+	done   bool
+}
+
+// ReadStream sends a ReadRequest and returns a stream over the chunks of
+// the report. Each chunk must arrive within the client's timeout of the
+// previous one; ctx bounds the whole transaction. A read refused with
+// Busy is sent again per the client's RetryPolicy when the first chunk is
+// awaited.
+//
+// The stream must be closed.
+func (c *Client) ReadStream(
+	ctx context.Context,
+	sess *session.SecureContext,
+	peerAddr transport.PeerAddress,
+	req *imsg.ReadRequestMessage,
+) (*ReadStream, error) {
+	ctx, span := c.tracer.Start(ctx, "im.read", trace.WithSpanKind(trace.SpanKindClient))
+
+	stream, err := c.openRead(ctx, sess, peerAddr, req)
+	if err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+	stream.span = span
+	return stream, nil
+}
+
+// Read sends a ReadRequest and returns the complete report, assembled
+// from all its chunks.
+func (c *Client) Read(
+	ctx context.Context,
+	sess *session.SecureContext,
+	peerAddr transport.PeerAddress,
+	req *imsg.ReadRequestMessage,
+) (*imsg.ReportDataMessage, error) {
+	stream, err := c.ReadStream(ctx, sess, peerAddr, req)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	assembler := NewAssembler()
+	for stream.Next() {
+		report, complete, err := assembler.AddReportData(stream.Report())
+		if err != nil {
+			return nil, err
+		}
+		if complete {
+			return report, nil
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return nil, err
+	}
+	return nil, ErrUnexpectedResponse
+}
+
+// openRead creates the exchange of a read and sends the request.
+func (c *Client) openRead(
+	ctx context.Context,
+	sess *session.SecureContext,
+	peerAddr transport.PeerAddress,
+	req *imsg.ReadRequestMessage,
+) (*ReadStream, error) {
+	payload, err := EncodeReadRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &ReadStream{
+		ctx:      ctx,
+		client:   c,
+		sess:     sess,
+		peerAddr: peerAddr,
+		payload:  payload,
+		timeout:  c.timeout,
+		log:      c.log,
+	}
+	if err := s.send(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// send sends the ReadRequest on a new exchange.
+func (s *ReadStream) send() error {
+	c := s.client
+	handler := newReadStreamHandler(c.log)
+	exch, err := c.exchangeManager.NewExchange(
+		s.sess,
+		s.sess.LocalSessionID(),
+		s.peerAddr,
+		ProtocolID,
+		handler,
+	)
+	if err != nil {
+		return err
+	}
+	exch.SetResponseTimeout(c.responseTimeout)
+
+	if err := exch.SendMessageExpectResponse(uint8(imsg.OpcodeReadRequest), s.payload, true); err != nil {
+		exch.Close()
+		return err
+	}
+	s.exch, s.handler = exch, handler
+	return nil
+}
+
+// Next waits for the next chunk of the report, first acknowledging the
+// current one if more are to come. It returns false once the report is
+// complete or the read failed; Err tells which.
+func (s *ReadStream) Next() bool {
+	if s.done {
+		return false
+	}
+
+	if s.report != nil {
+		// Ask for the next chunk, or acknowledge a final one that
+		// expects it
+		if s.report.MoreChunkedMessages || !s.report.SuppressResponse {
+			if err := s.sendStatus(imsg.StatusSuccess); err != nil {
+				s.finish(err)
+				return false
+			}
+		}
+		if !s.report.MoreChunkedMessages {
+			s.finish(nil)
+			return false
+		}
+	}
+
+	var report *imsg.ReportDataMessage
+	wait := func() error {
+		var err error
+		report, err = s.handler.wait(s.ctx, s.timeout)
+		return err
+	}
+	var err error
+	if s.report == nil {
+		// Only the ReadRequest can be refused with Busy
+		err = s.client.withBusyRetry(s.ctx, func() error {
+			if s.exch == nil {
+				if err := s.send(); err != nil {
+					return err
+				}
+			}
+			err := wait()
+			if errors.Is(err, ErrBusy) {
+				s.exch.Close()
+				s.exch = nil
+			}
+			return err
+		})
+	} else {
+		err = wait()
+	}
+	if err != nil {
+		if s.log != nil {
+			s.log.Warnf("ReadStream error: %v", err)
+		}
+		s.finish(err)
+		return false
+	}
+	s.report = report
+	return true
+}
+
+// Report returns the current chunk.
+func (s *ReadStream) Report() *imsg.ReportDataMessage {
+	return s.report
+}
+
+// Err returns the error that ended the stream, or nil if the report was
+// read completely.
+func (s *ReadStream) Err() error {
+	return s.err
+}
+
+// Close ends the read. Closing before the last chunk abandons the rest
+// of the report.
+func (s *ReadStream) Close() error {
+	s.finish(nil)
+	return nil
+}
+
+// sendStatus sends a StatusResponse for the current chunk, expecting the
+// next chunk in response if more are to come.
+func (s *ReadStream) sendStatus(status imsg.Status) error {
+	payload, err := EncodeStatusResponse(status)
+	if err != nil {
+		return err
+	}
+	if s.report.MoreChunkedMessages {
+		return s.exch.SendMessageExpectResponse(uint8(imsg.OpcodeStatusResponse), payload, true)
+	}
+	return s.exch.SendMessage(uint8(imsg.OpcodeStatusResponse), payload, true)
+}
+
+// finish ends the stream with err, closing the exchange.
+func (s *ReadStream) finish(err error) {
+	if s.done {
+		return
+	}
+	s.done = true
+	s.err = err
+	if s.exch != nil {
+		s.exch.Close()
+	}
+	if s.span != nil {
+		endSpan(s.span, err)
+	}
+}
+
+// readStreamMessage is a ReportData chunk, the SubscribeResponse ending a
+// subscribe, or the error ending either.
+type readStreamMessage struct {
+	report   *imsg.ReportDataMessage
+	response *imsg.SubscribeResponseMessage
+	err      error
+}
+
+// readStreamHandler receives the messages of a read exchange.
+type readStreamHandler struct {
+	// messages holds the chunk awaiting the stream. The server sends the
+	// next one only after our StatusResponse, so one is enough.
+	messages chan readStreamMessage
+
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	log logging.LeveledLogger
+}
+
+func newReadStreamHandler(log logging.LeveledLogger) *readStreamHandler {
+	return &readStreamHandler{
+		messages: make(chan readStreamMessage, 1),
+		closed:   make(chan struct{}),
+		log:      log,
+	}
+}
+
+// OnMessage implements exchange.ExchangeDelegate.
+func (h *readStreamHandler) OnMessage(
+	ctx *exchange.ExchangeContext,
+	header *message.ProtocolHeader,
+	payload []byte,
+) ([]byte, error) {
+	opcode := imsg.Opcode(header.ProtocolOpcode)
+
+	if h.log != nil {
+		h.log.Tracef("readStreamHandler received opcode=%d (%s), exchangeID=%d, payloadLen=%d",
+			opcode, opcode.String(), header.ExchangeID, len(payload))
+	}
+
+	var msg readStreamMessage
+	switch opcode {
+	case imsg.OpcodeReportData:
+		msg.report, msg.err = DecodeReportData(payload)
+	case imsg.OpcodeSubscribeResponse:
+		msg.response, msg.err = DecodeSubscribeResponse(payload)
+	case imsg.OpcodeStatusResponse:
+		statusMsg, err := DecodeStatusResponse(payload)
+		switch {
+		case err != nil:
+			msg.err = err
+		case statusMsg.Status == imsg.StatusBusy:
+			msg.err = ErrBusy
+		default:
+			msg.err = errors.New("im: read failed with status: " + statusMsg.Status.String())
+		}
+	default:
+		if h.log != nil {
+			h.log.Warnf("readStreamHandler unexpected opcode=%d (%s), expected ReportData or StatusResponse",
+				opcode, opcode.String())
+		}
+		msg.err = ErrUnexpectedResponse
+	}
+
+	select {
+	case h.messages <- msg:
+	default:
+		// The peer sent a chunk before we asked for it
+		if h.log != nil {
+			h.log.Warnf("readStreamHandler dropped unrequested %s", opcode.String())
+		}
+	}
+	return nil, nil
+}
+
+// OnClose implements exchange.ExchangeDelegate.
+func (h *readStreamHandler) OnClose(ctx *exchange.ExchangeContext) {
+	h.closeOnce.Do(func() { close(h.closed) })
+}
+
+// OnResponseTimeout implements exchange.ResponseTimeoutDelegate.
+func (h *readStreamHandler) OnResponseTimeout(ctx *exchange.ExchangeContext) {
+	select {
+	case h.messages <- readStreamMessage{err: ErrClientTimeout}:
+	default:
+	}
+}
+
+// wait returns the next chunk, waiting at most timeout for it.
+func (h *readStreamHandler) wait(ctx context.Context, timeout time.Duration) (*imsg.ReportDataMessage, error) {
+	msg := h.waitMessage(ctx, timeout)
+	if msg.err == nil && msg.report == nil {
+		return nil, ErrUnexpectedResponse
+	}
+	return msg.report, msg.err
+}
+
+// waitMessage returns the next message, waiting at most timeout for it.
+func (h *readStreamHandler) waitMessage(ctx context.Context, timeout time.Duration) readStreamMessage {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case msg := <-h.messages:
+		return msg
+	case <-h.closed:
+		// A message may have arrived just before the exchange closed
+		select {
+		case msg := <-h.messages:
+			return msg
+		default:
+			return readStreamMessage{err: ErrClientClosed}
+		}
+	case <-timer.C:
+		return readStreamMessage{err: ErrClientTimeout}
+	case <-ctx.Done():
+		return readStreamMessage{err: ErrClientTimeout}
+	}
+}
@@ -0,0 +1,411 @@
+package im
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/backkem/matter/pkg/exchange"
+	imsg "github.com/backkem/matter/pkg/im/message"
+	"github.com/backkem/matter/pkg/session"
+	"github.com/backkem/matter/pkg/tlv"
+	"github.com/backkem/matter/pkg/transport"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultLivenessMargin is the default time a subscription's peer has
+// beyond MaxInterval for its next report to arrive.
+const DefaultLivenessMargin = 10 * time.Second
+
+// Client subscription errors.
+var (
+	// ErrNoEngine indicates the client has no engine to receive the
+	// reports of its subscriptions.
+	ErrNoEngine = errors.New("im: no engine for subscription reports")
+
+	// ErrSubscriptionLost indicates no report of a subscription arrived
+	// within its MaxInterval.
+	ErrSubscriptionLost = errors.New("im: subscription lost")
+
+	// ErrSubscriptionClosed indicates a subscription was closed.
+	ErrSubscriptionClosed = errors.New("im: subscription closed")
+)
+
+// ClientSubscription is a subscription the client made to a peer. The
+// peer's reports, assembled from their chunks, are handed to the
+// subscription's callback until it is closed or lost.
+//
+// The peer sends a report at least every MaxInterval, empty if nothing
+// changed. A subscription that receives none within MaxInterval and the
+// client's LivenessMargin is lost: Done is closed and Err returns
+// ErrSubscriptionLost. Subscribing again is up to the caller.
+//
+// C++ Reference: app::ReadClient
+type ClientSubscription struct {
+	client    *Client
+	id        imsg.SubscriptionID
+	sess      *session.SecureContext
+	peerAddr  transport.PeerAddress
+	onReport  func(*imsg.ReportDataMessage)
+	assembler *Assembler
+
+	mu          sync.Mutex
+	started     bool
+	maxInterval time.Duration
+	liveness    *time.Timer
+	err         error
+	done        chan struct{}
+}
+
+// Subscribe sends a SubscribeRequest and returns the subscription once the
+// peer confirms it with a SubscribeResponse. onReport receives the priming
+// report before Subscribe returns, then each report with changes; empty
+// liveness reports are not handed to it. It runs on the goroutine
+// receiving the report and should not block.
+//
+// Later reports arrive on exchanges the peer opens, which the client's
+// Engine routes to the subscription by its ID: Subscribe fails with
+// ErrNoEngine without one.
+//
+// Spec: Section 8.5 (subscribe interaction)
+// C++ Reference: ReadClient::SendSubscribeRequest
+func (c *Client) Subscribe(
+	ctx context.Context,
+	sess *session.SecureContext,
+	peerAddr transport.PeerAddress,
+	req *imsg.SubscribeRequestMessage,
+	onReport func(*imsg.ReportDataMessage),
+) (sub *ClientSubscription, err error) {
+	ctx, span := c.tracer.Start(ctx, "im.subscribe", trace.WithSpanKind(trace.SpanKindClient))
+	defer func() { endSpan(span, err) }()
+
+	if c.engine == nil {
+		return nil, ErrNoEngine
+	}
+
+	// Apply timeout
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	payload, err := EncodeMessage(req.Encode)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.withBusyRetry(ctx, func() error {
+		var err error
+		sub, err = c.subscribe(ctx, sess, peerAddr, payload, onReport)
+		return err
+	})
+	if err != nil {
+		if c.log != nil {
+			c.log.Warnf("Subscribe error: %v", err)
+		}
+		return nil, err
+	}
+	return sub, nil
+}
+
+// subscribe sends an encoded SubscribeRequest on a new exchange and
+// acknowledges the chunks of the priming report until the
+// SubscribeResponse arrives.
+func (c *Client) subscribe(
+	ctx context.Context,
+	sess *session.SecureContext,
+	peerAddr transport.PeerAddress,
+	payload []byte,
+	onReport func(*imsg.ReportDataMessage),
+) (*ClientSubscription, error) {
+	handler := newReadStreamHandler(c.log)
+	exch, err := c.exchangeManager.NewExchange(
+		sess,
+		sess.LocalSessionID(),
+		peerAddr,
+		ProtocolID,
+		handler,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer exch.Close()
+	exch.SetResponseTimeout(c.responseTimeout)
+
+	if err := exch.SendMessageExpectResponse(uint8(imsg.OpcodeSubscribeRequest), payload, true); err != nil {
+		return nil, err
+	}
+
+	var sub *ClientSubscription
+	fail := func(err error) (*ClientSubscription, error) {
+		if sub != nil {
+			sub.end(err)
+		}
+		return nil, err
+	}
+	for {
+		msg := handler.waitMessage(ctx, c.timeout)
+		if msg.err != nil {
+			return fail(msg.err)
+		}
+
+		if resp := msg.response; resp != nil {
+			if sub == nil || resp.SubscriptionID != sub.id {
+				return fail(ErrUnexpectedResponse)
+			}
+			sub.start(resp.MaxInterval)
+			if c.log != nil {
+				c.log.Debugf("Subscribe: subscription %d established, maxInterval=%ds", resp.SubscriptionID, resp.MaxInterval)
+			}
+			return sub, nil
+		}
+
+		report := msg.report
+		if report.SubscriptionID == nil || (sub != nil && *report.SubscriptionID != sub.id) {
+			return fail(ErrUnexpectedResponse)
+		}
+		if sub == nil {
+			// Register before acknowledging, so that no report of the
+			// subscription finds the engine unaware of it
+			sub = &ClientSubscription{
+				client:    c,
+				id:        *report.SubscriptionID,
+				sess:      sess,
+				peerAddr:  peerAddr,
+				onReport:  onReport,
+				assembler: NewAssembler(),
+				done:      make(chan struct{}),
+			}
+			c.engine.addClientSubscription(sub)
+		}
+		if err := sub.handleReport(report); err != nil {
+			return fail(err)
+		}
+
+		status, err := EncodeStatusResponse(imsg.StatusSuccess)
+		if err != nil {
+			return fail(err)
+		}
+		if err := exch.SendMessageExpectResponse(uint8(imsg.OpcodeStatusResponse), status, true); err != nil {
+			return fail(err)
+		}
+	}
+}
+
+// ID returns the subscription's ID, assigned by the peer.
+func (s *ClientSubscription) ID() imsg.SubscriptionID {
+	return s.id
+}
+
+// LocalSessionID returns the local ID of the session the subscription
+// was made over.
+func (s *ClientSubscription) LocalSessionID() uint16 {
+	return s.sess.LocalSessionID()
+}
+
+// MaxInterval returns the longest time between two reports the peer
+// agreed to.
+func (s *ClientSubscription) MaxInterval() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.maxInterval
+}
+
+// Done returns a channel closed when the subscription ends.
+func (s *ClientSubscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns why the subscription ended: ErrSubscriptionLost,
+// ErrSubscriptionClosed, or nil while it is active.
+func (s *ClientSubscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close ends the subscription. The peer's next report of changes is
+// answered with InvalidSubscription, which ends it on the peer.
+func (s *ClientSubscription) Close() error {
+	s.end(ErrSubscriptionClosed)
+	return nil
+}
+
+// start arms the liveness timer once the peer confirmed the subscription.
+func (s *ClientSubscription) start(maxInterval uint16) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.started = true
+	s.maxInterval = time.Duration(maxInterval) * time.Second
+	s.resetLivenessLocked()
+}
+
+// handleReport hands a complete report to the callback and rearms the
+// liveness timer.
+func (s *ClientSubscription) handleReport(report *imsg.ReportDataMessage) error {
+	s.mu.Lock()
+	if s.err != nil {
+		s.mu.Unlock()
+		return s.err
+	}
+	s.resetLivenessLocked()
+	s.mu.Unlock()
+
+	complete, ok, err := s.assembler.AddReportData(report)
+	if err != nil {
+		return err
+	}
+	if ok && s.onReport != nil && (len(complete.AttributeReports) > 0 || len(complete.EventReports) > 0) {
+		s.onReport(complete)
+	}
+	return nil
+}
+
+// resetLivenessLocked rearms the liveness timer, if the subscription is
+// established.
+// Must be called with s.mu held.
+func (s *ClientSubscription) resetLivenessLocked() {
+	if !s.started {
+		return
+	}
+	if s.liveness != nil {
+		s.liveness.Stop()
+	}
+	s.liveness = time.AfterFunc(s.maxInterval+s.client.livenessMargin, func() {
+		if s.client.log != nil {
+			s.client.log.Warnf("subscription %d: no report within %v", s.id, s.MaxInterval())
+		}
+		s.end(ErrSubscriptionLost)
+	})
+}
+
+// end ends the subscription with err, unless it has ended.
+func (s *ClientSubscription) end(err error) {
+	s.mu.Lock()
+	if s.err != nil {
+		s.mu.Unlock()
+		return
+	}
+	s.err = err
+	if s.liveness != nil {
+		s.liveness.Stop()
+	}
+	close(s.done)
+	s.mu.Unlock()
+
+	s.client.engine.removeClientSubscription(s)
+}
+
+// isFrom reports whether a report on a session may be from the
+// subscription's peer: over the subscription's session, or over another
+// CASE session with the same node, e.g. after the peer resumed the
+// subscription following a restart.
+func (s *ClientSubscription) isFrom(sess *session.SecureContext) bool {
+	if sess == s.sess {
+		return true
+	}
+	return sess.SessionType() == session.SessionTypeCASE &&
+		s.sess.SessionType() == session.SessionTypeCASE &&
+		sess.FabricIndex() == s.sess.FabricIndex() &&
+		sess.PeerNodeID() == s.sess.PeerNodeID()
+}
+
+// addClientSubscription routes the reports of a client's subscription to
+// it.
+func (e *Engine) addClientSubscription(sub *ClientSubscription) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.clientSubscriptions[sub.id] = append(e.clientSubscriptions[sub.id], sub)
+}
+
+// removeClientSubscription stops routing reports to a client's
+// subscription.
+func (e *Engine) removeClientSubscription(sub *ClientSubscription) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	subs := e.clientSubscriptions[sub.id]
+	for i, s := range subs {
+		if s == sub {
+			subs = append(subs[:i], subs[i+1:]...)
+			break
+		}
+	}
+	if len(subs) == 0 {
+		delete(e.clientSubscriptions, sub.id)
+	} else {
+		e.clientSubscriptions[sub.id] = subs
+	}
+}
+
+// clientSubscription returns the subscription of a client a report on the
+// exchange is for. IDs are assigned by each peer, so the session tells
+// apart subscriptions to different peers with the same ID.
+func (e *Engine) clientSubscription(ctx *exchange.ExchangeContext, id imsg.SubscriptionID) *ClientSubscription {
+	if ctx == nil {
+		return nil
+	}
+	sess, ok := ctx.Session().(*session.SecureContext)
+	if !ok {
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, sub := range e.clientSubscriptions[id] {
+		if sub.isFrom(sess) {
+			return sub
+		}
+	}
+	return nil
+}
+
+// handleClientReport routes a report a peer sends for a subscription made
+// by one of the node's clients, and acknowledges it. A report of an
+// unknown subscription is answered with InvalidSubscription, which ends
+// it on the peer. A SubscribeResponse that follows the priming report of
+// a subscription the peer resumed needs no answer.
+//
+// Spec: Section 8.5.2 (reporting)
+// C++ Reference: InteractionModelEngine::OnUnsolicitedReportData
+func (e *Engine) handleClientReport(ctx *exchange.ExchangeContext, opcode imsg.Opcode, payload []byte) ([]byte, error) {
+	if opcode == imsg.OpcodeSubscribeResponse {
+		resp, err := DecodeSubscribeResponse(payload)
+		if err != nil {
+			return nil, nil
+		}
+		if sub := e.clientSubscription(ctx, resp.SubscriptionID); sub != nil {
+			sub.start(resp.MaxInterval)
+		}
+		return nil, nil
+	}
+
+	report, err := DecodeReportData(payload)
+	if err != nil || report.SubscriptionID == nil {
+		return e.replyStatus(ctx, imsg.StatusInvalidAction)
+	}
+	sub := e.clientSubscription(ctx, *report.SubscriptionID)
+	if sub == nil {
+		return e.replyStatus(ctx, imsg.StatusInvalidSubscription)
+	}
+	if err := sub.handleReport(report); err != nil {
+		return e.replyStatus(ctx, imsg.StatusInvalidSubscription)
+	}
+	if report.SuppressResponse && !report.MoreChunkedMessages {
+		return nil, nil
+	}
+	return e.replyStatus(ctx, imsg.StatusSuccess)
+}
+
+// DecodeSubscribeResponse decodes a SubscribeResponseMessage from TLV.
+func DecodeSubscribeResponse(data []byte) (*imsg.SubscribeResponseMessage, error) {
+	r := tlv.NewReader(bytes.NewReader(data))
+	var msg imsg.SubscribeResponseMessage
+	if err := msg.Decode(r); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
@@ -0,0 +1,340 @@
+package im
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/backkem/matter/pkg/exchange"
+	imsg "github.com/backkem/matter/pkg/im/message"
+	"github.com/backkem/matter/pkg/message"
+	"github.com/backkem/matter/pkg/session"
+	"github.com/backkem/matter/pkg/tlv"
+	"github.com/backkem/matter/pkg/transport"
+	"github.com/pion/logging"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WriteStatusError is returned for an attribute write the peer refused
+// with a status. It matches the error of its status with errors.Is, e.g.
+// ErrDataVersionMismatch when the attribute's cluster changed since the
+// version the write was conditioned on.
+type WriteStatusError struct {
+	// Path is the attribute written.
+	Path imsg.AttributePathIB
+
+	// Status is the status of the write.
+	Status imsg.StatusIB
+}
+
+// Error implements error.
+func (e *WriteStatusError) Error() string {
+	msg := "im: attribute write failed: " + e.Status.Status.String()
+	if e.Status.ClusterStatus != nil {
+		msg += fmt.Sprintf(" (cluster status 0x%02x)", *e.Status.ClusterStatus)
+	}
+	return msg
+}
+
+// Unwrap returns the error of the status, see StatusToError.
+func (e *WriteStatusError) Unwrap() error {
+	return StatusToError(e.Status.Status)
+}
+
+// Write sends a WriteRequest and returns the peer's WriteResponse, with a
+// status per attribute written. An AttributeDataIB whose DataVersion is
+// not zero is only written if its cluster is still at that version; the
+// others fail with DataVersionMismatch.
+//
+// The request must fit one message: chunked writes are not supported.
+// A request with SuppressResponse set returns a nil response once sent.
+//
+// Spec: Section 8.7 (write interaction)
+func (c *Client) Write(
+	ctx context.Context,
+	sess *session.SecureContext,
+	peerAddr transport.PeerAddress,
+	req *imsg.WriteRequestMessage,
+) (resp *imsg.WriteResponseMessage, err error) {
+	ctx, span := c.tracer.Start(ctx, "im.write", trace.WithSpanKind(trace.SpanKindClient))
+	defer func() { endSpan(span, err) }()
+
+	// Apply timeout
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	payload, err := EncodeWriteRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.withBusyRetry(ctx, func() error {
+		var err error
+		resp, err = c.write(ctx, sess, peerAddr, payload, req.SuppressResponse)
+		return err
+	})
+	if err != nil {
+		if c.log != nil {
+			c.log.Warnf("Write error: %v", err)
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+// WriteAttribute writes a single attribute. data is the TLV-encoded
+// value. A write the peer refuses fails with a *WriteStatusError.
+func (c *Client) WriteAttribute(
+	ctx context.Context,
+	sess *session.SecureContext,
+	peerAddr transport.PeerAddress,
+	endpointID uint16,
+	clusterID uint32,
+	attributeID uint32,
+	data []byte,
+) error {
+	return c.writeAttribute(ctx, sess, peerAddr, endpointID, clusterID, attributeID, data, 0)
+}
+
+// WriteAttributeWithVersion writes a single attribute if its cluster is
+// still at dataVersion, e.g. as read by ReadAttributeWithVersion.
+// Otherwise the write fails with a *WriteStatusError matching
+// ErrDataVersionMismatch, and the attribute is left as it is.
+//
+// Version 0 cannot be a precondition: the write is then unconditional.
+func (c *Client) WriteAttributeWithVersion(
+	ctx context.Context,
+	sess *session.SecureContext,
+	peerAddr transport.PeerAddress,
+	endpointID uint16,
+	clusterID uint32,
+	attributeID uint32,
+	data []byte,
+	dataVersion imsg.DataVersion,
+) error {
+	return c.writeAttribute(ctx, sess, peerAddr, endpointID, clusterID, attributeID, data, dataVersion)
+}
+
+// UpdateAttribute reads an attribute, applies mutate to its TLV-encoded
+// value and writes the result back, conditioned on the data version read.
+// If another client changed the cluster in between, the write fails with
+// DataVersionMismatch; the client then reads the attribute and applies
+// mutate again, up to its RetryOnVersionMismatch times.
+//
+// An error from mutate ends the update without writing.
+func (c *Client) UpdateAttribute(
+	ctx context.Context,
+	sess *session.SecureContext,
+	peerAddr transport.PeerAddress,
+	endpointID uint16,
+	clusterID uint32,
+	attributeID uint32,
+	mutate func(current []byte) ([]byte, error),
+) error {
+	for retry := 0; ; retry++ {
+		current, version, err := c.ReadAttributeWithVersion(ctx, sess, peerAddr, endpointID, clusterID, attributeID)
+		if err != nil {
+			return err
+		}
+		data, err := mutate(current)
+		if err != nil {
+			return err
+		}
+		err = c.WriteAttributeWithVersion(ctx, sess, peerAddr, endpointID, clusterID, attributeID, data, version)
+		if !errors.Is(err, ErrDataVersionMismatch) || retry >= c.versionRetries {
+			return err
+		}
+		if c.log != nil {
+			c.log.Debugf("UpdateAttribute: endpoint=%d, cluster=0x%04x, attribute=0x%04x changed, retrying (%d/%d)",
+				endpointID, clusterID, attributeID, retry+1, c.versionRetries)
+		}
+	}
+}
+
+// writeAttribute writes a single attribute, conditioned on dataVersion
+// unless it is zero.
+func (c *Client) writeAttribute(
+	ctx context.Context,
+	sess *session.SecureContext,
+	peerAddr transport.PeerAddress,
+	endpointID uint16,
+	clusterID uint32,
+	attributeID uint32,
+	data []byte,
+	dataVersion imsg.DataVersion,
+) (err error) {
+	ctx, span := c.tracer.Start(ctx, "im.write",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attributeAttributes(endpointID, clusterID, attributeID)...))
+	defer func() { endSpan(span, err) }()
+
+	epID := imsg.EndpointID(endpointID)
+	clID := imsg.ClusterID(clusterID)
+	atID := imsg.AttributeID(attributeID)
+	req := &imsg.WriteRequestMessage{
+		WriteRequests: []imsg.AttributeDataIB{
+			{
+				DataVersion: dataVersion,
+				Path: imsg.AttributePathIB{
+					Endpoint:  &epID,
+					Cluster:   &clID,
+					Attribute: &atID,
+				},
+				Data: data,
+			},
+		},
+	}
+
+	if c.log != nil {
+		c.log.Debugf("WriteAttribute: endpoint=%d, cluster=0x%04x, attribute=0x%04x, dataVersion=%d",
+			endpointID, clusterID, attributeID, dataVersion)
+	}
+
+	resp, err := c.Write(ctx, sess, peerAddr, req)
+	if err != nil {
+		return err
+	}
+	if len(resp.WriteResponses) == 0 {
+		return ErrUnexpectedResponse
+	}
+	status := resp.WriteResponses[0]
+	if status.Status.Status != imsg.StatusSuccess {
+		return &WriteStatusError{Path: status.Path, Status: status.Status}
+	}
+	return nil
+}
+
+// write sends an encoded WriteRequest on a new exchange and waits for the
+// response, unless it is suppressed.
+func (c *Client) write(
+	ctx context.Context,
+	sess *session.SecureContext,
+	peerAddr transport.PeerAddress,
+	payload []byte,
+	suppressResponse bool,
+) (*imsg.WriteResponseMessage, error) {
+	handler := newWriteResponseHandler(c.log)
+	exch, err := c.exchangeManager.NewExchange(
+		sess,
+		sess.LocalSessionID(),
+		peerAddr,
+		ProtocolID,
+		handler,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer exch.Close()
+	exch.SetResponseTimeout(c.responseTimeout)
+
+	if suppressResponse {
+		return nil, exch.SendMessage(uint8(imsg.OpcodeWriteRequest), payload, true)
+	}
+	if err := exch.SendMessageExpectResponse(uint8(imsg.OpcodeWriteRequest), payload, true); err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ErrClientTimeout
+	case result := <-handler.resultCh:
+		return result.resp, result.err
+	}
+}
+
+// writeResult is the WriteResponse to a write, or the error ending it.
+type writeResult struct {
+	resp *imsg.WriteResponseMessage
+	err  error
+}
+
+// writeResponseHandler receives the response of a write exchange.
+type writeResponseHandler struct {
+	resultCh chan writeResult
+	once     sync.Once
+	log      logging.LeveledLogger
+}
+
+func newWriteResponseHandler(log logging.LeveledLogger) *writeResponseHandler {
+	return &writeResponseHandler{
+		resultCh: make(chan writeResult, 1),
+		log:      log,
+	}
+}
+
+// OnMessage implements exchange.ExchangeDelegate.
+func (h *writeResponseHandler) OnMessage(
+	ctx *exchange.ExchangeContext,
+	header *message.ProtocolHeader,
+	payload []byte,
+) ([]byte, error) {
+	opcode := imsg.Opcode(header.ProtocolOpcode)
+
+	switch opcode {
+	case imsg.OpcodeWriteResponse:
+		resp, err := DecodeWriteResponse(payload)
+		h.send(writeResult{resp: resp, err: err})
+	case imsg.OpcodeStatusResponse:
+		// The whole request was refused
+		status, err := DecodeStatusResponse(payload)
+		if err == nil {
+			err = StatusToError(status.Status)
+			if err == nil {
+				err = ErrUnexpectedResponse
+			}
+		}
+		h.send(writeResult{err: err})
+	default:
+		if h.log != nil {
+			h.log.Warnf("writeResponseHandler unexpected opcode=%d (%s), expected WriteResponse or StatusResponse",
+				opcode, opcode.String())
+		}
+		h.send(writeResult{err: ErrUnexpectedResponse})
+	}
+	return nil, nil
+}
+
+// OnClose implements exchange.ExchangeDelegate.
+func (h *writeResponseHandler) OnClose(ctx *exchange.ExchangeContext) {
+	h.send(writeResult{err: ErrClientClosed})
+}
+
+// OnResponseTimeout implements exchange.ResponseTimeoutDelegate.
+func (h *writeResponseHandler) OnResponseTimeout(ctx *exchange.ExchangeContext) {
+	h.send(writeResult{err: ErrClientTimeout})
+}
+
+func (h *writeResponseHandler) send(result writeResult) {
+	h.once.Do(func() {
+		h.resultCh <- result
+	})
+}
+
+// EncodeWriteRequest encodes a WriteRequestMessage to TLV.
+func EncodeWriteRequest(req *imsg.WriteRequestMessage) ([]byte, error) {
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+
+	if err := req.Encode(w); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// DecodeWriteResponse decodes a WriteResponseMessage from TLV.
+func DecodeWriteResponse(data []byte) (*imsg.WriteResponseMessage, error) {
+	r := tlv.NewReader(bytes.NewReader(data))
+
+	msg := &imsg.WriteResponseMessage{}
+	if err := msg.Decode(r); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/backkem/matter/pkg/acl"
 	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/fabric"
 	"github.com/backkem/matter/pkg/im/message"
 	"github.com/backkem/matter/pkg/tlv"
 )
@@ -25,6 +26,47 @@ type Dispatcher interface {
 	InvokeCommand(ctx context.Context, req *CommandInvokeRequest, r *tlv.Reader) ([]byte, error)
 }
 
+// CommandResponder is implemented by Dispatchers that return typed command
+// responses, see datamodel.InvokeCommandResponse. The Engine answers with
+// the response command they name; for other Dispatchers, it takes the
+// response command to follow the request command.
+type CommandResponder interface {
+	// InvokeCommandResponse invokes a cluster command, like InvokeCommand,
+	// and returns its response, or nil for a status-only response.
+	InvokeCommandResponse(ctx context.Context, req *CommandInvokeRequest, r *tlv.Reader) (*datamodel.CommandResponse, error)
+}
+
+// DataVersionProvider is implemented by Dispatchers that track the data
+// version of each cluster instance. The Engine reports the versions in
+// ReportData and honors the DataVersionFilters of reads and subscriptions
+// with it; Dispatchers without it report version 0 and ignore filters.
+type DataVersionProvider interface {
+	// ClusterDataVersion returns the data version of a cluster instance,
+	// and false if the cluster is unknown.
+	ClusterDataVersion(endpoint message.EndpointID, cluster message.ClusterID) (message.DataVersion, bool)
+}
+
+// GroupMembership is implemented by Dispatchers that know the endpoints
+// in each group, as configured through the Groups cluster. The Engine
+// executes group commands on these endpoints; Dispatchers without it
+// drop group commands.
+type GroupMembership interface {
+	// GroupEndpoints returns the endpoints that are members of a group
+	// on a fabric.
+	GroupEndpoints(fabricIndex fabric.FabricIndex, groupID uint16) []message.EndpointID
+}
+
+// PathExpander is implemented by Dispatchers that know the endpoints,
+// clusters and attributes of the node. The Engine expands the wildcard
+// attribute paths of reads and subscriptions with it; Dispatchers
+// without it only serve concrete paths.
+type PathExpander interface {
+	// ExpandAttributePath returns the concrete paths of the readable
+	// attributes a wildcard path matches, in endpoint, cluster and
+	// attribute order.
+	ExpandAttributePath(path message.AttributePathIB) []message.AttributePathIB
+}
+
 // AttributeReadRequest contains parameters for reading an attribute via IM.
 type AttributeReadRequest struct {
 	// Path identifies the attribute to read.
@@ -138,6 +180,11 @@ func (r *CommandInvokeRequest) ToDataModelRequest() datamodel.InvokeRequest {
 			NodeID:      r.IMContext.Subject.Subject,
 			AuthMode:    toDataModelAuthMode(r.IMContext.Subject.AuthMode),
 		}
+		if r.IMContext.Exchange != nil {
+			if sess, ok := r.IMContext.Exchange.Session().(datamodel.SecureSession); ok {
+				req.Session = sess
+			}
+		}
 	}
 
 	return req
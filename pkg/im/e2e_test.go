@@ -1,11 +1,23 @@
 package im
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/backkem/matter/pkg/clusters/onoff"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/exchange"
 	imsg "github.com/backkem/matter/pkg/im/message"
+	"github.com/backkem/matter/pkg/message"
+	"github.com/backkem/matter/pkg/session"
+	"github.com/backkem/matter/pkg/tlv"
+	"github.com/backkem/matter/pkg/transport"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // =============================================================================
@@ -215,6 +227,273 @@ func TestE2E_ReadAttribute(t *testing.T) {
 	t.Logf("E2E ReadAttribute: path=%+v, data=%x", call.Path, data)
 }
 
+// chunkedReadRequest reads enough attributes for the report to be chunked.
+func chunkedReadRequest(count int) *imsg.ReadRequestMessage {
+	req := &imsg.ReadRequestMessage{FabricFiltered: true}
+	for i := 0; i < count; i++ {
+		req.AttributeRequests = append(req.AttributeRequests, makeAttrPath(1, 0x0006, imsg.AttributeID(i)))
+	}
+	return req
+}
+
+// TestE2E_ReadStream tests a chunked read consumed chunk by chunk.
+func TestE2E_ReadStream(t *testing.T) {
+	// A few attributes per chunk
+	mockDispatcher := NewMockDispatcher()
+	mockDispatcher.SetReadResult(make([]byte, 200), nil)
+
+	pair, err := NewSecureTestIMPair(SecureTestIMPairConfig{
+		Dispatchers: [2]Dispatcher{nil, mockDispatcher},
+	})
+	if err != nil {
+		t.Fatalf("NewSecureTestIMPair: %v", err)
+	}
+	defer pair.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	const count = 20
+	stream, err := pair.Client(0).ReadStream(ctx, pair.Session(0), pair.PeerAddress(1), chunkedReadRequest(count))
+	if err != nil {
+		t.Fatalf("ReadStream: %v", err)
+	}
+	defer stream.Close()
+
+	chunks, reports := 0, 0
+	for stream.Next() {
+		chunks++
+		for i, report := range stream.Report().AttributeReports {
+			if got := *report.AttributeData.Path.Attribute; got != imsg.AttributeID(reports+i) {
+				t.Fatalf("report %d: attribute %d", reports+i, got)
+			}
+		}
+		reports += len(stream.Report().AttributeReports)
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	if chunks < 2 {
+		t.Errorf("report sent in %d chunk(s), want several", chunks)
+	}
+	if reports != count {
+		t.Errorf("got %d attribute reports, want %d", reports, count)
+	}
+}
+
+// TestE2E_Read_Chunked tests a chunked read assembled into one report.
+func TestE2E_Read_Chunked(t *testing.T) {
+	// A few attributes per chunk
+	mockDispatcher := NewMockDispatcher()
+	mockDispatcher.SetReadResult(make([]byte, 200), nil)
+
+	pair, err := NewSecureTestIMPair(SecureTestIMPairConfig{
+		Dispatchers: [2]Dispatcher{nil, mockDispatcher},
+	})
+	if err != nil {
+		t.Fatalf("NewSecureTestIMPair: %v", err)
+	}
+	defer pair.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	const count = 20
+	report, err := pair.Client(0).Read(ctx, pair.Session(0), pair.PeerAddress(1), chunkedReadRequest(count))
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(report.AttributeReports) != count {
+		t.Errorf("got %d attribute reports, want %d", len(report.AttributeReports), count)
+	}
+}
+
+// silentHandler receives IM messages without ever responding.
+type silentHandler struct{}
+
+func (silentHandler) OnMessage(ctx *exchange.ExchangeContext, opcode uint8, payload []byte) ([]byte, error) {
+	return nil, nil
+}
+
+func (silentHandler) OnUnsolicited(ctx *exchange.ExchangeContext, opcode uint8, payload []byte) ([]byte, error) {
+	return nil, nil
+}
+
+// TestE2E_ReadStream_Timeout tests a read whose report never arrives.
+func TestE2E_ReadStream_Timeout(t *testing.T) {
+	pair, err := NewSecureTestIMPair(SecureTestIMPairConfig{})
+	if err != nil {
+		t.Fatalf("NewSecureTestIMPair: %v", err)
+	}
+	defer pair.Close()
+	pair.ExchangePair().Manager(1).RegisterProtocol(ProtocolID, silentHandler{})
+
+	client := NewClient(ClientConfig{
+		ExchangeManager: pair.ExchangePair().Manager(0),
+		Timeout:         100 * time.Millisecond,
+	})
+
+	stream, err := client.ReadStream(context.Background(), pair.Session(0), pair.PeerAddress(1), chunkedReadRequest(1))
+	if err != nil {
+		t.Fatalf("ReadStream: %v", err)
+	}
+	defer stream.Close()
+
+	if stream.Next() {
+		t.Fatal("Next() = true without a report")
+	}
+	if !errors.Is(stream.Err(), ErrClientTimeout) {
+		t.Errorf("Err() = %v, want %v", stream.Err(), ErrClientTimeout)
+	}
+}
+
+// TestE2E_Invoke_ResponseTimeout tests an invoke whose response never
+// arrives: the exchange's response timeout ends it before the request
+// timeout.
+func TestE2E_Invoke_ResponseTimeout(t *testing.T) {
+	pair, err := NewSecureTestIMPair(SecureTestIMPairConfig{})
+	if err != nil {
+		t.Fatalf("NewSecureTestIMPair: %v", err)
+	}
+	defer pair.Close()
+	pair.ExchangePair().Manager(1).RegisterProtocol(ProtocolID, silentHandler{})
+
+	client := NewClient(ClientConfig{
+		ExchangeManager: pair.ExchangePair().Manager(0),
+		ResponseTimeout: 50 * time.Millisecond,
+	})
+
+	start := time.Now()
+	_, err = client.InvokeWithStatus(context.Background(), pair.Session(0), pair.PeerAddress(1), 1, 0x0006, 0x01, nil)
+	if !errors.Is(err, ErrClientTimeout) {
+		t.Errorf("InvokeWithStatus() error = %v, want %v", err, ErrClientTimeout)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("InvokeWithStatus() returned after %v, want the response timeout", elapsed)
+	}
+}
+
+// TestE2E_Invoke_DeferredResponse tests a command that completes after
+// InvokeCommand returns: the engine answers once the cluster completes it.
+func TestE2E_Invoke_DeferredResponse(t *testing.T) {
+	dispatcher := &testDispatcher{
+		invokeFunc: func(ctx context.Context, req *CommandInvokeRequest, r *tlv.Reader) ([]byte, error) {
+			resp := datamodel.NewDeferredResponse()
+			go func() {
+				time.Sleep(200 * time.Millisecond)
+				resp.Complete([]byte{0x15, 0x24, 0x00, 0x00, 0x18}, nil)
+			}()
+			return nil, resp
+		},
+	}
+
+	pair, err := NewSecureTestIMPair(SecureTestIMPairConfig{
+		Dispatchers: [2]Dispatcher{nil, dispatcher},
+	})
+	if err != nil {
+		t.Fatalf("NewSecureTestIMPair: %v", err)
+	}
+	defer pair.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	result, err := pair.Client(0).InvokeWithStatus(ctx, pair.Session(0), pair.PeerAddress(1), 0, 0x0031, 0x06, nil)
+	if err != nil {
+		t.Fatalf("InvokeWithStatus: %v", err)
+	}
+	if result.HasStatus || !bytes.Contains(result.ResponseData, []byte{0x24, 0x00, 0x00}) {
+		t.Errorf("result = %+v, want the deferred response fields", result)
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("InvokeWithStatus() returned after %v, before the command completed", elapsed)
+	}
+}
+
+// TestE2E_GroupInvoke tests a command sent to a group: one encrypted
+// message to the group address, for all endpoints, without a response.
+func TestE2E_GroupInvoke(t *testing.T) {
+	f0, f1 := transport.NewPipeFactoryPair()
+	defer f0.Pipe().Close()
+
+	conn0, _ := f0.CreateUDPConn(5540)
+	conn1, _ := f1.CreateUDPConn(5540)
+
+	tm0, err := transport.NewManager(transport.ManagerConfig{
+		UDPConn:        conn0,
+		UDPEnabled:     true,
+		MessageHandler: func(*transport.ReceivedMessage) {},
+	})
+	if err != nil {
+		t.Fatalf("transport.NewManager: %v", err)
+	}
+	received := make(chan []byte, 1)
+	tm1, err := transport.NewManager(transport.ManagerConfig{
+		UDPConn:    conn1,
+		UDPEnabled: true,
+		MessageHandler: func(msg *transport.ReceivedMessage) {
+			received <- bytes.Clone(msg.Data)
+		},
+	})
+	if err != nil {
+		t.Fatalf("transport.NewManager: %v", err)
+	}
+	if err := tm1.JoinGroup(1, 0x0101); err != nil {
+		t.Fatalf("JoinGroup: %v", err)
+	}
+	if err := tm1.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer tm1.Stop()
+
+	group, err := session.NewGroupContext(session.GroupContextConfig{
+		SourceNodeID:   0x1234,
+		FabricIndex:    1,
+		GroupID:        0x0101,
+		GroupSessionID: 0x5A5A,
+		OperationalKey: make([]byte, session.SessionKeySize),
+		Counter:        message.NewMessageCounter(),
+	})
+	if err != nil {
+		t.Fatalf("NewGroupContext: %v", err)
+	}
+
+	client := NewClient(ClientConfig{
+		ExchangeManager: exchange.NewManager(exchange.ManagerConfig{TransportManager: tm0}),
+	})
+	peerAddr := transport.NewUDPPeerAddress(f1.LocalAddr())
+	if err := client.GroupInvoke(context.Background(), group, peerAddr, 0x0006, 0x02, nil); err != nil {
+		t.Fatalf("GroupInvoke: %v", err)
+	}
+
+	var data []byte
+	select {
+	case data = <-received:
+	case <-time.After(time.Second):
+		t.Fatal("group invoke not received")
+	}
+
+	frame, err := group.Decrypt(data)
+	if err != nil {
+		t.Fatalf("Decrypt: %v", err)
+	}
+	req, err := DecodeInvokeRequest(frame.Payload)
+	if err != nil {
+		t.Fatalf("DecodeInvokeRequest: %v", err)
+	}
+	if !req.SuppressResponse {
+		t.Error("group invoke should suppress responses")
+	}
+	if len(req.InvokeRequests) != 1 {
+		t.Fatalf("got %d commands, want 1", len(req.InvokeRequests))
+	}
+	path := req.InvokeRequests[0].Path
+	if !path.WildcardEndpoint || path.Cluster != 0x0006 || path.Command != 0x02 {
+		t.Errorf("path = %+v, want wildcard endpoint 0x0006/0x02", path)
+	}
+}
+
 // TestE2E_ReadAttribute_Error tests attribute read that returns error.
 func TestE2E_ReadAttribute_Error(t *testing.T) {
 	mockDispatcher := NewMockDispatcher()
@@ -250,6 +529,225 @@ func TestE2E_ReadAttribute_Error(t *testing.T) {
 	t.Logf("E2E ReadAttribute error: %v", err)
 }
 
+// TestE2E_WriteAttribute_DataVersion tests writes conditioned on the
+// cluster's data version, and UpdateAttribute retrying after a concurrent
+// change.
+func TestE2E_WriteAttribute_DataVersion(t *testing.T) {
+	light := onoff.New(onoff.Config{EndpointID: 1, FeatureMap: onoff.FeatureLighting})
+	dispatcher := NewClusterDispatcher()
+	dispatcher.RegisterCluster(1, light)
+
+	pair, err := NewSecureTestIMPair(SecureTestIMPairConfig{
+		Dispatchers: [2]Dispatcher{nil, dispatcher},
+	})
+	if err != nil {
+		t.Fatalf("NewSecureTestIMPair: %v", err)
+	}
+	defer pair.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cluster, attr := uint32(onoff.ClusterID), uint32(onoff.AttrOnTime)
+	readOnTime := func() uint64 {
+		t.Helper()
+		data, err := pair.Client(0).ReadAttribute(ctx, pair.Session(0), pair.PeerAddress(1), 1, cluster, attr)
+		if err != nil {
+			t.Fatalf("ReadAttribute: %v", err)
+		}
+		return decodeUint(t, data)
+	}
+
+	_, version, err := pair.Client(0).ReadAttributeWithVersion(ctx, pair.Session(0), pair.PeerAddress(1), 1, cluster, attr)
+	if err != nil {
+		t.Fatalf("ReadAttributeWithVersion: %v", err)
+	}
+	if version != light.DataVersion() {
+		t.Fatalf("DataVersion = %d, want %d", version, light.DataVersion())
+	}
+
+	// The version read allows one write, which moves the cluster on
+	if err := pair.Client(0).WriteAttributeWithVersion(ctx, pair.Session(0), pair.PeerAddress(1), 1, cluster, attr, encodeUint(t, 10), version); err != nil {
+		t.Fatalf("WriteAttributeWithVersion: %v", err)
+	}
+	err = pair.Client(0).WriteAttributeWithVersion(ctx, pair.Session(0), pair.PeerAddress(1), 1, cluster, attr, encodeUint(t, 20), version)
+	var statusErr *WriteStatusError
+	if !errors.Is(err, ErrDataVersionMismatch) || !errors.As(err, &statusErr) {
+		t.Fatalf("stale WriteAttributeWithVersion error = %v, want ErrDataVersionMismatch", err)
+	}
+	if statusErr.Status.Status != imsg.StatusDataVersionMismatch {
+		t.Errorf("Status = %v, want DataVersionMismatch", statusErr.Status.Status)
+	}
+	if got := readOnTime(); got != 10 {
+		t.Errorf("OnTime = %d, want 10", got)
+	}
+
+	// Another client changes the cluster between the read and the write
+	increment := func(calls *int) func([]byte) ([]byte, error) {
+		return func(current []byte) ([]byte, error) {
+			*calls++
+			if *calls == 1 {
+				light.IncrementDataVersion()
+			}
+			return encodeUint(t, decodeUint(t, current)+1), nil
+		}
+	}
+	var calls int
+	err = pair.Client(0).UpdateAttribute(ctx, pair.Session(0), pair.PeerAddress(1), 1, cluster, attr, increment(&calls))
+	if !errors.Is(err, ErrDataVersionMismatch) || calls != 1 {
+		t.Errorf("UpdateAttribute without retries = %v after %d calls, want ErrDataVersionMismatch after 1", err, calls)
+	}
+
+	retrying := NewClient(ClientConfig{
+		ExchangeManager:        pair.ExchangePair().Manager(0),
+		RetryOnVersionMismatch: 1,
+	})
+	calls = 0
+	if err := retrying.UpdateAttribute(ctx, pair.Session(0), pair.PeerAddress(1), 1, cluster, attr, increment(&calls)); err != nil {
+		t.Fatalf("UpdateAttribute: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("mutate called %d times, want 2", calls)
+	}
+	if got := readOnTime(); got != 11 {
+		t.Errorf("OnTime = %d, want 11", got)
+	}
+}
+
+// encodeUint encodes an anonymous unsigned integer.
+func encodeUint(t *testing.T, v uint64) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := tlv.NewWriter(&buf).PutUint(tlv.Anonymous(), v); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// decodeUint decodes an unsigned integer element.
+func decodeUint(t *testing.T, data []byte) uint64 {
+	t.Helper()
+	r := tlv.NewReader(bytes.NewReader(data))
+	if err := r.Next(); err != nil {
+		t.Fatal(err)
+	}
+	v, err := r.Uint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+// TestE2E_Subscribe tests a client subscription: the priming report and
+// change reports reach the callback, liveness reports keep it alive, and
+// closing it ends the subscription on the server at its next change report.
+func TestE2E_Subscribe(t *testing.T) {
+	dispatcher := NewMockDispatcher()
+	dispatcher.SetReadResult(true, nil)
+	pair, err := NewSecureTestIMPair(SecureTestIMPairConfig{
+		Dispatchers: [2]Dispatcher{nil, dispatcher},
+		FabricIndex: 1,
+	})
+	if err != nil {
+		t.Fatalf("NewSecureTestIMPair: %v", err)
+	}
+	defer pair.Close()
+
+	reports := make(chan *imsg.ReportDataMessage, 8)
+	sub, err := pair.Client(0).Subscribe(context.Background(), pair.Session(0), pair.PeerAddress(1),
+		onOffSubscribeRequest(1), func(report *imsg.ReportDataMessage) { reports <- report })
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if sub.MaxInterval() != time.Second {
+		t.Errorf("MaxInterval() = %v, want 1s", sub.MaxInterval())
+	}
+
+	// The priming report arrived before Subscribe returned
+	select {
+	case report := <-reports:
+		if len(report.AttributeReports) != 1 || *report.SubscriptionID != sub.ID() {
+			t.Errorf("priming report = %+v", report)
+		}
+	default:
+		t.Fatal("no priming report")
+	}
+
+	pair.Engine(1).MarkDirty(onOffAttr(0x0000))
+	select {
+	case report := <-reports:
+		if len(report.AttributeReports) != 1 {
+			t.Errorf("change report = %+v, want one attribute", report.AttributeReports)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for change report")
+	}
+
+	// Empty liveness reports are not handed to the callback
+	time.Sleep(2500 * time.Millisecond)
+	select {
+	case report := <-reports:
+		t.Errorf("unexpected report %+v", report)
+	default:
+	}
+	if sub.Err() != nil {
+		t.Fatalf("Err() = %v after liveness reports", sub.Err())
+	}
+
+	sub.Close()
+	if !errors.Is(sub.Err(), ErrSubscriptionClosed) {
+		t.Errorf("Err() = %v, want ErrSubscriptionClosed", sub.Err())
+	}
+	pair.Engine(1).MarkDirty(onOffAttr(0x0000))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	select {
+	case <-waitFor(ctx, func() bool { return len(pair.Engine(1).Subscriptions()) == 0 }):
+	case <-ctx.Done():
+		t.Fatal("server kept the closed subscription")
+	}
+}
+
+// TestE2E_Subscribe_Lost tests a subscription whose server stops
+// reporting.
+func TestE2E_Subscribe_Lost(t *testing.T) {
+	dispatcher := NewMockDispatcher()
+	dispatcher.SetReadResult(true, nil)
+	pair, err := NewSecureTestIMPair(SecureTestIMPairConfig{
+		Dispatchers: [2]Dispatcher{nil, dispatcher},
+		FabricIndex: 1,
+	})
+	if err != nil {
+		t.Fatalf("NewSecureTestIMPair: %v", err)
+	}
+	defer pair.Close()
+
+	client := NewClient(ClientConfig{
+		ExchangeManager: pair.ExchangePair().Manager(0),
+		Engine:          pair.Engine(0),
+		LivenessMargin:  100 * time.Millisecond,
+	})
+	sub, err := client.Subscribe(context.Background(), pair.Session(0), pair.PeerAddress(1), onOffSubscribeRequest(1), nil)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	pair.Engine(1).Close()
+	select {
+	case <-sub.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription not lost")
+	}
+	if !errors.Is(sub.Err(), ErrSubscriptionLost) {
+		t.Errorf("Err() = %v, want ErrSubscriptionLost", sub.Err())
+	}
+
+	if _, err := NewClient(ClientConfig{ExchangeManager: pair.ExchangePair().Manager(0)}).Subscribe(
+		context.Background(), pair.Session(0), pair.PeerAddress(1), onOffSubscribeRequest(1), nil); !errors.Is(err, ErrNoEngine) {
+		t.Errorf("Subscribe() without engine error = %v, want ErrNoEngine", err)
+	}
+}
+
 // TestE2E_Bidirectional tests bidirectional communication.
 func TestE2E_Bidirectional(t *testing.T) {
 	// Create mock dispatchers for both sides
@@ -367,3 +865,101 @@ func TestE2E_MultipleCommands(t *testing.T) {
 
 	t.Logf("E2E MultipleCommands: %d commands successful", len(commands))
 }
+
+// TestE2E_Tracing verifies client and server spans per transaction.
+func TestE2E_Tracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	mockDispatcher := NewMockDispatcher()
+	mockDispatcher.SetReadResult(true, nil)
+
+	pair, err := NewSecureTestIMPair(SecureTestIMPairConfig{
+		Dispatchers:    [2]Dispatcher{nil, mockDispatcher},
+		TracerProvider: tp,
+	})
+	if err != nil {
+		t.Fatalf("NewSecureTestIMPair: %v", err)
+	}
+	defer pair.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := pair.Client(0).ReadAttribute(ctx, pair.Session(0), pair.PeerAddress(1), 1, 0x0006, 0x0000); err != nil {
+		t.Fatalf("ReadAttribute: %v", err)
+	}
+	if _, err := pair.Client(0).InvokeWithStatus(ctx, pair.Session(0), pair.PeerAddress(1), 1, 0x0006, 0x01, nil); err != nil {
+		t.Fatalf("InvokeWithStatus: %v", err)
+	}
+
+	// Server spans end after the response is sent; wait for all four.
+	deadline := time.Now().Add(time.Second)
+	for len(recorder.Ended()) < 4 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	type key struct {
+		name string
+		kind trace.SpanKind
+	}
+	got := make(map[key]sdktrace.ReadOnlySpan)
+	for _, s := range recorder.Ended() {
+		got[key{s.Name(), s.SpanKind()}] = s
+	}
+	for _, k := range []key{
+		{"im.read", trace.SpanKindClient},
+		{"im.read", trace.SpanKindServer},
+		{"im.invoke", trace.SpanKindClient},
+		{"im.invoke", trace.SpanKindServer},
+	} {
+		if _, ok := got[k]; !ok {
+			t.Errorf("missing %s span %q", k.kind, k.name)
+		}
+	}
+
+	if s, ok := got[key{"im.invoke", trace.SpanKindClient}]; ok {
+		attrs := make(map[string]int64)
+		for _, kv := range s.Attributes() {
+			attrs[string(kv.Key)] = kv.Value.AsInt64()
+		}
+		if attrs["matter.cluster.id"] != 0x0006 || attrs["matter.command.id"] != 0x01 {
+			t.Errorf("client invoke attributes = %v", attrs)
+		}
+	}
+	if s, ok := got[key{"im.read", trace.SpanKindServer}]; ok {
+		var sessionID int64 = -1
+		for _, kv := range s.Attributes() {
+			if kv.Key == "matter.session.id" {
+				sessionID = kv.Value.AsInt64()
+			}
+		}
+		if sessionID != 2 {
+			t.Errorf("server session id = %d, want 2", sessionID)
+		}
+	}
+}
+
+// BenchmarkE2E_Invoke measures a complete IM Invoke round trip over a
+// secure session: encode, encrypt, transport, decrypt, dispatch, and the
+// response back.
+func BenchmarkE2E_Invoke(b *testing.B) {
+	pair, err := NewSecureTestIMPair(SecureTestIMPairConfig{
+		Dispatchers: [2]Dispatcher{nil, NewMockDispatcher()},
+	})
+	if err != nil {
+		b.Fatalf("NewSecureTestIMPair: %v", err)
+	}
+	defer pair.Close()
+
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := pair.Client(0).InvokeWithStatus(ctx, pair.Session(0), pair.PeerAddress(1), 1, 0x0006, 0x01, nil)
+		if err != nil {
+			b.Fatalf("InvokeWithStatus: %v", err)
+		}
+	}
+}
@@ -2,14 +2,20 @@ package im
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"sync"
+	"time"
 
-	"github.com/backkem/matter/pkg/acl"
+	"github.com/backkem/matter/pkg/clock"
+	"github.com/backkem/matter/pkg/datamodel"
 	"github.com/backkem/matter/pkg/exchange"
 	imsg "github.com/backkem/matter/pkg/im/message"
 	"github.com/backkem/matter/pkg/message"
+	"github.com/backkem/matter/pkg/metrics"
 	"github.com/backkem/matter/pkg/tlv"
 	"github.com/pion/logging"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ProtocolID is the Interaction Model protocol ID.
@@ -24,10 +30,13 @@ const ProtocolID message.ProtocolID = 0x0001
 //   - WriteRequest → WriteResponse
 //   - InvokeRequest → InvokeResponse
 //   - StatusResponse (for chunked flows)
+//   - TimedRequest → StatusResponse (timed Write/Invoke)
+//   - SubscribeRequest → ReportData → SubscribeResponse, with change
+//     and liveness reports and resumption of persisted subscriptions
+//   - ReportData → StatusResponse for the subscriptions of a Client
+//     configured with the engine
 //
 // It does NOT support (for commissioning simplicity):
-//   - Subscriptions
-//   - Timed interactions
 //   - Complex chunking
 //
 // Spec Reference: Chapter 8 "Interaction Model Specification"
@@ -37,17 +46,69 @@ type Engine struct {
 	dispatcher Dispatcher
 
 	// aclChecker performs access control checks (optional)
-	aclChecker *acl.Checker
+	aclChecker AccessChecker
+
+	// readHandlers holds the chunked reads in progress, by exchange.
+	readHandlers map[*exchange.ExchangeContext]*ReadHandler
 
 	// Handlers (pooled for reuse)
-	readHandler   *ReadHandler
 	writeHandler  *WriteHandler
 	invokeHandler *InvokeHandler
 
+	// resources counts the read transactions held per fabric.
+	resources *resourceTracker
+
 	// maxPayload for chunked responses
 	maxPayload int
 
-	log logging.LeveledLogger
+	// exchangeManager opens the exchanges of reports the engine initiates.
+	exchangeManager *exchange.Manager
+
+	// subscriptions holds the subscriptions served, by ID.
+	subscriptions map[imsg.SubscriptionID]*subscription
+
+	// reports holds the subscription reports awaiting a StatusResponse,
+	// by exchange.
+	reports map[*exchange.ExchangeContext]*subscription
+
+	// clientSubscriptions holds the subscriptions the node's clients made
+	// to peers, by ID, to route the peers' reports to them.
+	clientSubscriptions map[imsg.SubscriptionID][]*ClientSubscription
+
+	// subscriptionStore persists the records of active subscriptions.
+	subscriptionStore SubscriptionStore
+
+	// subscriptionsPerFabric bounds the subscriptions each fabric may hold.
+	subscriptionsPerFabric int
+
+	// events serves the events of reads and subscriptions (optional).
+	events *EventManager
+
+	// changes holds the attribute changes not yet handed to the
+	// subscriptions, in order; changed indexes them. They are guarded by
+	// changeMu rather than mu, as clusters report changes while the engine
+	// dispatches to them.
+	changes   []datamodel.ConcreteAttributePath
+	changed   map[datamodel.ConcreteAttributePath]struct{}
+	newEvents []*EventRecord
+	flushing  bool
+	changeMu  sync.Mutex
+
+	// timedDeadlines tracks Timed Request actions awaiting their
+	// Write/Invoke on the same exchange (Spec 8.7.2).
+	timedDeadlines map[*exchange.ExchangeContext]time.Time
+
+	// deferredTimeout bounds the wait for deferred command responses.
+	deferredTimeout time.Duration
+
+	// validation selects the checks received messages must pass (nil:
+	// strict).
+	validation *tlv.ValidationConfig
+
+	log     logging.LeveledLogger
+	metrics metrics.Collector
+	tracer  trace.Tracer
+	clock   clock.Clock
 
 	mu sync.Mutex
 }
@@ -58,19 +119,69 @@ type EngineConfig struct {
 	// Required.
 	Dispatcher Dispatcher
 
-	// ACLChecker performs access control checks.
+	// ACLChecker performs access control checks on every attribute and
+	// command path, with the privileges from PrivilegeResolver.
 	// Optional - if nil, ACL checks are skipped.
-	ACLChecker *acl.Checker
+	ACLChecker AccessChecker
 
 	// MaxPayload is the maximum payload size for responses.
 	// Defaults to DefaultMaxPayload if 0.
 	MaxPayload int
 
+	// Limits bounds the concurrent read transactions per fabric.
+	// Zero fields use the defaults.
+	Limits ResourceLimits
+
+	// ExchangeManager opens exchanges for the reports the engine
+	// initiates: change and liveness reports and resumed subscriptions.
+	// Optional - if nil, subscriptions only send their priming report.
+	ExchangeManager *exchange.Manager
+
+	// EventManager holds the events served to reads and subscriptions.
+	// Subscriptions are reported its new events.
+	// Optional - if nil, event paths are not reported.
+	EventManager *EventManager
+
+	// SubscriptionStore persists the subscriptions of CASE subscribers,
+	// to resume them after a restart with ResumeSubscription.
+	// Optional - if nil, subscriptions are not persisted.
+	SubscriptionStore SubscriptionStore
+
 	// LoggerFactory is the factory for creating loggers.
 	// If nil, logging is disabled.
 	LoggerFactory logging.LoggerFactory
+
+	// Metrics receives transaction counts.
+	// If nil, metrics are disabled.
+	Metrics metrics.Collector
+
+	// TracerProvider creates a server span per transaction.
+	// If nil, the global provider is used (a no-op unless set).
+	TracerProvider trace.TracerProvider
+
+	// Clock runs the subscription interval timers and times timed
+	// requests.
+	// If nil, the real clock is used.
+	Clock clock.Clock
+
+	// Validation selects the checks received messages must pass before
+	// they are decoded. Messages that fail are answered with a
+	// StatusResponse.
+	// If nil, tlv.StrictValidation is used.
+	Validation *tlv.ValidationConfig
+
+	// DeferredResponseTimeout is how long the engine keeps an invoke
+	// exchange open for the responses of commands that return a
+	// datamodel.DeferredResponse. Commands not complete by then are
+	// answered with Failure.
+	// Defaults to DefaultDeferredResponseTimeout if 0.
+	DeferredResponseTimeout time.Duration
 }
 
+// DefaultDeferredResponseTimeout is the default time the engine waits for
+// deferred command responses.
+const DefaultDeferredResponseTimeout = 30 * time.Second
+
 // NewEngine creates a new IM engine.
 func NewEngine(config EngineConfig) *Engine {
 	maxPayload := config.MaxPayload
@@ -88,14 +199,44 @@ func NewEngine(config EngineConfig) *Engine {
 		log = config.LoggerFactory.NewLogger("im")
 	}
 
+	subscriptionsPerFabric := config.Limits.SubscriptionsPerFabric
+	if subscriptionsPerFabric == 0 {
+		subscriptionsPerFabric = DefaultSubscriptionsPerFabric
+	}
+
+	deferredTimeout := config.DeferredResponseTimeout
+	if deferredTimeout == 0 {
+		deferredTimeout = DefaultDeferredResponseTimeout
+	}
+
 	e := &Engine{
-		dispatcher:    dispatcher,
-		aclChecker:    config.ACLChecker,
-		maxPayload:    maxPayload,
-		readHandler:   NewReadHandler(nil, maxPayload),     // Reader set per-request
-		writeHandler:  NewWriteHandler(dispatcher),
-		invokeHandler: NewInvokeHandler(nil, maxPayload, log), // Handler set per-request
-		log:           log,
+		dispatcher:             dispatcher,
+		aclChecker:             config.ACLChecker,
+		maxPayload:             maxPayload,
+		readHandlers:           make(map[*exchange.ExchangeContext]*ReadHandler),
+		writeHandler:           NewWriteHandler(dispatcher),
+		invokeHandler:          NewInvokeHandler(nil, maxPayload, log), // Handler set per-request
+		resources:              newResourceTracker(config.Limits),
+		exchangeManager:        config.ExchangeManager,
+		subscriptions:          make(map[imsg.SubscriptionID]*subscription),
+		reports:                make(map[*exchange.ExchangeContext]*subscription),
+		clientSubscriptions:    make(map[imsg.SubscriptionID][]*ClientSubscription),
+		changed:                make(map[datamodel.ConcreteAttributePath]struct{}),
+		events:                 config.EventManager,
+		subscriptionStore:      config.SubscriptionStore,
+		subscriptionsPerFabric: subscriptionsPerFabric,
+		timedDeadlines:         make(map[*exchange.ExchangeContext]time.Time),
+		deferredTimeout:        deferredTimeout,
+		log:                    log,
+		metrics:                metrics.OrNop(config.Metrics),
+		tracer:                 newTracer(config.TracerProvider),
+		clock:                  clock.OrReal(config.Clock),
+		validation:             config.Validation,
+	}
+
+	e.metrics.Set(metrics.Subscriptions, 0)
+	if e.events != nil {
+		e.events.AddListener(e)
 	}
 
 	return e
@@ -116,11 +257,40 @@ func (e *Engine) OnMessage(
 	payload []byte,
 ) ([]byte, error) {
 	opcode := imsg.Opcode(header.ProtocolOpcode)
+	if group, ok := groupSession(ctx); ok {
+		e.handleGroupMessage(ctx, group, opcode, payload)
+		return nil, nil
+	}
 
+	action, ok := transactionAction(opcode)
+	if !ok {
+		return e.handleMessage(ctx, opcode, payload)
+	}
+
+	e.metrics.Add(metrics.IMTransactions, 1, metrics.L(metrics.LabelAction, action))
+	_, span := e.tracer.Start(context.Background(), "im."+action,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attrAction.String(action)),
+		trace.WithAttributes(exchangeAttributes(ctx)...))
+	resp, err := e.handleMessage(ctx, opcode, payload)
+	endSpan(span, err)
+	return resp, err
+}
+
+// handleMessage dispatches a message by opcode and sends the response.
+func (e *Engine) handleMessage(
+	ctx *exchange.ExchangeContext,
+	opcode imsg.Opcode,
+	payload []byte,
+) ([]byte, error) {
 	var responsePayload []byte
 	var responseOpcode imsg.Opcode
 	var err error
 
+	if err := tlv.Validate(payload, e.validation); err != nil {
+		return e.rejectMalformed(ctx, opcode, err)
+	}
+
 	switch opcode {
 	case imsg.OpcodeReadRequest:
 		responsePayload, err = e.handleReadRequest(ctx, payload)
@@ -139,15 +309,17 @@ func (e *Engine) OnMessage(
 		return e.handleStatusResponse(ctx, payload)
 
 	case imsg.OpcodeSubscribeRequest:
-		// Not implemented in simplified engine
-		responsePayload, _ = e.encodeStatusResponse(imsg.StatusUnsupportedAccess)
-		responseOpcode = imsg.OpcodeStatusResponse
+		// Replies with the priming report or a StatusResponse
+		return e.handleSubscribeRequest(ctx, payload)
 
 	case imsg.OpcodeTimedRequest:
-		// Not implemented in simplified engine
-		responsePayload, _ = e.encodeStatusResponse(imsg.StatusUnsupportedAccess)
+		responsePayload, err = e.handleTimedRequest(ctx, payload)
 		responseOpcode = imsg.OpcodeStatusResponse
 
+	case imsg.OpcodeReportData, imsg.OpcodeSubscribeResponse:
+		// Reports of the subscriptions the node's clients made
+		return e.handleClientReport(ctx, opcode, payload)
+
 	default:
 		responsePayload, _ = e.encodeStatusResponse(imsg.StatusInvalidAction)
 		responseOpcode = imsg.OpcodeStatusResponse
@@ -178,15 +350,54 @@ func (e *Engine) OnMessage(
 	return nil, nil
 }
 
+// transactionAction returns the metrics label for a request opcode that
+// starts a transaction. StatusResponse continues an existing one.
+func transactionAction(opcode imsg.Opcode) (string, bool) {
+	switch opcode {
+	case imsg.OpcodeReadRequest:
+		return "read", true
+	case imsg.OpcodeWriteRequest:
+		return "write", true
+	case imsg.OpcodeInvokeRequest:
+		return "invoke", true
+	case imsg.OpcodeSubscribeRequest:
+		return "subscribe", true
+	case imsg.OpcodeTimedRequest:
+		return "timed", true
+	case imsg.OpcodeReportData:
+		return "report", true
+	case imsg.OpcodeStatusResponse, imsg.OpcodeSubscribeResponse:
+		return "", false
+	default:
+		return "other", true
+	}
+}
+
 // OnClose implements exchange.ExchangeDelegate.
 func (e *Engine) OnClose(ctx *exchange.ExchangeContext) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	// Reset handlers if they were active on this exchange
-	e.readHandler.Reset()
+	// Drop any pending timed action for this exchange
+	delete(e.timedDeadlines, ctx)
+
+	// Drop the read in progress on this exchange and free its slot
+	delete(e.readHandlers, ctx)
+	e.releaseRead(ctx)
+
+	// A subscription whose report went unanswered ends; its record stays
+	// for a later resumption.
+	if sub, ok := e.reports[ctx]; ok {
+		delete(e.reports, ctx)
+		e.removeSubscription(sub)
+	}
+
+	// Reset handlers if they were active on this exchange. A handler
+	// awaiting deferred responses keeps its own exchange.
 	e.writeHandler.Reset()
-	e.invokeHandler.Reset()
+	if e.invokeHandler.State() != InvokeHandlerStateAwaitingResponse || e.invokeHandler.onExchange(ctx) {
+		e.invokeHandler.Reset()
+	}
 }
 
 // handleReadRequest processes a ReadRequestMessage.
@@ -200,24 +411,32 @@ func (e *Engine) handleReadRequest(ctx *exchange.ExchangeContext, payload []byte
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	// Create attribute reader that uses dispatcher
-	reader := e.createAttributeReader()
-
-	// Create handler with reader
-	handler := NewReadHandler(reader, e.maxPayload)
+	// Reserve a read transaction for the accessing fabric
+	if status := e.resources.acquire(ctx, accessingFabric(ctx)); status != imsg.StatusSuccess {
+		e.refused("read", status)
+		return e.encodeStatusResponse(status)
+	}
+	e.metrics.Set(metrics.IMActiveReads, float64(e.resources.active()))
 
-	// Extract fabric/node info from session (simplified - would come from SecureContext)
-	fabricIndex := uint8(1)   // TODO: extract from session
-	sourceNodeID := uint64(0) // TODO: extract from session
+	// Create handler with a reader that uses the dispatcher
+	dispatcher := e.requestDispatcher(ctx)
+	handler := e.newReadHandler(dispatcher)
 
 	// Process request
-	resp, err := handler.HandleReadRequest(ctx, req, fabricIndex, sourceNodeID)
+	subject := dispatcher.rc.Subject
+	resp, err := handler.HandleReadRequest(ctx, req, uint8(subject.FabricIndex), subject.Subject)
 	if err != nil {
+		e.releaseRead(ctx)
 		return e.encodeStatusResponse(ErrorToStatus(err))
 	}
 
-	// Store handler for potential chunked continuation
-	e.readHandler = handler
+	// Keep the handler for chunked continuation; otherwise the read is done
+	if handler.State() == ReadHandlerStateSendingReport {
+		e.readHandlers[ctx] = handler
+	} else {
+		delete(e.readHandlers, ctx)
+		e.releaseRead(ctx)
+	}
 
 	return EncodeReportData(resp)
 }
@@ -233,13 +452,18 @@ func (e *Engine) handleWriteRequest(ctx *exchange.ExchangeContext, payload []byt
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	// Extract fabric/node info from session (simplified)
-	fabricIndex := uint8(1)
-	sourceNodeID := uint64(0)
-	isTimed := false // Timed interactions not supported in simplified engine
+	isTimed, status := e.consumeTimed(ctx, req.TimedRequest)
+	if status != imsg.StatusSuccess {
+		return e.encodeStatusResponse(status)
+	}
+
+	// Create handler with a dispatcher bound to the requesting subject
+	dispatcher := e.requestDispatcher(ctx)
+	e.writeHandler = NewWriteHandler(dispatcher)
 
 	// Process request
-	resp, err := e.writeHandler.HandleWriteRequest(ctx, req, fabricIndex, sourceNodeID, isTimed)
+	subject := dispatcher.rc.Subject
+	resp, err := e.writeHandler.HandleWriteRequest(ctx, req, uint8(subject.FabricIndex), subject.Subject, isTimed)
 	if err != nil {
 		return e.encodeStatusResponse(ErrorToStatus(err))
 	}
@@ -261,31 +485,100 @@ func (e *Engine) handleInvokeRequest(ctx *exchange.ExchangeContext, payload []by
 	}
 
 	e.mu.Lock()
-	defer e.mu.Unlock()
+	isTimed, status := e.consumeTimed(ctx, req.TimedRequest)
+	e.mu.Unlock()
+	if status != imsg.StatusSuccess {
+		return e.encodeStatusResponse(status)
+	}
 
 	// Create command handler that uses dispatcher
-	cmdHandler := e.createCommandHandler()
+	dispatcher := e.requestDispatcher(ctx)
+	cmdHandler := e.createCommandHandler(dispatcher)
 
 	// Create handler
 	handler := NewInvokeHandler(cmdHandler, e.maxPayload, e.log)
 
-	// Extract fabric/node info from session (simplified)
-	fabricIndex := uint8(1)
-	sourceNodeID := uint64(0)
-	isTimed := false
-
-	// Process request
-	resp, err := handler.HandleInvokeRequest(ctx, req, fabricIndex, sourceNodeID, isTimed)
+	// Process request. Commands run without the engine's lock: they may
+	// call back into the engine, e.g. RemoveFabric ending the fabric's
+	// subscriptions.
+	subject := dispatcher.rc.Subject
+	resp, err := handler.HandleInvokeRequest(ctx, req, uint8(subject.FabricIndex), subject.Subject, isTimed)
 	if err != nil {
 		return e.encodeStatusResponse(ErrorToStatus(err))
 	}
 
 	// Store handler for potential chunked continuation
+	e.mu.Lock()
 	e.invokeHandler = handler
+	e.mu.Unlock()
+
+	// The exchange stays open until the deferred responses complete
+	if handler.State() == InvokeHandlerStateAwaitingResponse {
+		go e.sendDeferredInvokeResponse(ctx, handler)
+		return nil, nil
+	}
 
 	return EncodeInvokeResponse(resp)
 }
 
+// sendDeferredInvokeResponse sends the InvokeResponse of a request whose
+// commands deferred their responses, once they complete or the deferred
+// response timeout passes.
+func (e *Engine) sendDeferredInvokeResponse(ctx *exchange.ExchangeContext, handler *InvokeHandler) {
+	timer := e.clock.NewTimer(e.deferredTimeout)
+	defer timer.Stop()
+
+	resp, err := handler.AwaitDeferred(timer.C())
+	if err == nil && resp != nil {
+		var payload []byte
+		if payload, err = EncodeInvokeResponse(resp); err == nil {
+			_, err = e.sendOrReturn(ctx, uint8(imsg.OpcodeInvokeResponse), payload)
+		}
+	}
+	if err != nil && e.log != nil {
+		e.log.Debugf("deferred InvokeResponse not sent: %v", err)
+	}
+}
+
+// handleTimedRequest processes a TimedRequestMessage.
+// It opens a timed window on the exchange that the following Write or
+// Invoke request must arrive within.
+//
+// Spec: Section 8.7.2
+func (e *Engine) handleTimedRequest(ctx *exchange.ExchangeContext, payload []byte) ([]byte, error) {
+	var req imsg.TimedRequestMessage
+	if err := req.Decode(tlv.NewReader(bytes.NewReader(payload))); err != nil {
+		return e.encodeStatusResponse(imsg.StatusInvalidAction)
+	}
+
+	e.mu.Lock()
+	e.timedDeadlines[ctx] = e.clock.Now().Add(time.Duration(req.Timeout) * time.Millisecond)
+	e.mu.Unlock()
+
+	return e.encodeStatusResponse(imsg.StatusSuccess)
+}
+
+// consumeTimed validates the TimedRequest flag of a Write/Invoke request
+// against the pending timed action on the exchange, and clears it.
+// Returns whether the request is timed, or a non-success status to reply with.
+//
+// Spec: Section 8.7.3.1, 8.8.3.1
+func (e *Engine) consumeTimed(ctx *exchange.ExchangeContext, timedFlag bool) (bool, imsg.Status) {
+	deadline, pending := e.timedDeadlines[ctx]
+	delete(e.timedDeadlines, ctx)
+
+	if timedFlag != pending {
+		return false, imsg.StatusTimedRequestMismatch
+	}
+	if !pending {
+		return false, imsg.StatusSuccess
+	}
+	if e.clock.Now().After(deadline) {
+		return false, imsg.StatusTimeout
+	}
+	return true, imsg.StatusSuccess
+}
+
 // handleStatusResponse processes a StatusResponseMessage.
 // Used for chunked response flow control.
 // This method sends responses directly with correct opcodes.
@@ -297,11 +590,42 @@ func (e *Engine) handleStatusResponse(ctx *exchange.ExchangeContext, payload []b
 	}
 
 	e.mu.Lock()
+
+	// Subscription reports are answered outside the lock, as closing the
+	// exchange calls back into OnClose.
+	if sub, ok := e.reports[ctx]; ok {
+		opcode, responsePayload, done, err := e.handleReportStatus(ctx, sub, statusMsg.Status)
+		if done {
+			// A priming report frees its read transaction
+			e.releaseRead(ctx)
+		}
+		e.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		var resp []byte
+		if responsePayload != nil {
+			if resp, err = e.sendOrReturn(ctx, uint8(opcode), responsePayload); err != nil {
+				return nil, err
+			}
+		}
+		if done {
+			if ctx != nil && ctx.IsInitiator() {
+				ctx.Close()
+			}
+			e.finishReport(sub)
+		}
+		return resp, nil
+	}
 	defer e.mu.Unlock()
 
 	// Check if read handler has pending chunks
-	if e.readHandler.State() == ReadHandlerStateSendingReport {
-		resp, err := e.readHandler.HandleStatusResponse(statusMsg.Status)
+	if handler, ok := e.readHandlers[ctx]; ok {
+		resp, err := handler.HandleStatusResponse(statusMsg.Status)
+		if err != nil || handler.State() != ReadHandlerStateSendingReport {
+			delete(e.readHandlers, ctx)
+			e.releaseRead(ctx)
+		}
 		if err != nil {
 			responsePayload, _ := e.encodeStatusResponse(ErrorToStatus(err))
 			return e.sendOrReturn(ctx, uint8(imsg.OpcodeStatusResponse), responsePayload)
@@ -337,6 +661,26 @@ func (e *Engine) handleStatusResponse(ctx *exchange.ExchangeContext, payload []b
 	return nil, nil
 }
 
+// releaseRead frees the read transaction held on the exchange.
+func (e *Engine) releaseRead(ctx *exchange.ExchangeContext) {
+	e.resources.release(ctx)
+	e.metrics.Set(metrics.IMActiveReads, float64(e.resources.active()))
+}
+
+// refused counts a request refused for lack of resources.
+func (e *Engine) refused(action string, status imsg.Status) {
+	label := "busy"
+	if status == imsg.StatusResourceExhausted {
+		label = "resource_exhausted"
+	}
+	e.metrics.Add(metrics.IMRefused, 1,
+		metrics.L(metrics.LabelAction, action),
+		metrics.L(metrics.LabelStatus, label))
+	if e.log != nil {
+		e.log.Debugf("refused %s request: %v", action, status)
+	}
+}
+
 // sendOrReturn either sends via exchange context or returns payload for unit tests.
 func (e *Engine) sendOrReturn(ctx *exchange.ExchangeContext, opcode uint8, payload []byte) ([]byte, error) {
 	if ctx == nil {
@@ -348,8 +692,35 @@ func (e *Engine) sendOrReturn(ctx *exchange.ExchangeContext, opcode uint8, paylo
 	return nil, nil
 }
 
+// requestDispatcher returns the dispatcher for a request on the exchange.
+// It carries the requesting subject to the clusters and enforces the ACL.
+func (e *Engine) requestDispatcher(ctx *exchange.ExchangeContext) *accessDispatcher {
+	return &accessDispatcher{
+		Dispatcher: e.dispatcher,
+		checker:    e.aclChecker,
+		rc:         NewRequestContext(ctx, subjectDescriptor(ctx)),
+	}
+}
+
+// newReadHandler creates a ReadHandler that reads through the dispatcher,
+// filters by the cluster versions of the engine's dispatcher and expands
+// wildcard paths over its node.
+func (e *Engine) newReadHandler(dispatcher *accessDispatcher) *ReadHandler {
+	handler := NewReadHandler(e.createAttributeReader(dispatcher), e.maxPayload)
+	if provider, ok := e.dispatcher.(DataVersionProvider); ok {
+		handler.SetDataVersionLookup(provider.ClusterDataVersion)
+	}
+	if expander, ok := e.dispatcher.(PathExpander); ok {
+		handler.SetPathExpansion(expander.ExpandAttributePath)
+	}
+	if e.events != nil {
+		handler.SetEventReader(e.createEventReader(dispatcher))
+	}
+	return handler
+}
+
 // createAttributeReader creates an AttributeReader that uses the dispatcher.
-func (e *Engine) createAttributeReader() AttributeReader {
+func (e *Engine) createAttributeReader(dispatcher Dispatcher) AttributeReader {
 	return func(ctx *ReadContext, path imsg.AttributePathIB) (*AttributeResult, error) {
 		req := &AttributeReadRequest{
 			Path:             path,
@@ -359,24 +730,62 @@ func (e *Engine) createAttributeReader() AttributeReader {
 		var buf bytes.Buffer
 		w := tlv.NewWriter(&buf)
 
-		err := e.dispatcher.ReadAttribute(nil, req, w)
+		err := dispatcher.ReadAttribute(context.Background(), req, w)
 		if err != nil {
+			status := ErrorToStatusIB(err)
 			return &AttributeResult{
-				Status: &imsg.StatusIB{
-					Status: ErrorToStatus(err),
-				},
+				Status: &status,
 			}, nil
 		}
 
 		return &AttributeResult{
-			DataVersion: 1, // TODO: get from cluster
+			DataVersion: e.dataVersion(path),
 			Data:        buf.Bytes(),
 		}, nil
 	}
 }
 
+// createEventReader creates an EventReader over the engine's events.
+// Fabric-sensitive events are only read by their fabric, and events of
+// clusters the subject may not read are left out.
+func (e *Engine) createEventReader(dispatcher *accessDispatcher) EventReader {
+	return func(ctx *ReadContext, paths []imsg.EventPathIB, minEventNumber imsg.EventNumber) []imsg.EventReportIB {
+		var reports []imsg.EventReportIB
+		for _, record := range e.events.EventsSince(minEventNumber, ctx.FabricIndex) {
+			if record.FabricIndex != 0 && record.FabricIndex != ctx.FabricIndex {
+				continue
+			}
+			included := false
+			for _, p := range paths {
+				if eventPathIncludes(p, record.Path) {
+					included = true
+					break
+				}
+			}
+			if !included || dispatcher.check(eventRequestPath(record.Path)) != nil {
+				continue
+			}
+			reports = append(reports, record.ToEventReportIB())
+		}
+		return reports
+	}
+}
+
+// dataVersion returns the data version of the attribute's cluster, or 0
+// if the dispatcher does not track versions.
+func (e *Engine) dataVersion(path imsg.AttributePathIB) imsg.DataVersion {
+	provider, ok := e.dispatcher.(DataVersionProvider)
+	if !ok || path.Endpoint == nil || path.Cluster == nil {
+		return 0
+	}
+	version, _ := provider.ClusterDataVersion(*path.Endpoint, *path.Cluster)
+	return version
+}
+
 // createCommandHandler creates a CommandHandler that uses the dispatcher.
-func (e *Engine) createCommandHandler() CommandHandler {
+// Responses name the response command of a CommandResponder; for other
+// dispatchers, the response command is taken to follow the request command.
+func (e *Engine) createCommandHandler(dispatcher Dispatcher) CommandHandler {
 	return func(ctx *InvokeContext, path imsg.CommandPathIB, fields []byte) (*CommandResult, error) {
 		req := &CommandInvokeRequest{
 			Path:    path,
@@ -385,31 +794,75 @@ func (e *Engine) createCommandHandler() CommandHandler {
 
 		r := tlv.NewReader(bytes.NewReader(fields))
 
-		respData, err := e.dispatcher.InvokeCommand(nil, req, r)
+		var resp *datamodel.CommandResponse
+		var err error
+		if responder, ok := dispatcher.(CommandResponder); ok {
+			resp, err = responder.InvokeCommandResponse(context.Background(), req, r)
+		} else {
+			var data []byte
+			data, err = dispatcher.InvokeCommand(context.Background(), req, r)
+			if data != nil {
+				resp = &datamodel.CommandResponse{Command: datamodel.CommandID(path.Command) + 1, Fields: data}
+			}
+		}
+
+		var deferred *datamodel.DeferredResponse
+		if errors.As(err, &deferred) {
+			// Deferred responses carry fields only
+			responsePath := path
+			responsePath.Command++
+			return &CommandResult{
+				ResponsePath: responsePath,
+				Deferred:     deferred,
+			}, nil
+		}
 		if err != nil {
 			if e.log != nil {
 				e.log.Tracef("InvokeCommand failed: cluster=0x%04X cmd=0x%02X err=%v",
 					path.Cluster, path.Command, err)
 			}
+			status := ErrorToStatusIB(err)
 			return &CommandResult{
-				Status: &imsg.StatusIB{
-					Status: ErrorToStatus(err),
-				},
+				Status: &status,
 			}, nil
 		}
+		if resp == nil {
+			// Status-only response
+			return nil, nil
+		}
 
-		// Server commands typically have response with command ID = request + 1
-		// TODO: Get response command ID from cluster metadata
 		responsePath := path
-		responsePath.Command++
-
+		responsePath.Command = imsg.CommandID(resp.Command)
 		return &CommandResult{
 			ResponsePath: responsePath,
-			ResponseData: respData,
+			ResponseData: resp.Fields,
 		}, nil
 	}
 }
 
+// rejectMalformed answers a message that failed TLV validation with a
+// StatusResponse.
+func (e *Engine) rejectMalformed(ctx *exchange.ExchangeContext, opcode imsg.Opcode, err error) ([]byte, error) {
+	if e.log != nil {
+		e.log.Debugf("rejected malformed %v message: %v", opcode, err)
+	}
+	payload, encErr := e.encodeStatusResponse(validationStatus(err))
+	if encErr != nil {
+		return nil, encErr
+	}
+	return e.sendOrReturn(ctx, uint8(imsg.OpcodeStatusResponse), payload)
+}
+
+// validationStatus returns the status a message failing TLV validation is
+// answered with: RESOURCE_EXHAUSTED for a message beyond the configured
+// limits, INVALID_ACTION for a malformed one.
+func validationStatus(err error) imsg.Status {
+	if errors.Is(err, tlv.ErrMaxDepthExceeded) || errors.Is(err, tlv.ErrStringTooLong) {
+		return imsg.StatusResourceExhausted
+	}
+	return imsg.StatusInvalidAction
+}
+
 // encodeStatusResponse encodes a status response message.
 func (e *Engine) encodeStatusResponse(status imsg.Status) ([]byte, error) {
 	return EncodeStatusResponse(status)
@@ -3,10 +3,16 @@ package im
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/backkem/matter/pkg/clock"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/im/imstatus"
 	imsg "github.com/backkem/matter/pkg/im/message"
 	"github.com/backkem/matter/pkg/message"
+	"github.com/backkem/matter/pkg/metrics"
 	"github.com/backkem/matter/pkg/tlv"
 )
 
@@ -92,7 +98,31 @@ func TestEngine_OnMessage_InvalidOpcode(t *testing.T) {
 	}
 }
 
-func TestEngine_OnMessage_SubscribeRequest_Unsupported(t *testing.T) {
+func TestEngine_Metrics(t *testing.T) {
+	rec := metrics.NewRecorder()
+	engine := NewEngine(EngineConfig{Metrics: rec})
+
+	for _, opcode := range []imsg.Opcode{
+		imsg.OpcodeSubscribeRequest,
+		imsg.OpcodeSubscribeRequest,
+		imsg.OpcodeStatusResponse,
+		0xFF,
+	} {
+		_, _ = engine.OnMessage(nil, &message.ProtocolHeader{ProtocolOpcode: uint8(opcode)}, nil)
+	}
+
+	if got := rec.Value(metrics.IMTransactions, metrics.L(metrics.LabelAction, "subscribe")); got != 2 {
+		t.Errorf("subscribe transactions = %v, want 2", got)
+	}
+	if got := rec.Value(metrics.IMTransactions, metrics.L(metrics.LabelAction, "other")); got != 1 {
+		t.Errorf("other transactions = %v, want 1", got)
+	}
+	if got := rec.Sum(metrics.IMTransactions); got != 3 {
+		t.Errorf("total transactions = %v, want 3 (StatusResponse not counted)", got)
+	}
+}
+
+func TestEngine_OnMessage_SubscribeRequest_Malformed(t *testing.T) {
 	engine := NewEngine(EngineConfig{})
 
 	header := &message.ProtocolHeader{
@@ -108,12 +138,33 @@ func TestEngine_OnMessage_SubscribeRequest_Unsupported(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to decode status response: %v", err)
 	}
-	if statusMsg.Status != imsg.StatusUnsupportedAccess {
-		t.Errorf("Status = %v, want UnsupportedAccess", statusMsg.Status)
+	if statusMsg.Status != imsg.StatusInvalidAction {
+		t.Errorf("Status = %v, want InvalidAction", statusMsg.Status)
+	}
+}
+
+func TestEngine_OnMessage_TimedRequest(t *testing.T) {
+	engine := NewEngine(EngineConfig{})
+
+	header := &message.ProtocolHeader{
+		ProtocolOpcode: uint8(imsg.OpcodeTimedRequest),
+	}
+
+	resp, err := engine.OnMessage(nil, header, encodeTimedRequest(t, 1000))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	statusMsg, err := DecodeStatusResponse(resp)
+	if err != nil {
+		t.Fatalf("failed to decode status response: %v", err)
+	}
+	if statusMsg.Status != imsg.StatusSuccess {
+		t.Errorf("Status = %v, want Success", statusMsg.Status)
 	}
 }
 
-func TestEngine_OnMessage_TimedRequest_Unsupported(t *testing.T) {
+func TestEngine_OnMessage_TimedRequest_Invalid(t *testing.T) {
 	engine := NewEngine(EngineConfig{})
 
 	header := &message.ProtocolHeader{
@@ -129,9 +180,137 @@ func TestEngine_OnMessage_TimedRequest_Unsupported(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to decode status response: %v", err)
 	}
-	if statusMsg.Status != imsg.StatusUnsupportedAccess {
-		t.Errorf("Status = %v, want UnsupportedAccess", statusMsg.Status)
+	if statusMsg.Status != imsg.StatusInvalidAction {
+		t.Errorf("Status = %v, want InvalidAction", statusMsg.Status)
+	}
+}
+
+func TestEngine_OnMessage_TimedInvoke(t *testing.T) {
+	var gotTimed bool
+	dispatcher := &testDispatcher{
+		invokeFunc: func(ctx context.Context, req *CommandInvokeRequest, r *tlv.Reader) ([]byte, error) {
+			gotTimed = req.IsTimed
+			return nil, nil
+		},
+	}
+
+	engine := NewEngine(EngineConfig{Dispatcher: dispatcher})
+
+	timedHeader := &message.ProtocolHeader{
+		ProtocolOpcode: uint8(imsg.OpcodeTimedRequest),
+	}
+	if _, err := engine.OnMessage(nil, timedHeader, encodeTimedRequest(t, 1000)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	invokeHeader := &message.ProtocolHeader{
+		ProtocolOpcode: uint8(imsg.OpcodeInvokeRequest),
+	}
+	resp, err := engine.OnMessage(nil, invokeHeader, encodeToggleInvoke(t, true))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var invokeResp imsg.InvokeResponseMessage
+	if err := invokeResp.Decode(tlv.NewReader(bytes.NewReader(resp))); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !gotTimed {
+		t.Error("expected dispatcher to see IsTimed = true")
+	}
+}
+
+func TestEngine_OnMessage_TimedInvoke_Mismatch(t *testing.T) {
+	invokeCalled := false
+	dispatcher := &testDispatcher{
+		invokeFunc: func(ctx context.Context, req *CommandInvokeRequest, r *tlv.Reader) ([]byte, error) {
+			invokeCalled = true
+			return nil, nil
+		},
+	}
+
+	engine := NewEngine(EngineConfig{Dispatcher: dispatcher})
+
+	header := &message.ProtocolHeader{
+		ProtocolOpcode: uint8(imsg.OpcodeInvokeRequest),
+	}
+
+	// TimedRequest flag set without a preceding Timed Request action
+	resp, err := engine.OnMessage(nil, header, encodeToggleInvoke(t, true))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	statusMsg, err := DecodeStatusResponse(resp)
+	if err != nil {
+		t.Fatalf("failed to decode status response: %v", err)
+	}
+	if statusMsg.Status != imsg.StatusTimedRequestMismatch {
+		t.Errorf("Status = %v, want TimedRequestMismatch", statusMsg.Status)
+	}
+	if invokeCalled {
+		t.Error("dispatcher should not be called on mismatch")
+	}
+}
+
+func TestEngine_OnMessage_TimedInvoke_Expired(t *testing.T) {
+	clk := clock.NewFakeClock(time.Time{})
+	engine := NewEngine(EngineConfig{Dispatcher: &testDispatcher{}, Clock: clk})
+
+	timedHeader := &message.ProtocolHeader{
+		ProtocolOpcode: uint8(imsg.OpcodeTimedRequest),
+	}
+	if _, err := engine.OnMessage(nil, timedHeader, encodeTimedRequest(t, 500)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	clk.Advance(501 * time.Millisecond)
+
+	invokeHeader := &message.ProtocolHeader{
+		ProtocolOpcode: uint8(imsg.OpcodeInvokeRequest),
+	}
+	resp, err := engine.OnMessage(nil, invokeHeader, encodeToggleInvoke(t, true))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	statusMsg, err := DecodeStatusResponse(resp)
+	if err != nil {
+		t.Fatalf("failed to decode status response: %v", err)
+	}
+	if statusMsg.Status != imsg.StatusTimeout {
+		t.Errorf("Status = %v, want Timeout", statusMsg.Status)
+	}
+}
+
+func encodeTimedRequest(t *testing.T, timeoutMs uint16) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	msg := &imsg.TimedRequestMessage{Timeout: timeoutMs}
+	if err := msg.Encode(tlv.NewWriter(&buf)); err != nil {
+		t.Fatalf("failed to encode timed request: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func encodeToggleInvoke(t *testing.T, timed bool) []byte {
+	t.Helper()
+	req := &imsg.InvokeRequestMessage{
+		TimedRequest: timed,
+		InvokeRequests: []imsg.CommandDataIB{
+			{
+				Path: imsg.CommandPathIB{
+					Endpoint: 1,
+					Cluster:  0x0006, // OnOff
+					Command:  2,      // Toggle
+				},
+			},
+		},
+	}
+	var buf bytes.Buffer
+	if err := req.Encode(tlv.NewWriter(&buf)); err != nil {
+		t.Fatalf("failed to encode request: %v", err)
 	}
+	return buf.Bytes()
 }
 
 func TestEngine_OnMessage_ReadRequest(t *testing.T) {
@@ -187,6 +366,88 @@ func TestEngine_OnMessage_ReadRequest(t *testing.T) {
 	}
 }
 
+// versionedDispatcher is a testDispatcher whose clusters all have version.
+type versionedDispatcher struct {
+	*testDispatcher
+	version imsg.DataVersion
+}
+
+func (d *versionedDispatcher) ClusterDataVersion(endpoint imsg.EndpointID, cluster imsg.ClusterID) (imsg.DataVersion, bool) {
+	return d.version, true
+}
+
+func TestEngine_OnMessage_ReadRequest_DataVersion(t *testing.T) {
+	dispatcher := &versionedDispatcher{
+		testDispatcher: &testDispatcher{
+			readFunc: func(ctx context.Context, req *AttributeReadRequest, w *tlv.Writer) error {
+				return w.PutBool(tlv.Anonymous(), true)
+			},
+		},
+		version: 0x1234,
+	}
+	engine := NewEngine(EngineConfig{Dispatcher: dispatcher})
+
+	ep := imsg.EndpointID(1)
+	cl := imsg.ClusterID(0x0006)
+	attr := imsg.AttributeID(0x0000)
+	read := func(filters []imsg.DataVersionFilterIB) *imsg.ReportDataMessage {
+		t.Helper()
+		req := &imsg.ReadRequestMessage{
+			AttributeRequests:  []imsg.AttributePathIB{{Endpoint: &ep, Cluster: &cl, Attribute: &attr}},
+			DataVersionFilters: filters,
+		}
+		payload, _ := EncodeMessage(req.Encode)
+		resp, err := engine.handleMessage(nil, imsg.OpcodeReadRequest, payload)
+		if err != nil {
+			t.Fatalf("handleMessage: %v", err)
+		}
+		report, err := DecodeReportData(resp)
+		if err != nil {
+			t.Fatalf("DecodeReportData: %v", err)
+		}
+		return report
+	}
+
+	// The report carries the cluster's version
+	report := read(nil)
+	if len(report.AttributeReports) != 1 || report.AttributeReports[0].AttributeData == nil {
+		t.Fatalf("AttributeReports = %+v, want one attribute", report.AttributeReports)
+	}
+	if v := report.AttributeReports[0].AttributeData.DataVersion; v != 0x1234 {
+		t.Errorf("DataVersion = 0x%x, want 0x1234", v)
+	}
+
+	// A client holding the current version gets nothing
+	report = read([]imsg.DataVersionFilterIB{{
+		Path:        imsg.ClusterPathIB{Endpoint: &ep, Cluster: &cl},
+		DataVersion: 0x1234,
+	}})
+	if len(report.AttributeReports) != 0 {
+		t.Errorf("filtered report has %d attributes, want 0", len(report.AttributeReports))
+	}
+
+	// The priming report of a subscription is filtered too
+	sub := &imsg.SubscribeRequestMessage{
+		MaxIntervalCeiling: 60,
+		AttributeRequests:  []imsg.AttributePathIB{{Endpoint: &ep, Cluster: &cl, Attribute: &attr}},
+		DataVersionFilters: []imsg.DataVersionFilterIB{{
+			Path:        imsg.ClusterPathIB{Endpoint: &ep, Cluster: &cl},
+			DataVersion: 0x1234,
+		}},
+	}
+	payload, _ := EncodeMessage(sub.Encode)
+	resp, err := engine.handleMessage(nil, imsg.OpcodeSubscribeRequest, payload)
+	if err != nil {
+		t.Fatalf("handleMessage: %v", err)
+	}
+	if report, err = DecodeReportData(resp); err != nil {
+		t.Fatalf("DecodeReportData: %v", err)
+	}
+	if len(report.AttributeReports) != 0 {
+		t.Errorf("filtered priming report has %d attributes, want 0", len(report.AttributeReports))
+	}
+}
+
 func TestEngine_OnMessage_ReadRequest_Invalid(t *testing.T) {
 	engine := NewEngine(EngineConfig{})
 
@@ -209,6 +470,100 @@ func TestEngine_OnMessage_ReadRequest_Invalid(t *testing.T) {
 	}
 }
 
+func TestEngine_OnMessage_StrictValidation(t *testing.T) {
+	// InvokeRequestMessage with a SuppressResponse and TimedRequest flag,
+	// then the given command fields in its InvokeRequests
+	invoke := func(fields func(w *tlv.Writer)) []byte {
+		w := tlv.NewAppendWriter(nil)
+		w.StartStructure(tlv.Anonymous())
+		w.PutBool(tlv.ContextTag(0), false)
+		w.PutBool(tlv.ContextTag(1), false)
+		w.StartArray(tlv.ContextTag(2))
+		w.StartStructure(tlv.Anonymous())
+		w.StartList(tlv.ContextTag(0))
+		w.PutUint(tlv.ContextTag(0), 1)
+		w.PutUint(tlv.ContextTag(1), 0x0006)
+		w.PutUint(tlv.ContextTag(2), 0x02)
+		w.EndContainer()
+		w.StartStructure(tlv.ContextTag(1))
+		fields(w)
+		w.EndContainer()
+		w.EndContainer()
+		w.EndContainer()
+		w.PutUint(tlv.ContextTag(0xFF), 12)
+		w.EndContainer()
+		return w.Bytes()
+	}
+
+	// The same message without a fault reaches the cluster
+	invoked := false
+	engine := NewEngine(EngineConfig{Dispatcher: &testDispatcher{
+		invokeFunc: func(context.Context, *CommandInvokeRequest, *tlv.Reader) ([]byte, error) {
+			invoked = true
+			return nil, nil
+		},
+	}})
+	header := &message.ProtocolHeader{ProtocolOpcode: uint8(imsg.OpcodeInvokeRequest)}
+	valid := invoke(func(w *tlv.Writer) { w.PutUint(tlv.ContextTag(0), 1) })
+	if _, err := engine.OnMessage(nil, header, valid); err != nil {
+		t.Fatalf("OnMessage() error = %v", err)
+	}
+	if !invoked {
+		t.Fatal("command not invoked for a valid message")
+	}
+
+	tests := []struct {
+		name    string
+		payload []byte
+		want    imsg.Status
+	}{
+		{"duplicate tag", invoke(func(w *tlv.Writer) {
+			w.PutUint(tlv.ContextTag(0), 1)
+			w.PutUint(tlv.ContextTag(0), 2)
+		}), imsg.StatusInvalidAction},
+		{"invalid UTF-8", invoke(func(w *tlv.Writer) {
+			w.PutRaw(tlv.ContextTag(0), []byte{0x0C, 0x02, 0xC3, 0x28})
+		}), imsg.StatusInvalidAction},
+		{"trailing data", append(invoke(func(*tlv.Writer) {}), 0x18), imsg.StatusInvalidAction},
+		{"too deep", invoke(func(w *tlv.Writer) {
+			w.StartList(tlv.ContextTag(0))
+			for range tlv.DefaultMaxDepth {
+				w.StartList(tlv.Anonymous())
+			}
+			for range tlv.DefaultMaxDepth + 1 {
+				w.EndContainer()
+			}
+		}), imsg.StatusResourceExhausted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invoked := false
+			engine := NewEngine(EngineConfig{Dispatcher: &testDispatcher{
+				invokeFunc: func(context.Context, *CommandInvokeRequest, *tlv.Reader) ([]byte, error) {
+					invoked = true
+					return nil, nil
+				},
+			}})
+			header := &message.ProtocolHeader{ProtocolOpcode: uint8(imsg.OpcodeInvokeRequest)}
+
+			resp, err := engine.OnMessage(nil, header, tt.payload)
+			if err != nil {
+				t.Fatalf("OnMessage() error = %v", err)
+			}
+			statusMsg, err := DecodeStatusResponse(resp)
+			if err != nil {
+				t.Fatalf("failed to decode status response: %v", err)
+			}
+			if statusMsg.Status != tt.want {
+				t.Errorf("Status = %v, want %v", statusMsg.Status, tt.want)
+			}
+			if invoked {
+				t.Error("command invoked for a rejected message")
+			}
+		})
+	}
+}
+
 func TestEngine_OnMessage_WriteRequest(t *testing.T) {
 	writeCalled := false
 	dispatcher := &testDispatcher{
@@ -367,6 +722,88 @@ func TestEngine_OnMessage_InvokeRequest(t *testing.T) {
 	if err := invokeResp.Decode(r); err != nil {
 		t.Fatalf("failed to decode response: %v", err)
 	}
+
+	// A command without response data is answered with a status
+	if got := invokeResp.InvokeResponses[0]; got.Status == nil || got.Status.Status.Status != imsg.StatusSuccess {
+		t.Errorf("InvokeResponse = %+v, want Success status", got)
+	}
+}
+
+// typedDispatcher is a testDispatcher returning typed command responses.
+type typedDispatcher struct {
+	testDispatcher
+	responseFunc func(req *CommandInvokeRequest) (*datamodel.CommandResponse, error)
+}
+
+func (d *typedDispatcher) InvokeCommandResponse(ctx context.Context, req *CommandInvokeRequest, r *tlv.Reader) (*datamodel.CommandResponse, error) {
+	return d.responseFunc(req)
+}
+
+func TestEngine_OnMessage_InvokeTypedResponses(t *testing.T) {
+	dispatcher := &typedDispatcher{
+		responseFunc: func(req *CommandInvokeRequest) (*datamodel.CommandResponse, error) {
+			switch req.Path.Command {
+			case 0x00, 0x01:
+				// Both answered with the same response command
+				return datamodel.NewCommandResponse(0x04, func(w *tlv.Writer) error {
+					return w.PutUint(tlv.ContextTag(0), uint64(req.Path.Command))
+				})
+			case 0x02:
+				return nil, nil
+			default:
+				return nil, datamodel.ErrUnsupportedCommand
+			}
+		},
+	}
+	engine := NewEngine(EngineConfig{Dispatcher: dispatcher})
+
+	req := &imsg.InvokeRequestMessage{}
+	for cmd := imsg.CommandID(0); cmd < 4; cmd++ {
+		ref := uint16(10 + cmd)
+		req.InvokeRequests = append(req.InvokeRequests, imsg.CommandDataIB{
+			Path: imsg.CommandPathIB{Endpoint: 1, Cluster: 0x0060, Command: cmd},
+			Ref:  &ref,
+		})
+	}
+	var buf bytes.Buffer
+	if err := req.Encode(tlv.NewWriter(&buf)); err != nil {
+		t.Fatalf("failed to encode request: %v", err)
+	}
+
+	header := &message.ProtocolHeader{ProtocolOpcode: uint8(imsg.OpcodeInvokeRequest)}
+	resp, err := engine.OnMessage(nil, header, buf.Bytes())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var invokeResp imsg.InvokeResponseMessage
+	if err := invokeResp.Decode(tlv.NewReader(bytes.NewReader(resp))); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(invokeResp.InvokeResponses) != 4 {
+		t.Fatalf("got %d responses, want 4", len(invokeResp.InvokeResponses))
+	}
+
+	for i, got := range invokeResp.InvokeResponses[:2] {
+		if got.Command == nil || got.Command.Path.Command != 0x04 || got.Command.Path.Endpoint != 1 ||
+			got.Command.Ref == nil || *got.Command.Ref != uint16(10+i) {
+			t.Errorf("response %d = %+v, want command 0x04 with ref %d", i, got.Command, 10+i)
+			continue
+		}
+		r := tlv.NewReader(bytes.NewReader(got.Command.Fields))
+		r.Next()
+		r.EnterContainer()
+		r.Next()
+		if v, _ := r.Uint(); v != uint64(i) {
+			t.Errorf("response %d field = %d, want %d", i, v, i)
+		}
+	}
+	if got := invokeResp.InvokeResponses[2]; got.Status == nil || got.Status.Status.Status != imsg.StatusSuccess {
+		t.Errorf("response 2 = %+v, want Success status", got)
+	}
+	if got := invokeResp.InvokeResponses[3]; got.Status == nil || got.Status.Status.Status != imsg.StatusUnsupportedCommand ||
+		got.Status.Ref == nil || *got.Status.Ref != 13 {
+		t.Errorf("response 3 = %+v, want UnsupportedCommand status with ref 13", got.Status)
+	}
 }
 
 func TestEngine_OnMessage_StatusResponse_NoActiveHandler(t *testing.T) {
@@ -405,8 +842,8 @@ func TestEngine_OnClose(t *testing.T) {
 	engine.OnClose(nil)
 
 	// Verify handlers are reset
-	if engine.readHandler.State() != ReadHandlerStateIdle {
-		t.Errorf("readHandler state = %v, want Idle", engine.readHandler.State())
+	if len(engine.readHandlers) != 0 {
+		t.Errorf("readHandlers = %d, want 0", len(engine.readHandlers))
 	}
 	if engine.writeHandler.State() != WriteHandlerStateIdle {
 		t.Errorf("writeHandler state = %v, want Idle", engine.writeHandler.State())
@@ -425,12 +862,17 @@ func TestProtocolID_Value(t *testing.T) {
 
 func TestEngine_DispatcherErrorMapping(t *testing.T) {
 	tests := []struct {
-		name       string
-		err        error
-		wantStatus imsg.Status
+		name              string
+		err               error
+		wantStatus        imsg.Status
+		wantClusterStatus *uint8
 	}{
-		{"cluster not found", ErrClusterNotFound, imsg.StatusUnsupportedCluster},
-		{"access denied", ErrAccessDenied, imsg.StatusUnsupportedAccess},
+		{"cluster not found", ErrClusterNotFound, imsg.StatusUnsupportedCluster, nil},
+		{"access denied", ErrAccessDenied, imsg.StatusUnsupportedAccess, nil},
+		{"datamodel constraint error", datamodel.ErrConstraintError, imsg.StatusConstraintError, nil},
+		{"datamodel invalid in state", fmt.Errorf("recall: %w", datamodel.ErrInvalidInState), imsg.StatusInvalidInState, nil},
+		{"imstatus error", imstatus.InvalidCommand, imsg.StatusInvalidCommand, nil},
+		{"cluster status", imstatus.ClusterFailure(0x02), imsg.StatusFailure, ptrUint8(0x02)},
 	}
 
 	for _, tt := range tests {
@@ -485,9 +927,18 @@ func TestEngine_DispatcherErrorMapping(t *testing.T) {
 				t.Fatal("expected status response")
 			}
 
-			if invokeResp.InvokeResponses[0].Status.Status.Status != tt.wantStatus {
-				t.Errorf("status = %v, want %v", invokeResp.InvokeResponses[0].Status.Status.Status, tt.wantStatus)
+			status := invokeResp.InvokeResponses[0].Status.Status
+			if status.Status != tt.wantStatus {
+				t.Errorf("status = %v, want %v", status.Status, tt.wantStatus)
+			}
+			if (status.ClusterStatus == nil) != (tt.wantClusterStatus == nil) ||
+				(status.ClusterStatus != nil && *status.ClusterStatus != *tt.wantClusterStatus) {
+				t.Errorf("cluster status = %v, want %v", status.ClusterStatus, tt.wantClusterStatus)
 			}
 		})
 	}
 }
+
+func ptrUint8(v uint8) *uint8 {
+	return &v
+}
@@ -3,6 +3,8 @@ package im
 import (
 	"errors"
 
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/im/imstatus"
 	"github.com/backkem/matter/pkg/im/message"
 )
 
@@ -20,6 +22,10 @@ var (
 	// ErrAccessDenied indicates ACL check failed.
 	ErrAccessDenied = errors.New("im: access denied")
 
+	// ErrAccessRestricted indicates an Access Restriction List entry
+	// forbids an operation the ACL allows.
+	ErrAccessRestricted = errors.New("im: access restricted")
+
 	// ErrUnsupportedWrite indicates the attribute doesn't support writes.
 	ErrUnsupportedWrite = errors.New("im: unsupported write")
 
@@ -47,20 +53,27 @@ var (
 
 // ErrorToStatus maps an error to an IM status code.
 // This follows the Matter spec mapping of errors to status codes.
+// An *imstatus.Error gives its own status; errors the engine does not
+// know are mapped by imstatus.Status.
 func ErrorToStatus(err error) message.Status {
 	if err == nil {
 		return message.StatusSuccess
 	}
 
+	var se *imstatus.Error
 	switch {
+	case errors.As(err, &se):
+		return se.Status
 	case errors.Is(err, ErrClusterNotFound):
 		return message.StatusUnsupportedCluster
 	case errors.Is(err, ErrAttributeNotFound):
 		return message.StatusUnsupportedAttribute
 	case errors.Is(err, ErrCommandNotFound):
 		return message.StatusUnsupportedCommand
-	case errors.Is(err, ErrAccessDenied):
+	case errors.Is(err, ErrAccessDenied), errors.Is(err, datamodel.ErrUnsupportedAccess):
 		return message.StatusUnsupportedAccess
+	case errors.Is(err, ErrAccessRestricted):
+		return message.StatusAccessRestricted
 	case errors.Is(err, ErrUnsupportedWrite):
 		return message.StatusUnsupportedWrite
 	case errors.Is(err, ErrUnsupportedRead):
@@ -69,7 +82,7 @@ func ErrorToStatus(err error) message.Status {
 		return message.StatusConstraintError
 	case errors.Is(err, ErrDataVersionMismatch):
 		return message.StatusDataVersionMismatch
-	case errors.Is(err, ErrNeedsTimedInteraction):
+	case errors.Is(err, ErrNeedsTimedInteraction), errors.Is(err, datamodel.ErrTimedRequired):
 		return message.StatusNeedsTimedInteraction
 	case errors.Is(err, ErrInvalidPath):
 		return message.StatusInvalidAction
@@ -77,9 +90,25 @@ func ErrorToStatus(err error) message.Status {
 		return message.StatusBusy
 	case errors.Is(err, ErrResourceExhausted):
 		return message.StatusResourceExhausted
+	case errors.Is(err, datamodel.ErrFailsafeRequired):
+		return message.StatusFailsafeRequired
+	case errors.Is(err, datamodel.ErrNotFound):
+		return message.StatusNotFound
+	case errors.Is(err, datamodel.ErrAlreadyExists):
+		return message.StatusAlreadyExists
 	default:
-		return message.StatusFailure
+		return imstatus.Status(err)
+	}
+}
+
+// ErrorToStatusIB maps an error returned by a cluster to the StatusIB that
+// answers it, keeping the cluster-specific status of an *imstatus.Error.
+func ErrorToStatusIB(err error) message.StatusIB {
+	var se *imstatus.Error
+	if errors.As(err, &se) {
+		return se.StatusIB()
 	}
+	return message.StatusIB{Status: ErrorToStatus(err)}
 }
 
 // StatusToError maps an IM status code to an error.
@@ -95,6 +124,8 @@ func StatusToError(status message.Status) error {
 		return ErrCommandNotFound
 	case message.StatusUnsupportedAccess:
 		return ErrAccessDenied
+	case message.StatusAccessRestricted:
+		return ErrAccessRestricted
 	case message.StatusUnsupportedWrite:
 		return ErrUnsupportedWrite
 	case message.StatusUnsupportedRead:
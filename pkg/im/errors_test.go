@@ -2,8 +2,11 @@ package im
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/im/imstatus"
 	"github.com/backkem/matter/pkg/im/message"
 )
 
@@ -18,14 +21,23 @@ func TestErrorToStatus(t *testing.T) {
 		{"attribute not found", ErrAttributeNotFound, message.StatusUnsupportedAttribute},
 		{"command not found", ErrCommandNotFound, message.StatusUnsupportedCommand},
 		{"access denied", ErrAccessDenied, message.StatusUnsupportedAccess},
+		{"access restricted", ErrAccessRestricted, message.StatusAccessRestricted},
 		{"unsupported write", ErrUnsupportedWrite, message.StatusUnsupportedWrite},
 		{"unsupported read", ErrUnsupportedRead, message.StatusUnsupportedRead},
 		{"constraint error", ErrConstraintError, message.StatusConstraintError},
 		{"data version mismatch", ErrDataVersionMismatch, message.StatusDataVersionMismatch},
 		{"needs timed interaction", ErrNeedsTimedInteraction, message.StatusNeedsTimedInteraction},
+		{"datamodel timed required", fmt.Errorf("wrapped: %w", datamodel.ErrTimedRequired), message.StatusNeedsTimedInteraction},
 		{"invalid path", ErrInvalidPath, message.StatusInvalidAction},
 		{"busy", ErrBusy, message.StatusBusy},
 		{"resource exhausted", ErrResourceExhausted, message.StatusResourceExhausted},
+		{"datamodel unsupported access", datamodel.ErrUnsupportedAccess, message.StatusUnsupportedAccess},
+		{"datamodel failsafe required", datamodel.ErrFailsafeRequired, message.StatusFailsafeRequired},
+		{"datamodel not found", datamodel.ErrNotFound, message.StatusNotFound},
+		{"datamodel already exists", datamodel.ErrAlreadyExists, message.StatusAlreadyExists},
+		{"datamodel constraint error", datamodel.ErrConstraintError, message.StatusConstraintError},
+		{"datamodel invalid in state", datamodel.ErrInvalidInState, message.StatusInvalidInState},
+		{"imstatus error", fmt.Errorf("wrapped: %w", imstatus.InvalidCommand), message.StatusInvalidCommand},
 		{"unknown error", errors.New("something else"), message.StatusFailure},
 	}
 
@@ -50,6 +62,7 @@ func TestStatusToError(t *testing.T) {
 		{"unsupported attribute", message.StatusUnsupportedAttribute, ErrAttributeNotFound},
 		{"unsupported command", message.StatusUnsupportedCommand, ErrCommandNotFound},
 		{"unsupported access", message.StatusUnsupportedAccess, ErrAccessDenied},
+		{"access restricted", message.StatusAccessRestricted, ErrAccessRestricted},
 		{"unsupported write", message.StatusUnsupportedWrite, ErrUnsupportedWrite},
 		{"unsupported read", message.StatusUnsupportedRead, ErrUnsupportedRead},
 		{"constraint error", message.StatusConstraintError, ErrConstraintError},
@@ -97,6 +110,7 @@ func TestErrorToStatusToError_Roundtrip(t *testing.T) {
 		ErrAttributeNotFound,
 		ErrCommandNotFound,
 		ErrAccessDenied,
+		ErrAccessRestricted,
 		ErrUnsupportedWrite,
 		ErrUnsupportedRead,
 		ErrConstraintError,
@@ -1,6 +1,7 @@
 package im
 
 import (
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -74,18 +75,21 @@ func (p EventPath) ToEventPathIB() message.EventPathIB {
 // EventManagerConfig configures the EventManager.
 type EventManagerConfig struct {
 	// MaxEvents is the maximum number of events to retain.
-	// Oldest events are evicted when this limit is exceeded.
+	// When this limit is exceeded, the oldest event of the lowest priority
+	// held is dropped.
 	// Default: 100
 	MaxEvents int
 
-	// MaxEventsPerPriority limits events per priority level.
+	// MaxEventsPerPriority limits events per priority level. When a
+	// level is full, its oldest event is dropped.
 	// Default: 50 per level
 	MaxEventsPerPriority int
 }
 
 // EventManager manages event generation and storage.
 // It maintains a circular buffer of recent events per priority level
-// and generates monotonically increasing event numbers.
+// and generates monotonically increasing event numbers. Full buffers drop
+// lower priority events first.
 type EventManager struct {
 	config EventManagerConfig
 
@@ -97,6 +101,9 @@ type EventManager struct {
 	// Global event counter (monotonically increasing)
 	nextEventNumber uint64
 
+	// dropped counts the events evicted per priority level.
+	dropped [EventPriorityCritical + 1]uint64
+
 	// Listeners for event notifications
 	listeners []EventListener
 
@@ -194,6 +201,7 @@ func (m *EventManager) PublishEventWithFabric(
 	case EventPriorityCritical:
 		m.criticalEvents = m.appendEvent(m.criticalEvents, record)
 	}
+	m.evictOverflow()
 
 	// Copy listeners for notification outside lock
 	listeners := make([]EventListener, len(m.listeners))
@@ -214,10 +222,38 @@ func (m *EventManager) appendEvent(queue []*EventRecord, record *EventRecord) []
 	if len(queue) >= m.config.MaxEventsPerPriority {
 		// Evict oldest (first element)
 		queue = queue[1:]
+		m.dropped[record.Priority]++
 	}
 	return append(queue, record)
 }
 
+// evictOverflow drops the oldest events of the lowest priorities until at
+// most MaxEvents are held.
+//
+// C++ Reference: EventManagement::EnsureSpaceInCircularBuffer
+func (m *EventManager) evictOverflow() {
+	queues := []*[]*EventRecord{&m.debugEvents, &m.infoEvents, &m.criticalEvents}
+	total := len(m.debugEvents) + len(m.infoEvents) + len(m.criticalEvents)
+	for priority, queue := range queues {
+		for total > m.config.MaxEvents && len(*queue) > 0 {
+			*queue = (*queue)[1:]
+			m.dropped[priority]++
+			total--
+		}
+	}
+}
+
+// Dropped returns the number of events of a priority level evicted from
+// full buffers.
+func (m *EventManager) Dropped(priority EventPriority) uint64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if int(priority) >= len(m.dropped) {
+		return 0
+	}
+	return m.dropped[priority]
+}
+
 // GetEvents returns events matching the filter criteria.
 func (m *EventManager) GetEvents(
 	path *EventPath,
@@ -256,6 +292,16 @@ func (m *EventManager) GetEvents(
 	return result
 }
 
+// EventsSince returns the events numbered minEventNumber or above that are
+// visible to the fabric, in EventNumber order.
+func (m *EventManager) EventsSince(minEventNumber message.EventNumber, fabricIndex uint8) []*EventRecord {
+	events := m.GetEvents(nil, &minEventNumber, fabricIndex, nil)
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].EventNumber < events[j].EventNumber
+	})
+	return events
+}
+
 // matchesFilter checks if a record matches the filter criteria.
 func (m *EventManager) matchesFilter(
 	record *EventRecord,
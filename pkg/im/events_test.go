@@ -327,3 +327,57 @@ func (l *testListener) OnEvent(r *EventRecord) {
 		l.onEvent(r)
 	}
 }
+
+func TestEventManager_EvictLowestPriority(t *testing.T) {
+	em := NewEventManager(EventManagerConfig{
+		MaxEvents:            3,
+		MaxEventsPerPriority: 3,
+	})
+
+	em.PublishEvent(1, 0x0006, 0, EventPriorityCritical, nil)
+	em.PublishEvent(1, 0x0006, 1, EventPriorityDebug, nil)
+	em.PublishEvent(1, 0x0006, 2, EventPriorityInfo, nil)
+	em.PublishEvent(1, 0x0006, 3, EventPriorityInfo, nil)
+	em.PublishEvent(1, 0x0006, 4, EventPriorityCritical, nil)
+
+	// The debug event goes first, then the oldest info event
+	events := em.EventsSince(0, 0)
+	want := []message.EventID{0, 3, 4}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %d", len(want), len(events))
+	}
+	for i, id := range want {
+		if events[i].Path.EventID != id {
+			t.Errorf("event %d: expected ID %d, got %d", i, id, events[i].Path.EventID)
+		}
+	}
+
+	if got := em.Dropped(EventPriorityDebug); got != 1 {
+		t.Errorf("expected 1 debug event dropped, got %d", got)
+	}
+	if got := em.Dropped(EventPriorityInfo); got != 1 {
+		t.Errorf("expected 1 info event dropped, got %d", got)
+	}
+	if got := em.Dropped(EventPriorityCritical); got != 0 {
+		t.Errorf("expected no critical events dropped, got %d", got)
+	}
+}
+
+func TestEventManager_EventsSince(t *testing.T) {
+	em := NewEventManager(EventManagerConfig{})
+
+	em.PublishEvent(1, 0x0006, 0, EventPriorityCritical, nil)
+	second := em.PublishEvent(1, 0x0006, 1, EventPriorityDebug, nil)
+	em.PublishEventWithFabric(1, 0x0006, 2, EventPriorityInfo, nil, 2)
+	last := em.PublishEvent(1, 0x0006, 3, EventPriorityInfo, nil)
+
+	// Ordered by number across priorities, without other fabrics' events
+	events := em.EventsSince(second, 1)
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].EventNumber != second || events[1].EventNumber != last {
+		t.Errorf("expected events %d and %d, got %d and %d",
+			second, last, events[0].EventNumber, events[1].EventNumber)
+	}
+}
@@ -0,0 +1,99 @@
+package im
+
+import (
+	"context"
+
+	"github.com/backkem/matter/pkg/exchange"
+	imsg "github.com/backkem/matter/pkg/im/message"
+	"github.com/backkem/matter/pkg/metrics"
+	"github.com/backkem/matter/pkg/session"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// groupSession returns the group session of an exchange started by a
+// group message, if it is one.
+func groupSession(ctx *exchange.ExchangeContext) (*session.GroupContext, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	group, ok := ctx.Session().(*session.GroupContext)
+	return group, ok
+}
+
+// handleGroupMessage processes a message sent to a group. Only invokes
+// are executed; other actions are dropped. Group messages are never
+// answered, not even with a status.
+//
+// Spec: Section 8.2.5 (groupcast actions)
+// C++ Reference: InteractionModelEngine::OnMessageReceived (group session)
+func (e *Engine) handleGroupMessage(ctx *exchange.ExchangeContext, group *session.GroupContext, opcode imsg.Opcode, payload []byte) {
+	if opcode != imsg.OpcodeInvokeRequest {
+		if e.log != nil {
+			e.log.Debugf("dropping %s sent to group 0x%04x", opcode.String(), group.GroupID())
+		}
+		return
+	}
+
+	e.metrics.Add(metrics.IMTransactions, 1, metrics.L(metrics.LabelAction, "invoke"))
+	_, span := e.tracer.Start(context.Background(), "im.invoke",
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attrAction.String("invoke"), attrGroup.Int(int(group.GroupID()))))
+	e.handleGroupInvoke(ctx, group, payload)
+	span.End()
+}
+
+// handleGroupInvoke executes the commands of a group InvokeRequest on
+// each endpoint of the group that the group's ACL entries allow. Failures
+// are only logged: nobody awaits their status.
+//
+// Spec: Section 8.9.2.4 (group invoke), 8.9.3.2 (no endpoint in the path)
+// C++ Reference: CommandHandler::ProcessGroupCommandDataIB
+func (e *Engine) handleGroupInvoke(ctx *exchange.ExchangeContext, group *session.GroupContext, payload []byte) {
+	req, err := DecodeInvokeRequest(payload)
+	if err != nil {
+		if e.log != nil {
+			e.log.Debugf("group 0x%04x: invalid InvokeRequest: %v", group.GroupID(), err)
+		}
+		return
+	}
+	// Timed invokes need a response, which groups never get (Spec 8.7.1)
+	if req.TimedRequest {
+		if e.log != nil {
+			e.log.Debugf("group 0x%04x: dropping timed InvokeRequest", group.GroupID())
+		}
+		return
+	}
+
+	membership, ok := e.dispatcher.(GroupMembership)
+	if !ok {
+		return
+	}
+	endpoints := membership.GroupEndpoints(group.FabricIndex(), group.GroupID())
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	dispatcher := e.requestDispatcher(ctx)
+	cmdHandler := e.createCommandHandler(dispatcher)
+	invokeCtx := &InvokeContext{
+		Exchange:     ctx,
+		FabricIndex:  uint8(group.FabricIndex()),
+		SourceNodeID: uint64(group.SourceNodeID()),
+	}
+
+	for _, cmd := range req.InvokeRequests {
+		// The endpoints come from the group, whatever the path says
+		for _, endpoint := range endpoints {
+			path := imsg.CommandPathIB{
+				Endpoint: endpoint,
+				Cluster:  cmd.Path.Cluster,
+				Command:  cmd.Path.Command,
+			}
+			result, _ := cmdHandler(invokeCtx, path, cmd.Fields)
+			if result != nil && result.Status != nil && result.Status.Status != imsg.StatusSuccess && e.log != nil {
+				e.log.Debugf("group 0x%04x: command 0x%04X/0x%02X on endpoint %d: %s",
+					group.GroupID(), path.Cluster, path.Command, endpoint, result.Status.Status.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package im
+
+import (
+	"testing"
+
+	"github.com/backkem/matter/pkg/acl"
+	"github.com/backkem/matter/pkg/exchange"
+	"github.com/backkem/matter/pkg/fabric"
+	imsg "github.com/backkem/matter/pkg/im/message"
+	"github.com/backkem/matter/pkg/message"
+	"github.com/backkem/matter/pkg/session"
+)
+
+// groupDispatcher adds group membership to a MockDispatcher.
+type groupDispatcher struct {
+	*MockDispatcher
+	endpoints map[uint16][]imsg.EndpointID
+}
+
+func (d groupDispatcher) GroupEndpoints(fabricIndex fabric.FabricIndex, groupID uint16) []imsg.EndpointID {
+	if fabricIndex != 1 {
+		return nil
+	}
+	return d.endpoints[groupID]
+}
+
+// groupExchange returns the exchange of a message received for a group.
+func groupExchange(t *testing.T, groupID uint16) *exchange.ExchangeContext {
+	t.Helper()
+	group, err := session.NewGroupContext(session.GroupContextConfig{
+		SourceNodeID:   0x1234,
+		FabricIndex:    1,
+		GroupID:        groupID,
+		GroupSessionID: 0x5A5A,
+		OperationalKey: make([]byte, session.SessionKeySize),
+	})
+	if err != nil {
+		t.Fatalf("NewGroupContext: %v", err)
+	}
+	return exchange.NewExchangeContext(exchange.ExchangeContextConfig{
+		Role:    exchange.ExchangeRoleResponder,
+		Session: group,
+	})
+}
+
+func TestEngine_GroupInvoke(t *testing.T) {
+	// The group may operate the OnOff cluster on endpoint 1 only
+	checker := acl.NewChecker(nil)
+	checker.SetEntries([]acl.Entry{{
+		FabricIndex: 1,
+		Privilege:   acl.PrivilegeOperate,
+		AuthMode:    acl.AuthModeGroup,
+		Subjects:    []uint64{acl.NodeIDFromGroupID(0x0101)},
+		Targets:     []acl.Target{acl.NewTargetClusterEndpoint(0x0006, 1)},
+	}})
+
+	mock := NewMockDispatcher()
+	engine := NewEngine(EngineConfig{
+		Dispatcher: groupDispatcher{
+			MockDispatcher: mock,
+			endpoints:      map[uint16][]imsg.EndpointID{0x0101: {1, 2}},
+		},
+		ACLChecker: checker,
+	})
+
+	invoke := func(t *testing.T, groupID uint16, req *imsg.InvokeRequestMessage) {
+		t.Helper()
+		payload, err := EncodeInvokeRequest(req)
+		if err != nil {
+			t.Fatalf("EncodeInvokeRequest: %v", err)
+		}
+		header := &message.ProtocolHeader{ProtocolOpcode: uint8(imsg.OpcodeInvokeRequest)}
+		resp, err := engine.OnMessage(groupExchange(t, groupID), header, payload)
+		if err != nil || resp != nil {
+			t.Fatalf("OnMessage() = %x, %v, want no response", resp, err)
+		}
+	}
+	toggle := imsg.CommandDataIB{Path: imsg.CommandPathIB{WildcardEndpoint: true, Cluster: 0x0006, Command: 0x02}}
+
+	t.Run("group endpoints", func(t *testing.T) {
+		mock.Reset()
+		invoke(t, 0x0101, &imsg.InvokeRequestMessage{
+			SuppressResponse: true,
+			InvokeRequests:   []imsg.CommandDataIB{toggle},
+		})
+
+		// Endpoint 2 is in the group, but denied by the ACL
+		calls := mock.InvokeCalls()
+		if len(calls) != 1 {
+			t.Fatalf("got %d invokes, want 1", len(calls))
+		}
+		if path := calls[0].Path; path.Endpoint != 1 || path.Cluster != 0x0006 || path.Command != 0x02 {
+			t.Errorf("path = %+v, want 1/0x0006/0x02", path)
+		}
+	})
+
+	t.Run("unknown group", func(t *testing.T) {
+		mock.Reset()
+		invoke(t, 0x0202, &imsg.InvokeRequestMessage{InvokeRequests: []imsg.CommandDataIB{toggle}})
+		if calls := mock.InvokeCalls(); len(calls) != 0 {
+			t.Errorf("got %d invokes, want 0", len(calls))
+		}
+	})
+
+	t.Run("timed", func(t *testing.T) {
+		mock.Reset()
+		invoke(t, 0x0101, &imsg.InvokeRequestMessage{
+			TimedRequest:   true,
+			InvokeRequests: []imsg.CommandDataIB{toggle},
+		})
+		if calls := mock.InvokeCalls(); len(calls) != 0 {
+			t.Errorf("got %d invokes, want 0", len(calls))
+		}
+	})
+
+	t.Run("read", func(t *testing.T) {
+		mock.Reset()
+		payload, err := EncodeReadRequest(&imsg.ReadRequestMessage{
+			AttributeRequests: []imsg.AttributePathIB{makeAttrPath(1, 0x0006, 0x0000)},
+		})
+		if err != nil {
+			t.Fatalf("EncodeReadRequest: %v", err)
+		}
+		header := &message.ProtocolHeader{ProtocolOpcode: uint8(imsg.OpcodeReadRequest)}
+		resp, err := engine.OnMessage(groupExchange(t, 0x0101), header, payload)
+		if err != nil || resp != nil {
+			t.Fatalf("OnMessage() = %x, %v, want no response", resp, err)
+		}
+		if calls := mock.ReadCalls(); len(calls) != 0 {
+			t.Errorf("got %d reads, want 0", len(calls))
+		}
+	})
+}
@@ -5,7 +5,9 @@ import (
 	"encoding/hex"
 	"errors"
 	"sync"
+	"time"
 
+	"github.com/backkem/matter/pkg/datamodel"
 	"github.com/backkem/matter/pkg/exchange"
 	"github.com/backkem/matter/pkg/im/message"
 	"github.com/backkem/matter/pkg/tlv"
@@ -18,6 +20,10 @@ var (
 	ErrInvokeTimedMismatch     = errors.New("invoke handler: timed request mismatch")
 	ErrInvokeCommandNotFound   = errors.New("invoke handler: command not found")
 	ErrInvokeInvalidPath       = errors.New("invoke handler: invalid command path")
+
+	// ErrDeferredResponseTimeout completes a deferred response the
+	// cluster did not complete in time.
+	ErrDeferredResponseTimeout = errors.New("invoke handler: deferred response timed out")
 )
 
 // CommandHandler is called to process an invoke request.
@@ -41,6 +47,21 @@ type CommandResult struct {
 
 	// Status is set if the command failed with a status instead of response.
 	Status *message.StatusIB
+
+	// Deferred is set if the cluster completes the command later. The
+	// response is then built from its result, with ResponsePath.
+	Deferred *datamodel.DeferredResponse
+}
+
+// deferredCommand is a command of a request whose response is deferred.
+type deferredCommand struct {
+	// index is the position of the command's response.
+	index int
+
+	cmdData  message.CommandDataIB
+	ref      *uint16
+	path     message.CommandPathIB
+	response *datamodel.DeferredResponse
 }
 
 // InvokeContext provides context for command invocation.
@@ -66,6 +87,10 @@ const (
 	InvokeHandlerStateReceiving
 	InvokeHandlerStateProcessing
 	InvokeHandlerStateSendingResponse
+
+	// InvokeHandlerStateAwaitingResponse waits for deferred command
+	// responses.
+	InvokeHandlerStateAwaitingResponse
 )
 
 // String returns the state name.
@@ -79,6 +104,8 @@ func (s InvokeHandlerState) String() string {
 		return "Processing"
 	case InvokeHandlerStateSendingResponse:
 		return "SendingResponse"
+	case InvokeHandlerStateAwaitingResponse:
+		return "AwaitingResponse"
 	default:
 		return "Unknown"
 	}
@@ -102,6 +129,10 @@ type InvokeHandler struct {
 	pendingChunks []*message.InvokeResponseMessage
 	chunkIndex    int
 
+	// The response awaiting deferred command responses
+	pendingResponse *message.InvokeResponseMessage
+	deferred        []deferredCommand
+
 	log logging.LeveledLogger
 	mu  sync.Mutex
 }
@@ -163,6 +194,65 @@ func (h *InvokeHandler) HandleInvokeRequest(
 		InvokeResponses:  responses,
 	}
 
+	// Deferred responses are sent by AwaitDeferred
+	if len(h.deferred) > 0 {
+		h.state = InvokeHandlerStateAwaitingResponse
+		h.pendingResponse = response
+		return nil, nil
+	}
+
+	return h.fragmentLocked(response)
+}
+
+// AwaitDeferred waits for the deferred command responses of the request,
+// up to timeout, and returns the response message (its first chunk, if
+// chunked). Responses not complete by then are answered with Failure.
+// Returns nil if the handler is not awaiting responses, e.g. because it was
+// reset as the exchange closed.
+func (h *InvokeHandler) AwaitDeferred(timeout <-chan time.Time) (*message.InvokeResponseMessage, error) {
+	h.mu.Lock()
+	if h.state != InvokeHandlerStateAwaitingResponse {
+		h.mu.Unlock()
+		return nil, nil
+	}
+	deferred := h.deferred
+	h.mu.Unlock()
+
+	for _, d := range deferred {
+		select {
+		case <-d.response.Done():
+		case <-timeout:
+			d.response.Complete(nil, ErrDeferredResponseTimeout)
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.state != InvokeHandlerStateAwaitingResponse {
+		return nil, nil
+	}
+
+	response := h.pendingResponse
+	for _, d := range deferred {
+		data, err := d.response.Result()
+		result := &CommandResult{ResponsePath: d.path, ResponseData: data}
+		if err != nil {
+			status := ErrorToStatusIB(err)
+			result = &CommandResult{Status: &status}
+		}
+		resp := h.commandResponse(&d.cmdData, result)
+		setCommandRef(&resp, d.ref)
+		response.InvokeResponses[d.index] = resp
+	}
+	h.pendingResponse = nil
+	h.deferred = nil
+
+	return h.fragmentLocked(response)
+}
+
+// fragmentLocked splits the response into chunks and returns the first.
+// Caller must hold h.mu.
+func (h *InvokeHandler) fragmentLocked(response *message.InvokeResponseMessage) (*message.InvokeResponseMessage, error) {
 	// Check if response needs chunking
 	chunks, err := h.fragmenter.FragmentInvokeResponse(response)
 	if err != nil {
@@ -225,54 +315,83 @@ func (h *InvokeHandler) HandleStatusResponse(status message.Status) (*message.In
 func (h *InvokeHandler) processCommands(msg *message.InvokeRequestMessage) ([]message.InvokeResponseIB, error) {
 	var responses []message.InvokeResponseIB
 
+	h.deferred = nil
 	for i, cmdData := range msg.InvokeRequests {
-		response, err := h.invokeCommand(&cmdData)
-		if err != nil {
-			// Create error response for this command
-			response = h.createErrorResponse(&cmdData, message.StatusFailure)
-		}
-
 		// Set CommandRef if present in request (for batch correlation)
+		var ref *uint16
 		if cmdData.Ref != nil {
-			if response.Command != nil {
-				response.Command.Ref = cmdData.Ref
-			}
-			if response.Status != nil {
-				ref := *cmdData.Ref
-				response.Status.Ref = &ref
-			}
+			ref = cmdData.Ref
 		} else if len(msg.InvokeRequests) > 1 {
 			// Multiple commands require CommandRef per spec
 			// Use index as implicit ref
-			ref := uint16(i)
-			if response.Command != nil {
-				response.Command.Ref = &ref
-			}
-			if response.Status != nil {
-				response.Status.Ref = &ref
-			}
+			implicit := uint16(i)
+			ref = &implicit
 		}
 
+		response, err := h.invokeCommand(i, ref, &cmdData)
+		if err != nil {
+			// Create error response for this command
+			response = h.createErrorResponse(&cmdData, message.StatusFailure)
+		}
+		setCommandRef(&response, ref)
+
 		responses = append(responses, response)
 	}
 
 	return responses, nil
 }
 
-// invokeCommand calls the command handler for a single command.
-func (h *InvokeHandler) invokeCommand(cmdData *message.CommandDataIB) (message.InvokeResponseIB, error) {
+// setCommandRef sets the CommandRef of a response, if ref is not nil.
+func setCommandRef(response *message.InvokeResponseIB, ref *uint16) {
+	if ref == nil {
+		return
+	}
+	if response.Command != nil {
+		response.Command.Ref = ref
+	}
+	if response.Status != nil {
+		r := *ref
+		response.Status.Ref = &r
+	}
+}
+
+// invokeCommand calls the command handler for a single command, the
+// index-th of the request. A deferred command is recorded and answered
+// with a placeholder until AwaitDeferred.
+func (h *InvokeHandler) invokeCommand(index int, ref *uint16, cmdData *message.CommandDataIB) (message.InvokeResponseIB, error) {
 	if h.commandHandler == nil {
 		return h.createErrorResponse(cmdData, message.StatusUnsupportedCommand), nil
 	}
 
+	// Only group invokes may omit the endpoint (Spec 8.9.3.2)
+	if cmdData.Path.WildcardEndpoint {
+		return h.createErrorResponse(cmdData, message.StatusInvalidAction), nil
+	}
+
 	result, err := h.commandHandler(h.ctx, cmdData.Path, cmdData.Fields)
 	if err != nil {
 		return h.createErrorResponse(cmdData, message.StatusFailure), nil
 	}
 
+	if result != nil && result.Deferred != nil {
+		h.deferred = append(h.deferred, deferredCommand{
+			index:    index,
+			cmdData:  *cmdData,
+			ref:      ref,
+			path:     result.ResponsePath,
+			response: result.Deferred,
+		})
+		return h.createSuccessResponse(cmdData), nil
+	}
+
+	return h.commandResponse(cmdData, result), nil
+}
+
+// commandResponse builds the response to a command from its result.
+func (h *InvokeHandler) commandResponse(cmdData *message.CommandDataIB, result *CommandResult) message.InvokeResponseIB {
 	if result == nil {
 		// No response (command with no response data)
-		return h.createSuccessResponse(cmdData), nil
+		return h.createSuccessResponse(cmdData)
 	}
 
 	if result.Status != nil {
@@ -282,7 +401,7 @@ func (h *InvokeHandler) invokeCommand(cmdData *message.CommandDataIB) (message.I
 				Path:   cmdData.Path,
 				Status: *result.Status,
 			},
-		}, nil
+		}
 	}
 
 	// Command returned response data
@@ -301,7 +420,7 @@ func (h *InvokeHandler) invokeCommand(cmdData *message.CommandDataIB) (message.I
 			Path:   result.ResponsePath,
 			Fields: result.ResponseData,
 		},
-	}, nil
+	}
 }
 
 // createErrorResponse creates an error response for a command.
@@ -337,9 +456,18 @@ func (h *InvokeHandler) Reset() {
 	h.ctx = nil
 	h.pendingChunks = nil
 	h.chunkIndex = 0
+	h.pendingResponse = nil
+	h.deferred = nil
 	h.assembler.Reset()
 }
 
+// onExchange reports whether the handler's request arrived on ctx.
+func (h *InvokeHandler) onExchange(ctx *exchange.ExchangeContext) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.ctx != nil && h.ctx.Exchange == ctx
+}
+
 // State returns the current handler state.
 func (h *InvokeHandler) State() InvokeHandlerState {
 	h.mu.Lock()
@@ -2,7 +2,10 @@ package im
 
 import (
 	"testing"
+	"time"
 
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/im/imstatus"
 	"github.com/backkem/matter/pkg/im/message"
 )
 
@@ -112,6 +115,38 @@ func TestInvokeHandler_NoHandler(t *testing.T) {
 	}
 }
 
+func TestInvokeHandler_WildcardEndpoint(t *testing.T) {
+	called := false
+	handler := NewInvokeHandler(func(ctx *InvokeContext, path message.CommandPathIB, fields []byte) (*CommandResult, error) {
+		called = true
+		return nil, nil
+	}, DefaultMaxPayload, nil)
+
+	req := &message.InvokeRequestMessage{
+		InvokeRequests: []message.CommandDataIB{
+			{
+				Path: message.CommandPathIB{
+					Cluster:          0x0006,
+					Command:          0x02,
+					WildcardEndpoint: true,
+				},
+			},
+		},
+	}
+
+	resp, err := handler.HandleInvokeRequest(nil, req, 1, 12345, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if called {
+		t.Error("command handler called for a wildcard endpoint")
+	}
+	if resp.InvokeResponses[0].Status == nil || resp.InvokeResponses[0].Status.Status.Status != message.StatusInvalidAction {
+		t.Errorf("expected InvalidAction, got %+v", resp.InvokeResponses[0])
+	}
+}
+
 func TestInvokeHandler_BatchCommands(t *testing.T) {
 	callCount := 0
 	handler := NewInvokeHandler(func(ctx *InvokeContext, path message.CommandPathIB, fields []byte) (*CommandResult, error) {
@@ -352,6 +387,129 @@ func TestInvokeHandler_InvokeContext(t *testing.T) {
 	}
 }
 
+func TestInvokeHandler_DeferredResponse(t *testing.T) {
+	deferred := map[message.CommandID]*datamodel.DeferredResponse{
+		0x00: datamodel.NewDeferredResponse(),
+		0x02: datamodel.NewDeferredResponse(),
+	}
+	handler := NewInvokeHandler(func(ctx *InvokeContext, path message.CommandPathIB, fields []byte) (*CommandResult, error) {
+		responsePath := path
+		responsePath.Command++
+		if d, ok := deferred[path.Command]; ok {
+			return &CommandResult{ResponsePath: responsePath, Deferred: d}, nil
+		}
+		return &CommandResult{ResponsePath: responsePath, ResponseData: []byte{0x15, 0x18}}, nil
+	}, DefaultMaxPayload, nil)
+
+	req := &message.InvokeRequestMessage{
+		InvokeRequests: []message.CommandDataIB{
+			{Path: message.CommandPathIB{Endpoint: 0, Cluster: 0x0031, Command: 0x00}},
+			{Path: message.CommandPathIB{Endpoint: 0, Cluster: 0x0031, Command: 0x04}},
+			{Path: message.CommandPathIB{Endpoint: 0, Cluster: 0x0031, Command: 0x02}},
+		},
+	}
+
+	resp, err := handler.HandleInvokeRequest(nil, req, 1, 12345, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp != nil {
+		t.Fatal("expected the response to wait for the deferred commands")
+	}
+	if handler.State() != InvokeHandlerStateAwaitingResponse {
+		t.Fatalf("expected AwaitingResponse state, got %s", handler.State())
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		deferred[0x00].Complete([]byte{0x15, 0x24, 0x00, 0x00, 0x18}, nil)
+		deferred[0x02].Complete(nil, imstatus.ClusterFailure(0x05))
+	}()
+
+	resp, err = handler.AwaitDeferred(time.After(5 * time.Second))
+	if err != nil {
+		t.Fatalf("AwaitDeferred: %v", err)
+	}
+	if len(resp.InvokeResponses) != 3 {
+		t.Fatalf("expected 3 responses, got %d", len(resp.InvokeResponses))
+	}
+
+	first := resp.InvokeResponses[0].Command
+	if first == nil || first.Path.Command != 0x01 || len(first.Fields) != 5 {
+		t.Errorf("response 0 = %+v, want the deferred response fields", resp.InvokeResponses[0])
+	}
+	if first != nil && (first.Ref == nil || *first.Ref != 0) {
+		t.Errorf("response 0 ref = %v, want 0", first.Ref)
+	}
+	if resp.InvokeResponses[1].Command == nil || resp.InvokeResponses[1].Command.Path.Command != 0x05 {
+		t.Errorf("response 1 = %+v, want the immediate response", resp.InvokeResponses[1])
+	}
+	third := resp.InvokeResponses[2].Status
+	if third == nil || third.Status.Status != message.StatusFailure ||
+		third.Status.ClusterStatus == nil || *third.Status.ClusterStatus != 0x05 {
+		t.Errorf("response 2 = %+v, want Failure with cluster status 0x05", resp.InvokeResponses[2])
+	}
+	if third != nil && (third.Ref == nil || *third.Ref != 2) {
+		t.Errorf("response 2 ref = %v, want 2", third.Ref)
+	}
+	if handler.State() != InvokeHandlerStateIdle {
+		t.Errorf("expected idle state, got %s", handler.State())
+	}
+}
+
+func TestInvokeHandler_DeferredResponseTimeout(t *testing.T) {
+	d := datamodel.NewDeferredResponse()
+	handler := NewInvokeHandler(func(ctx *InvokeContext, path message.CommandPathIB, fields []byte) (*CommandResult, error) {
+		return &CommandResult{ResponsePath: path, Deferred: d}, nil
+	}, DefaultMaxPayload, nil)
+
+	req := &message.InvokeRequestMessage{
+		InvokeRequests: []message.CommandDataIB{
+			{Path: message.CommandPathIB{Endpoint: 0, Cluster: 0x0031, Command: 0x06}},
+		},
+	}
+	if _, err := handler.HandleInvokeRequest(nil, req, 1, 12345, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	resp, err := handler.AwaitDeferred(time.After(10 * time.Millisecond))
+	if err != nil {
+		t.Fatalf("AwaitDeferred: %v", err)
+	}
+	status := resp.InvokeResponses[0].Status
+	if status == nil || status.Status.Status != message.StatusFailure {
+		t.Errorf("response = %+v, want Failure", resp.InvokeResponses[0])
+	}
+	if d.Complete(nil, nil) {
+		t.Error("Complete after the timeout took effect")
+	}
+}
+
+func TestInvokeHandler_DeferredReset(t *testing.T) {
+	d := datamodel.NewDeferredResponse()
+	handler := NewInvokeHandler(func(ctx *InvokeContext, path message.CommandPathIB, fields []byte) (*CommandResult, error) {
+		return &CommandResult{ResponsePath: path, Deferred: d}, nil
+	}, DefaultMaxPayload, nil)
+
+	req := &message.InvokeRequestMessage{
+		InvokeRequests: []message.CommandDataIB{
+			{Path: message.CommandPathIB{Endpoint: 0, Cluster: 0x0031, Command: 0x06}},
+		},
+	}
+	if _, err := handler.HandleInvokeRequest(nil, req, 1, 12345, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The exchange closed before the command completed
+	handler.Reset()
+	d.Complete(nil, nil)
+
+	resp, err := handler.AwaitDeferred(time.After(time.Second))
+	if err != nil || resp != nil {
+		t.Errorf("AwaitDeferred() = %v, %v; want no response after a reset", resp, err)
+	}
+}
+
 func TestInvokeHandlerState_String(t *testing.T) {
 	tests := []struct {
 		state InvokeHandlerState
@@ -361,6 +519,7 @@ func TestInvokeHandlerState_String(t *testing.T) {
 		{InvokeHandlerStateReceiving, "Receiving"},
 		{InvokeHandlerStateProcessing, "Processing"},
 		{InvokeHandlerStateSendingResponse, "SendingResponse"},
+		{InvokeHandlerStateAwaitingResponse, "AwaitingResponse"},
 		{InvokeHandlerState(99), "Unknown"},
 	}
 
@@ -36,6 +36,22 @@ type AttributeResult struct {
 	Status *message.StatusIB
 }
 
+// EventReader is called to read the events on a request's event paths
+// numbered minEventNumber or above. It returns them in EventNumber order.
+type EventReader func(
+	ctx *ReadContext,
+	paths []message.EventPathIB,
+	minEventNumber message.EventNumber,
+) []message.EventReportIB
+
+// DataVersionLookup returns the current data version of a cluster
+// instance, and false if it is unknown.
+type DataVersionLookup func(endpoint message.EndpointID, cluster message.ClusterID) (message.DataVersion, bool)
+
+// PathExpansion returns the concrete attribute paths a wildcard path
+// matches.
+type PathExpansion func(path message.AttributePathIB) []message.AttributePathIB
+
 // ReadContext provides context for attribute reads.
 type ReadContext struct {
 	// Exchange is the underlying exchange context.
@@ -76,9 +92,9 @@ func (s ReadHandlerState) String() string {
 
 // ReadHandler handles read request messages.
 // This is a simplified implementation for Descriptor/Basic clusters.
-// It does NOT support:
-//   - Wildcard path expansion (concrete paths only)
-//   - Complex ACL checks (assumes caller validated access)
+// Wildcard paths are expanded with the PathExpansion set on it. It does
+// NOT support:
+//   - ACL checks (the AttributeReader enforces access)
 //   - Chunked report assembly (single response)
 //
 // For full IM spec compliance, see docs/pkgs/im-plan.md.
@@ -86,6 +102,18 @@ type ReadHandler struct {
 	// attributeReader is called to read attributes.
 	attributeReader AttributeReader
 
+	// eventReader is called to read events. If nil, event paths are
+	// not reported.
+	eventReader EventReader
+
+	// dataVersions looks up cluster versions for DataVersionFilters.
+	// If nil, filters are ignored.
+	dataVersions DataVersionLookup
+
+	// expand expands wildcard attribute paths. If nil, a wildcard is
+	// read as if its omitted fields were 0.
+	expand PathExpansion
+
 	// fragmenter for chunked responses
 	fragmenter *Fragmenter
 
@@ -109,6 +137,29 @@ func NewReadHandler(reader AttributeReader, maxPayload int) *ReadHandler {
 	}
 }
 
+// SetEventReader sets the reader of the events requested on event paths.
+func (h *ReadHandler) SetEventReader(reader EventReader) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.eventReader = reader
+}
+
+// SetDataVersionLookup sets the lookup of cluster versions. With it, the
+// handler omits the attributes of clusters whose version matches one of
+// the request's DataVersionFilters.
+func (h *ReadHandler) SetDataVersionLookup(lookup DataVersionLookup) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.dataVersions = lookup
+}
+
+// SetPathExpansion sets the expansion of wildcard attribute paths.
+func (h *ReadHandler) SetPathExpansion(expand PathExpansion) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.expand = expand
+}
+
 // HandleReadRequest processes an incoming ReadRequestMessage.
 // Returns the ReportData response message.
 func (h *ReadHandler) HandleReadRequest(
@@ -116,6 +167,31 @@ func (h *ReadHandler) HandleReadRequest(
 	msg *message.ReadRequestMessage,
 	fabricIndex uint8,
 	sourceNodeID uint64,
+) (*message.ReportDataMessage, error) {
+	return h.handleRead(exchCtx, msg, fabricIndex, sourceNodeID, nil)
+}
+
+// HandleSubscriptionReport reads the paths of a subscription and returns
+// the first ReportData of a report carrying its subscription ID. Each
+// report message expects a StatusResponse.
+func (h *ReadHandler) HandleSubscriptionReport(
+	exchCtx *exchange.ExchangeContext,
+	msg *message.ReadRequestMessage,
+	fabricIndex uint8,
+	sourceNodeID uint64,
+	subscriptionID message.SubscriptionID,
+) (*message.ReportDataMessage, error) {
+	return h.handleRead(exchCtx, msg, fabricIndex, sourceNodeID, &subscriptionID)
+}
+
+// handleRead builds the report for a read, or for a subscription if
+// subscriptionID is set.
+func (h *ReadHandler) handleRead(
+	exchCtx *exchange.ExchangeContext,
+	msg *message.ReadRequestMessage,
+	fabricIndex uint8,
+	sourceNodeID uint64,
+	subscriptionID *message.SubscriptionID,
 ) (*message.ReportDataMessage, error) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
@@ -134,17 +210,37 @@ func (h *ReadHandler) HandleReadRequest(
 	var attributeReports []message.AttributeReportIB
 
 	for _, attrPath := range msg.AttributeRequests {
-		report := h.readAttribute(&attrPath, msg.DataVersionFilters)
-		attributeReports = append(attributeReports, report)
+		if h.expand == nil || !isWildcardAttributePath(&attrPath) {
+			if !h.shouldSkipForDataVersion(&attrPath, msg.DataVersionFilters) {
+				attributeReports = append(attributeReports, h.readAttribute(&attrPath))
+			}
+			continue
+		}
+		// Filters apply to the cluster of each expanded path
+		for _, path := range h.expand(attrPath) {
+			if h.shouldSkipForDataVersion(&path, msg.DataVersionFilters) {
+				continue
+			}
+			report := h.readAttribute(&path)
+			if report.AttributeStatus != nil && omitFromWildcard(report.AttributeStatus.Status.Status) {
+				continue
+			}
+			attributeReports = append(attributeReports, report)
+		}
 	}
 
-	// Note: EventRequests are not handled in this simple implementation.
-	// For WebRTC, events are pushed via unsolicited reports, not pulled.
+	// Process event requests
+	var eventReports []message.EventReportIB
+	if len(msg.EventRequests) > 0 && h.eventReader != nil {
+		eventReports = h.eventReader(h.ctx, msg.EventRequests, eventMin(msg.EventFilters))
+	}
 
 	// Build response
 	response := &message.ReportDataMessage{
+		SubscriptionID:      subscriptionID,
 		AttributeReports:    attributeReports,
-		SuppressResponse:    true, // Read responses suppress further response
+		EventReports:        eventReports,
+		SuppressResponse:    subscriptionID == nil, // Read responses suppress further response
 		MoreChunkedMessages: false,
 	}
 
@@ -201,23 +297,11 @@ func (h *ReadHandler) HandleStatusResponse(status message.Status) (*message.Repo
 }
 
 // readAttribute reads a single attribute and returns a report IB.
-func (h *ReadHandler) readAttribute(
-	path *message.AttributePathIB,
-	dataVersionFilters []message.DataVersionFilterIB,
-) message.AttributeReportIB {
+func (h *ReadHandler) readAttribute(path *message.AttributePathIB) message.AttributeReportIB {
 	if h.attributeReader == nil {
 		return h.createAttributeStatusReport(path, message.StatusUnsupportedAttribute)
 	}
 
-	// Check data version filter
-	if h.shouldSkipForDataVersion(path, dataVersionFilters) {
-		// Skip - attribute unchanged. Return empty (will be filtered).
-		// Per spec, if version matches, we don't report.
-		// But for simplicity, we return a status indicating no change.
-		// In practice, we'd just not include this in the response.
-		return message.AttributeReportIB{}
-	}
-
 	result, err := h.attributeReader(h.ctx, *path)
 	if err != nil {
 		return h.createAttributeStatusReport(path, message.StatusFailure)
@@ -245,40 +329,60 @@ func (h *ReadHandler) readAttribute(
 	}
 }
 
-// shouldSkipForDataVersion checks if the attribute should be skipped due to version filter.
+// omitFromWildcard reports whether a status read on a path expanded from
+// a wildcard is left out of the report rather than reported.
+//
+// Spec: Section 8.4.3.2 (wildcard paths skip unreadable attributes)
+func omitFromWildcard(status message.Status) bool {
+	switch status {
+	case message.StatusUnsupportedAccess, message.StatusUnsupportedRead, message.StatusAccessRestricted:
+		return true
+	default:
+		return false
+	}
+}
+
+// eventMin returns the lowest event number the filters ask for.
+func eventMin(filters []message.EventFilterIB) message.EventNumber {
+	var min message.EventNumber
+	for _, filter := range filters {
+		if filter.EventMin > min {
+			min = filter.EventMin
+		}
+	}
+	return min
+}
+
+// shouldSkipForDataVersion reports whether the attribute's cluster is
+// unchanged since a version the client holds, so it is left out.
+//
+// Spec: Section 8.4.3.2 (DataVersionFilters)
 func (h *ReadHandler) shouldSkipForDataVersion(
 	path *message.AttributePathIB,
 	filters []message.DataVersionFilterIB,
 ) bool {
-	if len(filters) == 0 {
+	if len(filters) == 0 || h.dataVersions == nil || path.Endpoint == nil || path.Cluster == nil {
 		return false
 	}
 
-	// Find matching filter
 	for _, filter := range filters {
-		if h.pathMatchesFilter(path, &filter.Path) {
-			// For now, we don't track data versions, so always report.
-			// A full implementation would compare filter.DataVersion with current version.
-			return false
+		if !h.pathMatchesFilter(path, &filter.Path) {
+			continue
 		}
+		version, ok := h.dataVersions(*path.Endpoint, *path.Cluster)
+		return ok && version == filter.DataVersion
 	}
 
 	return false
 }
 
-// pathMatchesFilter checks if an attribute path matches a cluster path filter.
+// pathMatchesFilter checks if an attribute path is in the filter's cluster.
+// A filter must name a concrete cluster instance.
 func (h *ReadHandler) pathMatchesFilter(attrPath *message.AttributePathIB, filterPath *message.ClusterPathIB) bool {
-	if filterPath.Endpoint != nil && attrPath.Endpoint != nil {
-		if *filterPath.Endpoint != *attrPath.Endpoint {
-			return false
-		}
-	}
-	if filterPath.Cluster != nil && attrPath.Cluster != nil {
-		if *filterPath.Cluster != *attrPath.Cluster {
-			return false
-		}
+	if filterPath.Endpoint == nil || filterPath.Cluster == nil {
+		return false
 	}
-	return true
+	return *filterPath.Endpoint == *attrPath.Endpoint && *filterPath.Cluster == *attrPath.Cluster
 }
 
 // createAttributeStatusReport creates an error status report.
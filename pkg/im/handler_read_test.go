@@ -372,3 +372,171 @@ func TestEncodeDecodeReadRequest(t *testing.T) {
 		t.Error("original request mismatch")
 	}
 }
+
+func TestReadHandler_DataVersionFilter(t *testing.T) {
+	ep := message.EndpointID(1)
+	cl := message.ClusterID(0x0006)
+	otherCl := message.ClusterID(0x0008)
+	attr := message.AttributeID(0x0000)
+
+	lookup := func(endpoint message.EndpointID, cluster message.ClusterID) (message.DataVersion, bool) {
+		return 7, endpoint == ep && cluster == cl
+	}
+
+	tests := []struct {
+		name    string
+		lookup  DataVersionLookup
+		filter  message.DataVersionFilterIB
+		reports int
+	}{
+		{"matching version", lookup, message.DataVersionFilterIB{Path: message.ClusterPathIB{Endpoint: &ep, Cluster: &cl}, DataVersion: 7}, 0},
+		{"stale version", lookup, message.DataVersionFilterIB{Path: message.ClusterPathIB{Endpoint: &ep, Cluster: &cl}, DataVersion: 6}, 1},
+		{"other cluster", lookup, message.DataVersionFilterIB{Path: message.ClusterPathIB{Endpoint: &ep, Cluster: &otherCl}, DataVersion: 7}, 1},
+		{"no lookup", nil, message.DataVersionFilterIB{Path: message.ClusterPathIB{Endpoint: &ep, Cluster: &cl}, DataVersion: 7}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewReadHandler(func(ctx *ReadContext, path message.AttributePathIB) (*AttributeResult, error) {
+				return &AttributeResult{DataVersion: 7, Data: []byte{0x09}}, nil
+			}, DefaultMaxPayload)
+			handler.SetDataVersionLookup(tt.lookup)
+
+			req := &message.ReadRequestMessage{
+				AttributeRequests: []message.AttributePathIB{
+					{Endpoint: &ep, Cluster: &cl, Attribute: &attr},
+				},
+				DataVersionFilters: []message.DataVersionFilterIB{tt.filter},
+			}
+
+			resp, err := handler.HandleReadRequest(nil, req, 1, 12345)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(resp.AttributeReports) != tt.reports {
+				t.Errorf("got %d attribute reports, want %d", len(resp.AttributeReports), tt.reports)
+			}
+		})
+	}
+}
+
+func TestReadHandler_EventReader(t *testing.T) {
+	ep := message.EndpointID(1)
+	cl := message.ClusterID(0x0006)
+
+	var gotMin message.EventNumber
+	handler := NewReadHandler(nil, DefaultMaxPayload)
+	handler.SetEventReader(func(ctx *ReadContext, paths []message.EventPathIB, min message.EventNumber) []message.EventReportIB {
+		gotMin = min
+		return []message.EventReportIB{{EventData: &message.EventDataIB{
+			Path:        message.EventPathIB{Endpoint: &ep, Cluster: &cl},
+			EventNumber: min,
+		}}}
+	})
+
+	req := &message.ReadRequestMessage{
+		EventRequests: []message.EventPathIB{{Endpoint: &ep, Cluster: &cl}},
+		EventFilters:  []message.EventFilterIB{{EventMin: 5}, {EventMin: 9}},
+	}
+
+	resp, err := handler.HandleReadRequest(nil, req, 1, 12345)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotMin != 9 {
+		t.Errorf("event reader called with min %d, want 9", gotMin)
+	}
+	if len(resp.EventReports) != 1 {
+		t.Errorf("got %d event reports, want 1", len(resp.EventReports))
+	}
+}
+
+func TestReadHandler_PathExpansion(t *testing.T) {
+	ep := message.EndpointID(1)
+	cl := message.ClusterID(0xFFF1FC20)
+	attrs := []message.AttributeID{0x0000, 0xFFF10000, 0xFFF10001}
+
+	handler := NewReadHandler(func(ctx *ReadContext, path message.AttributePathIB) (*AttributeResult, error) {
+		if *path.Attribute == attrs[2] {
+			return &AttributeResult{Status: &message.StatusIB{Status: message.StatusUnsupportedAccess}}, nil
+		}
+		return &AttributeResult{DataVersion: 1, Data: []byte{0x09}}, nil
+	}, DefaultMaxPayload)
+	handler.SetPathExpansion(func(path message.AttributePathIB) []message.AttributePathIB {
+		var paths []message.AttributePathIB
+		for _, attr := range attrs {
+			paths = append(paths, message.AttributePathIB{Endpoint: &ep, Cluster: &cl, Attribute: &attr})
+		}
+		return paths
+	})
+
+	req := &message.ReadRequestMessage{
+		AttributeRequests: []message.AttributePathIB{{Endpoint: &ep, Cluster: &cl}},
+	}
+
+	resp, err := handler.HandleReadRequest(nil, req, 1, 12345)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The attribute the subject may not read is left out
+	if len(resp.AttributeReports) != 2 {
+		t.Fatalf("got %d attribute reports, want 2", len(resp.AttributeReports))
+	}
+	for i, report := range resp.AttributeReports {
+		if report.AttributeData == nil {
+			t.Fatalf("report %d is a status", i)
+		}
+		if got := *report.AttributeData.Path.Attribute; got != attrs[i] {
+			t.Errorf("report %d attribute = 0x%08X, want 0x%08X", i, got, attrs[i])
+		}
+	}
+
+	// A concrete path reports its status
+	req.AttributeRequests = []message.AttributePathIB{{Endpoint: &ep, Cluster: &cl, Attribute: &attrs[2]}}
+	resp, err = handler.HandleReadRequest(nil, req, 1, 12345)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.AttributeReports) != 1 || resp.AttributeReports[0].AttributeStatus == nil {
+		t.Errorf("concrete read = %+v, want one status report", resp.AttributeReports)
+	}
+}
+
+func TestReadHandler_DataVersionFilterWildcard(t *testing.T) {
+	ep := message.EndpointID(1)
+	clusters := []message.ClusterID{0x0006, 0x0008}
+	attr := message.AttributeID(0x0000)
+
+	handler := NewReadHandler(func(ctx *ReadContext, path message.AttributePathIB) (*AttributeResult, error) {
+		return &AttributeResult{DataVersion: 7, Data: []byte{0x09}}, nil
+	}, DefaultMaxPayload)
+	handler.SetDataVersionLookup(func(endpoint message.EndpointID, cluster message.ClusterID) (message.DataVersion, bool) {
+		return 7, endpoint == ep
+	})
+	handler.SetPathExpansion(func(path message.AttributePathIB) []message.AttributePathIB {
+		var paths []message.AttributePathIB
+		for _, cl := range clusters {
+			paths = append(paths, message.AttributePathIB{Endpoint: &ep, Cluster: &cl, Attribute: &attr})
+		}
+		return paths
+	})
+
+	req := &message.ReadRequestMessage{
+		AttributeRequests: []message.AttributePathIB{{Endpoint: &ep}},
+		DataVersionFilters: []message.DataVersionFilterIB{
+			{Path: message.ClusterPathIB{Endpoint: &ep, Cluster: &clusters[0]}, DataVersion: 7},
+		},
+	}
+
+	resp, err := handler.HandleReadRequest(nil, req, 1, 12345)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Only the cluster whose version the client holds is left out
+	if len(resp.AttributeReports) != 1 {
+		t.Fatalf("got %d attribute reports, want 1", len(resp.AttributeReports))
+	}
+	if got := *resp.AttributeReports[0].AttributeData.Path.Cluster; got != clusters[1] {
+		t.Errorf("reported cluster 0x%04X, want 0x%04X", got, clusters[1])
+	}
+}
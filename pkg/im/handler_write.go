@@ -197,7 +197,7 @@ func (h *WriteHandler) processAttributeWrite(attrData *message.AttributeDataIB)
 	err := h.dispatcher.WriteAttribute(context.Background(), writeReq, r)
 
 	if err != nil {
-		return h.createWriteStatusResponse(&path, ErrorToStatus(err))
+		return message.AttributeStatusIB{Path: path, Status: ErrorToStatusIB(err)}
 	}
 
 	return h.createWriteStatusResponse(&path, message.StatusSuccess)
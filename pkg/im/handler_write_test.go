@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/backkem/matter/pkg/im/imstatus"
 	"github.com/backkem/matter/pkg/im/message"
 	"github.com/backkem/matter/pkg/tlv"
 )
@@ -170,6 +171,35 @@ func TestWriteHandler_WriteError(t *testing.T) {
 	}
 }
 
+func TestWriteHandler_WriteClusterStatus(t *testing.T) {
+	dispatcher := &mockWriteDispatcher{
+		writeFunc: func(ctx context.Context, req *AttributeWriteRequest, r *tlv.Reader) error {
+			return imstatus.ClusterFailure(0x04)
+		},
+	}
+	handler := NewWriteHandler(dispatcher)
+
+	ep := message.EndpointID(1)
+	cl := message.ClusterID(0x0006)
+	attr := message.AttributeID(0x4003)
+
+	req := &message.WriteRequestMessage{
+		WriteRequests: []message.AttributeDataIB{
+			{Path: message.AttributePathIB{Endpoint: &ep, Cluster: &cl, Attribute: &attr}, Data: []byte{0x24, 0x01}},
+		},
+	}
+
+	resp, err := handler.HandleWriteRequest(nil, req, 1, 12345, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	status := resp.WriteResponses[0].Status
+	if status.Status != message.StatusFailure || status.ClusterStatus == nil || *status.ClusterStatus != 0x04 {
+		t.Errorf("status = %+v, want Failure with cluster status 0x04", status)
+	}
+}
+
 func TestWriteHandler_WildcardPath(t *testing.T) {
 	dispatcher := &mockWriteDispatcher{}
 	handler := NewWriteHandler(dispatcher)
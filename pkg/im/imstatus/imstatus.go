@@ -0,0 +1,186 @@
+// Package imstatus maps Go errors to Interaction Model status codes.
+//
+// Clusters return errors from their handlers; the IM engine turns each one
+// into the StatusIB of the attribute or command it answers. An *Error
+// carries an IM status code, and optionally a cluster-specific status code,
+// so a cluster can pick the exact status it responds with:
+//
+//	if level > maxLevel {
+//	    return nil, imstatus.ConstraintError
+//	}
+//	if !c.ready {
+//	    return nil, imstatus.ClusterFailure(StatusNotCalibrated)
+//	}
+//
+// The datamodel sentinel errors (datamodel.ErrConstraintError,
+// datamodel.ErrInvalidInState, ...) map to their status codes too, so
+// clusters may use either.
+package imstatus
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/im/message"
+)
+
+// Error is an error that answers an interaction with a status code.
+type Error struct {
+	// Status is the IM status code.
+	Status message.Status
+
+	// ClusterStatus is the cluster-specific status code, or nil.
+	ClusterStatus *uint8
+
+	// Err is the underlying error, if any. It is not sent to the peer.
+	Err error
+}
+
+// Status codes clusters commonly return.
+var (
+	Failure                = New(message.StatusFailure)
+	InvalidAction          = New(message.StatusInvalidAction)
+	UnsupportedAccess      = New(message.StatusUnsupportedAccess)
+	UnsupportedCommand     = New(message.StatusUnsupportedCommand)
+	InvalidCommand         = New(message.StatusInvalidCommand)
+	UnsupportedAttribute   = New(message.StatusUnsupportedAttribute)
+	ConstraintError        = New(message.StatusConstraintError)
+	UnsupportedWrite       = New(message.StatusUnsupportedWrite)
+	ResourceExhausted      = New(message.StatusResourceExhausted)
+	NotFound               = New(message.StatusNotFound)
+	InvalidDataType        = New(message.StatusInvalidDataType)
+	UnsupportedRead        = New(message.StatusUnsupportedRead)
+	Busy                   = New(message.StatusBusy)
+	NeedsTimedInteraction  = New(message.StatusNeedsTimedInteraction)
+	FailsafeRequired       = New(message.StatusFailsafeRequired)
+	InvalidInState         = New(message.StatusInvalidInState)
+	DynamicConstraintError = New(message.StatusDynamicConstraintError)
+	AlreadyExists          = New(message.StatusAlreadyExists)
+)
+
+// New returns an error with the given IM status code.
+func New(status message.Status) *Error {
+	return &Error{Status: status}
+}
+
+// Wrap returns an error with the given IM status code that wraps err,
+// keeping the cause for logs.
+func Wrap(status message.Status, err error) *Error {
+	return &Error{Status: status, Err: err}
+}
+
+// ClusterFailure returns a Failure carrying a cluster-specific status code,
+// for the failures a cluster specification defines codes for.
+func ClusterFailure(code uint8) *Error {
+	return &Error{Status: message.StatusFailure, ClusterStatus: &code}
+}
+
+// ClusterSuccess returns a Success carrying a cluster-specific status code.
+// The handler's error is then not a failure: the interaction succeeds and
+// the peer reads the code.
+func ClusterSuccess(code uint8) *Error {
+	return &Error{Status: message.StatusSuccess, ClusterStatus: &code}
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	s := e.Status.String()
+	if e.ClusterStatus != nil {
+		s = fmt.Sprintf("%s (cluster status 0x%02x)", s, *e.ClusterStatus)
+	}
+	if e.Err != nil {
+		s += ": " + e.Err.Error()
+	}
+	return s
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is an *Error with the same status and cluster
+// status, so errors.Is(err, imstatus.ConstraintError) matches any
+// constraint error, wrapped or not.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	if e.Status != t.Status {
+		return false
+	}
+	if e.ClusterStatus == nil || t.ClusterStatus == nil {
+		return e.ClusterStatus == t.ClusterStatus
+	}
+	return *e.ClusterStatus == *t.ClusterStatus
+}
+
+// StatusIB returns the StatusIB that encodes the error.
+func (e *Error) StatusIB() message.StatusIB {
+	ib := message.StatusIB{Status: e.Status}
+	if e.ClusterStatus != nil {
+		code := *e.ClusterStatus
+		ib.ClusterStatus = &code
+	}
+	return ib
+}
+
+// FromError returns the StatusIB that answers an interaction that failed
+// with err. A nil error is Success. An *Error anywhere in the chain gives
+// its own status; the datamodel sentinel errors give theirs, and any other
+// error is Failure.
+func FromError(err error) message.StatusIB {
+	var se *Error
+	if errors.As(err, &se) {
+		return se.StatusIB()
+	}
+	return message.StatusIB{Status: Status(err)}
+}
+
+// Status returns the IM status code of err, without a cluster status.
+func Status(err error) message.Status {
+	if err == nil {
+		return message.StatusSuccess
+	}
+	var se *Error
+	if errors.As(err, &se) {
+		return se.Status
+	}
+	for _, m := range datamodelStatus {
+		if errors.Is(err, m.err) {
+			return m.status
+		}
+	}
+	return message.StatusFailure
+}
+
+// datamodelStatus maps the datamodel sentinel errors to status codes.
+var datamodelStatus = []struct {
+	err    error
+	status message.Status
+}{
+	{datamodel.ErrEndpointNotFound, message.StatusUnsupportedEndpoint},
+	{datamodel.ErrClusterNotFound, message.StatusUnsupportedCluster},
+	{datamodel.ErrAttributeNotFound, message.StatusUnsupportedAttribute},
+	{datamodel.ErrUnsupportedAttribute, message.StatusUnsupportedAttribute},
+	{datamodel.ErrAttributeNotReadable, message.StatusUnsupportedRead},
+	{datamodel.ErrAttributeNotWritable, message.StatusUnsupportedWrite},
+	{datamodel.ErrUnsupportedWrite, message.StatusUnsupportedWrite},
+	{datamodel.ErrCommandNotFound, message.StatusUnsupportedCommand},
+	{datamodel.ErrUnsupportedCommand, message.StatusUnsupportedCommand},
+	{datamodel.ErrEventNotFound, message.StatusUnsupportedEvent},
+	{datamodel.ErrUnsupportedAccess, message.StatusUnsupportedAccess},
+	{datamodel.ErrAccessDenied, message.StatusUnsupportedAccess},
+	{datamodel.ErrInvalidDataVersion, message.StatusDataVersionMismatch},
+	{datamodel.ErrTimedRequired, message.StatusNeedsTimedInteraction},
+	{datamodel.ErrInvalidInState, message.StatusInvalidInState},
+	{datamodel.ErrResourceExhausted, message.StatusResourceExhausted},
+	{datamodel.ErrBusy, message.StatusBusy},
+	{datamodel.ErrFailsafeRequired, message.StatusFailsafeRequired},
+	{datamodel.ErrNotFound, message.StatusNotFound},
+	{datamodel.ErrAlreadyExists, message.StatusAlreadyExists},
+	{datamodel.ErrConstraintError, message.StatusConstraintError},
+	{datamodel.ErrInvalidCommand, message.StatusInvalidCommand},
+}
@@ -0,0 +1,91 @@
+package imstatus
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/im/message"
+)
+
+func TestFromError(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  message.Status
+		wantCluster int // -1 for none
+	}{
+		{"nil", nil, message.StatusSuccess, -1},
+		{"sentinel", ConstraintError, message.StatusConstraintError, -1},
+		{"wrapped sentinel", fmt.Errorf("level: %w", InvalidInState), message.StatusInvalidInState, -1},
+		{"wrap", Wrap(message.StatusBusy, errors.New("moving")), message.StatusBusy, -1},
+		{"cluster failure", ClusterFailure(3), message.StatusFailure, 3},
+		{"cluster success", fmt.Errorf("x: %w", ClusterSuccess(1)), message.StatusSuccess, 1},
+		{"datamodel constraint", datamodel.ErrConstraintError, message.StatusConstraintError, -1},
+		{"datamodel invalid command", fmt.Errorf("bad: %w", datamodel.ErrInvalidCommand), message.StatusInvalidCommand, -1},
+		{"datamodel unsupported write", datamodel.ErrUnsupportedWrite, message.StatusUnsupportedWrite, -1},
+		{"datamodel cluster not found", datamodel.ErrClusterNotFound, message.StatusUnsupportedCluster, -1},
+		{"unknown", errors.New("disk full"), message.StatusFailure, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ib := FromError(tt.err)
+			if ib.Status != tt.wantStatus {
+				t.Errorf("Status = %v, want %v", ib.Status, tt.wantStatus)
+			}
+			switch {
+			case tt.wantCluster < 0 && ib.ClusterStatus != nil:
+				t.Errorf("ClusterStatus = %d, want none", *ib.ClusterStatus)
+			case tt.wantCluster >= 0 && (ib.ClusterStatus == nil || int(*ib.ClusterStatus) != tt.wantCluster):
+				t.Errorf("ClusterStatus = %v, want %d", ib.ClusterStatus, tt.wantCluster)
+			}
+			if got := Status(tt.err); got != tt.wantStatus {
+				t.Errorf("Status() = %v, want %v", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestError_Is(t *testing.T) {
+	cause := errors.New("out of range")
+	err := fmt.Errorf("set level: %w", Wrap(message.StatusConstraintError, cause))
+
+	if !errors.Is(err, ConstraintError) {
+		t.Error("wrapped constraint error does not match ConstraintError")
+	}
+	if errors.Is(err, InvalidCommand) {
+		t.Error("constraint error matches InvalidCommand")
+	}
+	if !errors.Is(err, cause) {
+		t.Error("cause is not in the chain")
+	}
+	if !errors.Is(ClusterFailure(2), ClusterFailure(2)) {
+		t.Error("cluster failure does not match the same code")
+	}
+	if errors.Is(ClusterFailure(2), ClusterFailure(3)) || errors.Is(ClusterFailure(2), Failure) {
+		t.Error("cluster failure matches another code")
+	}
+}
+
+func TestError_Error(t *testing.T) {
+	if got := ConstraintError.Error(); got != "ConstraintError" {
+		t.Errorf("Error() = %q", got)
+	}
+	if got := ClusterFailure(2).Error(); got != "Failure (cluster status 0x02)" {
+		t.Errorf("Error() = %q", got)
+	}
+	if got := Wrap(message.StatusBusy, errors.New("moving")).Error(); got != "Busy: moving" {
+		t.Errorf("Error() = %q", got)
+	}
+}
+
+func TestError_StatusIBCopies(t *testing.T) {
+	e := ClusterFailure(5)
+	ib := e.StatusIB()
+	*ib.ClusterStatus = 9
+	if *e.ClusterStatus != 5 {
+		t.Error("StatusIB shares the cluster status with the error")
+	}
+}
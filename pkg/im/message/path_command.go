@@ -13,6 +13,10 @@ type CommandPathIB struct {
 	Endpoint EndpointID // Tag 0
 	Cluster  ClusterID  // Tag 1
 	Command  CommandID  // Tag 2
+
+	// WildcardEndpoint omits Endpoint, addressing every endpoint of the
+	// group a command is sent to. Only valid in group invokes.
+	WildcardEndpoint bool
 }
 
 // Context tags for CommandPathIB.
@@ -33,8 +37,10 @@ func (p *CommandPathIB) EncodeWithTag(w *tlv.Writer, tag tlv.Tag) error {
 		return err
 	}
 
-	if err := w.PutUint(tlv.ContextTag(cmdPathTagEndpoint), uint64(p.Endpoint)); err != nil {
-		return err
+	if !p.WildcardEndpoint {
+		if err := w.PutUint(tlv.ContextTag(cmdPathTagEndpoint), uint64(p.Endpoint)); err != nil {
+			return err
+		}
 	}
 
 	if err := w.PutUint(tlv.ContextTag(cmdPathTagCluster), uint64(p.Cluster)); err != nil {
@@ -126,9 +132,10 @@ func (p *CommandPathIB) DecodeFrom(r *tlv.Reader) error {
 		return err
 	}
 
-	if !hasEndpoint || !hasCluster || !hasCommand {
+	if !hasCluster || !hasCommand {
 		return ErrMissingField
 	}
+	p.WildcardEndpoint = !hasEndpoint
 
 	return nil
 }
@@ -141,6 +141,14 @@ func TestCommandPathIB_Roundtrip(t *testing.T) {
 				Command:  2,     // Toggle command
 			},
 		},
+		{
+			name: "group wildcard endpoint",
+			path: CommandPathIB{
+				Cluster:          0x0006,
+				Command:          2,
+				WildcardEndpoint: true,
+			},
+		},
 	}
 
 	for _, tt := range tests {
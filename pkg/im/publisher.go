@@ -3,6 +3,7 @@ package im
 import (
 	"bytes"
 	"fmt"
+	"reflect"
 
 	"github.com/backkem/matter/pkg/datamodel"
 	"github.com/backkem/matter/pkg/im/message"
@@ -10,7 +11,8 @@ import (
 )
 
 // TLVMarshaler is implemented by types that can marshal themselves to TLV.
-// Event payload structs should implement this interface.
+// Event payload structs that cannot be described with tlv struct tags
+// should implement this interface.
 type TLVMarshaler interface {
 	MarshalTLV(w *tlv.Writer) error
 }
@@ -18,9 +20,10 @@ type TLVMarshaler interface {
 // EventManagerPublisher adapts EventManager to implement datamodel.EventPublisher.
 // It handles TLV encoding of event payloads centrally.
 //
-// Payload types should implement TLVMarshaler. If the payload is nil,
-// an empty event data is used. If the payload is already []byte, it's
-// used directly (for backwards compatibility).
+// Payloads are structs with tlv struct tags, encoded with tlv.Marshal, or
+// types implementing TLVMarshaler. If the payload is nil, an empty event
+// data is used. If the payload is already []byte, it's used directly (for
+// backwards compatibility).
 type EventManagerPublisher struct {
 	em *EventManager
 }
@@ -96,7 +99,12 @@ func (p *EventManagerPublisher) encodePayload(data interface{}) ([]byte, error)
 		return buf.Bytes(), nil
 	}
 
-	return nil, fmt.Errorf("payload type %T does not implement TLVMarshaler", data)
+	// Structs with tlv struct tags are encoded by reflection
+	if v := reflect.Indirect(reflect.ValueOf(data)); v.Kind() == reflect.Struct {
+		return tlv.Marshal(data)
+	}
+
+	return nil, fmt.Errorf("payload type %T is not a struct and does not implement TLVMarshaler", data)
 }
 
 // Verify EventManagerPublisher implements datamodel.EventPublisher.
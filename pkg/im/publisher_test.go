@@ -1,6 +1,7 @@
 package im
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/backkem/matter/pkg/datamodel"
@@ -119,6 +120,30 @@ func TestEventManagerPublisher_PublishEvent_TLVMarshaler(t *testing.T) {
 	}
 }
 
+func TestEventManagerPublisher_PublishEvent_StructTags(t *testing.T) {
+	em := NewEventManager(EventManagerConfig{})
+	pub := NewEventManagerPublisher(em)
+
+	payload := struct {
+		StartUp uint32  `tlv:"0"`
+		Reason  *uint8  `tlv:"1,nullable"`
+		Label   *string `tlv:"2"`
+	}{StartUp: 7}
+
+	if _, err := pub.PublishEvent(0, 0x0028, 0x00, datamodel.EventPriorityCritical, payload, 0); err != nil {
+		t.Fatalf("PublishEvent() error = %v", err)
+	}
+
+	events := em.GetEvents(nil, nil, 0, nil)
+	if len(events) != 1 {
+		t.Fatalf("stored events = %d, want 1", len(events))
+	}
+	want := []byte{0x15, 0x24, 0x00, 0x07, 0x34, 0x01, 0x18}
+	if !bytes.Equal(events[0].Data, want) {
+		t.Errorf("Data = % X, want % X", events[0].Data, want)
+	}
+}
+
 func TestEventManagerPublisher_PublishEvent_FabricScoped(t *testing.T) {
 	em := NewEventManager(EventManagerConfig{})
 	pub := NewEventManagerPublisher(em)
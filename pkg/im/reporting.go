@@ -0,0 +1,279 @@
+package im
+
+import (
+	"time"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	imsg "github.com/backkem/matter/pkg/im/message"
+)
+
+// MarkDirty records a change of an attribute's value, to be reported to
+// the subscriptions whose paths include it.
+//
+// Changes are coalesced: a subscription sends at most one report per
+// MinInterval, with all the paths that changed since its last report, and
+// holds them while a report is awaiting the subscriber's StatusResponse.
+//
+// MarkDirty does not block on the engine, so clusters may call it while
+// the engine dispatches a Write or Invoke to them.
+//
+// Spec: Section 8.5.2 (reporting)
+// C++ Reference: reporting::Engine::SetDirty
+func (e *Engine) MarkDirty(path datamodel.ConcreteAttributePath) {
+	e.changeMu.Lock()
+	if _, ok := e.changed[path]; !ok {
+		e.changed[path] = struct{}{}
+		e.changes = append(e.changes, path)
+	}
+	e.startFlush()
+	e.changeMu.Unlock()
+}
+
+// OnAttributeChanged implements datamodel.AttributeChangeListener.
+func (e *Engine) OnAttributeChanged(path datamodel.ConcreteAttributePath) {
+	e.MarkDirty(path)
+}
+
+// OnEvent implements EventListener. The event is delivered with the next
+// report of each subscription whose event paths include it; an event on
+// an urgent path triggers that report immediately, regardless of
+// MinInterval. Events are delivered in EventNumber order.
+//
+// Spec: Section 8.5.2 (urgent events)
+// C++ Reference: reporting::Engine::ScheduleUrgentEventDeliverySync
+func (e *Engine) OnEvent(record *EventRecord) {
+	e.changeMu.Lock()
+	e.newEvents = append(e.newEvents, record)
+	e.startFlush()
+	e.changeMu.Unlock()
+}
+
+// startFlush starts handing the queued changes to the subscriptions,
+// unless already started.
+// Must be called with e.changeMu held.
+func (e *Engine) startFlush() {
+	if e.flushing {
+		return
+	}
+	e.flushing = true
+	go e.flushChanges()
+}
+
+// flushChanges hands the changed paths and new events to the
+// subscriptions.
+func (e *Engine) flushChanges() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.changeMu.Lock()
+	changes, events := e.changes, e.newEvents
+	e.changes, e.newEvents = nil, nil
+	e.changed = make(map[datamodel.ConcreteAttributePath]struct{})
+	e.flushing = false
+	e.changeMu.Unlock()
+
+	for _, sub := range e.subscriptions {
+		for _, path := range changes {
+			if sub.includes(path) {
+				sub.markDirty(path)
+			}
+		}
+		for _, record := range events {
+			if included, urgent := sub.includesEvent(record); included {
+				sub.eventsPending = true
+				sub.urgent = sub.urgent || urgent
+			}
+		}
+		e.scheduleReport(sub)
+	}
+}
+
+// includes reports whether a subscribed path includes the attribute.
+// Missing path fields are wildcards.
+func (s *subscription) includes(path datamodel.ConcreteAttributePath) bool {
+	for _, p := range s.record.AttributePaths {
+		if (p.Endpoint == nil || datamodel.EndpointID(*p.Endpoint) == path.Endpoint) &&
+			(p.Cluster == nil || datamodel.ClusterID(*p.Cluster) == path.Cluster) &&
+			(p.Attribute == nil || datamodel.AttributeID(*p.Attribute) == path.Attribute) {
+			return true
+		}
+	}
+	return false
+}
+
+// includesEvent reports whether the subscription's event paths include an
+// event, and whether one that does is urgent. Missing path fields are
+// wildcards; fabric-sensitive events are only for their fabric.
+func (s *subscription) includesEvent(record *EventRecord) (included, urgent bool) {
+	if record.FabricIndex != 0 && record.FabricIndex != uint8(s.record.FabricIndex) {
+		return false, false
+	}
+	for _, p := range s.record.EventPaths {
+		if eventPathIncludes(p, record.Path) {
+			included = true
+			urgent = urgent || (p.IsUrgent != nil && *p.IsUrgent)
+		}
+	}
+	return included, urgent
+}
+
+// eventPathIncludes reports whether a requested event path includes an
+// event. Missing path fields are wildcards.
+func eventPathIncludes(p imsg.EventPathIB, path EventPath) bool {
+	return (p.Endpoint == nil || *p.Endpoint == path.EndpointID) &&
+		(p.Cluster == nil || *p.Cluster == path.ClusterID) &&
+		(p.Event == nil || *p.Event == path.EventID)
+}
+
+// markDirty adds a path to the next report.
+func (s *subscription) markDirty(path datamodel.ConcreteAttributePath) {
+	if s.dirtySet == nil {
+		s.dirtySet = make(map[datamodel.ConcreteAttributePath]struct{})
+	}
+	if _, ok := s.dirtySet[path]; ok {
+		return
+	}
+	s.dirtySet[path] = struct{}{}
+	s.dirty = append(s.dirty, path)
+}
+
+// takeDirty returns the paths to report and clears them.
+func (s *subscription) takeDirty() []imsg.AttributePathIB {
+	paths := make([]imsg.AttributePathIB, 0, len(s.dirty))
+	for _, path := range s.dirty {
+		endpoint := imsg.EndpointID(path.Endpoint)
+		cluster := imsg.ClusterID(path.Cluster)
+		attribute := imsg.AttributeID(path.Attribute)
+		paths = append(paths, imsg.AttributePathIB{
+			Endpoint:  &endpoint,
+			Cluster:   &cluster,
+			Attribute: &attribute,
+		})
+	}
+	s.dirty = nil
+	s.dirtySet = nil
+	return paths
+}
+
+// scheduleReport arms the timer for the subscription's next report, once
+// MinInterval has passed since the last one, or at once for urgent events.
+// Other events wait for a report of changed attributes or for MaxInterval.
+// Nothing is scheduled while a report is in flight: its completion
+// schedules the next.
+// Must be called with e.mu held.
+func (e *Engine) scheduleReport(sub *subscription) {
+	if !sub.active || sub.inFlight || (len(sub.dirty) == 0 && !sub.urgent) {
+		return
+	}
+	if e.exchangeManager == nil || sub.session == nil {
+		return
+	}
+
+	wait := sub.lastReport.Add(time.Duration(sub.record.MinInterval) * time.Second).Sub(e.clock.Now())
+	if wait < 0 || sub.urgent {
+		wait = 0
+	}
+	if sub.pending != nil {
+		if !sub.urgent {
+			return
+		}
+		sub.pending.Stop()
+	}
+
+	id := sub.record.SubscriptionID
+	sub.pending = e.clock.AfterFunc(wait, func() { e.sendReport(id) })
+}
+
+// sendReport sends a report of the subscription's dirty paths and new
+// events on a new exchange and rearms the liveness timer. Without either,
+// the report is empty and suppresses the StatusResponse.
+//
+// Spec: Section 8.5.3 (MinInterval, MaxInterval)
+func (e *Engine) sendReport(id imsg.SubscriptionID) {
+	e.mu.Lock()
+	sub, ok := e.subscriptions[id]
+	if !ok || !sub.active {
+		e.mu.Unlock()
+		return
+	}
+	sub.stopTimers()
+
+	// The report in flight proves the subscription alive; the next is
+	// scheduled once it completes.
+	if sub.inFlight {
+		e.mu.Unlock()
+		return
+	}
+
+	exch, err := e.exchangeManager.NewExchange(sub.session, sub.session.LocalSessionID(), sub.peerAddr, ProtocolID, e)
+	if err != nil {
+		e.mu.Unlock()
+		if e.log != nil {
+			e.log.Warnf("subscription %d: report: %v", id, err)
+		}
+		return
+	}
+
+	var report []byte
+	req := &imsg.ReadRequestMessage{AttributeRequests: sub.takeDirty()}
+	if sub.eventsPending {
+		req.EventRequests = sub.record.EventPaths
+	}
+	sub.eventsPending, sub.urgent = false, false
+
+	empty := len(req.AttributeRequests) == 0 && len(req.EventRequests) == 0
+	if empty {
+		report, err = EncodeReportData(&imsg.ReportDataMessage{
+			SubscriptionID:   &id,
+			SuppressResponse: true,
+		})
+	} else {
+		report, err = e.buildReport(exch, sub, req)
+		if err == nil {
+			sub.inFlight = true
+			e.reports[exch] = sub
+		}
+	}
+	sub.lastReport = e.clock.Now()
+	e.scheduleLiveness(sub)
+	e.mu.Unlock()
+
+	if err == nil {
+		err = exch.SendMessage(uint8(imsg.OpcodeReportData), report, true)
+	}
+	if err != nil && e.log != nil {
+		e.log.Warnf("subscription %d: report: %v", id, err)
+	}
+	if empty || err != nil {
+		// Closing a report in flight ends the subscription
+		exch.Close()
+	}
+}
+
+// finishReport schedules the subscription's next report once the report
+// in flight is complete.
+func (e *Engine) finishReport(sub *subscription) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.subscriptions[sub.record.SubscriptionID] != sub {
+		return
+	}
+	sub.inFlight = false
+	if sub.liveness == nil {
+		e.scheduleLiveness(sub)
+	}
+	e.scheduleReport(sub)
+}
+
+// stopTimers stops the subscription's pending report and liveness timers.
+func (s *subscription) stopTimers() {
+	if s.pending != nil {
+		s.pending.Stop()
+		s.pending = nil
+	}
+	if s.liveness != nil {
+		s.liveness.Stop()
+		s.liveness = nil
+	}
+}
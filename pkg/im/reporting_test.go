@@ -0,0 +1,265 @@
+package im
+
+import (
+	"testing"
+	"time"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	imsg "github.com/backkem/matter/pkg/im/message"
+)
+
+// establish subscribes from side 0 of the pair and waits for the
+// subscription to be confirmed.
+func establish(t *testing.T, pair *SecureTestIMPair, subscriber *testSubscriber, req *imsg.SubscribeRequestMessage) imsg.SubscriptionID {
+	t.Helper()
+	subscriber.subscribe(t, pair, req)
+	subscriber.waitReport(t, 5*time.Second)
+	return subscriber.waitResponse(t).SubscriptionID
+}
+
+func onOffAttr(attribute datamodel.AttributeID) datamodel.ConcreteAttributePath {
+	return datamodel.ConcreteAttributePath{Endpoint: 1, Cluster: 0x0006, Attribute: attribute}
+}
+
+func TestReporting_Change(t *testing.T) {
+	pair, subscriber := newSubscriptionPair(t, nil)
+	defer pair.Close()
+
+	id := establish(t, pair, subscriber, onOffSubscribeRequest(60))
+
+	// Only the subscribed attribute is reported
+	pair.Engine(1).MarkDirty(onOffAttr(0x4001))
+	pair.Engine(1).MarkDirty(onOffAttr(0x0000))
+
+	report := subscriber.waitReport(t, 5*time.Second)
+	if report.SubscriptionID == nil || *report.SubscriptionID != id {
+		t.Fatalf("report SubscriptionID = %v, want %d", report.SubscriptionID, id)
+	}
+	if report.SuppressResponse {
+		t.Error("change report should expect a StatusResponse")
+	}
+	if len(report.AttributeReports) != 1 || report.AttributeReports[0].AttributeData == nil {
+		t.Fatalf("report = %+v, want one attribute", report.AttributeReports)
+	}
+	if attr := *report.AttributeReports[0].AttributeData.Path.Attribute; attr != 0x0000 {
+		t.Errorf("reported attribute = 0x%04x, want 0x0000", attr)
+	}
+}
+
+func TestReporting_Coalesce(t *testing.T) {
+	pair, subscriber := newSubscriptionPair(t, nil)
+	defer pair.Close()
+
+	// Subscribe to the whole cluster with a one second floor
+	endpoint, cluster := imsg.EndpointID(1), imsg.ClusterID(0x0006)
+	establish(t, pair, subscriber, &imsg.SubscribeRequestMessage{
+		MinIntervalFloor:   1,
+		MaxIntervalCeiling: 60,
+		AttributeRequests:  []imsg.AttributePathIB{{Endpoint: &endpoint, Cluster: &cluster}},
+	})
+	start := time.Now()
+
+	pair.Engine(1).MarkDirty(onOffAttr(0x0000))
+	pair.Engine(1).MarkDirty(onOffAttr(0x4001))
+	pair.Engine(1).MarkDirty(onOffAttr(0x0000))
+
+	report := subscriber.waitReport(t, 5*time.Second)
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Errorf("report sent after %v, before MinInterval", elapsed)
+	}
+	if len(report.AttributeReports) != 2 {
+		t.Fatalf("report has %d attributes, want 2", len(report.AttributeReports))
+	}
+	for i, want := range []imsg.AttributeID{0x0000, 0x4001} {
+		if got := *report.AttributeReports[i].AttributeData.Path.Attribute; got != want {
+			t.Errorf("attribute %d = 0x%04x, want 0x%04x", i, got, want)
+		}
+	}
+
+	select {
+	case report := <-subscriber.reports:
+		t.Errorf("unexpected second report: %+v", report)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestReporting_Backpressure(t *testing.T) {
+	pair, subscriber := newSubscriptionPair(t, nil)
+	defer pair.Close()
+
+	id := establish(t, pair, subscriber, onOffSubscribeRequest(60))
+	engine := pair.Engine(1)
+
+	// Hold the subscription as if a report were awaiting its response
+	engine.mu.Lock()
+	sub := engine.subscriptions[id]
+	sub.inFlight = true
+	engine.mu.Unlock()
+
+	engine.MarkDirty(onOffAttr(0x0000))
+
+	select {
+	case report := <-subscriber.reports:
+		t.Fatalf("report sent while one is in flight: %+v", report)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	engine.mu.Lock()
+	dirty := len(sub.dirty)
+	engine.mu.Unlock()
+	if dirty != 1 {
+		t.Fatalf("dirty paths = %d, want 1", dirty)
+	}
+
+	// Completing the report in flight sends the held change
+	engine.finishReport(sub)
+	report := subscriber.waitReport(t, 5*time.Second)
+	if len(report.AttributeReports) != 1 {
+		t.Errorf("report has %d attributes, want 1", len(report.AttributeReports))
+	}
+}
+
+func TestReporting_Rejected(t *testing.T) {
+	pair, subscriber := newSubscriptionPair(t, nil)
+	defer pair.Close()
+
+	establish(t, pair, subscriber, onOffSubscribeRequest(60))
+
+	// The subscriber no longer knows the subscription
+	subscriber.reportStatus = imsg.StatusInvalidSubscription
+	pair.Engine(1).MarkDirty(onOffAttr(0x0000))
+	subscriber.waitReport(t, 5*time.Second)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(pair.Engine(1).Subscriptions()) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("rejected subscription was not ended")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSubscription_Includes(t *testing.T) {
+	endpoint, cluster, attribute := imsg.EndpointID(1), imsg.ClusterID(0x0006), imsg.AttributeID(0)
+
+	tests := []struct {
+		name string
+		path imsg.AttributePathIB
+		want bool
+	}{
+		{"concrete", imsg.AttributePathIB{Endpoint: &endpoint, Cluster: &cluster, Attribute: &attribute}, true},
+		{"cluster wildcard", imsg.AttributePathIB{Endpoint: &endpoint, Cluster: &cluster}, true},
+		{"endpoint wildcard", imsg.AttributePathIB{Cluster: &cluster, Attribute: &attribute}, true},
+		{"other attribute", makeAttrPath(1, 0x0006, 0x4001), false},
+		{"other endpoint", makeAttrPath(2, 0x0006, 0x0000), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := &subscription{record: SubscriptionRecord{AttributePaths: []imsg.AttributePathIB{tt.path}}}
+			if got := sub.includes(onOffAttr(0x0000)); got != tt.want {
+				t.Errorf("includes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// eventSubscribeRequest subscribes to OnOff attribute 0 and to the events
+// of the OnOff cluster.
+func eventSubscribeRequest(minInterval uint16, urgent bool) *imsg.SubscribeRequestMessage {
+	endpoint, cluster := imsg.EndpointID(1), imsg.ClusterID(0x0006)
+	req := onOffSubscribeRequest(60)
+	req.MinIntervalFloor = minInterval
+	req.EventRequests = []imsg.EventPathIB{{Endpoint: &endpoint, Cluster: &cluster, IsUrgent: &urgent}}
+	return req
+}
+
+func TestReporting_UrgentEvent(t *testing.T) {
+	events := NewEventManager(EventManagerConfig{})
+	pair, subscriber := newEventSubscriptionPair(t, nil, events)
+	defer pair.Close()
+
+	establish(t, pair, subscriber, eventSubscribeRequest(30, true))
+
+	// Reported at once, despite the MinInterval
+	number := events.PublishEvent(1, 0x0006, 0x00, EventPriorityInfo, nil)
+	events.PublishEvent(2, 0x0006, 0x00, EventPriorityInfo, nil)
+
+	report := subscriber.waitReport(t, 5*time.Second)
+	if len(report.EventReports) != 1 || report.EventReports[0].EventData == nil {
+		t.Fatalf("report = %+v, want one event", report.EventReports)
+	}
+	if got := report.EventReports[0].EventData.EventNumber; got != number {
+		t.Errorf("reported event %d, want %d", got, number)
+	}
+}
+
+func TestReporting_EventBookmark(t *testing.T) {
+	events := NewEventManager(EventManagerConfig{})
+	first := events.PublishEvent(1, 0x0006, 0x00, EventPriorityInfo, nil)
+
+	pair, subscriber := newEventSubscriptionPair(t, nil, events)
+	defer pair.Close()
+
+	// The priming report carries the buffered event
+	subscriber.subscribe(t, pair, eventSubscribeRequest(0, false))
+	priming := subscriber.waitReport(t, 5*time.Second)
+	subscriber.waitResponse(t)
+	if len(priming.EventReports) != 1 || priming.EventReports[0].EventData.EventNumber != first {
+		t.Fatalf("priming events = %+v, want event %d", priming.EventReports, first)
+	}
+
+	// A non-urgent event waits for the next report
+	second := events.PublishEvent(1, 0x0006, 0x01, EventPriorityInfo, nil)
+	select {
+	case report := <-subscriber.reports:
+		t.Fatalf("non-urgent event reported alone: %+v", report)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// It goes out with the next change, without the event already reported
+	pair.Engine(1).MarkDirty(onOffAttr(0x0000))
+	report := subscriber.waitReport(t, 5*time.Second)
+	if len(report.AttributeReports) != 1 {
+		t.Errorf("report has %d attributes, want 1", len(report.AttributeReports))
+	}
+	if len(report.EventReports) != 1 || report.EventReports[0].EventData.EventNumber != second {
+		t.Errorf("report events = %+v, want event %d", report.EventReports, second)
+	}
+}
+
+func TestSubscription_IncludesEvent(t *testing.T) {
+	endpoint, cluster, event := imsg.EndpointID(1), imsg.ClusterID(0x0006), imsg.EventID(0)
+	urgent := true
+
+	tests := []struct {
+		name       string
+		path       imsg.EventPathIB
+		fabric     uint8
+		included   bool
+		wantUrgent bool
+	}{
+		{"concrete", imsg.EventPathIB{Endpoint: &endpoint, Cluster: &cluster, Event: &event}, 0, true, false},
+		{"urgent", imsg.EventPathIB{Cluster: &cluster, IsUrgent: &urgent}, 0, true, true},
+		{"own fabric", imsg.EventPathIB{Endpoint: &endpoint}, 1, true, false},
+		{"other fabric", imsg.EventPathIB{Endpoint: &endpoint}, 2, false, false},
+		{"other cluster", imsg.EventPathIB{Cluster: new(imsg.ClusterID)}, 0, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := &subscription{record: SubscriptionRecord{
+				FabricIndex: 1,
+				EventPaths:  []imsg.EventPathIB{tt.path},
+			}}
+			record := &EventRecord{
+				Path:        EventPath{EndpointID: 1, ClusterID: 0x0006, EventID: 0},
+				FabricIndex: tt.fabric,
+			}
+			included, urgent := sub.includesEvent(record)
+			if included != tt.included || urgent != tt.wantUrgent {
+				t.Errorf("includesEvent() = %v, %v, want %v, %v", included, urgent, tt.included, tt.wantUrgent)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package im
+
+import (
+	"github.com/backkem/matter/pkg/exchange"
+	"github.com/backkem/matter/pkg/fabric"
+	imsg "github.com/backkem/matter/pkg/im/message"
+)
+
+// DefaultReadsPerFabric is the default number of concurrent read
+// transactions each fabric may hold. The spec requires a node to serve at
+// least one per fabric.
+const DefaultReadsPerFabric = 2
+
+// ResourceLimits bounds the concurrent read transactions served by an
+// Engine.
+//
+// A read transaction is held from its ReadRequest until the final
+// ReportData chunk is sent or the exchange closes. A subscription holds one
+// while it primes: from its SubscribeRequest until the subscriber
+// acknowledges the priming report, or the exchange closes. A fabric over its own
+// limit is refused with ResourceExhausted, so one fabric cannot starve the
+// others. A fabric within its limit that finds the node-wide pool full is
+// refused with Busy and may retry.
+//
+// C++ Reference: InteractionModelEngine::EnsureResourceForRead,
+// InteractionModelEngine::EnsureResourceForSubscription
+type ResourceLimits struct {
+	// ReadsPerFabric is the number of concurrent read transactions each
+	// fabric may hold. Defaults to DefaultReadsPerFabric if 0.
+	ReadsPerFabric int
+
+	// MaxReads bounds the read transactions across all fabrics.
+	// If 0, only the per-fabric limit applies.
+	MaxReads int
+
+	// SubscriptionsPerFabric is the number of subscriptions each fabric
+	// may hold; further SubscribeRequests are refused with
+	// ResourceExhausted. Defaults to DefaultSubscriptionsPerFabric if 0.
+	SubscriptionsPerFabric int
+}
+
+// readSlot is a read transaction held by a fabric.
+type readSlot struct {
+	fabric fabric.FabricIndex
+}
+
+// resourceTracker counts the read transactions held per fabric.
+// It is not safe for concurrent use; the Engine serializes access.
+type resourceTracker struct {
+	limits   ResourceLimits
+	held     map[*exchange.ExchangeContext]readSlot
+	byFabric map[fabric.FabricIndex]int
+}
+
+// newResourceTracker creates a tracker enforcing limits.
+func newResourceTracker(limits ResourceLimits) *resourceTracker {
+	if limits.ReadsPerFabric == 0 {
+		limits.ReadsPerFabric = DefaultReadsPerFabric
+	}
+	return &resourceTracker{
+		limits:   limits,
+		held:     make(map[*exchange.ExchangeContext]readSlot),
+		byFabric: make(map[fabric.FabricIndex]int),
+	}
+}
+
+// acquire reserves a read transaction for fabricIndex on the exchange.
+// It returns StatusSuccess, or the status to refuse the request with.
+// A new read on an exchange replaces the one it held.
+func (t *resourceTracker) acquire(ctx *exchange.ExchangeContext, fabricIndex fabric.FabricIndex) imsg.Status {
+	t.release(ctx)
+
+	if t.byFabric[fabricIndex] >= t.limits.ReadsPerFabric {
+		return imsg.StatusResourceExhausted
+	}
+	if t.limits.MaxReads > 0 && len(t.held) >= t.limits.MaxReads {
+		return imsg.StatusBusy
+	}
+
+	t.held[ctx] = readSlot{fabric: fabricIndex}
+	t.byFabric[fabricIndex]++
+	return imsg.StatusSuccess
+}
+
+// release frees the read transaction held on the exchange, if any.
+func (t *resourceTracker) release(ctx *exchange.ExchangeContext) {
+	slot, ok := t.held[ctx]
+	if !ok {
+		return
+	}
+	delete(t.held, ctx)
+	if t.byFabric[slot.fabric]--; t.byFabric[slot.fabric] == 0 {
+		delete(t.byFabric, slot.fabric)
+	}
+}
+
+// active returns the number of read transactions held.
+func (t *resourceTracker) active() int {
+	return len(t.held)
+}
+
+// accessingFabric returns the fabric index of the exchange's session.
+// PASE sessions and unsecured sessions have no fabric and report 0.
+func accessingFabric(ctx *exchange.ExchangeContext) fabric.FabricIndex {
+	if ctx == nil {
+		return 0
+	}
+	if sess, ok := ctx.Session().(interface{ FabricIndex() fabric.FabricIndex }); ok {
+		return sess.FabricIndex()
+	}
+	return 0
+}
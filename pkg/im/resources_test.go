@@ -0,0 +1,226 @@
+package im
+
+import (
+	"context"
+	"testing"
+
+	"github.com/backkem/matter/pkg/exchange"
+	"github.com/backkem/matter/pkg/fabric"
+	imsg "github.com/backkem/matter/pkg/im/message"
+	"github.com/backkem/matter/pkg/message"
+	"github.com/backkem/matter/pkg/metrics"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+func TestResourceTracker(t *testing.T) {
+	tracker := newResourceTracker(ResourceLimits{ReadsPerFabric: 1, MaxReads: 2})
+	a, b, c := &exchange.ExchangeContext{}, &exchange.ExchangeContext{}, &exchange.ExchangeContext{}
+
+	if got := tracker.acquire(a, 1); got != imsg.StatusSuccess {
+		t.Fatalf("acquire(a) = %v, want Success", got)
+	}
+	// A new read on the same exchange replaces the old one
+	if got := tracker.acquire(a, 1); got != imsg.StatusSuccess {
+		t.Fatalf("re-acquire(a) = %v, want Success", got)
+	}
+	if got := tracker.acquire(b, 1); got != imsg.StatusResourceExhausted {
+		t.Errorf("acquire(b) = %v, want ResourceExhausted", got)
+	}
+	if got := tracker.acquire(b, 2); got != imsg.StatusSuccess {
+		t.Errorf("acquire(b) on fabric 2 = %v, want Success", got)
+	}
+	if got := tracker.acquire(c, 3); got != imsg.StatusBusy {
+		t.Errorf("acquire(c) on full node = %v, want Busy", got)
+	}
+
+	tracker.release(a)
+	tracker.release(a)
+	if got := tracker.active(); got != 1 {
+		t.Errorf("active = %d, want 1", got)
+	}
+	if got := tracker.acquire(c, 1); got != imsg.StatusSuccess {
+		t.Errorf("acquire(c) after release = %v, want Success", got)
+	}
+}
+
+// fabricSession is a test session bound to a fabric.
+type fabricSession struct {
+	*exchange.TestUnsecuredSession
+	index fabric.FabricIndex
+}
+
+func (s fabricSession) FabricIndex() fabric.FabricIndex {
+	return s.index
+}
+
+// TestEngine_ReadLimits_Fairness holds chunked reads open on two fabrics
+// and checks that one fabric cannot take the other's share.
+func TestEngine_ReadLimits_Fairness(t *testing.T) {
+	// TCP avoids MRP, so each exchange can keep sending chunks.
+	pair, err := exchange.NewTestManagerPair(exchange.TestManagerPairConfig{TCP: true})
+	if err != nil {
+		t.Fatalf("NewTestManagerPair: %v", err)
+	}
+	defer pair.Close()
+	pair.Manager(0).RegisterProtocol(ProtocolID, &exchange.TestProtocolHandler{})
+
+	rec := metrics.NewRecorder()
+	engine := NewEngine(EngineConfig{
+		Dispatcher: &testDispatcher{
+			readFunc: func(ctx context.Context, req *AttributeReadRequest, w *tlv.Writer) error {
+				return w.PutBytes(tlv.Anonymous(), make([]byte, 50))
+			},
+		},
+		MaxPayload: 80, // Chunk the report so reads stay in progress
+		Limits:     ResourceLimits{ReadsPerFabric: 2, MaxReads: 3},
+		Metrics:    rec,
+	})
+
+	ep := imsg.EndpointID(1)
+	cl := imsg.ClusterID(0x001D)
+	req := &imsg.ReadRequestMessage{AttributeRequests: make([]imsg.AttributePathIB, 5)}
+	for i := range req.AttributeRequests {
+		attr := imsg.AttributeID(i)
+		req.AttributeRequests[i] = imsg.AttributePathIB{Endpoint: &ep, Cluster: &cl, Attribute: &attr}
+	}
+	payload, err := EncodeReadRequest(req)
+	if err != nil {
+		t.Fatalf("EncodeReadRequest: %v", err)
+	}
+
+	read := func(index fabric.FabricIndex) *exchange.ExchangeContext {
+		t.Helper()
+		sess := fabricSession{exchange.NewTestUnsecuredSession(0x2000), index}
+		ctx, err := pair.Manager(1).NewExchange(sess, 0, pair.PeerAddress(0, true), ProtocolID, nil)
+		if err != nil {
+			t.Fatalf("NewExchange: %v", err)
+		}
+		header := &message.ProtocolHeader{ProtocolOpcode: uint8(imsg.OpcodeReadRequest)}
+		if _, err := engine.OnMessage(ctx, header, payload); err != nil {
+			t.Fatalf("read on fabric %d: %v", index, err)
+		}
+		return ctx
+	}
+	refused := func(status string) float64 {
+		return rec.Value(metrics.IMRefused,
+			metrics.L(metrics.LabelAction, "read"), metrics.L(metrics.LabelStatus, status))
+	}
+
+	// Fabric 1 takes its two reads; a third is over its share.
+	first := read(1)
+	read(1)
+	read(1)
+	if got := refused("resource_exhausted"); got != 1 {
+		t.Errorf("resource_exhausted = %v, want 1", got)
+	}
+
+	// Fabric 2 is still served.
+	read(2)
+	if got := len(engine.readHandlers); got != 3 {
+		t.Errorf("reads in progress = %d, want 3", got)
+	}
+
+	// The node-wide pool is full: fabric 2 is within its share, so Busy.
+	read(2)
+	if got := refused("busy"); got != 1 {
+		t.Errorf("busy = %v, want 1", got)
+	}
+
+	// Closing one of fabric 1's reads lets fabric 2 take its second.
+	engine.OnClose(first)
+	read(2)
+	if got := engine.resources.byFabric[2]; got != 2 {
+		t.Errorf("fabric 2 reads = %d, want 2", got)
+	}
+	if got := rec.Value(metrics.IMActiveReads); got != 3 {
+		t.Errorf("active reads gauge = %v, want 3", got)
+	}
+}
+
+// TestEngine_SubscribeLimits holds priming reports unacknowledged and
+// checks that subscriptions count against the read transactions.
+func TestEngine_SubscribeLimits(t *testing.T) {
+	pair, err := exchange.NewTestManagerPair(exchange.TestManagerPairConfig{TCP: true})
+	if err != nil {
+		t.Fatalf("NewTestManagerPair: %v", err)
+	}
+	defer pair.Close()
+	pair.Manager(0).RegisterProtocol(ProtocolID, &exchange.TestProtocolHandler{})
+
+	rec := metrics.NewRecorder()
+	engine := NewEngine(EngineConfig{
+		Dispatcher: &testDispatcher{
+			readFunc: func(ctx context.Context, req *AttributeReadRequest, w *tlv.Writer) error {
+				return w.PutBool(tlv.Anonymous(), true)
+			},
+		},
+		Limits:  ResourceLimits{ReadsPerFabric: 1, MaxReads: 2},
+		Metrics: rec,
+	})
+	defer engine.Close()
+
+	ep := imsg.EndpointID(1)
+	cl := imsg.ClusterID(0x0006)
+	attr := imsg.AttributeID(0)
+	req := imsg.SubscribeRequestMessage{
+		KeepSubscriptions:  true,
+		MaxIntervalCeiling: 60,
+		AttributeRequests:  []imsg.AttributePathIB{{Endpoint: &ep, Cluster: &cl, Attribute: &attr}},
+	}
+	payload, err := EncodeMessage(req.Encode)
+	if err != nil {
+		t.Fatalf("encode SubscribeRequest: %v", err)
+	}
+
+	subscribe := func(index fabric.FabricIndex) *exchange.ExchangeContext {
+		t.Helper()
+		sess := fabricSession{exchange.NewTestUnsecuredSession(0x2000), index}
+		ctx, err := pair.Manager(1).NewExchange(sess, 0, pair.PeerAddress(0, true), ProtocolID, nil)
+		if err != nil {
+			t.Fatalf("NewExchange: %v", err)
+		}
+		header := &message.ProtocolHeader{ProtocolOpcode: uint8(imsg.OpcodeSubscribeRequest)}
+		if _, err := engine.OnMessage(ctx, header, payload); err != nil {
+			t.Fatalf("subscribe on fabric %d: %v", index, err)
+		}
+		return ctx
+	}
+	refused := func(status string) float64 {
+		return rec.Value(metrics.IMRefused,
+			metrics.L(metrics.LabelAction, "subscribe"), metrics.L(metrics.LabelStatus, status))
+	}
+
+	// Fabric 1's priming report holds its only read transaction
+	first := subscribe(1)
+	subscribe(1)
+	if got := refused("resource_exhausted"); got != 1 {
+		t.Errorf("resource_exhausted = %v, want 1", got)
+	}
+
+	// Fabric 2 takes the last one; fabric 3 finds the node busy
+	subscribe(2)
+	subscribe(3)
+	if got := refused("busy"); got != 1 {
+		t.Errorf("busy = %v, want 1", got)
+	}
+
+	// Acknowledging the priming report frees its transaction
+	ack, err := EncodeStatusResponse(imsg.StatusSuccess)
+	if err != nil {
+		t.Fatalf("EncodeStatusResponse: %v", err)
+	}
+	header := &message.ProtocolHeader{ProtocolOpcode: uint8(imsg.OpcodeStatusResponse)}
+	if _, err := engine.OnMessage(first, header, ack); err != nil {
+		t.Fatalf("acknowledge priming report: %v", err)
+	}
+	if got := engine.resources.byFabric[1]; got != 0 {
+		t.Errorf("fabric 1 reads after priming = %d, want 0", got)
+	}
+	subscribe(3)
+	if got := engine.resources.byFabric[3]; got != 1 {
+		t.Errorf("fabric 3 reads = %d, want 1", got)
+	}
+	if got := rec.Value(metrics.IMActiveReads); got != 2 {
+		t.Errorf("active reads gauge = %v, want 2", got)
+	}
+}
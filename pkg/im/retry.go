@@ -0,0 +1,137 @@
+package im
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// Busy retry defaults.
+const (
+	// DefaultBusyBackoff is the delay before the first retry of a request
+	// refused with Busy.
+	DefaultBusyBackoff = 100 * time.Millisecond
+
+	// DefaultMaxBusyBackoff caps the delay between retries.
+	DefaultMaxBusyBackoff = 5 * time.Second
+)
+
+// RetryPolicy controls how a Client retries requests the peer refuses with
+// Busy: a StatusResponse(Busy) to a read or invoke, a Busy command status,
+// or a Busy reply to a TimedRequest. A busy peer did not act on the
+// request, so it is sent again on a new exchange after a backoff that
+// grows with each retry.
+//
+// The zero RetryPolicy does not retry.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts, including the first.
+	// Requests are not retried if 0 or 1.
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry.
+	// Defaults to DefaultBusyBackoff if 0.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the delay between retries.
+	// Defaults to DefaultMaxBusyBackoff if 0.
+	MaxBackoff time.Duration
+
+	// Multiplier grows the delay after each retry. Defaults to 2 if 0.
+	Multiplier float64
+
+	// Jitter randomizes each delay by up to this fraction of it, so that
+	// clients refused together do not retry together. Between 0 and 1.
+	Jitter float64
+}
+
+// DefaultRetryPolicy returns a policy of 5 attempts, backing off from
+// DefaultBusyBackoff up to DefaultMaxBusyBackoff with 20% jitter.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 5,
+		Jitter:      0.2,
+	}
+}
+
+// backoff returns the delay before the given retry, counted from 1.
+// rnd returns a number in [0, 1).
+func (p RetryPolicy) backoff(retry int, rnd func() float64) time.Duration {
+	initial, maxDelay, multiplier := p.InitialBackoff, p.MaxBackoff, p.Multiplier
+	if initial == 0 {
+		initial = DefaultBusyBackoff
+	}
+	if maxDelay == 0 {
+		maxDelay = DefaultMaxBusyBackoff
+	}
+	if multiplier == 0 {
+		multiplier = 2
+	}
+
+	delay := float64(initial)
+	for i := 1; i < retry && delay < float64(maxDelay); i++ {
+		delay *= multiplier
+	}
+	delay = min(delay, float64(maxDelay))
+
+	if jitter := min(max(p.Jitter, 0), 1); jitter > 0 {
+		delay *= 1 - jitter + 2*jitter*rnd()
+	}
+	return time.Duration(delay)
+}
+
+// BusyError is returned when the peer still refuses a request with Busy
+// after all attempts of the client's RetryPolicy. It matches ErrBusy with
+// errors.Is.
+type BusyError struct {
+	// Attempts is the number of times the request was sent.
+	Attempts int
+
+	// Elapsed is the time from the first attempt to the last refusal.
+	Elapsed time.Duration
+}
+
+// Error implements error.
+func (e *BusyError) Error() string {
+	return fmt.Sprintf("%v after %d attempts in %v", ErrBusy, e.Attempts, e.Elapsed.Round(time.Millisecond))
+}
+
+// Unwrap returns ErrBusy.
+func (e *BusyError) Unwrap() error {
+	return ErrBusy
+}
+
+// retriesBusy reports whether the client retries requests refused with
+// Busy.
+func (c *Client) retriesBusy() bool {
+	return c.busyRetry.MaxAttempts > 1
+}
+
+// withBusyRetry calls attempt until it is not refused with Busy, which it
+// reports by returning an error matching ErrBusy, or until the client's
+// RetryPolicy runs out of attempts. Waits between attempts end with ctx.
+func (c *Client) withBusyRetry(ctx context.Context, attempt func() error) error {
+	start := time.Now()
+	for n := 1; ; n++ {
+		err := attempt()
+		if !errors.Is(err, ErrBusy) || !c.retriesBusy() {
+			return err
+		}
+		if n >= c.busyRetry.MaxAttempts {
+			return &BusyError{Attempts: n, Elapsed: time.Since(start)}
+		}
+
+		delay := c.busyRetry.backoff(n, rand.Float64)
+		if c.log != nil {
+			c.log.Debugf("peer busy (attempt %d/%d), retrying in %v", n, c.busyRetry.MaxAttempts, delay)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ErrClientTimeout
+		case <-timer.C:
+		}
+	}
+}
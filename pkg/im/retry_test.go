@@ -0,0 +1,124 @@
+package im
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/backkem/matter/pkg/exchange"
+	imsg "github.com/backkem/matter/pkg/im/message"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	half := func() float64 { return 0.5 }
+	for retry, want := range map[int]time.Duration{
+		1: 100 * time.Millisecond,
+		2: 200 * time.Millisecond,
+		4: 800 * time.Millisecond,
+		5: time.Second,
+		9: time.Second,
+	} {
+		if got := p.backoff(retry, half); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", retry, got, want)
+		}
+	}
+
+	// Jitter spreads the delay around the backoff
+	p.Jitter = 0.5
+	if got := p.backoff(1, func() float64 { return 0 }); got != 50*time.Millisecond {
+		t.Errorf("backoff with lowest jitter = %v, want 50ms", got)
+	}
+	if got := p.backoff(1, func() float64 { return 0.999 }); got < 149*time.Millisecond || got > 150*time.Millisecond {
+		t.Errorf("backoff with highest jitter = %v, want about 150ms", got)
+	}
+
+	if got := (RetryPolicy{}).backoff(1, half); got != DefaultBusyBackoff {
+		t.Errorf("default backoff = %v, want %v", got, DefaultBusyBackoff)
+	}
+}
+
+// busyHandler refuses the first IM requests with StatusResponse(Busy) and
+// passes the others to the engine.
+type busyHandler struct {
+	refuse atomic.Int32
+	next   exchange.ProtocolHandler
+}
+
+func (h *busyHandler) OnMessage(ctx *exchange.ExchangeContext, opcode uint8, payload []byte) ([]byte, error) {
+	return h.next.OnMessage(ctx, opcode, payload)
+}
+
+func (h *busyHandler) OnUnsolicited(ctx *exchange.ExchangeContext, opcode uint8, payload []byte) ([]byte, error) {
+	if h.refuse.Add(-1) >= 0 {
+		status, err := EncodeStatusResponse(imsg.StatusBusy)
+		if err != nil {
+			return nil, err
+		}
+		return nil, ctx.SendMessage(uint8(imsg.OpcodeStatusResponse), status, true)
+	}
+	return h.next.OnUnsolicited(ctx, opcode, payload)
+}
+
+func TestE2E_BusyRetry(t *testing.T) {
+	mockDispatcher := NewMockDispatcher()
+	mockDispatcher.SetReadResult([]byte{0x09}, nil) // Boolean true
+	pair, err := NewSecureTestIMPair(SecureTestIMPairConfig{
+		Dispatchers: [2]Dispatcher{nil, mockDispatcher},
+	})
+	if err != nil {
+		t.Fatalf("NewSecureTestIMPair: %v", err)
+	}
+	defer pair.Close()
+
+	busy := &busyHandler{next: &engineAdapter{engine: pair.Engine(1)}}
+	pair.ExchangePair().Manager(1).RegisterProtocol(ProtocolID, busy)
+
+	client := NewClient(ClientConfig{
+		ExchangeManager: pair.ExchangePair().Manager(0),
+		BusyRetry: RetryPolicy{
+			MaxAttempts:    3,
+			InitialBackoff: 10 * time.Millisecond,
+		},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Refused twice, accepted on the last attempt
+	busy.refuse.Store(2)
+	result, err := client.InvokeWithStatus(ctx, pair.Session(0), pair.PeerAddress(1), 1, 0x0006, 0x01, nil)
+	if err != nil {
+		t.Fatalf("InvokeWithStatus: %v", err)
+	}
+	if result.HasStatus && result.Status != imsg.StatusSuccess {
+		t.Errorf("Status = %s, want Success", result.Status)
+	}
+	if calls := len(mockDispatcher.InvokeCalls()); calls != 1 {
+		t.Errorf("dispatcher invoked %d times, want 1", calls)
+	}
+
+	busy.refuse.Store(2)
+	if _, err := client.ReadAttribute(ctx, pair.Session(0), pair.PeerAddress(1), 1, 0x0006, 0x0000); err != nil {
+		t.Fatalf("ReadAttribute: %v", err)
+	}
+
+	// Refused every time
+	busy.refuse.Store(3)
+	_, err = client.InvokeWithStatus(ctx, pair.Session(0), pair.PeerAddress(1), 1, 0x0006, 0x01, nil)
+	var busyErr *BusyError
+	if !errors.As(err, &busyErr) || !errors.Is(err, ErrBusy) {
+		t.Fatalf("InvokeWithStatus() error = %v, want a BusyError", err)
+	}
+	if busyErr.Attempts != 3 {
+		t.Errorf("Attempts = %d, want 3", busyErr.Attempts)
+	}
+
+	// Without a policy the first refusal fails the request
+	busy.refuse.Store(1)
+	_, err = pair.Client(0).ReadAttribute(ctx, pair.Session(0), pair.PeerAddress(1), 1, 0x0006, 0x0000)
+	if err != ErrBusy {
+		t.Errorf("ReadAttribute() error = %v, want %v", err, ErrBusy)
+	}
+}
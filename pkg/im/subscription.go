@@ -0,0 +1,572 @@
+package im
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"github.com/backkem/matter/pkg/clock"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/exchange"
+	"github.com/backkem/matter/pkg/fabric"
+	imsg "github.com/backkem/matter/pkg/im/message"
+	"github.com/backkem/matter/pkg/metrics"
+	"github.com/backkem/matter/pkg/session"
+	"github.com/backkem/matter/pkg/tlv"
+	"github.com/backkem/matter/pkg/transport"
+)
+
+// DefaultSubscriptionsPerFabric is the default number of subscriptions each
+// fabric may hold. The spec requires a node to support at least three.
+const DefaultSubscriptionsPerFabric = 3
+
+// Subscription errors.
+var (
+	// ErrNoExchangeManager indicates the engine cannot initiate exchanges.
+	ErrNoExchangeManager = errors.New("im: no exchange manager")
+
+	// ErrSubscriptionExists indicates a subscription with the same ID is active.
+	ErrSubscriptionExists = errors.New("im: subscription exists")
+)
+
+// SubscriptionRecord is the persisted state of a subscription. It holds
+// what the engine needs to resume reporting to the subscriber after a
+// restart, without a new SubscribeRequest.
+//
+// C++ Reference: SubscriptionResumptionStorage::SubscriptionInfo
+type SubscriptionRecord struct {
+	// SubscriptionID identifies the subscription to the subscriber.
+	SubscriptionID imsg.SubscriptionID
+
+	// FabricIndex and NodeID identify the subscriber.
+	FabricIndex fabric.FabricIndex
+	NodeID      fabric.NodeID
+
+	// MinInterval and MaxInterval are the negotiated reporting intervals,
+	// in seconds.
+	MinInterval uint16
+	MaxInterval uint16
+
+	// FabricFiltered is the fabric filtering of the subscription's reads.
+	FabricFiltered bool
+
+	// AttributePaths and EventPaths are the subscribed paths.
+	AttributePaths []imsg.AttributePathIB
+	EventPaths     []imsg.EventPathIB
+
+	// ResumptionAttempts counts the failed attempts to resume the
+	// subscription since it was last active.
+	ResumptionAttempts int
+}
+
+// Clone returns a copy of the record.
+// The paths are copied; their fields are never modified in place.
+func (r *SubscriptionRecord) Clone() *SubscriptionRecord {
+	clone := *r
+	clone.AttributePaths = append([]imsg.AttributePathIB(nil), r.AttributePaths...)
+	clone.EventPaths = append([]imsg.EventPathIB(nil), r.EventPaths...)
+	return &clone
+}
+
+// SubscriptionStore persists the records of active subscriptions.
+//
+// The engine saves a record once a subscription is established or resumed,
+// and deletes it when the subscription ends. Records are kept when the
+// engine is closed, so they can be resumed with ResumeSubscription.
+type SubscriptionStore interface {
+	SaveSubscription(rec *SubscriptionRecord) error
+	DeleteSubscription(id imsg.SubscriptionID) error
+}
+
+// subscription is a subscription served by the engine.
+type subscription struct {
+	record SubscriptionRecord
+
+	// session and peerAddr reach the subscriber for reports the engine
+	// initiates. session is nil if the subscription was made over an
+	// exchange without a secure session.
+	session  *session.SecureContext
+	peerAddr transport.PeerAddress
+
+	// active is set once the SubscribeResponse is sent.
+	active bool
+
+	// filters are the request's DataVersionFilters, which apply to the
+	// priming report only.
+	filters []imsg.DataVersionFilterIB
+
+	// report holds the chunks of the report in progress.
+	report *ReadHandler
+
+	// inFlight is set while a report awaits the subscriber's
+	// StatusResponse. Changes meanwhile wait for the next report.
+	inFlight bool
+
+	// eventMin is the number of the next event to deliver: events are
+	// delivered once each, in EventNumber order.
+	eventMin imsg.EventNumber
+
+	// eventsPending is set when new events await the next report; urgent
+	// when one of them is on an urgent path.
+	eventsPending bool
+	urgent        bool
+
+	// dirty holds the changed paths for the next report, in order.
+	dirty    []datamodel.ConcreteAttributePath
+	dirtySet map[datamodel.ConcreteAttributePath]struct{}
+
+	// lastReport is when the last report was sent, to keep MinInterval
+	// between reports.
+	lastReport time.Time
+
+	// pending sends the next report of dirty paths.
+	pending clock.Timer
+
+	// liveness sends the next report at MaxInterval.
+	liveness clock.Timer
+}
+
+// handleSubscribeRequest processes a SubscribeRequestMessage.
+// It replies with the priming report; the SubscribeResponse follows once
+// the subscriber acknowledges its last chunk.
+//
+// Spec: Section 8.5
+// C++ Reference: InteractionModelEngine::OnReadInitialRequest
+func (e *Engine) handleSubscribeRequest(ctx *exchange.ExchangeContext, payload []byte) ([]byte, error) {
+	req, err := DecodeSubscribeRequest(payload)
+	if err != nil ||
+		(len(req.AttributeRequests) == 0 && len(req.EventRequests) == 0) ||
+		req.MinIntervalFloor > req.MaxIntervalCeiling {
+		return e.replyStatus(ctx, imsg.StatusInvalidAction)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	subject := subjectDescriptor(ctx)
+	fabricIndex := fabric.FabricIndex(subject.FabricIndex)
+	nodeID := fabric.NodeID(subject.Subject)
+
+	// Without KeepSubscriptions, the subscriber's other subscriptions end
+	if !req.KeepSubscriptions {
+		for _, sub := range e.subscriptions {
+			if sub.record.FabricIndex == fabricIndex && sub.record.NodeID == nodeID {
+				e.endSubscription(sub)
+			}
+		}
+	}
+
+	if e.fabricSubscriptions(fabricIndex) >= e.subscriptionsPerFabric {
+		e.refused("subscribe", imsg.StatusResourceExhausted)
+		return e.replyStatus(ctx, imsg.StatusResourceExhausted)
+	}
+
+	// The priming report holds a read transaction until it is acknowledged
+	if status := e.resources.acquire(ctx, accessingFabric(ctx)); status != imsg.StatusSuccess {
+		e.refused("subscribe", status)
+		return e.replyStatus(ctx, status)
+	}
+	e.metrics.Set(metrics.IMActiveReads, float64(e.resources.active()))
+
+	id, err := e.newSubscriptionID()
+	if err != nil {
+		e.releaseRead(ctx)
+		return nil, err
+	}
+
+	sub := &subscription{
+		record: SubscriptionRecord{
+			SubscriptionID: id,
+			FabricIndex:    fabricIndex,
+			NodeID:         nodeID,
+			MinInterval:    req.MinIntervalFloor,
+			MaxInterval:    req.MaxIntervalCeiling,
+			FabricFiltered: req.FabricFiltered,
+			AttributePaths: req.AttributeRequests,
+			EventPaths:     req.EventRequests,
+		},
+		filters:  req.DataVersionFilters,
+		eventMin: eventMin(req.EventFilters),
+	}
+	if ctx != nil {
+		sub.session, _ = ctx.Session().(*session.SecureContext)
+		sub.peerAddr = ctx.PeerAddress()
+	}
+	e.addSubscription(sub)
+
+	report, err := e.primingReport(ctx, sub)
+	if err != nil {
+		e.removeSubscription(sub)
+		e.releaseRead(ctx)
+		return e.replyStatus(ctx, ErrorToStatus(err))
+	}
+	e.reports[ctx] = sub
+	sub.inFlight = true
+	sub.lastReport = e.clock.Now()
+
+	return e.sendOrReturn(ctx, uint8(imsg.OpcodeReportData), report)
+}
+
+// ResumeSubscription resumes a persisted subscription over a session to
+// the subscriber, typically after a restart. The engine sends a priming
+// report with the subscription's ID, and the SubscribeResponse once the
+// subscriber acknowledges it.
+//
+// Spec: Section 8.5.1 (subscription resumption)
+// C++ Reference: ReadHandler::ResumeSubscription
+func (e *Engine) ResumeSubscription(
+	rec *SubscriptionRecord,
+	sess *session.SecureContext,
+	peerAddr transport.PeerAddress,
+) error {
+	if e.exchangeManager == nil {
+		return ErrNoExchangeManager
+	}
+
+	e.mu.Lock()
+	if _, ok := e.subscriptions[rec.SubscriptionID]; ok {
+		e.mu.Unlock()
+		return ErrSubscriptionExists
+	}
+
+	exch, err := e.exchangeManager.NewExchange(sess, sess.LocalSessionID(), peerAddr, ProtocolID, e)
+	if err != nil {
+		e.mu.Unlock()
+		return err
+	}
+
+	sub := &subscription{
+		record:   *rec.Clone(),
+		session:  sess,
+		peerAddr: peerAddr,
+	}
+	e.addSubscription(sub)
+
+	report, err := e.primingReport(exch, sub)
+	if err != nil {
+		e.removeSubscription(sub)
+		e.mu.Unlock()
+		exch.Close()
+		return err
+	}
+	e.reports[exch] = sub
+	sub.inFlight = true
+	sub.lastReport = e.clock.Now()
+	e.mu.Unlock()
+
+	if err := exch.SendMessage(uint8(imsg.OpcodeReportData), report, true); err != nil {
+		e.mu.Lock()
+		delete(e.reports, exch)
+		e.removeSubscription(sub)
+		e.mu.Unlock()
+		exch.Close()
+		return err
+	}
+	return nil
+}
+
+// Subscriptions returns the records of the active subscriptions.
+func (e *Engine) Subscriptions() []*SubscriptionRecord {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	records := make([]*SubscriptionRecord, 0, len(e.subscriptions))
+	for _, sub := range e.subscriptions {
+		if sub.active {
+			records = append(records, sub.record.Clone())
+		}
+	}
+	return records
+}
+
+// RemoveFabricSubscriptions ends the subscriptions of a fabric and deletes
+// their records. Call it when the fabric is removed.
+func (e *Engine) RemoveFabricSubscriptions(fabricIndex fabric.FabricIndex) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, sub := range e.subscriptions {
+		if sub.record.FabricIndex == fabricIndex {
+			e.endSubscription(sub)
+		}
+	}
+}
+
+// SessionClosed stops serving the subscriptions on a secure session that
+// was closed, e.g. by the subscriber with CloseSession. Their records stay
+// for a later resumption, and a subscriber that opens a new session may
+// subscribe again.
+//
+// C++ Reference: ReadHandler::OnSessionReleased
+func (e *Engine) SessionClosed(localSessionID uint16) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, sub := range e.subscriptions {
+		if sub.session != nil && sub.session.LocalSessionID() == localSessionID {
+			e.removeSubscription(sub)
+		}
+	}
+}
+
+// ShiftSubscriptions moves the subscriptions of a peer to a new CASE
+// session with it, e.g. one established after the peer's keys were
+// updated, so reports follow the peer's newest session. The peer's
+// address is kept.
+//
+// C++ Reference: SessionHolder::ShiftToSession
+func (e *Engine) ShiftSubscriptions(sess *session.SecureContext) {
+	if sess.SessionType() != session.SessionTypeCASE {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, sub := range e.subscriptions {
+		if sub.session == nil || sub.session == sess ||
+			sub.session.SessionType() != session.SessionTypeCASE ||
+			sub.session.FabricIndex() != sess.FabricIndex() ||
+			sub.session.PeerNodeID() != sess.PeerNodeID() {
+			continue
+		}
+		if e.log != nil {
+			e.log.Debugf("subscription %d: session %d -> %d",
+				sub.record.SubscriptionID, sub.session.LocalSessionID(), sess.LocalSessionID())
+		}
+		sub.session = sess
+	}
+}
+
+// Close stops reporting on all subscriptions and events. Their records are
+// kept in the SubscriptionStore to be resumed later.
+func (e *Engine) Close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.events != nil {
+		e.events.RemoveListener(e)
+	}
+
+	for _, sub := range e.subscriptions {
+		e.removeSubscription(sub)
+	}
+	for exch := range e.reports {
+		delete(e.reports, exch)
+	}
+}
+
+// handleReportStatus processes the subscriber's StatusResponse to a report
+// message. It returns the next message to send on the exchange, if any,
+// and whether the exchange is done and should be closed.
+// Must be called with e.mu held.
+func (e *Engine) handleReportStatus(
+	ctx *exchange.ExchangeContext,
+	sub *subscription,
+	status imsg.Status,
+) (opcode imsg.Opcode, payload []byte, done bool, err error) {
+	// A failed report ends the subscription
+	if status != imsg.StatusSuccess {
+		delete(e.reports, ctx)
+		e.endSubscription(sub)
+		return 0, nil, true, nil
+	}
+
+	// Send the next chunk
+	if sub.report != nil {
+		chunk, err := sub.report.HandleStatusResponse(status)
+		if sub.report.State() != ReadHandlerStateSendingReport {
+			sub.report = nil
+		}
+		if err != nil {
+			return 0, nil, false, err
+		}
+		if chunk != nil {
+			payload, err := EncodeReportData(chunk)
+			return imsg.OpcodeReportData, payload, false, err
+		}
+	}
+
+	delete(e.reports, ctx)
+	if sub.active {
+		return 0, nil, true, nil
+	}
+
+	// The priming report is complete: confirm the subscription
+	sub.active = true
+	sub.record.ResumptionAttempts = 0
+	e.metrics.Set(metrics.Subscriptions, float64(e.activeSubscriptions()))
+	e.saveSubscription(sub)
+	e.scheduleLiveness(sub)
+
+	payload, err = EncodeMessage(func(w *tlv.Writer) error {
+		resp := imsg.SubscribeResponseMessage{
+			SubscriptionID: sub.record.SubscriptionID,
+			MaxInterval:    sub.record.MaxInterval,
+		}
+		return resp.Encode(w)
+	})
+	return imsg.OpcodeSubscribeResponse, payload, true, err
+}
+
+// primingReport builds the first message of a report of all the
+// subscription's paths, keeping the remaining chunks. Clusters matching
+// the request's DataVersionFilters are left out.
+// Must be called with e.mu held.
+func (e *Engine) primingReport(ctx *exchange.ExchangeContext, sub *subscription) ([]byte, error) {
+	return e.buildReport(ctx, sub, &imsg.ReadRequestMessage{
+		AttributeRequests:  sub.record.AttributePaths,
+		EventRequests:      sub.record.EventPaths,
+		DataVersionFilters: sub.filters,
+	})
+}
+
+// buildReport builds the first message of a report of the request's
+// paths, keeping the remaining chunks. Events are read from the
+// subscription's bookmark, which moves past the last one reported.
+// Must be called with e.mu held.
+func (e *Engine) buildReport(ctx *exchange.ExchangeContext, sub *subscription, req *imsg.ReadRequestMessage) ([]byte, error) {
+	dispatcher := e.requestDispatcher(ctx)
+	handler := e.newReadHandler(dispatcher)
+	if e.events != nil {
+		readEvents := e.createEventReader(dispatcher)
+		handler.SetEventReader(func(rc *ReadContext, paths []imsg.EventPathIB, min imsg.EventNumber) []imsg.EventReportIB {
+			reports := readEvents(rc, paths, min)
+			if n := len(reports); n > 0 {
+				sub.eventMin = reports[n-1].EventData.EventNumber + 1
+			}
+			return reports
+		})
+	}
+
+	req.FabricFiltered = sub.record.FabricFiltered
+	req.EventFilters = []imsg.EventFilterIB{{EventMin: sub.eventMin}}
+	subject := dispatcher.rc.Subject
+	resp, err := handler.HandleSubscriptionReport(ctx, req,
+		uint8(subject.FabricIndex), subject.Subject, sub.record.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	sub.report = nil
+	if handler.State() == ReadHandlerStateSendingReport {
+		sub.report = handler
+	}
+	return EncodeReportData(resp)
+}
+
+// scheduleLiveness arms the timer for the next report at MaxInterval,
+// which tells the subscriber the subscription is alive.
+// Must be called with e.mu held.
+func (e *Engine) scheduleLiveness(sub *subscription) {
+	if sub.liveness != nil {
+		sub.liveness.Stop()
+		sub.liveness = nil
+	}
+	if e.exchangeManager == nil || sub.session == nil || sub.record.MaxInterval == 0 {
+		return
+	}
+
+	id := sub.record.SubscriptionID
+	interval := time.Duration(sub.record.MaxInterval) * time.Second
+	sub.liveness = e.clock.AfterFunc(interval, func() { e.sendReport(id) })
+}
+
+// addSubscription registers a subscription.
+// Must be called with e.mu held.
+func (e *Engine) addSubscription(sub *subscription) {
+	e.subscriptions[sub.record.SubscriptionID] = sub
+}
+
+// removeSubscription stops serving a subscription, keeping its record.
+// Must be called with e.mu held.
+func (e *Engine) removeSubscription(sub *subscription) {
+	sub.stopTimers()
+	delete(e.subscriptions, sub.record.SubscriptionID)
+	e.metrics.Set(metrics.Subscriptions, float64(e.activeSubscriptions()))
+}
+
+// endSubscription stops serving a subscription and deletes its record.
+// Must be called with e.mu held.
+func (e *Engine) endSubscription(sub *subscription) {
+	e.removeSubscription(sub)
+	if e.subscriptionStore == nil || sub.record.FabricIndex == 0 {
+		return
+	}
+	if err := e.subscriptionStore.DeleteSubscription(sub.record.SubscriptionID); err != nil && e.log != nil {
+		e.log.Warnf("subscription %d: delete record: %v", sub.record.SubscriptionID, err)
+	}
+}
+
+// saveSubscription persists the record of a subscription. Only CASE
+// subscriptions are persisted: a subscriber without a fabric cannot be
+// reached again after a restart.
+// Must be called with e.mu held.
+func (e *Engine) saveSubscription(sub *subscription) {
+	if e.subscriptionStore == nil || sub.record.FabricIndex == 0 {
+		return
+	}
+	if err := e.subscriptionStore.SaveSubscription(sub.record.Clone()); err != nil && e.log != nil {
+		e.log.Warnf("subscription %d: save record: %v", sub.record.SubscriptionID, err)
+	}
+}
+
+// fabricSubscriptions returns the number of subscriptions of a fabric.
+// Must be called with e.mu held.
+func (e *Engine) fabricSubscriptions(fabricIndex fabric.FabricIndex) int {
+	n := 0
+	for _, sub := range e.subscriptions {
+		if sub.record.FabricIndex == fabricIndex {
+			n++
+		}
+	}
+	return n
+}
+
+// activeSubscriptions returns the number of established subscriptions.
+// Must be called with e.mu held.
+func (e *Engine) activeSubscriptions() int {
+	n := 0
+	for _, sub := range e.subscriptions {
+		if sub.active {
+			n++
+		}
+	}
+	return n
+}
+
+// newSubscriptionID returns a random ID unused by the active subscriptions.
+// Must be called with e.mu held.
+func (e *Engine) newSubscriptionID() (imsg.SubscriptionID, error) {
+	var buf [4]byte
+	for {
+		if _, err := rand.Read(buf[:]); err != nil {
+			return 0, err
+		}
+		id := imsg.SubscriptionID(binary.LittleEndian.Uint32(buf[:]))
+		if _, ok := e.subscriptions[id]; !ok {
+			return id, nil
+		}
+	}
+}
+
+// replyStatus sends a StatusResponse on the exchange.
+func (e *Engine) replyStatus(ctx *exchange.ExchangeContext, status imsg.Status) ([]byte, error) {
+	payload, err := e.encodeStatusResponse(status)
+	if err != nil {
+		return nil, err
+	}
+	return e.sendOrReturn(ctx, uint8(imsg.OpcodeStatusResponse), payload)
+}
+
+// DecodeSubscribeRequest decodes a subscribe request message.
+func DecodeSubscribeRequest(data []byte) (*imsg.SubscribeRequestMessage, error) {
+	r := tlv.NewReader(bytes.NewReader(data))
+	var msg imsg.SubscribeRequestMessage
+	if err := msg.Decode(r); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
@@ -0,0 +1,518 @@
+package im
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/backkem/matter/pkg/exchange"
+	"github.com/backkem/matter/pkg/fabric"
+	imsg "github.com/backkem/matter/pkg/im/message"
+	"github.com/backkem/matter/pkg/message"
+	"github.com/backkem/matter/pkg/session"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// memorySubscriptionStore is an in-memory SubscriptionStore.
+type memorySubscriptionStore struct {
+	mu      sync.Mutex
+	records map[imsg.SubscriptionID]*SubscriptionRecord
+}
+
+func newMemorySubscriptionStore() *memorySubscriptionStore {
+	return &memorySubscriptionStore{records: make(map[imsg.SubscriptionID]*SubscriptionRecord)}
+}
+
+func (s *memorySubscriptionStore) SaveSubscription(rec *SubscriptionRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[rec.SubscriptionID] = rec.Clone()
+	return nil
+}
+
+func (s *memorySubscriptionStore) DeleteSubscription(id imsg.SubscriptionID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, id)
+	return nil
+}
+
+func (s *memorySubscriptionStore) get(id imsg.SubscriptionID) (*SubscriptionRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.records[id]
+	return rec, ok
+}
+
+// testSubscriber is the client side of a subscription. It answers reports
+// with reportStatus and collects reports and SubscribeResponses.
+type testSubscriber struct {
+	reportStatus imsg.Status
+	reports      chan *imsg.ReportDataMessage
+	responses    chan *imsg.SubscribeResponseMessage
+}
+
+func newTestSubscriber() *testSubscriber {
+	return &testSubscriber{
+		reportStatus: imsg.StatusSuccess,
+		reports:      make(chan *imsg.ReportDataMessage, 8),
+		responses:    make(chan *imsg.SubscribeResponseMessage, 8),
+	}
+}
+
+// OnMessage implements exchange.ProtocolHandler.
+func (s *testSubscriber) OnMessage(ctx *exchange.ExchangeContext, opcode uint8, payload []byte) ([]byte, error) {
+	return s.handle(ctx, opcode, payload)
+}
+
+// OnUnsolicited implements exchange.ProtocolHandler.
+func (s *testSubscriber) OnUnsolicited(ctx *exchange.ExchangeContext, opcode uint8, payload []byte) ([]byte, error) {
+	return s.handle(ctx, opcode, payload)
+}
+
+func (s *testSubscriber) handle(ctx *exchange.ExchangeContext, opcode uint8, payload []byte) ([]byte, error) {
+	switch imsg.Opcode(opcode) {
+	case imsg.OpcodeReportData:
+		report, err := DecodeReportData(payload)
+		if err != nil {
+			return nil, err
+		}
+		s.reports <- report
+		if !report.SuppressResponse {
+			status, _ := EncodeStatusResponse(s.reportStatus)
+			return nil, ctx.SendMessage(uint8(imsg.OpcodeStatusResponse), status, true)
+		}
+	case imsg.OpcodeSubscribeResponse:
+		var resp imsg.SubscribeResponseMessage
+		if err := resp.Decode(tlv.NewReader(bytes.NewReader(payload))); err != nil {
+			return nil, err
+		}
+		s.responses <- &resp
+	}
+	return nil, nil
+}
+
+// subscriberExchange adapts testSubscriber to exchange.ExchangeDelegate.
+type subscriberExchange struct {
+	*testSubscriber
+}
+
+func (d subscriberExchange) OnMessage(ctx *exchange.ExchangeContext, header *message.ProtocolHeader, payload []byte) ([]byte, error) {
+	return d.handle(ctx, header.ProtocolOpcode, payload)
+}
+
+func (d subscriberExchange) OnClose(ctx *exchange.ExchangeContext) {}
+
+// subscribe sends a SubscribeRequest from side 0 of the pair.
+func (s *testSubscriber) subscribe(t *testing.T, pair *SecureTestIMPair, req *imsg.SubscribeRequestMessage) {
+	t.Helper()
+
+	payload, err := EncodeMessage(req.Encode)
+	if err != nil {
+		t.Fatalf("encode SubscribeRequest: %v", err)
+	}
+	sess := pair.Session(0)
+	exch, err := pair.ExchangePair().Manager(0).NewExchange(sess, sess.LocalSessionID(), pair.PeerAddress(1), ProtocolID, subscriberExchange{s})
+	if err != nil {
+		t.Fatalf("NewExchange: %v", err)
+	}
+	if err := exch.SendMessage(uint8(imsg.OpcodeSubscribeRequest), payload, true); err != nil {
+		t.Fatalf("send SubscribeRequest: %v", err)
+	}
+}
+
+func (s *testSubscriber) waitReport(t *testing.T, timeout time.Duration) *imsg.ReportDataMessage {
+	t.Helper()
+	select {
+	case report := <-s.reports:
+		return report
+	case <-time.After(timeout):
+		t.Fatal("timed out waiting for ReportData")
+		return nil
+	}
+}
+
+func (s *testSubscriber) waitResponse(t *testing.T) *imsg.SubscribeResponseMessage {
+	t.Helper()
+	select {
+	case resp := <-s.responses:
+		return resp
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for SubscribeResponse")
+		return nil
+	}
+}
+
+// newSubscriptionPair creates a CASE pair whose server side reads true from
+// every attribute and persists subscriptions to store.
+func newSubscriptionPair(t *testing.T, store SubscriptionStore) (*SecureTestIMPair, *testSubscriber) {
+	t.Helper()
+	return newEventSubscriptionPair(t, store, nil)
+}
+
+func newEventSubscriptionPair(t *testing.T, store SubscriptionStore, events *EventManager) (*SecureTestIMPair, *testSubscriber) {
+	t.Helper()
+
+	dispatcher := NewMockDispatcher()
+	dispatcher.SetReadResult(true, nil)
+
+	pair, err := NewSecureTestIMPair(SecureTestIMPairConfig{
+		Dispatchers:       [2]Dispatcher{nil, dispatcher},
+		FabricIndex:       1,
+		SubscriptionStore: store,
+		EventManager:      events,
+	})
+	if err != nil {
+		t.Fatalf("NewSecureTestIMPair: %v", err)
+	}
+
+	subscriber := newTestSubscriber()
+	pair.ExchangePair().Manager(0).RegisterProtocol(ProtocolID, subscriber)
+	return pair, subscriber
+}
+
+func onOffSubscribeRequest(maxInterval uint16) *imsg.SubscribeRequestMessage {
+	return &imsg.SubscribeRequestMessage{
+		MinIntervalFloor:   0,
+		MaxIntervalCeiling: maxInterval,
+		AttributeRequests: []imsg.AttributePathIB{
+			makeAttrPath(1, 0x0006, 0x0000),
+		},
+	}
+}
+
+func makeAttrPath(endpoint imsg.EndpointID, cluster imsg.ClusterID, attribute imsg.AttributeID) imsg.AttributePathIB {
+	return imsg.AttributePathIB{Endpoint: &endpoint, Cluster: &cluster, Attribute: &attribute}
+}
+
+func TestSubscribe_Establish(t *testing.T) {
+	store := newMemorySubscriptionStore()
+	pair, subscriber := newSubscriptionPair(t, store)
+	defer pair.Close()
+
+	subscriber.subscribe(t, pair, onOffSubscribeRequest(60))
+
+	report := subscriber.waitReport(t, 5*time.Second)
+	if report.SubscriptionID == nil {
+		t.Fatal("priming report has no SubscriptionID")
+	}
+	if report.SuppressResponse {
+		t.Error("priming report should expect a StatusResponse")
+	}
+	if len(report.AttributeReports) != 1 || report.AttributeReports[0].AttributeData == nil {
+		t.Fatalf("priming report = %+v, want one attribute", report.AttributeReports)
+	}
+
+	resp := subscriber.waitResponse(t)
+	if resp.SubscriptionID != *report.SubscriptionID {
+		t.Errorf("SubscriptionID = %d, want %d", resp.SubscriptionID, *report.SubscriptionID)
+	}
+	if resp.MaxInterval != 60 {
+		t.Errorf("MaxInterval = %d, want 60", resp.MaxInterval)
+	}
+
+	subs := pair.Engine(1).Subscriptions()
+	if len(subs) != 1 {
+		t.Fatalf("Subscriptions() = %d, want 1", len(subs))
+	}
+
+	rec, ok := store.get(resp.SubscriptionID)
+	if !ok {
+		t.Fatal("subscription was not persisted")
+	}
+	if rec.FabricIndex != 1 || rec.NodeID != SecureTestNodeIDs[0] {
+		t.Errorf("record subscriber = %d/0x%x, want 1/0x%x", rec.FabricIndex, rec.NodeID, SecureTestNodeIDs[0])
+	}
+	if len(rec.AttributePaths) != 1 || rec.MaxInterval != 60 {
+		t.Errorf("record = %+v", rec)
+	}
+}
+
+func TestSubscribe_Liveness(t *testing.T) {
+	pair, subscriber := newSubscriptionPair(t, nil)
+	defer pair.Close()
+
+	subscriber.subscribe(t, pair, onOffSubscribeRequest(1))
+	subscriber.waitReport(t, 5*time.Second)
+	resp := subscriber.waitResponse(t)
+
+	report := subscriber.waitReport(t, 3*time.Second)
+	if report.SubscriptionID == nil || *report.SubscriptionID != resp.SubscriptionID {
+		t.Fatalf("liveness report SubscriptionID = %v, want %d", report.SubscriptionID, resp.SubscriptionID)
+	}
+	if len(report.AttributeReports) != 0 {
+		t.Errorf("liveness report has %d attributes, want 0", len(report.AttributeReports))
+	}
+	if !report.SuppressResponse {
+		t.Error("empty liveness report should suppress the response")
+	}
+}
+
+func TestSubscribe_InvalidRequest(t *testing.T) {
+	engine := NewEngine(EngineConfig{})
+
+	tests := []struct {
+		name string
+		req  *imsg.SubscribeRequestMessage
+	}{
+		{"no paths", &imsg.SubscribeRequestMessage{MaxIntervalCeiling: 60}},
+		{"floor above ceiling", &imsg.SubscribeRequestMessage{
+			MinIntervalFloor:   10,
+			MaxIntervalCeiling: 5,
+			AttributeRequests:  onOffSubscribeRequest(0).AttributeRequests,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, _ := EncodeMessage(tt.req.Encode)
+			resp, err := engine.handleMessage(nil, imsg.OpcodeSubscribeRequest, payload)
+			if err != nil {
+				t.Fatalf("handleMessage: %v", err)
+			}
+			status, err := DecodeStatusResponse(resp)
+			if err != nil {
+				t.Fatalf("DecodeStatusResponse: %v", err)
+			}
+			if status.Status != imsg.StatusInvalidAction {
+				t.Errorf("Status = %s, want InvalidAction", status.Status)
+			}
+		})
+	}
+}
+
+func TestSubscribe_Limits(t *testing.T) {
+	engine := NewEngine(EngineConfig{
+		Limits: ResourceLimits{SubscriptionsPerFabric: 1},
+	})
+
+	subscribe := func(keep bool) imsg.Opcode {
+		req := onOffSubscribeRequest(60)
+		req.KeepSubscriptions = keep
+		payload, _ := EncodeMessage(req.Encode)
+		resp, err := engine.handleMessage(nil, imsg.OpcodeSubscribeRequest, payload)
+		if err != nil {
+			t.Fatalf("handleMessage: %v", err)
+		}
+		if status, err := DecodeStatusResponse(resp); err == nil && status.Status == imsg.StatusResourceExhausted {
+			return imsg.OpcodeStatusResponse
+		}
+		return imsg.OpcodeReportData
+	}
+
+	if got := subscribe(false); got != imsg.OpcodeReportData {
+		t.Fatalf("first subscription refused")
+	}
+	if got := subscribe(true); got != imsg.OpcodeStatusResponse {
+		t.Errorf("subscription over the fabric limit was not refused")
+	}
+
+	// Without KeepSubscriptions, the new subscription replaces the old one
+	if got := subscribe(false); got != imsg.OpcodeReportData {
+		t.Errorf("replacing subscription refused")
+	}
+	if n := len(engine.subscriptions); n != 1 {
+		t.Errorf("subscriptions = %d, want 1", n)
+	}
+}
+
+func TestSubscribe_Resume(t *testing.T) {
+	store := newMemorySubscriptionStore()
+	pair, subscriber := newSubscriptionPair(t, store)
+	defer pair.Close()
+
+	rec := &SubscriptionRecord{
+		SubscriptionID:     0x12345678,
+		FabricIndex:        1,
+		NodeID:             SecureTestNodeIDs[0],
+		MaxInterval:        60,
+		AttributePaths:     onOffSubscribeRequest(0).AttributeRequests,
+		ResumptionAttempts: 2,
+	}
+	store.SaveSubscription(rec)
+
+	if err := pair.Engine(1).ResumeSubscription(rec, pair.Session(1), pair.PeerAddress(0)); err != nil {
+		t.Fatalf("ResumeSubscription: %v", err)
+	}
+
+	report := subscriber.waitReport(t, 5*time.Second)
+	if report.SubscriptionID == nil || *report.SubscriptionID != rec.SubscriptionID {
+		t.Fatalf("report SubscriptionID = %v, want %d", report.SubscriptionID, rec.SubscriptionID)
+	}
+	if len(report.AttributeReports) != 1 {
+		t.Errorf("resumed priming report has %d attributes, want 1", len(report.AttributeReports))
+	}
+
+	resp := subscriber.waitResponse(t)
+	if resp.SubscriptionID != rec.SubscriptionID || resp.MaxInterval != 60 {
+		t.Errorf("SubscribeResponse = %+v", resp)
+	}
+
+	if subs := pair.Engine(1).Subscriptions(); len(subs) != 1 {
+		t.Fatalf("Subscriptions() = %d, want 1", len(subs))
+	}
+	saved, ok := store.get(rec.SubscriptionID)
+	if !ok {
+		t.Fatal("record deleted")
+	}
+	if saved.ResumptionAttempts != 0 {
+		t.Errorf("ResumptionAttempts = %d, want 0 once resumed", saved.ResumptionAttempts)
+	}
+
+	if err := pair.Engine(1).ResumeSubscription(rec, pair.Session(1), pair.PeerAddress(0)); err != ErrSubscriptionExists {
+		t.Errorf("second ResumeSubscription = %v, want ErrSubscriptionExists", err)
+	}
+}
+
+func TestSubscribe_ResumeRejected(t *testing.T) {
+	store := newMemorySubscriptionStore()
+	pair, subscriber := newSubscriptionPair(t, store)
+	defer pair.Close()
+
+	// The subscriber no longer knows the subscription
+	subscriber.reportStatus = imsg.StatusInvalidSubscription
+
+	rec := &SubscriptionRecord{
+		SubscriptionID: 0x1111,
+		FabricIndex:    1,
+		NodeID:         SecureTestNodeIDs[0],
+		MaxInterval:    60,
+		AttributePaths: onOffSubscribeRequest(0).AttributeRequests,
+	}
+	store.SaveSubscription(rec)
+
+	if err := pair.Engine(1).ResumeSubscription(rec, pair.Session(1), pair.PeerAddress(0)); err != nil {
+		t.Fatalf("ResumeSubscription: %v", err)
+	}
+	subscriber.waitReport(t, 5*time.Second)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, ok := store.get(rec.SubscriptionID); !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("rejected subscription record was not deleted")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if subs := pair.Engine(1).Subscriptions(); len(subs) != 0 {
+		t.Errorf("Subscriptions() = %d, want 0", len(subs))
+	}
+}
+
+func TestSubscribe_RemoveFabric(t *testing.T) {
+	store := newMemorySubscriptionStore()
+	pair, subscriber := newSubscriptionPair(t, store)
+	defer pair.Close()
+
+	subscriber.subscribe(t, pair, onOffSubscribeRequest(60))
+	subscriber.waitReport(t, 5*time.Second)
+	resp := subscriber.waitResponse(t)
+
+	// Closing the engine keeps the record for resumption
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	<-waitFor(ctx, func() bool { return len(pair.Engine(1).Subscriptions()) == 1 })
+	pair.Engine(1).Close()
+	if _, ok := store.get(resp.SubscriptionID); !ok {
+		t.Fatal("Close deleted the record")
+	}
+
+	// Removing the fabric deletes it
+	pair.Engine(1).ResumeSubscription(&SubscriptionRecord{
+		SubscriptionID: resp.SubscriptionID,
+		FabricIndex:    1,
+		NodeID:         SecureTestNodeIDs[0],
+		AttributePaths: onOffSubscribeRequest(0).AttributeRequests,
+	}, pair.Session(1), pair.PeerAddress(0))
+	pair.Engine(1).RemoveFabricSubscriptions(fabric.FabricIndex(1))
+	if _, ok := store.get(resp.SubscriptionID); ok {
+		t.Error("RemoveFabricSubscriptions kept the record")
+	}
+}
+
+func TestSubscribe_SessionShiftAndClose(t *testing.T) {
+	store := newMemorySubscriptionStore()
+	pair, subscriber := newSubscriptionPair(t, store)
+	defer pair.Close()
+
+	subscriber.subscribe(t, pair, onOffSubscribeRequest(60))
+	subscriber.waitReport(t, 5*time.Second)
+	resp := subscriber.waitResponse(t)
+
+	engine := pair.Engine(1)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	<-waitFor(ctx, func() bool { return len(engine.Subscriptions()) == 1 })
+
+	old := pair.Session(1)
+	next, err := session.NewSecureContext(session.SecureContextConfig{
+		SessionType:    session.SessionTypeCASE,
+		Role:           session.SessionRoleResponder,
+		LocalSessionID: 3,
+		PeerSessionID:  4,
+		I2RKey:         testI2RKey,
+		R2IKey:         testR2IKey,
+		FabricIndex:    old.FabricIndex(),
+		LocalNodeID:    old.LocalNodeID(),
+		PeerNodeID:     old.PeerNodeID(),
+	})
+	if err != nil {
+		t.Fatalf("NewSecureContext() error = %v", err)
+	}
+
+	// A new session with the subscriber takes over its subscriptions
+	engine.ShiftSubscriptions(next)
+	engine.mu.Lock()
+	for _, sub := range engine.subscriptions {
+		if sub.session != next {
+			t.Errorf("subscription %d on session %d, want 3", sub.record.SubscriptionID, sub.session.LocalSessionID())
+		}
+	}
+	engine.mu.Unlock()
+
+	// Closing the old session leaves them alone; closing the new one ends
+	// them, keeping the record for resumption
+	engine.SessionClosed(old.LocalSessionID())
+	if n := len(engine.Subscriptions()); n != 1 {
+		t.Fatalf("Subscriptions() = %d after closing the old session, want 1", n)
+	}
+	engine.SessionClosed(next.LocalSessionID())
+	if n := len(engine.Subscriptions()); n != 0 {
+		t.Errorf("Subscriptions() = %d after closing the session, want 0", n)
+	}
+	if _, ok := store.get(resp.SubscriptionID); !ok {
+		t.Error("SessionClosed deleted the record")
+	}
+}
+
+// waitFor returns a channel closed once cond holds or ctx is done.
+func waitFor(ctx context.Context, cond func() bool) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for !cond() {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}()
+	return done
+}
+
+func TestSubscriptionRecord_Clone(t *testing.T) {
+	rec := &SubscriptionRecord{
+		SubscriptionID: 1,
+		AttributePaths: onOffSubscribeRequest(0).AttributeRequests,
+	}
+	clone := rec.Clone()
+	clone.AttributePaths[0] = imsg.AttributePathIB{}
+	if rec.AttributePaths[0].Endpoint == nil {
+		t.Error("Clone shares the paths slice")
+	}
+}
@@ -8,11 +8,13 @@ import (
 
 	"github.com/backkem/matter/pkg/datamodel"
 	"github.com/backkem/matter/pkg/exchange"
+	"github.com/backkem/matter/pkg/fabric"
 	imsg "github.com/backkem/matter/pkg/im/message"
 	"github.com/backkem/matter/pkg/message"
 	"github.com/backkem/matter/pkg/session"
 	"github.com/backkem/matter/pkg/tlv"
 	"github.com/backkem/matter/pkg/transport"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // TestIMPairConfig configures a TestIMPair.
@@ -71,6 +73,7 @@ func NewTestIMPair(config TestIMPairConfig) (*TestIMPair, error) {
 		// Create IM client
 		pair.clients[i] = NewClient(ClientConfig{
 			ExchangeManager: exchangePair.Manager(i),
+			Engine:          pair.engines[i],
 			Timeout:         10 * time.Second,
 		})
 	}
@@ -186,6 +189,9 @@ func (d *ClusterDispatcher) WriteAttribute(ctx context.Context, req *AttributeWr
 	if !ok {
 		return ErrClusterNotFound
 	}
+	if req.DataVersion != nil && *req.DataVersion != cluster.DataVersion() {
+		return ErrDataVersionMismatch
+	}
 
 	dmReq := req.ToDataModelRequest()
 	return cluster.WriteAttribute(ctx, dmReq, r)
@@ -206,6 +212,32 @@ func (d *ClusterDispatcher) InvokeCommand(ctx context.Context, req *CommandInvok
 	return cluster.InvokeCommand(ctx, dmReq, r)
 }
 
+// InvokeCommandResponse implements CommandResponder.
+func (d *ClusterDispatcher) InvokeCommandResponse(ctx context.Context, req *CommandInvokeRequest, r *tlv.Reader) (*datamodel.CommandResponse, error) {
+	key := clusterKey{
+		endpoint: datamodel.EndpointID(req.Path.Endpoint),
+		cluster:  datamodel.ClusterID(req.Path.Cluster),
+	}
+	cluster, ok := d.clusters[key]
+	if !ok {
+		return nil, ErrClusterNotFound
+	}
+
+	return datamodel.InvokeCommandResponse(ctx, cluster, req.ToDataModelRequest(), r)
+}
+
+// ClusterDataVersion implements DataVersionProvider.
+func (d *ClusterDispatcher) ClusterDataVersion(endpoint imsg.EndpointID, cluster imsg.ClusterID) (imsg.DataVersion, bool) {
+	c, ok := d.clusters[clusterKey{
+		endpoint: datamodel.EndpointID(endpoint),
+		cluster:  datamodel.ClusterID(cluster),
+	}]
+	if !ok {
+		return 0, false
+	}
+	return imsg.DataVersion(c.DataVersion()), true
+}
+
 // =============================================================================
 // MockDispatcher - Records calls for E2E test verification
 // =============================================================================
@@ -412,8 +444,28 @@ var (
 type SecureTestIMPairConfig struct {
 	// Dispatcher for each side (index 0 = client side, index 1 = server side)
 	Dispatchers [2]Dispatcher
+
+	// TracerProvider for the engines and clients on both sides.
+	TracerProvider trace.TracerProvider
+
+	// FabricIndex, if set, makes the sessions CASE sessions on this fabric
+	// between SecureTestNodeIDs instead of PASE sessions.
+	FabricIndex fabric.FabricIndex
+
+	// ACLChecker enforces access control on the server side (index 1).
+	ACLChecker AccessChecker
+
+	// SubscriptionStore persists subscriptions on the server side (index 1).
+	SubscriptionStore SubscriptionStore
+
+	// EventManager holds the events of the server side (index 1).
+	EventManager *EventManager
 }
 
+// SecureTestNodeIDs are the operational node IDs of the client (index 0)
+// and server (index 1) in a CASE SecureTestIMPair.
+var SecureTestNodeIDs = [2]fabric.NodeID{0x1000, 0x2000}
+
 // SecureTestIMPair provides two connected IM engines with encrypted sessions.
 type SecureTestIMPair struct {
 	exchangePair   *exchange.TestManagerPair
@@ -438,13 +490,23 @@ func NewSecureTestIMPair(config SecureTestIMPairConfig) (*SecureTestIMPair, erro
 
 	// Create secure sessions for both sides
 	// Client (0) is initiator, Server (1) is responder
+	sessionType := session.SessionTypePASE
+	var nodeIDs [2]fabric.NodeID
+	if config.FabricIndex != 0 {
+		sessionType = session.SessionTypeCASE
+		nodeIDs = SecureTestNodeIDs
+	}
+
 	clientSession, err := session.NewSecureContext(session.SecureContextConfig{
-		SessionType:    session.SessionTypePASE,
+		SessionType:    sessionType,
 		Role:           session.SessionRoleInitiator,
 		LocalSessionID: 1,
 		PeerSessionID:  2,
 		I2RKey:         testI2RKey,
 		R2IKey:         testR2IKey,
+		FabricIndex:    config.FabricIndex,
+		LocalNodeID:    nodeIDs[0],
+		PeerNodeID:     nodeIDs[1],
 		Params: session.Params{
 			IdleInterval:    500 * time.Millisecond,
 			ActiveInterval:  300 * time.Millisecond,
@@ -457,12 +519,15 @@ func NewSecureTestIMPair(config SecureTestIMPairConfig) (*SecureTestIMPair, erro
 	}
 
 	serverSession, err := session.NewSecureContext(session.SecureContextConfig{
-		SessionType:    session.SessionTypePASE,
+		SessionType:    sessionType,
 		Role:           session.SessionRoleResponder,
 		LocalSessionID: 2,
 		PeerSessionID:  1,
 		I2RKey:         testI2RKey,
 		R2IKey:         testR2IKey,
+		FabricIndex:    config.FabricIndex,
+		LocalNodeID:    nodeIDs[1],
+		PeerNodeID:     nodeIDs[0],
 		Params: session.Params{
 			IdleInterval:    500 * time.Millisecond,
 			ActiveInterval:  300 * time.Millisecond,
@@ -496,9 +561,17 @@ func NewSecureTestIMPair(config SecureTestIMPairConfig) (*SecureTestIMPair, erro
 			dispatcher = NullDispatcher{}
 		}
 
-		pair.engines[i] = NewEngine(EngineConfig{
-			Dispatcher: dispatcher,
-		})
+		engineConfig := EngineConfig{
+			Dispatcher:      dispatcher,
+			ExchangeManager: exchangePair.Manager(i),
+			TracerProvider:  config.TracerProvider,
+		}
+		if i == 1 {
+			engineConfig.ACLChecker = config.ACLChecker
+			engineConfig.SubscriptionStore = config.SubscriptionStore
+			engineConfig.EventManager = config.EventManager
+		}
+		pair.engines[i] = NewEngine(engineConfig)
 
 		// Register IM handler with exchange manager
 		adapter := &engineAdapter{engine: pair.engines[i]}
@@ -507,7 +580,9 @@ func NewSecureTestIMPair(config SecureTestIMPairConfig) (*SecureTestIMPair, erro
 		// Create IM client
 		pair.clients[i] = NewClient(ClientConfig{
 			ExchangeManager: exchangePair.Manager(i),
+			Engine:          pair.engines[i],
 			Timeout:         10 * time.Second,
+			TracerProvider:  config.TracerProvider,
 		})
 	}
 
@@ -541,6 +616,11 @@ func (p *SecureTestIMPair) PeerAddress(idx int) transport.PeerAddress {
 
 // Close releases resources.
 func (p *SecureTestIMPair) Close() {
+	for _, engine := range p.engines {
+		if engine != nil {
+			engine.Close()
+		}
+	}
 	if p.exchangePair != nil {
 		p.exchangePair.Close()
 	}
@@ -0,0 +1,81 @@
+package im
+
+import (
+	"github.com/backkem/matter/pkg/exchange"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the instrumentation scope of Interaction Model spans.
+const TracerName = "github.com/backkem/matter/pkg/im"
+
+// Span attribute keys.
+const (
+	attrAction     = attribute.Key("matter.im.action")
+	attrExchangeID = attribute.Key("matter.exchange.id")
+	attrSessionID  = attribute.Key("matter.session.id")
+	attrEndpoint   = attribute.Key("matter.endpoint.id")
+	attrCluster    = attribute.Key("matter.cluster.id")
+	attrCommand    = attribute.Key("matter.command.id")
+	attrAttribute  = attribute.Key("matter.attribute.id")
+	attrGroup      = attribute.Key("matter.group.id")
+)
+
+// newTracer returns the IM tracer from tp, or from the global provider if
+// tp is nil.
+func newTracer(tp trace.TracerProvider) trace.Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return tp.Tracer(TracerName)
+}
+
+// exchangeAttributes returns span attributes identifying an exchange.
+func exchangeAttributes(ctx *exchange.ExchangeContext) []attribute.KeyValue {
+	if ctx == nil {
+		return nil
+	}
+	return []attribute.KeyValue{
+		attrExchangeID.Int(int(ctx.ID)),
+		attrSessionID.Int(int(ctx.LocalSessionID())),
+	}
+}
+
+// commandAttributes returns span attributes identifying a command path.
+func commandAttributes(endpointID uint16, clusterID, commandID uint32) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attrEndpoint.Int(int(endpointID)),
+		attrCluster.Int64(int64(clusterID)),
+		attrCommand.Int64(int64(commandID)),
+	}
+}
+
+// groupCommandAttributes returns span attributes identifying a command
+// sent to a group.
+func groupCommandAttributes(groupID uint16, clusterID, commandID uint32) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attrGroup.Int(int(groupID)),
+		attrCluster.Int64(int64(clusterID)),
+		attrCommand.Int64(int64(commandID)),
+	}
+}
+
+// attributeAttributes returns span attributes identifying an attribute path.
+func attributeAttributes(endpointID uint16, clusterID, attributeID uint32) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attrEndpoint.Int(int(endpointID)),
+		attrCluster.Int64(int64(clusterID)),
+		attrAttribute.Int64(int64(attributeID)),
+	}
+}
+
+// endSpan records err, if any, and ends the span.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// LevelTrace is below slog.LevelDebug and carries per-message traces such
+// as acknowledgements and raw frame details.
+const LevelTrace = slog.LevelDebug - 4
+
+// LevelOff disables a subsystem.
+const LevelOff = slog.Level(1 << 10)
+
+// Levels holds the minimum level per subsystem.
+type Levels struct {
+	// Default applies to subsystems without an entry.
+	Default slog.Level
+
+	// Subsystems maps a subsystem name to its level. A name also covers
+	// scopes it prefixes followed by '-', so "transport" covers
+	// "transport-udp" and "transport-tcp"; the longest match wins.
+	Subsystems map[string]slog.Level
+}
+
+// Level returns the level for a logger scope.
+func (l Levels) Level(scope string) slog.Level {
+	if lvl, ok := l.Subsystems[scope]; ok {
+		return lvl
+	}
+	level, best := l.Default, -1
+	for name, lvl := range l.Subsystems {
+		if len(name) > best && strings.HasPrefix(scope, name+"-") {
+			level, best = lvl, len(name)
+		}
+	}
+	return level
+}
+
+// ParseLevels parses a level spec such as "exchange=debug,im=info,warn".
+// Entries are comma separated; an entry without a subsystem, or with the
+// subsystem "*", sets the default. Level names are trace, debug, info,
+// warn, error and off. The default level is info unless set.
+func ParseLevels(spec string) (Levels, error) {
+	levels := Levels{Default: slog.LevelInfo, Subsystems: make(map[string]slog.Level)}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, found := strings.Cut(entry, "=")
+		if !found {
+			name, value = "*", name
+		}
+		name = strings.TrimSpace(name)
+		lvl, err := parseLevel(strings.TrimSpace(value))
+		if err != nil {
+			return Levels{}, err
+		}
+		if name == "" || name == "*" {
+			levels.Default = lvl
+			continue
+		}
+		levels.Subsystems[name] = lvl
+	}
+	return levels, nil
+}
+
+// parseLevel parses a single level name.
+func parseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(s) {
+	case "trace":
+		return LevelTrace, nil
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	case "off", "none":
+		return LevelOff, nil
+	default:
+		return 0, fmt.Errorf("%w: %q", ErrInvalidLevel, s)
+	}
+}
@@ -0,0 +1,151 @@
+// Package logger adapts log/slog to the leveled loggers used across the
+// stack.
+//
+// Transport, exchange, secure channel, IM and the other layers log through
+// the pion logging.LoggerFactory interface, each under its own scope
+// ("transport-udp", "exchange", "securechannel", "im", ...). A Factory
+// implements that interface on top of an slog.Handler: every record
+// carries a "subsystem" attribute, and each subsystem has its own minimum
+// level parsed from a spec such as "exchange=debug,im=info".
+//
+// Sensitive values are wrapped with Redact at the call site and print as
+// Placeholder unless the Factory sets ShowSecrets.
+//
+// Example:
+//
+//	levels, _ := logger.ParseLevels("warn,exchange=debug")
+//	factory := logger.NewFactory(logger.Config{
+//	    Handler: slog.NewTextHandler(os.Stderr, nil),
+//	    Levels:  levels,
+//	})
+//	log := factory.NewLogger("exchange")
+//	log.Debugf("received %d bytes", n)
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"time"
+
+	"github.com/pion/logging"
+)
+
+// ErrInvalidLevel is returned by ParseLevels for an unknown level name.
+var ErrInvalidLevel = errors.New("logger: invalid level")
+
+// SubsystemKey is the attribute key carrying the logger scope.
+const SubsystemKey = "subsystem"
+
+// Config configures a Factory.
+type Config struct {
+	// Handler receives the records. Defaults to slog.Default().Handler().
+	// The subsystem levels decide what is logged; the handler's own
+	// Enabled method is not consulted.
+	Handler slog.Handler
+
+	// Levels holds the minimum level per subsystem. The zero value logs
+	// info and above everywhere.
+	Levels Levels
+
+	// ShowSecrets prints values wrapped with Redact. Only enable this for
+	// local debugging.
+	ShowSecrets bool
+}
+
+// Factory creates slog-backed loggers. It implements
+// logging.LoggerFactory.
+type Factory struct {
+	config Config
+}
+
+// NewFactory creates a new Factory.
+func NewFactory(config Config) *Factory {
+	if config.Handler == nil {
+		config.Handler = slog.Default().Handler()
+	}
+	return &Factory{config: config}
+}
+
+// NewLogger implements logging.LoggerFactory.
+func (f *Factory) NewLogger(scope string) logging.LeveledLogger {
+	return &Logger{
+		handler:     f.config.Handler.WithAttrs([]slog.Attr{slog.String(SubsystemKey, scope)}),
+		level:       f.config.Levels.Level(scope),
+		showSecrets: f.config.ShowSecrets,
+	}
+}
+
+// Logger is a logging.LeveledLogger writing to an slog.Handler.
+type Logger struct {
+	handler     slog.Handler
+	level       slog.Level
+	showSecrets bool
+}
+
+// Enabled reports whether messages at level are logged.
+func (l *Logger) Enabled(level slog.Level) bool {
+	return level >= l.level
+}
+
+// Trace logs a message at LevelTrace.
+func (l *Logger) Trace(msg string) { l.output(LevelTrace, msg) }
+
+// Tracef formats and logs a message at LevelTrace.
+func (l *Logger) Tracef(format string, args ...any) { l.outputf(LevelTrace, format, args) }
+
+// Debug logs a message at slog.LevelDebug.
+func (l *Logger) Debug(msg string) { l.output(slog.LevelDebug, msg) }
+
+// Debugf formats and logs a message at slog.LevelDebug.
+func (l *Logger) Debugf(format string, args ...any) { l.outputf(slog.LevelDebug, format, args) }
+
+// Info logs a message at slog.LevelInfo.
+func (l *Logger) Info(msg string) { l.output(slog.LevelInfo, msg) }
+
+// Infof formats and logs a message at slog.LevelInfo.
+func (l *Logger) Infof(format string, args ...any) { l.outputf(slog.LevelInfo, format, args) }
+
+// Warn logs a message at slog.LevelWarn.
+func (l *Logger) Warn(msg string) { l.output(slog.LevelWarn, msg) }
+
+// Warnf formats and logs a message at slog.LevelWarn.
+func (l *Logger) Warnf(format string, args ...any) { l.outputf(slog.LevelWarn, format, args) }
+
+// Error logs a message at slog.LevelError.
+func (l *Logger) Error(msg string) { l.output(slog.LevelError, msg) }
+
+// Errorf formats and logs a message at slog.LevelError.
+func (l *Logger) Errorf(format string, args ...any) { l.outputf(slog.LevelError, format, args) }
+
+// outputf formats and writes a record. It must be called directly from
+// the exported method so the caller's PC is recorded.
+func (l *Logger) outputf(level slog.Level, format string, args []any) {
+	if !l.Enabled(level) {
+		return
+	}
+	if l.showSecrets {
+		args = reveal(args)
+	}
+	l.write(level, fmt.Sprintf(format, args...))
+}
+
+// output writes a record. It must be called directly from the exported
+// method so the caller's PC is recorded.
+func (l *Logger) output(level slog.Level, msg string) {
+	if !l.Enabled(level) {
+		return
+	}
+	l.write(level, msg)
+}
+
+// write hands a record to the handler.
+func (l *Logger) write(level slog.Level, msg string) {
+	var pcs [1]uintptr
+	// Skip runtime.Callers, write, output/outputf and the exported method.
+	runtime.Callers(4, pcs[:])
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	_ = l.handler.Handle(context.Background(), r)
+}
@@ -0,0 +1,112 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseLevels(t *testing.T) {
+	levels, err := ParseLevels(" warn , exchange=debug,im=INFO,transport=trace,discovery=off")
+	if err != nil {
+		t.Fatalf("ParseLevels error = %v", err)
+	}
+
+	tests := []struct {
+		scope string
+		want  slog.Level
+	}{
+		{"exchange", slog.LevelDebug},
+		{"im", slog.LevelInfo},
+		{"im-client", slog.LevelInfo},
+		{"transport-udp", LevelTrace},
+		{"discovery", LevelOff},
+		{"securechannel", slog.LevelWarn},
+		{"imx", slog.LevelWarn},
+	}
+	for _, tt := range tests {
+		if got := levels.Level(tt.scope); got != tt.want {
+			t.Errorf("Level(%q) = %v, want %v", tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestParseLevels_Defaults(t *testing.T) {
+	levels, err := ParseLevels("")
+	if err != nil {
+		t.Fatalf("ParseLevels error = %v", err)
+	}
+	if got := levels.Level("exchange"); got != slog.LevelInfo {
+		t.Errorf("default level = %v, want info", got)
+	}
+
+	levels, _ = ParseLevels("*=error")
+	if levels.Default != slog.LevelError {
+		t.Errorf("Default = %v, want error", levels.Default)
+	}
+
+	if _, err := ParseLevels("exchange=verbose"); !errors.Is(err, ErrInvalidLevel) {
+		t.Errorf("error = %v, want ErrInvalidLevel", err)
+	}
+}
+
+func newTestFactory(buf *bytes.Buffer, spec string, showSecrets bool) *Factory {
+	levels, _ := ParseLevels(spec)
+	return NewFactory(Config{
+		Handler:     slog.NewTextHandler(buf, &slog.HandlerOptions{AddSource: true}),
+		Levels:      levels,
+		ShowSecrets: showSecrets,
+	})
+}
+
+func TestLogger_Levels(t *testing.T) {
+	var buf bytes.Buffer
+	f := newTestFactory(&buf, "warn,exchange=trace", false)
+
+	f.NewLogger("exchange").Tracef("ack %d", 7)
+	f.NewLogger("im").Info("filtered")
+	f.NewLogger("im").Errorf("failed: %v", errors.New("boom"))
+
+	out := buf.String()
+	if !strings.Contains(out, "subsystem=exchange") || !strings.Contains(out, `msg="ack 7"`) {
+		t.Errorf("missing exchange trace record: %q", out)
+	}
+	if strings.Contains(out, "filtered") {
+		t.Errorf("im info should be filtered: %q", out)
+	}
+	if !strings.Contains(out, `msg="failed: boom"`) {
+		t.Errorf("missing im error record: %q", out)
+	}
+	if !strings.Contains(out, "logger_test.go") {
+		t.Errorf("source should point at the caller: %q", out)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	key := []byte{0xde, 0xad, 0xbe, 0xef}
+
+	if got := fmt.Sprintf("key=%x", Redact(key)); got != "key="+Placeholder {
+		t.Errorf("Sprintf = %q, want redacted", got)
+	}
+
+	var buf bytes.Buffer
+	newTestFactory(&buf, "debug", false).NewLogger("pase").Debugf("key=%x passcode=%d", Redact(key), Redact(20202021))
+	if out := buf.String(); strings.Contains(out, "deadbeef") || strings.Contains(out, "20202021") {
+		t.Errorf("secret leaked: %q", out)
+	}
+
+	buf.Reset()
+	newTestFactory(&buf, "debug", true).NewLogger("pase").Debugf("key=%x", Redact(key))
+	if out := buf.String(); !strings.Contains(out, "key=deadbeef") {
+		t.Errorf("ShowSecrets should reveal the value: %q", out)
+	}
+
+	buf.Reset()
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("attr", "key", Redact(key))
+	if out := buf.String(); !strings.Contains(out, "key="+Placeholder) {
+		t.Errorf("slog attribute not redacted: %q", out)
+	}
+}
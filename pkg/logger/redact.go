@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// Placeholder replaces redacted values in log output.
+const Placeholder = "[REDACTED]"
+
+// Secret marks a value such as a key, passcode or handshake random as
+// sensitive. It prints as Placeholder with every fmt verb and as an slog
+// value, so it stays hidden with any logger. Loggers from a Factory with
+// ShowSecrets print the wrapped value instead.
+type Secret struct {
+	value any
+}
+
+// Redact marks v as sensitive.
+func Redact(v any) Secret {
+	return Secret{value: v}
+}
+
+// Format implements fmt.Formatter.
+func (Secret) Format(f fmt.State, verb rune) {
+	io.WriteString(f, Placeholder)
+}
+
+// String implements fmt.Stringer.
+func (Secret) String() string {
+	return Placeholder
+}
+
+// LogValue implements slog.LogValuer.
+func (Secret) LogValue() slog.Value {
+	return slog.StringValue(Placeholder)
+}
+
+// reveal replaces Secret arguments with their values.
+func reveal(args []any) []any {
+	var out []any
+	for i, a := range args {
+		if s, ok := a.(Secret); ok {
+			if out == nil {
+				out = append([]any(nil), args...)
+			}
+			out[i] = s.value
+		}
+	}
+	if out == nil {
+		return args
+	}
+	return out
+}
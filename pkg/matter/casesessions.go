@@ -0,0 +1,373 @@
+package matter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/backkem/matter/pkg/clock"
+	"github.com/backkem/matter/pkg/commissioning"
+	"github.com/backkem/matter/pkg/exchange"
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/message"
+	"github.com/backkem/matter/pkg/securechannel"
+	casesession "github.com/backkem/matter/pkg/securechannel/case"
+	"github.com/backkem/matter/pkg/session"
+	"github.com/backkem/matter/pkg/transport"
+)
+
+// peerRef identifies a node on one of our fabrics.
+type peerRef struct {
+	fabricIndex fabric.FabricIndex
+	nodeID      fabric.NodeID
+}
+
+// sessionSetup is an attempt to establish a session to a peer, whose
+// result is shared by everyone waiting for it.
+type sessionSetup struct {
+	done chan struct{}
+
+	sess *session.SecureContext
+	addr transport.PeerAddress
+	err  error
+}
+
+// retiringSession is a session being replaced by a new one.
+type retiringSession struct {
+	addr     transport.PeerAddress
+	replaced time.Time // Zero until the new session is established
+}
+
+// casePool keeps the CASE sessions to peer nodes. It establishes a session
+// on demand, once for all concurrent callers, trying each of the peer's
+// resolved addresses in turn and resuming the peer's previous session
+// where possible.
+//
+// A session can be retired by refresh: a new session with the peer
+// replaces it, and retired reports it for closing once drained.
+//
+// C++ Reference: CASESessionManager, OperationalSessionSetup
+type casePool struct {
+	sessions *session.Manager
+	clock    clock.Clock
+
+	// resolve returns a peer's addresses, most preferred first.
+	resolve func(ctx context.Context, peer peerRef) ([]transport.PeerAddress, error)
+
+	// dial performs a CASE handshake with a peer at an address, resuming
+	// a previous session if resumption is not nil.
+	dial func(ctx context.Context, peer peerRef, addr transport.PeerAddress, resumption *casesession.ResumptionInfo) (*session.SecureContext, error)
+
+	// failed reports an address the handshake failed on.
+	failed func(peer peerRef, addr transport.PeerAddress)
+
+	mu         sync.Mutex
+	pending    map[peerRef]*sessionSetup
+	addrs      map[peerRef]transport.PeerAddress
+	resumption map[peerRef]*casesession.ResumptionInfo
+	retiring   map[*session.SecureContext]*retiringSession
+}
+
+func newCASEPool(sessions *session.Manager) *casePool {
+	return &casePool{
+		sessions:   sessions,
+		clock:      clock.OrReal(nil),
+		pending:    make(map[peerRef]*sessionSetup),
+		addrs:      make(map[peerRef]transport.PeerAddress),
+		resumption: make(map[peerRef]*casesession.ResumptionInfo),
+		retiring:   make(map[*session.SecureContext]*retiringSession),
+	}
+}
+
+// findOrEstablish returns a session to the peer with its address: an
+// active one, or else the result of establishing one. An establishment
+// already under way is joined rather than duplicated.
+//
+// The establishment runs under base, so it continues for the other
+// waiters when ctx ends.
+func (p *casePool) findOrEstablish(ctx, base context.Context, peer peerRef) (*session.SecureContext, transport.PeerAddress, error) {
+	p.mu.Lock()
+	if sess, addr, ok := p.activeLocked(peer); ok {
+		p.mu.Unlock()
+		return sess, addr, nil
+	}
+	setup, ok := p.pending[peer]
+	if !ok {
+		setup = &sessionSetup{done: make(chan struct{})}
+		p.pending[peer] = setup
+		go p.establish(base, peer, setup)
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-setup.done:
+		return setup.sess, setup.addr, setup.err
+	case <-ctx.Done():
+		return nil, transport.PeerAddress{}, ctx.Err()
+	}
+}
+
+// activeLocked returns the peer's CASE session, if one is open at a
+// known address and not retired. Caller must hold p.mu.
+func (p *casePool) activeLocked(peer peerRef) (*session.SecureContext, transport.PeerAddress, bool) {
+	addr, ok := p.addrs[peer]
+	if !ok {
+		return nil, transport.PeerAddress{}, false
+	}
+	for _, sess := range p.sessions.FindSecureContextByPeer(peer.fabricIndex, peer.nodeID) {
+		if sess.SessionType() == session.SessionTypeCASE && p.retiring[sess] == nil {
+			return sess, addr, true
+		}
+	}
+	return nil, transport.PeerAddress{}, false
+}
+
+// establish resolves the peer and performs CASE with each of its
+// addresses until one succeeds, then notifies the waiters. If resuming
+// the previous session fails, the handshake is repeated in full.
+func (p *casePool) establish(ctx context.Context, peer peerRef, setup *sessionSetup) {
+	addrs, err := p.resolve(ctx, peer)
+
+	p.mu.Lock()
+	resumption := p.resumption[peer]
+	p.mu.Unlock()
+
+	for _, addr := range addrs {
+		var sess *session.SecureContext
+		sess, err = p.dial(ctx, peer, addr, resumption)
+		if err != nil && resumption != nil && ctx.Err() == nil {
+			// The peer may have dropped the previous session
+			resumption = nil
+			sess, err = p.dial(ctx, peer, addr, nil)
+		}
+		if err == nil {
+			setup.sess, setup.addr = sess, addr
+			break
+		}
+		p.failed(peer, addr)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if err == nil && setup.sess == nil {
+		err = commissioning.ErrCASEProtocol
+	}
+	setup.err = err
+
+	p.mu.Lock()
+	delete(p.pending, peer)
+	if setup.sess != nil {
+		p.addrs[peer] = setup.addr
+		if secret := setup.sess.SharedSecret(); len(secret) > 0 {
+			p.resumption[peer] = &casesession.ResumptionInfo{
+				ResumptionID: setup.sess.ResumptionID(),
+				SharedSecret: secret,
+				PeerNodeID:   uint64(peer.nodeID),
+				PeerCATs:     setup.sess.CaseAuthTags(),
+			}
+		}
+	}
+	p.mu.Unlock()
+
+	close(setup.done)
+}
+
+// refresh replaces a session at addr, or at the peer's last known address
+// if addr is zero, with a new one to the same peer. The old session is no
+// longer handed out; once the new one is established, retired reports it.
+// The new session takes a full handshake, so its keys do not derive from
+// the old session's.
+//
+// If establishment fails, the old session stays in use.
+func (p *casePool) refresh(ctx context.Context, old *session.SecureContext, addr transport.PeerAddress) error {
+	peer := peerRef{fabricIndex: old.FabricIndex(), nodeID: old.PeerNodeID()}
+
+	p.mu.Lock()
+	if p.retiring[old] != nil {
+		p.mu.Unlock()
+		return nil
+	}
+	if addr.Addr == nil {
+		addr = p.addrs[peer]
+	}
+	r := &retiringSession{addr: addr}
+	p.retiring[old] = r
+	delete(p.resumption, peer)
+	p.mu.Unlock()
+
+	_, _, err := p.findOrEstablish(ctx, ctx, peer)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil {
+		delete(p.retiring, old)
+		return err
+	}
+	r.replaced = p.clock.Now()
+	return nil
+}
+
+// retired returns the replaced sessions to close, with their addresses:
+// those without exchanges left, and those replaced drainTimeout ago or
+// longer. They are forgotten, as are retired sessions already removed.
+func (p *casePool) retired(drainTimeout time.Duration, exchanges func(localSessionID uint16) int) map[*session.SecureContext]transport.PeerAddress {
+	now := p.clock.Now()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	closing := make(map[*session.SecureContext]transport.PeerAddress)
+	for sess, r := range p.retiring {
+		if p.sessions.FindSecureContext(sess.LocalSessionID()) != sess {
+			delete(p.retiring, sess)
+			continue
+		}
+		if r.replaced.IsZero() {
+			continue
+		}
+		if exchanges(sess.LocalSessionID()) == 0 || now.Sub(r.replaced) >= drainTimeout {
+			closing[sess] = r.addr
+			delete(p.retiring, sess)
+		}
+	}
+	return closing
+}
+
+// removeFabric forgets the addresses and resumption state of a fabric's
+// peers.
+func (p *casePool) removeFabric(index fabric.FabricIndex) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for peer := range p.addrs {
+		if peer.fabricIndex == index {
+			delete(p.addrs, peer)
+		}
+	}
+	for peer := range p.resumption {
+		if peer.fabricIndex == index {
+			delete(p.resumption, peer)
+		}
+	}
+	for sess := range p.retiring {
+		if sess.FabricIndex() == index {
+			delete(p.retiring, sess)
+		}
+	}
+}
+
+// FindOrEstablishSession returns a CASE session to a node on one of the
+// node's fabrics, with the node's address. An open session is reused;
+// otherwise the node is resolved through DNS-SD and a session established
+// with the first of its addresses that answers, resuming the previous
+// session with the node where possible. Concurrent calls for the same
+// node share one establishment.
+//
+// It requires NodeConfig.OperationalKey. It has the signature of a
+// SessionEstablisher.
+//
+// Spec: Section 4.14.2 (CASE)
+// C++ Reference: CASESessionManager::FindOrEstablishSession
+func (n *Node) FindOrEstablishSession(
+	ctx context.Context,
+	fabricIndex fabric.FabricIndex,
+	nodeID fabric.NodeID,
+) (*session.SecureContext, transport.PeerAddress, error) {
+	n.mu.RLock()
+	running, base := n.state.IsRunning(), n.ctx
+	_, hasFabric := n.fabricTable.Get(fabricIndex)
+	n.mu.RUnlock()
+
+	if !running {
+		return nil, transport.PeerAddress{}, ErrNotStarted
+	}
+	if !hasFabric {
+		return nil, transport.PeerAddress{}, ErrFabricNotFound
+	}
+	if n.config.OperationalKey == nil {
+		return nil, transport.PeerAddress{}, ErrNoOperationalKey
+	}
+
+	return n.casePool.findOrEstablish(ctx, base, peerRef{fabricIndex: fabricIndex, nodeID: nodeID})
+}
+
+// CloseSession closes a secure session with a peer. The peer is told with
+// a CloseSession status report, sent once without waiting for its
+// acknowledgement, then the session is removed along with its exchanges
+// and the subscriptions served over it. A Device using the session
+// establishes a new one at once.
+//
+// Spec: Section 4.11.1.4 (CloseSession)
+func (n *Node) CloseSession(sess *session.SecureContext, peerAddr transport.PeerAddress) error {
+	n.mu.RLock()
+	exchangeMgr := n.exchangeMgr
+	n.mu.RUnlock()
+
+	if exchangeMgr == nil {
+		return ErrNotStarted
+	}
+
+	id := sess.LocalSessionID()
+	err := sendCloseSession(exchangeMgr, sess, peerAddr)
+
+	n.sessionMgr.RemoveSecureContext(id)
+	n.onSessionClosed(id)
+	return err
+}
+
+// sendCloseSession sends a CloseSession status report over a session,
+// once and without waiting for its acknowledgement.
+func sendCloseSession(exchangeMgr *exchange.Manager, sess *session.SecureContext, peerAddr transport.PeerAddress) error {
+	exch, err := exchangeMgr.NewExchange(sess, sess.LocalSessionID(), peerAddr, message.ProtocolSecureChannel, nil)
+	if err != nil {
+		return err
+	}
+	err = exch.SendMessage(uint8(securechannel.OpcodeStatusReport), securechannel.SendCloseSession(), false)
+	exch.Close()
+	return err
+}
+
+// initCASEPool sets up the node's CASE session pool.
+func (n *Node) initCASEPool() {
+	n.casePool = newCASEPool(n.sessionMgr)
+	n.casePool.clock = n.clock
+	n.casePool.resolve = func(ctx context.Context, peer peerRef) ([]transport.PeerAddress, error) {
+		return n.ResolveNode(ctx, peer.fabricIndex, peer.nodeID)
+	}
+	n.casePool.failed = func(peer peerRef, addr transport.PeerAddress) {
+		n.NodeAddressFailed(peer.fabricIndex, peer.nodeID, addr)
+	}
+	n.casePool.dial = n.dialCASE
+}
+
+// dialCASE performs a CASE handshake as initiator with a node at an
+// address.
+func (n *Node) dialCASE(
+	ctx context.Context,
+	peer peerRef,
+	addr transport.PeerAddress,
+	resumption *casesession.ResumptionInfo,
+) (*session.SecureContext, error) {
+	n.mu.RLock()
+	info, ok := n.fabricTable.Get(peer.fabricIndex)
+	exchangeMgr, scMgr := n.exchangeMgr, n.scMgr
+	n.mu.RUnlock()
+
+	if !ok {
+		return nil, ErrFabricNotFound
+	}
+	if exchangeMgr == nil || scMgr == nil {
+		return nil, ErrNotStarted
+	}
+
+	key, err := n.config.OperationalKey(peer.fabricIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	client := commissioning.NewCASEClient(commissioning.CASEClientConfig{
+		ExchangeManager: exchangeMgr,
+		SecureChannel:   scMgr,
+		SessionManager:  n.sessionMgr,
+		LoggerFactory:   n.config.LoggerFactory,
+	})
+	return client.Establish(ctx, addr, info, key, peer.nodeID, resumption)
+}
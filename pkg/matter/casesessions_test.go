@@ -0,0 +1,204 @@
+package matter
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	casesession "github.com/backkem/matter/pkg/securechannel/case"
+	"github.com/backkem/matter/pkg/session"
+	"github.com/backkem/matter/pkg/transport"
+)
+
+// testCASEPool is a casePool whose handshakes succeed on the addresses in
+// reachable, recording the resumption info each dial is given.
+type testCASEPool struct {
+	*casePool
+
+	mu          sync.Mutex
+	reachable   map[string]bool
+	dials       []string
+	resumptions []*casesession.ResumptionInfo
+	failedAddrs []string
+	release     chan struct{}
+}
+
+func newTestCASEPool(t *testing.T, addrs ...string) *testCASEPool {
+	t.Helper()
+	sessions := session.NewManager(session.ManagerConfig{})
+	p := &testCASEPool{casePool: newCASEPool(sessions), reachable: make(map[string]bool)}
+
+	var peerAddrs []transport.PeerAddress
+	for _, a := range addrs {
+		peerAddrs = append(peerAddrs, transport.NewUDPPeerAddress(&net.UDPAddr{IP: net.ParseIP(a), Port: 5540}))
+	}
+	p.resolve = func(ctx context.Context, peer peerRef) ([]transport.PeerAddress, error) {
+		return peerAddrs, nil
+	}
+	p.failed = func(peer peerRef, addr transport.PeerAddress) {
+		p.mu.Lock()
+		p.failedAddrs = append(p.failedAddrs, addr.Addr.String())
+		p.mu.Unlock()
+	}
+	p.dial = func(ctx context.Context, peer peerRef, addr transport.PeerAddress, resumption *casesession.ResumptionInfo) (*session.SecureContext, error) {
+		p.mu.Lock()
+		p.dials = append(p.dials, addr.Addr.String())
+		p.resumptions = append(p.resumptions, resumption)
+		reachable, release := p.reachable[addr.Addr.String()], p.release
+		p.mu.Unlock()
+
+		if release != nil {
+			<-release
+		}
+		if !reachable {
+			return nil, errors.New("unreachable")
+		}
+
+		id, err := sessions.AllocateSessionID()
+		if err != nil {
+			return nil, err
+		}
+		key := make([]byte, session.SessionKeySize)
+		sess, err := session.NewSecureContext(session.SecureContextConfig{
+			SessionType:    session.SessionTypeCASE,
+			Role:           session.SessionRoleInitiator,
+			LocalSessionID: id,
+			PeerSessionID:  1,
+			I2RKey:         key,
+			R2IKey:         key,
+			SharedSecret:   []byte("shared secret"),
+			FabricIndex:    peer.fabricIndex,
+			PeerNodeID:     peer.nodeID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return sess, sessions.AddSecureContext(sess)
+	}
+	return p
+}
+
+func TestCASEPool_Deduplicates(t *testing.T) {
+	p := newTestCASEPool(t, "fd00::1")
+	p.reachable["[fd00::1]:5540"] = true
+	p.release = make(chan struct{})
+	peer := peerRef{fabricIndex: 1, nodeID: 0x22}
+
+	var wg sync.WaitGroup
+	results := make([]*session.SecureContext, 3)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sess, _, err := p.findOrEstablish(context.Background(), context.Background(), peer)
+			if err != nil {
+				t.Errorf("findOrEstablish() error = %v", err)
+				return
+			}
+			results[i] = sess
+		}(i)
+	}
+
+	// Let all callers join the attempt before it completes
+	time.Sleep(50 * time.Millisecond)
+	close(p.release)
+	wg.Wait()
+
+	if len(p.dials) != 1 {
+		t.Errorf("dials = %d, want 1", len(p.dials))
+	}
+	for i, sess := range results {
+		if sess != results[0] {
+			t.Errorf("caller %d got another session", i)
+		}
+	}
+
+	// An open session is reused
+	sess, addr, err := p.findOrEstablish(context.Background(), context.Background(), peer)
+	if err != nil || sess != results[0] {
+		t.Fatalf("findOrEstablish() = %v, %v, want open session", sess, err)
+	}
+	if addr.Addr.String() != "[fd00::1]:5540" {
+		t.Errorf("addr = %s, want [fd00::1]:5540", addr.Addr)
+	}
+	if len(p.dials) != 1 {
+		t.Errorf("dials = %d after reuse, want 1", len(p.dials))
+	}
+}
+
+func TestCASEPool_RetriesAddresses(t *testing.T) {
+	p := newTestCASEPool(t, "2001:db8::1", "fd00::1")
+	p.reachable["[fd00::1]:5540"] = true
+	peer := peerRef{fabricIndex: 1, nodeID: 0x22}
+
+	_, addr, err := p.findOrEstablish(context.Background(), context.Background(), peer)
+	if err != nil {
+		t.Fatalf("findOrEstablish() error = %v", err)
+	}
+	if addr.Addr.String() != "[fd00::1]:5540" {
+		t.Errorf("addr = %s, want the reachable address", addr.Addr)
+	}
+	if len(p.failedAddrs) != 1 || p.failedAddrs[0] != "[2001:db8::1]:5540" {
+		t.Errorf("failed addresses = %v, want [[2001:db8::1]:5540]", p.failedAddrs)
+	}
+
+	// No address answers
+	p.reachable = map[string]bool{}
+	if _, _, err := p.findOrEstablish(context.Background(), context.Background(), peerRef{fabricIndex: 1, nodeID: 0x23}); err == nil {
+		t.Error("findOrEstablish() succeeded without a reachable address")
+	}
+}
+
+func TestCASEPool_Resumption(t *testing.T) {
+	p := newTestCASEPool(t, "fd00::1")
+	p.reachable["[fd00::1]:5540"] = true
+	peer := peerRef{fabricIndex: 1, nodeID: 0x22}
+
+	sess, _, err := p.findOrEstablish(context.Background(), context.Background(), peer)
+	if err != nil {
+		t.Fatalf("findOrEstablish() error = %v", err)
+	}
+	if p.resumptions[0] != nil {
+		t.Error("first session resumed without a previous one")
+	}
+
+	// The next session resumes the closed one
+	p.sessions.RemoveSecureContext(sess.LocalSessionID())
+	if _, _, err := p.findOrEstablish(context.Background(), context.Background(), peer); err != nil {
+		t.Fatalf("findOrEstablish() error = %v", err)
+	}
+	if len(p.resumptions) != 2 || p.resumptions[1] == nil {
+		t.Fatalf("resumptions = %v, want the second dial to resume", p.resumptions)
+	}
+	if got := p.resumptions[1].PeerNodeID; got != 0x22 {
+		t.Errorf("resumption PeerNodeID = 0x%X, want 0x22", got)
+	}
+
+	// Forgetting the fabric drops the resumption state
+	p.removeFabric(1)
+	p.casePool.mu.Lock()
+	_, hasResumption := p.resumption[peer]
+	p.casePool.mu.Unlock()
+	if hasResumption {
+		t.Error("resumption state kept after removeFabric")
+	}
+}
+
+func TestNodeFindOrEstablishSession_NotStarted(t *testing.T) {
+	node, err := NewNode(NodeConfig{
+		VendorID:      0xFFF1,
+		ProductID:     0x8001,
+		Discriminator: 3840,
+		Passcode:      20202021,
+		Storage:       NewMemoryStorage(),
+	})
+	if err != nil {
+		t.Fatalf("NewNode failed: %v", err)
+	}
+	if _, _, err := node.FindOrEstablishSession(context.Background(), 1, 0x22); !errors.Is(err, ErrNotStarted) {
+		t.Errorf("FindOrEstablishSession() before Start error = %v, want ErrNotStarted", err)
+	}
+}
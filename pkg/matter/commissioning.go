@@ -2,10 +2,13 @@ package matter
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	"github.com/backkem/matter/pkg/clusters/admincommissioning"
 	"github.com/backkem/matter/pkg/commissioning"
 	"github.com/backkem/matter/pkg/discovery"
+	"github.com/backkem/matter/pkg/fabric"
 	"github.com/backkem/matter/pkg/session"
 )
 
@@ -13,6 +16,7 @@ import (
 // The window closes automatically after the timeout or when CloseCommissioningWindow is called.
 //
 // For uncommissioned devices, a commissioning window is opened automatically on Start().
+// Opening a window resets the count of failed PASE attempts.
 func (n *Node) OpenCommissioningWindow(timeout time.Duration) error {
 	n.mu.Lock()
 	defer n.mu.Unlock()
@@ -20,39 +24,98 @@ func (n *Node) OpenCommissioningWindow(timeout time.Duration) error {
 	if !n.state.IsRunning() {
 		return ErrNotStarted
 	}
+	if n.commWindow != nil {
+		return ErrCommissioningWindowOpen
+	}
 
-	return n.openCommissioningWindowLocked(timeout)
+	n.savePASEAttemptsLocked(0)
+	return n.openCommissioningWindowLocked(timeout, n.paseInfo, n.config.Discriminator, discovery.CommissioningModeBasic)
 }
 
-// openCommissioningWindowLocked opens a commissioning window.
-// Caller must hold n.mu.
-func (n *Node) openCommissioningWindowLocked(timeout time.Duration) error {
+// OpenEnhancedCommissioningWindow opens a commissioning window that accepts
+// PASE with PAKE parameters supplied by an administrator, rather than the
+// node's own passcode (Enhanced Commissioning Method). It is used by the
+// Administrator Commissioning cluster so a second commissioner can add the
+// node to its fabric. The node only receives the verifier; the passcode
+// stays with whoever derived it.
+//
+// Returns ErrInvalidPAKEParameters if the verifier, salt or iteration count
+// is invalid.
+func (n *Node) OpenEnhancedCommissioningWindow(timeout time.Duration, params admincommissioning.PAKEParameters) error {
+	if params.Verifier == nil {
+		return ErrInvalidPAKEParameters
+	}
+	if err := validatePAKE(params.Verifier, params.Salt, params.Iterations); err != nil {
+		return err
+	}
+	if params.Discriminator > 4095 {
+		return ErrInvalidDiscriminator
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if !n.state.IsRunning() {
+		return ErrNotStarted
+	}
+	if n.commWindow != nil {
+		return ErrCommissioningWindowOpen
+	}
+
+	n.savePASEAttemptsLocked(0)
+	pake := &paseInfo{
+		verifier:   params.Verifier,
+		salt:       params.Salt,
+		iterations: params.Iterations,
+	}
+	return n.openCommissioningWindowLocked(timeout, pake, params.Discriminator, discovery.CommissioningModeEnhanced)
+}
+
+// openBasicCommissioningWindowLocked opens the commissioning window of an
+// uncommissioned node, unless failed PASE attempts used up the last one;
+// OpenCommissioningWindow then opens a new one. Caller must hold n.mu.
+func (n *Node) openBasicCommissioningWindowLocked() {
+	if n.paseAttempts >= commissioning.DefaultMaxPASEAttempts {
+		if n.log != nil {
+			n.log.Warnf("not opening commissioning window: %d failed PASE attempts", n.paseAttempts)
+		}
+		return
+	}
+	n.openCommissioningWindowLocked(3*time.Minute, n.paseInfo, n.config.Discriminator, discovery.CommissioningModeBasic)
+}
+
+// openCommissioningWindowLocked opens a commissioning window accepting PASE
+// with the given parameters, advertised with the discriminator and mode.
+// The window continues the node's count of failed PASE attempts, so it
+// refuses PASE at first if the last attempt failed. Caller must hold n.mu.
+func (n *Node) openCommissioningWindowLocked(timeout time.Duration, pake *paseInfo, discriminator uint16, mode discovery.CommissioningMode) error {
 	if n.commWindow != nil {
 		return ErrCommissioningWindowOpen
 	}
 
 	// Create commissioning window
-	var err error
-	n.commWindow, err = commissioning.NewCommissioningWindow(commissioning.CommissioningWindowConfig{
-		Timeout:       timeout,
-		Discriminator: n.config.Discriminator,
-		VendorID:      uint16(n.config.VendorID),
-		ProductID:     n.config.ProductID,
-		DeviceName:    n.config.DeviceName,
-		Verifier:      n.paseInfo.verifier,
-		Salt:          n.paseInfo.salt,
-		Iterations:    n.paseInfo.iterations,
+	var cw *commissioning.CommissioningWindow
+	cw, err := commissioning.NewCommissioningWindow(commissioning.CommissioningWindowConfig{
+		Timeout:            timeout,
+		Discriminator:      discriminator,
+		VendorID:           uint16(n.config.VendorID),
+		ProductID:          n.config.ProductID,
+		DeviceName:         n.config.DeviceName,
+		Mode:               mode,
+		FailedPASEAttempts: n.paseAttempts,
+		Verifier:           pake.verifier,
+		Salt:               pake.salt,
+		Iterations:         pake.iterations,
+		Clock:              n.clock,
 		OnStateChanged: func(state commissioning.DeviceCommissioningState) {
 			n.onCommissioningStateChanged(state)
 		},
 		OnPASEEstablished: func(sess *session.SecureContext) {
 			// PASE session established
 		},
-		OnCommissioningComplete: func() {
-			n.onCommissioningComplete()
-		},
 		OnWindowClosed: func(reason error) {
-			n.onCommissioningWindowClosed(reason)
+			// The window may close under n.mu, e.g. from Stop()
+			go n.onCommissioningWindowClosed(cw, reason)
 		},
 	})
 	if err != nil {
@@ -61,15 +124,18 @@ func (n *Node) openCommissioningWindowLocked(timeout time.Duration) error {
 
 	// Configure PASE responder in secure channel manager
 	if n.scMgr != nil {
-		if err := n.scMgr.SetPASEResponder(n.paseInfo.verifier, n.paseInfo.salt, n.paseInfo.iterations); err != nil {
-			n.commWindow.Close()
-			n.commWindow = nil
+		if err := n.scMgr.SetPASEResponder(pake.verifier, pake.salt, pake.iterations); err != nil {
+			cw.Close()
 			return err
 		}
 	}
+	n.commWindow, n.commPAKE = cw, pake
+	if delay := cw.RetryDelay(); delay > 0 {
+		n.withholdPASELocked(cw, delay)
+	}
 
 	// Start advertising as commissionable
-	n.advertiseCommissionable()
+	n.advertiseCommissionable(discriminator, mode)
 
 	// Update state
 	if n.state == NodeStateUncommissioned {
@@ -78,12 +144,14 @@ func (n *Node) openCommissioningWindowLocked(timeout time.Duration) error {
 			n.config.OnStateChanged(n.state)
 		}
 	}
+	if n.config.OnCommissioningWindowOpened != nil {
+		n.config.OnCommissioningWindowOpened(mode)
+	}
 
 	// Start the commissioning window in background
-	// Capture commWindow to avoid race if Stop() is called
-	cw := n.commWindow
+	base := n.ctx
 	go func() {
-		ctx, cancel := context.WithCancel(n.ctx)
+		ctx, cancel := context.WithCancel(base)
 		defer cancel()
 		cw.Open(ctx)
 	}()
@@ -100,8 +168,29 @@ func (n *Node) CloseCommissioningWindow() error {
 		return ErrCommissioningWindowClosed
 	}
 
-	n.commWindow.Close()
+	n.closeCommissioningWindowLocked(nil)
+	return nil
+}
+
+// closeCommissioningWindowLocked closes the open commissioning window for
+// the given reason. Caller must hold n.mu.
+func (n *Node) closeCommissioningWindowLocked(reason error) {
+	cw := n.commWindow
 	n.commWindow = nil
+	// OnWindowClosed finds the window already detached
+	cw.Close()
+	n.commissioningWindowClosedLocked(reason)
+}
+
+// commissioningWindowClosedLocked stops accepting PASE and advertising
+// after the commissioning window closed, updates the state and reports the
+// closure. Caller must hold n.mu.
+func (n *Node) commissioningWindowClosedLocked(reason error) {
+	n.commPAKE = nil
+	if n.paseRetry != nil {
+		n.paseRetry.Stop()
+		n.paseRetry = nil
+	}
 
 	// Clear PASE responder from secure channel manager
 	if n.scMgr != nil {
@@ -113,6 +202,11 @@ func (n *Node) CloseCommissioningWindow() error {
 		n.discoveryMgr.StopAdvertising(discovery.ServiceTypeCommissionable)
 	}
 
+	// Report closure of an administrator-opened window
+	if n.adminCommissioning != nil {
+		n.adminCommissioning.WindowClosed()
+	}
+
 	// Update state
 	if n.state == NodeStateCommissioningOpen {
 		if n.fabricTable.Count() > 0 {
@@ -125,11 +219,16 @@ func (n *Node) CloseCommissioningWindow() error {
 		}
 	}
 
-	return nil
+	if errors.Is(reason, commissioning.ErrCommissioningTimeout) && n.config.OnCommissioningWindowExpired != nil {
+		n.config.OnCommissioningWindowExpired()
+	}
+	if n.config.OnCommissioningWindowClosed != nil {
+		n.config.OnCommissioningWindowClosed(reason)
+	}
 }
 
 // advertiseCommissionable starts DNS-SD advertising as commissionable.
-func (n *Node) advertiseCommissionable() {
+func (n *Node) advertiseCommissionable(discriminator uint16, mode discovery.CommissioningMode) {
 	if n.discoveryMgr == nil {
 		if n.log != nil {
 			n.log.Warn("Discovery manager is nil, cannot advertise")
@@ -138,13 +237,15 @@ func (n *Node) advertiseCommissionable() {
 	}
 
 	txt := discovery.CommissionableTXT{
-		Discriminator:     n.config.Discriminator,
+		Discriminator:     discriminator,
 		VendorID:          n.config.VendorID,
 		ProductID:         n.config.ProductID,
 		DeviceName:        n.config.DeviceName,
-		CommissioningMode: discovery.CommissioningModeBasic,
+		CommissioningMode: mode,
 	}
 
+	// Replace any advertisement of a previous window
+	n.discoveryMgr.StopAdvertising(discovery.ServiceTypeCommissionable)
 	if err := n.discoveryMgr.StartCommissionable(txt); err != nil && n.log != nil {
 		n.log.Errorf("Failed to start commissionable advertising: %v", err)
 	}
@@ -158,77 +259,107 @@ func (n *Node) onCommissioningStateChanged(state commissioning.DeviceCommissioni
 	}
 }
 
-// onCommissioningComplete handles successful commissioning.
-func (n *Node) onCommissioningComplete() {
+// onCommissioningComplete handles successful commissioning: a
+// commissioner sent CommissioningComplete on the fabric it added.
+func (n *Node) onCommissioningComplete(fabricIndex fabric.FabricIndex) {
 	n.mu.Lock()
-	defer n.mu.Unlock()
-
-	// Close commissioning window
 	if n.commWindow != nil {
-		n.commWindow = nil
+		n.closeCommissioningWindowLocked(nil)
 	}
-
-	// Clear PASE responder from secure channel manager
-	if n.scMgr != nil {
-		n.scMgr.ClearPASEResponder()
+	if n.state == NodeStateUncommissioned && n.fabricTable.Count() > 0 {
+		n.state = NodeStateCommissioned
+		if n.config.OnStateChanged != nil {
+			n.config.OnStateChanged(n.state)
+		}
 	}
+	n.mu.Unlock()
 
-	// Stop commissionable advertising
-	if n.discoveryMgr != nil {
-		n.discoveryMgr.StopAdvertising(discovery.ServiceTypeCommissionable)
+	if n.config.OnCommissioningComplete != nil {
+		n.config.OnCommissioningComplete(fabricIndex)
 	}
+}
 
-	// Start operational advertising
-	n.advertiseOperational()
+// onCommissioningWindowClosed handles closure of a window by itself: on
+// timeout, after too many failed PASE attempts or on fail-safe expiry.
+func (n *Node) onCommissioningWindowClosed(cw *commissioning.CommissioningWindow, reason error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
 
-	// Update state
-	n.state = NodeStateCommissioned
-	if n.config.OnStateChanged != nil {
-		n.config.OnStateChanged(n.state)
+	// Already cleaned up (e.g., by CloseCommissioningWindow or Stop())
+	if n.commWindow != cw {
+		return
 	}
+	n.commWindow = nil
+	n.commissioningWindowClosedLocked(reason)
+}
 
-	// Notify callback with fabric index
-	// TODO: Get fabric index from commissioning flow
-	if n.config.OnCommissioningComplete != nil {
-		n.config.OnCommissioningComplete(1) // Placeholder fabric index
-	}
+// onPASEAttemptFailed counts a failed PASE attempt against the open
+// commissioning window, and refuses PASE for the window's retry delay,
+// which grows with each failure. The window closes after too many failed
+// attempts. The count is persisted, so that restarting the node does not
+// reset it.
+//
+// It is called by the secure channel manager, possibly under its lock.
+func (n *Node) onPASEAttemptFailed(err error) {
+	go n.commissioningAttemptFailed()
 }
 
-// onCommissioningWindowClosed handles window closure.
-func (n *Node) onCommissioningWindowClosed(reason error) {
-	// Use TryLock to avoid deadlock if called during Stop()
-	if !n.mu.TryLock() {
-		// Already being shut down
+// commissioningAttemptFailed implements onPASEAttemptFailed.
+func (n *Node) commissioningAttemptFailed() {
+	n.mu.RLock()
+	cw := n.commWindow
+	n.mu.RUnlock()
+	if cw == nil {
 		return
 	}
-	defer n.mu.Unlock()
 
-	// Already cleaned up (e.g., by Stop())
-	if n.commWindow == nil {
-		return
+	delay := cw.OnPASEFailed()
+	attempts := cw.FailedPASEAttempts()
+	if n.log != nil {
+		n.log.Warnf("PASE attempt failed (%d so far)", attempts)
 	}
-	n.commWindow = nil
 
-	// Clear PASE responder from secure channel manager
-	if n.scMgr != nil {
-		n.scMgr.ClearPASEResponder()
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.savePASEAttemptsLocked(attempts)
+	if delay > 0 && n.commWindow == cw {
+		n.withholdPASELocked(cw, delay)
 	}
+}
 
-	// Stop commissionable advertising
-	if n.discoveryMgr != nil {
-		n.discoveryMgr.StopAdvertising(discovery.ServiceTypeCommissionable)
+// withholdPASELocked refuses PASE for the open window cw until delay
+// elapses. Caller must hold n.mu.
+func (n *Node) withholdPASELocked(cw *commissioning.CommissioningWindow, delay time.Duration) {
+	if n.scMgr == nil {
+		return
 	}
-
-	// Update state
-	if n.state == NodeStateCommissioningOpen {
-		if n.fabricTable.Count() > 0 {
-			n.state = NodeStateCommissioned
-		} else {
-			n.state = NodeStateUncommissioned
-		}
-		if n.config.OnStateChanged != nil {
-			n.config.OnStateChanged(n.state)
+	n.scMgr.ClearPASEResponder()
+	if n.paseRetry != nil {
+		n.paseRetry.Stop()
+	}
+	pake := n.commPAKE
+	n.paseRetry = n.clock.AfterFunc(delay, func() {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		if n.commWindow == cw && n.scMgr != nil {
+			n.scMgr.SetPASEResponder(pake.verifier, pake.salt, pake.iterations)
 		}
+	})
+}
+
+// savePASEAttemptsLocked records the number of failed PASE attempts.
+// Caller must hold n.mu.
+func (n *Node) savePASEAttemptsLocked(count int) {
+	if count == n.paseAttempts {
+		return
+	}
+	n.paseAttempts = count
+	attempts, ok := n.config.Storage.(PASEAttemptStorage)
+	if !ok {
+		return
+	}
+	if err := attempts.SavePASEAttempts(count); err != nil && n.log != nil {
+		n.log.Warnf("failed to save PASE attempts: %v", err)
 	}
 }
 
@@ -239,6 +370,20 @@ func (n *Node) IsCommissioningWindowOpen() bool {
 	return n.commWindow != nil
 }
 
+// CommissioningWindowMode returns the commissioning mode of the open
+// window: CommissioningModeBasic for a window using the node's passcode,
+// CommissioningModeEnhanced for one opened with an administrator's PAKE
+// parameters. Returns CommissioningModeDisabled if no window is open.
+func (n *Node) CommissioningWindowMode() discovery.CommissioningMode {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	if n.commWindow == nil {
+		return discovery.CommissioningModeDisabled
+	}
+	return n.commWindow.Mode()
+}
+
 // CommissioningWindowTimeout returns the remaining time in the commissioning window.
 // Returns 0 if no window is open.
 func (n *Node) CommissioningWindowTimeout() time.Duration {
@@ -248,8 +393,5 @@ func (n *Node) CommissioningWindowTimeout() time.Duration {
 	if n.commWindow == nil {
 		return 0
 	}
-
-	// The commissioning window tracks this internally
-	// For now, return 0 - would need to expose this from CommissioningWindow
-	return 0
+	return n.commWindow.Remaining()
 }
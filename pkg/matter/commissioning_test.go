@@ -0,0 +1,584 @@
+package matter
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/backkem/matter/pkg/clock"
+	"github.com/backkem/matter/pkg/clusters/admincommissioning"
+	"github.com/backkem/matter/pkg/clusters/operationalcredentials"
+	"github.com/backkem/matter/pkg/commissioning"
+	"github.com/backkem/matter/pkg/commissioning/payload"
+	"github.com/backkem/matter/pkg/discovery"
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/securechannel"
+	"github.com/backkem/matter/pkg/securechannel/pase"
+	"github.com/backkem/matter/pkg/session"
+	"github.com/backkem/matter/pkg/transport"
+)
+
+func TestNodeCommissioningWindow(t *testing.T) {
+	network := transport.NewPipeNetwork()
+	defer network.Close()
+
+	clk := clock.NewFakeClock(time.Time{})
+	opened := make(chan discovery.CommissioningMode, 2)
+	closed := make(chan error, 2)
+	expired := make(chan struct{}, 1)
+	node, err := NewNode(NodeConfig{
+		VendorID:                     0xFFF1,
+		ProductID:                    0x8001,
+		Discriminator:                3840,
+		Passcode:                     20202021,
+		Storage:                      NewMemoryStorage(),
+		TransportFactory:             network.NewFactory(),
+		OnCommissioningWindowOpened:  func(mode discovery.CommissioningMode) { opened <- mode },
+		OnCommissioningWindowClosed:  func(reason error) { closed <- reason },
+		OnCommissioningWindowExpired: func() { expired <- struct{}{} },
+		Clock:                        clk,
+	})
+	if err != nil {
+		t.Fatalf("NewNode failed: %v", err)
+	}
+	if err := node.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer node.Stop(context.Background())
+
+	// An uncommissioned node opens a basic window
+	if mode := <-opened; mode != discovery.CommissioningModeBasic {
+		t.Errorf("opened mode = %v, want basic", mode)
+	}
+	if mode := node.CommissioningWindowMode(); mode != discovery.CommissioningModeBasic {
+		t.Errorf("CommissioningWindowMode() = %v, want basic", mode)
+	}
+	deadline := time.Now().Add(time.Second)
+	for node.CommissioningWindowTimeout() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("CommissioningWindowTimeout() = 0 for an open window")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if remaining := node.CommissioningWindowTimeout(); remaining != 3*time.Minute {
+		t.Errorf("CommissioningWindowTimeout() = %v, want 3m", remaining)
+	}
+
+	// A failed attempt withholds PASE for a while
+	node.commissioningAttemptFailed()
+	if node.scMgr.HasPASEResponder() {
+		t.Error("PASE accepted right after a failed attempt")
+	}
+	clk.Advance(commissioning.DefaultPASERetryDelay - time.Millisecond)
+	if node.scMgr.HasPASEResponder() {
+		t.Error("PASE accepted before the retry delay")
+	}
+	clk.Advance(time.Millisecond)
+	if !node.scMgr.HasPASEResponder() {
+		t.Fatal("PASE not accepted again after the retry delay")
+	}
+
+	// Too many failed attempts close the window
+	for i := 1; i < commissioning.DefaultMaxPASEAttempts; i++ {
+		node.commissioningAttemptFailed()
+	}
+	select {
+	case reason := <-closed:
+		if reason != commissioning.ErrPASEAttemptsExceeded {
+			t.Errorf("close reason = %v, want %v", reason, commissioning.ErrPASEAttemptsExceeded)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("window not closed after too many failed attempts")
+	}
+	if node.IsCommissioningWindowOpen() || node.scMgr.HasPASEResponder() {
+		t.Error("window still open after too many failed attempts")
+	}
+	if node.CommissioningWindowMode() != discovery.CommissioningModeDisabled {
+		t.Errorf("CommissioningWindowMode() = %v after close, want disabled", node.CommissioningWindowMode())
+	}
+
+	// An enhanced window expires
+	err = node.OpenEnhancedCommissioningWindow(3*time.Minute, admincommissioning.PAKEParameters{
+		Verifier:      node.paseInfo.verifier,
+		Discriminator: 1234,
+		Iterations:    node.paseInfo.iterations,
+		Salt:          node.paseInfo.salt,
+	})
+	if err != nil {
+		t.Fatalf("OpenEnhancedCommissioningWindow failed: %v", err)
+	}
+	if mode := <-opened; mode != discovery.CommissioningModeEnhanced {
+		t.Errorf("opened mode = %v, want enhanced", mode)
+	}
+	// The window arms its timer in its own goroutine: keep advancing
+	deadline = time.Now().Add(time.Second)
+	for len(expired) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("OnCommissioningWindowExpired not called")
+		}
+		clk.Advance(3 * time.Minute)
+		time.Sleep(time.Millisecond)
+	}
+	<-expired
+	if reason := <-closed; reason != commissioning.ErrCommissioningTimeout {
+		t.Errorf("close reason = %v, want %v", reason, commissioning.ErrCommissioningTimeout)
+	}
+	if node.State() != NodeStateUncommissioned {
+		t.Errorf("State() = %v after expiry, want uncommissioned", node.State())
+	}
+
+	// An explicit close has no reason
+	if err := node.OpenCommissioningWindow(time.Minute); err != nil {
+		t.Fatalf("OpenCommissioningWindow failed: %v", err)
+	}
+	<-opened
+	if err := node.CloseCommissioningWindow(); err != nil {
+		t.Fatalf("CloseCommissioningWindow failed: %v", err)
+	}
+	if reason := <-closed; reason != nil {
+		t.Errorf("close reason = %v, want nil", reason)
+	}
+}
+
+func TestNodePASEAttemptsPersisted(t *testing.T) {
+	network := transport.NewPipeNetwork()
+	defer network.Close()
+
+	newNode := func(storage Storage) *Node {
+		t.Helper()
+		node, err := NewNode(NodeConfig{
+			VendorID:         0xFFF1,
+			ProductID:        0x8001,
+			Discriminator:    3840,
+			Passcode:         20202021,
+			Storage:          storage,
+			TransportFactory: network.NewFactory(),
+		})
+		if err != nil {
+			t.Fatalf("NewNode failed: %v", err)
+		}
+		if err := node.Start(context.Background()); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		return node
+	}
+
+	// Failed attempts before a restart still delay PASE
+	storage := NewMemoryStorage()
+	storage.SavePASEAttempts(3)
+	node := newNode(storage)
+	if !node.IsCommissioningWindowOpen() {
+		t.Error("commissioning window not opened on Start")
+	}
+	if node.scMgr.HasPASEResponder() {
+		t.Error("PASE accepted right after a restart with failed attempts")
+	}
+	deadline := time.Now().Add(time.Second)
+	for node.CommissioningWindowTimeout() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("commissioning window not open")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	node.commissioningAttemptFailed()
+	if n, _ := storage.LoadPASEAttempts(); n != 4 {
+		t.Errorf("stored PASE attempts = %d, want 4", n)
+	}
+	node.Stop(context.Background())
+
+	// Used up attempts need a new window
+	storage.SavePASEAttempts(commissioning.DefaultMaxPASEAttempts)
+	node = newNode(storage)
+	defer node.Stop(context.Background())
+	if node.IsCommissioningWindowOpen() {
+		t.Error("commissioning window opened with failed attempts used up")
+	}
+	if err := node.OpenCommissioningWindow(time.Minute); err != nil {
+		t.Fatalf("OpenCommissioningWindow failed: %v", err)
+	}
+	if !node.scMgr.HasPASEResponder() {
+		t.Error("PASE refused in a new window")
+	}
+	if n, _ := storage.LoadPASEAttempts(); n != 0 {
+		t.Errorf("stored PASE attempts = %d after a new window, want 0", n)
+	}
+	node.Stop(context.Background())
+
+	// Storage that does not keep the attempts leaves them in memory
+	node = newNode(struct{ Storage }{NewMemoryStorage()})
+	defer node.Stop(context.Background())
+	deadline = time.Now().Add(time.Second)
+	for node.CommissioningWindowTimeout() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("commissioning window not open")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	node.commissioningAttemptFailed()
+	if node.paseAttempts != 1 {
+		t.Errorf("PASE attempts = %d, want 1", node.paseAttempts)
+	}
+}
+
+func TestNodeVerifierOnly(t *testing.T) {
+	network := transport.NewPipeNetwork()
+	defer network.Close()
+
+	// The SDK's default verifier for passcode 20202021
+	verifier, err := pase.ParseVerifier("uWFwqugDNGiEck/po7KHwwMwwqZgN10XuyBajPGuyzUEV/iree4lOrao5GuwnlQ65CJzbeUB49s31EH+NEkg0JVI5MGCQGMMT/SRPFNRODm3wH/MBiehuFc6FJ/NH6Rmzw==")
+	if err != nil {
+		t.Fatalf("ParseVerifier failed: %v", err)
+	}
+	config := NodeConfig{
+		VendorID:         0xFFF1,
+		ProductID:        0x8001,
+		Discriminator:    3840,
+		Storage:          NewMemoryStorage(),
+		TransportFactory: network.NewFactory(),
+		PASEVerifier:     verifier,
+		PASESalt:         []byte("SPAKE2P Key Salt"),
+		PASEIterations:   1000,
+	}
+
+	bad := config
+	bad.PASESalt = []byte("short")
+	if _, err := NewNode(bad); !errors.Is(err, ErrInvalidPAKEParameters) {
+		t.Errorf("NewNode() with a short salt error = %v, want ErrInvalidPAKEParameters", err)
+	}
+
+	node, err := NewNode(config)
+	if err != nil {
+		t.Fatalf("NewNode failed: %v", err)
+	}
+	if node.OnboardingPayload() != "" || node.ManualPairingCode() != "" {
+		t.Error("verifier-only node has an onboarding payload")
+	}
+	if err := node.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer node.Stop(context.Background())
+
+	// A commissioner knowing the passcode pairs with the node
+	established := make(chan *session.SecureContext, 1)
+	commissioner := securechannel.NewManager(securechannel.ManagerConfig{
+		SessionManager: session.NewManager(session.ManagerConfig{}),
+		Callbacks: securechannel.Callbacks{
+			OnSessionEstablished: func(ctx *session.SecureContext) { established <- ctx },
+		},
+	})
+	const exchangeID = 1
+	payload, err := commissioner.StartPASE(exchangeID, 20202021)
+	if err != nil {
+		t.Fatalf("StartPASE failed: %v", err)
+	}
+	msg := &securechannel.Message{Opcode: securechannel.OpcodePBKDFParamRequest, Payload: payload}
+	for mgr := node.scMgr; msg != nil; {
+		msg, err = mgr.Route(exchangeID, msg)
+		if err != nil {
+			t.Fatalf("PASE failed: %v", err)
+		}
+		if mgr == node.scMgr {
+			mgr = commissioner
+		} else {
+			mgr = node.scMgr
+		}
+	}
+	select {
+	case <-established:
+	case <-time.After(time.Second):
+		t.Fatal("PASE session not established with the configured verifier")
+	}
+
+	// Invalid administrator parameters are refused
+	err = node.OpenEnhancedCommissioningWindow(time.Minute, admincommissioning.PAKEParameters{
+		Verifier:   &pase.Verifier{W0: make([]byte, 32), L: verifier.L},
+		Salt:       config.PASESalt,
+		Iterations: 1000,
+	})
+	if !errors.Is(err, ErrInvalidPAKEParameters) {
+		t.Errorf("OpenEnhancedCommissioningWindow() error = %v, want ErrInvalidPAKEParameters", err)
+	}
+}
+
+func TestNodeFailSafe(t *testing.T) {
+	factory, _ := transport.NewPipeFactoryPair()
+	clk := clock.NewFakeClock(time.Time{})
+	var expired []error
+	var removed, completed []fabric.FabricIndex
+	node, err := NewNode(NodeConfig{
+		VendorID:                0xFFF1,
+		ProductID:               0x8001,
+		Discriminator:           3840,
+		Passcode:                20202021,
+		Storage:                 NewMemoryStorage(),
+		TransportFactory:        factory,
+		Clock:                   clk,
+		OnFailSafeExpired:       func(reason error) { expired = append(expired, reason) },
+		OnFabricRemoved:         func(fi fabric.FabricIndex) { removed = append(removed, fi) },
+		OnCommissioningComplete: func(fi fabric.FabricIndex) { completed = append(completed, fi) },
+	})
+	if err != nil {
+		t.Fatalf("NewNode failed: %v", err)
+	}
+	ctx := context.Background()
+	if err := node.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer node.Stop(ctx)
+
+	fsm := failSafeManager{node}
+	if err := node.TerminateCommissioning(); err != ErrFailSafeNotArmed {
+		t.Errorf("TerminateCommissioning() unarmed error = %v, want %v", err, ErrFailSafeNotArmed)
+	}
+
+	// The fail-safe expires: the fabric added under it is removed
+	if err := fsm.Arm(0, 60); err != nil {
+		t.Fatalf("Arm failed: %v", err)
+	}
+	if err := fsm.Arm(0, 60); err != ErrFailSafeArmed {
+		t.Errorf("Arm() armed error = %v, want %v", err, ErrFailSafeArmed)
+	}
+	index, err := node.AddFabric(&fabric.FabricInfo{FabricID: 0x100, NodeID: 0x1})
+	if err != nil {
+		t.Fatalf("AddFabric failed: %v", err)
+	}
+	if got := fsm.ArmedFabricIndex(); got != index {
+		t.Errorf("ArmedFabricIndex() = %d, want %d", got, index)
+	}
+	node.generalCommissioning.SetBreadcrumb(5)
+	clk.Advance(FailSafeExpiryLength)
+	if node.IsFailSafeArmed() || node.IsCommissioned() {
+		t.Errorf("after expiry: armed = %v, commissioned = %v", node.IsFailSafeArmed(), node.IsCommissioned())
+	}
+	if len(expired) != 1 || expired[0] != commissioning.ErrFailSafeExpired {
+		t.Errorf("OnFailSafeExpired reasons = %v", expired)
+	}
+	if len(removed) != 1 || removed[0] != index {
+		t.Errorf("OnFabricRemoved = %v, want [%d]", removed, index)
+	}
+	if got := node.generalCommissioning.GetBreadcrumb(); got != 0 {
+		t.Errorf("Breadcrumb = %d after expiry, want 0", got)
+	}
+	if !node.IsCommissioningWindowOpen() {
+		t.Error("commissioning window closed after expiry")
+	}
+
+	// Extensions do not go past the cumulative limit
+	fsm.Arm(0, uint16(MaxCumulativeFailSafe/time.Second))
+	clk.Advance(MaxCumulativeFailSafe - time.Minute)
+	if !node.IsFailSafeArmed() {
+		t.Fatal("fail-safe expired before the extensions ran out")
+	}
+	fsm.ExtendArm(0, 120)
+	clk.Advance(time.Minute)
+	if node.IsFailSafeArmed() {
+		t.Error("fail-safe armed past MaxCumulativeFailSafe")
+	}
+
+	// The user aborts commissioning
+	fsm.Arm(0, 60)
+	if err := node.TerminateCommissioning(); err != nil {
+		t.Fatalf("TerminateCommissioning failed: %v", err)
+	}
+	if n := len(expired); n != 3 || expired[n-1] != ErrCommissioningTerminated {
+		t.Errorf("OnFailSafeExpired reasons = %v", expired)
+	}
+
+	// Commissioning completes: the fabric stays
+	fsm.Arm(0, 60)
+	index, err = node.AddFabric(&fabric.FabricInfo{FabricID: 0x200, NodeID: 0x1})
+	if err != nil {
+		t.Fatalf("AddFabric failed: %v", err)
+	}
+	if err := fsm.Complete(index); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	clk.Advance(MaxCumulativeFailSafe)
+	if !node.IsCommissioned() || node.State() != NodeStateCommissioned {
+		t.Errorf("after Complete: commissioned = %v, state = %v", node.IsCommissioned(), node.State())
+	}
+	if len(completed) != 1 || completed[0] != index {
+		t.Errorf("OnCommissioningComplete = %v, want [%d]", completed, index)
+	}
+	if node.IsCommissioningWindowOpen() {
+		t.Error("commissioning window open after Complete")
+	}
+}
+
+func TestNodeCommissionBatch(t *testing.T) {
+	network := transport.NewPipeNetwork()
+	defer network.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	ca, err := commissioning.NewCertificateAuthority(commissioning.CAConfig{})
+	if err != nil {
+		t.Fatalf("NewCertificateAuthority failed: %v", err)
+	}
+
+	// The controller joins the CA's fabric out of band
+	keystore, err := fabric.NewKeystore(fabric.KeystoreConfig{})
+	if err != nil {
+		t.Fatalf("NewKeystore failed: %v", err)
+	}
+	controller, err := NewNode(NodeConfig{
+		VendorID:            0xFFF1,
+		ProductID:           0x8001,
+		Discriminator:       3839,
+		Passcode:            20202021,
+		Storage:             NewMemoryStorage(),
+		TransportFactory:    network.NewFactory(),
+		OperationalKeystore: keystore,
+	})
+	if err != nil {
+		t.Fatalf("NewNode failed: %v", err)
+	}
+	handle, _, err := keystore.GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	operationalKey, err := keystore.Signer(handle)
+	if err != nil {
+		t.Fatalf("Signer failed: %v", err)
+	}
+	info, err := ca.IssueFabricInfo(1, operationalKey)
+	if err != nil {
+		t.Fatalf("IssueFabricInfo failed: %v", err)
+	}
+	info.KeyHandle = handle
+	if _, err := controller.AddFabric(info); err != nil {
+		t.Fatalf("AddFabric failed: %v", err)
+	}
+	if err := controller.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer controller.Stop(context.Background())
+
+	// Uncommissioned devices open their commissioning window on start
+	const devices = 4
+	var payloads []*payload.SetupPayload
+	var addrs []transport.PeerAddress
+	var nodes []*Node
+	for i := range devices {
+		keystore, err := fabric.NewKeystore(fabric.KeystoreConfig{})
+		if err != nil {
+			t.Fatalf("NewKeystore failed: %v", err)
+		}
+		link := network.NewFactory()
+		node, err := NewNode(NodeConfig{
+			VendorID:            0xFFF1,
+			ProductID:           0x8001,
+			Discriminator:       uint16(3840 + i),
+			Passcode:            20202021,
+			Storage:             NewMemoryStorage(),
+			TransportFactory:    link,
+			OperationalKeystore: keystore,
+			Attestation:         testAttestationCredentials(t),
+		})
+		if err != nil {
+			t.Fatalf("NewNode %d failed: %v", i, err)
+		}
+		if err := node.Start(ctx); err != nil {
+			t.Fatalf("Start %d failed: %v", i, err)
+		}
+		defer node.Stop(context.Background())
+
+		nodes = append(nodes, node)
+		addrs = append(addrs, transport.NewUDPPeerAddress(link.LocalAddr()))
+		payloads = append(payloads, &payload.SetupPayload{
+			Discriminator: payload.NewLongDiscriminator(uint16(3840 + i)),
+			Passcode:      20202021,
+		})
+	}
+
+	results := commissioning.CommissionBatch(ctx, commissioning.BatchConfig{
+		Commissioner: commissioning.CommissionerConfig{
+			SecureChannel:   controller.SecureChannelManager(),
+			SessionManager:  controller.SessionManager(),
+			ExchangeManager: controller.ExchangeManager(),
+			FabricInfo:      info,
+			OperationalKey:  operationalKey,
+			CA:              ca,
+		},
+		Addresses:   addrs,
+		Concurrency: devices,
+	}, payloads)
+
+	seen := make(map[fabric.NodeID]int)
+	for i, r := range results {
+		if r.Err != nil {
+			t.Fatalf("device %d: %v", i, r.Err)
+		}
+		if r.NodeID == info.NodeID {
+			t.Errorf("device %d got the controller's node ID %v", i, r.NodeID)
+		}
+		if j, ok := seen[r.NodeID]; ok {
+			t.Errorf("devices %d and %d both got node ID %v", j, i, r.NodeID)
+		}
+		seen[r.NodeID] = i
+
+		// The device joined the CA's fabric under the node ID it was given
+		fabrics := nodes[i].Fabrics()
+		if len(fabrics) != 1 {
+			t.Fatalf("device %d has %d fabrics, want 1", i, len(fabrics))
+		}
+		if f := fabrics[0]; f.FabricID != ca.FabricID() || f.NodeID != r.NodeID {
+			t.Errorf("device %d joined fabric %v as %v, want %v as %v", i, f.FabricID, f.NodeID, ca.FabricID(), r.NodeID)
+		}
+		if nodes[i].IsFailSafeArmed() {
+			t.Errorf("device %d fail-safe still armed", i)
+		}
+
+		// Commissioning completed over CASE with the new credentials
+		if !hasCASESession(controller, r.NodeID, info.NodeID) {
+			t.Errorf("controller has no CASE session to device %d as %v", i, r.NodeID)
+		}
+		if !hasCASESession(nodes[i], info.NodeID, r.NodeID) {
+			t.Errorf("device %d has no CASE session from the controller", i)
+		}
+	}
+}
+
+// hasCASESession reports whether a node holds a CASE session as local to
+// peer.
+func hasCASESession(n *Node, peer, local fabric.NodeID) bool {
+	found := false
+	n.SessionManager().ForEachSecureSession(func(sess *session.SecureContext) bool {
+		found = sess.SessionType() == session.SessionTypeCASE && sess.PeerNodeID() == peer && sess.LocalNodeID() == local
+		return !found
+	})
+	return found
+}
+
+// testAttestationCredentials returns a self-signed DAC and its key. The
+// commissioner's default verifier accepts any chain.
+func testAttestationCredentials(t *testing.T) *operationalcredentials.AttestationCredentials {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Test DAC"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	dac, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate failed: %v", err)
+	}
+	return &operationalcredentials.AttestationCredentials{
+		DAC:                      dac,
+		PAI:                      dac,
+		CertificationDeclaration: []byte{0x30, 0x00},
+		Key:                      key,
+	}
+}
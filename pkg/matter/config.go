@@ -1,12 +1,29 @@
 package matter
 
 import (
+	gocrypto "crypto"
+	"fmt"
+	"log/slog"
+	"net"
 	"time"
 
+	"github.com/backkem/matter/pkg/acl"
+	"github.com/backkem/matter/pkg/clock"
+	"github.com/backkem/matter/pkg/clusters/accesscontrol"
+	"github.com/backkem/matter/pkg/clusters/diagnosticlogs"
+	"github.com/backkem/matter/pkg/clusters/generaldiagnostics"
+	"github.com/backkem/matter/pkg/clusters/operationalcredentials"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/discovery"
 	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/logger"
+	"github.com/backkem/matter/pkg/metrics"
+	"github.com/backkem/matter/pkg/netmonitor"
+	"github.com/backkem/matter/pkg/securechannel/pase"
 	"github.com/backkem/matter/pkg/session"
 	"github.com/backkem/matter/pkg/transport"
 	"github.com/pion/logging"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // DefaultPort is the default Matter port.
@@ -19,20 +36,45 @@ type NodeConfig struct {
 	ProductID uint16          // Product ID (vendor-assigned)
 
 	// Device Information - Optional
-	DeviceName       string // Human-readable name (max 32 chars)
-	SerialNumber     string // Serial number
+	DeviceName       string // Human-readable name (truncated to 32 chars)
+	SerialNumber     string // Serial number (max 32 chars)
 	HardwareVersion  uint16 // Hardware version
 	SoftwareVersion  uint32 // Software version
-	SoftwareVersionString string // Software version string (e.g., "1.0.0")
+	SoftwareVersionString string // Software version string (e.g., "1.0.0", max 64 chars)
 
 	// Network
-	Port     int  // UDP/TCP port (default: 5540)
+	Port     int  // UDP/TCP port (1-65535, default: 5540)
 	IPv6Only bool // Disable IPv4 (default: false)
 
+	// Interface binds the node's sockets to a network interface, e.g. the
+	// one facing the Matter network on a hub with several. ReusePort lets
+	// other stacks on the host, like an mDNS responder or a second
+	// controller, bind the node's port. SelectSource picks the source
+	// address and interface of each UDP packet by destination. See
+	// transport.ManagerConfig.
+	Interface    *net.Interface
+	ReusePort    bool
+	SelectSource transport.SourceSelector
+
 	// Commissioning
 	Discriminator uint16 // 12-bit discriminator for pairing (0-4095)
 	Passcode      uint32 // Setup passcode (1-99999998, excluding invalid codes)
 
+	// Verifier-only Commissioning - Optional
+	// PASEVerifier, PASESalt and PASEIterations configure PASE with a
+	// SPAKE2+ verifier provided from outside, e.g. provisioned at
+	// manufacturing or by an ecosystem using dynamic passcodes, so that
+	// the node never holds its passcode (see pase.ParseVerifier, or
+	// pase.ParseVerifierRecord for all three in one blob). Passcode
+	// may then be 0, in which case the node has no onboarding payload of
+	// its own.
+	PASEVerifier   *pase.Verifier
+	PASESalt       []byte // PBKDF salt (16-32 bytes) the verifier was derived with
+	PASEIterations uint32 // PBKDF iterations (1000-100000) the verifier was derived with
+
+	// Fabrics
+	SupportedFabrics uint8 // Max fabrics the node joins (5-254, default: 5)
+
 	// Storage - Required
 	Storage Storage // Persistence interface
 
@@ -48,40 +90,308 @@ type NodeConfig struct {
 	OnCommissioningStart  func()
 	OnCommissioningComplete func(fabricIndex fabric.FabricIndex)
 
+	// Commissioning Window Callbacks - Optional
+	// Called with the node's lock held; they must not call back into the
+	// Node. OnCommissioningWindowOpened receives the advertised mode
+	// (basic or enhanced). OnCommissioningWindowClosed is called for every
+	// closure with its reason: nil when closed explicitly or by a
+	// commissioner's PASE session, commissioning.ErrCommissioningTimeout
+	// when the window expired (after OnCommissioningWindowExpired), and
+	// commissioning.ErrPASEAttemptsExceeded after too many failed PASE
+	// attempts.
+	OnCommissioningWindowOpened  func(mode discovery.CommissioningMode)
+	OnCommissioningWindowClosed  func(reason error)
+	OnCommissioningWindowExpired func()
+
+	// OnFailSafeExpired is called after commissioning ended without
+	// CommissioningComplete: the fail-safe expired
+	// (commissioning.ErrFailSafeExpired), or commissioning was aborted
+	// (ErrCommissioningTerminated). The node has removed the fabrics added
+	// under the fail-safe; the application reverts the network
+	// configuration changed under it. Called without the node's lock held.
+	OnFailSafeExpired func(reason error)
+
+	// Fabric Callbacks - Optional
+	// Called after the node joins, leaves or updates a fabric. They run
+	// without the node's lock held and may call back into the Node.
+	OnFabricAdded   func(fabricIndex fabric.FabricIndex)
+	OnFabricRemoved func(fabricIndex fabric.FabricIndex)
+	OnFabricUpdated func(fabricIndex fabric.FabricIndex)
+
 	// Logging - Optional
+	// Logger receives stack logs through its handler, tagged with a
+	// "subsystem" attribute. Ignored if LoggerFactory is set.
+	Logger *slog.Logger
+
+	// LogLevels sets per-subsystem levels for Logger, e.g.
+	// "warn,exchange=debug,im=info" (see logger.ParseLevels).
+	// Defaults to info for all subsystems.
+	LogLevels string
+
 	// LoggerFactory is the factory for creating loggers.
-	// If nil, logging is disabled.
+	// If nil and Logger is nil, logging is disabled.
 	LoggerFactory logging.LoggerFactory
 
+	// Metrics - Optional
+	// Metrics receives session, message, MRP and Interaction Model
+	// counters (see package metrics). If nil, metrics are disabled.
+	Metrics metrics.Collector
+
+	// Tracing - Optional
+	// TracerProvider creates OpenTelemetry spans for Interaction Model
+	// transactions. If nil, the global provider is used.
+	TracerProvider trace.TracerProvider
+
+	// Access Restrictions - Optional
+	// RestrictionReviewer makes the node a managed device: it reviews
+	// requests to lift the restrictions set with AccessControl(). If nil,
+	// access restrictions are not supported.
+	RestrictionReviewer accesscontrol.Reviewer
+
+	// Access Auditing - Optional
+	// OnAccessDecision is called with the decision of every access control
+	// check: subject, target, required privilege, matched ACL entry and
+	// result. It is called on the request path and must not block; the
+	// RecordDenied method of an acl.DecisionLog keeps the recent denials.
+	// If nil, decisions are not traced.
+	OnAccessDecision acl.DecisionTracer
+
+	// Diagnostic Logs - Optional
+	// DiagnosticLogs supplies the logs that administrators retrieve with
+	// the root endpoint's Diagnostic Logs cluster, e.g. the Bytes of an
+	// acl.DecisionLog for end user support. If nil, the root endpoint has
+	// no Diagnostic Logs cluster.
+	DiagnosticLogs diagnosticlogs.Provider
+
+	// Test Event Triggers - Optional
+	// TestEventTriggerEnableKey (16 bytes) enables the TestEventTrigger
+	// command of the root endpoint's General Diagnostics cluster, with
+	// which a test harness holding the key simulates events, such as
+	// faults. Triggers go to the delegates registered per cluster with
+	// Node.TestEventTriggers, then to TestEventTriggers. For certification
+	// testing only: production devices leave both unset. If both are nil,
+	// the root endpoint has no General Diagnostics cluster.
+	TestEventTriggers         generaldiagnostics.TestEventTriggerHandler
+	TestEventTriggerEnableKey []byte
+
+	// CASE Initiation - Optional
+	// OperationalKey returns the node's operational key on a fabric, with
+	// which FindOrEstablishSession initiates CASE. A *crypto.P256KeyPair
+	// holds the key in memory; any P-256 crypto.Signer, e.g. one backed by
+	// a secure element or a TPM, keeps it out of the process. If nil, the
+	// keys of the fabrics in OperationalKeystore are used.
+	OperationalKey func(fabricIndex fabric.FabricIndex) (gocrypto.Signer, error)
+
+	// Operational Keystore - Optional
+	// OperationalKeystore holds the operational keys that the fabrics
+	// reference by FabricInfo.KeyHandle; removing a fabric deletes its key.
+	// A fabric.Keystore persists the keys to Storage (MemoryStorage
+	// implements fabric.KeyStorage), sealed with its EncryptionKey.
+	// OperationalKey defaults to the keys of the fabrics.
+	// If nil, a fabric.Keystore over Storage is used, which keeps the keys
+	// in memory only if Storage does not implement fabric.KeyStorage.
+	OperationalKeystore fabric.OperationalKeystore
+
+	// Device Attestation - Optional
+	// Attestation holds the DAC, PAI, Certification Declaration and DAC
+	// key the root endpoint's Operational Credentials cluster attests the
+	// node with. A commissioner installs the node's operational
+	// credentials after attesting it, for a key of the OperationalKeystore
+	// that it signs a CSR for. If nil, commissioners can only add the node to a
+	// fabric out of band, with AddFabric.
+	Attestation *operationalcredentials.AttestationCredentials
+
+	// Group Messaging - Optional
+	// DisableGroupLoopback stops the node from executing the group
+	// messages it sends to groups it is a member of. By default they are
+	// executed once, as if received, and the copies its multicast socket
+	// loops back are dropped either way.
+	DisableGroupLoopback bool
+
+	// Session Refresh - Optional
+	// The CASE sessions the node initiates are replaced before their
+	// message counters run out: with SessionCounterMargin counter values
+	// left, or once they are SessionLifetime old. A new session with the
+	// peer takes over, and the old one is closed once its exchanges
+	// complete, or after SessionDrainTimeout. SessionCounterMargin and
+	// SessionDrainTimeout default to DefaultSessionCounterMargin and
+	// DefaultSessionDrainTimeout if zero; if SessionLifetime is zero,
+	// sessions are only replaced by counter. Requires OperationalKey.
+	SessionCounterMargin uint32
+	SessionLifetime      time.Duration
+	SessionDrainTimeout  time.Duration
+
+	// Subscription Resumption - Optional
+	// EstablishSession opens a CASE session to a subscriber, so the node
+	// resumes its persisted subscriptions after a restart. If nil,
+	// FindOrEstablishSession is used when OperationalKey is set; otherwise
+	// persisted subscriptions are kept but not resumed.
+	EstablishSession SessionEstablisher
+
+	// Network Monitoring - Optional
+	// NetworkMonitor reports changes of the host's interface addresses,
+	// e.g. on DHCP renewal or an interface going up or down. The node then
+	// announces its DNS-SD services again, so that peers learn its new
+	// addresses, and drops its cached resolutions of peers, which were
+	// ordered by the old ones. OnNetworkChanged is called after, without
+	// the node's lock held. If nil, a netmonitor.Monitor of Interface, or of
+	// all interfaces, is used, unless TransportFactory is set: virtual
+	// networks do not use the host's addresses.
+	NetworkMonitor   netmonitor.Watcher
+	OnNetworkChanged func(change netmonitor.Change)
+
+	// Capture - Optional
+	// Tap observes every frame sent and received, e.g. a pcapng.Writer.
+	Tap transport.Tap
+
+	// Time - Optional
+	// Clock runs the node's timers: MRP retransmissions, handshake
+	// timeouts, the commissioning window and fail-safe, PASE backoff and
+	// subscription intervals. If nil, the real clock is used; tests pass
+	// a clock.FakeClock.
+	Clock clock.Clock
+
+	// Conformance - Optional
+	// Conformance selects what Start does when an endpoint does not meet
+	// the requirements of its device types: log a warning (default), fail
+	// with a *datamodel.ConformanceError, or skip the check.
+	Conformance ConformanceMode
+
+	// DeviceLibrary holds the device type requirements checked at Start.
+	// If nil, datamodel.DefaultDeviceLibrary() is used.
+	DeviceLibrary datamodel.DeviceLibrary
+
 	// Advanced - Internal use / Testing
-	TransportFactory transport.Factory // For virtual network testing
+	// TransportFactory replaces the host's sockets, e.g. with a virtual
+	// network, or a transport.ThreadFactory over an OpenThread stack. A
+	// factory without TCP leaves the node on UDP, and one whose conns
+	// implement transport.SizeLimitedConn shrinks its messages. DNS-SD is
+	// not started; Thread nodes advertise through their SRP client.
+	TransportFactory transport.Factory
 }
 
-// Validate checks the configuration for errors.
+// ConformanceMode selects how Start handles endpoint compositions that do
+// not conform to their device types.
+type ConformanceMode int
+
+const (
+	// ConformanceWarn logs each issue and starts the node.
+	ConformanceWarn ConformanceMode = iota
+
+	// ConformanceStrict fails Start.
+	ConformanceStrict
+
+	// ConformanceOff skips the check.
+	ConformanceOff
+)
+
+// Limits of the Basic Information strings filled from NodeConfig.
+const (
+	maxDeviceNameLength            = 32
+	maxSerialNumberLength          = 32
+	maxSoftwareVersionStringLength = 64
+)
+
+// Validate checks the configuration for errors. NewNode calls it, but it
+// can be called earlier, e.g. after loading the configuration from a file,
+// to report a bad value before anything is created.
+//
+// Each error wraps the sentinel of the invalid field, e.g.
+// ErrInvalidDiscriminator, and describes the value. Zero values that
+// select a default are valid.
 func (c *NodeConfig) Validate() error {
 	if c.Storage == nil {
 		return ErrStorageRequired
 	}
 
-	if c.VendorID == 0 {
-		return ErrInvalidVendorID
+	if !c.VendorID.IsValid() {
+		return fmt.Errorf("%w: 0x%04X is not 0x0001-0x%04X", ErrInvalidVendorID, uint16(c.VendorID), uint16(fabric.VendorIDTestVendor4))
 	}
 
 	if c.ProductID == 0 {
-		return ErrInvalidProductID
+		return fmt.Errorf("%w: 0x0000 is reserved", ErrInvalidProductID)
 	}
 
 	if c.Discriminator > 4095 {
-		return ErrInvalidDiscriminator
+		return fmt.Errorf("%w: %d does not fit in 12 bits", ErrInvalidDiscriminator, c.Discriminator)
+	}
+
+	if c.PASEVerifier != nil {
+		if err := validatePAKE(c.PASEVerifier, c.PASESalt, c.PASEIterations); err != nil {
+			return err
+		}
+	}
+	if (c.PASEVerifier == nil || c.Passcode != 0) && !IsValidPasscode(c.Passcode) {
+		if c.Passcode < 1 || c.Passcode > 99999998 {
+			return fmt.Errorf("%w: %d is not 1-99999998", ErrInvalidPasscode, c.Passcode)
+		}
+		return fmt.Errorf("%w: %08d is too easy to guess", ErrInvalidPasscode, c.Passcode)
+	}
+
+	if c.Port < 0 || c.Port > 65535 {
+		return fmt.Errorf("%w: %d is not 0-65535", ErrInvalidPort, c.Port)
+	}
+
+	if c.SupportedFabrics != 0 &&
+		(c.SupportedFabrics < fabric.MinSupportedFabrics || c.SupportedFabrics > fabric.MaxSupportedFabrics) {
+		return fmt.Errorf("%w: SupportedFabrics %d is not %d-%d",
+			ErrInvalidConfig, c.SupportedFabrics, fabric.MinSupportedFabrics, fabric.MaxSupportedFabrics)
+	}
+
+	for _, s := range []struct {
+		field string
+		value string
+		max   int
+	}{
+		{"SerialNumber", c.SerialNumber, maxSerialNumberLength},
+		{"SoftwareVersionString", c.SoftwareVersionString, maxSoftwareVersionStringLength},
+	} {
+		if len(s.value) > s.max {
+			return fmt.Errorf("%w: %s is %d bytes, longer than %d", ErrInvalidConfig, s.field, len(s.value), s.max)
+		}
+	}
+
+	for _, d := range []struct {
+		field string
+		value time.Duration
+	}{
+		{"IdleRetransTimeout", c.IdleRetransTimeout},
+		{"ActiveRetransTimeout", c.ActiveRetransTimeout},
+		{"ActiveThreshold", c.ActiveThreshold},
+		{"SessionLifetime", c.SessionLifetime},
+		{"SessionDrainTimeout", c.SessionDrainTimeout},
+	} {
+		if d.value < 0 {
+			return fmt.Errorf("%w: %s is negative (%v)", ErrInvalidConfig, d.field, d.value)
+		}
+	}
+
+	if c.TestEventTriggerEnableKey != nil && len(c.TestEventTriggerEnableKey) != generaldiagnostics.EnableKeySize {
+		return fmt.Errorf("%w: TestEventTriggerEnableKey is %d bytes, not %d",
+			ErrInvalidConfig, len(c.TestEventTriggerEnableKey), generaldiagnostics.EnableKeySize)
 	}
 
-	if !IsValidPasscode(c.Passcode) {
-		return ErrInvalidPasscode
+	if _, err := logger.ParseLevels(c.LogLevels); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidLogLevels, err)
 	}
 
 	return nil
 }
 
+// validatePAKE checks PASE parameters provided without a passcode.
+func validatePAKE(verifier *pase.Verifier, salt []byte, iterations uint32) error {
+	if err := verifier.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPAKEParameters, err)
+	}
+	if len(salt) < pase.PBKDFMinSaltLength || len(salt) > pase.PBKDFMaxSaltLength {
+		return fmt.Errorf("%w: %v", ErrInvalidPAKEParameters, pase.ErrInvalidSalt)
+	}
+	if iterations < pase.PBKDFMinIterations || iterations > pase.PBKDFMaxIterations {
+		return fmt.Errorf("%w: %v", ErrInvalidPAKEParameters, pase.ErrInvalidIterations)
+	}
+	return nil
+}
+
 // applyDefaults fills in default values for unset fields.
 func (c *NodeConfig) applyDefaults() {
 	if c.Port == 0 {
@@ -100,9 +410,32 @@ func (c *NodeConfig) applyDefaults() {
 		c.ActiveThreshold = 4 * time.Second
 	}
 
+	if c.SessionCounterMargin == 0 {
+		c.SessionCounterMargin = DefaultSessionCounterMargin
+	}
+
+	if c.SessionDrainTimeout == 0 {
+		c.SessionDrainTimeout = DefaultSessionDrainTimeout
+	}
+
+	if c.LoggerFactory == nil && c.Logger != nil {
+		levels, _ := logger.ParseLevels(c.LogLevels)
+		c.LoggerFactory = logger.NewFactory(logger.Config{
+			Handler: c.Logger.Handler(),
+			Levels:  levels,
+		})
+	}
+
+	if c.OperationalKeystore == nil {
+		keyStorage, _ := c.Storage.(fabric.KeyStorage)
+		if keystore, err := fabric.NewKeystore(fabric.KeystoreConfig{Storage: keyStorage}); err == nil {
+			c.OperationalKeystore = keystore
+		}
+	}
+
 	// Truncate device name to 32 chars per spec
-	if len(c.DeviceName) > 32 {
-		c.DeviceName = c.DeviceName[:32]
+	if len(c.DeviceName) > maxDeviceNameLength {
+		c.DeviceName = c.DeviceName[:maxDeviceNameLength]
 	}
 }
 
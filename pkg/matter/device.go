@@ -0,0 +1,319 @@
+package matter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/backkem/matter/pkg/clusters/basic"
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/im"
+	imsg "github.com/backkem/matter/pkg/im/message"
+	"github.com/pion/logging"
+)
+
+// DefaultKeepAliveInterval is the default interval between liveness
+// probes of a Device.
+const DefaultKeepAliveInterval = 30 * time.Second
+
+// DefaultProbeTimeout is the default time a Device has to answer a
+// liveness probe.
+const DefaultProbeTimeout = 10 * time.Second
+
+// DeviceConfig configures a Device.
+type DeviceConfig struct {
+	// FabricIndex and NodeID identify the device on one of the node's
+	// fabrics.
+	FabricIndex fabric.FabricIndex
+	NodeID      fabric.NodeID
+
+	// KeepAliveInterval is the interval between liveness probes.
+	// Defaults to DefaultKeepAliveInterval if zero.
+	KeepAliveInterval time.Duration
+
+	// ProbeTimeout bounds each probe. Defaults to DefaultProbeTimeout if
+	// zero.
+	ProbeTimeout time.Duration
+
+	// OnConnectionLost is called when a probe fails after the device was
+	// reachable.
+	OnConnectionLost func()
+
+	// OnReconnected is called when a probe succeeds after the connection
+	// was lost.
+	OnReconnected func()
+
+	// SubscriptionMinInterval and SubscriptionMaxInterval bound the
+	// reports of the device's subscriptions, in whole seconds: at most
+	// one per MinInterval, and at least one per MaxInterval, which the
+	// device may lengthen. SubscriptionMaxInterval defaults to
+	// DefaultSubscriptionMaxInterval if zero.
+	SubscriptionMinInterval time.Duration
+	SubscriptionMaxInterval time.Duration
+
+	// ResubscribeBackoff is the delay before subscribing again after a
+	// subscription was lost. It doubles with each failed attempt, up to
+	// MaxResubscribeBackoff. They default to DefaultResubscribeBackoff
+	// and DefaultMaxResubscribeBackoff if zero.
+	ResubscribeBackoff    time.Duration
+	MaxResubscribeBackoff time.Duration
+}
+
+// Device watches the reachability of a remote node, e.g. a device a
+// controller commissioned. It probes the device periodically by reading
+// its Basic Information DataModelRevision over a CASE session from
+// Node.FindOrEstablishSession, so a probe also re-establishes a lost
+// session, re-resolving the device's address if needed.
+//
+// A failed probe closes the session it used, so that the next probe
+// establishes a new one rather than retrying a session the device may
+// have dropped. When the device closes the session itself with
+// CloseSession, or Node.CloseSession closes it, the device is probed at
+// once: the new session resumes the closed one where possible, and the
+// device stays reachable.
+//
+// Subscribe keeps subscriptions to the device's attributes, subscribing
+// again when they are lost.
+//
+// C++ Reference: OperationalSessionSetup, app::ReadClient liveness
+type Device struct {
+	config DeviceConfig
+	probe  func(ctx context.Context) error
+	// subscribe makes a subscription to the device.
+	subscribe func(ctx context.Context, req *imsg.SubscribeRequestMessage, onReport func(*imsg.ReportDataMessage)) (*im.ClientSubscription, error)
+	// base bounds the device's subscriptions.
+	base context.Context
+	log  logging.LeveledLogger
+
+	mu        sync.Mutex
+	reachable bool
+	lost      bool
+	// reached is closed once the device is reachable, and replaced when
+	// the connection is lost.
+	reached chan struct{}
+	// sessionID is the local ID of the session of the last successful
+	// probe.
+	sessionID uint16
+	// subscriptions holds the subscriptions kept to the device.
+	subscriptions map[*DeviceSubscription]struct{}
+
+	// wake requests a probe before the next KeepAliveInterval.
+	wake chan struct{}
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// Device starts watching the reachability of a remote node. The first
+// probe runs at once. The node must be started and have an operational
+// key (see NodeConfig.OperationalKey); the Device must be closed.
+func (n *Node) Device(config DeviceConfig) (*Device, error) {
+	n.mu.RLock()
+	running, base := n.state.IsRunning(), n.ctx
+	_, hasFabric := n.fabricTable.Get(config.FabricIndex)
+	n.mu.RUnlock()
+
+	if !running {
+		return nil, ErrNotStarted
+	}
+	if !hasFabric {
+		return nil, ErrFabricNotFound
+	}
+	if n.config.OperationalKey == nil {
+		return nil, ErrNoOperationalKey
+	}
+
+	d := newDevice(config, nil)
+	d.probe = func(ctx context.Context) error {
+		return n.probeDevice(ctx, d)
+	}
+	d.subscribe = func(ctx context.Context, req *imsg.SubscribeRequestMessage, onReport func(*imsg.ReportDataMessage)) (*im.ClientSubscription, error) {
+		return n.subscribeDevice(ctx, d, req, onReport)
+	}
+	d.base, d.log = base, n.log
+
+	n.mu.Lock()
+	n.devices[d] = struct{}{}
+	n.mu.Unlock()
+
+	go d.run(base)
+	return d, nil
+}
+
+func newDevice(config DeviceConfig, probe func(ctx context.Context) error) *Device {
+	if config.KeepAliveInterval == 0 {
+		config.KeepAliveInterval = DefaultKeepAliveInterval
+	}
+	if config.ProbeTimeout == 0 {
+		config.ProbeTimeout = DefaultProbeTimeout
+	}
+	if config.SubscriptionMaxInterval == 0 {
+		config.SubscriptionMaxInterval = DefaultSubscriptionMaxInterval
+	}
+	if config.ResubscribeBackoff == 0 {
+		config.ResubscribeBackoff = DefaultResubscribeBackoff
+	}
+	if config.MaxResubscribeBackoff == 0 {
+		config.MaxResubscribeBackoff = DefaultMaxResubscribeBackoff
+	}
+	return &Device{
+		config:        config,
+		probe:         probe,
+		base:          context.Background(),
+		reached:       make(chan struct{}),
+		subscriptions: make(map[*DeviceSubscription]struct{}),
+		wake:          make(chan struct{}, 1),
+		done:          make(chan struct{}),
+	}
+}
+
+// IsReachable reports whether the last probe of the device succeeded.
+func (d *Device) IsReachable() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.reachable
+}
+
+// WaitForReachable blocks until the device is reachable, ctx ends or the
+// Device is closed.
+func (d *Device) WaitForReachable(ctx context.Context) error {
+	d.mu.Lock()
+	reached := d.reached
+	d.mu.Unlock()
+
+	select {
+	case <-reached:
+		return nil
+	case <-d.done:
+		return ErrDeviceClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops watching the device.
+func (d *Device) Close() error {
+	d.closeOnce.Do(func() { close(d.done) })
+	return nil
+}
+
+// run probes the device every KeepAliveInterval until the Device is
+// closed or ctx ends.
+func (d *Device) run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.KeepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		probeCtx, cancel := context.WithTimeout(ctx, d.config.ProbeTimeout)
+		err := d.probe(probeCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		d.setReachable(err == nil)
+
+		select {
+		case <-ticker.C:
+		case <-d.wake:
+		case <-d.done:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// setReachable records the outcome of a probe and notifies the waiters
+// and callbacks of a change.
+func (d *Device) setReachable(reachable bool) {
+	d.mu.Lock()
+	if reachable == d.reachable {
+		d.mu.Unlock()
+		return
+	}
+	d.reachable = reachable
+	var callback func()
+	if reachable {
+		close(d.reached)
+		if d.lost {
+			callback = d.config.OnReconnected
+		}
+	} else {
+		d.reached = make(chan struct{})
+		d.lost = true
+		callback = d.config.OnConnectionLost
+	}
+	d.mu.Unlock()
+
+	if callback != nil {
+		callback()
+	}
+}
+
+// reprobe requests a probe at once, e.g. after the device's session was
+// closed.
+func (d *Device) reprobe() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// sessionClosed ends the subscriptions over a session that closed, so
+// that they are established again over a new one.
+func (d *Device) sessionClosed(localSessionID uint16) {
+	d.mu.Lock()
+	subs := make([]*DeviceSubscription, 0, len(d.subscriptions))
+	for s := range d.subscriptions {
+		subs = append(subs, s)
+	}
+	d.mu.Unlock()
+
+	for _, s := range subs {
+		s.sessionClosed(localSessionID)
+	}
+}
+
+// usesSession reports whether the last successful probe used a session.
+func (d *Device) usesSession(localSessionID uint16) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.sessionID != 0 && d.sessionID == localSessionID
+}
+
+// isClosed reports whether the Device was closed.
+func (d *Device) isClosed() bool {
+	select {
+	case <-d.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// probeDevice reads a device's DataModelRevision over a CASE session.
+// On failure the session is closed and its address reported.
+func (n *Node) probeDevice(ctx context.Context, d *Device) error {
+	fabricIndex, nodeID := d.config.FabricIndex, d.config.NodeID
+	sess, peerAddr, err := n.FindOrEstablishSession(ctx, fabricIndex, nodeID)
+	if err != nil {
+		return err
+	}
+
+	client := im.NewClient(im.ClientConfig{
+		ExchangeManager: n.ExchangeManager(),
+		LoggerFactory:   n.config.LoggerFactory,
+		TracerProvider:  n.config.TracerProvider,
+	})
+	_, err = client.ReadAttribute(ctx, sess, peerAddr, uint16(RootEndpointID), uint32(basic.ClusterID), uint32(basic.AttrDataModelRevision))
+	if err != nil {
+		n.sessionMgr.RemoveSecureContext(sess.LocalSessionID())
+		n.NodeAddressFailed(fabricIndex, nodeID, peerAddr)
+		return err
+	}
+
+	d.mu.Lock()
+	d.sessionID = sess.LocalSessionID()
+	d.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,241 @@
+package matter
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/backkem/matter/pkg/clusters/onoff"
+	"github.com/backkem/matter/pkg/im"
+	imsg "github.com/backkem/matter/pkg/im/message"
+	"github.com/backkem/matter/pkg/session"
+)
+
+func TestDevice_Liveness(t *testing.T) {
+	var up atomic.Bool
+	lost := make(chan struct{}, 1)
+	reconnected := make(chan struct{}, 1)
+
+	d := newDevice(DeviceConfig{
+		KeepAliveInterval: 10 * time.Millisecond,
+		OnConnectionLost:  func() { lost <- struct{}{} },
+		OnReconnected:     func() { reconnected <- struct{}{} },
+	}, func(ctx context.Context) error {
+		if up.Load() {
+			return nil
+		}
+		return errors.New("unreachable")
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go d.run(ctx)
+	defer d.Close()
+
+	// Not reachable yet
+	waitCtx, waitCancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	if err := d.WaitForReachable(waitCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("WaitForReachable() = %v, want deadline exceeded", err)
+	}
+	waitCancel()
+
+	// Reachable for the first time: no reconnection
+	up.Store(true)
+	waitCtx, waitCancel = context.WithTimeout(ctx, time.Second)
+	defer waitCancel()
+	if err := d.WaitForReachable(waitCtx); err != nil {
+		t.Fatalf("WaitForReachable() = %v", err)
+	}
+	if !d.IsReachable() {
+		t.Error("IsReachable() = false after WaitForReachable")
+	}
+
+	// Connection lost
+	up.Store(false)
+	select {
+	case <-lost:
+	case <-time.After(time.Second):
+		t.Fatal("OnConnectionLost not called")
+	}
+	select {
+	case <-reconnected:
+		t.Fatal("OnReconnected called before the connection was lost")
+	default:
+	}
+
+	// Reconnected
+	up.Store(true)
+	select {
+	case <-reconnected:
+	case <-time.After(time.Second):
+		t.Fatal("OnReconnected not called")
+	}
+	if err := d.WaitForReachable(waitCtx); err != nil {
+		t.Errorf("WaitForReachable() after reconnect = %v", err)
+	}
+}
+
+func TestDevice_Close(t *testing.T) {
+	d := newDevice(DeviceConfig{}, func(ctx context.Context) error {
+		return errors.New("unreachable")
+	})
+	d.Close()
+	if err := d.WaitForReachable(context.Background()); !errors.Is(err, ErrDeviceClosed) {
+		t.Errorf("WaitForReachable() on closed device = %v, want ErrDeviceClosed", err)
+	}
+}
+
+func TestDevice_SessionClosed(t *testing.T) {
+	node, err := NewNode(NodeConfig{
+		VendorID:      0xFFF1,
+		ProductID:     0x8001,
+		Discriminator: 3840,
+		Passcode:      20202021,
+		Storage:       NewMemoryStorage(),
+	})
+	if err != nil {
+		t.Fatalf("NewNode failed: %v", err)
+	}
+
+	probes := make(chan struct{}, 4)
+	var d *Device
+	d = newDevice(DeviceConfig{KeepAliveInterval: time.Hour}, func(ctx context.Context) error {
+		d.mu.Lock()
+		d.sessionID = 5
+		d.mu.Unlock()
+		probes <- struct{}{}
+		return nil
+	})
+	node.devices[d] = struct{}{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go d.run(ctx)
+	defer d.Close()
+
+	select {
+	case <-probes:
+	case <-time.After(time.Second):
+		t.Fatal("device not probed")
+	}
+
+	// Closing another session leaves the device alone
+	node.onSessionClosed(6)
+	select {
+	case <-probes:
+		t.Fatal("device probed after another session closed")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Closing its session probes it at once, re-establishing a session
+	node.onSessionClosed(5)
+	select {
+	case <-probes:
+	case <-time.After(time.Second):
+		t.Fatal("device not probed after its session closed")
+	}
+	if !d.IsReachable() {
+		t.Error("IsReachable() = false after the session was re-established")
+	}
+}
+
+func TestDevice_Subscribe(t *testing.T) {
+	f, err := TestFabric(1)
+	if err != nil {
+		t.Fatalf("TestFabric() error = %v", err)
+	}
+	defer f.Stop()
+	ctrl, device := f.Controller(), f.Device(0)
+	light := onoff.New(onoff.Config{EndpointID: 1})
+	if err := device.AddEndpoint(NewEndpoint(1).WithDeviceType(0x0100, 1).AddCluster(light)); err != nil {
+		t.Fatalf("AddEndpoint() error = %v", err)
+	}
+	if err := f.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	// A session between the two nodes, as PASE would leave it
+	key := make([]byte, session.SessionKeySize)
+	newSession := func(node *Node, role session.SessionRole, local, peer uint16) *session.SecureContext {
+		t.Helper()
+		sess, err := session.NewSecureContext(session.SecureContextConfig{
+			SessionType:    session.SessionTypePASE,
+			Role:           role,
+			LocalSessionID: local,
+			PeerSessionID:  peer,
+			I2RKey:         key,
+			R2IKey:         key,
+		})
+		if err != nil {
+			t.Fatalf("NewSecureContext() error = %v", err)
+		}
+		if err := node.SessionManager().AddSecureContext(sess); err != nil {
+			t.Fatalf("AddSecureContext() error = %v", err)
+		}
+		return sess
+	}
+	sess := newSession(ctrl, session.SessionRoleInitiator, 100, 200)
+	newSession(device, session.SessionRoleResponder, 200, 100)
+
+	d := newDevice(DeviceConfig{
+		KeepAliveInterval:       time.Hour,
+		SubscriptionMaxInterval: 10 * time.Second,
+		ResubscribeBackoff:      10 * time.Millisecond,
+	}, func(ctx context.Context) error { return nil })
+	d.subscribe = func(ctx context.Context, req *imsg.SubscribeRequestMessage, onReport func(*imsg.ReportDataMessage)) (*im.ClientSubscription, error) {
+		client := im.NewClient(im.ClientConfig{ExchangeManager: ctrl.ExchangeManager(), Engine: ctrl.IMEngine()})
+		return client.Subscribe(ctx, sess, f.Address(device), req, onReport)
+	}
+	ctrl.devices[d] = struct{}{}
+	defer d.Close()
+
+	changes := make(chan AttributeChange, 8)
+	endpoint, cluster, attribute := imsg.EndpointID(1), imsg.ClusterID(onoff.ClusterID), imsg.AttributeID(onoff.AttrOnOff)
+	sub, err := d.Subscribe([]imsg.AttributePathIB{{Endpoint: &endpoint, Cluster: &cluster, Attribute: &attribute}},
+		func(c AttributeChange) { changes <- c })
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	defer sub.Close()
+
+	next := func(want bool) {
+		t.Helper()
+		select {
+		case c := <-changes:
+			if c.Value != want {
+				t.Errorf("Value = %v, want %v", c.Value, want)
+			}
+			var on bool
+			if err := c.Decode(&on); err != nil || on != want {
+				t.Errorf("Decode() = %v, %v, want %v", on, err, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("no attribute change")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := sub.WaitForActive(ctx); err != nil {
+		t.Fatalf("WaitForActive() error = %v", err)
+	}
+	id, _ := sub.SubscriptionID()
+	next(false) // Priming report
+
+	light.SetOnOff(true)
+	next(true)
+
+	// Losing the session subscribes again, with a new priming report
+	ctrl.onSessionClosed(100)
+	next(true)
+	if err := sub.WaitForActive(ctx); err != nil {
+		t.Fatalf("WaitForActive() after resubscribing error = %v", err)
+	}
+	if newID, ok := sub.SubscriptionID(); !ok || newID == id {
+		t.Errorf("SubscriptionID() = %d, %v after resubscribing, want a new ID", newID, ok)
+	}
+
+	if _, err := d.Subscribe(nil, nil); !errors.Is(err, ErrNoSubscriptionPaths) {
+		t.Errorf("Subscribe() without paths error = %v, want ErrNoSubscriptionPaths", err)
+	}
+}
@@ -0,0 +1,327 @@
+package matter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/im"
+	imsg "github.com/backkem/matter/pkg/im/message"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// Device subscription defaults.
+const (
+	// DefaultSubscriptionMaxInterval is the default longest time between
+	// two reports of a Device subscription.
+	DefaultSubscriptionMaxInterval = 60 * time.Second
+
+	// DefaultResubscribeBackoff is the default delay before subscribing
+	// again after a Device subscription was lost.
+	DefaultResubscribeBackoff = time.Second
+
+	// DefaultMaxResubscribeBackoff caps the delay between attempts to
+	// subscribe again.
+	DefaultMaxResubscribeBackoff = 5 * time.Minute
+)
+
+// AttributeChange is an attribute value reported by a Device
+// subscription.
+type AttributeChange struct {
+	// Path is the attribute reported.
+	Path datamodel.ConcreteAttributePath
+
+	// DataVersion is the version of the attribute's cluster.
+	DataVersion imsg.DataVersion
+
+	// Value is the attribute's value, decoded as tlv.Unmarshal decodes
+	// into an empty interface: e.g. uint64 for an unsigned integer, and
+	// map[tlv.Tag]any for a structure.
+	Value any
+
+	// data is the value's TLV encoding.
+	data []byte
+}
+
+// Decode decodes the value into v, e.g. a pointer to the Go type of the
+// attribute, as tlv.Unmarshal does.
+func (c AttributeChange) Decode(v any) error {
+	return tlv.Unmarshal(c.data, v)
+}
+
+// DeviceSubscription keeps a subscription to attributes of a Device. It
+// subscribes over a CASE session from Node.FindOrEstablishSession and,
+// whenever the subscription is lost, subscribes again, backing off
+// exponentially from ResubscribeBackoff to MaxResubscribeBackoff between
+// failed attempts. A subscription is lost when the device sends no report
+// within its MaxInterval or its session closes.
+//
+// Each subscription starts with a priming report of all the subscribed
+// attributes, so after resubscribing the callback receives their current
+// values again.
+//
+// C++ Reference: app::ReadClient (resubscription policy)
+type DeviceSubscription struct {
+	device   *Device
+	req      imsg.SubscribeRequestMessage
+	onChange func(AttributeChange)
+
+	mu      sync.Mutex
+	current *im.ClientSubscription
+	// active is closed while a subscription is established, and replaced
+	// when it is lost.
+	active chan struct{}
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// Subscribe subscribes to attributes of the device and keeps the
+// subscription until it is closed or the Device is. onChange receives
+// each attribute reported, decoded; attributes the device reports an
+// error for are left out. It runs on the goroutine receiving the report
+// and should not block.
+//
+// Subscribe returns at once; the subscription is established in the
+// background, see WaitForActive. The DeviceSubscription must be closed.
+func (d *Device) Subscribe(paths []imsg.AttributePathIB, onChange func(AttributeChange)) (*DeviceSubscription, error) {
+	if d.isClosed() {
+		return nil, ErrDeviceClosed
+	}
+	if len(paths) == 0 {
+		return nil, ErrNoSubscriptionPaths
+	}
+
+	s := &DeviceSubscription{
+		device: d,
+		req: imsg.SubscribeRequestMessage{
+			// The device's other subscriptions stay
+			KeepSubscriptions:  true,
+			MinIntervalFloor:   uint16(d.config.SubscriptionMinInterval / time.Second),
+			MaxIntervalCeiling: uint16(d.config.SubscriptionMaxInterval / time.Second),
+			AttributeRequests:  paths,
+			FabricFiltered:     true,
+		},
+		onChange: onChange,
+		active:   make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	d.mu.Lock()
+	d.subscriptions[s] = struct{}{}
+	d.mu.Unlock()
+
+	go s.run(d.base)
+	return s, nil
+}
+
+// IsActive reports whether the subscription is established.
+func (s *DeviceSubscription) IsActive() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.current != nil
+}
+
+// SubscriptionID returns the ID of the established subscription, which
+// changes when the device is subscribed again.
+func (s *DeviceSubscription) SubscriptionID() (imsg.SubscriptionID, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current == nil {
+		return 0, false
+	}
+	return s.current.ID(), true
+}
+
+// WaitForActive blocks until the subscription is established, ctx ends or
+// the subscription is closed.
+func (s *DeviceSubscription) WaitForActive(ctx context.Context) error {
+	s.mu.Lock()
+	active := s.active
+	s.mu.Unlock()
+
+	select {
+	case <-active:
+		return nil
+	case <-s.done:
+		return ErrSubscriptionClosed
+	case <-s.device.done:
+		return ErrDeviceClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close ends the subscription.
+func (s *DeviceSubscription) Close() error {
+	s.closeOnce.Do(func() { close(s.done) })
+	return nil
+}
+
+// run subscribes, and subscribes again whenever the subscription is lost,
+// until it is closed, the Device is, or ctx ends.
+func (s *DeviceSubscription) run(ctx context.Context) {
+	d := s.device
+	defer func() {
+		d.mu.Lock()
+		delete(d.subscriptions, s)
+		d.mu.Unlock()
+	}()
+
+	for attempt := 0; ; {
+		attemptCtx, cancel := context.WithTimeout(ctx, im.DefaultRequestTimeout)
+		sub, err := d.subscribe(attemptCtx, &s.req, s.handleReport)
+		cancel()
+		if ctx.Err() != nil {
+			if sub != nil {
+				sub.Close()
+			}
+			return
+		}
+
+		if err == nil {
+			attempt = 0
+			s.setCurrent(sub)
+			select {
+			case <-sub.Done():
+			case <-s.done:
+			case <-d.done:
+			case <-ctx.Done():
+			}
+			sub.Close()
+			s.setCurrent(nil)
+			if s.isClosed() || ctx.Err() != nil {
+				return
+			}
+			err = sub.Err()
+			if errors.Is(err, im.ErrSubscriptionLost) {
+				// The session may be gone too: a failed probe closes it
+				d.reprobe()
+			}
+		}
+
+		attempt++
+		delay := d.resubscribeBackoff(attempt)
+		if d.log != nil {
+			d.log.Debugf("subscription to node 0x%016X: %v, subscribing again in %v", uint64(d.config.NodeID), err, delay)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-s.done:
+			timer.Stop()
+			return
+		case <-d.done:
+			timer.Stop()
+			return
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// setCurrent records the established subscription, or nil once it is
+// lost.
+func (s *DeviceSubscription) setCurrent(sub *im.ClientSubscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if sub != nil {
+		close(s.active)
+	} else if s.current != nil {
+		s.active = make(chan struct{})
+	}
+	s.current = sub
+}
+
+// sessionClosed ends the subscription if it is over a session that
+// closed, so that it is established again over a new one.
+func (s *DeviceSubscription) sessionClosed(localSessionID uint16) {
+	s.mu.Lock()
+	current := s.current
+	s.mu.Unlock()
+
+	if current != nil && current.LocalSessionID() == localSessionID {
+		current.Close()
+	}
+}
+
+// handleReport hands the attributes of a report to the callback.
+func (s *DeviceSubscription) handleReport(report *imsg.ReportDataMessage) {
+	for _, r := range report.AttributeReports {
+		data := r.AttributeData
+		if data == nil || data.Path.Endpoint == nil || data.Path.Cluster == nil || data.Path.Attribute == nil {
+			continue
+		}
+		change := AttributeChange{
+			Path: datamodel.ConcreteAttributePath{
+				Endpoint:  datamodel.EndpointID(*data.Path.Endpoint),
+				Cluster:   datamodel.ClusterID(*data.Path.Cluster),
+				Attribute: datamodel.AttributeID(*data.Path.Attribute),
+			},
+			DataVersion: data.DataVersion,
+			data:        data.Data,
+		}
+		if err := tlv.Unmarshal(data.Data, &change.Value); err != nil {
+			if s.device.log != nil {
+				s.device.log.Warnf("subscription to node 0x%016X: attribute %v: %v", uint64(s.device.config.NodeID), change.Path, err)
+			}
+			continue
+		}
+		if s.onChange != nil {
+			s.onChange(change)
+		}
+	}
+}
+
+// isClosed reports whether the subscription was closed.
+func (s *DeviceSubscription) isClosed() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// resubscribeBackoff returns the delay before the given attempt to
+// subscribe again, counted from 1.
+func (d *Device) resubscribeBackoff(attempt int) time.Duration {
+	delay := d.config.ResubscribeBackoff
+	for i := 1; i < attempt && delay < d.config.MaxResubscribeBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, d.config.MaxResubscribeBackoff)
+}
+
+// subscribeDevice subscribes to attributes of a device over a CASE
+// session. A subscription that times out closes the session and reports
+// its address, as a failed probe does.
+func (n *Node) subscribeDevice(
+	ctx context.Context,
+	d *Device,
+	req *imsg.SubscribeRequestMessage,
+	onReport func(*imsg.ReportDataMessage),
+) (*im.ClientSubscription, error) {
+	fabricIndex, nodeID := d.config.FabricIndex, d.config.NodeID
+	sess, peerAddr, err := n.FindOrEstablishSession(ctx, fabricIndex, nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	client := im.NewClient(im.ClientConfig{
+		ExchangeManager: n.ExchangeManager(),
+		Engine:          n.IMEngine(),
+		LoggerFactory:   n.config.LoggerFactory,
+		TracerProvider:  n.config.TracerProvider,
+	})
+	sub, err := client.Subscribe(ctx, sess, peerAddr, req, onReport)
+	if errors.Is(err, im.ErrClientTimeout) {
+		n.sessionMgr.RemoveSecureContext(sess.LocalSessionID())
+		n.NodeAddressFailed(fabricIndex, nodeID, peerAddr)
+	}
+	return sub, err
+}
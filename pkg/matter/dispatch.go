@@ -2,9 +2,13 @@ package matter
 
 import (
 	"context"
+	"sync"
 
+	"github.com/backkem/matter/pkg/acl"
 	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/fabric"
 	"github.com/backkem/matter/pkg/im"
+	"github.com/backkem/matter/pkg/im/imstatus"
 	imsg "github.com/backkem/matter/pkg/im/message"
 	"github.com/backkem/matter/pkg/tlv"
 )
@@ -12,11 +16,17 @@ import (
 // nodeDispatcher implements im.Dispatcher by routing to the datamodel.
 type nodeDispatcher struct {
 	node *datamodel.BasicNode
+
+	mu     sync.RWMutex
+	groups map[groupRef][]imsg.EndpointID
 }
 
 // newNodeDispatcher creates a dispatcher that routes to the given node's data model.
 func newNodeDispatcher(node *datamodel.BasicNode) *nodeDispatcher {
-	return &nodeDispatcher{node: node}
+	return &nodeDispatcher{
+		node:   node,
+		groups: make(map[groupRef][]imsg.EndpointID),
+	}
 }
 
 // ReadAttribute reads an attribute value.
@@ -43,20 +53,8 @@ func (d *nodeDispatcher) ReadAttribute(ctx context.Context, req *im.AttributeRea
 		return im.ErrClusterNotFound
 	}
 
-	// Read the attribute using the cluster's ReadAttribute method
-	attrID := datamodel.AttributeID(0)
-	if req.Path.Attribute != nil {
-		attrID = datamodel.AttributeID(*req.Path.Attribute)
-	}
-
-	// Build a ReadAttributeRequest for the cluster
-	readReq := datamodel.ReadAttributeRequest{
-		Path: datamodel.ConcreteAttributePath{
-			Endpoint:  endpointID,
-			Cluster:   clusterID,
-			Attribute: attrID,
-		},
-	}
+	// Build a ReadAttributeRequest for the cluster (carries subject and fabric filter)
+	readReq := req.ToDataModelRequest()
 
 	return cluster.ReadAttribute(ctx, readReq, w)
 }
@@ -85,22 +83,14 @@ func (d *nodeDispatcher) WriteAttribute(ctx context.Context, req *im.AttributeWr
 		return im.ErrClusterNotFound
 	}
 
-	// Write the attribute using the cluster's WriteAttribute method
-	attrID := datamodel.AttributeID(0)
-	if req.Path.Attribute != nil {
-		attrID = datamodel.AttributeID(*req.Path.Attribute)
+	// A write conditioned on a data version the cluster moved past is
+	// refused
+	if req.DataVersion != nil && *req.DataVersion != imsg.DataVersion(cluster.DataVersion()) {
+		return im.ErrDataVersionMismatch
 	}
 
-	// Build a WriteAttributeRequest for the cluster
-	writeReq := datamodel.WriteAttributeRequest{
-		Path: datamodel.ConcreteDataAttributePath{
-			ConcreteAttributePath: datamodel.ConcreteAttributePath{
-				Endpoint:  endpointID,
-				Cluster:   clusterID,
-				Attribute: attrID,
-			},
-		},
-	}
+	// Build a WriteAttributeRequest for the cluster (carries subject and timed flag)
+	writeReq := req.ToDataModelRequest()
 
 	return cluster.WriteAttribute(ctx, writeReq, r)
 }
@@ -119,31 +109,150 @@ func (d *nodeDispatcher) InvokeCommand(ctx context.Context, req *im.CommandInvok
 		return nil, im.ErrClusterNotFound
 	}
 
-	// Build an InvokeRequest for the cluster
-	invokeReq := datamodel.InvokeRequest{
-		Path: datamodel.ConcreteCommandPath{
-			Endpoint: datamodel.EndpointID(req.Path.Endpoint),
-			Cluster:  datamodel.ClusterID(req.Path.Cluster),
-			Command:  datamodel.CommandID(req.Path.Command),
-		},
-	}
+	// Build an InvokeRequest for the cluster (carries subject and timed flag)
+	invokeReq := req.ToDataModelRequest()
 
 	return cluster.InvokeCommand(ctx, invokeReq, r)
 }
 
-// Verify nodeDispatcher implements im.Dispatcher.
-var _ im.Dispatcher = (*nodeDispatcher)(nil)
+// InvokeCommandResponse implements im.CommandResponder.
+func (d *nodeDispatcher) InvokeCommandResponse(ctx context.Context, req *im.CommandInvokeRequest, r *tlv.Reader) (*datamodel.CommandResponse, error) {
+	endpoint := d.node.GetEndpoint(datamodel.EndpointID(req.Path.Endpoint))
+	if endpoint == nil {
+		return nil, im.ErrClusterNotFound
+	}
+	cluster := endpoint.GetCluster(datamodel.ClusterID(req.Path.Cluster))
+	if cluster == nil {
+		return nil, im.ErrClusterNotFound
+	}
+	return datamodel.InvokeCommandResponse(ctx, cluster, req.ToDataModelRequest(), r)
+}
 
-// StatusError wraps an IM status code as an error.
-type StatusError struct {
-	Status imsg.Status
+// RequiredPrivilege returns the privilege the cluster metadata requires for
+// the operation on the path.
+func (d *nodeDispatcher) RequiredPrivilege(path acl.RequestPath) (acl.Privilege, bool) {
+	if path.EntityID == nil {
+		return 0, false
+	}
+	endpoint := d.node.GetEndpoint(datamodel.EndpointID(path.Endpoint))
+	if endpoint == nil {
+		return 0, false
+	}
+	cluster := endpoint.GetCluster(datamodel.ClusterID(path.Cluster))
+	if cluster == nil {
+		return 0, false
+	}
+
+	var privilege *datamodel.Privilege
+	switch path.RequestType {
+	case acl.RequestTypeAttributeRead, acl.RequestTypeAttributeWrite:
+		for _, attr := range cluster.AttributeList() {
+			if uint32(attr.ID) != *path.EntityID {
+				continue
+			}
+			if path.RequestType == acl.RequestTypeAttributeRead {
+				privilege = attr.ReadPrivilege
+			} else {
+				privilege = attr.WritePrivilege
+			}
+			break
+		}
+	case acl.RequestTypeCommandInvoke:
+		for _, cmd := range cluster.AcceptedCommandList() {
+			if uint32(cmd.ID) == *path.EntityID {
+				privilege = &cmd.InvokePrivilege
+				break
+			}
+		}
+	}
+
+	if privilege == nil || !privilege.IsValid() {
+		return 0, false
+	}
+	// datamodel.Privilege shares the spec values of acl.Privilege.
+	return acl.Privilege(*privilege), true
+}
+
+// ClusterDataVersion returns the data version of a cluster instance.
+func (d *nodeDispatcher) ClusterDataVersion(endpoint imsg.EndpointID, cluster imsg.ClusterID) (imsg.DataVersion, bool) {
+	c := d.node.GetCluster(datamodel.EndpointID(endpoint), datamodel.ClusterID(cluster))
+	if c == nil {
+		return 0, false
+	}
+	return imsg.DataVersion(c.DataVersion()), true
+}
+
+// ExpandAttributePath returns the readable attributes of the node on a
+// wildcard path. Manufacturer-specific clusters and attributes are
+// expanded like standard ones.
+//
+// Spec: Section 8.2.1.6 (wildcard path expansion)
+func (d *nodeDispatcher) ExpandAttributePath(path imsg.AttributePathIB) []imsg.AttributePathIB {
+	var paths []imsg.AttributePathIB
+	for _, endpoint := range d.node.GetEndpoints() {
+		endpointID := imsg.EndpointID(endpoint.ID())
+		if path.Endpoint != nil && *path.Endpoint != endpointID {
+			continue
+		}
+		for _, cluster := range endpoint.GetClusters() {
+			clusterID := imsg.ClusterID(cluster.ID())
+			if path.Cluster != nil && *path.Cluster != clusterID {
+				continue
+			}
+			for _, attr := range cluster.AttributeList() {
+				attributeID := imsg.AttributeID(attr.ID)
+				if !attr.IsReadable() || (path.Attribute != nil && *path.Attribute != attributeID) {
+					continue
+				}
+				concrete := path
+				concrete.Endpoint = &endpointID
+				concrete.Cluster = &clusterID
+				concrete.Attribute = &attributeID
+				paths = append(paths, concrete)
+			}
+		}
+	}
+	return paths
+}
+
+// GroupEndpoints returns the node's endpoints in a group.
+func (d *nodeDispatcher) GroupEndpoints(fabricIndex fabric.FabricIndex, groupID uint16) []imsg.EndpointID {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.groups[groupRef{fabricIndex: fabricIndex, groupID: groupID}]
 }
 
-func (e *StatusError) Error() string {
-	return e.Status.String()
+// setGroupEndpoints sets the node's endpoints in a group; none removes
+// the group.
+func (d *nodeDispatcher) setGroupEndpoints(ref groupRef, endpoints []datamodel.EndpointID) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(endpoints) == 0 {
+		delete(d.groups, ref)
+		return
+	}
+	ids := make([]imsg.EndpointID, len(endpoints))
+	for i, ep := range endpoints {
+		ids[i] = imsg.EndpointID(ep)
+	}
+	d.groups[ref] = ids
 }
 
+// Verify nodeDispatcher implements im.Dispatcher, im.PrivilegeResolver,
+// im.DataVersionProvider, im.GroupMembership and im.PathExpander.
+var (
+	_ im.Dispatcher          = (*nodeDispatcher)(nil)
+	_ im.PrivilegeResolver   = (*nodeDispatcher)(nil)
+	_ im.DataVersionProvider = (*nodeDispatcher)(nil)
+	_ im.GroupMembership     = (*nodeDispatcher)(nil)
+	_ im.PathExpander        = (*nodeDispatcher)(nil)
+)
+
+// StatusError wraps an IM status code as an error.
+type StatusError = imstatus.Error
+
 // NewStatusError creates a new StatusError.
 func NewStatusError(status imsg.Status) *StatusError {
-	return &StatusError{Status: status}
+	return imstatus.New(status)
 }
@@ -0,0 +1,170 @@
+package matter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/backkem/matter/pkg/acl"
+	"github.com/backkem/matter/pkg/clusters/generalcommissioning"
+	"github.com/backkem/matter/pkg/im"
+	imsg "github.com/backkem/matter/pkg/im/message"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+func TestNodeDispatcherRequiredPrivilege(t *testing.T) {
+	node, err := NewNode(NodeConfig{
+		VendorID:      0xFFF1,
+		ProductID:     0x8001,
+		Discriminator: 3840,
+		Passcode:      20202021,
+		Storage:       NewMemoryStorage(),
+	})
+	if err != nil {
+		t.Fatalf("NewNode failed: %v", err)
+	}
+
+	id := func(v uint32) *uint32 { return &v }
+	cluster := uint32(generalcommissioning.ClusterID)
+	tests := []struct {
+		name   string
+		path   acl.RequestPath
+		want   acl.Privilege
+		wantOK bool
+	}{
+		{
+			name: "read breadcrumb",
+			path: acl.RequestPath{Cluster: cluster, RequestType: acl.RequestTypeAttributeRead, EntityID: id(uint32(generalcommissioning.AttrBreadcrumb))},
+			want: acl.PrivilegeView, wantOK: true,
+		},
+		{
+			name: "write breadcrumb",
+			path: acl.RequestPath{Cluster: cluster, RequestType: acl.RequestTypeAttributeWrite, EntityID: id(uint32(generalcommissioning.AttrBreadcrumb))},
+			want: acl.PrivilegeAdminister, wantOK: true,
+		},
+		{
+			name: "invoke ArmFailSafe",
+			path: acl.RequestPath{Cluster: cluster, RequestType: acl.RequestTypeCommandInvoke, EntityID: id(uint32(generalcommissioning.CmdArmFailSafe))},
+			want: acl.PrivilegeAdminister, wantOK: true,
+		},
+		{
+			name: "write read-only attribute",
+			path: acl.RequestPath{Cluster: cluster, RequestType: acl.RequestTypeAttributeWrite, EntityID: id(uint32(generalcommissioning.AttrRegulatoryConfig))},
+		},
+		{
+			name: "unknown endpoint",
+			path: acl.RequestPath{Endpoint: 9, Cluster: cluster, RequestType: acl.RequestTypeAttributeRead, EntityID: id(0)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := node.dispatcher.RequiredPrivilege(tt.path)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("RequiredPrivilege = (%v, %v), want (%v, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestNodeDispatcherClusterDataVersion(t *testing.T) {
+	node, err := NewNode(NodeConfig{
+		VendorID:      0xFFF1,
+		ProductID:     0x8001,
+		Discriminator: 3840,
+		Passcode:      20202021,
+		Storage:       NewMemoryStorage(),
+	})
+	if err != nil {
+		t.Fatalf("NewNode failed: %v", err)
+	}
+
+	cluster := node.dataModel.GetCluster(0, generalcommissioning.ClusterID)
+	got, ok := node.dispatcher.ClusterDataVersion(0, imsg.ClusterID(generalcommissioning.ClusterID))
+	if !ok || got != imsg.DataVersion(cluster.DataVersion()) {
+		t.Errorf("ClusterDataVersion = (%v, %v), want (%v, true)", got, ok, cluster.DataVersion())
+	}
+
+	if _, ok := node.dispatcher.ClusterDataVersion(9, imsg.ClusterID(generalcommissioning.ClusterID)); ok {
+		t.Error("ClusterDataVersion found a cluster on an unknown endpoint")
+	}
+}
+
+func TestNodeDispatcherWriteDataVersion(t *testing.T) {
+	node, err := NewNode(NodeConfig{
+		VendorID:      0xFFF1,
+		ProductID:     0x8001,
+		Discriminator: 3840,
+		Passcode:      20202021,
+		Storage:       NewMemoryStorage(),
+	})
+	if err != nil {
+		t.Fatalf("NewNode failed: %v", err)
+	}
+
+	cluster := node.dataModel.GetCluster(0, generalcommissioning.ClusterID)
+	endpoint, clusterID := imsg.EndpointID(0), imsg.ClusterID(generalcommissioning.ClusterID)
+	attribute := imsg.AttributeID(generalcommissioning.AttrBreadcrumb)
+	write := func(version imsg.DataVersion) error {
+		var buf bytes.Buffer
+		if err := tlv.NewWriter(&buf).PutUint(tlv.Anonymous(), 7); err != nil {
+			t.Fatal(err)
+		}
+		return node.dispatcher.WriteAttribute(context.Background(), &im.AttributeWriteRequest{
+			Path:        imsg.AttributePathIB{Endpoint: &endpoint, Cluster: &clusterID, Attribute: &attribute},
+			DataVersion: &version,
+		}, tlv.NewReader(bytes.NewReader(buf.Bytes())))
+	}
+
+	stale := imsg.DataVersion(cluster.DataVersion()) - 1
+	if err := write(stale); !errors.Is(err, im.ErrDataVersionMismatch) {
+		t.Errorf("WriteAttribute with stale version error = %v, want ErrDataVersionMismatch", err)
+	}
+	if err := write(imsg.DataVersion(cluster.DataVersion())); err != nil {
+		t.Errorf("WriteAttribute with current version error = %v", err)
+	}
+}
+
+func TestNodeDispatcherExpandAttributePath(t *testing.T) {
+	node, err := NewNode(NodeConfig{
+		VendorID:      0xFFF1,
+		ProductID:     0x8001,
+		Discriminator: 3840,
+		Passcode:      20202021,
+		Storage:       NewMemoryStorage(),
+	})
+	if err != nil {
+		t.Fatalf("NewNode failed: %v", err)
+	}
+
+	cluster := node.dataModel.GetCluster(0, generalcommissioning.ClusterID)
+	endpoint, clusterID := imsg.EndpointID(0), imsg.ClusterID(generalcommissioning.ClusterID)
+	paths := node.dispatcher.ExpandAttributePath(imsg.AttributePathIB{Endpoint: &endpoint, Cluster: &clusterID})
+
+	var want []imsg.AttributeID
+	for _, attr := range cluster.AttributeList() {
+		if attr.IsReadable() {
+			want = append(want, imsg.AttributeID(attr.ID))
+		}
+	}
+	if len(paths) != len(want) {
+		t.Fatalf("expanded %d paths, want %d", len(paths), len(want))
+	}
+	for i, path := range paths {
+		if *path.Endpoint != endpoint || *path.Cluster != clusterID || *path.Attribute != want[i] {
+			t.Errorf("path %d = %d/0x%04X/0x%04X, want 0/0x%04X/0x%04X",
+				i, *path.Endpoint, *path.Cluster, *path.Attribute, clusterID, want[i])
+		}
+	}
+
+	// A wildcard endpoint matches the cluster wherever it is
+	attribute := imsg.AttributeID(generalcommissioning.AttrBreadcrumb)
+	if paths := node.dispatcher.ExpandAttributePath(imsg.AttributePathIB{Cluster: &clusterID, Attribute: &attribute}); len(paths) != 1 {
+		t.Errorf("expanded %d paths for Breadcrumb on any endpoint, want 1", len(paths))
+	}
+
+	unknown := imsg.EndpointID(9)
+	if paths := node.dispatcher.ExpandAttributePath(imsg.AttributePathIB{Endpoint: &unknown}); len(paths) != 0 {
+		t.Errorf("expanded %d paths on an unknown endpoint, want 0", len(paths))
+	}
+}
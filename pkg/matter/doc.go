@@ -43,13 +43,12 @@
 //
 // For testing, use MemoryStorage and the virtual network helpers:
 //
-//	// Create a pair of nodes connected via a bridge
-//	device, controller, bridge, _ := matter.TestNodePair()
-//	device.Start(ctx)
-//	controller.Start(ctx)
-//
-//	// Process messages between nodes
-//	bridge.Process()
+//	// A controller and three devices on one fabric, bridged in memory
+//	f, _ := matter.TestFabric(3)
+//	defer f.Stop()
+//	f.AddGroup(0x0101, epochKey, 1)
+//	f.SetLinkCondition(f.Device(2), transport.NetworkCondition{DropRate: 0.1})
+//	f.Start(ctx)
 //
 // See the examples/ directory for complete working examples.
 package matter
@@ -1,6 +1,9 @@
 package matter
 
 import (
+	"fmt"
+
+	"github.com/backkem/matter/pkg/clusters/descriptor"
 	"github.com/backkem/matter/pkg/datamodel"
 )
 
@@ -15,6 +18,16 @@ import (
 type Endpoint struct {
 	endpoint    *datamodel.BasicEndpoint
 	deviceTypes []datamodel.DeviceTypeEntry
+
+	// Child endpoints added with the endpoint, see AddPart
+	parts []*Endpoint
+
+	// Reported by the Descriptor cluster added by Node.AddEndpoint
+	uniqueID *string
+	tags     []descriptor.SemanticTag
+
+	// err is the first error from AddCluster, returned by Node.AddEndpoint.
+	err error
 }
 
 // NewEndpoint creates a new endpoint with the given ID.
@@ -40,20 +53,86 @@ func NewEndpoint(id datamodel.EndpointID) *Endpoint {
 //   - 0x0302: Temperature Sensor
 //   - 0x0850: Camera
 func (e *Endpoint) WithDeviceType(deviceType uint32, revision uint8) *Endpoint {
-	e.deviceTypes = append(e.deviceTypes, datamodel.DeviceTypeEntry{
+	entry := datamodel.DeviceTypeEntry{
 		DeviceTypeID: datamodel.DeviceTypeID(deviceType),
 		Revision:     revision,
-	})
+	}
+	e.deviceTypes = append(e.deviceTypes, entry)
+	e.endpoint.AddDeviceType(entry)
 	return e
 }
 
 // AddCluster adds a cluster implementation to the endpoint.
-// The cluster must implement datamodel.Cluster.
+// The cluster must implement datamodel.Cluster. A cluster with a duplicate
+// or invalid ID (see datamodel.ValidateCluster) is not added; Node.AddEndpoint
+// then returns the error.
 func (e *Endpoint) AddCluster(cluster datamodel.Cluster) *Endpoint {
-	e.endpoint.AddCluster(cluster)
+	if err := e.endpoint.AddCluster(cluster); err != nil && e.err == nil {
+		e.err = fmt.Errorf("matter: cluster 0x%08X: %w", uint32(cluster.ID()), err)
+	}
+	return e
+}
+
+// WithParent makes the endpoint a child of an endpoint already added to
+// the node, e.g. a bridged device under the bridge's Aggregator. The parent
+// lists it in its Descriptor PartsList.
+func (e *Endpoint) WithParent(parentID datamodel.EndpointID) *Endpoint {
+	e.endpoint.SetParent(parentID)
+	return e
+}
+
+// WithComposition sets how the endpoint's PartsList lists its descendants:
+// datamodel.CompositionTree (the default) lists its direct children,
+// datamodel.CompositionFullFamily all descendants, as an Aggregator does.
+func (e *Endpoint) WithComposition(pattern datamodel.EndpointComposition) *Endpoint {
+	e.endpoint.SetCompositionPattern(pattern)
+	return e
+}
+
+// WithUniqueID sets the endpoint's EndpointUniqueID, which identifies it
+// across restarts and endpoint renumbering. It must be unique within the
+// node and at most descriptor.MaxEndpointUniqueIDLength characters;
+// otherwise Node.AddEndpoint returns an error.
+func (e *Endpoint) WithUniqueID(id string) *Endpoint {
+	if len(id) > descriptor.MaxEndpointUniqueIDLength && e.err == nil {
+		e.err = fmt.Errorf("%w: %q", ErrInvalidEndpointUniqueID, id)
+	}
+	e.uniqueID = &id
 	return e
 }
 
+// WithSemanticTags sets the endpoint's Descriptor TagList, which tells
+// apart the endpoints of a composed device with the same device type, e.g.
+// the front left and rear right cook surfaces of a cooktop.
+func (e *Endpoint) WithSemanticTags(tags ...descriptor.SemanticTag) *Endpoint {
+	e.tags = append(e.tags, tags...)
+	return e
+}
+
+// AddPart adds a child endpoint of a composed device, e.g. a cook surface
+// of a cooktop. The child's parent is set to this endpoint, and
+// Node.AddEndpoint adds it, and its own parts, with this endpoint.
+//
+// Example:
+//
+//	cooktop := matter.NewEndpoint(2).
+//	    WithDeviceType(0x0078, 1). // Cooktop
+//	    AddPart(matter.NewEndpoint(3).WithDeviceType(0x0077, 1)). // Cook Surface
+//	    AddPart(matter.NewEndpoint(4).WithDeviceType(0x0077, 1))
+//	node.AddEndpoint(matter.NewEndpoint(1).
+//	    WithDeviceType(0x007B, 1). // Oven
+//	    AddPart(cooktop))
+func (e *Endpoint) AddPart(child *Endpoint) *Endpoint {
+	child.endpoint.SetParent(e.ID())
+	e.parts = append(e.parts, child)
+	return e
+}
+
+// Parts returns the child endpoints added with AddPart.
+func (e *Endpoint) Parts() []*Endpoint {
+	return e.parts
+}
+
 // ID returns the endpoint ID.
 func (e *Endpoint) ID() datamodel.EndpointID {
 	return e.endpoint.ID()
@@ -79,3 +158,18 @@ func (e *Endpoint) GetClusters() []datamodel.Cluster {
 func (e *Endpoint) Inner() *datamodel.BasicEndpoint {
 	return e.endpoint
 }
+
+// parentID returns the endpoint's parent, or nil if it is a top-level
+// endpoint.
+func (e *Endpoint) parentID() *datamodel.EndpointID {
+	return e.endpoint.Entry().ParentID
+}
+
+// tree returns the endpoint and its parts, recursively, parents first.
+func (e *Endpoint) tree() []*Endpoint {
+	endpoints := []*Endpoint{e}
+	for _, part := range e.parts {
+		endpoints = append(endpoints, part.tree()...)
+	}
+	return endpoints
+}
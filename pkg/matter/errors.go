@@ -34,6 +34,16 @@ var (
 	// ErrInvalidPasscode is returned when Passcode is invalid.
 	ErrInvalidPasscode = errors.New("matter: invalid passcode")
 
+	// ErrInvalidPort is returned when Port is not a valid port number.
+	ErrInvalidPort = errors.New("matter: invalid port")
+
+	// ErrInvalidPAKEParameters is returned when a PASE verifier, salt or
+	// iteration count provided without a passcode is invalid.
+	ErrInvalidPAKEParameters = errors.New("matter: invalid PAKE parameters")
+
+	// ErrInvalidLogLevels is returned when LogLevels cannot be parsed.
+	ErrInvalidLogLevels = errors.New("matter: invalid log levels")
+
 	// ErrEndpointExists is returned when adding an endpoint with a duplicate ID.
 	ErrEndpointExists = errors.New("matter: endpoint already exists")
 
@@ -43,6 +53,18 @@ var (
 	// ErrRootEndpointReserved is returned when trying to add endpoint 0 manually.
 	ErrRootEndpointReserved = errors.New("matter: endpoint 0 is reserved for root endpoint")
 
+	// ErrParentNotFound is returned when adding an endpoint whose parent
+	// was not added.
+	ErrParentNotFound = errors.New("matter: parent endpoint not found")
+
+	// ErrEndpointHasParts is returned when removing an endpoint that still
+	// has child endpoints.
+	ErrEndpointHasParts = errors.New("matter: endpoint has child endpoints")
+
+	// ErrInvalidEndpointUniqueID is returned when adding an endpoint whose
+	// EndpointUniqueID is too long or used by another endpoint.
+	ErrInvalidEndpointUniqueID = errors.New("matter: invalid endpoint unique ID")
+
 	// ErrCommissioningWindowOpen is returned when a commissioning window is already open.
 	ErrCommissioningWindowOpen = errors.New("matter: commissioning window already open")
 
@@ -57,6 +79,46 @@ var (
 
 	// ErrFabricNotFound is returned when a fabric is not found.
 	ErrFabricNotFound = errors.New("matter: fabric not found")
+
+	// ErrNoOperationalKey is returned when initiating CASE without
+	// NodeConfig.OperationalKey.
+	ErrNoOperationalKey = errors.New("matter: no operational key")
+
+	// ErrDeviceClosed is returned when waiting on a closed Device.
+	ErrDeviceClosed = errors.New("matter: device closed")
+
+	// ErrNoSubscriptionPaths is returned when subscribing to a Device
+	// without paths.
+	ErrNoSubscriptionPaths = errors.New("matter: no subscription paths")
+
+	// ErrSubscriptionClosed is returned when waiting on a closed
+	// DeviceSubscription.
+	ErrSubscriptionClosed = errors.New("matter: subscription closed")
+
+	// ErrICDClosed is returned when queuing an interaction with a closed
+	// ICD registration.
+	ErrICDClosed = errors.New("matter: ICD registration closed")
+
+	// ErrDiscoveryDisabled is returned when resolving nodes without DNS-SD,
+	// as with a custom transport.
+	ErrDiscoveryDisabled = errors.New("matter: DNS-SD discovery disabled")
+
+	// ErrFabricTableFull is returned when the node is on SupportedFabrics fabrics.
+	ErrFabricTableFull = errors.New("matter: fabric table full")
+
+	// ErrFabricExists is returned when adding a fabric the node is already on.
+	ErrFabricExists = errors.New("matter: fabric already exists")
+
+	// ErrFailSafeArmed is returned when arming a fail-safe that is armed.
+	ErrFailSafeArmed = errors.New("matter: fail-safe already armed")
+
+	// ErrFailSafeNotArmed is returned when no commissioner armed the fail-safe.
+	ErrFailSafeNotArmed = errors.New("matter: fail-safe not armed")
+
+	// ErrCommissioningTerminated is the reason given to OnFailSafeExpired
+	// when commissioning was aborted by the commissioner or with
+	// TerminateCommissioning.
+	ErrCommissioningTerminated = errors.New("matter: commissioning terminated")
 )
 
 // InvalidPasscodes lists passcodes that are not allowed per Matter spec.
@@ -0,0 +1,500 @@
+package matter
+
+import (
+	"errors"
+	"time"
+
+	"github.com/backkem/matter/pkg/acl"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/discovery"
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/logger"
+	"github.com/backkem/matter/pkg/session"
+)
+
+// AddFabric joins the node to a fabric, e.g. once its operational
+// credentials have been installed. If info.FabricIndex is 0, the next free
+// index is allocated. Returns the fabric's index.
+//
+// Returns ErrFabricTableFull if the node is already on SupportedFabrics
+// fabrics, or ErrFabricExists if the index or the fabric is already in use.
+func (n *Node) AddFabric(info *fabric.FabricInfo) (fabric.FabricIndex, error) {
+	n.mu.Lock()
+	index, err := n.addFabricLocked(info)
+	n.mu.Unlock()
+	if err != nil {
+		return fabric.FabricIndexInvalid, err
+	}
+
+	if n.config.OnFabricAdded != nil {
+		n.config.OnFabricAdded(index)
+	}
+	return index, nil
+}
+
+// addFabricLocked adds a fabric to the table and storage.
+// Caller must hold n.mu.
+func (n *Node) addFabricLocked(info *fabric.FabricInfo) (fabric.FabricIndex, error) {
+	info = info.Clone()
+	if info.FabricIndex == fabric.FabricIndexInvalid {
+		index, err := n.fabricTable.AllocateFabricIndex()
+		if err != nil {
+			return fabric.FabricIndexInvalid, ErrFabricTableFull
+		}
+		info.FabricIndex = index
+	}
+
+	if err := n.fabricTable.Add(info); err != nil {
+		if errors.Is(err, fabric.ErrTableFull) {
+			return fabric.FabricIndexInvalid, ErrFabricTableFull
+		}
+		return fabric.FabricIndexInvalid, ErrFabricExists
+	}
+
+	if err := n.config.Storage.SaveFabric(info); err != nil && n.log != nil {
+		n.log.Warnf("failed to persist fabric %d: %v", info.FabricIndex, err)
+	}
+	if n.log != nil {
+		n.log.Infof("fabric %d added: fabric ID 0x%016X, node 0x%016X", info.FabricIndex,
+			logger.Redact(uint64(info.FabricID)), logger.Redact(uint64(info.NodeID)))
+	}
+
+	n.failSafeFabricAddedLocked(info.FabricIndex)
+	n.readvertiseOperational()
+	if n.state == NodeStateUncommissioned {
+		n.state = NodeStateCommissioned
+		if n.config.OnStateChanged != nil {
+			n.config.OnStateChanged(n.state)
+		}
+	}
+
+	return info.FabricIndex, nil
+}
+
+// UpdateFabric replaces the operational credentials of a fabric the node is
+// on, e.g. after UpdateNOC. The fabric's label is kept.
+func (n *Node) UpdateFabric(info *fabric.FabricInfo) error {
+	n.mu.Lock()
+	err := n.fabricTable.Update(info.FabricIndex, func(existing *fabric.FabricInfo) error {
+		label := existing.Label
+		*existing = *info.Clone()
+		existing.Label = label
+		return nil
+	})
+	if err == nil {
+		n.saveFabricLocked(info.FabricIndex)
+		n.readvertiseOperational()
+	}
+	n.mu.Unlock()
+	if err != nil {
+		return ErrFabricNotFound
+	}
+
+	if n.config.OnFabricUpdated != nil {
+		n.config.OnFabricUpdated(info.FabricIndex)
+	}
+	return nil
+}
+
+// Fabric returns the fabric with the given index.
+// The returned FabricInfo is a copy.
+func (n *Node) Fabric(index fabric.FabricIndex) (*fabric.FabricInfo, bool) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.fabricTable.Get(index)
+}
+
+// FabricCount returns the number of fabrics the node is on.
+func (n *Node) FabricCount() int {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.fabricTable.Count()
+}
+
+// SupportedFabrics returns the maximum number of fabrics the node joins.
+func (n *Node) SupportedFabrics() uint8 {
+	return n.fabricTable.SupportedFabrics()
+}
+
+// FabricLabel returns the label of a fabric.
+func (n *Node) FabricLabel(index fabric.FabricIndex) (string, error) {
+	info, ok := n.Fabric(index)
+	if !ok {
+		return "", ErrFabricNotFound
+	}
+	return info.Label, nil
+}
+
+// SetFabricLabel sets the label of a fabric.
+//
+// Returns fabric.ErrLabelConflict if another fabric uses the label, or
+// fabric.ErrInvalidLabel if it is too long.
+func (n *Node) SetFabricLabel(index fabric.FabricIndex, label string) error {
+	n.mu.Lock()
+	err := n.fabricTable.UpdateLabel(index, label)
+	if err == nil {
+		n.saveFabricLocked(index)
+	}
+	n.mu.Unlock()
+	if errors.Is(err, fabric.ErrFabricNotFound) {
+		return ErrFabricNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if n.config.OnFabricUpdated != nil {
+		n.config.OnFabricUpdated(index)
+	}
+	return nil
+}
+
+// readvertiseOperational restarts operational advertising after the
+// fabric table changed. Caller must hold n.mu.
+func (n *Node) readvertiseOperational() {
+	if n.discoveryMgr == nil || !n.state.IsRunning() {
+		return
+	}
+	n.discoveryMgr.StopAdvertising(discovery.ServiceTypeOperational)
+	n.advertiseOperational()
+}
+
+// saveFabricLocked persists a fabric from the table.
+// Caller must hold n.mu.
+func (n *Node) saveFabricLocked(index fabric.FabricIndex) {
+	info, ok := n.fabricTable.Get(index)
+	if !ok {
+		return
+	}
+	if err := n.config.Storage.SaveFabric(info); err != nil && n.log != nil {
+		n.log.Warnf("failed to persist fabric %d: %v", index, err)
+	}
+}
+
+// RemoveFabric removes the node from a fabric.
+//
+// Everything the node holds for the fabric goes with it: its sessions,
+// ACL entries and access restrictions, group keys, subscriptions, CASE
+// resumption records and the operational advertisement (Spec 11.18,
+// RemoveFabric command). Clusters implementing
+// datamodel.FabricRemovalListener and the listeners added with
+// AddFabricRemovalListener then drop their own data of the fabric.
+func (n *Node) RemoveFabric(index fabric.FabricIndex) error {
+	n.mu.Lock()
+	err := n.removeFabricLocked(index)
+	n.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	n.fabricsRemoved([]fabric.FabricIndex{index})
+	return nil
+}
+
+// AddFabricRemovalListener registers an owner of fabric-scoped data kept
+// outside the data model, e.g. an application store, to be notified when
+// the node leaves a fabric, whether by RemoveFabric, FactoryReset or an
+// expired fail-safe. Clusters on the node's endpoints that implement
+// datamodel.FabricRemovalListener are notified without registering.
+//
+// Listeners run without the node's lock held and may call back into the
+// Node.
+func (n *Node) AddFabricRemovalListener(l datamodel.FabricRemovalListener) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.fabricListeners = append(n.fabricListeners, l)
+}
+
+// fabricsRemoved notifies the clusters, the registered listeners and
+// OnFabricRemoved, in that order, of removed fabrics.
+// Caller must not hold n.mu.
+func (n *Node) fabricsRemoved(removed []fabric.FabricIndex) {
+	if len(removed) == 0 {
+		return
+	}
+
+	var listeners []datamodel.FabricRemovalListener
+	for _, ep := range n.dataModel.GetEndpoints() {
+		for _, c := range ep.GetClusters() {
+			if l, ok := c.(datamodel.FabricRemovalListener); ok {
+				listeners = append(listeners, l)
+			}
+		}
+	}
+	n.mu.RLock()
+	listeners = append(listeners, n.fabricListeners...)
+	n.mu.RUnlock()
+
+	for _, index := range removed {
+		n.closeFabricICDs(index)
+		for _, l := range listeners {
+			l.FabricRemoved(index)
+		}
+		if n.config.OnFabricRemoved != nil {
+			n.config.OnFabricRemoved(index)
+		}
+	}
+}
+
+// FactoryReset returns the node to its factory state. It leaves every
+// fabric, emitting the Basic Information Leave event for each, closes the
+// remaining sessions, and wipes the persisted ACL entries, group keys,
+// subscriptions, message counters and failed PASE attempts. A running
+// node then opens a commissioning window, as on its first start.
+//
+// The fabric removal listeners and OnFabricRemoved are called for each
+// fabric, so that the application can delete the fabric's operational key
+// and other data.
+func (n *Node) FactoryReset() error {
+	n.mu.Lock()
+	var removed []fabric.FabricIndex
+	n.fabricTable.ForEach(func(info *fabric.FabricInfo) error {
+		removed = append(removed, info.FabricIndex)
+		return nil
+	})
+	for _, index := range removed {
+		if _, err := n.basicInfo.EmitLeave(uint8(index)); err != nil && n.log != nil {
+			n.log.Warnf("failed to emit Leave event for fabric %d: %v", index, err)
+		}
+		if err := n.removeFabricLocked(index); err != nil {
+			n.mu.Unlock()
+			return err
+		}
+	}
+
+	// Wipe what no fabric owns
+	storage := n.config.Storage
+	errs := []error{
+		storage.SaveACLs(nil),
+		storage.SaveGroupKeys(nil),
+		storage.SaveCounters(NewCounterState()),
+	}
+	if subs, err := storage.LoadSubscriptions(); err != nil {
+		errs = append(errs, err)
+	} else {
+		for _, rec := range subs {
+			errs = append(errs, storage.DeleteSubscription(rec.SubscriptionID))
+		}
+	}
+	if err := errors.Join(errs...); err != nil && n.log != nil {
+		n.log.Warnf("failed to wipe stored state: %v", err)
+	}
+	n.savePASEAttemptsLocked(0)
+
+	var sessions []uint16
+	n.sessionMgr.ForEachSecureSession(func(sess *session.SecureContext) bool {
+		sessions = append(sessions, sess.LocalSessionID())
+		return true
+	})
+	for _, id := range sessions {
+		n.sessionMgr.RemoveSecureContext(id)
+	}
+
+	// Become commissionable again
+	var err error
+	if n.state.IsRunning() {
+		if n.commWindow != nil {
+			n.closeCommissioningWindowLocked(nil)
+		}
+		err = n.openCommissioningWindowLocked(3*time.Minute, n.paseInfo, n.config.Discriminator, discovery.CommissioningModeBasic)
+	}
+	n.mu.Unlock()
+
+	for _, id := range sessions {
+		n.onSessionClosed(id)
+	}
+	n.fabricsRemoved(removed)
+	return err
+}
+
+// removeFabricLocked removes a fabric and its fabric-scoped data.
+// Caller must hold n.mu.
+func (n *Node) removeFabricLocked(index fabric.FabricIndex) error {
+	if err := n.fabricTable.Remove(index); errors.Is(err, fabric.ErrFabricNotFound) {
+		return ErrFabricNotFound
+	} else if err != nil && n.log != nil {
+		// The fabric is gone from the table; its state goes with it
+		n.log.Warnf("fabric %d removed, but: %v", index, err)
+	}
+
+	// Close the fabric's sessions, zeroizing their keys
+	n.removeFabricSessionsLocked(index)
+
+	// Leave the fabric's groups
+	n.leaveFabricGroupsLocked(index)
+
+	// Forget the fabric's peers
+	n.casePool.removeFabric(index)
+
+	// Drop the fabric's ACL entries and access restrictions
+	if err := n.aclMgr.DeleteAllForFabric(index); err != nil && n.log != nil {
+		n.log.Warnf("failed to delete ACL entries of fabric %d: %v", index, err)
+	}
+	if n.accessControl != nil {
+		n.accessControl.IncrementDataVersion()
+	}
+
+	// End the fabric's subscriptions
+	if n.imEngine != nil {
+		n.imEngine.RemoveFabricSubscriptions(index)
+	}
+
+	// Remove from storage
+	n.deleteFabricStateLocked(index)
+
+	// Stop advertising on the fabric
+	n.readvertiseOperational()
+
+	// Update state if no fabrics remain
+	if n.fabricTable.Count() == 0 && n.state == NodeStateCommissioned {
+		n.state = NodeStateUncommissioned
+		if n.config.OnStateChanged != nil {
+			n.config.OnStateChanged(n.state)
+		}
+	}
+
+	return nil
+}
+
+// removeFabricSessionsLocked closes the sessions of a fabric. A session
+// serving an IM request, e.g. the RemoveFabric of the fabric it is on, is
+// closed once the response is sent.
+//
+// Spec: Section 11.18.6.12
+// Caller must hold n.mu.
+func (n *Node) removeFabricSessionsLocked(index fabric.FabricIndex) {
+	n.requestsMu.Lock()
+	var serving []uint16
+	for id := range n.imRequests {
+		if sess := n.sessionMgr.FindSecureContext(id); sess != nil && sess.FabricIndex() == index {
+			serving = append(serving, id)
+			n.closeAfterResponse[id] = struct{}{}
+		}
+	}
+	n.requestsMu.Unlock()
+
+	n.sessionMgr.RemoveFabric(index, serving...)
+}
+
+// beginIMRequest notes that an IM request on a session is being served.
+func (n *Node) beginIMRequest(localSessionID uint16) {
+	n.requestsMu.Lock()
+	n.imRequests[localSessionID]++
+	n.requestsMu.Unlock()
+}
+
+// endIMRequest notes that an IM request on a session was served, and
+// closes the session if it was left open only to send the response.
+func (n *Node) endIMRequest(localSessionID uint16) {
+	n.requestsMu.Lock()
+	n.imRequests[localSessionID]--
+	if n.imRequests[localSessionID] > 0 {
+		n.requestsMu.Unlock()
+		return
+	}
+	delete(n.imRequests, localSessionID)
+	_, closing := n.closeAfterResponse[localSessionID]
+	delete(n.closeAfterResponse, localSessionID)
+	n.requestsMu.Unlock()
+
+	if closing {
+		n.sessionMgr.RemoveSecureContext(localSessionID)
+		n.onSessionClosed(localSessionID)
+	}
+}
+
+// deleteFabricStateLocked removes a fabric's persisted state: the fabric,
+// its group keys, its ACL entries and its subscriptions.
+// Caller must hold n.mu.
+func (n *Node) deleteFabricStateLocked(index fabric.FabricIndex) {
+	storage := n.config.Storage
+	var errs []error
+
+	errs = append(errs, storage.DeleteFabric(index))
+
+	if keys, err := storage.LoadGroupKeys(); err != nil {
+		errs = append(errs, err)
+	} else {
+		kept := keys[:0]
+		for _, key := range keys {
+			if key.FabricIndex != index {
+				kept = append(kept, key)
+			}
+		}
+		if len(kept) != len(keys) {
+			errs = append(errs, storage.SaveGroupKeys(kept))
+		}
+	}
+
+	// Rewrite the ACL from the remaining fabrics' entries
+	var entries []*acl.Entry
+	n.fabricTable.ForEach(func(info *fabric.FabricInfo) error {
+		fabricEntries, err := n.aclMgr.GetEntries(info.FabricIndex)
+		if err != nil {
+			return nil
+		}
+		for i := range fabricEntries {
+			entries = append(entries, &fabricEntries[i])
+		}
+		return nil
+	})
+	errs = append(errs, storage.SaveACLs(entries))
+
+	if subs, err := storage.LoadSubscriptions(); err != nil {
+		errs = append(errs, err)
+	} else {
+		for _, rec := range subs {
+			if rec.FabricIndex == index {
+				errs = append(errs, storage.DeleteSubscription(rec.SubscriptionID))
+			}
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil && n.log != nil {
+		n.log.Warnf("failed to delete stored state of fabric %d: %v", index, err)
+	}
+}
+
+// opCredsManager joins and leaves fabrics for the Operational Credentials
+// cluster.
+type opCredsManager struct {
+	n *Node
+}
+
+// AddFabric implements operationalcredentials.FabricManager. The
+// administrator that added the fabric manages the node on it over CASE.
+func (m opCredsManager) AddFabric(info *fabric.FabricInfo, caseAdminSubject uint64) (fabric.FabricIndex, error) {
+	n := m.n
+	index, err := n.AddFabric(info)
+	if err != nil {
+		return fabric.FabricIndexInvalid, err
+	}
+
+	if _, err := n.aclMgr.CreateEntry(index, acl.Entry{
+		Privilege: acl.PrivilegeAdminister,
+		AuthMode:  acl.AuthModeCASE,
+		Subjects:  []uint64{caseAdminSubject},
+	}); err != nil {
+		n.RemoveFabric(index)
+		return fabric.FabricIndexInvalid, err
+	}
+	if n.accessControl != nil {
+		n.accessControl.IncrementDataVersion()
+	}
+	return index, nil
+}
+
+// RemoveFabric implements operationalcredentials.FabricManager.
+func (m opCredsManager) RemoveFabric(index fabric.FabricIndex) error {
+	return m.n.RemoveFabric(index)
+}
+
+// UpdateFabric implements operationalcredentials.FabricManager.
+func (m opCredsManager) UpdateFabric(info *fabric.FabricInfo) error {
+	return m.n.UpdateFabric(info)
+}
+
+// SetFabricLabel implements operationalcredentials.FabricManager.
+func (m opCredsManager) SetFabricLabel(index fabric.FabricIndex, label string) error {
+	return m.n.SetFabricLabel(index, label)
+}
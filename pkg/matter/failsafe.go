@@ -0,0 +1,218 @@
+package matter
+
+import (
+	"time"
+
+	"github.com/backkem/matter/pkg/clock"
+	"github.com/backkem/matter/pkg/commissioning"
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/session"
+)
+
+// Fail-safe limits, advertised in the General Commissioning
+// BasicCommissioningInfo attribute.
+const (
+	// FailSafeExpiryLength is the fail-safe duration a commissioner
+	// should arm at first.
+	FailSafeExpiryLength = 60 * time.Second
+
+	// MaxCumulativeFailSafe bounds the time the fail-safe stays armed
+	// from its first arming, however often it is extended.
+	MaxCumulativeFailSafe = 900 * time.Second
+)
+
+// failSafeContext is the state of an armed fail-safe.
+type failSafeContext struct {
+	timer       clock.Timer
+	fabricIndex fabric.FabricIndex // Fabric that armed it, or the one added under it
+	deadline    time.Time          // Bound set by MaxCumulativeFailSafe
+
+	// Fabrics added while armed; they are removed unless commissioning
+	// completes
+	added []fabric.FabricIndex
+}
+
+// failSafeManager exposes the node's fail-safe to the General
+// Commissioning cluster.
+type failSafeManager struct {
+	n *Node
+}
+
+// IsArmed implements generalcommissioning.FailSafeManager.
+func (m failSafeManager) IsArmed() bool {
+	m.n.mu.RLock()
+	defer m.n.mu.RUnlock()
+	return m.n.failSafe != nil
+}
+
+// ArmedFabricIndex implements generalcommissioning.FailSafeManager.
+func (m failSafeManager) ArmedFabricIndex() fabric.FabricIndex {
+	m.n.mu.RLock()
+	defer m.n.mu.RUnlock()
+	if m.n.failSafe == nil {
+		return 0
+	}
+	return m.n.failSafe.fabricIndex
+}
+
+// Arm implements generalcommissioning.FailSafeManager.
+func (m failSafeManager) Arm(fabricIndex fabric.FabricIndex, expirySeconds uint16) error {
+	n := m.n
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.failSafe != nil {
+		return ErrFailSafeArmed
+	}
+	n.failSafe = &failSafeContext{
+		fabricIndex: fabricIndex,
+		deadline:    n.clock.Now().Add(MaxCumulativeFailSafe),
+	}
+	n.armFailSafeLocked(time.Duration(expirySeconds) * time.Second)
+	return nil
+}
+
+// ExtendArm implements generalcommissioning.FailSafeManager.
+func (m failSafeManager) ExtendArm(fabricIndex fabric.FabricIndex, expirySeconds uint16) error {
+	n := m.n
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.failSafe == nil {
+		return ErrFailSafeNotArmed
+	}
+	n.armFailSafeLocked(time.Duration(expirySeconds) * time.Second)
+	return nil
+}
+
+// Disarm implements generalcommissioning.FailSafeManager. A commissioner
+// disarms the fail-safe with an expiry of zero to abort commissioning,
+// which cleans up as if the fail-safe expired. The commissioner's PASE
+// session is kept for it to close: the response goes out over it.
+func (m failSafeManager) Disarm(fabricIndex fabric.FabricIndex) error {
+	return m.n.expireFailSafe(ErrCommissioningTerminated, false)
+}
+
+// Complete implements generalcommissioning.FailSafeManager. It commits
+// the fabrics added under the fail-safe.
+func (m failSafeManager) Complete(fabricIndex fabric.FabricIndex) error {
+	n := m.n
+	n.mu.Lock()
+	if n.failSafe == nil {
+		n.mu.Unlock()
+		return ErrFailSafeNotArmed
+	}
+	n.failSafe.timer.Stop()
+	n.failSafe = nil
+	n.mu.Unlock()
+
+	n.operationalCredentials.OnFailSafeEnded()
+	n.onCommissioningComplete(fabricIndex)
+	return nil
+}
+
+// armFailSafeLocked (re)starts the fail-safe timer, bounded by the
+// fail-safe's cumulative deadline. Caller must hold n.mu.
+func (n *Node) armFailSafeLocked(expiry time.Duration) {
+	fs := n.failSafe
+	expiry = min(expiry, fs.deadline.Sub(n.clock.Now()))
+	if fs.timer != nil {
+		fs.timer.Stop()
+	}
+	fs.timer = n.clock.AfterFunc(expiry, func() {
+		// A re-armed fail-safe has a new timer
+		n.mu.RLock()
+		current := n.failSafe == fs
+		n.mu.RUnlock()
+		if current {
+			n.expireFailSafe(commissioning.ErrFailSafeExpired, true)
+		}
+	})
+}
+
+// failSafeFabricAddedLocked records a fabric added under the fail-safe,
+// which the commissioner completes commissioning on.
+// Caller must hold n.mu.
+func (n *Node) failSafeFabricAddedLocked(index fabric.FabricIndex) {
+	if n.failSafe == nil {
+		return
+	}
+	n.failSafe.added = append(n.failSafe.added, index)
+	n.failSafe.fabricIndex = index
+}
+
+// TerminateCommissioning aborts the commissioning in progress, e.g. when
+// the user cancels it on the device. The fail-safe expires at once.
+// Returns ErrFailSafeNotArmed if no commissioner armed it.
+func (n *Node) TerminateCommissioning() error {
+	return n.expireFailSafe(ErrCommissioningTerminated, true)
+}
+
+// IsFailSafeArmed reports whether a commissioner armed the fail-safe.
+func (n *Node) IsFailSafeArmed() bool {
+	return failSafeManager{n}.IsArmed()
+}
+
+// expireFailSafe cleans up after commissioning that did not complete:
+// the fabrics added under the fail-safe are removed, an operational key
+// generated for a CSR is deleted, the Breadcrumb is reset, the PASE
+// sessions are closed if closePASE is set, and a node left without
+// fabrics becomes commissionable again. OnFailSafeExpired
+// then lets the application revert its network configuration.
+func (n *Node) expireFailSafe(reason error, closePASE bool) error {
+	n.mu.Lock()
+	fs := n.failSafe
+	if fs == nil {
+		n.mu.Unlock()
+		return ErrFailSafeNotArmed
+	}
+	n.failSafe = nil
+	fs.timer.Stop()
+
+	var removed []fabric.FabricIndex
+	for _, index := range fs.added {
+		if n.removeFabricLocked(index) == nil {
+			removed = append(removed, index)
+		}
+	}
+	if n.generalCommissioning != nil {
+		n.generalCommissioning.SetBreadcrumb(0)
+	}
+
+	var pase []*session.SecureContext
+	if closePASE {
+		n.sessionMgr.ForEachSecureSession(func(sess *session.SecureContext) bool {
+			if sess.SessionType() == session.SessionTypePASE && sess.Role() == session.SessionRoleResponder {
+				pase = append(pase, sess)
+			}
+			return true
+		})
+	}
+
+	if n.state.IsRunning() && n.fabricTable.Count() == 0 && n.commWindow == nil {
+		n.openBasicCommissioningWindowLocked()
+	}
+	exchangeMgr := n.exchangeMgr
+	n.mu.Unlock()
+
+	if n.log != nil {
+		n.log.Infof("fail-safe cleanup: %v", reason)
+	}
+	for _, sess := range pase {
+		id := sess.LocalSessionID()
+		if exchangeMgr != nil {
+			if addr, ok := exchangeMgr.SessionPeerAddress(id); ok {
+				n.CloseSession(sess, addr)
+				continue
+			}
+		}
+		n.sessionMgr.RemoveSecureContext(id)
+		n.onSessionClosed(id)
+	}
+	n.fabricsRemoved(removed)
+	n.operationalCredentials.OnFailSafeExpired()
+	if n.config.OnFailSafeExpired != nil {
+		n.config.OnFailSafeExpired(reason)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package matter
+
+import (
+	"github.com/backkem/matter/pkg/crypto"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/session"
+)
+
+// Group is the node's membership of a group on one of its fabrics, as
+// the Groups and Group Key Management clusters configure it.
+type Group struct {
+	FabricIndex fabric.FabricIndex
+	GroupID     uint16
+
+	// EpochKeys are the epoch keys of the group's key set (16 bytes
+	// each). Messages encrypted with any of them are accepted, so that
+	// members keep up while the key set rotates.
+	EpochKeys [][]byte
+
+	// Endpoints are the node's endpoints in the group, on which the
+	// group's commands are executed.
+	Endpoints []datamodel.EndpointID
+}
+
+// groupRef identifies a group on a fabric.
+type groupRef struct {
+	fabricIndex fabric.FabricIndex
+	groupID     uint16
+}
+
+// JoinGroup makes the node a member of a group: it derives the group's
+// operational keys, subscribes to the group's multicast address and
+// executes the group's commands on its endpoints, as far as the ACL grants
+// the group. Joining a group again replaces its keys and endpoints.
+//
+// Group membership is not persisted.
+//
+// Spec: Section 4.17 (group communication)
+func (n *Node) JoinGroup(group Group) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	info, ok := n.fabricTable.Get(group.FabricIndex)
+	if !ok {
+		return ErrFabricNotFound
+	}
+
+	// Spec 4.17.2: operational group keys and session IDs
+	keys := make([]session.GroupKey, 0, len(group.EpochKeys))
+	for _, epochKey := range group.EpochKeys {
+		key, err := crypto.DeriveGroupOperationalKeyV1(epochKey, info.CompressedFabricID[:])
+		if err != nil {
+			return err
+		}
+		sessionID, err := crypto.DeriveGroupSessionIDV1(key)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, session.GroupKey{
+			FabricIndex:    group.FabricIndex,
+			GroupID:        group.GroupID,
+			GroupSessionID: sessionID,
+			OperationalKey: key,
+			LocalNodeID:    info.NodeID,
+		})
+	}
+
+	ref := groupRef{fabricIndex: group.FabricIndex, groupID: group.GroupID}
+	n.sessionMgr.RemoveGroupKeys(ref.fabricIndex, ref.groupID)
+	for _, key := range keys {
+		if err := n.sessionMgr.AddGroupKey(key); err != nil {
+			return err
+		}
+	}
+	n.dispatcher.setGroupEndpoints(ref, group.Endpoints)
+	n.groups[ref] = info.FabricID
+
+	if n.state.IsRunning() {
+		return n.transportMgr.JoinGroup(uint64(info.FabricID), group.GroupID)
+	}
+	return nil
+}
+
+// LeaveGroup ends the node's membership of a group. Leaving a group the
+// node is not a member of has no effect.
+func (n *Node) LeaveGroup(fabricIndex fabric.FabricIndex, groupID uint16) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.leaveGroupLocked(groupRef{fabricIndex: fabricIndex, groupID: groupID})
+}
+
+// leaveGroupLocked forgets a group's keys and endpoints and unsubscribes
+// from its multicast address.
+// Caller must hold n.mu.
+func (n *Node) leaveGroupLocked(ref groupRef) error {
+	fabricID, ok := n.groups[ref]
+	if !ok {
+		return nil
+	}
+	delete(n.groups, ref)
+	n.sessionMgr.RemoveGroupKeys(ref.fabricIndex, ref.groupID)
+	n.dispatcher.setGroupEndpoints(ref, nil)
+
+	if n.state.IsRunning() {
+		return n.transportMgr.LeaveGroup(uint64(fabricID), ref.groupID)
+	}
+	return nil
+}
+
+// leaveFabricGroupsLocked leaves the groups of a removed fabric.
+// Caller must hold n.mu.
+func (n *Node) leaveFabricGroupsLocked(index fabric.FabricIndex) {
+	for ref := range n.groups {
+		if ref.fabricIndex != index {
+			continue
+		}
+		if err := n.leaveGroupLocked(ref); err != nil && n.log != nil {
+			n.log.Warnf("failed to leave group 0x%04x: %v", ref.groupID, err)
+		}
+	}
+}
+
+// joinGroupsLocked subscribes the started transport to the multicast
+// addresses of the node's groups.
+// Caller must hold n.mu.
+func (n *Node) joinGroupsLocked() {
+	for ref, fabricID := range n.groups {
+		if err := n.transportMgr.JoinGroup(uint64(fabricID), ref.groupID); err != nil && n.log != nil {
+			n.log.Warnf("failed to join group 0x%04x: %v", ref.groupID, err)
+		}
+	}
+}
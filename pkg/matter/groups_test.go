@@ -0,0 +1,134 @@
+package matter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/backkem/matter/pkg/acl"
+	"github.com/backkem/matter/pkg/clusters/onoff"
+	"github.com/backkem/matter/pkg/crypto"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/exchange"
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/im"
+	"github.com/backkem/matter/pkg/message"
+	"github.com/backkem/matter/pkg/session"
+	"github.com/backkem/matter/pkg/transport"
+)
+
+func TestNodeGroupInvoke(t *testing.T) {
+	network := transport.NewPipeNetwork()
+	defer network.Close()
+
+	node, err := NewNode(NodeConfig{
+		VendorID:         0xFFF1,
+		ProductID:        0x8001,
+		Discriminator:    3840,
+		Passcode:         20202021,
+		Storage:          NewMemoryStorage(),
+		TransportFactory: network.NewFactory(),
+	})
+	if err != nil {
+		t.Fatalf("NewNode failed: %v", err)
+	}
+	light := onoff.New(onoff.Config{EndpointID: 1})
+	if err := node.AddEndpoint(NewEndpoint(1).WithDeviceType(0x0100, 1).AddCluster(light)); err != nil {
+		t.Fatalf("AddEndpoint failed: %v", err)
+	}
+
+	info := &fabric.FabricInfo{
+		FabricID:           0x100,
+		NodeID:             0x1,
+		CompressedFabricID: [fabric.CompressedFabricIDSize]byte{1, 2, 3, 4, 5, 6, 7, 8},
+	}
+	index, err := node.AddFabric(info)
+	if err != nil {
+		t.Fatalf("AddFabric failed: %v", err)
+	}
+	if _, err := node.aclMgr.CreateEntry(index, acl.Entry{
+		Privilege: acl.PrivilegeOperate,
+		AuthMode:  acl.AuthModeGroup,
+		Subjects:  []uint64{acl.NodeIDFromGroupID(0x0101)},
+	}); err != nil {
+		t.Fatalf("CreateEntry failed: %v", err)
+	}
+
+	epochKey := []byte("group-epoch-key!")
+	if err := node.JoinGroup(Group{
+		FabricIndex: index,
+		GroupID:     0x0101,
+		EpochKeys:   [][]byte{epochKey},
+		Endpoints:   []datamodel.EndpointID{1},
+	}); err != nil {
+		t.Fatalf("JoinGroup failed: %v", err)
+	}
+	if err := node.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer node.Stop(context.Background())
+
+	// A controller on the fabric sends Toggle to the group
+	conn, err := network.NewFactory().CreateUDPConn(transport.DefaultPort)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm, err := transport.NewManager(transport.ManagerConfig{
+		UDPConn:        conn,
+		UDPEnabled:     true,
+		MessageHandler: func(*transport.ReceivedMessage) {},
+	})
+	if err != nil {
+		t.Fatalf("transport.NewManager: %v", err)
+	}
+	defer tm.Stop()
+	client := im.NewClient(im.ClientConfig{
+		ExchangeManager: exchange.NewManager(exchange.ManagerConfig{TransportManager: tm}),
+	})
+
+	key, err := crypto.DeriveGroupOperationalKeyV1(epochKey, info.CompressedFabricID[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionID, err := crypto.DeriveGroupSessionIDV1(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	group, err := session.NewGroupContext(session.GroupContextConfig{
+		SourceNodeID:   0x2,
+		FabricIndex:    index,
+		GroupID:        0x0101,
+		GroupSessionID: sessionID,
+		OperationalKey: key,
+		Counter:        message.NewMessageCounter(),
+	})
+	if err != nil {
+		t.Fatalf("NewGroupContext: %v", err)
+	}
+	toggle := func() {
+		t.Helper()
+		peerAddr := transport.NewGroupPeerAddress(uint64(info.FabricID), 0x0101)
+		if err := client.GroupInvoke(context.Background(), group, peerAddr, uint32(onoff.ClusterID), 0x02, nil); err != nil {
+			t.Fatalf("GroupInvoke: %v", err)
+		}
+	}
+
+	toggle()
+	deadline := time.Now().Add(time.Second)
+	for !light.GetOnOff() {
+		if time.Now().After(deadline) {
+			t.Fatal("group Toggle was not executed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Once the node left the group, its commands are ignored
+	if err := node.LeaveGroup(index, 0x0101); err != nil {
+		t.Fatalf("LeaveGroup failed: %v", err)
+	}
+	toggle()
+	time.Sleep(100 * time.Millisecond)
+	if !light.GetOnOff() {
+		t.Error("group Toggle executed after LeaveGroup")
+	}
+}
@@ -0,0 +1,411 @@
+package matter
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/backkem/matter/pkg/clusters/icdmanagement"
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/im"
+	imsg "github.com/backkem/matter/pkg/im/message"
+	"github.com/backkem/matter/pkg/securechannel"
+	"github.com/backkem/matter/pkg/session"
+	"github.com/backkem/matter/pkg/transport"
+)
+
+// ICDConfig configures a client registration with a Long Idle Time ICD.
+type ICDConfig struct {
+	// FabricIndex and NodeID identify the ICD on one of the node's
+	// fabrics.
+	FabricIndex fabric.FabricIndex
+	NodeID      fabric.NodeID
+
+	// Registration resumes a registration made earlier, e.g. one saved
+	// from ICD.Registration before the node restarted.
+	// Optional - if nil, the node registers with the ICD.
+	Registration *ICDRegistration
+
+	// MonitoredSubject is the subject whose subscriptions stop the ICD
+	// from checking in. Defaults to the node's own node ID if zero.
+	MonitoredSubject uint64
+
+	// ClientType is the kind of registration. Defaults to
+	// icdmanagement.ClientTypePermanent.
+	ClientType icdmanagement.ClientType
+
+	// OnCheckIn is called with each Check-In message accepted from the
+	// ICD, before queued interactions run.
+	OnCheckIn func(securechannel.CheckIn)
+}
+
+// ICDRegistration is the state of a registration with an ICD. Save it
+// to resume the registration with ICDConfig.Registration.
+type ICDRegistration struct {
+	// CheckInNodeID is the node the ICD checks in with: this node.
+	CheckInNodeID fabric.NodeID
+
+	// MonitoredSubject is the subject registered with the ICD.
+	MonitoredSubject uint64
+
+	// Key is the 16-byte key of the ICD's Check-In messages.
+	Key []byte
+
+	// Counter is the ICD's Check-In counter at registration, or of the
+	// last Check-In message accepted.
+	Counter uint32
+}
+
+// ICDInteraction is an interaction with an ICD, run on a CASE session
+// while the ICD is active.
+type ICDInteraction func(ctx context.Context, sess *session.SecureContext, peerAddr transport.PeerAddress) error
+
+// icdRequest is an interaction waiting for the ICD to check in.
+type icdRequest struct {
+	ctx  context.Context
+	fn   ICDInteraction
+	done chan error
+}
+
+// ICD is a registration of the node, as a client, with a Long Idle Time
+// Intermittently Connected Device. A LIT ICD sleeps for long periods and
+// cannot be reached then; when it wakes up it sends each registered
+// client without an active subscription a Check-In message, encrypted
+// with the key the client registered.
+//
+// Interactions queued with Do run on the next Check-In, over a CASE
+// session from Node.FindOrEstablishSession. KeepActive asks the ICD to
+// stay active for longer, e.g. for a series of interactions.
+//
+// Check-In messages whose counter is not ahead of the last one accepted
+// are dropped as replays.
+//
+// C++ Reference: app::CheckInHandler, app::DefaultICDClientStorage
+type ICD struct {
+	config ICDConfig
+	// establish finds or establishes a session with the ICD.
+	establish func(ctx context.Context) (*session.SecureContext, transport.PeerAddress, error)
+	// invoke invokes an ICD Management command on the ICD.
+	invoke func(ctx context.Context, sess *session.SecureContext, peerAddr transport.PeerAddress, cmd uint32, req []byte) ([]byte, error)
+	// base bounds the interactions run on a Check-In.
+	base context.Context
+
+	mu      sync.Mutex
+	reg     ICDRegistration
+	queue   []*icdRequest
+	running bool
+	closed  bool
+}
+
+// RegisterICD registers the node with an ICD, or resumes the
+// registration in ICDConfig.Registration, and starts listening for its
+// Check-In messages. Registering sends RegisterClient with a new random
+// key over a CASE session, so the ICD must be active. The node must be
+// started and have an operational key (see NodeConfig.OperationalKey);
+// the ICD must be closed.
+func (n *Node) RegisterICD(ctx context.Context, config ICDConfig) (*ICD, error) {
+	n.mu.RLock()
+	running, base := n.state.IsRunning(), n.ctx
+	info, hasFabric := n.fabricTable.Get(config.FabricIndex)
+	n.mu.RUnlock()
+
+	if !running {
+		return nil, ErrNotStarted
+	}
+	if !hasFabric {
+		return nil, ErrFabricNotFound
+	}
+	if n.config.OperationalKey == nil {
+		return nil, ErrNoOperationalKey
+	}
+
+	icd := newICD(config, base, func(ctx context.Context) (*session.SecureContext, transport.PeerAddress, error) {
+		return n.FindOrEstablishSession(ctx, config.FabricIndex, config.NodeID)
+	})
+	icd.invoke = n.invokeICD
+	if config.Registration != nil {
+		if len(config.Registration.Key) != securechannel.CheckInKeySize {
+			return nil, securechannel.ErrInvalidCheckInKey
+		}
+		icd.reg = *config.Registration
+	} else {
+		reg, err := icd.register(ctx, info.NodeID)
+		if err != nil {
+			return nil, err
+		}
+		icd.reg = reg
+	}
+
+	n.mu.Lock()
+	n.icds[icd] = struct{}{}
+	n.mu.Unlock()
+	return icd, nil
+}
+
+func newICD(config ICDConfig, base context.Context, establish func(ctx context.Context) (*session.SecureContext, transport.PeerAddress, error)) *ICD {
+	return &ICD{
+		config:    config,
+		establish: establish,
+		base:      base,
+	}
+}
+
+// register sends RegisterClient to the ICD with a new key.
+func (i *ICD) register(ctx context.Context, nodeID fabric.NodeID) (ICDRegistration, error) {
+	key := make([]byte, securechannel.CheckInKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return ICDRegistration{}, err
+	}
+	reg := ICDRegistration{
+		CheckInNodeID:    nodeID,
+		MonitoredSubject: i.config.MonitoredSubject,
+		Key:              key,
+	}
+	if reg.MonitoredSubject == 0 {
+		reg.MonitoredSubject = uint64(nodeID)
+	}
+
+	req, err := icdmanagement.EncodeRegisterClientRequest(&icdmanagement.RegisterClientRequest{
+		CheckInNodeID:    uint64(reg.CheckInNodeID),
+		MonitoredSubject: reg.MonitoredSubject,
+		Key:              reg.Key,
+		ClientType:       i.config.ClientType,
+	})
+	if err != nil {
+		return ICDRegistration{}, err
+	}
+
+	sess, peerAddr, err := i.establish(ctx)
+	if err != nil {
+		return ICDRegistration{}, err
+	}
+	data, err := i.invoke(ctx, sess, peerAddr, uint32(icdmanagement.CmdRegisterClient), req)
+	if err != nil {
+		return ICDRegistration{}, err
+	}
+	resp, err := icdmanagement.DecodeRegisterClientResponse(data)
+	if err != nil {
+		return ICDRegistration{}, err
+	}
+	reg.Counter = resp.ICDCounter
+	return reg, nil
+}
+
+// invokeICD invokes an ICD Management command on the ICD's root endpoint
+// and returns the response fields.
+func (n *Node) invokeICD(ctx context.Context, sess *session.SecureContext, peerAddr transport.PeerAddress, cmd uint32, req []byte) ([]byte, error) {
+	client := im.NewClient(im.ClientConfig{
+		ExchangeManager: n.ExchangeManager(),
+		LoggerFactory:   n.config.LoggerFactory,
+		TracerProvider:  n.config.TracerProvider,
+	})
+	res, err := client.InvokeWithStatus(ctx, sess, peerAddr, uint16(RootEndpointID), uint32(icdmanagement.ClusterID), cmd, req)
+	if err != nil {
+		return nil, err
+	}
+	if res.HasStatus && res.Status != imsg.StatusSuccess {
+		return nil, NewStatusError(res.Status)
+	}
+	return res.ResponseData, nil
+}
+
+// Registration returns the state of the registration, with the counter of
+// the last Check-In message accepted.
+func (i *ICD) Registration() ICDRegistration {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	reg := i.reg
+	reg.Key = append([]byte(nil), i.reg.Key...)
+	return reg
+}
+
+// Do queues an interaction for the next time the ICD checks in, and
+// waits for it to run. It returns the interaction's error, the error
+// establishing a session with the ICD, ctx's error if ctx ends first, or
+// ErrICDClosed.
+func (i *ICD) Do(ctx context.Context, fn ICDInteraction) error {
+	req := &icdRequest{ctx: ctx, fn: fn, done: make(chan error, 1)}
+
+	i.mu.Lock()
+	if i.closed {
+		i.mu.Unlock()
+		return ErrICDClosed
+	}
+	i.queue = append(i.queue, req)
+	i.mu.Unlock()
+
+	select {
+	case err := <-req.done:
+		return err
+	case <-ctx.Done():
+		// The request is skipped when the ICD checks in
+		return ctx.Err()
+	}
+}
+
+// KeepActive asks the ICD, the next time it checks in, to stay active for
+// a duration with StayActiveRequest. It returns the duration the ICD
+// promises, which may differ from the one requested.
+func (i *ICD) KeepActive(ctx context.Context, duration time.Duration) (time.Duration, error) {
+	req, err := icdmanagement.EncodeStayActiveRequest(&icdmanagement.StayActiveRequest{
+		StayActiveDuration: uint32(duration.Milliseconds()),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var promised time.Duration
+	err = i.Do(ctx, func(ctx context.Context, sess *session.SecureContext, peerAddr transport.PeerAddress) error {
+		data, err := i.invoke(ctx, sess, peerAddr, uint32(icdmanagement.CmdStayActiveRequest), req)
+		if err != nil {
+			return err
+		}
+		resp, err := icdmanagement.DecodeStayActiveResponse(data)
+		if err != nil {
+			return err
+		}
+		promised = time.Duration(resp.PromisedActiveDuration) * time.Millisecond
+		return nil
+	})
+	return promised, err
+}
+
+// Close stops listening for the ICD's Check-In messages. Queued
+// interactions return ErrICDClosed. The registration stays on the ICD;
+// unregister with UnregisterClient to remove it.
+func (i *ICD) Close() error {
+	i.mu.Lock()
+	if i.closed {
+		i.mu.Unlock()
+		return nil
+	}
+	i.closed = true
+	queue := i.queue
+	i.queue = nil
+	i.mu.Unlock()
+
+	for _, req := range queue {
+		req.done <- ErrICDClosed
+	}
+	return nil
+}
+
+// isClosed reports whether the ICD was closed.
+func (i *ICD) isClosed() bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.closed
+}
+
+// checkIn handles a Check-In message decrypted with the ICD's key. It
+// reports whether the message was accepted, and runs the queued
+// interactions if so.
+func (i *ICD) checkIn(msg *securechannel.CheckIn) bool {
+	i.mu.Lock()
+	// The counter must be ahead of the last one, within half its range
+	if delta := msg.Counter - i.reg.Counter; i.closed || delta == 0 || delta >= 1<<31 {
+		i.mu.Unlock()
+		return false
+	}
+	i.reg.Counter = msg.Counter
+	run := len(i.queue) > 0 && !i.running
+	if run {
+		i.running = true
+	}
+	i.mu.Unlock()
+
+	if i.config.OnCheckIn != nil {
+		i.config.OnCheckIn(*msg)
+	}
+	if run {
+		go i.runQueue(msg)
+	}
+	return true
+}
+
+// runQueue runs the queued interactions while the ICD is active, over one
+// session. Interactions queued meanwhile run too.
+func (i *ICD) runQueue(msg *securechannel.CheckIn) {
+	// The ICD listens for at least its active mode threshold
+	timeout := DefaultProbeTimeout
+	if threshold := time.Duration(msg.ActiveModeThreshold) * time.Millisecond; threshold > timeout {
+		timeout = threshold
+	}
+	ctx, cancel := context.WithTimeout(i.base, timeout)
+	defer cancel()
+
+	sess, peerAddr, err := i.establish(ctx)
+	for {
+		i.mu.Lock()
+		if len(i.queue) == 0 || i.closed {
+			i.running = false
+			i.mu.Unlock()
+			return
+		}
+		req := i.queue[0]
+		i.queue = i.queue[1:]
+		i.mu.Unlock()
+
+		if req.ctx.Err() != nil {
+			// Abandoned by Do
+			continue
+		}
+		if err != nil {
+			req.done <- fmt.Errorf("matter: ICD session: %w", err)
+			continue
+		}
+		req.done <- req.fn(req.ctx, sess, peerAddr)
+	}
+}
+
+// handleCheckIn finds the ICD whose key decrypts a Check-In message, and
+// hands it the message.
+func (n *Node) handleCheckIn(payload []byte) {
+	n.mu.Lock()
+	icds := make([]*ICD, 0, len(n.icds))
+	for icd := range n.icds {
+		if icd.isClosed() {
+			delete(n.icds, icd)
+		} else {
+			icds = append(icds, icd)
+		}
+	}
+	n.mu.Unlock()
+
+	for _, icd := range icds {
+		icd.mu.Lock()
+		key := icd.reg.Key
+		icd.mu.Unlock()
+
+		msg, err := securechannel.DecodeCheckIn(key, payload)
+		if err != nil {
+			continue
+		}
+		if !icd.checkIn(msg) && n.log != nil {
+			n.log.Debugf("dropping Check-In from node 0x%016x with stale counter %d", uint64(icd.config.NodeID), msg.Counter)
+		}
+		return
+	}
+	if n.log != nil {
+		n.log.Debugf("dropping Check-In from an unknown ICD")
+	}
+}
+
+// closeFabricICDs closes the ICD registrations on a removed fabric.
+func (n *Node) closeFabricICDs(index fabric.FabricIndex) {
+	n.mu.Lock()
+	var closing []*ICD
+	for icd := range n.icds {
+		if icd.config.FabricIndex == index {
+			closing = append(closing, icd)
+			delete(n.icds, icd)
+		}
+	}
+	n.mu.Unlock()
+
+	for _, icd := range closing {
+		icd.Close()
+	}
+}
@@ -0,0 +1,183 @@
+package matter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/backkem/matter/pkg/message"
+	"github.com/backkem/matter/pkg/securechannel"
+	"github.com/backkem/matter/pkg/session"
+	"github.com/backkem/matter/pkg/transport"
+)
+
+// testICD creates an ICD registration on a node, whose sessions come from
+// establish rather than CASE.
+func testICD(t *testing.T, node *Node, key []byte, counter uint32, config ICDConfig) *ICD {
+	t.Helper()
+	icd := newICD(config, context.Background(), func(ctx context.Context) (*session.SecureContext, transport.PeerAddress, error) {
+		return nil, transport.PeerAddress{}, nil
+	})
+	icd.reg = ICDRegistration{Key: key, Counter: counter}
+	node.mu.Lock()
+	node.icds[icd] = struct{}{}
+	node.mu.Unlock()
+	t.Cleanup(func() { icd.Close() })
+	return icd
+}
+
+func checkInPayload(t *testing.T, key []byte, counter uint32) []byte {
+	t.Helper()
+	payload, err := securechannel.EncodeCheckIn(key, securechannel.CheckIn{Counter: counter, ActiveModeThreshold: 5000})
+	if err != nil {
+		t.Fatalf("EncodeCheckIn() error = %v", err)
+	}
+	return payload
+}
+
+func TestICD_CheckIn(t *testing.T) {
+	node, err := NewNode(NodeConfig{
+		VendorID:      0xFFF1,
+		ProductID:     0x8001,
+		Discriminator: 3840,
+		Passcode:      20202021,
+		Storage:       NewMemoryStorage(),
+	})
+	if err != nil {
+		t.Fatalf("NewNode failed: %v", err)
+	}
+
+	key := bytes.Repeat([]byte{0x11}, securechannel.CheckInKeySize)
+	checkIns := make(chan securechannel.CheckIn, 4)
+	icd := testICD(t, node, key, 10, ICDConfig{
+		OnCheckIn: func(c securechannel.CheckIn) { checkIns <- c },
+	})
+	// Another registration, whose key does not decrypt the messages
+	testICD(t, node, bytes.Repeat([]byte{0x22}, securechannel.CheckInKeySize), 0, ICDConfig{})
+
+	ran := make(chan error, 1)
+	go func() {
+		ran <- icd.Do(context.Background(), func(ctx context.Context, sess *session.SecureContext, peerAddr transport.PeerAddress) error {
+			return errors.New("interaction ran")
+		})
+	}()
+
+	// The counter at registration is stale
+	waitQueued(t, icd)
+	node.handleCheckIn(checkInPayload(t, key, 10))
+	select {
+	case c := <-checkIns:
+		t.Fatalf("stale Check-In %+v accepted", c)
+	case <-ran:
+		t.Fatal("interaction ran on a stale Check-In")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// A fresh Check-In runs the queued interaction
+	node.handleCheckIn(checkInPayload(t, key, 11))
+	select {
+	case c := <-checkIns:
+		if c.Counter != 11 || c.ActiveModeThreshold != 5000 {
+			t.Errorf("Check-In = %+v, want counter 11, threshold 5000", c)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Check-In not accepted")
+	}
+	select {
+	case err := <-ran:
+		if err == nil || err.Error() != "interaction ran" {
+			t.Errorf("Do() = %v, want the interaction's error", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("queued interaction did not run")
+	}
+	if got := icd.Registration().Counter; got != 11 {
+		t.Errorf("Registration().Counter = %d, want 11", got)
+	}
+
+	// Replays are dropped
+	node.handleCheckIn(checkInPayload(t, key, 11))
+	select {
+	case c := <-checkIns:
+		t.Errorf("replayed Check-In %+v accepted", c)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// Closing fails queued interactions
+	go func() {
+		ran <- icd.Do(context.Background(), func(context.Context, *session.SecureContext, transport.PeerAddress) error {
+			return nil
+		})
+	}()
+	waitQueued(t, icd)
+	icd.Close()
+	if err := <-ran; !errors.Is(err, ErrICDClosed) {
+		t.Errorf("Do() after Close = %v, want ErrICDClosed", err)
+	}
+	if err := icd.Do(context.Background(), nil); !errors.Is(err, ErrICDClosed) {
+		t.Errorf("Do() on closed ICD = %v, want ErrICDClosed", err)
+	}
+}
+
+// waitQueued waits for an interaction to be queued on an ICD.
+func waitQueued(t *testing.T, icd *ICD) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		icd.mu.Lock()
+		n := len(icd.queue)
+		icd.mu.Unlock()
+		if n > 0 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("interaction not queued")
+}
+
+func TestICD_CheckInMessage(t *testing.T) {
+	f, err := TestFabric(1)
+	if err != nil {
+		t.Fatalf("TestFabric() error = %v", err)
+	}
+	defer f.Stop()
+	ctx := context.Background()
+	if err := f.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	controller, device := f.Controller(), f.Device(0)
+	key := bytes.Repeat([]byte{0x33}, securechannel.CheckInKeySize)
+	checkIns := make(chan securechannel.CheckIn, 1)
+	testICD(t, controller, key, 0, ICDConfig{
+		FabricIndex: f.FabricIndex(controller),
+		NodeID:      f.NodeID(device),
+		OnCheckIn:   func(c securechannel.CheckIn) { checkIns <- c },
+	})
+
+	// The device checks in, as an ICD, on an unsecured session
+	unsecured, err := device.SessionManager().CreateUnsecuredInitiatorContext()
+	if err != nil {
+		t.Fatalf("CreateUnsecuredInitiatorContext() error = %v", err)
+	}
+	defer device.SessionManager().RemoveUnsecuredContext(unsecured.EphemeralNodeID())
+	exch, err := device.ExchangeManager().NewExchange(unsecured, 0, f.Address(controller), message.ProtocolSecureChannel, nil)
+	if err != nil {
+		t.Fatalf("NewExchange() error = %v", err)
+	}
+	defer exch.Close()
+	if err := exch.SendMessage(uint8(securechannel.OpcodeICDCheckIn), checkInPayload(t, key, 1), false); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+
+	select {
+	case c := <-checkIns:
+		if c.Counter != 1 {
+			t.Errorf("Check-In counter = %d, want 1", c.Counter)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Check-In not received")
+	}
+}
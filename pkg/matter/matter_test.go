@@ -1,12 +1,30 @@
 package matter
 
 import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"errors"
 	"fmt"
+	"log/slog"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/backkem/matter/pkg/acl"
+	"github.com/backkem/matter/pkg/clusters/basic"
+	"github.com/backkem/matter/pkg/clusters/descriptor"
+	"github.com/backkem/matter/pkg/clusters/diagnosticlogs"
+	"github.com/backkem/matter/pkg/clusters/generaldiagnostics"
 	"github.com/backkem/matter/pkg/clusters/onoff"
+	"github.com/backkem/matter/pkg/clusters/operationalcredentials"
+	"github.com/backkem/matter/pkg/datamodel"
 	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/logger"
+	"github.com/backkem/matter/pkg/im"
+	imsg "github.com/backkem/matter/pkg/im/message"
+	"github.com/backkem/matter/pkg/session"
+	"github.com/backkem/matter/pkg/tlv"
 	"github.com/backkem/matter/pkg/transport"
 )
 
@@ -106,6 +124,125 @@ func TestNodeRootEndpointReserved(t *testing.T) {
 	}
 }
 
+func TestNodeAccessDecisions(t *testing.T) {
+	decisions := acl.NewDecisionLog(0)
+	node, err := NewNode(NodeConfig{
+		VendorID:         0xFFF1,
+		ProductID:        0x8001,
+		Discriminator:    3840,
+		Passcode:         20202021,
+		Storage:          NewMemoryStorage(),
+		OnAccessDecision: decisions.RecordDenied,
+		DiagnosticLogs: diagnosticlogs.ProviderFunc(func(intent diagnosticlogs.Intent) []byte {
+			if intent == diagnosticlogs.IntentEndUserSupport {
+				return decisions.Bytes()
+			}
+			return nil
+		}),
+	})
+	if err != nil {
+		t.Fatalf("NewNode failed: %v", err)
+	}
+
+	// A CASE subject without an ACL entry is denied, and the denial traced
+	subject := acl.SubjectDescriptor{FabricIndex: 1, AuthMode: acl.AuthModeCASE, Subject: 0x1234}
+	if got := node.aclMgr.Check(subject, acl.NewRequestPath(uint32(onoff.ClusterID), 1, acl.RequestTypeCommandInvoke), acl.PrivilegeOperate); got != acl.ResultDenied {
+		t.Fatalf("Check() = %v, want Denied", got)
+	}
+
+	// Installers retrieve it from the Diagnostic Logs cluster
+	cluster, ok := node.GetEndpoint(RootEndpointID).GetCluster(diagnosticlogs.ClusterID).(*diagnosticlogs.Cluster)
+	if !ok {
+		t.Fatal("root endpoint has no Diagnostic Logs cluster")
+	}
+	resp, err := cluster.RetrieveLogs(&diagnosticlogs.RetrieveLogsRequest{Intent: diagnosticlogs.IntentEndUserSupport})
+	if err != nil {
+		t.Fatalf("RetrieveLogs() error = %v", err)
+	}
+	if resp.Status != diagnosticlogs.StatusExhausted || !strings.Contains(string(resp.LogContent), "Denied: fabric 1 CASE subject 0x0000000000001234") {
+		t.Errorf("RetrieveLogs() = %v, %q", resp.Status, resp.LogContent)
+	}
+}
+
+func TestNodeTestEventTriggers(t *testing.T) {
+	key := bytes.Repeat([]byte{0xAB}, generaldiagnostics.EnableKeySize)
+	config := NodeConfig{
+		VendorID:                  0xFFF1,
+		ProductID:                 0x8001,
+		Discriminator:             3840,
+		Passcode:                  20202021,
+		Storage:                   NewMemoryStorage(),
+		TestEventTriggerEnableKey: key[:8],
+	}
+	if _, err := NewNode(config); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("NewNode() with short key error = %v, want ErrInvalidConfig", err)
+	}
+
+	config.TestEventTriggerEnableKey = key
+	node, err := NewNode(config)
+	if err != nil {
+		t.Fatalf("NewNode failed: %v", err)
+	}
+	cluster, ok := node.GetEndpoint(RootEndpointID).GetCluster(generaldiagnostics.ClusterID).(*generaldiagnostics.Cluster)
+	if !ok {
+		t.Fatal("root endpoint has no General Diagnostics cluster")
+	}
+
+	// Triggers go to the delegate of their cluster
+	var fired uint64
+	node.TestEventTriggers().Register(0x005C, generaldiagnostics.TestEventTriggerFunc(func(trigger uint64) error {
+		fired = trigger
+		return nil
+	}))
+	trigger := generaldiagnostics.Trigger(0x005C, 1)
+	if err := cluster.TestEventTrigger(key, trigger); err != nil {
+		t.Errorf("TestEventTrigger() error = %v", err)
+	}
+	if fired != trigger {
+		t.Errorf("delegate got 0x%016X, want 0x%016X", fired, trigger)
+	}
+	if err := cluster.TestEventTrigger(key, generaldiagnostics.Trigger(0x0101, 1)); !errors.Is(err, datamodel.ErrInvalidCommand) {
+		t.Errorf("TestEventTrigger() without delegate error = %v, want ErrInvalidCommand", err)
+	}
+}
+
+func TestNodeOperationalKeystore(t *testing.T) {
+	storage := NewMemoryStorage()
+	keystore, err := fabric.NewKeystore(fabric.KeystoreConfig{
+		Storage:       storage,
+		EncryptionKey: bytes.Repeat([]byte{0x5A}, 16),
+	})
+	if err != nil {
+		t.Fatalf("NewKeystore() error = %v", err)
+	}
+	handle, public, _ := keystore.GenerateKey()
+	storage.SaveFabric(&fabric.FabricInfo{FabricIndex: 1, FabricID: 1, NodeID: 2, KeyHandle: handle})
+
+	node, err := NewNode(NodeConfig{
+		VendorID:            0xFFF1,
+		ProductID:           0x8001,
+		Discriminator:       3840,
+		Passcode:            20202021,
+		Storage:             storage,
+		OperationalKeystore: keystore,
+	})
+	if err != nil {
+		t.Fatalf("NewNode failed: %v", err)
+	}
+
+	// CASE initiation signs with the key the fabric references
+	key, err := node.config.OperationalKey(1)
+	if err != nil {
+		t.Fatalf("OperationalKey() error = %v", err)
+	}
+	if !key.Public().(*ecdsa.PublicKey).Equal(public) {
+		t.Error("OperationalKey() returned another key")
+	}
+	if _, err := node.config.OperationalKey(2); !errors.Is(err, fabric.ErrFabricNotFound) {
+		t.Errorf("OperationalKey() unknown fabric error = %v, want ErrFabricNotFound", err)
+	}
+}
+
 func TestNodeDuplicateEndpoint(t *testing.T) {
 	storage := NewMemoryStorage()
 
@@ -140,6 +277,93 @@ func TestNodeDuplicateEndpoint(t *testing.T) {
 	}
 }
 
+// partsList returns the PartsList of an endpoint's descriptor.
+func partsList(t *testing.T, node *Node, id datamodel.EndpointID) []datamodel.EndpointID {
+	t.Helper()
+	d, ok := node.GetEndpoint(id).GetCluster(descriptor.ClusterID).(*descriptor.Cluster)
+	if !ok {
+		t.Fatalf("endpoint %d has no descriptor", id)
+	}
+	return d.PartsList()
+}
+
+func TestNodeComposedEndpoints(t *testing.T) {
+	node, err := NewNode(NodeConfig{
+		VendorID:      0xFFF1,
+		ProductID:     0x8001,
+		Discriminator: 3840,
+		Passcode:      20202021,
+		Storage:       NewMemoryStorage(),
+	})
+	if err != nil {
+		t.Fatalf("NewNode failed: %v", err)
+	}
+
+	// Oven with a cooktop of two cook surfaces
+	cooktop := NewEndpoint(2).
+		WithDeviceType(0x0078, 1).
+		AddPart(NewEndpoint(3).WithDeviceType(0x0077, 1).WithUniqueID("surface-left")).
+		AddPart(NewEndpoint(4).WithDeviceType(0x0077, 1).WithUniqueID("surface-right"))
+	oven := NewEndpoint(1).WithDeviceType(0x007B, 1).AddPart(cooktop)
+	if err := node.AddEndpoint(oven); err != nil {
+		t.Fatalf("AddEndpoint failed: %v", err)
+	}
+
+	root := node.GetEndpoint(RootEndpointID).GetCluster(descriptor.ClusterID).(*descriptor.Cluster)
+	rootVersion := root.DataVersion()
+
+	if got := partsList(t, node, RootEndpointID); fmt.Sprint(got) != "[1 2 3 4]" {
+		t.Errorf("root PartsList = %v, want [1 2 3 4]", got)
+	}
+	if got := partsList(t, node, 1); fmt.Sprint(got) != "[2]" {
+		t.Errorf("oven PartsList = %v, want [2]", got)
+	}
+	if got := partsList(t, node, 2); fmt.Sprint(got) != "[3 4]" {
+		t.Errorf("cooktop PartsList = %v, want [3 4]", got)
+	}
+
+	// A flat (full family) parent lists all descendants
+	node.GetEndpoint(1).Inner().SetCompositionPattern(datamodel.CompositionFullFamily)
+	if got := partsList(t, node, 1); fmt.Sprint(got) != "[2 3 4]" {
+		t.Errorf("full family PartsList = %v, want [2 3 4]", got)
+	}
+
+	// Endpoints added later join an existing parent
+	if err := node.AddEndpoint(NewEndpoint(5).WithParent(2).WithDeviceType(0x0077, 1)); err != nil {
+		t.Fatalf("AddEndpoint with parent failed: %v", err)
+	}
+	if root.DataVersion() == rootVersion {
+		t.Error("root PartsList change not reported")
+	}
+	if err := node.AddEndpoint(NewEndpoint(6).WithParent(9)); !errors.Is(err, ErrParentNotFound) {
+		t.Errorf("AddEndpoint with unknown parent = %v, want %v", err, ErrParentNotFound)
+	}
+
+	// Unique IDs
+	if err := node.AddEndpoint(NewEndpoint(6).WithUniqueID("surface-left")); !errors.Is(err, ErrInvalidEndpointUniqueID) {
+		t.Errorf("AddEndpoint with used unique ID = %v, want %v", err, ErrInvalidEndpointUniqueID)
+	}
+	if err := node.AddEndpoint(NewEndpoint(6).WithUniqueID(strings.Repeat("x", 33))); !errors.Is(err, ErrInvalidEndpointUniqueID) {
+		t.Errorf("AddEndpoint with long unique ID = %v, want %v", err, ErrInvalidEndpointUniqueID)
+	}
+	if node.GetEndpoint(6) != nil {
+		t.Error("rejected endpoint was added")
+	}
+
+	// Parts are removed first
+	if err := node.RemoveEndpoint(2); !errors.Is(err, ErrEndpointHasParts) {
+		t.Errorf("RemoveEndpoint with parts = %v, want %v", err, ErrEndpointHasParts)
+	}
+	for _, id := range []datamodel.EndpointID{5, 4, 3, 2} {
+		if err := node.RemoveEndpoint(id); err != nil {
+			t.Fatalf("RemoveEndpoint(%d) failed: %v", id, err)
+		}
+	}
+	if got := partsList(t, node, RootEndpointID); fmt.Sprint(got) != "[1]" {
+		t.Errorf("root PartsList after removal = %v, want [1]", got)
+	}
+}
+
 func TestOnboardingPayload(t *testing.T) {
 	storage := NewMemoryStorage()
 
@@ -359,11 +583,109 @@ func TestInvalidPasscodeConfig(t *testing.T) {
 		SoftwareVersionString: "1.0.0",
 		Storage:               storage,
 	})
-	if err != ErrInvalidPasscode {
+	if !errors.Is(err, ErrInvalidPasscode) {
 		t.Errorf("expected ErrInvalidPasscode, got %v", err)
 	}
 }
 
+func TestNodeConfigValidate(t *testing.T) {
+	valid := func() NodeConfig {
+		return NodeConfig{
+			VendorID:      0xFFF1,
+			ProductID:     0x8001,
+			Discriminator: 3840,
+			Passcode:      20202021,
+			Storage:       NewMemoryStorage(),
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(c *NodeConfig)
+		want   error
+	}{
+		{"valid", func(c *NodeConfig) {}, nil},
+		{"defaults", func(c *NodeConfig) { c.Port, c.SupportedFabrics = 0, 0 }, nil},
+		{"no storage", func(c *NodeConfig) { c.Storage = nil }, ErrStorageRequired},
+		{"vendor 0", func(c *NodeConfig) { c.VendorID = 0 }, ErrInvalidVendorID},
+		{"reserved vendor", func(c *NodeConfig) { c.VendorID = 0xFFFF }, ErrInvalidVendorID},
+		{"product 0", func(c *NodeConfig) { c.ProductID = 0 }, ErrInvalidProductID},
+		{"discriminator", func(c *NodeConfig) { c.Discriminator = 4096 }, ErrInvalidDiscriminator},
+		{"trivial passcode", func(c *NodeConfig) { c.Passcode = 12345678 }, ErrInvalidPasscode},
+		{"passcode too large", func(c *NodeConfig) { c.Passcode = 99999999 }, ErrInvalidPasscode},
+		{"negative port", func(c *NodeConfig) { c.Port = -1 }, ErrInvalidPort},
+		{"port too large", func(c *NodeConfig) { c.Port = 65536 }, ErrInvalidPort},
+		{"too few fabrics", func(c *NodeConfig) { c.SupportedFabrics = 4 }, ErrInvalidConfig},
+		{"too many fabrics", func(c *NodeConfig) { c.SupportedFabrics = 255 }, ErrInvalidConfig},
+		{"long device name", func(c *NodeConfig) { c.DeviceName = strings.Repeat("x", 33) }, nil}, // Truncated
+		{"long serial number", func(c *NodeConfig) { c.SerialNumber = strings.Repeat("x", 33) }, ErrInvalidConfig},
+		{"negative timeout", func(c *NodeConfig) { c.IdleRetransTimeout = -time.Second }, ErrInvalidConfig},
+		{"log levels", func(c *NodeConfig) { c.LogLevels = "loud" }, ErrInvalidLogLevels},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid()
+			tt.modify(&c)
+			err := c.Validate()
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Validate() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNodeLogger(t *testing.T) {
+	config := NodeConfig{
+		VendorID:      0xFFF1,
+		ProductID:     0x8001,
+		Discriminator: 3840,
+		Passcode:      20202021,
+		Storage:       NewMemoryStorage(),
+		LogLevels:     "matter=loud",
+	}
+	if _, err := NewNode(config); !errors.Is(err, ErrInvalidLogLevels) {
+		t.Errorf("expected ErrInvalidLogLevels, got %v", err)
+	}
+
+	var buf bytes.Buffer
+	config.Logger = slog.New(slog.NewTextHandler(&buf, nil))
+	config.LogLevels = "warn,matter=debug"
+	node, err := NewNode(config)
+	if err != nil {
+		t.Fatalf("NewNode failed: %v", err)
+	}
+	if node.LoggerFactory() == nil {
+		t.Fatal("expected a logger factory built from Logger")
+	}
+
+	node.LoggerFactory().NewLogger("matter").Debug("hello")
+	node.LoggerFactory().NewLogger("exchange").Info("dropped")
+	out := buf.String()
+	if !strings.Contains(out, "subsystem=matter") || !strings.Contains(out, "msg=hello") {
+		t.Errorf("missing matter debug record in %q", out)
+	}
+	if strings.Contains(out, "dropped") {
+		t.Errorf("exchange info should be filtered at warn: %q", out)
+	}
+
+	// Operational identities are redacted
+	buf.Reset()
+	if _, err := node.AddFabric(&fabric.FabricInfo{FabricID: 0xFAB1, NodeID: 0x1234}); err != nil {
+		t.Fatalf("AddFabric failed: %v", err)
+	}
+	out = buf.String()
+	if !strings.Contains(out, logger.Placeholder) || strings.Contains(out, "1234") || strings.Contains(out, "FAB1") {
+		t.Errorf("fabric log should redact the fabric and node IDs: %q", out)
+	}
+}
+
 func TestPipeFactory(t *testing.T) {
 	factory1, factory2 := transport.NewPipeFactoryPair()
 
@@ -462,7 +784,7 @@ func TestNodeStateTransitions(t *testing.T) {
 		{NodeStateCommissioningOpen, false, true, true},
 		{NodeStateCommissioned, false, true, true},
 		{NodeStateStopping, false, false, false},
-		{NodeStateStopped, false, false, false},
+		{NodeStateStopped, true, false, false},
 	}
 
 	for _, tc := range tests {
@@ -522,6 +844,11 @@ func TestEndpointBuilder(t *testing.T) {
 	if deviceTypes[1].DeviceTypeID != 0x0010 {
 		t.Errorf("expected second device type 0x0010, got 0x%X", deviceTypes[1].DeviceTypeID)
 	}
+
+	// The Descriptor reads the device types from the data model endpoint
+	if got := ep.Inner().GetDeviceTypes(); len(got) != 2 {
+		t.Errorf("expected 2 device types on the data model endpoint, got %d", len(got))
+	}
 }
 
 func TestNodeStopWithoutStart(t *testing.T) {
@@ -544,7 +871,7 @@ func TestNodeStopWithoutStart(t *testing.T) {
 	}
 
 	// Try to stop without starting - should fail
-	err = node.Stop()
+	err = node.Stop(context.Background())
 	if err != ErrNotStarted {
 		t.Errorf("expected ErrNotStarted, got %v", err)
 	}
@@ -553,3 +880,678 @@ func TestNodeStopWithoutStart(t *testing.T) {
 // Note: TestNodeStartWithPipeTransport is commented out because it requires
 // proper transport mocking. Full start/stop integration tests will be added
 // in test/integration/ with proper virtual network support.
+
+func TestNodeFabricLifecycle(t *testing.T) {
+	storage := NewMemoryStorage()
+	var added, removed, updated []fabric.FabricIndex
+
+	node, err := NewNode(NodeConfig{
+		VendorID:         0xFFF1,
+		ProductID:        0x8001,
+		Discriminator:    3840,
+		Passcode:         20202021,
+		Storage:          storage,
+		SupportedFabrics: 5,
+		OnFabricAdded:    func(fi fabric.FabricIndex) { added = append(added, fi) },
+		OnFabricRemoved:  func(fi fabric.FabricIndex) { removed = append(removed, fi) },
+		OnFabricUpdated:  func(fi fabric.FabricIndex) { updated = append(updated, fi) },
+	})
+	if err != nil {
+		t.Fatalf("NewNode failed: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		fi, err := node.AddFabric(&fabric.FabricInfo{FabricID: fabric.FabricID(0x100 + i), NodeID: 0x1})
+		if err != nil {
+			t.Fatalf("AddFabric(%d) failed: %v", i, err)
+		}
+		if fi != fabric.FabricIndex(i+1) {
+			t.Errorf("AddFabric(%d) index = %d, want %d", i, fi, i+1)
+		}
+	}
+	if _, err := node.AddFabric(&fabric.FabricInfo{FabricID: 0x200}); !errors.Is(err, ErrFabricTableFull) {
+		t.Errorf("AddFabric beyond SupportedFabrics error = %v, want ErrFabricTableFull", err)
+	}
+	if len(added) != 5 || node.FabricCount() != 5 {
+		t.Errorf("added = %v, FabricCount = %d", added, node.FabricCount())
+	}
+
+	// Labels
+	if err := node.SetFabricLabel(1, "Home"); err != nil {
+		t.Fatalf("SetFabricLabel failed: %v", err)
+	}
+	if err := node.SetFabricLabel(2, "Home"); !errors.Is(err, fabric.ErrLabelConflict) {
+		t.Errorf("duplicate label error = %v, want ErrLabelConflict", err)
+	}
+	if label, _ := node.FabricLabel(1); label != "Home" {
+		t.Errorf("FabricLabel(1) = %q, want Home", label)
+	}
+	if err := node.UpdateFabric(&fabric.FabricInfo{FabricIndex: 1, FabricID: 0x100, NodeID: 0x2}); err != nil {
+		t.Fatalf("UpdateFabric failed: %v", err)
+	}
+	if info, _ := node.Fabric(1); info.NodeID != 0x2 || info.Label != "Home" {
+		t.Errorf("updated fabric NodeID = %#x, label = %q", info.NodeID, info.Label)
+	}
+	if len(updated) != 2 {
+		t.Errorf("updated = %v, want two updates", updated)
+	}
+
+	// Removal drops the fabric's data
+	if _, err := node.aclMgr.CreateEntry(2, acl.Entry{Privilege: acl.PrivilegeAdminister, AuthMode: acl.AuthModeCASE}); err != nil {
+		t.Fatalf("CreateEntry failed: %v", err)
+	}
+	storage.SaveGroupKeys([]GroupKeyEntry{{FabricIndex: 1}, {FabricIndex: 2}})
+
+	if err := node.RemoveFabric(2); err != nil {
+		t.Fatalf("RemoveFabric failed: %v", err)
+	}
+	if err := node.RemoveFabric(2); !errors.Is(err, ErrFabricNotFound) {
+		t.Errorf("second RemoveFabric error = %v, want ErrFabricNotFound", err)
+	}
+	if len(removed) != 1 || removed[0] != 2 {
+		t.Errorf("removed = %v, want [2]", removed)
+	}
+	if n, _ := node.aclMgr.GetEntryCount(2); n != 0 {
+		t.Errorf("ACL entries of removed fabric = %d, want 0", n)
+	}
+	if keys, _ := storage.LoadGroupKeys(); len(keys) != 1 || keys[0].FabricIndex != 1 {
+		t.Errorf("group keys after removal = %+v", keys)
+	}
+	if fabrics, _ := storage.LoadFabrics(); len(fabrics) != 4 {
+		t.Errorf("stored fabrics = %d, want 4", len(fabrics))
+	}
+
+	if _, err := node.AddFabric(&fabric.FabricInfo{FabricIndex: 1, FabricID: 0x200}); !errors.Is(err, ErrFabricExists) {
+		t.Errorf("AddFabric with used index error = %v, want ErrFabricExists", err)
+	}
+
+	// The freed index is reused
+	if fi, err := node.AddFabric(&fabric.FabricInfo{FabricID: 0x200}); err != nil || fi != 2 {
+		t.Errorf("AddFabric after removal = %d, %v; want 2", fi, err)
+	}
+}
+
+// fabricScopedCluster is an on/off cluster holding fabric-scoped data.
+type fabricScopedCluster struct {
+	*onoff.Cluster
+	removed []fabric.FabricIndex
+}
+
+func (c *fabricScopedCluster) FabricRemoved(fi fabric.FabricIndex) {
+	c.removed = append(c.removed, fi)
+}
+
+type fabricStore map[fabric.FabricIndex]string
+
+func (s fabricStore) FabricRemoved(fi fabric.FabricIndex) {
+	delete(s, fi)
+}
+
+func TestNodeFabricRemovalListeners(t *testing.T) {
+	var configCalls int
+	node, err := NewNode(NodeConfig{
+		VendorID:        0xFFF1,
+		ProductID:       0x8001,
+		Discriminator:   3840,
+		Passcode:        20202021,
+		Storage:         NewMemoryStorage(),
+		OnFabricRemoved: func(fi fabric.FabricIndex) { configCalls++ },
+	})
+	if err != nil {
+		t.Fatalf("NewNode failed: %v", err)
+	}
+
+	cluster := &fabricScopedCluster{Cluster: onoff.New(onoff.Config{EndpointID: 1})}
+	if err := node.AddEndpoint(NewEndpoint(1).WithDeviceType(0x0100, 1).AddCluster(cluster)); err != nil {
+		t.Fatalf("AddEndpoint failed: %v", err)
+	}
+	store := fabricStore{}
+	node.AddFabricRemovalListener(store)
+
+	for _, id := range []fabric.FabricID{0x100, 0x200, 0x300} {
+		fi, err := node.AddFabric(&fabric.FabricInfo{FabricID: id, NodeID: 1})
+		if err != nil {
+			t.Fatalf("AddFabric failed: %v", err)
+		}
+		store[fi] = fmt.Sprintf("fabric %d", fi)
+	}
+
+	if err := node.RemoveFabric(2); err != nil {
+		t.Fatalf("RemoveFabric failed: %v", err)
+	}
+	if len(cluster.removed) != 1 || cluster.removed[0] != 2 {
+		t.Errorf("cluster notified of %v, want [2]", cluster.removed)
+	}
+	if _, ok := store[2]; ok || len(store) != 2 {
+		t.Errorf("store after RemoveFabric = %v", store)
+	}
+	if configCalls != 1 {
+		t.Errorf("OnFabricRemoved called %d times, want 1", configCalls)
+	}
+
+	// Factory reset removes the remaining fabrics
+	if err := node.FactoryReset(); err != nil {
+		t.Fatalf("FactoryReset failed: %v", err)
+	}
+	if len(cluster.removed) != 3 || len(store) != 0 {
+		t.Errorf("after FactoryReset: cluster notified of %v, store = %v", cluster.removed, store)
+	}
+}
+
+func TestNodeRemoveFabric_ThroughCluster(t *testing.T) {
+	removed := make(chan fabric.FabricIndex, 1)
+	f, err := NewVirtualFabric(VirtualFabricConfig{
+		Devices: 1,
+		ConfigureNode: func(i int, config *NodeConfig) {
+			if i == 1 {
+				config.OnFabricRemoved = func(fi fabric.FabricIndex) { removed <- fi }
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewVirtualFabric() error = %v", err)
+	}
+	defer f.Stop()
+	ctrl, device := f.Controller(), f.Device(0)
+	light := onoff.New(onoff.Config{EndpointID: 1})
+	if err := device.AddEndpoint(NewEndpoint(1).WithDeviceType(0x0100, 1).AddCluster(light)); err != nil {
+		t.Fatalf("AddEndpoint() error = %v", err)
+	}
+	if err := f.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	index := f.FabricIndex(device)
+	if _, err := device.aclMgr.CreateEntry(index, acl.Entry{
+		Privilege: acl.PrivilegeAdminister,
+		AuthMode:  acl.AuthModeCASE,
+		Subjects:  []uint64{uint64(f.NodeID(ctrl))},
+	}); err != nil {
+		t.Fatalf("CreateEntry() error = %v", err)
+	}
+
+	// A CASE session between the two nodes on the fabric
+	key := make([]byte, session.SessionKeySize)
+	newSession := func(node, peer *Node, role session.SessionRole, local, peerID uint16) *session.SecureContext {
+		t.Helper()
+		sess, err := session.NewSecureContext(session.SecureContextConfig{
+			SessionType:    session.SessionTypeCASE,
+			Role:           role,
+			LocalSessionID: local,
+			PeerSessionID:  peerID,
+			I2RKey:         key,
+			R2IKey:         key,
+			SharedSecret:   []byte("shared secret"),
+			FabricIndex:    f.FabricIndex(node),
+			PeerNodeID:     f.NodeID(peer),
+			LocalNodeID:    f.NodeID(node),
+		})
+		if err != nil {
+			t.Fatalf("NewSecureContext() error = %v", err)
+		}
+		if err := node.SessionManager().AddSecureContext(sess); err != nil {
+			t.Fatalf("AddSecureContext() error = %v", err)
+		}
+		return sess
+	}
+	sess := newSession(ctrl, device, session.SessionRoleInitiator, 100, 200)
+	newSession(device, ctrl, session.SessionRoleResponder, 200, 100)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client := im.NewClient(im.ClientConfig{ExchangeManager: ctrl.ExchangeManager(), Engine: ctrl.IMEngine()})
+	endpoint, cluster, attribute := imsg.EndpointID(1), imsg.ClusterID(onoff.ClusterID), imsg.AttributeID(onoff.AttrOnOff)
+	sub, err := client.Subscribe(ctx, sess, f.Address(device), &imsg.SubscribeRequestMessage{
+		MaxIntervalCeiling: 60,
+		AttributeRequests:  []imsg.AttributePathIB{{Endpoint: &endpoint, Cluster: &cluster, Attribute: &attribute}},
+	}, func(*imsg.ReportDataMessage) {})
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	defer sub.Close()
+	if n := len(device.IMEngine().Subscriptions()); n != 1 {
+		t.Fatalf("device serves %d subscriptions, want 1", n)
+	}
+
+	// The controller removes the device from the fabric it accesses it on
+	var fields bytes.Buffer
+	w := tlv.NewWriter(&fields)
+	w.StartStructure(tlv.Anonymous())
+	w.PutUint(tlv.ContextTag(0), uint64(index))
+	w.EndContainer()
+	result, err := client.InvokeWithStatus(ctx, sess, f.Address(device), 0,
+		uint32(operationalcredentials.ClusterID), uint32(operationalcredentials.CmdRemoveFabric), fields.Bytes())
+	if err != nil {
+		t.Fatalf("RemoveFabric error = %v", err)
+	}
+	if result.HasStatus {
+		t.Fatalf("RemoveFabric status = %v, want a NOCResponse", result.Status)
+	}
+
+	select {
+	case fi := <-removed:
+		if fi != index {
+			t.Errorf("OnFabricRemoved(%v), want %v", fi, index)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("OnFabricRemoved not called")
+	}
+	if device.FabricCount() != 0 {
+		t.Errorf("device still on %d fabrics", device.FabricCount())
+	}
+	device.SessionManager().ForEachSecureSession(func(s *session.SecureContext) bool {
+		if s.FabricIndex() == index {
+			t.Errorf("session %d on the removed fabric still open", s.LocalSessionID())
+		}
+		return true
+	})
+	if n := len(device.IMEngine().Subscriptions()); n != 0 {
+		t.Errorf("device serves %d subscriptions after the removal, want 0", n)
+	}
+	if subs, _ := device.config.Storage.LoadSubscriptions(); len(subs) != 0 {
+		t.Errorf("%d subscriptions left in storage", len(subs))
+	}
+}
+
+// keyDeleteFailingStorage is a MemoryStorage that cannot delete keys.
+type keyDeleteFailingStorage struct {
+	*MemoryStorage
+}
+
+func (s keyDeleteFailingStorage) DeleteOperationalKey(h fabric.KeyHandle) error {
+	return errors.New("storage offline")
+}
+
+func TestNodeRemoveFabric_KeyNotDeleted(t *testing.T) {
+	storage := keyDeleteFailingStorage{NewMemoryStorage()}
+	removed := 0
+	node, err := NewNode(NodeConfig{
+		VendorID:        0xFFF1,
+		ProductID:       0x8001,
+		Discriminator:   3840,
+		Passcode:        20202021,
+		Storage:         storage,
+		OnFabricRemoved: func(fi fabric.FabricIndex) { removed++ },
+	})
+	if err != nil {
+		t.Fatalf("NewNode failed: %v", err)
+	}
+
+	// The default keystore persists the keys to Storage
+	handle, _, err := node.config.OperationalKeystore.GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	if _, err := storage.LoadOperationalKey(handle); err != nil {
+		t.Fatalf("key not in storage: %v", err)
+	}
+
+	index, err := node.AddFabric(&fabric.FabricInfo{FabricID: 0x100, NodeID: 1, KeyHandle: handle})
+	if err != nil {
+		t.Fatalf("AddFabric failed: %v", err)
+	}
+	if _, err := node.aclMgr.CreateEntry(index, acl.Entry{
+		Privilege: acl.PrivilegeAdminister,
+		AuthMode:  acl.AuthModeCASE,
+		Subjects:  []uint64{2},
+	}); err != nil {
+		t.Fatalf("CreateEntry failed: %v", err)
+	}
+
+	// A key left behind does not keep the fabric's state
+	if err := node.RemoveFabric(index); err != nil {
+		t.Fatalf("RemoveFabric failed: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("OnFabricRemoved called %d times, want 1", removed)
+	}
+	if fabrics, _ := storage.LoadFabrics(); len(fabrics) != 0 {
+		t.Errorf("%d fabrics left in storage", len(fabrics))
+	}
+	if n, _ := node.aclMgr.GetEntryCount(index); n != 0 {
+		t.Errorf("%d ACL entries left", n)
+	}
+	if err := node.RemoveFabric(index); !errors.Is(err, ErrFabricNotFound) {
+		t.Errorf("second RemoveFabric: %v, want %v", err, ErrFabricNotFound)
+	}
+}
+
+func TestMemoryStorageSubscriptions(t *testing.T) {
+	storage := NewMemoryStorage()
+
+	rec := &im.SubscriptionRecord{SubscriptionID: 1, FabricIndex: 1, NodeID: 0x1000, MaxInterval: 60}
+	storage.SaveSubscription(rec)
+	storage.SaveSubscription(&im.SubscriptionRecord{SubscriptionID: 2, FabricIndex: 2})
+
+	// Stored records are copies
+	rec.MaxInterval = 0
+	subs, _ := storage.LoadSubscriptions()
+	if len(subs) != 2 {
+		t.Fatalf("LoadSubscriptions = %d records, want 2", len(subs))
+	}
+	for _, s := range subs {
+		if s.SubscriptionID == 1 && s.MaxInterval != 60 {
+			t.Errorf("stored MaxInterval = %d, want 60", s.MaxInterval)
+		}
+	}
+
+	// Deleting a fabric deletes its subscriptions
+	storage.DeleteFabric(2)
+	if subs, _ := storage.LoadSubscriptions(); len(subs) != 1 || subs[0].SubscriptionID != 1 {
+		t.Errorf("subscriptions after DeleteFabric = %+v", subs)
+	}
+
+	storage.DeleteSubscription(1)
+	if subs, _ := storage.LoadSubscriptions(); len(subs) != 0 {
+		t.Errorf("subscriptions after DeleteSubscription = %d, want 0", len(subs))
+	}
+}
+
+func TestNodeSubscriptionResumption(t *testing.T) {
+	network := transport.NewPipeNetwork()
+	defer network.Close()
+
+	storage := NewMemoryStorage()
+	storage.SaveFabric(&fabric.FabricInfo{FabricIndex: 1, FabricID: 0x100, NodeID: 0x1})
+	storage.SaveSubscription(&im.SubscriptionRecord{SubscriptionID: 1, FabricIndex: 1, NodeID: 0x1000})
+	storage.SaveSubscription(&im.SubscriptionRecord{
+		SubscriptionID:     2,
+		FabricIndex:        1,
+		NodeID:             0x2000,
+		ResumptionAttempts: MaxSubscriptionResumptionAttempts,
+	})
+	storage.SaveSubscription(&im.SubscriptionRecord{SubscriptionID: 3, FabricIndex: 7, NodeID: 0x3000})
+
+	attempts := make(chan fabric.NodeID, 4)
+	node, err := NewNode(NodeConfig{
+		VendorID:         0xFFF1,
+		ProductID:        0x8001,
+		Discriminator:    3840,
+		Passcode:         20202021,
+		Storage:          storage,
+		TransportFactory: network.NewFactory(),
+		EstablishSession: func(ctx context.Context, fabricIndex fabric.FabricIndex, nodeID fabric.NodeID) (*session.SecureContext, transport.PeerAddress, error) {
+			attempts <- nodeID
+			return nil, transport.PeerAddress{}, errors.New("subscriber unreachable")
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewNode failed: %v", err)
+	}
+	if err := node.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer node.Stop(context.Background())
+
+	select {
+	case nodeID := <-attempts:
+		if nodeID != 0x1000 {
+			t.Errorf("resumed subscriber = 0x%x, want 0x1000", nodeID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscription was not resumed")
+	}
+
+	// Expired records are deleted; the failed attempt is counted
+	deadline := time.Now().Add(time.Second)
+	for {
+		subs, _ := storage.LoadSubscriptions()
+		if len(subs) == 1 && subs[0].SubscriptionID == 1 && subs[0].ResumptionAttempts == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("stored subscriptions = %+v, want only 1 with one attempt", subs)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(attempts) != 0 {
+		t.Errorf("expired subscriptions were resumed")
+	}
+}
+
+func TestNodeConformance(t *testing.T) {
+	newLight := func(mode ConformanceMode, library datamodel.DeviceLibrary, logger *slog.Logger) *Node {
+		t.Helper()
+		factory, _ := transport.NewPipeFactoryPair()
+		node, err := NewNode(NodeConfig{
+			VendorID:         0xFFF1,
+			ProductID:        0x8001,
+			Discriminator:    3840,
+			Passcode:         20202021,
+			Storage:          NewMemoryStorage(),
+			TransportFactory: factory,
+			Logger:           logger,
+			Conformance:      mode,
+			DeviceLibrary:    library,
+		})
+		if err != nil {
+			t.Fatalf("NewNode failed: %v", err)
+		}
+		// An On/Off Light without the Lighting feature and without
+		// Identify, Groups and Scenes Management
+		ep := NewEndpoint(1).
+			WithDeviceType(uint32(datamodel.DeviceTypeOnOffLight), 1).
+			AddCluster(onoff.New(onoff.Config{EndpointID: 1}))
+		if err := node.AddEndpoint(ep); err != nil {
+			t.Fatalf("AddEndpoint failed: %v", err)
+		}
+		return node
+	}
+	ctx := context.Background()
+
+	node := newLight(ConformanceStrict, nil, nil)
+	err := node.Start(ctx)
+	var cerr *datamodel.ConformanceError
+	if !errors.Is(err, datamodel.ErrNonConformant) || !errors.As(err, &cerr) {
+		t.Fatalf("Start() error = %v, want a ConformanceError", err)
+	}
+	if len(cerr.Issues) != 4 {
+		t.Errorf("Issues = %v, want 4", cerr.Issues)
+	}
+	if node.State() != NodeStateInitialized {
+		t.Errorf("State() = %v, want %v", node.State(), NodeStateInitialized)
+	}
+
+	var buf bytes.Buffer
+	node = newLight(ConformanceWarn, nil, slog.New(slog.NewTextHandler(&buf, nil)))
+	if err := node.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	node.Stop(ctx)
+	if !strings.Contains(buf.String(), "missing mandatory features 0x00000001") {
+		t.Errorf("missing conformance warning in %q", buf.String())
+	}
+
+	// A library without the device type accepts the composition
+	node = newLight(ConformanceStrict, datamodel.DeviceLibrary{}, nil)
+	if err := node.Start(ctx); err != nil {
+		t.Fatalf("Start() with an empty library error = %v", err)
+	}
+	node.Stop(ctx)
+}
+
+// flushStorage is a MemoryStorage that counts flushes.
+type flushStorage struct {
+	*MemoryStorage
+	flushes int
+}
+
+func (s *flushStorage) Flush() error {
+	s.flushes++
+	return nil
+}
+
+func TestNodeRestart(t *testing.T) {
+	factory, _ := transport.NewPipeFactoryPair()
+	storage := &flushStorage{MemoryStorage: NewMemoryStorage()}
+	node, err := NewNode(NodeConfig{
+		VendorID:         0xFFF1,
+		ProductID:        0x8001,
+		Discriminator:    3840,
+		Passcode:         20202021,
+		Storage:          storage,
+		TransportFactory: factory,
+	})
+	if err != nil {
+		t.Fatalf("NewNode failed: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := node.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := node.Restart(ctx); err != nil {
+		t.Fatalf("Restart failed: %v", err)
+	}
+	if !node.State().IsRunning() || !node.IsCommissioningWindowOpen() {
+		t.Errorf("after Restart: State() = %v, window open = %v", node.State(), node.IsCommissioningWindowOpen())
+	}
+	if err := node.Stop(ctx); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if err := node.Stop(ctx); err != ErrAlreadyStopped {
+		t.Errorf("second Stop() error = %v, want ErrAlreadyStopped", err)
+	}
+	if storage.flushes != 2 {
+		t.Errorf("storage flushed %d times, want 2", storage.flushes)
+	}
+
+	var got []datamodel.EventID
+	for _, rec := range node.events.EventsSince(0, 0) {
+		if rec.Path.ClusterID == basic.ClusterID {
+			got = append(got, rec.Path.EventID)
+		}
+	}
+	want := []datamodel.EventID{basic.EventStartUp, basic.EventShutDown, basic.EventStartUp, basic.EventShutDown}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Basic Information events = %v, want %v", got, want)
+	}
+}
+
+func TestNodeStopClosesSessions(t *testing.T) {
+	closed := make(chan uint16, 1)
+	f, err := NewVirtualFabric(VirtualFabricConfig{
+		Devices: 1,
+		ConfigureNode: func(i int, config *NodeConfig) {
+			if i == 1 {
+				config.OnSessionClosed = func(id uint16) { closed <- id }
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewVirtualFabric failed: %v", err)
+	}
+	defer f.Stop()
+	if err := f.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	ctrl, device := f.Controller(), f.Device(0)
+
+	// A session between the two nodes, as PASE would leave it
+	key := make([]byte, session.SessionKeySize)
+	newSession := func(node *Node, role session.SessionRole, local, peer uint16) *session.SecureContext {
+		t.Helper()
+		sess, err := session.NewSecureContext(session.SecureContextConfig{
+			SessionType:    session.SessionTypePASE,
+			Role:           role,
+			LocalSessionID: local,
+			PeerSessionID:  peer,
+			I2RKey:         key,
+			R2IKey:         key,
+		})
+		if err != nil {
+			t.Fatalf("NewSecureContext failed: %v", err)
+		}
+		if err := node.SessionManager().AddSecureContext(sess); err != nil {
+			t.Fatalf("AddSecureContext failed: %v", err)
+		}
+		return sess
+	}
+	sess := newSession(ctrl, session.SessionRoleInitiator, 100, 200)
+	newSession(device, session.SessionRoleResponder, 200, 100)
+
+	// The controller has talked to the device over the session
+	exch, err := ctrl.ExchangeManager().NewExchange(sess, 100, f.Address(device), 0, nil)
+	if err != nil {
+		t.Fatalf("NewExchange failed: %v", err)
+	}
+	exch.Close()
+
+	if err := ctrl.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	select {
+	case id := <-closed:
+		if id != 200 {
+			t.Errorf("closed session = %d, want 200", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("device was not told the session closed")
+	}
+	if n := ctrl.SessionManager().SecureSessionCount(); n != 0 {
+		t.Errorf("SecureSessionCount() = %d after Stop, want 0", n)
+	}
+}
+
+func TestNodeFactoryReset(t *testing.T) {
+	factory, _ := transport.NewPipeFactoryPair()
+	storage := NewMemoryStorage()
+	storage.SavePASEAttempts(3)
+	var removed []fabric.FabricIndex
+	node, err := NewNode(NodeConfig{
+		VendorID:         0xFFF1,
+		ProductID:        0x8001,
+		Discriminator:    3840,
+		Passcode:         20202021,
+		Storage:          storage,
+		TransportFactory: factory,
+		SupportedFabrics: 5,
+		OnFabricRemoved:  func(fi fabric.FabricIndex) { removed = append(removed, fi) },
+	})
+	if err != nil {
+		t.Fatalf("NewNode failed: %v", err)
+	}
+	for _, id := range []fabric.FabricID{0x100, 0x200} {
+		if _, err := node.AddFabric(&fabric.FabricInfo{FabricID: id, NodeID: 0x1}); err != nil {
+			t.Fatalf("AddFabric failed: %v", err)
+		}
+	}
+	storage.SaveSubscription(&im.SubscriptionRecord{SubscriptionID: 1, FabricIndex: 1, NodeID: 0x1000})
+
+	ctx := context.Background()
+	if err := node.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer node.Stop(ctx)
+	if node.IsCommissioningWindowOpen() {
+		t.Fatal("commissioned node opened a commissioning window")
+	}
+
+	if err := node.FactoryReset(); err != nil {
+		t.Fatalf("FactoryReset failed: %v", err)
+	}
+	if node.IsCommissioned() || len(removed) != 2 {
+		t.Errorf("after FactoryReset: commissioned = %v, removed = %v", node.IsCommissioned(), removed)
+	}
+	if fabrics, _ := storage.LoadFabrics(); len(fabrics) != 0 {
+		t.Errorf("stored fabrics = %d, want 0", len(fabrics))
+	}
+	if subs, _ := storage.LoadSubscriptions(); len(subs) != 0 {
+		t.Errorf("stored subscriptions = %d, want 0", len(subs))
+	}
+	if attempts, _ := storage.LoadPASEAttempts(); attempts != 0 {
+		t.Errorf("stored PASE attempts = %d, want 0", attempts)
+	}
+	if !node.IsCommissioningWindowOpen() {
+		t.Error("commissioning window not opened after FactoryReset")
+	}
+
+	leaves := 0
+	for _, rec := range node.events.EventsSince(0, 0) {
+		if rec.Path.ClusterID == basic.ClusterID && rec.Path.EventID == basic.EventLeave {
+			leaves++
+		}
+	}
+	if leaves != 2 {
+		t.Errorf("Leave events = %d, want 2", leaves)
+	}
+}
@@ -0,0 +1,61 @@
+package matter
+
+import (
+	"errors"
+	"net"
+
+	"github.com/backkem/matter/pkg/discovery"
+	"github.com/backkem/matter/pkg/netmonitor"
+)
+
+// startNetworkMonitorLocked watches the host's addresses until the node
+// stops. Caller must hold n.mu.
+func (n *Node) startNetworkMonitorLocked() {
+	watcher := n.config.NetworkMonitor
+	if watcher == nil {
+		if n.config.TransportFactory != nil {
+			return
+		}
+		var ifaces []net.Interface
+		if n.config.Interface != nil {
+			ifaces = []net.Interface{*n.config.Interface}
+		}
+		watcher = netmonitor.New(netmonitor.Config{
+			Interfaces:    ifaces,
+			Clock:         n.config.Clock,
+			LoggerFactory: n.config.LoggerFactory,
+		})
+	}
+
+	ctx := n.ctx
+	go func() {
+		if err := watcher.Watch(ctx, n.onNetworkChanged); err != nil && n.log != nil {
+			n.log.Warnf("network monitor stopped: %v", err)
+		}
+	}()
+}
+
+// onNetworkChanged announces the node again with its new addresses and
+// forgets the peer addresses resolved for the old ones.
+func (n *Node) onNetworkChanged(change netmonitor.Change) {
+	n.mu.RLock()
+	running, mgr := n.state.IsRunning(), n.discoveryMgr
+	n.mu.RUnlock()
+	if !running {
+		return
+	}
+
+	if n.log != nil {
+		n.log.Infof("network addresses changed: %s", change)
+	}
+	if mgr != nil {
+		if err := mgr.Readvertise(); err != nil && !errors.Is(err, discovery.ErrClosed) && n.log != nil {
+			n.log.Warnf("failed to re-announce services: %v", err)
+		}
+		mgr.NodeResolver().InvalidateAll()
+	}
+
+	if n.config.OnNetworkChanged != nil {
+		n.config.OnNetworkChanged(change)
+	}
+}
@@ -0,0 +1,67 @@
+package matter
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/backkem/matter/pkg/netmonitor"
+	"github.com/backkem/matter/pkg/transport"
+)
+
+// fakeWatcher hands the node's change handler to the test.
+type fakeWatcher struct {
+	fn chan func(netmonitor.Change)
+}
+
+func (w *fakeWatcher) Watch(ctx context.Context, fn func(netmonitor.Change)) error {
+	w.fn <- fn
+	<-ctx.Done()
+	return nil
+}
+
+func TestNodeNetworkChanged(t *testing.T) {
+	watcher := &fakeWatcher{fn: make(chan func(netmonitor.Change), 1)}
+	changed := make(chan netmonitor.Change, 1)
+	_, factory := transport.NewPipeFactoryPair()
+	node, err := NewNode(NodeConfig{
+		VendorID:         0xFFF1,
+		ProductID:        0x8001,
+		Discriminator:    3840,
+		Passcode:         20202021,
+		Storage:          NewMemoryStorage(),
+		TransportFactory: factory,
+		NetworkMonitor:   watcher,
+		OnNetworkChanged: func(c netmonitor.Change) { changed <- c },
+	})
+	if err != nil {
+		t.Fatalf("NewNode failed: %v", err)
+	}
+	ctx := context.Background()
+	if err := node.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	notify := <-watcher.fn
+	change := netmonitor.Change{Added: []netmonitor.Address{{IP: net.ParseIP("192.168.1.20"), Interface: "eth0"}}}
+	notify(change)
+	select {
+	case got := <-changed:
+		if got.String() != change.String() {
+			t.Errorf("OnNetworkChanged(%s), want %s", got, change)
+		}
+	default:
+		t.Fatal("OnNetworkChanged not called")
+	}
+
+	// A stopped node ignores changes
+	if err := node.Stop(ctx); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	notify(change)
+	select {
+	case got := <-changed:
+		t.Errorf("OnNetworkChanged(%s) after Stop", got)
+	default:
+	}
+}
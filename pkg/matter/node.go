@@ -2,13 +2,24 @@ package matter
 
 import (
 	"context"
+	gocrypto "crypto"
 	"crypto/rand"
 	"encoding/binary"
+	"errors"
+	"fmt"
 	"net"
 	"sync"
 	"time"
 
 	"github.com/backkem/matter/pkg/acl"
+	"github.com/backkem/matter/pkg/clock"
+	"github.com/backkem/matter/pkg/clusters/accesscontrol"
+	"github.com/backkem/matter/pkg/clusters/admincommissioning"
+	"github.com/backkem/matter/pkg/clusters/basic"
+	"github.com/backkem/matter/pkg/clusters/descriptor"
+	"github.com/backkem/matter/pkg/clusters/generalcommissioning"
+	"github.com/backkem/matter/pkg/clusters/generaldiagnostics"
+	"github.com/backkem/matter/pkg/clusters/operationalcredentials"
 	"github.com/backkem/matter/pkg/commissioning"
 	"github.com/backkem/matter/pkg/datamodel"
 	"github.com/backkem/matter/pkg/discovery"
@@ -37,19 +48,56 @@ type Node struct {
 	exchangeMgr  *exchange.Manager
 	scMgr        *securechannel.Manager
 	imEngine     *im.Engine
+	events       *im.EventManager
 	discoveryMgr *discovery.Manager
 	aclMgr       *acl.Manager
 
 	// Data model
-	dataModel  *datamodel.BasicNode
-	dispatcher *nodeDispatcher
+	dataModel              *datamodel.BasicNode
+	dispatcher             *nodeDispatcher
+	basicInfo              *basic.Cluster
+	generalCommissioning   *generalcommissioning.Cluster
+	accessControl          *accesscontrol.Cluster
+	adminCommissioning     *admincommissioning.Cluster
+	operationalCredentials *operationalcredentials.Cluster
+	testEventTriggers      *generaldiagnostics.TriggerRegistry
 
 	// Endpoints (including root)
 	endpoints map[datamodel.EndpointID]*Endpoint
 
+	// Groups the node is a member of, with their fabric's ID
+	groups map[groupRef]fabric.FabricID
+
+	// Owners of fabric-scoped data outside the data model
+	fabricListeners []datamodel.FabricRemovalListener
+
+	// CASE sessions the node initiates
+	casePool     *casePool
+	refreshTimer clock.Timer // Checks the sessions for refresh
+
+	// Devices being watched, probed again when their session is closed
+	devices map[*Device]struct{}
+
+	// ICDs the node is registered with, listening for their Check-Ins
+	icds map[*ICD]struct{}
+
+	// IM requests being served, by local session ID, and the sessions of
+	// removed fabrics left open to send their responses (protected by
+	// requestsMu)
+	requestsMu         sync.Mutex
+	imRequests         map[uint16]int
+	closeAfterResponse map[uint16]struct{}
+
 	// Commissioning
-	commWindow *commissioning.CommissioningWindow
-	paseInfo   *paseInfo // PASE parameters for commissioning
+	commWindow   *commissioning.CommissioningWindow
+	commPAKE     *paseInfo        // PASE parameters of the open window
+	paseRetry    clock.Timer      // Restores the PASE responder after a failed attempt
+	paseAttempts int              // Failed PASE attempts, persisted
+	paseInfo     *paseInfo        // PASE parameters for commissioning
+	failSafe     *failSafeContext // Armed by a commissioner, nil otherwise
+
+	// Timers
+	clock clock.Clock
 
 	// Synchronization
 	mu       sync.RWMutex
@@ -83,7 +131,19 @@ func NewNode(config NodeConfig) (*Node, error) {
 		config:    config,
 		state:     NodeStateUninitialized,
 		endpoints: make(map[datamodel.EndpointID]*Endpoint),
+		groups:    make(map[groupRef]fabric.FabricID),
+		devices:   make(map[*Device]struct{}),
+		icds:      make(map[*ICD]struct{}),
 		stopCh:    make(chan struct{}),
+		clock:     clock.OrReal(config.Clock),
+
+		imRequests:         make(map[uint16]int),
+		closeAfterResponse: make(map[uint16]struct{}),
+	}
+	if config.OperationalKey == nil && config.OperationalKeystore != nil {
+		n.config.OperationalKey = func(index fabric.FabricIndex) (gocrypto.Signer, error) {
+			return n.fabricTable.OperationalKey(index)
+		}
 	}
 
 	// Initialize logger
@@ -94,6 +154,7 @@ func NewNode(config NodeConfig) (*Node, error) {
 	// Initialize data model
 	n.dataModel = datamodel.NewNode()
 	n.dispatcher = newNodeDispatcher(n.dataModel)
+	n.events = im.NewEventManager(im.EventManagerConfig{})
 
 	// Load persisted state
 	if err := n.loadState(); err != nil {
@@ -104,9 +165,41 @@ func NewNode(config NodeConfig) (*Node, error) {
 	if err := n.initManagers(); err != nil {
 		return nil, err
 	}
+	n.initCASEPool()
 
 	// Create root endpoint (pass dataModel so descriptor cluster can query endpoints)
-	rootEP := createRootEndpoint(&config, n.fabricTable, n.dataModel)
+	n.accessControl = accesscontrol.New(accesscontrol.Config{
+		EndpointID: RootEndpointID,
+		ACL:        n.aclMgr,
+		Reviewer:   config.RestrictionReviewer,
+	})
+	n.adminCommissioning = admincommissioning.New(admincommissioning.Config{
+		EndpointID:    RootEndpointID,
+		WindowManager: n,
+		Fabrics:       n.fabricTable,
+	})
+	n.generalCommissioning = generalcommissioning.New(generalcommissioning.Config{
+		EndpointID: RootEndpointID,
+		BasicCommissioningInfo: generalcommissioning.BasicCommissioningInfo{
+			FailSafeExpiryLengthSeconds:  uint16(FailSafeExpiryLength / time.Second),
+			MaxCumulativeFailsafeSeconds: uint16(MaxCumulativeFailSafe / time.Second),
+		},
+		// Default: all locations allowed
+		LocationCapability:         generalcommissioning.RegulatoryIndoorOutdoor,
+		FailSafeManager:            failSafeManager{n},
+		CommissioningWindowManager: n,
+	})
+	n.operationalCredentials, _ = operationalcredentials.New(operationalcredentials.Config{
+		EndpointID:  RootEndpointID,
+		Fabrics:     n.fabricTable,
+		Manager:     opCredsManager{n},
+		FailSafe:    failSafeManager{n},
+		Attestation: config.Attestation,
+	})
+	n.basicInfo = newBasicInformation(&config, n.EventPublisher())
+	n.testEventTriggers = generaldiagnostics.NewTriggerRegistry(config.TestEventTriggers)
+	rootEP := createRootEndpoint(&config, n.fabricTable, n.dataModel, n.basicInfo, n.generalCommissioning,
+		n.accessControl, n.adminCommissioning, n.operationalCredentials, n.testEventTriggers)
 	n.endpoints[RootEndpointID] = rootEP
 	n.dataModel.AddEndpoint(rootEP.Inner())
 
@@ -128,7 +221,10 @@ func (n *Node) loadState() error {
 	}
 
 	// Create fabric table
-	n.fabricTable = fabric.NewTable(fabric.TableConfig{})
+	n.fabricTable = fabric.NewTable(fabric.TableConfig{
+		MaxFabrics: n.config.SupportedFabrics,
+		Keystore:   n.config.OperationalKeystore,
+	})
 	for _, f := range fabrics {
 		if err := n.fabricTable.Add(f); err != nil {
 			return err
@@ -148,8 +244,20 @@ func (n *Node) loadState() error {
 		store.Save(fabric.FabricIndex(entry.FabricIndex), *entry)
 	}
 
-	// Create ACL manager with null device type resolver
-	n.aclMgr = acl.NewManager(store, acl.NullDeviceTypeResolver{})
+	// Create ACL manager; device type targets resolve against the
+	// endpoints' descriptor device type lists
+	n.aclMgr = acl.NewManager(store, descriptor.NewDeviceTypeResolver(n.dataModel),
+		acl.WithDecisionTracer(n.config.OnAccessDecision))
+	if err := n.aclMgr.LoadFromStore(); err != nil {
+		return err
+	}
+
+	// Load failed PASE attempts
+	if attempts, ok := n.config.Storage.(PASEAttemptStorage); ok {
+		if n.paseAttempts, err = attempts.LoadPASEAttempts(); err != nil {
+			return err
+		}
+	}
 
 	// Load counters
 	counters, err := n.config.Storage.LoadCounters()
@@ -181,8 +289,18 @@ func (n *Node) initManagers() error {
 	return nil
 }
 
-// initPASE generates PASE parameters from the passcode.
+// initPASE generates PASE parameters from the passcode, or takes the
+// verifier the node was configured with.
 func (n *Node) initPASE() error {
+	if n.config.PASEVerifier != nil {
+		n.paseInfo = &paseInfo{
+			verifier:   n.config.PASEVerifier,
+			salt:       n.config.PASESalt,
+			iterations: n.config.PASEIterations,
+		}
+		return nil
+	}
+
 	// Generate random salt (32 bytes per spec)
 	salt := make([]byte, 32)
 	if _, err := rand.Read(salt); err != nil {
@@ -210,6 +328,9 @@ func (n *Node) initPASE() error {
 // Start initializes the network stack and begins operation.
 // For uncommissioned devices, this enables commissioning discovery.
 // For commissioned devices, this enables operational discovery.
+//
+// A stopped node can be started again. Once running, the node emits the
+// Basic Information StartUp event.
 func (n *Node) Start(ctx context.Context) error {
 	n.mu.Lock()
 	defer n.mu.Unlock()
@@ -221,21 +342,31 @@ func (n *Node) Start(ctx context.Context) error {
 		return ErrNotInitialized
 	}
 
+	if err := n.checkConformance(); err != nil {
+		return err
+	}
+
+	prev := n.state
 	n.state = NodeStateStarting
 
 	// Create context for background operations
 	n.ctx, n.cancel = context.WithCancel(ctx)
+	if prev == NodeStateStopped {
+		n.stopCh = make(chan struct{})
+		n.stopOnce = sync.Once{}
+	}
 
 	// Start transport
 	if err := n.startTransport(); err != nil {
-		n.state = NodeStateInitialized
+		n.state = prev
 		return err
 	}
+	n.joinGroupsLocked()
 
 	// Start exchange manager
 	if err := n.startExchange(); err != nil {
 		n.stopTransport()
-		n.state = NodeStateInitialized
+		n.state = prev
 		return err
 	}
 
@@ -246,7 +377,7 @@ func (n *Node) Start(ctx context.Context) error {
 	if err := n.startDiscovery(); err != nil {
 		n.stopExchange()
 		n.stopTransport()
-		n.state = NodeStateInitialized
+		n.state = prev
 		return err
 	}
 
@@ -254,10 +385,17 @@ func (n *Node) Start(ctx context.Context) error {
 	if n.fabricTable.Count() > 0 {
 		n.state = NodeStateCommissioned
 		n.advertiseOperational()
+		go n.resumeSubscriptions(n.ctx, n.imEngine)
 	} else {
 		n.state = NodeStateUncommissioned
-		// Auto-open commissioning window for uncommissioned devices
-		n.openCommissioningWindowLocked(3 * time.Minute)
+		n.openBasicCommissioningWindowLocked()
+	}
+
+	n.startSessionRefreshLocked()
+	n.startNetworkMonitorLocked()
+
+	if _, err := n.basicInfo.EmitStartUp(); err != nil && n.log != nil {
+		n.log.Warnf("failed to emit StartUp event: %v", err)
 	}
 
 	if n.log != nil {
@@ -276,6 +414,7 @@ func (n *Node) Start(ctx context.Context) error {
 func (n *Node) startTransport() error {
 	var udpConn net.PacketConn
 	var tcpListener net.Listener
+	var tcpDial func(net.Addr) (net.Conn, error)
 	var err error
 
 	if n.config.TransportFactory != nil {
@@ -288,6 +427,9 @@ func (n *Node) startTransport() error {
 		if err != nil {
 			return err
 		}
+		if dialer, ok := n.config.TransportFactory.(transport.StreamDialer); ok {
+			tcpDial = dialer.DialStream
+		}
 	}
 
 	// Create message handler that routes to exchange manager
@@ -297,15 +439,21 @@ func (n *Node) startTransport() error {
 		}
 	}
 
-	// Create transport manager
+	// Create transport manager; a factory without TCP, like a Thread
+	// stack's, leaves the node on UDP
 	n.transportMgr, err = transport.NewManager(transport.ManagerConfig{
 		Port:           n.config.Port,
 		UDPEnabled:     true,
-		TCPEnabled:     true,
+		TCPEnabled:     n.config.TransportFactory == nil || tcpListener != nil,
 		UDPConn:        udpConn,
 		TCPListener:    tcpListener,
+		TCPDial:        tcpDial,
+		Interface:      n.config.Interface,
+		ReusePort:      n.config.ReusePort,
+		SelectSource:   n.config.SelectSource,
 		MessageHandler: handler,
 		LoggerFactory:  n.config.LoggerFactory,
+		Tap:            n.config.Tap,
 	})
 	if err != nil {
 		return err
@@ -328,6 +476,13 @@ func (n *Node) startExchange() error {
 		SessionManager:   n.sessionMgr,
 		TransportManager: n.transportMgr,
 		LoggerFactory:    n.config.LoggerFactory,
+		Metrics:          n.config.Metrics,
+		Clock:            n.clock,
+		CounterSync: securechannel.NewCounterSync(securechannel.CounterSyncConfig{
+			SessionManager: n.sessionMgr,
+			Clock:          n.clock,
+		}),
+		DisableGroupLoopback: n.config.DisableGroupLoopback,
 	})
 	return nil
 }
@@ -345,27 +500,39 @@ func (n *Node) registerProtocols() {
 	n.scMgr = securechannel.NewManager(securechannel.ManagerConfig{
 		SessionManager: n.sessionMgr,
 		FabricTable:    n.fabricTable,
+		CertValidator:  securechannel.NewCertValidator(),
 		Callbacks: securechannel.Callbacks{
 			OnSessionEstablished: n.onSessionEstablished,
 			OnSessionError:       n.onSessionError,
 			OnSessionClosed:      n.onSessionClosed,
+			OnPASEAttemptFailed:  n.onPASEAttemptFailed,
 		},
 		LoggerFactory: n.config.LoggerFactory,
+		Metrics:       n.config.Metrics,
+		Clock:         n.clock,
 	})
 
-	// Create ACL checker for IM
-	aclChecker := acl.NewChecker(acl.NullDeviceTypeResolver{})
-
-	// Create IM engine
+	// Create IM engine; access is checked against the node's ACL, and
+	// reports are chunked to the transport's message size
 	n.imEngine = im.NewEngine(im.EngineConfig{
-		Dispatcher:    n.dispatcher,
-		ACLChecker:    aclChecker,
-		LoggerFactory: n.config.LoggerFactory,
+		Dispatcher:        n.dispatcher,
+		MaxPayload:        n.transportMgr.MaxMessageSize() - im.MessageHeaderOverhead,
+		ACLChecker:        n.aclMgr,
+		ExchangeManager:   n.exchangeMgr,
+		SubscriptionStore: n.config.Storage,
+		EventManager:      n.events,
+		LoggerFactory:     n.config.LoggerFactory,
+		Metrics:           n.config.Metrics,
+		TracerProvider:    n.config.TracerProvider,
+		Clock:             n.clock,
 	})
 
+	// Report attribute changes to subscribers
+	n.dataModel.SetAttributeChangeListener(n.imEngine)
+
 	// Register with exchange manager
-	n.exchangeMgr.RegisterProtocol(message.ProtocolSecureChannel, newSecureChannelAdapter(n.scMgr))
-	n.exchangeMgr.RegisterProtocol(im.ProtocolID, newIMAdapter(n.imEngine))
+	n.exchangeMgr.RegisterProtocol(message.ProtocolSecureChannel, newSecureChannelAdapter(n.scMgr, n.sessionMgr, n.handleCheckIn))
+	n.exchangeMgr.RegisterProtocol(im.ProtocolID, newIMAdapter(n, n.imEngine))
 }
 
 // startDiscovery initializes DNS-SD.
@@ -383,10 +550,13 @@ func (n *Node) startDiscovery() error {
 	return err
 }
 
-// stopDiscovery shuts down DNS-SD.
+// stopDiscovery withdraws the node's advertisements and shuts down
+// DNS-SD.
 func (n *Node) stopDiscovery() {
 	if n.discoveryMgr != nil {
+		n.discoveryMgr.StopAllAdvertising()
 		n.discoveryMgr.Close()
+		n.discoveryMgr = nil
 	}
 }
 
@@ -404,19 +574,39 @@ func (n *Node) advertiseOperational() {
 	})
 }
 
-// Stop gracefully shuts down the node.
-func (n *Node) Stop() error {
+// Stop gracefully shuts down the node. It emits the Basic Information
+// ShutDown event, sends CloseSession to the peer of each secure session,
+// stops advertising, persists its state and flushes the storage, then
+// stops the transports. The node can be started again.
+//
+// ctx bounds the time spent closing sessions: once it is done, the
+// remaining sessions are dropped without telling their peers.
+func (n *Node) Stop(ctx context.Context) error {
 	n.mu.Lock()
-	defer n.mu.Unlock()
-
 	if !n.state.CanStop() {
-		if n.state == NodeStateStopped {
+		state := n.state
+		n.mu.Unlock()
+		if state == NodeStateStopped {
 			return ErrAlreadyStopped
 		}
 		return ErrNotStarted
 	}
-
 	n.state = NodeStateStopping
+	exchangeMgr := n.exchangeMgr
+	n.mu.Unlock()
+
+	if _, err := n.basicInfo.EmitShutDown(); err != nil && n.log != nil {
+		n.log.Warnf("failed to emit ShutDown event: %v", err)
+	}
+
+	// Commissioning cannot complete across a restart: undo it
+	n.expireFailSafe(commissioning.ErrFailSafeExpired, false)
+
+	// Tell the peers before the transports go
+	n.closeSessions(ctx, exchangeMgr)
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
 
 	// Signal stop
 	n.stopOnce.Do(func() {
@@ -427,11 +617,15 @@ func (n *Node) Stop() error {
 	})
 
 	// Close commissioning window if open
-	// Set to nil first so callback sees it's already cleaned up
 	if n.commWindow != nil {
-		cw := n.commWindow
-		n.commWindow = nil
-		cw.Close()
+		n.closeCommissioningWindowLocked(nil)
+	}
+
+	n.stopSessionRefreshLocked()
+
+	// Stop reporting; persisted subscriptions are resumed on the next start
+	if n.imEngine != nil {
+		n.imEngine.Close()
 	}
 
 	// Stop in reverse order
@@ -441,6 +635,11 @@ func (n *Node) Stop() error {
 
 	// Persist state
 	n.saveState()
+	if flusher, ok := n.config.Storage.(StorageFlusher); ok {
+		if err := flusher.Flush(); err != nil && n.log != nil {
+			n.log.Warnf("failed to flush storage: %v", err)
+		}
+	}
 
 	n.state = NodeStateStopped
 
@@ -455,6 +654,38 @@ func (n *Node) Stop() error {
 	return nil
 }
 
+// Restart stops the node and starts it again, e.g. to apply a new
+// network configuration. ctx bounds the stop and, as for Start, scopes
+// the node's background operations.
+func (n *Node) Restart(ctx context.Context) error {
+	if err := n.Stop(ctx); err != nil {
+		return err
+	}
+	return n.Start(ctx)
+}
+
+// closeSessions sends CloseSession to the peer of each secure session
+// whose address is known, while ctx lasts, and removes the sessions.
+func (n *Node) closeSessions(ctx context.Context, exchangeMgr *exchange.Manager) {
+	var sessions []*session.SecureContext
+	n.sessionMgr.ForEachSecureSession(func(sess *session.SecureContext) bool {
+		sessions = append(sessions, sess)
+		return true
+	})
+
+	for _, sess := range sessions {
+		id := sess.LocalSessionID()
+		if exchangeMgr != nil && ctx.Err() == nil {
+			if addr, ok := exchangeMgr.SessionPeerAddress(id); ok {
+				if err := sendCloseSession(exchangeMgr, sess, addr); err != nil && n.log != nil {
+					n.log.Debugf("failed to send CloseSession on session %d: %v", id, err)
+				}
+			}
+		}
+		n.sessionMgr.RemoveSecureContext(id)
+	}
+}
+
 // saveState persists current state to storage.
 func (n *Node) saveState() {
 	// Save counters
@@ -470,37 +701,102 @@ func (n *Node) State() NodeState {
 	return n.state
 }
 
-// AddEndpoint registers an endpoint with the node.
+// checkConformance checks the endpoint compositions against the device
+// library, as selected by NodeConfig.Conformance.
+func (n *Node) checkConformance() error {
+	if n.config.Conformance == ConformanceOff {
+		return nil
+	}
+	library := n.config.DeviceLibrary
+	if library == nil {
+		library = datamodel.DefaultDeviceLibrary()
+	}
+
+	err := library.CheckNode(n.dataModel)
+	var cerr *datamodel.ConformanceError
+	if !errors.As(err, &cerr) {
+		return nil
+	}
+	if n.config.Conformance == ConformanceStrict {
+		return err
+	}
+	if n.log != nil {
+		for _, issue := range cerr.Issues {
+			n.log.Warnf("non-conformant: %s", issue)
+		}
+	}
+	return nil
+}
+
+// AddEndpoint registers an endpoint with the node, together with the parts
+// added to it with Endpoint.AddPart.
 // The Root Endpoint (0) is created automatically and cannot be added manually.
+// If a cluster could not be added to the endpoint, its error is returned.
+// An endpoint with a parent (see Endpoint.WithParent) must be added after
+// the parent.
 func (n *Node) AddEndpoint(ep *Endpoint) error {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
-	if ep.ID() == RootEndpointID {
-		return ErrRootEndpointReserved
+	tree := ep.tree()
+	if err := n.checkEndpointsLocked(tree); err != nil {
+		return err
+	}
+	if parent := ep.parentID(); parent != nil {
+		if _, exists := n.endpoints[*parent]; !exists {
+			return ErrParentNotFound
+		}
 	}
 
-	if _, exists := n.endpoints[ep.ID()]; exists {
-		return ErrEndpointExists
+	for _, e := range tree {
+		// Ensure endpoint has a descriptor cluster
+		updateEndpointDescriptor(e, n.dataModel)
+
+		n.endpoints[e.ID()] = e
+		n.dataModel.AddEndpoint(e.Inner())
 	}
 
-	// Ensure endpoint has a descriptor cluster
-	updateEndpointDescriptor(ep, n.dataModel)
+	// Update the descriptors listing the endpoint
+	n.updatePartsListsLocked(ep)
 
-	n.endpoints[ep.ID()] = ep
-	n.dataModel.AddEndpoint(ep.Inner())
+	return nil
+}
 
-	// Update root endpoint's descriptor
-	endpoints := make([]*Endpoint, 0, len(n.endpoints))
+// checkEndpointsLocked checks that endpoints can be added: their IDs and
+// unique IDs are unused and their clusters were added.
+// Caller must hold n.mu.
+func (n *Node) checkEndpointsLocked(endpoints []*Endpoint) error {
+	ids := make(map[datamodel.EndpointID]bool)
+	uniqueIDs := make(map[string]bool)
 	for _, e := range n.endpoints {
-		endpoints = append(endpoints, e)
+		if e.uniqueID != nil {
+			uniqueIDs[*e.uniqueID] = true
+		}
 	}
-	updateDescriptorCluster(n.dataModel, endpoints)
 
+	for _, e := range endpoints {
+		if e.ID() == RootEndpointID {
+			return ErrRootEndpointReserved
+		}
+		if _, exists := n.endpoints[e.ID()]; exists || ids[e.ID()] {
+			return ErrEndpointExists
+		}
+		if e.err != nil {
+			return e.err
+		}
+		if e.uniqueID != nil {
+			if uniqueIDs[*e.uniqueID] {
+				return fmt.Errorf("%w: %q is used by another endpoint", ErrInvalidEndpointUniqueID, *e.uniqueID)
+			}
+			uniqueIDs[*e.uniqueID] = true
+		}
+		ids[e.ID()] = true
+	}
 	return nil
 }
 
-// RemoveEndpoint removes an endpoint by ID.
+// RemoveEndpoint removes an endpoint by ID. An endpoint with parts cannot
+// be removed before them.
 func (n *Node) RemoveEndpoint(id datamodel.EndpointID) error {
 	n.mu.Lock()
 	defer n.mu.Unlock()
@@ -509,19 +805,21 @@ func (n *Node) RemoveEndpoint(id datamodel.EndpointID) error {
 		return ErrRootEndpointReserved
 	}
 
-	if _, exists := n.endpoints[id]; !exists {
+	ep, exists := n.endpoints[id]
+	if !exists {
 		return ErrEndpointNotFound
 	}
+	for _, e := range n.endpoints {
+		if parent := e.parentID(); parent != nil && *parent == id {
+			return ErrEndpointHasParts
+		}
+	}
 
 	delete(n.endpoints, id)
 	n.dataModel.RemoveEndpoint(id)
 
-	// Update root endpoint's descriptor
-	endpoints := make([]*Endpoint, 0, len(n.endpoints))
-	for _, e := range n.endpoints {
-		endpoints = append(endpoints, e)
-	}
-	updateDescriptorCluster(n.dataModel, endpoints)
+	// Update the descriptors that listed the endpoint
+	n.updatePartsListsLocked(ep)
 
 	return nil
 }
@@ -553,6 +851,26 @@ func (n *Node) Fabrics() []*fabric.FabricInfo {
 	return result
 }
 
+// AccessControl returns the root endpoint's Access Control cluster, e.g. to
+// set the access restrictions of a managed device.
+func (n *Node) AccessControl() *accesscontrol.Cluster {
+	return n.accessControl
+}
+
+// TestEventTriggers returns the registry of the delegates simulating the
+// events of TestEventTrigger, keyed by cluster. Triggers are accepted only
+// with NodeConfig.TestEventTriggerEnableKey.
+func (n *Node) TestEventTriggers() *generaldiagnostics.TriggerRegistry {
+	return n.testEventTriggers
+}
+
+// EventPublisher returns the publisher of the node's events, to be set in
+// cluster configs. Published events are read by and reported to the
+// node's subscribers.
+func (n *Node) EventPublisher() datamodel.EventPublisher {
+	return im.NewEventManagerPublisher(n.events)
+}
+
 // SessionManager returns the node's session manager.
 // Exposed for testing and advanced use cases.
 func (n *Node) SessionManager() *session.Manager {
@@ -571,6 +889,15 @@ func (n *Node) ExchangeManager() *exchange.Manager {
 	return n.exchangeMgr
 }
 
+// IMEngine returns the node's Interaction Model engine. An im.Client
+// configured with it receives the reports of its subscriptions.
+// Exposed for testing and advanced use cases.
+func (n *Node) IMEngine() *im.Engine {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.imEngine
+}
+
 // TransportManager returns the node's transport manager.
 // Exposed for testing and advanced use cases.
 func (n *Node) TransportManager() *transport.Manager {
@@ -583,43 +910,30 @@ func (n *Node) LoggerFactory() logging.LoggerFactory {
 	return n.config.LoggerFactory
 }
 
-// RemoveFabric removes the node from a fabric.
-func (n *Node) RemoveFabric(index fabric.FabricIndex) error {
-	n.mu.Lock()
-	defer n.mu.Unlock()
-
-	if err := n.fabricTable.Remove(index); err != nil {
-		return ErrFabricNotFound
-	}
-
-	// Remove from storage
-	n.config.Storage.DeleteFabric(index)
-
-	// Update state if no fabrics remain
-	if n.fabricTable.Count() == 0 && n.state == NodeStateCommissioned {
-		n.state = NodeStateUncommissioned
-		if n.config.OnStateChanged != nil {
-			n.config.OnStateChanged(n.state)
-		}
-	}
-
-	return nil
-}
-
 // Session callbacks
 
 func (n *Node) onSessionEstablished(ctx *session.SecureContext) {
 	n.mu.Lock()
-	defer n.mu.Unlock()
 
-	// Notify commissioning window if PASE session
-	if ctx.SessionType() == session.SessionTypePASE && n.commWindow != nil {
+	// A PASE session uses up the commissioning window: the commissioner
+	// continues over the session, and no other may pair meanwhile
+	if ctx.SessionType() == session.SessionTypePASE && ctx.Role() == session.SessionRoleResponder &&
+		n.commWindow != nil {
 		n.commWindow.OnPASEComplete(ctx)
+		n.closeCommissioningWindowLocked(nil)
+		n.savePASEAttemptsLocked(0)
 	}
 
 	if n.config.OnSessionEstablished != nil {
 		n.config.OnSessionEstablished(ctx.LocalSessionID(), ctx.SessionType())
 	}
+	engine := n.imEngine
+	n.mu.Unlock()
+
+	// Reports to the peer follow its newest session
+	if engine != nil {
+		engine.ShiftSubscriptions(ctx)
+	}
 }
 
 func (n *Node) onSessionError(err error, stage string) {
@@ -628,7 +942,37 @@ func (n *Node) onSessionError(err error, stage string) {
 	}
 }
 
+// onSessionClosed cleans up after a secure session closed by the peer or
+// by CloseSession: its exchanges end and the subscriptions served over it
+// stop. Devices that were using it are probed at once, which establishes
+// a new session, resuming the closed one where possible, and their
+// subscriptions over it are made again.
 func (n *Node) onSessionClosed(localSessionID uint16) {
+	n.mu.Lock()
+	exchangeMgr, engine := n.exchangeMgr, n.imEngine
+	var devices []*Device
+	for d := range n.devices {
+		if d.isClosed() {
+			delete(n.devices, d)
+		} else {
+			devices = append(devices, d)
+		}
+	}
+	n.mu.Unlock()
+
+	if exchangeMgr != nil {
+		exchangeMgr.AbortSessionExchanges(localSessionID)
+	}
+	if engine != nil {
+		engine.SessionClosed(localSessionID)
+	}
+	for _, d := range devices {
+		if d.usesSession(localSessionID) {
+			d.reprobe()
+		}
+		d.sessionClosed(localSessionID)
+	}
+
 	if n.config.OnSessionClosed != nil {
 		n.config.OnSessionClosed(localSessionID)
 	}
@@ -9,10 +9,16 @@ import (
 // The payload encodes the discriminator, passcode, and other setup information.
 //
 // Example output: "MT:Y.K90SO000000000000"
+//
+// Returns "" for a node configured with only a PASE verifier.
 func (n *Node) OnboardingPayload() string {
 	n.mu.RLock()
 	defer n.mu.RUnlock()
 
+	if n.config.Passcode == 0 {
+		return ""
+	}
+
 	p := payload.SetupPayload{
 		Version:                  0,
 		VendorID:                 uint16(n.config.VendorID),
@@ -36,10 +42,16 @@ func (n *Node) OnboardingPayload() string {
 // The format depends on whether custom commissioning flow is used.
 //
 // Example output: "34970112332"
+//
+// Returns "" for a node configured with only a PASE verifier.
 func (n *Node) ManualPairingCode() string {
 	n.mu.RLock()
 	defer n.mu.RUnlock()
 
+	if n.config.Passcode == 0 {
+		return ""
+	}
+
 	p := payload.SetupPayload{
 		Version:           0,
 		VendorID:          uint16(n.config.VendorID),
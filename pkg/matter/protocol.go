@@ -6,16 +6,20 @@ import (
 	imsg "github.com/backkem/matter/pkg/im/message"
 	"github.com/backkem/matter/pkg/message"
 	"github.com/backkem/matter/pkg/securechannel"
+	"github.com/backkem/matter/pkg/session"
 )
 
 // secureChannelAdapter adapts securechannel.Manager to exchange.ProtocolHandler.
 type secureChannelAdapter struct {
-	manager *securechannel.Manager
+	manager        *securechannel.Manager
+	sessionManager *session.Manager
+	onCheckIn      func(payload []byte)
 }
 
 // newSecureChannelAdapter creates a new secure channel protocol adapter.
-func newSecureChannelAdapter(manager *securechannel.Manager) *secureChannelAdapter {
-	return &secureChannelAdapter{manager: manager}
+// ICD Check-In messages are handed to onCheckIn.
+func newSecureChannelAdapter(manager *securechannel.Manager, sessionManager *session.Manager, onCheckIn func(payload []byte)) *secureChannelAdapter {
+	return &secureChannelAdapter{manager: manager, sessionManager: sessionManager, onCheckIn: onCheckIn}
 }
 
 // OnMessage handles a message on an existing exchange.
@@ -30,6 +34,28 @@ func (a *secureChannelAdapter) OnUnsolicited(ctx *exchange.ExchangeContext, opco
 
 // handleSecureChannel routes secure channel messages and sends response with correct opcode.
 func (a *secureChannelAdapter) handleSecureChannel(ctx *exchange.ExchangeContext, opcode uint8, payload []byte) ([]byte, error) {
+	// Status reports on an established session, like CloseSession, are
+	// not part of a handshake
+	if _, secure := ctx.Session().(exchange.SecureSessionContext); secure &&
+		securechannel.Opcode(opcode) == securechannel.OpcodeStatusReport {
+		if _, err := a.manager.HandleSessionStatusReport(ctx.LocalSessionID(), payload); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	// Check-In messages from ICDs come alone on an unsecured session,
+	// which ends with them
+	if securechannel.Opcode(opcode) == securechannel.OpcodeICDCheckIn {
+		if unsecured, ok := ctx.Session().(*session.UnsecuredContext); ok {
+			a.sessionManager.RemoveUnsecuredContext(unsecured.PeerEphemeralNodeID())
+			if a.onCheckIn != nil {
+				a.onCheckIn(payload)
+			}
+		}
+		return nil, nil
+	}
+
 	msg := &securechannel.Message{
 		Opcode:  securechannel.Opcode(opcode),
 		Payload: payload,
@@ -58,12 +84,13 @@ var _ exchange.ProtocolHandler = (*secureChannelAdapter)(nil)
 
 // imAdapter adapts im.Engine to exchange.ProtocolHandler.
 type imAdapter struct {
+	node   *Node
 	engine *im.Engine
 }
 
 // newIMAdapter creates a new interaction model protocol adapter.
-func newIMAdapter(engine *im.Engine) *imAdapter {
-	return &imAdapter{engine: engine}
+func newIMAdapter(node *Node, engine *im.Engine) *imAdapter {
+	return &imAdapter{node: node, engine: engine}
 }
 
 // OnMessage handles a message on an existing exchange.
@@ -78,6 +105,10 @@ func (a *imAdapter) OnUnsolicited(ctx *exchange.ExchangeContext, opcode uint8, p
 
 // handleIM routes IM messages and handles response opcodes.
 func (a *imAdapter) handleIM(ctx *exchange.ExchangeContext, opcode uint8, payload []byte) ([]byte, error) {
+	id := ctx.LocalSessionID()
+	a.node.beginIMRequest(id)
+	defer a.node.endIMRequest(id)
+
 	// Build protocol header for IM
 	header := &message.ProtocolHeader{
 		ProtocolID:     im.ProtocolID,
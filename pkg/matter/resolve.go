@@ -0,0 +1,55 @@
+package matter
+
+import (
+	"context"
+
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/transport"
+)
+
+// ResolveNode resolves a node on one of the node's fabrics to its
+// operational addresses, most preferred first. Resolutions are cached for
+// their DNS-SD TTL.
+//
+// When CASE or message delivery to an address fails, report it with
+// NodeAddressFailed: the address is then tried last, and the node is
+// resolved again once all its addresses have failed.
+//
+// Spec: Section 4.3.2 (operational discovery)
+func (n *Node) ResolveNode(ctx context.Context, fabricIndex fabric.FabricIndex, nodeID fabric.NodeID) ([]transport.PeerAddress, error) {
+	n.mu.RLock()
+	info, ok := n.fabricTable.Get(fabricIndex)
+	mgr := n.discoveryMgr
+	n.mu.RUnlock()
+
+	if !ok {
+		return nil, ErrFabricNotFound
+	}
+	if mgr == nil {
+		return nil, ErrDiscoveryDisabled
+	}
+
+	resolved, err := mgr.ResolveNode(ctx, info.CompressedFabricID, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]transport.PeerAddress, len(resolved.Addresses))
+	for i, addr := range resolved.Addresses {
+		addrs[i] = transport.NewUDPPeerAddress(addr)
+	}
+	return addrs, nil
+}
+
+// NodeAddressFailed reports that CASE or message delivery to an address
+// returned by ResolveNode failed.
+func (n *Node) NodeAddressFailed(fabricIndex fabric.FabricIndex, nodeID fabric.NodeID, addr transport.PeerAddress) {
+	n.mu.RLock()
+	info, ok := n.fabricTable.Get(fabricIndex)
+	mgr := n.discoveryMgr
+	n.mu.RUnlock()
+
+	if !ok || mgr == nil || addr.Addr == nil {
+		return
+	}
+	mgr.NodeResolver().MarkFailed(info.CompressedFabricID, nodeID, addr.Addr)
+}
@@ -1,9 +1,14 @@
 package matter
 
 import (
+	"github.com/backkem/matter/pkg/clusters/accesscontrol"
+	"github.com/backkem/matter/pkg/clusters/admincommissioning"
 	"github.com/backkem/matter/pkg/clusters/basic"
 	"github.com/backkem/matter/pkg/clusters/descriptor"
+	"github.com/backkem/matter/pkg/clusters/diagnosticlogs"
 	"github.com/backkem/matter/pkg/clusters/generalcommissioning"
+	"github.com/backkem/matter/pkg/clusters/generaldiagnostics"
+	"github.com/backkem/matter/pkg/clusters/operationalcredentials"
 	"github.com/backkem/matter/pkg/datamodel"
 	"github.com/backkem/matter/pkg/fabric"
 )
@@ -20,8 +25,12 @@ const RootDeviceTypeRevision uint8 = 1
 
 // createRootEndpoint creates the root endpoint (endpoint 0) with required clusters.
 // The root endpoint contains node-wide clusters like Basic Information,
-// General Commissioning, and the Descriptor cluster.
-func createRootEndpoint(config *NodeConfig, fabricTable *fabric.Table, node datamodel.Node) *Endpoint {
+// General Commissioning, Access Control, Administrator Commissioning, and
+// the Descriptor cluster.
+func createRootEndpoint(config *NodeConfig, fabricTable *fabric.Table, node datamodel.Node, basicInfo *basic.Cluster,
+	generalCommissioning *generalcommissioning.Cluster, accessControl *accesscontrol.Cluster,
+	adminCommissioning *admincommissioning.Cluster, operationalCredentials *operationalcredentials.Cluster,
+	triggers *generaldiagnostics.TriggerRegistry) *Endpoint {
 	ep := NewEndpoint(RootEndpointID).
 		WithDeviceType(RootDeviceType, RootDeviceTypeRevision)
 
@@ -35,8 +44,59 @@ func createRootEndpoint(config *NodeConfig, fabricTable *fabric.Table, node data
 
 	// Basic Information Cluster (0x0028) - Required
 	// Provides device identity and version information
-	basicInfoCluster := basic.New(basic.Config{
-		EndpointID: RootEndpointID,
+	ep.AddCluster(basicInfo)
+
+	// General Commissioning Cluster (0x0030) - Required
+	// Manages commissioning state and fail-safe timer
+	ep.AddCluster(generalCommissioning)
+
+	// Access Control Cluster (0x001F) - Required
+	// Exposes the ACL and, on managed devices, the access restrictions
+	ep.AddCluster(accessControl)
+
+	// Administrator Commissioning Cluster (0x003C) - Required
+	// Lets an administrator open a window for another commissioner
+	ep.AddCluster(adminCommissioning)
+
+	// Operational Credentials Cluster (0x003E) - Required
+	// Attests the node and installs its operational credentials
+	ep.AddCluster(operationalCredentials)
+
+	// Diagnostic Logs Cluster (0x0032) - Optional
+	// Serves the logs of config.DiagnosticLogs to administrators
+	if config.DiagnosticLogs != nil {
+		diagnosticLogs, _ := diagnosticlogs.New(diagnosticlogs.Config{
+			EndpointID: RootEndpointID,
+			Provider:   config.DiagnosticLogs,
+		})
+		ep.AddCluster(diagnosticLogs)
+	}
+
+	// General Diagnostics Cluster (0x0033) - Optional
+	// Serves TestEventTrigger, dispatched to the registered delegates
+	if config.TestEventTriggerEnableKey != nil || config.TestEventTriggers != nil {
+		generalDiagnostics, _ := generaldiagnostics.New(generaldiagnostics.Config{
+			EndpointID:        RootEndpointID,
+			EnableKey:         config.TestEventTriggerEnableKey,
+			TestEventTriggers: triggers,
+		})
+		ep.AddCluster(generalDiagnostics)
+	}
+
+	// TODO: Add these clusters when implemented:
+	// - Network Commissioning (0x0031) - Required for Wi-Fi/Thread
+	// - Group Key Management (0x003F) - Required for group messaging
+	// - ICD Management (0x0046) - Optional for sleepy devices
+
+	return ep
+}
+
+// newBasicInformation creates the root endpoint's Basic Information
+// cluster, which emits the node's StartUp, ShutDown and Leave events.
+func newBasicInformation(config *NodeConfig, events datamodel.EventPublisher) *basic.Cluster {
+	return basic.New(basic.Config{
+		EndpointID:     RootEndpointID,
+		EventPublisher: events,
 		DeviceInfo: basic.DeviceInfo{
 			DataModelRevision:     17, // Matter 1.5 data model revision
 			VendorName:            getVendorName(config.VendorID),
@@ -57,25 +117,6 @@ func createRootEndpoint(config *NodeConfig, fabricTable *fabric.Table, node data
 			SerialNumber:         &config.SerialNumber,
 		},
 	})
-	ep.AddCluster(basicInfoCluster)
-
-	// General Commissioning Cluster (0x0030) - Required
-	// Manages commissioning state and fail-safe timer
-	gcCluster := generalcommissioning.New(generalcommissioning.Config{
-		EndpointID: RootEndpointID,
-		// Default: all locations allowed
-		LocationCapability: generalcommissioning.RegulatoryIndoorOutdoor,
-	})
-	ep.AddCluster(gcCluster)
-
-	// TODO: Add these clusters when implemented:
-	// - Network Commissioning (0x0031) - Required for Wi-Fi/Thread
-	// - Operational Credentials (0x003E) - Required for certificate management
-	// - Access Control (0x001F) - Required for ACL management
-	// - Group Key Management (0x003F) - Required for group messaging
-	// - ICD Management (0x0046) - Optional for sleepy devices
-
-	return ep
 }
 
 // getVendorName returns a human-readable vendor name.
@@ -90,23 +131,43 @@ func getVendorName(vendorID fabric.VendorID) string {
 	}
 }
 
-// updateDescriptorCluster is a no-op since the descriptor cluster now queries
-// the node directly for endpoint information. Kept for API compatibility.
-func updateDescriptorCluster(node *datamodel.BasicNode, endpoints []*Endpoint) {
-	// No-op: The descriptor cluster queries the node directly via its Node field
-	// to get parts list, server list, etc. No manual updates needed.
+// updatePartsListsLocked reports the change to the PartsList of the
+// descriptors that list an added or removed endpoint: the root endpoint's,
+// which lists every endpoint, and its ancestors'.
+// Caller must hold n.mu.
+func (n *Node) updatePartsListsLocked(ep *Endpoint) {
+	ids := []datamodel.EndpointID{RootEndpointID}
+	for parent := ep.parentID(); parent != nil && *parent != RootEndpointID; {
+		ancestor := n.endpoints[*parent]
+		if ancestor == nil {
+			break
+		}
+		ids = append(ids, *parent)
+		parent = ancestor.parentID()
+	}
+
+	for _, id := range ids {
+		if d, ok := n.endpoints[id].GetCluster(descriptor.ClusterID).(interface {
+			MarkDirty(datamodel.AttributeID)
+		}); ok {
+			d.MarkDirty(descriptor.AttrPartsList)
+		}
+	}
 }
 
 // updateEndpointDescriptor ensures the endpoint has a descriptor cluster.
-// If the endpoint doesn't have one, a descriptor cluster is added.
+// If the endpoint doesn't have one, a descriptor cluster is added, with
+// the endpoint's unique ID and semantic tags.
 // The descriptor cluster will query the node directly for attribute values.
 func updateEndpointDescriptor(ep *Endpoint, node datamodel.Node) {
 	descriptorCluster := ep.GetCluster(descriptor.ClusterID)
 	if descriptorCluster == nil {
 		// Endpoint doesn't have a descriptor cluster - add one
 		descCluster := descriptor.New(descriptor.Config{
-			EndpointID: ep.ID(),
-			Node:       node,
+			EndpointID:       ep.ID(),
+			Node:             node,
+			SemanticTags:     ep.tags,
+			EndpointUniqueID: ep.uniqueID,
 		})
 		ep.AddCluster(descCluster)
 	}
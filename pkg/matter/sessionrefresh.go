@@ -0,0 +1,100 @@
+package matter
+
+import (
+	"time"
+
+	"github.com/backkem/matter/pkg/logger"
+	"github.com/backkem/matter/pkg/session"
+)
+
+// Session refresh defaults.
+const (
+	// DefaultSessionCounterMargin is the number of message counter values
+	// a CASE session may have left before it is replaced.
+	DefaultSessionCounterMargin = 1 << 20
+
+	// DefaultSessionDrainTimeout is how long a replaced session is kept
+	// for its exchanges to complete.
+	DefaultSessionDrainTimeout = 30 * time.Second
+
+	// sessionRefreshInterval is how often the node checks its sessions.
+	sessionRefreshInterval = 10 * time.Second
+)
+
+// startSessionRefreshLocked starts checking the sessions the node initiates for
+// refresh. Caller must hold n.mu.
+func (n *Node) startSessionRefreshLocked() {
+	if n.config.OperationalKey == nil {
+		return
+	}
+	n.refreshTimer = n.clock.AfterFunc(sessionRefreshInterval, n.refreshSessions)
+}
+
+// stopSessionRefreshLocked stops checking the sessions. Caller must hold
+// n.mu.
+func (n *Node) stopSessionRefreshLocked() {
+	if n.refreshTimer != nil {
+		n.refreshTimer.Stop()
+		n.refreshTimer = nil
+	}
+}
+
+// refreshSessions replaces the CASE sessions that are due, and closes the
+// replaced ones once drained.
+//
+// Subscriptions served to the peer move to the new session as it is
+// established (see onSessionEstablished), and FindOrEstablishSession
+// hands out the new session from then on. Exchanges already open on the
+// old session complete on it; it is closed, telling the peer, once they
+// have, or after SessionDrainTimeout.
+func (n *Node) refreshSessions() {
+	n.mu.Lock()
+	if !n.state.IsRunning() {
+		n.mu.Unlock()
+		return
+	}
+	base, exchangeMgr := n.ctx, n.exchangeMgr
+	n.refreshTimer = n.clock.AfterFunc(sessionRefreshInterval, n.refreshSessions)
+	n.mu.Unlock()
+
+	now := n.clock.Now()
+	var due []*session.SecureContext
+	n.sessionMgr.ForEachSecureSession(func(sess *session.SecureContext) bool {
+		if n.sessionDue(sess, now) {
+			due = append(due, sess)
+		}
+		return true
+	})
+	for _, sess := range due {
+		addr, _ := exchangeMgr.SessionPeerAddress(sess.LocalSessionID())
+		go func() {
+			if err := n.casePool.refresh(base, sess, addr); err != nil && n.log != nil {
+				n.log.Warnf("failed to refresh session %d with node 0x%016X: %v",
+					sess.LocalSessionID(), logger.Redact(uint64(sess.PeerNodeID())), err)
+			}
+		}()
+	}
+
+	for sess, addr := range n.casePool.retired(n.config.SessionDrainTimeout, exchangeMgr.SessionExchangeCount) {
+		if n.log != nil {
+			n.log.Infof("closing replaced session %d", sess.LocalSessionID())
+		}
+		if err := n.CloseSession(sess, addr); err != nil && n.log != nil {
+			n.log.Debugf("failed to send CloseSession for session %d: %v", sess.LocalSessionID(), err)
+		}
+	}
+}
+
+// sessionDue reports whether a session must be replaced: a CASE session
+// the node initiated whose message counter is near exhaustion or that
+// reached SessionLifetime.
+func (n *Node) sessionDue(sess *session.SecureContext, now time.Time) bool {
+	if sess.SessionType() != session.SessionTypeCASE || sess.Role() != session.SessionRoleInitiator {
+		return false
+	}
+	if sess.CounterRemaining() < n.config.SessionCounterMargin {
+		return true
+	}
+	lifetime := n.config.SessionLifetime
+	return lifetime > 0 && now.Sub(sess.EstablishedAt()) >= lifetime
+}
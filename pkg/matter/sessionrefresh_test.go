@@ -0,0 +1,137 @@
+package matter
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/backkem/matter/pkg/clock"
+	"github.com/backkem/matter/pkg/session"
+	"github.com/backkem/matter/pkg/transport"
+)
+
+func TestCASEPool_Refresh(t *testing.T) {
+	p := newTestCASEPool(t, "fd00::1")
+	p.reachable["[fd00::1]:5540"] = true
+	clk := clock.NewFakeClock(time.Unix(1000, 0))
+	p.clock = clk
+	peer := peerRef{fabricIndex: 1, nodeID: 0x22}
+	ctx := context.Background()
+
+	old, _, err := p.findOrEstablish(ctx, ctx, peer)
+	if err != nil {
+		t.Fatalf("findOrEstablish() error = %v", err)
+	}
+	if err := p.refresh(ctx, old, transport.PeerAddress{}); err != nil {
+		t.Fatalf("refresh() error = %v", err)
+	}
+
+	// The new session is handed out, established without resumption
+	sess, _, err := p.findOrEstablish(ctx, ctx, peer)
+	if err != nil || sess == old {
+		t.Fatalf("findOrEstablish() = %v, %v, want the new session", sess, err)
+	}
+	if len(p.resumptions) != 2 || p.resumptions[1] != nil {
+		t.Errorf("resumptions = %v, want a full handshake", p.resumptions)
+	}
+
+	// The old session drains its exchanges, then is closed
+	busy := func(uint16) int { return 1 }
+	if closing := p.retired(time.Minute, busy); len(closing) != 0 {
+		t.Errorf("retired() = %v with open exchanges", closing)
+	}
+	clk.Advance(time.Minute)
+	closing := p.retired(time.Minute, busy)
+	if addr, ok := closing[old]; !ok || len(closing) != 1 || addr.Addr.String() != "[fd00::1]:5540" {
+		t.Errorf("retired() = %v after the drain timeout, want the old session", closing)
+	}
+	if closing := p.retired(time.Minute, busy); len(closing) != 0 {
+		t.Errorf("retired() = %v, want the old session reported once", closing)
+	}
+}
+
+func TestCASEPool_RefreshFails(t *testing.T) {
+	p := newTestCASEPool(t, "fd00::1")
+	p.reachable["[fd00::1]:5540"] = true
+	peer := peerRef{fabricIndex: 1, nodeID: 0x22}
+	ctx := context.Background()
+
+	old, _, err := p.findOrEstablish(ctx, ctx, peer)
+	if err != nil {
+		t.Fatalf("findOrEstablish() error = %v", err)
+	}
+	p.reachable["[fd00::1]:5540"] = false
+	if err := p.refresh(ctx, old, transport.PeerAddress{}); err == nil {
+		t.Fatal("refresh() succeeded with the peer unreachable")
+	}
+
+	// The old session stays in use
+	if sess, _, err := p.findOrEstablish(ctx, ctx, peer); err != nil || sess != old {
+		t.Errorf("findOrEstablish() = %v, %v, want the old session", sess, err)
+	}
+	if closing := p.retired(0, func(uint16) int { return 0 }); len(closing) != 0 {
+		t.Errorf("retired() = %v after a failed refresh", closing)
+	}
+}
+
+func TestNodeSessionDue(t *testing.T) {
+	clk := clock.NewFakeClock(time.Unix(1000, 0))
+	newNode := func(margin uint32, lifetime time.Duration) *Node {
+		node, err := NewNode(NodeConfig{
+			VendorID:             0xFFF1,
+			ProductID:            0x8001,
+			Discriminator:        3840,
+			Passcode:             20202021,
+			Storage:              NewMemoryStorage(),
+			Clock:                clk,
+			SessionCounterMargin: margin,
+			SessionLifetime:      lifetime,
+		})
+		if err != nil {
+			t.Fatalf("NewNode failed: %v", err)
+		}
+		return node
+	}
+	newSession := func(typ session.SessionType, role session.SessionRole) *session.SecureContext {
+		key := make([]byte, session.SessionKeySize)
+		sess, err := session.NewSecureContext(session.SecureContextConfig{
+			SessionType:    typ,
+			Role:           role,
+			LocalSessionID: 1,
+			I2RKey:         key,
+			R2IKey:         key,
+			Clock:          clk,
+		})
+		if err != nil {
+			t.Fatalf("NewSecureContext: %v", err)
+		}
+		return sess
+	}
+
+	initiator := newSession(session.SessionTypeCASE, session.SessionRoleInitiator)
+	responder := newSession(session.SessionTypeCASE, session.SessionRoleResponder)
+	pase := newSession(session.SessionTypePASE, session.SessionRoleInitiator)
+
+	byLifetime := newNode(0, time.Hour)
+	now := clk.Now()
+	if byLifetime.sessionDue(initiator, now) {
+		t.Error("new session due")
+	}
+	now = now.Add(time.Hour)
+	if !byLifetime.sessionDue(initiator, now) {
+		t.Error("session not due after SessionLifetime")
+	}
+	if byLifetime.sessionDue(responder, now) || byLifetime.sessionDue(pase, now) {
+		t.Error("responder or PASE session due")
+	}
+
+	// Every session is within a margin this large of exhaustion
+	byCounter := newNode(math.MaxUint32, 0)
+	if !byCounter.sessionDue(initiator, clk.Now()) {
+		t.Error("session not due within SessionCounterMargin")
+	}
+	if newNode(0, 0).sessionDue(initiator, now.Add(24*time.Hour)) {
+		t.Error("session due by age without SessionLifetime")
+	}
+}
@@ -27,7 +27,8 @@ const (
 	// NodeStateStopping means Stop() has been called and shutdown is in progress.
 	NodeStateStopping
 
-	// NodeStateStopped means the node has been shut down.
+	// NodeStateStopped means the node has been shut down. It can be
+	// started again.
 	NodeStateStopped
 )
 
@@ -67,7 +68,7 @@ func (s NodeState) IsRunning() bool {
 
 // CanStart returns true if Start() can be called in this state.
 func (s NodeState) CanStart() bool {
-	return s == NodeStateInitialized
+	return s == NodeStateInitialized || s == NodeStateStopped
 }
 
 // CanStop returns true if Stop() can be called in this state.
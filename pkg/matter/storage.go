@@ -3,6 +3,8 @@ package matter
 import (
 	"github.com/backkem/matter/pkg/acl"
 	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/im"
+	imsg "github.com/backkem/matter/pkg/im/message"
 )
 
 // Storage abstracts persistent storage for Matter state.
@@ -26,6 +28,26 @@ type Storage interface {
 	// Group keys
 	LoadGroupKeys() ([]GroupKeyEntry, error)
 	SaveGroupKeys(keys []GroupKeyEntry) error
+
+	// Subscriptions (resumed after a restart)
+	LoadSubscriptions() ([]*im.SubscriptionRecord, error)
+	SaveSubscription(rec *im.SubscriptionRecord) error
+	DeleteSubscription(id imsg.SubscriptionID) error
+}
+
+// PASEAttemptStorage is implemented by storage that keeps the number of
+// failed PASE attempts across reboots, so that restarting the node does
+// not reset the brute-force protection. Without it the node counts the
+// attempts in memory.
+type PASEAttemptStorage interface {
+	LoadPASEAttempts() (int, error)
+	SavePASEAttempts(count int) error
+}
+
+// StorageFlusher is implemented by storage that buffers writes. Node.Stop
+// flushes it.
+type StorageFlusher interface {
+	Flush() error
 }
 
 // CounterState holds message counter state for persistence.
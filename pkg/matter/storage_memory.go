@@ -5,6 +5,8 @@ import (
 
 	"github.com/backkem/matter/pkg/acl"
 	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/im"
+	imsg "github.com/backkem/matter/pkg/im/message"
 )
 
 // MemoryStorage is an in-memory Storage implementation.
@@ -14,19 +16,24 @@ import (
 type MemoryStorage struct {
 	mu sync.RWMutex
 
-	fabrics   map[fabric.FabricIndex]*fabric.FabricInfo
-	acls      []*acl.Entry
-	counters  *CounterState
-	groupKeys []GroupKeyEntry
+	fabrics       map[fabric.FabricIndex]*fabric.FabricInfo
+	acls          []*acl.Entry
+	counters      *CounterState
+	groupKeys     []GroupKeyEntry
+	subscriptions map[imsg.SubscriptionID]*im.SubscriptionRecord
+	paseAttempts  int
+	keys          map[fabric.KeyHandle][]byte
 }
 
 // NewMemoryStorage creates a new in-memory storage.
 func NewMemoryStorage() *MemoryStorage {
 	return &MemoryStorage{
-		fabrics:   make(map[fabric.FabricIndex]*fabric.FabricInfo),
-		acls:      make([]*acl.Entry, 0),
-		counters:  NewCounterState(),
-		groupKeys: make([]GroupKeyEntry, 0),
+		fabrics:       make(map[fabric.FabricIndex]*fabric.FabricInfo),
+		acls:          make([]*acl.Entry, 0),
+		counters:      NewCounterState(),
+		groupKeys:     make([]GroupKeyEntry, 0),
+		subscriptions: make(map[imsg.SubscriptionID]*im.SubscriptionRecord),
+		keys:          make(map[fabric.KeyHandle][]byte),
 	}
 }
 
@@ -67,6 +74,13 @@ func (m *MemoryStorage) DeleteFabric(index fabric.FabricIndex) error {
 	}
 	m.acls = filtered
 
+	// And its subscriptions
+	for id, rec := range m.subscriptions {
+		if rec.FabricIndex == index {
+			delete(m.subscriptions, id)
+		}
+	}
+
 	return nil
 }
 
@@ -150,6 +164,53 @@ func (m *MemoryStorage) SaveGroupKeys(keys []GroupKeyEntry) error {
 	return nil
 }
 
+// LoadSubscriptions returns all stored subscriptions.
+func (m *MemoryStorage) LoadSubscriptions() ([]*im.SubscriptionRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]*im.SubscriptionRecord, 0, len(m.subscriptions))
+	for _, rec := range m.subscriptions {
+		result = append(result, rec.Clone())
+	}
+	return result, nil
+}
+
+// SaveSubscription stores or updates a subscription.
+func (m *MemoryStorage) SaveSubscription(rec *im.SubscriptionRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.subscriptions[rec.SubscriptionID] = rec.Clone()
+	return nil
+}
+
+// DeleteSubscription removes a subscription by ID.
+func (m *MemoryStorage) DeleteSubscription(id imsg.SubscriptionID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.subscriptions, id)
+	return nil
+}
+
+// LoadPASEAttempts returns the stored number of failed PASE attempts. It
+// implements PASEAttemptStorage.
+func (m *MemoryStorage) LoadPASEAttempts() (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.paseAttempts, nil
+}
+
+// SavePASEAttempts stores the number of failed PASE attempts.
+func (m *MemoryStorage) SavePASEAttempts(count int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.paseAttempts = count
+	return nil
+}
+
 // Clear removes all stored data.
 func (m *MemoryStorage) Clear() {
 	m.mu.Lock()
@@ -159,7 +220,43 @@ func (m *MemoryStorage) Clear() {
 	m.acls = make([]*acl.Entry, 0)
 	m.counters = NewCounterState()
 	m.groupKeys = make([]GroupKeyEntry, 0)
+	m.subscriptions = make(map[imsg.SubscriptionID]*im.SubscriptionRecord)
+	m.paseAttempts = 0
+	m.keys = make(map[fabric.KeyHandle][]byte)
 }
 
-// Verify MemoryStorage implements Storage.
-var _ Storage = (*MemoryStorage)(nil)
+// LoadOperationalKey implements fabric.KeyStorage.
+func (m *MemoryStorage) LoadOperationalKey(h fabric.KeyHandle) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	key, ok := m.keys[h]
+	if !ok {
+		return nil, fabric.ErrKeyNotFound
+	}
+	return append([]byte(nil), key...), nil
+}
+
+// SaveOperationalKey implements fabric.KeyStorage.
+func (m *MemoryStorage) SaveOperationalKey(h fabric.KeyHandle, key []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.keys[h] = append([]byte(nil), key...)
+	return nil
+}
+
+// DeleteOperationalKey implements fabric.KeyStorage.
+func (m *MemoryStorage) DeleteOperationalKey(h fabric.KeyHandle) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.keys, h)
+	return nil
+}
+
+// Verify MemoryStorage implements Storage and fabric.KeyStorage.
+var (
+	_ Storage           = (*MemoryStorage)(nil)
+	_ fabric.KeyStorage = (*MemoryStorage)(nil)
+)
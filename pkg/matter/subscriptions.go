@@ -0,0 +1,85 @@
+package matter
+
+import (
+	"context"
+
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/im"
+	"github.com/backkem/matter/pkg/session"
+	"github.com/backkem/matter/pkg/transport"
+)
+
+// MaxSubscriptionResumptionAttempts is the number of restarts over which
+// the node tries to resume a persisted subscription before the record
+// expires.
+const MaxSubscriptionResumptionAttempts = 3
+
+// SessionEstablisher opens a CASE session to a node on one of the node's
+// fabrics and returns it with the node's address.
+//
+// Node.FindOrEstablishSession is one; NodeConfig.EstablishSession lets
+// the application provide another, e.g. one that keeps its own sessions.
+type SessionEstablisher func(
+	ctx context.Context,
+	fabricIndex fabric.FabricIndex,
+	nodeID fabric.NodeID,
+) (*session.SecureContext, transport.PeerAddress, error)
+
+// resumeSubscriptions resumes the persisted subscriptions after a restart.
+//
+// Each attempt is counted in the record before it is made, and the engine
+// resets the count once the subscriber confirms the subscription. A record
+// that runs out of attempts, or whose fabric is gone, expires and is
+// deleted.
+//
+// Spec: Section 8.5.1 (subscription resumption)
+// C++ Reference: InteractionModelEngine::ResumeSubscriptions
+func (n *Node) resumeSubscriptions(ctx context.Context, engine *im.Engine) {
+	storage := n.config.Storage
+	records, err := storage.LoadSubscriptions()
+	if err != nil {
+		if n.log != nil {
+			n.log.Warnf("failed to load subscriptions: %v", err)
+		}
+		return
+	}
+
+	for _, rec := range records {
+		if ctx.Err() != nil {
+			return
+		}
+
+		_, hasFabric := n.fabricTable.Get(rec.FabricIndex)
+		if !hasFabric || rec.ResumptionAttempts >= MaxSubscriptionResumptionAttempts {
+			if n.log != nil {
+				n.log.Infof("subscription %d of node 0x%016X expired", rec.SubscriptionID, uint64(rec.NodeID))
+			}
+			if err := storage.DeleteSubscription(rec.SubscriptionID); err != nil && n.log != nil {
+				n.log.Warnf("failed to delete subscription %d: %v", rec.SubscriptionID, err)
+			}
+			continue
+		}
+
+		establish := n.config.EstablishSession
+		if establish == nil && n.config.OperationalKey != nil {
+			establish = n.FindOrEstablishSession
+		}
+		if establish == nil {
+			continue
+		}
+
+		rec.ResumptionAttempts++
+		if err := storage.SaveSubscription(rec); err != nil && n.log != nil {
+			n.log.Warnf("failed to save subscription %d: %v", rec.SubscriptionID, err)
+		}
+
+		sess, peerAddr, err := establish(ctx, rec.FabricIndex, rec.NodeID)
+		if err == nil {
+			err = engine.ResumeSubscription(rec, sess, peerAddr)
+		}
+		if err != nil && n.log != nil {
+			n.log.Warnf("failed to resume subscription %d (attempt %d): %v",
+				rec.SubscriptionID, rec.ResumptionAttempts, err)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package matter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/backkem/matter/pkg/acl"
+	"github.com/backkem/matter/pkg/crypto"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/session"
+	"github.com/backkem/matter/pkg/transport"
+)
+
+// VirtualFabricConfig configures a VirtualFabric.
+type VirtualFabricConfig struct {
+	// Devices is the number of device nodes (at least 1).
+	Devices int
+
+	// FabricID is the fabric the nodes join (default: 1).
+	FabricID fabric.FabricID
+
+	// ConfigureNode adjusts a node's configuration before the node is
+	// created, e.g. to set callbacks. Node 0 is the controller, nodes 1
+	// to Devices are the devices.
+	ConfigureNode func(i int, config *NodeConfig)
+}
+
+// VirtualFabric is a controller and any number of devices on one fabric,
+// bridged by an in-memory network switch. Use it to test group messaging
+// and multi-device scenarios without network I/O.
+//
+// The nodes join the fabric directly, as if commissioned, with node ID 1
+// for the controller and 2, 3, ... for the devices. No operational
+// certificates are issued, so they cannot establish CASE sessions with
+// each other: use group messages, or PASE with a device whose
+// commissioning window is open.
+//
+// Each node has its own link to the switch, whose network conditions can
+// be set with SetLinkCondition. Partition cuts two nodes off from each
+// other.
+//
+// Example:
+//
+//	f, _ := matter.TestFabric(3)
+//	defer f.Stop()
+//	for _, device := range f.Devices() {
+//		device.AddEndpoint(matter.NewEndpoint(1).AddCluster(onoff.New(onoff.Config{EndpointID: 1})))
+//	}
+//	f.AddGroup(0x0101, epochKey, 1)
+//	f.SetLinkCondition(f.Device(2), transport.NetworkCondition{DropRate: 0.2})
+//	f.Start(ctx)
+//	group, peerAddr, _ := f.GroupContext(0x0101, epochKey)
+type VirtualFabric struct {
+	network    *transport.PipeNetwork
+	info       fabric.FabricInfo
+	controller *fabricNode
+	devices    []*fabricNode
+	nodes      map[*Node]*fabricNode
+	started    []*Node
+}
+
+// fabricNode is a node of a VirtualFabric with its link to the switch.
+type fabricNode struct {
+	node        *Node
+	link        *transport.PipeNetworkFactory
+	nodeID      fabric.NodeID
+	fabricIndex fabric.FabricIndex
+}
+
+// TestFabric creates a VirtualFabric with a controller and n devices.
+func TestFabric(n int) (*VirtualFabric, error) {
+	return NewVirtualFabric(VirtualFabricConfig{Devices: n})
+}
+
+// NewVirtualFabric creates the nodes of a virtual fabric and joins them to
+// the fabric. The nodes are started by Start, so endpoints can be added
+// first.
+func NewVirtualFabric(config VirtualFabricConfig) (*VirtualFabric, error) {
+	if config.Devices < 1 {
+		return nil, fmt.Errorf("%w: virtual fabric needs at least one device", ErrInvalidConfig)
+	}
+	if config.FabricID == 0 {
+		config.FabricID = 1
+	}
+
+	f := &VirtualFabric{
+		network: transport.NewPipeNetwork(),
+		info: fabric.FabricInfo{
+			FabricID:           config.FabricID,
+			CompressedFabricID: compressedTestFabricID(config.FabricID),
+		},
+		nodes: make(map[*Node]*fabricNode),
+	}
+
+	for i := 0; i <= config.Devices; i++ {
+		fn, err := f.newNode(i, config.ConfigureNode)
+		if err != nil {
+			f.network.Close()
+			return nil, err
+		}
+		if i == 0 {
+			f.controller = fn
+		} else {
+			f.devices = append(f.devices, fn)
+		}
+		f.nodes[fn.node] = fn
+	}
+	return f, nil
+}
+
+// newNode creates the i-th node of the fabric on its own link.
+func (f *VirtualFabric) newNode(i int, configure func(int, *NodeConfig)) (*fabricNode, error) {
+	link := f.network.NewFactory()
+	config := NodeConfig{
+		VendorID:         0xFFF1,
+		ProductID:        0x8001,
+		DeviceName:       fmt.Sprintf("node-%d", i),
+		Discriminator:    uint16(3840 + i),
+		Passcode:         20202021,
+		Storage:          NewMemoryStorage(),
+		TransportFactory: link,
+	}
+	if configure != nil {
+		configure(i, &config)
+	}
+	config.TransportFactory = link
+
+	node, err := NewNode(config)
+	if err != nil {
+		return nil, fmt.Errorf("node %d: %w", i, err)
+	}
+
+	info := f.info
+	info.NodeID = fabric.NodeID(i + 1)
+	index, err := node.AddFabric(&info)
+	if err != nil {
+		return nil, fmt.Errorf("node %d: %w", i, err)
+	}
+	return &fabricNode{node: node, link: link, nodeID: info.NodeID, fabricIndex: index}, nil
+}
+
+// compressedTestFabricID derives a stable compressed fabric ID from a
+// fabric ID. Real compressed fabric IDs are derived from the root public
+// key, which a virtual fabric does not have.
+func compressedTestFabricID(fabricID fabric.FabricID) [fabric.CompressedFabricIDSize]byte {
+	var id [fabric.CompressedFabricIDSize]byte
+	for i := range id {
+		id[i] = byte(uint64(fabricID) >> (8 * (len(id) - 1 - i)))
+	}
+	id[0] |= 0x80
+	return id
+}
+
+// Start starts every node. If a node fails to start, the nodes already
+// started are stopped.
+func (f *VirtualFabric) Start(ctx context.Context) error {
+	for _, node := range f.Nodes() {
+		if err := node.Start(ctx); err != nil {
+			f.stopNodes()
+			return err
+		}
+		f.started = append(f.started, node)
+	}
+	return nil
+}
+
+// Stop stops every node and closes the network.
+func (f *VirtualFabric) Stop() error {
+	f.stopNodes()
+	return f.network.Close()
+}
+
+func (f *VirtualFabric) stopNodes() {
+	for _, node := range f.started {
+		node.Stop(context.Background())
+	}
+	f.started = nil
+}
+
+// Network returns the network switch the nodes are bridged on.
+func (f *VirtualFabric) Network() *transport.PipeNetwork {
+	return f.network
+}
+
+// FabricID returns the fabric's ID.
+func (f *VirtualFabric) FabricID() fabric.FabricID {
+	return f.info.FabricID
+}
+
+// Controller returns the controller node.
+func (f *VirtualFabric) Controller() *Node {
+	return f.controller.node
+}
+
+// Devices returns the device nodes.
+func (f *VirtualFabric) Devices() []*Node {
+	nodes := make([]*Node, len(f.devices))
+	for i, fn := range f.devices {
+		nodes[i] = fn.node
+	}
+	return nodes
+}
+
+// Device returns the i-th device node, counting from 0.
+func (f *VirtualFabric) Device(i int) *Node {
+	return f.devices[i].node
+}
+
+// Nodes returns the controller followed by the devices.
+func (f *VirtualFabric) Nodes() []*Node {
+	return append([]*Node{f.controller.node}, f.Devices()...)
+}
+
+// Address returns a node's address on the network, or the zero address if
+// the node is not on the fabric.
+func (f *VirtualFabric) Address(node *Node) transport.PeerAddress {
+	fn, ok := f.nodes[node]
+	if !ok {
+		return transport.PeerAddress{}
+	}
+	return transport.NewUDPPeerAddress(fn.link.LocalAddr())
+}
+
+// NodeID returns a node's operational node ID, or 0 if the node is not on
+// the fabric.
+func (f *VirtualFabric) NodeID(node *Node) fabric.NodeID {
+	fn, ok := f.nodes[node]
+	if !ok {
+		return 0
+	}
+	return fn.nodeID
+}
+
+// FabricIndex returns the index of the fabric on a node, or
+// fabric.FabricIndexInvalid if the node is not on the fabric.
+func (f *VirtualFabric) FabricIndex(node *Node) fabric.FabricIndex {
+	fn, ok := f.nodes[node]
+	if !ok {
+		return fabric.FabricIndexInvalid
+	}
+	return fn.fabricIndex
+}
+
+// SetLinkCondition sets the network conditions of a node's link to the
+// switch. They apply to every packet the node sends or receives; the zero
+// NetworkCondition restores a perfect link.
+func (f *VirtualFabric) SetLinkCondition(node *Node, cond transport.NetworkCondition) {
+	if fn, ok := f.nodes[node]; ok {
+		fn.link.SetCondition(cond)
+	}
+}
+
+// Partition cuts two nodes off from each other, e.g. a device the
+// controller can no longer reach, while both still reach the other nodes.
+func (f *VirtualFabric) Partition(a, b *Node) {
+	fa, okA := f.nodes[a]
+	fb, okB := f.nodes[b]
+	if okA && okB {
+		f.network.Partition(fa.link, fb.link)
+	}
+}
+
+// Heal reconnects two nodes cut off from each other by Partition.
+func (f *VirtualFabric) Heal(a, b *Node) {
+	fa, okA := f.nodes[a]
+	fb, okB := f.nodes[b]
+	if okA && okB {
+		f.network.Heal(fa.link, fb.link)
+	}
+}
+
+// AddGroup makes every device a member of a group with the given epoch
+// key and endpoints, and grants the group Operate privilege on the
+// devices, as an administrator would through the Groups, Group Key
+// Management and Access Control clusters.
+//
+// Spec: Section 4.17 (group communication)
+func (f *VirtualFabric) AddGroup(groupID uint16, epochKey []byte, endpoints ...datamodel.EndpointID) error {
+	for _, fn := range f.devices {
+		if _, err := fn.node.aclMgr.CreateEntry(fn.fabricIndex, acl.Entry{
+			Privilege: acl.PrivilegeOperate,
+			AuthMode:  acl.AuthModeGroup,
+			Subjects:  []uint64{acl.NodeIDFromGroupID(groupID)},
+		}); err != nil {
+			return err
+		}
+		if err := fn.node.JoinGroup(Group{
+			FabricIndex: fn.fabricIndex,
+			GroupID:     groupID,
+			EpochKeys:   [][]byte{epochKey},
+			Endpoints:   endpoints,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GroupContext creates the context for the controller to send messages to
+// a group, with the group's multicast address.
+//
+// Spec: Section 4.17.2 (operational group keys)
+func (f *VirtualFabric) GroupContext(groupID uint16, epochKey []byte) (*session.GroupContext, transport.PeerAddress, error) {
+	key, err := crypto.DeriveGroupOperationalKeyV1(epochKey, f.info.CompressedFabricID[:])
+	if err != nil {
+		return nil, transport.PeerAddress{}, err
+	}
+	sessionID, err := crypto.DeriveGroupSessionIDV1(key)
+	if err != nil {
+		return nil, transport.PeerAddress{}, err
+	}
+
+	group, err := session.NewGroupContext(session.GroupContextConfig{
+		SourceNodeID:   f.controller.nodeID,
+		FabricIndex:    f.controller.fabricIndex,
+		GroupID:        groupID,
+		GroupSessionID: sessionID,
+		OperationalKey: key,
+		Counter:        f.controller.node.SessionManager().GroupDataCounter(),
+	})
+	if err != nil {
+		return nil, transport.PeerAddress{}, err
+	}
+	return group, transport.NewGroupPeerAddress(uint64(f.info.FabricID), groupID), nil
+}
@@ -0,0 +1,183 @@
+package matter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/backkem/matter/pkg/acl"
+	"github.com/backkem/matter/pkg/clusters/onoff"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/im"
+	"github.com/backkem/matter/pkg/transport"
+)
+
+func TestVirtualFabricGroupInvoke(t *testing.T) {
+	f, err := TestFabric(3)
+	if err != nil {
+		t.Fatalf("TestFabric failed: %v", err)
+	}
+	defer f.Stop()
+
+	lights := make([]*onoff.Cluster, len(f.Devices()))
+	for i, device := range f.Devices() {
+		lights[i] = onoff.New(onoff.Config{EndpointID: 1})
+		if err := device.AddEndpoint(NewEndpoint(1).WithDeviceType(0x0100, 1).AddCluster(lights[i])); err != nil {
+			t.Fatalf("AddEndpoint failed: %v", err)
+		}
+		if got, want := f.NodeID(device), fabric.NodeID(i+2); got != want {
+			t.Errorf("device %d node ID = %d, want %d", i, got, want)
+		}
+	}
+
+	if got := f.NodeID(f.Controller()); got != 1 {
+		t.Errorf("controller node ID = %d, want 1", got)
+	}
+
+	epochKey := []byte("group-epoch-key!")
+	if err := f.AddGroup(0x0101, epochKey, 1); err != nil {
+		t.Fatalf("AddGroup failed: %v", err)
+	}
+	if err := f.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	client := im.NewClient(im.ClientConfig{ExchangeManager: f.Controller().ExchangeManager()})
+	group, peerAddr, err := f.GroupContext(0x0101, epochKey)
+	if err != nil {
+		t.Fatalf("GroupContext failed: %v", err)
+	}
+	toggle := func() {
+		t.Helper()
+		if err := client.GroupInvoke(context.Background(), group, peerAddr, uint32(onoff.ClusterID), uint32(onoff.CmdToggle), nil); err != nil {
+			t.Fatalf("GroupInvoke: %v", err)
+		}
+	}
+	waitFor := func(want []bool) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for {
+			done := true
+			for i, light := range lights {
+				done = done && light.GetOnOff() == want[i]
+			}
+			if done {
+				return
+			}
+			if time.Now().After(deadline) {
+				for i, light := range lights {
+					t.Errorf("device %d OnOff = %v, want %v", i, light.GetOnOff(), want[i])
+				}
+				t.FailNow()
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// One group message reaches every device
+	toggle()
+	waitFor([]bool{true, true, true})
+
+	// A device whose link loses every packet misses the next one
+	f.SetLinkCondition(f.Device(1), transport.NetworkCondition{DropRate: 1})
+	toggle()
+	waitFor([]bool{false, true, false})
+
+	// A slow link delays the message, but it still arrives
+	f.SetLinkCondition(f.Device(1), transport.NetworkCondition{
+		DelayMin: 20 * time.Millisecond,
+		DelayMax: 50 * time.Millisecond,
+	})
+	toggle()
+	waitFor([]bool{true, false, true})
+
+	// A device partitioned from the controller misses the next one
+	f.SetLinkCondition(f.Device(1), transport.NetworkCondition{})
+	f.Partition(f.Controller(), f.Device(2))
+	toggle()
+	waitFor([]bool{false, true, true})
+
+	f.Heal(f.Controller(), f.Device(2))
+	toggle()
+	waitFor([]bool{true, false, false})
+}
+
+// TestVirtualFabricGroupLoopback verifies a node executes the group
+// messages it sends to its own group once, though the network loops them
+// back, unless group loopback is disabled.
+func TestVirtualFabricGroupLoopback(t *testing.T) {
+	for _, disabled := range []bool{false, true} {
+		f, err := NewVirtualFabric(VirtualFabricConfig{
+			Devices: 1,
+			ConfigureNode: func(i int, config *NodeConfig) {
+				config.DisableGroupLoopback = disabled
+			},
+		})
+		if err != nil {
+			t.Fatalf("NewVirtualFabric failed: %v", err)
+		}
+		f.Network().SetMulticastLoopback(true)
+
+		// The controller is a member of the group too
+		lights := make([]*onoff.Cluster, len(f.Nodes()))
+		for i, node := range f.Nodes() {
+			lights[i] = onoff.New(onoff.Config{EndpointID: 1})
+			if err := node.AddEndpoint(NewEndpoint(1).WithDeviceType(0x0100, 1).AddCluster(lights[i])); err != nil {
+				t.Fatalf("AddEndpoint failed: %v", err)
+			}
+		}
+		epochKey := []byte("group-epoch-key!")
+		if err := f.AddGroup(0x0101, epochKey, 1); err != nil {
+			t.Fatalf("AddGroup failed: %v", err)
+		}
+		controller := f.Controller()
+		if _, err := controller.aclMgr.CreateEntry(f.FabricIndex(controller), acl.Entry{
+			Privilege: acl.PrivilegeOperate,
+			AuthMode:  acl.AuthModeGroup,
+			Subjects:  []uint64{acl.NodeIDFromGroupID(0x0101)},
+		}); err != nil {
+			t.Fatalf("CreateEntry failed: %v", err)
+		}
+		if err := controller.JoinGroup(Group{
+			FabricIndex: f.FabricIndex(controller),
+			GroupID:     0x0101,
+			EpochKeys:   [][]byte{epochKey},
+			Endpoints:   []datamodel.EndpointID{1},
+		}); err != nil {
+			t.Fatalf("JoinGroup failed: %v", err)
+		}
+		if err := f.Start(context.Background()); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+
+		client := im.NewClient(im.ClientConfig{ExchangeManager: controller.ExchangeManager()})
+		group, peerAddr, err := f.GroupContext(0x0101, epochKey)
+		if err != nil {
+			t.Fatalf("GroupContext failed: %v", err)
+		}
+		if err := client.GroupInvoke(context.Background(), group, peerAddr, uint32(onoff.ClusterID), uint32(onoff.CmdToggle), nil); err != nil {
+			t.Fatalf("GroupInvoke: %v", err)
+		}
+
+		deadline := time.Now().Add(time.Second)
+		for !lights[1].GetOnOff() && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		// Give a second execution, from the looped back copy, time to show
+		time.Sleep(50 * time.Millisecond)
+		if !lights[1].GetOnOff() {
+			t.Errorf("disabled=%v: device OnOff = false, want true", disabled)
+		}
+		if got := lights[0].GetOnOff(); got != !disabled {
+			t.Errorf("disabled=%v: controller OnOff = %v, want %v", disabled, got, !disabled)
+		}
+		f.Stop()
+	}
+}
+
+func TestVirtualFabricInvalidConfig(t *testing.T) {
+	if _, err := TestFabric(0); err == nil {
+		t.Error("TestFabric(0) succeeded, want an error")
+	}
+}
@@ -7,9 +7,10 @@ import (
 // Codec handles message encoding and decoding for a specific session.
 // It manages encryption keys and provides methods for secure message processing.
 type Codec struct {
-	encryptionKey []byte // 16-byte AES-128 key
-	privacyKey    []byte // Derived privacy key (cached)
-	sourceNodeID  uint64 // Node ID for nonce construction
+	encryptionKey []byte         // 16-byte AES-128 key
+	privacyKey    []byte         // Derived privacy key (cached)
+	sourceNodeID  uint64         // Node ID for nonce construction
+	aead          *crypto.AESCCM // Cipher for the encryption key (cached)
 }
 
 // NewCodec creates a new codec with the given encryption key and source node ID.
@@ -27,13 +28,26 @@ func NewCodec(encryptionKey []byte, sourceNodeID uint64) (*Codec, error) {
 		return nil, err
 	}
 
+	aead, err := crypto.NewAESCCM(encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Codec{
-		encryptionKey: encryptionKey,
+		encryptionKey: append([]byte(nil), encryptionKey...),
 		privacyKey:    privacyKey,
 		sourceNodeID:  sourceNodeID,
+		aead:          aead,
 	}, nil
 }
 
+// Zeroize wipes the codec's keys and releases its cipher. The codec
+// cannot encode or decode afterwards.
+func (c *Codec) Zeroize() {
+	crypto.Zeroize(c.encryptionKey, c.privacyKey)
+	c.aead = nil
+}
+
 // Encode encrypts a frame for transmission.
 // This implements Spec Section 4.8.2 (Security Processing of Outgoing Messages)
 // and optionally 4.9.3 (Privacy Processing of Outgoing Messages).
@@ -46,62 +60,65 @@ func NewCodec(encryptionKey []byte, sourceNodeID uint64) (*Codec, error) {
 //
 // Returns the complete encoded message ready for transmission.
 func (c *Codec) Encode(header *MessageHeader, protocol *ProtocolHeader, payload []byte, privacy bool) ([]byte, error) {
+	return c.EncodeTo(nil, header, protocol, payload, privacy)
+}
+
+// EncodeTo is like Encode, but appends the encoded message to dst and
+// returns the updated slice. The message is encrypted in place, so with
+// enough capacity in dst and without privacy, EncodeTo does not allocate.
+// payload must not overlap dst.
+func (c *Codec) EncodeTo(dst []byte, header *MessageHeader, protocol *ProtocolHeader, payload []byte, privacy bool) ([]byte, error) {
+	return c.encodeTo(dst, header, protocol, payload, privacy, c.sourceNodeID)
+}
+
+// encodeTo encodes a message with the nonce built from sourceNodeID.
+func (c *Codec) encodeTo(dst []byte, header *MessageHeader, protocol *ProtocolHeader, payload []byte, privacy bool, sourceNodeID uint64) ([]byte, error) {
+	if c.aead == nil {
+		return nil, ErrInvalidKey
+	}
+
 	// Set privacy flag
 	header.Privacy = privacy
 
-	// Build plaintext: protocol header + application payload
-	protocolBytes := protocol.Encode()
-	plaintext := make([]byte, len(protocolBytes)+len(payload))
-	copy(plaintext, protocolBytes)
-	copy(plaintext[len(protocolBytes):], payload)
+	// Lay out header || protocol header || payload || MIC
+	headerLen := header.Size()
+	plaintextLen := protocol.Size() + len(payload)
+	ret, out := sliceForAppend(dst, headerLen+plaintextLen+MICSize)
 
-	// Build AAD (Additional Authenticated Data) = message header
-	aad := header.Encode()
+	// The message header is the AAD (Additional Authenticated Data)
+	header.EncodeTo(out)
+	aad := out[:headerLen]
+	plaintext := out[headerLen : headerLen+plaintextLen]
+	n := protocol.EncodeTo(plaintext)
+	copy(plaintext[n:], payload)
 
 	// Build nonce per Spec 4.8.1.1
-	nonce := crypto.BuildAEADNonce(header.securityFlags(), header.MessageCounter, c.sourceNodeID)
+	nonce := crypto.BuildAEADNonce(header.securityFlags(), header.MessageCounter, sourceNodeID)
 
-	// Encrypt with AES-CCM
-	ciphertext, err := crypto.AESCCM128Encrypt(c.encryptionKey, nonce, plaintext, aad)
-	if err != nil {
+	// Encrypt with AES-CCM; the ciphertext and MIC replace the plaintext
+	if _, err := c.aead.SealTo(plaintext[:0], nonce, plaintext, aad); err != nil {
 		return nil, ErrDecryptionFailed
 	}
 
-	// ciphertext includes the MIC at the end
-	// Split into encrypted payload and MIC for privacy processing
-	encryptedPayload := ciphertext[:len(ciphertext)-MICSize]
-	mic := ciphertext[len(ciphertext)-MICSize:]
-
 	// Apply privacy obfuscation if requested
-	var headerBytes []byte
 	if privacy {
-		headerBytes, err = c.applyPrivacy(header, mic)
-		if err != nil {
+		mic := out[headerLen+plaintextLen:]
+		if err := c.obfuscate(aad, header, mic); err != nil {
 			return nil, err
 		}
-	} else {
-		headerBytes = aad
 	}
 
-	// Build final message: header || encrypted payload || MIC
-	result := make([]byte, len(headerBytes)+len(encryptedPayload)+MICSize)
-	copy(result, headerBytes)
-	copy(result[len(headerBytes):], encryptedPayload)
-	copy(result[len(headerBytes)+len(encryptedPayload):], mic)
-
-	return result, nil
+	return ret, nil
 }
 
-// applyPrivacy applies privacy obfuscation to the message header.
-// Implements Spec Section 4.9.3.
-func (c *Codec) applyPrivacy(header *MessageHeader, mic []byte) ([]byte, error) {
-	// Get the full header bytes
-	headerBytes := header.Encode()
-
+// obfuscate applies or removes privacy obfuscation of the header bytes in
+// place. AES-CTR is its own inverse, so both directions are the same.
+// Implements Spec Sections 4.9.3 and 4.9.4.
+func (c *Codec) obfuscate(headerBytes []byte, header *MessageHeader, mic []byte) error {
 	// Privacy nonce = SessionID (BE) || MIC[5..15]
 	privacyNonce, err := crypto.BuildPrivacyNonce(header.SessionID, mic)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	// The obfuscated portion: Message Counter || [Source ID] || [Destination ID]
@@ -111,19 +128,18 @@ func (c *Codec) applyPrivacy(header *MessageHeader, mic []byte) ([]byte, error)
 
 	if privacyLen == 0 {
 		// Nothing to obfuscate
-		return headerBytes, nil
+		return nil
 	}
 
-	// Encrypt the obfuscated portion with AES-CTR
 	obfuscated, err := crypto.AESCTREncrypt(c.privacyKey, privacyNonce, headerBytes[privacyOffset:privacyOffset+privacyLen])
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	// Replace the original bytes with obfuscated bytes
+	// Replace the original bytes
 	copy(headerBytes[privacyOffset:], obfuscated)
 
-	return headerBytes, nil
+	return nil
 }
 
 // Decode decrypts a received secure message.
@@ -136,93 +152,76 @@ func (c *Codec) applyPrivacy(header *MessageHeader, mic []byte) ([]byte, error)
 //
 // Returns the decoded frame with decrypted payload.
 func (c *Codec) Decode(data []byte, sourceNodeID uint64) (*Frame, error) {
-	// Parse raw frame to get header and encrypted payload
-	raw, err := DecodeRaw(data)
-	if err != nil {
+	frame := &Frame{}
+	if err := c.DecodeInto(frame, data, sourceNodeID); err != nil {
 		return nil, err
 	}
-
-	// Verify this is a secure message
-	if !raw.Header.IsSecure() {
-		return nil, ErrDecryptionFailed
+	if len(frame.Payload) == 0 {
+		frame.Payload = nil
 	}
+	return frame, nil
+}
 
-	// Deobfuscate header if privacy is enabled
-	headerBytes := make([]byte, raw.Header.Size())
-	if raw.Header.Privacy {
-		// Need to reconstruct original header bytes from data
-		copy(headerBytes, data[:raw.Header.Size()])
-
-		err = c.removePrivacy(headerBytes, &raw.Header, raw.MIC)
-		if err != nil {
-			return nil, err
-		}
+// DecodeInto is like Decode, but decodes into f, reusing the capacity of
+// f.Payload for the decrypted payload. data is not modified. Without
+// privacy and with enough capacity in f.Payload, DecodeInto does not
+// allocate. On error, the contents of f are unspecified.
+func (c *Codec) DecodeInto(f *Frame, data []byte, sourceNodeID uint64) error {
+	return c.decodeInto(f, data, func(*MessageHeader) uint64 { return sourceNodeID })
+}
 
-		// Re-decode header after deobfuscation
-		if _, err := raw.Header.Decode(headerBytes); err != nil {
-			return nil, err
-		}
-	} else {
-		raw.Header.EncodeTo(headerBytes)
+// decodeInto decodes a message with the nonce built from the source node
+// ID sourceNodeID returns for the header, after privacy deobfuscation.
+func (c *Codec) decodeInto(f *Frame, data []byte, sourceNodeID func(*MessageHeader) uint64) error {
+	if c.aead == nil {
+		return ErrInvalidKey
 	}
-
-	// Build nonce
-	nonce := crypto.BuildAEADNonce(raw.Header.securityFlags(), raw.Header.MessageCounter, sourceNodeID)
-
-	// Reconstruct ciphertext = encrypted payload || MIC
-	ciphertext := make([]byte, len(raw.EncryptedPayload)+MICSize)
-	copy(ciphertext, raw.EncryptedPayload)
-	copy(ciphertext[len(raw.EncryptedPayload):], raw.MIC)
-
-	// Decrypt with AES-CCM
-	plaintext, err := crypto.AESCCM128Decrypt(c.encryptionKey, nonce, ciphertext, headerBytes)
+	headerLen, err := f.Header.Decode(data)
 	if err != nil {
-		return nil, ErrDecryptionFailed
+		return err
 	}
 
-	// Parse decrypted plaintext into protocol header + payload
-	frame := &Frame{
-		Header: raw.Header,
+	// Verify this is a secure message
+	if !f.Header.IsSecure() {
+		return ErrDecryptionFailed
 	}
-
-	protocolLen, err := frame.Protocol.Decode(plaintext)
-	if err != nil {
-		return nil, err
+	if len(data) < headerLen+MICSize {
+		return ErrMessageTooShort
 	}
+	ciphertext := data[headerLen:]
+	mic := data[len(data)-MICSize:]
+
+	// The AAD is the header as sent, before privacy obfuscation
+	headerBytes := data[:headerLen]
+	if f.Header.Privacy {
+		headerBytes = append([]byte(nil), headerBytes...)
+		if err := c.obfuscate(headerBytes, &f.Header, mic); err != nil {
+			return err
+		}
 
-	if len(plaintext) > protocolLen {
-		frame.Payload = make([]byte, len(plaintext)-protocolLen)
-		copy(frame.Payload, plaintext[protocolLen:])
+		// Re-decode header after deobfuscation
+		if _, err := f.Header.Decode(headerBytes); err != nil {
+			return err
+		}
 	}
 
-	return frame, nil
-}
+	// Build nonce
+	nonce := crypto.BuildAEADNonce(f.Header.securityFlags(), f.Header.MessageCounter, sourceNodeID(&f.Header))
 
-// removePrivacy removes privacy obfuscation from header bytes.
-// Implements Spec Section 4.9.4.
-func (c *Codec) removePrivacy(headerBytes []byte, header *MessageHeader, mic []byte) error {
-	// Privacy nonce = SessionID (BE) || MIC[5..15]
-	privacyNonce, err := crypto.BuildPrivacyNonce(header.SessionID, mic)
+	// Decrypt with AES-CCM
+	plaintext, err := c.aead.OpenTo(f.Payload[:0], nonce, ciphertext, headerBytes)
 	if err != nil {
-		return err
+		return ErrDecryptionFailed
 	}
 
-	// The obfuscated portion starts at offset 4
-	privacyOffset := header.PrivacyHeaderOffset()
-	privacyLen := header.PrivacyObfuscatedSize()
-
-	if privacyLen == 0 {
-		return nil
-	}
-
-	// Decrypt the obfuscated portion with AES-CTR
-	deobfuscated, err := crypto.AESCTRDecrypt(c.privacyKey, privacyNonce, headerBytes[privacyOffset:privacyOffset+privacyLen])
+	// Split decrypted plaintext into protocol header + payload
+	protocolLen, err := f.Protocol.Decode(plaintext)
 	if err != nil {
 		return err
 	}
 
-	// Replace the obfuscated bytes with original bytes
-	copy(headerBytes[privacyOffset:], deobfuscated)
+	n := copy(plaintext, plaintext[protocolLen:])
+	f.Payload = plaintext[:n]
 
 	return nil
 }
@@ -260,3 +259,16 @@ func (u *UnsecuredCodec) Encode(header *MessageHeader, protocol *ProtocolHeader,
 func (u *UnsecuredCodec) Decode(data []byte) (*Frame, error) {
 	return DecodeUnsecured(data)
 }
+
+// sliceForAppend extends in by n bytes, reusing its capacity if possible.
+// Returns the extended slice and the n bytes appended.
+func sliceForAppend(in []byte, n int) (head, tail []byte) {
+	if total := len(in) + n; cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+	tail = head[len(in):]
+	return
+}
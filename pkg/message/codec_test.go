@@ -135,6 +135,56 @@ func TestCodecRoundtrip(t *testing.T) {
 	}
 }
 
+func TestCodecEncodeToDecodeInto(t *testing.T) {
+	codec, err := NewCodec(testKey, UnspecifiedNodeID)
+	if err != nil {
+		t.Fatalf("NewCodec() error: %v", err)
+	}
+
+	for _, privacy := range []bool{false, true} {
+		header, proto := benchInvokeHeaders()
+		want, err := codec.Encode(&header, &proto, benchInvokePayload, privacy)
+		if err != nil {
+			t.Fatalf("Encode() error: %v", err)
+		}
+
+		prefix := []byte{0xAA, 0xBB}
+		got, err := codec.EncodeTo(prefix, &header, &proto, benchInvokePayload, privacy)
+		if err != nil {
+			t.Fatalf("EncodeTo() error: %v", err)
+		}
+		if !bytes.Equal(got[:2], prefix) || !bytes.Equal(got[2:], want) {
+			t.Errorf("EncodeTo(privacy=%v) = %x, want prefix || %x", privacy, got, want)
+		}
+
+		var frame Frame
+		for i := 0; i < 2; i++ {
+			if err := codec.DecodeInto(&frame, want, UnspecifiedNodeID); err != nil {
+				t.Fatalf("DecodeInto(privacy=%v) error: %v", privacy, err)
+			}
+			if frame.Header.MessageCounter != header.MessageCounter {
+				t.Errorf("MessageCounter = %d, want %d", frame.Header.MessageCounter, header.MessageCounter)
+			}
+			compareProtocolHeaders(t, &proto, &frame.Protocol)
+			if !bytes.Equal(frame.Payload, benchInvokePayload) {
+				t.Errorf("Payload = %x, want %x", frame.Payload, benchInvokePayload)
+			}
+		}
+	}
+
+	// Steady state without privacy: no allocations
+	header, proto := benchInvokeHeaders()
+	buf := make([]byte, 0, MaxUDPMessageSize)
+	var frame Frame
+	allocs := testing.AllocsPerRun(100, func() {
+		encoded, _ := codec.EncodeTo(buf[:0], &header, &proto, benchInvokePayload, false)
+		_ = codec.DecodeInto(&frame, encoded, UnspecifiedNodeID)
+	})
+	if allocs != 0 {
+		t.Errorf("EncodeTo/DecodeInto allocated %v times, want 0", allocs)
+	}
+}
+
 func TestCodecWithSourceNodeID(t *testing.T) {
 	sourceNodeID := uint64(0x0102030405060708)
 	codec, err := NewCodec(testKey, sourceNodeID)
@@ -320,6 +370,34 @@ func TestCodecInvalidKey(t *testing.T) {
 	}
 }
 
+func TestCodecZeroize(t *testing.T) {
+	key := bytes.Clone(testKey)
+	codec, err := NewCodec(key, UnspecifiedNodeID)
+	if err != nil {
+		t.Fatalf("NewCodec() error: %v", err)
+	}
+	header := MessageHeader{SessionID: 0x1234, MessageCounter: 1}
+	proto := ProtocolHeader{ProtocolID: ProtocolSecureChannel, ProtocolOpcode: 0x40}
+	encoded, err := codec.Encode(&header, &proto, nil, false)
+	if err != nil {
+		t.Fatalf("Encode() error: %v", err)
+	}
+
+	codec.Zeroize()
+	if !bytes.Equal(key, testKey) {
+		t.Error("Zeroize() wiped the caller's key")
+	}
+	if !bytes.Equal(codec.encryptionKey, make([]byte, len(testKey))) {
+		t.Errorf("encryptionKey = % X after Zeroize()", codec.encryptionKey)
+	}
+	if _, err := codec.Encode(&header, &proto, nil, false); err != ErrInvalidKey {
+		t.Errorf("Encode() after Zeroize() error = %v, want %v", err, ErrInvalidKey)
+	}
+	if _, err := codec.Decode(encoded, UnspecifiedNodeID); err != ErrInvalidKey {
+		t.Errorf("Decode() after Zeroize() error = %v, want %v", err, ErrInvalidKey)
+	}
+}
+
 func TestUnsecuredCodec(t *testing.T) {
 	codec := NewUnsecuredCodec()
 
@@ -484,3 +562,101 @@ func TestCodecLargePayload(t *testing.T) {
 		t.Errorf("Large payload roundtrip failed")
 	}
 }
+
+// benchInvokePayload is an InvokeRequestMessage for OnOff Toggle on
+// endpoint 1, the payload of a typical IM Invoke.
+var benchInvokePayload = []byte{
+	0x15,
+	0x28, 0x00, // SuppressResponse = false
+	0x28, 0x01, // TimedRequest = false
+	0x36, 0x02, // InvokeRequests
+	0x15,
+	0x37, 0x00, // CommandPath
+	0x24, 0x00, 0x01, // EndpointID = 1
+	0x24, 0x01, 0x06, // ClusterID = OnOff
+	0x24, 0x02, 0x02, // CommandID = Toggle
+	0x18,
+	0x35, 0x01, 0x18, // CommandFields = {}
+	0x18,
+	0x18,
+	0x24, 0xFF, 0x0C, // InteractionModelRevision = 12
+	0x18,
+}
+
+func benchInvokeHeaders() (MessageHeader, ProtocolHeader) {
+	header := MessageHeader{
+		SessionID:       0x1234,
+		SessionType:     SessionTypeUnicast,
+		MessageCounter:  0x01020304,
+		DestinationType: DestinationNone,
+	}
+	proto := ProtocolHeader{
+		ProtocolID:     ProtocolInteractionModel,
+		ProtocolOpcode: 0x08, // InvokeRequest
+		ExchangeID:     0x4242,
+		Initiator:      true,
+		Reliability:    true,
+	}
+	return header, proto
+}
+
+func BenchmarkCodecEncode(b *testing.B) {
+	codec, _ := NewCodec(testKey, UnspecifiedNodeID)
+	header, proto := benchInvokeHeaders()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = codec.Encode(&header, &proto, benchInvokePayload, false)
+	}
+}
+
+func BenchmarkCodecEncodeTo(b *testing.B) {
+	codec, _ := NewCodec(testKey, UnspecifiedNodeID)
+	header, proto := benchInvokeHeaders()
+	buf := make([]byte, 0, MaxUDPMessageSize)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = codec.EncodeTo(buf, &header, &proto, benchInvokePayload, false)
+	}
+}
+
+func BenchmarkCodecDecode(b *testing.B) {
+	codec, _ := NewCodec(testKey, UnspecifiedNodeID)
+	header, proto := benchInvokeHeaders()
+	data, _ := codec.Encode(&header, &proto, benchInvokePayload, false)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = codec.Decode(data, UnspecifiedNodeID)
+	}
+}
+
+func BenchmarkCodecDecodeInto(b *testing.B) {
+	codec, _ := NewCodec(testKey, UnspecifiedNodeID)
+	header, proto := benchInvokeHeaders()
+	data, _ := codec.Encode(&header, &proto, benchInvokePayload, false)
+	var frame Frame
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = codec.DecodeInto(&frame, data, UnspecifiedNodeID)
+	}
+}
+
+func BenchmarkCodecDecodePrivacy(b *testing.B) {
+	codec, _ := NewCodec(testKey, UnspecifiedNodeID)
+	header, proto := benchInvokeHeaders()
+	data, _ := codec.Encode(&header, &proto, benchInvokePayload, true)
+	var frame Frame
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = codec.DecodeInto(&frame, data, UnspecifiedNodeID)
+	}
+}
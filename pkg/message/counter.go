@@ -3,6 +3,7 @@ package message
 import (
 	"crypto/rand"
 	"encoding/binary"
+	"math"
 	"sync"
 )
 
@@ -345,6 +346,20 @@ func (c *SessionCounter) Next() (uint32, error) {
 	return current, nil
 }
 
+// Remaining returns how many counter values are left before the counter
+// wraps, capped at math.MaxUint32. It is 0 once the counter is exhausted.
+func (c *SessionCounter) Remaining() uint32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.exhausted {
+		return 0
+	}
+	if c.value == 0 {
+		return math.MaxUint32
+	}
+	return -c.value
+}
+
 // IsExhausted returns true if the counter has wrapped.
 func (c *SessionCounter) IsExhausted() bool {
 	c.mu.Lock()
@@ -250,6 +250,10 @@ func TestSessionCounterExhaustion(t *testing.T) {
 		exhausted:      false,
 	}
 
+	if got := c.Remaining(); got != 2 {
+		t.Errorf("Remaining() = %d, want 2", got)
+	}
+
 	// Get value at 0xFFFFFFFE
 	v, err := c.Next()
 	if err != nil {
@@ -272,6 +276,9 @@ func TestSessionCounterExhaustion(t *testing.T) {
 	if !c.IsExhausted() {
 		t.Error("Counter should be exhausted after wrap")
 	}
+	if got := c.Remaining(); got != 0 {
+		t.Errorf("Remaining() = %d after wrap, want 0", got)
+	}
 
 	// Further calls should fail
 	_, err = c.Next()
@@ -59,6 +59,9 @@ const (
 	// TCPLengthPrefixSize is the size of the TCP length prefix (Section 4.5.1).
 	TCPLengthPrefixSize = 4
 
+	// MaxStreamFrameSize is the largest frame a StreamReader accepts.
+	MaxStreamFrameSize = MaxUDPMessageSize * 2
+
 	// BTPLengthPrefixSize is the size of the BTP/PAFTP length prefix.
 	BTPLengthPrefixSize = 2
 )
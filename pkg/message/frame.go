@@ -172,6 +172,13 @@ func NewStreamReader(r io.Reader) *StreamReader {
 // Read reads a length-prefixed message from the stream.
 // Returns the frame data without the length prefix.
 func (sr *StreamReader) Read() ([]byte, error) {
+	return sr.ReadTo(nil)
+}
+
+// ReadTo is like Read, but reads the frame into buf if it is large enough,
+// so that a reader loop can reuse one buffer for every frame. A buffer of
+// MaxStreamFrameSize always is.
+func (sr *StreamReader) ReadTo(buf []byte) ([]byte, error) {
 	// Read 4-byte length prefix
 	var lenBuf [TCPLengthPrefixSize]byte
 	if _, err := io.ReadFull(sr.r, lenBuf[:]); err != nil {
@@ -187,12 +194,17 @@ func (sr *StreamReader) Read() ([]byte, error) {
 	if frameLen == 0 {
 		return nil, ErrInvalidLengthPrefix
 	}
-	if frameLen > MaxUDPMessageSize*2 { // Allow larger for TCP
+	if frameLen > MaxStreamFrameSize { // Allow larger for TCP
 		return nil, ErrMessageTooLong
 	}
 
 	// Read frame data
-	frame := make([]byte, frameLen)
+	var frame []byte
+	if uint32(cap(buf)) >= frameLen {
+		frame = buf[:frameLen]
+	} else {
+		frame = make([]byte, frameLen)
+	}
 	if _, err := io.ReadFull(sr.r, frame); err != nil {
 		return nil, ErrStreamReadFailed
 	}
@@ -196,6 +196,42 @@ func TestStreamFraming(t *testing.T) {
 	}
 }
 
+func TestStreamReaderReadTo(t *testing.T) {
+	var stream bytes.Buffer
+	writer := NewStreamWriter(&stream)
+	writer.Write([]byte{0x01, 0x02, 0x03})
+	writer.Write(bytes.Repeat([]byte{0xFF}, 100))
+	writer.Write(bytes.Repeat([]byte{0xEE}, 10))
+
+	reader := NewStreamReader(&stream)
+	buf := make([]byte, 64)
+
+	got, err := reader.ReadTo(buf)
+	if err != nil {
+		t.Fatalf("ReadTo() error: %v", err)
+	}
+	if !bytes.Equal(got, []byte{0x01, 0x02, 0x03}) || &got[0] != &buf[0] {
+		t.Errorf("ReadTo() = %x, want 010203 in buf", got)
+	}
+
+	// Too large for buf: a new buffer is allocated
+	got, err = reader.ReadTo(buf)
+	if err != nil {
+		t.Fatalf("ReadTo() error: %v", err)
+	}
+	if len(got) != 100 || &got[0] == &buf[0] {
+		t.Errorf("ReadTo() of a 100-byte frame returned %d bytes, reusing buf: %v", len(got), &got[0] == &buf[0])
+	}
+
+	got, err = reader.ReadTo(buf)
+	if err != nil {
+		t.Fatalf("ReadTo() error: %v", err)
+	}
+	if !bytes.Equal(got, bytes.Repeat([]byte{0xEE}, 10)) || &got[0] != &buf[0] {
+		t.Errorf("ReadTo() = %x, want 10 bytes of EE in buf", got)
+	}
+}
+
 func TestStreamWriterReadFrames(t *testing.T) {
 	// Test WriteFrame and ReadFrame with actual RawFrame
 	clientConn, serverConn := net.Pipe()
@@ -0,0 +1,102 @@
+package message
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// testGroupKey is an operational group key with group session ID 0x6c80.
+var testGroupKey = []byte{
+	0x1f, 0x19, 0xed, 0x3c, 0xef, 0x8a, 0x21, 0x1b,
+	0xaf, 0x30, 0x6f, 0xae, 0xee, 0xe7, 0xaa, 0xc6,
+}
+
+func TestGroupCodec_RoundTrip(t *testing.T) {
+	codec, err := NewGroupCodec(testGroupKey)
+	if err != nil {
+		t.Fatalf("NewGroupCodec() error = %v", err)
+	}
+	if codec.SessionID() != 0x6c80 {
+		t.Errorf("SessionID() = 0x%04x, want 0x6c80", codec.SessionID())
+	}
+
+	protocol := &ProtocolHeader{ProtocolID: ProtocolInteractionModel, ProtocolOpcode: 0x08, ExchangeID: 7, Initiator: true}
+	payload := []byte{0x15, 0x18}
+
+	for _, privacy := range []bool{false, true} {
+		header := &MessageHeader{
+			MessageCounter:     0x01020304,
+			SourcePresent:      true,
+			SourceNodeID:       0x1122334455667788,
+			DestinationType:    DestinationGroupID,
+			DestinationGroupID: 0x0101,
+		}
+		data, err := codec.Encode(header, protocol, payload, privacy)
+		if err != nil {
+			t.Fatalf("Encode(privacy=%v) error = %v", privacy, err)
+		}
+
+		// Any member decodes it, whatever the sender
+		receiver, _ := NewGroupCodec(testGroupKey)
+		frame, err := receiver.Decode(data)
+		if err != nil {
+			t.Fatalf("Decode(privacy=%v) error = %v", privacy, err)
+		}
+		h := frame.Header
+		if h.SessionType != SessionTypeGroup || h.SessionID != 0x6c80 || h.SourceNodeID != 0x1122334455667788 ||
+			h.DestinationGroupID != 0x0101 || h.MessageCounter != 0x01020304 || h.Privacy != privacy {
+			t.Errorf("Decode(privacy=%v) header = %+v", privacy, h)
+		}
+		if !bytes.Equal(frame.Payload, payload) || frame.Protocol.ExchangeID != 7 {
+			t.Errorf("Decode(privacy=%v) = exchange %d, payload %x", privacy, frame.Protocol.ExchangeID, frame.Payload)
+		}
+	}
+}
+
+func TestGroupCodec_Invalid(t *testing.T) {
+	codec, err := NewGroupCodec(testGroupKey)
+	if err != nil {
+		t.Fatalf("NewGroupCodec() error = %v", err)
+	}
+	protocol := &ProtocolHeader{ProtocolID: ProtocolInteractionModel, ProtocolOpcode: 0x08}
+
+	// Group messages carry their source
+	if _, err := codec.Encode(&MessageHeader{DestinationType: DestinationGroupID}, protocol, nil, false); !errors.Is(err, ErrMissingSourceNodeID) {
+		t.Errorf("Encode() without source error = %v, want ErrMissingSourceNodeID", err)
+	}
+
+	header := &MessageHeader{
+		SourcePresent:      true,
+		SourceNodeID:       1,
+		DestinationType:    DestinationGroupID,
+		DestinationGroupID: 1,
+	}
+	data, err := codec.Encode(header, protocol, nil, false)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	// Another key with the same session ID fails to decrypt
+	other, _ := NewGroupCodecWithSessionID(bytes.Repeat([]byte{0xFF}, 16), codec.SessionID())
+	if _, err := other.Decode(data); !errors.Is(err, ErrDecryptionFailed) {
+		t.Errorf("Decode() with another key error = %v, want ErrDecryptionFailed", err)
+	}
+
+	// Another session ID is not looked at
+	other, _ = NewGroupCodecWithSessionID(testGroupKey, codec.SessionID()+1)
+	if _, err := other.Decode(data); !errors.Is(err, ErrNotGroupMessage) {
+		t.Errorf("Decode() with another session ID error = %v, want ErrNotGroupMessage", err)
+	}
+
+	// Unicast messages are not group messages
+	unicast, _ := NewCodec(testGroupKey, 1)
+	data, _ = unicast.Encode(&MessageHeader{SessionID: codec.SessionID()}, protocol, nil, false)
+	if _, err := codec.Decode(data); !errors.Is(err, ErrNotGroupMessage) {
+		t.Errorf("Decode() of unicast message error = %v, want ErrNotGroupMessage", err)
+	}
+
+	if _, err := NewGroupCodec(testGroupKey[:8]); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("NewGroupCodec() with short key error = %v, want ErrInvalidKey", err)
+	}
+}
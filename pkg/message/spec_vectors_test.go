@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/backkem/matter/pkg/crypto"
+	"github.com/backkem/matter/pkg/testvectors"
 )
 
 // Test vectors from connectedhomeip SDK to ensure spec compliance.
@@ -517,3 +518,35 @@ func TestPrivacyObfuscationConsistency(t *testing.T) {
 		t.Error("Decrypted payload doesn't match original")
 	}
 }
+
+// TestMessageEncryptionVectors encrypts and decrypts each message vector.
+func TestMessageEncryptionVectors(t *testing.T) {
+	for _, tv := range testvectors.Messages {
+		t.Run(tv.Name, func(t *testing.T) {
+			codec, err := NewCodec(tv.Key, tv.SourceNodeID)
+			if err != nil {
+				t.Fatalf("NewCodec failed: %v", err)
+			}
+
+			plain, err := DecodeUnsecured(tv.Plain)
+			if err != nil {
+				t.Fatalf("DecodeUnsecured(Plain) failed: %v", err)
+			}
+			encrypted, err := codec.Encode(&plain.Header, &plain.Protocol, plain.Payload, false)
+			if err != nil {
+				t.Fatalf("Encode failed: %v", err)
+			}
+			if !bytes.Equal(encrypted, tv.Encrypted) {
+				t.Errorf("Encode:\ngot:  %x\nwant: %x", encrypted, tv.Encrypted)
+			}
+
+			frame, err := codec.Decode(tv.Encrypted, tv.SourceNodeID)
+			if err != nil {
+				t.Fatalf("Decode failed: %v", err)
+			}
+			if got := frame.EncodeUnsecured(); !bytes.Equal(got, tv.Plain) {
+				t.Errorf("Decode:\ngot:  %x\nwant: %x", got, tv.Plain)
+			}
+		})
+	}
+}
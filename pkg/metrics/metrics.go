@@ -0,0 +1,56 @@
+// Package metrics defines the counters and gauges reported by the stack.
+//
+// Layers report through the small Collector interface: exchange counts
+// messages and MRP retransmissions, securechannel counts established and
+// failed sessions, and the Interaction Model counts transactions. A nil
+// Collector in any layer config disables reporting.
+//
+// The reported metrics are listed in Descs. The prometheus subpackage
+// exports them through a prometheus.Registerer; Recorder keeps them in
+// memory for tests.
+//
+// Example:
+//
+//	rec := metrics.NewRecorder()
+//	node, _ := matter.NewNode(matter.NodeConfig{..., Metrics: rec})
+//	...
+//	rec.Value(metrics.SessionsEstablished, metrics.L(metrics.LabelType, "case"))
+package metrics
+
+// Collector receives metric updates. Implementations must be safe for
+// concurrent use.
+type Collector interface {
+	// Add adds delta to the counter name.
+	Add(name string, delta float64, labels ...Label)
+
+	// Set sets the gauge name to value.
+	Set(name string, value float64, labels ...Label)
+}
+
+// Label is a metric label.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// L returns a Label.
+func L(name, value string) Label {
+	return Label{Name: name, Value: value}
+}
+
+// Nop is a Collector that discards all updates.
+type Nop struct{}
+
+// Add implements Collector.
+func (Nop) Add(string, float64, ...Label) {}
+
+// Set implements Collector.
+func (Nop) Set(string, float64, ...Label) {}
+
+// OrNop returns c, or Nop if c is nil.
+func OrNop(c Collector) Collector {
+	if c == nil {
+		return Nop{}
+	}
+	return c
+}
@@ -0,0 +1,57 @@
+package metrics
+
+import "testing"
+
+func TestRecorder(t *testing.T) {
+	r := NewRecorder()
+
+	r.Add(IMTransactions, 1, L(LabelAction, "read"))
+	r.Add(IMTransactions, 2, L(LabelAction, "invoke"))
+	r.Add(IMTransactions, 1, L(LabelAction, "read"))
+	r.Set(Subscriptions, 4)
+	r.Set(Subscriptions, 2)
+
+	if got := r.Value(IMTransactions, L(LabelAction, "read")); got != 2 {
+		t.Errorf("read = %v, want 2", got)
+	}
+	if got := r.Sum(IMTransactions); got != 4 {
+		t.Errorf("Sum = %v, want 4", got)
+	}
+	if got := r.Value(Subscriptions); got != 2 {
+		t.Errorf("Subscriptions = %v, want 2", got)
+	}
+	if got := r.Value(MRPRetransmits); got != 0 {
+		t.Errorf("unreported = %v, want 0", got)
+	}
+}
+
+func TestRecorder_LabelOrder(t *testing.T) {
+	r := NewRecorder()
+	r.Add("m", 1, L("a", "1"), L("b", "2"))
+	if got := r.Value("m", L("b", "2"), L("a", "1")); got != 1 {
+		t.Errorf("Value = %v, want 1", got)
+	}
+}
+
+func TestOrNop(t *testing.T) {
+	if _, ok := OrNop(nil).(Nop); !ok {
+		t.Error("OrNop(nil) should return Nop")
+	}
+	r := NewRecorder()
+	if OrNop(r) != r {
+		t.Error("OrNop should return a non-nil collector")
+	}
+}
+
+func TestDescs(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, d := range Descs {
+		if seen[d.Name] {
+			t.Errorf("duplicate metric %s", d.Name)
+		}
+		seen[d.Name] = true
+		if d.Help == "" {
+			t.Errorf("%s has no help", d.Name)
+		}
+	}
+}
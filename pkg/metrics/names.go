@@ -0,0 +1,101 @@
+package metrics
+
+// Metric names.
+const (
+	// SessionsEstablished counts secure sessions established, labelled by
+	// LabelType ("pase" or "case").
+	SessionsEstablished = "matter_sessions_established_total"
+
+	// SessionsFailed counts failed session establishments, labelled by
+	// LabelType.
+	SessionsFailed = "matter_sessions_failed_total"
+
+	// MessagesSent counts messages handed to the transport, labelled by
+	// LabelSession ("secure" or "unsecured").
+	MessagesSent = "matter_messages_sent_total"
+
+	// MessagesReceived counts messages received from the transport,
+	// labelled by LabelSession.
+	MessagesReceived = "matter_messages_received_total"
+
+	// MRPRetransmits counts MRP retransmissions.
+	MRPRetransmits = "matter_mrp_retransmits_total"
+
+	// MRPDeliveryFailures counts reliable messages abandoned after the
+	// final retransmission.
+	MRPDeliveryFailures = "matter_mrp_delivery_failures_total"
+
+	// ExchangesRefused counts exchanges refused because the exchange table
+	// was full.
+	ExchangesRefused = "matter_exchanges_refused_total"
+
+	// ExchangesReaped counts responder exchanges closed after going idle.
+	ExchangesReaped = "matter_exchanges_reaped_total"
+
+	// IMTransactions counts Interaction Model requests received, labelled
+	// by LabelAction.
+	IMTransactions = "matter_im_transactions_total"
+
+	// Subscriptions is the number of active subscriptions.
+	Subscriptions = "matter_im_subscriptions"
+
+	// IMActiveReads is the number of read transactions in progress.
+	IMActiveReads = "matter_im_active_reads"
+
+	// IMRefused counts Interaction Model requests refused for lack of
+	// resources, labelled by LabelAction and LabelStatus ("busy" or
+	// "resource_exhausted").
+	IMRefused = "matter_im_refused_total"
+)
+
+// Label names.
+const (
+	// LabelType is the session type: "pase" or "case".
+	LabelType = "type"
+
+	// LabelSession is the session kind of a message: "secure",
+	// "unsecured" or "group".
+	LabelSession = "session"
+
+	// LabelAction is the Interaction Model action: "read", "write",
+	// "invoke", "subscribe", "timed" or "other".
+	LabelAction = "action"
+
+	// LabelStatus is the Interaction Model status of a refused request.
+	LabelStatus = "status"
+)
+
+// Kind is the type of a metric.
+type Kind uint8
+
+const (
+	// KindCounter is a monotonically increasing counter.
+	KindCounter Kind = iota
+
+	// KindGauge is a value that can go up and down.
+	KindGauge
+)
+
+// Desc describes a metric reported by the stack.
+type Desc struct {
+	Name   string
+	Help   string
+	Kind   Kind
+	Labels []string
+}
+
+// Descs lists every metric reported by the stack.
+var Descs = []Desc{
+	{SessionsEstablished, "Secure sessions established.", KindCounter, []string{LabelType}},
+	{SessionsFailed, "Failed secure session establishments.", KindCounter, []string{LabelType}},
+	{MessagesSent, "Messages sent.", KindCounter, []string{LabelSession}},
+	{MessagesReceived, "Messages received.", KindCounter, []string{LabelSession}},
+	{MRPRetransmits, "MRP retransmissions.", KindCounter, nil},
+	{MRPDeliveryFailures, "Reliable messages abandoned after the final retransmission.", KindCounter, nil},
+	{ExchangesRefused, "Exchanges refused because the exchange table was full.", KindCounter, nil},
+	{ExchangesReaped, "Responder exchanges closed after going idle.", KindCounter, nil},
+	{IMTransactions, "Interaction Model requests received.", KindCounter, []string{LabelAction}},
+	{Subscriptions, "Active subscriptions.", KindGauge, nil},
+	{IMActiveReads, "Read transactions in progress.", KindGauge, nil},
+	{IMRefused, "Interaction Model requests refused for lack of resources.", KindCounter, []string{LabelAction, LabelStatus}},
+}
@@ -0,0 +1,77 @@
+// Package prometheus exports the stack's metrics to Prometheus.
+//
+// New registers a collector for every metric in metrics.Descs and returns a
+// metrics.Collector that updates them:
+//
+//	c, err := prometheus.New(prom.DefaultRegisterer)
+//	node, _ := matter.NewNode(matter.NodeConfig{..., Metrics: c})
+//	http.Handle("/metrics", promhttp.Handler())
+package prometheus
+
+import (
+	"github.com/backkem/matter/pkg/metrics"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector is a metrics.Collector backed by Prometheus vectors.
+// Updates for unknown metrics or mismatched labels are dropped.
+type Collector struct {
+	counters map[string]*prom.CounterVec
+	gauges   map[string]*prom.GaugeVec
+}
+
+// New creates a Collector and registers its metrics with reg.
+func New(reg prom.Registerer) (*Collector, error) {
+	c := &Collector{
+		counters: make(map[string]*prom.CounterVec),
+		gauges:   make(map[string]*prom.GaugeVec),
+	}
+	for _, d := range metrics.Descs {
+		var vec prom.Collector
+		switch d.Kind {
+		case metrics.KindCounter:
+			v := prom.NewCounterVec(prom.CounterOpts{Name: d.Name, Help: d.Help}, d.Labels)
+			c.counters[d.Name] = v
+			vec = v
+		case metrics.KindGauge:
+			v := prom.NewGaugeVec(prom.GaugeOpts{Name: d.Name, Help: d.Help}, d.Labels)
+			c.gauges[d.Name] = v
+			vec = v
+		}
+		if err := reg.Register(vec); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Add implements metrics.Collector.
+func (c *Collector) Add(name string, delta float64, labels ...metrics.Label) {
+	v, ok := c.counters[name]
+	if !ok {
+		return
+	}
+	if m, err := v.GetMetricWith(promLabels(labels)); err == nil {
+		m.Add(delta)
+	}
+}
+
+// Set implements metrics.Collector.
+func (c *Collector) Set(name string, value float64, labels ...metrics.Label) {
+	v, ok := c.gauges[name]
+	if !ok {
+		return
+	}
+	if m, err := v.GetMetricWith(promLabels(labels)); err == nil {
+		m.Set(value)
+	}
+}
+
+// promLabels converts labels to prom.Labels.
+func promLabels(labels []metrics.Label) prom.Labels {
+	out := make(prom.Labels, len(labels))
+	for _, l := range labels {
+		out[l.Name] = l.Value
+	}
+	return out
+}
@@ -0,0 +1,48 @@
+package prometheus
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/backkem/matter/pkg/metrics"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	reg := prom.NewRegistry()
+	c, err := New(reg)
+	if err != nil {
+		t.Fatalf("New error = %v", err)
+	}
+
+	c.Add(metrics.SessionsEstablished, 1, metrics.L(metrics.LabelType, "case"))
+	c.Add(metrics.SessionsEstablished, 2, metrics.L(metrics.LabelType, "case"))
+	c.Add(metrics.MRPRetransmits, 1)
+	c.Set(metrics.Subscriptions, 3)
+
+	// Dropped: unknown metric and mismatched labels.
+	c.Add("unknown_total", 1)
+	c.Add(metrics.MRPRetransmits, 1, metrics.L("bogus", "x"))
+
+	want := `
+# HELP matter_sessions_established_total Secure sessions established.
+# TYPE matter_sessions_established_total counter
+matter_sessions_established_total{type="case"} 3
+# HELP matter_mrp_retransmits_total MRP retransmissions.
+# TYPE matter_mrp_retransmits_total counter
+matter_mrp_retransmits_total 1
+# HELP matter_im_subscriptions Active subscriptions.
+# TYPE matter_im_subscriptions gauge
+matter_im_subscriptions 3
+`
+	err = testutil.GatherAndCompare(reg, strings.NewReader(want),
+		metrics.SessionsEstablished, metrics.MRPRetransmits, metrics.Subscriptions)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if _, err := New(reg); err == nil {
+		t.Error("registering twice should fail")
+	}
+}
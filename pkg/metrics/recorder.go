@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Recorder is a Collector that keeps values in memory. It is intended for
+// tests and simple status pages.
+type Recorder struct {
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// NewRecorder creates a new Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{values: make(map[string]float64)}
+}
+
+// Add implements Collector.
+func (r *Recorder) Add(name string, delta float64, labels ...Label) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.values[key(name, labels)] += delta
+}
+
+// Set implements Collector.
+func (r *Recorder) Set(name string, value float64, labels ...Label) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.values[key(name, labels)] = value
+}
+
+// Value returns the value of a metric with exactly the given labels, in
+// any order. It returns 0 for metrics never reported.
+func (r *Recorder) Value(name string, labels ...Label) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.values[key(name, labels)]
+}
+
+// Sum returns the sum of a metric across all label values.
+func (r *Recorder) Sum(name string) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var sum float64
+	for k, v := range r.values {
+		if k == name || strings.HasPrefix(k, name+"{") {
+			sum += v
+		}
+	}
+	return sum
+}
+
+// key returns the map key for a metric, with labels in sorted order.
+func key(name string, labels []Label) string {
+	if len(labels) == 0 {
+		return name
+	}
+	sorted := append([]Label(nil), labels...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteByte('{')
+	for i, l := range sorted {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(l.Name)
+		b.WriteByte('=')
+		b.WriteString(l.Value)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
@@ -0,0 +1,282 @@
+// Package netmonitor watches the host's interface addresses.
+//
+// A Matter node announces its addresses over DNS-SD and orders the
+// addresses of its peers by what its own addresses can reach. Both go
+// stale when the host's addresses change, e.g. on DHCP renewal, an IPv6
+// prefix change or an interface going up or down. A Monitor reports such
+// changes, so that the node can announce itself again and resolve its
+// peers again:
+//
+//	m := netmonitor.New(netmonitor.Config{})
+//	go m.Watch(ctx, func(c netmonitor.Change) {
+//	    discoveryMgr.Readvertise()
+//	})
+//
+// On Linux the Monitor listens to the kernel's netlink address and link
+// notifications; elsewhere, or if netlink is unavailable, it polls.
+package netmonitor
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/backkem/matter/pkg/clock"
+	"github.com/pion/logging"
+)
+
+// Monitor defaults.
+const (
+	// DefaultPollInterval is how often the addresses are compared when
+	// the platform does not notify address changes.
+	DefaultPollInterval = 10 * time.Second
+
+	// DefaultSettleDelay is how long the Monitor waits after a
+	// notification before comparing the addresses, so that a burst of
+	// changes, like an interface coming up with several addresses, is
+	// reported once.
+	DefaultSettleDelay = 500 * time.Millisecond
+)
+
+// Address is an address of the host, on one of its interfaces.
+type Address struct {
+	IP        net.IP
+	Interface string
+}
+
+// String returns the address as "interface/ip".
+func (a Address) String() string {
+	return a.Interface + "/" + a.IP.String()
+}
+
+// Change is a change of the host's addresses.
+type Change struct {
+	// Added are the addresses that appeared.
+	Added []Address
+
+	// Removed are the addresses that went away.
+	Removed []Address
+}
+
+// String describes the change, e.g. "added [eth0/192.168.1.20], removed
+// [eth0/192.168.1.10]".
+func (c Change) String() string {
+	return fmt.Sprintf("added %v, removed %v", c.Added, c.Removed)
+}
+
+// Watcher reports changes of the host's addresses.
+type Watcher interface {
+	// Watch calls fn with each change of the addresses until ctx is
+	// done. fn is called from one goroutine at a time.
+	Watch(ctx context.Context, fn func(Change)) error
+}
+
+// Config configures a Monitor.
+type Config struct {
+	// Interfaces restricts the watched interfaces.
+	// Optional - if empty, all interfaces are watched.
+	Interfaces []net.Interface
+
+	// PollInterval is how often the addresses are compared without
+	// platform notifications. Defaults to DefaultPollInterval if zero.
+	PollInterval time.Duration
+
+	// SettleDelay is how long to wait after a notification before
+	// comparing the addresses. Defaults to DefaultSettleDelay if zero.
+	SettleDelay time.Duration
+
+	// Clock runs the poll and settle timers. If nil, the real clock is
+	// used.
+	Clock clock.Clock
+
+	// LoggerFactory is the factory for creating loggers.
+	// If nil, logging is disabled.
+	LoggerFactory logging.LoggerFactory
+}
+
+// Monitor watches the host's interface addresses. It implements Watcher.
+type Monitor struct {
+	config Config
+	clock  clock.Clock
+	log    logging.LeveledLogger
+
+	// addresses and subscribe are replaced in tests.
+	addresses func() ([]Address, error)
+	subscribe func() (events <-chan struct{}, stop func(), err error)
+}
+
+// New creates a Monitor.
+func New(config Config) *Monitor {
+	if config.PollInterval == 0 {
+		config.PollInterval = DefaultPollInterval
+	}
+	if config.SettleDelay == 0 {
+		config.SettleDelay = DefaultSettleDelay
+	}
+
+	m := &Monitor{
+		config:    config,
+		clock:     clock.OrReal(config.Clock),
+		subscribe: subscribe,
+	}
+	m.addresses = func() ([]Address, error) {
+		return interfaceAddresses(m.config.Interfaces)
+	}
+	if config.LoggerFactory != nil {
+		m.log = config.LoggerFactory.NewLogger("netmonitor")
+	}
+	return m
+}
+
+// Addresses returns the current addresses of the watched interfaces that
+// are up, loopback excluded.
+func (m *Monitor) Addresses() ([]Address, error) {
+	return m.addresses()
+}
+
+// Watch implements Watcher. It returns an error if the addresses cannot
+// be read at the start, and nil once ctx is done.
+func (m *Monitor) Watch(ctx context.Context, fn func(Change)) error {
+	last, err := m.addresses()
+	if err != nil {
+		return err
+	}
+
+	events, stop, err := m.subscribe()
+	if err != nil {
+		if m.log != nil {
+			m.log.Debugf("no address notifications, polling every %v: %v", m.config.PollInterval, err)
+		}
+		events = nil
+	} else {
+		defer stop()
+	}
+
+	var poll, settle clock.Timer
+	var pollC, settleC <-chan time.Time
+	startPolling := func() {
+		poll = m.clock.NewTimer(m.config.PollInterval)
+		pollC = poll.C()
+	}
+	if events == nil {
+		startPolling()
+	}
+	defer func() {
+		for _, t := range []clock.Timer{poll, settle} {
+			if t != nil {
+				t.Stop()
+			}
+		}
+	}()
+
+	check := func() {
+		current, err := m.addresses()
+		if err != nil {
+			if m.log != nil {
+				m.log.Warnf("failed to read addresses: %v", err)
+			}
+			return
+		}
+		if change, changed := diff(last, current); changed {
+			last = current
+			fn(change)
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case _, ok := <-events:
+			if !ok {
+				// The listener failed: fall back to polling
+				events = nil
+				startPolling()
+				continue
+			}
+			if settle == nil {
+				settle = m.clock.NewTimer(m.config.SettleDelay)
+				settleC = settle.C()
+			}
+
+		case <-settleC:
+			settle, settleC = nil, nil
+			check()
+
+		case <-pollC:
+			poll.Reset(m.config.PollInterval)
+			check()
+		}
+	}
+}
+
+// interfaceAddresses returns the addresses of the interfaces that are up,
+// loopback excluded. If ifaces is empty, all interfaces are used.
+func interfaceAddresses(ifaces []net.Interface) ([]Address, error) {
+	if len(ifaces) == 0 {
+		var err error
+		if ifaces, err = net.Interfaces(); err != nil {
+			return nil, err
+		}
+	}
+
+	var addrs []Address
+	for _, iface := range ifaces {
+		// Read the flags afresh: the interface may have gone down
+		current, err := net.InterfaceByName(iface.Name)
+		if err != nil {
+			continue
+		}
+		if current.Flags&net.FlagUp == 0 || current.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		ifAddrs, err := current.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range ifAddrs {
+			if ipNet, ok := a.(*net.IPNet); ok {
+				addrs = append(addrs, Address{IP: ipNet.IP, Interface: current.Name})
+			}
+		}
+	}
+	return addrs, nil
+}
+
+// diff returns the change from the old to the new addresses, and whether
+// there is one.
+func diff(old, new []Address) (Change, bool) {
+	oldSet := make(map[string]Address, len(old))
+	for _, a := range old {
+		oldSet[a.String()] = a
+	}
+	newSet := make(map[string]Address, len(new))
+	for _, a := range new {
+		newSet[a.String()] = a
+	}
+
+	var change Change
+	for key, a := range newSet {
+		if _, ok := oldSet[key]; !ok {
+			change.Added = append(change.Added, a)
+		}
+	}
+	for key, a := range oldSet {
+		if _, ok := newSet[key]; !ok {
+			change.Removed = append(change.Removed, a)
+		}
+	}
+	sortAddresses(change.Added)
+	sortAddresses(change.Removed)
+	return change, len(change.Added) > 0 || len(change.Removed) > 0
+}
+
+func sortAddresses(addrs []Address) {
+	sort.Slice(addrs, func(i, j int) bool {
+		return strings.Compare(addrs[i].String(), addrs[j].String()) < 0
+	})
+}
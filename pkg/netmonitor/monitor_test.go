@@ -0,0 +1,143 @@
+package netmonitor
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/backkem/matter/pkg/clock"
+)
+
+// fakeHost holds the addresses a test Monitor reads.
+type fakeHost struct {
+	mu    sync.Mutex
+	addrs []Address
+}
+
+func (h *fakeHost) set(addrs ...Address) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.addrs = addrs
+}
+
+func (h *fakeHost) addresses() ([]Address, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]Address(nil), h.addrs...), nil
+}
+
+func addr(iface, ip string) Address {
+	return Address{IP: net.ParseIP(ip), Interface: iface}
+}
+
+// watch starts a Monitor on a fake host and returns the changes it
+// reports.
+func watch(t *testing.T, m *Monitor) <-chan Change {
+	t.Helper()
+	changes := make(chan Change, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- m.Watch(ctx, func(c Change) { changes <- c })
+	}()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Watch: %v", err)
+		}
+	})
+	return changes
+}
+
+func expectChange(t *testing.T, changes <-chan Change, want string) {
+	t.Helper()
+	select {
+	case c := <-changes:
+		if c.String() != want {
+			t.Errorf("change = %s, want %s", c, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no change reported, want %s", want)
+	}
+}
+
+func expectNoChange(t *testing.T, changes <-chan Change) {
+	t.Helper()
+	select {
+	case c := <-changes:
+		t.Errorf("unexpected change %s", c)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestMonitor_Polling(t *testing.T) {
+	clk := clock.NewFakeClock(time.Time{})
+	host := &fakeHost{}
+	host.set(addr("eth0", "192.168.1.10"), addr("eth0", "fe80::1"))
+
+	m := New(Config{Clock: clk})
+	m.addresses = host.addresses
+	m.subscribe = func() (<-chan struct{}, func(), error) {
+		return nil, nil, errors.New("unsupported")
+	}
+	changes := watch(t, m)
+
+	// DHCP renewal with a new address
+	clk.BlockUntil(1)
+	host.set(addr("eth0", "192.168.1.20"), addr("eth0", "fe80::1"))
+	clk.Advance(DefaultPollInterval)
+	expectChange(t, changes, "added [eth0/192.168.1.20], removed [eth0/192.168.1.10]")
+
+	// Nothing changed
+	clk.BlockUntil(1)
+	clk.Advance(DefaultPollInterval)
+	expectNoChange(t, changes)
+}
+
+func TestMonitor_Notifications(t *testing.T) {
+	clk := clock.NewFakeClock(time.Time{})
+	host := &fakeHost{}
+	host.set(addr("eth0", "192.168.1.10"))
+
+	events := make(chan struct{}, 1)
+	subscribed := make(chan struct{})
+	m := New(Config{Clock: clk})
+	m.addresses = host.addresses
+	m.subscribe = func() (<-chan struct{}, func(), error) {
+		close(subscribed) // after the initial addresses are read
+		return events, func() {}, nil
+	}
+	changes := watch(t, m)
+	<-subscribed
+
+	// A burst of notifications is reported once, after the settle delay
+	host.set(addr("eth0", "192.168.1.10"), addr("wlan0", "10.0.0.5"))
+	events <- struct{}{}
+	clk.BlockUntil(1)
+	events <- struct{}{}
+	clk.Advance(DefaultSettleDelay)
+	expectChange(t, changes, "added [wlan0/10.0.0.5], removed []")
+	expectNoChange(t, changes)
+
+	// The listener failing falls back to polling
+	close(events)
+	clk.BlockUntil(1)
+	host.set(addr("wlan0", "10.0.0.5"))
+	clk.Advance(DefaultPollInterval)
+	expectChange(t, changes, "added [], removed [eth0/192.168.1.10]")
+}
+
+func TestInterfaceAddresses(t *testing.T) {
+	addrs, err := interfaceAddresses(nil)
+	if err != nil {
+		t.Fatalf("interfaceAddresses: %v", err)
+	}
+	for _, a := range addrs {
+		if a.IP.IsLoopback() {
+			t.Errorf("loopback address %s listed", a)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package netmonitor
+
+import (
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// subscribe opens a netlink socket receiving the kernel's link and
+// address notifications. An event is sent for each, and the channel is
+// closed if reading fails.
+func subscribe() (<-chan struct{}, func(), error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC|unix.SOCK_NONBLOCK, unix.NETLINK_ROUTE)
+	if err != nil {
+		return nil, nil, err
+	}
+	addr := &unix.SockaddrNetlink{
+		Family: unix.AF_NETLINK,
+		Groups: unix.RTMGRP_LINK | unix.RTMGRP_IPV4_IFADDR | unix.RTMGRP_IPV6_IFADDR,
+	}
+	if err := unix.Bind(fd, addr); err != nil {
+		unix.Close(fd)
+		return nil, nil, err
+	}
+
+	// A non-blocking file uses the runtime poller, so Close ends Read
+	f := os.NewFile(uintptr(fd), "netlink")
+	events := make(chan struct{}, 1)
+	go func() {
+		defer close(events)
+		buf := make([]byte, 1<<16)
+		for {
+			n, err := f.Read(buf)
+			if err != nil {
+				return
+			}
+			if isAddressChange(buf[:n]) {
+				select {
+				case events <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return events, func() { f.Close() }, nil
+}
+
+// isAddressChange reports whether netlink messages announce a link or
+// address change. Messages that cannot be parsed count as one.
+func isAddressChange(b []byte) bool {
+	msgs, err := syscall.ParseNetlinkMessage(b)
+	if err != nil {
+		return true
+	}
+	for _, msg := range msgs {
+		switch msg.Header.Type {
+		case unix.RTM_NEWADDR, unix.RTM_DELADDR, unix.RTM_NEWLINK, unix.RTM_DELLINK:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package netmonitor
+
+import "errors"
+
+// subscribe is not supported on this platform; the Monitor polls.
+func subscribe() (<-chan struct{}, func(), error) {
+	return nil, nil, errors.New("netmonitor: address notifications not supported")
+}
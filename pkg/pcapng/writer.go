@@ -0,0 +1,226 @@
+// Package pcapng writes Matter frames to pcapng captures for Wireshark.
+//
+// A Writer implements transport.Tap, so it can be attached to a
+// transport.Manager to record every frame sent and received. Each packet
+// holds the Matter message as seen on the wire, with the direction in the
+// packet flags and the UDP/TCP addresses in a packet comment. When a Decrypt
+// function is configured and the session keys are known, secure frames are
+// annotated with their decrypted protocol header and payload.
+//
+// Packets use LinkTypeMatter (LINKTYPE_USER0). To decode them in
+// Wireshark, map "User 0 (DLT=147)" to the "matter" payload protocol under
+// Preferences > Protocols > DLT_USER.
+//
+// Example:
+//
+//	f, _ := os.Create("matter.pcapng")
+//	w, _ := pcapng.NewWriter(f, pcapng.Config{
+//	    Decrypt: node.SessionManager().Open,
+//	})
+//	node.TransportManager().SetTap(w)
+package pcapng
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/backkem/matter/pkg/message"
+	"github.com/backkem/matter/pkg/transport"
+)
+
+// LinkTypeMatter is the link type of captured packets. Matter has no
+// registered link type, so captures use LINKTYPE_USER0.
+const LinkTypeMatter = 147
+
+// Block types and options (pcapng specification, Section 4).
+const (
+	blockSectionHeader  = 0x0A0D0D0A
+	blockInterfaceDesc  = 0x00000001
+	blockEnhancedPacket = 0x00000006
+	byteOrderMagic      = 0x1A2B3C4D
+	optEndOfOpt         = 0
+	optComment          = 1
+	optSHBUserAppl      = 4
+	optIfName           = 2
+	optIfTsresol        = 9
+	optEPBFlags         = 2
+	epbFlagInbound      = 0x1
+	epbFlagOutbound     = 0x2
+	defaultSnapLen      = 0
+	tsresolMicroseconds = 6
+	userApplication     = "github.com/backkem/matter"
+	interfaceName       = "matter"
+)
+
+// DecryptFunc opens a captured secure frame, such as session.Manager.Open.
+type DecryptFunc func(data []byte, outbound bool) (*message.Frame, error)
+
+// Config configures a Writer.
+type Config struct {
+	// Decrypt opens secure frames to annotate them with their plaintext.
+	// If nil, frames are written as captured.
+	Decrypt DecryptFunc
+}
+
+// Writer writes captured frames as pcapng. It implements transport.Tap and
+// is safe for concurrent use.
+type Writer struct {
+	w      io.Writer
+	config Config
+
+	mu  sync.Mutex
+	err error
+}
+
+// NewWriter creates a Writer and writes the section and interface headers
+// to w.
+func NewWriter(w io.Writer, config Config) (*Writer, error) {
+	pw := &Writer{w: w, config: config}
+
+	var shb []byte
+	shb = binary.LittleEndian.AppendUint32(shb, byteOrderMagic)
+	shb = binary.LittleEndian.AppendUint16(shb, 1)                  // Major version
+	shb = binary.LittleEndian.AppendUint16(shb, 0)                  // Minor version
+	shb = binary.LittleEndian.AppendUint64(shb, 0xFFFFFFFFFFFFFFFF) // Section length unknown
+	shb = appendOption(shb, optSHBUserAppl, []byte(userApplication))
+	shb = appendOption(shb, optEndOfOpt, nil)
+	if err := pw.writeBlock(blockSectionHeader, shb); err != nil {
+		return nil, err
+	}
+
+	var idb []byte
+	idb = binary.LittleEndian.AppendUint16(idb, LinkTypeMatter)
+	idb = binary.LittleEndian.AppendUint16(idb, 0) // Reserved
+	idb = binary.LittleEndian.AppendUint32(idb, defaultSnapLen)
+	idb = appendOption(idb, optIfName, []byte(interfaceName))
+	idb = appendOption(idb, optIfTsresol, []byte{tsresolMicroseconds})
+	idb = appendOption(idb, optEndOfOpt, nil)
+	if err := pw.writeBlock(blockInterfaceDesc, idb); err != nil {
+		return nil, err
+	}
+
+	return pw, nil
+}
+
+// Capture implements transport.Tap. Write errors are retained and
+// reported by Err; later frames are dropped.
+func (w *Writer) Capture(frame *transport.CapturedFrame) {
+	_ = w.WriteFrame(frame)
+}
+
+// WriteFrame writes a captured frame as an Enhanced Packet Block.
+func (w *Writer) WriteFrame(frame *transport.CapturedFrame) error {
+	ts := uint64(frame.Time.UnixMicro())
+
+	var epb []byte
+	epb = binary.LittleEndian.AppendUint32(epb, 0) // Interface ID
+	epb = binary.LittleEndian.AppendUint32(epb, uint32(ts>>32))
+	epb = binary.LittleEndian.AppendUint32(epb, uint32(ts))
+	epb = binary.LittleEndian.AppendUint32(epb, uint32(len(frame.Data)))
+	epb = binary.LittleEndian.AppendUint32(epb, uint32(len(frame.Data)))
+	epb = append(epb, frame.Data...)
+	epb = pad(epb)
+
+	var flags uint32
+	switch frame.Direction {
+	case transport.DirectionInbound:
+		flags = epbFlagInbound
+	case transport.DirectionOutbound:
+		flags = epbFlagOutbound
+	}
+	epb = appendOption(epb, optEPBFlags, binary.LittleEndian.AppendUint32(nil, flags))
+	epb = appendOption(epb, optComment, []byte(addressComment(frame)))
+	if text, ok := w.decrypt(frame); ok {
+		epb = appendOption(epb, optComment, []byte(text))
+	}
+	epb = appendOption(epb, optEndOfOpt, nil)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.writeBlockLocked(blockEnhancedPacket, epb)
+}
+
+// Err returns the first write error, if any. After an error no further
+// blocks are written.
+func (w *Writer) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// decrypt returns a comment describing the decrypted frame.
+func (w *Writer) decrypt(frame *transport.CapturedFrame) (string, bool) {
+	if w.config.Decrypt == nil {
+		return "", false
+	}
+	f, err := w.config.Decrypt(frame.Data, frame.Direction == transport.DirectionOutbound)
+	if err != nil {
+		return "", false
+	}
+	p := f.Protocol
+	return fmt.Sprintf("decrypted: exchange=%d initiator=%t protocol=0x%04X opcode=0x%02X reliable=%t ack=%t payload=%x",
+		p.ExchangeID, p.Initiator, uint16(p.ProtocolID), p.ProtocolOpcode,
+		p.Reliability, p.Acknowledgement, f.Payload), true
+}
+
+// writeBlock writes a block under the lock.
+func (w *Writer) writeBlock(blockType uint32, body []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.writeBlockLocked(blockType, body)
+}
+
+// writeBlockLocked writes a block. Caller must hold w.mu.
+func (w *Writer) writeBlockLocked(blockType uint32, body []byte) error {
+	if w.err != nil {
+		return w.err
+	}
+
+	total := uint32(12 + len(body))
+	buf := make([]byte, 0, total)
+	buf = binary.LittleEndian.AppendUint32(buf, blockType)
+	buf = binary.LittleEndian.AppendUint32(buf, total)
+	buf = append(buf, body...)
+	buf = binary.LittleEndian.AppendUint32(buf, total)
+
+	if _, err := w.w.Write(buf); err != nil {
+		w.err = err
+		return err
+	}
+	return nil
+}
+
+// addressComment describes the frame's addressing.
+func addressComment(frame *transport.CapturedFrame) string {
+	local := "?"
+	if frame.Local != nil {
+		local = frame.Local.String()
+	}
+	peer := "?"
+	if frame.Peer.Addr != nil {
+		peer = frame.Peer.Addr.String()
+	}
+
+	if frame.Direction == transport.DirectionOutbound {
+		return fmt.Sprintf("%s %s -> %s", frame.Peer.TransportType, local, peer)
+	}
+	return fmt.Sprintf("%s %s -> %s", frame.Peer.TransportType, peer, local)
+}
+
+// appendOption appends a pcapng option, padded to 32 bits.
+func appendOption(b []byte, code uint16, value []byte) []byte {
+	b = binary.LittleEndian.AppendUint16(b, code)
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(value)))
+	b = append(b, value...)
+	return pad(b)
+}
+
+// pad pads b to a multiple of 4 bytes.
+func pad(b []byte) []byte {
+	for len(b)%4 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
@@ -0,0 +1,188 @@
+package pcapng
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/backkem/matter/pkg/message"
+	"github.com/backkem/matter/pkg/transport"
+)
+
+// block is a parsed pcapng block.
+type block struct {
+	typ  uint32
+	body []byte
+}
+
+// parseBlocks splits a capture into blocks, checking the length trailers.
+func parseBlocks(t *testing.T, data []byte) []block {
+	t.Helper()
+	var blocks []block
+	for len(data) > 0 {
+		if len(data) < 12 {
+			t.Fatalf("truncated block: %d bytes", len(data))
+		}
+		typ := binary.LittleEndian.Uint32(data)
+		total := binary.LittleEndian.Uint32(data[4:])
+		if total%4 != 0 || int(total) > len(data) {
+			t.Fatalf("bad block length %d", total)
+		}
+		if trailer := binary.LittleEndian.Uint32(data[total-4:]); trailer != total {
+			t.Fatalf("trailer length %d, want %d", trailer, total)
+		}
+		blocks = append(blocks, block{typ: typ, body: data[8 : total-4]})
+		data = data[total:]
+	}
+	return blocks
+}
+
+// parseOptions returns the options of a block body starting at offset.
+func parseOptions(b []byte) map[uint16][][]byte {
+	opts := make(map[uint16][][]byte)
+	for len(b) >= 4 {
+		code := binary.LittleEndian.Uint16(b)
+		n := int(binary.LittleEndian.Uint16(b[2:]))
+		if code == optEndOfOpt {
+			break
+		}
+		opts[code] = append(opts[code], b[4:4+n])
+		b = b[4+(n+3)&^3:]
+	}
+	return opts
+}
+
+// parsePacket returns the packet data and options of an EPB body.
+func parsePacket(body []byte) ([]byte, map[uint16][][]byte) {
+	n := int(binary.LittleEndian.Uint32(body[12:]))
+	data := body[20 : 20+n]
+	return data, parseOptions(body[20+(n+3)&^3:])
+}
+
+func TestWriter(t *testing.T) {
+	local := &net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 5540}
+	peer := transport.NewUDPPeerAddress(&net.UDPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 5541})
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, Config{
+		Decrypt: func(data []byte, outbound bool) (*message.Frame, error) {
+			if !outbound {
+				return nil, errors.New("unknown session")
+			}
+			return &message.Frame{
+				Protocol: message.ProtocolHeader{
+					ProtocolID:     message.ProtocolInteractionModel,
+					ProtocolOpcode: 0x08,
+					ExchangeID:     42,
+					Initiator:      true,
+				},
+				Payload: []byte{0x15, 0x18},
+			}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewWriter error = %v", err)
+	}
+
+	now := time.Unix(1700000000, 123456000)
+	w.Capture(&transport.CapturedFrame{
+		Time: now, Direction: transport.DirectionInbound, Local: local, Peer: peer, Data: []byte{1, 2, 3},
+	})
+	w.Capture(&transport.CapturedFrame{
+		Time: now, Direction: transport.DirectionOutbound, Local: local, Peer: peer, Data: []byte{4, 5, 6, 7},
+	})
+	if err := w.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+
+	blocks := parseBlocks(t, buf.Bytes())
+	if len(blocks) != 4 {
+		t.Fatalf("got %d blocks, want 4", len(blocks))
+	}
+	if blocks[0].typ != blockSectionHeader || binary.LittleEndian.Uint32(blocks[0].body) != byteOrderMagic {
+		t.Error("first block should be a little-endian section header")
+	}
+	if blocks[1].typ != blockInterfaceDesc || binary.LittleEndian.Uint16(blocks[1].body) != LinkTypeMatter {
+		t.Error("second block should be an interface description with LinkTypeMatter")
+	}
+
+	// Inbound packet: no decryption annotation.
+	in := blocks[2]
+	if in.typ != blockEnhancedPacket {
+		t.Fatalf("block type = %#x, want EPB", in.typ)
+	}
+	ts := uint64(binary.LittleEndian.Uint32(in.body[4:]))<<32 | uint64(binary.LittleEndian.Uint32(in.body[8:]))
+	if ts != uint64(now.UnixMicro()) {
+		t.Errorf("timestamp = %d, want %d", ts, now.UnixMicro())
+	}
+	data, opts := parsePacket(in.body)
+	if !bytes.Equal(data, []byte{1, 2, 3}) {
+		t.Errorf("inbound data = %x", data)
+	}
+	if flags := binary.LittleEndian.Uint32(opts[optEPBFlags][0]); flags != epbFlagInbound {
+		t.Errorf("inbound flags = %#x", flags)
+	}
+	if got := string(opts[optComment][0]); got != "UDP 10.0.0.2:5541 -> 10.0.0.1:5540" {
+		t.Errorf("inbound comment = %q", got)
+	}
+	if len(opts[optComment]) != 1 {
+		t.Errorf("inbound comments = %d, want 1", len(opts[optComment]))
+	}
+
+	// Outbound packet: annotated with the decrypted frame.
+	data, opts = parsePacket(blocks[3].body)
+	if !bytes.Equal(data, []byte{4, 5, 6, 7}) {
+		t.Errorf("outbound data = %x", data)
+	}
+	if flags := binary.LittleEndian.Uint32(opts[optEPBFlags][0]); flags != epbFlagOutbound {
+		t.Errorf("outbound flags = %#x", flags)
+	}
+	if got := string(opts[optComment][0]); got != "UDP 10.0.0.1:5540 -> 10.0.0.2:5541" {
+		t.Errorf("outbound comment = %q", got)
+	}
+	if len(opts[optComment]) != 2 || !strings.Contains(string(opts[optComment][1]), "exchange=42") ||
+		!strings.Contains(string(opts[optComment][1]), "opcode=0x08") ||
+		!strings.Contains(string(opts[optComment][1]), "payload=1518") {
+		t.Errorf("outbound decrypted comment = %q", opts[optComment])
+	}
+}
+
+// failWriter fails after the headers are written.
+type failWriter struct {
+	n int
+}
+
+func (f *failWriter) Write(p []byte) (int, error) {
+	if f.n == 0 {
+		return 0, errors.New("disk full")
+	}
+	f.n--
+	return len(p), nil
+}
+
+func TestWriter_Error(t *testing.T) {
+	fw := &failWriter{n: 2}
+	w, err := NewWriter(fw, Config{})
+	if err != nil {
+		t.Fatalf("NewWriter error = %v", err)
+	}
+
+	frame := &transport.CapturedFrame{Time: time.Now(), Direction: transport.DirectionOutbound, Data: []byte{1}}
+	w.Capture(frame)
+	if w.Err() == nil {
+		t.Fatal("expected a write error")
+	}
+
+	fw.n = 10
+	if err := w.WriteFrame(frame); err == nil {
+		t.Error("writes after an error should fail")
+	}
+
+	if _, err := NewWriter(&failWriter{}, Config{}); err == nil {
+		t.Error("NewWriter should report header write errors")
+	}
+}
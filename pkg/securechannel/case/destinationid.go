@@ -1,6 +1,7 @@
 package casesession
 
 import (
+	"crypto/subtle"
 	"encoding/binary"
 
 	"github.com/backkem/matter/pkg/crypto"
@@ -98,7 +99,7 @@ func GenerateDestinationIDFromEpochKey(
 //   - nodeID: Candidate node ID
 //   - ipk: 16-byte candidate IPK (derived operational group key)
 //
-// Returns true if the destination ID matches.
+// Returns true if the destination ID matches. The comparison is constant-time.
 func MatchDestinationID(
 	destinationID [DestinationIDSize]byte,
 	initiatorRandom [RandomSize]byte,
@@ -108,5 +109,5 @@ func MatchDestinationID(
 	ipk [crypto.SymmetricKeySize]byte,
 ) bool {
 	candidate := GenerateDestinationID(initiatorRandom, rootPublicKey, fabricID, nodeID, ipk)
-	return destinationID == candidate
+	return subtle.ConstantTimeCompare(destinationID[:], candidate[:]) == 1
 }
@@ -1,6 +1,8 @@
 package casesession
 
 import (
+	"crypto/subtle"
+
 	"github.com/backkem/matter/pkg/crypto"
 )
 
@@ -342,7 +344,7 @@ func ComputeResumeMIC(
 //   - nonce: 13-byte nonce (Resume1Nonce or Resume2Nonce)
 //   - mic: 16-byte MIC to verify
 //
-// Returns true if the MIC is valid.
+// Returns true if the MIC is valid. The comparison is constant-time.
 func VerifyResumeMIC(
 	key [crypto.SymmetricKeySize]byte,
 	nonce []byte,
@@ -353,5 +355,5 @@ func VerifyResumeMIC(
 		return false
 	}
 
-	return expected == mic
+	return subtle.ConstantTimeCompare(expected[:], mic[:]) == 1
 }
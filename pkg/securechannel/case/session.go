@@ -1,6 +1,8 @@
 package casesession
 
 import (
+	"bytes"
+	gocrypto "crypto"
 	"crypto/rand"
 	"fmt"
 	"io"
@@ -17,11 +19,11 @@ import (
 //   - destinationID: 32-byte destination identifier from Sigma1
 //   - initiatorRandom: 32-byte random from Sigma1 (needed to compute candidate IDs)
 //
-// Returns the matching FabricInfo and operational key pair, or error if not found.
+// Returns the matching FabricInfo and operational key, or error if not found.
 type FabricLookupFunc func(
 	destinationID [DestinationIDSize]byte,
 	initiatorRandom [RandomSize]byte,
-) (*fabric.FabricInfo, *crypto.P256KeyPair, error)
+) (*fabric.FabricInfo, gocrypto.Signer, error)
 
 // ResumptionLookupFunc finds a previous session for resumption.
 // Used by responder to look up shared secret and validate resumption.
@@ -32,7 +34,7 @@ type FabricLookupFunc func(
 // Returns the previous session's shared secret and fabric info, or nil if not found.
 type ResumptionLookupFunc func(
 	resumptionID [ResumptionIDSize]byte,
-) (sharedSecret []byte, fabricInfo *fabric.FabricInfo, operationalKey *crypto.P256KeyPair, ok bool)
+) (sharedSecret []byte, fabricInfo *fabric.FabricInfo, operationalKey gocrypto.Signer, ok bool)
 
 // Session manages the state of a CASE handshake.
 //
@@ -54,7 +56,7 @@ type Session struct {
 
 	// Configuration
 	fabricInfo     *fabric.FabricInfo   // Our fabric credentials
-	operationalKey *crypto.P256KeyPair  // Our NOC private key
+	operationalKey gocrypto.Signer      // Our NOC private key
 	targetNodeID   uint64               // For initiator: target peer node ID
 
 	// Lookup functions (responder)
@@ -119,11 +121,12 @@ type Session struct {
 //
 // Parameters:
 //   - fabricInfo: Our fabric credentials (NOC chain, IPK, etc.)
-//   - operationalKey: Our NOC private key for signing
+//   - operationalKey: Our NOC private key for signing, possibly held in
+//     a secure element
 //   - targetNodeID: The peer node ID we want to connect to
 func NewInitiator(
 	fabricInfo *fabric.FabricInfo,
-	operationalKey *crypto.P256KeyPair,
+	operationalKey gocrypto.Signer,
 	targetNodeID uint64,
 ) *Session {
 	// Derive IPK from epoch key and compressed fabric ID
@@ -247,6 +250,7 @@ func (s *Session) Start(localSessionID uint16) ([]byte, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to derive S1RK: %w", err)
 		}
+		defer crypto.Zeroize(s1rk[:])
 
 		mic, err := ComputeResumeMIC(s1rk, Resume1Nonce)
 		if err != nil {
@@ -311,11 +315,12 @@ func (s *Session) HandleSigma1(data []byte, localSessionID uint16) (response []b
 		if ok {
 			// Derive S1RK and verify Resume1MIC
 			s1rk, err := DeriveS1RK(sharedSecret, sigma1.InitiatorRandom, *sigma1.ResumptionID)
+			defer crypto.Zeroize(s1rk[:])
 			if err == nil && VerifyResumeMIC(s1rk, Resume1Nonce, *sigma1.InitiatorResumeMIC) {
 				// Resumption validated, generate Sigma2Resume
 				s.fabricInfo = fabricInfo
 				s.operationalKey = operationalKey
-				s.sharedSecret = sharedSecret
+				s.sharedSecret = bytes.Clone(sharedSecret)
 
 				// Derive IPK
 				ipkSlice, _ := crypto.DeriveGroupOperationalKeyV1(fabricInfo.IPK[:], fabricInfo.CompressedFabricID[:])
@@ -383,7 +388,7 @@ func (s *Session) generateSigma2(sigma1 *Sigma1) ([]byte, bool, error) {
 		return nil, false, fmt.Errorf("failed to encode TBSData2: %w", err)
 	}
 
-	signature, err := crypto.P256Sign(s.operationalKey, tbsData2Bytes)
+	signature, err := crypto.P256SignWith(s.operationalKey, tbsData2Bytes)
 	if err != nil {
 		return nil, false, fmt.Errorf("failed to sign TBSData2: %w", err)
 	}
@@ -406,6 +411,7 @@ func (s *Session) generateSigma2(sigma1 *Sigma1) ([]byte, bool, error) {
 	if err != nil {
 		return nil, false, fmt.Errorf("failed to derive S2K: %w", err)
 	}
+	defer crypto.Zeroize(s2k[:])
 
 	encrypted2, err := EncryptTBEData(s2k, tbeData2Bytes, Sigma2Nonce, nil)
 	if err != nil {
@@ -442,6 +448,7 @@ func (s *Session) generateSigma2Resume(sigma1 *Sigma1) ([]byte, bool, error) {
 	if err != nil {
 		return nil, false, fmt.Errorf("failed to derive S2RK: %w", err)
 	}
+	defer crypto.Zeroize(s2rk[:])
 
 	resume2MIC, err := ComputeResumeMIC(s2rk, Resume2Nonce)
 	if err != nil {
@@ -510,6 +517,7 @@ func (s *Session) HandleSigma2(data []byte) ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to derive S2K: %w", err)
 	}
+	defer crypto.Zeroize(s2k[:])
 
 	tbeData2Bytes, err := DecryptTBEData(s2k, sigma2.Encrypted2, Sigma2Nonce, nil)
 	if err != nil {
@@ -591,7 +599,7 @@ func (s *Session) generateSigma3() ([]byte, error) {
 		return nil, fmt.Errorf("failed to encode TBSData3: %w", err)
 	}
 
-	signature, err := crypto.P256Sign(s.operationalKey, tbsData3Bytes)
+	signature, err := crypto.P256SignWith(s.operationalKey, tbsData3Bytes)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign TBSData3: %w", err)
 	}
@@ -613,6 +621,7 @@ func (s *Session) generateSigma3() ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to derive S3K: %w", err)
 	}
+	defer crypto.Zeroize(s3k[:])
 
 	encrypted3, err := EncryptTBEData(s3k, tbeData3Bytes, Sigma3Nonce, nil)
 	if err != nil {
@@ -660,14 +669,19 @@ func (s *Session) HandleSigma2Resume(data []byte) error {
 	s.peerMRPParams = sigma2Resume.MRPParams
 	s.newResumptionID = sigma2Resume.ResumptionID
 
-	// Use shared secret from previous session
-	s.sharedSecret = s.resumptionInfo.SharedSecret
+	// Use shared secret and peer of the previous session
+	s.sharedSecret = bytes.Clone(s.resumptionInfo.SharedSecret)
+	s.peerNodeID = s.resumptionInfo.PeerNodeID
+	if s.peerNodeID == 0 {
+		s.peerNodeID = s.targetNodeID
+	}
 
 	// Verify Resume2MIC
 	s2rk, err := DeriveS2RK(s.sharedSecret, s.localRandom, sigma2Resume.ResumptionID)
 	if err != nil {
 		return fmt.Errorf("failed to derive S2RK: %w", err)
 	}
+	defer crypto.Zeroize(s2rk[:])
 
 	if !VerifyResumeMIC(s2rk, Resume2Nonce, sigma2Resume.Resume2MIC) {
 		return ErrInvalidResumeMIC
@@ -710,6 +724,7 @@ func (s *Session) HandleSigma3(data []byte) error {
 	if err != nil {
 		return fmt.Errorf("failed to derive S3K: %w", err)
 	}
+	defer crypto.Zeroize(s3k[:])
 
 	tbeData3Bytes, err := DecryptTBEData(s3k, sigma3.Encrypted3, Sigma3Nonce, nil)
 	if err != nil {
@@ -863,6 +878,23 @@ func (s *Session) SharedSecret() []byte {
 	return secret
 }
 
+// Zeroize wipes the shared secret, the IPK and the session keys, and drops
+// the ephemeral key pair. Call it once the session keys have been copied
+// into a secure session, or when the handshake fails. Keys returned by
+// SessionKeys are wiped too.
+func (s *Session) Zeroize() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	crypto.Zeroize(s.sharedSecret, s.ipk[:])
+	s.sharedSecret = nil
+	s.ephKeyPair = nil
+	if s.sessionKeys != nil {
+		crypto.Zeroize(s.sessionKeys.I2RKey[:], s.sessionKeys.R2IKey[:], s.sessionKeys.AttestationChallenge[:])
+		s.sessionKeys = nil
+	}
+}
+
 // PeerMRPParams returns the peer's MRP parameters (if provided).
 func (s *Session) PeerMRPParams() *MRPParameters {
 	s.mu.Lock()
@@ -896,6 +928,17 @@ func (s *Session) FabricIndex() uint8 {
 	return uint8(s.fabricInfo.FabricIndex)
 }
 
+// LocalNodeID returns our node ID on the session's fabric, or 0 if the
+// fabric is not known yet.
+func (s *Session) LocalNodeID() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fabricInfo == nil {
+		return 0
+	}
+	return uint64(s.fabricInfo.NodeID)
+}
+
 // PeerCATs returns the peer's CASE Authenticated Tags from their NOC.
 // Returns nil if no CATs were present.
 func (s *Session) PeerCATs() []uint32 {
@@ -2,6 +2,8 @@ package casesession
 
 import (
 	"bytes"
+	gocrypto "crypto"
+	"io"
 	"testing"
 
 	"github.com/backkem/matter/pkg/crypto"
@@ -78,7 +80,7 @@ func TestSession_FullHandshake(t *testing.T) {
 	responderFabric.CompressedFabricID = cfid
 
 	// Create fabric lookup function for responder
-	fabricLookup := func(destID [DestinationIDSize]byte, initiatorRandom [RandomSize]byte) (*fabric.FabricInfo, *crypto.P256KeyPair, error) {
+	fabricLookup := func(destID [DestinationIDSize]byte, initiatorRandom [RandomSize]byte) (*fabric.FabricInfo, gocrypto.Signer, error) {
 		// Derive IPK
 		ipkSlice, _ := crypto.DeriveGroupOperationalKeyV1(responderFabric.IPK[:], responderFabric.CompressedFabricID[:])
 		var ipk [crypto.SymmetricKeySize]byte
@@ -178,6 +180,79 @@ func TestSession_FullHandshake(t *testing.T) {
 	if initiator.UsedResumption() || responder.UsedResumption() {
 		t.Error("expected no resumption to be used")
 	}
+
+	// Zeroize wipes the handshake secrets
+	sharedSecret := initiator.sharedSecret
+	initiator.Zeroize()
+	responder.Zeroize()
+	if !bytes.Equal(sharedSecret, make([]byte, len(sharedSecret))) {
+		t.Error("shared secret not wiped by Zeroize")
+	}
+	if initiatorKeys.I2RKey != ([SessionKeySize]byte{}) || responderKeys.R2IKey != ([SessionKeySize]byte{}) {
+		t.Error("session keys not wiped by Zeroize")
+	}
+	if initiator.ipk != ([crypto.SymmetricKeySize]byte{}) || initiator.ephKeyPair != nil {
+		t.Error("IPK or ephemeral key left after Zeroize")
+	}
+	if keys, _ := initiator.SessionKeys(); keys != nil {
+		t.Errorf("SessionKeys() = %+v after Zeroize", keys)
+	}
+}
+
+// opaqueSigner hides a key pair behind crypto.Signer, as a secure element
+// does.
+type opaqueSigner struct {
+	key *crypto.P256KeyPair
+}
+
+func (s opaqueSigner) Public() gocrypto.PublicKey { return s.key.Public() }
+
+func (s opaqueSigner) Sign(random io.Reader, digest []byte, opts gocrypto.SignerOpts) ([]byte, error) {
+	return s.key.Sign(random, digest, opts)
+}
+
+// TestSession_FullHandshake_Signer tests a CASE handshake with operational
+// keys only available as crypto.Signer.
+func TestSession_FullHandshake_Signer(t *testing.T) {
+	fabricID := uint64(0x1234567890ABCDEF)
+	responderNodeID := uint64(0x2222222222222222)
+
+	initiatorFabric, initiatorKey := createTestFabricInfo(t, 1, fabricID, 0x1111111111111111)
+	responderFabric, responderKey := createTestFabricInfo(t, 1, fabricID, responderNodeID)
+	responderFabric.RootPublicKey = initiatorFabric.RootPublicKey
+	responderFabric.IPK = initiatorFabric.IPK
+	responderFabric.CompressedFabricID, _ = fabric.CompressedFabricIDFromCert(responderFabric.RootPublicKey, responderFabric.FabricID)
+
+	fabricLookup := func(destID [DestinationIDSize]byte, initiatorRandom [RandomSize]byte) (*fabric.FabricInfo, gocrypto.Signer, error) {
+		return responderFabric, opaqueSigner{responderKey}, nil
+	}
+	initiator := NewInitiator(initiatorFabric, opaqueSigner{initiatorKey}, responderNodeID)
+	responder := NewResponder(fabricLookup, nil)
+
+	sigma1, err := initiator.Start(0x1000)
+	if err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	sigma2, _, err := responder.HandleSigma1(sigma1, 0x2000)
+	if err != nil {
+		t.Fatalf("HandleSigma1() failed: %v", err)
+	}
+	sigma3, err := initiator.HandleSigma2(sigma2)
+	if err != nil {
+		t.Fatalf("HandleSigma2() failed: %v", err)
+	}
+	if err := responder.HandleSigma3(sigma3); err != nil {
+		t.Fatalf("HandleSigma3() failed: %v", err)
+	}
+	if err := initiator.HandleStatusReport(true); err != nil {
+		t.Fatalf("HandleStatusReport() failed: %v", err)
+	}
+
+	initiatorKeys, _ := initiator.SessionKeys()
+	responderKeys, _ := responder.SessionKeys()
+	if initiatorKeys.I2RKey != responderKeys.I2RKey || initiatorKeys.R2IKey != responderKeys.R2IKey {
+		t.Error("session key mismatch between initiator and responder")
+	}
 }
 
 // TestSession_Resumption tests session resumption.
@@ -196,7 +271,7 @@ func TestSession_Resumption(t *testing.T) {
 	responderFabric.CompressedFabricID = cfid
 
 	// First, complete a full handshake to get shared secret
-	fabricLookup := func(destID [DestinationIDSize]byte, initiatorRandom [RandomSize]byte) (*fabric.FabricInfo, *crypto.P256KeyPair, error) {
+	fabricLookup := func(destID [DestinationIDSize]byte, initiatorRandom [RandomSize]byte) (*fabric.FabricInfo, gocrypto.Signer, error) {
 		ipkSlice, _ := crypto.DeriveGroupOperationalKeyV1(responderFabric.IPK[:], responderFabric.CompressedFabricID[:])
 		var ipk [crypto.SymmetricKeySize]byte
 		copy(ipk[:], ipkSlice)
@@ -225,7 +300,7 @@ func TestSession_Resumption(t *testing.T) {
 	storedSharedSecret := make([]byte, len(sharedSecret))
 	copy(storedSharedSecret, sharedSecret)
 
-	resumptionLookup := func(incomingID [ResumptionIDSize]byte) ([]byte, *fabric.FabricInfo, *crypto.P256KeyPair, bool) {
+	resumptionLookup := func(incomingID [ResumptionIDSize]byte) ([]byte, *fabric.FabricInfo, gocrypto.Signer, bool) {
 		if incomingID == storedResumptionID {
 			return storedSharedSecret, responderFabric, responderKey, true
 		}
@@ -298,7 +373,7 @@ func TestSession_ResumptionFallback(t *testing.T) {
 	cfid, _ := fabric.CompressedFabricIDFromCert(responderFabric.RootPublicKey, responderFabric.FabricID)
 	responderFabric.CompressedFabricID = cfid
 
-	fabricLookup := func(destID [DestinationIDSize]byte, initiatorRandom [RandomSize]byte) (*fabric.FabricInfo, *crypto.P256KeyPair, error) {
+	fabricLookup := func(destID [DestinationIDSize]byte, initiatorRandom [RandomSize]byte) (*fabric.FabricInfo, gocrypto.Signer, error) {
 		ipkSlice, _ := crypto.DeriveGroupOperationalKeyV1(responderFabric.IPK[:], responderFabric.CompressedFabricID[:])
 		var ipk [crypto.SymmetricKeySize]byte
 		copy(ipk[:], ipkSlice)
@@ -399,7 +474,7 @@ func TestSession_InvalidState(t *testing.T) {
 func TestSession_MissingResumptionFields(t *testing.T) {
 	_, _ = createTestFabricInfo(t, 1, 0x1234, 0x5678)
 
-	fabricLookup := func(destID [DestinationIDSize]byte, initiatorRandom [RandomSize]byte) (*fabric.FabricInfo, *crypto.P256KeyPair, error) {
+	fabricLookup := func(destID [DestinationIDSize]byte, initiatorRandom [RandomSize]byte) (*fabric.FabricInfo, gocrypto.Signer, error) {
 		return nil, nil, ErrNoSharedRoot
 	}
 
@@ -469,7 +544,7 @@ func TestSession_NoSharedRoot(t *testing.T) {
 	fabricInfo, key := createTestFabricInfo(t, 1, 0x1234, 0x5678)
 
 	// Fabric lookup always returns error
-	fabricLookup := func(destID [DestinationIDSize]byte, initiatorRandom [RandomSize]byte) (*fabric.FabricInfo, *crypto.P256KeyPair, error) {
+	fabricLookup := func(destID [DestinationIDSize]byte, initiatorRandom [RandomSize]byte) (*fabric.FabricInfo, gocrypto.Signer, error) {
 		return nil, nil, ErrNoSharedRoot
 	}
 
@@ -501,7 +576,7 @@ func TestSession_WithMRPParams(t *testing.T) {
 	cfid, _ := fabric.CompressedFabricIDFromCert(responderFabric.RootPublicKey, responderFabric.FabricID)
 	responderFabric.CompressedFabricID = cfid
 
-	fabricLookup := func(destID [DestinationIDSize]byte, initiatorRandom [RandomSize]byte) (*fabric.FabricInfo, *crypto.P256KeyPair, error) {
+	fabricLookup := func(destID [DestinationIDSize]byte, initiatorRandom [RandomSize]byte) (*fabric.FabricInfo, gocrypto.Signer, error) {
 		ipkSlice, _ := crypto.DeriveGroupOperationalKeyV1(responderFabric.IPK[:], responderFabric.CompressedFabricID[:])
 		var ipk [crypto.SymmetricKeySize]byte
 		copy(ipk[:], ipkSlice)
@@ -593,7 +668,7 @@ func TestSession_CertValidatorCallback(t *testing.T) {
 	cfid, _ := fabric.CompressedFabricIDFromCert(responderFabric.RootPublicKey, responderFabric.FabricID)
 	responderFabric.CompressedFabricID = cfid
 
-	fabricLookup := func(destID [DestinationIDSize]byte, initiatorRandom [RandomSize]byte) (*fabric.FabricInfo, *crypto.P256KeyPair, error) {
+	fabricLookup := func(destID [DestinationIDSize]byte, initiatorRandom [RandomSize]byte) (*fabric.FabricInfo, gocrypto.Signer, error) {
 		ipkSlice, _ := crypto.DeriveGroupOperationalKeyV1(responderFabric.IPK[:], responderFabric.CompressedFabricID[:])
 		var ipk [crypto.SymmetricKeySize]byte
 		copy(ipk[:], ipkSlice)
@@ -747,7 +822,7 @@ func TestSession_CertValidatorCallback(t *testing.T) {
 
 		var receivedICAC []byte
 
-		fabricLookupWithICAC := func(destID [DestinationIDSize]byte, initiatorRandom [RandomSize]byte) (*fabric.FabricInfo, *crypto.P256KeyPair, error) {
+		fabricLookupWithICAC := func(destID [DestinationIDSize]byte, initiatorRandom [RandomSize]byte) (*fabric.FabricInfo, gocrypto.Signer, error) {
 			ipkSlice, _ := crypto.DeriveGroupOperationalKeyV1(fabricWithICAC.IPK[:], fabricWithICAC.CompressedFabricID[:])
 			var ipk [crypto.SymmetricKeySize]byte
 			copy(ipk[:], ipkSlice)
@@ -804,7 +879,7 @@ func TestSession_CertValidatorFailure(t *testing.T) {
 	cfid, _ := fabric.CompressedFabricIDFromCert(responderFabric.RootPublicKey, responderFabric.FabricID)
 	responderFabric.CompressedFabricID = cfid
 
-	fabricLookup := func(destID [DestinationIDSize]byte, initiatorRandom [RandomSize]byte) (*fabric.FabricInfo, *crypto.P256KeyPair, error) {
+	fabricLookup := func(destID [DestinationIDSize]byte, initiatorRandom [RandomSize]byte) (*fabric.FabricInfo, gocrypto.Signer, error) {
 		ipkSlice, _ := crypto.DeriveGroupOperationalKeyV1(responderFabric.IPK[:], responderFabric.CompressedFabricID[:])
 		var ipk [crypto.SymmetricKeySize]byte
 		copy(ipk[:], ipkSlice)
@@ -917,7 +992,7 @@ func TestSession_SignatureVerification(t *testing.T) {
 	cfid, _ := fabric.CompressedFabricIDFromCert(responderFabric.RootPublicKey, responderFabric.FabricID)
 	responderFabric.CompressedFabricID = cfid
 
-	fabricLookup := func(destID [DestinationIDSize]byte, initiatorRandom [RandomSize]byte) (*fabric.FabricInfo, *crypto.P256KeyPair, error) {
+	fabricLookup := func(destID [DestinationIDSize]byte, initiatorRandom [RandomSize]byte) (*fabric.FabricInfo, gocrypto.Signer, error) {
 		ipkSlice, _ := crypto.DeriveGroupOperationalKeyV1(responderFabric.IPK[:], responderFabric.CompressedFabricID[:])
 		var ipk [crypto.SymmetricKeySize]byte
 		copy(ipk[:], ipkSlice)
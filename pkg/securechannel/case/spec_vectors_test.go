@@ -0,0 +1,96 @@
+package casesession
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/backkem/matter/pkg/crypto"
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/testvectors"
+)
+
+// TestDestinationIDVectors walks each destination ID vector from the root
+// public key: compressed fabric ID, IPK, then the destination identifier.
+func TestDestinationIDVectors(t *testing.T) {
+	for _, tv := range testvectors.DestinationID {
+		t.Run(tv.Name, func(t *testing.T) {
+			cfid, err := fabric.CompressedFabricID(tv.RootPublicKey, fabric.FabricID(tv.FabricID))
+			if err != nil {
+				t.Fatalf("CompressedFabricID failed: %v", err)
+			}
+			if !bytes.Equal(cfid[:], tv.CompressedFabricID) {
+				t.Errorf("compressed fabric ID = %x, want %x", cfid, tv.CompressedFabricID)
+			}
+
+			ipk, err := crypto.DeriveGroupOperationalKeyV1(tv.EpochKey, tv.CompressedFabricID)
+			if err != nil {
+				t.Fatalf("DeriveGroupOperationalKeyV1 failed: %v", err)
+			}
+			if !bytes.Equal(ipk, tv.IPK) {
+				t.Errorf("IPK = %x, want %x", ipk, tv.IPK)
+			}
+
+			var random [RandomSize]byte
+			var rootPubKey [crypto.P256PublicKeySizeBytes]byte
+			var ipkKey [crypto.SymmetricKeySize]byte
+			var want [DestinationIDSize]byte
+			copy(random[:], tv.InitiatorRandom)
+			copy(rootPubKey[:], tv.RootPublicKey)
+			copy(ipkKey[:], tv.IPK)
+			copy(want[:], tv.DestinationID)
+
+			got := GenerateDestinationID(random, rootPubKey, tv.FabricID, tv.NodeID, ipkKey)
+			if got != want {
+				t.Errorf("destination ID = %x, want %x", got, want)
+			}
+			if !MatchDestinationID(want, random, rootPubKey, tv.FabricID, tv.NodeID, ipkKey) {
+				t.Error("MatchDestinationID = false for the vector")
+			}
+		})
+	}
+}
+
+// TestCASEKeysVectors checks the Sigma2 and Sigma3 keys and the session
+// keys against each key schedule vector.
+func TestCASEKeysVectors(t *testing.T) {
+	for _, tv := range testvectors.CASEKeys {
+		t.Run(tv.Name, func(t *testing.T) {
+			var ipk [crypto.SymmetricKeySize]byte
+			var random [RandomSize]byte
+			var ephPubKey [crypto.P256PublicKeySizeBytes]byte
+			copy(ipk[:], tv.IPK)
+			copy(random[:], tv.ResponderRandom)
+			copy(ephPubKey[:], tv.ResponderEphPubKey)
+
+			s2k, err := DeriveS2K(tv.SharedSecret, ipk, random, ephPubKey, tv.Sigma1)
+			if err != nil {
+				t.Fatalf("DeriveS2K failed: %v", err)
+			}
+			if !bytes.Equal(s2k[:], tv.S2K) {
+				t.Errorf("S2K = %x, want %x", s2k, tv.S2K)
+			}
+
+			s3k, err := DeriveS3K(tv.SharedSecret, ipk, tv.Sigma1, tv.Sigma2)
+			if err != nil {
+				t.Fatalf("DeriveS3K failed: %v", err)
+			}
+			if !bytes.Equal(s3k[:], tv.S3K) {
+				t.Errorf("S3K = %x, want %x", s3k, tv.S3K)
+			}
+
+			keys, err := DeriveSessionKeys(tv.SharedSecret, ipk, tv.Sigma1, tv.Sigma2, tv.Sigma3)
+			if err != nil {
+				t.Fatalf("DeriveSessionKeys failed: %v", err)
+			}
+			if !bytes.Equal(keys.I2RKey[:], tv.I2RKey) {
+				t.Errorf("I2RKey = %x, want %x", keys.I2RKey, tv.I2RKey)
+			}
+			if !bytes.Equal(keys.R2IKey[:], tv.R2IKey) {
+				t.Errorf("R2IKey = %x, want %x", keys.R2IKey, tv.R2IKey)
+			}
+			if !bytes.Equal(keys.AttestationChallenge[:], tv.AttestationChallenge) {
+				t.Errorf("AttestationChallenge = %x, want %x", keys.AttestationChallenge, tv.AttestationChallenge)
+			}
+		})
+	}
+}
@@ -1,7 +1,6 @@
 package securechannel
 
 import (
-	"bytes"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/sha256"
@@ -194,36 +193,7 @@ func parseP256PublicKey(data []byte) (*ecdsa.PublicKey, error) {
 // getTBSData returns the TBS (to-be-signed) portion of the certificate.
 // This is the certificate without the signature field.
 func getTBSData(cert *credentials.Certificate) ([]byte, error) {
-	// Create a copy of the certificate without signature
-	tbsCert := &credentials.Certificate{
-		SerialNum:  cert.SerialNum,
-		SigAlgo:    cert.SigAlgo,
-		Issuer:     cert.Issuer,
-		NotBefore:  cert.NotBefore,
-		NotAfter:   cert.NotAfter,
-		Subject:    cert.Subject,
-		PubKeyAlgo: cert.PubKeyAlgo,
-		ECCurveID:  cert.ECCurveID,
-		ECPubKey:   cert.ECPubKey,
-		Extensions: cert.Extensions,
-		// Signature omitted
-	}
-
-	// Encode to TLV
-	var buf bytes.Buffer
-	// We need to encode just the structure without the signature
-	// For Matter certificates, the TBS data is the structure up to but not including tag 11 (signature)
-	tlvBytes, err := tbsCert.EncodeTLV()
-	if err != nil {
-		return nil, err
-	}
-
-	// The TBS data needs the signature removed
-	// For proper implementation, we should use a custom encoder that stops at signature
-	// For now, we return the full cert encoding minus the signature at the end
-	buf.Write(tlvBytes)
-
-	return buf.Bytes(), nil
+	return cert.TBSData()
 }
 
 // validateCertTime validates the certificate's validity period.
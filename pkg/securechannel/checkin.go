@@ -0,0 +1,107 @@
+package securechannel
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/backkem/matter/pkg/crypto"
+)
+
+// Check-In message sizes.
+const (
+	// CheckInKeySize is the size of the symmetric key a client registers
+	// with an ICD for its Check-In messages.
+	CheckInKeySize = 16
+
+	// checkInNonceSize is the size of the nonce leading the payload.
+	checkInNonceSize = 13
+
+	// checkInCounterSize is the size of the counter leading the
+	// encrypted application data.
+	checkInCounterSize = 4
+
+	// CheckInMinPayloadSize is the size of a Check-In payload without
+	// application data: nonce, counter and MIC.
+	CheckInMinPayloadSize = checkInNonceSize + checkInCounterSize + crypto.AESCCMTagSize
+)
+
+// Check-In errors.
+var (
+	ErrInvalidCheckInKey     = errors.New("securechannel: Check-In key must be 16 bytes")
+	ErrInvalidCheckInPayload = errors.New("securechannel: invalid Check-In payload")
+)
+
+// CheckIn is the content of an ICD Check-In message. A Long Idle Time
+// ICD sends it, unsolicited on an unsecured session, to each registered
+// client that has no active subscription when it becomes active, so the
+// client can reach it while it listens.
+//
+// C++ Reference: src/protocols/secure_channel/CheckinMessage.cpp
+type CheckIn struct {
+	// Counter is the ICD's Check-In counter, incremented for each
+	// message. Clients reject counters they have seen.
+	Counter uint32
+
+	// ActiveModeThreshold is how long, in milliseconds, the ICD stays
+	// active after the message. Zero if the ICD did not send it.
+	ActiveModeThreshold uint16
+}
+
+// EncodeCheckIn encodes a Check-In payload with the key registered by the
+// client. The nonce is derived from the counter with HMAC-SHA256, and the
+// counter and ActiveModeThreshold are encrypted with AES-CCM:
+//
+//	nonce (13) | AES-CCM(counter (4) | ActiveModeThreshold (2)) | MIC (16)
+func EncodeCheckIn(key []byte, msg CheckIn) ([]byte, error) {
+	if len(key) != CheckInKeySize {
+		return nil, ErrInvalidCheckInKey
+	}
+
+	plaintext := make([]byte, checkInCounterSize+2)
+	binary.LittleEndian.PutUint32(plaintext, msg.Counter)
+	binary.LittleEndian.PutUint16(plaintext[checkInCounterSize:], msg.ActiveModeThreshold)
+
+	nonce := checkInNonce(key, msg.Counter)
+	ciphertext, err := crypto.AESCCM128Encrypt(key, nonce, plaintext, nil)
+	if err != nil {
+		return nil, err
+	}
+	return append(nonce, ciphertext...), nil
+}
+
+// DecodeCheckIn decrypts a Check-In payload with a registered key. It
+// returns ErrInvalidCheckInPayload if the payload was not encrypted with
+// the key, so a client can try each of its keys in turn.
+func DecodeCheckIn(key, payload []byte) (*CheckIn, error) {
+	if len(key) != CheckInKeySize {
+		return nil, ErrInvalidCheckInKey
+	}
+	if len(payload) < CheckInMinPayloadSize {
+		return nil, ErrInvalidCheckInPayload
+	}
+
+	nonce := payload[:checkInNonceSize]
+	plaintext, err := crypto.AESCCM128Decrypt(key, nonce, payload[checkInNonceSize:], nil)
+	if err != nil {
+		return nil, ErrInvalidCheckInPayload
+	}
+
+	msg := &CheckIn{Counter: binary.LittleEndian.Uint32(plaintext)}
+	// The nonce must be the one derived from the counter
+	if !crypto.HMACEqual(nonce, checkInNonce(key, msg.Counter)) {
+		return nil, ErrInvalidCheckInPayload
+	}
+	if appData := plaintext[checkInCounterSize:]; len(appData) >= 2 {
+		msg.ActiveModeThreshold = binary.LittleEndian.Uint16(appData)
+	}
+	return msg, nil
+}
+
+// checkInNonce derives the nonce of a Check-In message: the first 13
+// bytes of HMAC-SHA256 over the little-endian counter.
+func checkInNonce(key []byte, counter uint32) []byte {
+	var b [checkInCounterSize]byte
+	binary.LittleEndian.PutUint32(b[:], counter)
+	mac := crypto.HMACSHA256(key, b[:])
+	return append([]byte(nil), mac[:checkInNonceSize]...)
+}
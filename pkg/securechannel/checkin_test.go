@@ -0,0 +1,64 @@
+package securechannel
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestCheckIn_RoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{0x5A}, CheckInKeySize)
+	msg := CheckIn{Counter: 0x01020304, ActiveModeThreshold: 5000}
+
+	payload, err := EncodeCheckIn(key, msg)
+	if err != nil {
+		t.Fatalf("EncodeCheckIn() error = %v", err)
+	}
+	if len(payload) != CheckInMinPayloadSize+2 {
+		t.Errorf("payload length = %d, want %d", len(payload), CheckInMinPayloadSize+2)
+	}
+
+	got, err := DecodeCheckIn(key, payload)
+	if err != nil {
+		t.Fatalf("DecodeCheckIn() error = %v", err)
+	}
+	if *got != msg {
+		t.Errorf("DecodeCheckIn() = %+v, want %+v", *got, msg)
+	}
+
+	// A different counter gives a different nonce
+	other, _ := EncodeCheckIn(key, CheckIn{Counter: msg.Counter + 1})
+	if bytes.Equal(other[:checkInNonceSize], payload[:checkInNonceSize]) {
+		t.Error("nonce reused across counters")
+	}
+}
+
+func TestCheckIn_Invalid(t *testing.T) {
+	key := bytes.Repeat([]byte{0x5A}, CheckInKeySize)
+	payload, err := EncodeCheckIn(key, CheckIn{Counter: 7})
+	if err != nil {
+		t.Fatalf("EncodeCheckIn() error = %v", err)
+	}
+
+	tampered := append([]byte(nil), payload...)
+	tampered[0] ^= 0xFF
+
+	tests := []struct {
+		name    string
+		key     []byte
+		payload []byte
+		want    error
+	}{
+		{"wrong key", bytes.Repeat([]byte{0xA5}, CheckInKeySize), payload, ErrInvalidCheckInPayload},
+		{"short key", key[:8], payload, ErrInvalidCheckInKey},
+		{"truncated", key, payload[:CheckInMinPayloadSize-1], ErrInvalidCheckInPayload},
+		{"tampered nonce", key, tampered, ErrInvalidCheckInPayload},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCheckIn(tt.key, tt.payload); !errors.Is(err, tt.want) {
+				t.Errorf("DecodeCheckIn() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
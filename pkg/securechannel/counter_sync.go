@@ -0,0 +1,228 @@
+package securechannel
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/backkem/matter/pkg/clock"
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/session"
+)
+
+// Message Counter Synchronization Protocol sizes.
+const (
+	// CounterSyncChallengeSize is the size of the challenge of a
+	// MsgCounterSyncReq, echoed in the MsgCounterSyncRsp.
+	CounterSyncChallengeSize = 8
+
+	// CounterSyncResponseSize is the size of a MsgCounterSyncRsp payload.
+	CounterSyncResponseSize = 4 + CounterSyncChallengeSize // SynchronizedCounter(4) + Response(8)
+)
+
+// DefaultCounterSyncTimeout is how long a MsgCounterSyncReq waits for its
+// response. Once it passes, the next control message from the peer starts
+// a new request.
+const DefaultCounterSyncTimeout = 2 * time.Second
+
+// Counter synchronization errors.
+var (
+	ErrCounterSyncPayload  = errors.New("securechannel: invalid counter sync payload")
+	ErrCounterSyncNoReq    = errors.New("securechannel: no counter sync request pending")
+	ErrCounterSyncMismatch = errors.New("securechannel: counter sync response does not match challenge")
+)
+
+// CounterSyncRequest is a MsgCounterSyncReq: a node asks a group peer for
+// its group control counter.
+//
+// See Matter Specification Section 4.6 (message counter synchronization).
+type CounterSyncRequest struct {
+	Challenge [CounterSyncChallengeSize]byte
+}
+
+// Encode serializes the request.
+func (r *CounterSyncRequest) Encode() []byte {
+	return append([]byte(nil), r.Challenge[:]...)
+}
+
+// DecodeCounterSyncRequest parses a MsgCounterSyncReq payload.
+func DecodeCounterSyncRequest(data []byte) (*CounterSyncRequest, error) {
+	if len(data) != CounterSyncChallengeSize {
+		return nil, ErrCounterSyncPayload
+	}
+	var r CounterSyncRequest
+	copy(r.Challenge[:], data)
+	return &r, nil
+}
+
+// CounterSyncResponse is a MsgCounterSyncRsp: the peer's group control
+// counter, with the challenge of the request it answers.
+type CounterSyncResponse struct {
+	SynchronizedCounter uint32
+	Response            [CounterSyncChallengeSize]byte
+}
+
+// Encode serializes the response.
+func (r *CounterSyncResponse) Encode() []byte {
+	buf := make([]byte, CounterSyncResponseSize)
+	binary.LittleEndian.PutUint32(buf[0:4], r.SynchronizedCounter)
+	copy(buf[4:], r.Response[:])
+	return buf
+}
+
+// DecodeCounterSyncResponse parses a MsgCounterSyncRsp payload.
+func DecodeCounterSyncResponse(data []byte) (*CounterSyncResponse, error) {
+	if len(data) != CounterSyncResponseSize {
+		return nil, ErrCounterSyncPayload
+	}
+	var r CounterSyncResponse
+	r.SynchronizedCounter = binary.LittleEndian.Uint32(data[0:4])
+	copy(r.Response[:], data[4:])
+	return &r, nil
+}
+
+// CounterSyncConfig configures a CounterSync.
+type CounterSyncConfig struct {
+	// SessionManager holds the node's group control counter and the
+	// control counters of its group peers.
+	SessionManager *session.Manager
+
+	// Timeout is how long a request waits for its response.
+	// Default: DefaultCounterSyncTimeout
+	Timeout time.Duration
+
+	// Clock expires requests.
+	// If nil, the real clock is used.
+	Clock clock.Clock
+}
+
+// counterSyncPeer identifies a group peer.
+type counterSyncPeer struct {
+	fabricIndex fabric.FabricIndex
+	nodeID      fabric.NodeID
+}
+
+// pendingCounterSync is a request awaiting its response.
+type pendingCounterSync struct {
+	challenge [CounterSyncChallengeSize]byte
+	deadline  time.Time
+}
+
+// CounterSync runs the Message Counter Synchronization Protocol (MCSP) for
+// group control messages. Their counters, unlike those of group data
+// messages, are not trusted on first use: a node that receives a control
+// message from a peer it has not synchronized with asks the peer for its
+// control counter with a MsgCounterSyncReq carrying a random challenge.
+// The peer answers with a MsgCounterSyncRsp carrying its counter and the
+// challenge; as both are encrypted with the group key and the challenge
+// is fresh, the counter can be trusted and initializes the peer's control
+// message receive state.
+//
+// CounterSync holds the protocol state; the exchange layer sends and
+// receives its messages with the group key, to and from single nodes.
+//
+// See Matter Specification Section 4.6 (message counter synchronization).
+type CounterSync struct {
+	sessions *session.Manager
+	timeout  time.Duration
+	clock    clock.Clock
+
+	pending map[counterSyncPeer]*pendingCounterSync
+
+	mu sync.Mutex
+}
+
+// NewCounterSync creates a CounterSync.
+func NewCounterSync(config CounterSyncConfig) *CounterSync {
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = DefaultCounterSyncTimeout
+	}
+	return &CounterSync{
+		sessions: config.SessionManager,
+		timeout:  timeout,
+		clock:    clock.OrReal(config.Clock),
+		pending:  make(map[counterSyncPeer]*pendingCounterSync),
+	}
+}
+
+// Request starts synchronizing with a group peer. It returns the payload of
+// the MsgCounterSyncReq to send, or nil if a request to the peer is already
+// pending.
+func (s *CounterSync) Request(fabricIndex fabric.FabricIndex, nodeID fabric.NodeID) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	peer := counterSyncPeer{fabricIndex: fabricIndex, nodeID: nodeID}
+	if p, ok := s.pending[peer]; ok && now.Before(p.deadline) {
+		return nil, nil
+	}
+	for k, p := range s.pending {
+		if !now.Before(p.deadline) {
+			delete(s.pending, k)
+		}
+	}
+
+	var req CounterSyncRequest
+	if _, err := rand.Read(req.Challenge[:]); err != nil {
+		return nil, err
+	}
+	s.pending[peer] = &pendingCounterSync{challenge: req.Challenge, deadline: now.Add(s.timeout)}
+	return req.Encode(), nil
+}
+
+// Pending reports whether a request to a group peer awaits its response.
+func (s *CounterSync) Pending(fabricIndex fabric.FabricIndex, nodeID fabric.NodeID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.pending[counterSyncPeer{fabricIndex: fabricIndex, nodeID: nodeID}]
+	return ok && s.clock.Now().Before(p.deadline)
+}
+
+// HandleRequest answers a MsgCounterSyncReq with the payload of the
+// MsgCounterSyncRsp: the node's group control counter and the challenge.
+//
+// The counter is that of the last control message sent, so that every
+// later one, including the response itself, is accepted by the peer.
+func (s *CounterSync) HandleRequest(payload []byte) ([]byte, error) {
+	req, err := DecodeCounterSyncRequest(payload)
+	if err != nil {
+		return nil, err
+	}
+	resp := CounterSyncResponse{
+		SynchronizedCounter: s.sessions.GroupControlCounter().Current() - 1,
+		Response:            req.Challenge,
+	}
+	return resp.Encode(), nil
+}
+
+// HandleResponse processes a MsgCounterSyncRsp from a group peer. If it
+// answers the pending request to the peer, the peer's control counter is
+// synchronized and its control messages are accepted from then on.
+func (s *CounterSync) HandleResponse(fabricIndex fabric.FabricIndex, nodeID fabric.NodeID, payload []byte) error {
+	resp, err := DecodeCounterSyncResponse(payload)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	peer := counterSyncPeer{fabricIndex: fabricIndex, nodeID: nodeID}
+	p, ok := s.pending[peer]
+	if !ok || !s.clock.Now().Before(p.deadline) {
+		s.mu.Unlock()
+		return ErrCounterSyncNoReq
+	}
+	if subtle.ConstantTimeCompare(p.challenge[:], resp.Response[:]) != 1 {
+		s.mu.Unlock()
+		return ErrCounterSyncMismatch
+	}
+	delete(s.pending, peer)
+	s.mu.Unlock()
+
+	return s.sessions.SynchronizeGroupControlCounter(fabricIndex, nodeID, resp.SynchronizedCounter)
+}
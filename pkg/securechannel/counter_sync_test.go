@@ -0,0 +1,145 @@
+package securechannel
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/backkem/matter/pkg/clock"
+	"github.com/backkem/matter/pkg/message"
+	"github.com/backkem/matter/pkg/session"
+)
+
+var testCounterSyncKey = bytes.Repeat([]byte{0x42}, 16)
+
+func TestCounterSyncResponseRoundtrip(t *testing.T) {
+	resp := CounterSyncResponse{
+		SynchronizedCounter: 0x01020304,
+		Response:            [CounterSyncChallengeSize]byte{1, 2, 3, 4, 5, 6, 7, 8},
+	}
+	encoded := resp.Encode()
+	want := []byte{0x04, 0x03, 0x02, 0x01, 1, 2, 3, 4, 5, 6, 7, 8}
+	if !bytes.Equal(encoded, want) {
+		t.Fatalf("Encode() = % X, want % X", encoded, want)
+	}
+
+	decoded, err := DecodeCounterSyncResponse(encoded)
+	if err != nil {
+		t.Fatalf("DecodeCounterSyncResponse() error = %v", err)
+	}
+	if *decoded != resp {
+		t.Errorf("decoded = %+v, want %+v", decoded, resp)
+	}
+
+	if _, err := DecodeCounterSyncResponse(encoded[:11]); err != ErrCounterSyncPayload {
+		t.Errorf("DecodeCounterSyncResponse(short) error = %v, want %v", err, ErrCounterSyncPayload)
+	}
+	if _, err := DecodeCounterSyncRequest(make([]byte, 9)); err != ErrCounterSyncPayload {
+		t.Errorf("DecodeCounterSyncRequest(long) error = %v, want %v", err, ErrCounterSyncPayload)
+	}
+}
+
+func TestCounterSync(t *testing.T) {
+	requester := session.NewManager(session.ManagerConfig{})
+	responder := session.NewManager(session.ManagerConfig{})
+	clk := clock.NewFakeClock(time.Unix(0, 0))
+	rs := NewCounterSync(CounterSyncConfig{SessionManager: requester, Clock: clk})
+	ps := NewCounterSync(CounterSyncConfig{SessionManager: responder})
+
+	req, err := rs.Request(1, 0x1234)
+	if err != nil || len(req) != CounterSyncChallengeSize {
+		t.Fatalf("Request() = % X, %v", req, err)
+	}
+	if !rs.Pending(1, 0x1234) {
+		t.Error("Pending() = false after Request()")
+	}
+	if again, err := rs.Request(1, 0x1234); again != nil || err != nil {
+		t.Errorf("Request() while pending = % X, %v, want nil", again, err)
+	}
+
+	resp, err := ps.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("HandleRequest() error = %v", err)
+	}
+	decoded, _ := DecodeCounterSyncResponse(resp)
+	if want := responder.GroupControlCounter().Current() - 1; decoded.SynchronizedCounter != want {
+		t.Errorf("SynchronizedCounter = %d, want %d", decoded.SynchronizedCounter, want)
+	}
+
+	// A response from another peer or with another challenge is rejected
+	if err := rs.HandleResponse(1, 0x5678, resp); err != ErrCounterSyncNoReq {
+		t.Errorf("HandleResponse(other peer) error = %v, want %v", err, ErrCounterSyncNoReq)
+	}
+	forged := *decoded
+	forged.Response[0] ^= 0xFF
+	if err := rs.HandleResponse(1, 0x1234, forged.Encode()); err != ErrCounterSyncMismatch {
+		t.Errorf("HandleResponse(forged) error = %v, want %v", err, ErrCounterSyncMismatch)
+	}
+
+	if err := rs.HandleResponse(1, 0x1234, resp); err != nil {
+		t.Fatalf("HandleResponse() error = %v", err)
+	}
+	if rs.Pending(1, 0x1234) {
+		t.Error("Pending() = true after HandleResponse()")
+	}
+	if err := requester.AddGroupKey(session.GroupKey{
+		FabricIndex:    1,
+		GroupID:        100,
+		GroupSessionID: 200,
+		OperationalKey: testCounterSyncKey,
+	}); err != nil {
+		t.Fatalf("AddGroupKey() error = %v", err)
+	}
+	sender, err := session.NewGroupContext(session.GroupContextConfig{
+		SourceNodeID:   0x1234,
+		FabricIndex:    1,
+		GroupID:        100,
+		GroupSessionID: 200,
+		OperationalKey: testCounterSyncKey,
+		Counter:        message.NewMessageCounterWithValue(decoded.SynchronizedCounter + 1),
+		Control:        true,
+	})
+	if err != nil {
+		t.Fatalf("NewGroupContext() error = %v", err)
+	}
+	data, err := sender.Encrypt(&message.MessageHeader{}, &message.ProtocolHeader{Initiator: true}, nil, false)
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if _, _, err := requester.DecryptGroupMessage(data); err != nil {
+		t.Errorf("DecryptGroupMessage() after sync error = %v", err)
+	}
+
+	// The challenge is used once
+	if err := rs.HandleResponse(1, 0x1234, resp); err != ErrCounterSyncNoReq {
+		t.Errorf("HandleResponse(replay) error = %v, want %v", err, ErrCounterSyncNoReq)
+	}
+}
+
+func TestCounterSync_Timeout(t *testing.T) {
+	clk := clock.NewFakeClock(time.Unix(0, 0))
+	cs := NewCounterSync(CounterSyncConfig{
+		SessionManager: session.NewManager(session.ManagerConfig{}),
+		Clock:          clk,
+	})
+
+	req, err := cs.Request(1, 0x1234)
+	if err != nil {
+		t.Fatalf("Request() error = %v", err)
+	}
+	resp := (&CounterSyncResponse{SynchronizedCounter: 10, Response: [CounterSyncChallengeSize]byte(req)}).Encode()
+
+	clk.Advance(DefaultCounterSyncTimeout)
+	if cs.Pending(1, 0x1234) {
+		t.Error("Pending() = true after timeout")
+	}
+	if err := cs.HandleResponse(1, 0x1234, resp); err != ErrCounterSyncNoReq {
+		t.Errorf("HandleResponse() after timeout error = %v, want %v", err, ErrCounterSyncNoReq)
+	}
+
+	// The next control message starts a new request
+	next, err := cs.Request(1, 0x1234)
+	if err != nil || next == nil {
+		t.Fatalf("Request() after timeout = % X, %v", next, err)
+	}
+}
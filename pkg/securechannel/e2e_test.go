@@ -2,6 +2,7 @@ package securechannel
 
 import (
 	"bytes"
+	gocrypto "crypto"
 	"sync"
 	"testing"
 
@@ -298,7 +299,7 @@ func TestE2E_CASE_HappyPath(t *testing.T) {
 	})
 
 	// Create fabric lookup for responder
-	fabricLookup := func(destID [casesession.DestinationIDSize]byte, initiatorRandom [casesession.RandomSize]byte) (*fabric.FabricInfo, *crypto.P256KeyPair, error) {
+	fabricLookup := func(destID [casesession.DestinationIDSize]byte, initiatorRandom [casesession.RandomSize]byte) (*fabric.FabricInfo, gocrypto.Signer, error) {
 		ipkSlice, _ := crypto.DeriveGroupOperationalKeyV1(responderFabric.IPK[:], responderFabric.CompressedFabricID[:])
 		var ipk [crypto.SymmetricKeySize]byte
 		copy(ipk[:], ipkSlice)
@@ -476,6 +477,67 @@ func TestE2E_PASE_WrongPasscode(t *testing.T) {
 	}
 }
 
+// TestE2E_PASE_ResponderAttemptFailed tests that a responder reports a
+// PASE handshake failing its confirmation check.
+func TestE2E_PASE_ResponderAttemptFailed(t *testing.T) {
+	passcode := uint32(20202021)
+	salt := []byte("SPAKE2P Key Salt")
+	iterations := uint32(1000)
+
+	verifier, _ := pase.GenerateVerifier(passcode, salt, iterations)
+
+	controllerMgr := NewManager(ManagerConfig{
+		SessionManager: session.NewManager(session.ManagerConfig{}),
+	})
+
+	var failures []error
+	deviceMgr := NewManager(ManagerConfig{
+		SessionManager: session.NewManager(session.ManagerConfig{}),
+		Callbacks: Callbacks{
+			OnPASEAttemptFailed: func(err error) {
+				failures = append(failures, err)
+			},
+		},
+	})
+	_ = deviceMgr.SetPASEResponder(verifier, salt, iterations)
+
+	exchangeID := uint16(1)
+	pbkdfReq, err := controllerMgr.StartPASE(exchangeID, passcode)
+	if err != nil {
+		t.Fatalf("StartPASE failed: %v", err)
+	}
+	msg := &Message{OpcodePBKDFParamRequest, pbkdfReq}
+	for _, step := range []struct {
+		mgr  *Manager
+		want Opcode
+	}{
+		{deviceMgr, OpcodePBKDFParamResponse},
+		{controllerMgr, OpcodePASEPake1},
+		{deviceMgr, OpcodePASEPake2},
+		{controllerMgr, OpcodePASEPake3},
+	} {
+		msg, err = step.mgr.Route(exchangeID, msg)
+		if err != nil {
+			t.Fatalf("Route failed: %v", err)
+		}
+		if msg == nil || msg.Opcode != step.want {
+			t.Fatalf("expected %s, got %v", step.want, msg)
+		}
+	}
+
+	// Tamper with the initiator's confirmation (cA)
+	msg.Payload[len(msg.Payload)-2] ^= 0xFF
+	if _, err := deviceMgr.Route(exchangeID, msg); err == nil {
+		t.Fatal("expected Pake3 with a bad confirmation to fail")
+	}
+	if len(failures) != 1 {
+		t.Fatalf("OnPASEAttemptFailed called %d times, want 1", len(failures))
+	}
+	if deviceMgr.HasActiveHandshake(exchangeID) {
+		t.Error("failed handshake still active")
+	}
+}
+
 // TestE2E_PASE_CorruptedTLV tests handling of corrupted handshake messages.
 func TestE2E_PASE_CorruptedTLV(t *testing.T) {
 	passcode := uint32(20202021)
@@ -627,7 +689,7 @@ func TestE2E_CASE_NoSharedRoot(t *testing.T) {
 	})
 
 	// Responder fabric lookup that won't match
-	fabricLookup := func(destID [casesession.DestinationIDSize]byte, initiatorRandom [casesession.RandomSize]byte) (*fabric.FabricInfo, *crypto.P256KeyPair, error) {
+	fabricLookup := func(destID [casesession.DestinationIDSize]byte, initiatorRandom [casesession.RandomSize]byte) (*fabric.FabricInfo, gocrypto.Signer, error) {
 		// Check with responder's own root/IPK (won't match initiator's destination ID)
 		ipkSlice, _ := crypto.DeriveGroupOperationalKeyV1(responderFabric.IPK[:], responderFabric.CompressedFabricID[:])
 		var ipk [crypto.SymmetricKeySize]byte
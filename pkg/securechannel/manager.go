@@ -8,12 +8,18 @@
 package securechannel
 
 import (
+	gocrypto "crypto"
 	"errors"
+	"fmt"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/backkem/matter/pkg/clock"
 	"github.com/backkem/matter/pkg/crypto"
 	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/logger"
+	"github.com/backkem/matter/pkg/metrics"
 	casesession "github.com/backkem/matter/pkg/securechannel/case"
 	"github.com/backkem/matter/pkg/securechannel/pase"
 	"github.com/backkem/matter/pkg/session"
@@ -84,6 +90,12 @@ type Callbacks struct {
 	// The callback receives the error and the stage at which it occurred.
 	OnSessionError func(err error, stage string)
 
+	// OnPASEAttemptFailed is called, after OnSessionError, when a PASE
+	// handshake in which the Manager is the responder fails, e.g. because
+	// the initiator used a wrong passcode. A commissionee counts these to
+	// limit passcode guessing.
+	OnPASEAttemptFailed func(err error)
+
 	// OnSessionClosed is called when a peer closes a session via CloseSession.
 	// The callback receives the closed session's local ID.
 	OnSessionClosed func(localSessionID uint16)
@@ -114,6 +126,14 @@ type ManagerConfig struct {
 	// LoggerFactory is the factory for creating loggers.
 	// If nil, logging is disabled.
 	LoggerFactory logging.LoggerFactory
+
+	// Metrics receives session establishment counts.
+	// If nil, metrics are disabled.
+	Metrics metrics.Collector
+
+	// Clock times handshakes for CleanupExpiredHandshakes.
+	// If nil, the real clock is used.
+	Clock clock.Clock
 }
 
 // handshakeContext tracks an active handshake.
@@ -136,8 +156,10 @@ type paseResponderConfig struct {
 
 // Manager coordinates secure channel protocol operations.
 type Manager struct {
-	config ManagerConfig
-	log    logging.LeveledLogger
+	config  ManagerConfig
+	log     logging.LeveledLogger
+	metrics metrics.Collector
+	clock   clock.Clock
 
 	// Active handshakes keyed by exchange ID
 	handshakes map[uint16]*handshakeContext
@@ -145,6 +167,9 @@ type Manager struct {
 	// PASE responder configuration (set when commissioning window is open)
 	paseResponder *paseResponderConfig
 
+	// unsolicited handles status reports on established sessions
+	unsolicited *UnsolicitedHandler
+
 	mu sync.RWMutex
 }
 
@@ -152,8 +177,11 @@ type Manager struct {
 func NewManager(config ManagerConfig) *Manager {
 	m := &Manager{
 		config:     config,
+		metrics:    metrics.OrNop(config.Metrics),
+		clock:      clock.OrReal(config.Clock),
 		handshakes: make(map[uint16]*handshakeContext),
 	}
+	m.unsolicited = NewUnsolicitedHandler(config.SessionManager, config.Callbacks)
 
 	if config.LoggerFactory != nil {
 		m.log = config.LoggerFactory.NewLogger("securechannel")
@@ -222,6 +250,25 @@ func (m *Manager) Route(exchangeID uint16, msg *Message) (*Message, error) {
 	}
 }
 
+// HandleSessionStatusReport processes a StatusReport received on an
+// established secure session rather than during a handshake. A
+// CloseSession removes the session and is reported through
+// Callbacks.OnSessionClosed. Returns false if the report is not for the
+// secure channel to handle.
+//
+// See Matter Specification Section 4.11.1.4 (CloseSession).
+func (m *Manager) HandleSessionStatusReport(localSessionID uint16, payload []byte) (bool, error) {
+	status, err := DecodeStatusReport(payload)
+	if err != nil {
+		return false, err
+	}
+	handled := m.unsolicited.HandleStatusReport(localSessionID, status)
+	if handled && IsCloseSession(status) && m.log != nil {
+		m.log.Infof("session %d closed by peer", localSessionID)
+	}
+	return handled, nil
+}
+
 // handlePASE routes PASE protocol messages.
 func (m *Manager) handlePASE(exchangeID uint16, opcode Opcode, payload []byte) (*Message, error) {
 	resp, secureCtx, err := m.handlePASELocked(exchangeID, opcode, payload)
@@ -230,8 +277,8 @@ func (m *Manager) handlePASE(exchangeID uint16, opcode Opcode, payload []byte) (
 	}
 
 	// Notify callback outside lock to prevent deadlocks
-	if secureCtx != nil && m.config.Callbacks.OnSessionEstablished != nil {
-		m.config.Callbacks.OnSessionEstablished(secureCtx)
+	if secureCtx != nil {
+		m.sessionEstablished(secureCtx)
 	}
 
 	return resp, nil
@@ -283,6 +330,8 @@ func (m *Manager) handlePASELocked(exchangeID uint16, opcode Opcode, payload []b
 		}
 		resp, needsComplete, err := m.handlePake3(exchangeID, ctx, payload)
 		if err != nil {
+			m.cleanupHandshakeLocked(exchangeID)
+			m.sessionFailed(ctx, err, "Pake3")
 			return nil, nil, err
 		}
 		if needsComplete {
@@ -307,8 +356,8 @@ func (m *Manager) handleCASE(exchangeID uint16, opcode Opcode, payload []byte) (
 	}
 
 	// Notify callback outside lock to prevent deadlocks
-	if secureCtx != nil && m.config.Callbacks.OnSessionEstablished != nil {
-		m.config.Callbacks.OnSessionEstablished(secureCtx)
+	if secureCtx != nil {
+		m.sessionEstablished(secureCtx)
 	}
 
 	return resp, nil
@@ -338,7 +387,19 @@ func (m *Manager) handleCASELocked(exchangeID uint16, opcode Opcode, payload []b
 			return nil, nil, ErrNoActiveHandshake
 		}
 		resp, err := m.handleSigma2(ctx, opcode, payload)
-		return resp, nil, err
+		if err != nil {
+			return nil, nil, err
+		}
+		// A resumed session is complete: the initiator confirms it with
+		// a success StatusReport
+		if ctx.caseSession.State() == casesession.StateComplete {
+			secureCtx, completeErr := m.completeHandshakeLocked(exchangeID, ctx)
+			if completeErr != nil {
+				return nil, nil, completeErr
+			}
+			return NewMessage(OpcodeStatusReport, Success().Encode()), secureCtx, nil
+		}
+		return resp, nil, nil
 
 	case OpcodeCASESigma3:
 		if !exists || ctx.handshakeType != HandshakeTypeCASE || ctx.caseSession == nil {
@@ -388,8 +449,8 @@ func (m *Manager) handleStatusReport(exchangeID uint16, payload []byte) (*Messag
 			return nil, err
 		}
 		// Notify callback outside lock
-		if secureCtx != nil && m.config.Callbacks.OnSessionEstablished != nil {
-			m.config.Callbacks.OnSessionEstablished(secureCtx)
+		if secureCtx != nil {
+			m.sessionEstablished(secureCtx)
 		}
 		return nil, nil
 	}
@@ -407,11 +468,8 @@ func (m *Manager) handleStatusReport(exchangeID uint16, payload []byte) (*Messag
 	m.mu.Unlock()
 	if exists && !status.IsSuccess() {
 		m.cleanupHandshake(exchangeID)
-		if m.config.Callbacks.OnSessionError != nil {
-			m.config.Callbacks.OnSessionError(status, "StatusReport")
-		}
+		m.sessionFailed(ctx, status, "StatusReport")
 	}
-	_ = ctx // ctx used for exists check
 
 	return nil, nil
 }
@@ -445,16 +503,31 @@ func (m *Manager) sendBusyResponse(ctx *handshakeContext) (*Message, error) {
 	return NewMessage(OpcodeStatusReport, Busy(waitTimeMs).Encode()), nil
 }
 
-// cleanupHandshake removes a handshake context.
+// cleanupHandshake removes a handshake context and wipes its secrets.
 func (m *Manager) cleanupHandshake(exchangeID uint16) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	delete(m.handshakes, exchangeID)
+	m.cleanupHandshakeLocked(exchangeID)
 }
 
-// cleanupHandshakeLocked removes a handshake context. Caller must hold m.mu.
+// cleanupHandshakeLocked removes a handshake context and wipes its
+// secrets. Caller must hold m.mu.
 func (m *Manager) cleanupHandshakeLocked(exchangeID uint16) {
-	delete(m.handshakes, exchangeID)
+	if ctx, exists := m.handshakes[exchangeID]; exists {
+		ctx.zeroize()
+		delete(m.handshakes, exchangeID)
+	}
+}
+
+// zeroize wipes the secrets of the handshake. A completed handshake's
+// keys have been copied into its secure session by then.
+func (ctx *handshakeContext) zeroize() {
+	if ctx.paseSession != nil {
+		ctx.paseSession.Zeroize()
+	}
+	if ctx.caseSession != nil {
+		ctx.caseSession.Zeroize()
+	}
 }
 
 // StartPASE begins a PASE handshake as initiator.
@@ -491,7 +564,7 @@ func (m *Manager) StartPASE(exchangeID uint16, passcode uint32) ([]byte, error)
 		handshakeType:  HandshakeTypePASE,
 		paseSession:    paseSession,
 		localSessionID: localSessionID,
-		startTime:      time.Now(),
+		startTime:      m.clock.Now(),
 	}
 
 	if m.log != nil {
@@ -506,7 +579,7 @@ func (m *Manager) StartPASE(exchangeID uint16, passcode uint32) ([]byte, error)
 func (m *Manager) StartCASE(
 	exchangeID uint16,
 	fabricInfo *fabric.FabricInfo,
-	operationalKey *crypto.P256KeyPair,
+	operationalKey gocrypto.Signer,
 	targetNodeID uint64,
 	resumptionInfo *casesession.ResumptionInfo,
 ) ([]byte, error) {
@@ -548,7 +621,7 @@ func (m *Manager) StartCASE(
 		handshakeType:  HandshakeTypeCASE,
 		caseSession:    caseSession,
 		localSessionID: localSessionID,
-		startTime:      time.Now(),
+		startTime:      m.clock.Now(),
 	}
 
 	if m.log != nil {
@@ -596,7 +669,7 @@ func (m *Manager) handlePBKDFParamRequest(exchangeID uint16, payload []byte) (*M
 		m.log.Tracef("PBKDFParamResponse: localSessionID=%d, peerSessionID=%d, responseLen=%d",
 			localSessionID, paseSession.PeerSessionID(), len(pbkdfResp))
 		// Log response bytes for debugging
-		m.log.Debugf("PBKDFParamResponse bytes (hex): %x", pbkdfResp)
+		m.log.Debugf("PBKDFParamResponse bytes (hex): %x", logger.Redact(pbkdfResp))
 	}
 
 	// Store peer session ID from the request
@@ -608,7 +681,7 @@ func (m *Manager) handlePBKDFParamRequest(exchangeID uint16, payload []byte) (*M
 		paseSession:    paseSession,
 		localSessionID: localSessionID,
 		peerSessionID:  peerSessionID,
-		startTime:      time.Now(),
+		startTime:      m.clock.Now(),
 	}
 
 	return NewMessage(OpcodePBKDFParamResponse, pbkdfResp), nil
@@ -736,7 +809,7 @@ func (m *Manager) handleSigma1(exchangeID uint16, payload []byte) (*Message, err
 		handshakeType:  HandshakeTypeCASE,
 		caseSession:    caseSession,
 		localSessionID: localSessionID,
-		startTime:      time.Now(),
+		startTime:      m.clock.Now(),
 	}
 
 	// Return appropriate opcode based on resumption
@@ -754,7 +827,7 @@ func (m *Manager) handleSigma2(ctx *handshakeContext, opcode Opcode, payload []b
 		if err != nil {
 			return nil, err
 		}
-		// For resumption, no Sigma3 is sent - just wait for StatusReport
+		// For resumption, no Sigma3 is sent; the session is complete
 		return nil, nil
 	}
 
@@ -797,18 +870,14 @@ func (m *Manager) completeHandshakeLocked(exchangeID uint16, ctx *handshakeConte
 	}
 
 	if err != nil {
-		if m.config.Callbacks.OnSessionError != nil {
-			m.config.Callbacks.OnSessionError(err, "CompleteHandshake")
-		}
+		m.sessionFailed(ctx, err, "CompleteHandshake")
 		m.cleanupHandshakeLocked(exchangeID)
 		return nil, err
 	}
 
 	// Add to session manager
 	if err := m.config.SessionManager.AddSecureContext(secureCtx); err != nil {
-		if m.config.Callbacks.OnSessionError != nil {
-			m.config.Callbacks.OnSessionError(err, "AddSecureContext")
-		}
+		m.sessionFailed(ctx, err, "AddSecureContext")
 		m.cleanupHandshakeLocked(exchangeID)
 		return nil, err
 	}
@@ -817,8 +886,14 @@ func (m *Manager) completeHandshakeLocked(exchangeID uint16, ctx *handshakeConte
 	m.cleanupHandshakeLocked(exchangeID)
 
 	if m.log != nil {
-		m.log.Infof("%s session established: local=%d peer=%d",
-			ctx.handshakeType, secureCtx.LocalSessionID(), secureCtx.PeerSessionID())
+		if secureCtx.SessionType() == session.SessionTypeCASE {
+			m.log.Infof("%s session established: local=%d peer=%d fabric=%d node=0x%016X",
+				ctx.handshakeType, secureCtx.LocalSessionID(), secureCtx.PeerSessionID(),
+				secureCtx.FabricIndex(), logger.Redact(uint64(secureCtx.PeerNodeID())))
+		} else {
+			m.log.Infof("%s session established: local=%d peer=%d",
+				ctx.handshakeType, secureCtx.LocalSessionID(), secureCtx.PeerSessionID())
+		}
 	}
 
 	// Return secure context for callback notification (done outside lock by caller)
@@ -855,6 +930,11 @@ func (m *Manager) completePASESession(ctx *handshakeContext) (*session.SecureCon
 		FabricIndex:    0, // PASE sessions have no fabric initially
 		PeerNodeID:     0, // PASE sessions have unspecified node ID
 		LocalNodeID:    0, // PASE sessions have unspecified node ID
+		Clock:          m.clock,
+	}
+	config.AttestationChallenge = keys.AttestationChallenge[:]
+	if p := ctx.paseSession.PeerMRPParams(); p != nil {
+		config.Params = peerSessionParams(p.IdleRetransTimeout, p.ActiveRetransTimeout, p.ActiveThreshold)
 	}
 
 	return session.NewSecureContext(config)
@@ -883,22 +963,35 @@ func (m *Manager) completeCASESession(ctx *handshakeContext) (*session.SecureCon
 		role = session.SessionRoleResponder
 	}
 
+	// The secure context keeps its own copy of the shared secret
+	sharedSecret := ctx.caseSession.SharedSecret()
+	defer crypto.Zeroize(sharedSecret)
+
 	// Get peer info from CASE session
 	peerNodeID := ctx.caseSession.PeerNodeID()
 	fabricIndex := fabric.FabricIndex(ctx.caseSession.FabricIndex())
+	localNodeID := m.config.LocalNodeID
+	if id := ctx.caseSession.LocalNodeID(); id != 0 {
+		localNodeID = fabric.NodeID(id)
+	}
 
 	config := session.SecureContextConfig{
 		SessionType:    session.SessionTypeCASE,
 		Role:           role,
 		LocalSessionID: ctx.localSessionID,
-		PeerSessionID:  ctx.peerSessionID,
+		PeerSessionID:  ctx.caseSession.PeerSessionID(),
 		I2RKey:         keys.I2RKey[:],
 		R2IKey:         keys.R2IKey[:],
-		SharedSecret:   ctx.caseSession.SharedSecret(),
+		SharedSecret:   sharedSecret,
 		FabricIndex:    fabricIndex,
 		PeerNodeID:     fabric.NodeID(peerNodeID),
-		LocalNodeID:    m.config.LocalNodeID,
+		LocalNodeID:    localNodeID,
 		CaseAuthTags:   ctx.caseSession.PeerCATs(),
+		Clock:          m.clock,
+	}
+	config.AttestationChallenge = keys.AttestationChallenge[:]
+	if p := ctx.caseSession.PeerMRPParams(); p != nil {
+		config.Params = peerSessionParams(p.IdleRetransTimeout, p.ActiveRetransTimeout, p.ActiveThreshold)
 	}
 
 	secureCtx, err := session.NewSecureContext(config)
@@ -913,35 +1006,60 @@ func (m *Manager) completeCASESession(ctx *handshakeContext) (*session.SecureCon
 	return secureCtx, nil
 }
 
+// peerSessionParams converts the MRP parameters a peer advertised in the
+// handshake, in milliseconds, to session parameters. Parameters the peer
+// left out take their defaults.
+//
+// The session retransmits to the peer with its intervals, and its
+// ActiveThreshold decides whether the peer is active (Spec Section
+// 4.13.3.1, field 15).
+func peerSessionParams(idleMs, activeMs uint32, thresholdMs uint16) session.Params {
+	return session.Params{
+		IdleInterval:    time.Duration(idleMs) * time.Millisecond,
+		ActiveInterval:  time.Duration(activeMs) * time.Millisecond,
+		ActiveThreshold: time.Duration(thresholdMs) * time.Millisecond,
+	}.WithDefaults()
+}
+
 // createFabricLookupFunc creates a fabric lookup function for CASE responder.
 func (m *Manager) createFabricLookupFunc() casesession.FabricLookupFunc {
-	return func(destinationID [casesession.DestinationIDSize]byte, initiatorRandom [casesession.RandomSize]byte) (*fabric.FabricInfo, *crypto.P256KeyPair, error) {
+	return func(destinationID [casesession.DestinationIDSize]byte, initiatorRandom [casesession.RandomSize]byte) (*fabric.FabricInfo, gocrypto.Signer, error) {
 		if m.config.FabricTable == nil {
 			return nil, nil, errors.New("securechannel: no fabric table configured")
 		}
 
-		// Iterate fabrics and find one that matches the destination ID
+		// Find the fabric whose root, fabric ID, node ID and IPK produce
+		// the destination ID (Spec 4.14.2.4.1)
 		var matchedFabric *fabric.FabricInfo
 		_ = m.config.FabricTable.ForEach(func(info *fabric.FabricInfo) error {
-			// Compute expected destination ID for this fabric
-			// destinationID = HMAC-SHA256(key=IPK, msg=initiatorRandom || rootPubKey || fabricID || nodeID)
-			// For now, this is a placeholder - full implementation needs to derive IPK and compute
-			matchedFabric = info
-			return errors.New("stop") // Stop at first match for now
+			ipk, err := crypto.DeriveGroupOperationalKeyV1(info.IPK[:], info.CompressedFabricID[:])
+			if err != nil {
+				return nil
+			}
+			if casesession.MatchDestinationID(destinationID, initiatorRandom, info.RootPublicKey,
+				uint64(info.FabricID), uint64(info.NodeID), [crypto.SymmetricKeySize]byte(ipk)) {
+				matchedFabric = info
+				return errors.New("stop")
+			}
+			return nil
 		})
 
 		if matchedFabric == nil {
 			return nil, nil, casesession.ErrNoSharedRoot
 		}
 
-		// Return the fabric info - operational key would need to be stored/retrieved separately
-		return matchedFabric, nil, nil
+		// Without its operational key the session cannot sign Sigma2
+		key, err := m.config.FabricTable.OperationalKey(matchedFabric.FabricIndex)
+		if err != nil {
+			return nil, nil, fmt.Errorf("securechannel: operational key of fabric %d: %w", matchedFabric.FabricIndex, err)
+		}
+		return matchedFabric, key, nil
 	}
 }
 
 // createResumptionLookupFunc creates a resumption lookup function for CASE responder.
 func (m *Manager) createResumptionLookupFunc() casesession.ResumptionLookupFunc {
-	return func(resumptionID [casesession.ResumptionIDSize]byte) ([]byte, *fabric.FabricInfo, *crypto.P256KeyPair, bool) {
+	return func(resumptionID [casesession.ResumptionIDSize]byte) ([]byte, *fabric.FabricInfo, gocrypto.Signer, bool) {
 		// Look up previous session by resumption ID
 		// This requires access to stored resumption state
 		// For now, return not found
@@ -957,6 +1075,28 @@ func (m *Manager) HasActiveHandshake(exchangeID uint16) bool {
 	return exists
 }
 
+// sessionEstablished records an established session and notifies the
+// callback.
+func (m *Manager) sessionEstablished(secureCtx *session.SecureContext) {
+	m.metrics.Add(metrics.SessionsEstablished, 1,
+		metrics.L(metrics.LabelType, strings.ToLower(secureCtx.SessionType().String())))
+	if m.config.Callbacks.OnSessionEstablished != nil {
+		m.config.Callbacks.OnSessionEstablished(secureCtx)
+	}
+}
+
+// sessionFailed records a failed handshake and notifies the callbacks.
+func (m *Manager) sessionFailed(ctx *handshakeContext, err error, stage string) {
+	m.metrics.Add(metrics.SessionsFailed, 1, metrics.L(metrics.LabelType, strings.ToLower(ctx.handshakeType.String())))
+	if m.config.Callbacks.OnSessionError != nil {
+		m.config.Callbacks.OnSessionError(err, stage)
+	}
+	if ctx.handshakeType == HandshakeTypePASE && ctx.paseSession != nil &&
+		ctx.paseSession.Role() == pase.RoleResponder && m.config.Callbacks.OnPASEAttemptFailed != nil {
+		m.config.Callbacks.OnPASEAttemptFailed(err)
+	}
+}
+
 // GetHandshakeType returns the type of handshake on the exchange, if any.
 func (m *Manager) GetHandshakeType(exchangeID uint16) (HandshakeType, bool) {
 	m.mu.RLock()
@@ -968,18 +1108,30 @@ func (m *Manager) GetHandshakeType(exchangeID uint16) (HandshakeType, bool) {
 	return ctx.handshakeType, true
 }
 
+// HandshakeSessionID returns the local session ID of the session the
+// handshake on the exchange establishes, if any. Initiators running
+// several handshakes at once use it to tell their sessions apart.
+func (m *Manager) HandshakeSessionID(exchangeID uint16) (uint16, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ctx, exists := m.handshakes[exchangeID]
+	if !exists {
+		return 0, false
+	}
+	return ctx.localSessionID, true
+}
+
 // CleanupExpiredHandshakes removes handshakes that have timed out.
 func (m *Manager) CleanupExpiredHandshakes() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	now := time.Now()
+	now := m.clock.Now()
 	for exchangeID, ctx := range m.handshakes {
 		if now.Sub(ctx.startTime) > HandshakeTimeout {
+			ctx.zeroize()
 			delete(m.handshakes, exchangeID)
-			if m.config.Callbacks.OnSessionError != nil {
-				m.config.Callbacks.OnSessionError(errors.New("handshake timeout"), "Timeout")
-			}
+			m.sessionFailed(ctx, errors.New("handshake timeout"), "Timeout")
 		}
 	}
 }
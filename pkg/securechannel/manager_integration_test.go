@@ -2,8 +2,12 @@ package securechannel
 
 import (
 	"bytes"
+	gocrypto "crypto"
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/backkem/matter/pkg/clock"
 	"github.com/backkem/matter/pkg/crypto"
 	"github.com/backkem/matter/pkg/fabric"
 	"github.com/backkem/matter/pkg/message"
@@ -136,6 +140,86 @@ func TestManager_PASEHandshake_ManagerToManager(t *testing.T) {
 	}
 }
 
+// TestManager_PASEHandshake_PeerMRPParams verifies that the session takes
+// the MRP parameters the peer advertised in the handshake, and times the
+// peer's activity with them.
+func TestManager_PASEHandshake_PeerMRPParams(t *testing.T) {
+	clk := clock.NewFakeClock(time.Unix(0, 0))
+	var established *session.SecureContext
+	initiatorMgr := NewManager(ManagerConfig{
+		SessionManager: session.NewManager(session.ManagerConfig{}),
+		Clock:          clk,
+		Callbacks: Callbacks{
+			OnSessionEstablished: func(ctx *session.SecureContext) {
+				established = ctx
+			},
+		},
+	})
+
+	passcode := uint32(20202021)
+	salt := []byte("SPAKE2P Key Salt")
+	verifier, err := pase.GenerateVerifier(passcode, salt, 1000)
+	if err != nil {
+		t.Fatalf("GenerateVerifier failed: %v", err)
+	}
+	responderPASE, err := pase.NewResponder(verifier, salt, 1000)
+	if err != nil {
+		t.Fatalf("NewResponder failed: %v", err)
+	}
+	// The idle interval is left out and takes its default
+	responderPASE.SetLocalMRPParams(&pase.MRPParameters{
+		ActiveRetransTimeout: 200,
+		ActiveThreshold:      1000,
+	})
+
+	const exchangeID = uint16(1)
+	pbkdfReq, err := initiatorMgr.StartPASE(exchangeID, passcode)
+	if err != nil {
+		t.Fatalf("StartPASE failed: %v", err)
+	}
+	pbkdfResp, err := responderPASE.HandlePBKDFParamRequest(pbkdfReq, 2)
+	if err != nil {
+		t.Fatalf("HandlePBKDFParamRequest failed: %v", err)
+	}
+	pake1, err := initiatorMgr.Route(exchangeID, &Message{Opcode: OpcodePBKDFParamResponse, Payload: pbkdfResp})
+	if err != nil {
+		t.Fatalf("Route PBKDFParamResponse failed: %v", err)
+	}
+	pake2, err := responderPASE.HandlePake1(pake1.Payload)
+	if err != nil {
+		t.Fatalf("HandlePake1 failed: %v", err)
+	}
+	pake3, err := initiatorMgr.Route(exchangeID, &Message{Opcode: OpcodePASEPake2, Payload: pake2})
+	if err != nil {
+		t.Fatalf("Route Pake2 failed: %v", err)
+	}
+	if _, ok, err := responderPASE.HandlePake3(pake3.Payload); err != nil || !ok {
+		t.Fatalf("HandlePake3 = %v, %v", ok, err)
+	}
+	if _, err := initiatorMgr.Route(exchangeID, &Message{Opcode: OpcodeStatusReport, Payload: Success().Encode()}); err != nil {
+		t.Fatalf("Route StatusReport failed: %v", err)
+	}
+	if established == nil {
+		t.Fatal("session not established")
+	}
+
+	want := session.Params{
+		IdleInterval:    session.DefaultIdleInterval,
+		ActiveInterval:  200 * time.Millisecond,
+		ActiveThreshold: time.Second,
+	}
+	if got := established.GetParams(); got != want {
+		t.Errorf("GetParams() = %+v, want %+v", got, want)
+	}
+	if !established.IsPeerActive() {
+		t.Error("IsPeerActive() = false right after the handshake")
+	}
+	clk.Advance(time.Second)
+	if established.IsPeerActive() {
+		t.Error("IsPeerActive() = true after the peer's ActiveThreshold")
+	}
+}
+
 // TestManager_CASEHandshake_ManagerToManager tests a full CASE handshake
 // with two Manager instances communicating via message passing.
 func TestManager_CASEHandshake_ManagerToManager(t *testing.T) {
@@ -193,7 +277,7 @@ func TestManager_CASEHandshake_ManagerToManager(t *testing.T) {
 	})
 
 	// Create fabric lookup for responder
-	fabricLookup := func(destID [casesession.DestinationIDSize]byte, initiatorRandom [casesession.RandomSize]byte) (*fabric.FabricInfo, *crypto.P256KeyPair, error) {
+	fabricLookup := func(destID [casesession.DestinationIDSize]byte, initiatorRandom [casesession.RandomSize]byte) (*fabric.FabricInfo, gocrypto.Signer, error) {
 		ipkSlice, _ := crypto.DeriveGroupOperationalKeyV1(responderFabric.IPK[:], responderFabric.CompressedFabricID[:])
 		var ipk [crypto.SymmetricKeySize]byte
 		copy(ipk[:], ipkSlice)
@@ -279,6 +363,54 @@ func TestManager_CASEHandshake_ManagerToManager(t *testing.T) {
 	t.Log("CASE handshake completed successfully")
 }
 
+// TestManager_FabricLookup_MultipleFabrics tests that the CASE responder
+// picks the fabric a Sigma1 destination ID names, not the first installed.
+func TestManager_FabricLookup_MultipleFabrics(t *testing.T) {
+	keystore, err := fabric.NewKeystore(fabric.KeystoreConfig{})
+	if err != nil {
+		t.Fatalf("NewKeystore failed: %v", err)
+	}
+	table := fabric.NewTable(fabric.TableConfig{Keystore: keystore})
+	var fabrics []*fabric.FabricInfo
+	for i := range 3 {
+		info, _ := createTestFabricInfo(t, uint8(i+1), uint64(0x1000+i), 0x2222)
+		info.KeyHandle, _, err = keystore.GenerateKey()
+		if err != nil {
+			t.Fatalf("GenerateKey failed: %v", err)
+		}
+		if err := table.Add(info); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		fabrics = append(fabrics, info)
+	}
+	lookup := NewManager(ManagerConfig{FabricTable: table}).createFabricLookupFunc()
+
+	var initiatorRandom [casesession.RandomSize]byte
+	initiatorRandom[0] = 0x42
+	for _, want := range fabrics {
+		destID, err := casesession.GenerateDestinationIDFromEpochKey(initiatorRandom, want.RootPublicKey,
+			uint64(want.FabricID), uint64(want.NodeID), want.IPK, want.CompressedFabricID)
+		if err != nil {
+			t.Fatalf("GenerateDestinationIDFromEpochKey failed: %v", err)
+		}
+		got, key, err := lookup(destID, initiatorRandom)
+		if err != nil {
+			t.Fatalf("lookup for fabric %d: %v", want.FabricIndex, err)
+		}
+		if got.FabricIndex != want.FabricIndex || key == nil {
+			t.Errorf("lookup = fabric %d, want %d", got.FabricIndex, want.FabricIndex)
+		}
+	}
+
+	// A destination ID for another node matches no fabric
+	other := fabrics[0]
+	destID, _ := casesession.GenerateDestinationIDFromEpochKey(initiatorRandom, other.RootPublicKey,
+		uint64(other.FabricID), 0x3333, other.IPK, other.CompressedFabricID)
+	if _, _, err := lookup(destID, initiatorRandom); !errors.Is(err, casesession.ErrNoSharedRoot) {
+		t.Errorf("lookup for unknown node error = %v, want ErrNoSharedRoot", err)
+	}
+}
+
 // TestManager_BusyResponse tests that Busy status is properly handled.
 func TestManager_BusyResponse(t *testing.T) {
 	sessionMgr := session.NewManager(session.ManagerConfig{})
@@ -3,6 +3,7 @@ package securechannel
 import (
 	"testing"
 
+	"github.com/backkem/matter/pkg/metrics"
 	"github.com/backkem/matter/pkg/securechannel/pase"
 	"github.com/backkem/matter/pkg/session"
 )
@@ -418,8 +419,10 @@ func TestPASEHandshake(t *testing.T) {
 	// Create responder manager (device)
 	responderSessionMgr := session.NewManager(session.ManagerConfig{})
 	var responderSessionEstablished bool
+	responderMetrics := metrics.NewRecorder()
 	responderMgr := NewManager(ManagerConfig{
 		SessionManager: responderSessionMgr,
+		Metrics:        responderMetrics,
 		Callbacks: Callbacks{
 			OnSessionEstablished: func(ctx *session.SecureContext) {
 				responderSessionEstablished = true
@@ -485,6 +488,9 @@ func TestPASEHandshake(t *testing.T) {
 	if !responderSessionEstablished {
 		t.Error("Responder session should be established after Pake3")
 	}
+	if got := responderMetrics.Value(metrics.SessionsEstablished, metrics.L(metrics.LabelType, "pase")); got != 1 {
+		t.Errorf("pase sessions established = %v, want 1", got)
+	}
 
 	// Step 7: Initiator handles StatusReport -> complete
 	_, err = initiatorMgr.Route(exchangeID, statusReport)
@@ -543,3 +549,51 @@ func TestPASEHandshake(t *testing.T) {
 
 	t.Log("PASE handshake completed successfully!")
 }
+
+func TestHandleSessionStatusReport(t *testing.T) {
+	sessionMgr := session.NewManager(session.ManagerConfig{})
+	ctx, err := session.NewSecureContext(session.SecureContextConfig{
+		SessionType:    session.SessionTypeCASE,
+		Role:           session.SessionRoleResponder,
+		LocalSessionID: 7,
+		PeerSessionID:  8,
+		I2RKey:         make([]byte, 16),
+		R2IKey:         make([]byte, 16),
+	})
+	if err != nil {
+		t.Fatalf("failed to create secure context: %v", err)
+	}
+	if err := sessionMgr.AddSecureContext(ctx); err != nil {
+		t.Fatalf("failed to add secure context: %v", err)
+	}
+
+	var closed []uint16
+	mgr := NewManager(ManagerConfig{
+		SessionManager: sessionMgr,
+		Callbacks: Callbacks{
+			OnSessionClosed: func(localSessionID uint16) {
+				closed = append(closed, localSessionID)
+			},
+		},
+	})
+
+	// Other status reports on a session are left to the exchange
+	handled, err := mgr.HandleSessionStatusReport(7, Success().Encode())
+	if err != nil || handled {
+		t.Errorf("HandleSessionStatusReport(Success) = %v, %v, want false, nil", handled, err)
+	}
+	if _, err := mgr.HandleSessionStatusReport(7, []byte{0x01}); err == nil {
+		t.Error("HandleSessionStatusReport(truncated) succeeded, want error")
+	}
+
+	handled, err = mgr.HandleSessionStatusReport(7, SendCloseSession())
+	if err != nil || !handled {
+		t.Fatalf("HandleSessionStatusReport(CloseSession) = %v, %v, want true, nil", handled, err)
+	}
+	if sessionMgr.FindSecureContext(7) != nil {
+		t.Error("session should be removed after CloseSession")
+	}
+	if len(closed) != 1 || closed[0] != 7 {
+		t.Errorf("OnSessionClosed calls = %v, want [7]", closed)
+	}
+}
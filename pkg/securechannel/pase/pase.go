@@ -84,6 +84,7 @@ var (
 	ErrInvalidPasscode     = errors.New("pase: invalid passcode")
 	ErrInvalidSalt         = errors.New("pase: invalid salt length")
 	ErrInvalidIterations   = errors.New("pase: invalid iteration count")
+	ErrInvalidVerifier     = errors.New("pase: invalid verifier")
 	ErrInvalidPasscodeID   = errors.New("pase: invalid passcode ID")
 	ErrInvalidRandom       = errors.New("pase: invalid random value")
 	ErrRandomMismatch      = errors.New("pase: initiator random mismatch")
@@ -0,0 +1,183 @@
+package pase
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"strings"
+
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// TLV context tags for a VerifierRecord. Iterations and salt take the tags
+// of the PBKDF parameters of a PBKDFParamResponse.
+const (
+	tagRecordIterations = 1
+	tagRecordSalt       = 2
+	tagRecordVerifier   = 3
+)
+
+// VerifierRecord holds everything a device needs to act as PASE responder
+// without its passcode: the verifier and the PBKDF parameters it was
+// derived with. A factory generates records at manufacturing time, e.g.
+// with the matter-spake2p tool, and provisions one onto each device.
+//
+// Encoded, a record is an anonymous TLV structure:
+//
+//	{
+//	  1: iterations (unsigned integer)
+//	  2: salt (octet string, 16-32 bytes)
+//	  3: verifier (octet string, W0 || L, 97 bytes)
+//	}
+type VerifierRecord struct {
+	Verifier   *Verifier
+	Salt       []byte
+	Iterations uint32
+}
+
+// NewVerifierRecord derives the verifier of a passcode and returns it
+// with its PBKDF parameters.
+func NewVerifierRecord(passcode uint32, salt []byte, iterations uint32) (*VerifierRecord, error) {
+	verifier, err := GenerateVerifier(passcode, salt, iterations)
+	if err != nil {
+		return nil, err
+	}
+	return &VerifierRecord{
+		Verifier:   verifier,
+		Salt:       copyBytes(salt),
+		Iterations: iterations,
+	}, nil
+}
+
+// Validate checks the verifier and the PBKDF parameters of the record.
+func (r *VerifierRecord) Validate() error {
+	if r.Verifier == nil {
+		return ErrInvalidVerifier
+	}
+	if err := r.Verifier.Validate(); err != nil {
+		return err
+	}
+	return validatePBKDFParams(r.Salt, r.Iterations)
+}
+
+// MarshalBinary encodes the record as TLV.
+func (r *VerifierRecord) MarshalBinary() ([]byte, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w := tlv.NewWriter(&buf)
+	if err := w.StartStructure(tlv.Anonymous()); err != nil {
+		return nil, err
+	}
+	if err := w.PutUint(tlv.ContextTag(tagRecordIterations), uint64(r.Iterations)); err != nil {
+		return nil, err
+	}
+	if err := w.PutBytes(tlv.ContextTag(tagRecordSalt), r.Salt); err != nil {
+		return nil, err
+	}
+	if err := w.PutBytes(tlv.ContextTag(tagRecordVerifier), r.Verifier.Serialize()); err != nil {
+		return nil, err
+	}
+	if err := w.EndContainer(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a TLV encoded record and validates it.
+func (r *VerifierRecord) UnmarshalBinary(data []byte) error {
+	tr := tlv.NewReader(bytes.NewReader(data))
+	if err := tr.Next(); err != nil {
+		return ErrInvalidVerifier
+	}
+	if tr.Type() != tlv.ElementTypeStruct {
+		return ErrInvalidVerifier
+	}
+	if err := tr.EnterContainer(); err != nil {
+		return ErrInvalidVerifier
+	}
+
+	var record VerifierRecord
+	for {
+		err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return ErrInvalidVerifier
+		}
+		if tr.Type() == tlv.ElementTypeEnd {
+			break
+		}
+
+		tag := tr.Tag()
+		if !tag.IsContext() {
+			continue
+		}
+		switch tag.TagNumber() {
+		case tagRecordIterations:
+			v, err := tr.Uint()
+			if err != nil || v > uint64(PBKDFMaxIterations) {
+				return ErrInvalidIterations
+			}
+			record.Iterations = uint32(v)
+		case tagRecordSalt:
+			salt, err := tr.Bytes()
+			if err != nil {
+				return ErrInvalidSalt
+			}
+			record.Salt = salt
+		case tagRecordVerifier:
+			data, err := tr.Bytes()
+			if err != nil {
+				return ErrInvalidVerifier
+			}
+			if record.Verifier, err = DeserializeVerifier(data); err != nil {
+				return ErrInvalidVerifier
+			}
+		}
+	}
+
+	if err := record.Validate(); err != nil {
+		return err
+	}
+	*r = record
+	return nil
+}
+
+// String returns the encoded record in base64, the form in which the
+// matter-spake2p tool prints records. It is empty for an invalid record.
+func (r *VerifierRecord) String() string {
+	text, _ := r.MarshalText()
+	return string(text)
+}
+
+// MarshalText returns the encoded record in base64.
+func (r *VerifierRecord) MarshalText() ([]byte, error) {
+	data, err := r.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(base64.StdEncoding.EncodeToString(data)), nil
+}
+
+// UnmarshalText parses a base64 encoded record and validates it.
+func (r *VerifierRecord) UnmarshalText(text []byte) error {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(text)))
+	if err != nil {
+		return ErrInvalidVerifier
+	}
+	return r.UnmarshalBinary(data)
+}
+
+// ParseVerifierRecord parses a base64 encoded record, as printed by
+// String.
+func ParseVerifierRecord(s string) (*VerifierRecord, error) {
+	r := &VerifierRecord{}
+	if err := r.UnmarshalText([]byte(s)); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
@@ -0,0 +1,132 @@
+package pase
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+func TestVerifierRecordRoundtrip(t *testing.T) {
+	record, err := NewVerifierRecord(testSpake2p01PinCode, testSpake2p01Salt, testSpake2p01IterationCount)
+	if err != nil {
+		t.Fatalf("NewVerifierRecord() error = %v", err)
+	}
+
+	data, err := record.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+	var decoded VerifierRecord
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary() error = %v", err)
+	}
+	if decoded.Iterations != testSpake2p01IterationCount ||
+		!bytes.Equal(decoded.Salt, testSpake2p01Salt) ||
+		!bytes.Equal(decoded.Verifier.W0, testSpake2p01W0) ||
+		!bytes.Equal(decoded.Verifier.L, testSpake2p01L) {
+		t.Errorf("UnmarshalBinary() = %+v", decoded)
+	}
+
+	parsed, err := ParseVerifierRecord(record.String() + "\n")
+	if err != nil {
+		t.Fatalf("ParseVerifierRecord() error = %v", err)
+	}
+	if !bytes.Equal(parsed.Verifier.Serialize(), record.Verifier.Serialize()) {
+		t.Error("ParseVerifierRecord() does not match the record")
+	}
+
+	// The responder derives the same keys from the record as from the passcode
+	initiator, _ := NewInitiator(testSpake2p01PinCode)
+	responder, _ := NewResponder(parsed.Verifier, parsed.Salt, parsed.Iterations)
+	req, _ := initiator.Start(1)
+	resp, err := responder.HandlePBKDFParamRequest(req, 2)
+	if err != nil {
+		t.Fatalf("HandlePBKDFParamRequest() error = %v", err)
+	}
+	pake1, _ := initiator.HandlePBKDFParamResponse(resp)
+	pake2, _ := responder.HandlePake1(pake1)
+	pake3, err := initiator.HandlePake2(pake2)
+	if err != nil {
+		t.Fatalf("HandlePake2() error = %v", err)
+	}
+	if _, ok, err := responder.HandlePake3(pake3); !ok || err != nil {
+		t.Errorf("HandlePake3() = %v, %v", ok, err)
+	}
+}
+
+func TestVerifierMarshalText(t *testing.T) {
+	verifier, err := GenerateVerifier(testSpake2p01PinCode, testSpake2p01Salt, testSpake2p01IterationCount)
+	if err != nil {
+		t.Fatalf("GenerateVerifier() error = %v", err)
+	}
+
+	// A verifier can be kept in a JSON provisioning file
+	data, err := json.Marshal(map[string]*Verifier{"verifier": verifier})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var decoded map[string]*Verifier
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if !bytes.Equal(decoded["verifier"].Serialize(), verifier.Serialize()) {
+		t.Error("verifier changed in a JSON round trip")
+	}
+
+	bin, err := verifier.MarshalBinary()
+	if err != nil || !bytes.Equal(bin, verifier.Serialize()) {
+		t.Errorf("MarshalBinary() = % X, %v", bin, err)
+	}
+	var v Verifier
+	if err := v.UnmarshalBinary(bin[:96]); err != ErrInvalidMessage {
+		t.Errorf("UnmarshalBinary(short) error = %v, want %v", err, ErrInvalidMessage)
+	}
+}
+
+func TestVerifierRecordInvalid(t *testing.T) {
+	record, err := NewVerifierRecord(testSpake2p01PinCode, testSpake2p01Salt, testSpake2p01IterationCount)
+	if err != nil {
+		t.Fatalf("NewVerifierRecord() error = %v", err)
+	}
+
+	encode := func(iterations uint64, salt, verifier []byte) []byte {
+		var buf bytes.Buffer
+		w := tlv.NewWriter(&buf)
+		w.StartStructure(tlv.Anonymous())
+		w.PutUint(tlv.ContextTag(tagRecordIterations), iterations)
+		w.PutBytes(tlv.ContextTag(tagRecordSalt), salt)
+		if verifier != nil {
+			w.PutBytes(tlv.ContextTag(tagRecordVerifier), verifier)
+		}
+		w.EndContainer()
+		return buf.Bytes()
+	}
+	serialized := record.Verifier.Serialize()
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"not TLV", []byte{0xFF}, ErrInvalidVerifier},
+		{"missing verifier", encode(1000, testSpake2p01Salt, nil), ErrInvalidVerifier},
+		{"short salt", encode(1000, make([]byte, 8), serialized), ErrInvalidSalt},
+		{"too few iterations", encode(10, testSpake2p01Salt, serialized), ErrInvalidIterations},
+		{"iterations overflow", encode(1<<32, testSpake2p01Salt, serialized), ErrInvalidIterations},
+		{"invalid verifier", encode(1000, testSpake2p01Salt, make([]byte, 97)), ErrInvalidVerifier},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r VerifierRecord
+			if err := r.UnmarshalBinary(tt.data); err != tt.want {
+				t.Errorf("UnmarshalBinary() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := (&VerifierRecord{}).MarshalBinary(); err != ErrInvalidVerifier {
+		t.Errorf("MarshalBinary(empty) error = %v, want %v", err, ErrInvalidVerifier)
+	}
+}
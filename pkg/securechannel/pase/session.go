@@ -9,6 +9,7 @@ import (
 
 	"github.com/backkem/matter/pkg/crypto"
 	"github.com/backkem/matter/pkg/crypto/spake2p"
+	"github.com/backkem/matter/pkg/logger"
 	"github.com/pion/logging"
 )
 
@@ -274,7 +275,7 @@ func (s *Session) HandlePBKDFParamRequest(data []byte, localSessionID uint16) ([
 
 	// Debug: Log the initiator's random that we'll echo back
 	if s.log != nil {
-		s.log.Debugf("Stored InitiatorRandom: %x", req.InitiatorRandom[:])
+		s.log.Debugf("Stored InitiatorRandom: %x", logger.Redact(req.InitiatorRandom[:]))
 	}
 
 	// Generate our random
@@ -371,8 +372,9 @@ func (s *Session) HandlePBKDFParamResponse(data []byte) ([]byte, error) {
 		return nil, err
 	}
 
-	// Setup SPAKE2+ as prover
+	// Setup SPAKE2+ as prover, which keeps its own copy of w0 and w1
 	s.spake, err = spake2p.NewProver(s.commissioningHash, nil, nil, w0, w1)
+	crypto.Zeroize(w0, w1)
 	if err != nil {
 		return nil, err
 	}
@@ -574,6 +576,7 @@ func (s *Session) deriveSessionKeys() error {
 	if len(ke) == 0 {
 		return ErrSessionNotReady
 	}
+	defer crypto.Zeroize(ke)
 
 	// Derive session keys
 	info := []byte("SessionKeys")
@@ -586,10 +589,32 @@ func (s *Session) deriveSessionKeys() error {
 	copy(s.sessionKeys.I2RKey[:], seKeys[0:16])
 	copy(s.sessionKeys.R2IKey[:], seKeys[16:32])
 	copy(s.sessionKeys.AttestationChallenge[:], seKeys[32:48])
+	crypto.Zeroize(seKeys)
+
+	// The SPAKE2+ intermediates are no longer needed
+	s.spake.Zeroize()
 
 	return nil
 }
 
+// Zeroize wipes the passcode, the SPAKE2+ state and the session keys.
+// Call it once the session keys have been copied into a secure session,
+// or when the handshake fails. Keys returned by SessionKeys are wiped too.
+// The responder's verifier is left intact for later commissioning attempts.
+func (s *Session) Zeroize() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.passcode = 0
+	if s.spake != nil {
+		s.spake.Zeroize()
+	}
+	if s.sessionKeys != nil {
+		crypto.Zeroize(s.sessionKeys.I2RKey[:], s.sessionKeys.R2IKey[:], s.sessionKeys.AttestationChallenge[:])
+		s.sessionKeys = nil
+	}
+}
+
 // State returns the current protocol state.
 func (s *Session) State() State {
 	s.mu.Lock()
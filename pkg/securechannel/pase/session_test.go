@@ -133,6 +133,19 @@ func TestPASEHandshakeSuccess(t *testing.T) {
 	if responder.PeerSessionID() != 1000 {
 		t.Errorf("Expected responder peer session ID 1000, got %d", responder.PeerSessionID())
 	}
+
+	// Zeroize wipes the keys and the passcode, but not the verifier
+	initiator.Zeroize()
+	responder.Zeroize()
+	if initiatorKeys.I2RKey != [16]byte{} || responderKeys.R2IKey != [16]byte{} {
+		t.Error("session keys not wiped by Zeroize")
+	}
+	if initiator.SessionKeys() != nil || initiator.passcode != 0 {
+		t.Error("initiator secrets left after Zeroize")
+	}
+	if bytes.Equal(verifier.W0, make([]byte, len(verifier.W0))) {
+		t.Error("Zeroize wiped the responder's verifier")
+	}
 }
 
 func TestPASEWrongPasscode(t *testing.T) {
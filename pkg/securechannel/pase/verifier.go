@@ -2,8 +2,10 @@ package pase
 
 import (
 	"crypto/elliptic"
+	"encoding/base64"
 	"encoding/binary"
 	"math/big"
+	"strings"
 
 	"github.com/backkem/matter/pkg/crypto"
 	"github.com/backkem/matter/pkg/crypto/spake2p"
@@ -168,7 +170,9 @@ func (v *Verifier) Serialize() []byte {
 	return result
 }
 
-// DeserializeVerifier parses a serialized verifier.
+// DeserializeVerifier parses a serialized verifier, e.g. the
+// PAKEPasscodeVerifier of an OpenCommissioningWindow command. It rejects
+// a W0 outside the curve order and an L that is not a point on the curve.
 func DeserializeVerifier(data []byte) (*Verifier, error) {
 	expected := spake2p.GroupSizeBytes + spake2p.PointSizeBytes
 	if len(data) != expected {
@@ -182,5 +186,86 @@ func DeserializeVerifier(data []byte) (*Verifier, error) {
 	copy(v.W0, data[:spake2p.GroupSizeBytes])
 	copy(v.L, data[spake2p.GroupSizeBytes:])
 
+	if err := v.Validate(); err != nil {
+		return nil, err
+	}
 	return v, nil
 }
+
+// Validate checks that W0 is a scalar in [1, n) and L an uncompressed
+// point on P-256, so that a verifier provided from outside, without the
+// passcode it was derived from, can be used for PASE.
+func (v *Verifier) Validate() error {
+	if len(v.W0) != spake2p.GroupSizeBytes || len(v.L) != spake2p.PointSizeBytes {
+		return ErrInvalidVerifier
+	}
+	w0 := new(big.Int).SetBytes(v.W0)
+	if w0.Sign() == 0 || w0.Cmp(p256.Params().N) >= 0 {
+		return ErrInvalidVerifier
+	}
+	if v.L[0] != 0x04 {
+		return ErrInvalidVerifier
+	}
+	x := new(big.Int).SetBytes(v.L[1:33])
+	y := new(big.Int).SetBytes(v.L[33:65])
+	if !p256.IsOnCurve(x, y) {
+		return ErrInvalidVerifier
+	}
+	return nil
+}
+
+// String returns the serialized verifier in base64, the form in which
+// tools such as the SDK's spake2p utility print verifiers for
+// provisioning.
+func (v *Verifier) String() string {
+	return base64.StdEncoding.EncodeToString(v.Serialize())
+}
+
+// ParseVerifier parses a base64 serialized verifier, as printed by
+// String.
+func ParseVerifier(s string) (*Verifier, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, ErrInvalidVerifier
+	}
+	return DeserializeVerifier(data)
+}
+
+// MarshalBinary returns the verifier in the standard W0 || L format, as
+// Serialize does.
+func (v *Verifier) MarshalBinary() ([]byte, error) {
+	if err := v.Validate(); err != nil {
+		return nil, err
+	}
+	return v.Serialize(), nil
+}
+
+// UnmarshalBinary parses a verifier in the W0 || L format and validates
+// it, as DeserializeVerifier does.
+func (v *Verifier) UnmarshalBinary(data []byte) error {
+	parsed, err := DeserializeVerifier(data)
+	if err != nil {
+		return err
+	}
+	*v = *parsed
+	return nil
+}
+
+// MarshalText returns the verifier in base64, as String does, so that a
+// verifier can be kept in a JSON or YAML provisioning file.
+func (v *Verifier) MarshalText() ([]byte, error) {
+	if err := v.Validate(); err != nil {
+		return nil, err
+	}
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText parses a base64 verifier, as ParseVerifier does.
+func (v *Verifier) UnmarshalText(text []byte) error {
+	parsed, err := ParseVerifier(string(text))
+	if err != nil {
+		return err
+	}
+	*v = *parsed
+	return nil
+}
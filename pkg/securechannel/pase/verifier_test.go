@@ -215,3 +215,61 @@ func TestDeserializeVerifierInvalidLength(t *testing.T) {
 		t.Error("Expected error for too-long verifier")
 	}
 }
+
+func TestVerifierString(t *testing.T) {
+	// The SDK's default verifier for passcode 20202021 (CHIP_DEVICE_CONFIG_USE_TEST_SPAKE2P_VERIFIER)
+	const want = "uWFwqugDNGiEck/po7KHwwMwwqZgN10XuyBajPGuyzUEV/iree4lOrao5GuwnlQ65CJzbeUB49s31EH+NEkg0JVI5MGCQGMMT/SRPFNRODm3wH/MBiehuFc6FJ/NH6Rmzw=="
+
+	verifier, err := GenerateVerifier(testSpake2p01PinCode, testSpake2p01Salt, testSpake2p01IterationCount)
+	if err != nil {
+		t.Fatalf("GenerateVerifier failed: %v", err)
+	}
+	if got := verifier.String(); got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+
+	parsed, err := ParseVerifier(want + "\n")
+	if err != nil {
+		t.Fatalf("ParseVerifier failed: %v", err)
+	}
+	if !bytes.Equal(parsed.W0, verifier.W0) || !bytes.Equal(parsed.L, verifier.L) {
+		t.Error("ParseVerifier() does not match the generated verifier")
+	}
+
+	if _, err := ParseVerifier("not base64!"); err != ErrInvalidVerifier {
+		t.Errorf("ParseVerifier(invalid) error = %v, want %v", err, ErrInvalidVerifier)
+	}
+}
+
+func TestVerifierValidate(t *testing.T) {
+	verifier, err := GenerateVerifier(testSpake2p01PinCode, testSpake2p01Salt, testSpake2p01IterationCount)
+	if err != nil {
+		t.Fatalf("GenerateVerifier failed: %v", err)
+	}
+	if err := verifier.Validate(); err != nil {
+		t.Errorf("Validate() = %v for a generated verifier", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(data []byte)
+	}{
+		{"zero W0", func(data []byte) { clear(data[:32]) }},
+		{"W0 above the curve order", func(data []byte) {
+			for i := range data[:32] {
+				data[i] = 0xFF
+			}
+		}},
+		{"compressed L", func(data []byte) { data[32] = 0x02 }},
+		{"L off the curve", func(data []byte) { data[len(data)-1] ^= 0x01 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := verifier.Serialize()
+			tt.mutate(data)
+			if _, err := DeserializeVerifier(data); err != ErrInvalidVerifier {
+				t.Errorf("DeserializeVerifier() error = %v, want %v", err, ErrInvalidVerifier)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+// Package standalone establishes secure sessions without a matter.Node.
+//
+// Test tools and minimal controllers that only need a session with one
+// peer call EstablishPASE or EstablishCASE, which create the transport,
+// session, exchange and secure channel managers internally and return the
+// session once the handshake completes:
+//
+//	sess, err := standalone.EstablishPASE(ctx, nil, peerAddr, 20202021)
+//	if err != nil {
+//	    return err
+//	}
+//	defer sess.Close()
+//	exch, _ := sess.NewExchange(im.ProtocolID, delegate)
+//
+// The API lives outside package securechannel because the exchange layer
+// it runs on imports securechannel.
+package standalone
+
+import (
+	"context"
+	gocrypto "crypto"
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/backkem/matter/pkg/commissioning"
+	"github.com/backkem/matter/pkg/exchange"
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/message"
+	"github.com/backkem/matter/pkg/securechannel"
+	"github.com/backkem/matter/pkg/session"
+	"github.com/backkem/matter/pkg/transport"
+)
+
+// ErrSessionClosed is returned when using a Session after Close.
+var ErrSessionClosed = errors.New("standalone: session closed")
+
+// Session is a secure session with one peer, together with the stack that
+// carries its messages.
+type Session struct {
+	// SecureContext is the established session.
+	SecureContext *session.SecureContext
+
+	// PeerAddress is the address of the peer.
+	PeerAddress transport.PeerAddress
+
+	// ExchangeManager opens exchanges on the session.
+	ExchangeManager *exchange.Manager
+
+	// SessionManager holds the session.
+	SessionManager *session.Manager
+
+	stack  *stack
+	mu     sync.Mutex
+	closed bool
+}
+
+// EstablishPASE performs a PASE handshake with a commissionee at peerAddr
+// and returns the session.
+//
+// The stack sends through connections created by factory, as with
+// matter.NodeConfig.TransportFactory. If factory is nil, it uses a UDP
+// socket on an ephemeral port. If ctx has no deadline, the handshake
+// times out after commissioning.DefaultPASETimeout.
+func EstablishPASE(ctx context.Context, factory transport.Factory, peerAddr transport.PeerAddress, passcode uint32) (*Session, error) {
+	s, err := newStack(factory)
+	if err != nil {
+		return nil, err
+	}
+
+	client := commissioning.NewPASEClient(commissioning.PASEClientConfig{
+		ExchangeManager: s.exchangeMgr,
+		SecureChannel:   s.scMgr,
+		SessionManager:  s.sessionMgr,
+	})
+	secureCtx, err := client.Establish(ctx, peerAddr, passcode)
+	if err != nil {
+		s.stop()
+		return nil, err
+	}
+	return s.session(secureCtx, peerAddr), nil
+}
+
+// EstablishCASE performs a CASE handshake with the node peerNodeID of the
+// fabric described by fabricInfo, at peerAddr, and returns the session.
+// operationalKey is our operational key on the fabric. The peer's
+// certificate chain is validated against the fabric's root certificate.
+//
+// factory and ctx are used as by EstablishPASE; without a deadline, the
+// handshake times out after commissioning.DefaultCASETimeout.
+func EstablishCASE(
+	ctx context.Context,
+	factory transport.Factory,
+	peerAddr transport.PeerAddress,
+	fabricInfo *fabric.FabricInfo,
+	operationalKey gocrypto.Signer,
+	peerNodeID fabric.NodeID,
+) (*Session, error) {
+	s, err := newStack(factory)
+	if err != nil {
+		return nil, err
+	}
+
+	client := commissioning.NewCASEClient(commissioning.CASEClientConfig{
+		ExchangeManager: s.exchangeMgr,
+		SecureChannel:   s.scMgr,
+		SessionManager:  s.sessionMgr,
+	})
+	secureCtx, err := client.Establish(ctx, peerAddr, fabricInfo, operationalKey, peerNodeID, nil)
+	if err != nil {
+		s.stop()
+		return nil, err
+	}
+	return s.session(secureCtx, peerAddr), nil
+}
+
+// NewExchange opens an exchange with the peer on the session.
+func (s *Session) NewExchange(protocolID message.ProtocolID, delegate exchange.ExchangeDelegate) (*exchange.ExchangeContext, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, ErrSessionClosed
+	}
+	return s.ExchangeManager.NewExchange(s.SecureContext, s.SecureContext.LocalSessionID(), s.PeerAddress, protocolID, delegate)
+}
+
+// Close tells the peer the session is closed, with a CloseSession status
+// report, and stops the stack. It returns the error of sending the status
+// report, if any; the stack stops regardless.
+func (s *Session) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	err := s.sendCloseSession()
+	s.SessionManager.RemoveSecureContext(s.SecureContext.LocalSessionID())
+	s.stack.stop()
+	return err
+}
+
+// sendCloseSession sends a CloseSession status report to the peer.
+func (s *Session) sendCloseSession() error {
+	exch, err := s.ExchangeManager.NewExchange(s.SecureContext, s.SecureContext.LocalSessionID(), s.PeerAddress, message.ProtocolSecureChannel, nil)
+	if err != nil {
+		return err
+	}
+	defer exch.Close()
+	return exch.SendMessage(uint8(securechannel.OpcodeStatusReport), securechannel.SendCloseSession(), false)
+}
+
+// stack is the transport, session, exchange and secure channel managers
+// of a Session.
+type stack struct {
+	transportMgr *transport.Manager
+	sessionMgr   *session.Manager
+	exchangeMgr  *exchange.Manager
+	scMgr        *securechannel.Manager
+}
+
+// newStack creates and starts a stack.
+func newStack(factory transport.Factory) (*stack, error) {
+	s := &stack{sessionMgr: session.NewManager(session.ManagerConfig{})}
+
+	config := transport.ManagerConfig{
+		UDPEnabled: true,
+		MessageHandler: func(msg *transport.ReceivedMessage) {
+			s.exchangeMgr.OnMessageReceived(msg)
+		},
+	}
+	if factory != nil {
+		udpConn, err := factory.CreateUDPConn(0)
+		if err != nil {
+			return nil, err
+		}
+		config.UDPConn = udpConn
+		if config.TCPListener, err = factory.CreateTCPListener(0); err != nil {
+			udpConn.Close()
+			return nil, err
+		}
+		config.TCPEnabled = config.TCPListener != nil
+		if dialer, ok := factory.(transport.StreamDialer); ok {
+			config.TCPDial = dialer.DialStream
+		}
+	} else {
+		udpConn, err := net.ListenPacket("udp", ":0")
+		if err != nil {
+			return nil, err
+		}
+		config.UDPConn = udpConn
+	}
+
+	var err error
+	if s.transportMgr, err = transport.NewManager(config); err != nil {
+		config.UDPConn.Close()
+		if config.TCPListener != nil {
+			config.TCPListener.Close()
+		}
+		return nil, err
+	}
+	s.exchangeMgr = exchange.NewManager(exchange.ManagerConfig{
+		SessionManager:   s.sessionMgr,
+		TransportManager: s.transportMgr,
+	})
+	s.scMgr = securechannel.NewManager(securechannel.ManagerConfig{
+		SessionManager: s.sessionMgr,
+		CertValidator:  securechannel.NewCertValidator(),
+	})
+	if err := s.transportMgr.Start(); err != nil {
+		s.stop()
+		return nil, err
+	}
+	return s, nil
+}
+
+// session returns the Session of an established secure context.
+func (s *stack) session(secureCtx *session.SecureContext, peerAddr transport.PeerAddress) *Session {
+	return &Session{
+		SecureContext:   secureCtx,
+		PeerAddress:     peerAddr,
+		ExchangeManager: s.exchangeMgr,
+		SessionManager:  s.sessionMgr,
+		stack:           s,
+	}
+}
+
+// stop closes the exchanges and the transport.
+func (s *stack) stop() {
+	s.exchangeMgr.Close()
+	s.transportMgr.Stop()
+}
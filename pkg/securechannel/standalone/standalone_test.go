@@ -0,0 +1,160 @@
+package standalone
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/backkem/matter/pkg/commissioning"
+	"github.com/backkem/matter/pkg/crypto"
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/matter"
+	"github.com/backkem/matter/pkg/session"
+	"github.com/backkem/matter/pkg/transport"
+)
+
+// newCommissionee starts an uncommissioned device, whose commissioning
+// window opens on start, and returns a factory on its network.
+func newCommissionee(t *testing.T) (*matter.Node, transport.Factory, transport.PeerAddress) {
+	t.Helper()
+	return newDevice(t, nil, nil)
+}
+
+// newDevice starts a device on a pipe network, after setup, and returns
+// the factory of another endpoint on the network. The network keeps the
+// UDP and TCP traffic of each endpoint apart, unlike the two ends of a
+// pipe.
+func newDevice(t *testing.T, keystore fabric.OperationalKeystore, setup func(*matter.Node)) (*matter.Node, transport.Factory, transport.PeerAddress) {
+	t.Helper()
+	network := transport.NewPipeNetwork()
+	t.Cleanup(func() { network.Close() })
+	local, remote := network.NewFactory(), network.NewFactory()
+	device, err := matter.NewNode(matter.NodeConfig{
+		VendorID:            0xFFF1,
+		ProductID:           0x8001,
+		Discriminator:       3840,
+		Passcode:            20202021,
+		Storage:             matter.NewMemoryStorage(),
+		TransportFactory:    remote,
+		OperationalKeystore: keystore,
+	})
+	if err != nil {
+		t.Fatalf("NewNode: %v", err)
+	}
+	if setup != nil {
+		setup(device)
+	}
+	ctx := context.Background()
+	if err := device.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() { device.Stop(ctx) })
+	return device, local, transport.NewUDPPeerAddress(remote.LocalAddr())
+}
+
+func TestEstablishPASE(t *testing.T) {
+	device, factory, addr := newCommissionee(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	sess, err := EstablishPASE(ctx, factory, addr, 20202021)
+	if err != nil {
+		t.Fatalf("EstablishPASE: %v", err)
+	}
+	if got := sess.SecureContext.SessionType(); got != session.SessionTypePASE {
+		t.Errorf("SessionType = %v, want PASE", got)
+	}
+	if device.SessionManager().SecureSessionCount() != 1 {
+		t.Errorf("device has %d sessions, want 1", device.SessionManager().SecureSessionCount())
+	}
+
+	if err := sess.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	if _, err := sess.NewExchange(0x0001, nil); err != ErrSessionClosed {
+		t.Errorf("NewExchange after Close = %v, want %v", err, ErrSessionClosed)
+	}
+	if err := sess.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
+
+func TestEstablishPASE_WrongPasscode(t *testing.T) {
+	_, factory, addr := newCommissionee(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if sess, err := EstablishPASE(ctx, factory, addr, 12345678); err == nil {
+		sess.Close()
+		t.Fatal("EstablishPASE succeeded with a wrong passcode")
+	}
+}
+
+func TestEstablishCASE(t *testing.T) {
+	ca, err := commissioning.NewCertificateAuthority(commissioning.CAConfig{})
+	if err != nil {
+		t.Fatalf("NewCertificateAuthority: %v", err)
+	}
+
+	// A device commissioned onto the CA's fabric
+	keystore, err := fabric.NewKeystore(fabric.KeystoreConfig{})
+	if err != nil {
+		t.Fatalf("NewKeystore: %v", err)
+	}
+	var deviceInfo *fabric.FabricInfo
+	device, factory, addr := newDevice(t, keystore, func(device *matter.Node) {
+		handle, _, err := keystore.GenerateKey()
+		if err != nil {
+			t.Fatalf("GenerateKey: %v", err)
+		}
+		deviceKey, err := keystore.Signer(handle)
+		if err != nil {
+			t.Fatalf("Signer: %v", err)
+		}
+		if deviceInfo, err = ca.IssueFabricInfo(1, deviceKey); err != nil {
+			t.Fatalf("IssueFabricInfo: %v", err)
+		}
+		deviceInfo.KeyHandle = handle
+		if _, err := device.AddFabric(deviceInfo); err != nil {
+			t.Fatalf("AddFabric: %v", err)
+		}
+	})
+
+	// The controller's credentials come from the same CA
+	controllerKey, err := crypto.P256GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("P256GenerateKeyPair: %v", err)
+	}
+	controllerInfo, err := ca.IssueFabricInfo(1, controllerKey)
+	if err != nil {
+		t.Fatalf("IssueFabricInfo: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	sess, err := EstablishCASE(ctx, factory, addr, controllerInfo, controllerKey, deviceInfo.NodeID)
+	if err != nil {
+		t.Fatalf("EstablishCASE: %v", err)
+	}
+	if got := sess.SecureContext.SessionType(); got != session.SessionTypeCASE {
+		t.Errorf("SessionType = %v, want CASE", got)
+	}
+	if got := sess.SecureContext.PeerNodeID(); got != deviceInfo.NodeID {
+		t.Errorf("PeerNodeID = %v, want %v", got, deviceInfo.NodeID)
+	}
+	if device.SessionManager().SecureSessionCount() != 1 {
+		t.Fatalf("device has %d sessions, want 1", device.SessionManager().SecureSessionCount())
+	}
+
+	// The device drops the session on the CloseSession status report
+	if err := sess.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for device.SessionManager().SecureSessionCount() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("device has %d sessions after Close, want 0", device.SessionManager().SecureSessionCount())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,33 @@
+package session
+
+import (
+	"sync"
+
+	"github.com/backkem/matter/pkg/message"
+)
+
+// messageBufferSize is the capacity of pooled message buffers, enough for
+// any message sent over UDP.
+const messageBufferSize = message.MaxUDPMessageSize
+
+// messageBuffers pools the buffers Encrypt encodes messages into.
+var messageBuffers = sync.Pool{
+	New: func() any { return new([messageBufferSize]byte) },
+}
+
+// getMessageBuffer returns an empty buffer from the pool.
+func getMessageBuffer() []byte {
+	return messageBuffers.Get().(*[messageBufferSize]byte)[:0]
+}
+
+// ReleaseMessageBuffer returns a message encrypted by SecureContext.Encrypt
+// to the pool once it has been sent and will not be retransmitted. The
+// message must not be used afterwards. Releasing is optional: a message
+// that is not released is garbage collected. Buffers not taken from the
+// pool, including messages too large for a pooled buffer, are ignored.
+func ReleaseMessageBuffer(b []byte) {
+	if cap(b) != messageBufferSize {
+		return
+	}
+	messageBuffers.Put((*[messageBufferSize]byte)(b[:messageBufferSize]))
+}
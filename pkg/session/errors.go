@@ -41,6 +41,22 @@ var (
 	// ErrGroupPeerTableFull is returned when no more group peers can be tracked.
 	ErrGroupPeerTableFull = errors.New("session: group peer table full")
 
+	// ErrGroupCounterNotSynchronized is returned for a group control
+	// message from a peer whose control counter has not been synchronized.
+	ErrGroupCounterNotSynchronized = errors.New("session: group control counter not synchronized")
+
+	// ErrOwnGroupMessage is returned for a group message the node sent
+	// itself, received back from the multicast group.
+	ErrOwnGroupMessage = errors.New("session: own group message")
+
 	// ErrInvalidNodeID is returned when a node ID is invalid (0 for unsecured sessions).
 	ErrInvalidNodeID = errors.New("session: invalid node ID")
+
+	// ErrNoGroupCounter is returned when sending on a group context
+	// without a message counter.
+	ErrNoGroupCounter = errors.New("session: no group message counter")
+
+	// ErrKeysZeroized is returned when a session is used after its keys
+	// were zeroized, e.g. when a report races the session's removal.
+	ErrKeysZeroized = errors.New("session: keys zeroized")
 )
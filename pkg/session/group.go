@@ -11,6 +11,13 @@ import (
 // Unlike SecureContext, GroupContext is created per-message when processing
 // incoming group messages and destroyed after processing.
 //
+// A GroupContext whose source is the local node, and which has a message
+// counter, also encrypts outgoing group messages.
+//
+// Control messages, those of the Message Counter Synchronization Protocol,
+// use the group's key too, but their own counter: the node's global group
+// control counter.
+//
 // Group sessions use symmetric keys from the Group Key Management cluster.
 // The same key is used by all group members for encryption and decryption.
 //
@@ -21,8 +28,19 @@ type GroupContext struct {
 	groupID        uint16
 	groupSessionID uint16
 
-	// Codec for decryption (uses group operational key)
-	codec *message.Codec
+	// localNodeID is the node's own ID on the fabric, when known.
+	localNodeID fabric.NodeID
+
+	// Codec for the group operational key
+	codec *message.GroupCodec
+	key   []byte
+
+	// counter numbers outgoing messages. Nil for received messages.
+	counter *message.MessageCounter
+
+	// control marks counter as the group control counter: messages sent
+	// are control messages.
+	control bool
 }
 
 // GroupContextConfig is used to create a group context for message processing.
@@ -32,6 +50,19 @@ type GroupContextConfig struct {
 	GroupID        uint16
 	GroupSessionID uint16
 	OperationalKey []byte // 16 bytes, from Group Key Management
+
+	// Counter numbers sent messages: the node's global group data
+	// counter, shared by all groups (Spec 4.6.1.2). Only needed to send.
+	Counter *message.MessageCounter
+
+	// Control makes the context send control messages: Counter is then
+	// the node's global group control counter.
+	Control bool
+
+	// LocalNodeID is the node's own ID on the fabric. For a received
+	// message it is the node the reply context of ControlReplyContext
+	// sends from.
+	LocalNodeID fabric.NodeID
 }
 
 // NewGroupContext creates a new group session context for processing a message.
@@ -41,8 +72,7 @@ func NewGroupContext(config GroupContextConfig) (*GroupContext, error) {
 		return nil, ErrInvalidKey
 	}
 
-	// For group messages, the source NodeID is used in nonce construction
-	codec, err := message.NewCodec(config.OperationalKey, uint64(config.SourceNodeID))
+	codec, err := message.NewGroupCodecWithSessionID(config.OperationalKey, config.GroupSessionID)
 	if err != nil {
 		return nil, err
 	}
@@ -52,7 +82,11 @@ func NewGroupContext(config GroupContextConfig) (*GroupContext, error) {
 		fabricIndex:    config.FabricIndex,
 		groupID:        config.GroupID,
 		groupSessionID: config.GroupSessionID,
+		localNodeID:    config.LocalNodeID,
 		codec:          codec,
+		key:            config.OperationalKey,
+		counter:        config.Counter,
+		control:        config.Control,
 	}, nil
 }
 
@@ -77,31 +111,101 @@ func (g *GroupContext) GroupSessionID() uint16 {
 	return g.groupSessionID
 }
 
+// GetParams returns the default session parameters. Group messages are
+// never sent reliably, so they are only informational.
+func (g *GroupContext) GetParams() Params {
+	return DefaultParams()
+}
+
 // Decrypt decrypts an incoming group message.
 // Returns the decrypted frame with protocol header and payload.
 func (g *GroupContext) Decrypt(data []byte) (*message.Frame, error) {
-	frame, err := g.codec.Decode(data, uint64(g.sourceNodeID))
-	if err != nil {
+	frame, err := g.codec.Decode(data)
+	if err != nil || fabric.NodeID(frame.Header.SourceNodeID) != g.sourceNodeID {
 		return nil, ErrDecryptionFailed
 	}
 	return frame, nil
 }
 
+// Encrypt encrypts an outgoing group message from the source node to the
+// group, filling in the session, source, destination and counter fields
+// of the header.
+//
+// See Spec Section 4.16.2 (Groupcast Session Context).
+func (g *GroupContext) Encrypt(header *message.MessageHeader, protocol *message.ProtocolHeader, payload []byte, privacy bool) ([]byte, error) {
+	if g.counter == nil {
+		return nil, ErrNoGroupCounter
+	}
+
+	counter, err := g.counter.Next()
+	if err != nil {
+		return nil, ErrCounterExhausted
+	}
+
+	header.MessageCounter = counter
+	header.Control = g.control
+	header.SourcePresent = true
+	header.SourceNodeID = uint64(g.sourceNodeID)
+	if header.DestinationType != message.DestinationNodeID {
+		header.DestinationType = message.DestinationGroupID
+		header.DestinationGroupID = g.groupID
+	}
+
+	return g.codec.Encode(header, protocol, payload, privacy)
+}
+
+// EncryptTo encrypts an outgoing message with the group's key, like
+// Encrypt, but to a single node of the group. Message Counter
+// Synchronization Protocol messages are sent this way.
+//
+// See Spec Section 4.6 (message counter synchronization).
+func (g *GroupContext) EncryptTo(destination fabric.NodeID, protocol *message.ProtocolHeader, payload []byte) ([]byte, error) {
+	header := &message.MessageHeader{
+		DestinationType:   message.DestinationNodeID,
+		DestinationNodeID: uint64(destination),
+	}
+	return g.Encrypt(header, protocol, payload, false)
+}
+
+// GroupKey is an operational group key of a group the node is a member
+// of, as derived from an epoch key of the group's key set.
+//
+// See Spec Section 4.17.2 (Operational Group Key Derivation).
+type GroupKey struct {
+	FabricIndex    fabric.FabricIndex
+	GroupID        uint16
+	GroupSessionID uint16
+	OperationalKey []byte // 16 bytes
+
+	// LocalNodeID is the node's own ID on the fabric, the source of the
+	// control messages it sends with the key.
+	LocalNodeID fabric.NodeID
+}
+
 // groupPeerKey uniquely identifies a group message sender.
 type groupPeerKey struct {
 	fabricIndex fabric.FabricIndex
 	nodeID      fabric.NodeID
 }
 
+// groupPeerState holds the reception states of a group message sender:
+// one per counter, as data and control messages are numbered apart.
+type groupPeerState struct {
+	data    *message.ReceptionState // Nil until the first data message
+	control *message.ReceptionState // Nil until synchronized
+}
+
 // GroupPeerTable tracks per-peer message counters for group messages.
-// This implements the trust-first policy per Spec 4.6.5.2.2:
-// the first message from a new peer is accepted unconditionally to
-// establish the counter baseline.
+// Data messages use the trust-first policy per Spec 4.6.5.2.2: the first
+// message from a new peer is accepted unconditionally to establish the
+// counter baseline. Control messages are only accepted once the peer's
+// control counter has been synchronized with the Message Counter
+// Synchronization Protocol (Spec 4.6).
 //
 // Group peers are tracked per-fabric because the same NodeID may appear
 // on different fabrics.
 type GroupPeerTable struct {
-	peers    map[groupPeerKey]*message.ReceptionState
+	peers    map[groupPeerKey]*groupPeerState
 	maxPeers int
 
 	mu sync.RWMutex
@@ -111,7 +215,7 @@ type GroupPeerTable struct {
 // maxPeers limits the number of tracked peers (0 means unlimited).
 func NewGroupPeerTable(maxPeers int) *GroupPeerTable {
 	return &GroupPeerTable{
-		peers:    make(map[groupPeerKey]*message.ReceptionState),
+		peers:    make(map[groupPeerKey]*groupPeerState),
 		maxPeers: maxPeers,
 	}
 }
@@ -126,25 +230,70 @@ func (t *GroupPeerTable) CheckCounter(fabricIndex fabric.FabricIndex, sourceNode
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	key := groupPeerKey{fabricIndex: fabricIndex, nodeID: sourceNodeID}
-
-	state, exists := t.peers[key]
-	if !exists {
+	peer := t.peerLocked(fabricIndex, sourceNodeID)
+	if peer == nil {
+		return false // Capacity exceeded
+	}
+	if peer.data == nil {
 		// First message from this peer - trust-first policy
-		// Check capacity before adding
-		if t.maxPeers > 0 && len(t.peers) >= t.maxPeers {
-			return false // Capacity exceeded
-		}
-
-		// Create new state and accept the message
-		state = message.NewReceptionState(counter)
-		t.peers[key] = state
+		peer.data = message.NewReceptionState(counter)
 		return true
 	}
 
 	// Subsequent messages: verify with rollover awareness
 	// Group messages allow rollover per spec
-	return state.CheckAndAccept(counter, true)
+	return peer.data.CheckAndAccept(counter, true)
+}
+
+// CheckControlCounter verifies a group control message counter against
+// the peer's synchronized control counter. Returns
+// ErrGroupCounterNotSynchronized if the peer's control counter has not
+// been synchronized yet and ErrReplayDetected for a duplicate.
+//
+// See Spec Section 4.6 (message counter synchronization).
+func (t *GroupPeerTable) CheckControlCounter(fabricIndex fabric.FabricIndex, sourceNodeID fabric.NodeID, counter uint32) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	peer, ok := t.peers[groupPeerKey{fabricIndex: fabricIndex, nodeID: sourceNodeID}]
+	if !ok || peer.control == nil {
+		return ErrGroupCounterNotSynchronized
+	}
+	if !peer.control.CheckAndAccept(counter, true) {
+		return ErrReplayDetected
+	}
+	return nil
+}
+
+// SynchronizeControlCounter sets the peer's control counter to counter,
+// as learned from its MsgCounterSyncRsp: later control messages must have
+// a greater counter.
+func (t *GroupPeerTable) SynchronizeControlCounter(fabricIndex fabric.FabricIndex, nodeID fabric.NodeID, counter uint32) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	peer := t.peerLocked(fabricIndex, nodeID)
+	if peer == nil {
+		return ErrGroupPeerTableFull
+	}
+	peer.control = message.NewReceptionState(counter)
+	return nil
+}
+
+// peerLocked returns the state of a peer, creating it if there is room.
+// Returns nil if the table is full.
+// Caller must hold t.mu.
+func (t *GroupPeerTable) peerLocked(fabricIndex fabric.FabricIndex, nodeID fabric.NodeID) *groupPeerState {
+	key := groupPeerKey{fabricIndex: fabricIndex, nodeID: nodeID}
+	if peer, ok := t.peers[key]; ok {
+		return peer
+	}
+	if t.maxPeers > 0 && len(t.peers) >= t.maxPeers {
+		return nil
+	}
+	peer := &groupPeerState{}
+	t.peers[key] = peer
+	return peer
 }
 
 // RemovePeer removes tracking for a specific peer.
@@ -181,5 +330,5 @@ func (t *GroupPeerTable) Count() int {
 func (t *GroupPeerTable) Clear() {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	t.peers = make(map[groupPeerKey]*message.ReceptionState)
+	t.peers = make(map[groupPeerKey]*groupPeerState)
 }
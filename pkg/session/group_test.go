@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/message"
 )
 
 var testGroupKey = []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0A, 0x0B, 0x0C, 0x0D, 0x0E, 0x0F, 0x10}
@@ -49,6 +50,75 @@ func TestNewGroupContext(t *testing.T) {
 	})
 }
 
+func TestGroupContext_Encrypt(t *testing.T) {
+	config := GroupContextConfig{
+		SourceNodeID:   fabric.NodeID(0x1234),
+		FabricIndex:    1,
+		GroupID:        100,
+		GroupSessionID: 200,
+		OperationalKey: testGroupKey,
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		config := config
+		config.Counter = message.NewMessageCounterWithValue(7)
+		sender, err := NewGroupContext(config)
+		if err != nil {
+			t.Fatalf("NewGroupContext() error = %v", err)
+		}
+
+		protocol := &message.ProtocolHeader{ProtocolID: message.ProtocolInteractionModel, ProtocolOpcode: 0x08}
+		data, err := sender.Encrypt(&message.MessageHeader{}, protocol, []byte{0xAA}, false)
+		if err != nil {
+			t.Fatalf("Encrypt() error = %v", err)
+		}
+
+		// A member decrypts with the sender's node ID
+		receiver, err := NewGroupContext(GroupContextConfig{
+			SourceNodeID:   fabric.NodeID(0x1234),
+			FabricIndex:    1,
+			GroupID:        100,
+			GroupSessionID: 200,
+			OperationalKey: testGroupKey,
+		})
+		if err != nil {
+			t.Fatalf("NewGroupContext() error = %v", err)
+		}
+		frame, err := receiver.Decrypt(data)
+		if err != nil {
+			t.Fatalf("Decrypt() error = %v", err)
+		}
+
+		h := frame.Header
+		if h.SessionType != message.SessionTypeGroup || h.SessionID != 200 {
+			t.Errorf("session = %v/%d, want group/200", h.SessionType, h.SessionID)
+		}
+		if h.DestinationType != message.DestinationGroupID || h.DestinationGroupID != 100 {
+			t.Errorf("destination = %v/%d, want group 100", h.DestinationType, h.DestinationGroupID)
+		}
+		if !h.SourcePresent || h.SourceNodeID != 0x1234 {
+			t.Errorf("source = %v/0x%x, want 0x1234", h.SourcePresent, h.SourceNodeID)
+		}
+		if h.MessageCounter != 7 {
+			t.Errorf("MessageCounter = %d, want 7", h.MessageCounter)
+		}
+		if len(frame.Payload) != 1 || frame.Payload[0] != 0xAA {
+			t.Errorf("Payload = %x, want aa", frame.Payload)
+		}
+	})
+
+	t.Run("no counter", func(t *testing.T) {
+		ctx, err := NewGroupContext(config)
+		if err != nil {
+			t.Fatalf("NewGroupContext() error = %v", err)
+		}
+		_, err = ctx.Encrypt(&message.MessageHeader{}, &message.ProtocolHeader{}, nil, false)
+		if err != ErrNoGroupCounter {
+			t.Errorf("Encrypt() error = %v, want ErrNoGroupCounter", err)
+		}
+	})
+}
+
 func TestNewGroupPeerTable(t *testing.T) {
 	table := NewGroupPeerTable(10)
 	if table == nil {
@@ -247,3 +317,40 @@ func TestGroupPeerTable_RolloverHandling(t *testing.T) {
 		t.Error("Higher counter should be accepted")
 	}
 }
+
+func TestGroupPeerTable_ControlCounter(t *testing.T) {
+	table := NewGroupPeerTable(1)
+
+	fabricIndex := fabric.FabricIndex(1)
+	nodeID := fabric.NodeID(0x1234)
+
+	// Control counters are not trusted on first use
+	if err := table.CheckControlCounter(fabricIndex, nodeID, 100); err != ErrGroupCounterNotSynchronized {
+		t.Fatalf("CheckControlCounter() before sync error = %v, want %v", err, ErrGroupCounterNotSynchronized)
+	}
+
+	if err := table.SynchronizeControlCounter(fabricIndex, nodeID, 100); err != nil {
+		t.Fatalf("SynchronizeControlCounter() error = %v", err)
+	}
+	if err := table.CheckControlCounter(fabricIndex, nodeID, 100); err != ErrReplayDetected {
+		t.Errorf("CheckControlCounter(synchronized) error = %v, want %v", err, ErrReplayDetected)
+	}
+	if err := table.CheckControlCounter(fabricIndex, nodeID, 101); err != nil {
+		t.Errorf("CheckControlCounter(101) error = %v", err)
+	}
+	if err := table.CheckControlCounter(fabricIndex, nodeID, 101); err != ErrReplayDetected {
+		t.Errorf("CheckControlCounter(duplicate) error = %v, want %v", err, ErrReplayDetected)
+	}
+
+	// Data counters are numbered apart
+	if !table.CheckCounter(fabricIndex, nodeID, 100) {
+		t.Error("CheckCounter() should accept the first data message")
+	}
+	if table.Count() != 1 {
+		t.Errorf("Count() = %d, want 1", table.Count())
+	}
+
+	if err := table.SynchronizeControlCounter(fabricIndex, 0x5678, 1); err != ErrGroupPeerTableFull {
+		t.Errorf("SynchronizeControlCounter() on full table error = %v, want %v", err, ErrGroupPeerTableFull)
+	}
+}
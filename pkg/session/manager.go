@@ -1,6 +1,8 @@
 package session
 
 import (
+	"bytes"
+	"slices"
 	"sync"
 
 	"github.com/backkem/matter/pkg/fabric"
@@ -18,12 +20,17 @@ const DefaultMaxGroupPeers = 64
 //   - A table of secure session contexts (PASE/CASE)
 //   - A table of unsecured session contexts (for PASE/CASE handshake)
 //   - A table of group peer counters for anti-replay
+//   - The operational keys of the groups the node is a member of
 //   - A global message counter for unsecured messages
+//   - Global message counters for sent group data and control messages
 type Manager struct {
-	secure        *Table
-	unsecured     map[fabric.NodeID]*UnsecuredContext // Keyed by ephemeral node ID
-	groupPeers    *GroupPeerTable
-	globalCounter *message.GlobalCounter
+	secure              *Table
+	unsecured           map[fabric.NodeID]*UnsecuredContext // Keyed by ephemeral node ID
+	groupPeers          *GroupPeerTable
+	globalCounter       *message.GlobalCounter
+	groupCounter        *message.MessageCounter
+	groupControlCounter *message.MessageCounter
+	groupKeys           []GroupKey
 
 	mu sync.RWMutex
 }
@@ -49,10 +56,12 @@ func NewManager(config ManagerConfig) *Manager {
 	}
 
 	return &Manager{
-		secure:        NewTable(config.MaxSessions),
-		unsecured:     make(map[fabric.NodeID]*UnsecuredContext),
-		groupPeers:    NewGroupPeerTable(config.MaxGroupPeers),
-		globalCounter: message.NewGlobalCounter(),
+		secure:              NewTable(config.MaxSessions),
+		unsecured:           make(map[fabric.NodeID]*UnsecuredContext),
+		groupPeers:          NewGroupPeerTable(config.MaxGroupPeers),
+		globalCounter:       message.NewGlobalCounter(),
+		groupCounter:        message.NewMessageCounter(),
+		groupControlCounter: message.NewMessageCounter(),
 	}
 }
 
@@ -210,23 +219,208 @@ func (m *Manager) CheckGroupCounter(fabricIndex fabric.FabricIndex, sourceNodeID
 	return m.groupPeers.CheckCounter(fabricIndex, sourceNodeID, counter)
 }
 
+// GroupDataCounter returns the counter of the group messages this node
+// sends, shared by all its groups. See GroupContextConfig.Counter.
+func (m *Manager) GroupDataCounter() *message.MessageCounter {
+	return m.groupCounter
+}
+
+// GroupControlCounter returns the counter of the group control messages
+// this node sends, shared by all its groups (Spec 4.6.1.2).
+func (m *Manager) GroupControlCounter() *message.MessageCounter {
+	return m.groupControlCounter
+}
+
+// SynchronizeGroupControlCounter sets the control counter of a group peer,
+// as received in its MsgCounterSyncRsp. Its control messages are accepted
+// from then on.
+func (m *Manager) SynchronizeGroupControlCounter(fabricIndex fabric.FabricIndex, nodeID fabric.NodeID, counter uint32) error {
+	return m.groupPeers.SynchronizeControlCounter(fabricIndex, nodeID, counter)
+}
+
+// ControlReplyContext returns the context for sending control messages
+// from this node to the sender of a received group message, with the key
+// the message was received with.
+//
+// See Spec Section 4.6 (message counter synchronization).
+func (m *Manager) ControlReplyContext(received *GroupContext) (*GroupContext, error) {
+	if received.localNodeID == 0 {
+		return nil, ErrInvalidNodeID
+	}
+	return NewGroupContext(GroupContextConfig{
+		SourceNodeID:   received.localNodeID,
+		FabricIndex:    received.fabricIndex,
+		GroupID:        received.groupID,
+		GroupSessionID: received.groupSessionID,
+		OperationalKey: received.key,
+		Counter:        m.groupControlCounter,
+		Control:        true,
+		LocalNodeID:    received.localNodeID,
+	})
+}
+
+// AddGroupKey adds an operational key of a group the node is a member of,
+// to decrypt the group's messages. A group may have several keys, e.g.
+// while its key set rotates epoch keys; adding a key twice has no effect.
+func (m *Manager) AddGroupKey(key GroupKey) error {
+	if len(key.OperationalKey) != SessionKeySize {
+		return ErrInvalidKey
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, k := range m.groupKeys {
+		if k.FabricIndex == key.FabricIndex && k.GroupID == key.GroupID &&
+			bytes.Equal(k.OperationalKey, key.OperationalKey) {
+			return nil
+		}
+	}
+	key.OperationalKey = bytes.Clone(key.OperationalKey)
+	m.groupKeys = append(m.groupKeys, key)
+	return nil
+}
+
+// FindGroupKey returns a key of a group the node is a member of, with the
+// given session ID.
+func (m *Manager) FindGroupKey(fabricIndex fabric.FabricIndex, groupID, groupSessionID uint16) (GroupKey, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, k := range m.groupKeys {
+		if k.FabricIndex == fabricIndex && k.GroupID == groupID && k.GroupSessionID == groupSessionID {
+			return k, true
+		}
+	}
+	return GroupKey{}, false
+}
+
+// RemoveGroupKeys removes the keys of a group, after which its messages
+// are dropped.
+func (m *Manager) RemoveGroupKeys(fabricIndex fabric.FabricIndex, groupID uint16) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.groupKeys = slices.DeleteFunc(m.groupKeys, func(k GroupKey) bool {
+		return k.FabricIndex == fabricIndex && k.GroupID == groupID
+	})
+}
+
+// DecryptGroupMessage decrypts a received group message. The keys of the
+// destination group whose session ID matches are tried in turn, as
+// session IDs are not unique (Spec 4.16.3.1). The message counter is then
+// checked against the sender's: with the trust-first policy for data
+// messages, and against the synchronized control counter for control
+// messages.
+//
+// Control messages may also be sent to a single node with a group key, as
+// the Message Counter Synchronization Protocol does; they are decrypted
+// with the keys of the node's groups with the destination as local node.
+//
+// Returns the group context of the message, which identifies its sender,
+// fabric and group, and the decrypted frame. Returns ErrSessionNotFound if
+// no key decrypts the message and ErrReplayDetected for a duplicate. A
+// control message from a peer whose control counter is not synchronized
+// is returned, decrypted, along with ErrGroupCounterNotSynchronized.
+// The node's own messages, which a multicast socket may loop back, are
+// dropped with ErrOwnGroupMessage before their counter is checked.
+//
+// With privacy obfuscation, the source and destination are only known
+// once the message is decrypted, so the keys of every group whose session
+// ID matches are tried.
+//
+// See Spec Section 4.16.3 (Group message reception).
+func (m *Manager) DecryptGroupMessage(data []byte) (*GroupContext, *message.Frame, error) {
+	var header message.MessageHeader
+	if _, err := header.Decode(data); err != nil {
+		return nil, nil, err
+	}
+	unicast := header.DestinationType == message.DestinationNodeID && header.Control
+	if header.SessionType != message.SessionTypeGroup || !header.SourcePresent ||
+		(header.DestinationType != message.DestinationGroupID && !unicast) {
+		return nil, nil, ErrInvalidSessionType
+	}
+
+	m.mu.RLock()
+	var candidates []GroupKey
+	for _, k := range m.groupKeys {
+		if k.GroupSessionID == header.SessionID {
+			candidates = append(candidates, k)
+		}
+	}
+	m.mu.RUnlock()
+
+	for _, k := range candidates {
+		codec, err := message.NewGroupCodecWithSessionID(k.OperationalKey, k.GroupSessionID)
+		if err != nil {
+			continue
+		}
+		frame, err := codec.Decode(data)
+		if err != nil {
+			continue
+		}
+		// The destination is read from the deobfuscated header
+		h := &frame.Header
+		if unicast && k.LocalNodeID != fabric.NodeID(h.DestinationNodeID) ||
+			!unicast && k.GroupID != h.DestinationGroupID {
+			continue
+		}
+		if k.LocalNodeID != 0 && k.LocalNodeID == fabric.NodeID(h.SourceNodeID) {
+			return nil, nil, ErrOwnGroupMessage
+		}
+		group, err := NewGroupContext(GroupContextConfig{
+			SourceNodeID:   fabric.NodeID(h.SourceNodeID),
+			FabricIndex:    k.FabricIndex,
+			GroupID:        k.GroupID,
+			GroupSessionID: k.GroupSessionID,
+			OperationalKey: k.OperationalKey,
+			LocalNodeID:    k.LocalNodeID,
+		})
+		if err != nil {
+			continue
+		}
+		if frame.Header.Control {
+			err := m.groupPeers.CheckControlCounter(k.FabricIndex, group.SourceNodeID(), frame.Header.MessageCounter)
+			if err == ErrGroupCounterNotSynchronized {
+				return group, frame, err
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			return group, frame, nil
+		}
+		if !m.CheckGroupCounter(k.FabricIndex, group.SourceNodeID(), frame.Header.MessageCounter) {
+			return nil, nil, ErrReplayDetected
+		}
+		return group, frame, nil
+	}
+	return nil, nil, ErrSessionNotFound
+}
+
 // RemoveGroupPeer removes group counter tracking for a specific peer.
 func (m *Manager) RemoveGroupPeer(fabricIndex fabric.FabricIndex, nodeID fabric.NodeID) {
 	m.groupPeers.RemovePeer(fabricIndex, nodeID)
 }
 
 // RemoveFabric removes all sessions and group peers on a fabric.
-// Called when a fabric is removed from the node.
-func (m *Manager) RemoveFabric(fabricIndex fabric.FabricIndex) {
-	// Remove all secure sessions on this fabric
-	sessions := m.secure.FindByFabric(fabricIndex)
-	for _, ctx := range sessions {
-		ctx.ZeroizeKeys()
+// Called when a fabric is removed from the node. The sessions whose local
+// session IDs are in keep stay open, for the caller to remove with
+// RemoveSecureContext once done with them, e.g. after sending a response.
+func (m *Manager) RemoveFabric(fabricIndex fabric.FabricIndex, keep ...uint16) {
+	// Remove the secure sessions on this fabric
+	for _, ctx := range m.secure.FindByFabric(fabricIndex) {
+		if !slices.Contains(keep, ctx.LocalSessionID()) {
+			m.RemoveSecureContext(ctx.LocalSessionID())
+		}
 	}
-	m.secure.RemoveByFabric(fabricIndex)
 
-	// Remove all group peer tracking for this fabric
+	// Remove all group peer tracking and group keys for this fabric
 	m.groupPeers.RemoveFabric(fabricIndex)
+	m.mu.Lock()
+	m.groupKeys = slices.DeleteFunc(m.groupKeys, func(k GroupKey) bool {
+		return k.FabricIndex == fabricIndex
+	})
+	m.mu.Unlock()
 }
 
 // RemovePeer removes all sessions to a specific peer.
@@ -264,6 +458,45 @@ func (m *Manager) Clear() {
 	m.globalCounter = message.NewGlobalCounter()
 }
 
+// Open decrypts a captured secure unicast frame with the keys of its
+// session, without affecting message counters (see SecureContext.Open).
+// Inbound frames are matched by local session ID and outbound frames by
+// peer session ID. Returns ErrSessionNotFound if no session opens the frame.
+func (m *Manager) Open(data []byte, outbound bool) (*message.Frame, error) {
+	raw, err := message.DecodeRaw(data)
+	if err != nil {
+		return nil, err
+	}
+	if !raw.Header.IsSecure() || raw.Header.SessionType != message.SessionTypeUnicast {
+		return nil, ErrSessionNotFound
+	}
+
+	if !outbound {
+		ctx := m.secure.FindByLocalID(raw.Header.SessionID)
+		if ctx == nil {
+			return nil, ErrSessionNotFound
+		}
+		return ctx.Open(data, false)
+	}
+
+	// Peer session IDs are only unique per peer, so try each candidate.
+	var frame *message.Frame
+	m.secure.ForEach(func(ctx *SecureContext) bool {
+		if ctx.PeerSessionID() != raw.Header.SessionID {
+			return true
+		}
+		if f, err := ctx.Open(data, true); err == nil {
+			frame = f
+			return false
+		}
+		return true
+	})
+	if frame == nil {
+		return nil, ErrSessionNotFound
+	}
+	return frame, nil
+}
+
 // ForEachSecureSession calls fn for each secure session.
 // The callback receives the session context and should return true to continue.
 func (m *Manager) ForEachSecureSession(fn func(*SecureContext) bool) {
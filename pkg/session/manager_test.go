@@ -1,9 +1,11 @@
 package session
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/message"
 )
 
 func TestNewManager(t *testing.T) {
@@ -208,6 +210,14 @@ func TestManager_RemoveFabric(t *testing.T) {
 	if m.GroupPeerCount() != 1 {
 		t.Errorf("GroupPeerCount() after RemoveFabric = %d, want 1", m.GroupPeerCount())
 	}
+
+	// A kept session stays open until removed
+	ctx4 := createTestSecureContextWithPeer(4, fabric.FabricIndex(2), fabric.NodeID(0x5678))
+	m.AddSecureContext(ctx4)
+	m.RemoveFabric(fabric.FabricIndex(2), 4)
+	if m.SecureSessionCount() != 1 || m.FindSecureContext(4) == nil {
+		t.Errorf("kept session removed, %d sessions left", m.SecureSessionCount())
+	}
 }
 
 func TestManager_RemovePeer(t *testing.T) {
@@ -503,3 +513,269 @@ func TestManager_ClearIncludesUnsecured(t *testing.T) {
 		t.Errorf("UnsecuredSessionCount() after Clear = %d, want 0", m.UnsecuredSessionCount())
 	}
 }
+
+func TestManager_Open(t *testing.T) {
+	m := NewManager(ManagerConfig{})
+	local, _ := NewSecureContext(SecureContextConfig{
+		SessionType:    SessionTypePASE,
+		Role:           SessionRoleResponder,
+		LocalSessionID: 10,
+		PeerSessionID:  20,
+		I2RKey:         testI2RKey,
+		R2IKey:         testR2IKey,
+	})
+	if err := m.AddSecureContext(local); err != nil {
+		t.Fatalf("AddSecureContext() error = %v", err)
+	}
+	peer, _ := NewSecureContext(SecureContextConfig{
+		SessionType:    SessionTypePASE,
+		Role:           SessionRoleInitiator,
+		LocalSessionID: 20,
+		PeerSessionID:  10,
+		I2RKey:         testI2RKey,
+		R2IKey:         testR2IKey,
+	})
+
+	protocol := &message.ProtocolHeader{ProtocolID: message.ProtocolInteractionModel, ProtocolOpcode: 0x08}
+
+	in, _ := peer.Encrypt(&message.MessageHeader{SessionType: message.SessionTypeUnicast}, protocol, []byte("in"), false)
+	frame, err := m.Open(in, false)
+	if err != nil || !bytes.Equal(frame.Payload, []byte("in")) {
+		t.Errorf("Open(inbound) = %v, %v", frame, err)
+	}
+
+	out, _ := local.Encrypt(&message.MessageHeader{SessionType: message.SessionTypeUnicast}, protocol, []byte("out"), false)
+	frame, err = m.Open(out, true)
+	if err != nil || !bytes.Equal(frame.Payload, []byte("out")) {
+		t.Errorf("Open(outbound) = %v, %v", frame, err)
+	}
+
+	if _, err := m.Open(out, false); err != ErrSessionNotFound {
+		t.Errorf("Open(unknown session) error = %v, want ErrSessionNotFound", err)
+	}
+
+	unsecured := (&message.Frame{
+		Header:   message.MessageHeader{SessionType: message.SessionTypeUnicast},
+		Protocol: *protocol,
+	}).EncodeUnsecured()
+	if _, err := m.Open(unsecured, false); err != ErrSessionNotFound {
+		t.Errorf("Open(unsecured) error = %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestManager_DecryptGroupMessage(t *testing.T) {
+	sender, err := NewGroupContext(GroupContextConfig{
+		SourceNodeID:   fabric.NodeID(0x1234),
+		FabricIndex:    1,
+		GroupID:        100,
+		GroupSessionID: 200,
+		OperationalKey: testGroupKey,
+		Counter:        message.NewMessageCounterWithValue(7),
+	})
+	if err != nil {
+		t.Fatalf("NewGroupContext() error = %v", err)
+	}
+	protocol := &message.ProtocolHeader{ProtocolID: message.ProtocolInteractionModel, ProtocolOpcode: 0x08}
+	data, err := sender.Encrypt(&message.MessageHeader{}, protocol, []byte{0xAA}, false)
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	m := NewManager(ManagerConfig{})
+	if _, _, err := m.DecryptGroupMessage(data); err != ErrSessionNotFound {
+		t.Fatalf("DecryptGroupMessage() without key error = %v, want %v", err, ErrSessionNotFound)
+	}
+
+	// A colliding session ID with another key is skipped
+	otherKey := bytes.Repeat([]byte{0xFF}, SessionKeySize)
+	for _, key := range []GroupKey{
+		{FabricIndex: 2, GroupID: 100, GroupSessionID: 200, OperationalKey: otherKey},
+		{FabricIndex: 1, GroupID: 100, GroupSessionID: 200, OperationalKey: testGroupKey},
+	} {
+		if err := m.AddGroupKey(key); err != nil {
+			t.Fatalf("AddGroupKey() error = %v", err)
+		}
+	}
+
+	group, frame, err := m.DecryptGroupMessage(data)
+	if err != nil {
+		t.Fatalf("DecryptGroupMessage() error = %v", err)
+	}
+	if group.FabricIndex() != 1 || group.GroupID() != 100 || group.SourceNodeID() != 0x1234 {
+		t.Errorf("group = fabric %d, group %d, source 0x%x, want 1, 100, 0x1234",
+			group.FabricIndex(), group.GroupID(), group.SourceNodeID())
+	}
+	if len(frame.Payload) != 1 || frame.Payload[0] != 0xAA {
+		t.Errorf("Payload = %x, want aa", frame.Payload)
+	}
+
+	if _, _, err := m.DecryptGroupMessage(data); err != ErrReplayDetected {
+		t.Errorf("DecryptGroupMessage() replay error = %v, want %v", err, ErrReplayDetected)
+	}
+
+	m.RemoveGroupKeys(1, 100)
+	if _, _, err := m.DecryptGroupMessage(data); err != ErrSessionNotFound {
+		t.Errorf("DecryptGroupMessage() after RemoveGroupKeys error = %v, want %v", err, ErrSessionNotFound)
+	}
+}
+
+func TestManager_DecryptGroupMessage_Privacy(t *testing.T) {
+	sender, err := NewGroupContext(GroupContextConfig{
+		SourceNodeID:   fabric.NodeID(0x1234),
+		FabricIndex:    1,
+		GroupID:        100,
+		GroupSessionID: 200,
+		OperationalKey: testGroupKey,
+		Counter:        message.NewMessageCounterWithValue(7),
+	})
+	if err != nil {
+		t.Fatalf("NewGroupContext() error = %v", err)
+	}
+	protocol := &message.ProtocolHeader{ProtocolID: message.ProtocolInteractionModel, ProtocolOpcode: 0x08}
+	data, err := sender.Encrypt(&message.MessageHeader{}, protocol, []byte{0xAA}, true)
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	// Another group with the same session ID is tried first
+	m := NewManager(ManagerConfig{})
+	for _, key := range []GroupKey{
+		{FabricIndex: 1, GroupID: 101, GroupSessionID: 200, OperationalKey: testGroupKey},
+		{FabricIndex: 1, GroupID: 100, GroupSessionID: 200, OperationalKey: testGroupKey},
+	} {
+		if err := m.AddGroupKey(key); err != nil {
+			t.Fatalf("AddGroupKey() error = %v", err)
+		}
+	}
+
+	group, frame, err := m.DecryptGroupMessage(data)
+	if err != nil {
+		t.Fatalf("DecryptGroupMessage() error = %v", err)
+	}
+	if group.GroupID() != 100 || group.SourceNodeID() != 0x1234 {
+		t.Errorf("group = group %d, source 0x%x, want 100, 0x1234", group.GroupID(), group.SourceNodeID())
+	}
+	if frame.Header.MessageCounter != 7 || len(frame.Payload) != 1 || frame.Payload[0] != 0xAA {
+		t.Errorf("frame = counter %d, payload %x, want 7, aa", frame.Header.MessageCounter, frame.Payload)
+	}
+}
+
+func TestManager_DecryptGroupMessage_Own(t *testing.T) {
+	sender, err := NewGroupContext(GroupContextConfig{
+		SourceNodeID:   fabric.NodeID(0x1234),
+		FabricIndex:    1,
+		GroupID:        100,
+		GroupSessionID: 200,
+		OperationalKey: testGroupKey,
+		Counter:        message.NewMessageCounterWithValue(7),
+	})
+	if err != nil {
+		t.Fatalf("NewGroupContext() error = %v", err)
+	}
+	protocol := &message.ProtocolHeader{ProtocolID: message.ProtocolInteractionModel, ProtocolOpcode: 0x08}
+	data, err := sender.Encrypt(&message.MessageHeader{}, protocol, []byte{0xAA}, false)
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	// The sender is a member of the group: its message comes back
+	m := NewManager(ManagerConfig{})
+	key := GroupKey{FabricIndex: 1, GroupID: 100, GroupSessionID: 200, OperationalKey: testGroupKey, LocalNodeID: 0x1234}
+	if err := m.AddGroupKey(key); err != nil {
+		t.Fatalf("AddGroupKey() error = %v", err)
+	}
+	if _, _, err := m.DecryptGroupMessage(data); err != ErrOwnGroupMessage {
+		t.Errorf("DecryptGroupMessage() error = %v, want %v", err, ErrOwnGroupMessage)
+	}
+	if n := m.groupPeers.Count(); n != 0 {
+		t.Errorf("group peers = %d, want 0", n)
+	}
+
+	if k, ok := m.FindGroupKey(1, 100, 200); !ok || k.LocalNodeID != 0x1234 {
+		t.Errorf("FindGroupKey() = %+v, %v", k, ok)
+	}
+	if _, ok := m.FindGroupKey(1, 101, 200); ok {
+		t.Error("FindGroupKey() found a key of another group")
+	}
+}
+
+func TestManager_DecryptGroupMessage_Control(t *testing.T) {
+	sender, err := NewGroupContext(GroupContextConfig{
+		SourceNodeID:   fabric.NodeID(0x1234),
+		FabricIndex:    1,
+		GroupID:        100,
+		GroupSessionID: 200,
+		OperationalKey: testGroupKey,
+		Counter:        message.NewMessageCounterWithValue(7),
+		Control:        true,
+	})
+	if err != nil {
+		t.Fatalf("NewGroupContext() error = %v", err)
+	}
+	protocol := &message.ProtocolHeader{ProtocolID: message.ProtocolInteractionModel, ProtocolOpcode: 0x08, Initiator: true}
+
+	m := NewManager(ManagerConfig{})
+	if err := m.AddGroupKey(GroupKey{
+		FabricIndex:    1,
+		GroupID:        100,
+		GroupSessionID: 200,
+		OperationalKey: testGroupKey,
+		LocalNodeID:    0x5678,
+	}); err != nil {
+		t.Fatalf("AddGroupKey() error = %v", err)
+	}
+
+	data, err := sender.Encrypt(&message.MessageHeader{}, protocol, []byte{0xAA}, false)
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	group, frame, err := m.DecryptGroupMessage(data)
+	if err != ErrGroupCounterNotSynchronized {
+		t.Fatalf("DecryptGroupMessage() error = %v, want %v", err, ErrGroupCounterNotSynchronized)
+	}
+	if !frame.Header.Control || frame.Header.MessageCounter != 7 {
+		t.Errorf("frame: control = %v, counter = %d, want true, 7", frame.Header.Control, frame.Header.MessageCounter)
+	}
+
+	// The reply goes from the local node to the sender, on the control counter
+	reply, err := m.ControlReplyContext(group)
+	if err != nil {
+		t.Fatalf("ControlReplyContext() error = %v", err)
+	}
+	if reply.SourceNodeID() != 0x5678 {
+		t.Errorf("reply SourceNodeID() = 0x%x, want 0x5678", reply.SourceNodeID())
+	}
+	before := m.GroupControlCounter().Current()
+	if _, err := reply.EncryptTo(group.SourceNodeID(), protocol, nil); err != nil {
+		t.Fatalf("EncryptTo() error = %v", err)
+	}
+	if got := m.GroupControlCounter().Current(); got != before+1 {
+		t.Errorf("GroupControlCounter() = %d, want %d", got, before+1)
+	}
+
+	if err := m.SynchronizeGroupControlCounter(1, 0x1234, 6); err != nil {
+		t.Fatalf("SynchronizeGroupControlCounter() error = %v", err)
+	}
+	if _, _, err := m.DecryptGroupMessage(data); err != nil {
+		t.Fatalf("DecryptGroupMessage() after sync error = %v", err)
+	}
+	if _, _, err := m.DecryptGroupMessage(data); err != ErrReplayDetected {
+		t.Errorf("DecryptGroupMessage() replay error = %v, want %v", err, ErrReplayDetected)
+	}
+
+	// Control messages to a single node are matched by the local node ID
+	unicast, err := sender.EncryptTo(0x5678, protocol, []byte{0xBB})
+	if err != nil {
+		t.Fatalf("EncryptTo() error = %v", err)
+	}
+	if _, frame, err := m.DecryptGroupMessage(unicast); err != nil || frame.Payload[0] != 0xBB {
+		t.Errorf("DecryptGroupMessage(unicast) = %v, %v", frame, err)
+	}
+	other, err := sender.EncryptTo(0x9999, protocol, []byte{0xCC})
+	if err != nil {
+		t.Fatalf("EncryptTo() error = %v", err)
+	}
+	if _, _, err := m.DecryptGroupMessage(other); err != ErrSessionNotFound {
+		t.Errorf("DecryptGroupMessage(other node) error = %v, want %v", err, ErrSessionNotFound)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/backkem/matter/pkg/clock"
+	"github.com/backkem/matter/pkg/crypto"
 	"github.com/backkem/matter/pkg/fabric"
 	"github.com/backkem/matter/pkg/message"
 )
@@ -49,9 +51,12 @@ type SecureContext struct {
 	peerSessionID  uint16      // 4. Used in outgoing message Session ID field
 
 	// === Keys (fields 5-7) ===
-	i2rKey       []byte // 5. Initiator-to-Responder encryption key (16 bytes)
-	r2iKey       []byte // 6. Responder-to-Initiator encryption key (16 bytes)
-	sharedSecret []byte // 7. For CASE resumption (nil for PASE)
+	i2rKey       []byte               // 5. Initiator-to-Responder encryption key (16 bytes)
+	r2iKey       []byte               // 6. Responder-to-Initiator encryption key (16 bytes)
+	sharedSecret *crypto.SecretBuffer // 7. For CASE resumption (nil for PASE)
+
+	// Attestation challenge derived with the session keys
+	attestationChallenge []byte
 
 	// === Derived codecs (from keys) ===
 	encryptCodec *message.Codec // For encrypting outgoing messages
@@ -75,12 +80,16 @@ type SecureContext struct {
 	sessionTimestamp time.Time // 13. Last send/receive
 	activeTimestamp  time.Time // 14. Last receive (for PeerActiveMode)
 
+	establishedAt time.Time // When the session was created
+
 	// === Parameters (field 15) ===
 	params Params // 15. MRP timing parameters
 
 	// === CAT fields (up to 3) ===
 	caseAuthTags []uint32 // CASE Authenticated Tags from NOC
 
+	clock clock.Clock // Reads the timestamps
+
 	mu sync.RWMutex
 }
 
@@ -96,8 +105,16 @@ type SecureContextConfig struct {
 	FabricIndex    fabric.FabricIndex
 	PeerNodeID     fabric.NodeID
 	LocalNodeID    fabric.NodeID // Our node ID (0 for PASE)
-	Params         Params
-	CaseAuthTags   []uint32 // Up to 3
+	Params         Params        // The peer's MRP parameters from the handshake
+	CaseAuthTags   []uint32      // Up to 3
+
+	// AttestationChallenge is the challenge derived with the session keys,
+	// signed by the device in attestation and CSR responses (optional).
+	AttestationChallenge []byte
+
+	// Clock reads the session and active timestamps.
+	// If nil, the real clock is used.
+	Clock clock.Clock
 }
 
 // NewSecureContext creates a new secure session context.
@@ -155,7 +172,8 @@ func NewSecureContext(config SecureContextConfig) (*SecureContext, error) {
 		}
 	}
 
-	now := time.Now()
+	clk := clock.OrReal(config.Clock)
+	now := clk.Now()
 
 	ctx := &SecureContext{
 		sessionType:      config.SessionType,
@@ -173,17 +191,22 @@ func NewSecureContext(config SecureContextConfig) (*SecureContext, error) {
 		localNodeID:      config.LocalNodeID,
 		sessionTimestamp: now,
 		activeTimestamp:  now,
+		establishedAt:    now,
 		params:           config.Params.WithDefaults(),
+		clock:            clk,
 	}
 
 	// Copy keys (don't hold references to caller's slices)
 	copy(ctx.i2rKey, config.I2RKey)
 	copy(ctx.r2iKey, config.R2IKey)
 
+	if len(config.AttestationChallenge) > 0 {
+		ctx.attestationChallenge = append([]byte(nil), config.AttestationChallenge...)
+	}
+
 	// Copy shared secret if provided (CASE only)
 	if len(config.SharedSecret) > 0 {
-		ctx.sharedSecret = make([]byte, len(config.SharedSecret))
-		copy(ctx.sharedSecret, config.SharedSecret)
+		ctx.sharedSecret = crypto.NewSecretBuffer(config.SharedSecret)
 	}
 
 	// Copy CATs (up to 3)
@@ -262,7 +285,8 @@ func (s *SecureContext) LocalNodeID() fabric.NodeID {
 }
 
 // Encrypt encrypts a message for transmission.
-// Returns the complete encrypted frame bytes.
+// Returns the complete encrypted frame bytes, in a pooled buffer the caller
+// may hand back with ReleaseMessageBuffer once the message has been sent.
 //
 // The header's SessionID will be set to the peer's session ID.
 // The header's MessageCounter will be set from the local counter.
@@ -270,6 +294,10 @@ func (s *SecureContext) Encrypt(header *message.MessageHeader, protocol *message
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if s.encryptCodec == nil {
+		return nil, ErrKeysZeroized
+	}
+
 	// Get next message counter
 	counter, err := s.localCounter.Next()
 	if err != nil {
@@ -281,13 +309,13 @@ func (s *SecureContext) Encrypt(header *message.MessageHeader, protocol *message
 	header.MessageCounter = counter
 
 	// Encrypt using the appropriate codec
-	encrypted, err := s.encryptCodec.Encode(header, protocol, payload, privacy)
+	encrypted, err := s.encryptCodec.EncodeTo(getMessageBuffer(), header, protocol, payload, privacy)
 	if err != nil {
 		return nil, err
 	}
 
 	// Update timestamp
-	s.sessionTimestamp = time.Now()
+	s.sessionTimestamp = s.clock.Now()
 
 	return encrypted, nil
 }
@@ -296,6 +324,9 @@ func (s *SecureContext) Encrypt(header *message.MessageHeader, protocol *message
 // Returns the decrypted frame with protocol header and payload.
 //
 // The message counter is verified against the reception state for replay detection.
+//
+// The payload is decrypted into a buffer of its own rather than a pooled
+// one, since handlers keep received payloads, e.g. CASE for its transcript.
 func (s *SecureContext) Decrypt(data []byte) (*message.Frame, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -306,11 +337,18 @@ func (s *SecureContext) Decrypt(data []byte) (*message.Frame, error) {
 		peerNodeIDForNonce = 0
 	}
 
+	if s.decryptCodec == nil {
+		return nil, ErrKeysZeroized
+	}
+
 	// Decrypt using the appropriate codec
-	frame, err := s.decryptCodec.Decode(data, peerNodeIDForNonce)
-	if err != nil {
+	frame := &message.Frame{Payload: make([]byte, 0, len(data))}
+	if err := s.decryptCodec.DecodeInto(frame, data, peerNodeIDForNonce); err != nil {
 		return nil, ErrDecryptionFailed
 	}
+	if len(frame.Payload) == 0 {
+		frame.Payload = nil
+	}
 
 	// Verify message counter for replay
 	if !s.receptionState.CheckAndAccept(frame.Header.MessageCounter, false) {
@@ -318,13 +356,39 @@ func (s *SecureContext) Decrypt(data []byte) (*message.Frame, error) {
 	}
 
 	// Update timestamps
-	now := time.Now()
+	now := s.clock.Now()
 	s.sessionTimestamp = now
 	s.activeTimestamp = now
 
 	return frame, nil
 }
 
+// Open decrypts a frame sent or received on this session without checking
+// or updating message counters and timestamps. Outbound frames are opened
+// with the encryption key. It is intended for diagnostics such as packet
+// capture; incoming messages must be processed with Decrypt.
+func (s *SecureContext) Open(data []byte, outbound bool) (*message.Frame, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	codec, nodeID := s.decryptCodec, uint64(s.peerNodeID)
+	if outbound {
+		codec, nodeID = s.encryptCodec, uint64(s.localNodeID)
+	}
+	if s.sessionType == SessionTypePASE {
+		nodeID = 0
+	}
+	if codec == nil {
+		return nil, ErrKeysZeroized
+	}
+
+	frame, err := codec.Decode(data, nodeID)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	return frame, nil
+}
+
 // NextCounter returns and increments the local message counter.
 // Returns ErrCounterExhausted if the counter has wrapped.
 func (s *SecureContext) NextCounter() (uint32, error) {
@@ -337,6 +401,15 @@ func (s *SecureContext) NextCounter() (uint32, error) {
 	return counter, nil
 }
 
+// CounterRemaining returns how many messages the session can still send
+// before its message counter is exhausted and the session must be
+// re-established.
+func (s *SecureContext) CounterRemaining() uint32 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.localCounter.Remaining()
+}
+
 // CheckCounter verifies an incoming message counter for replay.
 // Returns true if the message should be accepted.
 func (s *SecureContext) CheckCounter(counter uint32) bool {
@@ -345,13 +418,15 @@ func (s *SecureContext) CheckCounter(counter uint32) bool {
 	return s.receptionState.CheckAndAccept(counter, false)
 }
 
-// IsPeerActive returns whether the peer is in active mode.
-// Used for MRP retransmission timing.
+// IsPeerActive returns whether the peer is in active mode: a message was
+// received from it within its ActiveThreshold. MRP retransmits to an
+// active peer after its ActiveInterval and to an idle one after its
+// IdleInterval.
 // Per Spec 4.13.3.1 field 15d: PeerActiveMode = (now - ActiveTimestamp) < ActiveThreshold
 func (s *SecureContext) IsPeerActive() bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return time.Since(s.activeTimestamp) < s.params.ActiveThreshold
+	return s.clock.Now().Sub(s.activeTimestamp) < s.params.ActiveThreshold
 }
 
 // MarkActivity updates timestamps on message send/receive.
@@ -359,7 +434,7 @@ func (s *SecureContext) IsPeerActive() bool {
 func (s *SecureContext) MarkActivity(isReceive bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	now := time.Now()
+	now := s.clock.Now()
 	s.sessionTimestamp = now
 	if isReceive {
 		s.activeTimestamp = now
@@ -403,9 +478,19 @@ func (s *SecureContext) SharedSecret() []byte {
 		return nil
 	}
 	// Return a copy to prevent modification
-	result := make([]byte, len(s.sharedSecret))
-	copy(result, s.sharedSecret)
-	return result
+	return s.sharedSecret.Bytes()
+}
+
+// AttestationChallenge returns the attestation challenge of the session.
+// Returns nil if the session was created without one.
+func (s *SecureContext) AttestationChallenge() []byte {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.attestationChallenge == nil {
+		return nil
+	}
+	// Return a copy to prevent modification
+	return append([]byte(nil), s.attestationChallenge...)
 }
 
 // CaseAuthTags returns the CASE Authenticated Tags.
@@ -436,6 +521,11 @@ func (s *SecureContext) ActiveTimestamp() time.Time {
 	return s.activeTimestamp
 }
 
+// EstablishedAt returns the time the session was established.
+func (s *SecureContext) EstablishedAt() time.Time {
+	return s.establishedAt
+}
+
 // getEncryptKey returns the key used for encrypting outgoing messages.
 func (s *SecureContext) getEncryptKey() []byte {
 	if s.role == SessionRoleInitiator {
@@ -458,17 +548,16 @@ func (s *SecureContext) ZeroizeKeys() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// Clear keys
-	for i := range s.i2rKey {
-		s.i2rKey[i] = 0
+	// Clear keys, including the copies held by the codecs
+	crypto.Zeroize(s.i2rKey, s.r2iKey, s.attestationChallenge)
+	if s.sharedSecret != nil {
+		s.sharedSecret.Close()
 	}
-	for i := range s.r2iKey {
-		s.r2iKey[i] = 0
+	if s.encryptCodec != nil {
+		s.encryptCodec.Zeroize()
 	}
-	if s.sharedSecret != nil {
-		for i := range s.sharedSecret {
-			s.sharedSecret[i] = 0
-		}
+	if s.decryptCodec != nil {
+		s.decryptCodec.Zeroize()
 	}
 
 	// Invalidate codecs
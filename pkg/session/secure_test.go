@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/backkem/matter/pkg/clock"
 	"github.com/backkem/matter/pkg/fabric"
 	"github.com/backkem/matter/pkg/message"
 )
@@ -287,6 +288,65 @@ func TestSecureContext_IsPeerActive(t *testing.T) {
 	}
 }
 
+func TestSecureContext_PeerActivity(t *testing.T) {
+	clk := clock.NewFakeClock(time.Unix(0, 0))
+	params := Params{ActiveThreshold: 4 * time.Second}
+	local, _ := NewSecureContext(SecureContextConfig{
+		SessionType:    SessionTypePASE,
+		Role:           SessionRoleInitiator,
+		LocalSessionID: 1,
+		PeerSessionID:  2,
+		I2RKey:         testI2RKey,
+		R2IKey:         testR2IKey,
+		Params:         params,
+		Clock:          clk,
+	})
+	peer, _ := NewSecureContext(SecureContextConfig{
+		SessionType:    SessionTypePASE,
+		Role:           SessionRoleResponder,
+		LocalSessionID: 2,
+		PeerSessionID:  1,
+		I2RKey:         testI2RKey,
+		R2IKey:         testR2IKey,
+	})
+
+	clk.Advance(params.ActiveThreshold)
+	if local.IsPeerActive() {
+		t.Fatal("IsPeerActive() = true after ActiveThreshold")
+	}
+
+	// Sending does not make the peer active
+	if _, err := local.Encrypt(&message.MessageHeader{}, &message.ProtocolHeader{}, nil, false); err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if local.IsPeerActive() {
+		t.Error("IsPeerActive() = true after sending")
+	}
+	if !local.SessionTimestamp().Equal(clk.Now()) {
+		t.Errorf("SessionTimestamp() = %v, want %v", local.SessionTimestamp(), clk.Now())
+	}
+
+	// Receiving does, until ActiveThreshold passes without another message
+	data, err := peer.Encrypt(&message.MessageHeader{}, &message.ProtocolHeader{}, nil, false)
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if _, err := local.Decrypt(data); err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	if !local.IsPeerActive() {
+		t.Error("IsPeerActive() = false after receiving")
+	}
+	clk.Advance(params.ActiveThreshold - time.Millisecond)
+	if !local.IsPeerActive() {
+		t.Error("IsPeerActive() = false within ActiveThreshold")
+	}
+	clk.Advance(time.Millisecond)
+	if local.IsPeerActive() {
+		t.Error("IsPeerActive() = true after ActiveThreshold")
+	}
+}
+
 func TestSecureContext_Timestamps(t *testing.T) {
 	before := time.Now()
 	ctx, _ := NewSecureContext(SecureContextConfig{
@@ -336,6 +396,35 @@ func TestSecureContext_NextCounter(t *testing.T) {
 	}
 }
 
+func TestSecureContext_CounterRemaining(t *testing.T) {
+	clk := clock.NewFakeClock(time.Unix(1000, 0))
+	ctx, _ := NewSecureContext(SecureContextConfig{
+		SessionType:    SessionTypeCASE,
+		Role:           SessionRoleInitiator,
+		LocalSessionID: 1,
+		PeerSessionID:  2,
+		I2RKey:         testI2RKey,
+		R2IKey:         testR2IKey,
+		Clock:          clk,
+	})
+	clk.Advance(time.Minute)
+
+	if got := ctx.EstablishedAt(); !got.Equal(time.Unix(1000, 0)) {
+		t.Errorf("EstablishedAt() = %v, want %v", got, time.Unix(1000, 0))
+	}
+
+	// A new counter starts in [1, 2^28]
+	if got := ctx.CounterRemaining(); got < 1<<32-1<<28-1 {
+		t.Errorf("CounterRemaining() = %d for a new session", got)
+	}
+
+	ctx.localCounter = message.NewSessionCounterWithValue(0xFFFFFFF0)
+	ctx.NextCounter()
+	if got := ctx.CounterRemaining(); got != 15 {
+		t.Errorf("CounterRemaining() = %d, want 15", got)
+	}
+}
+
 func TestSecureContext_CheckCounter(t *testing.T) {
 	ctx, _ := NewSecureContext(SecureContextConfig{
 		SessionType:    SessionTypePASE,
@@ -388,11 +477,8 @@ func TestSecureContext_ZeroizeKeys(t *testing.T) {
 			break
 		}
 	}
-	for _, b := range ctx.sharedSecret {
-		if b != 0 {
-			t.Error("sharedSecret should be zeroed")
-			break
-		}
+	if secret := ctx.SharedSecret(); secret != nil {
+		t.Errorf("SharedSecret() = % X after ZeroizeKeys", secret)
 	}
 
 	// Codecs should be nil
@@ -402,6 +488,17 @@ func TestSecureContext_ZeroizeKeys(t *testing.T) {
 	if ctx.decryptCodec != nil {
 		t.Error("decryptCodec should be nil after ZeroizeKeys")
 	}
+
+	// The session can no longer be used
+	if _, err := ctx.Encrypt(&message.MessageHeader{}, &message.ProtocolHeader{}, nil, false); err != ErrKeysZeroized {
+		t.Errorf("Encrypt() error = %v, want ErrKeysZeroized", err)
+	}
+	if _, err := ctx.Decrypt([]byte{0x00}); err != ErrKeysZeroized {
+		t.Errorf("Decrypt() error = %v, want ErrKeysZeroized", err)
+	}
+	if _, err := ctx.Open([]byte{0x00}, true); err != ErrKeysZeroized {
+		t.Errorf("Open() error = %v, want ErrKeysZeroized", err)
+	}
 }
 
 func TestSecureContext_EncryptDecrypt_Roundtrip(t *testing.T) {
@@ -506,6 +603,53 @@ func TestSecureContext_EncryptDecrypt_ReverseDirection(t *testing.T) {
 	}
 }
 
+func TestSecureContext_Open(t *testing.T) {
+	initiator, _ := NewSecureContext(SecureContextConfig{
+		SessionType:    SessionTypePASE,
+		Role:           SessionRoleInitiator,
+		LocalSessionID: 1,
+		PeerSessionID:  2,
+		I2RKey:         testI2RKey,
+		R2IKey:         testR2IKey,
+	})
+	responder, _ := NewSecureContext(SecureContextConfig{
+		SessionType:    SessionTypePASE,
+		Role:           SessionRoleResponder,
+		LocalSessionID: 2,
+		PeerSessionID:  1,
+		I2RKey:         testI2RKey,
+		R2IKey:         testR2IKey,
+	})
+
+	payload := []byte("captured")
+	header := &message.MessageHeader{SessionType: message.SessionTypeUnicast}
+	protocol := &message.ProtocolHeader{ProtocolID: message.ProtocolInteractionModel, ProtocolOpcode: 0x02}
+	encrypted, err := initiator.Encrypt(header, protocol, payload, false)
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	// The sender opens its own frame with the encryption key.
+	frame, err := initiator.Open(encrypted, true)
+	if err != nil {
+		t.Fatalf("Open(outbound) error = %v", err)
+	}
+	if !bytes.Equal(frame.Payload, payload) {
+		t.Errorf("Open(outbound) payload = %q, want %q", frame.Payload, payload)
+	}
+	if _, err := initiator.Open(encrypted, false); err != ErrDecryptionFailed {
+		t.Errorf("Open(inbound) on sender error = %v, want ErrDecryptionFailed", err)
+	}
+
+	// Opening does not consume the counter, so Decrypt still accepts it.
+	if _, err := responder.Open(encrypted, false); err != nil {
+		t.Fatalf("Open(inbound) error = %v", err)
+	}
+	if _, err := responder.Decrypt(encrypted); err != nil {
+		t.Errorf("Decrypt() after Open error = %v", err)
+	}
+}
+
 func TestSecureContext_Encrypt_SetsHeaderFields(t *testing.T) {
 	ctx, _ := NewSecureContext(SecureContextConfig{
 		SessionType:    SessionTypePASE,
@@ -532,3 +676,86 @@ func TestSecureContext_Encrypt_SetsHeaderFields(t *testing.T) {
 		t.Error("header.MessageCounter should be non-zero")
 	}
 }
+
+func TestSecureContext_Encrypt_ReleaseMessageBuffer(t *testing.T) {
+	initiator, responder := benchSessionPair()
+	header, protocol := &message.MessageHeader{}, &message.ProtocolHeader{ExchangeID: 100}
+
+	encrypted, err := initiator.Encrypt(header, protocol, []byte("first"), false)
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if cap(encrypted) != messageBufferSize {
+		t.Errorf("cap(Encrypt()) = %d, want pooled buffer of %d", cap(encrypted), messageBufferSize)
+	}
+	frame, err := responder.Decrypt(encrypted)
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	ReleaseMessageBuffer(encrypted)
+
+	// The received payload does not share the released buffer.
+	if _, err := initiator.Encrypt(header, protocol, []byte("second"), false); err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if string(frame.Payload) != "first" {
+		t.Errorf("payload = %q after buffer reuse, want %q", frame.Payload, "first")
+	}
+
+	// Buffers not from the pool are ignored.
+	ReleaseMessageBuffer(make([]byte, 10))
+	ReleaseMessageBuffer(nil)
+}
+
+func benchSessionPair() (initiator, responder *SecureContext) {
+	initiator, _ = NewSecureContext(SecureContextConfig{
+		SessionType:    SessionTypeCASE,
+		Role:           SessionRoleInitiator,
+		LocalSessionID: 1,
+		PeerSessionID:  2,
+		I2RKey:         testI2RKey,
+		R2IKey:         testR2IKey,
+		LocalNodeID:    0x1111,
+		PeerNodeID:     0x2222,
+	})
+	responder, _ = NewSecureContext(SecureContextConfig{
+		SessionType:    SessionTypeCASE,
+		Role:           SessionRoleResponder,
+		LocalSessionID: 2,
+		PeerSessionID:  1,
+		I2RKey:         testI2RKey,
+		R2IKey:         testR2IKey,
+		LocalNodeID:    0x2222,
+		PeerNodeID:     0x1111,
+	})
+	return initiator, responder
+}
+
+// BenchmarkSecureContextSendReceive measures a message through the session
+// path the exchange layer uses: Encrypt into a pooled buffer, Decrypt on the
+// peer, then release the buffer as after sending.
+func BenchmarkSecureContextSendReceive(b *testing.B) {
+	initiator, responder := benchSessionPair()
+	payload := bytes.Repeat([]byte{0x15}, 64)
+	protocol := &message.ProtocolHeader{
+		ProtocolID:     message.ProtocolInteractionModel,
+		ProtocolOpcode: 0x08,
+		ExchangeID:     0x4242,
+		Initiator:      true,
+		Reliability:    true,
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var header message.MessageHeader
+		encrypted, err := initiator.Encrypt(&header, protocol, payload, false)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := responder.Decrypt(encrypted); err != nil {
+			b.Fatal(err)
+		}
+		ReleaseMessageBuffer(encrypted)
+	}
+}
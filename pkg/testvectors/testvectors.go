@@ -0,0 +1,268 @@
+// Package testvectors holds known-answer vectors for the Matter security
+// primitives: SPAKE2+, the CASE key schedule, destination identifiers and
+// message encryption.
+//
+// The stack's own tests check against these vectors, and they are exported
+// so that other implementations, or builds of this one with a different
+// crypto backend, can be verified the same way.
+//
+// Every vector names its source. Vectors from the Matter specification or
+// the C SDK are reproduced byte for byte. The spec publishes no vectors for
+// the CASE Sigma keys, so CASEKeys was computed independently from the
+// spec's HKDF formulas over fixed inputs.
+//
+// The vectors are shared; callers must not modify them.
+package testvectors
+
+import "encoding/hex"
+
+// SPAKE2PVector is a SPAKE2+ (P256-SHA256-HKDF-HMAC) exchange with fixed
+// random scalars.
+//
+// Spec: Section 3.10
+type SPAKE2PVector struct {
+	Name string
+
+	Context          []byte
+	ProverIdentity   []byte
+	VerifierIdentity []byte
+	W0               []byte
+	W1               []byte
+	L                []byte
+
+	// ProverRandom (x) and VerifierRandom (y) are the random scalars.
+	ProverRandom   []byte
+	VerifierRandom []byte
+
+	// ProverShare (X = x*P + w0*M) and VerifierShare (Y = y*P + w0*N) are
+	// the exchanged shares.
+	ProverShare   []byte
+	VerifierShare []byte
+
+	Z []byte
+	V []byte
+
+	// Ka, KcA and KcB are nil where the vector does not record them.
+	Ka  []byte
+	Ke  []byte
+	KcA []byte
+	KcB []byte
+
+	// ProverConfirmation is HMAC(KcA, Y) and VerifierConfirmation is
+	// HMAC(KcB, X).
+	ProverConfirmation   []byte
+	VerifierConfirmation []byte
+}
+
+// SPAKE2P are the SPAKE2+ vectors of the C SDK
+// (src/crypto/tests/SPAKE2P_RFC_test_vectors.h), which follow the
+// SPAKE2+ draft-01 test vectors. They share W0, W1 and L and differ in the
+// identities.
+var SPAKE2P = []SPAKE2PVector{
+	{
+		Name:                 "both identities",
+		Context:              spake2pContext,
+		ProverIdentity:       []byte("client"),
+		VerifierIdentity:     []byte("server"),
+		W0:                   spake2pW0,
+		W1:                   spake2pW1,
+		L:                    spake2pL,
+		ProverRandom:         h("8b0f3f383905cf3a3bb955ef8fb62e24849dd349a05ca79aafb18041d30cbdb6"),
+		VerifierRandom:       h("2e0895b0e763d6d5a9564433e64ac3cac74ff897f6c3445247ba1bab40082a91"),
+		ProverShare:          h("04af09987a593d3bac8694b123839422c3cc87e37d6b41c1d630f000dd64980e537ae704bcede04ea3bec9b7475b32fa2ca3b684be14d11645e38ea6609eb39e7e"),
+		VerifierShare:        h("04417592620aebf9fd203616bbb9f121b730c258b286f890c5f19fea833a9c900cbe9057bc549a3e19975be9927f0e7614f08d1f0a108eede5fd7eb5624584a4f4"),
+		Z:                    h("0471a35282d2026f36bf3ceb38fcf87e3112a4452f46e9f7b47fd769cfb570145b62589c76b7aa1eb6080a832e5332c36898426912e29c40ef9e9c742eee82bf30"),
+		V:                    h("046718981bf15bc4db538fc1f1c1d058cb0eececf1dbe1b1ea08a4e25275d382e82b348c8131d8ed669d169c2e03a858db7cf6ca2853a4071251a39fbe8cfc39bc"),
+		Ka:                   h("f9cab9adcc0ed8e5a4db11a8505914b2"),
+		Ke:                   h("801db297654816eb4f02868129b9dc89"),
+		KcA:                  h("0d248d7d19234f1486b2efba5179c52d"),
+		KcB:                  h("556291df26d705a2caedd6474dd0079b"),
+		ProverConfirmation:   h("d4376f2da9c72226dd151b77c2919071155fc22a2068d90b5faa6c78c11e77dd"),
+		VerifierConfirmation: h("0660a680663e8c5695956fb22dff298b1d07a526cf3cc591adfecd1f6ef6e02e"),
+	},
+	{
+		Name:                 "empty verifier identity",
+		Context:              spake2pContext,
+		ProverIdentity:       []byte("client"),
+		VerifierIdentity:     []byte{},
+		W0:                   spake2pW0,
+		W1:                   spake2pW1,
+		L:                    spake2pL,
+		ProverRandom:         h("ec82d9258337f61239c9cd68e8e532a3a6b83d12d2b1ca5d543f44def17dfb8d"),
+		VerifierRandom:       h("eac3f7de4b198d5fe25c443c0cd4963807add767815dd02a6f0133b4bc2c9eb0"),
+		ProverShare:          h("04230779960824076d3666a7418e4d433e2fa15b06176eabdd572f43a32ecc79a192b243d2624310a7356273b86e5fd9bd627d3ade762baeff1a320d4ad7a4e47f"),
+		VerifierShare:        h("044558642e71b616b248c9583bd6d7aa1b3952c6df6a9f7492a06035ca5d92522d84443de7aa20a59380fa4de6b7438d925dbfb7f1cfe60d79acf961ee33988c7d"),
+		Z:                    h("04b4e8770f19f58ddf83f9220c3a9305792665e0c60989e6ee9d7fa449c775d6395f6f25f307e3903ac045a013fbb5a676e872a6abfcf4d7bb5aac69efd6140eed"),
+		V:                    h("04141db83bc7d96f41b636622e7a5c552ad83211ff55319ac25ed0a09f0818bd942e8150319bfbfa686183806dc61911183f6a0f5956156023d96e0f93d275bf50"),
+		Ke:                   h("6989d8f9177ef7df67da437987f07255"),
+		ProverConfirmation:   h("e1b9258807ba4750dae1d7f3c3c294f13dc4fa60cde346d5de7d200e2f8fd3fc"),
+		VerifierConfirmation: h("b9c39dfa49c47757de778d9bedeaca2448b905be19a43b94ee24b770208135e3"),
+	},
+	{
+		Name:                 "empty prover identity",
+		Context:              spake2pContext,
+		ProverIdentity:       []byte{},
+		VerifierIdentity:     []byte("server"),
+		W0:                   spake2pW0,
+		W1:                   spake2pW1,
+		L:                    spake2pL,
+		ProverRandom:         h("ba0f0f5b78ef23fd07868e46aeca63b51fda519a3420501acbe23d53c2918748"),
+		VerifierRandom:       h("39397fbe6db47e9fbd1a263d79f5d0aaa44df26ce755f78e092644b434533a42"),
+		ProverShare:          h("04c14d28f4370fea20745106cea58bcfb60f2949fa4e131b9aff5ea13fd5aa79d507ae1d229e447e000f15eb78a9a32c2b88652e3411642043c1b2b7992cf2d4de"),
+		VerifierShare:        h("04d1bee3120fd87e86fe189cb952dc688823080e62524dd2c08dffe3d22a0a8986aa64c9fe0191033cafbc9bcaefc8e2ba8ba860cd127af9efdd7f1c3a41920fe8"),
+		Z:                    h("04aac71cf4c8df8181b867c9ecbee9d0963caf51f1534a823429c26fe5248313ffc5c5e44ea8162161ab6b3d73b87704a45889bf6343d96fa96cd1641efa71607c"),
+		V:                    h("04c7c9505365f7ce57293c92a37f1bbdc68e0322901e61edef59fee7876b17b063e0fa4a126eae0a671b37f1464cf1ccad591c33ae944e3b1f318d76e36fea9966"),
+		Ke:                   h("2ea40e4badfa5452b5744dc5983e99ba"),
+		ProverConfirmation:   h("e564c93b3015efb946dc16d642bbe7d1c8da5be164ed9fc3bae4e0ff86e1bd3c"),
+		VerifierConfirmation: h("072a94d9a54edc201d8891534c2317cadf3ea3792827f479e873f93e90f21552"),
+	},
+	{
+		Name:                 "no identities",
+		Context:              spake2pContext,
+		ProverIdentity:       []byte{},
+		VerifierIdentity:     []byte{},
+		W0:                   spake2pW0,
+		W1:                   spake2pW1,
+		L:                    spake2pL,
+		ProverRandom:         h("5b478619804f4938d361fbba3a20648725222f0a54cc4c876139efe7d9a21786"),
+		VerifierRandom:       h("766770dad8c8eecba936823c0aed044b8c3c4f7655e8beec44a15dcbcaf78e5e"),
+		ProverShare:          h("04a6db23d001723fb01fcfc9d08746c3c2a0a3feff8635d29cad2853e7358623425cf39712e928054561ba71e2dc11f300f1760e71eb177021a8f85e78689071cd"),
+		VerifierShare:        h("04390d29bf185c3abf99f150ae7c13388c82b6be0c07b1b8d90d26853e84374bbdc82becdb978ca3792f472424106a2578012752c11938fcf60a41df75ff7cf947"),
+		Z:                    h("040a150d9a62f514c9a1fedd782a0240a342721046cefb1111c3adb3be893ce9fcd2ffa137922fcf8a588d0f76ba9c55c85da2af3f1c789ca17976810387fb1d7e"),
+		V:                    h("04f8e247cc263a1846272f5a3b61b68aa60a5a2665d10cd22c89cd6bad05dc0e5e650f21ff017186cc92651a4cd7e66ce88f529299f340ea80fb90a9bad094e1a6"),
+		Ke:                   h("ea3276d68334576097e04b19ee5a3a8b"),
+		ProverConfirmation:   h("71d9412779b6c45a2c615c9df3f1fd93dc0aaf63104da8ece4aa1b5a3a415fea"),
+		VerifierConfirmation: h("095dc0400355cc233fde7437811815b3c1524aae80fd4e6810cf531cf11d20e3"),
+	},
+}
+
+var (
+	spake2pContext = []byte("SPAKE2+-P256-SHA256-HKDF draft-01")
+	spake2pW0      = h("e6887cf9bdfb7579c69bf47928a84514b5e355ac034863f7ffaf4390e67d798c")
+	spake2pW1      = h("24b5ae4abda868ec9336ffc3b78ee31c5755bef1759227ef5372ca139b94e512")
+	spake2pL       = h("0495645cfb74df6e58f9748bb83a86620bab7c82e107f57d6870da8cbcb2ff9f7063a14b6402c62f99afcb9706a4d1a143273259fe76f1c605a3639745a92154b9")
+)
+
+// DestinationIDVector derives a Sigma1 destination identifier from a root
+// public key, walking the compressed fabric ID and the IPK on the way.
+//
+// Spec: Sections 4.3.2.2 and 4.14.2.4.1
+type DestinationIDVector struct {
+	Name string
+
+	RootPublicKey      []byte
+	FabricID           uint64
+	NodeID             uint64
+	CompressedFabricID []byte
+
+	// EpochKey is the IPK epoch key and IPK the operational group key
+	// derived from it.
+	EpochKey []byte
+	IPK      []byte
+
+	InitiatorRandom []byte
+	DestinationID   []byte
+}
+
+// DestinationID is the destination identifier example of the
+// specification.
+var DestinationID = []DestinationIDVector{
+	{
+		Name:               "spec example",
+		RootPublicKey:      h("044a9f42b1ca4840d37292bbc7f6a7e11e22200c976fc900dbc98a7a383a641cb8254a2e56d4e295a847943b4e3897c4a773e930277b4d9fbede8a052686bfacfa"),
+		FabricID:           0x2906C908D115D362,
+		NodeID:             0xCD5544AA7B13EF14,
+		CompressedFabricID: h("87e1b004e235a130"),
+		EpochKey:           h("4a71cdd7b2a3ca9024f96f3c96a19dee"),
+		IPK:                h("9bc61cd9c62a2df6d64dfcaa9dc472d4"),
+		InitiatorRandom:    h("7e171231568dfa17206b3accf8faec2f4d21b580113196f47c7c4deb810a73dc"),
+		DestinationID:      h("dc35dd5fc9134cc5544538c9c3fc4297c1ec3370c839136a80e10796451d4c53"),
+	},
+}
+
+// CASEKeysVector is the CASE key schedule for one handshake: the Sigma2
+// and Sigma3 encryption keys and the session keys.
+//
+// Spec: Section 4.14.2.6
+type CASEKeysVector struct {
+	Name string
+
+	SharedSecret       []byte
+	IPK                []byte
+	ResponderRandom    []byte
+	ResponderEphPubKey []byte
+
+	// Sigma1, Sigma2 and Sigma3 are the encoded messages hashed into the
+	// transcript.
+	Sigma1 []byte
+	Sigma2 []byte
+	Sigma3 []byte
+
+	S2K                  []byte
+	S3K                  []byte
+	I2RKey               []byte
+	R2IKey               []byte
+	AttestationChallenge []byte
+}
+
+// CASEKeys was computed with an independent HKDF-SHA256 over the inputs
+// shown. The shared secret is SHA-256("CASE shared secret"); the IPK and
+// the responder's ephemeral key are taken from the DestinationID example.
+var CASEKeys = []CASEKeysVector{
+	{
+		Name:                 "fixed inputs",
+		SharedSecret:         h("baeba5a4cfdccfd7fcdee8280d8aa7f637d9c654e850f68a03a444ba047f0bcb"),
+		IPK:                  h("9bc61cd9c62a2df6d64dfcaa9dc472d4"),
+		ResponderRandom:      h("202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f"),
+		ResponderEphPubKey:   h("044a9f42b1ca4840d37292bbc7f6a7e11e22200c976fc900dbc98a7a383a641cb8254a2e56d4e295a847943b4e3897c4a773e930277b4d9fbede8a052686bfacfa"),
+		Sigma1:               h("152401012502341218"),
+		Sigma2:               h("152401022502785618"),
+		Sigma3:               h("153003040102030418"),
+		S2K:                  h("6f950eb32b4e713c12604a496c3a74c3"),
+		S3K:                  h("4abe09b251d45f501df38deae2e46f39"),
+		I2RKey:               h("c07a2901a6dd016c3776f1049a530f68"),
+		R2IKey:               h("489f1117593a2c8bbfa2c4500c2219c7"),
+		AttestationChallenge: h("b7ac984b9f11e6c5b021ed445c0b2a37"),
+	},
+}
+
+// MessageVector is a message encrypted with a session key.
+//
+// Spec: Sections 4.8.2 and 4.8.3
+type MessageVector struct {
+	Name string
+
+	Key          []byte
+	SourceNodeID uint64
+
+	// Plain is the message header, protocol header and payload in the
+	// clear; Encrypted is the same message as sent, with its MIC.
+	Plain     []byte
+	Encrypted []byte
+}
+
+// Messages are PASE session messages from the C SDK
+// (src/transport/tests/TestSessionManagerDispatch.cpp).
+var Messages = []MessageVector{
+	{
+		Name:      "secure pase message (no payload)",
+		Key:       h("5eded244e5532b3cdc23409dbad052d2"),
+		Plain:     h("00b80b0039300000" + "0564ee0e207d"),
+		Encrypted: h("00b80b0039300000" + "5a989ae42e8d" + "847f535c3007e6150cd65867f2b817db"),
+	},
+	{
+		Name:      "secure pase message (short payload)",
+		Key:       h("5eded244e5532b3cdc23409dbad052d2"),
+		Plain:     h("00b80b0039300000" + "0564ee0e207d" + "1122334455"),
+		Encrypted: h("00b80b0039300000" + "5a989ae42e8d0f7f885dfb" + "2faa8949cf730a5728e0354610a0c4a7"),
+	},
+}
+
+// h decodes a hex literal.
+func h(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic("testvectors: " + err.Error())
+	}
+	return b
+}
@@ -44,4 +44,34 @@ var (
 
 	// ErrOverflow is returned when a value overflows the target type.
 	ErrOverflow = errors.New("tlv: value overflow")
+
+	// ErrDuplicateTag is returned by Validate when a structure has two
+	// members with the same tag.
+	ErrDuplicateTag = errors.New("tlv: duplicate tag in structure")
+
+	// ErrMaxDepthExceeded is returned by Validate when containers nest
+	// deeper than allowed.
+	ErrMaxDepthExceeded = errors.New("tlv: maximum container depth exceeded")
+
+	// ErrStringTooLong is returned by Validate when a string is longer than
+	// allowed.
+	ErrStringTooLong = errors.New("tlv: string too long")
+
+	// ErrTrailingData is returned by Validate when bytes follow the element.
+	ErrTrailingData = errors.New("tlv: trailing data after element")
+
+	// ErrUnsupportedType is returned when Marshal or Unmarshal meets a Go
+	// type with no TLV mapping.
+	ErrUnsupportedType = errors.New("tlv: unsupported type")
+
+	// ErrInvalidStructTag is returned for a malformed tlv struct tag.
+	ErrInvalidStructTag = errors.New("tlv: invalid struct tag")
+
+	// ErrMissingField is returned when Unmarshal finds no member for a
+	// mandatory struct field.
+	ErrMissingField = errors.New("tlv: missing mandatory field")
+
+	// ErrInvalidDecodeTarget is returned when Unmarshal is not given a
+	// non-nil pointer.
+	ErrInvalidDecodeTarget = errors.New("tlv: decode target must be a non-nil pointer")
 )
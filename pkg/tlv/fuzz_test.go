@@ -0,0 +1,104 @@
+package tlv
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// FuzzTLVReader walks arbitrary input with the Reader, reading every
+// element as its own type, and checks that it never panics. Elements that
+// decode are re-encoded with RawBytes and must decode the same way again.
+// The slice-backed reader must agree with the io.Reader-backed one, and
+// Validate must not panic either.
+//
+// Seeds: Spec Appendix A.12 test vectors (Tables 125-127).
+func FuzzTLVReader(f *testing.F) {
+	for _, v := range table125Vectors {
+		f.Add(v.encoding)
+	}
+	for _, v := range table126Vectors {
+		f.Add(v.encoding)
+	}
+	for _, v := range table127Vectors {
+		f.Add(v.encoding)
+	}
+	// Lengths far beyond the input
+	f.Add([]byte{0x0C, 0xFF})
+	f.Add([]byte{0x13, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		walkTLV(NewReader(bytes.NewReader(data)))
+		walkTLV(NewSliceReader(data))
+		Validate(data, nil)
+
+		r := NewReader(bytes.NewReader(data))
+		if err := r.Next(); err != nil {
+			return
+		}
+		raw, err := r.RawBytes()
+
+		sr := NewSliceReader(data)
+		if err := sr.Next(); err != nil {
+			t.Fatalf("slice reader Next failed: %v", err)
+		}
+		sraw, serr := sr.RawBytes()
+		if (err == nil) != (serr == nil) || !bytes.Equal(raw, sraw) {
+			t.Fatalf("RawBytes: reader = %x, %v; slice reader = %x, %v", raw, err, sraw, serr)
+		}
+		if err != nil {
+			return
+		}
+		again := NewReader(bytes.NewReader(raw))
+		if err := again.Next(); err != nil {
+			t.Fatalf("re-encoded element % X does not decode: %v", raw, err)
+		}
+		if _, err := again.RawBytes(); err != nil {
+			t.Fatalf("re-encoded element % X does not decode: %v", raw, err)
+		}
+	})
+}
+
+// walkTLV reads every element of r until the input or a container ends,
+// ignoring errors.
+func walkTLV(r *Reader) {
+	for {
+		if err := r.Next(); err != nil {
+			return
+		}
+		if r.IsEndOfContainer() {
+			if r.ExitContainer() != nil {
+				return
+			}
+			continue
+		}
+
+		typ := r.Type()
+		switch {
+		case typ.IsContainer():
+			if r.EnterContainer() != nil {
+				return
+			}
+		case typ.IsSignedInt():
+			r.Int()
+		case typ.IsUnsignedInt():
+			r.Uint()
+		case typ.IsBool():
+			r.Bool()
+		case typ == ElementTypeFloat32:
+			r.Float32()
+		case typ == ElementTypeFloat64:
+			r.Float64()
+		case typ.IsUTF8String():
+			if _, err := r.String(); err == io.ErrUnexpectedEOF {
+				return
+			}
+		case typ.IsBytes():
+			if _, err := r.Bytes(); err == io.ErrUnexpectedEOF {
+				return
+			}
+		case typ == ElementTypeNull:
+			r.Null()
+		}
+	}
+}
@@ -0,0 +1,544 @@
+package tlv
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Marshal encodes v as a TLV element with an anonymous tag.
+//
+// Structs are encoded as structures. Each exported field with a tlv struct
+// tag becomes a member with the tag's context tag number; other fields are
+// ignored:
+//
+//	type Target struct {
+//		Node     uint64  `tlv:"1"`
+//		Group    *uint16 `tlv:"2"`                   // optional: omitted when nil
+//		Endpoint *uint16 `tlv:"3,nullable"`          // nullable: null when nil
+//		Cluster  **uint32 `tlv:"4,optional,nullable"` // both
+//		Labels   []string `tlv:"5,optional"`         // omitted when empty
+//	}
+//
+// A pointer field is optional, omitted when nil, unless it is nullable;
+// then nil is encoded as null. An optional and nullable field is a pointer
+// to a pointer: the outer one nil omits it, the inner one nil is null. A
+// non-pointer field with the optional option is omitted when it has its
+// zero value.
+//
+// Other values map to TLV as follows: bool to booleans, signed and unsigned
+// integers to integers of the smallest width, float32 and float64 to
+// floats, string to UTF-8 strings, []byte and byte arrays to octet strings,
+// and other slices and arrays to arrays. A nil pointer elsewhere is null.
+func Marshal(v any) ([]byte, error) {
+	w := NewAppendWriter(nil)
+	if err := w.Encode(Anonymous(), v); err != nil {
+		return nil, err
+	}
+	return w.Bytes(), nil
+}
+
+// Unmarshal decodes the first TLV element of data into v, which must be a
+// non-nil pointer. It is the inverse of Marshal.
+//
+// Structure members are matched to struct fields by context tag; members
+// without a field are skipped. A mandatory field, one that is neither a
+// pointer nor optional, that is missing fails with ErrMissingField.
+// Integers that do not fit their field fail with ErrOverflow.
+//
+// An empty interface receives the Go value of the element's type: int64,
+// uint64, bool, float32, float64, string, []byte or nil; []any for an
+// array or list and map[Tag]any for a structure.
+func Unmarshal(data []byte, v any) error {
+	r := NewSliceReader(data)
+	if err := r.Next(); err != nil {
+		return err
+	}
+	return r.Decode(v)
+}
+
+// Encode writes v as a TLV element with the given tag, as Marshal does.
+func (w *Writer) Encode(tag Tag, v any) error {
+	if v == nil {
+		return w.PutNull(tag)
+	}
+	return w.encodeValue(tag, reflect.ValueOf(v))
+}
+
+// Decode decodes the current element into v, which must be a non-nil
+// pointer, as Unmarshal does. Containers are read to their end.
+func (r *Reader) Decode(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return ErrInvalidDecodeTarget
+	}
+	if !r.hasElement {
+		return ErrNoElement
+	}
+	return r.decodeValue(rv.Elem())
+}
+
+// encodeValue writes v as an element with the given tag.
+func (w *Writer) encodeValue(tag Tag, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return w.PutNull(tag)
+		}
+		return w.encodeValue(tag, v.Elem())
+
+	case reflect.Bool:
+		return w.PutBool(tag, v.Bool())
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return w.PutInt(tag, v.Int())
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return w.PutUint(tag, v.Uint())
+
+	case reflect.Float32:
+		return w.PutFloat32(tag, float32(v.Float()))
+
+	case reflect.Float64:
+		return w.PutFloat64(tag, v.Float())
+
+	case reflect.String:
+		return w.PutString(tag, v.String())
+
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return w.PutBytes(tag, byteSlice(v))
+		}
+		if err := w.StartArray(tag); err != nil {
+			return err
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := w.encodeValue(Anonymous(), v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return w.EndContainer()
+
+	case reflect.Struct:
+		info, err := structInfoFor(v.Type())
+		if err != nil {
+			return err
+		}
+		if err := w.StartStructure(tag); err != nil {
+			return err
+		}
+		for i := range info.fields {
+			if err := w.encodeField(&info.fields[i], v.Field(info.fields[i].index)); err != nil {
+				return err
+			}
+		}
+		return w.EndContainer()
+	}
+
+	return fmt.Errorf("%w: %s", ErrUnsupportedType, v.Type())
+}
+
+// encodeField writes a struct field as a structure member, applying its
+// optional and nullable options.
+func (w *Writer) encodeField(f *fieldInfo, v reflect.Value) error {
+	tag := ContextTag(f.tag)
+
+	if v.Kind() != reflect.Pointer {
+		if f.optional && v.IsZero() {
+			return nil
+		}
+		return w.encodeValue(tag, v)
+	}
+
+	if f.optional && f.nullable {
+		// **T: the outer pointer is presence, the inner one nullness
+		if v.IsNil() {
+			return nil
+		}
+		return w.encodeValue(tag, v.Elem())
+	}
+	if v.IsNil() && !f.nullable {
+		return nil
+	}
+	return w.encodeValue(tag, v)
+}
+
+// byteSlice returns the bytes of a []byte or byte array value.
+func byteSlice(v reflect.Value) []byte {
+	if v.Kind() == reflect.Slice {
+		return v.Bytes()
+	}
+	b := make([]byte, v.Len())
+	reflect.Copy(reflect.ValueOf(b), v)
+	return b
+}
+
+// decodeValue decodes the current element into v.
+func (r *Reader) decodeValue(v reflect.Value) error {
+	if r.elemType == ElementTypeNull {
+		if v.Kind() != reflect.Pointer && v.Kind() != reflect.Interface {
+			return ErrTypeMismatch
+		}
+		v.SetZero()
+		return r.Null()
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return r.decodeValue(v.Elem())
+
+	case reflect.Bool:
+		b, err := r.Bool()
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+		return nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := r.anyInt()
+		if err != nil {
+			return err
+		}
+		if v.OverflowInt(n) {
+			return ErrOverflow
+		}
+		v.SetInt(n)
+		return nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := r.anyUint()
+		if err != nil {
+			return err
+		}
+		if v.OverflowUint(n) {
+			return ErrOverflow
+		}
+		v.SetUint(n)
+		return nil
+
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		var err error
+		if r.elemType == ElementTypeFloat32 {
+			var f32 float32
+			f32, err = r.Float32()
+			f = float64(f32)
+		} else {
+			f, err = r.Float64()
+		}
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+		return nil
+
+	case reflect.String:
+		s, err := r.String()
+		if err != nil {
+			return err
+		}
+		v.SetString(s)
+		return nil
+
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b, err := r.Bytes()
+			if err != nil {
+				return err
+			}
+			// Copy, as a slice-backed reader returns a view of its input
+			v.SetBytes(append(make([]byte, 0, len(b)), b...))
+			return nil
+		}
+		s := reflect.MakeSlice(v.Type(), 0, 0)
+		err := r.decodeMembers(func() error {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := r.decodeValue(elem); err != nil {
+				return err
+			}
+			s = reflect.Append(s, elem)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		v.Set(s)
+		return nil
+
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b, err := r.Bytes()
+			if err != nil {
+				return err
+			}
+			if len(b) != v.Len() {
+				return ErrTypeMismatch
+			}
+			reflect.Copy(v, reflect.ValueOf(b))
+			return nil
+		}
+		v.SetZero()
+		i := 0
+		return r.decodeMembers(func() error {
+			if i >= v.Len() {
+				return ErrOverflow
+			}
+			i++
+			return r.decodeValue(v.Index(i - 1))
+		})
+
+	case reflect.Struct:
+		if r.elemType != ElementTypeStruct {
+			return ErrTypeMismatch
+		}
+		info, err := structInfoFor(v.Type())
+		if err != nil {
+			return err
+		}
+		v.SetZero()
+		var seen uint64 // Bit per field, for the first 64 fields
+		err = r.decodeMembers(func() error {
+			if !r.tag.IsContext() {
+				return r.Skip()
+			}
+			i, ok := info.byTag[uint8(r.tag.TagNumber())]
+			if !ok {
+				return r.Skip()
+			}
+			seen |= 1 << uint(i)
+			return r.decodeField(&info.fields[i], v.Field(info.fields[i].index))
+		})
+		if err != nil {
+			return err
+		}
+		for i := range info.fields {
+			f := &info.fields[i]
+			if f.required && seen&(1<<uint(i)) == 0 {
+				return fmt.Errorf("%w: %s (tag %d)", ErrMissingField, f.name, f.tag)
+			}
+		}
+		return nil
+
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			break
+		}
+		x, err := r.decodeAny()
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(&x).Elem())
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrUnsupportedType, v.Type())
+}
+
+// decodeField decodes the current element into a struct field, applying
+// its optional and nullable options.
+func (r *Reader) decodeField(f *fieldInfo, v reflect.Value) error {
+	if f.optional && f.nullable && r.elemType == ElementTypeNull {
+		// **T: present, but null
+		v.Set(reflect.New(v.Type().Elem()))
+		return r.Null()
+	}
+	return r.decodeValue(v)
+}
+
+// decodeMembers enters the current array, list or structure and calls fn
+// for each member, positioned on it, then exits the container.
+func (r *Reader) decodeMembers(fn func() error) error {
+	if !r.elemType.IsContainer() {
+		return ErrTypeMismatch
+	}
+	if err := r.EnterContainer(); err != nil {
+		return err
+	}
+	for {
+		if err := r.Next(); err != nil {
+			if err == io.EOF {
+				return io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		if r.IsEndOfContainer() {
+			return r.ExitContainer()
+		}
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+}
+
+// decodeAny decodes the current element into the Go value of its type:
+// int64, uint64, bool, float32, float64, string, []byte or nil, []any for
+// an array or list, and map[Tag]any for a structure.
+func (r *Reader) decodeAny() (any, error) {
+	switch t := r.elemType; {
+	case t.IsSignedInt():
+		return r.Int()
+	case t.IsUnsignedInt():
+		return r.Uint()
+	case t.IsBool():
+		return r.Bool()
+	case t == ElementTypeFloat32:
+		return r.Float32()
+	case t == ElementTypeFloat64:
+		return r.Float64()
+	case t.IsUTF8String():
+		return r.String()
+	case t.IsBytes():
+		b, err := r.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		return append(make([]byte, 0, len(b)), b...), nil
+	case t == ElementTypeNull:
+		return nil, r.Null()
+	case t == ElementTypeStruct:
+		m := make(map[Tag]any)
+		err := r.decodeMembers(func() error {
+			tag := r.tag
+			x, err := r.decodeAny()
+			m[tag] = x
+			return err
+		})
+		return m, err
+	case t == ElementTypeArray || t == ElementTypeList:
+		s := []any{}
+		err := r.decodeMembers(func() error {
+			x, err := r.decodeAny()
+			s = append(s, x)
+			return err
+		})
+		return s, err
+	}
+	return nil, ErrTypeMismatch
+}
+
+// anyInt returns the current integer element, signed or unsigned, as an
+// int64.
+func (r *Reader) anyInt() (int64, error) {
+	if r.elemType.IsUnsignedInt() {
+		n, err := r.Uint()
+		if err != nil {
+			return 0, err
+		}
+		if n > math.MaxInt64 {
+			return 0, ErrOverflow
+		}
+		return int64(n), nil
+	}
+	return r.Int()
+}
+
+// anyUint returns the current integer element, signed or unsigned, as a
+// uint64.
+func (r *Reader) anyUint() (uint64, error) {
+	if r.elemType.IsSignedInt() {
+		n, err := r.Int()
+		if err != nil {
+			return 0, err
+		}
+		if n < 0 {
+			return 0, ErrOverflow
+		}
+		return uint64(n), nil
+	}
+	return r.Uint()
+}
+
+// structInfo describes the tagged fields of a struct type.
+type structInfo struct {
+	fields []fieldInfo
+	byTag  map[uint8]int // Context tag to index in fields
+}
+
+// fieldInfo describes a struct field with a tlv struct tag.
+type fieldInfo struct {
+	index    int
+	name     string
+	tag      uint8
+	optional bool
+	nullable bool
+	required bool // Must be present when decoding
+}
+
+var structInfoCache sync.Map // reflect.Type -> *structInfo
+
+// structInfoFor returns the field information of struct type t, parsing
+// its struct tags on first use.
+func structInfoFor(t reflect.Type) (*structInfo, error) {
+	if info, ok := structInfoCache.Load(t); ok {
+		return info.(*structInfo), nil
+	}
+
+	info := &structInfo{byTag: make(map[uint8]int)}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		spec, ok := sf.Tag.Lookup("tlv")
+		if !ok || spec == "-" || !sf.IsExported() {
+			continue
+		}
+
+		f, err := parseFieldTag(sf, spec)
+		if err != nil {
+			return nil, err
+		}
+		f.index = i
+		if _, dup := info.byTag[f.tag]; dup {
+			return nil, fmt.Errorf("%w: %s.%s: duplicate tag %d", ErrInvalidStructTag, t, sf.Name, f.tag)
+		}
+		if len(info.fields) == 64 {
+			return nil, fmt.Errorf("%w: %s: more than 64 tagged fields", ErrInvalidStructTag, t)
+		}
+		info.byTag[f.tag] = len(info.fields)
+		info.fields = append(info.fields, f)
+	}
+
+	actual, _ := structInfoCache.LoadOrStore(t, info)
+	return actual.(*structInfo), nil
+}
+
+// parseFieldTag parses a tlv struct tag: a context tag number followed by
+// optional "optional" and "nullable" options.
+func parseFieldTag(sf reflect.StructField, spec string) (fieldInfo, error) {
+	f := fieldInfo{name: sf.Name}
+
+	parts := strings.Split(spec, ",")
+	n, err := strconv.ParseUint(parts[0], 10, 8)
+	if err != nil {
+		return f, fmt.Errorf("%w: %s: %q is not a context tag number", ErrInvalidStructTag, sf.Name, parts[0])
+	}
+	f.tag = uint8(n)
+
+	for _, opt := range parts[1:] {
+		switch opt {
+		case "optional":
+			f.optional = true
+		case "nullable":
+			f.nullable = true
+		default:
+			return f, fmt.Errorf("%w: %s: unknown option %q", ErrInvalidStructTag, sf.Name, opt)
+		}
+	}
+
+	isPointer := sf.Type.Kind() == reflect.Pointer
+	if f.nullable && !isPointer {
+		return f, fmt.Errorf("%w: %s: nullable field must be a pointer", ErrInvalidStructTag, sf.Name)
+	}
+	if f.optional && f.nullable && sf.Type.Elem().Kind() != reflect.Pointer {
+		return f, fmt.Errorf("%w: %s: optional nullable field must be a pointer to a pointer", ErrInvalidStructTag, sf.Name)
+	}
+	f.required = !isPointer && !f.optional
+
+	return f, nil
+}
@@ -0,0 +1,324 @@
+package tlv
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type marshalMode uint8
+
+type marshalInner struct {
+	ID    uint16 `tlv:"0"`
+	Label string `tlv:"1,optional"`
+}
+
+type marshalOuter struct {
+	Node       uint64         `tlv:"0"`
+	Delta      int32          `tlv:"1"`
+	Mode       marshalMode    `tlv:"2"`
+	Enabled    bool           `tlv:"3"`
+	Name       string         `tlv:"4"`
+	Key        []byte         `tlv:"5"`
+	Group      *uint16        `tlv:"6"`
+	Endpoint   *uint16        `tlv:"7,nullable"`
+	Cluster    **uint32       `tlv:"8,optional,nullable"`
+	Targets    []marshalInner `tlv:"9"`
+	Nested     marshalInner   `tlv:"10"`
+	NestedPtr  *marshalInner  `tlv:"11"`
+	Ratio      float32        `tlv:"12"`
+	Hash       [4]byte        `tlv:"13"`
+	Tags       []uint8        `tlv:"14,optional"` // []uint8 is []byte
+	Values     []int64        `tlv:"15,optional"`
+	Ignored    string         `tlv:"-"`
+	Untagged   string
+	unexported uint8 `tlv:"16"`
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func TestMarshal_RoundTrip(t *testing.T) {
+	in := marshalOuter{
+		Node:      0x1122334455667788,
+		Delta:     -42,
+		Mode:      3,
+		Enabled:   true,
+		Name:      "kitchen",
+		Key:       []byte{1, 2, 3},
+		Group:     ptr(uint16(7)),
+		Endpoint:  nil,
+		Cluster:   ptr((*uint32)(nil)),
+		Targets:   []marshalInner{{ID: 1}, {ID: 2, Label: "b"}},
+		Nested:    marshalInner{ID: 9, Label: "n"},
+		NestedPtr: &marshalInner{ID: 10},
+		Ratio:     0.5,
+		Hash:      [4]byte{0xDE, 0xAD, 0xBE, 0xEF},
+		Values:    []int64{-1, 0, 1 << 40},
+		Ignored:   "x",
+		Untagged:  "y",
+	}
+
+	data, err := Marshal(&in)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	var out marshalOuter
+	if err := Unmarshal(data, &out); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	want := in
+	want.Ignored, want.Untagged = "", ""
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("round trip:\n got %+v\nwant %+v", out, want)
+	}
+}
+
+func TestMarshal_MatchesWriter(t *testing.T) {
+	type event struct {
+		Session *uint32 `tlv:"0,nullable"`
+		State   uint8   `tlv:"1"`
+		Reason  uint8   `tlv:"2"`
+		Energy  int64   `tlv:"4"`
+		Note    *string `tlv:"5"`
+	}
+
+	got, err := Marshal(event{State: 2, Reason: 1, Energy: -1000})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	w := NewAppendWriter(nil)
+	w.StartStructure(Anonymous())
+	w.PutNull(ContextTag(0))
+	w.PutUint(ContextTag(1), 2)
+	w.PutUint(ContextTag(2), 1)
+	w.PutInt(ContextTag(4), -1000)
+	w.EndContainer()
+
+	if !bytes.Equal(got, w.Bytes()) {
+		t.Errorf("Marshal() = % X, want % X", got, w.Bytes())
+	}
+}
+
+func TestMarshal_OptionalNullable(t *testing.T) {
+	type s struct {
+		A *uint8  `tlv:"0"`
+		B *uint8  `tlv:"1,nullable"`
+		C **uint8 `tlv:"2,optional,nullable"`
+		D uint8   `tlv:"3,optional"`
+	}
+
+	tests := []struct {
+		name string
+		in   s
+		want []byte
+	}{
+		{"all absent", s{}, []byte{0x15, 0x34, 0x01, 0x18}},
+		{"all present", s{A: ptr(uint8(1)), B: ptr(uint8(2)), C: ptr(ptr(uint8(3))), D: 4},
+			[]byte{0x15, 0x24, 0x00, 0x01, 0x24, 0x01, 0x02, 0x24, 0x02, 0x03, 0x24, 0x03, 0x04, 0x18}},
+		{"null", s{C: new(*uint8)}, []byte{0x15, 0x34, 0x01, 0x34, 0x02, 0x18}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Marshal(tt.in)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if !bytes.Equal(data, tt.want) {
+				t.Fatalf("Marshal() = % X, want % X", data, tt.want)
+			}
+			var out s
+			if err := Unmarshal(data, &out); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(out, tt.in) {
+				t.Errorf("Unmarshal() = %+v, want %+v", out, tt.in)
+			}
+		})
+	}
+}
+
+func TestUnmarshal_Errors(t *testing.T) {
+	type s struct {
+		A uint8  `tlv:"0"`
+		B *uint8 `tlv:"1"`
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		into any
+		want error
+	}{
+		{"missing field", []byte{0x15, 0x24, 0x01, 0x05, 0x18}, &s{}, ErrMissingField},
+		{"overflow", []byte{0x15, 0x25, 0x00, 0x00, 0x01, 0x18}, &s{}, ErrOverflow},
+		{"negative into unsigned", []byte{0x15, 0x20, 0x00, 0xFF, 0x18}, &s{}, ErrOverflow},
+		{"null into value", []byte{0x15, 0x34, 0x00, 0x18}, &s{}, ErrTypeMismatch},
+		{"not a structure", []byte{0x04, 0x01}, &s{}, ErrTypeMismatch},
+		{"truncated", []byte{0x15, 0x24, 0x00, 0x01}, &s{}, nil},
+		{"not a pointer", []byte{0x04, 0x01}, s{}, ErrInvalidDecodeTarget},
+		{"unsupported type", []byte{0x04, 0x01}, new(map[int]int), ErrUnsupportedType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Unmarshal(tt.data, tt.into)
+			if err == nil {
+				t.Fatal("Unmarshal() succeeded, want error")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("Unmarshal() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshal_SkipsUnknownMembers(t *testing.T) {
+	type s struct {
+		A uint8 `tlv:"1"`
+	}
+
+	w := NewAppendWriter(nil)
+	w.StartStructure(Anonymous())
+	w.PutString(ContextTag(0), "unknown")
+	w.StartList(ContextTag(2))
+	w.PutUint(FullyQualifiedTag(0xFFF1, 0xDEED, 1), 5)
+	w.EndContainer()
+	w.PutUint(ContextTag(1), 7)
+	w.PutUint(FullyQualifiedTag(0xFFF1, 0xDEED, 1), 5)
+	w.EndContainer()
+
+	var out s
+	if err := Unmarshal(w.Bytes(), &out); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if out.A != 7 {
+		t.Errorf("A = %d, want 7", out.A)
+	}
+}
+
+func TestUnmarshal_CopiesBytes(t *testing.T) {
+	type s struct {
+		Key []byte `tlv:"0"`
+	}
+
+	data, _ := Marshal(s{Key: []byte{1, 2, 3}})
+	var out s
+	if err := Unmarshal(data, &out); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	for i := range data {
+		data[i] = 0
+	}
+	if !bytes.Equal(out.Key, []byte{1, 2, 3}) {
+		t.Errorf("Key = % X after clearing the input", out.Key)
+	}
+}
+
+func TestUnmarshal_Any(t *testing.T) {
+	w := NewAppendWriter(nil)
+	w.StartStructure(Anonymous())
+	w.PutUint(ContextTag(0), 7)
+	w.PutInt(ContextTag(1), -2)
+	w.PutString(ContextTag(2), "on")
+	w.PutNull(ContextTag(3))
+	w.StartList(ContextTag(4))
+	w.PutBool(Anonymous(), true)
+	w.PutBytes(Anonymous(), []byte{1, 2})
+	w.EndContainer()
+	w.EndContainer()
+
+	var out any
+	if err := Unmarshal(w.Bytes(), &out); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	want := map[Tag]any{
+		ContextTag(0): uint64(7),
+		ContextTag(1): int64(-2),
+		ContextTag(2): "on",
+		ContextTag(3): nil,
+		ContextTag(4): []any{true, []byte{1, 2}},
+	}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("Unmarshal() = %#v, want %#v", out, want)
+	}
+
+	// A null element leaves nil
+	out = "set"
+	if err := Unmarshal([]byte{0x14}, &out); err != nil || out != nil {
+		t.Errorf("Unmarshal(null) = %v, %v, want nil", out, err)
+	}
+}
+
+func TestMarshal_InvalidStructTag(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+	}{
+		{"not a number", struct {
+			A uint8 `tlv:"a"`
+		}{}},
+		{"out of range", struct {
+			A uint8 `tlv:"256"`
+		}{}},
+		{"unknown option", struct {
+			A uint8 `tlv:"0,omitempty"`
+		}{}},
+		{"nullable value", struct {
+			A uint8 `tlv:"0,nullable"`
+		}{}},
+		{"optional nullable single pointer", struct {
+			A *uint8 `tlv:"0,optional,nullable"`
+		}{}},
+		{"duplicate tag", struct {
+			A uint8 `tlv:"0"`
+			B uint8 `tlv:"0"`
+		}{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Marshal(tt.v); !errors.Is(err, ErrInvalidStructTag) {
+				t.Errorf("Marshal() error = %v, want ErrInvalidStructTag", err)
+			}
+		})
+	}
+}
+
+func TestMarshal_UnsupportedType(t *testing.T) {
+	if _, err := Marshal(map[string]int{}); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Marshal() error = %v, want ErrUnsupportedType", err)
+	}
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	v := marshalOuter{
+		Node:    1,
+		Name:    "kitchen",
+		Key:     make([]byte, 16),
+		Targets: []marshalInner{{ID: 1}, {ID: 2}},
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := Marshal(&v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	data, _ := Marshal(marshalOuter{
+		Node:    1,
+		Name:    "kitchen",
+		Key:     make([]byte, 16),
+		Targets: []marshalInner{{ID: 1}, {ID: 2}},
+	})
+	b.ReportAllocs()
+	for b.Loop() {
+		var v marshalOuter
+		if err := Unmarshal(data, &v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -7,11 +7,24 @@ import (
 	"unicode/utf8"
 )
 
-// Reader decodes TLV elements from an io.Reader.
+// Reader decodes TLV elements from an io.Reader or, without copying, from
+// a byte slice.
 type Reader struct {
 	r              io.Reader
 	containerStack []ElementType // Track container nesting
 
+	// Slice-backed input, when r is nil (NewSliceReader)
+	data  []byte
+	off   int
+	start int // Offset of the current element's control octet
+
+	// Read buffer for the control octet, tag and length fields of an
+	// io.Reader-backed reader
+	scratch [8]byte
+
+	// Initial backing array of containerStack, enough for typical nesting
+	stackBuf [8]ElementType
+
 	// Current element state
 	hasElement bool
 	elemType   ElementType
@@ -28,7 +41,19 @@ type Reader struct {
 
 // NewReader creates a new TLV Reader that reads from r.
 func NewReader(r io.Reader) *Reader {
-	return &Reader{r: r}
+	rd := &Reader{r: r}
+	rd.containerStack = rd.stackBuf[:0]
+	return rd
+}
+
+// NewSliceReader creates a new TLV Reader that reads from data in place,
+// without intermediate buffering. Bytes and RawBytes return sub-slices of
+// data instead of copies, so data must not be modified while they are in
+// use.
+func NewSliceReader(data []byte) *Reader {
+	rd := &Reader{data: data}
+	rd.containerStack = rd.stackBuf[:0]
+	return rd
 }
 
 // Next advances to the next TLV element.
@@ -42,8 +67,9 @@ func (r *Reader) Next() error {
 	}
 
 	// Read control octet
+	r.start = r.off
 	var ctrl [1]byte
-	if _, err := io.ReadFull(r.r, ctrl[:]); err != nil {
+	if err := r.readFull(ctrl[:]); err != nil {
 		return err
 	}
 
@@ -54,17 +80,24 @@ func (r *Reader) Next() error {
 	if r.elemType > ElementTypeEnd {
 		return ErrInvalidElementType
 	}
+	// End-of-container markers are always anonymous
+	if r.elemType == ElementTypeEnd && tagCtrl != TagControlAnonymous {
+		return ErrInvalidTagControl
+	}
 
 	// Read tag
-	tag, err := ReadTag(r.r, tagCtrl)
-	if err != nil {
-		return err
+	var tagBuf [8]byte
+	tagSize := tagCtrl.Size()
+	if tagSize > 0 {
+		if err := r.readFull(tagBuf[:tagSize]); err != nil {
+			return unexpectedEOF(err)
+		}
 	}
-	r.tag = tag
+	r.tag = decodeTag(tagCtrl, tagBuf[:tagSize])
 
 	// Read value (or length for strings)
 	if err := r.readValueOrLength(); err != nil {
-		return err
+		return unexpectedEOF(err)
 	}
 
 	r.hasElement = true
@@ -80,7 +113,7 @@ func (r *Reader) readValueOrLength() error {
 		// Fixed-size value
 		r.valueLen = r.elemType.ValueSize()
 		if r.valueLen > 0 {
-			if _, err := io.ReadFull(r.r, r.valueBuf[:r.valueLen]); err != nil {
+			if err := r.readFull(r.valueBuf[:r.valueLen]); err != nil {
 				return err
 			}
 		}
@@ -89,7 +122,7 @@ func (r *Reader) readValueOrLength() error {
 		// Read length field
 		lenSize := r.elemType.LengthFieldSize()
 		var lenBuf [8]byte
-		if _, err := io.ReadFull(r.r, lenBuf[:lenSize]); err != nil {
+		if err := r.readFull(lenBuf[:lenSize]); err != nil {
 			return err
 		}
 
@@ -250,8 +283,8 @@ func (r *Reader) String() (string, error) {
 		return "", nil
 	}
 
-	data := make([]byte, r.stringLen)
-	if _, err := io.ReadFull(r.r, data); err != nil {
+	data, err := r.readStringData()
+	if err != nil {
 		return "", err
 	}
 
@@ -280,8 +313,8 @@ func (r *Reader) Bytes() ([]byte, error) {
 		return nil, nil
 	}
 
-	data := make([]byte, r.stringLen)
-	if _, err := io.ReadFull(r.r, data); err != nil {
+	data, err := r.readStringData()
+	if err != nil {
 		return nil, err
 	}
 
@@ -354,6 +387,29 @@ func (r *Reader) ExitContainer() error {
 	return nil
 }
 
+// ContainerReader returns a Reader over the members of the current
+// container element and advances r past the container, as Skip does. The
+// returned reader's Next returns io.EOF after the last member.
+//
+// For a slice-backed reader, the returned reader is a view of the same
+// input; otherwise the container is read into memory first.
+func (r *Reader) ContainerReader() (*Reader, error) {
+	if !r.hasElement {
+		return nil, ErrNoElement
+	}
+	if !r.elemType.IsContainer() {
+		return nil, ErrTypeMismatch
+	}
+
+	headerLen := 1 + r.tag.Size()
+	raw, err := r.RawBytes()
+	if err != nil {
+		return nil, err
+	}
+	// Members lie between the container's tag and its end-of-container marker
+	return NewSliceReader(raw[headerLen : len(raw)-1]), nil
+}
+
 // ContainerDepth returns the current container nesting depth.
 func (r *Reader) ContainerDepth() int {
 	return len(r.containerStack)
@@ -392,23 +448,118 @@ func (r *Reader) skipValue() error {
 
 	// For string types, we need to skip the actual string data
 	if r.elemType.IsString() && r.stringLen > 0 {
+		if r.r == nil {
+			if r.stringLen > uint64(len(r.data)-r.off) {
+				r.off = len(r.data)
+				return io.ErrUnexpectedEOF
+			}
+			r.off += int(r.stringLen)
+			return nil
+		}
+		if r.stringLen > math.MaxInt64 {
+			return io.ErrUnexpectedEOF
+		}
 		// Use io.CopyN to skip bytes efficiently
 		_, err := io.CopyN(io.Discard, r.r, int64(r.stringLen))
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
 		return err
 	}
 
 	return nil
 }
 
+// maxStringPrealloc is the longest string whose buffer is allocated before
+// its data is read. The length of a string comes from the input, so longer
+// buffers grow with the data actually read instead.
+const maxStringPrealloc = 64 * 1024
+
+// readFull reads exactly len(p) bytes, at most 8, like io.ReadFull: it
+// returns io.EOF if no bytes were left and io.ErrUnexpectedEOF if some
+// were. io.Reader input goes through r.scratch, so that p can live on the
+// caller's stack.
+func (r *Reader) readFull(p []byte) error {
+	if r.r != nil {
+		n, err := io.ReadFull(r.r, r.scratch[:len(p)])
+		copy(p, r.scratch[:n])
+		return err
+	}
+	n := copy(p, r.data[r.off:])
+	r.off += n
+	if n < len(p) {
+		if n == 0 {
+			return io.EOF
+		}
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+// unexpectedEOF maps io.EOF, the input ending inside an element, to
+// io.ErrUnexpectedEOF.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// readStringData reads the data of the current string element. For a
+// slice-backed reader, it is a sub-slice of the input.
+// Returns io.ErrUnexpectedEOF if the input ends first.
+func (r *Reader) readStringData() ([]byte, error) {
+	if r.r == nil {
+		if r.stringLen > uint64(len(r.data)-r.off) {
+			r.off = len(r.data)
+			return nil, io.ErrUnexpectedEOF
+		}
+		end := r.off + int(r.stringLen)
+		data := r.data[r.off:end:end]
+		r.off = end
+		return data, nil
+	}
+	if r.stringLen <= maxStringPrealloc {
+		data := make([]byte, r.stringLen)
+		if _, err := io.ReadFull(r.r, data); err != nil {
+			if err == io.EOF {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		return data, nil
+	}
+	if r.stringLen > math.MaxInt64 {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.r, int64(r.stringLen)))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(data)) != r.stringLen {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return data, nil
+}
 
 // RawBytes reads the current element as raw TLV bytes.
 // This includes the control byte, tag, and value bytes.
 // The returned bytes can be passed to PutRaw to write the same element with a different tag.
+// For a slice-backed reader, they are a sub-slice of the input.
 func (r *Reader) RawBytes() ([]byte, error) {
 	if !r.hasElement {
 		return nil, ErrNoElement
 	}
 
+	if r.r == nil {
+		start := r.start
+		if err := r.Skip(); err != nil {
+			return nil, err
+		}
+		return r.data[start:r.off:r.off], nil
+	}
+
 	var result []byte
 
 	// Start with control byte and tag of current element
@@ -462,8 +613,8 @@ func (r *Reader) RawBytes() ([]byte, error) {
 		result = append(result, lengthBytes...)
 
 		if r.stringLen > 0 {
-			stringData := make([]byte, r.stringLen)
-			if _, err := io.ReadFull(r.r, stringData); err != nil {
+			stringData, err := r.readStringData()
+			if err != nil {
 				return nil, err
 			}
 			result = append(result, stringData...)
@@ -0,0 +1,255 @@
+package tlv
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestSliceReader_SpecVectors(t *testing.T) {
+	type vector struct {
+		name     string
+		encoding []byte
+		check    func(t *testing.T, r *Reader)
+	}
+	var vectors []vector
+	for _, v := range table125Vectors {
+		vectors = append(vectors, vector{v.name, v.encoding, v.check})
+	}
+	for _, v := range table126Vectors {
+		vectors = append(vectors, vector{v.name, v.encoding, v.check})
+	}
+	for _, v := range table127Vectors {
+		vectors = append(vectors, vector{v.name, v.encoding, v.check})
+	}
+
+	for _, tc := range vectors {
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewSliceReader(tc.encoding)
+			if err := r.Next(); err != nil {
+				t.Fatalf("Next failed: %v", err)
+			}
+			tc.check(t, r)
+
+			// The whole element is one span of the input
+			r = NewSliceReader(tc.encoding)
+			if err := r.Next(); err != nil {
+				t.Fatalf("Next failed: %v", err)
+			}
+			raw, err := r.RawBytes()
+			if err != nil {
+				t.Fatalf("RawBytes failed: %v", err)
+			}
+			if !bytes.Equal(raw, tc.encoding) {
+				t.Errorf("RawBytes = %x, want %x", raw, tc.encoding)
+			}
+			if err := r.Next(); err != io.EOF {
+				t.Errorf("Next after RawBytes = %v, want io.EOF", err)
+			}
+		})
+	}
+}
+
+func TestSliceReader_TruncatedInput(t *testing.T) {
+	for _, encoding := range [][]byte{
+		{0x01, 0x2a},
+		{0x0c},
+		{0x20},
+		{0xc4, 0xf1, 0xff},
+	} {
+		if err := NewSliceReader(encoding).Next(); err != io.ErrUnexpectedEOF {
+			t.Errorf("Next(% x) = %v, want io.ErrUnexpectedEOF", encoding, err)
+		}
+	}
+
+	if err := NewSliceReader(nil).Next(); err != io.EOF {
+		t.Errorf("Next() on empty input = %v, want io.EOF", err)
+	}
+
+	// Length says 5, only 2 bytes of data follow
+	r := NewSliceReader([]byte{0x10, 0x05, 0x00, 0x01})
+	if err := r.Next(); err != nil {
+		t.Fatalf("Next() should succeed, got error: %v", err)
+	}
+	if _, err := r.Bytes(); err != io.ErrUnexpectedEOF {
+		t.Errorf("Bytes() = %v, want io.ErrUnexpectedEOF", err)
+	}
+
+	r = NewSliceReader([]byte{0x15, 0x24, 0x01, 0x2a})
+	if err := r.Next(); err != nil {
+		t.Fatalf("Next() should succeed, got error: %v", err)
+	}
+	if err := r.Skip(); err != io.EOF {
+		t.Errorf("Skip() of an unterminated structure = %v, want io.EOF", err)
+	}
+}
+
+func TestSliceReader_ZeroCopy(t *testing.T) {
+	// {1: h'0102', 2: "hi"}
+	data := []byte{0x15, 0x30, 0x01, 0x02, 0x01, 0x02, 0x2c, 0x02, 0x02, 0x68, 0x69, 0x18}
+	r := NewSliceReader(data)
+	if err := r.Next(); err != nil {
+		t.Fatalf("Next failed: %v", err)
+	}
+	if err := r.EnterContainer(); err != nil {
+		t.Fatalf("EnterContainer failed: %v", err)
+	}
+	if err := r.Next(); err != nil {
+		t.Fatalf("Next failed: %v", err)
+	}
+
+	b, err := r.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes failed: %v", err)
+	}
+	if !bytes.Equal(b, []byte{0x01, 0x02}) {
+		t.Fatalf("Bytes = %x, want 0102", b)
+	}
+	if &b[0] != &data[4] {
+		t.Error("Bytes returned a copy, want a view of the input")
+	}
+	if cap(b) != len(b) {
+		t.Errorf("cap(Bytes) = %d, want %d so appends cannot overwrite the input", cap(b), len(b))
+	}
+
+	if err := r.Next(); err != nil {
+		t.Fatalf("Next failed: %v", err)
+	}
+	raw, err := r.RawBytes()
+	if err != nil {
+		t.Fatalf("RawBytes failed: %v", err)
+	}
+	if &raw[0] != &data[6] || len(raw) != 5 {
+		t.Errorf("RawBytes = %x, want a view of input bytes 6-10", raw)
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		r := NewSliceReader(data)
+		r.Next()
+		r.EnterContainer()
+		r.Next()
+		r.Bytes()
+		r.Next()
+		r.RawBytes()
+		r.ExitContainer()
+	})
+	// Only the Reader itself
+	if allocs > 1 {
+		t.Errorf("reading allocated %v times, want at most 1", allocs)
+	}
+}
+
+func TestReader_ContainerReader(t *testing.T) {
+	// {1: [1, 2], 2: 42}, 3
+	data := []byte{
+		0x15,
+		0x36, 0x01, 0x04, 0x01, 0x04, 0x02, 0x18,
+		0x24, 0x02, 0x2a,
+		0x18,
+		0x04, 0x03,
+	}
+
+	readers := map[string]*Reader{
+		"slice":  NewSliceReader(data),
+		"stream": NewReader(bytes.NewReader(data)),
+	}
+	for name, r := range readers {
+		t.Run(name, func(t *testing.T) {
+			if err := r.Next(); err != nil {
+				t.Fatalf("Next failed: %v", err)
+			}
+			sr, err := r.ContainerReader()
+			if err != nil {
+				t.Fatalf("ContainerReader failed: %v", err)
+			}
+
+			// r is past the structure
+			if err := r.Next(); err != nil {
+				t.Fatalf("Next failed: %v", err)
+			}
+			if v, err := r.Uint(); err != nil || v != 3 {
+				t.Errorf("element after structure = %d, %v; want 3", v, err)
+			}
+
+			// The sub-reader sees the members, then io.EOF
+			if err := sr.Next(); err != nil {
+				t.Fatalf("sub-reader Next failed: %v", err)
+			}
+			if sr.Tag() != ContextTag(1) || sr.Type() != ElementTypeArray {
+				t.Fatalf("first member = %v %v, want array with tag 1", sr.Tag(), sr.Type())
+			}
+			ar, err := sr.ContainerReader()
+			if err != nil {
+				t.Fatalf("nested ContainerReader failed: %v", err)
+			}
+			if err := sr.Next(); err != nil {
+				t.Fatalf("sub-reader Next failed: %v", err)
+			}
+			if v, err := sr.Uint(); err != nil || v != 42 {
+				t.Errorf("second member = %d, %v; want 42", v, err)
+			}
+			if err := sr.Next(); err != io.EOF {
+				t.Errorf("sub-reader Next after last member = %v, want io.EOF", err)
+			}
+
+			var items []uint64
+			for ar.Next() == nil {
+				v, err := ar.Uint()
+				if err != nil {
+					t.Fatalf("Int failed: %v", err)
+				}
+				items = append(items, v)
+			}
+			if len(items) != 2 || items[0] != 1 || items[1] != 2 {
+				t.Errorf("array items = %v, want [1 2]", items)
+			}
+		})
+	}
+
+	r := NewSliceReader([]byte{0x04, 0x01})
+	r.Next()
+	if _, err := r.ContainerReader(); err != ErrTypeMismatch {
+		t.Errorf("ContainerReader on an integer = %v, want ErrTypeMismatch", err)
+	}
+}
+
+func TestReader_TaggedEndOfContainer(t *testing.T) {
+	// A list ended by an end-of-container marker with context tag 0x30
+	data := []byte{0x17, 0x38, 0x30}
+	for name, r := range map[string]*Reader{
+		"slice":  NewSliceReader(data),
+		"stream": NewReader(bytes.NewReader(data)),
+	} {
+		if err := r.Next(); err != nil {
+			t.Fatalf("%s: Next failed: %v", name, err)
+		}
+		if err := r.EnterContainer(); err != nil {
+			t.Fatalf("%s: EnterContainer failed: %v", name, err)
+		}
+		if err := r.Next(); err != ErrInvalidTagControl {
+			t.Errorf("%s: Next on tagged end-of-container = %v, want ErrInvalidTagControl", name, err)
+		}
+	}
+}
+
+// benchStruct is an InvokeRequestMessage for OnOff Toggle.
+var benchStruct = []byte{
+	0x15, 0x28, 0x00, 0x28, 0x01, 0x36, 0x02,
+	0x15, 0x37, 0x00, 0x24, 0x00, 0x01, 0x24, 0x01, 0x06, 0x24, 0x02, 0x02, 0x18,
+	0x35, 0x01, 0x18, 0x18, 0x18,
+	0x24, 0xff, 0x0c, 0x18,
+}
+
+func BenchmarkReader(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		walkTLV(NewReader(bytes.NewReader(benchStruct)))
+	}
+}
+
+func BenchmarkSliceReader(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		walkTLV(NewSliceReader(benchStruct))
+	}
+}
@@ -172,87 +172,82 @@ func (t Tag) Size() int {
 // WriteTo writes the tag to the given writer in little-endian order (Spec A.8).
 func (t Tag) WriteTo(w io.Writer) (int64, error) {
 	var buf [8]byte
-
-	switch t.control {
-	case TagControlAnonymous:
+	n := t.put(buf[:])
+	if n == 0 {
 		return 0, nil
+	}
+	m, err := w.Write(buf[:n])
+	return int64(m), err
+}
 
+// put encodes the tag into buf in little-endian order (Spec A.8) and
+// returns the number of bytes written. buf must have room for 8 bytes.
+func (t Tag) put(buf []byte) int {
+	switch t.control {
 	case TagControlContext:
 		buf[0] = byte(t.tagNumber)
-		n, err := w.Write(buf[:1])
-		return int64(n), err
+		return 1
 
 	case TagControlCommonProfile2, TagControlImplicitProfile2:
 		binary.LittleEndian.PutUint16(buf[:2], uint16(t.tagNumber))
-		n, err := w.Write(buf[:2])
-		return int64(n), err
+		return 2
 
 	case TagControlCommonProfile4, TagControlImplicitProfile4:
 		binary.LittleEndian.PutUint32(buf[:4], t.tagNumber)
-		n, err := w.Write(buf[:4])
-		return int64(n), err
+		return 4
 
 	case TagControlFullyQualified6:
 		binary.LittleEndian.PutUint16(buf[0:2], t.vendorID)
 		binary.LittleEndian.PutUint16(buf[2:4], t.profileNumber)
 		binary.LittleEndian.PutUint16(buf[4:6], uint16(t.tagNumber))
-		n, err := w.Write(buf[:6])
-		return int64(n), err
+		return 6
 
 	case TagControlFullyQualified8:
 		binary.LittleEndian.PutUint16(buf[0:2], t.vendorID)
 		binary.LittleEndian.PutUint16(buf[2:4], t.profileNumber)
 		binary.LittleEndian.PutUint32(buf[4:8], t.tagNumber)
-		n, err := w.Write(buf[:8])
-		return int64(n), err
+		return 8
 	}
 
-	return 0, nil
+	return 0
 }
 
 // ReadTag reads a tag from the given reader based on the tag control.
 func ReadTag(r io.Reader, ctrl TagControl) (Tag, error) {
-	tag := Tag{control: ctrl}
 	var buf [8]byte
+	size := ctrl.Size()
+	if size > 0 {
+		if _, err := io.ReadFull(r, buf[:size]); err != nil {
+			return Tag{control: ctrl}, err
+		}
+	}
+	return decodeTag(ctrl, buf[:size]), nil
+}
 
-	switch ctrl {
-	case TagControlAnonymous:
-		return tag, nil
+// decodeTag decodes a tag from the ctrl.Size() bytes of buf.
+func decodeTag(ctrl TagControl, buf []byte) Tag {
+	tag := Tag{control: ctrl}
 
+	switch ctrl {
 	case TagControlContext:
-		if _, err := io.ReadFull(r, buf[:1]); err != nil {
-			return tag, err
-		}
 		tag.tagNumber = uint32(buf[0])
 
 	case TagControlCommonProfile2, TagControlImplicitProfile2:
-		if _, err := io.ReadFull(r, buf[:2]); err != nil {
-			return tag, err
-		}
 		tag.tagNumber = uint32(binary.LittleEndian.Uint16(buf[:2]))
 
 	case TagControlCommonProfile4, TagControlImplicitProfile4:
-		if _, err := io.ReadFull(r, buf[:4]); err != nil {
-			return tag, err
-		}
 		tag.tagNumber = binary.LittleEndian.Uint32(buf[:4])
 
 	case TagControlFullyQualified6:
-		if _, err := io.ReadFull(r, buf[:6]); err != nil {
-			return tag, err
-		}
 		tag.vendorID = binary.LittleEndian.Uint16(buf[0:2])
 		tag.profileNumber = binary.LittleEndian.Uint16(buf[2:4])
 		tag.tagNumber = uint32(binary.LittleEndian.Uint16(buf[4:6]))
 
 	case TagControlFullyQualified8:
-		if _, err := io.ReadFull(r, buf[:8]); err != nil {
-			return tag, err
-		}
 		tag.vendorID = binary.LittleEndian.Uint16(buf[0:2])
 		tag.profileNumber = binary.LittleEndian.Uint16(buf[2:4])
 		tag.tagNumber = binary.LittleEndian.Uint32(buf[4:8])
 	}
 
-	return tag, nil
+	return tag
 }
@@ -0,0 +1,170 @@
+package tlv
+
+import "unicode/utf8"
+
+// DefaultMaxDepth is the container nesting limit of StrictValidation.
+// Interaction Model messages nest at most about ten containers deep,
+// including attribute values.
+const DefaultMaxDepth = 32
+
+// ValidationConfig selects the checks Validate makes beyond
+// well-formedness. Well-formedness, which every check includes, means
+// that the input is exactly one complete element: every element decodes,
+// every container is closed and no bytes follow.
+type ValidationConfig struct {
+	// MaxDepth is the deepest container nesting allowed. Zero means no
+	// limit.
+	MaxDepth int
+
+	// MaxStringLength is the longest UTF-8 or octet string allowed, in
+	// bytes. Zero means no limit.
+	MaxStringLength int
+
+	// CheckUTF8 rejects UTF-8 strings that are not valid UTF-8.
+	CheckUTF8 bool
+
+	// CheckTags rejects tags in the wrong place (Spec Appendix A.2):
+	// anonymous structure members, tagged array members and context tags
+	// outside a structure or list.
+	CheckTags bool
+
+	// CheckDuplicateTags rejects structures with two members of the same
+	// tag.
+	CheckDuplicateTags bool
+}
+
+// StrictValidation enables every check, with a DefaultMaxDepth nesting
+// limit and no string length limit beyond the input itself.
+var StrictValidation = ValidationConfig{
+	MaxDepth:           DefaultMaxDepth,
+	CheckUTF8:          true,
+	CheckTags:          true,
+	CheckDuplicateTags: true,
+}
+
+// Validate checks that data is a single TLV element that passes the checks
+// of cfg, without decoding it into Go values. A nil cfg means
+// StrictValidation.
+//
+// Decoders are lenient, so callers that must reject malformed input, like
+// the Interaction Model on received messages, validate it first and decode
+// only input that passes.
+func Validate(data []byte, cfg *ValidationConfig) error {
+	if cfg == nil {
+		cfg = &StrictValidation
+	}
+
+	v := validator{cfg: cfg, r: NewSliceReader(data)}
+	if err := v.r.Next(); err != nil {
+		return unexpectedEOF(err)
+	}
+	if err := v.element(); err != nil {
+		return err
+	}
+	for len(v.frames) > 0 {
+		if err := v.r.Next(); err != nil {
+			return unexpectedEOF(err)
+		}
+		if err := v.element(); err != nil {
+			return err
+		}
+	}
+
+	if v.r.off != len(data) {
+		return ErrTrailingData
+	}
+	return nil
+}
+
+// validator walks an encoding for Validate.
+type validator struct {
+	cfg    *ValidationConfig
+	r      *Reader
+	frames []validatorFrame
+}
+
+// validatorFrame is an open container.
+type validatorFrame struct {
+	typ  ElementType
+	tags map[Tag]struct{} // Member tags seen, for CheckDuplicateTags
+}
+
+// element checks the current element, entering or exiting a container.
+func (v *validator) element() error {
+	r := v.r
+
+	if r.IsEndOfContainer() {
+		if len(v.frames) == 0 {
+			return ErrUnexpectedEndOfContainer
+		}
+		v.frames = v.frames[:len(v.frames)-1]
+		return r.ExitContainer()
+	}
+
+	if v.cfg.CheckTags {
+		if err := v.checkTag(r.Tag()); err != nil {
+			return err
+		}
+	}
+	if v.cfg.CheckDuplicateTags && len(v.frames) > 0 {
+		f := &v.frames[len(v.frames)-1]
+		if f.typ == ElementTypeStruct {
+			if _, dup := f.tags[r.Tag()]; dup {
+				return ErrDuplicateTag
+			}
+			if f.tags == nil {
+				f.tags = make(map[Tag]struct{})
+			}
+			f.tags[r.Tag()] = struct{}{}
+		}
+	}
+
+	typ := r.Type()
+	switch {
+	case typ.IsContainer():
+		if v.cfg.MaxDepth > 0 && len(v.frames) >= v.cfg.MaxDepth {
+			return ErrMaxDepthExceeded
+		}
+		v.frames = append(v.frames, validatorFrame{typ: typ})
+		return r.EnterContainer()
+
+	case typ.IsString():
+		if v.cfg.MaxStringLength > 0 && r.stringLen > uint64(v.cfg.MaxStringLength) {
+			return ErrStringTooLong
+		}
+		r.valueRead = true
+		data, err := r.readStringData()
+		if err != nil {
+			return err
+		}
+		if v.cfg.CheckUTF8 && typ.IsUTF8String() && !utf8.Valid(data) {
+			return ErrInvalidUTF8
+		}
+		return nil
+	}
+
+	// Fixed-size values were read by Next
+	return nil
+}
+
+// checkTag checks that tag is allowed in the current container.
+func (v *validator) checkTag(tag Tag) error {
+	if len(v.frames) == 0 {
+		if tag.IsContext() {
+			return ErrContextTagOutsideStruct
+		}
+		return nil
+	}
+
+	switch v.frames[len(v.frames)-1].typ {
+	case ElementTypeStruct:
+		if tag.IsAnonymous() {
+			return ErrAnonymousTagInStruct
+		}
+	case ElementTypeArray:
+		if !tag.IsAnonymous() {
+			return ErrTaggedElementInArray
+		}
+	}
+	return nil
+}
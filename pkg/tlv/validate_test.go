@@ -0,0 +1,96 @@
+package tlv
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestValidate_SpecVectors(t *testing.T) {
+	var encodings [][]byte
+	for _, v := range table125Vectors {
+		encodings = append(encodings, v.encoding)
+	}
+	for _, v := range table126Vectors {
+		encodings = append(encodings, v.encoding)
+	}
+	for _, data := range encodings {
+		if err := Validate(data, nil); err != nil {
+			t.Errorf("Validate(% X) error = %v", data, err)
+		}
+	}
+
+	// The tag examples are elements taken out of their containers
+	noTags := StrictValidation
+	noTags.CheckTags = false
+	for _, v := range table127Vectors {
+		if err := Validate(v.encoding, &noTags); err != nil {
+			t.Errorf("Validate(% X) error = %v", v.encoding, err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	nested := func(depth int) []byte {
+		var b []byte
+		for range depth {
+			b = append(b, 0x16) // Anonymous array
+		}
+		for range depth {
+			b = append(b, 0x18)
+		}
+		return b
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		cfg  *ValidationConfig
+		want error
+	}{
+		{"empty", nil, nil, io.ErrUnexpectedEOF},
+		{"unclosed container", []byte{0x15, 0x24, 0x00, 0x01}, nil, io.ErrUnexpectedEOF},
+		{"truncated string", []byte{0x0C, 0x05, 'a'}, nil, io.ErrUnexpectedEOF},
+		{"end of container", []byte{0x18}, nil, ErrUnexpectedEndOfContainer},
+		{"trailing data", []byte{0x04, 0x01, 0x04}, nil, ErrTrailingData},
+		{"two elements", []byte{0x04, 0x01, 0x04, 0x02}, nil, ErrTrailingData},
+		{"invalid UTF-8", []byte{0x0C, 0x02, 0xC3, 0x28}, nil, ErrInvalidUTF8},
+		{"invalid UTF-8 unchecked", []byte{0x0C, 0x02, 0xC3, 0x28}, &ValidationConfig{}, nil},
+		{"anonymous member of structure", []byte{0x15, 0x04, 0x01, 0x18}, nil, ErrAnonymousTagInStruct},
+		{"tagged member of array", []byte{0x16, 0x24, 0x00, 0x01, 0x18}, nil, ErrTaggedElementInArray},
+		{"tagged member of list", []byte{0x17, 0x24, 0x00, 0x01, 0x04, 0x02, 0x18}, nil, nil},
+		{"context tag at top level", []byte{0x24, 0x00, 0x01}, nil, ErrContextTagOutsideStruct},
+		{"duplicate tag", []byte{0x15, 0x24, 0x00, 0x01, 0x24, 0x00, 0x02, 0x18}, nil, ErrDuplicateTag},
+		{"same tag in sibling structures",
+			[]byte{0x15, 0x35, 0x01, 0x24, 0x00, 0x01, 0x18, 0x35, 0x02, 0x24, 0x00, 0x01, 0x18, 0x18}, nil, nil},
+		{"duplicate tag in list", []byte{0x17, 0x24, 0x00, 0x01, 0x24, 0x00, 0x02, 0x18}, nil, nil},
+		{"duplicate tag unchecked", []byte{0x15, 0x24, 0x00, 0x01, 0x24, 0x00, 0x02, 0x18}, &ValidationConfig{}, nil},
+		{"max depth", nested(DefaultMaxDepth), nil, nil},
+		{"too deep", nested(DefaultMaxDepth + 1), nil, ErrMaxDepthExceeded},
+		{"string at limit", []byte{0x10, 0x02, 0x01, 0x02}, &ValidationConfig{MaxStringLength: 2}, nil},
+		{"string too long", []byte{0x10, 0x03, 0x01, 0x02, 0x03}, &ValidationConfig{MaxStringLength: 2}, ErrStringTooLong},
+		{"string length beyond input", []byte{0x12, 0xFF, 0xFF, 0xFF, 0xFF}, nil, io.ErrUnexpectedEOF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.data, tt.cfg)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Validate(% X) error = %v, want %v", tt.data, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate_Marshaled(t *testing.T) {
+	data, err := Marshal(marshalOuter{Name: "ok", Targets: []marshalInner{{ID: 1}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Validate(data, nil); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if err := Validate(bytes.Repeat(data, 2), nil); !errors.Is(err, ErrTrailingData) {
+		t.Errorf("Validate() error = %v, want ErrTrailingData", err)
+	}
+}
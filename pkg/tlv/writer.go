@@ -7,10 +7,15 @@ import (
 	"unicode/utf8"
 )
 
-// Writer encodes TLV elements to an io.Writer.
+// Writer encodes TLV elements to an io.Writer or appends them to a byte
+// slice.
 type Writer struct {
 	w              io.Writer
+	buf            []byte        // Output in append mode, when w is nil
 	containerStack []ElementType // Track open containers for validation
+
+	// Write buffer for control octets, tags and fixed-size values to w
+	scratch [9]byte
 }
 
 // NewWriter creates a new TLV Writer that writes to w.
@@ -18,16 +23,50 @@ func NewWriter(w io.Writer) *Writer {
 	return &Writer{w: w}
 }
 
-// writeControlAndTag writes the control octet and tag.
-func (w *Writer) writeControlAndTag(elemType ElementType, tag Tag) error {
-	ctrl := BuildControlOctet(elemType, tag.Control())
-	if _, err := w.w.Write([]byte{ctrl}); err != nil {
-		return err
+// NewAppendWriter creates a new TLV Writer that appends to buf, without
+// intermediate buffering. Bytes returns the result. Pass buf[:0] to reuse
+// the capacity of a previous encoding.
+func NewAppendWriter(buf []byte) *Writer {
+	return &Writer{buf: buf}
+}
+
+// Bytes returns the output of a Writer created by NewAppendWriter: buf with
+// the elements written so far appended. Returns nil for a Writer created by
+// NewWriter.
+func (w *Writer) Bytes() []byte {
+	return w.buf
+}
+
+// write writes p to the output.
+func (w *Writer) write(p []byte) error {
+	if w.w == nil {
+		w.buf = append(w.buf, p...)
+		return nil
+	}
+	_, err := w.w.Write(p)
+	return err
+}
+
+// writeSmall writes p, at most 9 bytes, to the output. io.Writer output
+// goes through w.scratch, so that p can live on the caller's stack.
+func (w *Writer) writeSmall(p []byte) error {
+	if w.w == nil {
+		w.buf = append(w.buf, p...)
+		return nil
 	}
-	_, err := tag.WriteTo(w.w)
+	n := copy(w.scratch[:], p)
+	_, err := w.w.Write(w.scratch[:n])
 	return err
 }
 
+// writeControlAndTag writes the control octet and tag.
+func (w *Writer) writeControlAndTag(elemType ElementType, tag Tag) error {
+	var hdr [9]byte
+	hdr[0] = BuildControlOctet(elemType, tag.Control())
+	n := tag.put(hdr[1:])
+	return w.writeSmall(hdr[:1+n])
+}
+
 // PutInt writes a signed integer with the given tag.
 // The writer chooses the minimum width needed to encode the value.
 func (w *Writer) PutInt(tag Tag, v int64) error {
@@ -214,8 +253,7 @@ func (w *Writer) PutRaw(tag Tag, rawTLV []byte) error {
 
 	// Write the value portion only
 	if skipBytes < len(rawTLV) {
-		_, err := w.w.Write(rawTLV[skipBytes:])
-		return err
+		return w.write(rawTLV[skipBytes:])
 	}
 
 	return nil
@@ -261,8 +299,7 @@ func (w *Writer) EndContainer() error {
 	w.containerStack = w.containerStack[:len(w.containerStack)-1]
 
 	// End-of-container always has anonymous tag (tag control = 0)
-	_, err := w.w.Write([]byte{byte(ElementTypeEnd)})
-	return err
+	return w.writeSmall([]byte{byte(ElementTypeEnd)})
 }
 
 // ContainerDepth returns the current container nesting depth.
@@ -275,8 +312,7 @@ func (w *Writer) writeFixedValue(elemType ElementType, tag Tag, value []byte) er
 	if err := w.writeControlAndTag(elemType, tag); err != nil {
 		return err
 	}
-	_, err := w.w.Write(value)
-	return err
+	return w.writeSmall(value)
 }
 
 // writeStringValue writes a string (UTF-8 or octet) with length prefix.
@@ -326,9 +362,8 @@ func (w *Writer) writeStringValue(isUTF8 bool, tag Tag, data []byte) error {
 	if err := w.writeControlAndTag(elemType, tag); err != nil {
 		return err
 	}
-	if _, err := w.w.Write(lenBuf[:lenSize]); err != nil {
+	if err := w.writeSmall(lenBuf[:lenSize]); err != nil {
 		return err
 	}
-	_, err := w.w.Write(data)
-	return err
+	return w.write(data)
 }
@@ -363,3 +363,46 @@ func TestWriter_EmptyStrings(t *testing.T) {
 		}
 	})
 }
+
+func TestWriter_AppendMode(t *testing.T) {
+	encode := func(w *Writer) {
+		w.StartStructure(Anonymous())
+		w.PutUint(ContextTag(1), 42)
+		w.PutString(ContextTag(2), "hi")
+		w.PutBytes(FullyQualifiedTag(0xFFF1, 0xDEED, 1), []byte{0x01, 0x02})
+		w.StartArray(ContextTag(3))
+		w.PutBool(Anonymous(), true)
+		w.PutNull(Anonymous())
+		w.EndContainer()
+		w.PutRaw(ContextTag(4), []byte{0x24, 0x09, 0x07})
+		w.EndContainer()
+	}
+
+	var buf bytes.Buffer
+	encode(NewWriter(&buf))
+
+	prefix := []byte{0xAA}
+	w := NewAppendWriter(prefix)
+	encode(w)
+	got := w.Bytes()
+	if got[0] != 0xAA || !bytes.Equal(got[1:], buf.Bytes()) {
+		t.Errorf("append writer = %x, want aa || %x", got, buf.Bytes())
+	}
+
+	if NewWriter(&buf).Bytes() != nil {
+		t.Error("Bytes() of a stream writer = non-nil, want nil")
+	}
+
+	// With enough capacity, encoding appends in place
+	out := make([]byte, 0, 64)
+	value := []byte{0x01, 0x02}
+	allocs := testing.AllocsPerRun(100, func() {
+		w := NewAppendWriter(out[:0])
+		w.PutUint(ContextTag(1), 42)
+		w.PutBytes(ContextTag(2), value)
+	})
+	// Only the Writer itself
+	if allocs > 1 {
+		t.Errorf("append writer allocated %v times, want at most 1", allocs)
+	}
+}
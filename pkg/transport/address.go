@@ -1,6 +1,7 @@
 package transport
 
 import (
+	"encoding/binary"
 	"fmt"
 	"net"
 )
@@ -42,7 +43,55 @@ func NewTCPPeerAddress(addr net.Addr) PeerAddress {
 	}
 }
 
+// NewScopedUDPPeerAddress creates a PeerAddress for a UDP peer at ip and
+// port, scoped to a network interface by name. A link-local IPv6 address
+// is ambiguous on a host with several interfaces: the same fe80:: address
+// may be a different node on each, and the zone selects the interface the
+// peer is reached through. The zone is ignored for other addresses.
+//
+// ErrMissingZone is returned for a link-local IPv6 address without a zone.
+func NewScopedUDPPeerAddress(ip net.IP, port int, zone string) (PeerAddress, error) {
+	addr := &net.UDPAddr{IP: ip, Port: port}
+	if needsZone(ip) {
+		if zone == "" {
+			return PeerAddress{}, ErrMissingZone
+		}
+		addr.Zone = zone
+	}
+	return NewUDPPeerAddress(addr), nil
+}
+
+// Zone returns the IPv6 zone, the interface name or index, of the peer's
+// address, or "" if it has none.
+func (p PeerAddress) Zone() string {
+	switch a := p.Addr.(type) {
+	case *net.UDPAddr:
+		return a.Zone
+	case *net.TCPAddr:
+		return a.Zone
+	}
+	return ""
+}
+
+// missingZone reports whether the peer's address is link-local IPv6
+// without a zone, which cannot be routed.
+func (p PeerAddress) missingZone() bool {
+	switch a := p.Addr.(type) {
+	case *net.UDPAddr:
+		return a.Zone == "" && needsZone(a.IP)
+	case *net.TCPAddr:
+		return a.Zone == "" && needsZone(a.IP)
+	}
+	return false
+}
+
+// needsZone reports whether ip is a link-local IPv6 unicast address.
+func needsZone(ip net.IP) bool {
+	return ip.To4() == nil && ip.IsLinkLocalUnicast()
+}
+
 // UDPAddrFromString parses an address string and creates a UDP PeerAddress.
+// A zone in the string, as in "[fe80::1%eth0]:5540", is kept.
 func UDPAddrFromString(addr string) (PeerAddress, error) {
 	udpAddr, err := net.ResolveUDPAddr("udp", addr)
 	if err != nil {
@@ -59,3 +108,24 @@ func TCPAddrFromString(addr string) (PeerAddress, error) {
 	}
 	return NewTCPPeerAddress(tcpAddr), nil
 }
+
+// GroupMulticastAddress returns the IPv6 multicast address of a group on a
+// fabric: FF35:0040:FD<FabricID>00:<GroupID>.
+//
+// See Spec Section 2.5.6.2 (IPv6 Multicast Address).
+func GroupMulticastAddress(fabricID uint64, groupID uint16) net.IP {
+	ip := make(net.IP, net.IPv6len)
+	ip[0], ip[1], ip[2], ip[3], ip[4] = 0xFF, 0x35, 0x00, 0x40, 0xFD
+	binary.BigEndian.PutUint64(ip[5:13], fabricID)
+	binary.BigEndian.PutUint16(ip[14:16], groupID)
+	return ip
+}
+
+// NewGroupPeerAddress creates a PeerAddress for the members of a group,
+// at the group's multicast address and the Matter port.
+func NewGroupPeerAddress(fabricID uint64, groupID uint16) PeerAddress {
+	return NewUDPPeerAddress(&net.UDPAddr{
+		IP:   GroupMulticastAddress(fabricID, groupID),
+		Port: DefaultPort,
+	})
+}
@@ -0,0 +1,73 @@
+package transport
+
+import (
+	"net"
+	"testing"
+)
+
+func TestGroupMulticastAddress(t *testing.T) {
+	got := GroupMulticastAddress(0x2906C908D115D362, 0x1234)
+	want := net.ParseIP("ff35:40:fd29:6c9:8d1:15d3:6200:1234")
+	if !got.Equal(want) {
+		t.Errorf("GroupMulticastAddress() = %v, want %v", got, want)
+	}
+	if !got.IsMulticast() {
+		t.Errorf("GroupMulticastAddress() = %v, not multicast", got)
+	}
+
+	addr := NewGroupPeerAddress(0x2906C908D115D362, 0x1234)
+	if addr.TransportType != TransportTypeUDP {
+		t.Errorf("TransportType = %v, want UDP", addr.TransportType)
+	}
+	if udp := addr.Addr.(*net.UDPAddr); udp.Port != DefaultPort || !udp.IP.Equal(want) {
+		t.Errorf("Addr = %v, want [%v]:%d", udp, want, DefaultPort)
+	}
+}
+
+func TestScopedPeerAddress(t *testing.T) {
+	ip := net.ParseIP("fe80::1")
+	if _, err := NewScopedUDPPeerAddress(ip, DefaultPort, ""); err != ErrMissingZone {
+		t.Errorf("NewScopedUDPPeerAddress(no zone) error = %v, want %v", err, ErrMissingZone)
+	}
+
+	eth0, err := NewScopedUDPPeerAddress(ip, DefaultPort, "eth0")
+	if err != nil {
+		t.Fatalf("NewScopedUDPPeerAddress() error = %v", err)
+	}
+	wlan0, _ := NewScopedUDPPeerAddress(ip, DefaultPort, "wlan0")
+	if eth0.Zone() != "eth0" || wlan0.Zone() != "wlan0" {
+		t.Errorf("Zone() = %q, %q, want eth0, wlan0", eth0.Zone(), wlan0.Zone())
+	}
+	if eth0.String() == wlan0.String() {
+		t.Errorf("String() = %q on both interfaces", eth0.String())
+	}
+
+	// The zone only scopes link-local addresses
+	global, err := NewScopedUDPPeerAddress(net.ParseIP("2001:db8::1"), DefaultPort, "eth0")
+	if err != nil || global.Zone() != "" {
+		t.Errorf("NewScopedUDPPeerAddress(global) = %v, %v, want no zone", global, err)
+	}
+
+	parsed, err := UDPAddrFromString("[fe80::1%eth0]:5540")
+	if err != nil || parsed.Zone() != "eth0" || parsed.String() != eth0.String() {
+		t.Errorf("UDPAddrFromString() = %v, %v, want %v", parsed, err, eth0)
+	}
+	tcp, err := TCPAddrFromString("[fe80::1%eth0]:5540")
+	if err != nil || tcp.Zone() != "eth0" {
+		t.Errorf("TCPAddrFromString() = %v, %v, want zone eth0", tcp, err)
+	}
+}
+
+func TestManagerSend_MissingZone(t *testing.T) {
+	handler := func(msg *ReceivedMessage) {}
+	pair, err := NewPipeManagerPair(PipeManagerConfig{UDP: true, Handlers: [2]MessageHandler{handler, handler}})
+	if err != nil {
+		t.Fatalf("NewPipeManagerPair() error = %v", err)
+	}
+	defer pair.Close()
+
+	unscoped := NewUDPPeerAddress(&net.UDPAddr{IP: net.ParseIP("fe80::1"), Port: DefaultPort})
+	if err := pair.Manager(0).Send([]byte("hello"), unscoped); err != ErrMissingZone {
+		t.Errorf("Send(unscoped link-local) error = %v, want %v", err, ErrMissingZone)
+	}
+}
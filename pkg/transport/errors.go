@@ -19,12 +19,28 @@ var (
 	// ErrAlreadyStarted is returned when Start is called on an already running transport.
 	ErrAlreadyStarted = errors.New("transport: already started")
 
+	// ErrMissingZone is returned for a link-local IPv6 peer address
+	// without the zone that selects the interface to reach it through.
+	ErrMissingZone = errors.New("transport: link-local address without zone")
+
 	// ErrConnectionNotFound is returned when no connection exists for a peer address.
 	ErrConnectionNotFound = errors.New("transport: connection not found for peer")
 
 	// ErrSendFailed is returned when sending a message fails.
 	ErrSendFailed = errors.New("transport: send failed")
 
+	// ErrUDPDisabled is returned when an operation requires the disabled
+	// UDP transport.
+	ErrUDPDisabled = errors.New("transport: UDP not enabled")
+
+	// ErrNoTLSConfig is returned when a TLS factory is created without a
+	// TLS configuration.
+	ErrNoTLSConfig = errors.New("transport: TLS configuration required")
+
+	// ErrSocketOptionUnsupported is returned when interface binding or
+	// port reuse is requested on a platform without them.
+	ErrSocketOptionUnsupported = errors.New("transport: socket option not supported on this platform")
+
 	// ErrMessageTooLarge is returned when a message exceeds the maximum size.
 	ErrMessageTooLarge = errors.New("transport: message too large")
 )
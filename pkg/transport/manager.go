@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"net"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/backkem/matter/pkg/message"
 	"github.com/pion/logging"
 )
 
@@ -15,10 +18,16 @@ type Manager struct {
 	udp     *UDP
 	tcp     *TCP
 	handler MessageHandler
+	tap     atomic.Pointer[Tap]
+	log     logging.LeveledLogger
 
 	mu      sync.RWMutex
 	started bool
 	closed  bool
+
+	// groups holds the joined groups, by group ID, with the fabrics
+	// they were joined on.
+	groups map[uint16]map[uint64]struct{}
 }
 
 // ManagerConfig configures the transport manager.
@@ -42,9 +51,44 @@ type ManagerConfig struct {
 	// TCPListener is an optional pre-existing TCP listener for testing.
 	TCPListener net.Listener
 
+	// TCPDial opens outgoing stream connections, like those of a
+	// StreamDialer. If nil, plain TCP connections are dialed.
+	TCPDial func(addr net.Addr) (net.Conn, error)
+
+	// MulticastInterface is the interface to join group multicast
+	// addresses on. If nil, Interface is used, or else the system chooses
+	// one.
+	MulticastInterface *net.Interface
+
+	// Interface binds the sockets the manager creates, and the TCP
+	// connections it dials, to a network interface, e.g. the one facing
+	// the Matter network on a hub with several. Supported on Linux and
+	// macOS. If nil, all interfaces are used.
+	Interface *net.Interface
+
+	// ReusePort sets SO_REUSEPORT on the sockets the manager creates, so
+	// that other sockets, like those of an mDNS stack or another
+	// controller on the same host, can bind the same port. Supported on
+	// Linux and macOS.
+	ReusePort bool
+
+	// SelectSource picks the source address and interface of each UDP
+	// packet by destination. If nil, the system routes packets.
+	SelectSource SourceSelector
+
+	// MaxMessageSize is the largest message sent over UDP, for links with
+	// a smaller MTU, such as Thread. A UDPConn implementing
+	// SizeLimitedConn lowers it to its limit.
+	// Defaults to message.MaxUDPMessageSize if zero.
+	MaxMessageSize int
+
 	// LoggerFactory is the factory for creating loggers.
 	// If nil, logging is disabled.
 	LoggerFactory logging.LoggerFactory
+
+	// Tap observes every frame sent and received.
+	// If nil, frames are not captured. See also Manager.SetTap.
+	Tap Tap
 }
 
 // NewManager creates a new transport manager with the given configuration.
@@ -67,6 +111,11 @@ func NewManager(config ManagerConfig) (*Manager, error) {
 
 	m := &Manager{
 		handler: config.MessageHandler,
+		groups:  make(map[uint16]map[uint64]struct{}),
+	}
+	m.SetTap(config.Tap)
+	if config.LoggerFactory != nil {
+		m.log = config.LoggerFactory.NewLogger("transport")
 	}
 
 	listenAddr := fmt.Sprintf(":%d", config.Port)
@@ -74,10 +123,15 @@ func NewManager(config ManagerConfig) (*Manager, error) {
 	// Create UDP transport if enabled
 	if config.UDPEnabled {
 		udp, err := NewUDP(UDPConfig{
-			Conn:           config.UDPConn,
-			ListenAddr:     listenAddr,
-			MessageHandler: config.MessageHandler,
-			LoggerFactory:  config.LoggerFactory,
+			Conn:               config.UDPConn,
+			ListenAddr:         listenAddr,
+			MessageHandler:     m.receive,
+			MulticastInterface: config.MulticastInterface,
+			Interface:          config.Interface,
+			ReusePort:          config.ReusePort,
+			SelectSource:       config.SelectSource,
+			MaxMessageSize:     config.MaxMessageSize,
+			LoggerFactory:      config.LoggerFactory,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("creating UDP transport: %w", err)
@@ -90,7 +144,10 @@ func NewManager(config ManagerConfig) (*Manager, error) {
 		tcp, err := NewTCP(TCPConfig{
 			Listener:       config.TCPListener,
 			ListenAddr:     listenAddr,
-			MessageHandler: config.MessageHandler,
+			Dial:           config.TCPDial,
+			Interface:      config.Interface,
+			ReusePort:      config.ReusePort,
+			MessageHandler: m.receive,
 			LoggerFactory:  config.LoggerFactory,
 		})
 		if err != nil {
@@ -169,6 +226,8 @@ func (m *Manager) Stop() error {
 
 // Send sends a message to the specified peer address.
 // The transport type is determined by the PeerAddress.TransportType field.
+// A link-local IPv6 address must carry its zone, or ErrMissingZone is
+// returned.
 func (m *Manager) Send(data []byte, peer PeerAddress) error {
 	m.mu.RLock()
 	if m.closed {
@@ -180,21 +239,154 @@ func (m *Manager) Send(data []byte, peer PeerAddress) error {
 	if !peer.IsValid() {
 		return ErrInvalidAddress
 	}
+	if peer.missingZone() {
+		return ErrMissingZone
+	}
 
+	var err error
 	switch peer.TransportType {
 	case TransportTypeUDP:
 		if m.udp == nil {
 			return fmt.Errorf("UDP transport not enabled")
 		}
-		return m.udp.Send(data, peer.Addr)
+		err = m.udp.Send(data, peer.Addr)
 	case TransportTypeTCP:
 		if m.tcp == nil {
 			return fmt.Errorf("TCP transport not enabled")
 		}
-		return m.tcp.SendRaw(data, peer.Addr)
+		err = m.tcp.SendRaw(data, peer.Addr)
 	default:
 		return ErrInvalidAddress
 	}
+	if err != nil {
+		return err
+	}
+
+	m.capture(DirectionOutbound, data, peer)
+	return nil
+}
+
+// SetTap sets the Tap observing sent and received frames. A nil tap
+// disables capture. It is safe to call while the manager is running.
+func (m *Manager) SetTap(tap Tap) {
+	if tap == nil {
+		m.tap.Store(nil)
+		return
+	}
+	m.tap.Store(&tap)
+}
+
+// JoinGroup starts receiving the messages of a group, sent to its IPv6
+// multicast address on the fabric. Joining a group twice has no effect.
+//
+// See Spec Section 2.5.6.2 (IPv6 Multicast Address).
+func (m *Manager) JoinGroup(fabricID uint64, groupID uint16) error {
+	if m.udp == nil {
+		return ErrUDPDisabled
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return ErrClosed
+	}
+	if _, ok := m.groups[groupID][fabricID]; ok {
+		return nil
+	}
+	if err := m.udp.JoinGroup(GroupMulticastAddress(fabricID, groupID)); err != nil {
+		return err
+	}
+	if m.groups[groupID] == nil {
+		m.groups[groupID] = make(map[uint64]struct{})
+	}
+	m.groups[groupID][fabricID] = struct{}{}
+	return nil
+}
+
+// LeaveGroup stops receiving the messages of a group. Leaving a group
+// that was not joined has no effect.
+func (m *Manager) LeaveGroup(fabricID uint64, groupID uint16) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.groups[groupID][fabricID]; !ok {
+		return nil
+	}
+	delete(m.groups[groupID], fabricID)
+	if len(m.groups[groupID]) == 0 {
+		delete(m.groups, groupID)
+	}
+	if m.closed {
+		return nil
+	}
+	return m.udp.LeaveGroup(GroupMulticastAddress(fabricID, groupID))
+}
+
+// receive passes a received message to the tap and the handler.
+func (m *Manager) receive(msg *ReceivedMessage) {
+	m.capture(DirectionInbound, msg.Data, msg.PeerAddr)
+	if !m.acceptGroup(msg.Data) {
+		return
+	}
+	m.handler(msg)
+}
+
+// acceptGroup reports whether a received message is for us as far as
+// groups are concerned: unicast messages always are, group messages only
+// for a joined group. A socket may deliver packets of a multicast address
+// joined by another group, or by another process on the same port.
+//
+// With privacy, the destination is obfuscated and checked once decrypted.
+// Group key messages to a single node, as sent by message counter
+// synchronization, are checked by the session layer.
+func (m *Manager) acceptGroup(data []byte) bool {
+	var header message.MessageHeader
+	if _, err := header.Decode(data); err != nil {
+		// Malformed messages are for the upper layer to reject
+		return true
+	}
+	if header.SessionType != message.SessionTypeGroup || header.Privacy ||
+		header.DestinationType != message.DestinationGroupID {
+		return true
+	}
+
+	m.mu.RLock()
+	_, joined := m.groups[header.DestinationGroupID]
+	m.mu.RUnlock()
+
+	if !joined && m.log != nil {
+		m.log.Debugf("dropping message for group 0x%04x, not joined", header.DestinationGroupID)
+	}
+	return joined
+}
+
+// capture hands a frame to the tap, if any.
+func (m *Manager) capture(dir Direction, data []byte, peer PeerAddress) {
+	tap := m.tap.Load()
+	if tap == nil {
+		return
+	}
+
+	var local net.Addr
+	switch peer.TransportType {
+	case TransportTypeUDP:
+		if m.udp != nil {
+			local = m.udp.LocalAddr()
+		}
+	case TransportTypeTCP:
+		if m.tcp != nil {
+			local = m.tcp.LocalAddr()
+		}
+	}
+
+	(*tap).Capture(&CapturedFrame{
+		Time:      time.Now(),
+		Direction: dir,
+		Local:     local,
+		Peer:      peer,
+		Data:      data,
+	})
 }
 
 // LocalAddresses returns all local addresses the manager is listening on.
@@ -211,6 +403,15 @@ func (m *Manager) LocalAddresses() []net.Addr {
 	return addrs
 }
 
+// MaxMessageSize returns the largest message the manager sends over UDP,
+// to which a node sizes its messages. Messages over TCP may be larger.
+func (m *Manager) MaxMessageSize() int {
+	if m.udp != nil {
+		return m.udp.MaxMessageSize()
+	}
+	return message.MaxUDPMessageSize
+}
+
 // UDP returns the UDP transport, or nil if not enabled.
 func (m *Manager) UDP() *UDP {
 	return m.udp
@@ -3,8 +3,11 @@ package transport
 import (
 	"bytes"
 	"net"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/backkem/matter/pkg/message"
 )
 
 func TestNewManager(t *testing.T) {
@@ -129,7 +132,7 @@ func TestManagerSendUDP(t *testing.T) {
 		UDPConn:        serverConn,
 		UDPEnabled:     true,
 		TCPEnabled:     false,
-		MessageHandler: func(msg *ReceivedMessage) { received <- msg },
+		MessageHandler: func(msg *ReceivedMessage) { received <- cloneMessage(msg) },
 	})
 	if err != nil {
 		t.Fatalf("NewManager() server error = %v", err)
@@ -174,6 +177,103 @@ func TestManagerSendUDP(t *testing.T) {
 	}
 }
 
+// recordingTap records captured frames.
+type recordingTap struct {
+	mu     sync.Mutex
+	frames []CapturedFrame
+}
+
+func (r *recordingTap) Capture(f *CapturedFrame) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	frame := *f
+	frame.Data = append([]byte(nil), f.Data...)
+	r.frames = append(r.frames, frame)
+}
+
+func (r *recordingTap) Frames() []CapturedFrame {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]CapturedFrame(nil), r.frames...)
+}
+
+func TestManagerTap(t *testing.T) {
+	serverConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket() server error = %v", err)
+	}
+	clientConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket() client error = %v", err)
+	}
+
+	received := make(chan struct{}, 2)
+	serverTap := &recordingTap{}
+	server, err := NewManager(ManagerConfig{
+		UDPConn:        serverConn,
+		UDPEnabled:     true,
+		MessageHandler: func(msg *ReceivedMessage) { received <- struct{}{} },
+		Tap:            serverTap,
+	})
+	if err != nil {
+		t.Fatalf("NewManager() server error = %v", err)
+	}
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start() server error = %v", err)
+	}
+	defer server.Stop()
+
+	client, err := NewManager(ManagerConfig{
+		UDPConn:        clientConn,
+		UDPEnabled:     true,
+		MessageHandler: func(msg *ReceivedMessage) {},
+	})
+	if err != nil {
+		t.Fatalf("NewManager() client error = %v", err)
+	}
+	if err := client.Start(); err != nil {
+		t.Fatalf("Start() client error = %v", err)
+	}
+	defer client.Stop()
+
+	clientTap := &recordingTap{}
+	client.SetTap(clientTap)
+
+	peer := NewUDPPeerAddress(server.UDP().LocalAddr())
+	if err := client.Send([]byte("first"), peer); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	<-received
+
+	client.SetTap(nil)
+	if err := client.Send([]byte("second"), peer); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	<-received
+
+	out := clientTap.Frames()
+	if len(out) != 1 {
+		t.Fatalf("client captured %d frames, want 1", len(out))
+	}
+	if out[0].Direction != DirectionOutbound || string(out[0].Data) != "first" {
+		t.Errorf("client frame = %v %q", out[0].Direction, out[0].Data)
+	}
+	if out[0].Local.String() != clientConn.LocalAddr().String() {
+		t.Errorf("Local = %v, want %v", out[0].Local, clientConn.LocalAddr())
+	}
+
+	in := serverTap.Frames()
+	if len(in) != 2 {
+		t.Fatalf("server captured %d frames, want 2", len(in))
+	}
+	if in[0].Direction != DirectionInbound || string(in[1].Data) != "second" {
+		t.Errorf("server frames = %v %q", in[0].Direction, in[1].Data)
+	}
+	if in[0].Peer.Addr.String() != clientConn.LocalAddr().String() {
+		t.Errorf("Peer = %v, want %v", in[0].Peer.Addr, clientConn.LocalAddr())
+	}
+}
+
 func TestManagerSendErrors(t *testing.T) {
 	t.Run("invalid peer address", func(t *testing.T) {
 		m, err := NewManager(ManagerConfig{
@@ -299,3 +399,73 @@ func TestManagerAccessors(t *testing.T) {
 		t.Error("TCP() = nil")
 	}
 }
+
+func TestManagerGroups(t *testing.T) {
+	network := NewPipeNetwork()
+	defer network.Close()
+
+	conn, err := network.NewFactory().CreateUDPConn(DefaultPort)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewManager(ManagerConfig{
+		UDPConn:        conn,
+		UDPEnabled:     true,
+		MessageHandler: func(msg *ReceivedMessage) {},
+	})
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	defer m.Stop()
+
+	if err := m.JoinGroup(1, 0x0101); err != nil {
+		t.Fatalf("JoinGroup() error = %v", err)
+	}
+	if err := m.JoinGroup(1, 0x0101); err != nil {
+		t.Fatalf("JoinGroup() again error = %v", err)
+	}
+
+	groupMessage := func(groupID uint16) []byte {
+		header := message.MessageHeader{
+			SessionID:          0x1234,
+			SessionType:        message.SessionTypeGroup,
+			SourcePresent:      true,
+			SourceNodeID:       1,
+			DestinationType:    message.DestinationGroupID,
+			DestinationGroupID: groupID,
+		}
+		return header.Encode()
+	}
+	unicast := (&message.MessageHeader{SessionID: 1}).Encode()
+	groupToNode := (&message.MessageHeader{
+		SessionID:         0x1234,
+		SessionType:       message.SessionTypeGroup,
+		SourcePresent:     true,
+		SourceNodeID:      1,
+		DestinationType:   message.DestinationNodeID,
+		DestinationNodeID: 2,
+	}).Encode()
+
+	tests := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{"unicast", unicast, true},
+		{"joined group", groupMessage(0x0101), true},
+		{"other group", groupMessage(0x0202), false},
+		{"group key to a node", groupToNode, true},
+	}
+	for _, tt := range tests {
+		if got := m.acceptGroup(tt.data); got != tt.want {
+			t.Errorf("acceptGroup(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if err := m.LeaveGroup(1, 0x0101); err != nil {
+		t.Fatalf("LeaveGroup() error = %v", err)
+	}
+	if m.acceptGroup(groupMessage(0x0101)) {
+		t.Error("acceptGroup() accepts a group that was left")
+	}
+}
@@ -5,7 +5,9 @@ package transport
 // including the Matter message header, payload, and MIC (if encrypted).
 // Higher layers are responsible for parsing and processing the message.
 type ReceivedMessage struct {
-	// Data contains the raw message bytes.
+	// Data contains the raw message bytes. The transports reuse their
+	// receive buffers, so Data must not be modified or retained after the
+	// handler returns; copy it to keep it.
 	Data []byte
 	// PeerAddr identifies the source of the message.
 	PeerAddr PeerAddress
@@ -13,5 +15,6 @@ type ReceivedMessage struct {
 
 // MessageHandler is called for each received message.
 // Implementations should process messages quickly or dispatch to a goroutine
-// to avoid blocking the transport's read loop.
+// to avoid blocking the transport's read loop. The message is only valid
+// until the handler returns.
 type MessageHandler func(msg *ReceivedMessage)
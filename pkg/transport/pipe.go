@@ -20,11 +20,29 @@ type Factory interface {
 	// CreateTCPListener creates a TCP-like listener.
 	// The port parameter is used for address assignment.
 	// Returns nil if TCP is not supported.
+	// The listener's Addr need not be a *net.TCPAddr: a stream transport
+	// over another protocol reports that protocol's address, e.g. the
+	// *net.UDPAddr of a QUIC listener. Peers dial it as a TCP peer address
+	// with the same IP and port.
 	CreateTCPListener(port int) (net.Listener, error)
 }
 
+// StreamDialer is implemented by a Factory whose stream transport needs
+// more than a plain TCP connection to reach a peer, e.g. a TLS handshake.
+// A node dials its outgoing stream connections with it; see
+// ManagerConfig.TCPDial.
+type StreamDialer interface {
+	// DialStream opens a stream connection to addr.
+	DialStream(addr net.Addr) (net.Conn, error)
+}
+
 // NetworkCondition configures network behavior simulation.
 // Use this to test protocol behavior under adverse network conditions.
+//
+// A condition applies to the packets crossing a link in one direction;
+// SetCondition applies it to both. The random drops,
+// delays and duplicates are drawn from the pipe's random source, which
+// PipeConfig.Seed or SetSeed make reproducible.
 type NetworkCondition struct {
 	// DropRate is the probability of dropping a packet (0.0 - 1.0).
 	DropRate float64
@@ -45,6 +63,52 @@ type NetworkCondition struct {
 
 	// ReorderDelay is the additional delay for reordered packets.
 	ReorderDelay time.Duration
+
+	// Bandwidth is the link's throughput in bytes per second. A packet
+	// takes len/Bandwidth to send, after the packets queued before it, so
+	// bursts are delayed as they would be on a slow link. Zero means no
+	// limit.
+	Bandwidth int
+
+	// MTU is the largest packet the link carries, in bytes. Larger packets
+	// are dropped, as a network that does not fragment them would. Zero
+	// means no limit.
+	MTU int
+}
+
+// transmit decides the fate of a packet of size bytes crossing the link:
+// whether it arrives, after what delay and in how many copies. busyUntil
+// is when the link is done sending the packets queued before, and is moved
+// past this one.
+func (c NetworkCondition) transmit(rng *rand.Rand, size int, now time.Time, busyUntil *time.Time) (delay time.Duration, copies int, ok bool) {
+	if c.MTU > 0 && size > c.MTU {
+		return 0, 0, false
+	}
+	if c.DropRate > 0 && rng.Float64() < c.DropRate {
+		return 0, 0, false
+	}
+
+	if c.Bandwidth > 0 {
+		start := now
+		if busyUntil.After(start) {
+			start = *busyUntil
+		}
+		*busyUntil = start.Add(time.Duration(size) * time.Second / time.Duration(c.Bandwidth))
+		delay = busyUntil.Sub(now)
+	}
+	delay += c.DelayMin
+	if c.DelayMax > c.DelayMin {
+		delay += time.Duration(rng.Int63n(int64(c.DelayMax - c.DelayMin)))
+	}
+	if c.ReorderRate > 0 && rng.Float64() < c.ReorderRate {
+		delay += c.ReorderDelay
+	}
+
+	copies = 1
+	if c.DuplicateRate > 0 && rng.Float64() < c.DuplicateRate {
+		copies++
+	}
+	return delay, copies, true
 }
 
 // PipeConfig configures a Pipe.
@@ -56,6 +120,11 @@ type PipeConfig struct {
 	// ProcessInterval is how often the auto-processor checks for messages.
 	// Default: 1ms
 	ProcessInterval time.Duration
+
+	// Seed seeds the random source of the network conditions, so that a
+	// test's drops, delays and duplicates repeat across runs.
+	// Default: 0 (seeded from the clock)
+	Seed int64
 }
 
 // DefaultPipeConfig returns the default pipe configuration.
@@ -68,6 +137,8 @@ func DefaultPipeConfig() PipeConfig {
 
 // Pipe provides bidirectional in-memory packet communication between two endpoints.
 // It wraps pion's test.Bridge and adds network condition simulation.
+// Stream (TCP) connections run over a bridge of their own, so a TCP reader
+// never takes UDP packets.
 //
 // By default, Pipe automatically delivers messages in a background goroutine.
 // Use SetAutoProcess(false) or NewPipeWithConfig for manual control.
@@ -76,11 +147,14 @@ func DefaultPipeConfig() PipeConfig {
 // Use Pipe for deterministic, flaky-free tests without real network I/O.
 type Pipe struct {
 	bridge *test.Bridge
+	stream *test.Bridge
+
+	mu         sync.RWMutex
+	conditions [2]NetworkCondition // by sending endpoint
+	busyUntil  [2]time.Time        // by sending endpoint, see NetworkCondition.Bandwidth
+	closed     bool
+	rng        *rand.Rand
 
-	mu              sync.RWMutex
-	condition       NetworkCondition
-	closed          bool
-	rng             *rand.Rand
 	autoProcess     bool
 	processInterval time.Duration
 	stopCh          chan struct{}
@@ -95,9 +169,15 @@ func NewPipe() *Pipe {
 
 // NewPipeWithConfig creates a new pipe with the given configuration.
 func NewPipeWithConfig(config PipeConfig) *Pipe {
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
 	p := &Pipe{
 		bridge:          test.NewBridge(),
-		rng:             rand.New(rand.NewSource(time.Now().UnixNano())),
+		stream:          test.NewBridge(),
+		rng:             rand.New(rand.NewSource(seed)),
 		autoProcess:     config.AutoProcess,
 		processInterval: config.ProcessInterval,
 		stopCh:          make(chan struct{}),
@@ -127,7 +207,7 @@ func (p *Pipe) startAutoProcess() {
 			case <-p.stopCh:
 				return
 			case <-ticker.C:
-				p.bridge.Tick()
+				p.Tick()
 			}
 		}
 	}()
@@ -173,14 +253,56 @@ func (p *Pipe) AutoProcess() bool {
 func (p *Pipe) SetCondition(cond NetworkCondition) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	p.condition = cond
+	p.conditions = [2]NetworkCondition{cond, cond}
 }
 
-// Condition returns the current network condition configuration.
+// Condition returns the network conditions of packets sent by endpoint 0,
+// which SetCondition also applies to those sent by endpoint 1.
 func (p *Pipe) Condition() NetworkCondition {
+	return p.DirectionCondition(0)
+}
+
+// SetDirectionCondition configures network condition simulation for the
+// packets sent by one endpoint (0 or 1), e.g. to simulate a device whose
+// uplink is slower than its downlink.
+func (p *Pipe) SetDirectionCondition(from int, cond NetworkCondition) {
+	if from < 0 || from > 1 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.conditions[from] = cond
+}
+
+// DirectionCondition returns the network conditions of packets sent by
+// one endpoint (0 or 1).
+func (p *Pipe) DirectionCondition(from int) NetworkCondition {
+	if from < 0 || from > 1 {
+		return NetworkCondition{}
+	}
 	p.mu.RLock()
 	defer p.mu.RUnlock()
-	return p.condition
+	return p.conditions[from]
+}
+
+// SetSeed reseeds the random source of the network conditions.
+func (p *Pipe) SetSeed(seed int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rng = rand.New(rand.NewSource(seed))
+}
+
+// transmit decides the fate of a packet of size bytes sent by an endpoint,
+// see NetworkCondition.transmit.
+func (p *Pipe) transmit(from int, size int) (delay time.Duration, copies int, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	cond := p.conditions[from]
+	if cond == (NetworkCondition{}) {
+		return 0, 1, true
+	}
+	return cond.transmit(p.rng, size, time.Now(), &p.busyUntil[from])
 }
 
 // Conn0 returns the connection for endpoint 0.
@@ -193,13 +315,22 @@ func (p *Pipe) Conn1() net.Conn {
 	return p.bridge.GetConn1()
 }
 
-// Tick delivers one packet in each direction (if available).
-// Returns the number of packets delivered (0, 1, or 2).
+// streamConn returns the stream connection of an endpoint.
+func (p *Pipe) streamConn(id int) net.Conn {
+	if id == 0 {
+		return p.stream.GetConn0()
+	}
+	return p.stream.GetConn1()
+}
+
+// Tick delivers one packet in each direction (if available), and one
+// stream write in each direction.
+// Returns the number of packets and writes delivered (0 to 4).
 //
 // Note: When AutoProcess is enabled (default), you typically don't need
 // to call this manually. Use SetAutoProcess(false) for manual control.
 func (p *Pipe) Tick() int {
-	return p.bridge.Tick()
+	return p.bridge.Tick() + p.stream.Tick()
 }
 
 // Process delivers all queued packets.
@@ -238,13 +369,12 @@ func (p *Pipe) Close() error {
 	// Wait for goroutine outside lock
 	p.wg.Wait()
 
-	// Close both connections
+	// Close both connections of both bridges
 	var errs []error
-	if err := p.bridge.GetConn0().Close(); err != nil {
-		errs = append(errs, err)
-	}
-	if err := p.bridge.GetConn1().Close(); err != nil {
-		errs = append(errs, err)
+	for _, conn := range []net.Conn{p.bridge.GetConn0(), p.bridge.GetConn1(), p.stream.GetConn0(), p.stream.GetConn1()} {
+		if err := conn.Close(); err != nil {
+			errs = append(errs, err)
+		}
 	}
 
 	if len(errs) > 0 {
@@ -287,40 +417,38 @@ func (c *PipePacketConn) ReadFrom(b []byte) (n int, addr net.Addr, err error) {
 func (c *PipePacketConn) WriteTo(b []byte, addr net.Addr) (n int, err error) {
 	// Apply network conditions if configured
 	if c.pipe != nil {
-		c.pipe.mu.RLock()
-		cond := c.pipe.condition
-		rng := c.pipe.rng
-		c.pipe.mu.RUnlock()
-
-		// Check for drop
-		if cond.DropRate > 0 && rng.Float64() < cond.DropRate {
+		delay, copies, ok := c.pipe.transmit(c.localID, len(b))
+		if !ok {
 			return len(b), nil // Silently drop
 		}
 
-		// Apply delay
-		if cond.DelayMax > 0 {
-			delay := cond.DelayMin
-			if cond.DelayMax > cond.DelayMin {
-				delay += time.Duration(rng.Int63n(int64(cond.DelayMax - cond.DelayMin)))
-			}
-			if delay > 0 {
-				time.Sleep(delay)
-			}
+		// Apply delay, including the wait for the link's bandwidth
+		if delay > 0 {
+			time.Sleep(delay)
 		}
 
-		// Check for duplicate - send twice
-		if cond.DuplicateRate > 0 && rng.Float64() < cond.DuplicateRate {
-			// Send first copy
+		// Send the duplicates first
+		for i := 1; i < copies; i++ {
 			if _, err := c.conn.Write(b); err != nil {
 				return 0, err
 			}
-			// Fall through to send second copy
 		}
 	}
 
 	return c.conn.Write(b)
 }
 
+// JoinGroup implements MulticastConn. The pipe delivers every packet to
+// its peer, so there is nothing to join.
+func (c *PipePacketConn) JoinGroup(group net.IP) error {
+	return nil
+}
+
+// LeaveGroup implements MulticastConn.
+func (c *PipePacketConn) LeaveGroup(group net.IP) error {
+	return nil
+}
+
 // Close closes the pipe connection.
 func (c *PipePacketConn) Close() error {
 	return c.conn.Close()
@@ -584,13 +712,8 @@ func (f *PipeFactory) CreateTCPListener(port int) (net.Listener, error) {
 		return f.tcpListener, nil
 	}
 
-	// Get the appropriate connection from the pipe
-	var conn net.Conn
-	if f.localID == 0 {
-		conn = f.pipe.Conn0()
-	} else {
-		conn = f.pipe.Conn1()
-	}
+	// Get the appropriate stream connection from the pipe
+	conn := f.pipe.streamConn(f.localID)
 
 	// Determine peer address
 	peerID := 1 - f.localID
@@ -611,6 +734,18 @@ func (f *PipeFactory) SetCondition(cond NetworkCondition) {
 	f.pipe.SetCondition(cond)
 }
 
+// SetSendCondition configures network condition simulation for the
+// packets this side of the pipe sends.
+func (f *PipeFactory) SetSendCondition(cond NetworkCondition) {
+	f.pipe.SetDirectionCondition(f.localID, cond)
+}
+
+// SetReceiveCondition configures network condition simulation for the
+// packets this side of the pipe receives.
+func (f *PipeFactory) SetReceiveCondition(cond NetworkCondition) {
+	f.pipe.SetDirectionCondition(1-f.localID, cond)
+}
+
 // GetTCPClientConn returns a TCP client connection for connecting to the peer's listener.
 // This is the counterpart to CreateTCPListener - use it on the "client" side of the pipe.
 //
@@ -622,13 +757,8 @@ func (f *PipeFactory) SetCondition(cond NetworkCondition) {
 //	serverConn, _ := listener.Accept()
 //	// Now clientConn and serverConn are connected via the pipe
 func (f *PipeFactory) GetTCPClientConn(port int) net.Conn {
-	// Get the appropriate connection from the pipe
-	var conn net.Conn
-	if f.localID == 0 {
-		conn = f.pipe.Conn0()
-	} else {
-		conn = f.pipe.Conn1()
-	}
+	// Get the appropriate stream connection from the pipe
+	conn := f.pipe.streamConn(f.localID)
 
 	// Determine peer address
 	peerID := 1 - f.localID
@@ -703,7 +833,9 @@ func NewPipeManagerPair(config PipeManagerConfig) (*PipeManagerPair, error) {
 		config.TCP = true
 	}
 	if config.PipeConfig.ProcessInterval == 0 {
+		seed := config.PipeConfig.Seed
 		config.PipeConfig = DefaultPipeConfig()
+		config.PipeConfig.Seed = seed
 	}
 
 	port := DefaultPort
@@ -467,6 +467,104 @@ func TestNetworkCondition_StatisticalDropRate(t *testing.T) {
 	}
 }
 
+func TestNetworkCondition_Direction(t *testing.T) {
+	f0, f1 := NewPipeFactoryPair()
+	defer f0.Pipe().Close()
+
+	// Only the packets f0 sends are lost
+	f0.SetSendCondition(NetworkCondition{DropRate: 1.0})
+	if got := f0.Pipe().DirectionCondition(0); got.DropRate != 1.0 {
+		t.Errorf("DirectionCondition(0).DropRate = %v, want 1", got.DropRate)
+	}
+	if got := f0.Pipe().DirectionCondition(1); got != (NetworkCondition{}) {
+		t.Errorf("DirectionCondition(1) = %+v, want none", got)
+	}
+
+	conn0, _ := f0.CreateUDPConn(5540)
+	conn1, _ := f1.CreateUDPConn(5540)
+
+	conn0.WriteTo([]byte("dropped"), f0.PeerAddr())
+	conn1.WriteTo([]byte("delivered"), f1.PeerAddr())
+
+	buf := make([]byte, 100)
+	conn0.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn0.ReadFrom(buf)
+	if err != nil || string(buf[:n]) != "delivered" {
+		t.Errorf("ReadFrom() = %q, %v, want \"delivered\"", buf[:n], err)
+	}
+	conn1.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	if _, _, err := conn1.ReadFrom(buf); err == nil {
+		t.Error("expected timeout error due to dropped packet")
+	}
+
+	// The receiving side's condition is the sending side's of its peer
+	f1.SetReceiveCondition(NetworkCondition{})
+	if got := f0.Pipe().DirectionCondition(0); got != (NetworkCondition{}) {
+		t.Errorf("DirectionCondition(0) = %+v after SetReceiveCondition, want none", got)
+	}
+}
+
+func TestNetworkCondition_MTU(t *testing.T) {
+	f0, f1 := NewPipeFactoryPair()
+	defer f0.Pipe().Close()
+
+	f0.SetCondition(NetworkCondition{MTU: 8})
+
+	conn0, _ := f0.CreateUDPConn(5540)
+	conn1, _ := f1.CreateUDPConn(5540)
+
+	// Oversized datagrams are dropped, as if sent
+	if n, err := conn0.WriteTo([]byte("oversized"), f1.PeerAddr()); err != nil || n != 9 {
+		t.Errorf("WriteTo() = %d, %v, want 9, nil", n, err)
+	}
+	conn0.WriteTo([]byte("fits"), f1.PeerAddr())
+
+	buf := make([]byte, 100)
+	conn1.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn1.ReadFrom(buf)
+	if err != nil || string(buf[:n]) != "fits" {
+		t.Errorf("ReadFrom() = %q, %v, want \"fits\"", buf[:n], err)
+	}
+}
+
+func TestNetworkCondition_Bandwidth(t *testing.T) {
+	f0, f1 := NewPipeFactoryPair()
+	defer f0.Pipe().Close()
+
+	// 20ms per 20-byte packet
+	f0.SetCondition(NetworkCondition{Bandwidth: 1000})
+
+	conn0, _ := f0.CreateUDPConn(5540)
+	f1.CreateUDPConn(5540)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		conn0.WriteTo(make([]byte, 20), f1.PeerAddr())
+	}
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("sending 60 bytes at 1000 B/s took %v, want at least 60ms", elapsed)
+	}
+}
+
+func TestNetworkCondition_Seed(t *testing.T) {
+	// Pipes with the same seed lose the same packets
+	var fates [2][]bool
+	for i := range fates {
+		p := NewPipeWithConfig(PipeConfig{Seed: 42})
+		p.SetCondition(NetworkCondition{DropRate: 0.5})
+		for j := 0; j < 64; j++ {
+			_, _, ok := p.transmit(0, 10)
+			fates[i] = append(fates[i], ok)
+		}
+		p.Close()
+	}
+	for j := range fates[0] {
+		if fates[0][j] != fates[1][j] {
+			t.Fatalf("packet %d: delivered = %v and %v with the same seed", j, fates[0][j], fates[1][j])
+		}
+	}
+}
+
 func TestPipe_Close(t *testing.T) {
 	pipe := NewPipe()
 
@@ -717,6 +815,59 @@ func TestPipeTCPListener_ReusesListener(t *testing.T) {
 	}
 }
 
+func TestPipeTCPListener_SeparateFromUDP(t *testing.T) {
+	f0, f1 := NewPipeFactoryPair()
+	defer f0.Pipe().Close()
+
+	udp0, _ := f0.CreateUDPConn(5540)
+	udp1, _ := f1.CreateUDPConn(5540)
+
+	// A TCP reader on the same side must not take UDP packets
+	listener, _ := f0.CreateTCPListener(5540)
+	defer listener.Close()
+	serverConn, _ := listener.Accept()
+	defer serverConn.Close()
+	streamData := make(chan []byte, 1)
+	go func() {
+		buf := make([]byte, 100)
+		n, err := serverConn.Read(buf)
+		if err == nil {
+			streamData <- buf[:n]
+		}
+	}()
+
+	const packets = 10
+	for i := range packets {
+		if _, err := udp1.WriteTo([]byte{byte(i)}, f0.LocalAddr()); err != nil {
+			t.Fatalf("WriteTo: %v", err)
+		}
+	}
+	udp0.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 100)
+	for i := range packets {
+		n, _, err := udp0.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("ReadFrom packet %d: %v", i, err)
+		}
+		if n != 1 || buf[0] != byte(i) {
+			t.Fatalf("packet %d = % X", i, buf[:n])
+		}
+	}
+
+	// ...and stream writes reach the TCP reader, not the UDP conn
+	if _, err := f1.GetTCPClientConn(5540).Write([]byte("stream")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	select {
+	case data := <-streamData:
+		if string(data) != "stream" {
+			t.Errorf("stream read %q, want %q", data, "stream")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for stream read")
+	}
+}
+
 func TestPipe_SetAutoProcess(t *testing.T) {
 	pipe := NewPipe()
 	defer pipe.Close()
@@ -0,0 +1,529 @@
+package transport
+
+import (
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+// pipeNetworkQueueSize is the number of packets buffered per endpoint.
+// Packets arriving at a full queue are dropped, like on a real network.
+const pipeNetworkQueueSize = 256
+
+// PipeNetwork is an in-memory packet network connecting any number of
+// endpoints, a virtual switch in a star topology. Use it instead of a Pipe
+// when a test needs more than two parties, e.g. a device administered by
+// two controllers.
+//
+// Each endpoint gets a PipeNetworkFactory with its own PipeAddr ID.
+// Packets are routed by the destination PipeAddr's ID and delivered
+// immediately, unless the sender's or receiver's link has network
+// conditions set (see PipeNetworkFactory.SetCondition). SetSeed makes the
+// conditions' random drops, delays and duplicates reproducible. Packets to an IPv6
+// multicast address reach every other endpoint that joined it, and the
+// sender too with SetMulticastLoopback. Partition cuts two endpoints off
+// from each other while both still reach the rest of the network. TCP is
+// not supported: listeners never accept.
+//
+// Example:
+//
+//	network := transport.NewPipeNetwork()
+//	defer network.Close()
+//	device := network.NewFactory()
+//	ctrl1, ctrl2 := network.NewFactory(), network.NewFactory()
+//	// Reach the device at transport.NewUDPPeerAddress(device.LocalAddr())
+type PipeNetwork struct {
+	mu        sync.RWMutex
+	endpoints map[int]*PipeNetworkConn
+	groups    map[string]map[int]struct{} // multicast address -> endpoint IDs
+	links     map[int]*pipeLink           // endpoint ID -> link conditions
+	cuts      map[[2]int]struct{}         // partitioned endpoint ID pairs, lower ID first
+	loopback  bool                        // multicast reaches the sender
+	nextID    int
+	closed    bool
+
+	// rngMu guards rng and the links' busy times
+	rngMu sync.Mutex
+	rng   *rand.Rand
+}
+
+// pipeLink is an endpoint's link to a PipeNetwork, with a condition per
+// direction.
+type pipeLink struct {
+	send    NetworkCondition
+	receive NetworkCondition
+
+	// sendBusy and receiveBusy are when each direction is done sending
+	// its queued packets, see NetworkCondition.Bandwidth.
+	sendBusy    time.Time
+	receiveBusy time.Time
+}
+
+// NewPipeNetwork creates an empty pipe network.
+func NewPipeNetwork() *PipeNetwork {
+	return &PipeNetwork{
+		endpoints: make(map[int]*PipeNetworkConn),
+		groups:    make(map[string]map[int]struct{}),
+		links:     make(map[int]*pipeLink),
+		cuts:      make(map[[2]int]struct{}),
+		rng:       rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// NewFactory creates a factory for a new endpoint on the network.
+func (n *PipeNetwork) NewFactory() *PipeNetworkFactory {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	id := n.nextID
+	n.nextID++
+	return &PipeNetworkFactory{network: n, id: id}
+}
+
+// Close closes all endpoints on the network.
+func (n *PipeNetwork) Close() error {
+	n.mu.Lock()
+	n.closed = true
+	endpoints := n.endpoints
+	n.endpoints = make(map[int]*PipeNetworkConn)
+	n.mu.Unlock()
+
+	for _, conn := range endpoints {
+		conn.close()
+	}
+	return nil
+}
+
+// SetMulticastLoopback sets whether packets to a multicast address also
+// reach the sender, if it joined the address, as with IP_MULTICAST_LOOP on
+// a host's sockets. Default: false.
+func (n *PipeNetwork) SetMulticastLoopback(enabled bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.loopback = enabled
+}
+
+// SetSeed reseeds the random source of the network conditions, so that a
+// test's drops, delays and duplicates repeat across runs.
+func (n *PipeNetwork) SetSeed(seed int64) {
+	n.rngMu.Lock()
+	defer n.rngMu.Unlock()
+	n.rng = rand.New(rand.NewSource(seed))
+}
+
+// Partition cuts two endpoints off from each other: packets between them,
+// unicast or multicast, are dropped in both directions, while each still
+// reaches every other endpoint. Packets already in flight arrive.
+func (n *PipeNetwork) Partition(a, b *PipeNetworkFactory) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.cuts[pipeCut(a.id, b.id)] = struct{}{}
+}
+
+// Heal reconnects two endpoints cut off from each other by Partition.
+func (n *PipeNetwork) Heal(a, b *PipeNetworkFactory) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.cuts, pipeCut(a.id, b.id))
+}
+
+// HealAll reconnects every partitioned pair of endpoints.
+func (n *PipeNetwork) HealAll() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.cuts = make(map[[2]int]struct{})
+}
+
+// pipeCut returns the key of a partitioned pair of endpoints.
+func pipeCut(a, b int) [2]int {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]int{a, b}
+}
+
+// attach registers an endpoint's connection.
+func (n *PipeNetwork) attach(conn *PipeNetworkConn) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.closed {
+		return ErrClosed
+	}
+	n.endpoints[conn.addr.ID] = conn
+	return nil
+}
+
+// detach removes an endpoint's connection.
+func (n *PipeNetwork) detach(conn *PipeNetworkConn) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.endpoints[conn.addr.ID] == conn {
+		delete(n.endpoints, conn.addr.ID)
+		for _, members := range n.groups {
+			delete(members, conn.addr.ID)
+		}
+	}
+}
+
+// join adds an endpoint to a multicast group.
+func (n *PipeNetwork) join(id int, group net.IP) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	key := group.String()
+	if n.groups[key] == nil {
+		n.groups[key] = make(map[int]struct{})
+	}
+	n.groups[key][id] = struct{}{}
+}
+
+// leave removes an endpoint from a multicast group.
+func (n *PipeNetwork) leave(id int, group net.IP) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	key := group.String()
+	delete(n.groups[key], id)
+	if len(n.groups[key]) == 0 {
+		delete(n.groups, key)
+	}
+}
+
+// route delivers a packet to the endpoint with the destination's ID, or
+// to the members of the destination's multicast group. Packets to unknown
+// destinations are dropped.
+func (n *PipeNetwork) route(data []byte, from PipeAddr, to net.Addr) {
+	if udpAddr, ok := to.(*net.UDPAddr); ok && udpAddr.IP.IsMulticast() {
+		n.multicast(data, from, udpAddr.IP)
+		return
+	}
+
+	dst, ok := to.(PipeAddr)
+	if !ok {
+		return
+	}
+
+	n.mu.RLock()
+	conn := n.endpoints[dst.ID]
+	n.mu.RUnlock()
+
+	if conn != nil {
+		n.transmit(conn, data, from)
+	}
+}
+
+// multicast delivers a packet to every member of a group but the sender,
+// unless multicast loopback is enabled.
+func (n *PipeNetwork) multicast(data []byte, from PipeAddr, group net.IP) {
+	n.mu.RLock()
+	var conns []*PipeNetworkConn
+	for id := range n.groups[group.String()] {
+		if conn := n.endpoints[id]; conn != nil && (id != from.ID || n.loopback) {
+			conns = append(conns, conn)
+		}
+	}
+	n.mu.RUnlock()
+
+	for _, conn := range conns {
+		n.transmit(conn, data, from)
+	}
+}
+
+// setCondition sets the conditions of an endpoint's link in the given
+// directions; a nil condition is left as it is.
+func (n *PipeNetwork) setCondition(id int, send, receive *NetworkCondition) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	link := n.links[id]
+	if link == nil {
+		link = &pipeLink{}
+		n.links[id] = link
+	}
+	if send != nil {
+		link.send = *send
+	}
+	if receive != nil {
+		link.receive = *receive
+	}
+	if link.send == (NetworkCondition{}) && link.receive == (NetworkCondition{}) {
+		delete(n.links, id)
+	}
+}
+
+// condition returns the conditions of an endpoint's link, per direction.
+func (n *PipeNetwork) condition(id int) (send, receive NetworkCondition) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if link := n.links[id]; link != nil {
+		return link.send, link.receive
+	}
+	return NetworkCondition{}, NetworkCondition{}
+}
+
+// transmit carries a packet over the sender's link and then the
+// receiver's, applying each link's condition, and delivers it, unless the
+// two endpoints are partitioned. Delayed
+// packets are delivered from a timer, so a sender never blocks and packets
+// with shorter delays overtake those before them.
+func (n *PipeNetwork) transmit(conn *PipeNetworkConn, data []byte, from PipeAddr) {
+	// The sender's link, then the receiver's; a missing link is perfect
+	var conds [2]NetworkCondition
+	var idle [2]time.Time
+	busy := [2]*time.Time{&idle[0], &idle[1]}
+	n.mu.RLock()
+	if _, cut := n.cuts[pipeCut(from.ID, conn.addr.ID)]; cut {
+		n.mu.RUnlock()
+		return
+	}
+	sender, receiver := n.links[from.ID], n.links[conn.addr.ID]
+	if sender != nil {
+		conds[0], busy[0] = sender.send, &sender.sendBusy
+	}
+	if receiver != nil {
+		conds[1], busy[1] = receiver.receive, &receiver.receiveBusy
+	}
+	n.mu.RUnlock()
+
+	if conds[0] == (NetworkCondition{}) && conds[1] == (NetworkCondition{}) {
+		conn.deliver(data, from)
+		return
+	}
+
+	copies := 1
+	var delay time.Duration
+	now := time.Now()
+	n.rngMu.Lock()
+	for i, cond := range conds {
+		// The receiver's link carries the packet once the sender's has
+		d, c, ok := cond.transmit(n.rng, len(data), now.Add(delay), busy[i])
+		if !ok {
+			n.rngMu.Unlock()
+			return
+		}
+		delay += d
+		copies += c - 1
+	}
+	n.rngMu.Unlock()
+
+	if delay <= 0 {
+		for i := 0; i < copies; i++ {
+			conn.deliver(data, from)
+		}
+		return
+	}
+	data = append([]byte(nil), data...)
+	time.AfterFunc(delay, func() {
+		for i := 0; i < copies; i++ {
+			conn.deliver(data, from)
+		}
+	})
+}
+
+// pipePacket is a packet queued at a PipeNetworkConn.
+type pipePacket struct {
+	data []byte
+	from PipeAddr
+}
+
+// PipeNetworkConn is an endpoint's packet connection on a PipeNetwork.
+type PipeNetworkConn struct {
+	network *PipeNetwork
+	addr    PipeAddr
+	inbox   chan pipePacket
+	closeCh chan struct{}
+	once    sync.Once
+}
+
+// ReadFrom reads the next packet and returns the sender's address.
+func (c *PipeNetworkConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	select {
+	case pkt := <-c.inbox:
+		return copy(b, pkt.data), pkt.from, nil
+	case <-c.closeCh:
+		return 0, nil, &net.OpError{Op: "read", Net: "pipe", Addr: c.addr, Err: net.ErrClosed}
+	}
+}
+
+// WriteTo sends a packet to the endpoint at addr.
+func (c *PipeNetworkConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	select {
+	case <-c.closeCh:
+		return 0, &net.OpError{Op: "write", Net: "pipe", Addr: c.addr, Err: net.ErrClosed}
+	default:
+	}
+
+	c.network.route(b, c.addr, addr)
+	return len(b), nil
+}
+
+// deliver queues a copy of a packet, dropping it if the queue is full.
+func (c *PipeNetworkConn) deliver(data []byte, from PipeAddr) {
+	pkt := pipePacket{data: append([]byte(nil), data...), from: from}
+	select {
+	case c.inbox <- pkt:
+	case <-c.closeCh:
+	default:
+	}
+}
+
+// JoinGroup implements MulticastConn.
+func (c *PipeNetworkConn) JoinGroup(group net.IP) error {
+	c.network.join(c.addr.ID, group)
+	return nil
+}
+
+// LeaveGroup implements MulticastConn.
+func (c *PipeNetworkConn) LeaveGroup(group net.IP) error {
+	c.network.leave(c.addr.ID, group)
+	return nil
+}
+
+// Close detaches the connection from the network.
+func (c *PipeNetworkConn) Close() error {
+	c.network.detach(c)
+	c.close()
+	return nil
+}
+
+func (c *PipeNetworkConn) close() {
+	c.once.Do(func() { close(c.closeCh) })
+}
+
+// LocalAddr returns the endpoint's address.
+func (c *PipeNetworkConn) LocalAddr() net.Addr {
+	return c.addr
+}
+
+// SetDeadline is a no-op; Close unblocks pending reads.
+func (c *PipeNetworkConn) SetDeadline(t time.Time) error { return nil }
+
+// SetReadDeadline is a no-op; Close unblocks pending reads.
+func (c *PipeNetworkConn) SetReadDeadline(t time.Time) error { return nil }
+
+// SetWriteDeadline is a no-op; writes never block.
+func (c *PipeNetworkConn) SetWriteDeadline(t time.Time) error { return nil }
+
+// Verify PipeNetworkConn implements net.PacketConn and MulticastConn.
+var (
+	_ net.PacketConn = (*PipeNetworkConn)(nil)
+	_ MulticastConn  = (*PipeNetworkConn)(nil)
+)
+
+// pipeNetworkListener is a TCP listener that never accepts connections.
+// It keeps nodes on a PipeNetwork from binding real TCP ports.
+type pipeNetworkListener struct {
+	addr    PipeAddr
+	closeCh chan struct{}
+	once    sync.Once
+}
+
+// Accept blocks until the listener is closed.
+func (l *pipeNetworkListener) Accept() (net.Conn, error) {
+	<-l.closeCh
+	return nil, &net.OpError{Op: "accept", Net: "pipe", Addr: l.addr, Err: net.ErrClosed}
+}
+
+// Close closes the listener.
+func (l *pipeNetworkListener) Close() error {
+	l.once.Do(func() { close(l.closeCh) })
+	return nil
+}
+
+// Addr returns the listener's address.
+func (l *pipeNetworkListener) Addr() net.Addr {
+	return l.addr
+}
+
+// PipeNetworkFactory creates transports for one endpoint on a PipeNetwork.
+type PipeNetworkFactory struct {
+	network *PipeNetwork
+	id      int
+
+	mu          sync.Mutex
+	udpConn     *PipeNetworkConn
+	tcpListener *pipeNetworkListener
+}
+
+// LocalAddr returns the endpoint's address on the network.
+func (f *PipeNetworkFactory) LocalAddr() net.Addr {
+	return PipeAddr{ID: f.id, Port: DefaultPort}
+}
+
+// SetCondition sets the network conditions of the endpoint's link to the
+// network, e.g. to simulate a lossy or distant device. They apply to every
+// packet the endpoint sends or receives, on top of the other party's link.
+// The zero NetworkCondition restores a perfect link.
+func (f *PipeNetworkFactory) SetCondition(cond NetworkCondition) {
+	f.network.setCondition(f.id, &cond, &cond)
+}
+
+// SetSendCondition sets the network conditions of the packets the endpoint
+// sends, e.g. to simulate a device whose uplink is slower than its
+// downlink.
+func (f *PipeNetworkFactory) SetSendCondition(cond NetworkCondition) {
+	f.network.setCondition(f.id, &cond, nil)
+}
+
+// SetReceiveCondition sets the network conditions of the packets the
+// endpoint receives.
+func (f *PipeNetworkFactory) SetReceiveCondition(cond NetworkCondition) {
+	f.network.setCondition(f.id, nil, &cond)
+}
+
+// Condition returns the network conditions of the packets the endpoint
+// sends, which SetCondition also applies to those it receives.
+func (f *PipeNetworkFactory) Condition() NetworkCondition {
+	send, _ := f.network.condition(f.id)
+	return send
+}
+
+// ReceiveCondition returns the network conditions of the packets the
+// endpoint receives.
+func (f *PipeNetworkFactory) ReceiveCondition() NetworkCondition {
+	_, receive := f.network.condition(f.id)
+	return receive
+}
+
+// CreateUDPConn creates the endpoint's packet connection.
+func (f *PipeNetworkFactory) CreateUDPConn(port int) (net.PacketConn, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.udpConn != nil {
+		return f.udpConn, nil
+	}
+
+	conn := &PipeNetworkConn{
+		network: f.network,
+		addr:    PipeAddr{ID: f.id, Port: port},
+		inbox:   make(chan pipePacket, pipeNetworkQueueSize),
+		closeCh: make(chan struct{}),
+	}
+	if err := f.network.attach(conn); err != nil {
+		return nil, err
+	}
+	f.udpConn = conn
+	return conn, nil
+}
+
+// CreateTCPListener creates a listener that never accepts connections,
+// as the network only carries packets.
+func (f *PipeNetworkFactory) CreateTCPListener(port int) (net.Listener, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.tcpListener == nil {
+		f.tcpListener = &pipeNetworkListener{
+			addr:    PipeAddr{ID: f.id, Port: port},
+			closeCh: make(chan struct{}),
+		}
+	}
+	return f.tcpListener, nil
+}
+
+// Verify PipeNetworkFactory implements Factory.
+var _ Factory = (*PipeNetworkFactory)(nil)
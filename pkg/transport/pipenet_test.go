@@ -0,0 +1,370 @@
+package transport
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestPipeNetwork_Routing verifies packets reach the addressed endpoint with
+// the sender's address, among three endpoints.
+func TestPipeNetwork_Routing(t *testing.T) {
+	network := NewPipeNetwork()
+	defer network.Close()
+
+	factories := []*PipeNetworkFactory{network.NewFactory(), network.NewFactory(), network.NewFactory()}
+	conns := make([]net.PacketConn, len(factories))
+	for i, f := range factories {
+		conn, err := f.CreateUDPConn(DefaultPort)
+		if err != nil {
+			t.Fatalf("CreateUDPConn(%d): %v", i, err)
+		}
+		conns[i] = conn
+	}
+
+	// 1 -> 0 and 2 -> 0
+	for _, from := range []int{1, 2} {
+		if _, err := conns[from].WriteTo([]byte{byte(from)}, factories[0].LocalAddr()); err != nil {
+			t.Fatalf("WriteTo: %v", err)
+		}
+
+		buf := make([]byte, 16)
+		n, addr, err := conns[0].ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("ReadFrom: %v", err)
+		}
+		if n != 1 || buf[0] != byte(from) {
+			t.Errorf("got %v, want [%d]", buf[:n], from)
+		}
+		if addr.(PipeAddr).ID != from {
+			t.Errorf("sender ID = %d, want %d", addr.(PipeAddr).ID, from)
+		}
+
+		// Reply to the sender; the third endpoint must not see it
+		if _, err := conns[0].WriteTo([]byte("ack"), addr); err != nil {
+			t.Fatalf("WriteTo: %v", err)
+		}
+		if n, _, err := conns[from].ReadFrom(buf); err != nil || string(buf[:n]) != "ack" {
+			t.Errorf("reply = %q, %v", buf[:n], err)
+		}
+	}
+
+	for i, conn := range conns {
+		if q := len(conn.(*PipeNetworkConn).inbox); q != 0 {
+			t.Errorf("endpoint %d has %d stray packets", i, q)
+		}
+	}
+}
+
+// TestPipeNetwork_UnknownDestination verifies packets to unknown endpoints
+// are dropped without error.
+func TestPipeNetwork_UnknownDestination(t *testing.T) {
+	network := NewPipeNetwork()
+	defer network.Close()
+
+	conn, err := network.NewFactory().CreateUDPConn(DefaultPort)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.WriteTo([]byte("lost"), PipeAddr{ID: 42, Port: DefaultPort}); err != nil {
+		t.Errorf("WriteTo: %v", err)
+	}
+}
+
+// TestPipeNetwork_Multicast verifies packets to a multicast address reach
+// the endpoints that joined it, but not the others, nor the sender unless
+// multicast loopback is enabled.
+func TestPipeNetwork_Multicast(t *testing.T) {
+	network := NewPipeNetwork()
+	defer network.Close()
+
+	conns := make([]*PipeNetworkConn, 4)
+	for i := range conns {
+		conn, err := network.NewFactory().CreateUDPConn(DefaultPort)
+		if err != nil {
+			t.Fatalf("CreateUDPConn(%d): %v", i, err)
+		}
+		conns[i] = conn.(*PipeNetworkConn)
+	}
+
+	group := GroupMulticastAddress(1, 0x0101)
+	for _, i := range []int{0, 1, 2} {
+		if err := conns[i].JoinGroup(group); err != nil {
+			t.Fatalf("JoinGroup(%d): %v", i, err)
+		}
+	}
+	if err := conns[2].LeaveGroup(group); err != nil {
+		t.Fatalf("LeaveGroup: %v", err)
+	}
+
+	if _, err := conns[0].WriteTo([]byte("all"), &net.UDPAddr{IP: group, Port: DefaultPort}); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+
+	for i, want := range []int{0, 1, 0, 0} {
+		if q := len(conns[i].inbox); q != want {
+			t.Errorf("endpoint %d has %d packets, want %d", i, q, want)
+		}
+	}
+
+	// With loopback, the sender gets its own packet too
+	network.SetMulticastLoopback(true)
+	if _, err := conns[0].WriteTo([]byte("loop"), &net.UDPAddr{IP: group, Port: DefaultPort}); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	for i, want := range []int{1, 2, 0, 0} {
+		if q := len(conns[i].inbox); q != want {
+			t.Errorf("with loopback, endpoint %d has %d packets, want %d", i, q, want)
+		}
+	}
+}
+
+// TestPipeNetwork_LinkCondition verifies a link's condition applies to
+// packets the endpoint sends and receives, but not to other links.
+func TestPipeNetwork_LinkCondition(t *testing.T) {
+	network := NewPipeNetwork()
+	defer network.Close()
+
+	factories := []*PipeNetworkFactory{network.NewFactory(), network.NewFactory(), network.NewFactory()}
+	conns := make([]*PipeNetworkConn, len(factories))
+	for i, f := range factories {
+		conn, err := f.CreateUDPConn(DefaultPort)
+		if err != nil {
+			t.Fatalf("CreateUDPConn(%d): %v", i, err)
+		}
+		conns[i] = conn.(*PipeNetworkConn)
+	}
+
+	// Endpoint 2's link loses everything
+	factories[2].SetCondition(NetworkCondition{DropRate: 1})
+	if got := factories[2].Condition(); got.DropRate != 1 {
+		t.Errorf("Condition().DropRate = %v, want 1", got.DropRate)
+	}
+	conns[0].WriteTo([]byte("a"), factories[1].LocalAddr())
+	conns[0].WriteTo([]byte("b"), factories[2].LocalAddr())
+	conns[2].WriteTo([]byte("c"), factories[1].LocalAddr())
+	for i, want := range []int{0, 1, 0} {
+		if q := len(conns[i].inbox); q != want {
+			t.Errorf("endpoint %d has %d packets, want %d", i, q, want)
+		}
+	}
+	<-conns[1].inbox
+
+	// Endpoint 1's link delays and duplicates; the sender does not block
+	factories[2].SetCondition(NetworkCondition{})
+	factories[1].SetCondition(NetworkCondition{
+		DelayMin:      50 * time.Millisecond,
+		DelayMax:      50 * time.Millisecond,
+		DuplicateRate: 1,
+	})
+	start := time.Now()
+	conns[0].WriteTo([]byte("d"), factories[1].LocalAddr())
+	if elapsed := time.Since(start); elapsed >= 50*time.Millisecond {
+		t.Errorf("WriteTo blocked for %v", elapsed)
+	}
+	buf := make([]byte, 16)
+	for i := 0; i < 2; i++ {
+		n, _, err := conns[1].ReadFrom(buf)
+		if err != nil || string(buf[:n]) != "d" {
+			t.Fatalf("copy %d = %q, %v", i, buf[:n], err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("packet arrived after %v, want at least 50ms", elapsed)
+	}
+
+	// Endpoint 2's restored link is perfect again
+	conns[0].WriteTo([]byte("e"), factories[2].LocalAddr())
+	if q := len(conns[2].inbox); q != 1 {
+		t.Errorf("endpoint 2 has %d packets after its link was restored, want 1", q)
+	}
+}
+
+// TestPipeNetwork_DirectionCondition verifies a link's send and receive
+// conditions apply to one direction each, and that a bandwidth cap queues
+// packets without blocking the sender.
+func TestPipeNetwork_DirectionCondition(t *testing.T) {
+	network := NewPipeNetwork()
+	defer network.Close()
+
+	factories := []*PipeNetworkFactory{network.NewFactory(), network.NewFactory()}
+	conns := make([]*PipeNetworkConn, len(factories))
+	for i, f := range factories {
+		conn, err := f.CreateUDPConn(DefaultPort)
+		if err != nil {
+			t.Fatalf("CreateUDPConn(%d): %v", i, err)
+		}
+		conns[i] = conn.(*PipeNetworkConn)
+	}
+
+	// Endpoint 1 cannot send packets larger than 4 bytes
+	factories[1].SetSendCondition(NetworkCondition{MTU: 4})
+	if got := factories[1].ReceiveCondition(); got != (NetworkCondition{}) {
+		t.Errorf("ReceiveCondition() = %+v, want none", got)
+	}
+	conns[1].WriteTo([]byte("large"), factories[0].LocalAddr())
+	conns[1].WriteTo([]byte("ok"), factories[0].LocalAddr())
+	conns[0].WriteTo([]byte("large"), factories[1].LocalAddr())
+	for i, want := range []int{1, 1} {
+		if q := len(conns[i].inbox); q != want {
+			t.Errorf("endpoint %d has %d packets, want %d", i, q, want)
+		}
+	}
+	<-conns[0].inbox
+	<-conns[1].inbox
+
+	// Endpoint 1 receives 1000 bytes per second: 50ms per packet
+	factories[1].SetSendCondition(NetworkCondition{})
+	factories[1].SetReceiveCondition(NetworkCondition{Bandwidth: 1000})
+	start := time.Now()
+	conns[0].WriteTo(make([]byte, 50), factories[1].LocalAddr())
+	conns[0].WriteTo(make([]byte, 50), factories[1].LocalAddr())
+	if elapsed := time.Since(start); elapsed >= 50*time.Millisecond {
+		t.Errorf("WriteTo blocked for %v", elapsed)
+	}
+	buf := make([]byte, 64)
+	for i, want := range []time.Duration{50 * time.Millisecond, 100 * time.Millisecond} {
+		if _, _, err := conns[1].ReadFrom(buf); err != nil {
+			t.Fatalf("ReadFrom(): %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < want {
+			t.Errorf("packet %d arrived after %v, want at least %v", i, elapsed, want)
+		}
+	}
+}
+
+// TestPipeNetwork_SetSeed verifies networks with the same seed lose the
+// same packets.
+func TestPipeNetwork_SetSeed(t *testing.T) {
+	var fates [2][]bool
+	for i := range fates {
+		network := NewPipeNetwork()
+		network.SetSeed(7)
+		sender, receiver := network.NewFactory(), network.NewFactory()
+		conn, _ := sender.CreateUDPConn(DefaultPort)
+		rconn, _ := receiver.CreateUDPConn(DefaultPort)
+		inbox := rconn.(*PipeNetworkConn).inbox
+		sender.SetCondition(NetworkCondition{DropRate: 0.5})
+
+		for j := 0; j < 64; j++ {
+			conn.WriteTo([]byte("x"), receiver.LocalAddr())
+			select {
+			case <-inbox:
+				fates[i] = append(fates[i], true)
+			default:
+				fates[i] = append(fates[i], false)
+			}
+		}
+		network.Close()
+	}
+	for j := range fates[0] {
+		if fates[0][j] != fates[1][j] {
+			t.Fatalf("packet %d: delivered = %v and %v with the same seed", j, fates[0][j], fates[1][j])
+		}
+	}
+}
+
+// TestPipeNetwork_Partition verifies partitioned endpoints cannot reach
+// each other, by unicast or multicast, while both reach the others.
+func TestPipeNetwork_Partition(t *testing.T) {
+	network := NewPipeNetwork()
+	defer network.Close()
+
+	factories := []*PipeNetworkFactory{network.NewFactory(), network.NewFactory(), network.NewFactory()}
+	conns := make([]*PipeNetworkConn, len(factories))
+	group := net.ParseIP("ff35:0040:fd00::0101")
+	for i, f := range factories {
+		conn, err := f.CreateUDPConn(DefaultPort)
+		if err != nil {
+			t.Fatalf("CreateUDPConn(%d): %v", i, err)
+		}
+		conns[i] = conn.(*PipeNetworkConn)
+		conns[i].JoinGroup(group)
+	}
+	drain := func() {
+		for _, conn := range conns {
+			for len(conn.inbox) > 0 {
+				<-conn.inbox
+			}
+		}
+	}
+
+	network.Partition(factories[0], factories[1])
+	conns[0].WriteTo([]byte("a"), factories[1].LocalAddr())
+	conns[1].WriteTo([]byte("b"), factories[0].LocalAddr())
+	conns[0].WriteTo([]byte("c"), factories[2].LocalAddr())
+	conns[2].WriteTo([]byte("d"), factories[1].LocalAddr())
+	for i, want := range []int{0, 1, 1} { // Only those from and to endpoint 2
+		if q := len(conns[i].inbox); q != want {
+			t.Errorf("unicast: endpoint %d has %d packets, want %d", i, q, want)
+		}
+	}
+	drain()
+
+	conns[0].WriteTo([]byte("e"), &net.UDPAddr{IP: group, Port: DefaultPort})
+	for i, want := range []int{0, 0, 1} {
+		if q := len(conns[i].inbox); q != want {
+			t.Errorf("multicast: endpoint %d has %d packets, want %d", i, q, want)
+		}
+	}
+	drain()
+
+	network.Heal(factories[1], factories[0])
+	conns[0].WriteTo([]byte("f"), factories[1].LocalAddr())
+	if q := len(conns[1].inbox); q != 1 {
+		t.Errorf("endpoint 1 has %d packets after Heal, want 1", q)
+	}
+	drain()
+
+	network.Partition(factories[0], factories[2])
+	network.Partition(factories[1], factories[2])
+	network.HealAll()
+	conns[2].WriteTo([]byte("g"), &net.UDPAddr{IP: group, Port: DefaultPort})
+	for i, want := range []int{1, 1, 0} {
+		if q := len(conns[i].inbox); q != want {
+			t.Errorf("after HealAll: endpoint %d has %d packets, want %d", i, q, want)
+		}
+	}
+}
+
+// TestPipeNetwork_Close verifies closing the network unblocks readers and
+// listeners.
+func TestPipeNetwork_Close(t *testing.T) {
+	network := NewPipeNetwork()
+	f := network.NewFactory()
+
+	conn, err := f.CreateUDPConn(DefaultPort)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := f.CreateTCPListener(DefaultPort)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	readErr := make(chan error, 1)
+	go func() {
+		_, _, err := conn.ReadFrom(make([]byte, 16))
+		readErr <- err
+	}()
+
+	network.Close()
+	ln.Close()
+
+	select {
+	case err := <-readErr:
+		if !errors.Is(err, net.ErrClosed) {
+			t.Errorf("ReadFrom error = %v, want net.ErrClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("ReadFrom did not unblock")
+	}
+	if _, err := ln.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Accept error = %v, want net.ErrClosed", err)
+	}
+
+	if _, err := network.NewFactory().CreateUDPConn(DefaultPort); !errors.Is(err, ErrClosed) {
+		t.Errorf("CreateUDPConn after Close = %v, want ErrClosed", err)
+	}
+}
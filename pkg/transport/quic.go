@@ -0,0 +1,439 @@
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/quic-go/quic-go"
+)
+
+// DefaultQUICNextProto is the ALPN protocol QUICFactory negotiates if its
+// TLS configuration names none.
+const DefaultQUICNextProto = "matter-experimental"
+
+// QUICFactoryConfig configures a QUICFactory.
+type QUICFactoryConfig struct {
+	// TLS configures the QUIC handshakes, as TLSFactoryConfig.TLS does the
+	// TLS ones. Required. QUIC always runs TLS 1.3. NextProtos defaults to
+	// DefaultQUICNextProto.
+	TLS *tls.Config
+
+	// QUIC configures the QUIC connections, e.g. their idle timeout.
+	// If nil, the quic-go defaults are used.
+	QUIC *quic.Config
+
+	// Base creates the UDP sockets QUIC runs over, e.g. a
+	// PipeNetworkFactory. If nil, OS sockets are used.
+	Base Factory
+
+	// HandshakeTimeout bounds the handshake of dialed connections.
+	// Default: DefaultTLSHandshakeTimeout
+	HandshakeTimeout time.Duration
+}
+
+// QUICFactory is an experimental Factory whose stream transport runs over
+// QUIC, for closed ecosystems that want the stream transport without TCP's
+// head-of-line blocking on lossy links, e.g. for camera signaling. It is
+// not part of the Matter specification, and both peers must use it.
+//
+// The UDP connection and the stream listener of a port, port 0 included,
+// share one socket:
+// Matter messages, whose first byte never has the two bits QUIC packets
+// set, pass through to the UDP transport as they are. Each QUIC connection
+// carries one bidirectional stream of length-prefixed messages, as a TCP
+// connection does.
+//
+// QUICFactory implements StreamDialer; connections are dialed from the
+// socket of the factory's listener, if it has one.
+type QUICFactory struct {
+	tls              *tls.Config
+	quic             *quic.Config
+	base             Factory
+	handshakeTimeout time.Duration
+
+	mu      sync.Mutex
+	sockets map[int]*quicSocket // By port
+}
+
+// NewQUICFactory creates a QUICFactory.
+func NewQUICFactory(config QUICFactoryConfig) (*QUICFactory, error) {
+	if config.TLS == nil {
+		return nil, ErrNoTLSConfig
+	}
+	tlsConfig := config.TLS.Clone()
+	if len(tlsConfig.NextProtos) == 0 {
+		tlsConfig.NextProtos = []string{DefaultQUICNextProto}
+	}
+	timeout := config.HandshakeTimeout
+	if timeout == 0 {
+		timeout = DefaultTLSHandshakeTimeout
+	}
+	return &QUICFactory{
+		tls:              tlsConfig,
+		quic:             config.QUIC,
+		base:             config.Base,
+		handshakeTimeout: timeout,
+		sockets:          make(map[int]*quicSocket),
+	}, nil
+}
+
+// CreateUDPConn implements Factory. The connection reads the packets of
+// the port's socket that are not QUIC packets.
+func (f *QUICFactory) CreateUDPConn(port int) (net.PacketConn, error) {
+	s, err := f.acquire(port)
+	if err != nil {
+		return nil, err
+	}
+	return &quicPacketConn{socket: s}, nil
+}
+
+// CreateTCPListener implements Factory. It accepts QUIC connections on
+// the port's socket, and returns each once its peer opened the stream.
+// The listener's Addr is the socket's UDP address.
+func (f *QUICFactory) CreateTCPListener(port int) (net.Listener, error) {
+	s, err := f.acquire(port)
+	if err != nil {
+		return nil, err
+	}
+	ln, err := s.transport.Listen(f.tls, f.quic)
+	if err != nil {
+		s.release()
+		return nil, err
+	}
+	l := &quicListener{
+		listener: ln,
+		socket:   s,
+		conns:    make(chan net.Conn),
+		closeCh:  make(chan struct{}),
+		timeout:  f.handshakeTimeout,
+	}
+	go l.acceptLoop()
+	return l, nil
+}
+
+// DialStream implements StreamDialer. It connects to addr, the address of
+// the peer's listener, and opens the stream.
+func (f *QUICFactory) DialStream(addr net.Addr) (net.Conn, error) {
+	s, err := f.dialSocket()
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := f.tls
+	if tlsConfig.ServerName == "" {
+		// Verify the certificate against the address dialed
+		tlsConfig = tlsConfig.Clone()
+		if host, _, err := net.SplitHostPort(addr.String()); err == nil {
+			tlsConfig.ServerName = host
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), f.handshakeTimeout)
+	defer cancel()
+	conn, err := s.transport.Dial(ctx, quicAddr(addr), tlsConfig, f.quic)
+	if err != nil {
+		s.release()
+		return nil, err
+	}
+	stream, err := conn.OpenStreamSync(ctx)
+	if err != nil {
+		conn.CloseWithError(0, "")
+		s.release()
+		return nil, err
+	}
+	return &quicStreamConn{Stream: stream, conn: conn, socket: s}, nil
+}
+
+// acquire returns the socket of a port, creating it if needed.
+func (f *QUICFactory) acquire(port int) (*quicSocket, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if s, ok := f.sockets[port]; ok {
+		s.refs++
+		return s, nil
+	}
+	s, err := f.newSocket(port)
+	if err != nil {
+		return nil, err
+	}
+	s.release = func() { f.releasePort(port, s) }
+	f.sockets[port] = s
+	return s, nil
+}
+
+// dialSocket returns a socket to dial from: that of a port, so the peer
+// sees the node's address, or a new one for the connection alone. A node
+// uses a single port, so any port's socket will do.
+func (f *QUICFactory) dialSocket() (*quicSocket, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, s := range f.sockets {
+		s.refs++
+		return s, nil
+	}
+	s, err := f.newSocket(0)
+	if err != nil {
+		return nil, err
+	}
+	s.release = func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		s.unref()
+	}
+	return s, nil
+}
+
+// releasePort drops a reference to the socket of a port.
+func (f *QUICFactory) releasePort(port int, s *quicSocket) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if s.unref() && f.sockets[port] == s {
+		delete(f.sockets, port)
+	}
+}
+
+// newSocket opens a UDP socket with one reference. Caller must hold f.mu.
+func (f *QUICFactory) newSocket(port int) (*quicSocket, error) {
+	var conn net.PacketConn
+	var err error
+	if f.base != nil {
+		conn, err = f.base.CreateUDPConn(port)
+	} else {
+		conn, err = net.ListenPacket("udp", fmt.Sprintf(":%d", port))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &quicSocket{
+		conn:      conn,
+		transport: &quic.Transport{Conn: conn},
+		refs:      1,
+	}, nil
+}
+
+// quicAddr returns the UDP address QUIC reaches a TCP address at.
+func quicAddr(addr net.Addr) net.Addr {
+	if tcpAddr, ok := addr.(*net.TCPAddr); ok {
+		return &net.UDPAddr{IP: tcpAddr.IP, Port: tcpAddr.Port, Zone: tcpAddr.Zone}
+	}
+	return addr
+}
+
+// quicSocket is a UDP socket shared by the UDP connection, the listener
+// and the dialed connections of a port. It is closed with its last user.
+type quicSocket struct {
+	conn      net.PacketConn
+	transport *quic.Transport
+	release   func()
+	refs      int // Protected by the factory's mutex
+}
+
+// unref drops a reference and closes the socket with the last one.
+// Caller must hold the factory's mutex.
+func (s *quicSocket) unref() bool {
+	s.refs--
+	if s.refs > 0 {
+		return false
+	}
+	// Closing the socket first ends the transport's read, which a read
+	// deadline does not do on every PacketConn
+	s.conn.Close()
+	s.transport.Close()
+	return true
+}
+
+// quicPacketConn is the UDP connection of a QUICFactory: the packets of
+// its socket that are not QUIC packets.
+type quicPacketConn struct {
+	socket *quicSocket
+
+	mu           sync.Mutex
+	readDeadline time.Time
+	cancelRead   context.CancelFunc
+	closed       bool
+}
+
+// ReadFrom implements net.PacketConn.
+func (c *quicPacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return 0, nil, net.ErrClosed
+	}
+	if !c.readDeadline.IsZero() {
+		var cancelDeadline context.CancelFunc
+		ctx, cancelDeadline = context.WithDeadline(ctx, c.readDeadline)
+		defer cancelDeadline()
+	}
+	c.cancelRead = cancel
+	c.mu.Unlock()
+
+	n, addr, err := c.socket.transport.ReadNonQUICPacket(ctx, b)
+	if err != nil {
+		c.mu.Lock()
+		closed := c.closed
+		c.mu.Unlock()
+		switch {
+		case closed:
+			err = net.ErrClosed
+		case ctx.Err() != nil:
+			err = os.ErrDeadlineExceeded
+		}
+	}
+	return n, addr, err
+}
+
+// WriteTo implements net.PacketConn.
+func (c *quicPacketConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	return c.socket.transport.WriteTo(b, addr)
+}
+
+// Close implements net.PacketConn.
+func (c *quicPacketConn) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	if c.cancelRead != nil {
+		c.cancelRead()
+	}
+	c.mu.Unlock()
+
+	c.socket.release()
+	return nil
+}
+
+// LocalAddr implements net.PacketConn.
+func (c *quicPacketConn) LocalAddr() net.Addr {
+	return c.socket.conn.LocalAddr()
+}
+
+// SetDeadline implements net.PacketConn.
+func (c *quicPacketConn) SetDeadline(t time.Time) error {
+	return c.SetReadDeadline(t)
+}
+
+// SetReadDeadline implements net.PacketConn. A deadline in the past
+// unblocks a pending read.
+func (c *quicPacketConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.readDeadline = t
+	if c.cancelRead != nil && !t.IsZero() && !t.After(time.Now()) {
+		c.cancelRead()
+	}
+	return nil
+}
+
+// SetWriteDeadline implements net.PacketConn. Writes do not block.
+func (c *quicPacketConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
+
+// quicListener is the stream listener of a QUICFactory.
+type quicListener struct {
+	listener *quic.Listener
+	socket   *quicSocket
+	conns    chan net.Conn
+	closeCh  chan struct{}
+	once     sync.Once
+	timeout  time.Duration
+}
+
+// acceptLoop accepts connections and hands them to Accept once their
+// stream is open.
+func (l *quicListener) acceptLoop() {
+	for {
+		conn, err := l.listener.Accept(context.Background())
+		if err != nil {
+			return
+		}
+		go l.acceptStream(conn)
+	}
+}
+
+// acceptStream waits for the peer to open the stream of a connection.
+func (l *quicListener) acceptStream(conn *quic.Conn) {
+	ctx, cancel := context.WithTimeout(conn.Context(), l.timeout)
+	defer cancel()
+	stream, err := conn.AcceptStream(ctx)
+	if err != nil {
+		conn.CloseWithError(0, "")
+		return
+	}
+	select {
+	case l.conns <- &quicStreamConn{Stream: stream, conn: conn}:
+	case <-l.closeCh:
+		conn.CloseWithError(0, "")
+	}
+}
+
+// Accept implements net.Listener.
+func (l *quicListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closeCh:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close implements net.Listener. Accepted connections stay open.
+func (l *quicListener) Close() error {
+	l.once.Do(func() {
+		close(l.closeCh)
+		l.listener.Close()
+		l.socket.release()
+	})
+	return nil
+}
+
+// Addr implements net.Listener. It is the *net.UDPAddr of the socket, as
+// QUIC runs over UDP; see Factory.CreateTCPListener.
+func (l *quicListener) Addr() net.Addr {
+	return l.listener.Addr()
+}
+
+// quicStreamConn is the stream of a QUIC connection as a net.Conn.
+type quicStreamConn struct {
+	*quic.Stream
+	conn   *quic.Conn
+	socket *quicSocket // Released on Close, for dialed connections
+	once   sync.Once
+}
+
+// Close closes the QUIC connection.
+func (c *quicStreamConn) Close() error {
+	var err error
+	c.once.Do(func() {
+		err = c.conn.CloseWithError(0, "")
+		if c.socket != nil {
+			c.socket.release()
+		}
+	})
+	if err != nil && !errors.Is(err, net.ErrClosed) {
+		return err
+	}
+	return nil
+}
+
+// LocalAddr implements net.Conn.
+func (c *quicStreamConn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+// RemoteAddr implements net.Conn.
+func (c *quicStreamConn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
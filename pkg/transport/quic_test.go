@@ -0,0 +1,125 @@
+package transport
+
+import (
+	"bytes"
+	"crypto/tls"
+	"net"
+	"testing"
+	"time"
+)
+
+// newQUICManager creates a started Manager whose transports share the
+// factory's QUIC socket, and the loopback address of that socket.
+func newQUICManager(t *testing.T, factory *QUICFactory, handler MessageHandler) (*Manager, *net.UDPAddr) {
+	t.Helper()
+	udpConn, err := factory.CreateUDPConn(0)
+	if err != nil {
+		t.Fatalf("CreateUDPConn() error = %v", err)
+	}
+	listener, err := factory.CreateTCPListener(0)
+	if err != nil {
+		t.Fatalf("CreateTCPListener() error = %v", err)
+	}
+	port := listener.Addr().(*net.UDPAddr).Port
+	if udpConn.LocalAddr().(*net.UDPAddr).Port != port {
+		t.Fatalf("UDP connection on port %v, want the listener's %d", udpConn.LocalAddr(), port)
+	}
+	m, err := NewManager(ManagerConfig{
+		UDPEnabled:     true,
+		TCPEnabled:     true,
+		UDPConn:        udpConn,
+		TCPListener:    listener,
+		TCPDial:        factory.DialStream,
+		MessageHandler: handler,
+	})
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(func() { m.Stop() })
+
+	return m, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port}
+}
+
+func TestQUICFactory(t *testing.T) {
+	if _, err := NewQUICFactory(QUICFactoryConfig{}); err != ErrNoTLSConfig {
+		t.Errorf("NewQUICFactory() without TLS error = %v, want %v", err, ErrNoTLSConfig)
+	}
+
+	config := testTLSConfig(t)
+	serverFactory, err := NewQUICFactory(QUICFactoryConfig{TLS: config})
+	if err != nil {
+		t.Fatalf("NewQUICFactory() error = %v", err)
+	}
+	clientFactory, err := NewQUICFactory(QUICFactoryConfig{TLS: config})
+	if err != nil {
+		t.Fatalf("NewQUICFactory() error = %v", err)
+	}
+
+	// newReceiver returns a handler that queues the messages it receives
+	newReceiver := func() (MessageHandler, chan ReceivedMessage) {
+		ch := make(chan ReceivedMessage, 1)
+		return func(msg *ReceivedMessage) {
+			ch <- ReceivedMessage{Data: bytes.Clone(msg.Data), PeerAddr: msg.PeerAddr}
+		}, ch
+	}
+	receive := func(ch chan ReceivedMessage, data []byte, transport TransportType) PeerAddress {
+		t.Helper()
+		select {
+		case msg := <-ch:
+			if !bytes.Equal(msg.Data, data) {
+				t.Errorf("received %q, want %q", msg.Data, data)
+			}
+			if msg.PeerAddr.TransportType != transport {
+				t.Errorf("%q arrived over %v, want %v", data, msg.PeerAddr.TransportType, transport)
+			}
+			return msg.PeerAddr
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%q not received", data)
+			return PeerAddress{}
+		}
+	}
+	serverHandler, serverReceived := newReceiver()
+	server, serverAddr := newQUICManager(t, serverFactory, serverHandler)
+	clientHandler, clientReceived := newReceiver()
+	client, clientAddr := newQUICManager(t, clientFactory, clientHandler)
+
+	// roundTrip sends data to the server, which replies to the address it
+	// arrived from
+	roundTrip := func(data []byte, addr PeerAddress) {
+		t.Helper()
+		if err := client.Send(data, addr); err != nil {
+			t.Fatalf("client Send() error = %v", err)
+		}
+		from := receive(serverReceived, data, addr.TransportType)
+		if port := from.Addr.(*net.UDPAddr).Port; port != clientAddr.Port {
+			t.Errorf("%q from port %d, want the client's %d", data, port, clientAddr.Port)
+		}
+		if err := server.Send(data, from); err != nil {
+			t.Fatalf("server Send() error = %v", err)
+		}
+		receive(clientReceived, data, addr.TransportType)
+	}
+
+	// Stream messages run over QUIC, dialed from the client's socket
+	tcpAddr := NewTCPPeerAddress(&net.TCPAddr{IP: serverAddr.IP, Port: serverAddr.Port})
+	roundTrip([]byte("quic message"), tcpAddr)
+
+	// Matter UDP messages share the socket
+	roundTrip([]byte{0x00, 0x01, 0x02}, NewUDPPeerAddress(serverAddr))
+
+	// A peer that does not trust the certificate cannot connect
+	untrusted, err := NewQUICFactory(QUICFactoryConfig{
+		TLS:              &tls.Config{},
+		HandshakeTimeout: time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewQUICFactory() error = %v", err)
+	}
+	if conn, err := untrusted.DialStream(tcpAddr.Addr); err == nil {
+		conn.Close()
+		t.Error("DialStream() with an untrusted certificate succeeded")
+	}
+}
@@ -0,0 +1,45 @@
+package transport
+
+import (
+	"net"
+	"syscall"
+)
+
+// SourceRoute is the source of the packets sent to a destination: the
+// address they are sent from and the interface they leave on. Zero values
+// leave the choice to the system.
+type SourceRoute struct {
+	Addr      net.IP
+	Interface *net.Interface
+}
+
+// SourceSelector picks the SourceRoute of the packets sent to dst, e.g.
+// the address of the interface that reaches dst's subnet on a hub with
+// several networks.
+type SourceSelector func(dst *net.UDPAddr) SourceRoute
+
+// socketControl returns the Control function that applies the socket
+// options to the sockets of a net.ListenConfig or net.Dialer, or nil if
+// none are set.
+func socketControl(iface *net.Interface, reusePort bool) func(network, address string, c syscall.RawConn) error {
+	if iface == nil && !reusePort {
+		return nil
+	}
+	return func(network, address string, c syscall.RawConn) error {
+		var sockErr error
+		err := c.Control(func(fd uintptr) {
+			if reusePort {
+				if sockErr = setReusePort(fd); sockErr != nil {
+					return
+				}
+			}
+			if iface != nil {
+				sockErr = bindToInterface(fd, network, iface)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		return sockErr
+	}
+}
@@ -0,0 +1,22 @@
+package transport
+
+import (
+	"net"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// setReusePort lets other sockets bind the socket's port.
+func setReusePort(fd uintptr) error {
+	return unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
+}
+
+// bindToInterface restricts the socket to an interface with IP_BOUND_IF,
+// or IPV6_BOUND_IF for IPv6 sockets.
+func bindToInterface(fd uintptr, network string, iface *net.Interface) error {
+	if strings.HasSuffix(network, "6") {
+		return unix.SetsockoptInt(int(fd), unix.IPPROTO_IPV6, unix.IPV6_BOUND_IF, iface.Index)
+	}
+	return unix.SetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_BOUND_IF, iface.Index)
+}
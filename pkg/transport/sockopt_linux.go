@@ -0,0 +1,18 @@
+package transport
+
+import (
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+// setReusePort lets other sockets bind the socket's port.
+func setReusePort(fd uintptr) error {
+	return unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
+}
+
+// bindToInterface restricts the socket to an interface with
+// SO_BINDTODEVICE.
+func bindToInterface(fd uintptr, network string, iface *net.Interface) error {
+	return unix.BindToDevice(int(fd), iface.Name)
+}
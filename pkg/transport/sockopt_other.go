@@ -0,0 +1,15 @@
+//go:build !linux && !darwin
+
+package transport
+
+import "net"
+
+// setReusePort is not supported on this platform.
+func setReusePort(fd uintptr) error {
+	return ErrSocketOptionUnsupported
+}
+
+// bindToInterface is not supported on this platform.
+func bindToInterface(fd uintptr, network string, iface *net.Interface) error {
+	return ErrSocketOptionUnsupported
+}
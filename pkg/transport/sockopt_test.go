@@ -0,0 +1,140 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestUDPReusePort(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("SO_REUSEPORT is supported on Linux and macOS")
+	}
+	handler := func(msg *ReceivedMessage) {}
+
+	first, err := NewUDP(UDPConfig{ListenAddr: "127.0.0.1:0", MessageHandler: handler, ReusePort: true})
+	if err != nil {
+		t.Fatalf("NewUDP() error = %v", err)
+	}
+	defer first.Stop()
+	addr := fmt.Sprintf("127.0.0.1:%d", first.LocalAddr().(*net.UDPAddr).Port)
+
+	second, err := NewUDP(UDPConfig{ListenAddr: addr, MessageHandler: handler, ReusePort: true})
+	if err != nil {
+		t.Fatalf("NewUDP() sharing the port error = %v", err)
+	}
+	second.Stop()
+
+	if third, err := NewUDP(UDPConfig{ListenAddr: addr, MessageHandler: handler}); err == nil {
+		third.Stop()
+		t.Error("NewUDP() without ReusePort bound a shared port")
+	}
+}
+
+func TestUDPInterface(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("interface binding is tested on Linux")
+	}
+	lo, err := loopbackInterface()
+	if err != nil {
+		t.Skip(err)
+	}
+
+	received := make(chan []byte, 1)
+	u, err := NewUDP(UDPConfig{
+		ListenAddr:     "127.0.0.1:0",
+		Interface:      lo,
+		MessageHandler: func(msg *ReceivedMessage) { received <- append([]byte(nil), msg.Data...) },
+	})
+	if errors.Is(err, syscall.EPERM) {
+		t.Skip("binding to an interface needs CAP_NET_RAW")
+	}
+	if err != nil {
+		t.Fatalf("NewUDP() error = %v", err)
+	}
+	defer u.Stop()
+	if u.iface != lo {
+		t.Error("Interface is not the multicast interface")
+	}
+	if err := u.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	sender, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket() error = %v", err)
+	}
+	defer sender.Close()
+	if _, err := sender.WriteTo([]byte("over lo"), u.LocalAddr()); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	select {
+	case <-received:
+	case <-time.After(time.Second):
+		t.Fatal("packet over the bound interface not received")
+	}
+}
+
+func TestUDPSelectSource(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("127.0.0.0/8 is routed to loopback on Linux")
+	}
+
+	receiver, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket() error = %v", err)
+	}
+	defer receiver.Close()
+
+	conn, err := net.ListenPacket("udp4", "0.0.0.0:0")
+	if err != nil {
+		t.Fatalf("ListenPacket() error = %v", err)
+	}
+	src := net.IPv4(127, 0, 0, 2)
+	var selected *net.UDPAddr
+	u, err := NewUDP(UDPConfig{
+		Conn:           conn,
+		MessageHandler: func(msg *ReceivedMessage) {},
+		SelectSource: func(dst *net.UDPAddr) SourceRoute {
+			selected = dst
+			return SourceRoute{Addr: src}
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewUDP() error = %v", err)
+	}
+	defer u.Stop()
+
+	if err := u.Send([]byte("hello"), receiver.LocalAddr()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	receiver.SetReadDeadline(time.Now().Add(time.Second))
+	_, from, err := receiver.ReadFrom(make([]byte, 64))
+	if err != nil {
+		t.Fatalf("ReadFrom() error = %v", err)
+	}
+	if got := from.(*net.UDPAddr).IP; !got.Equal(src) {
+		t.Errorf("packet sent from %v, want %v", got, src)
+	}
+	if selected == nil || selected.String() != receiver.LocalAddr().String() {
+		t.Errorf("SelectSource() called with %v, want %v", selected, receiver.LocalAddr())
+	}
+}
+
+// loopbackInterface returns the loopback interface.
+func loopbackInterface() (*net.Interface, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	for i := range ifaces {
+		if ifaces[i].Flags&net.FlagLoopback != 0 {
+			return &ifaces[i], nil
+		}
+	}
+	return nil, errors.New("no loopback interface")
+}
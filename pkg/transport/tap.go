@@ -0,0 +1,50 @@
+package transport
+
+import (
+	"net"
+	"time"
+)
+
+// Direction identifies whether a captured frame was sent or received.
+type Direction int
+
+const (
+	// DirectionInbound indicates a frame received from a peer.
+	DirectionInbound Direction = iota + 1
+	// DirectionOutbound indicates a frame sent to a peer.
+	DirectionOutbound
+)
+
+// String returns the string representation of the direction.
+func (d Direction) String() string {
+	switch d {
+	case DirectionInbound:
+		return "in"
+	case DirectionOutbound:
+		return "out"
+	default:
+		return "unknown"
+	}
+}
+
+// CapturedFrame is a frame observed by a Tap.
+type CapturedFrame struct {
+	// Time is when the frame was sent or received.
+	Time time.Time
+	// Direction is the direction of the frame.
+	Direction Direction
+	// Local is the local transport address, if known.
+	Local net.Addr
+	// Peer is the remote address and transport type.
+	Peer PeerAddress
+	// Data contains the raw frame bytes as seen on the wire. It must not
+	// be modified or retained after Capture returns.
+	Data []byte
+}
+
+// Tap observes frames sent and received by a Manager, e.g. to write a
+// packet capture. Capture is called synchronously on the send and receive
+// paths and must be safe for concurrent use.
+type Tap interface {
+	Capture(frame *CapturedFrame)
+}
@@ -1,6 +1,7 @@
 package transport
 
 import (
+	"context"
 	"io"
 	"net"
 	"sync"
@@ -14,6 +15,8 @@ import (
 // Messages are framed with a 4-byte length prefix per Spec Section 4.5.
 type TCP struct {
 	listener net.Listener
+	dial     func(addr net.Addr) (net.Conn, error)
+	dialer   net.Dialer
 	handler  MessageHandler
 	closeCh  chan struct{}
 	wg       sync.WaitGroup
@@ -46,6 +49,21 @@ type TCPConfig struct {
 	// Ignored if Listener is provided.
 	ListenAddr string
 
+	// Dial opens the connections to peers that messages are sent to.
+	// If nil, a plain TCP connection is dialed.
+	Dial func(addr net.Addr) (net.Conn, error)
+
+	// Interface binds the listener created for ListenAddr, and the plain
+	// TCP connections dialed, to a network interface. Supported on Linux
+	// and macOS.
+	// If nil, all interfaces are used.
+	Interface *net.Interface
+
+	// ReusePort sets SO_REUSEPORT on the listener created for ListenAddr,
+	// so that other sockets can bind the same port. Supported on Linux
+	// and macOS.
+	ReusePort bool
+
 	// MessageHandler is called for each received message.
 	// Required.
 	MessageHandler MessageHandler
@@ -63,6 +81,8 @@ func NewTCP(config TCPConfig) (*TCP, error) {
 
 	t := &TCP{
 		listener: config.Listener,
+		dial:     config.Dial,
+		dialer:   net.Dialer{Control: socketControl(config.Interface, false)},
 		handler:  config.MessageHandler,
 		closeCh:  make(chan struct{}),
 		conns:    make(map[string]*tcpConn),
@@ -80,7 +100,8 @@ func NewTCP(config TCPConfig) (*TCP, error) {
 			addr = ":0" // Use ephemeral port
 		}
 
-		listener, err := net.Listen("tcp", addr)
+		lc := net.ListenConfig{Control: socketControl(config.Interface, config.ReusePort)}
+		listener, err := lc.Listen(context.Background(), "tcp", addr)
 		if err != nil {
 			return nil, err
 		}
@@ -221,6 +242,15 @@ func (t *TCP) acceptLoop() {
 	}
 }
 
+// frameBufferPool holds receive buffers for TCP connections, each large
+// enough for the biggest frame a StreamReader accepts.
+var frameBufferPool = sync.Pool{
+	New: func() any {
+		buf := make([]byte, message.MaxStreamFrameSize)
+		return &buf
+	},
+}
+
 // handleConn handles a single TCP connection.
 func (t *TCP) handleConn(conn net.Conn) {
 	defer t.wg.Done()
@@ -244,6 +274,14 @@ func (t *TCP) handleConn(conn net.Conn) {
 		t.connsMu.Unlock()
 	}()
 
+	// Frames are read into a pooled buffer that is reused for every frame
+	// of the connection; handlers must not retain it.
+	bufp := frameBufferPool.Get().(*[]byte)
+	defer frameBufferPool.Put(bufp)
+	msg := &ReceivedMessage{
+		PeerAddr: NewTCPPeerAddress(conn.RemoteAddr()),
+	}
+
 	for {
 		select {
 		case <-t.closeCh:
@@ -251,7 +289,7 @@ func (t *TCP) handleConn(conn net.Conn) {
 		default:
 		}
 
-		data, err := tc.reader.Read()
+		data, err := tc.reader.ReadTo(*bufp)
 		if err != nil {
 			if err == io.EOF {
 				return
@@ -265,10 +303,7 @@ func (t *TCP) handleConn(conn net.Conn) {
 			}
 		}
 
-		msg := &ReceivedMessage{
-			Data:     data,
-			PeerAddr: NewTCPPeerAddress(conn.RemoteAddr()),
-		}
+		msg.Data = data
 
 		t.handler(msg)
 	}
@@ -287,7 +322,13 @@ func (t *TCP) getOrCreateConn(addr net.Addr) (*tcpConn, error) {
 	}
 
 	// Create new connection
-	conn, err := net.Dial("tcp", addrStr)
+	var conn net.Conn
+	var err error
+	if t.dial != nil {
+		conn, err = t.dial(addr)
+	} else {
+		conn, err = t.dialer.Dial("tcp", addrStr)
+	}
 	if err != nil {
 		return nil, err
 	}
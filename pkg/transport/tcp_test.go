@@ -92,7 +92,7 @@ func TestTCPWithPipe(t *testing.T) {
 
 	tcp, err := NewTCP(TCPConfig{
 		ListenAddr:     "127.0.0.1:0",
-		MessageHandler: func(msg *ReceivedMessage) { received <- msg },
+		MessageHandler: func(msg *ReceivedMessage) { received <- cloneMessage(msg) },
 	})
 	if err != nil {
 		t.Fatalf("NewTCP() error = %v", err)
@@ -140,7 +140,7 @@ func TestTCPRoundtrip(t *testing.T) {
 	// Create server
 	server, err := NewTCP(TCPConfig{
 		ListenAddr:     "127.0.0.1:0",
-		MessageHandler: func(msg *ReceivedMessage) { received1 <- msg },
+		MessageHandler: func(msg *ReceivedMessage) { received1 <- cloneMessage(msg) },
 	})
 	if err != nil {
 		t.Fatalf("NewTCP() server error = %v", err)
@@ -153,7 +153,7 @@ func TestTCPRoundtrip(t *testing.T) {
 	// Create client
 	client, err := NewTCP(TCPConfig{
 		ListenAddr:     "127.0.0.1:0",
-		MessageHandler: func(msg *ReceivedMessage) { received2 <- msg },
+		MessageHandler: func(msg *ReceivedMessage) { received2 <- cloneMessage(msg) },
 	})
 	if err != nil {
 		t.Fatalf("NewTCP() client error = %v", err)
@@ -0,0 +1,282 @@
+package transport
+
+import (
+	"net"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/backkem/matter/pkg/message"
+)
+
+// Thread link constants.
+const (
+	// IEEE802154FrameSize is the largest 802.15.4 frame. IPv6 packets
+	// larger than a frame are split into 6LoWPAN fragments.
+	IEEE802154FrameSize = 127
+
+	// ThreadMaxMessageSize is the largest Matter message sent over a
+	// Thread socket: the UDP payload of a packet of the IPv6 minimum MTU
+	// (1280), less the IPv6 (40) and UDP (8) headers. Thread carries it
+	// in about a dozen 6LoWPAN fragments, each lost with the frame.
+	ThreadMaxMessageSize = message.MaxUDPMessageSize - 40 - 8
+
+	// DefaultThreadReceiveQueue is the number of received datagrams a
+	// ThreadConn holds until they are read.
+	DefaultThreadReceiveQueue = 16
+)
+
+// ThreadSocket is a UDP socket of an OpenThread stack, as exposed by the
+// otUdp API on a Thread SoC, or by a host driving a radio co-processor
+// (RCP) over spinel. The methods follow otUdpBind, otUdpSend and
+// otIp6SubscribeMulticastAddress; a binding implements them on top of the
+// platform's calls.
+type ThreadSocket interface {
+	// Bind binds the socket to a local port and starts delivering the
+	// datagrams that arrive on it to receive, as the otUdpReceive handler
+	// given to otUdpOpen. receive may be called on the stack's thread and
+	// must not retain payload.
+	Bind(port uint16, receive func(payload []byte, from netip.AddrPort)) error
+
+	// SendTo sends a datagram.
+	SendTo(payload []byte, to netip.AddrPort) error
+
+	// SubscribeMulticast starts receiving datagrams sent to an IPv6
+	// multicast address.
+	SubscribeMulticast(group netip.Addr) error
+
+	// UnsubscribeMulticast stops receiving datagrams sent to an IPv6
+	// multicast address.
+	UnsubscribeMulticast(group netip.Addr) error
+
+	// Close closes the socket.
+	Close() error
+}
+
+// SizeLimitedConn is implemented by PacketConns whose link carries
+// smaller messages than the IPv6 minimum MTU allows. The UDP transport
+// sends no larger messages, and a node sizes its Interaction Model chunks
+// to fit.
+type SizeLimitedConn interface {
+	// MaxMessageSize returns the largest message the connection sends.
+	MaxMessageSize() int
+}
+
+// ThreadConfig configures a ThreadConn.
+type ThreadConfig struct {
+	// Port is the local UDP port to bind (default: 5540).
+	Port int
+
+	// MaxMessageSize is the largest message sent, e.g. lower on a
+	// platform short of 6LoWPAN reassembly buffers.
+	// Defaults to ThreadMaxMessageSize if zero.
+	MaxMessageSize int
+
+	// ReceiveQueue is the number of received datagrams held until read;
+	// datagrams arriving beyond it are dropped, as a radio would.
+	// Defaults to DefaultThreadReceiveQueue if zero.
+	ReceiveQueue int
+}
+
+// ThreadConn adapts a ThreadSocket to a net.PacketConn, so that a
+// transport Manager runs over it through ManagerConfig.UDPConn or a
+// ThreadFactory. It implements MulticastConn and SizeLimitedConn.
+type ThreadConn struct {
+	sock    ThreadSocket
+	local   *net.UDPAddr
+	maxSize int
+
+	queue   chan threadDatagram
+	closeCh chan struct{}
+
+	mu       sync.Mutex
+	deadline time.Time
+	closed   bool
+}
+
+type threadDatagram struct {
+	data []byte
+	from netip.AddrPort
+}
+
+// NewThreadConn binds a Thread socket and returns it as a PacketConn.
+func NewThreadConn(sock ThreadSocket, config ThreadConfig) (*ThreadConn, error) {
+	if config.Port == 0 {
+		config.Port = DefaultPort
+	}
+	if config.MaxMessageSize == 0 {
+		config.MaxMessageSize = ThreadMaxMessageSize
+	}
+	if config.ReceiveQueue == 0 {
+		config.ReceiveQueue = DefaultThreadReceiveQueue
+	}
+
+	c := &ThreadConn{
+		sock:    sock,
+		local:   &net.UDPAddr{IP: net.IPv6unspecified, Port: config.Port},
+		maxSize: config.MaxMessageSize,
+		queue:   make(chan threadDatagram, config.ReceiveQueue),
+		closeCh: make(chan struct{}),
+	}
+	if err := sock.Bind(uint16(config.Port), c.receive); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// receive queues a datagram delivered by the socket.
+func (c *ThreadConn) receive(payload []byte, from netip.AddrPort) {
+	d := threadDatagram{data: append([]byte(nil), payload...), from: from}
+	select {
+	case c.queue <- d:
+	case <-c.closeCh:
+	default:
+		// Queue full: drop
+	}
+}
+
+// ReadFrom implements net.PacketConn.
+func (c *ThreadConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	c.mu.Lock()
+	deadline := c.deadline
+	c.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case d := <-c.queue:
+		n := copy(p, d.data)
+		return n, net.UDPAddrFromAddrPort(d.from), nil
+	case <-c.closeCh:
+		return 0, nil, net.ErrClosed
+	case <-timeout:
+		return 0, nil, errTimeout{}
+	}
+}
+
+// WriteTo implements net.PacketConn.
+func (c *ThreadConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	udpAddr, ok := addr.(*net.UDPAddr)
+	if !ok {
+		return 0, ErrInvalidAddress
+	}
+	if len(p) > c.maxSize {
+		return 0, ErrMessageTooLarge
+	}
+	select {
+	case <-c.closeCh:
+		return 0, net.ErrClosed
+	default:
+	}
+	if err := c.sock.SendTo(p, udpAddr.AddrPort()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// JoinGroup implements MulticastConn.
+func (c *ThreadConn) JoinGroup(group net.IP) error {
+	addr, ok := netip.AddrFromSlice(group)
+	if !ok {
+		return ErrInvalidAddress
+	}
+	return c.sock.SubscribeMulticast(addr)
+}
+
+// LeaveGroup implements MulticastConn.
+func (c *ThreadConn) LeaveGroup(group net.IP) error {
+	addr, ok := netip.AddrFromSlice(group)
+	if !ok {
+		return ErrInvalidAddress
+	}
+	return c.sock.UnsubscribeMulticast(addr)
+}
+
+// MaxMessageSize implements SizeLimitedConn.
+func (c *ThreadConn) MaxMessageSize() int {
+	return c.maxSize
+}
+
+// Close implements net.PacketConn. It closes the socket.
+func (c *ThreadConn) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+
+	close(c.closeCh)
+	return c.sock.Close()
+}
+
+// LocalAddr implements net.PacketConn.
+func (c *ThreadConn) LocalAddr() net.Addr {
+	return c.local
+}
+
+// SetDeadline implements net.PacketConn; only reads have deadlines, as
+// sends do not block.
+func (c *ThreadConn) SetDeadline(t time.Time) error {
+	return c.SetReadDeadline(t)
+}
+
+// SetReadDeadline implements net.PacketConn. It applies to reads started
+// after it is set.
+func (c *ThreadConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.deadline = t
+	c.mu.Unlock()
+	return nil
+}
+
+// SetWriteDeadline implements net.PacketConn.
+func (c *ThreadConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
+
+// errTimeout is the net.Error of a read past its deadline.
+type errTimeout struct{}
+
+func (errTimeout) Error() string   { return "transport: i/o timeout" }
+func (errTimeout) Timeout() bool   { return true }
+func (errTimeout) Temporary() bool { return true }
+
+// ThreadFactory is a Factory over the UDP sockets of an OpenThread stack,
+// for a node running on a Thread SoC or behind an RCP. Thread offers no
+// TCP, so the node only uses UDP.
+type ThreadFactory struct {
+	// Open opens a socket of the Thread stack, like otUdpOpen. Required.
+	Open func() (ThreadSocket, error)
+
+	// Config configures the connections; its Port is set to the node's.
+	Config ThreadConfig
+}
+
+// CreateUDPConn implements Factory.
+func (f *ThreadFactory) CreateUDPConn(port int) (net.PacketConn, error) {
+	sock, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	config := f.Config
+	config.Port = port
+	conn, err := NewThreadConn(sock, config)
+	if err != nil {
+		sock.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// CreateTCPListener implements Factory. It returns nil, as Thread has no
+// TCP transport.
+func (f *ThreadFactory) CreateTCPListener(port int) (net.Listener, error) {
+	return nil, nil
+}
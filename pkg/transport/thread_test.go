@@ -0,0 +1,229 @@
+package transport
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeThreadMesh delivers datagrams between fakeThreadSockets, like a
+// Thread partition.
+type fakeThreadMesh struct {
+	mu      sync.Mutex
+	sockets map[netip.AddrPort]*fakeThreadSocket
+}
+
+func newFakeThreadMesh() *fakeThreadMesh {
+	return &fakeThreadMesh{sockets: make(map[netip.AddrPort]*fakeThreadSocket)}
+}
+
+// fakeThreadSocket is a ThreadSocket on a fakeThreadMesh.
+type fakeThreadSocket struct {
+	mesh    *fakeThreadMesh
+	addr    netip.Addr
+	local   netip.AddrPort
+	receive func([]byte, netip.AddrPort)
+	groups  map[netip.Addr]bool
+	closed  bool
+}
+
+func (m *fakeThreadMesh) socket(addr string) *fakeThreadSocket {
+	return &fakeThreadSocket{mesh: m, addr: netip.MustParseAddr(addr), groups: make(map[netip.Addr]bool)}
+}
+
+func (s *fakeThreadSocket) Bind(port uint16, receive func([]byte, netip.AddrPort)) error {
+	s.mesh.mu.Lock()
+	defer s.mesh.mu.Unlock()
+	s.local = netip.AddrPortFrom(s.addr, port)
+	s.receive = receive
+	s.mesh.sockets[s.local] = s
+	return nil
+}
+
+func (s *fakeThreadSocket) SendTo(payload []byte, to netip.AddrPort) error {
+	s.mesh.mu.Lock()
+	var peers []*fakeThreadSocket
+	for _, peer := range s.mesh.sockets {
+		if peer.local == to || (to.Addr().IsMulticast() && peer.groups[to.Addr()] && peer.local.Port() == to.Port()) {
+			peers = append(peers, peer)
+		}
+	}
+	s.mesh.mu.Unlock()
+
+	for _, peer := range peers {
+		peer.receive(payload, s.local)
+	}
+	return nil
+}
+
+func (s *fakeThreadSocket) SubscribeMulticast(group netip.Addr) error {
+	s.mesh.mu.Lock()
+	defer s.mesh.mu.Unlock()
+	s.groups[group] = true
+	return nil
+}
+
+func (s *fakeThreadSocket) UnsubscribeMulticast(group netip.Addr) error {
+	s.mesh.mu.Lock()
+	defer s.mesh.mu.Unlock()
+	delete(s.groups, group)
+	return nil
+}
+
+func (s *fakeThreadSocket) Close() error {
+	s.mesh.mu.Lock()
+	defer s.mesh.mu.Unlock()
+	delete(s.mesh.sockets, s.local)
+	s.closed = true
+	return nil
+}
+
+func TestThreadConn(t *testing.T) {
+	mesh := newFakeThreadMesh()
+	a, err := NewThreadConn(mesh.socket("fd00::1"), ThreadConfig{})
+	if err != nil {
+		t.Fatalf("NewThreadConn() error = %v", err)
+	}
+	defer a.Close()
+	b, err := NewThreadConn(mesh.socket("fd00::2"), ThreadConfig{Port: 5541})
+	if err != nil {
+		t.Fatalf("NewThreadConn() error = %v", err)
+	}
+	defer b.Close()
+
+	if a.MaxMessageSize() != ThreadMaxMessageSize {
+		t.Errorf("MaxMessageSize() = %d, want %d", a.MaxMessageSize(), ThreadMaxMessageSize)
+	}
+
+	// Unicast
+	to := &net.UDPAddr{IP: net.ParseIP("fd00::2"), Port: 5541}
+	if _, err := a.WriteTo([]byte("hello"), to); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	buf := make([]byte, ThreadMaxMessageSize)
+	n, from, err := b.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("ReadFrom() error = %v", err)
+	}
+	if !bytes.Equal(buf[:n], []byte("hello")) {
+		t.Errorf("ReadFrom() = %q, want hello", buf[:n])
+	}
+	if want := "[fd00::1]:5540"; from.String() != want {
+		t.Errorf("from = %v, want %s", from, want)
+	}
+
+	// Messages beyond the Thread limit are refused
+	if _, err := a.WriteTo(make([]byte, ThreadMaxMessageSize+1), to); !errors.Is(err, ErrMessageTooLarge) {
+		t.Errorf("WriteTo() oversized error = %v, want ErrMessageTooLarge", err)
+	}
+
+	// Multicast
+	group := net.ParseIP("ff35:40:fd00::100:fa")
+	if err := b.JoinGroup(group); err != nil {
+		t.Fatalf("JoinGroup() error = %v", err)
+	}
+	if _, err := a.WriteTo([]byte("group"), &net.UDPAddr{IP: group, Port: 5541}); err != nil {
+		t.Fatalf("WriteTo() group error = %v", err)
+	}
+	if n, _, err := b.ReadFrom(buf); err != nil || string(buf[:n]) != "group" {
+		t.Errorf("ReadFrom() group = %q, %v", buf[:n], err)
+	}
+	b.LeaveGroup(group)
+	a.WriteTo([]byte("group"), &net.UDPAddr{IP: group, Port: 5541})
+
+	// Reads time out at the deadline
+	b.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	_, _, err = b.ReadFrom(buf)
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("ReadFrom() after deadline error = %v, want timeout", err)
+	}
+}
+
+func TestThreadConn_CloseUnblocksRead(t *testing.T) {
+	mesh := newFakeThreadMesh()
+	sock := mesh.socket("fd00::1")
+	c, err := NewThreadConn(sock, ThreadConfig{})
+	if err != nil {
+		t.Fatalf("NewThreadConn() error = %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := c.ReadFrom(make([]byte, 16))
+		done <- err
+	}()
+	c.Close()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, net.ErrClosed) {
+			t.Errorf("ReadFrom() error = %v, want net.ErrClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("ReadFrom() not unblocked by Close")
+	}
+	if !sock.closed {
+		t.Error("Close() did not close the socket")
+	}
+}
+
+func TestThreadFactory_Manager(t *testing.T) {
+	mesh := newFakeThreadMesh()
+	received := make(chan *ReceivedMessage, 1)
+
+	newManager := func(addr string, handler MessageHandler) *Manager {
+		factory := &ThreadFactory{Open: func() (ThreadSocket, error) {
+			return mesh.socket(addr), nil
+		}}
+		conn, err := factory.CreateUDPConn(DefaultPort)
+		if err != nil {
+			t.Fatalf("CreateUDPConn() error = %v", err)
+		}
+		if l, err := factory.CreateTCPListener(DefaultPort); l != nil || err != nil {
+			t.Fatalf("CreateTCPListener() = %v, %v, want nil", l, err)
+		}
+		m, err := NewManager(ManagerConfig{
+			UDPConn:        conn,
+			UDPEnabled:     true,
+			MessageHandler: handler,
+		})
+		if err != nil {
+			t.Fatalf("NewManager() error = %v", err)
+		}
+		if err := m.Start(); err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+		return m
+	}
+
+	server := newManager("fd00::1", func(msg *ReceivedMessage) { received <- cloneMessage(msg) })
+	defer server.Stop()
+	client := newManager("fd00::2", func(msg *ReceivedMessage) {})
+	defer client.Stop()
+
+	if client.MaxMessageSize() != ThreadMaxMessageSize {
+		t.Errorf("MaxMessageSize() = %d, want %d", client.MaxMessageSize(), ThreadMaxMessageSize)
+	}
+
+	peer := NewUDPPeerAddress(&net.UDPAddr{IP: net.ParseIP("fd00::1"), Port: DefaultPort})
+	if err := client.Send([]byte("over thread"), peer); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	select {
+	case msg := <-received:
+		if string(msg.Data) != "over thread" {
+			t.Errorf("received %q, want %q", msg.Data, "over thread")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("message not received")
+	}
+
+	if err := client.Send(make([]byte, ThreadMaxMessageSize+1), peer); !errors.Is(err, ErrMessageTooLarge) {
+		t.Errorf("Send() oversized error = %v, want ErrMessageTooLarge", err)
+	}
+}
@@ -0,0 +1,124 @@
+package transport
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+// testTLSConfig returns a TLS configuration with a self-signed certificate
+// for 127.0.0.1 that it also trusts.
+func testTLSConfig(t *testing.T) *tls.Config {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "matter-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate() error = %v", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		RootCAs:      roots,
+	}
+}
+
+// newTLSManager creates a started Manager whose stream transport runs
+// over the factory's TLS, and the loopback address of its listener.
+func newTLSManager(t *testing.T, factory *TLSFactory, handler MessageHandler) (*Manager, PeerAddress) {
+	t.Helper()
+	udpConn, err := factory.CreateUDPConn(0)
+	if err != nil {
+		t.Fatalf("CreateUDPConn() error = %v", err)
+	}
+	listener, err := factory.CreateTCPListener(0)
+	if err != nil {
+		t.Fatalf("CreateTCPListener() error = %v", err)
+	}
+	m, err := NewManager(ManagerConfig{
+		UDPEnabled:     true,
+		TCPEnabled:     true,
+		UDPConn:        udpConn,
+		TCPListener:    listener,
+		TCPDial:        factory.DialStream,
+		MessageHandler: handler,
+	})
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(func() { m.Stop() })
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	return m, NewTCPPeerAddress(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port})
+}
+
+func TestTLSFactory(t *testing.T) {
+	if _, err := NewTLSFactory(TLSFactoryConfig{}); err != ErrNoTLSConfig {
+		t.Errorf("NewTLSFactory() without TLS error = %v, want %v", err, ErrNoTLSConfig)
+	}
+
+	factory, err := NewTLSFactory(TLSFactoryConfig{TLS: testTLSConfig(t)})
+	if err != nil {
+		t.Fatalf("NewTLSFactory() error = %v", err)
+	}
+
+	received := make(chan ReceivedMessage, 1)
+	var server *Manager
+	server, serverAddr := newTLSManager(t, factory, func(msg *ReceivedMessage) {
+		// Echo over the connection the message arrived on
+		server.Send(bytes.Clone(msg.Data), msg.PeerAddr)
+	})
+	client, _ := newTLSManager(t, factory, func(msg *ReceivedMessage) {
+		received <- ReceivedMessage{Data: bytes.Clone(msg.Data), PeerAddr: msg.PeerAddr}
+	})
+
+	data := []byte("tls message")
+	if err := client.Send(data, serverAddr); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	select {
+	case msg := <-received:
+		if !bytes.Equal(msg.Data, data) {
+			t.Errorf("received %q, want %q", msg.Data, data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("echo not received")
+	}
+
+	// A peer that does not trust the certificate cannot connect
+	untrusted, err := NewTLSFactory(TLSFactoryConfig{TLS: &tls.Config{}})
+	if err != nil {
+		t.Fatalf("NewTLSFactory() error = %v", err)
+	}
+	if conn, err := untrusted.DialStream(serverAddr.Addr); err == nil {
+		conn.Close()
+		t.Error("DialStream() with an untrusted certificate succeeded")
+	}
+}
@@ -1,12 +1,15 @@
 package transport
 
 import (
+	"context"
 	"net"
 	"sync"
 	"time"
 
 	"github.com/backkem/matter/pkg/message"
 	"github.com/pion/logging"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
 )
 
 // DefaultPort is the default Matter port (Spec Section 2.5.6.3).
@@ -17,10 +20,18 @@ const DefaultPort = 5540
 // the configured MessageHandler for each received message.
 type UDP struct {
 	conn    net.PacketConn
+	iface   *net.Interface
 	handler MessageHandler
-	closeCh chan struct{}
-	wg      sync.WaitGroup
-	log     logging.LeveledLogger
+
+	// selectSource picks the source of packets; pc4 or pc6 sends them
+	// with it, depending on the socket's address family.
+	selectSource SourceSelector
+	maxSize      int
+	pc4          *ipv4.PacketConn
+	pc6          *ipv6.PacketConn
+	closeCh      chan struct{}
+	wg           sync.WaitGroup
+	log          logging.LeveledLogger
 
 	mu      sync.RWMutex
 	started bool
@@ -41,11 +52,48 @@ type UDPConfig struct {
 	// Required.
 	MessageHandler MessageHandler
 
+	// MulticastInterface is the interface to join multicast groups on.
+	// If nil, Interface is used, or else the system chooses one.
+	MulticastInterface *net.Interface
+
+	// Interface binds the socket created for ListenAddr to a network
+	// interface, so that it only sends and receives over it. Supported on
+	// Linux and macOS.
+	// If nil, all interfaces are used.
+	Interface *net.Interface
+
+	// ReusePort sets SO_REUSEPORT on the socket created for ListenAddr,
+	// so that other sockets can bind the same port. Supported on Linux
+	// and macOS.
+	ReusePort bool
+
+	// SelectSource picks the source address and interface of each packet
+	// by destination. It applies to *net.UDPConn sockets.
+	// If nil, the system routes packets.
+	SelectSource SourceSelector
+
+	// MaxMessageSize is the largest message sent or received, for links
+	// with a smaller MTU. Conns implementing SizeLimitedConn lower it to
+	// their limit.
+	// Defaults to message.MaxUDPMessageSize if zero.
+	MaxMessageSize int
+
 	// LoggerFactory is the factory for creating loggers.
 	// If nil, logging is disabled.
 	LoggerFactory logging.LoggerFactory
 }
 
+// MulticastConn is implemented by PacketConns that manage their multicast
+// group membership themselves, such as the in-memory pipes. The UDP
+// transport joins groups on other connections through IPv6 socket options.
+type MulticastConn interface {
+	// JoinGroup starts receiving packets sent to the group address.
+	JoinGroup(group net.IP) error
+
+	// LeaveGroup stops receiving packets sent to the group address.
+	LeaveGroup(group net.IP) error
+}
+
 // NewUDP creates a new UDP transport with the given configuration.
 func NewUDP(config UDPConfig) (*UDP, error) {
 	if config.MessageHandler == nil {
@@ -53,9 +101,18 @@ func NewUDP(config UDPConfig) (*UDP, error) {
 	}
 
 	u := &UDP{
-		conn:    config.Conn,
-		handler: config.MessageHandler,
-		closeCh: make(chan struct{}),
+		conn:         config.Conn,
+		iface:        config.MulticastInterface,
+		handler:      config.MessageHandler,
+		selectSource: config.SelectSource,
+		maxSize:      config.MaxMessageSize,
+		closeCh:      make(chan struct{}),
+	}
+	if u.maxSize == 0 || u.maxSize > message.MaxUDPMessageSize {
+		u.maxSize = message.MaxUDPMessageSize
+	}
+	if u.iface == nil {
+		u.iface = config.Interface
 	}
 
 	// Create logger
@@ -70,12 +127,26 @@ func NewUDP(config UDPConfig) (*UDP, error) {
 			addr = ":0" // Use ephemeral port
 		}
 
-		conn, err := net.ListenPacket("udp", addr)
+		lc := net.ListenConfig{Control: socketControl(config.Interface, config.ReusePort)}
+		conn, err := lc.ListenPacket(context.Background(), "udp", addr)
 		if err != nil {
 			return nil, err
 		}
 		u.conn = conn
 	}
+	if limited, ok := u.conn.(SizeLimitedConn); ok && limited.MaxMessageSize() < u.maxSize {
+		u.maxSize = limited.MaxMessageSize()
+	}
+
+	if u.selectSource != nil {
+		if udpConn, ok := u.conn.(*net.UDPConn); ok {
+			if local, ok := udpConn.LocalAddr().(*net.UDPAddr); ok && local.IP.To4() != nil {
+				u.pc4 = ipv4.NewPacketConn(udpConn)
+			} else {
+				u.pc6 = ipv6.NewPacketConn(udpConn)
+			}
+		}
+	}
 
 	return u, nil
 }
@@ -142,7 +213,7 @@ func (u *UDP) Send(data []byte, addr net.Addr) error {
 		return ErrInvalidAddress
 	}
 
-	if len(data) > message.MaxUDPMessageSize {
+	if len(data) > u.maxSize {
 		return ErrMessageTooLarge
 	}
 
@@ -150,7 +221,7 @@ func (u *UDP) Send(data []byte, addr net.Addr) error {
 		u.log.Debugf("sending %d bytes to %v", len(data), addr)
 	}
 
-	_, err := u.conn.WriteTo(data, addr)
+	err := u.writeTo(data, addr)
 	if err != nil {
 		if u.log != nil {
 			u.log.Warnf("send failed: %v", err)
@@ -161,6 +232,54 @@ func (u *UDP) Send(data []byte, addr net.Addr) error {
 	return nil
 }
 
+// writeTo sends a packet, from the source SelectSource picks for addr.
+func (u *UDP) writeTo(data []byte, addr net.Addr) error {
+	dst, ok := addr.(*net.UDPAddr)
+	if !ok || (u.pc4 == nil && u.pc6 == nil) {
+		_, err := u.conn.WriteTo(data, addr)
+		return err
+	}
+
+	route := u.selectSource(dst)
+	var ifIndex int
+	if route.Interface != nil {
+		ifIndex = route.Interface.Index
+	}
+	var err error
+	switch {
+	case route.Addr == nil && ifIndex == 0:
+		_, err = u.conn.WriteTo(data, addr)
+	case u.pc4 != nil:
+		_, err = u.pc4.WriteTo(data, &ipv4.ControlMessage{Src: route.Addr, IfIndex: ifIndex}, dst)
+	default:
+		_, err = u.pc6.WriteTo(data, &ipv6.ControlMessage{Src: route.Addr, IfIndex: ifIndex}, dst)
+	}
+	return err
+}
+
+// JoinGroup starts receiving packets sent to an IPv6 multicast address.
+//
+// See Spec Section 2.5.6.2 (IPv6 Multicast Address).
+func (u *UDP) JoinGroup(group net.IP) error {
+	if mc, ok := u.conn.(MulticastConn); ok {
+		return mc.JoinGroup(group)
+	}
+	return ipv6.NewPacketConn(u.conn).JoinGroup(u.iface, &net.UDPAddr{IP: group})
+}
+
+// LeaveGroup stops receiving packets sent to an IPv6 multicast address.
+func (u *UDP) LeaveGroup(group net.IP) error {
+	if mc, ok := u.conn.(MulticastConn); ok {
+		return mc.LeaveGroup(group)
+	}
+	return ipv6.NewPacketConn(u.conn).LeaveGroup(u.iface, &net.UDPAddr{IP: group})
+}
+
+// MaxMessageSize returns the largest message the transport sends.
+func (u *UDP) MaxMessageSize() int {
+	return u.maxSize
+}
+
 // LocalAddr returns the local address the transport is listening on.
 func (u *UDP) LocalAddr() net.Addr {
 	return u.conn.LocalAddr()
@@ -170,7 +289,10 @@ func (u *UDP) LocalAddr() net.Addr {
 func (u *UDP) readLoop() {
 	defer u.wg.Done()
 
-	buf := make([]byte, message.MaxUDPMessageSize)
+	// The buffer and message are reused for every packet; handlers must
+	// not retain them.
+	buf := make([]byte, u.maxSize)
+	msg := &ReceivedMessage{}
 
 	for {
 		select {
@@ -197,19 +319,13 @@ func (u *UDP) readLoop() {
 			continue
 		}
 
-		// Make a copy of the data for the handler
-		data := make([]byte, n)
-		copy(data, buf[:n])
-
 		// Debug logging for received packets
 		if u.log != nil {
 			u.log.Debugf("received %d bytes from %v", n, addr)
 		}
 
-		msg := &ReceivedMessage{
-			Data:     data,
-			PeerAddr: NewUDPPeerAddress(addr),
-		}
+		msg.Data = buf[:n]
+		msg.PeerAddr = NewUDPPeerAddress(addr)
 
 		u.handler(msg)
 	}
@@ -93,7 +93,7 @@ func TestUDPSend(t *testing.T) {
 		received := make(chan *ReceivedMessage, 1)
 		server, err := NewUDP(UDPConfig{
 			ListenAddr:     "127.0.0.1:0",
-			MessageHandler: func(msg *ReceivedMessage) { received <- msg },
+			MessageHandler: func(msg *ReceivedMessage) { received <- cloneMessage(msg) },
 		})
 		if err != nil {
 			t.Fatalf("NewUDP() error = %v", err)
@@ -189,7 +189,7 @@ func TestUDPRoundtrip(t *testing.T) {
 	// Create two UDP transports that can communicate
 	udp1, err := NewUDP(UDPConfig{
 		ListenAddr:     "127.0.0.1:0",
-		MessageHandler: func(msg *ReceivedMessage) { received1 <- msg },
+		MessageHandler: func(msg *ReceivedMessage) { received1 <- cloneMessage(msg) },
 	})
 	if err != nil {
 		t.Fatalf("NewUDP() error = %v", err)
@@ -201,7 +201,7 @@ func TestUDPRoundtrip(t *testing.T) {
 
 	udp2, err := NewUDP(UDPConfig{
 		ListenAddr:     "127.0.0.1:0",
-		MessageHandler: func(msg *ReceivedMessage) { received2 <- msg },
+		MessageHandler: func(msg *ReceivedMessage) { received2 <- cloneMessage(msg) },
 	})
 	if err != nil {
 		t.Fatalf("NewUDP() error = %v", err)
@@ -268,3 +268,12 @@ func TestUDPLocalAddr(t *testing.T) {
 		t.Error("LocalAddr() port = 0, want ephemeral port")
 	}
 }
+
+// cloneMessage copies a received message so that it outlives the handler,
+// which the transports' buffer reuse does not allow for msg itself.
+func cloneMessage(msg *ReceivedMessage) *ReceivedMessage {
+	return &ReceivedMessage{
+		Data:     bytes.Clone(msg.Data),
+		PeerAddr: msg.PeerAddr,
+	}
+}
@@ -0,0 +1,72 @@
+package integration
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/backkem/matter/examples/allclusters"
+	"github.com/backkem/matter/pkg/clusters/descriptor"
+	"github.com/backkem/matter/pkg/clusters/generaldiagnostics"
+	"github.com/backkem/matter/pkg/clusters/smokecoalarm"
+	"github.com/backkem/matter/pkg/im"
+	"github.com/backkem/matter/pkg/matter"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// TestE2E_AllClustersTestEventTrigger verifies that the all-clusters
+// device lists its endpoints and simulates events on TestEventTrigger,
+// rejecting a wrong enable key.
+func TestE2E_AllClustersTestEventTrigger(t *testing.T) {
+	pair := NewTestPair(t, allclusters.Factory)
+	defer pair.Close()
+
+	ctx := pair.Context()
+
+	data, err := pair.Controller.ReadAttribute(ctx, pair.Session, pair.DeviceAddr,
+		uint16(matter.RootEndpointID), uint32(descriptor.ClusterID), uint32(descriptor.AttrPartsList))
+	if err != nil {
+		t.Fatalf("ReadAttribute(PartsList) failed: %v", err)
+	}
+	parts, err := decodeTLVUintList(data)
+	if err != nil {
+		t.Fatalf("decode PartsList: %v", err)
+	}
+	for _, ep := range []uint64{uint64(allclusters.LightEndpointID), uint64(allclusters.NetworkManagerEndpointID)} {
+		if !slices.Contains(parts, ep) {
+			t.Errorf("PartsList = %v, want endpoint %d", parts, ep)
+		}
+	}
+
+	trigger := func(key []byte, trigger uint64) (*im.InvokeResult, error) {
+		t.Helper()
+		var args bytes.Buffer
+		w := tlv.NewWriter(&args)
+		w.StartStructure(tlv.Anonymous())
+		w.PutBytes(tlv.ContextTag(0), key)
+		w.PutUint(tlv.ContextTag(1), trigger)
+		w.EndContainer()
+		return pair.Controller.SendCommand(ctx, pair.Session, pair.DeviceAddr, uint16(matter.RootEndpointID),
+			uint32(generaldiagnostics.ClusterID), uint32(generaldiagnostics.CmdTestEventTrigger), args.Bytes())
+	}
+
+	result, err := trigger(allclusters.DefaultEnableKey, allclusters.TriggerSmokeCritical)
+	if err != nil {
+		t.Fatalf("SendCommand(TestEventTrigger) failed: %v", err)
+	}
+	if result.HasStatus && result.Status != 0 {
+		t.Fatalf("TestEventTrigger status = %v", result.Status)
+	}
+	if got := pair.Device.SmokeCOAlarm.ExpressedState(); got != smokecoalarm.ExpressedStateSmokeAlarm {
+		t.Errorf("ExpressedState() = %v, want SmokeAlarm", got)
+	}
+
+	// A wrong key must not clear the alarm
+	result, err = trigger(make([]byte, generaldiagnostics.EnableKeySize), allclusters.TriggerSmokeCOClear)
+	if err == nil && (!result.HasStatus || result.Status == 0) {
+		t.Error("TestEventTrigger with a wrong key succeeded")
+	}
+	if got := pair.Device.SmokeCOAlarm.ExpressedState(); got != smokecoalarm.ExpressedStateSmokeAlarm {
+		t.Errorf("ExpressedState() after wrong key = %v, want SmokeAlarm", got)
+	}
+}
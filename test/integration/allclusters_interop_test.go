@@ -0,0 +1,215 @@
+//go:build interop
+
+// Package integration contains integration tests for Matter devices.
+//
+// This file (allclusters_interop_test.go) contains interop tests where our
+// controller commissions and operates the C++ SDK's chip-all-clusters-app.
+// These tests require chip-all-clusters-app in PATH, in the repo root, or
+// at $CHIP_ALL_CLUSTERS_APP.
+//
+// Build with: go test -tags=interop ./test/integration/...
+package integration
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/backkem/matter/examples/controller"
+	"github.com/backkem/matter/pkg/clusters/basic"
+	"github.com/backkem/matter/pkg/clusters/generalcommissioning"
+	"github.com/backkem/matter/pkg/clusters/onoff"
+	"github.com/backkem/matter/pkg/im"
+	imsg "github.com/backkem/matter/pkg/im/message"
+	"github.com/backkem/matter/pkg/session"
+	"github.com/backkem/matter/pkg/tlv"
+	"github.com/backkem/matter/pkg/transport"
+	"github.com/backkem/matter/test/integration/framework"
+)
+
+// allClustersPair is our controller paired over PASE with a running
+// chip-all-clusters-app.
+type allClustersPair struct {
+	app     *framework.AllClustersApp
+	ctrl    *controller.Controller
+	session *session.SecureContext
+	addr    transport.PeerAddress
+}
+
+// newAllClustersPair starts chip-all-clusters-app and pairs our controller
+// with it, skipping the test if the app is not available.
+func newAllClustersPair(t *testing.T, ctx context.Context) *allClustersPair {
+	t.Helper()
+
+	config := framework.AllClustersAppConfig{
+		StorageDir: t.TempDir(),
+		Passcode:   testPinCode,
+	}
+	if logFile := os.Getenv("INTEROP_LOG_FILE"); logFile != "" {
+		config.LogFile = logFile
+	}
+	app, err := framework.NewAllClustersApp(config)
+	if err != nil {
+		t.Skipf("Skipping: %v", err)
+	}
+	if err := app.Start(); err != nil {
+		t.Fatalf("Failed to start chip-all-clusters-app: %v", err)
+	}
+	t.Cleanup(func() { app.Stop() })
+
+	opts := controller.DefaultOptions()
+	ctrl, err := controller.New(opts)
+	if err != nil {
+		t.Fatalf("Failed to create controller: %v", err)
+	}
+	if err := ctrl.Start(ctx); err != nil {
+		t.Fatalf("Failed to start controller: %v", err)
+	}
+	t.Cleanup(func() { ctrl.Stop() })
+
+	addr := app.PeerAddress()
+	sess, err := ctrl.CommissionDevice(ctx, addr, testPinCode)
+	if err != nil {
+		t.Fatalf("PASE with chip-all-clusters-app failed: %v", err)
+	}
+
+	return &allClustersPair{app: app, ctrl: ctrl, session: sess, addr: addr}
+}
+
+// TestInterop_AllClustersPASE establishes PASE with the SDK device and
+// drives the first commissioning step, arming the fail-safe.
+func TestInterop_AllClustersPASE(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	pair := newAllClustersPair(t, ctx)
+
+	if pair.session.SessionType() != session.SessionTypePASE {
+		t.Fatalf("session type = %v, want PASE", pair.session.SessionType())
+	}
+
+	req, err := generalcommissioning.EncodeArmFailSafeRequest(&generalcommissioning.ArmFailSafeRequest{
+		ExpiryLengthSeconds: 60,
+		Breadcrumb:          1,
+	})
+	if err != nil {
+		t.Fatalf("EncodeArmFailSafeRequest failed: %v", err)
+	}
+	result, err := pair.ctrl.SendCommand(ctx, pair.session, pair.addr, 0,
+		uint32(generalcommissioning.ClusterID), uint32(generalcommissioning.CmdArmFailSafe), req)
+	if err != nil {
+		t.Fatalf("ArmFailSafe failed: %v", err)
+	}
+	if result.HasStatus {
+		t.Fatalf("ArmFailSafe status = %v, want a response", result.Status)
+	}
+	resp, err := generalcommissioning.DecodeArmFailSafeResponse(result.ResponseData)
+	if err != nil {
+		t.Fatalf("DecodeArmFailSafeResponse failed: %v", err)
+	}
+	if resp.ErrorCode != generalcommissioning.CommissioningOK {
+		t.Errorf("ArmFailSafe error code = %v (%s), want OK", resp.ErrorCode, resp.DebugText)
+	}
+
+	// The breadcrumb is kept while the fail-safe is armed
+	data, err := pair.ctrl.ReadAttribute(ctx, pair.session, pair.addr, 0,
+		uint32(generalcommissioning.ClusterID), uint32(generalcommissioning.AttrBreadcrumb))
+	if err != nil {
+		t.Fatalf("Read Breadcrumb failed: %v", err)
+	}
+	if breadcrumb, err := decodeTLVUint(data); err != nil || breadcrumb != 1 {
+		t.Errorf("Breadcrumb = %d, %v, want 1", breadcrumb, err)
+	}
+}
+
+// TestInterop_AllClustersRead reads Basic Information attributes and a
+// wildcard path whose report spans several chunks.
+func TestInterop_AllClustersRead(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	pair := newAllClustersPair(t, ctx)
+
+	data, err := pair.ctrl.ReadAttribute(ctx, pair.session, pair.addr, 0,
+		uint32(basic.ClusterID), uint32(basic.AttrVendorID))
+	if err != nil {
+		t.Fatalf("Read VendorID failed: %v", err)
+	}
+	if vendorID, err := decodeTLVUint(data); err != nil || vendorID != 0xFFF1 {
+		t.Errorf("VendorID = 0x%X, %v, want 0xFFF1", vendorID, err)
+	}
+
+	data, err = pair.ctrl.ReadAttribute(ctx, pair.session, pair.addr, 0,
+		uint32(basic.ClusterID), uint32(basic.AttrDataModelRevision))
+	if err != nil {
+		t.Fatalf("Read DataModelRevision failed: %v", err)
+	}
+	if _, err := decodeTLVUint(data); err != nil {
+		t.Errorf("DataModelRevision: %v", err)
+	}
+
+	// Every attribute of endpoint 1 does not fit in one message
+	endpoint := imsg.EndpointID(1)
+	client := im.NewClient(im.ClientConfig{ExchangeManager: pair.ctrl.Node().ExchangeManager()})
+	report, err := client.Read(ctx, pair.session, pair.addr, &imsg.ReadRequestMessage{
+		AttributeRequests: []imsg.AttributePathIB{{Endpoint: &endpoint}},
+		FabricFiltered:    true,
+	})
+	if err != nil {
+		t.Fatalf("Wildcard read failed: %v", err)
+	}
+	clusters := make(map[imsg.ClusterID]bool)
+	for _, r := range report.AttributeReports {
+		if r.AttributeData != nil && r.AttributeData.Path.Cluster != nil {
+			clusters[*r.AttributeData.Path.Cluster] = true
+		}
+	}
+	if !clusters[imsg.ClusterID(onoff.ClusterID)] {
+		t.Error("wildcard read of endpoint 1 has no OnOff attributes")
+	}
+	t.Logf("Wildcard read: %d attribute reports from %d clusters", len(report.AttributeReports), len(clusters))
+}
+
+// TestInterop_AllClustersOnOff toggles the SDK device's light and reads the
+// state back.
+func TestInterop_AllClustersOnOff(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	pair := newAllClustersPair(t, ctx)
+
+	readOnOff := func() bool {
+		t.Helper()
+		data, err := pair.ctrl.ReadAttribute(ctx, pair.session, pair.addr, testEndpointID,
+			uint32(onoff.ClusterID), uint32(onoff.AttrOnOff))
+		if err != nil {
+			t.Fatalf("Read OnOff failed: %v", err)
+		}
+		on, err := decodeTLVBool(data)
+		if err != nil {
+			t.Fatalf("Decode OnOff failed: %v", err)
+		}
+		return on
+	}
+
+	before := readOnOff()
+	result, err := pair.ctrl.SendCommand(ctx, pair.session, pair.addr, testEndpointID,
+		uint32(onoff.ClusterID), uint32(onoff.CmdToggle), nil)
+	if err != nil {
+		t.Fatalf("Toggle failed: %v", err)
+	}
+	if result.HasStatus && !result.Status.IsSuccess() {
+		t.Fatalf("Toggle status = %v", result.Status)
+	}
+	if after := readOnOff(); after == before {
+		t.Errorf("OnOff = %v after Toggle, want %v", after, !before)
+	}
+}
+
+// decodeTLVUint decodes a TLV-encoded unsigned integer value.
+func decodeTLVUint(data []byte) (uint64, error) {
+	r := tlv.NewReader(bytes.NewReader(data))
+	if err := r.Next(); err != nil {
+		return 0, err
+	}
+	return r.Uint()
+}
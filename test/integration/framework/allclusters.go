@@ -0,0 +1,218 @@
+// Package framework provides test infrastructure for Matter integration tests.
+package framework
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/backkem/matter/pkg/transport"
+)
+
+// AllClustersAppEnv names the environment variable that may point to the
+// chip-all-clusters-app binary.
+const AllClustersAppEnv = "CHIP_ALL_CLUSTERS_APP"
+
+// FindBinary looks up a CHIP SDK binary: at the path in env if set, else in
+// PATH, else in the repo root (../../name from test/integration).
+func FindBinary(name, env string) (string, error) {
+	if env != "" {
+		if path := os.Getenv(env); path != "" {
+			if _, err := os.Stat(path); err != nil {
+				return "", fmt.Errorf("%s=%s: %w", env, path, err)
+			}
+			return path, nil
+		}
+	}
+	if path, err := exec.LookPath(name); err == nil {
+		return path, nil
+	}
+	path := filepath.Join("..", "..", name)
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("%s not found in PATH or repo root", name)
+	}
+	return path, nil
+}
+
+// AllClustersApp manages a chip-all-clusters-app process, the C++ SDK's
+// reference device implementing every cluster, for our controller to
+// commission.
+type AllClustersApp struct {
+	binary  string
+	port    int
+	args    []string
+	logFile string
+
+	cmd           *exec.Cmd
+	started       bool
+	mu            sync.Mutex
+	logFileHandle *os.File
+	done          chan struct{}
+	ctx           context.Context
+	cancelFunc    context.CancelFunc
+}
+
+// AllClustersAppConfig holds configuration for chip-all-clusters-app.
+type AllClustersAppConfig struct {
+	// Binary is the path to chip-all-clusters-app
+	// (default: FindBinary("chip-all-clusters-app", AllClustersAppEnv))
+	Binary string
+
+	// Port is the secured UDP port to listen on (default: 5560)
+	Port int
+
+	// Discriminator is the 12-bit discriminator value (default: 3840)
+	Discriminator uint16
+
+	// Passcode is the 27-bit setup PIN code (default: 20202021)
+	Passcode uint32
+
+	// StorageDir is the directory for the app's key-value store (required)
+	StorageDir string
+
+	// LogFile is an optional path to write logs to (in addition to test output)
+	LogFile string
+
+	// ExtraArgs are additional command-line arguments
+	ExtraArgs []string
+}
+
+// NewAllClustersApp creates a new chip-all-clusters-app process manager.
+func NewAllClustersApp(config AllClustersAppConfig) (*AllClustersApp, error) {
+	if config.Binary == "" {
+		binary, err := FindBinary("chip-all-clusters-app", AllClustersAppEnv)
+		if err != nil {
+			return nil, err
+		}
+		config.Binary = binary
+	}
+	if config.Port == 0 {
+		config.Port = 5560
+	}
+	if config.Discriminator == 0 {
+		config.Discriminator = 3840
+	}
+	if config.Passcode == 0 {
+		config.Passcode = 20202021
+	}
+	if config.StorageDir == "" {
+		return nil, fmt.Errorf("chip-all-clusters-app: storage directory required")
+	}
+
+	args := []string{
+		"--secured-device-port", strconv.Itoa(config.Port),
+		// Keep clear of a commissioner on the default 5550
+		"--unsecured-commissioner-port", strconv.Itoa(config.Port + 1),
+		"--discriminator", strconv.FormatUint(uint64(config.Discriminator), 10),
+		"--passcode", strconv.FormatUint(uint64(config.Passcode), 10),
+		"--KVS", filepath.Join(config.StorageDir, "chip_kvs"),
+	}
+	args = append(args, config.ExtraArgs...)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &AllClustersApp{
+		binary:     config.Binary,
+		port:       config.Port,
+		args:       args,
+		logFile:    config.LogFile,
+		done:       make(chan struct{}),
+		ctx:        ctx,
+		cancelFunc: cancel,
+	}, nil
+}
+
+// Start starts chip-all-clusters-app and gives it time to open its
+// commissioning window.
+func (a *AllClustersApp) Start() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.started {
+		return fmt.Errorf("chip-all-clusters-app already started")
+	}
+
+	a.cmd = exec.CommandContext(a.ctx, a.binary, a.args...)
+
+	// Open log file if specified
+	if a.logFile != "" {
+		logFile, err := os.OpenFile(a.logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open log file: %w", err)
+		}
+		a.logFileHandle = logFile
+	}
+
+	a.cmd.Stdout = newLogWriter("[chip-all-clusters-app stdout]", a.logFileHandle)
+	a.cmd.Stderr = newLogWriter("[chip-all-clusters-app stderr]", a.logFileHandle)
+
+	if err := a.cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start chip-all-clusters-app: %w", err)
+	}
+
+	a.started = true
+
+	go func() {
+		defer close(a.done)
+		a.cmd.Wait()
+	}()
+
+	// Give the app time to start up and open its commissioning window
+	select {
+	case <-a.done:
+		return fmt.Errorf("chip-all-clusters-app exited during startup")
+	case <-time.After(3 * time.Second):
+	}
+
+	return nil
+}
+
+// Stop gracefully stops the app.
+func (a *AllClustersApp) Stop() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.started {
+		return nil
+	}
+
+	// SIGTERM first, so the app can persist its state
+	if a.cmd != nil && a.cmd.Process != nil {
+		if err := a.cmd.Process.Signal(syscall.SIGTERM); err != nil {
+			a.cmd.Process.Kill()
+		}
+	}
+
+	select {
+	case <-a.done:
+	case <-time.After(5 * time.Second):
+		a.cancelFunc()
+		<-a.done
+	}
+	a.cancelFunc()
+
+	if a.logFileHandle != nil {
+		a.logFileHandle.Close()
+		a.logFileHandle = nil
+	}
+
+	a.started = false
+	return nil
+}
+
+// Port returns the UDP port the app is listening on.
+func (a *AllClustersApp) Port() int {
+	return a.port
+}
+
+// PeerAddress returns the app's loopback address.
+func (a *AllClustersApp) PeerAddress() transport.PeerAddress {
+	return transport.NewUDPPeerAddress(&net.UDPAddr{IP: net.IPv6loopback, Port: a.port})
+}
@@ -70,7 +70,7 @@ func TestE2E_DeviceAndControllerStartup(t *testing.T) {
 	if err := device.Node.Start(ctx); err != nil {
 		t.Fatalf("Failed to start device: %v", err)
 	}
-	defer device.Node.Stop()
+	defer device.Node.Stop(context.Background())
 
 	if err := ctrl.Start(ctx); err != nil {
 		t.Fatalf("Failed to start controller: %v", err)
@@ -10,7 +10,6 @@ package integration
 
 import (
 	"os"
-	"os/exec"
 	"path/filepath"
 	"testing"
 	"time"
@@ -31,6 +30,8 @@ const (
 
 // TestInterop_LightCommissioning tests commissioning a light device with chip-tool.
 func TestInterop_LightCommissioning(t *testing.T) {
+	requireChipTool(t)
+
 	// Create temporary storage directories
 	deviceStorage := t.TempDir()
 	chipToolStorage := t.TempDir()
@@ -93,6 +94,8 @@ func TestInterop_LightCommissioning(t *testing.T) {
 
 // TestInterop_LightOnOffControl tests controlling the light with chip-tool OnOff commands.
 func TestInterop_LightOnOffControl(t *testing.T) {
+	requireChipTool(t)
+
 	// Create temporary storage directories
 	deviceStorage := t.TempDir()
 	chipToolStorage := t.TempDir()
@@ -168,6 +171,8 @@ func TestInterop_LightOnOffControl(t *testing.T) {
 
 // TestInterop_LightReadAttributes tests reading attributes from the light with chip-tool.
 func TestInterop_LightReadAttributes(t *testing.T) {
+	requireChipTool(t)
+
 	// Create temporary storage directories
 	deviceStorage := t.TempDir()
 	chipToolStorage := t.TempDir()
@@ -238,22 +243,13 @@ func TestInterop_LightReadAttributes(t *testing.T) {
 	t.Log("Successfully read attributes from light with chip-tool")
 }
 
-// TestMain sets up and tears down for interop tests.
-func TestMain(m *testing.M) {
-	// Check if chip-tool is available (in PATH or repo root)
-	chipToolPath, err := exec.LookPath("chip-tool")
+// requireChipTool skips the test unless chip-tool is in PATH or the repo
+// root.
+func requireChipTool(t *testing.T) {
+	t.Helper()
+	path, err := framework.FindBinary("chip-tool", "")
 	if err != nil {
-		// Try chip-tool in repo root (../../chip-tool from test/integration)
-		repoRoot := filepath.Join("..", "..")
-		if _, err := os.Stat(filepath.Join(repoRoot, "chip-tool")); err != nil {
-			println("chip-tool not found in PATH or repo root, skipping interop tests")
-			println("Install chip-tool or add it to PATH to run these tests")
-			os.Exit(0)
-		}
-		chipToolPath = filepath.Join(repoRoot, "chip-tool")
+		t.Skipf("Skipping: %v", err)
 	}
-	println("Using chip-tool:", chipToolPath)
-
-	// Run tests
-	os.Exit(m.Run())
+	t.Logf("Using chip-tool: %s", path)
 }
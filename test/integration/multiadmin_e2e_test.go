@@ -0,0 +1,349 @@
+// Package integration contains integration tests for Matter devices.
+//
+// This file (multiadmin_e2e_test.go) contains end-to-end tests where a
+// device is administered by two controllers (multi-admin) over a shared
+// virtual pipe network.
+package integration
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/backkem/matter/examples/controller"
+	"github.com/backkem/matter/examples/light"
+	"github.com/backkem/matter/pkg/clusters/admincommissioning"
+	"github.com/backkem/matter/pkg/clusters/onoff"
+	"github.com/backkem/matter/pkg/clusters/operationalcredentials"
+	"github.com/backkem/matter/pkg/commissioning"
+	"github.com/backkem/matter/pkg/commissioning/payload"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/fabric"
+	"github.com/backkem/matter/pkg/matter"
+	"github.com/backkem/matter/pkg/session"
+	"github.com/backkem/matter/pkg/tlv"
+	"github.com/backkem/matter/pkg/transport"
+)
+
+// TestE2E_MultiAdmin commissions a device onto the fabric of one
+// controller, has it open an enhanced commissioning window through the
+// Administrator Commissioning cluster, and hands the onboarding payload to
+// a second controller that commissions the device onto its own fabric.
+// Both controllers operate the device over CASE, and removing one fabric
+// leaves the other working.
+func TestE2E_MultiAdmin(t *testing.T) {
+	network := transport.NewPipeNetwork()
+	defer network.Close()
+
+	deviceTransport := network.NewFactory()
+	deviceAddr := transport.NewUDPPeerAddress(deviceTransport.LocalAddr())
+
+	device, err := light.NewDeviceWithConfig(matter.NodeConfig{
+		VendorID:         fabric.VendorID(0xFFF1),
+		ProductID:        0x8001,
+		DeviceName:       "Test Light",
+		Discriminator:    3840,
+		Passcode:         20202021,
+		Port:             5540,
+		Storage:          matter.NewMemoryStorage(),
+		TransportFactory: deviceTransport,
+		Attestation:      testAttestationCredentials(t),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create device: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	if err := device.Node.Start(ctx); err != nil {
+		t.Fatalf("Failed to start device: %v", err)
+	}
+	defer device.Node.Stop(context.Background())
+
+	// First administrator commissions the device with its onboarding passcode
+	admin1 := newFabricAdmin(t, ctx, network, 0)
+	defer admin1.ctrl.Stop()
+
+	sess1 := admin1.commission(t, ctx, deviceAddr, &payload.SetupPayload{
+		Discriminator: payload.NewLongDiscriminator(3840),
+		Passcode:      20202021,
+	})
+	if device.Node.IsCommissioningWindowOpen() {
+		t.Fatal("commissioning window should close once commissioned")
+	}
+
+	// ...and opens a window for a second administrator
+	window, err := admin1.ctrl.OpenCommissioningWindow(ctx, sess1, deviceAddr, 0)
+	if err != nil {
+		t.Fatalf("Controller 1: OpenCommissioningWindow failed: %v", err)
+	}
+	t.Logf("Commissioning window: QR=%s manual=%s", window.QRCode, window.ManualCode)
+
+	if !device.Node.IsCommissioningWindowOpen() {
+		t.Fatal("device should have an open commissioning window")
+	}
+	status := readAttributeUint(t, ctx, admin1.ctrl, sess1, deviceAddr,
+		uint32(admincommissioning.ClusterID), uint32(admincommissioning.AttrWindowStatus))
+	if status != uint64(admincommissioning.WindowEnhancedWindowOpen) {
+		t.Errorf("WindowStatus = %d, want EnhancedWindowOpen", status)
+	}
+
+	// The device's own passcode is not accepted in an enhanced window
+	if window.Passcode == 20202021 {
+		t.Fatal("window passcode should differ from the device passcode")
+	}
+
+	// Second administrator onboards from the handed-off payload
+	p, err := payload.ParseQRCode(window.QRCode)
+	if err != nil {
+		t.Fatalf("Failed to parse QR code: %v", err)
+	}
+	if p.Discriminator.Long() != window.Discriminator {
+		t.Errorf("QR discriminator = %d, want %d", p.Discriminator.Long(), window.Discriminator)
+	}
+
+	admin2 := newFabricAdmin(t, ctx, network, 1)
+	defer admin2.ctrl.Stop()
+
+	sess2 := admin2.commission(t, ctx, deviceAddr, p)
+	if device.Node.IsCommissioningWindowOpen() {
+		t.Error("commissioning window should close once the second administrator joins")
+	}
+	status = readAttributeUint(t, ctx, admin1.ctrl, sess1, deviceAddr,
+		uint32(admincommissioning.ClusterID), uint32(admincommissioning.AttrWindowStatus))
+	if status != uint64(admincommissioning.WindowNotOpen) {
+		t.Errorf("WindowStatus = %d, want WindowNotOpen", status)
+	}
+
+	// The device is on both fabrics
+	for i, admin := range []*fabricAdmin{admin1, admin2} {
+		sess := []*session.SecureContext{sess1, sess2}[i]
+		n := readAttributeUint(t, ctx, admin.ctrl, sess, deviceAddr,
+			uint32(operationalcredentials.ClusterID), uint32(operationalcredentials.AttrCommissionedFabrics))
+		if n != 2 {
+			t.Errorf("Controller %d: CommissionedFabrics = %d, want 2", i+1, n)
+		}
+	}
+
+	// Both administrators operate the device
+	sendOnOff(t, ctx, admin2.ctrl, sess2, deviceAddr, onoff.CmdOn)
+	if !device.IsOn() {
+		t.Error("Controller 2: expected light on")
+	}
+	sendOnOff(t, ctx, admin1.ctrl, sess1, deviceAddr, onoff.CmdOff)
+	if device.IsOn() {
+		t.Error("Controller 1: expected light off")
+	}
+
+	// The first administrator removes the second's fabric
+	var removed fabric.FabricIndex
+	for _, info := range device.Node.Fabrics() {
+		if info.FabricID == admin2.ca.FabricID() {
+			removed = info.FabricIndex
+		}
+	}
+	if removed == 0 {
+		t.Fatal("device is not on the second controller's fabric")
+	}
+	var fields bytes.Buffer
+	w := tlv.NewWriter(&fields)
+	w.StartStructure(tlv.Anonymous())
+	w.PutUint(tlv.ContextTag(0), uint64(removed))
+	w.EndContainer()
+	result, err := admin1.ctrl.SendCommand(ctx, sess1, deviceAddr, 0,
+		uint32(operationalcredentials.ClusterID), uint32(operationalcredentials.CmdRemoveFabric), fields.Bytes())
+	if err != nil {
+		t.Fatalf("RemoveFabric failed: %v", err)
+	}
+	if result.HasStatus {
+		t.Fatalf("RemoveFabric status = %s, want a NOCResponse", result.Status)
+	}
+
+	if n := device.Node.FabricCount(); n != 1 {
+		t.Errorf("device FabricCount = %d after RemoveFabric, want 1", n)
+	}
+	device.Node.SessionManager().ForEachSecureSession(func(sess *session.SecureContext) bool {
+		if sess.FabricIndex() == removed {
+			t.Errorf("device kept session %d on the removed fabric", sess.LocalSessionID())
+		}
+		return true
+	})
+
+	// The remaining administrator still operates the device
+	n := readAttributeUint(t, ctx, admin1.ctrl, sess1, deviceAddr,
+		uint32(operationalcredentials.ClusterID), uint32(operationalcredentials.AttrCommissionedFabrics))
+	if n != 1 {
+		t.Errorf("CommissionedFabrics = %d after RemoveFabric, want 1", n)
+	}
+	sendOnOff(t, ctx, admin1.ctrl, sess1, deviceAddr, onoff.CmdOn)
+	if !device.IsOn() {
+		t.Error("Controller 1: expected light on after RemoveFabric")
+	}
+}
+
+// fabricAdmin is a controller administering devices on a fabric of its
+// own, issued by its CA.
+type fabricAdmin struct {
+	ctrl *controller.Controller
+	ca   *commissioning.CertificateAuthority
+	info *fabric.FabricInfo
+	key  *ecdsa.PrivateKey
+}
+
+// newFabricAdmin starts the i-th controller on a new fabric.
+func newFabricAdmin(t *testing.T, ctx context.Context, network *transport.PipeNetwork, i int) *fabricAdmin {
+	t.Helper()
+
+	ca, err := commissioning.NewCertificateAuthority(commissioning.CAConfig{
+		FabricID: fabric.FabricID(i + 1),
+	})
+	if err != nil {
+		t.Fatalf("Controller %d: NewCertificateAuthority failed: %v", i+1, err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Controller %d: GenerateKey failed: %v", i+1, err)
+	}
+	info, err := ca.IssueFabricInfo(1, key)
+	if err != nil {
+		t.Fatalf("Controller %d: IssueFabricInfo failed: %v", i+1, err)
+	}
+
+	ctrl, err := controller.NewWithConfig(matter.NodeConfig{
+		VendorID:         fabric.VendorID(0xFFF2 + i),
+		ProductID:        0x8002,
+		DeviceName:       fmt.Sprintf("Test Controller %d", i+1),
+		Discriminator:    3841 + uint16(i),
+		Passcode:         20202022,
+		Port:             5541 + i,
+		Storage:          matter.NewMemoryStorage(),
+		TransportFactory: network.NewFactory(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create controller %d: %v", i+1, err)
+	}
+	if _, err := ctrl.Node().AddFabric(info); err != nil {
+		t.Fatalf("Controller %d: AddFabric failed: %v", i+1, err)
+	}
+	if err := ctrl.Start(ctx); err != nil {
+		t.Fatalf("Failed to start controller %d: %v", i+1, err)
+	}
+	return &fabricAdmin{ctrl: ctrl, ca: ca, info: info, key: key}
+}
+
+// commission commissions the device at addr onto the admin's fabric and
+// returns the CASE session commissioning completed over.
+func (a *fabricAdmin) commission(t *testing.T, ctx context.Context, addr transport.PeerAddress, p *payload.SetupPayload) *session.SecureContext {
+	t.Helper()
+
+	node := a.ctrl.Node()
+	var nodeID fabric.NodeID
+	c := commissioning.NewCommissioner(commissioning.CommissionerConfig{
+		SecureChannel:   node.SecureChannelManager(),
+		SessionManager:  node.SessionManager(),
+		ExchangeManager: node.ExchangeManager(),
+		FabricInfo:      a.info,
+		OperationalKey:  a.key,
+		CA:              a.ca,
+		Callbacks: commissioning.CommissionerCallbacks{
+			OnCommissioningComplete: func(id fabric.NodeID) { nodeID = id },
+		},
+	})
+	if err := c.CommissionAtAddress(ctx, addr, p); err != nil {
+		t.Fatalf("Commissioning onto fabric %v failed: %v", a.info.FabricID, err)
+	}
+
+	var sess *session.SecureContext
+	node.SessionManager().ForEachSecureSession(func(s *session.SecureContext) bool {
+		if s.SessionType() == session.SessionTypeCASE && s.PeerNodeID() == nodeID && s.LocalNodeID() == a.info.NodeID {
+			sess = s
+		}
+		return sess == nil
+	})
+	if sess == nil {
+		t.Fatalf("no CASE session to node %v on fabric %v", nodeID, a.info.FabricID)
+	}
+	return sess
+}
+
+// testAttestationCredentials returns a self-signed DAC and its key. The
+// commissioners accept all devices, so the chain is not verified.
+func testAttestationCredentials(t *testing.T) *operationalcredentials.AttestationCredentials {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Test DAC"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	dac, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate failed: %v", err)
+	}
+	return &operationalcredentials.AttestationCredentials{
+		DAC:                      dac,
+		PAI:                      dac,
+		CertificationDeclaration: []byte{0x30, 0x00},
+		Key:                      key,
+	}
+}
+
+// sendOnOff sends an On/Off cluster command to endpoint 1.
+func sendOnOff(
+	t *testing.T,
+	ctx context.Context,
+	ctrl *controller.Controller,
+	sess *session.SecureContext,
+	deviceAddr transport.PeerAddress,
+	cmd datamodel.CommandID,
+) {
+	t.Helper()
+
+	result, err := ctrl.SendCommand(ctx, sess, deviceAddr, 1, uint32(onoff.ClusterID), uint32(cmd), nil)
+	if err != nil {
+		t.Fatalf("SendCommand(0x%02x) failed: %v", cmd, err)
+	}
+	if result.HasStatus && !result.Status.IsSuccess() {
+		t.Fatalf("SendCommand(0x%02x) status: %s", cmd, result.Status)
+	}
+}
+
+// readAttributeUint reads an unsigned integer attribute from endpoint 0.
+func readAttributeUint(
+	t *testing.T,
+	ctx context.Context,
+	ctrl *controller.Controller,
+	sess *session.SecureContext,
+	deviceAddr transport.PeerAddress,
+	clusterID uint32,
+	attributeID uint32,
+) uint64 {
+	t.Helper()
+
+	data, err := ctrl.ReadAttribute(ctx, sess, deviceAddr, 0, clusterID, attributeID)
+	if err != nil {
+		t.Fatalf("ReadAttribute(0x%04x/0x%04x) failed: %v", clusterID, attributeID, err)
+	}
+	r := tlv.NewReader(bytes.NewReader(data))
+	if err := r.Next(); err != nil {
+		t.Fatalf("failed to decode attribute: %v", err)
+	}
+	v, err := r.Uint()
+	if err != nil {
+		t.Fatalf("failed to decode attribute: %v", err)
+	}
+	return v
+}
@@ -0,0 +1,165 @@
+package integration
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/backkem/matter/examples/samplemei"
+	"github.com/backkem/matter/pkg/clusters/descriptor"
+	"github.com/backkem/matter/pkg/datamodel"
+	"github.com/backkem/matter/pkg/im"
+	imsg "github.com/backkem/matter/pkg/im/message"
+	"github.com/backkem/matter/pkg/tlv"
+)
+
+// TestE2E_ManufacturerCluster verifies that a manufacturer-specific cluster
+// is discoverable through the Descriptor and global attributes, and that
+// its vendor-prefixed attributes and commands work like standard ones.
+func TestE2E_ManufacturerCluster(t *testing.T) {
+	pair := NewTestPair(t, samplemei.Factory)
+	defer pair.Close()
+
+	ctx := pair.Context()
+	endpoint := uint16(samplemei.LightEndpointID)
+	cluster := uint32(samplemei.ClusterID)
+
+	readList := func(clusterID, attrID uint32) []uint64 {
+		t.Helper()
+		data, err := pair.Controller.ReadAttribute(ctx, pair.Session, pair.DeviceAddr, endpoint, clusterID, attrID)
+		if err != nil {
+			t.Fatalf("ReadAttribute(0x%08X/0x%08X) failed: %v", clusterID, attrID, err)
+		}
+		list, err := decodeTLVUintList(data)
+		if err != nil {
+			t.Fatalf("decode 0x%08X/0x%08X: %v", clusterID, attrID, err)
+		}
+		return list
+	}
+
+	if servers := readList(uint32(descriptor.ClusterID), uint32(descriptor.AttrServerList)); !slices.Contains(servers, uint64(cluster)) {
+		t.Errorf("ServerList = %X, want 0x%08X", servers, cluster)
+	}
+	if attrs := readList(cluster, uint32(datamodel.GlobalAttrAttributeList)); !slices.Contains(attrs, uint64(samplemei.AttrPingCount)) {
+		t.Errorf("AttributeList = %X, want 0x%08X", attrs, uint32(samplemei.AttrPingCount))
+	}
+	if cmds := readList(cluster, uint32(datamodel.GlobalAttrAcceptedCommandList)); !slices.Contains(cmds, uint64(samplemei.CmdPing)) {
+		t.Errorf("AcceptedCommandList = %X, want 0x%08X", cmds, uint32(samplemei.CmdPing))
+	}
+
+	// Vendor-prefixed command
+	result, err := pair.Controller.SendCommand(ctx, pair.Session, pair.DeviceAddr, endpoint, cluster, uint32(samplemei.CmdPing), nil)
+	if err != nil {
+		t.Fatalf("SendCommand(Ping) failed: %v", err)
+	}
+	if result.HasStatus && result.Status != 0 {
+		t.Fatalf("Ping status = %v", result.Status)
+	}
+	if got := pair.Device.SampleCluster.PingCount(); got != 1 {
+		t.Errorf("PingCount() = %d, want 1", got)
+	}
+
+	// Vendor-prefixed attribute
+	data, err := pair.Controller.ReadAttribute(ctx, pair.Session, pair.DeviceAddr, endpoint, cluster, uint32(samplemei.AttrPingCount))
+	if err != nil {
+		t.Fatalf("ReadAttribute(PingCount) failed: %v", err)
+	}
+	if count, err := decodeTLVUint16(data); err != nil || count != 1 {
+		t.Errorf("PingCount = %d, %v, want 1", count, err)
+	}
+
+	// Command with a response on a manufacturer-specific cluster
+	var args bytes.Buffer
+	w := tlv.NewWriter(&args)
+	w.StartStructure(tlv.Anonymous())
+	w.PutUint(tlv.ContextTag(0), 2)
+	w.PutUint(tlv.ContextTag(1), 3)
+	w.EndContainer()
+	result, err = pair.Controller.SendCommand(ctx, pair.Session, pair.DeviceAddr, endpoint, cluster, uint32(samplemei.CmdAddArguments), args.Bytes())
+	if err != nil {
+		t.Fatalf("SendCommand(AddArguments) failed: %v", err)
+	}
+	r := tlv.NewReader(bytes.NewReader(result.ResponseData))
+	if err := r.Next(); err != nil || r.EnterContainer() != nil || r.Next() != nil {
+		t.Fatalf("AddArgumentsResponse = % X", result.ResponseData)
+	}
+	if sum, err := r.Uint(); err != nil || sum != 5 {
+		t.Errorf("AddArgumentsResponse.ReturnValue = %d, %v, want 5", sum, err)
+	}
+}
+
+// TestE2E_ManufacturerClusterWildcardRead verifies that wildcard reads
+// expand to manufacturer-specific clusters and attributes.
+func TestE2E_ManufacturerClusterWildcardRead(t *testing.T) {
+	pair := NewTestPair(t, samplemei.Factory)
+	defer pair.Close()
+
+	ctx := pair.Context()
+	client := im.NewClient(im.ClientConfig{ExchangeManager: pair.Controller.Node().ExchangeManager()})
+	endpoint := imsg.EndpointID(samplemei.LightEndpointID)
+	cluster := imsg.ClusterID(samplemei.ClusterID)
+
+	read := func(path imsg.AttributePathIB) map[imsg.ClusterID][]imsg.AttributeID {
+		t.Helper()
+		report, err := client.Read(ctx, pair.Session, pair.DeviceAddr, &imsg.ReadRequestMessage{
+			AttributeRequests: []imsg.AttributePathIB{path},
+			FabricFiltered:    true,
+		})
+		if err != nil {
+			t.Fatalf("Read(%+v) failed: %v", path, err)
+		}
+		attrs := make(map[imsg.ClusterID][]imsg.AttributeID)
+		for _, r := range report.AttributeReports {
+			if r.AttributeStatus != nil {
+				t.Errorf("wildcard read reported status %v on %+v", r.AttributeStatus.Status.Status, r.AttributeStatus.Path)
+				continue
+			}
+			p := r.AttributeData.Path
+			attrs[*p.Cluster] = append(attrs[*p.Cluster], *p.Attribute)
+		}
+		return attrs
+	}
+
+	// Wildcard attribute on the manufacturer-specific cluster
+	attrs := read(imsg.AttributePathIB{Endpoint: &endpoint, Cluster: &cluster})[cluster]
+	for _, want := range []datamodel.AttributeID{samplemei.AttrFlipFlop, samplemei.AttrPingCount, datamodel.GlobalAttrAttributeList} {
+		if !slices.Contains(attrs, imsg.AttributeID(want)) {
+			t.Errorf("wildcard attribute read = %X, want 0x%08X", attrs, uint32(want))
+		}
+	}
+
+	// Wildcard cluster and attribute on the endpoint
+	clusters := read(imsg.AttributePathIB{Endpoint: &endpoint})
+	if !slices.Contains(clusters[cluster], imsg.AttributeID(samplemei.AttrPingCount)) {
+		t.Errorf("wildcard endpoint read has no PingCount on 0x%08X: %X", uint32(cluster), clusters[cluster])
+	}
+
+	// Wildcard endpoint and cluster for a vendor-prefixed attribute
+	pingCount := imsg.AttributeID(samplemei.AttrPingCount)
+	if clusters := read(imsg.AttributePathIB{Attribute: &pingCount}); len(clusters) != 1 || len(clusters[cluster]) != 1 {
+		t.Errorf("wildcard PingCount read = %X, want only 0x%08X", clusters, uint32(cluster))
+	}
+}
+
+// decodeTLVUintList decodes a TLV-encoded list of unsigned integers.
+func decodeTLVUintList(data []byte) ([]uint64, error) {
+	r := tlv.NewReader(bytes.NewReader(data))
+	if err := r.Next(); err != nil {
+		return nil, err
+	}
+	if err := r.EnterContainer(); err != nil {
+		return nil, err
+	}
+	var list []uint64
+	for {
+		if err := r.Next(); err != nil || r.Type() == tlv.ElementTypeEnd {
+			break
+		}
+		v, err := r.Uint()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	return list, nil
+}
@@ -236,7 +236,7 @@ func NewTestPairWithController[D TestDevice, C TestController](
 
 	// Start controller
 	if err := ctrl.Start(ctx); err != nil {
-		deviceNode.Stop()
+		deviceNode.Stop(context.Background())
 		cancel()
 		t.Fatalf("Failed to start controller: %v", err)
 	}
@@ -248,7 +248,7 @@ func NewTestPairWithController[D TestDevice, C TestController](
 	sess, err := ctrl.CommissionDevice(ctx, deviceAddr, config.DevicePasscode)
 	if err != nil {
 		ctrl.Stop()
-		deviceNode.Stop()
+		deviceNode.Stop(context.Background())
 		cancel()
 		t.Fatalf("CommissionDevice failed: %v", err)
 	}
@@ -279,7 +279,7 @@ func (p *TestPair[D, C]) Close() {
 	var zeroD D
 	if any(p.Device) != any(zeroD) {
 		if node := p.Device.GetNode(); node != nil {
-			node.Stop()
+			node.Stop(context.Background())
 		}
 	}
 